@@ -22,6 +22,7 @@ package aggregation
 
 import (
 	"math"
+	"time"
 
 	"github.com/m3db/m3/src/metrics/aggregation"
 )
@@ -35,6 +36,14 @@ func stdev(count int64, sumSq, sum float64) float64 {
 	return math.Sqrt(num / float64(div))
 }
 
+// timeNanos returns the unix nanoseconds of a time, or zero for the zero time.
+func timeNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
 func isExpensive(aggTypes aggregation.Types) bool {
 	for _, aggType := range aggTypes {
 		if aggType == aggregation.SumSq || aggType == aggregation.Stdev {
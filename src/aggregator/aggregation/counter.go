@@ -74,6 +74,40 @@ func (c *Counter) Update(timestamp time.Time, value int64) {
 	}
 }
 
+// Merge merges another counter into the counter.
+func (c *Counter) Merge(other *Counter) { c.MergeState(other.State()) }
+
+// State returns the partial state of the counter.
+func (c *Counter) State() CounterState {
+	return CounterState{
+		LastAtNanos: timeNanos(c.lastAt),
+		Count:       c.count,
+		Sum:         c.sum,
+		SumSq:       c.sumSq,
+		Min:         c.min,
+		Max:         c.max,
+	}
+}
+
+// MergeState merges the partial state of a counter computed elsewhere.
+func (c *Counter) MergeState(s CounterState) {
+	if s.Count == 0 {
+		return
+	}
+	if lastAt := time.Unix(0, s.LastAtNanos); c.lastAt.IsZero() || lastAt.After(c.lastAt) {
+		c.lastAt = lastAt
+	}
+	c.sum += s.Sum
+	c.sumSq += s.SumSq
+	c.count += s.Count
+	if c.max < s.Max {
+		c.max = s.Max
+	}
+	if c.min > s.Min {
+		c.min = s.Min
+	}
+}
+
 // LastAt returns the time of the last value received.
 func (c *Counter) LastAt() time.Time { return c.lastAt }
 
@@ -79,6 +79,42 @@ func (g *Gauge) Update(timestamp time.Time, value float64) {
 	}
 }
 
+// Merge merges another gauge into the gauge.
+func (g *Gauge) Merge(other *Gauge) { g.MergeState(other.State()) }
+
+// State returns the partial state of the gauge.
+func (g *Gauge) State() GaugeState {
+	return GaugeState{
+		LastAtNanos: timeNanos(g.lastAt),
+		Last:        g.last,
+		Count:       g.count,
+		Sum:         g.sum,
+		SumSq:       g.sumSq,
+		Min:         g.min,
+		Max:         g.max,
+	}
+}
+
+// MergeState merges the partial state of a gauge computed elsewhere.
+func (g *Gauge) MergeState(s GaugeState) {
+	if s.Count == 0 {
+		return
+	}
+	if lastAt := time.Unix(0, s.LastAtNanos); g.lastAt.IsZero() || lastAt.After(g.lastAt) {
+		g.lastAt = lastAt
+		g.last = s.Last
+	}
+	g.sum += s.Sum
+	g.sumSq += s.SumSq
+	g.count += s.Count
+	if g.max < s.Max {
+		g.max = s.Max
+	}
+	if g.min > s.Min {
+		g.min = s.Min
+	}
+}
+
 // LastAt returns the time of the last value received.
 func (g *Gauge) LastAt() time.Time { return g.lastAt }
 
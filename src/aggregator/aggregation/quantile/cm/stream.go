@@ -173,6 +173,70 @@ func (s *stream) Quantile(q float64) float64 {
 	return prev.value
 }
 
+func (s *stream) Samples(dst []WeightedSample) []WeightedSample {
+	s.Flush()
+	for sample := s.samples.Front(); sample != nil; sample = sample.next {
+		dst = append(dst, WeightedSample{
+			Value:    sample.value,
+			NumRanks: sample.numRanks,
+			Delta:    sample.delta,
+		})
+	}
+	return dst
+}
+
+func (s *stream) Merge(samples []WeightedSample) {
+	if len(samples) == 0 {
+		return
+	}
+	s.Flush()
+
+	// Merge the two sorted sample lists. The rank uncertainty of each sample
+	// is widened by that of the next sample from the other list, which bounds
+	// the number of values from the other list the sample may be ranked after.
+	var (
+		merged    sampleList
+		curr      = s.samples.Front()
+		idx       int
+		numValues = s.numValues
+	)
+	for curr != nil || idx < len(samples) {
+		sample := s.acquireSampleFn()
+		if idx == len(samples) || (curr != nil && curr.value <= samples[idx].Value) {
+			var delta int64
+			if idx < len(samples) {
+				delta = samples[idx].NumRanks + samples[idx].Delta - 1
+			}
+			sample.setData(curr.value, curr.numRanks, curr.delta+delta)
+			next := curr.next
+			s.releaseSampleFn(curr)
+			curr = next
+		} else {
+			var delta int64
+			if curr != nil {
+				delta = curr.numRanks + curr.delta - 1
+			}
+			other := samples[idx]
+			sample.setData(other.Value, other.NumRanks, other.Delta+delta)
+			numValues += other.NumRanks
+			idx++
+		}
+		merged.PushBack(sample)
+	}
+
+	s.samples = merged
+	s.numValues = numValues
+	s.insertCursor = nil
+	s.compressCursor = nil
+	s.compressMinRank = 0
+
+	// Compress the merged samples in full.
+	s.compress()
+	for s.compressCursor != nil {
+		s.compress()
+	}
+}
+
 func (s *stream) ResetSetData(quantiles []float64) {
 	s.quantiles = quantiles
 	s.closed = false
@@ -185,6 +185,66 @@ func TestStreamWithSkewedDistributionPeriodicInsertCompressPeriodicFlush(t *test
 	testStreamWithSkewedDistribution(t, opts)
 }
 
+func TestStreamSamples(t *testing.T) {
+	opts := testStreamOptions()
+	s := NewStream(testQuantiles, opts)
+	require.Equal(t, 0, len(s.Samples(nil)))
+
+	for _, v := range []float64{3.0, 1.0, 2.0} {
+		s.Add(v)
+	}
+	samples := s.Samples(nil)
+	require.Equal(t, 3, len(samples))
+	var numRanks int64
+	for i, sample := range samples {
+		if i > 0 {
+			require.True(t, samples[i-1].Value <= sample.Value)
+		}
+		numRanks += sample.NumRanks
+	}
+	require.Equal(t, int64(3), numRanks)
+}
+
+func TestStreamMergeIntoEmptyStream(t *testing.T) {
+	opts := testStreamOptions()
+	src := NewStream(testQuantiles, opts)
+	for _, v := range []float64{3.0, 1.0, 2.0} {
+		src.Add(v)
+	}
+	dst := NewStream(testQuantiles, opts)
+	dst.Merge(src.Samples(nil))
+
+	require.Equal(t, 1.0, dst.Min())
+	require.Equal(t, 3.0, dst.Max())
+	require.Equal(t, int64(3), dst.(*stream).numValues)
+}
+
+func TestStreamMerge(t *testing.T) {
+	numSamples := 100000
+	opts := testStreamOptions()
+	evens := NewStream(testQuantiles, opts)
+	odds := NewStream(testQuantiles, opts)
+	for i := 0; i < numSamples; i++ {
+		if i%2 == 0 {
+			evens.Add(float64(i))
+		} else {
+			odds.Add(float64(i))
+		}
+	}
+	evens.Merge(odds.Samples(nil))
+
+	require.Equal(t, int64(numSamples), evens.(*stream).numValues)
+	require.Equal(t, 0.0, evens.Min())
+	require.Equal(t, float64(numSamples-1), evens.Max())
+
+	// Merging doubles the worst case error of the sketch.
+	margin := 2 * float64(numSamples) * opts.Eps()
+	for _, q := range testQuantiles {
+		val := evens.Quantile(q)
+		require.True(t, val >= float64(numSamples)*q-margin && val <= float64(numSamples)*q+margin)
+	}
+}
+
 func TestStreamClose(t *testing.T) {
 	opts := testStreamOptions()
 	s := NewStream(testQuantiles, opts).(*stream)
@@ -31,6 +31,14 @@ type Sample struct {
 	next     *Sample // next sample
 }
 
+// WeightedSample is an exported snapshot of a sample in a stream, used to
+// transfer the sketch of a stream to another stream.
+type WeightedSample struct {
+	Value    float64
+	NumRanks int64
+	Delta    int64
+}
+
 // SamplePool is a pool of samples.
 type SamplePool interface {
 	// Init initializes the pool.
@@ -60,6 +68,14 @@ type Stream interface {
 	// Quantile returns the quantile value.
 	Quantile(q float64) float64
 
+	// Samples flushes the stream and appends its samples to the given
+	// slice in ascending order of value.
+	Samples(dst []WeightedSample) []WeightedSample
+
+	// Merge merges the samples of another stream, in ascending order of
+	// value, into the stream.
+	Merge(samples []WeightedSample)
+
 	// Close closes the stream.
 	Close()
 
@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/m3db/m3/src/aggregator/aggregation/quantile/cm"
+)
+
+const (
+	// partialStateVersion is the version of the partial state wire format.
+	partialStateVersion byte = 1
+
+	numCounterStateFields = 6
+	numGaugeStateFields   = 7
+	numTimerStateFields   = 5
+	numSampleFields       = 3
+	fieldSize             = 8
+)
+
+var (
+	errPartialStateTooShort = errors.New("partial state is too short")
+)
+
+// CounterState is the partial state of a counter aggregation, which can be
+// merged losslessly with counter states of the same metric computed elsewhere.
+type CounterState struct {
+	LastAtNanos int64
+	Count       int64
+	Sum         int64
+	SumSq       int64
+	Min         int64
+	Max         int64
+}
+
+// MarshalBinary encodes the counter state.
+func (s CounterState) MarshalBinary() ([]byte, error) {
+	enc := newStateEncoder(numCounterStateFields)
+	enc.putInt64(s.LastAtNanos)
+	enc.putInt64(s.Count)
+	enc.putInt64(s.Sum)
+	enc.putInt64(s.SumSq)
+	enc.putInt64(s.Min)
+	enc.putInt64(s.Max)
+	return enc.bytes(), nil
+}
+
+// UnmarshalBinary decodes the counter state.
+func (s *CounterState) UnmarshalBinary(data []byte) error {
+	dec, err := newStateDecoder(data, numCounterStateFields)
+	if err != nil {
+		return err
+	}
+	s.LastAtNanos = dec.readInt64()
+	s.Count = dec.readInt64()
+	s.Sum = dec.readInt64()
+	s.SumSq = dec.readInt64()
+	s.Min = dec.readInt64()
+	s.Max = dec.readInt64()
+	return nil
+}
+
+// GaugeState is the partial state of a gauge aggregation, which can be
+// merged losslessly with gauge states of the same metric computed elsewhere.
+type GaugeState struct {
+	LastAtNanos int64
+	Last        float64
+	Count       int64
+	Sum         float64
+	SumSq       float64
+	Min         float64
+	Max         float64
+}
+
+// MarshalBinary encodes the gauge state.
+func (s GaugeState) MarshalBinary() ([]byte, error) {
+	enc := newStateEncoder(numGaugeStateFields)
+	enc.putInt64(s.LastAtNanos)
+	enc.putFloat64(s.Last)
+	enc.putInt64(s.Count)
+	enc.putFloat64(s.Sum)
+	enc.putFloat64(s.SumSq)
+	enc.putFloat64(s.Min)
+	enc.putFloat64(s.Max)
+	return enc.bytes(), nil
+}
+
+// UnmarshalBinary decodes the gauge state.
+func (s *GaugeState) UnmarshalBinary(data []byte) error {
+	dec, err := newStateDecoder(data, numGaugeStateFields)
+	if err != nil {
+		return err
+	}
+	s.LastAtNanos = dec.readInt64()
+	s.Last = dec.readFloat64()
+	s.Count = dec.readInt64()
+	s.Sum = dec.readFloat64()
+	s.SumSq = dec.readFloat64()
+	s.Min = dec.readFloat64()
+	s.Max = dec.readFloat64()
+	return nil
+}
+
+// TimerState is the partial state of a timer aggregation. The count, sum and
+// squared sum are merged losslessly while the quantile sketch samples are
+// merged within the error bounds of the sketch.
+type TimerState struct {
+	LastAtNanos int64
+	Count       int64
+	Sum         float64
+	SumSq       float64
+	Samples     []cm.WeightedSample
+}
+
+// MarshalBinary encodes the timer state.
+func (s TimerState) MarshalBinary() ([]byte, error) {
+	enc := newStateEncoder(numTimerStateFields + numSampleFields*len(s.Samples))
+	enc.putInt64(s.LastAtNanos)
+	enc.putInt64(s.Count)
+	enc.putFloat64(s.Sum)
+	enc.putFloat64(s.SumSq)
+	enc.putInt64(int64(len(s.Samples)))
+	for _, sample := range s.Samples {
+		enc.putFloat64(sample.Value)
+		enc.putInt64(sample.NumRanks)
+		enc.putInt64(sample.Delta)
+	}
+	return enc.bytes(), nil
+}
+
+// UnmarshalBinary decodes the timer state, reusing the samples slice if possible.
+func (s *TimerState) UnmarshalBinary(data []byte) error {
+	dec, err := newStateDecoder(data, numTimerStateFields)
+	if err != nil {
+		return err
+	}
+	s.LastAtNanos = dec.readInt64()
+	s.Count = dec.readInt64()
+	s.Sum = dec.readFloat64()
+	s.SumSq = dec.readFloat64()
+	numSamples := dec.readInt64()
+	if numSamples < 0 || int64(dec.remaining()) != numSamples*numSampleFields*fieldSize {
+		return errPartialStateTooShort
+	}
+	s.Samples = s.Samples[:0]
+	for i := int64(0); i < numSamples; i++ {
+		s.Samples = append(s.Samples, cm.WeightedSample{
+			Value:    dec.readFloat64(),
+			NumRanks: dec.readInt64(),
+			Delta:    dec.readInt64(),
+		})
+	}
+	return nil
+}
+
+// stateEncoder encodes partial states as a version byte followed by
+// fixed size little endian fields.
+type stateEncoder struct {
+	buf []byte
+}
+
+func newStateEncoder(numFields int) *stateEncoder {
+	buf := make([]byte, 1, 1+numFields*fieldSize)
+	buf[0] = partialStateVersion
+	return &stateEncoder{buf: buf}
+}
+
+func (e *stateEncoder) putInt64(v int64) {
+	var b [fieldSize]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	e.buf = append(e.buf, b[:]...)
+}
+
+func (e *stateEncoder) putFloat64(v float64) {
+	e.putInt64(int64(math.Float64bits(v)))
+}
+
+func (e *stateEncoder) bytes() []byte { return e.buf }
+
+type stateDecoder struct {
+	buf []byte
+}
+
+func newStateDecoder(data []byte, minFields int) (*stateDecoder, error) {
+	if len(data) < 1+minFields*fieldSize {
+		return nil, errPartialStateTooShort
+	}
+	if data[0] != partialStateVersion {
+		return nil, fmt.Errorf("unsupported partial state version %d", data[0])
+	}
+	return &stateDecoder{buf: data[1:]}, nil
+}
+
+func (d *stateDecoder) readInt64() int64 {
+	v := int64(binary.LittleEndian.Uint64(d.buf))
+	d.buf = d.buf[fieldSize:]
+	return v
+}
+
+func (d *stateDecoder) readFloat64() float64 {
+	return math.Float64frombits(uint64(d.readInt64()))
+}
+
+func (d *stateDecoder) remaining() int { return len(d.buf) }
@@ -0,0 +1,182 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/aggregation/quantile/cm"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterMerge(t *testing.T) {
+	opts := NewOptions(instrument.NewOptions())
+	opts.HasExpensiveAggregations = true
+	now := time.Unix(0, 12345)
+
+	c1 := NewCounter(opts)
+	c2 := NewCounter(opts)
+	expected := NewCounter(opts)
+	for i := 1; i <= 100; i++ {
+		ts := now.Add(time.Duration(i))
+		if i%2 == 0 {
+			c1.Update(ts, int64(i))
+		} else {
+			c2.Update(ts, int64(i))
+		}
+		expected.Update(ts, int64(i))
+	}
+	c1.Merge(&c2)
+	require.Equal(t, expected.State(), c1.State())
+
+	// Merging an empty counter is a no-op.
+	empty := NewCounter(opts)
+	c1.Merge(&empty)
+	require.Equal(t, expected.State(), c1.State())
+}
+
+func TestGaugeMerge(t *testing.T) {
+	opts := NewOptions(instrument.NewOptions())
+	opts.HasExpensiveAggregations = true
+	now := time.Unix(0, 12345)
+
+	g1 := NewGauge(opts)
+	g2 := NewGauge(opts)
+	expected := NewGauge(opts)
+	for i := 1; i <= 100; i++ {
+		ts := now.Add(time.Duration(i))
+		if i <= 50 {
+			g1.Update(ts, float64(i))
+		} else {
+			g2.Update(ts, float64(i))
+		}
+		expected.Update(ts, float64(i))
+	}
+
+	// The last value is taken from the gauge with the latest timestamp
+	// regardless of the merge order.
+	g2.Merge(&g1)
+	require.Equal(t, expected.State(), g2.State())
+	require.Equal(t, 100.0, g2.Last())
+}
+
+func TestTimerMerge(t *testing.T) {
+	opts := NewOptions(instrument.NewOptions())
+	opts.HasExpensiveAggregations = true
+	now := time.Unix(0, 12345)
+
+	t1 := NewTimer(testQuantiles, cm.NewOptions(), opts)
+	t2 := NewTimer(testQuantiles, cm.NewOptions(), opts)
+	for i := 1; i <= 100; i++ {
+		ts := now.Add(time.Duration(i))
+		if i%2 == 0 {
+			t1.Add(ts, float64(i))
+		} else {
+			t2.Add(ts, float64(i))
+		}
+	}
+	t1.Merge(&t2)
+
+	require.Equal(t, int64(100), t1.Count())
+	require.Equal(t, 5050.0, t1.Sum())
+	require.Equal(t, 338350.0, t1.SumSq())
+	require.Equal(t, now.Add(100), t1.LastAt())
+	require.Equal(t, 1.0, t1.Min())
+	require.Equal(t, 100.0, t1.Max())
+	require.InDelta(t, 50.0, t1.Quantile(0.5), 2.0)
+}
+
+func TestCounterStateRoundTrip(t *testing.T) {
+	state := CounterState{
+		LastAtNanos: 12345,
+		Count:       3,
+		Sum:         -1 << 62,
+		SumSq:       1 << 62,
+		Min:         -100,
+		Max:         100,
+	}
+	data, err := state.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded CounterState
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, state, decoded)
+}
+
+func TestGaugeStateRoundTrip(t *testing.T) {
+	state := GaugeState{
+		LastAtNanos: 12345,
+		Last:        1.5,
+		Count:       3,
+		Sum:         4.5,
+		SumSq:       7.25,
+		Min:         -0.5,
+		Max:         3.5,
+	}
+	data, err := state.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded GaugeState
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, state, decoded)
+}
+
+func TestTimerStateRoundTrip(t *testing.T) {
+	state := TimerState{
+		LastAtNanos: 12345,
+		Count:       3,
+		Sum:         6.0,
+		SumSq:       14.0,
+		Samples: []cm.WeightedSample{
+			{Value: 1.0, NumRanks: 1, Delta: 0},
+			{Value: 3.0, NumRanks: 2, Delta: 1},
+		},
+	}
+	data, err := state.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded TimerState
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.Equal(t, state, decoded)
+}
+
+func TestStateUnmarshalInvalidData(t *testing.T) {
+	state := TimerState{
+		Count:   1,
+		Samples: []cm.WeightedSample{{Value: 1.0, NumRanks: 1}},
+	}
+	data, err := state.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded TimerState
+	require.Error(t, decoded.UnmarshalBinary(data[:len(data)-1]))
+	require.Error(t, decoded.UnmarshalBinary(nil))
+
+	// Unknown versions are rejected.
+	data[0] = partialStateVersion + 1
+	require.Error(t, decoded.UnmarshalBinary(data))
+
+	var counter CounterState
+	require.Error(t, counter.UnmarshalBinary(data[:5]))
+}
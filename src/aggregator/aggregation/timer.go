@@ -82,6 +82,32 @@ func (t *Timer) addValue(value float64) {
 	}
 }
 
+// Merge merges another timer into the timer.
+func (t *Timer) Merge(other *Timer) { t.MergeState(other.State()) }
+
+// State returns the partial state of the timer.
+func (t *Timer) State() TimerState {
+	return TimerState{
+		LastAtNanos: timeNanos(t.lastAt),
+		Count:       t.count,
+		Sum:         t.sum,
+		SumSq:       t.sumSq,
+		Samples:     t.stream.Samples(nil),
+	}
+}
+
+// MergeState merges the partial state of a timer computed elsewhere.
+func (t *Timer) MergeState(s TimerState) {
+	if s.Count == 0 {
+		return
+	}
+	t.recordLastAt(time.Unix(0, s.LastAtNanos))
+	t.count += s.Count
+	t.sum += s.Sum
+	t.sumSq += s.SumSq
+	t.stream.Merge(s.Samples)
+}
+
 // LastAt returns the time of the last value received.
 func (t *Timer) LastAt() time.Time { return t.lastAt }
 
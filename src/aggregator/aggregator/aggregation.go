@@ -44,6 +44,15 @@ func (a *counterAggregation) AddUnion(t time.Time, mu unaggregated.MetricUnion)
 	a.Counter.Update(t, mu.CounterVal)
 }
 
+func (a *counterAggregation) MergeState(state []byte) error {
+	var s aggregation.CounterState
+	if err := s.UnmarshalBinary(state); err != nil {
+		return err
+	}
+	a.Counter.MergeState(s)
+	return nil
+}
+
 // timerAggregation is a timer aggregation.
 type timerAggregation struct {
 	aggregation.Timer
@@ -61,6 +70,15 @@ func (a *timerAggregation) AddUnion(timestamp time.Time, mu unaggregated.MetricU
 	a.Timer.AddBatch(timestamp, mu.BatchTimerVal)
 }
 
+func (a *timerAggregation) MergeState(state []byte) error {
+	var s aggregation.TimerState
+	if err := s.UnmarshalBinary(state); err != nil {
+		return err
+	}
+	a.Timer.MergeState(s)
+	return nil
+}
+
 // gaugeAggregation is a gauge aggregation.
 type gaugeAggregation struct {
 	aggregation.Gauge
@@ -77,3 +95,12 @@ func (a *gaugeAggregation) Add(t time.Time, value float64) {
 func (a *gaugeAggregation) AddUnion(t time.Time, mu unaggregated.MetricUnion) {
 	a.Gauge.Update(t, mu.GaugeVal)
 }
+
+func (a *gaugeAggregation) MergeState(state []byte) error {
+	var s aggregation.GaugeState
+	if err := s.UnmarshalBinary(state); err != nil {
+		return err
+	}
+	a.Gauge.MergeState(s)
+	return nil
+}
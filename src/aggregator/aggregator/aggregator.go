@@ -70,6 +70,10 @@ type Aggregator interface {
 	// AddUntimed adds an untimed metric with staged metadatas.
 	AddUntimed(metric unaggregated.MetricUnion, metas metadata.StagedMetadatas) error
 
+	// AddUntimedBatch adds a batch of untimed metrics sharing the same staged
+	// metadatas, resolving shards and taking locks once per batch.
+	AddUntimedBatch(metrics []unaggregated.MetricUnion, metas metadata.StagedMetadatas) error
+
 	// AddTimed adds a timed metric with metadata.
 	AddTimed(metric aggregated.Metric, metadata metadata.TimedMetadata) error
 
@@ -200,6 +204,56 @@ func (agg *aggregator) AddUntimed(
 	return nil
 }
 
+func (agg *aggregator) AddUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) error {
+	callStart := agg.nowFn()
+	agg.metrics.addUntimedBatch.batches.Inc(1)
+	agg.metrics.addUntimedBatch.metrics.Inc(int64(len(metrics)))
+
+	var (
+		multiErr     = xerrors.NewMultiError()
+		shardBatches = make(map[*aggregatorShard][]unaggregated.MetricUnion)
+	)
+	agg.RLock()
+	for _, mu := range metrics {
+		if err := agg.checkMetricType(mu); err != nil {
+			agg.metrics.addUntimed.ReportError(err)
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		shard, err := agg.shardForWithLock(mu.ID, noUpdateShards)
+		if err == errActivePlacementChanged {
+			// NB: the new placement is processed with the write lock held,
+			// after which the rest of the batch is resolved under the read lock.
+			agg.RUnlock()
+			shard, err = agg.shardFor(mu.ID)
+			agg.RLock()
+		}
+		if err != nil {
+			agg.metrics.addUntimed.ReportError(err)
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		shardBatches[shard] = append(shardBatches[shard], mu)
+	}
+	agg.RUnlock()
+
+	for shard, shardMetrics := range shardBatches {
+		shardErr := shard.AddUntimedBatch(shardMetrics, metadatas)
+		for _, err := range shardErr.Errors() {
+			agg.metrics.addUntimed.ReportError(err)
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	numAdded := len(metrics) - multiErr.NumErrors()
+	agg.metrics.addUntimed.success.Inc(int64(numAdded))
+	agg.metrics.addUntimedBatch.latency.Record(agg.nowFn().Sub(callStart))
+	return multiErr.FinalError()
+}
+
 func (agg *aggregator) AddTimed(
 	metric aggregated.Metric,
 	metadata metadata.TimedMetadata,
@@ -287,8 +341,9 @@ func (agg *aggregator) AddPassthrough(
 			ChunkedID: id.ChunkedID{
 				Data: []byte(metric.ID),
 			},
-			TimeNanos: metric.TimeNanos,
-			Value:     metric.Value,
+			TimeNanos:  metric.TimeNanos,
+			Value:      metric.Value,
+			Annotation: metric.Annotation,
 		},
 		StoragePolicy: storagePolicy,
 	}
@@ -785,6 +840,23 @@ func (m *aggregatorAddUntimedMetrics) ReportError(err error) {
 	m.aggregatorAddMetricMetrics.ReportError(err)
 }
 
+type aggregatorAddUntimedBatchMetrics struct {
+	batches tally.Counter
+	metrics tally.Counter
+	latency tally.Timer
+}
+
+func newAggregatorAddUntimedBatchMetrics(
+	scope tally.Scope,
+	opts instrument.TimerOptions,
+) aggregatorAddUntimedBatchMetrics {
+	return aggregatorAddUntimedBatchMetrics{
+		batches: scope.Counter("batches"),
+		metrics: scope.Counter("metrics"),
+		latency: instrument.NewTimer(scope, "latency", opts),
+	}
+}
+
 type aggregatorAddTimedMetrics struct {
 	aggregatorAddMetricMetrics
 
@@ -1013,21 +1085,22 @@ func newAggregatorShardSetIDMetrics(scope tally.Scope) aggregatorShardSetIDMetri
 }
 
 type aggregatorMetrics struct {
-	counters       tally.Counter
-	timers         tally.Counter
-	timerBatches   tally.Counter
-	gauges         tally.Counter
-	forwarded      tally.Counter
-	timed          tally.Counter
-	passthrough    tally.Counter
-	addUntimed     aggregatorAddUntimedMetrics
-	addTimed       aggregatorAddTimedMetrics
-	addForwarded   aggregatorAddForwardedMetrics
-	addPassthrough aggregatorAddPassthroughMetrics
-	placement      aggregatorPlacementMetrics
-	shards         aggregatorShardsMetrics
-	shardSetID     aggregatorShardSetIDMetrics
-	tick           aggregatorTickMetrics
+	counters        tally.Counter
+	timers          tally.Counter
+	timerBatches    tally.Counter
+	gauges          tally.Counter
+	forwarded       tally.Counter
+	timed           tally.Counter
+	passthrough     tally.Counter
+	addUntimed      aggregatorAddUntimedMetrics
+	addUntimedBatch aggregatorAddUntimedBatchMetrics
+	addTimed        aggregatorAddTimedMetrics
+	addForwarded    aggregatorAddForwardedMetrics
+	addPassthrough  aggregatorAddPassthroughMetrics
+	placement       aggregatorPlacementMetrics
+	shards          aggregatorShardsMetrics
+	shardSetID      aggregatorShardSetIDMetrics
+	tick            aggregatorTickMetrics
 }
 
 func newAggregatorMetrics(
@@ -1036,6 +1109,7 @@ func newAggregatorMetrics(
 	maxAllowedForwardingDelayFn MaxAllowedForwardingDelayFn,
 ) aggregatorMetrics {
 	addUntimedScope := scope.SubScope("addUntimed")
+	addUntimedBatchScope := scope.SubScope("addUntimedBatch")
 	addTimedScope := scope.SubScope("addTimed")
 	addForwardedScope := scope.SubScope("addForwarded")
 	addPassthroughScope := scope.SubScope("addPassthrough")
@@ -1044,21 +1118,22 @@ func newAggregatorMetrics(
 	shardSetIDScope := scope.SubScope("shard-set-id")
 	tickScope := scope.SubScope("tick")
 	return aggregatorMetrics{
-		counters:       scope.Counter("counters"),
-		timers:         scope.Counter("timers"),
-		timerBatches:   scope.Counter("timer-batches"),
-		gauges:         scope.Counter("gauges"),
-		forwarded:      scope.Counter("forwarded"),
-		timed:          scope.Counter("timed"),
-		passthrough:    scope.Counter("passthrough"),
-		addUntimed:     newAggregatorAddUntimedMetrics(addUntimedScope, opts),
-		addTimed:       newAggregatorAddTimedMetrics(addTimedScope, opts),
-		addForwarded:   newAggregatorAddForwardedMetrics(addForwardedScope, opts, maxAllowedForwardingDelayFn),
-		addPassthrough: newAggregatorAddPassthroughMetrics(addPassthroughScope, opts),
-		placement:      newAggregatorPlacementMetrics(placementScope),
-		shards:         newAggregatorShardsMetrics(shardsScope),
-		shardSetID:     newAggregatorShardSetIDMetrics(shardSetIDScope),
-		tick:           newAggregatorTickMetrics(tickScope),
+		counters:        scope.Counter("counters"),
+		timers:          scope.Counter("timers"),
+		timerBatches:    scope.Counter("timer-batches"),
+		gauges:          scope.Counter("gauges"),
+		forwarded:       scope.Counter("forwarded"),
+		timed:           scope.Counter("timed"),
+		passthrough:     scope.Counter("passthrough"),
+		addUntimed:      newAggregatorAddUntimedMetrics(addUntimedScope, opts),
+		addUntimedBatch: newAggregatorAddUntimedBatchMetrics(addUntimedBatchScope, opts),
+		addTimed:        newAggregatorAddTimedMetrics(addTimedScope, opts),
+		addForwarded:    newAggregatorAddForwardedMetrics(addForwardedScope, opts, maxAllowedForwardingDelayFn),
+		addPassthrough:  newAggregatorAddPassthroughMetrics(addPassthroughScope, opts),
+		placement:       newAggregatorPlacementMetrics(placementScope),
+		shards:          newAggregatorShardsMetrics(shardsScope),
+		shardSetID:      newAggregatorShardSetIDMetrics(shardSetIDScope),
+		tick:            newAggregatorTickMetrics(tickScope),
 	}
 }
 
@@ -1088,4 +1163,6 @@ const (
 	latencyBucketVersion             = 2
 	numLatencyBuckets                = 40
 	maxLatencyBucketLimitScaleFactor = 2
+
+	ingestToFlushLatencyBucketLimitScaleFactor = 10
 )
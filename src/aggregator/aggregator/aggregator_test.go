@@ -44,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/metrics/pipeline"
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/instrument"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -350,7 +351,34 @@ func TestAggregatorAddUntimedSuccessNoPlacementUpdate(t *testing.T) {
 	agg.shardFn = func([]byte, uint32) uint32 { return 1 }
 	err := agg.AddUntimed(testUntimedMetric, testStagedMetadatas)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
+}
+
+func TestAggregatorAddUntimedBatchNotOpen(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	agg, _ := testAggregator(t, ctrl)
+	metrics := []unaggregated.MetricUnion{testUntimedMetric, testUntimedMetric}
+	err := agg.AddUntimedBatch(metrics, testStagedMetadatas)
+	require.Error(t, err)
+	require.Equal(t, 2, err.(xerrors.MultiError).NumErrors())
+}
+
+func TestAggregatorAddUntimedBatchPartialSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	agg, _ := testAggregator(t, ctrl)
+	require.NoError(t, agg.Open())
+	agg.shardFn = func([]byte, uint32) uint32 { return 1 }
+	otherMetric := testUntimedMetric
+	otherMetric.ID = []byte("bar")
+	metrics := []unaggregated.MetricUnion{testUntimedMetric, testInvalidMetric, otherMetric}
+	err := agg.AddUntimedBatch(metrics, testStagedMetadatas)
+	require.Error(t, err)
+	require.Equal(t, 1, err.(xerrors.MultiError).NumErrors())
+	require.Equal(t, 2, numTestMapEntries(agg.shards[1].metricMap))
 }
 
 func TestAggregatorAddUntimedSuccessWithPlacementUpdate(t *testing.T) {
@@ -414,7 +442,7 @@ func TestAggregatorAddUntimedSuccessWithPlacementUpdate(t *testing.T) {
 			require.Equal(t, expected.latestNanos, agg.shards[i].latestWriteableNanos)
 		}
 	}
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
 	require.Equal(t, newPlacementCutoverNanos, agg.currPlacement.CutoverNanos())
 
 	for {
@@ -460,7 +488,7 @@ func TestAggregatorAddTimedSuccessNoPlacementUpdate(t *testing.T) {
 	agg.shardFn = func([]byte, uint32) uint32 { return 1 }
 	err := agg.AddTimed(testTimedMetric, testTimedMetadata)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
 }
 
 func TestAggregatorAddTimedSuccessWithPlacementUpdate(t *testing.T) {
@@ -524,7 +552,7 @@ func TestAggregatorAddTimedSuccessWithPlacementUpdate(t *testing.T) {
 			require.Equal(t, expected.latestNanos, agg.shards[i].latestWriteableNanos)
 		}
 	}
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
 	require.Equal(t, newPlacementCutoverNanos, agg.currPlacement.CutoverNanos())
 
 	for {
@@ -570,7 +598,7 @@ func TestAggregatorAddForwardedSuccessNoPlacementUpdate(t *testing.T) {
 	agg.shardFn = func([]byte, uint32) uint32 { return 1 }
 	err := agg.AddForwarded(testForwardedMetric, testForwardMetadata)
 	require.NoError(t, err)
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
 }
 
 func TestAggregatorAddForwardedSuccessWithPlacementUpdate(t *testing.T) {
@@ -634,7 +662,7 @@ func TestAggregatorAddForwardedSuccessWithPlacementUpdate(t *testing.T) {
 			require.Equal(t, expected.latestNanos, agg.shards[i].latestWriteableNanos)
 		}
 	}
-	require.Equal(t, 1, len(agg.shards[1].metricMap.entries))
+	require.Equal(t, 1, numTestMapEntries(agg.shards[1].metricMap))
 	require.Equal(t, newPlacementCutoverNanos, agg.currPlacement.CutoverNanos())
 
 	for {
@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/metrics/metric/id"
 	"github.com/m3db/m3/src/metrics/metric/unaggregated"
 	"github.com/m3db/m3/src/metrics/policy"
+	xerrors "github.com/m3db/m3/src/x/errors"
 )
 
 // aggregator is an aggregator that simply captures metrics coming
@@ -93,6 +94,19 @@ func (agg *aggregator) AddUntimed(
 	return nil
 }
 
+func (agg *aggregator) AddUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	sm metadata.StagedMetadatas,
+) error {
+	multiErr := xerrors.NewMultiError()
+	for _, mu := range metrics {
+		if err := agg.AddUntimed(mu, sm); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return multiErr.FinalError()
+}
+
 func (agg *aggregator) AddTimed(
 	metric aggregated.Metric,
 	metadata metadata.TimedMetadata,
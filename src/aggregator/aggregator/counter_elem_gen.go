@@ -44,6 +44,7 @@ type lockedCounterAggregation struct {
 	sync.Mutex
 
 	closed      bool
+	ingestNanos int64 // ingest time of the first metric in the window
 	sourcesSeen *bitset.BitSet
 	aggregation counterAggregation
 }
@@ -63,10 +64,12 @@ type CounterElem struct {
 	elemBase
 	counterElemBase
 
-	values              []timedCounter             // metric aggregations sorted by time in ascending order
-	toConsume           []timedCounter             // small buffer to avoid memory allocations during consumption
-	lastConsumedAtNanos int64                      // last consumed at in Unix nanoseconds
-	lastConsumedValues  []transformation.Datapoint // last consumed values
+	values                 []timedCounter             // metric aggregations sorted by time in ascending order
+	toConsume              []timedCounter             // small buffer to avoid memory allocations during consumption
+	lastConsumedAtNanos    int64                      // last consumed at in Unix nanoseconds
+	lastConsumedValues     []transformation.Datapoint // last consumed values
+	lastConsumedStartNanos int64                      // start of the last consumed aggregation window in Unix nanoseconds
+	stalenessMarked        bool                       // whether a staleness marker was flushed since the last consumed aggregation
 }
 
 // NewCounterElem creates a new element for the given metric type.
@@ -125,6 +128,8 @@ func (e *CounterElem) ResetSetData(
 	if err := e.counterElemBase.ResetSetData(e.aggTypesOpts, aggTypes, useDefaultAggregation); err != nil {
 		return err
 	}
+	e.lastConsumedStartNanos = 0
+	e.stalenessMarked = false
 	// If the pipeline contains derivative transformations, we need to store past
 	// values in order to compute the derivatives.
 	if !e.parsedPipeline.HasDerivativeTransform {
@@ -155,6 +160,7 @@ func (e *CounterElem) AddUnion(timestamp time.Time, mu unaggregated.MetricUnion)
 	}
 	lockedAgg.aggregation.AddUnion(timestamp, mu)
 	lockedAgg.Unlock()
+	e.recordWrite(timestamp)
 	return nil
 }
 
@@ -172,6 +178,29 @@ func (e *CounterElem) AddValue(timestamp time.Time, value float64) error {
 	}
 	lockedAgg.aggregation.Add(timestamp, value)
 	lockedAgg.Unlock()
+	e.recordWrite(timestamp)
+	return nil
+}
+
+// MergeState merges the encoded partial state of an aggregation computed
+// elsewhere into the aggregation at a given timestamp.
+func (e *CounterElem) MergeState(timestamp time.Time, state []byte) error {
+	alignedStart := timestamp.Truncate(e.sp.Resolution().Window).UnixNano()
+	lockedAgg, err := e.findOrCreate(alignedStart, createAggregationOptions{})
+	if err != nil {
+		return err
+	}
+	lockedAgg.Lock()
+	if lockedAgg.closed {
+		lockedAgg.Unlock()
+		return errAggregationClosed
+	}
+	err = lockedAgg.aggregation.MergeState(state)
+	lockedAgg.Unlock()
+	if err != nil {
+		return err
+	}
+	e.recordWrite(timestamp)
 	return nil
 }
 
@@ -199,6 +228,7 @@ func (e *CounterElem) AddUnique(timestamp time.Time, values []float64, sourceID
 		lockedAgg.aggregation.Add(timestamp, v)
 	}
 	lockedAgg.Unlock()
+	e.recordWrite(timestamp)
 	return nil
 }
 
@@ -245,10 +275,15 @@ func (e *CounterElem) Consume(
 	e.Unlock()
 
 	// Process the aggregations that are ready for consumption.
+	var flushedAtNanos int64
+	if len(e.toConsume) > 0 && e.ingestToFlushLatency != nil {
+		flushedAtNanos = e.nowFn().UnixNano()
+	}
 	for i := range e.toConsume {
 		timeNanos := timestampNanosFn(e.toConsume[i].startAtNanos, resolution)
 		e.toConsume[i].lockedAgg.Lock()
 		e.processValueWithAggregationLock(timeNanos, e.toConsume[i].lockedAgg, flushLocalFn, flushForwardedFn)
+		e.recordIngestToFlushLatency(e.toConsume[i].lockedAgg.ingestNanos, flushedAtNanos)
 		// Closes the aggregation object after it's processed.
 		e.toConsume[i].lockedAgg.closed = true
 		e.toConsume[i].lockedAgg.aggregation.Close()
@@ -263,9 +298,17 @@ func (e *CounterElem) Consume(
 			e.toConsume[i].lockedAgg.sourcesSeen = nil
 		}
 		e.toConsume[i].lockedAgg.Unlock()
+		e.lastConsumedStartNanos = e.toConsume[i].startAtNanos
 		e.toConsume[i].Reset()
 	}
 
+	if len(e.toConsume) > 0 {
+		e.stalenessMarked = false
+	} else if e.isStale(targetNanos, isEarlierThanFn) {
+		staleAtNanos := timestampNanosFn(e.lastConsumedStartNanos+int64(resolution), resolution)
+		e.flushStalenessMarker(staleAtNanos, flushLocalFn)
+	}
+
 	if e.parsedPipeline.HasRollup {
 		forwardedAggregationKey, _ := e.ForwardedAggregationKey()
 		onForwardedFlushedFn(e.onForwardedAggregationWrittenFn, forwardedAggregationKey)
@@ -283,6 +326,10 @@ func (e *CounterElem) Close() {
 	}
 	e.closed = true
 	e.id = nil
+	if e.idRef != nil {
+		e.idRef.DecRef()
+		e.idRef = nil
+	}
 	e.parsedPipeline = parsedPipeline{}
 	e.writeForwardedMetricFn = nil
 	e.onForwardedAggregationWrittenFn = nil
@@ -362,6 +409,7 @@ func (e *CounterElem) findOrCreate(
 	e.values[idx] = timedCounter{
 		startAtNanos: alignedStart,
 		lockedAgg: &lockedCounterAggregation{
+			ingestNanos: e.nowFn().UnixNano(),
 			sourcesSeen: sourcesSeen,
 			aggregation: e.NewAggregation(e.opts, e.aggOpts),
 		},
@@ -400,6 +448,37 @@ func (e *CounterElem) indexOfWithLock(alignedStart int64) (int, bool) {
 	return left, false
 }
 
+// isStale returns whether the element should flush a staleness marker, which
+// is the case once the window following the last consumed aggregation closes
+// without any writes.
+func (e *CounterElem) isStale(targetNanos int64, isEarlierThanFn isEarlierThanFn) bool {
+	if !e.opts.EmitStalenessMarkers() ||
+		e.parsedPipeline.HasRollup ||
+		e.stalenessMarked ||
+		e.lastConsumedStartNanos == 0 {
+		return false
+	}
+	resolution := e.sp.Resolution().Window
+	nextStartNanos := e.lastConsumedStartNanos + int64(resolution)
+	return e.LastWriteNanos() < nextStartNanos &&
+		isEarlierThanFn(nextStartNanos, resolution, targetNanos)
+}
+
+// flushStalenessMarker flushes a NaN value for each aggregation type to mark
+// the series as stale, NaN values are flushed regardless of whether NaN
+// aggregated values are discarded.
+func (e *CounterElem) flushStalenessMarker(timeNanos int64, flushLocalFn flushLocalMetricFn) {
+	for _, aggType := range e.aggTypes {
+		switch e.idPrefixSuffixType {
+		case NoPrefixNoSuffix:
+			flushLocalFn(nil, e.id, nil, timeNanos, nan, e.sp)
+		case WithPrefixWithSuffix:
+			flushLocalFn(e.FullPrefix(e.opts), e.id, e.TypeStringFor(e.aggTypesOpts, aggType), timeNanos, nan, e.sp)
+		}
+	}
+	e.stalenessMarked = true
+}
+
 func (e *CounterElem) processValueWithAggregationLock(
 	timeNanos int64,
 	lockedAgg *lockedCounterAggregation,
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	raggregation "github.com/m3db/m3/src/aggregator/aggregation"
@@ -36,9 +37,11 @@ import (
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/metrics/transformation"
+	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/pool"
 	"go.uber.org/zap"
 
+	"github.com/uber-go/tally"
 	"github.com/willf/bitset"
 )
 
@@ -95,6 +98,13 @@ type metricElem interface {
 	// ID returns the metric id.
 	ID() id.RawID
 
+	// IDRef returns the pooled metric owning the metric id if any.
+	IDRef() *unaggregated.PooledMetric
+
+	// SetIDRef sets the pooled metric owning the metric id, the element takes
+	// over the reference passed in and releases it when closed.
+	SetIDRef(ref *unaggregated.PooledMetric)
+
 	// ForwardedID returns the id of the forwarded metric if applicable.
 	ForwardedID() (id.RawID, bool)
 
@@ -118,6 +128,11 @@ type metricElem interface {
 		onDoneFn onForwardedAggregationDoneFn,
 	)
 
+	// SetIngestToFlushLatency sets the histogram recording the latency between
+	// the first metric of an aggregation window being ingested and the window
+	// being flushed.
+	SetIngestToFlushLatency(value tally.Histogram)
+
 	// AddUnion adds a metric value union at a given timestamp.
 	AddUnion(timestamp time.Time, mu unaggregated.MetricUnion) error
 
@@ -129,6 +144,10 @@ type metricElem interface {
 	// same aggregation, the incoming value is discarded.
 	AddUnique(timestamp time.Time, values []float64, sourceID uint32) error
 
+	// MergeState merges the encoded partial state of an aggregation computed
+	// elsewhere into the aggregation at a given timestamp.
+	MergeState(timestamp time.Time, state []byte) error
+
 	// Consume consumes values before a given time and removes
 	// them from the element after they are consumed, returning whether
 	// the element can be collected after the consumption is completed.
@@ -145,6 +164,10 @@ type metricElem interface {
 	// will be deleted once its aggregated values have been flushed.
 	MarkAsTombstoned()
 
+	// LastWriteNanos returns the latest timestamp written to the element in
+	// Unix nanoseconds, or zero if nothing has been written.
+	LastWriteNanos() int64
+
 	// Close closes the element.
 	Close()
 }
@@ -157,6 +180,7 @@ type elemBase struct {
 	opts                            Options
 	aggTypesOpts                    maggregation.TypesOptions
 	id                              id.RawID
+	idRef                           *unaggregated.PooledMetric
 	sp                              policy.StoragePolicy
 	useDefaultAggregation           bool
 	aggTypes                        maggregation.Types
@@ -166,8 +190,11 @@ type elemBase struct {
 	idPrefixSuffixType              IDPrefixSuffixType
 	writeForwardedMetricFn          writeForwardedMetricFn
 	onForwardedAggregationWrittenFn onForwardedAggregationDoneFn
+	ingestToFlushLatency            tally.Histogram
+	nowFn                           clock.NowFn
 
 	// Mutable states.
+	lastWriteNanos       int64 // accessed atomically
 	tombstoned           bool
 	closed               bool
 	cachedSourceSetsLock sync.Mutex       // nolint: structcheck
@@ -177,6 +204,7 @@ type elemBase struct {
 func newElemBase(opts Options) elemBase {
 	return elemBase{
 		opts:         opts,
+		nowFn:        opts.ClockOptions().NowFn(),
 		aggTypesOpts: opts.AggregationTypesOptions(),
 		aggOpts:      raggregation.NewOptions(opts.InstrumentOptions()),
 	}
@@ -205,6 +233,7 @@ func (e *elemBase) resetSetData(
 	e.aggOpts.ResetSetData(aggTypes)
 	e.parsedPipeline = parsed
 	e.numForwardedTimes = numForwardedTimes
+	e.lastWriteNanos = 0
 	e.tombstoned = false
 	e.closed = false
 	e.idPrefixSuffixType = idPrefixSuffixType
@@ -219,8 +248,25 @@ func (e *elemBase) SetForwardedCallbacks(
 	e.onForwardedAggregationWrittenFn = onDoneFn
 }
 
+func (e *elemBase) SetIngestToFlushLatency(value tally.Histogram) {
+	e.ingestToFlushLatency = value
+}
+
+// recordIngestToFlushLatency records the latency between the first metric of
+// an aggregation window being ingested and the window being flushed.
+func (e *elemBase) recordIngestToFlushLatency(ingestNanos, flushedAtNanos int64) {
+	if e.ingestToFlushLatency == nil {
+		return
+	}
+	e.ingestToFlushLatency.RecordDuration(time.Duration(flushedAtNanos - ingestNanos))
+}
+
 func (e *elemBase) ID() id.RawID { return e.id }
 
+func (e *elemBase) IDRef() *unaggregated.PooledMetric { return e.idRef }
+
+func (e *elemBase) SetIDRef(ref *unaggregated.PooledMetric) { e.idRef = ref }
+
 func (e *elemBase) ForwardedID() (id.RawID, bool) {
 	if !e.parsedPipeline.HasRollup {
 		return nil, false
@@ -252,6 +298,25 @@ func (e *elemBase) MarkAsTombstoned() {
 	e.Unlock()
 }
 
+func (e *elemBase) LastWriteNanos() int64 {
+	return atomic.LoadInt64(&e.lastWriteNanos)
+}
+
+// recordWrite advances the last write timestamp of the element if the
+// timestamp written is later than any written before.
+func (e *elemBase) recordWrite(timestamp time.Time) {
+	timeNanos := timestamp.UnixNano()
+	for {
+		lastWriteNanos := atomic.LoadInt64(&e.lastWriteNanos)
+		if timeNanos <= lastWriteNanos {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&e.lastWriteNanos, lastWriteNanos, timeNanos) {
+			return
+		}
+	}
+}
+
 type counterElemBase struct{}
 
 func (e counterElemBase) Type() metric.Type { return metric.CounterType }
@@ -35,10 +35,12 @@ import (
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/metrics/transformation"
+	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/pool"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 	"github.com/willf/bitset"
 )
 
@@ -247,6 +249,36 @@ func TestCounterElemAddUnionWithCustomAggregation(t *testing.T) {
 	require.Equal(t, errElemClosed, e.AddUnion(testTimestamps[2], testCounter))
 }
 
+func TestCounterElemMergeState(t *testing.T) {
+	e, err := NewCounterElem(testCounterID, testStoragePolicy, maggregation.DefaultTypes, applied.DefaultPipeline, testNumForwardedTimes, NoPrefixNoSuffix, NewOptions())
+	require.NoError(t, err)
+	require.NoError(t, e.AddValue(testTimestamps[0], 100))
+
+	// Merge the partial state of a counter aggregated elsewhere.
+	state, err := raggregation.CounterState{
+		LastAtNanos: testTimestamps[1].UnixNano(),
+		Count:       2,
+		Sum:         300,
+		Min:         50,
+		Max:         250,
+	}.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, e.MergeState(testTimestamps[1], state))
+	require.Equal(t, 1, len(e.values))
+	require.Equal(t, testAlignedStarts[0], e.values[0].startAtNanos)
+	require.Equal(t, int64(400), e.values[0].lockedAgg.aggregation.Sum())
+	require.Equal(t, int64(3), e.values[0].lockedAgg.aggregation.Count())
+	require.Equal(t, int64(50), e.values[0].lockedAgg.aggregation.Min())
+	require.Equal(t, int64(250), e.values[0].lockedAgg.aggregation.Max())
+
+	// Merging an invalid state results in an error.
+	require.Error(t, e.MergeState(testTimestamps[1], state[:1]))
+
+	// Merging into a closed element results in an error.
+	e.closed = true
+	require.Equal(t, errElemClosed, e.MergeState(testTimestamps[2], state))
+}
+
 func TestCounterElemAddUnique(t *testing.T) {
 	e, err := NewCounterElem(testCounterID, testStoragePolicy, maggregation.DefaultTypes, applied.DefaultPipeline, testNumForwardedTimes, NoPrefixNoSuffix, NewOptions())
 	require.NoError(t, err)
@@ -350,6 +382,106 @@ func TestCounterElemAddUniqueWithCustomAggregation(t *testing.T) {
 	require.Equal(t, errElemClosed, e.AddUnique(testTimestamps[2], []float64{40}, 1376))
 }
 
+func TestCounterElemConsumeStalenessMarker(t *testing.T) {
+	var (
+		isEarlierThanFn  = isStandardMetricEarlierThan
+		timestampNanosFn = standardMetricTimestampNanos
+		resolution       = testStoragePolicy.Resolution().Window
+		start            = time.Unix(1600000000, 0).Truncate(resolution)
+		opts             = NewOptions().SetEmitStalenessMarkers(true)
+	)
+	e := MustNewCounterElem(testCounterID, testStoragePolicy, maggregation.DefaultTypes,
+		applied.DefaultPipeline, testNumForwardedTimes, WithPrefixWithSuffix, opts)
+	require.Equal(t, int64(0), e.LastWriteNanos())
+
+	require.NoError(t, e.AddValue(start, 1))
+	require.Equal(t, start.UnixNano(), e.LastWriteNanos())
+
+	// Nothing was flushed yet so the element is not stale.
+	localFn, localRes := testFlushLocalMetricFn()
+	forwardFn, _ := testFlushForwardedMetricFn()
+	onForwardedFlushedFn, _ := testOnForwardedFlushedFn()
+	require.False(t, e.Consume(start.UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 0, len(*localRes))
+
+	// Consume the value written.
+	localFn, localRes = testFlushLocalMetricFn()
+	require.False(t, e.Consume(start.Add(resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 1, len(*localRes))
+	require.Equal(t, 1.0, (*localRes)[0].value)
+
+	// The next window closed without writes so a staleness marker is flushed.
+	localFn, localRes = testFlushLocalMetricFn()
+	require.False(t, e.Consume(start.Add(2*resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 1, len(*localRes))
+	require.Equal(t, start.Add(2*resolution).UnixNano(), (*localRes)[0].timeNanos)
+	require.True(t, math.IsNaN((*localRes)[0].value))
+
+	// The staleness marker is only flushed once.
+	localFn, localRes = testFlushLocalMetricFn()
+	require.False(t, e.Consume(start.Add(3*resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 0, len(*localRes))
+
+	// The series reporting again resets the staleness marker.
+	require.NoError(t, e.AddValue(start.Add(3*resolution), 2))
+	localFn, localRes = testFlushLocalMetricFn()
+	require.False(t, e.Consume(start.Add(4*resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 1, len(*localRes))
+	require.Equal(t, 2.0, (*localRes)[0].value)
+	require.False(t, e.stalenessMarked)
+}
+
+func TestCounterElemConsumeStalenessMarkerDisabled(t *testing.T) {
+	var (
+		isEarlierThanFn  = isStandardMetricEarlierThan
+		timestampNanosFn = standardMetricTimestampNanos
+		resolution       = testStoragePolicy.Resolution().Window
+		start            = time.Unix(1600000000, 0).Truncate(resolution)
+	)
+	e := MustNewCounterElem(testCounterID, testStoragePolicy, maggregation.DefaultTypes,
+		applied.DefaultPipeline, testNumForwardedTimes, WithPrefixWithSuffix, NewOptions())
+	require.NoError(t, e.AddValue(start, 1))
+
+	localFn, localRes := testFlushLocalMetricFn()
+	forwardFn, _ := testFlushForwardedMetricFn()
+	onForwardedFlushedFn, _ := testOnForwardedFlushedFn()
+	require.False(t, e.Consume(start.Add(resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 1, len(*localRes))
+
+	localFn, localRes = testFlushLocalMetricFn()
+	require.False(t, e.Consume(start.Add(2*resolution).UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 0, len(*localRes))
+}
+
+func TestCounterElemConsumeIngestToFlushLatency(t *testing.T) {
+	var (
+		isEarlierThanFn  = isStandardMetricEarlierThan
+		timestampNanosFn = standardMetricTimestampNanos
+		resolution       = testStoragePolicy.Resolution().Window
+		start            = time.Unix(1600000000, 0).Truncate(resolution)
+		now              = start
+		nowFn            = func() time.Time { return now }
+		opts             = NewOptions().SetClockOptions(clock.NewOptions().SetNowFn(nowFn))
+		scope            = tally.NewTestScope("", nil)
+		buckets          = tally.DurationBuckets{time.Second, time.Minute}
+	)
+	e := MustNewCounterElem(testCounterID, testStoragePolicy, maggregation.DefaultTypes,
+		applied.DefaultPipeline, testNumForwardedTimes, WithPrefixWithSuffix, opts)
+	e.SetIngestToFlushLatency(scope.Histogram("latency", buckets))
+	require.NoError(t, e.AddValue(start, 1))
+
+	now = start.Add(resolution)
+	localFn, localRes := testFlushLocalMetricFn()
+	forwardFn, _ := testFlushForwardedMetricFn()
+	onForwardedFlushedFn, _ := testOnForwardedFlushedFn()
+	require.False(t, e.Consume(now.UnixNano(), isEarlierThanFn, timestampNanosFn, localFn, forwardFn, onForwardedFlushedFn))
+	require.Equal(t, 1, len(*localRes))
+
+	histogram, ok := scope.Snapshot().Histograms()["latency+"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), histogram.Durations()[time.Minute])
+}
+
 func TestCounterElemConsumeDefaultAggregationDefaultPipeline(t *testing.T) {
 	isEarlierThanFn := isStandardMetricEarlierThan
 	timestampNanosFn := standardMetricTimestampNanos
@@ -1280,6 +1412,29 @@ func TestGaugeElemAddUnionWithCustomAggregation(t *testing.T) {
 	require.Equal(t, errElemClosed, e.AddUnion(testTimestamps[2], testGauge))
 }
 
+func TestGaugeElemMergeState(t *testing.T) {
+	e, err := NewGaugeElem(testGaugeID, testStoragePolicy, maggregation.DefaultTypes, applied.DefaultPipeline, testNumForwardedTimes, NoPrefixNoSuffix, NewOptions())
+	require.NoError(t, err)
+	require.NoError(t, e.AddValue(testTimestamps[1], 10.0))
+
+	// The last value is only taken from the merged state if it is more recent.
+	state, err := raggregation.GaugeState{
+		LastAtNanos: testTimestamps[0].UnixNano(),
+		Last:        20.0,
+		Count:       1,
+		Sum:         20.0,
+		Min:         20.0,
+		Max:         20.0,
+	}.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, e.MergeState(testTimestamps[0], state))
+	require.Equal(t, 1, len(e.values))
+	require.Equal(t, 10.0, e.values[0].lockedAgg.aggregation.Last())
+	require.Equal(t, 30.0, e.values[0].lockedAgg.aggregation.Sum())
+	require.Equal(t, int64(2), e.values[0].lockedAgg.aggregation.Count())
+	require.Equal(t, 20.0, e.values[0].lockedAgg.aggregation.Max())
+}
+
 func TestGaugeElemAddUnique(t *testing.T) {
 	e, err := NewGaugeElem(testGaugeID, testStoragePolicy, maggregation.DefaultTypes, applied.DefaultPipeline, testNumForwardedTimes, NoPrefixNoSuffix, NewOptions())
 	require.NoError(t, err)
@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/aggregator/bitset"
 	"github.com/m3db/m3/src/aggregator/rate"
 	"github.com/m3db/m3/src/aggregator/runtime"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
@@ -38,8 +39,11 @@ import (
 	"github.com/m3db/m3/src/metrics/metric/id"
 	metricid "github.com/m3db/m3/src/metrics/metric/id"
 	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/metrics/pipeline"
+	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/intern"
 
 	"github.com/uber-go/tally"
 )
@@ -84,6 +88,7 @@ type untimedEntryMetrics struct {
 	staleMetadata           tally.Counter
 	tombstonedMetadata      tally.Counter
 	metadatasUpdates        tally.Counter
+	dedupedGauges           tally.Counter
 }
 
 func newUntimedEntryMetrics(scope tally.Scope) untimedEntryMetrics {
@@ -97,6 +102,7 @@ func newUntimedEntryMetrics(scope tally.Scope) untimedEntryMetrics {
 		staleMetadata:           scope.Counter("stale-metadata"),
 		tombstonedMetadata:      scope.Counter("tombstoned-metadata"),
 		metadatasUpdates:        scope.Counter("metadatas-updates"),
+		dedupedGauges:           scope.Counter("deduped-gauges"),
 	}
 }
 
@@ -108,6 +114,7 @@ type timedEntryMetrics struct {
 	tombstonedMetadata    tally.Counter
 	metadataUpdates       tally.Counter
 	metadatasUpdates      tally.Counter
+	dedupedGauges         tally.Counter
 }
 
 func newTimedEntryMetrics(scope tally.Scope) timedEntryMetrics {
@@ -119,6 +126,7 @@ func newTimedEntryMetrics(scope tally.Scope) timedEntryMetrics {
 		tombstonedMetadata:    scope.Counter("tombstoned-metadata"),
 		metadataUpdates:       scope.Counter("metadata-updates"),
 		metadatasUpdates:      scope.Counter("metadatas-updates"),
+		dedupedGauges:         scope.Counter("deduped-gauges"),
 	}
 }
 
@@ -155,6 +163,44 @@ func newEntryMetrics(scope tally.Scope) entryMetrics {
 	}
 }
 
+// gaugeDedupKey identifies the metric policy and time bucket of a gauge write.
+type gaugeDedupKey struct {
+	bucketNanos   int64
+	cutoverNanos  int64
+	aggregationID aggregation.ID
+	storagePolicy policy.StoragePolicy
+}
+
+// gaugeDedupState keeps track of the last gauge write so identical writes
+// resent by upstream sources can be dropped.
+type gaugeDedupState struct {
+	sync.Mutex
+
+	key   gaugeDedupKey
+	value float64
+	valid bool
+}
+
+// isDuplicate returns true if the write has the same key and value as the
+// last write, and records the write as the last write otherwise.
+func (s *gaugeDedupState) isDuplicate(key gaugeDedupKey, value float64) bool {
+	s.Lock()
+	duplicate := s.valid && s.key == key && s.value == value
+	s.key = key
+	s.value = value
+	s.valid = true
+	s.Unlock()
+	return duplicate
+}
+
+func (s *gaugeDedupState) reset() {
+	s.Lock()
+	s.key = gaugeDedupKey{}
+	s.value = 0
+	s.valid = false
+	s.Unlock()
+}
+
 // Entry keeps track of a metric's aggregations alongside the aggregation
 // metadatas including storage policies, aggregation types, and remaining pipeline
 // steps if any.
@@ -179,6 +225,13 @@ type Entry struct {
 	// The entry keeps a decompressor to reuse the bitset in it, so we can
 	// save some heap allocations.
 	decompressor aggregation.IDDecompressor
+	// Gauge dedup states are guarded by their own locks since writes
+	// only hold the entry read lock in the common case.
+	untimedGaugeDedup gaugeDedupState
+	timedGaugeDedup   gaugeDedupState
+	// gaugeDedupEnabled is true if every aggregation of the entry produces the
+	// same result when the same gauge value is aggregated more than once.
+	gaugeDedupEnabled bool
 }
 
 // NewEntry creates a new entry.
@@ -211,6 +264,8 @@ func (e *Entry) ResetSetData(lists *metricLists, runtimeOpts runtime.Options, op
 	e.cutoverNanos = uninitializedCutoverNanos
 	e.lists = lists
 	e.numWriters = 0
+	e.untimedGaugeDedup.reset()
+	e.timedGaugeDedup.reset()
 	e.recordLastAccessed(e.opts.ClockOptions().NowFn()())
 	e.Unlock()
 }
@@ -230,6 +285,16 @@ func (e *Entry) SetRuntimeOptions(opts runtime.Options) {
 func (e *Entry) AddUntimed(
 	metricUnion unaggregated.MetricUnion,
 	metadatas metadata.StagedMetadatas,
+) error {
+	return e.addUntimedWithTrace(metricUnion, metadatas, nil)
+}
+
+// addUntimedWithTrace adds an untimed metric, attributing the wall time of
+// the write to the phases of the trace if the write is traced.
+func (e *Entry) addUntimedWithTrace(
+	metricUnion unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+	trace *writetrace.Trace,
 ) error {
 	switch metricUnion.Type {
 	case metric.TimerType:
@@ -238,7 +303,7 @@ func (e *Entry) AddUntimed(
 			int64(len(metricUnion.BatchTimerVal)),
 			e.metrics.untimed.rateLimit,
 		); err == nil {
-			err = e.writeBatchTimerWithMetadatas(metricUnion, metadatas)
+			err = e.writeBatchTimerWithMetadatas(metricUnion, metadatas, trace)
 		}
 		if metricUnion.BatchTimerVal != nil && metricUnion.TimerValPool != nil {
 			metricUnion.TimerValPool.Put(metricUnion.BatchTimerVal)
@@ -249,7 +314,7 @@ func (e *Entry) AddUntimed(
 		if err := e.applyValueRateLimit(1, e.metrics.untimed.rateLimit); err != nil {
 			return err
 		}
-		return e.addUntimed(metricUnion, metadatas)
+		return e.addUntimed(metricUnion, metadatas, trace)
 	}
 }
 
@@ -318,6 +383,7 @@ func (e *Entry) TryExpire(now time.Time) bool {
 		e.aggregations[i] = aggregationValue{}
 	}
 	e.aggregations = e.aggregations[:0]
+	e.gaugeDedupEnabled = false
 	e.lists = nil
 	pool := e.opts.EntryPool()
 	e.Unlock()
@@ -329,12 +395,13 @@ func (e *Entry) TryExpire(now time.Time) bool {
 func (e *Entry) writeBatchTimerWithMetadatas(
 	metric unaggregated.MetricUnion,
 	metadatas metadata.StagedMetadatas,
+	trace *writetrace.Trace,
 ) error {
 	// If there is no limit on the maximum batch size per write, write
 	// all timers at once.
 	maxTimerBatchSizePerWrite := e.opts.MaxTimerBatchSizePerWrite()
 	if maxTimerBatchSizePerWrite == 0 {
-		return e.addUntimed(metric, metadatas)
+		return e.addUntimed(metric, metadatas, trace)
 	}
 
 	// Otherwise, honor maximum timer batch size.
@@ -350,7 +417,7 @@ func (e *Entry) writeBatchTimerWithMetadatas(
 		}
 		splitTimer := metric
 		splitTimer.BatchTimerVal = timerValues[start:end]
-		if err := e.addUntimed(splitTimer, metadatas); err != nil {
+		if err := e.addUntimed(splitTimer, metadatas, trace); err != nil {
 			return err
 		}
 	}
@@ -360,6 +427,7 @@ func (e *Entry) writeBatchTimerWithMetadatas(
 func (e *Entry) addUntimed(
 	metric unaggregated.MetricUnion,
 	metadatas metadata.StagedMetadatas,
+	trace *writetrace.Trace,
 ) error {
 	timeLock := e.opts.TimeLock()
 	timeLock.RLock()
@@ -383,7 +451,9 @@ func (e *Entry) addUntimed(
 	// Fast exit path for the common case where the metric has default metadatas for aggregation.
 	hasDefaultMetadatas := metadatas.IsDefault()
 	if e.hasDefaultMetadatas && hasDefaultMetadatas {
+		trace.Mark(writetrace.MetadataPhase)
 		err := e.addUntimedWithLock(currTime, metric)
+		trace.Mark(writetrace.UpdatePhase)
 		e.RUnlock()
 		timeLock.RUnlock()
 		return err
@@ -417,7 +487,9 @@ func (e *Entry) addUntimed(
 	}
 
 	if !e.shouldUpdateStagedMetadatasWithLock(sm) {
+		trace.Mark(writetrace.MetadataPhase)
 		err = e.addUntimedWithLock(currTime, metric)
+		trace.Mark(writetrace.UpdatePhase)
 		e.RUnlock()
 		timeLock.RUnlock()
 		return err
@@ -432,8 +504,8 @@ func (e *Entry) addUntimed(
 	}
 
 	if e.shouldUpdateStagedMetadatasWithLock(sm) {
-		err := e.updateStagedMetadatasWithLock(metric.ID, metric.Type,
-			hasDefaultMetadatas, sm)
+		err := e.updateStagedMetadatasWithLock(metric.ID, metric.Ref, metric.Type,
+			standardMetricListType, hasDefaultMetadatas, sm)
 		if err != nil {
 			// NB(xichen): if an error occurred during policy update, the policies
 			// will remain as they are, i.e., there are no half-updated policies.
@@ -443,8 +515,10 @@ func (e *Entry) addUntimed(
 		}
 		e.metrics.untimed.metadatasUpdates.Inc(1)
 	}
+	trace.Mark(writetrace.MetadataPhase)
 
 	err = e.addUntimedWithLock(currTime, metric)
+	trace.Mark(writetrace.UpdatePhase)
 	e.Unlock()
 	timeLock.RUnlock()
 
@@ -516,23 +590,80 @@ func (e *Entry) storagePolicies(policies policy.StoragePolicies) policy.StorageP
 	return e.opts.DefaultStoragePolicies()
 }
 
-func (e *Entry) maybeCopyIDWithLock(id metricid.RawID) metricid.RawID {
+// stagedMetricListID returns the id of the list aggregations created from
+// staged metadatas are added to, timed metrics are added to timed lists so
+// their windows are only flushed once the buffer for past metrics has elapsed.
+func stagedMetricListID(listType metricListType, resolution time.Duration) metricListID {
+	if listType == timedMetricListType {
+		return timedMetricListID{resolution: resolution}.toMetricListID()
+	}
+	return standardMetricListID{resolution: resolution}.toMetricListID()
+}
+
+// minResolution returns the finest resolution of the storage policies
+// the staged metadata aggregates at.
+func (e *Entry) minResolution(sm metadata.StagedMetadata) time.Duration {
+	var minResolution time.Duration
+	for _, pipeline := range sm.Pipelines {
+		for _, storagePolicy := range e.storagePolicies(pipeline.StoragePolicies) {
+			resolution := storagePolicy.Resolution().Window
+			if minResolution == 0 || resolution < minResolution {
+				minResolution = resolution
+			}
+		}
+	}
+	return minResolution
+}
+
+func (e *Entry) maybeCopyIDWithLock(
+	id metricid.RawID,
+	idRef *unaggregated.PooledMetric,
+) (metricid.RawID, *unaggregated.PooledMetric) {
 	// If there are existing elements for this id, try reusing
 	// the id from the elements because those are owned by us.
 	if len(e.aggregations) > 0 {
-		return e.aggregations[0].elem.Value.(metricElem).ID()
+		elem := e.aggregations[0].elem.Value.(metricElem)
+		return elem.ID(), elem.IDRef()
+	}
+
+	// If the id is owned by a pooled metric, it is retained by taking
+	// references on the pooled metric instead of making a copy.
+	if idRef != nil {
+		return id, idRef
 	}
 
 	// Otherwise it is necessary to make a copy because it's not owned by us.
 	elemID := make(metricid.RawID, len(id))
 	copy(elemID, id)
-	return elemID
+	return elemID, nil
+}
+
+// clonePipeline clones a pipeline, interning its rollup ids since they are
+// shared by the elements of every series rolled up into them.
+func clonePipeline(p applied.Pipeline, interner *intern.Cache) applied.Pipeline {
+	ops := make([]applied.OpUnion, p.Len())
+	for i := range ops {
+		op := p.At(i)
+		if op.Type != pipeline.RollupOpType {
+			ops[i] = op.Clone()
+			continue
+		}
+		ops[i] = applied.OpUnion{
+			Type: op.Type,
+			Rollup: applied.RollupOp{
+				ID:            interner.Intern(op.Rollup.ID),
+				AggregationID: op.Rollup.AggregationID,
+			},
+		}
+	}
+	return applied.NewPipeline(ops)
 }
 
 // addAggregationKey adds a new aggregation key to the list of new aggregations.
 func (e *Entry) addNewAggregationKeyWithLock(
 	metricType metric.Type,
 	metricID metricid.RawID,
+	idRef *unaggregated.PooledMetric,
 	key aggregationKey,
 	listID metricListID,
 	newAggregations aggregationValues,
@@ -561,10 +692,14 @@ func (e *Entry) addNewAggregationKeyWithLock(
 		return nil, errInvalidMetricType
 	}
 	// NB: The pipeline may not be owned by us and as such we need to make a copy here.
-	key.pipeline = key.pipeline.Clone()
+	key.pipeline = clonePipeline(key.pipeline, e.opts.RollupIDInterner())
 	if err = newElem.ResetSetData(metricID, key.storagePolicy, aggTypes, key.pipeline, key.numForwardedTimes, key.idPrefixSuffixType); err != nil {
 		return nil, err
 	}
+	if idRef != nil {
+		idRef.IncRef()
+		newElem.SetIDRef(idRef)
+	}
 	list, err := e.lists.FindOrCreate(listID)
 	if err != nil {
 		return nil, err
@@ -587,12 +722,14 @@ func (e *Entry) removeOldAggregations(newAggregations aggregationValues) {
 
 func (e *Entry) updateStagedMetadatasWithLock(
 	metricID id.RawID,
+	metricIDRef *unaggregated.PooledMetric,
 	metricType metric.Type,
+	listType metricListType,
 	hasDefaultMetadatas bool,
 	sm metadata.StagedMetadata,
 ) error {
 	var (
-		elemID          = e.maybeCopyIDWithLock(metricID)
+		elemID, idRef   = e.maybeCopyIDWithLock(metricID, metricIDRef)
 		newAggregations = make(aggregationValues, 0, initialAggregationCapacity)
 	)
 
@@ -606,11 +743,9 @@ func (e *Entry) updateStagedMetadatasWithLock(
 				pipeline:           pipeline.Pipeline,
 				idPrefixSuffixType: WithPrefixWithSuffix,
 			}
-			listID := standardMetricListID{
-				resolution: storagePolicy.Resolution().Window,
-			}.toMetricListID()
+			listID := stagedMetricListID(listType, storagePolicy.Resolution().Window)
 			var err error
-			newAggregations, err = e.addNewAggregationKeyWithLock(metricType, elemID, key, listID, newAggregations)
+			newAggregations, err = e.addNewAggregationKeyWithLock(metricType, elemID, idRef, key, listID, newAggregations)
 			if err != nil {
 				return err
 			}
@@ -621,7 +756,7 @@ func (e *Entry) updateStagedMetadatasWithLock(
 	e.removeOldAggregations(newAggregations)
 
 	// Replace the existing aggregations with new aggregations.
-	e.aggregations = newAggregations
+	e.setAggregationsWithLock(newAggregations)
 	e.hasDefaultMetadatas = hasDefaultMetadatas
 	e.cutoverNanos = sm.CutoverNanos
 
@@ -629,6 +764,11 @@ func (e *Entry) updateStagedMetadatasWithLock(
 }
 
 func (e *Entry) addUntimedWithLock(timestamp time.Time, mu unaggregated.MetricUnion) error {
+	key := gaugeDedupKey{cutoverNanos: e.cutoverNanos}
+	if e.isDuplicateGauge(&e.untimedGaugeDedup, mu.Type, timestamp.UnixNano(), key, mu.GaugeVal) {
+		e.metrics.untimed.dedupedGauges.Inc(1)
+		return nil
+	}
 	multiErr := xerrors.NewMultiError()
 	for _, val := range e.aggregations {
 		if err := val.elem.Value.(metricElem).AddUnion(timestamp, mu); err != nil {
@@ -662,17 +802,6 @@ func (e *Entry) addTimed(
 		return errEntryClosed
 	}
 
-	// Reject datapoints that arrive too late or too early.
-	if err := e.checkTimestampForTimedMetric(
-		metric,
-		currTime.UnixNano(),
-		metadata.StoragePolicy.Resolution().Window,
-	); err != nil {
-		e.RUnlock()
-		timeLock.RUnlock()
-		return err
-	}
-
 	// Only apply processing of staged metadatas if has sent staged metadatas
 	// that isn't the default staged metadatas.
 	hasDefaultMetadatas := stagedMetadatas.IsDefault()
@@ -704,6 +833,18 @@ func (e *Entry) addTimed(
 			return errNoPipelinesInMetadata
 		}
 
+		// Reject datapoints that arrive too late or too early for the finest
+		// resolution they are aggregated at, since its windows are flushed first.
+		if err := e.checkTimestampForTimedMetric(
+			metric,
+			currTime.UnixNano(),
+			e.minResolution(sm),
+		); err != nil {
+			e.RUnlock()
+			timeLock.RUnlock()
+			return err
+		}
+
 		if !e.shouldUpdateStagedMetadatasWithLock(sm) {
 			err = e.addTimedWithStagedMetadatasAndLock(metric)
 			e.RUnlock()
@@ -720,8 +861,8 @@ func (e *Entry) addTimed(
 		}
 
 		if e.shouldUpdateStagedMetadatasWithLock(sm) {
-			err := e.updateStagedMetadatasWithLock(metric.ID, metric.Type,
-				hasDefaultMetadatas, sm)
+			err := e.updateStagedMetadatasWithLock(metric.ID, nil, metric.Type,
+				timedMetricListType, hasDefaultMetadatas, sm)
 			if err != nil {
 				// NB(xichen): if an error occurred during policy update, the policies
 				// will remain as they are, i.e., there are no half-updated policies.
@@ -739,6 +880,17 @@ func (e *Entry) addTimed(
 		return err
 	}
 
+	// Reject datapoints that arrive too late or too early.
+	if err := e.checkTimestampForTimedMetric(
+		metric,
+		currTime.UnixNano(),
+		metadata.StoragePolicy.Resolution().Window,
+	); err != nil {
+		e.RUnlock()
+		timeLock.RUnlock()
+		return err
+	}
+
 	// Check if we should update metadata, and add metric if not.
 	key := aggregationKey{
 		aggregationID:      metadata.AggregationID,
@@ -831,8 +983,8 @@ func (e *Entry) updateTimedMetadataWithLock(
 	metadata metadata.TimedMetadata,
 ) error {
 	var (
-		elemID = e.maybeCopyIDWithLock(metric.ID)
-		err    error
+		elemID, idRef = e.maybeCopyIDWithLock(metric.ID, nil)
+		err           error
 	)
 
 	// Update the timed metadata.
@@ -844,12 +996,12 @@ func (e *Entry) updateTimedMetadataWithLock(
 	listID := timedMetricListID{
 		resolution: metadata.StoragePolicy.Resolution().Window,
 	}.toMetricListID()
-	newAggregations, err := e.addNewAggregationKeyWithLock(metric.Type, elemID, key, listID, e.aggregations)
+	newAggregations, err := e.addNewAggregationKeyWithLock(metric.Type, elemID, idRef, key, listID, e.aggregations)
 	if err != nil {
 		return err
 	}
 
-	e.aggregations = newAggregations
+	e.setAggregationsWithLock(newAggregations)
 	e.metrics.timed.metadataUpdates.Inc(1)
 	return nil
 }
@@ -858,6 +1010,14 @@ func (e *Entry) addTimedWithLock(
 	value aggregationValue,
 	metric aggregated.Metric,
 ) error {
+	key := gaugeDedupKey{
+		aggregationID: value.key.aggregationID,
+		storagePolicy: value.key.storagePolicy,
+	}
+	if e.isDuplicateGauge(&e.timedGaugeDedup, metric.Type, metric.TimeNanos, key, metric.Value) {
+		e.metrics.timed.dedupedGauges.Inc(1)
+		return nil
+	}
 	timestamp := time.Unix(0, metric.TimeNanos)
 	return value.elem.Value.(metricElem).AddValue(timestamp, metric.Value)
 }
@@ -865,6 +1025,11 @@ func (e *Entry) addTimedWithLock(
 func (e *Entry) addTimedWithStagedMetadatasAndLock(
 	metric aggregated.Metric,
 ) error {
+	key := gaugeDedupKey{cutoverNanos: e.cutoverNanos}
+	if e.isDuplicateGauge(&e.timedGaugeDedup, metric.Type, metric.TimeNanos, key, metric.Value) {
+		e.metrics.timed.dedupedGauges.Inc(1)
+		return nil
+	}
 	timestamp := time.Unix(0, metric.TimeNanos)
 	multiErr := xerrors.NewMultiError()
 	for _, val := range e.aggregations {
@@ -990,8 +1155,8 @@ func (e *Entry) updateForwardMetadataWithLock(
 	metadata metadata.ForwardMetadata,
 ) error {
 	var (
-		elemID = e.maybeCopyIDWithLock(metric.ID)
-		err    error
+		elemID, idRef = e.maybeCopyIDWithLock(metric.ID, nil)
+		err           error
 	)
 
 	// Update the forward metadata.
@@ -1006,12 +1171,12 @@ func (e *Entry) updateForwardMetadataWithLock(
 		resolution:        metadata.StoragePolicy.Resolution().Window,
 		numForwardedTimes: metadata.NumForwardedTimes,
 	}.toMetricListID()
-	newAggregations, err := e.addNewAggregationKeyWithLock(metric.Type, elemID, key, listID, e.aggregations)
+	newAggregations, err := e.addNewAggregationKeyWithLock(metric.Type, elemID, idRef, key, listID, e.aggregations)
 	if err != nil {
 		return err
 	}
 
-	e.aggregations = newAggregations
+	e.setAggregationsWithLock(newAggregations)
 	e.metrics.forwarded.metadataUpdates.Inc(1)
 	return nil
 }
@@ -1032,6 +1197,59 @@ func (e *Entry) addForwardedWithLock(
 	return err
 }
 
+// setAggregationsWithLock replaces the aggregations of the entry and determines
+// whether gauge writes may be deduplicated for the new aggregations.
+func (e *Entry) setAggregationsWithLock(aggregations aggregationValues) {
+	e.aggregations = aggregations
+	e.gaugeDedupEnabled = len(aggregations) > 0
+	for _, agg := range aggregations {
+		if !e.isIdempotentGaugeAggregation(agg.key.aggregationID) {
+			e.gaugeDedupEnabled = false
+			return
+		}
+	}
+}
+
+// isIdempotentGaugeAggregation returns true if the gauge aggregation types
+// are not affected by aggregating the same value more than once, deduping
+// gauges aggregated with other types such as Sum or Count changes the results.
+func (e *Entry) isIdempotentGaugeAggregation(aggregationID aggregation.ID) bool {
+	var aggTypes aggregation.Types
+	if aggregationID.IsDefault() {
+		aggTypes = e.opts.AggregationTypesOptions().DefaultGaugeAggregationTypes()
+	} else {
+		var err error
+		if aggTypes, err = e.decompressor.Decompress(aggregationID); err != nil {
+			return false
+		}
+	}
+	for _, aggType := range aggTypes {
+		switch aggType {
+		case aggregation.Last, aggregation.Min, aggregation.Max:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isDuplicateGauge returns true if gauge deduplication is enabled and the
+// gauge write is identical to the last write in the same dedup window.
+func (e *Entry) isDuplicateGauge(
+	state *gaugeDedupState,
+	metricType metric.Type,
+	timeNanos int64,
+	key gaugeDedupKey,
+	value float64,
+) bool {
+	window := int64(e.opts.GaugeDedupWindow())
+	if window <= 0 || metricType != metric.GaugeType || !e.gaugeDedupEnabled {
+		return false
+	}
+	key.bucketNanos = timeNanos - timeNanos%window
+	return state.isDuplicate(key, value)
+}
+
 func (e *Entry) writerCount() int        { return int(atomic.LoadInt32(&e.numWriters)) }
 func (e *Entry) lastAccessed() time.Time { return time.Unix(0, atomic.LoadInt64(&e.lastAccessNanos)) }
 
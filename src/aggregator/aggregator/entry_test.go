@@ -21,6 +21,7 @@
 package aggregator
 
 import (
+	"bytes"
 	"container/list"
 	"fmt"
 	"strings"
@@ -30,6 +31,8 @@ import (
 
 	"github.com/m3db/m3/src/aggregator/runtime"
 	"github.com/m3db/m3/src/metrics/aggregation"
+	"github.com/m3db/m3/src/metrics/encoding"
+	"github.com/m3db/m3/src/metrics/encoding/protobuf"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/id"
@@ -40,11 +43,14 @@ import (
 	"github.com/m3db/m3/src/metrics/transformation"
 	"github.com/m3db/m3/src/x/clock"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
 	"github.com/m3db/m3/src/x/pool"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 var (
@@ -1425,6 +1431,65 @@ func TestEntryAddTimed(t *testing.T) {
 	require.Equal(t, testTimedMetric.ID, counterElem.ID())
 }
 
+func TestEntryAddTimedWithStagedMetadatas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e, lists, now := testEntry(ctrl, testEntryOptions{})
+	metas := metadata.StagedMetadatas{
+		metadata.StagedMetadata{
+			CutoverNanos: now.UnixNano() - 1000,
+			Metadata:     metadata.Metadata{Pipelines: testPipelines},
+		},
+	}
+	metric := testTimedMetric
+	metric.TimeNanos = now.Add(-time.Minute).UnixNano()
+	require.NoError(t, e.AddTimedWithStagedMetadatas(metric, metas))
+	require.True(t, len(e.aggregations) > 0)
+
+	// Verify the aggregations are added to timed lists so their windows are
+	// flushed only after the buffer for past timed metrics has elapsed.
+	for _, agg := range e.aggregations {
+		listID := timedMetricListID{
+			resolution: agg.key.storagePolicy.Resolution().Window,
+		}.toMetricListID()
+		res, exists := lists.lists[listID]
+		require.True(t, exists)
+		_, ok := res.(*timedMetricList)
+		require.True(t, ok)
+	}
+	for _, res := range lists.lists {
+		_, ok := res.(*timedMetricList)
+		require.True(t, ok)
+	}
+}
+
+func TestEntryAddTimedWithStagedMetadatasTooLate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e, _, now := testEntry(ctrl, testEntryOptions{})
+	e.opts = e.opts.SetBufferForPastTimedMetricFn(func(
+		resolution time.Duration,
+	) time.Duration {
+		return resolution + time.Second
+	})
+	metas := metadata.StagedMetadatas{
+		metadata.StagedMetadata{
+			CutoverNanos: now.UnixNano() - 1000,
+			Metadata:     metadata.Metadata{Pipelines: testPipelines},
+		},
+	}
+
+	// The finest resolution of the pipelines is 10 seconds.
+	metric := testTimedMetric
+	metric.TimeNanos = now.Add(-12 * time.Second).UnixNano()
+	require.Equal(t, errTooFarInThePast, e.AddTimedWithStagedMetadatas(metric, metas))
+
+	metric.TimeNanos = now.Add(-10 * time.Second).UnixNano()
+	require.NoError(t, e.AddTimedWithStagedMetadatas(metric, metas))
+}
+
 func TestEntryForwardedRateLimiting(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1702,14 +1767,254 @@ func TestEntryMaybeCopyIDWithLock(t *testing.T) {
 
 	id := id.RawID("foo")
 	e, _, _ := testEntry(ctrl, testEntryOptions{})
-	res := e.maybeCopyIDWithLock(id)
+	res, ref := e.maybeCopyIDWithLock(id, nil)
 	require.Equal(t, id, res)
+	require.Nil(t, ref)
 
 	// Verify the returned ID is a clone of the original ID.
 	id[0] = 'b'
 	require.NotEqual(t, id, res)
 }
 
+func TestEntryAddUntimedPooledMetricRetainsID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e, _, _ := testEntry(ctrl, testEntryOptions{})
+
+	m := unaggregated.NewPooledMetric(nil)
+	m.Type = metric.CounterType
+	m.ID = append(m.ID, testCounterID...)
+	m.CounterVal = testCounter.CounterVal
+	require.NoError(t, e.AddUntimed(m.MetricUnion, testDefaultStagedMetadatas))
+
+	// Release the reference held by the writer.
+	id := m.ID
+	m.DecRef()
+
+	// Verify the elements retain the pooled metric id rather than a copy.
+	require.True(t, len(e.aggregations) > 0)
+	var elems []metricElem
+	for _, agg := range e.aggregations {
+		elem := agg.elem.Value.(metricElem)
+		require.True(t, elem.IDRef() == m)
+		require.True(t, &elem.ID()[0] == &id[0])
+		elems = append(elems, elem)
+	}
+
+	// Verify the pooled metric is only reset once all elements are closed.
+	for _, elem := range elems {
+		require.Equal(t, testCounterID, m.ID)
+		elem.Close()
+	}
+	require.Equal(t, 0, len(m.ID))
+}
+
+func BenchmarkEntryAddUntimedPooledMetric(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	e, _, _ := testEntry(ctrl, testEntryOptions{})
+
+	enc := protobuf.NewUnaggregatedEncoder(protobuf.NewUnaggregatedOptions())
+	for i := 0; i < 1024; i++ {
+		err := enc.EncodeMessage(encoding.UnaggregatedMessageUnion{
+			Type: encoding.CounterWithMetadatasType,
+			CounterWithMetadatas: unaggregated.CounterWithMetadatas{
+				Counter: unaggregated.Counter{
+					ID:    testCounterID,
+					Value: int64(i),
+				},
+				StagedMetadatas: testDefaultStagedMetadatas,
+			},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := enc.Relinquish()
+	defer data.Close()
+
+	metricPool := unaggregated.NewPooledMetricPool(nil)
+	metricPool.Init()
+	opts := protobuf.NewUnaggregatedOptions().SetPooledMetricPool(metricPool)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for written := 0; written < b.N; {
+		// NB: only the writes are measured, not setting up the iterators.
+		b.StopTimer()
+		it := protobuf.NewUnaggregatedIterator(bytes.NewReader(data.Bytes()), opts)
+		b.StartTimer()
+		for ; written < b.N && it.Next(); written++ {
+			m := it.Current().PooledMetric
+			if err := e.AddUntimed(m.MetricUnion, testDefaultStagedMetadatas); err != nil {
+				b.Fatal(err)
+			}
+			m.DecRef()
+		}
+		b.StopTimer()
+		it.Close()
+		b.StartTimer()
+	}
+}
+
+func TestEntryAddUntimedGaugeDedup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := testOptions(ctrl).
+		SetGaugeDedupWindow(time.Minute).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	e, _, now := testEntry(ctrl, testEntryOptions{options: opts})
+	*now = time.Unix(0, 0).Add(time.Hour)
+
+	// Identical writes within the same window are deduplicated.
+	require.NoError(t, e.AddUntimed(testGauge, testDefaultStagedMetadatas))
+	require.NoError(t, e.AddUntimed(testGauge, testDefaultStagedMetadatas))
+	require.Equal(t, int64(1), testEntryCounterValue(scope, "deduped-gauges", "untimed"))
+
+	// Writes with a different value are not deduplicated.
+	otherGauge := testGauge
+	otherGauge.GaugeVal++
+	require.NoError(t, e.AddUntimed(otherGauge, testDefaultStagedMetadatas))
+	require.Equal(t, int64(1), testEntryCounterValue(scope, "deduped-gauges", "untimed"))
+
+	// Identical writes in a different window are not deduplicated.
+	*now = now.Add(time.Minute)
+	require.NoError(t, e.AddUntimed(otherGauge, testDefaultStagedMetadatas))
+	require.Equal(t, int64(1), testEntryCounterValue(scope, "deduped-gauges", "untimed"))
+}
+
+func TestEntryAddUntimedGaugeDedupDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := testOptions(ctrl).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	e, _, _ := testEntry(ctrl, testEntryOptions{options: opts})
+
+	require.NoError(t, e.AddUntimed(testGauge, testDefaultStagedMetadatas))
+	require.NoError(t, e.AddUntimed(testGauge, testDefaultStagedMetadatas))
+	require.Equal(t, int64(0), testEntryCounterValue(scope, "deduped-gauges", "untimed"))
+}
+
+func TestEntryAddTimedGaugeDedup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := testOptions(ctrl).
+		SetGaugeDedupWindow(time.Minute).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	e, _, now := testEntry(ctrl, testEntryOptions{options: opts})
+
+	gauge := testTimedMetric
+	gauge.Type = metric.GaugeType
+	gauge.TimeNanos = now.UnixNano()
+	timedMetadata := metadata.TimedMetadata{
+		AggregationID: aggregation.DefaultID,
+		StoragePolicy: testDefaultStoragePolicies[0],
+	}
+	require.NoError(t, e.AddTimed(gauge, timedMetadata))
+	require.NoError(t, e.AddTimed(gauge, timedMetadata))
+	require.Equal(t, int64(1), testEntryCounterValue(scope, "deduped-gauges", "timed"))
+
+	// Identical writes for a different policy are not deduplicated.
+	timedMetadata.StoragePolicy = testDefaultStoragePolicies[1]
+	require.NoError(t, e.AddTimed(gauge, timedMetadata))
+	require.Equal(t, int64(1), testEntryCounterValue(scope, "deduped-gauges", "timed"))
+}
+
+func TestEntryAddTimedGaugeDedupOnlyIdempotentAggregations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	inputs := []struct {
+		aggregationTypes aggregation.Types
+		expectedDeduped  int64
+	}{
+		{aggregationTypes: aggregation.Types{aggregation.Last}, expectedDeduped: 1},
+		{aggregationTypes: aggregation.Types{aggregation.Min, aggregation.Max}, expectedDeduped: 1},
+		{aggregationTypes: aggregation.Types{aggregation.Sum}, expectedDeduped: 0},
+		{aggregationTypes: aggregation.Types{aggregation.Count}, expectedDeduped: 0},
+		{aggregationTypes: aggregation.Types{aggregation.Max, aggregation.Sum}, expectedDeduped: 0},
+	}
+	for _, input := range inputs {
+		scope := tally.NewTestScope("", nil)
+		opts := testOptions(ctrl).
+			SetGaugeDedupWindow(time.Minute).
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+		e, _, now := testEntry(ctrl, testEntryOptions{options: opts})
+
+		gauge := testTimedMetric
+		gauge.Type = metric.GaugeType
+		gauge.TimeNanos = now.UnixNano()
+		timedMetadata := metadata.TimedMetadata{
+			AggregationID: aggregation.MustCompressTypes(input.aggregationTypes...),
+			StoragePolicy: testDefaultStoragePolicies[0],
+		}
+		require.NoError(t, e.AddTimed(gauge, timedMetadata))
+		require.NoError(t, e.AddTimed(gauge, timedMetadata))
+		require.Equal(t, input.expectedDeduped, testEntryCounterValue(scope, "deduped-gauges", "timed"))
+	}
+}
+
+func testEntryCounterValue(scope tally.TestScope, name, entryType string) int64 {
+	for _, c := range scope.Snapshot().Counters() {
+		if c.Name() == "entry."+name && c.Tags()["entry-type"] == entryType {
+			return c.Value()
+		}
+	}
+	return 0
+}
+
+func TestClonePipelineInternsRollupIDs(t *testing.T) {
+	interner, err := intern.NewCache(10, 100, tally.NoopScope)
+	require.NoError(t, err)
+
+	newPipeline := func() applied.Pipeline {
+		return applied.NewPipeline([]applied.OpUnion{
+			{
+				Type: pipeline.TransformationOpType,
+				Transformation: pipeline.TransformationOp{
+					Type: transformation.PerSecond,
+				},
+			},
+			{
+				Type: pipeline.RollupOpType,
+				Rollup: applied.RollupOp{
+					ID:            []byte("rollup.foo"),
+					AggregationID: aggregation.DefaultID,
+				},
+			},
+		})
+	}
+
+	var (
+		p1      = newPipeline()
+		p2      = newPipeline()
+		cloned1 = clonePipeline(p1, interner)
+		cloned2 = clonePipeline(p2, interner)
+	)
+	require.True(t, p1.Equal(cloned1))
+	require.True(t, p2.Equal(cloned2))
+
+	// Both clones share the interned rollup id rather than the original.
+	id1, id2 := cloned1.At(1).Rollup.ID, cloned2.At(1).Rollup.ID
+	require.True(t, &id1[0] == &id2[0])
+	require.False(t, &id1[0] == &p1.At(1).Rollup.ID[0])
+
+	// Without interning the rollup ids are copied.
+	cloned3 := clonePipeline(p1, nil)
+	require.True(t, p1.Equal(cloned3))
+	id3 := cloned3.At(1).Rollup.ID
+	require.False(t, &id1[0] == &id3[0])
+	require.False(t, &id3[0] == &p1.At(1).Rollup.ID[0])
+}
+
 func TestAggregationValues(t *testing.T) {
 	aggregationKeys := []aggregationKey{
 		{},
@@ -47,6 +47,10 @@ var (
 // FlushHandlerConfiguration configures flush handlers.
 type FlushHandlerConfiguration struct {
 	Handlers []flushHandlerConfiguration `yaml:"handlers" validate:"nonzero"`
+
+	// Replication, if set, treats each handler as a zone and replicates
+	// flushed data to all zones instead of broadcasting it.
+	Replication *replicationConfiguration `yaml:"replication"`
 }
 
 // NewHandler creates a new flush handler based on the configuration.
@@ -67,18 +71,30 @@ func (c FlushHandlerConfiguration) NewHandler(
 		}
 		handlers = append(handlers, handler)
 	}
+	if c.Replication != nil {
+		return NewReplicatedHandler(handlers, c.Replication.MinSuccessfulZones)
+	}
 	if len(handlers) == 1 {
 		return handlers[0], nil
 	}
 	return NewBroadcastHandler(handlers), nil
 }
 
+type replicationConfiguration struct {
+	// MinSuccessfulZones is the number of zones data must be written to
+	// before the write is considered successful.
+	MinSuccessfulZones int `yaml:"minSuccessfulZones" validate:"min=1"`
+}
+
 type writerConfiguration struct {
 	// Pool of buffered bytes.
 	BytesPool *pool.BucketizedPoolConfiguration `yaml:"bytesPool"`
 
 	// How frequent is the encoding time sampled and included in the payload.
 	EncodingTimeSamplingRate float64 `yaml:"encodingTimeSamplingRate" validate:"min=0.0,max=1.0"`
+
+	// Rewrites the ids of aggregated metrics before they are encoded.
+	OutputTransform *outputTransformConfiguration `yaml:"outputTransform"`
 }
 
 func (c writerConfiguration) NewWriterOptions(
@@ -96,9 +112,28 @@ func (c writerConfiguration) NewWriterOptions(
 		bytesPool.Init()
 		opts = opts.SetBytesPool(bytesPool)
 	}
+	if c.OutputTransform != nil {
+		opts = opts.SetOutputTransformFn(c.OutputTransform.NewOutputTransformFn())
+	}
 	return opts
 }
 
+type outputTransformConfiguration struct {
+	// Prefixes stripped from ids, only the first matching prefix is stripped.
+	StripPrefixes []string `yaml:"stripPrefixes"`
+
+	// Suffix appended to ids, e.g. static tags.
+	AppendSuffix string `yaml:"appendSuffix"`
+}
+
+func (c outputTransformConfiguration) NewOutputTransformFn() writer.OutputTransformFn {
+	stripPrefixes := make([][]byte, 0, len(c.StripPrefixes))
+	for _, prefix := range c.StripPrefixes {
+		stripPrefixes = append(stripPrefixes, []byte(prefix))
+	}
+	return writer.NewStaticOutputTransformFn(stripPrefixes, []byte(c.AppendSuffix))
+}
+
 type flushHandlerConfiguration struct {
 	// StaticBackend configures the backend.
 	StaticBackend *staticBackendConfiguration `yaml:"staticBackend"`
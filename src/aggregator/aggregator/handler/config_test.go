@@ -23,6 +23,8 @@ package handler
 import (
 	"testing"
 
+	"github.com/m3db/m3/src/x/instrument"
+
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -67,3 +69,30 @@ dynamicBackend:
 	require.Error(t, err)
 	require.Equal(t, errBothDynamicAndStaticBackendConfiguration, err)
 }
+
+func TestFlushHandlerConfigurationReplicated(t *testing.T) {
+	var cfg FlushHandlerConfiguration
+
+	str := `
+handlers:
+  - staticBackend:
+      type: blackhole
+  - staticBackend:
+      type: blackhole
+  - staticBackend:
+      type: blackhole
+replication:
+  minSuccessfulZones: 2
+`
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+	require.Equal(t, 2, cfg.Replication.MinSuccessfulZones)
+
+	h, err := cfg.NewHandler(nil, instrument.NewOptions())
+	require.NoError(t, err)
+	_, ok := h.(*replicatedHandler)
+	require.True(t, ok)
+
+	cfg.Replication.MinSuccessfulZones = 4
+	_, err = cfg.NewHandler(nil, instrument.NewOptions())
+	require.Equal(t, errInvalidMinSuccessfulZones, err)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/aggregator/aggregator/handler/writer"
+
+	"github.com/uber-go/tally"
+)
+
+var errInvalidMinSuccessfulZones = errors.New("min successful zones must be between 1 and the number of zones")
+
+type replicatedHandler struct {
+	handlers           []Handler
+	minSuccessfulZones int
+}
+
+// NewReplicatedHandler creates a new Handler that replicates incoming data
+// to a list of zone handlers, each with its own independent queue and retries,
+// and requires the data to land in at least minSuccessfulZones zones.
+func NewReplicatedHandler(handlers []Handler, minSuccessfulZones int) (Handler, error) {
+	if minSuccessfulZones <= 0 || minSuccessfulZones > len(handlers) {
+		return nil, errInvalidMinSuccessfulZones
+	}
+	return &replicatedHandler{
+		handlers:           handlers,
+		minSuccessfulZones: minSuccessfulZones,
+	}, nil
+}
+
+func (h *replicatedHandler) NewWriter(scope tally.Scope) (writer.Writer, error) {
+	writers := make([]writer.Writer, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		w, err := handler.NewWriter(scope)
+		if err != nil {
+			for _, created := range writers {
+				created.Close() // nolint: errcheck
+			}
+			return nil, err
+		}
+		writers = append(writers, w)
+	}
+	return writer.NewReplicatedWriter(writers, h.minSuccessfulZones, scope), nil
+}
+
+func (h *replicatedHandler) Close() {
+	for _, handler := range h.handlers {
+		handler.Close()
+	}
+}
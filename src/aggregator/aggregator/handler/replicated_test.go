@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/aggregator/aggregator/handler/writer"
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestNewReplicatedHandlerInvalidMinSuccessfulZones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handlers := []Handler{NewMockHandler(ctrl), NewMockHandler(ctrl)}
+	_, err := NewReplicatedHandler(handlers, 0)
+	require.Equal(t, errInvalidMinSuccessfulZones, err)
+	_, err = NewReplicatedHandler(handlers, 3)
+	require.Equal(t, errInvalidMinSuccessfulZones, err)
+}
+
+func TestReplicatedHandlerNewWriter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	data := aggregated.ChunkedMetricWithStoragePolicy{}
+
+	writer1 := writer.NewMockWriter(ctrl)
+	writer1.EXPECT().Write(data).Return(nil)
+	writer2 := writer.NewMockWriter(ctrl)
+	writer2.EXPECT().Write(data).Return(errors.New("write error"))
+
+	handler1 := NewMockHandler(ctrl)
+	handler1.EXPECT().NewWriter(tally.NoopScope).Return(writer1, nil)
+	handler2 := NewMockHandler(ctrl)
+	handler2.EXPECT().NewWriter(tally.NoopScope).Return(writer2, nil)
+
+	h, err := NewReplicatedHandler([]Handler{handler1, handler2}, 1)
+	require.NoError(t, err)
+	w, err := h.NewWriter(tally.NoopScope)
+	require.NoError(t, err)
+	require.NoError(t, w.Write(data))
+}
+
+func TestReplicatedHandlerNewWriterWithError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer1 := writer.NewMockWriter(ctrl)
+	writer1.EXPECT().Close().Return(nil)
+	handler1 := NewMockHandler(ctrl)
+	handler1.EXPECT().NewWriter(tally.NoopScope).Return(writer1, nil)
+	handler2 := NewMockHandler(ctrl)
+	handler2.EXPECT().NewWriter(tally.NoopScope).Return(nil, errors.New("new writer error"))
+
+	h, err := NewReplicatedHandler([]Handler{handler1, handler2}, 1)
+	require.NoError(t, err)
+	_, err = h.NewWriter(tally.NoopScope)
+	require.Error(t, err)
+}
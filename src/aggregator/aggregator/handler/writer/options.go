@@ -59,6 +59,14 @@ type Options interface {
 	// included in the encoded data. A value of 0 means the encoding time is never included,
 	// and a value of 1 means the encoding time is always included.
 	EncodingTimeSamplingRate() float64
+
+	// SetOutputTransformFn sets the function rewriting the ids of aggregated
+	// metrics before they are encoded, nil leaves ids unchanged.
+	SetOutputTransformFn(value OutputTransformFn) Options
+
+	// OutputTransformFn returns the function rewriting the ids of aggregated
+	// metrics before they are encoded.
+	OutputTransformFn() OutputTransformFn
 }
 
 type options struct {
@@ -66,6 +74,7 @@ type options struct {
 	instrumentOpts           instrument.Options
 	bytesPool                pool.BytesPool
 	encodingTimeSamplingRate float64
+	outputTransformFn        OutputTransformFn
 }
 
 // NewOptions provide a set of writer options.
@@ -116,3 +125,13 @@ func (o *options) SetEncodingTimeSamplingRate(value float64) Options {
 func (o *options) EncodingTimeSamplingRate() float64 {
 	return o.encodingTimeSamplingRate
 }
+
+func (o *options) SetOutputTransformFn(value OutputTransformFn) Options {
+	opts := *o
+	opts.outputTransformFn = value
+	return &opts
+}
+
+func (o *options) OutputTransformFn() OutputTransformFn {
+	return o.outputTransformFn
+}
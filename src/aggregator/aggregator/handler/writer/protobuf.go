@@ -70,12 +70,14 @@ type protobufWriter struct {
 
 	closed  bool
 	m       aggregated.MetricWithStoragePolicy
+	scratch []byte
 	rand    *rand.Rand
 	metrics protobufWriterMetrics
 
-	nowFn   clock.NowFn
-	randFn  randFn
-	shardFn sharding.ShardFn
+	nowFn             clock.NowFn
+	randFn            randFn
+	shardFn           sharding.ShardFn
+	outputTransformFn OutputTransformFn
 }
 
 // NewProtobufWriter creates a writer that encodes metric in protobuf.
@@ -96,12 +98,20 @@ func NewProtobufWriter(
 		metrics:                  newProtobufWriterMetrics(instrumentOpts.MetricsScope()),
 		nowFn:                    nowFn,
 		shardFn:                  shardFn,
+		outputTransformFn:        opts.OutputTransformFn(),
 	}
 	w.randFn = w.rand.Float64
 	return w
 }
 
 func (w *protobufWriter) Write(mp aggregated.ChunkedMetricWithStoragePolicy) error {
+	return w.WriteWithAck(mp, nil)
+}
+
+func (w *protobufWriter) WriteWithAck(
+	mp aggregated.ChunkedMetricWithStoragePolicy,
+	fn AckFn,
+) error {
 	if w.closed {
 		w.metrics.writerClosed.Inc(1)
 		return errWriterClosed
@@ -117,7 +127,7 @@ func (w *protobufWriter) Write(mp aggregated.ChunkedMetricWithStoragePolicy) err
 	}
 
 	w.metrics.encodeSuccess.Inc(1)
-	if err := w.p.Produce(newMessage(shard, mp.StoragePolicy, w.encoder.Buffer())); err != nil {
+	if err := w.p.Produce(newMessage(shard, mp.StoragePolicy, w.encoder.Buffer(), fn)); err != nil {
 		w.metrics.routeErrors.Inc(1)
 		return err
 	}
@@ -131,8 +141,14 @@ func (w *protobufWriter) prepare(mp aggregated.ChunkedMetricWithStoragePolicy) (
 	w.m.ID = append(w.m.ID, mp.Prefix...)
 	w.m.ID = append(w.m.ID, mp.Data...)
 	w.m.ID = append(w.m.ID, mp.Suffix...)
+	if w.outputTransformFn != nil {
+		// Swap the id and scratch buffers so both are reused across writes.
+		w.scratch = w.outputTransformFn(w.scratch[:0], w.m.ID, mp.StoragePolicy)
+		w.m.ID, w.scratch = w.scratch, w.m.ID
+	}
 	w.m.Metric.TimeNanos = mp.TimeNanos
 	w.m.Metric.Value = mp.Value
+	w.m.Metric.Annotation = mp.Annotation
 	w.m.StoragePolicy = mp.StoragePolicy
 	shard := w.shardFn(w.m.ID, w.numShards)
 	return w.m, shard
@@ -156,10 +172,16 @@ type message struct {
 	shard uint32
 	sp    policy.StoragePolicy
 	data  protobuf.Buffer
+	ackFn AckFn
 }
 
-func newMessage(shard uint32, sp policy.StoragePolicy, data protobuf.Buffer) producer.Message {
-	return message{shard: shard, sp: sp, data: data}
+func newMessage(
+	shard uint32,
+	sp policy.StoragePolicy,
+	data protobuf.Buffer,
+	ackFn AckFn,
+) producer.Message {
+	return message{shard: shard, sp: sp, data: data, ackFn: ackFn}
 }
 
 func (d message) Shard() uint32 {
@@ -179,8 +201,11 @@ func (d message) Size() int {
 	return cap(d.data.Bytes())
 }
 
-func (d message) Finalize(producer.FinalizeReason) {
+func (d message) Finalize(reason producer.FinalizeReason) {
 	d.data.Close()
+	if d.ackFn != nil {
+		d.ackFn(reason == producer.Consumed)
+	}
 }
 
 type storagePolicyFilter struct {
@@ -69,17 +69,19 @@ var (
 	}
 	testChunkedMetricWithStoragePolicy2 = aggregated.ChunkedMetricWithStoragePolicy{
 		ChunkedMetric: aggregated.ChunkedMetric{
-			ChunkedID: testChunkedID2,
-			TimeNanos: 1000,
-			Value:     987,
+			ChunkedID:  testChunkedID2,
+			TimeNanos:  1000,
+			Value:      987,
+			Annotation: []byte("traceID"),
 		},
 		StoragePolicy: policy.NewStoragePolicy(time.Minute, xtime.Minute, 24*time.Hour),
 	}
 	testMetricWithStoragePolicy2 = aggregated.MetricWithStoragePolicy{
 		Metric: aggregated.Metric{
-			ID:        testRawID2,
-			TimeNanos: 1000,
-			Value:     987,
+			ID:         testRawID2,
+			TimeNanos:  1000,
+			Value:      987,
+			Annotation: []byte("traceID"),
 		},
 		StoragePolicy: policy.NewStoragePolicy(time.Minute, xtime.Minute, 24*time.Hour),
 	}
@@ -94,8 +96,21 @@ func TestStoragePolicyFilter(t *testing.T) {
 	f := NewStoragePolicyFilter([]policy.StoragePolicy{sp2})
 
 	require.True(t, f(m2))
-	require.False(t, f(newMessage(0, sp1, protobuf.Buffer{})))
-	require.True(t, f(newMessage(0, sp2, protobuf.Buffer{})))
+	require.False(t, f(newMessage(0, sp1, protobuf.Buffer{}, nil)))
+	require.True(t, f(newMessage(0, sp2, protobuf.Buffer{}, nil)))
+}
+
+func TestMessageFinalizeAcks(t *testing.T) {
+	var acks []bool
+	ackFn := func(acked bool) { acks = append(acks, acked) }
+
+	newMessage(0, policy.StoragePolicy{}, protobuf.Buffer{}, ackFn).
+		Finalize(producer.Consumed)
+	newMessage(0, policy.StoragePolicy{}, protobuf.Buffer{}, ackFn).
+		Finalize(producer.Dropped)
+	newMessage(0, policy.StoragePolicy{}, protobuf.Buffer{}, nil).
+		Finalize(producer.Consumed)
+	require.Equal(t, []bool{true, false}, acks)
 }
 
 func TestProtobufWriterWriteClosed(t *testing.T) {
@@ -128,9 +143,10 @@ func TestProtobufWriterWrite(t *testing.T) {
 		actualData[s] = append(actualData[s], decodeData{
 			MetricWithStoragePolicy: aggregated.MetricWithStoragePolicy{
 				Metric: aggregated.Metric{
-					ID:        d.ID(),
-					TimeNanos: d.TimeNanos(),
-					Value:     d.Value(),
+					ID:         d.ID(),
+					TimeNanos:  d.TimeNanos(),
+					Value:      d.Value(),
+					Annotation: d.Annotation(),
 				},
 				StoragePolicy: sp,
 			},
@@ -198,6 +214,37 @@ func TestProtobufWriterWrite(t *testing.T) {
 
 }
 
+func TestProtobufWriterWriteOutputTransform(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := NewOptions().SetOutputTransformFn(
+		NewStaticOutputTransformFn([][]byte{[]byte("testPrefix.")}, []byte(";dc=east")))
+	writer := testProtobufWriter(t, ctrl, opts)
+
+	var actualIDs []string
+	writer.p.(*producer.MockProducer).EXPECT().Produce(gomock.Any()).Do(func(m producer.Message) error {
+		d := protobuf.NewAggregatedDecoder(nil)
+		require.NoError(t, d.Decode(m.Bytes()))
+		actualIDs = append(actualIDs, string(d.ID()))
+		return nil
+	}).Return(nil).Times(3)
+
+	for _, input := range []aggregated.ChunkedMetricWithStoragePolicy{
+		testChunkedMetricWithStoragePolicy,
+		testChunkedMetricWithStoragePolicy2,
+		testChunkedMetricWithStoragePolicy,
+	} {
+		require.NoError(t, writer.Write(input))
+	}
+	expectedIDs := []string{
+		"testData.testSuffix;dc=east",
+		"testPrefix2.testData2.testSuffix2;dc=east",
+		"testData.testSuffix;dc=east",
+	}
+	require.Equal(t, expectedIDs, actualIDs)
+}
+
 func testProtobufWriter(t *testing.T, ctrl *gomock.Controller, opts Options) *protobufWriter {
 	p := producer.NewMockProducer(ctrl)
 	p.EXPECT().NumShards().Return(uint32(1024))
@@ -0,0 +1,182 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/x/errors"
+
+	"github.com/uber-go/tally"
+)
+
+type replicatedWriterMetrics struct {
+	zoneWriteErrors      tally.Counter
+	writeUnderReplicated tally.Counter
+	writeLanded          tally.Counter
+	ackUnderReplicated   tally.Counter
+	zoneFlushErrors      tally.Counter
+	flushUnderReplicated tally.Counter
+}
+
+func newReplicatedWriterMetrics(scope tally.Scope) replicatedWriterMetrics {
+	writeScope := scope.SubScope("replicated-write")
+	flushScope := scope.SubScope("replicated-flush")
+	return replicatedWriterMetrics{
+		zoneWriteErrors:      writeScope.Counter("zone-errors"),
+		writeUnderReplicated: writeScope.Counter("under-replicated"),
+		writeLanded:          writeScope.Counter("landed"),
+		ackUnderReplicated:   writeScope.Counter("ack-under-replicated"),
+		zoneFlushErrors:      flushScope.Counter("zone-errors"),
+		flushUnderReplicated: flushScope.Counter("under-replicated"),
+	}
+}
+
+// replicatedWriter writes each metric to the writers of every zone and only
+// reports success if the metric was written to at least a minimum number of
+// zones. A failing zone does not prevent the metric from being written to the
+// other zones. Writes to zones are acked asynchronously, so a metric is only
+// counted as landed once it has been acked in the minimum number of zones.
+type replicatedWriter struct {
+	writers            []Writer
+	minSuccessfulZones int
+	metrics            replicatedWriterMetrics
+}
+
+// NewReplicatedWriter creates a new writer that replicates metrics to the
+// writers of each zone, requiring writes to succeed in at least
+// minSuccessfulZones zones.
+func NewReplicatedWriter(
+	writers []Writer,
+	minSuccessfulZones int,
+	scope tally.Scope,
+) Writer {
+	return &replicatedWriter{
+		writers:            writers,
+		minSuccessfulZones: minSuccessfulZones,
+		metrics:            newReplicatedWriterMetrics(scope),
+	}
+}
+
+func (w *replicatedWriter) Write(mp aggregated.ChunkedMetricWithStoragePolicy) error {
+	var (
+		multiErr   = errors.NewMultiError()
+		write      = newReplicatedWrite(w, len(w.writers))
+		successful int
+	)
+	for _, writer := range w.writers {
+		if err := write.writeZone(writer, mp); err != nil {
+			w.metrics.zoneWriteErrors.Inc(1)
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		successful++
+	}
+	if successful >= w.minSuccessfulZones {
+		return nil
+	}
+	w.metrics.writeUnderReplicated.Inc(1)
+	return newUnderReplicatedError("write", successful, w.minSuccessfulZones, multiErr.FinalError())
+}
+
+func (w *replicatedWriter) Flush() error {
+	var (
+		multiErr   = errors.NewMultiError()
+		successful int
+	)
+	for _, writer := range w.writers {
+		if err := writer.Flush(); err != nil {
+			w.metrics.zoneFlushErrors.Inc(1)
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		successful++
+	}
+	if successful >= w.minSuccessfulZones {
+		return nil
+	}
+	w.metrics.flushUnderReplicated.Inc(1)
+	return newUnderReplicatedError("flush", successful, w.minSuccessfulZones, multiErr.FinalError())
+}
+
+func (w *replicatedWriter) Close() error {
+	multiErr := errors.NewMultiError()
+	for _, writer := range w.writers {
+		if err := writer.Close(); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return multiErr.FinalError()
+}
+
+// replicatedWrite tracks the zones that have acked a metric.
+type replicatedWrite struct {
+	w       *replicatedWriter
+	pending int32
+	acked   int32
+}
+
+func newReplicatedWrite(w *replicatedWriter, numZones int) *replicatedWrite {
+	return &replicatedWrite{w: w, pending: int32(numZones)}
+}
+
+// writeZone writes the metric to the writer of a zone. Writers that are not
+// acked by their backend count as acked once written.
+func (rw *replicatedWrite) writeZone(
+	writer Writer,
+	mp aggregated.ChunkedMetricWithStoragePolicy,
+) error {
+	ackWriter, ok := writer.(AckWriter)
+	if !ok {
+		err := writer.Write(mp)
+		rw.zoneDone(err == nil)
+		return err
+	}
+
+	var done int32
+	err := ackWriter.WriteWithAck(mp, func(acked bool) {
+		if atomic.CompareAndSwapInt32(&done, 0, 1) {
+			rw.zoneDone(acked)
+		}
+	})
+	if err != nil && atomic.CompareAndSwapInt32(&done, 0, 1) {
+		rw.zoneDone(false)
+	}
+	return err
+}
+
+func (rw *replicatedWrite) zoneDone(acked bool) {
+	minZones := int32(rw.w.minSuccessfulZones)
+	if acked && atomic.AddInt32(&rw.acked, 1) == minZones {
+		rw.w.metrics.writeLanded.Inc(1)
+	}
+	if atomic.AddInt32(&rw.pending, -1) == 0 &&
+		atomic.LoadInt32(&rw.acked) < minZones {
+		rw.w.metrics.ackUnderReplicated.Inc(1)
+	}
+}
+
+func newUnderReplicatedError(op string, successful, required int, err error) error {
+	return fmt.Errorf("%s succeeded in %d zones, at least %d required: %v",
+		op, successful, required, err)
+}
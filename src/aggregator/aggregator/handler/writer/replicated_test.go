@@ -0,0 +1,154 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestReplicatedWriterWriteMinZonesSucceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer1 := NewMockWriter(ctrl)
+	writer1.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(nil)
+	writer2 := NewMockWriter(ctrl)
+	writer2.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(errors.New("write error"))
+	writer3 := NewMockWriter(ctrl)
+	writer3.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(nil)
+
+	scope := tally.NewTestScope("", nil)
+	w := NewReplicatedWriter([]Writer{writer1, writer2, writer3}, 2, scope)
+	require.NoError(t, w.Write(testChunkedMetricWithStoragePolicy))
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["replicated-write.zone-errors+"].Value())
+	require.Equal(t, int64(0), counters["replicated-write.under-replicated+"].Value())
+	require.Equal(t, int64(1), counters["replicated-write.landed+"].Value())
+}
+
+func TestReplicatedWriterWriteUnderReplicated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer1 := NewMockWriter(ctrl)
+	writer1.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(nil)
+	writer2 := NewMockWriter(ctrl)
+	writer2.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(errors.New("write error"))
+	writer3 := NewMockWriter(ctrl)
+	writer3.EXPECT().Write(testChunkedMetricWithStoragePolicy).Return(errors.New("write error"))
+
+	scope := tally.NewTestScope("", nil)
+	w := NewReplicatedWriter([]Writer{writer1, writer2, writer3}, 2, scope)
+	require.Error(t, w.Write(testChunkedMetricWithStoragePolicy))
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["replicated-write.zone-errors+"].Value())
+	require.Equal(t, int64(1), counters["replicated-write.under-replicated+"].Value())
+	require.Equal(t, int64(0), counters["replicated-write.landed+"].Value())
+	require.Equal(t, int64(1), counters["replicated-write.ack-under-replicated+"].Value())
+}
+
+type testAckWriter struct {
+	Writer
+
+	err  error
+	acks []AckFn
+}
+
+func (w *testAckWriter) WriteWithAck(
+	_ aggregated.ChunkedMetricWithStoragePolicy,
+	fn AckFn,
+) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.acks = append(w.acks, fn)
+	return nil
+}
+
+func TestReplicatedWriterWriteLandsOnAck(t *testing.T) {
+	var (
+		writer1 = &testAckWriter{}
+		writer2 = &testAckWriter{}
+		writer3 = &testAckWriter{}
+		scope   = tally.NewTestScope("", nil)
+		w       = NewReplicatedWriter([]Writer{writer1, writer2, writer3}, 2, scope)
+	)
+	landed := func() int64 {
+		return scope.Snapshot().Counters()["replicated-write.landed+"].Value()
+	}
+	ackUnderReplicated := func() int64 {
+		return scope.Snapshot().Counters()["replicated-write.ack-under-replicated+"].Value()
+	}
+
+	// Written to all zones but not yet acked.
+	require.NoError(t, w.Write(testChunkedMetricWithStoragePolicy))
+	require.Equal(t, int64(0), landed())
+
+	writer1.acks[0](true)
+	writer1.acks[0](true)
+	require.Equal(t, int64(0), landed())
+	writer2.acks[0](true)
+	require.Equal(t, int64(1), landed())
+	writer3.acks[0](false)
+	require.Equal(t, int64(1), landed())
+	require.Equal(t, int64(0), ackUnderReplicated())
+
+	// Dropped in all but one zone.
+	require.NoError(t, w.Write(testChunkedMetricWithStoragePolicy))
+	writer1.acks[1](true)
+	writer2.acks[1](false)
+	writer3.acks[1](false)
+	require.Equal(t, int64(1), landed())
+	require.Equal(t, int64(1), ackUnderReplicated())
+
+	// Failing to write to a zone counts as it not acking.
+	writer3.err = errors.New("write error")
+	require.NoError(t, w.Write(testChunkedMetricWithStoragePolicy))
+	writer1.acks[2](true)
+	writer2.acks[2](false)
+	require.Equal(t, int64(1), landed())
+	require.Equal(t, int64(2), ackUnderReplicated())
+}
+
+func TestReplicatedWriterFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer1 := NewMockWriter(ctrl)
+	writer1.EXPECT().Flush().Return(nil).Times(2)
+	writer2 := NewMockWriter(ctrl)
+	writer2.EXPECT().Flush().Return(errors.New("flush error")).Times(2)
+
+	w := NewReplicatedWriter([]Writer{writer1, writer2}, 1, tally.NoopScope)
+	require.NoError(t, w.Flush())
+
+	w = NewReplicatedWriter([]Writer{writer1, writer2}, 2, tally.NoopScope)
+	require.Error(t, w.Flush())
+}
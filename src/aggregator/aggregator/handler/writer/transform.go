@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+
+	"github.com/m3db/m3/src/metrics/policy"
+)
+
+// OutputTransformFn rewrites the id of an aggregated metric before it is
+// encoded, appending the rewritten id to dst and returning the resulting
+// slice. The buffers passed in are owned by the writer and reused across
+// writes, so transforms must not retain them and need not allocate.
+type OutputTransformFn func(dst []byte, id []byte, sp policy.StoragePolicy) []byte
+
+// NewStaticOutputTransformFn returns a transform that strips the first of the
+// given prefixes the id starts with, e.g. a tenant prefix, and then appends
+// the given suffix to the id, e.g. static tags.
+func NewStaticOutputTransformFn(
+	stripPrefixes [][]byte,
+	suffix []byte,
+) OutputTransformFn {
+	return func(dst []byte, id []byte, _ policy.StoragePolicy) []byte {
+		for _, prefix := range stripPrefixes {
+			if bytes.HasPrefix(id, prefix) {
+				id = id[len(prefix):]
+				break
+			}
+		}
+		dst = append(dst, id...)
+		return append(dst, suffix...)
+	}
+}
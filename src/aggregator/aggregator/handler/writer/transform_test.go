@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/metrics/policy"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticOutputTransformFn(t *testing.T) {
+	var (
+		sp = policy.NewStoragePolicy(10*time.Second, xtime.Second, 6*time.Hour)
+		fn = NewStaticOutputTransformFn(
+			[][]byte{[]byte("tenant1."), []byte("tenant2.")},
+			[]byte(";dc=east"),
+		)
+	)
+	inputs := []struct {
+		id       string
+		expected string
+	}{
+		{id: "tenant1.foo", expected: "foo;dc=east"},
+		{id: "tenant2.foo", expected: "foo;dc=east"},
+		{id: "tenant3.foo", expected: "tenant3.foo;dc=east"},
+		{id: "tenant1.tenant2.foo", expected: "tenant2.foo;dc=east"},
+	}
+	dst := make([]byte, 0, 64)
+	for _, input := range inputs {
+		dst = fn(dst[:0], []byte(input.id), sp)
+		require.Equal(t, input.expected, string(dst))
+	}
+}
@@ -33,3 +33,18 @@ type Writer interface {
 	// Close closes the writer.
 	Close() error
 }
+
+// AckFn is called once a metric has been acked by the backend, or with
+// false if it was dropped before being acked.
+type AckFn func(acked bool)
+
+// AckWriter is a writer that can notify when the metrics it writes are
+// acked by the backend.
+type AckWriter interface {
+	Writer
+
+	// WriteWithAck writes an aggregated metric alongside its storage policy,
+	// calling the given function once it is acked or dropped. The function
+	// is not called if an error is returned.
+	WriteWithAck(mp aggregated.ChunkedMetricWithStoragePolicy, fn AckFn) error
+}
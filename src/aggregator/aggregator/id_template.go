@@ -0,0 +1,174 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"fmt"
+	"strings"
+
+	metricid "github.com/m3db/m3/src/metrics/metric/id"
+)
+
+const (
+	idTemplatePrefixVar = "{prefix}"
+	idTemplateIDVar     = "{id}"
+	idTemplateSuffixVar = "{suffix}"
+)
+
+type idTemplatePartType int
+
+const (
+	literalIDTemplatePart idTemplatePartType = iota
+	prefixIDTemplatePart
+	suffixIDTemplatePart
+)
+
+type idTemplatePart struct {
+	partType idTemplatePartType
+	literal  []byte
+}
+
+type idTemplateParts []idTemplatePart
+
+// expand expands the template parts with the given id prefix and suffix,
+// returning the id prefix or suffix as is if it's the only part.
+func (p idTemplateParts) expand(idPrefix, idSuffix []byte) []byte {
+	if len(p) == 0 {
+		return nil
+	}
+	if len(p) == 1 {
+		return p[0].bytes(idPrefix, idSuffix)
+	}
+	size := 0
+	for _, part := range p {
+		size += len(part.bytes(idPrefix, idSuffix))
+	}
+	b := make([]byte, 0, size)
+	for _, part := range p {
+		b = append(b, part.bytes(idPrefix, idSuffix)...)
+	}
+	return b
+}
+
+func (p idTemplatePart) bytes(idPrefix, idSuffix []byte) []byte {
+	switch p.partType {
+	case prefixIDTemplatePart:
+		return idPrefix
+	case suffixIDTemplatePart:
+		return idSuffix
+	default:
+		return p.literal
+	}
+}
+
+// IDTemplate is a template for the ids of aggregated metrics flushed locally.
+// The {prefix}, {id} and {suffix} variables in a template are substituted
+// with the id prefix determined by the metric type, the metric id and the
+// id suffix determined by the aggregation type respectively, and any other
+// text is kept as is. For instance, the template "dc1.{prefix}{id}" adds a
+// datacenter prefix and drops the aggregation type suffix, and the template
+// "{prefix}{id};aggregation={suffix}" places the aggregation type in a tag.
+// A nil template assembles ids as "{prefix}{id}{suffix}".
+type IDTemplate struct {
+	str    string
+	prefix idTemplateParts
+	suffix idTemplateParts
+}
+
+// NewIDTemplate parses an id template, which must contain the {id}
+// variable exactly once.
+func NewIDTemplate(str string) (*IDTemplate, error) {
+	if strings.Count(str, idTemplateIDVar) != 1 {
+		return nil, fmt.Errorf("id template %s must contain %s exactly once", str, idTemplateIDVar)
+	}
+	idx := strings.Index(str, idTemplateIDVar)
+	prefix, err := parseIDTemplateParts(str[:idx])
+	if err != nil {
+		return nil, fmt.Errorf("invalid id template %s: %v", str, err)
+	}
+	suffix, err := parseIDTemplateParts(str[idx+len(idTemplateIDVar):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid id template %s: %v", str, err)
+	}
+	return &IDTemplate{
+		str:    str,
+		prefix: prefix,
+		suffix: suffix,
+	}, nil
+}
+
+// ChunkedID returns the chunked id of an aggregated metric given the id prefix,
+// the metric id and the id suffix.
+func (t *IDTemplate) ChunkedID(
+	idPrefix []byte,
+	id metricid.RawID,
+	idSuffix []byte,
+) metricid.ChunkedID {
+	if t == nil {
+		return metricid.ChunkedID{
+			Prefix: idPrefix,
+			Data:   []byte(id),
+			Suffix: idSuffix,
+		}
+	}
+	return metricid.ChunkedID{
+		Prefix: t.prefix.expand(idPrefix, idSuffix),
+		Data:   []byte(id),
+		Suffix: t.suffix.expand(idPrefix, idSuffix),
+	}
+}
+
+// String returns the string representation of the template.
+func (t *IDTemplate) String() string {
+	if t == nil {
+		return idTemplatePrefixVar + idTemplateIDVar + idTemplateSuffixVar
+	}
+	return t.str
+}
+
+func parseIDTemplateParts(str string) (idTemplateParts, error) {
+	var parts idTemplateParts
+	for len(str) > 0 {
+		start := strings.IndexByte(str, '{')
+		if start < 0 {
+			parts = append(parts, idTemplatePart{literal: []byte(str)})
+			break
+		}
+		if start > 0 {
+			parts = append(parts, idTemplatePart{literal: []byte(str[:start])})
+			str = str[start:]
+		}
+		end := strings.IndexByte(str, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated variable %s", str)
+		}
+		switch variable := str[:end+1]; variable {
+		case idTemplatePrefixVar:
+			parts = append(parts, idTemplatePart{partType: prefixIDTemplatePart})
+		case idTemplateSuffixVar:
+			parts = append(parts, idTemplatePart{partType: suffixIDTemplatePart})
+		default:
+			return nil, fmt.Errorf("unknown variable %s", variable)
+		}
+		str = str[end+1:]
+	}
+	return parts, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"testing"
+
+	metricid "github.com/m3db/m3/src/metrics/metric/id"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDTemplateChunkedID(t *testing.T) {
+	var (
+		idPrefix = []byte("stats.counts.")
+		id       = metricid.RawID("foo")
+		idSuffix = []byte(".sum")
+	)
+	inputs := []struct {
+		template string
+		expected metricid.ChunkedID
+	}{
+		{
+			template: "{prefix}{id}{suffix}",
+			expected: metricid.ChunkedID{Prefix: idPrefix, Data: id, Suffix: idSuffix},
+		},
+		{
+			template: "dc1.{prefix}{id}{suffix}",
+			expected: metricid.ChunkedID{Prefix: []byte("dc1.stats.counts."), Data: id, Suffix: idSuffix},
+		},
+		{
+			template: "{id};aggregation={suffix}",
+			expected: metricid.ChunkedID{Data: id, Suffix: []byte(";aggregation=.sum")},
+		},
+		{
+			template: "{id}",
+			expected: metricid.ChunkedID{Data: id},
+		},
+	}
+	for _, input := range inputs {
+		template, err := NewIDTemplate(input.template)
+		require.NoError(t, err)
+		require.Equal(t, input.template, template.String())
+		require.Equal(t, input.expected, template.ChunkedID(idPrefix, id, idSuffix))
+	}
+}
+
+func TestIDTemplateNilChunkedID(t *testing.T) {
+	var template *IDTemplate
+	chunkedID := template.ChunkedID([]byte("prefix."), metricid.RawID("foo"), []byte(".suffix"))
+	require.Equal(t, metricid.ChunkedID{
+		Prefix: []byte("prefix."),
+		Data:   []byte("foo"),
+		Suffix: []byte(".suffix"),
+	}, chunkedID)
+	require.Equal(t, "{prefix}{id}{suffix}", template.String())
+}
+
+func TestNewIDTemplateInvalid(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"{prefix}{suffix}",
+		"{id}{id}",
+		"{prefix}{id}{unknown}",
+		"{prefix{id}",
+		"{id}{suffix",
+	} {
+		_, err := NewIDTemplate(input)
+		require.Error(t, err, input)
+	}
+}
@@ -24,16 +24,20 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/aggregator/aggregator/handler"
 	"github.com/m3db/m3/src/aggregator/aggregator/handler/writer"
+	"github.com/m3db/m3/src/aggregator/verify"
 	"github.com/m3db/m3/src/metrics/metric/aggregated"
 	metricid "github.com/m3db/m3/src/metrics/metric/id"
+	"github.com/m3db/m3/src/metrics/metric/id/m3"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/x/clock"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
@@ -42,6 +46,11 @@ import (
 var (
 	errListClosed  = errors.New("metric list is closed")
 	errListsClosed = errors.New("metric lists are closed")
+
+	heartbeatInstanceTag   = []byte("instance")
+	heartbeatShardTag      = []byte("shard")
+	heartbeatResolutionTag = []byte("resolution")
+	heartbeatListTypeTag   = []byte("list_type")
 )
 
 type metricList interface {
@@ -116,11 +125,15 @@ type baseMetricListMetrics struct {
 	flushBeforeStale            tally.Counter
 	flushBeforeDuration         tally.Timer
 	discardBefore               tally.Counter
+	ingestToFlushLatency        tally.Histogram
+	heartbeatSuccess            tally.Counter
+	heartbeatErrors             tally.Counter
 }
 
-func newMetricListMetrics(scope tally.Scope) baseMetricListMetrics {
+func newMetricListMetrics(scope tally.Scope, resolution time.Duration) baseMetricListMetrics {
 	flushScope := scope.SubScope("flush")
 	flushBeforeScope := scope.SubScope("flush-before")
+	heartbeatScope := flushScope.SubScope("heartbeat")
 	flushLocalScope := flushScope.Tagged(map[string]string{"flush-type": "local"})
 	flushLocalWriterScope := flushLocalScope.SubScope("writer")
 	flushForwardedScope := flushScope.Tagged(map[string]string{"flush-type": "forwarded"})
@@ -139,9 +152,22 @@ func newMetricListMetrics(scope tally.Scope) baseMetricListMetrics {
 		flushBeforeStale:            flushBeforeScope.Counter("stale"),
 		flushBeforeDuration:         flushBeforeScope.Timer("duration"),
 		discardBefore:               scope.Counter("discard-before"),
+		ingestToFlushLatency: flushScope.Histogram("ingest-to-flush-latency",
+			newIngestToFlushLatencyBuckets(resolution)),
+		heartbeatSuccess: heartbeatScope.Counter("success"),
+		heartbeatErrors:  heartbeatScope.Counter("errors"),
 	}
 }
 
+// newIngestToFlushLatencyBuckets returns the buckets of the ingest to flush
+// latency histogram, which scale with the resolution since an aggregation
+// window is flushed no earlier than a resolution after it starts.
+func newIngestToFlushLatencyBuckets(resolution time.Duration) tally.Buckets {
+	maxLatencyBucketLimit := resolution * ingestToFlushLatencyBucketLimitScaleFactor
+	latencyBucketSize := maxLatencyBucketLimit / time.Duration(numLatencyBuckets)
+	return tally.MustMakeLinearDurationBuckets(0, latencyBucketSize, numLatencyBuckets)
+}
+
 // targetNanosFn computes the target timestamp in nanoseconds from the current
 // time. This in combination with isEarlierThanFn is used to determine the set
 // of aggregation windows that are eligible for flushing.
@@ -166,6 +192,11 @@ type baseMetricList struct {
 	targetNanosFn    targetNanosFn
 	isEarlierThanFn  isEarlierThanFn
 	timestampNanosFn timestampNanosFn
+	idTemplate       *IDTemplate
+	shadowChecksums  *verify.WindowChecksums
+	windowChecksums  *verify.WindowChecksums
+	heartbeatID      []byte
+	heartbeatPolicy  policy.StoragePolicy
 
 	closed           bool
 	aggregations     *list.List
@@ -184,6 +215,7 @@ type baseMetricList struct {
 
 func newBaseMetricList(
 	shard uint32,
+	listType metricListType,
 	resolution time.Duration,
 	targetNanosFn targetNanosFn,
 	isEarlierThanFn isEarlierThanFn,
@@ -213,8 +245,11 @@ func newBaseMetricList(
 		targetNanosFn:    targetNanosFn,
 		isEarlierThanFn:  isEarlierThanFn,
 		timestampNanosFn: timestampNanosFn,
+		idTemplate:       opts.IDTemplate(),
+		shadowChecksums:  opts.ShadowChecksums(),
+		windowChecksums:  opts.WindowChecksums(),
 		aggregations:     list.New(),
-		metrics:          newMetricListMetrics(scope),
+		metrics:          newMetricListMetrics(scope, resolution),
 	}
 	l.flushBeforeFn = l.flushBefore
 	l.consumeLocalMetricFn = l.consumeLocalMetric
@@ -223,10 +258,46 @@ func newBaseMetricList(
 	l.discardForwardedMetricFn = l.discardForwardedMetric
 	l.onForwardingElemConsumedFn = l.onForwardingElemConsumed
 	l.onForwardingElemDiscardedFn = l.onForwardingElemDiscarded
+	if name := opts.HeartbeatName(); name != nil {
+		l.heartbeatID = newHeartbeatID(name, shard, listType, resolution, opts)
+		l.heartbeatPolicy = newHeartbeatPolicy(resolution, opts.HeartbeatRetention())
+	}
 
 	return l, nil
 }
 
+// newHeartbeatID returns the id of the heartbeat series of a metric list,
+// tagged with the instance, shard, list type and resolution of the list so
+// missing data downstream can be attributed to the list that should have
+// produced it. The id is encoded the same way as rollup ids so it can be decoded by
+// the same consumers as the rest of the flushed output.
+func newHeartbeatID(
+	name []byte,
+	shard uint32,
+	listType metricListType,
+	resolution time.Duration,
+	opts Options,
+) []byte {
+	var instanceID string
+	if placementManager := opts.PlacementManager(); placementManager != nil {
+		instanceID = placementManager.InstanceID()
+	}
+	return m3.NewRollupID(name, []metricid.TagPair{
+		{Name: heartbeatInstanceTag, Value: []byte(instanceID)},
+		{Name: heartbeatShardTag, Value: []byte(strconv.FormatUint(uint64(shard), 10))},
+		{Name: heartbeatListTypeTag, Value: []byte(listType.String())},
+		{Name: heartbeatResolutionTag, Value: []byte(resolution.String())},
+	})
+}
+
+func newHeartbeatPolicy(resolution, retention time.Duration) policy.StoragePolicy {
+	precision, err := xtime.UnitFromDuration(resolution)
+	if err != nil {
+		precision = xtime.Second
+	}
+	return policy.NewStoragePolicy(resolution, precision, retention)
+}
+
 func (l *baseMetricList) Shard() uint32                { return l.shard }
 func (l *baseMetricList) Resolution() time.Duration    { return l.resolution }
 func (l *baseMetricList) FlushInterval() time.Duration { return l.resolution }
@@ -261,6 +332,7 @@ func (l *baseMetricList) PushBack(value metricElem) (*list.Element, error) {
 		return nil, errListClosed
 	}
 	elem := l.aggregations.PushBack(value)
+	value.SetIngestToFlushLatency(l.metrics.ingestToFlushLatency)
 	if !hasForwardedID {
 		l.Unlock()
 		return elem, nil
@@ -391,6 +463,8 @@ func (l *baseMetricList) flushBefore(beforeNanos int64, flushType flushType) {
 	l.RUnlock()
 
 	if flushType == consumeType {
+		l.writeHeartbeat(beforeNanos)
+
 		// Flush remaining bytes buffered in the local writer.
 		if err := l.localWriter.Flush(); err != nil {
 			l.metrics.flushLocalWriter.flushErrors.Inc(1)
@@ -439,11 +513,7 @@ func (l *baseMetricList) consumeLocalMetric(
 	value float64,
 	sp policy.StoragePolicy,
 ) {
-	chunkedID := metricid.ChunkedID{
-		Prefix: idPrefix,
-		Data:   []byte(id),
-		Suffix: idSuffix,
-	}
+	chunkedID := l.idTemplate.ChunkedID(idPrefix, id, idSuffix)
 	chunkedMetricWithPolicy := aggregated.ChunkedMetricWithStoragePolicy{
 		ChunkedMetric: aggregated.ChunkedMetric{
 			ChunkedID: chunkedID,
@@ -456,6 +526,34 @@ func (l *baseMetricList) consumeLocalMetric(
 		l.metrics.flushLocal.metricConsumeErrors.Inc(1)
 	} else {
 		l.metrics.flushLocal.metricConsumeSuccess.Inc(1)
+		// NB: the metrics of standard and timed lists are flushed with the
+		// ID they were written with, forwarded lists do not checksum shadowed
+		// metrics since their IDs are rollups of many source IDs.
+		l.shadowChecksums.AddChunked(id, chunkedMetricWithPolicy)
+		l.windowChecksums.AddChunked(id, chunkedMetricWithPolicy)
+	}
+}
+
+// writeHeartbeat writes the heartbeat series of the list to the local writer
+// if heartbeats are enabled.
+func (l *baseMetricList) writeHeartbeat(beforeNanos int64) {
+	if l.heartbeatID == nil {
+		return
+	}
+	resolution := int64(l.resolution)
+	heartbeat := aggregated.ChunkedMetricWithStoragePolicy{
+		ChunkedMetric: aggregated.ChunkedMetric{
+			ChunkedID: metricid.ChunkedID{Data: l.heartbeatID},
+			TimeNanos: beforeNanos - beforeNanos%resolution,
+			Value:     1,
+		},
+		StoragePolicy: l.heartbeatPolicy,
+	}
+	if err := l.localWriter.Write(heartbeat); err != nil {
+		l.metrics.heartbeatErrors.Inc(1)
+	} else {
+		l.metrics.heartbeatSuccess.Inc(1)
+		l.windowChecksums.AddChunked(l.heartbeatID, heartbeat)
 	}
 }
 
@@ -557,6 +655,7 @@ func newStandardMetricList(
 	listScope := iOpts.MetricsScope().Tagged(map[string]string{"list-type": "standard"})
 	l, err := newBaseMetricList(
 		shard,
+		standardMetricListType,
 		id.resolution,
 		standardMetricTargetNanos,
 		isStandardMetricEarlierThan,
@@ -645,13 +744,18 @@ func newForwardedMetricList(
 	targetNanosFn := func(nowNanos int64) int64 {
 		return nowNanos - maxLatenessAllowed.Nanoseconds()
 	}
+	// NB: rollups flushed by forwarded lists aggregate many source IDs, only
+	// some of which are shadowed, so they are not checksummed for comparison
+	// with a shadow cluster.
 	l, err := newBaseMetricList(
 		shard,
+		forwardedMetricListType,
 		resolution,
 		targetNanosFn,
 		isForwardedMetricEarlierThan,
 		forwardedMetricTimestampNanos,
-		opts.SetInstrumentOptions(iOpts.SetMetricsScope(listScope)),
+		opts.SetInstrumentOptions(iOpts.SetMetricsScope(listScope)).
+			SetShadowChecksums(nil),
 	)
 	if err != nil {
 		return nil, err
@@ -724,6 +828,7 @@ func newTimedMetricList(
 	}
 	l, err := newBaseMetricList(
 		shard,
+		timedMetricListType,
 		resolution,
 		targetNanosFn,
 		isStandardMetricEarlierThan,
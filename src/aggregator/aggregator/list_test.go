@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/aggregator/aggregator/handler"
 	"github.com/m3db/m3/src/aggregator/aggregator/handler/writer"
 	"github.com/m3db/m3/src/aggregator/client"
+	"github.com/m3db/m3/src/aggregator/verify"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
@@ -41,6 +42,7 @@ import (
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/x/clock"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -50,7 +52,7 @@ func TestBaseMetricListPushBackElemWithDefaultPipeline(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	l, err := newBaseMetricList(testShard, time.Second, nil, nil, nil, testOptions(ctrl))
+	l, err := newBaseMetricList(testShard, standardMetricListType, time.Second, nil, nil, nil, testOptions(ctrl))
 	require.NoError(t, err)
 	elem, err := NewCounterElem(nil, policy.EmptyStoragePolicy, aggregation.DefaultTypes, applied.DefaultPipeline, 0, NoPrefixNoSuffix, l.opts)
 	require.NoError(t, err)
@@ -76,7 +78,7 @@ func TestBaseMetricListPushBackElemWithForwardingPipeline(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	l, err := newBaseMetricList(testShard, time.Second, nil, nil, nil, testOptions(ctrl))
+	l, err := newBaseMetricList(testShard, standardMetricListType, time.Second, nil, nil, nil, testOptions(ctrl))
 	require.NoError(t, err)
 	elem, err := NewCounterElem(nil, policy.EmptyStoragePolicy, aggregation.DefaultTypes, testPipeline, 0, NoPrefixNoSuffix, l.opts)
 	require.NoError(t, err)
@@ -95,7 +97,7 @@ func TestBaseMetricListClose(t *testing.T) {
 	defer ctrl.Finish()
 
 	opts := testOptions(ctrl)
-	l, err := newBaseMetricList(testShard, time.Second, nil, nil, nil, opts)
+	l, err := newBaseMetricList(testShard, standardMetricListType, time.Second, nil, nil, nil, opts)
 	require.NoError(t, err)
 
 	l.RLock()
@@ -123,7 +125,7 @@ func TestBaseMetricListFlushWithRequests(t *testing.T) {
 		results          []flushBeforeResult
 	)
 	opts := testOptions(ctrl).SetClockOptions(clock.NewOptions().SetNowFn(nowFn))
-	l, err := newBaseMetricList(testShard, time.Second, targetNanosFn, isEarlierThanFn, timestampNanosFn, opts)
+	l, err := newBaseMetricList(testShard, standardMetricListType, time.Second, targetNanosFn, isEarlierThanFn, timestampNanosFn, opts)
 	require.NoError(t, err)
 	l.flushBeforeFn = func(beforeNanos int64, flushType flushType) {
 		results = append(results, flushBeforeResult{
@@ -235,13 +237,84 @@ func TestBaseMetricListFlushBeforeStale(t *testing.T) {
 		timestampNanosFn = standardMetricTimestampNanos
 		opts             = testOptions(ctrl)
 	)
-	l, err := newBaseMetricList(testShard, 0, targetNanosFn, isEarlierThanFn, timestampNanosFn, opts)
+	l, err := newBaseMetricList(testShard, standardMetricListType, 0, targetNanosFn, isEarlierThanFn, timestampNanosFn, opts)
 	require.NoError(t, err)
 	l.lastFlushedNanos = 1234
 	l.flushBefore(1000, discardType)
 	require.Equal(t, int64(1234), l.LastFlushedNanos())
 }
 
+func TestBaseMetricListFlushWritesHeartbeat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var flushed []aggregated.ChunkedMetricWithStoragePolicy
+	w := writer.NewMockWriter(ctrl)
+	w.EXPECT().Write(gomock.Any()).DoAndReturn(func(mp aggregated.ChunkedMetricWithStoragePolicy) error {
+		flushed = append(flushed, mp)
+		return nil
+	}).AnyTimes()
+	w.EXPECT().Flush().Return(nil).AnyTimes()
+	handler := handler.NewMockHandler(ctrl)
+	handler.EXPECT().NewWriter(gomock.Any()).Return(w, nil).AnyTimes()
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().InstanceID().Return("localhost:6000").AnyTimes()
+
+	var (
+		resolution       = 10 * time.Second
+		targetNanosFn    = standardMetricTargetNanos
+		isEarlierThanFn  = isStandardMetricEarlierThan
+		timestampNanosFn = standardMetricTimestampNanos
+	)
+	windowChecksums, err := verify.NewWindowChecksums(10)
+	require.NoError(t, err)
+	opts := testOptions(ctrl).
+		SetFlushHandler(handler).
+		SetPlacementManager(placementManager).
+		SetHeartbeatName([]byte("aggregator.heartbeat")).
+		SetHeartbeatRetention(48 * time.Hour).
+		SetWindowChecksums(windowChecksums)
+	l, err := newBaseMetricList(testShard, standardMetricListType, resolution, targetNanosFn, isEarlierThanFn, timestampNanosFn, opts)
+	require.NoError(t, err)
+
+	// Discarding does not write a heartbeat.
+	l.flushBefore(time.Unix(100, 0).UnixNano(), discardType)
+	require.Equal(t, 0, len(flushed))
+
+	l.flushBefore(time.Unix(125, 0).UnixNano(), consumeType)
+	require.Equal(t, 1, len(flushed))
+	expectedID := "m3+aggregator.heartbeat+instance=localhost:6000,list_type=standard,m3_rollup=true,resolution=10s,shard=0"
+	require.Equal(t, expectedID, flushed[0].ChunkedID.String())
+	require.Equal(t, time.Unix(120, 0).UnixNano(), flushed[0].TimeNanos)
+	require.Equal(t, 1.0, flushed[0].Value)
+	require.Equal(t, policy.NewStoragePolicy(resolution, xtime.Second, 48*time.Hour), flushed[0].StoragePolicy)
+
+	// The heartbeat is included in the window checksums of the flushed output.
+	checksums := windowChecksums.Checksums()
+	require.Equal(t, 1, len(checksums))
+	require.Equal(t, flushed[0].TimeNanos, checksums[0].TimeNanos)
+	require.Equal(t, int64(1), checksums[0].Count)
+}
+
+func TestNewHeartbeatIDDistinctPerListType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().InstanceID().Return("localhost:6000").AnyTimes()
+	opts := testOptions(ctrl).SetPlacementManager(placementManager)
+	ids := make(map[string]struct{})
+	for _, listType := range []metricListType{
+		standardMetricListType,
+		forwardedMetricListType,
+		timedMetricListType,
+	} {
+		id := newHeartbeatID([]byte("aggregator.heartbeat"), testShard, listType, 10*time.Second, opts)
+		ids[string(id)] = struct{}{}
+	}
+	require.Equal(t, 3, len(ids))
+}
+
 func TestStandardMetricListID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -739,6 +812,28 @@ func TestForwardedMetricListFlushOffset(t *testing.T) {
 	require.Equal(t, 2*time.Second, l.FlushOffset())
 }
 
+func TestMetricListsShadowChecksums(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shadowChecksums, err := verify.NewSampledWindowChecksums(1, 10)
+	require.NoError(t, err)
+	resolution := 10 * time.Second
+	opts := testOptions(ctrl).SetShadowChecksums(shadowChecksums)
+
+	// Standard metrics are flushed with the ID they were written with so
+	// they are checksummed, forwarded rollups are not.
+	standard, err := newStandardMetricList(testShard,
+		standardMetricListID{resolution: resolution}, opts)
+	require.NoError(t, err)
+	require.Equal(t, shadowChecksums, standard.shadowChecksums)
+
+	forwarded, err := newForwardedMetricList(testShard,
+		forwardedMetricListID{resolution: resolution, numForwardedTimes: testNumForwardedTimes}, opts)
+	require.NoError(t, err)
+	require.Nil(t, forwarded.shadowChecksums)
+}
+
 func TestForwardedMetricListFlushConsumingAndCollectingForwardedMetrics(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -25,11 +25,13 @@ import (
 	"errors"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/aggregator/hash"
 	"github.com/m3db/m3/src/aggregator/rate"
 	"github.com/m3db/m3/src/aggregator/runtime"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/aggregated"
@@ -43,6 +45,10 @@ import (
 const (
 	defaultSoftDeadlineCheckEvery = 128
 	defaultExpireBatchSize        = 1024
+
+	// Size of a cache line, used to pad the map shards so the locks of
+	// adjacent shards are not on the same cache line.
+	cacheLineSize = 64
 )
 
 var (
@@ -77,6 +83,10 @@ type metricMapMetrics struct {
 	noRateLimitWarmup          tally.Counter
 	newMetricRateLimitExceeded tally.Counter
 	droppedNewMetrics          tally.Counter
+	insertLockWait             tally.Timer
+	insertRaces                tally.Counter
+	shardEntriesMax            tally.Gauge
+	shardEntriesMin            tally.Gauge
 }
 
 func newMetricMapMetrics(scope tally.Scope) metricMapMetrics {
@@ -85,11 +95,38 @@ func newMetricMapMetrics(scope tally.Scope) metricMapMetrics {
 		noRateLimitWarmup:          scope.Counter("no-rate-limit-warmup"),
 		newMetricRateLimitExceeded: scope.Counter("new-metric-rate-limit-exceeded"),
 		droppedNewMetrics:          scope.Counter("dropped-new-metrics"),
+		insertLockWait:             scope.Timer("insert-lock-wait"),
+		insertRaces:                scope.Counter("insert-races"),
+		shardEntriesMax:            scope.Gauge("shard-entries-max"),
+		shardEntriesMin:            scope.Gauge("shard-entries-min"),
 	}
 }
 
+// metricMapShard holds the entries of a metric map whose id hashes map to the
+// shard, so that concurrent lookups of different ids do not contend on a
+// single lock.
+type metricMapShard struct {
+	sync.RWMutex
+
+	entries map[entryKey]*list.Element
+
+	_ [cacheLineSize]byte
+}
+
+func (s *metricMapShard) lookupEntryWithLock(key entryKey) (*Entry, bool) {
+	elem, exists := s.entries[key]
+	if !exists {
+		return nil, false
+	}
+	return elem.Value.(hashedEntry).entry, true
+}
+
 // NB(xichen): use a type-specific list for hashedEntry if the conversion
 // overhead between interface{} and hashedEntry becomes a problem.
+// NB: the map lock guards the entry list and inserting entries while the
+// shard locks guard the entries of each shard, lookups of existing entries
+// only take the lock of the shard the entry belongs to. The map lock must be
+// held before a shard lock to avoid deadlocks.
 // nolint: maligned
 type metricMap struct {
 	sync.RWMutex
@@ -100,9 +137,9 @@ type metricMap struct {
 	entryPool    EntryPool
 	batchPercent float64
 
-	closed            bool
+	closed            int32
 	metricLists       *metricLists
-	entries           map[entryKey]*list.Element
+	shards            []metricMapShard
 	entryList         *list.List
 	entryListDelLock  sync.Mutex // Must be held when deleting elements from the entry list
 	firstInsertAt     time.Time
@@ -110,12 +147,21 @@ type metricMap struct {
 	runtimeOpts       runtime.Options
 	runtimeOptsCloser close.SimpleCloser
 	sleepFn           sleepFn
+	writeTracer       *writetrace.Tracer
 	metrics           metricMapMetrics
 }
 
 func newMetricMap(shard uint32, opts Options) *metricMap {
 	metricLists := newMetricLists(shard, opts)
 	scope := opts.InstrumentOptions().MetricsScope().SubScope("map")
+	numShards := opts.EntryMapShards()
+	if numShards < 1 {
+		numShards = 1
+	}
+	shards := make([]metricMapShard, numShards)
+	for i := range shards {
+		shards[i].entries = make(map[entryKey]*list.Element)
+	}
 	m := &metricMap{
 		shard:        shard,
 		opts:         opts,
@@ -123,9 +169,10 @@ func newMetricMap(shard uint32, opts Options) *metricMap {
 		entryPool:    opts.EntryPool(),
 		batchPercent: opts.EntryCheckBatchPercent(),
 		metricLists:  metricLists,
-		entries:      make(map[entryKey]*list.Element),
+		shards:       shards,
 		entryList:    list.New(),
 		sleepFn:      time.Sleep,
+		writeTracer:  opts.WriteTracer(),
 		metrics:      newMetricMapMetrics(scope),
 	}
 
@@ -151,12 +198,15 @@ func (m *metricMap) AddUntimed(
 		metricType:     metric.Type,
 		idHash:         hash.Murmur3Hash128(metric.ID),
 	}
+	trace := m.writeTracer.Start()
 	entry, err := m.findOrCreate(key)
 	if err != nil {
 		return err
 	}
-	err = entry.AddUntimed(metric, metadatas)
+	trace.Mark(writetrace.LookupPhase)
+	err = entry.addUntimedWithTrace(metric, metadatas, trace)
 	entry.DecWriter()
+	m.writeTracer.Finish(trace, metric.ID)
 	return err
 }
 
@@ -216,6 +266,7 @@ func (m *metricMap) AddForwarded(
 
 func (m *metricMap) Tick(target time.Duration) tickResult {
 	mapTickRes := m.tick(target)
+	m.reportShardEntries()
 	listsTickRes := m.metricLists.Tick()
 	mapTickRes.standard.activeElems = listsTickRes.standard
 	mapTickRes.forwarded.activeElems = listsTickRes.forwarded
@@ -247,39 +298,52 @@ func (m *metricMap) Close() {
 	m.Lock()
 	defer m.Unlock()
 
-	if m.closed {
+	if m.isClosed() {
 		return
 	}
 	m.runtimeOptsCloser.Close()
 	m.metricLists.Close()
-	m.closed = true
+	atomic.StoreInt32(&m.closed, 1)
+}
+
+func (m *metricMap) isClosed() bool {
+	return atomic.LoadInt32(&m.closed) == 1
+}
+
+func (m *metricMap) shardFor(key entryKey) *metricMapShard {
+	return &m.shards[key.idHash[0]%uint64(len(m.shards))]
 }
 
 func (m *metricMap) findOrCreate(key entryKey) (*Entry, error) {
-	m.RLock()
-	if m.closed {
-		m.RUnlock()
+	if m.isClosed() {
 		return nil, errMetricMapClosed
 	}
-	if entry, found := m.lookupEntryWithLock(key); found {
+	shard := m.shardFor(key)
+	shard.RLock()
+	if entry, found := shard.lookupEntryWithLock(key); found {
 		// NB(xichen): it is important to increase number of writers
 		// within a lock so we can account for active writers
 		// when deleting expired entries.
 		entry.IncWriter()
-		m.RUnlock()
+		shard.RUnlock()
 		return entry, nil
 	}
-	m.RUnlock()
+	shard.RUnlock()
 
+	lockStart := m.nowFn()
 	m.Lock()
-	if m.closed {
+	m.metrics.insertLockWait.Record(m.nowFn().Sub(lockStart))
+	if m.isClosed() {
 		m.Unlock()
 		return nil, errMetricMapClosed
 	}
-	entry, found := m.lookupEntryWithLock(key)
+	shard.Lock()
+	entry, found := shard.lookupEntryWithLock(key)
 	if found {
 		entry.IncWriter()
+		shard.Unlock()
 		m.Unlock()
+		m.metrics.insertRaces.Inc(1)
 		return entry, nil
 	}
 
@@ -289,28 +353,42 @@ func (m *metricMap) findOrCreate(key entryKey) (*Entry, error) {
 		m.firstInsertAt = now
 	}
 	if err := m.applyNewMetricRateLimitWithLock(now); err != nil {
+		shard.Unlock()
 		m.Unlock()
 		return nil, err
 	}
 	entry = m.entryPool.Get()
 	entry.ResetSetData(m.metricLists, m.runtimeOpts, m.opts)
-	m.entries[key] = m.entryList.PushBack(hashedEntry{
+	shard.entries[key] = m.entryList.PushBack(hashedEntry{
 		key:   key,
 		entry: entry,
 	})
 	entry.IncWriter()
+	shard.Unlock()
 	m.Unlock()
 	m.metrics.newEntries.Inc(1)
 
 	return entry, nil
 }
 
-func (m *metricMap) lookupEntryWithLock(key entryKey) (*Entry, bool) {
-	elem, exists := m.entries[key]
-	if !exists {
-		return nil, false
+// reportShardEntries reports the number of entries of the largest and the
+// smallest shards, a large skew means lookups contend on few shards.
+func (m *metricMap) reportShardEntries() {
+	var maxEntries, minEntries int
+	for i := range m.shards {
+		shard := &m.shards[i]
+		shard.RLock()
+		numEntries := len(shard.entries)
+		shard.RUnlock()
+		if i == 0 || numEntries > maxEntries {
+			maxEntries = numEntries
+		}
+		if i == 0 || numEntries < minEntries {
+			minEntries = numEntries
+		}
 	}
-	return elem.Value.(hashedEntry).entry, true
+	m.metrics.shardEntriesMax.Update(float64(maxEntries))
+	m.metrics.shardEntriesMin.Update(float64(minEntries))
 }
 
 // tick performs two operations:
@@ -403,21 +481,29 @@ func (m *metricMap) purgeExpired(
 	m.entryListDelLock.Lock()
 	m.Lock()
 	for i := range entries {
-		if entries[i].entry.TryExpire(now) {
-			key := entries[i].key
-			switch key.metricCategory {
-			case untimedMetric:
-				numStandardExpired++
-			case forwardedMetric:
-				numForwardedExpired++
-			case timedMetric:
-				numTimedExpired++
-			}
-			elem := m.entries[key]
-			delete(m.entries, key)
-			elem.Value = nil
-			m.entryList.Remove(elem)
+		// NB: the shard lock must be held while expiring an entry so writers
+		// looking up the entry cannot start writing to it once it is expired.
+		key := entries[i].key
+		shard := m.shardFor(key)
+		shard.Lock()
+		if !entries[i].entry.TryExpire(now) {
+			shard.Unlock()
+			continue
+		}
+		elem := shard.entries[key]
+		delete(shard.entries, key)
+		shard.Unlock()
+
+		switch key.metricCategory {
+		case untimedMetric:
+			numStandardExpired++
+		case forwardedMetric:
+			numForwardedExpired++
+		case timedMetric:
+			numTimedExpired++
 		}
+		elem.Value = nil
+		m.entryList.Remove(elem)
 	}
 	m.Unlock()
 	m.entryListDelLock.Unlock()
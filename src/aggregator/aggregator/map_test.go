@@ -21,6 +21,7 @@
 package aggregator
 
 import (
+	"container/list"
 	"fmt"
 	"sync/atomic"
 	"testing"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/m3db/m3/src/aggregator/hash"
 	"github.com/m3db/m3/src/aggregator/runtime"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
@@ -90,10 +92,10 @@ func TestMetricMapAddUntimedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(testCounterID),
 	}
 	require.NoError(t, m.AddUntimed(testCounter, policies))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
 
-	elem, exists := m.entries[key]
+	elem, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry := elem.Value.(hashedEntry)
 	require.Equal(t, int32(0), atomic.LoadInt32(&entry.entry.numWriters))
@@ -102,9 +104,9 @@ func TestMetricMapAddUntimedNoRateLimit(t *testing.T) {
 
 	// Add the same counter and assert there is still one entry.
 	require.NoError(t, m.AddUntimed(testCounter, policies))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
-	elem2, exists := m.entries[key]
+	elem2, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry2 := elem2.Value.(hashedEntry)
 	require.Equal(t, entry, entry2)
@@ -127,11 +129,11 @@ func TestMetricMapAddUntimedNoRateLimit(t *testing.T) {
 		metricWithDifferentType,
 		testCustomStagedMetadatas,
 	))
-	require.Equal(t, 2, len(m.entries))
+	require.Equal(t, 2, numTestMapEntries(m))
 	require.Equal(t, 2, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e1, exists1 := m.entries[key]
-	e2, exists2 := m.entries[key2]
+	e1, exists1 := lookupTestMapElem(m, key)
+	e2, exists2 := lookupTestMapElem(m, key2)
 	require.True(t, exists1)
 	require.True(t, exists2)
 	require.NotEqual(t, e1, e2)
@@ -146,11 +148,28 @@ func TestMetricMapAddUntimedNoRateLimit(t *testing.T) {
 		metricWithDifferentID,
 		testCustomStagedMetadatas,
 	))
-	require.Equal(t, 3, len(m.entries))
+	require.Equal(t, 3, numTestMapEntries(m))
 	require.Equal(t, 3, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
 }
 
+func TestMetricMapAddUntimedWithWriteTracer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tracer, err := writetrace.NewTracer(2, time.Nanosecond, 10, time.Now)
+	require.NoError(t, err)
+	opts := testOptions(ctrl).SetWriteTracer(tracer)
+	m := newMetricMap(testShard, opts)
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, m.AddUntimed(testCounter, testDefaultStagedMetadatas))
+	}
+	breakdown := tracer.Breakdown()
+	require.Equal(t, int64(2), breakdown.Traced)
+	require.Equal(t, 3, len(breakdown.Phases))
+}
+
 func TestMetricMapSetRuntimeOptions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -278,10 +297,10 @@ func TestMetricMapAddTimedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(am.ID),
 	}
 	require.NoError(t, m.AddTimed(am, testTimedMetadata))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
 
-	elem, exists := m.entries[key]
+	elem, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry := elem.Value.(hashedEntry)
 	require.Equal(t, int32(0), atomic.LoadInt32(&entry.entry.numWriters))
@@ -290,9 +309,9 @@ func TestMetricMapAddTimedNoRateLimit(t *testing.T) {
 
 	// Add the same counter and assert there is still one entry.
 	require.NoError(t, m.AddTimed(am, testTimedMetadata))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
-	elem2, exists := m.entries[key]
+	elem2, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry2 := elem2.Value.(hashedEntry)
 	require.Equal(t, entry, entry2)
@@ -311,11 +330,11 @@ func TestMetricMapAddTimedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(um.ID),
 	}
 	require.NoError(t, m.AddUntimed(um, testStagedMetadatas))
-	require.Equal(t, 2, len(m.entries))
+	require.Equal(t, 2, numTestMapEntries(m))
 	require.Equal(t, 2, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e1, exists1 := m.entries[key]
-	e2, exists2 := m.entries[key2]
+	e1, exists1 := lookupTestMapElem(m, key)
+	e2, exists2 := lookupTestMapElem(m, key2)
 	require.True(t, exists1)
 	require.True(t, exists2)
 	require.False(t, e1 == e2)
@@ -333,10 +352,10 @@ func TestMetricMapAddTimedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(metricWithDifferentType.ID),
 	}
 	require.NoError(t, m.AddTimed(metricWithDifferentType, testTimedMetadata))
-	require.Equal(t, 3, len(m.entries))
+	require.Equal(t, 3, numTestMapEntries(m))
 	require.Equal(t, 3, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e3, exists3 := m.entries[key3]
+	e3, exists3 := lookupTestMapElem(m, key3)
 	require.True(t, exists3)
 	require.False(t, e1 == e3)
 
@@ -353,10 +372,10 @@ func TestMetricMapAddTimedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(metricWithDifferentID.ID),
 	}
 	require.NoError(t, m.AddTimed(metricWithDifferentID, testTimedMetadata))
-	require.Equal(t, 4, len(m.entries))
+	require.Equal(t, 4, numTestMapEntries(m))
 	require.Equal(t, 4, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e4, exists4 := m.entries[key4]
+	e4, exists4 := lookupTestMapElem(m, key4)
 	require.True(t, exists4)
 	require.False(t, e1 == e4)
 }
@@ -381,10 +400,10 @@ func TestMetricMapAddForwardedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(am.ID),
 	}
 	require.NoError(t, m.AddForwarded(am, testForwardMetadata))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
 
-	elem, exists := m.entries[key]
+	elem, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry := elem.Value.(hashedEntry)
 	require.Equal(t, int32(0), atomic.LoadInt32(&entry.entry.numWriters))
@@ -393,9 +412,9 @@ func TestMetricMapAddForwardedNoRateLimit(t *testing.T) {
 
 	// Add the same counter and assert there is still one entry.
 	require.NoError(t, m.AddForwarded(am, testForwardMetadata))
-	require.Equal(t, 1, len(m.entries))
+	require.Equal(t, 1, numTestMapEntries(m))
 	require.Equal(t, 1, m.entryList.Len())
-	elem2, exists := m.entries[key]
+	elem2, exists := lookupTestMapElem(m, key)
 	require.True(t, exists)
 	entry2 := elem2.Value.(hashedEntry)
 	require.Equal(t, entry, entry2)
@@ -414,11 +433,11 @@ func TestMetricMapAddForwardedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(um.ID),
 	}
 	require.NoError(t, m.AddUntimed(um, testStagedMetadatas))
-	require.Equal(t, 2, len(m.entries))
+	require.Equal(t, 2, numTestMapEntries(m))
 	require.Equal(t, 2, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e1, exists1 := m.entries[key]
-	e2, exists2 := m.entries[key2]
+	e1, exists1 := lookupTestMapElem(m, key)
+	e2, exists2 := lookupTestMapElem(m, key2)
 	require.True(t, exists1)
 	require.True(t, exists2)
 	require.False(t, e1 == e2)
@@ -436,10 +455,10 @@ func TestMetricMapAddForwardedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(metricWithDifferentType.ID),
 	}
 	require.NoError(t, m.AddForwarded(metricWithDifferentType, testForwardMetadata))
-	require.Equal(t, 3, len(m.entries))
+	require.Equal(t, 3, numTestMapEntries(m))
 	require.Equal(t, 3, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e3, exists3 := m.entries[key3]
+	e3, exists3 := lookupTestMapElem(m, key3)
 	require.True(t, exists3)
 	require.False(t, e1 == e3)
 
@@ -456,10 +475,10 @@ func TestMetricMapAddForwardedNoRateLimit(t *testing.T) {
 		idHash:         hash.Murmur3Hash128(metricWithDifferentID.ID),
 	}
 	require.NoError(t, m.AddForwarded(metricWithDifferentID, testForwardMetadata))
-	require.Equal(t, 4, len(m.entries))
+	require.Equal(t, 4, numTestMapEntries(m))
 	require.Equal(t, 4, m.entryList.Len())
 	require.Equal(t, 3, m.metricLists.Len())
-	e4, exists4 := m.entries[key4]
+	e4, exists4 := lookupTestMapElem(m, key4)
 	require.True(t, exists4)
 	require.False(t, e1 == e4)
 }
@@ -501,12 +520,12 @@ func TestMetricMapDeleteExpired(t *testing.T) {
 			idHash:     hash.Murmur3Hash128([]byte(fmt.Sprintf("%d", i))),
 		}
 		if i%2 == 0 {
-			m.entries[key] = m.entryList.PushBack(hashedEntry{
+			m.shardFor(key).entries[key] = m.entryList.PushBack(hashedEntry{
 				key:   key,
 				entry: NewEntry(m.metricLists, runtime.NewOptions(), liveEntryOpts),
 			})
 		} else {
-			m.entries[key] = m.entryList.PushBack(hashedEntry{
+			m.shardFor(key).entries[key] = m.entryList.PushBack(hashedEntry{
 				key:   key,
 				entry: NewEntry(m.metricLists, runtime.NewOptions(), expiredEntryOpts),
 			})
@@ -517,12 +536,84 @@ func TestMetricMapDeleteExpired(t *testing.T) {
 	m.tick(opts.EntryCheckInterval())
 
 	// Assert there should be only half of the entries left.
-	require.Equal(t, numEntries/2, len(m.entries))
+	require.Equal(t, numEntries/2, numTestMapEntries(m))
 	require.Equal(t, numEntries/2, m.entryList.Len())
 	require.Equal(t, len(sleepIntervals), numEntries/defaultSoftDeadlineCheckEvery)
-	for k, v := range m.entries {
-		e := v.Value.(hashedEntry)
-		require.Equal(t, k, e.key)
-		require.NotNil(t, e.entry)
+	for i := range m.shards {
+		for k, v := range m.shards[i].entries {
+			e := v.Value.(hashedEntry)
+			require.Equal(t, k, e.key)
+			require.NotNil(t, e.entry)
+		}
+	}
+}
+
+func TestMetricMapShardsEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	numShards := 8
+	opts := testOptions(ctrl).SetEntryMapShards(numShards)
+	m := newMetricMap(testShard, opts)
+	require.Equal(t, numShards, len(m.shards))
+
+	numEntries := 100
+	for i := 0; i < numEntries; i++ {
+		mu := testCounter
+		mu.ID = id.RawID(fmt.Sprintf("counter%d", i))
+		require.NoError(t, m.AddUntimed(mu, testDefaultStagedMetadatas))
 	}
+	require.Equal(t, numEntries, numTestMapEntries(m))
+	require.Equal(t, numEntries, m.entryList.Len())
+
+	// Verify the entries are spread across shards by id hash.
+	var nonEmptyShards int
+	for i := range m.shards {
+		for k := range m.shards[i].entries {
+			require.True(t, m.shardFor(k) == &m.shards[i])
+		}
+		if len(m.shards[i].entries) > 0 {
+			nonEmptyShards++
+		}
+	}
+	require.True(t, nonEmptyShards > 1)
+}
+
+func BenchmarkMetricMapAddUntimedParallel(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	m := newMetricMap(testShard, testOptions(ctrl))
+	metrics := make([]unaggregated.MetricUnion, 1024)
+	for i := range metrics {
+		metrics[i] = testCounter
+		metrics[i].ID = id.RawID(fmt.Sprintf("counter%d", i))
+		if err := m.AddUntimed(metrics[i], testDefaultStagedMetadatas); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			if err := m.AddUntimed(metrics[i%len(metrics)], testDefaultStagedMetadatas); err != nil {
+				b.Fatal(err)
+			}
+			i++
+		}
+	})
+}
+
+func numTestMapEntries(m *metricMap) int {
+	var numEntries int
+	for i := range m.shards {
+		numEntries += len(m.shards[i].entries)
+	}
+	return numEntries
+}
+
+func lookupTestMapElem(m *metricMap, key entryKey) (*list.Element, bool) {
+	elem, exists := m.shardFor(key).entries[key]
+	return elem, exists
 }
@@ -30,11 +30,14 @@ import (
 	"github.com/m3db/m3/src/aggregator/client"
 	"github.com/m3db/m3/src/aggregator/runtime"
 	"github.com/m3db/m3/src/aggregator/sharding"
+	"github.com/m3db/m3/src/aggregator/verify"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
 	xtime "github.com/m3db/m3/src/x/time"
 )
 
@@ -46,10 +49,12 @@ var (
 	defaultEntryTTL                   = time.Hour
 	defaultEntryCheckInterval         = time.Hour
 	defaultEntryCheckBatchPercent     = 0.01
+	defaultEntryMapShards             = 32
 	defaultMaxTimerBatchSizePerWrite  = 0
 	defaultMaxNumCachedSourceSets     = 2
 	defaultDiscardNaNAggregatedValues = true
 	defaultResignTimeout              = 5 * time.Minute
+	defaultHeartbeatRetention         = 2 * 24 * time.Hour
 	defaultDefaultStoragePolicies     = []policy.StoragePolicy{
 		policy.NewStoragePolicy(10*time.Second, xtime.Second, 2*24*time.Hour),
 		policy.NewStoragePolicy(time.Minute, xtime.Minute, 40*24*time.Hour),
@@ -224,12 +229,30 @@ type Options interface {
 	// EntryCheckBatchPercent returns the batch percentage for checking expired entries.
 	EntryCheckBatchPercent() float64
 
+	// SetEntryMapShards sets the number of shards the entries of each metric map
+	// are split into to reduce lock contention.
+	SetEntryMapShards(value int) Options
+
+	// EntryMapShards returns the number of shards the entries of each metric map
+	// are split into to reduce lock contention.
+	EntryMapShards() int
+
 	// SetMaxTimerBatchSizePerWrite sets the maximum timer batch size for each batched write.
 	SetMaxTimerBatchSizePerWrite(value int) Options
 
 	// MaxTimerBatchSizePerWrite returns the maximum timer batch size for each batched write.
 	MaxTimerBatchSizePerWrite() int
 
+	// SetGaugeDedupWindow sets the window within which identical gauge writes for
+	// the same metric and policy are deduplicated, a zero window disables deduplication.
+	// Only gauges aggregated solely with Last, Min or Max are deduplicated.
+	SetGaugeDedupWindow(value time.Duration) Options
+
+	// GaugeDedupWindow returns the window within which identical gauge writes for
+	// the same metric and policy are deduplicated, a zero window disables deduplication.
+	// Only gauges aggregated solely with Last, Min or Max are deduplicated.
+	GaugeDedupWindow() time.Duration
+
 	// SetDefaultStoragePolicies sets the default policies.
 	SetDefaultStoragePolicies(value []policy.StoragePolicy) Options
 
@@ -274,6 +297,70 @@ type Options interface {
 	// DiscardNaNAggregatedValues determines whether NaN aggregated values are discarded.
 	DiscardNaNAggregatedValues() bool
 
+	// SetEmitStalenessMarkers sets whether a NaN staleness marker is flushed for
+	// a series once an aggregation window closes without any writes after the
+	// series was last flushed.
+	SetEmitStalenessMarkers(value bool) Options
+
+	// EmitStalenessMarkers returns whether staleness markers are flushed for
+	// series that stopped reporting.
+	EmitStalenessMarkers() bool
+
+	// SetIDTemplate sets the template for the ids of aggregated metrics flushed
+	// locally, nil assembles ids from the id prefix, metric id and id suffix.
+	SetIDTemplate(value *IDTemplate) Options
+
+	// IDTemplate returns the template for the ids of aggregated metrics flushed
+	// locally.
+	IDTemplate() *IDTemplate
+
+	// SetShadowChecksums sets the checksums of the metrics flushed locally per
+	// aggregation window whose source IDs are sampled, used to validate a
+	// shadow cluster, nil disables checksumming.
+	SetShadowChecksums(value *verify.WindowChecksums) Options
+
+	// ShadowChecksums returns the checksums of the metrics flushed locally per
+	// aggregation window whose source IDs are sampled.
+	ShadowChecksums() *verify.WindowChecksums
+
+	// SetWindowChecksums sets the checksums of all metrics flushed locally per
+	// aggregation window used to verify delivery downstream, nil disables
+	// checksumming.
+	SetWindowChecksums(value *verify.WindowChecksums) Options
+
+	// WindowChecksums returns the checksums of all metrics flushed locally per
+	// aggregation window.
+	WindowChecksums() *verify.WindowChecksums
+
+	// SetWriteTracer sets the tracer of sampled untimed writes, nil disables
+	// tracing.
+	SetWriteTracer(value *writetrace.Tracer) Options
+
+	// WriteTracer returns the tracer of sampled untimed writes.
+	WriteTracer() *writetrace.Tracer
+
+	// SetHeartbeatName sets the name of the heartbeat series each metric list
+	// writes to its local flush output on every flush, nil disables heartbeats.
+	SetHeartbeatName(value []byte) Options
+
+	// HeartbeatName returns the name of the heartbeat series each metric list
+	// writes to its local flush output on every flush.
+	HeartbeatName() []byte
+
+	// SetHeartbeatRetention sets the retention of the heartbeat series.
+	SetHeartbeatRetention(value time.Duration) Options
+
+	// HeartbeatRetention returns the retention of the heartbeat series.
+	HeartbeatRetention() time.Duration
+
+	// SetRollupIDInterner sets the cache interning the rollup ids of the
+	// pipelines of aggregated metrics, nil disables interning.
+	SetRollupIDInterner(value *intern.Cache) Options
+
+	// RollupIDInterner returns the cache interning the rollup ids of the
+	// pipelines of aggregated metrics.
+	RollupIDInterner() *intern.Cache
+
 	// SetEntryPool sets the entry pool.
 	SetEntryPool(value EntryPool) Options
 
@@ -339,7 +426,9 @@ type options struct {
 	entryTTL                         time.Duration
 	entryCheckInterval               time.Duration
 	entryCheckBatchPercent           float64
+	entryMapShards                   int
 	maxTimerBatchSizePerWrite        int
+	gaugeDedupWindow                 time.Duration
 	defaultStoragePolicies           []policy.StoragePolicy
 	flushTimesManager                FlushTimesManager
 	electionManager                  ElectionManager
@@ -349,6 +438,14 @@ type options struct {
 	bufferForFutureTimedMetric       time.Duration
 	maxNumCachedSourceSets           int
 	discardNaNAggregatedValues       bool
+	emitStalenessMarkers             bool
+	idTemplate                       *IDTemplate
+	shadowChecksums                  *verify.WindowChecksums
+	windowChecksums                  *verify.WindowChecksums
+	writeTracer                      *writetrace.Tracer
+	heartbeatName                    []byte
+	heartbeatRetention               time.Duration
+	rollupIDInterner                 *intern.Cache
 	entryPool                        EntryPool
 	counterElemPool                  CounterElemPool
 	timerElemPool                    TimerElemPool
@@ -386,6 +483,7 @@ func NewOptions() Options {
 		entryTTL:                         defaultEntryTTL,
 		entryCheckInterval:               defaultEntryCheckInterval,
 		entryCheckBatchPercent:           defaultEntryCheckBatchPercent,
+		entryMapShards:                   defaultEntryMapShards,
 		maxTimerBatchSizePerWrite:        defaultMaxTimerBatchSizePerWrite,
 		defaultStoragePolicies:           defaultDefaultStoragePolicies,
 		resignTimeout:                    defaultResignTimeout,
@@ -394,6 +492,7 @@ func NewOptions() Options {
 		bufferForFutureTimedMetric:       defaultTimedMetricBuffer,
 		maxNumCachedSourceSets:           defaultMaxNumCachedSourceSets,
 		discardNaNAggregatedValues:       defaultDiscardNaNAggregatedValues,
+		heartbeatRetention:               defaultHeartbeatRetention,
 		verboseErrors:                    defaultVerboseErrors,
 	}
 
@@ -640,6 +739,16 @@ func (o *options) EntryCheckBatchPercent() float64 {
 	return o.entryCheckBatchPercent
 }
 
+func (o *options) SetEntryMapShards(value int) Options {
+	opts := *o
+	opts.entryMapShards = value
+	return &opts
+}
+
+func (o *options) EntryMapShards() int {
+	return o.entryMapShards
+}
+
 func (o *options) SetMaxTimerBatchSizePerWrite(value int) Options {
 	opts := *o
 	opts.maxTimerBatchSizePerWrite = value
@@ -650,6 +759,16 @@ func (o *options) MaxTimerBatchSizePerWrite() int {
 	return o.maxTimerBatchSizePerWrite
 }
 
+func (o *options) SetGaugeDedupWindow(value time.Duration) Options {
+	opts := *o
+	opts.gaugeDedupWindow = value
+	return &opts
+}
+
+func (o *options) GaugeDedupWindow() time.Duration {
+	return o.gaugeDedupWindow
+}
+
 func (o *options) SetDefaultStoragePolicies(value []policy.StoragePolicy) Options {
 	opts := *o
 	opts.defaultStoragePolicies = value
@@ -720,6 +839,86 @@ func (o *options) DiscardNaNAggregatedValues() bool {
 	return o.discardNaNAggregatedValues
 }
 
+func (o *options) SetEmitStalenessMarkers(value bool) Options {
+	opts := *o
+	opts.emitStalenessMarkers = value
+	return &opts
+}
+
+func (o *options) EmitStalenessMarkers() bool {
+	return o.emitStalenessMarkers
+}
+
+func (o *options) SetIDTemplate(value *IDTemplate) Options {
+	opts := *o
+	opts.idTemplate = value
+	return &opts
+}
+
+func (o *options) IDTemplate() *IDTemplate {
+	return o.idTemplate
+}
+
+func (o *options) SetShadowChecksums(value *verify.WindowChecksums) Options {
+	opts := *o
+	opts.shadowChecksums = value
+	return &opts
+}
+
+func (o *options) ShadowChecksums() *verify.WindowChecksums {
+	return o.shadowChecksums
+}
+
+func (o *options) SetWindowChecksums(value *verify.WindowChecksums) Options {
+	opts := *o
+	opts.windowChecksums = value
+	return &opts
+}
+
+func (o *options) WindowChecksums() *verify.WindowChecksums {
+	return o.windowChecksums
+}
+
+func (o *options) SetWriteTracer(value *writetrace.Tracer) Options {
+	opts := *o
+	opts.writeTracer = value
+	return &opts
+}
+
+func (o *options) WriteTracer() *writetrace.Tracer {
+	return o.writeTracer
+}
+
+func (o *options) SetHeartbeatName(value []byte) Options {
+	opts := *o
+	opts.heartbeatName = value
+	return &opts
+}
+
+func (o *options) HeartbeatName() []byte {
+	return o.heartbeatName
+}
+
+func (o *options) SetHeartbeatRetention(value time.Duration) Options {
+	opts := *o
+	opts.heartbeatRetention = value
+	return &opts
+}
+
+func (o *options) HeartbeatRetention() time.Duration {
+	return o.heartbeatRetention
+}
+
+func (o *options) SetRollupIDInterner(value *intern.Cache) Options {
+	opts := *o
+	opts.rollupIDInterner = value
+	return &opts
+}
+
+func (o *options) RollupIDInterner() *intern.Cache {
+	return o.rollupIDInterner
+}
+
 func (o *options) SetEntryPool(value EntryPool) Options {
 	opts := *o
 	opts.entryPool = value
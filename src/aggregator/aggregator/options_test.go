@@ -60,6 +60,10 @@ func TestOptionsValidateDefault(t *testing.T) {
 	require.Equal(t, defaultEntryTTL, o.EntryTTL())
 	require.Equal(t, defaultEntryCheckInterval, o.EntryCheckInterval())
 	require.Equal(t, defaultEntryCheckBatchPercent, o.EntryCheckBatchPercent())
+	require.Equal(t, defaultEntryMapShards, o.EntryMapShards())
+	require.Equal(t, time.Duration(0), o.GaugeDedupWindow())
+	require.False(t, o.EmitStalenessMarkers())
+	require.Nil(t, o.IDTemplate())
 	require.NotNil(t, o.ClockOptions())
 	require.NotNil(t, o.InstrumentOptions())
 	require.NotNil(t, o.TimeLock())
@@ -203,6 +207,18 @@ func TestSetEntryCheckBatchPercent(t *testing.T) {
 	require.Equal(t, value, o.EntryCheckBatchPercent())
 }
 
+func TestSetEntryMapShards(t *testing.T) {
+	value := 64
+	o := NewOptions().SetEntryMapShards(value)
+	require.Equal(t, value, o.EntryMapShards())
+}
+
+func TestSetGaugeDedupWindow(t *testing.T) {
+	value := 10 * time.Second
+	o := NewOptions().SetGaugeDedupWindow(value)
+	require.Equal(t, value, o.GaugeDedupWindow())
+}
+
 func TestSetEntryPool(t *testing.T) {
 	value := NewEntryPool(nil)
 	o := NewOptions().SetEntryPool(value)
@@ -221,6 +237,30 @@ func TestSetDiscardNaNAggregatedValues(t *testing.T) {
 	require.Equal(t, value, o.DiscardNaNAggregatedValues())
 }
 
+func TestSetEmitStalenessMarkers(t *testing.T) {
+	o := NewOptions().SetEmitStalenessMarkers(true)
+	require.True(t, o.EmitStalenessMarkers())
+}
+
+func TestSetIDTemplate(t *testing.T) {
+	value, err := NewIDTemplate("dc1.{prefix}{id}{suffix}")
+	require.NoError(t, err)
+	o := NewOptions().SetIDTemplate(value)
+	require.Equal(t, value, o.IDTemplate())
+}
+
+func TestSetHeartbeatName(t *testing.T) {
+	value := []byte("aggregator.heartbeat")
+	o := NewOptions().SetHeartbeatName(value)
+	require.Equal(t, value, o.HeartbeatName())
+}
+
+func TestSetHeartbeatRetention(t *testing.T) {
+	value := 40 * 24 * time.Hour
+	o := NewOptions().SetHeartbeatRetention(value)
+	require.Equal(t, value, o.HeartbeatRetention())
+}
+
 func TestSetCounterElemPool(t *testing.T) {
 	value := NewCounterElemPool(nil)
 	o := NewOptions().SetCounterElemPool(value)
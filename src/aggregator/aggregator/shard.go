@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/metrics/metric/aggregated"
 	"github.com/m3db/m3/src/metrics/metric/unaggregated"
 	"github.com/m3db/m3/src/x/clock"
+	xerrors "github.com/m3db/m3/src/x/errors"
 
 	"github.com/uber-go/tally"
 )
@@ -185,6 +186,42 @@ func (s *aggregatorShard) AddUntimed(
 	return nil
 }
 
+// AddUntimedBatch adds a batch of untimed metrics under a single acquisition
+// of the shard lock, returning the errors of the metrics that failed.
+func (s *aggregatorShard) AddUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) xerrors.MultiError {
+	multiErr := xerrors.NewMultiError()
+	s.RLock()
+	if s.closed {
+		s.RUnlock()
+		for range metrics {
+			multiErr = multiErr.Add(errAggregatorShardClosed)
+		}
+		return multiErr
+	}
+	if !s.isWritableWithLock() {
+		s.RUnlock()
+		s.metrics.notWriteableErrors.Inc(int64(len(metrics)))
+		for range metrics {
+			multiErr = multiErr.Add(errAggregatorShardNotWriteable)
+		}
+		return multiErr
+	}
+	var numAdded int64
+	for _, metric := range metrics {
+		if err := s.addUntimedFn(metric, metadatas); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		numAdded++
+	}
+	s.RUnlock()
+	s.metrics.writeSucccess.Inc(numAdded)
+	return multiErr
+}
+
 func (s *aggregatorShard) AddTimed(
 	metric aggregated.Metric,
 	metadata metadata.TimedMetadata,
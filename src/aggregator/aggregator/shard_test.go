@@ -175,6 +175,37 @@ func TestAggregatorShardAddUntimedSuccess(t *testing.T) {
 	require.Equal(t, testStagedMetadatas, resultMetadatas)
 }
 
+func TestAggregatorShardAddUntimedBatchShardClosed(t *testing.T) {
+	shard := newAggregatorShard(testShard, NewOptions().SetEntryCheckInterval(0))
+	shard.closed = true
+	metrics := []unaggregated.MetricUnion{testUntimedMetric, testUntimedMetric}
+	multiErr := shard.AddUntimedBatch(metrics, testStagedMetadatas)
+	require.Equal(t, 2, multiErr.NumErrors())
+	for _, err := range multiErr.Errors() {
+		require.Equal(t, errAggregatorShardClosed, err)
+	}
+}
+
+func TestAggregatorShardAddUntimedBatchSuccess(t *testing.T) {
+	shard := newAggregatorShard(testShard, NewOptions())
+
+	var resultMus []unaggregated.MetricUnion
+	shard.addUntimedFn = func(
+		mu unaggregated.MetricUnion,
+		sm metadata.StagedMetadatas,
+	) error {
+		require.Equal(t, testStagedMetadatas, sm)
+		resultMus = append(resultMus, mu)
+		return nil
+	}
+
+	shard.SetWriteableRange(timeRange{cutoverNanos: 0, cutoffNanos: math.MaxInt64})
+	metrics := []unaggregated.MetricUnion{testUntimedMetric, testUntimedMetric}
+	multiErr := shard.AddUntimedBatch(metrics, testStagedMetadatas)
+	require.True(t, multiErr.Empty())
+	require.Equal(t, metrics, resultMus)
+}
+
 func TestAggregatorShardAddTimedShardNotWriteable(t *testing.T) {
 	now := time.Unix(0, 12345)
 	shard := newAggregatorShard(testShard, NewOptions())
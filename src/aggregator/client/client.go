@@ -73,6 +73,13 @@ type Client interface {
 		metadatas metadata.StagedMetadatas,
 	) error
 
+	// WriteUntimedBatch writes a batch of untimed metrics sharing the same
+	// staged metadatas.
+	WriteUntimedBatch(
+		metrics []unaggregated.MetricUnion,
+		metadatas metadata.StagedMetadatas,
+	) error
+
 	// WriteTimed writes timed metrics.
 	WriteTimed(
 		metric aggregated.Metric,
@@ -123,11 +130,13 @@ type clientMetrics struct {
 	writeUntimedCounter    instrument.MethodMetrics
 	writeUntimedBatchTimer instrument.MethodMetrics
 	writeUntimedGauge      instrument.MethodMetrics
+	writeUntimedBatch      instrument.MethodMetrics
 	writePassthrough       instrument.MethodMetrics
 	writeForwarded         instrument.MethodMetrics
 	flush                  instrument.MethodMetrics
 	shardNotOwned          tally.Counter
 	shardNotWriteable      tally.Counter
+	reshardMirrored        tally.Counter
 }
 
 func newClientMetrics(
@@ -138,11 +147,13 @@ func newClientMetrics(
 		writeUntimedCounter:    instrument.NewMethodMetrics(scope, "writeUntimedCounter", opts),
 		writeUntimedBatchTimer: instrument.NewMethodMetrics(scope, "writeUntimedBatchTimer", opts),
 		writeUntimedGauge:      instrument.NewMethodMetrics(scope, "writeUntimedGauge", opts),
+		writeUntimedBatch:      instrument.NewMethodMetrics(scope, "writeUntimedBatch", opts),
 		writePassthrough:       instrument.NewMethodMetrics(scope, "writePassthrough", opts),
 		writeForwarded:         instrument.NewMethodMetrics(scope, "writeForwarded", opts),
 		flush:                  instrument.NewMethodMetrics(scope, "flush", opts),
 		shardNotOwned:          scope.Counter("shard-not-owned"),
 		shardNotWriteable:      scope.Counter("shard-not-writeable"),
+		reshardMirrored:        scope.Counter("reshard-mirrored"),
 	}
 }
 
@@ -159,6 +170,7 @@ type client struct {
 	nowFn                      clock.NowFn
 	shardCutoverWarmupDuration time.Duration
 	shardCutoffLingerDuration  time.Duration
+	reshardMirroringEnabled    bool
 	writerMgr                  instanceWriterManager
 	shardFn                    sharding.ShardFn
 	placementWatcher           placement.StagedPlacementWatcher
@@ -220,6 +232,12 @@ func NewClient(opts Options) (Client, error) {
 			SetClockOptions(opts.ClockOptions()).
 			SetOnPlacementsAddedFn(onPlacementsAddedFn).
 			SetOnPlacementsRemovedFn(onPlacementsRemovedFn)
+		if opts.ReshardMirroringEnabled() {
+			// Retain superseded placements so writes can be mirrored to their
+			// shard owners while lingering after the new placement cuts over.
+			activeStagedPlacementOpts = activeStagedPlacementOpts.
+				SetExpiryDelay(opts.ShardCutoffLingerDuration())
+		}
 		placementWatcherOpts := opts.StagedPlacementWatcherOptions().
 			SetActiveStagedPlacementOptions(activeStagedPlacementOpts)
 		placementWatcher = placement.NewStagedPlacementWatcher(placementWatcherOpts)
@@ -234,6 +252,7 @@ func NewClient(opts Options) (Client, error) {
 		nowFn:                      opts.ClockOptions().NowFn(),
 		shardCutoverWarmupDuration: opts.ShardCutoverWarmupDuration(),
 		shardCutoffLingerDuration:  opts.ShardCutoffLingerDuration(),
+		reshardMirroringEnabled:    opts.ReshardMirroringEnabled(),
 		writerMgr:                  writerMgr,
 		shardFn:                    opts.ShardFn(),
 		placementWatcher:           placementWatcher,
@@ -316,6 +335,33 @@ func (c *client) WriteUntimedGauge(
 	return err
 }
 
+func (c *client) WriteUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) error {
+	var (
+		callStart = c.nowFn()
+		nowNanos  = c.nowNanos()
+		err       error
+	)
+	switch c.aggregatorClientType {
+	case LegacyAggregatorClient:
+		err = c.writeLegacyUntimedBatch(metrics, metadatas, nowNanos)
+	case M3MsgAggregatorClient:
+		multiErr := xerrors.NewMultiError()
+		for _, mu := range metrics {
+			if err := c.writeM3Msg(mu.ID, nowNanos, newUntimedPayloadUnion(mu, metadatas)); err != nil {
+				multiErr = multiErr.Add(err)
+			}
+		}
+		err = multiErr.FinalError()
+	default:
+		err = fmt.Errorf("unrecognized client type: %v", c.aggregatorClientType)
+	}
+	c.metrics.writeUntimedBatch.ReportSuccessOrError(err, c.nowFn().Sub(callStart))
+	return err
+}
+
 func (c *client) WriteTimed(
 	metric aggregated.Metric,
 	metadata metadata.TimedMetadata,
@@ -442,21 +488,179 @@ func (c *client) write(metricID id.RawID, timeNanos int64, payload payloadUnion)
 
 func (c *client) writeLegacy(metricID id.RawID, timeNanos int64, payload payloadUnion) error {
 	c.RLock()
-	if c.state != clientInitialized {
+	if c.reshardMirroringEnabled {
+		err := c.writeLegacyMirroredWithLock(metricID, timeNanos, payload)
 		c.RUnlock()
-		return errClientIsUninitializedOrClosed
+		return err
 	}
-	stagedPlacement, onStagedPlacementDoneFn, err := c.placementWatcher.ActiveStagedPlacement()
+	placement, onPlacementDoneFn, onStagedPlacementDoneFn, err := c.activePlacementWithLock()
 	if err != nil {
 		c.RUnlock()
 		return err
 	}
-	placement, onPlacementDoneFn, err := stagedPlacement.ActivePlacement()
+	err = c.writeLegacyWithPlacement(placement, metricID, timeNanos, payload)
+	onPlacementDoneFn()
+	onStagedPlacementDoneFn()
+	c.RUnlock()
+	return err
+}
+
+// writeLegacyUntimedBatch writes a batch of untimed metrics, acquiring the
+// client lock and the active placement once for the whole batch.
+func (c *client) writeLegacyUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+	timeNanos int64,
+) error {
+	c.RLock()
+	if c.reshardMirroringEnabled {
+		multiErr := xerrors.NewMultiError()
+		for _, mu := range metrics {
+			payload := newUntimedPayloadUnion(mu, metadatas)
+			if err := c.writeLegacyMirroredWithLock(mu.ID, timeNanos, payload); err != nil {
+				multiErr = multiErr.Add(err)
+			}
+		}
+		c.RUnlock()
+		return multiErr.FinalError()
+	}
+	placement, onPlacementDoneFn, onStagedPlacementDoneFn, err := c.activePlacementWithLock()
 	if err != nil {
-		onStagedPlacementDoneFn()
 		c.RUnlock()
 		return err
 	}
+	multiErr := xerrors.NewMultiError()
+	for _, mu := range metrics {
+		payload := newUntimedPayloadUnion(mu, metadatas)
+		if err := c.writeLegacyWithPlacement(placement, mu.ID, timeNanos, payload); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	onPlacementDoneFn()
+	onStagedPlacementDoneFn()
+	c.RUnlock()
+	return multiErr.FinalError()
+}
+
+func (c *client) activePlacementWithLock() (
+	placement.Placement,
+	placement.DoneFn,
+	placement.DoneFn,
+	error,
+) {
+	if c.state != clientInitialized {
+		return nil, nil, nil, errClientIsUninitializedOrClosed
+	}
+	stagedPlacement, onStagedPlacementDoneFn, err := c.placementWatcher.ActiveStagedPlacement()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	activePlacement, onPlacementDoneFn, err := stagedPlacement.ActivePlacement()
+	if err != nil {
+		onStagedPlacementDoneFn()
+		return nil, nil, nil, err
+	}
+	return activePlacement, onPlacementDoneFn, onStagedPlacementDoneFn, nil
+}
+
+// writeLegacyMirroredWithLock writes the metric to the shard owners in the active
+// placement, as well as to the shard owners in any placement with a different
+// number of shards that cuts over within the shard cutover warmup duration or
+// that has been superseded within the shard cutoff linger duration. This ensures
+// aggregation windows around a resharding cutover are fully aggregated by either
+// the old or the new shard owners.
+func (c *client) writeLegacyMirroredWithLock(
+	metricID id.RawID,
+	timeNanos int64,
+	payload payloadUnion,
+) error {
+	if c.state != clientInitialized {
+		return errClientIsUninitializedOrClosed
+	}
+	stagedPlacement, onStagedPlacementDoneFn, err := c.placementWatcher.ActiveStagedPlacement()
+	if err != nil {
+		return err
+	}
+	defer onStagedPlacementDoneFn()
+
+	nowNanos := c.nowNanos()
+	placements, onPlacementsDoneFn, err := stagedPlacement.ActivePlacementsInRange(
+		nowNanos-int64(c.shardCutoffLingerDuration),
+		nowNanos+int64(c.shardCutoverWarmupDuration),
+	)
+	if err != nil {
+		return err
+	}
+	defer onPlacementsDoneFn()
+
+	activeIdx := placements.ActiveIndex(nowNanos)
+	if activeIdx < 0 {
+		// The earliest placement is still warming up, write to it only.
+		activeIdx = 0
+	}
+	var (
+		active    = placements[activeIdx]
+		written   = make([]instanceShard, 0, active.ReplicaFactor())
+		multiErr  = xerrors.NewMultiError()
+		numShards = active.NumShards()
+	)
+	for i, placement := range placements {
+		if i != activeIdx && placement.NumShards() == numShards {
+			continue
+		}
+		shardID := c.shardFn(metricID, uint32(placement.NumShards()))
+		for _, instance := range placement.InstancesForShard(shardID) {
+			// NB: an instance owning a different shard in each placement
+			// aggregates the metric separately for each shard, so only
+			// skip writing the same shard to the same instance twice.
+			target := instanceShard{instanceID: instance.ID(), shardID: shardID}
+			if containsInstanceShard(written, target) {
+				continue
+			}
+			shard, ok := instance.Shards().Shard(shardID)
+			if !ok {
+				err := fmt.Errorf("instance %s does not own shard %d", instance.ID(), shardID)
+				multiErr = multiErr.Add(err)
+				c.metrics.shardNotOwned.Inc(1)
+				continue
+			}
+			if !c.shouldWriteForShard(timeNanos, shard) {
+				c.metrics.shardNotWriteable.Inc(1)
+				continue
+			}
+			if err := c.writerMgr.Write(instance, shardID, payload); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			if i != activeIdx {
+				c.metrics.reshardMirrored.Inc(1)
+			}
+			written = append(written, target)
+		}
+	}
+	return multiErr.FinalError()
+}
+
+type instanceShard struct {
+	instanceID string
+	shardID    uint32
+}
+
+func containsInstanceShard(written []instanceShard, target instanceShard) bool {
+	for _, curr := range written {
+		if curr == target {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *client) writeLegacyWithPlacement(
+	placement placement.Placement,
+	metricID id.RawID,
+	timeNanos int64,
+	payload payloadUnion,
+) error {
 	var (
 		shardID   = c.shardFn(metricID, uint32(placement.NumShards()))
 		instances = placement.InstancesForShard(shardID)
@@ -467,7 +671,7 @@ func (c *client) writeLegacy(metricID id.RawID, timeNanos int64, payload payload
 		// are computed from the placement, but protect against errors here regardless.
 		shard, ok := instance.Shards().Shard(shardID)
 		if !ok {
-			err = fmt.Errorf("instance %s does not own shard %d", instance.ID(), shardID)
+			err := fmt.Errorf("instance %s does not own shard %d", instance.ID(), shardID)
 			multiErr = multiErr.Add(err)
 			c.metrics.shardNotOwned.Inc(1)
 			continue
@@ -476,14 +680,10 @@ func (c *client) writeLegacy(metricID id.RawID, timeNanos int64, payload payload
 			c.metrics.shardNotWriteable.Inc(1)
 			continue
 		}
-		if err = c.writerMgr.Write(instance, shardID, payload); err != nil {
+		if err := c.writerMgr.Write(instance, shardID, payload); err != nil {
 			multiErr = multiErr.Add(err)
 		}
 	}
-
-	onPlacementDoneFn()
-	onStagedPlacementDoneFn()
-	c.RUnlock()
 	return multiErr.FinalError()
 }
 
@@ -562,6 +762,7 @@ type message struct {
 	fm     metricpb.ForwardedMetricWithMetadata
 	tm     metricpb.TimedMetricWithMetadata
 	tms    metricpb.TimedMetricWithMetadatas
+	pm     metricpb.TimedMetricWithStoragePolicy
 
 	buf []byte
 }
@@ -663,6 +864,19 @@ func (m *message) Encode(
 			Type:                     metricpb.MetricWithMetadatas_TIMED_METRIC_WITH_METADATAS,
 			TimedMetricWithMetadatas: &m.tms,
 		}
+	case passthroughType:
+		value := aggregated.PassthroughMetricWithMetadata{
+			Metric:        payload.passthrough.metric,
+			StoragePolicy: payload.passthrough.storagePolicy,
+		}
+		if err := value.ToProto(&m.pm); err != nil {
+			return err
+		}
+
+		m.metric = metricpb.MetricWithMetadatas{
+			Type:                         metricpb.MetricWithMetadatas_TIMED_METRIC_WITH_STORAGE_POLICY,
+			TimedMetricWithStoragePolicy: &m.pm,
+		}
 	default:
 		return fmt.Errorf("unrecognized payload type: %v",
 			payload.payloadType)
@@ -142,6 +142,20 @@ func (mr *MockClientMockRecorder) WriteTimedWithStagedMetadatas(arg0, arg1 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTimedWithStagedMetadatas", reflect.TypeOf((*MockClient)(nil).WriteTimedWithStagedMetadatas), arg0, arg1)
 }
 
+// WriteUntimedBatch mocks base method
+func (m *MockClient) WriteUntimedBatch(arg0 []unaggregated.MetricUnion, arg1 metadata.StagedMetadatas) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteUntimedBatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteUntimedBatch indicates an expected call of WriteUntimedBatch
+func (mr *MockClientMockRecorder) WriteUntimedBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteUntimedBatch", reflect.TypeOf((*MockClient)(nil).WriteUntimedBatch), arg0, arg1)
+}
+
 // WriteUntimedBatchTimer mocks base method
 func (m *MockClient) WriteUntimedBatchTimer(arg0 unaggregated.BatchTimer, arg1 metadata.StagedMetadatas) error {
 	m.ctrl.T.Helper()
@@ -305,6 +319,20 @@ func (mr *MockAdminClientMockRecorder) WriteTimedWithStagedMetadatas(arg0, arg1
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTimedWithStagedMetadatas", reflect.TypeOf((*MockAdminClient)(nil).WriteTimedWithStagedMetadatas), arg0, arg1)
 }
 
+// WriteUntimedBatch mocks base method
+func (m *MockAdminClient) WriteUntimedBatch(arg0 []unaggregated.MetricUnion, arg1 metadata.StagedMetadatas) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteUntimedBatch", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteUntimedBatch indicates an expected call of WriteUntimedBatch
+func (mr *MockAdminClientMockRecorder) WriteUntimedBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteUntimedBatch", reflect.TypeOf((*MockAdminClient)(nil).WriteUntimedBatch), arg0, arg1)
+}
+
 // WriteUntimedBatchTimer mocks base method
 func (m *MockAdminClient) WriteUntimedBatchTimer(arg0 unaggregated.BatchTimer, arg1 metadata.StagedMetadatas) error {
 	m.ctrl.T.Helper()
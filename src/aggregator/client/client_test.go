@@ -22,6 +22,7 @@ package client
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"strings"
 	"testing"
@@ -30,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/metrics/aggregation"
+	"github.com/m3db/m3/src/metrics/generated/proto/metricpb"
 	"github.com/m3db/m3/src/metrics/metadata"
 	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/aggregated"
@@ -368,6 +370,126 @@ func TestClientWriteUntimedMetricSuccess(t *testing.T) {
 	}
 }
 
+func TestClientWriteUntimedMetricReshardMirroring(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var instancesRes []string
+	writerMgr := NewMockinstanceWriterManager(ctrl)
+	writerMgr.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			instance placement.Instance,
+			shardID uint32,
+			payload payloadUnion,
+		) error {
+			instancesRes = append(instancesRes,
+				fmt.Sprintf("%s/%d", instance.ID(), shardID))
+			return nil
+		}).
+		MinTimes(1)
+
+	// The resharded placement cuts over within the warmup duration and
+	// maps the metric to shard 1 instead of shard 3.
+	reshardedInstances := []placement.Instance{
+		placement.NewInstance().
+			SetID("instance2").
+			SetEndpoint("instance2_endpoint").
+			SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(1).
+					SetState(shard.Initializing).
+					SetCutoverNanos(testNowNanos + int64(time.Second)),
+			})),
+		placement.NewInstance().
+			SetID("instance5").
+			SetEndpoint("instance5_endpoint").
+			SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(1).
+					SetState(shard.Initializing).
+					SetCutoverNanos(testNowNanos + int64(time.Second)),
+			})),
+	}
+	reshardedPlacement := placement.NewPlacement().
+		SetVersion(2).
+		SetCutoverNanos(testNowNanos + int64(time.Second)).
+		SetShards([]uint32{0, 1}).
+		SetReplicaFactor(2).
+		SetInstances(reshardedInstances)
+	placements := placement.Placements{
+		testPlacement,
+		reshardedPlacement,
+	}
+
+	stagedPlacement := placement.NewMockActiveStagedPlacement(ctrl)
+	stagedPlacement.EXPECT().
+		ActivePlacementsInRange(
+			testNowNanos-int64(10*time.Minute),
+			testNowNanos+int64(time.Minute),
+		).
+		Return(placements, func() {}, nil)
+	watcher := placement.NewMockStagedPlacementWatcher(ctrl)
+	watcher.EXPECT().ActiveStagedPlacement().Return(stagedPlacement, func() {}, nil)
+	opts := testOptions().
+		SetShardFn(func(_ []byte, numShards uint32) uint32 { return numShards - 1 }).
+		SetReshardMirroringEnabled(true)
+	c := mustNewTestClient(t, opts)
+	c.state = clientInitialized
+	c.nowFn = func() time.Time { return time.Unix(0, testNowNanos) }
+	c.writerMgr = writerMgr
+	c.placementWatcher = watcher
+
+	require.NoError(t, c.WriteUntimedCounter(testCounter.Counter(), testStagedMetadatas))
+	// instance2 owns shard 3 before and shard 1 after the cutover so is
+	// written both shards.
+	require.Equal(t, []string{
+		"instance2/3", "instance4/3", "instance2/1", "instance5/1",
+	}, instancesRes)
+}
+
+func TestClientWriteUntimedBatchClosed(t *testing.T) {
+	c := mustNewTestClient(t, testOptions())
+	c.state = clientUninitialized
+	metrics := []unaggregated.MetricUnion{testCounter, testBatchTimer, testGauge}
+	require.Equal(t, errClientIsUninitializedOrClosed, c.WriteUntimedBatch(metrics, testStagedMetadatas))
+}
+
+func TestClientWriteUntimedBatchSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var payloadsRes []payloadUnion
+	writerMgr := NewMockinstanceWriterManager(ctrl)
+	writerMgr.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			instance placement.Instance,
+			shardID uint32,
+			payload payloadUnion,
+		) error {
+			payloadsRes = append(payloadsRes, payload)
+			return nil
+		}).
+		Times(6)
+	stagedPlacement := placement.NewMockActiveStagedPlacement(ctrl)
+	stagedPlacement.EXPECT().ActivePlacement().Return(testPlacement, func() {}, nil).Times(1)
+	watcher := placement.NewMockStagedPlacementWatcher(ctrl)
+	watcher.EXPECT().ActiveStagedPlacement().Return(stagedPlacement, func() {}, nil).Times(1)
+	c := mustNewTestClient(t, testOptions())
+	c.state = clientInitialized
+	c.nowFn = func() time.Time { return time.Unix(0, testNowNanos) }
+	c.writerMgr = writerMgr
+	c.placementWatcher = watcher
+
+	metrics := []unaggregated.MetricUnion{testCounter, testBatchTimer, testGauge}
+	require.NoError(t, c.WriteUntimedBatch(metrics, testStagedMetadatas))
+	require.Equal(t, 6, len(payloadsRes))
+	for i, payload := range payloadsRes {
+		require.Equal(t, untimedType, payload.payloadType)
+		require.Equal(t, metrics[i/2], payload.untimed.metric)
+		require.Equal(t, testStagedMetadatas, payload.untimed.metadatas)
+	}
+}
+
 func TestClientWriteUntimedMetricPartialError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -842,6 +964,28 @@ func TestClientWriteTimeRangeFor(t *testing.T) {
 	}
 }
 
+func TestMessageEncodePassthrough(t *testing.T) {
+	msg := newMessagePool().Get()
+	payload := payloadUnion{
+		payloadType: passthroughType,
+		passthrough: passthroughPayload{
+			metric:        testPassthrough,
+			storagePolicy: testPassthroughMetadata,
+		},
+	}
+	require.NoError(t, msg.Encode(1, payload))
+	require.Equal(t, uint32(1), msg.Shard())
+
+	var pb metricpb.MetricWithMetadatas
+	require.NoError(t, pb.Unmarshal(msg.Bytes()))
+	require.Equal(t, metricpb.MetricWithMetadatas_TIMED_METRIC_WITH_STORAGE_POLICY, pb.Type)
+
+	var decoded aggregated.PassthroughMetricWithMetadata
+	require.NoError(t, decoded.FromProto(pb.TimedMetricWithStoragePolicy))
+	require.Equal(t, testPassthrough, decoded.Metric)
+	require.Equal(t, testPassthroughMetadata, decoded.StoragePolicy)
+}
+
 func testOptions() Options {
 	return NewOptions().
 		SetClockOptions(clock.NewOptions()).
@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/retry"
+	xsampler "github.com/m3db/m3/src/x/sampler"
 
 	"github.com/uber-go/tally"
 )
@@ -52,6 +53,7 @@ type Configuration struct {
 	HashType                   *sharding.HashType              `yaml:"hashType"`
 	ShardCutoverWarmupDuration *time.Duration                  `yaml:"shardCutoverWarmupDuration"`
 	ShardCutoffLingerDuration  *time.Duration                  `yaml:"shardCutoffLingerDuration"`
+	ReshardMirroringEnabled    bool                            `yaml:"reshardMirroringEnabled"`
 	Encoder                    EncoderConfiguration            `yaml:"encoder"`
 	FlushSize                  int                             `yaml:"flushSize"`
 	MaxBatchSize               int                             `yaml:"maxBatchSize"`
@@ -60,6 +62,17 @@ type Configuration struct {
 	QueueSize                  int                             `yaml:"queueSize"`
 	QueueDropType              *DropType                       `yaml:"queueDropType"`
 	Connection                 ConnectionConfiguration         `yaml:"connection"`
+	Shadow                     *ShadowConfiguration            `yaml:"shadow"`
+}
+
+// ShadowConfiguration configures mirroring a sampled fraction of writes to a
+// shadow aggregator cluster.
+type ShadowConfiguration struct {
+	// SampleRate is the fraction of metric IDs whose writes are mirrored.
+	SampleRate xsampler.Rate `yaml:"sampleRate"`
+
+	// Client configures the client of the shadow aggregator cluster.
+	Client Configuration `yaml:"client"`
 }
 
 // NewAdminClient creates a new admin client.
@@ -85,7 +98,20 @@ func (c *Configuration) NewClient(
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(opts)
+	client, err := NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.Shadow == nil {
+		return client, nil
+	}
+	shadowInstrumentOpts := instrumentOpts.SetMetricsScope(
+		instrumentOpts.MetricsScope().Tagged(map[string]string{"cluster": "shadow"}))
+	shadowClient, err := c.Shadow.Client.NewAdminClient(kvClient, clockOpts, shadowInstrumentOpts)
+	if err != nil {
+		return nil, err
+	}
+	return NewShadowClient(client.(AdminClient), shadowClient, c.Shadow.SampleRate, instrumentOpts)
 }
 
 var (
@@ -171,6 +197,9 @@ func (c *Configuration) newClientOptions(
 		if c.ShardCutoffLingerDuration != nil {
 			opts = opts.SetShardCutoffLingerDuration(*c.ShardCutoffLingerDuration)
 		}
+		if c.ReshardMirroringEnabled {
+			opts = opts.SetReshardMirroringEnabled(true)
+		}
 		if c.FlushSize != 0 {
 			opts = opts.SetFlushSize(c.FlushSize)
 		}
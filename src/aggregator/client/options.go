@@ -173,6 +173,16 @@ type Options interface {
 	// ShardCutoffLingerDuration returns the linger duration for traffic cut off from a shard.
 	ShardCutoffLingerDuration() time.Duration
 
+	// SetReshardMirroringEnabled sets whether writes are mirrored to the shard owners
+	// in both the old and the new placement when the number of shards changes, starting
+	// from the shard cutover warmup duration before the new placement cuts over until
+	// the shard cutoff linger duration after.
+	SetReshardMirroringEnabled(value bool) Options
+
+	// ReshardMirroringEnabled returns whether writes are mirrored to the shard owners
+	// in both the old and the new placement when the number of shards changes.
+	ReshardMirroringEnabled() bool
+
 	// SetConnectionOptions sets the connection options.
 	SetConnectionOptions(value ConnectionOptions) Options
 
@@ -226,6 +236,7 @@ type options struct {
 	shardFn                    sharding.ShardFn
 	shardCutoverWarmupDuration time.Duration
 	shardCutoffLingerDuration  time.Duration
+	reshardMirroringEnabled    bool
 	watcherOpts                placement.StagedPlacementWatcherOptions
 	connOpts                   ConnectionOptions
 	flushSize                  int
@@ -365,6 +376,16 @@ func (o *options) StagedPlacementWatcherOptions() placement.StagedPlacementWatch
 	return o.watcherOpts
 }
 
+func (o *options) SetReshardMirroringEnabled(value bool) Options {
+	opts := *o
+	opts.reshardMirroringEnabled = value
+	return &opts
+}
+
+func (o *options) ReshardMirroringEnabled() bool {
+	return o.reshardMirroringEnabled
+}
+
 func (o *options) SetConnectionOptions(value ConnectionOptions) Options {
 	opts := *o
 	opts.connOpts = value
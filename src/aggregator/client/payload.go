@@ -72,3 +72,16 @@ type payloadUnion struct {
 	timedWithStagedMetadatas timedWithStagedMetadatas
 	passthrough              passthroughPayload
 }
+
+func newUntimedPayloadUnion(
+	metric unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) payloadUnion {
+	return payloadUnion{
+		payloadType: untimedType,
+		untimed: untimedPayload{
+			metric:    metric,
+			metadatas: metadatas,
+		},
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"github.com/m3db/m3/src/aggregator/verify"
+	"github.com/m3db/m3/src/metrics/metadata"
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/metrics/policy"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	xsampler "github.com/m3db/m3/src/x/sampler"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+type shadowClientMetrics struct {
+	shadowed     tally.Counter
+	shadowErrors tally.Counter
+}
+
+func newShadowClientMetrics(scope tally.Scope) shadowClientMetrics {
+	return shadowClientMetrics{
+		shadowed:     scope.Counter("shadowed"),
+		shadowErrors: scope.Counter("shadow-errors"),
+	}
+}
+
+// shadowClient writes metrics to the primary aggregator cluster and mirrors
+// the writes of a sampled fraction of metric IDs to a shadow aggregator cluster
+// being validated before migration. Errors writing to the shadow cluster are
+// logged and counted but never returned.
+type shadowClient struct {
+	primary    AdminClient
+	shadow     AdminClient
+	sampleRate xsampler.Rate
+	logger     *zap.Logger
+	metrics    shadowClientMetrics
+}
+
+// NewShadowClient creates a new client that writes to the primary client and
+// mirrors the writes of the metric IDs sampled at the sample rate to the shadow
+// client.
+func NewShadowClient(
+	primary AdminClient,
+	shadow AdminClient,
+	sampleRate xsampler.Rate,
+	instrumentOpts instrument.Options,
+) (AdminClient, error) {
+	if err := sampleRate.Validate(); err != nil {
+		return nil, err
+	}
+	return &shadowClient{
+		primary:    primary,
+		shadow:     shadow,
+		sampleRate: sampleRate,
+		logger:     instrumentOpts.Logger(),
+		metrics:    newShadowClientMetrics(instrumentOpts.MetricsScope().SubScope("shadow")),
+	}, nil
+}
+
+func (c *shadowClient) Init() error {
+	if err := c.primary.Init(); err != nil {
+		return err
+	}
+	return c.shadow.Init()
+}
+
+func (c *shadowClient) WriteUntimedCounter(
+	counter unaggregated.Counter,
+	metadatas metadata.StagedMetadatas,
+) error {
+	if c.isSampled(counter.ID) {
+		c.recordShadowErr(c.shadow.WriteUntimedCounter(counter, metadatas))
+	}
+	return c.primary.WriteUntimedCounter(counter, metadatas)
+}
+
+func (c *shadowClient) WriteUntimedBatchTimer(
+	batchTimer unaggregated.BatchTimer,
+	metadatas metadata.StagedMetadatas,
+) error {
+	if c.isSampled(batchTimer.ID) {
+		c.recordShadowErr(c.shadow.WriteUntimedBatchTimer(batchTimer, metadatas))
+	}
+	return c.primary.WriteUntimedBatchTimer(batchTimer, metadatas)
+}
+
+func (c *shadowClient) WriteUntimedGauge(
+	gauge unaggregated.Gauge,
+	metadatas metadata.StagedMetadatas,
+) error {
+	if c.isSampled(gauge.ID) {
+		c.recordShadowErr(c.shadow.WriteUntimedGauge(gauge, metadatas))
+	}
+	return c.primary.WriteUntimedGauge(gauge, metadatas)
+}
+
+func (c *shadowClient) WriteUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) error {
+	var sampled []unaggregated.MetricUnion
+	for _, mu := range metrics {
+		if c.isSampled(mu.ID) {
+			sampled = append(sampled, mu)
+		}
+	}
+	if len(sampled) > 0 {
+		c.recordShadowErr(c.shadow.WriteUntimedBatch(sampled, metadatas))
+	}
+	return c.primary.WriteUntimedBatch(metrics, metadatas)
+}
+
+func (c *shadowClient) WriteTimed(
+	metric aggregated.Metric,
+	metadata metadata.TimedMetadata,
+) error {
+	if c.isSampled(metric.ID) {
+		c.recordShadowErr(c.shadow.WriteTimed(metric, metadata))
+	}
+	return c.primary.WriteTimed(metric, metadata)
+}
+
+func (c *shadowClient) WritePassthrough(
+	metric aggregated.Metric,
+	storagePolicy policy.StoragePolicy,
+) error {
+	if c.isSampled(metric.ID) {
+		c.recordShadowErr(c.shadow.WritePassthrough(metric, storagePolicy))
+	}
+	return c.primary.WritePassthrough(metric, storagePolicy)
+}
+
+func (c *shadowClient) WriteTimedWithStagedMetadatas(
+	metric aggregated.Metric,
+	metadatas metadata.StagedMetadatas,
+) error {
+	if c.isSampled(metric.ID) {
+		c.recordShadowErr(c.shadow.WriteTimedWithStagedMetadatas(metric, metadatas))
+	}
+	return c.primary.WriteTimedWithStagedMetadatas(metric, metadatas)
+}
+
+// WriteForwarded is not mirrored since forwarded metrics are rollups of
+// many source IDs, the shadow cluster forwards its own rollups of the
+// sampled source IDs it receives.
+func (c *shadowClient) WriteForwarded(
+	metric aggregated.ForwardedMetric,
+	metadata metadata.ForwardMetadata,
+) error {
+	return c.primary.WriteForwarded(metric, metadata)
+}
+
+func (c *shadowClient) Flush() error {
+	if err := c.shadow.Flush(); err != nil {
+		c.recordShadowErr(err)
+	}
+	return c.primary.Flush()
+}
+
+func (c *shadowClient) Close() error {
+	multiErr := xerrors.NewMultiError()
+	if err := c.primary.Close(); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	if err := c.shadow.Close(); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	return multiErr.FinalError()
+}
+
+func (c *shadowClient) isSampled(id []byte) bool {
+	return verify.IsSampled(id, c.sampleRate)
+}
+
+func (c *shadowClient) recordShadowErr(err error) {
+	if err == nil {
+		c.metrics.shadowed.Inc(1)
+		return
+	}
+	c.metrics.shadowErrors.Inc(1)
+	c.logger.Debug("error writing to shadow client", zap.Error(err))
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestNewShadowClientInvalidSampleRate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, err := NewShadowClient(NewMockAdminClient(ctrl), NewMockAdminClient(ctrl),
+		2, instrument.NewOptions())
+	require.Error(t, err)
+}
+
+func TestShadowClientWriteSampled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := NewMockAdminClient(ctrl)
+	primary.EXPECT().WriteUntimedCounter(testCounter.Counter(), testStagedMetadatas).Return(nil)
+	primary.EXPECT().WriteTimed(testTimed, testTimedMetadata).Return(nil)
+	shadow := NewMockAdminClient(ctrl)
+	shadow.EXPECT().WriteUntimedCounter(testCounter.Counter(), testStagedMetadatas).Return(nil)
+	shadow.EXPECT().WriteTimed(testTimed, testTimedMetadata).Return(errors.New("shadow error"))
+
+	scope := tally.NewTestScope("", nil)
+	c, err := NewShadowClient(primary, shadow, 1,
+		instrument.NewOptions().SetMetricsScope(scope))
+	require.NoError(t, err)
+
+	require.NoError(t, c.WriteUntimedCounter(testCounter.Counter(), testStagedMetadatas))
+	// Errors writing to the shadow client are not returned.
+	require.NoError(t, c.WriteTimed(testTimed, testTimedMetadata))
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["shadow.shadowed+"].Value())
+	require.Equal(t, int64(1), counters["shadow.shadow-errors+"].Value())
+}
+
+func TestShadowClientWriteNotSampled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metrics := []unaggregated.MetricUnion{testCounter, testGauge}
+	primary := NewMockAdminClient(ctrl)
+	primary.EXPECT().WriteUntimedGauge(testGauge.Gauge(), testStagedMetadatas).Return(nil)
+	primary.EXPECT().WriteUntimedBatch(metrics, testStagedMetadatas).Return(nil)
+	primary.EXPECT().Flush().Return(nil)
+	shadow := NewMockAdminClient(ctrl)
+	shadow.EXPECT().Flush().Return(nil)
+
+	c, err := NewShadowClient(primary, shadow, 0, instrument.NewOptions())
+	require.NoError(t, err)
+
+	require.NoError(t, c.WriteUntimedGauge(testGauge.Gauge(), testStagedMetadatas))
+	require.NoError(t, c.WriteUntimedBatch(metrics, testStagedMetadatas))
+	require.NoError(t, c.Flush())
+}
@@ -24,7 +24,6 @@ package integration
 
 import (
 	"sort"
-	"sync"
 	"testing"
 	"time"
 
@@ -109,19 +108,8 @@ func testCustomAggregations(t *testing.T, metadataFns [4]metadataFn) {
 		SetAggregationTypesOptions(aggTypesOpts)
 
 	// Clock setup.
-	var lock sync.RWMutex
-	now := time.Now().Truncate(time.Hour)
-	getNowFn := func() time.Time {
-		lock.RLock()
-		t := now
-		lock.RUnlock()
-		return t
-	}
-	setNowFn := func(t time.Time) {
-		lock.Lock()
-		now = t
-		lock.Unlock()
-	}
+	testClock := newTestClock(time.Now().Truncate(time.Hour))
+	getNowFn, setNowFn := testClock.Now, testClock.SetNow
 	clockOpts := clock.NewOptions().SetNowFn(getNowFn)
 	serverOpts = serverOpts.SetClockOptions(clockOpts)
 
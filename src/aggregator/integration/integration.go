@@ -21,6 +21,7 @@
 package integration
 
 import (
+	"sync"
 	"time"
 )
 
@@ -36,3 +37,28 @@ func waitUntil(fn conditionFn, timeout time.Duration) bool {
 	}
 	return false
 }
+
+// testClock is a manually advanced clock shared between the test and the
+// servers under test so that flushes can be triggered deterministically.
+type testClock struct {
+	sync.RWMutex
+
+	now time.Time
+}
+
+func newTestClock(now time.Time) *testClock {
+	return &testClock{now: now}
+}
+
+func (c *testClock) Now() time.Time {
+	c.RLock()
+	now := c.now
+	c.RUnlock()
+	return now
+}
+
+func (c *testClock) SetNow(now time.Time) {
+	c.Lock()
+	c.now = now
+	c.Unlock()
+}
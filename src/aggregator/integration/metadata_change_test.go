@@ -24,7 +24,6 @@ package integration
 
 import (
 	"sort"
-	"sync"
 	"testing"
 	"time"
 
@@ -75,19 +74,8 @@ func testMetadataChange(t *testing.T, oldMetadataFn, newMetadataFn metadataFn) {
 	serverOpts := newTestServerOptions()
 
 	// Clock setup.
-	var lock sync.RWMutex
-	now := time.Now().Truncate(time.Hour)
-	getNowFn := func() time.Time {
-		lock.RLock()
-		t := now
-		lock.RUnlock()
-		return t
-	}
-	setNowFn := func(t time.Time) {
-		lock.Lock()
-		now = t
-		lock.Unlock()
-	}
+	testClock := newTestClock(time.Now().Truncate(time.Hour))
+	getNowFn, setNowFn := testClock.Now, testClock.SetNow
 	clockOpts := clock.NewOptions().SetNowFn(getNowFn)
 	serverOpts = serverOpts.SetClockOptions(clockOpts)
 
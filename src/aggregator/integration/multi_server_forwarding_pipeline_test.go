@@ -66,19 +66,8 @@ func testMultiServerForwardingPipeline(t *testing.T, discardNaNAggregatedValues
 	}
 
 	// Clock setup.
-	var lock sync.RWMutex
-	now := time.Now().Truncate(time.Hour)
-	getNowFn := func() time.Time {
-		lock.RLock()
-		t := now
-		lock.RUnlock()
-		return t
-	}
-	setNowFn := func(t time.Time) {
-		lock.Lock()
-		now = t
-		lock.Unlock()
-	}
+	testClock := newTestClock(time.Now().Truncate(time.Hour))
+	getNowFn, setNowFn := testClock.Now, testClock.SetNow
 	clockOpts := clock.NewOptions().SetNowFn(getNowFn)
 
 	// Placement setup.
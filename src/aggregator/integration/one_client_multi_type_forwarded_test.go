@@ -23,7 +23,6 @@
 package integration
 
 import (
-	"sync"
 	"testing"
 	"time"
 
@@ -45,19 +44,8 @@ func TestOneClientMultiTypeForwardedMetrics(t *testing.T) {
 	serverOpts := newTestServerOptions()
 
 	// Clock setup.
-	var lock sync.RWMutex
-	now := time.Now().Truncate(time.Hour)
-	getNowFn := func() time.Time {
-		lock.RLock()
-		t := now
-		lock.RUnlock()
-		return t
-	}
-	setNowFn := func(t time.Time) {
-		lock.Lock()
-		now = t
-		lock.Unlock()
-	}
+	testClock := newTestClock(time.Now().Truncate(time.Hour))
+	getNowFn, setNowFn := testClock.Now, testClock.SetNow
 	clockOpts := clock.NewOptions().SetNowFn(getNowFn)
 	serverOpts = serverOpts.SetClockOptions(clockOpts)
 
@@ -268,6 +268,8 @@ func (ts *testServerSetup) startServer() error {
 			ts.rawTCPServerOpts,
 			ts.httpAddr,
 			ts.httpServerOpts,
+			"",
+			nil,
 			ts.aggregator,
 			ts.doneCh,
 			instrumentOpts,
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// HandlerURL is the url of the ingest samples debug handler.
+	HandlerURL = "/debug/ingest/samples"
+
+	idParam     = "id"
+	sourceParam = "source"
+)
+
+// Response is the response of the ingest samples debug handler.
+type Response struct {
+	Samples []Sample `json:"samples"`
+}
+
+type handler struct {
+	sampler *Sampler
+	logger  *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the retained samples,
+// optionally filtered to those whose ID or source address contain the id
+// and source query parameters.
+func NewHandler(sampler *Sampler, logger *zap.Logger) http.Handler {
+	return &handler{sampler: sampler, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	var (
+		id      = r.URL.Query().Get(idParam)
+		source  = r.URL.Query().Get(sourceParam)
+		samples = h.sampler.Samples()
+		matched = make([]Sample, 0, len(samples))
+	)
+	for _, sample := range samples {
+		if !strings.Contains(sample.ID, id) ||
+			!strings.Contains(sample.SourceAddress, source) {
+			continue
+		}
+		matched = append(matched, sample)
+	}
+
+	xhttp.WriteJSONResponse(w, Response{Samples: matched}, h.logger)
+}
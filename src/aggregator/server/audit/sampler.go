@@ -0,0 +1,218 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package audit provides sampling of ingested metrics to answer questions
+// about where a metric is coming from and which policies it matched.
+package audit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/metrics/encoding"
+	"github.com/m3db/m3/src/metrics/metadata"
+	"github.com/m3db/m3/src/metrics/metric"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/x/clock"
+	xsampler "github.com/m3db/m3/src/x/sampler"
+)
+
+const defaultCapacity = 1024
+
+var errInvalidCapacity = errors.New("ingest sampler capacity must be positive")
+
+// Sample is an ingested metric recorded by a sampler.
+type Sample struct {
+	Time            time.Time `json:"time"`
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	SourceAddress   string    `json:"sourceAddress"`
+	StoragePolicies []string  `json:"storagePolicies"`
+	Pipelines       []string  `json:"pipelines,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// Configuration configures an ingest sampler.
+type Configuration struct {
+	// Rate is the fraction of ingested metrics that are sampled.
+	Rate xsampler.Rate `yaml:"rate"`
+
+	// Capacity is the number of most recent samples retained.
+	Capacity int `yaml:"capacity"`
+}
+
+// NewSampler creates a new ingest sampler.
+func (c Configuration) NewSampler(clockOpts clock.Options) (*Sampler, error) {
+	capacity := c.Capacity
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+	return NewSampler(c.Rate, capacity, clockOpts.NowFn())
+}
+
+// Sampler records a fraction of ingested metrics in a fixed size ring
+// buffer, overwriting the oldest samples once full.
+type Sampler struct {
+	sync.Mutex
+
+	sampler *xsampler.Sampler
+	nowFn   clock.NowFn
+	samples []Sample
+	next    int
+	full    bool
+}
+
+// NewSampler creates a new ingest sampler retaining up to capacity samples.
+func NewSampler(
+	rate xsampler.Rate,
+	capacity int,
+	nowFn clock.NowFn,
+) (*Sampler, error) {
+	if capacity <= 0 {
+		return nil, errInvalidCapacity
+	}
+	sampler, err := xsampler.NewSampler(rate)
+	if err != nil {
+		return nil, err
+	}
+	return &Sampler{
+		sampler: sampler,
+		nowFn:   nowFn,
+		samples: make([]Sample, capacity),
+	}, nil
+}
+
+// Sample returns true if the next ingested metric should be recorded, a nil
+// sampler never samples so callers need not check whether sampling is on.
+func (s *Sampler) Sample() bool {
+	return s != nil && s.sampler.Sample()
+}
+
+// Record records a sample, setting its time if unset.
+func (s *Sampler) Record(sample Sample) {
+	if sample.Time.IsZero() {
+		sample.Time = s.nowFn()
+	}
+
+	s.Lock()
+	s.samples[s.next] = sample
+	s.next++
+	if s.next == len(s.samples) {
+		s.next = 0
+		s.full = true
+	}
+	s.Unlock()
+}
+
+// Samples returns the retained samples, oldest first.
+func (s *Sampler) Samples() []Sample {
+	s.Lock()
+	defer s.Unlock()
+
+	if !s.full {
+		return append([]Sample(nil), s.samples[:s.next]...)
+	}
+	samples := make([]Sample, 0, len(s.samples))
+	samples = append(samples, s.samples[s.next:]...)
+	return append(samples, s.samples[:s.next]...)
+}
+
+// NewSample returns a sample of the given ingested message, the error is
+// the error returned when adding the message to the aggregator, if any.
+func NewSample(
+	msg encoding.UnaggregatedMessageUnion,
+	sourceAddress string,
+	err error,
+) Sample {
+	sample := Sample{SourceAddress: sourceAddress}
+	if err != nil {
+		sample.Error = err.Error()
+	}
+
+	switch msg.Type {
+	case encoding.CounterWithMetadatasType:
+		sample.ID = string(msg.CounterWithMetadatas.ID)
+		sample.Type = metric.CounterType.String()
+		sample.StoragePolicies, sample.Pipelines =
+			StagedMetadatasPolicies(msg.CounterWithMetadatas.StagedMetadatas)
+	case encoding.BatchTimerWithMetadatasType:
+		sample.ID = string(msg.BatchTimerWithMetadatas.ID)
+		sample.Type = metric.TimerType.String()
+		sample.StoragePolicies, sample.Pipelines =
+			StagedMetadatasPolicies(msg.BatchTimerWithMetadatas.StagedMetadatas)
+	case encoding.GaugeWithMetadatasType:
+		sample.ID = string(msg.GaugeWithMetadatas.ID)
+		sample.Type = metric.GaugeType.String()
+		sample.StoragePolicies, sample.Pipelines =
+			StagedMetadatasPolicies(msg.GaugeWithMetadatas.StagedMetadatas)
+	case encoding.ForwardedMetricWithMetadataType:
+		forwarded := msg.ForwardedMetricWithMetadata
+		sample.ID = string(forwarded.ForwardedMetric.ID)
+		sample.Type = forwarded.ForwardedMetric.Type.String()
+		sample.StoragePolicies = []string{forwarded.ForwardMetadata.StoragePolicy.String()}
+		if !forwarded.ForwardMetadata.Pipeline.IsEmpty() {
+			sample.Pipelines = []string{forwarded.ForwardMetadata.Pipeline.String()}
+		}
+	case encoding.TimedMetricWithMetadataType:
+		timed := msg.TimedMetricWithMetadata
+		sample.ID = string(timed.Metric.ID)
+		sample.Type = timed.Metric.Type.String()
+		sample.StoragePolicies = []string{timed.TimedMetadata.StoragePolicy.String()}
+	case encoding.TimedMetricWithMetadatasType:
+		timed := msg.TimedMetricWithMetadatas
+		sample.ID = string(timed.Metric.ID)
+		sample.Type = timed.Metric.Type.String()
+		sample.StoragePolicies, sample.Pipelines =
+			StagedMetadatasPolicies(timed.StagedMetadatas)
+	case encoding.PassthroughMetricWithMetadataType:
+		passthrough := msg.PassthroughMetricWithMetadata
+		sample.ID = string(passthrough.Metric.ID)
+		sample.Type = passthrough.Metric.Type.String()
+		sample.StoragePolicies = []string{passthrough.StoragePolicy.String()}
+	}
+
+	return sample
+}
+
+// StagedMetadatasPolicies returns the distinct storage policies and the
+// non-empty pipelines of the given staged metadatas.
+func StagedMetadatasPolicies(metadatas metadata.StagedMetadatas) ([]string, []string) {
+	var (
+		policies  []string
+		pipelines []string
+		seen      = make(map[policy.StoragePolicy]struct{})
+	)
+	for _, sm := range metadatas {
+		for _, pipeline := range sm.Pipelines {
+			for _, sp := range pipeline.StoragePolicies {
+				if _, ok := seen[sp]; ok {
+					continue
+				}
+				seen[sp] = struct{}{}
+				policies = append(policies, sp.String())
+			}
+			if !pipeline.Pipeline.IsEmpty() {
+				pipelines = append(pipelines, pipeline.Pipeline.String())
+			}
+		}
+	}
+	return policies, pipelines
+}
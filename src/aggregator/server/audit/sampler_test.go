@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/metrics/encoding"
+	"github.com/m3db/m3/src/metrics/metadata"
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/metrics/metric/id"
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/metrics/policy"
+	xsampler "github.com/m3db/m3/src/x/sampler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testNow = time.Unix(1600000000, 0)
+
+func newTestSampler(t *testing.T, rate float64, capacity int) *Sampler {
+	s, err := NewSampler(xsampler.Rate(rate), capacity, func() time.Time { return testNow })
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewSamplerInvalid(t *testing.T) {
+	_, err := NewSampler(1, 0, time.Now)
+	require.Equal(t, errInvalidCapacity, err)
+
+	_, err = NewSampler(2, 1, time.Now)
+	require.Error(t, err)
+}
+
+func TestSamplerSample(t *testing.T) {
+	var s *Sampler
+	assert.False(t, s.Sample())
+
+	s = newTestSampler(t, 0, 1)
+	assert.False(t, s.Sample())
+
+	s = newTestSampler(t, 0.5, 1)
+	sampled := 0
+	for i := 0; i < 10; i++ {
+		if s.Sample() {
+			sampled++
+		}
+	}
+	assert.Equal(t, 5, sampled)
+}
+
+func TestSamplerRecordWrapsAround(t *testing.T) {
+	s := newTestSampler(t, 1, 3)
+	assert.Empty(t, s.Samples())
+
+	for _, id := range []string{"a", "b"} {
+		s.Record(Sample{ID: id})
+	}
+	samples := s.Samples()
+	require.Len(t, samples, 2)
+	assert.Equal(t, "a", samples[0].ID)
+	assert.Equal(t, testNow, samples[0].Time)
+
+	for _, id := range []string{"c", "d", "e"} {
+		s.Record(Sample{ID: id})
+	}
+	var ids []string
+	for _, sample := range s.Samples() {
+		ids = append(ids, sample.ID)
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, ids)
+}
+
+func TestNewSample(t *testing.T) {
+	sp := policy.MustParseStoragePolicy("10s:2d")
+	msg := encoding.UnaggregatedMessageUnion{
+		Type: encoding.CounterWithMetadatasType,
+		CounterWithMetadatas: unaggregated.CounterWithMetadatas{
+			Counter: unaggregated.Counter{ID: id.RawID("foo"), Value: 1},
+			StagedMetadatas: metadata.StagedMetadatas{{
+				Metadata: metadata.Metadata{Pipelines: []metadata.PipelineMetadata{
+					{StoragePolicies: policy.StoragePolicies{sp}},
+					{StoragePolicies: policy.StoragePolicies{sp}},
+				}},
+			}},
+		},
+	}
+
+	sample := NewSample(msg, "127.0.0.1:1234", errors.New("boom"))
+	assert.Equal(t, Sample{
+		ID:              "foo",
+		Type:            "counter",
+		SourceAddress:   "127.0.0.1:1234",
+		StoragePolicies: []string{sp.String()},
+		Error:           "boom",
+	}, sample)
+
+	msg = encoding.UnaggregatedMessageUnion{
+		Type: encoding.PassthroughMetricWithMetadataType,
+		PassthroughMetricWithMetadata: aggregated.PassthroughMetricWithMetadata{
+			Metric:        aggregated.Metric{ID: id.RawID("bar")},
+			StoragePolicy: sp,
+		},
+	}
+	sample = NewSample(msg, "127.0.0.1:1234", nil)
+	assert.Equal(t, "bar", sample.ID)
+	assert.Equal(t, []string{sp.String()}, sample.StoragePolicies)
+	assert.Empty(t, sample.Error)
+}
+
+func TestHandler(t *testing.T) {
+	s := newTestSampler(t, 1, 10)
+	s.Record(Sample{ID: "foo.bar", SourceAddress: "10.0.0.1:1234"})
+	s.Record(Sample{ID: "foo.baz", SourceAddress: "10.0.0.2:1234"})
+	s.Record(Sample{ID: "qux", SourceAddress: "10.0.0.1:1234"})
+	h := NewHandler(s, zap.NewNop())
+
+	serve := func(url string) []string {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		var ids []string
+		for _, sample := range resp.Samples {
+			ids = append(ids, sample.ID)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"foo.bar", "foo.baz", "qux"}, serve(HandlerURL))
+	assert.Equal(t, []string{"foo.bar", "foo.baz"}, serve(HandlerURL+"?id=foo"))
+	assert.Equal(t, []string{"foo.bar", "qux"}, serve(HandlerURL+"?source=10.0.0.1"))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, HandlerURL, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -29,11 +29,13 @@ import (
 
 	"github.com/m3db/m3/src/aggregator/aggregator"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/health"
 )
 
 // A list of HTTP endpoints.
 const (
-	HealthPath = "/health"
+	HealthPath = health.LivenessURL
+	ReadyPath  = health.ReadinessURL
 	ResignPath = "/resign"
 	StatusPath = "/status"
 )
@@ -43,13 +45,18 @@ var (
 	errRequestMustBePost = xerrors.NewInvalidParamsError(errors.New("request must be POST"))
 )
 
-func registerHandlers(mux *http.ServeMux, aggregator aggregator.Aggregator) {
-	registerHealthHandler(mux)
+func registerHandlers(
+	mux *http.ServeMux,
+	aggregator aggregator.Aggregator,
+	registry health.Registry,
+) {
+	registerHealthHandler(mux, registry)
+	registerReadyHandler(mux, registry)
 	registerResignHandler(mux, aggregator)
 	registerStatusHandler(mux, aggregator)
 }
 
-func registerHealthHandler(mux *http.ServeMux) {
+func registerHealthHandler(mux *http.ServeMux, registry health.Registry) {
 	mux.HandleFunc(HealthPath, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -57,10 +64,26 @@ func registerHealthHandler(mux *http.ServeMux) {
 			writeErrorResponse(w, errRequestMustBeGet)
 			return
 		}
+		if report := registry.Check(r.Context(), health.Liveness); !report.Healthy {
+			health.WriteReport(w, report, report)
+			return
+		}
 		writeSuccessResponse(w)
 	})
 }
 
+func registerReadyHandler(mux *http.ServeMux, registry health.Registry) {
+	ready := health.NewHandler(registry, health.Readiness)
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, r *http.Request) {
+		if httpMethod := strings.ToUpper(r.Method); httpMethod != http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			writeErrorResponse(w, errRequestMustBeGet)
+			return
+		}
+		ready.ServeHTTP(w, r)
+	})
+}
+
 func registerResignHandler(mux *http.ServeMux, aggregator aggregator.Aggregator) {
 	mux.HandleFunc(ResignPath, func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
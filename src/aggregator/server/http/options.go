@@ -20,7 +20,15 @@
 
 package http
 
-import "time"
+import (
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
+	"github.com/m3db/m3/src/aggregator/verify"
+	"github.com/m3db/m3/src/aggregator/writetrace"
+	"github.com/m3db/m3/src/x/health"
+)
 
 const (
 	defaultReadTimeout  = 10 * time.Second
@@ -40,11 +48,63 @@ type Options interface {
 
 	// WriteTimeout returns the write timeout.
 	WriteTimeout() time.Duration
+
+	// SetIngestSampler sets the sampler of ingested metrics exposed by the
+	// ingest samples debug handler, nil disables the handler.
+	SetIngestSampler(value *audit.Sampler) Options
+
+	// IngestSampler returns the sampler of ingested metrics.
+	IngestSampler() *audit.Sampler
+
+	// SetHealthRegistry sets the registry of health checks reported by the
+	// health and readiness handlers.
+	SetHealthRegistry(value health.Registry) Options
+
+	// HealthRegistry returns the registry of health checks.
+	HealthRegistry() health.Registry
+
+	// SetShadowChecksums sets the checksums of flushed metrics per aggregation
+	// window whose source IDs are sampled exposed by the shadow checksums
+	// debug handler, nil disables the handler.
+	SetShadowChecksums(value *verify.WindowChecksums) Options
+
+	// ShadowChecksums returns the checksums of flushed metrics per aggregation
+	// window whose source IDs are sampled.
+	ShadowChecksums() *verify.WindowChecksums
+
+	// SetWindowChecksums sets the checksums of flushed metrics per aggregation
+	// window exposed by the window checksums debug handler, nil disables the
+	// handler.
+	SetWindowChecksums(value *verify.WindowChecksums) Options
+
+	// WindowChecksums returns the checksums of flushed metrics per aggregation
+	// window.
+	WindowChecksums() *verify.WindowChecksums
+
+	// SetQuarantine sets the quarantine of ingest clients exposed by the
+	// quarantine debug handler, nil disables the handler.
+	SetQuarantine(value *quarantine.Quarantine) Options
+
+	// Quarantine returns the quarantine of ingest clients.
+	Quarantine() *quarantine.Quarantine
+
+	// SetWriteTracer sets the tracer of sampled writes exposed by the write
+	// traces debug handler, nil disables the handler.
+	SetWriteTracer(value *writetrace.Tracer) Options
+
+	// WriteTracer returns the tracer of sampled writes.
+	WriteTracer() *writetrace.Tracer
 }
 
 type options struct {
-	readTimeout  time.Duration
-	writeTimeout time.Duration
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	ingestSampler   *audit.Sampler
+	healthRegistry  health.Registry
+	shadowChecksums *verify.WindowChecksums
+	windowChecksums *verify.WindowChecksums
+	quarantine      *quarantine.Quarantine
+	writeTracer     *writetrace.Tracer
 }
 
 // NewOptions creates a new set of server options.
@@ -74,3 +134,63 @@ func (o *options) SetWriteTimeout(value time.Duration) Options {
 func (o *options) WriteTimeout() time.Duration {
 	return o.writeTimeout
 }
+
+func (o *options) SetIngestSampler(value *audit.Sampler) Options {
+	opts := *o
+	opts.ingestSampler = value
+	return &opts
+}
+
+func (o *options) IngestSampler() *audit.Sampler {
+	return o.ingestSampler
+}
+
+func (o *options) SetHealthRegistry(value health.Registry) Options {
+	opts := *o
+	opts.healthRegistry = value
+	return &opts
+}
+
+func (o *options) HealthRegistry() health.Registry {
+	return o.healthRegistry
+}
+
+func (o *options) SetShadowChecksums(value *verify.WindowChecksums) Options {
+	opts := *o
+	opts.shadowChecksums = value
+	return &opts
+}
+
+func (o *options) ShadowChecksums() *verify.WindowChecksums {
+	return o.shadowChecksums
+}
+
+func (o *options) SetWindowChecksums(value *verify.WindowChecksums) Options {
+	opts := *o
+	opts.windowChecksums = value
+	return &opts
+}
+
+func (o *options) WindowChecksums() *verify.WindowChecksums {
+	return o.windowChecksums
+}
+
+func (o *options) SetQuarantine(value *quarantine.Quarantine) Options {
+	opts := *o
+	opts.quarantine = value
+	return &opts
+}
+
+func (o *options) Quarantine() *quarantine.Quarantine {
+	return o.quarantine
+}
+
+func (o *options) SetWriteTracer(value *writetrace.Tracer) Options {
+	opts := *o
+	opts.writeTracer = value
+	return &opts
+}
+
+func (o *options) WriteTracer() *writetrace.Tracer {
+	return o.writeTracer
+}
@@ -27,7 +27,12 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/aggregator/aggregator"
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
+	"github.com/m3db/m3/src/aggregator/verify"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	xdebug "github.com/m3db/m3/src/x/debug"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pprof"
 	xserver "github.com/m3db/m3/src/x/server"
@@ -72,7 +77,12 @@ func (s *server) ListenAndServe() error {
 
 func (s *server) Serve(l net.Listener) error {
 	mux := http.NewServeMux()
-	registerHandlers(mux, s.aggregator)
+	registry := s.opts.HealthRegistry()
+	if registry == nil {
+		registry = health.NewRegistry(health.NewOptions().
+			SetInstrumentOptions(s.iOpts))
+	}
+	registerHandlers(mux, s.aggregator, registry)
 	pprof.RegisterHandler(mux)
 
 	// create and register debug handler
@@ -88,6 +98,26 @@ func (s *server) Serve(l net.Listener) error {
 		return fmt.Errorf("unable to register debug writer endpoint: %v", err)
 	}
 
+	if sampler := s.opts.IngestSampler(); sampler != nil {
+		mux.Handle(audit.HandlerURL, audit.NewHandler(sampler, s.iOpts.Logger()))
+	}
+
+	if q := s.opts.Quarantine(); q != nil {
+		mux.Handle(quarantine.HandlerURL, quarantine.NewHandler(q, s.iOpts.Logger()))
+	}
+
+	if checksums := s.opts.ShadowChecksums(); checksums != nil {
+		mux.Handle(verify.ShadowHandlerURL, verify.NewHandler(checksums, s.iOpts.Logger()))
+	}
+
+	if tracer := s.opts.WriteTracer(); tracer != nil {
+		mux.Handle(writetrace.HandlerURL, writetrace.NewHandler(tracer, s.iOpts.Logger()))
+	}
+
+	if checksums := s.opts.WindowChecksums(); checksums != nil {
+		mux.Handle(verify.HandlerURL, verify.NewHandler(checksums, s.iOpts.Logger()))
+	}
+
 	server := http.Server{
 		Handler:      mux,
 		ReadTimeout:  s.opts.ReadTimeout(),
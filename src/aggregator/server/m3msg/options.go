@@ -23,6 +23,7 @@ package m3msg
 import (
 	"errors"
 
+	"github.com/m3db/m3/src/aggregator/server/audit"
 	"github.com/m3db/m3/src/msg/consumer"
 	"github.com/m3db/m3/src/x/instrument"
 	xserver "github.com/m3db/m3/src/x/server"
@@ -56,12 +57,20 @@ type Options interface {
 
 	// ConsumerOptions returns the consumer options.
 	ConsumerOptions() consumer.Options
+
+	// SetIngestSampler sets the sampler of ingested metrics, nil disables
+	// sampling.
+	SetIngestSampler(value *audit.Sampler) Options
+
+	// IngestSampler returns the sampler of ingested metrics.
+	IngestSampler() *audit.Sampler
 }
 
 type options struct {
 	instrumentOpts instrument.Options
 	serverOpts     xserver.Options
 	consumerOpts   consumer.Options
+	ingestSampler  *audit.Sampler
 }
 
 // NewOptions returns a set of M3Msg options.
@@ -111,3 +120,13 @@ func (o *options) SetConsumerOptions(value consumer.Options) Options {
 func (o *options) ConsumerOptions() consumer.Options {
 	return o.consumerOpts
 }
+
+func (o *options) SetIngestSampler(value *audit.Sampler) Options {
+	opts := *o
+	opts.ingestSampler = value
+	return &opts
+}
+
+func (o *options) IngestSampler() *audit.Sampler {
+	return o.ingestSampler
+}
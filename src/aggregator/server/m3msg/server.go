@@ -25,6 +25,7 @@ import (
 	"io"
 
 	"github.com/m3db/m3/src/aggregator/aggregator"
+	"github.com/m3db/m3/src/aggregator/server/audit"
 	"github.com/m3db/m3/src/metrics/encoding"
 	"github.com/m3db/m3/src/metrics/encoding/protobuf"
 	"github.com/m3db/m3/src/metrics/generated/proto/metricpb"
@@ -34,9 +35,12 @@ import (
 	"go.uber.org/zap"
 )
 
+const unknownRemoteHostAddress = "<unknown>"
+
 type server struct {
-	aggregator aggregator.Aggregator
-	logger     *zap.Logger
+	aggregator    aggregator.Aggregator
+	ingestSampler *audit.Sampler
+	logger        *zap.Logger
 }
 
 // NewServer creates a new M3Msg server.
@@ -50,8 +54,9 @@ func NewServer(
 	}
 
 	s := &server{
-		aggregator: aggregator,
-		logger:     opts.InstrumentOptions().Logger(),
+		aggregator:    aggregator,
+		ingestSampler: opts.IngestSampler(),
+		logger:        opts.InstrumentOptions().Logger(),
 	}
 	handler := consumer.NewConsumerHandler(s.Consume, opts.ConsumerOptions())
 	return xserver.NewServer(address, handler, opts.ServerOptions()), nil
@@ -59,18 +64,22 @@ func NewServer(
 
 func (s *server) Consume(c consumer.Consumer) {
 	var (
-		pb     = &metricpb.MetricWithMetadatas{}
-		union  = &encoding.UnaggregatedMessageUnion{}
-		msgErr error
-		msg    consumer.Message
+		pb            = &metricpb.MetricWithMetadatas{}
+		union         = &encoding.UnaggregatedMessageUnion{}
+		remoteAddress = unknownRemoteHostAddress
+		msgErr        error
+		msg           consumer.Message
 	)
+	if remoteAddr := c.RemoteAddr(); remoteAddr != nil {
+		remoteAddress = remoteAddr.String()
+	}
 	for {
 		msg, msgErr = c.Message()
 		if msgErr != nil {
 			break
 		}
 
-		err := s.handleMessage(pb, union, msg)
+		err := s.handleMessage(pb, union, msg, remoteAddress)
 		if err != nil {
 			s.logger.Error("could not process message", zap.Error(err))
 		}
@@ -85,6 +94,7 @@ func (s *server) handleMessage(
 	pb *metricpb.MetricWithMetadatas,
 	union *encoding.UnaggregatedMessageUnion,
 	msg consumer.Message,
+	remoteAddress string,
 ) error {
 	defer msg.Ack()
 
@@ -96,12 +106,25 @@ func (s *server) handleMessage(
 		return err
 	}
 
+	err := s.addMessage(pb, union)
+	if s.ingestSampler.Sample() {
+		s.ingestSampler.Record(audit.NewSample(*union, remoteAddress, err))
+	}
+	return err
+}
+
+func (s *server) addMessage(
+	pb *metricpb.MetricWithMetadatas,
+	union *encoding.UnaggregatedMessageUnion,
+) error {
+	union.Type = encoding.UnknownMessageType
 	switch pb.Type {
 	case metricpb.MetricWithMetadatas_COUNTER_WITH_METADATAS:
 		err := union.CounterWithMetadatas.FromProto(pb.CounterWithMetadatas)
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.CounterWithMetadatasType
 		return s.aggregator.AddUntimed(
 			union.CounterWithMetadatas.ToUnion(),
 			union.CounterWithMetadatas.StagedMetadatas)
@@ -110,6 +133,7 @@ func (s *server) handleMessage(
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.BatchTimerWithMetadatasType
 		return s.aggregator.AddUntimed(
 			union.BatchTimerWithMetadatas.ToUnion(),
 			union.BatchTimerWithMetadatas.StagedMetadatas)
@@ -118,6 +142,7 @@ func (s *server) handleMessage(
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.GaugeWithMetadatasType
 		return s.aggregator.AddUntimed(
 			union.GaugeWithMetadatas.ToUnion(),
 			union.GaugeWithMetadatas.StagedMetadatas)
@@ -126,6 +151,7 @@ func (s *server) handleMessage(
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.ForwardedMetricWithMetadataType
 		return s.aggregator.AddForwarded(
 			union.ForwardedMetricWithMetadata.ForwardedMetric,
 			union.ForwardedMetricWithMetadata.ForwardMetadata)
@@ -134,6 +160,7 @@ func (s *server) handleMessage(
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.TimedMetricWithMetadataType
 		return s.aggregator.AddTimed(
 			union.TimedMetricWithMetadata.Metric,
 			union.TimedMetricWithMetadata.TimedMetadata)
@@ -142,9 +169,19 @@ func (s *server) handleMessage(
 		if err != nil {
 			return err
 		}
+		union.Type = encoding.TimedMetricWithMetadatasType
 		return s.aggregator.AddTimedWithStagedMetadatas(
 			union.TimedMetricWithMetadatas.Metric,
 			union.TimedMetricWithMetadatas.StagedMetadatas)
+	case metricpb.MetricWithMetadatas_TIMED_METRIC_WITH_STORAGE_POLICY:
+		err := union.PassthroughMetricWithMetadata.FromProto(pb.TimedMetricWithStoragePolicy)
+		if err != nil {
+			return err
+		}
+		union.Type = encoding.PassthroughMetricWithMetadataType
+		return s.aggregator.AddPassthrough(
+			union.PassthroughMetricWithMetadata.Metric,
+			union.PassthroughMetricWithMetadata.StoragePolicy)
 	default:
 		return fmt.Errorf("unrecognized message type: %v", pb.Type)
 	}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quarantine
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// HandlerURL is the url of the quarantine debug handler.
+	HandlerURL = "/debug/ingest/quarantine"
+
+	hostParam = "host"
+)
+
+var errMissingHost = errors.New("missing host parameter")
+
+// Response is the response of the quarantine debug handler.
+type Response struct {
+	Quarantined []Client `json:"quarantined"`
+}
+
+type handler struct {
+	quarantine *Quarantine
+	logger     *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the quarantined clients on
+// GET requests, and lifts the quarantine of the client given by the host query
+// parameter on DELETE requests.
+func NewHandler(quarantine *Quarantine, logger *zap.Logger) http.Handler {
+	return &handler{quarantine: quarantine, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		xhttp.WriteJSONResponse(w, Response{Quarantined: h.quarantine.Quarantined()}, h.logger)
+	case http.MethodDelete:
+		host := r.URL.Query().Get(hostParam)
+		if host == "" {
+			xhttp.Error(w, errMissingHost, http.StatusBadRequest)
+			return
+		}
+		if !h.quarantine.Unquarantine(host) {
+			xhttp.Error(w, fmt.Errorf("host is not quarantined: %s", host),
+				http.StatusNotFound)
+			return
+		}
+		h.logger.Info("lifted quarantine of ingest client", zap.String("host", host))
+		xhttp.WriteJSONResponse(w, Response{Quarantined: h.quarantine.Quarantined()}, h.logger)
+	default:
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quarantine tracks decode errors of ingest clients and quarantines
+// clients whose error rate exceeds a threshold, rejecting their connections
+// until the quarantine expires or is lifted manually, to protect the server
+// from corrupt producers.
+package quarantine
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultWindow   = time.Minute
+	defaultDuration = 10 * time.Minute
+)
+
+var (
+	errInvalidMaxErrors = errors.New("quarantine max errors must be positive")
+	errInvalidWindow    = errors.New("quarantine window must be positive")
+	errInvalidDuration  = errors.New("quarantine duration must be positive")
+)
+
+// Configuration configures decode error quarantining.
+type Configuration struct {
+	// MaxErrors is the number of decode errors within a window after which
+	// a client is quarantined.
+	MaxErrors int `yaml:"maxErrors" validate:"min=1"`
+
+	// Window is the window decode errors are counted over.
+	Window time.Duration `yaml:"window"`
+
+	// Duration is how long a client is quarantined for.
+	Duration time.Duration `yaml:"duration"`
+}
+
+// NewQuarantine creates a new quarantine.
+func (c Configuration) NewQuarantine(
+	clockOpts clock.Options,
+	instrumentOpts instrument.Options,
+) (*Quarantine, error) {
+	window := c.Window
+	if window == 0 {
+		window = defaultWindow
+	}
+	duration := c.Duration
+	if duration == 0 {
+		duration = defaultDuration
+	}
+	return NewQuarantine(c.MaxErrors, window, duration, clockOpts.NowFn(),
+		instrumentOpts.MetricsScope().SubScope("quarantine"))
+}
+
+// Client is a quarantined client.
+type Client struct {
+	Host          string    `json:"host"`
+	QuarantinedAt time.Time `json:"quarantinedAt"`
+	Until         time.Time `json:"until"`
+}
+
+type errorWindow struct {
+	start time.Time
+	count int
+}
+
+type quarantineMetrics struct {
+	quarantined   tally.Counter
+	unquarantined tally.Counter
+	expired       tally.Counter
+	rejected      tally.Counter
+}
+
+func newQuarantineMetrics(scope tally.Scope) quarantineMetrics {
+	return quarantineMetrics{
+		quarantined:   scope.Counter("quarantined"),
+		unquarantined: scope.Counter("unquarantined"),
+		expired:       scope.Counter("expired"),
+		rejected:      scope.Counter("rejected"),
+	}
+}
+
+// Quarantine counts decode errors per client host and quarantines hosts
+// with more than the maximum number of errors within a window. Errors are
+// tracked by host rather than by connection since a client whose connection
+// is closed on a decode error reconnects from a different port.
+type Quarantine struct {
+	sync.Mutex
+
+	maxErrors   int
+	window      time.Duration
+	duration    time.Duration
+	nowFn       clock.NowFn
+	errors      map[string]*errorWindow
+	lastExpired time.Time
+	quarantined map[string]Client
+	metrics     quarantineMetrics
+}
+
+// NewQuarantine creates a new quarantine.
+func NewQuarantine(
+	maxErrors int,
+	window time.Duration,
+	duration time.Duration,
+	nowFn clock.NowFn,
+	scope tally.Scope,
+) (*Quarantine, error) {
+	if maxErrors <= 0 {
+		return nil, errInvalidMaxErrors
+	}
+	if window <= 0 {
+		return nil, errInvalidWindow
+	}
+	if duration <= 0 {
+		return nil, errInvalidDuration
+	}
+	return &Quarantine{
+		maxErrors:   maxErrors,
+		window:      window,
+		duration:    duration,
+		nowFn:       nowFn,
+		errors:      make(map[string]*errorWindow),
+		quarantined: make(map[string]Client),
+		metrics:     newQuarantineMetrics(scope),
+	}, nil
+}
+
+// Admit returns false if the host is quarantined and its connection should
+// be rejected, a nil quarantine admits all hosts.
+func (q *Quarantine) Admit(host string) bool {
+	if q == nil {
+		return true
+	}
+	now := q.nowFn()
+
+	q.Lock()
+	defer q.Unlock()
+
+	q.expireErrorsWithLock(now)
+	client, exists := q.quarantined[host]
+	if !exists {
+		return true
+	}
+	if !now.Before(client.Until) {
+		delete(q.quarantined, host)
+		q.metrics.expired.Inc(1)
+		return true
+	}
+	q.metrics.rejected.Inc(1)
+	return false
+}
+
+// RecordError records a decode error of the host and returns true if the
+// host is quarantined as a result, a nil quarantine never quarantines.
+func (q *Quarantine) RecordError(host string) bool {
+	if q == nil {
+		return false
+	}
+	now := q.nowFn()
+
+	q.Lock()
+	defer q.Unlock()
+
+	q.expireErrorsWithLock(now)
+	if _, exists := q.quarantined[host]; exists {
+		return true
+	}
+	errWindow, exists := q.errors[host]
+	if !exists || now.Sub(errWindow.start) >= q.window {
+		errWindow = &errorWindow{start: now}
+		q.errors[host] = errWindow
+	}
+	errWindow.count++
+	if errWindow.count < q.maxErrors {
+		return false
+	}
+	delete(q.errors, host)
+	q.quarantined[host] = Client{
+		Host:          host,
+		QuarantinedAt: now,
+		Until:         now.Add(q.duration),
+	}
+	q.metrics.quarantined.Inc(1)
+	return true
+}
+
+// expireErrorsWithLock removes the error windows that have ended so hosts
+// that stop erroring below the threshold are not tracked forever, it scans
+// the errors at most once per window to keep admitting hosts cheap.
+func (q *Quarantine) expireErrorsWithLock(now time.Time) {
+	if now.Sub(q.lastExpired) < q.window {
+		return
+	}
+	q.lastExpired = now
+	for host, errWindow := range q.errors {
+		if now.Sub(errWindow.start) >= q.window {
+			delete(q.errors, host)
+		}
+	}
+}
+
+// Unquarantine lifts the quarantine of the host, and returns false if the
+// host is not quarantined.
+func (q *Quarantine) Unquarantine(host string) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	if _, exists := q.quarantined[host]; !exists {
+		return false
+	}
+	delete(q.quarantined, host)
+	q.metrics.unquarantined.Inc(1)
+	return true
+}
+
+// Quarantined returns the clients currently quarantined ordered by host.
+func (q *Quarantine) Quarantined() []Client {
+	now := q.nowFn()
+
+	q.Lock()
+	clients := make([]Client, 0, len(q.quarantined))
+	for host, client := range q.quarantined {
+		if !now.Before(client.Until) {
+			delete(q.quarantined, host)
+			q.metrics.expired.Inc(1)
+			continue
+		}
+		clients = append(clients, client)
+	}
+	q.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].Host < clients[j].Host
+	})
+	return clients
+}
+
+// Host returns the host of a remote address, or the address itself if it
+// has no port.
+func Host(remoteAddress string) string {
+	host, _, err := net.SplitHostPort(remoteAddress)
+	if err != nil {
+		return remoteAddress
+	}
+	return host
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quarantine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+func newTestQuarantine(t *testing.T, now *time.Time) *Quarantine {
+	q, err := NewQuarantine(3, time.Minute, 10*time.Minute,
+		func() time.Time { return *now }, tally.NoopScope)
+	require.NoError(t, err)
+	return q
+}
+
+func TestNewQuarantineInvalid(t *testing.T) {
+	_, err := NewQuarantine(0, time.Minute, time.Minute, time.Now, tally.NoopScope)
+	require.Equal(t, errInvalidMaxErrors, err)
+	_, err = NewQuarantine(1, 0, time.Minute, time.Now, tally.NoopScope)
+	require.Equal(t, errInvalidWindow, err)
+	_, err = NewQuarantine(1, time.Minute, 0, time.Now, tally.NoopScope)
+	require.Equal(t, errInvalidDuration, err)
+}
+
+func TestQuarantineExpiresErrors(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	q := newTestQuarantine(t, &now)
+
+	require.False(t, q.RecordError("foo"))
+	require.False(t, q.RecordError("bar"))
+	require.Equal(t, 2, len(q.errors))
+
+	// Windows that have not ended are kept.
+	now = now.Add(30 * time.Second)
+	require.True(t, q.Admit("baz"))
+	require.Equal(t, 2, len(q.errors))
+
+	// Ended windows of hosts below the threshold are removed.
+	now = now.Add(time.Minute)
+	require.True(t, q.Admit("baz"))
+	require.Equal(t, 0, len(q.errors))
+}
+
+func TestQuarantineNil(t *testing.T) {
+	var q *Quarantine
+	require.True(t, q.Admit("foo"))
+	require.False(t, q.RecordError("foo"))
+}
+
+func TestQuarantineRecordError(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	q := newTestQuarantine(t, &now)
+
+	// Errors spread across windows do not quarantine.
+	require.False(t, q.RecordError("foo"))
+	require.False(t, q.RecordError("foo"))
+	now = now.Add(time.Minute)
+	require.False(t, q.RecordError("foo"))
+	require.True(t, q.Admit("foo"))
+
+	// Errors within a window quarantine.
+	require.False(t, q.RecordError("foo"))
+	require.True(t, q.RecordError("foo"))
+	require.False(t, q.Admit("foo"))
+	require.True(t, q.Admit("bar"))
+	require.Equal(t, []Client{{
+		Host:          "foo",
+		QuarantinedAt: now,
+		Until:         now.Add(10 * time.Minute),
+	}}, q.Quarantined())
+
+	// The quarantine expires.
+	now = now.Add(10 * time.Minute)
+	require.True(t, q.Admit("foo"))
+	require.Equal(t, 0, len(q.Quarantined()))
+}
+
+func TestQuarantineUnquarantine(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	q := newTestQuarantine(t, &now)
+
+	require.False(t, q.Unquarantine("foo"))
+	for i := 0; i < 3; i++ {
+		q.RecordError("foo")
+	}
+	require.False(t, q.Admit("foo"))
+	require.True(t, q.Unquarantine("foo"))
+	require.True(t, q.Admit("foo"))
+}
+
+func TestHost(t *testing.T) {
+	require.Equal(t, "127.0.0.1", Host("127.0.0.1:1234"))
+	require.Equal(t, "::1", Host("[::1]:1234"))
+	require.Equal(t, "<unknown>", Host("<unknown>"))
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	q := newTestQuarantine(t, &now)
+	for i := 0; i < 3; i++ {
+		q.RecordError("foo")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(HandlerURL, NewHandler(q, zap.NewNop()))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + HandlerURL)
+	require.NoError(t, err)
+	var res Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	resp.Body.Close()
+	require.Equal(t, 1, len(res.Quarantined))
+	require.Equal(t, "foo", res.Quarantined[0].Host)
+
+	for _, test := range []struct {
+		query    string
+		expected int
+	}{
+		{query: "", expected: http.StatusBadRequest},
+		{query: "?host=bar", expected: http.StatusNotFound},
+		{query: "?host=foo", expected: http.StatusOK},
+	} {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+HandlerURL+test.query, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, test.expected, resp.StatusCode)
+	}
+	require.True(t, q.Admit("foo"))
+}
@@ -21,6 +21,8 @@
 package rawtcp
 
 import (
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
 	"github.com/m3db/m3/src/metrics/encoding/msgpack"
 	"github.com/m3db/m3/src/metrics/encoding/protobuf"
 	"github.com/m3db/m3/src/x/clock"
@@ -34,6 +36,10 @@ const (
 
 	// The default read buffer size for raw TCP connections.
 	defaultReadBufferSize = 1440
+
+	// The default maximum number of untimed metrics added to the aggregator
+	// in a single batch.
+	defaultUntimedBatchSize = 64
 )
 
 // Options provide a set of server options.
@@ -79,6 +85,29 @@ type Options interface {
 
 	// ErrorLogLimitPerSecond returns the error log limit per second.
 	ErrorLogLimitPerSecond() int64
+
+	// SetIngestSampler sets the sampler of ingested metrics, nil disables
+	// sampling.
+	SetIngestSampler(value *audit.Sampler) Options
+
+	// IngestSampler returns the sampler of ingested metrics.
+	IngestSampler() *audit.Sampler
+
+	// SetQuarantine sets the quarantine of clients with too many decode
+	// errors, nil disables quarantining.
+	SetQuarantine(value *quarantine.Quarantine) Options
+
+	// Quarantine returns the quarantine of clients with too many decode errors.
+	Quarantine() *quarantine.Quarantine
+
+	// SetUntimedBatchSize sets the maximum number of consecutive untimed
+	// metrics sharing the same staged metadatas that are added to the
+	// aggregator in a single batch, zero or one disables batching.
+	SetUntimedBatchSize(value int) Options
+
+	// UntimedBatchSize returns the maximum number of untimed metrics added
+	// to the aggregator in a single batch.
+	UntimedBatchSize() int
 }
 
 type options struct {
@@ -89,6 +118,9 @@ type options struct {
 	protobufItOpts       protobuf.UnaggregatedOptions
 	readBufferSize       int
 	errLogLimitPerSecond int64
+	ingestSampler        *audit.Sampler
+	quarantine           *quarantine.Quarantine
+	untimedBatchSize     int
 }
 
 // NewOptions creates a new set of server options.
@@ -101,6 +133,7 @@ func NewOptions() Options {
 		protobufItOpts:       protobuf.NewUnaggregatedOptions(),
 		readBufferSize:       defaultReadBufferSize,
 		errLogLimitPerSecond: defaultErrorLogLimitPerSecond,
+		untimedBatchSize:     defaultUntimedBatchSize,
 	}
 }
 
@@ -173,3 +206,33 @@ func (o *options) SetErrorLogLimitPerSecond(value int64) Options {
 func (o *options) ErrorLogLimitPerSecond() int64 {
 	return o.errLogLimitPerSecond
 }
+
+func (o *options) SetIngestSampler(value *audit.Sampler) Options {
+	opts := *o
+	opts.ingestSampler = value
+	return &opts
+}
+
+func (o *options) IngestSampler() *audit.Sampler {
+	return o.ingestSampler
+}
+
+func (o *options) SetQuarantine(value *quarantine.Quarantine) Options {
+	opts := *o
+	opts.quarantine = value
+	return &opts
+}
+
+func (o *options) Quarantine() *quarantine.Quarantine {
+	return o.quarantine
+}
+
+func (o *options) SetUntimedBatchSize(value int) Options {
+	opts := *o
+	opts.untimedBatchSize = value
+	return &opts
+}
+
+func (o *options) UntimedBatchSize() int {
+	return o.untimedBatchSize
+}
@@ -31,6 +31,8 @@ import (
 
 	"github.com/m3db/m3/src/aggregator/aggregator"
 	"github.com/m3db/m3/src/aggregator/rate"
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
 	"github.com/m3db/m3/src/metrics/encoding"
 	"github.com/m3db/m3/src/metrics/encoding/migration"
 	"github.com/m3db/m3/src/metrics/encoding/msgpack"
@@ -60,6 +62,7 @@ func NewServer(address string, aggregator aggregator.Aggregator, opts Options) x
 type handlerMetrics struct {
 	unknownMessageTypeErrors tally.Counter
 	addUntimedErrors         tally.Counter
+	addUntimedBatches        tally.Counter
 	addTimedErrors           tally.Counter
 	addForwardedErrors       tally.Counter
 	addPassthroughErrors     tally.Counter
@@ -72,6 +75,7 @@ func newHandlerMetrics(scope tally.Scope) handlerMetrics {
 	return handlerMetrics{
 		unknownMessageTypeErrors: scope.Counter("unknown-message-type-errors"),
 		addUntimedErrors:         scope.Counter("add-untimed-errors"),
+		addUntimedBatches:        scope.Counter("add-untimed-batches"),
 		addTimedErrors:           scope.Counter("add-timed-errors"),
 		addForwardedErrors:       scope.Counter("add-forwarded-errors"),
 		addPassthroughErrors:     scope.Counter("add-passthrough-errors"),
@@ -89,6 +93,9 @@ type handler struct {
 	readBufferSize int
 	msgpackItOpts  msgpack.UnaggregatedIteratorOptions
 	protobufItOpts protobuf.UnaggregatedOptions
+	ingestSampler  *audit.Sampler
+	quarantine     *quarantine.Quarantine
+	batchSize      int
 
 	errLogRateLimiter *rate.Limiter
 	rand              *rand.Rand
@@ -109,6 +116,9 @@ func NewHandler(aggregator aggregator.Aggregator, opts Options) xserver.Handler
 		readBufferSize:    opts.ReadBufferSize(),
 		msgpackItOpts:     opts.MsgpackUnaggregatedIteratorOptions(),
 		protobufItOpts:    opts.ProtobufUnaggregatedIteratorOptions(),
+		ingestSampler:     opts.IngestSampler(),
+		quarantine:        opts.Quarantine(),
+		batchSize:         opts.UntimedBatchSize(),
 		errLogRateLimiter: limiter,
 		rand:              rand.New(rand.NewSource(nowFn().UnixNano())),
 		metrics:           newHandlerMetrics(iOpts.MetricsScope()),
@@ -120,6 +130,11 @@ func (s *handler) Handle(conn net.Conn) {
 	if remoteAddr := conn.RemoteAddr(); remoteAddr != nil {
 		remoteAddress = remoteAddr.String()
 	}
+	host := quarantine.Host(remoteAddress)
+	if !s.quarantine.Admit(host) {
+		// NB: the connection is closed once the handler returns.
+		return
+	}
 
 	reader := bufio.NewReaderSize(conn, s.readBufferSize)
 	it := migration.NewUnaggregatedIterator(reader, s.msgpackItOpts, s.protobufItOpts)
@@ -135,23 +150,34 @@ func (s *handler) Handle(conn net.Conn) {
 		timedMetadata       metadata.TimedMetadata
 		passthroughMetric   aggregated.Metric
 		passthroughMetadata policy.StoragePolicy
+		pooledMetric        *unaggregated.PooledMetric
+		batch               untimedBatch
 		err                 error
 	)
 	for it.Next() {
+		// The previous pooled metric is released only once the next message is
+		// decoded since it may still be referenced when logging errors.
+		if pooledMetric != nil {
+			pooledMetric.DecRef()
+		}
 		current := it.Current()
+		pooledMetric = current.PooledMetric
+		if !isUntimedMessage(current.Type) {
+			s.flushUntimed(&batch, remoteAddress)
+		}
 		switch current.Type {
 		case encoding.CounterWithMetadatasType:
-			untimedMetric = current.CounterWithMetadatas.Counter.ToUnion()
+			untimedMetric = untimedMetricUnion(current.CounterWithMetadatas.Counter.ToUnion(), pooledMetric)
 			stagedMetadatas = current.CounterWithMetadatas.StagedMetadatas
-			err = toAddUntimedError(s.aggregator.AddUntimed(untimedMetric, stagedMetadatas))
+			err = s.addUntimed(&batch, untimedMetric, stagedMetadatas, pooledMetric, remoteAddress)
 		case encoding.BatchTimerWithMetadatasType:
-			untimedMetric = current.BatchTimerWithMetadatas.BatchTimer.ToUnion()
+			untimedMetric = untimedMetricUnion(current.BatchTimerWithMetadatas.BatchTimer.ToUnion(), pooledMetric)
 			stagedMetadatas = current.BatchTimerWithMetadatas.StagedMetadatas
-			err = toAddUntimedError(s.aggregator.AddUntimed(untimedMetric, stagedMetadatas))
+			err = s.addUntimed(&batch, untimedMetric, stagedMetadatas, pooledMetric, remoteAddress)
 		case encoding.GaugeWithMetadatasType:
-			untimedMetric = current.GaugeWithMetadatas.Gauge.ToUnion()
+			untimedMetric = untimedMetricUnion(current.GaugeWithMetadatas.Gauge.ToUnion(), pooledMetric)
 			stagedMetadatas = current.GaugeWithMetadatas.StagedMetadatas
-			err = toAddUntimedError(s.aggregator.AddUntimed(untimedMetric, stagedMetadatas))
+			err = s.addUntimed(&batch, untimedMetric, stagedMetadatas, pooledMetric, remoteAddress)
 		case encoding.ForwardedMetricWithMetadataType:
 			forwardedMetric = current.ForwardedMetricWithMetadata.ForwardedMetric
 			forwardMetadata = current.ForwardedMetricWithMetadata.ForwardMetadata
@@ -172,9 +198,26 @@ func (s *handler) Handle(conn net.Conn) {
 			err = newUnknownMessageTypeError(current.Type)
 		}
 
+		// Add the pending batch before blocking on the connection for the
+		// next message so batched metrics are never delayed by an idle client.
+		if reader.Buffered() == 0 {
+			s.flushUntimed(&batch, remoteAddress)
+		}
+
+		if s.ingestSampler.Sample() {
+			s.ingestSampler.Record(audit.NewSample(current, remoteAddress, err))
+		}
+
 		if err == nil {
 			continue
 		}
+		if _, ok := err.(unknownMessageTypeError); ok && s.quarantine.RecordError(host) {
+			s.log.Warn("quarantined client with too many decode errors",
+				zap.String("remoteAddress", remoteAddress),
+				zap.Error(err),
+			)
+			break
+		}
 
 		// We rate limit the error log here because the error rate may scale with
 		// the metrics incoming rate and consume lots of cpu cycles.
@@ -234,6 +277,11 @@ func (s *handler) Handle(conn net.Conn) {
 		}
 	}
 
+	s.flushUntimed(&batch, remoteAddress)
+	if pooledMetric != nil {
+		pooledMetric.DecRef()
+	}
+
 	// If there is an error during decoding, it's likely due to a broken connection
 	// and therefore we ignore the EOF error.
 	if err := it.Err(); err != nil && err != io.EOF {
@@ -242,7 +290,73 @@ func (s *handler) Handle(conn net.Conn) {
 			zap.Error(err),
 		)
 		s.metrics.decodeErrors.Inc(1)
+		if s.quarantine.RecordError(host) {
+			s.log.Warn("quarantined client with too many decode errors",
+				zap.String("remoteAddress", remoteAddress),
+			)
+		}
+	}
+}
+
+// addUntimed adds the untimed metric to the batch if it was decoded into a
+// pooled metric, whose id remains valid once the next message is decoded, and
+// otherwise adds it to the aggregator directly.
+func (s *handler) addUntimed(
+	batch *untimedBatch,
+	metric unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+	pooledMetric *unaggregated.PooledMetric,
+	remoteAddress string,
+) error {
+	if s.batchSize <= 1 || pooledMetric == nil {
+		s.flushUntimed(batch, remoteAddress)
+		return toAddUntimedError(s.aggregator.AddUntimed(metric, metadatas))
+	}
+	if len(batch.metrics) > 0 && !batch.metadatas.Equal(metadatas) {
+		s.flushUntimed(batch, remoteAddress)
+	}
+	if len(batch.metrics) == 0 {
+		// NB: the decoded metadatas are reused by the next message.
+		batch.metadatas = cloneStagedMetadatas(metadatas)
+	}
+	pooledMetric.IncRef()
+	batch.metrics = append(batch.metrics, metric)
+	batch.pooled = append(batch.pooled, pooledMetric)
+	if len(batch.metrics) >= s.batchSize {
+		s.flushUntimed(batch, remoteAddress)
 	}
+	return nil
+}
+
+// flushUntimed adds the pending untimed metrics to the aggregator in a single
+// call and releases them.
+func (s *handler) flushUntimed(batch *untimedBatch, remoteAddress string) {
+	if len(batch.metrics) == 0 {
+		return
+	}
+	s.metrics.addUntimedBatches.Inc(1)
+	err := s.aggregator.AddUntimedBatch(batch.metrics, batch.metadatas)
+	if err != nil {
+		s.metrics.addUntimedErrors.Inc(1)
+		if s.errLogRateLimiter == nil || s.errLogRateLimiter.IsAllowed(1) {
+			s.log.Error("error adding untimed metric batch",
+				zap.String("remoteAddress", remoteAddress),
+				zap.Int("batchSize", len(batch.metrics)),
+				zap.Any("metadatas", batch.metadatas),
+				zap.Error(err),
+			)
+		} else {
+			s.metrics.errLogRateLimited.Inc(1)
+		}
+	}
+	for i := range batch.pooled {
+		batch.pooled[i].DecRef()
+		batch.pooled[i] = nil
+		batch.metrics[i] = unaggregated.MetricUnion{}
+	}
+	batch.metrics = batch.metrics[:0]
+	batch.pooled = batch.pooled[:0]
+	batch.metadatas = nil
 }
 
 func (s *handler) Close() {
@@ -251,6 +365,45 @@ func (s *handler) Close() {
 	// exit signal.
 }
 
+// untimedMetricUnion returns the pooled metric if the untimed metric was
+// decoded into one so its id is retained by reference rather than copied.
+func untimedMetricUnion(
+	metric unaggregated.MetricUnion,
+	pooledMetric *unaggregated.PooledMetric,
+) unaggregated.MetricUnion {
+	if pooledMetric == nil {
+		return metric
+	}
+	return pooledMetric.MetricUnion
+}
+
+// untimedBatch is a batch of consecutive untimed metrics sharing the same
+// staged metadatas.
+type untimedBatch struct {
+	metrics   []unaggregated.MetricUnion
+	pooled    []*unaggregated.PooledMetric
+	metadatas metadata.StagedMetadatas
+}
+
+func isUntimedMessage(msgType encoding.UnaggregatedMessageType) bool {
+	switch msgType {
+	case encoding.CounterWithMetadatasType,
+		encoding.BatchTimerWithMetadatasType,
+		encoding.GaugeWithMetadatasType:
+		return true
+	}
+	return false
+}
+
+func cloneStagedMetadatas(metadatas metadata.StagedMetadatas) metadata.StagedMetadatas {
+	cloned := make(metadata.StagedMetadatas, 0, len(metadatas))
+	for _, sm := range metadatas {
+		sm.Pipelines = sm.Pipelines.Clone()
+		cloned = append(cloned, sm)
+	}
+	return cloned
+}
+
 type unknownMessageTypeError struct {
 	msgType encoding.UnaggregatedMessageType
 }
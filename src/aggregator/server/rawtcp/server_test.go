@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/aggregator/aggregator/capture"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/encoding"
 	"github.com/m3db/m3/src/metrics/encoding/msgpack"
@@ -38,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/metrics/pipeline"
 	"github.com/m3db/m3/src/metrics/pipeline/applied"
 	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/retry"
 	xserver "github.com/m3db/m3/src/x/server"
 	xtime "github.com/m3db/m3/src/x/time"
@@ -45,6 +47,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -304,6 +307,96 @@ func testRawTCPServerHandleUnaggregated(
 	require.True(t, cmp.Equal(expectedResult, snapshot, testCmpOpts...), expectedResult, snapshot)
 }
 
+func TestRawTCPServerHandleQuarantinedClient(t *testing.T) {
+	q, err := quarantine.NewQuarantine(1, time.Minute, time.Minute, time.Now, tally.NoopScope)
+	require.NoError(t, err)
+	agg := capture.NewAggregator()
+	h := NewHandler(agg, testServerOptions().SetQuarantine(q))
+
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	host := quarantine.Host(serverConn.RemoteAddr().String())
+	require.True(t, q.RecordError(host))
+
+	// The handler returns without reading from a quarantined client.
+	done := make(chan struct{})
+	go func() {
+		h.Handle(serverConn)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "quarantined client was not rejected")
+	}
+	require.Equal(t, 0, agg.NumMetricsAdded())
+}
+
+func TestRawTCPServerHandleUntimedBatch(t *testing.T) {
+	metricPool := unaggregated.NewPooledMetricPool(pool.NewObjectPoolOptions().SetSize(1))
+	metricPool.Init()
+	opts := testServerOptions().SetProtobufUnaggregatedIteratorOptions(
+		protobuf.NewUnaggregatedOptions().SetPooledMetricPool(metricPool))
+	agg := &batchRecordingAggregator{Aggregator: capture.NewAggregator()}
+	h := NewHandler(agg, opts)
+
+	var (
+		encoder  = protobuf.NewUnaggregatedEncoder(protobuf.NewUnaggregatedOptions())
+		expected capture.SnapshotResult
+	)
+	for _, id := range []string{"foo", "bar", "baz"} {
+		counter := unaggregated.CounterWithMetadatas{
+			Counter:         unaggregated.Counter{ID: []byte(id), Value: 1},
+			StagedMetadatas: testCustomMetadatas,
+		}
+		expected.CountersWithMetadatas = append(expected.CountersWithMetadatas, counter)
+		require.NoError(t, encoder.EncodeMessage(encoding.UnaggregatedMessageUnion{
+			Type:                 encoding.CounterWithMetadatasType,
+			CounterWithMetadatas: counter,
+		}))
+	}
+	// A metric with different metadatas starts a new batch.
+	expected.GaugesWithMetadatas = append(expected.GaugesWithMetadatas, testGaugeWithMetadatas)
+	require.NoError(t, encoder.EncodeMessage(encoding.UnaggregatedMessageUnion{
+		Type:               encoding.GaugeWithMetadatasType,
+		GaugeWithMetadatas: testGaugeWithMetadatas,
+	}))
+	stream := encoder.Relinquish().Bytes()
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		h.Handle(serverConn)
+		close(done)
+	}()
+	_, err := clientConn.Write(stream)
+	require.NoError(t, err)
+	require.NoError(t, clientConn.Close())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "handler did not return")
+	}
+
+	require.Equal(t, []int{3, 1}, agg.batchSizes)
+	snapshot := agg.Snapshot()
+	require.True(t, cmp.Equal(expected, snapshot, testCmpOpts...), expected, snapshot)
+}
+
+type batchRecordingAggregator struct {
+	capture.Aggregator
+
+	batchSizes []int
+}
+
+func (agg *batchRecordingAggregator) AddUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) error {
+	agg.batchSizes = append(agg.batchSizes, len(metrics))
+	return agg.Aggregator.AddUntimedBatch(metrics, metadatas)
+}
+
 func testServerOptions() Options {
 	opts := NewOptions()
 	instrumentOpts := opts.InstrumentOptions().SetReportInterval(time.Second)
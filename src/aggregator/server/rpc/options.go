@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/x/health"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultHealthWatchInterval = 5 * time.Second
+)
+
+var (
+	errNoInstrumentOptions = errors.New("no instrument options")
+)
+
+// Options is a set of gRPC server options.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetReflectionEnabled sets whether gRPC server reflection is enabled.
+	SetReflectionEnabled(value bool) Options
+
+	// ReflectionEnabled returns whether gRPC server reflection is enabled.
+	ReflectionEnabled() bool
+
+	// SetHealthRegistry sets the health registry backing the gRPC health
+	// service, nil disables the health service.
+	SetHealthRegistry(value health.Registry) Options
+
+	// HealthRegistry returns the health registry backing the gRPC health
+	// service.
+	HealthRegistry() health.Registry
+
+	// SetHealthWatchInterval sets the interval health watches re-run the
+	// checks at.
+	SetHealthWatchInterval(value time.Duration) Options
+
+	// HealthWatchInterval returns the interval health watches re-run the
+	// checks at.
+	HealthWatchInterval() time.Duration
+
+	// SetIngestSampler sets the sampler of ingested metrics, nil disables
+	// sampling.
+	SetIngestSampler(value *audit.Sampler) Options
+
+	// IngestSampler returns the sampler of ingested metrics.
+	IngestSampler() *audit.Sampler
+}
+
+type options struct {
+	instrumentOpts      instrument.Options
+	reflectionEnabled   bool
+	healthRegistry      health.Registry
+	healthWatchInterval time.Duration
+	ingestSampler       *audit.Sampler
+}
+
+// NewOptions returns a set of gRPC server options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts:      instrument.NewOptions(),
+		healthWatchInterval: defaultHealthWatchInterval,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.instrumentOpts == nil {
+		return errNoInstrumentOptions
+	}
+	return nil
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetReflectionEnabled(value bool) Options {
+	opts := *o
+	opts.reflectionEnabled = value
+	return &opts
+}
+
+func (o *options) ReflectionEnabled() bool {
+	return o.reflectionEnabled
+}
+
+func (o *options) SetHealthRegistry(value health.Registry) Options {
+	opts := *o
+	opts.healthRegistry = value
+	return &opts
+}
+
+func (o *options) HealthRegistry() health.Registry {
+	return o.healthRegistry
+}
+
+func (o *options) SetHealthWatchInterval(value time.Duration) Options {
+	opts := *o
+	opts.healthWatchInterval = value
+	return &opts
+}
+
+func (o *options) HealthWatchInterval() time.Duration {
+	return o.healthWatchInterval
+}
+
+func (o *options) SetIngestSampler(value *audit.Sampler) Options {
+	opts := *o
+	opts.ingestSampler = value
+	return &opts
+}
+
+func (o *options) IngestSampler() *audit.Sampler {
+	return o.ingestSampler
+}
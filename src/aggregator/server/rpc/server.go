@@ -0,0 +1,255 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/m3db/m3/src/aggregator/aggregator"
+	"github.com/m3db/m3/src/aggregator/generated/proto/aggregatorpb"
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/metrics/encoding"
+	"github.com/m3db/m3/src/metrics/generated/proto/metricpb"
+	"github.com/m3db/m3/src/x/health"
+	xserver "github.com/m3db/m3/src/x/server"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const unknownRemoteHostAddress = "<unknown>"
+
+var writeResponse = &aggregatorpb.WriteResponse{}
+
+// server is a gRPC server serving the aggregator write service.
+type server struct {
+	address  string
+	opts     Options
+	service  *service
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// NewServer creates a new gRPC server.
+func NewServer(
+	address string,
+	aggregator aggregator.Aggregator,
+	opts Options,
+) (xserver.Server, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &server{
+		address: address,
+		opts:    opts,
+		service: newService(aggregator, opts),
+	}, nil
+}
+
+func (s *server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(listener)
+}
+
+func (s *server) Serve(l net.Listener) error {
+	server := grpc.NewServer()
+	aggregatorpb.RegisterAggregatorServer(server, s.service)
+	if registry := s.opts.HealthRegistry(); registry != nil {
+		healthpb.RegisterHealthServer(server,
+			health.NewGRPCServer(registry, s.opts.HealthWatchInterval()))
+	}
+	if s.opts.ReflectionEnabled() {
+		reflection.Register(server)
+	}
+
+	s.server = server
+	s.listener = l
+	s.address = l.Addr().String()
+
+	logger := s.opts.InstrumentOptions().Logger()
+	go func() {
+		if err := server.Serve(l); err != nil {
+			logger.Error("error from serving gRPC server", zap.Error(err))
+		}
+	}()
+
+	return nil
+}
+
+func (s *server) Close() {
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+	s.server = nil
+	s.listener = nil
+}
+
+// service implements the aggregator write service.
+type service struct {
+	aggregator    aggregator.Aggregator
+	ingestSampler *audit.Sampler
+}
+
+func newService(aggregator aggregator.Aggregator, opts Options) *service {
+	return &service{
+		aggregator:    aggregator,
+		ingestSampler: opts.IngestSampler(),
+	}
+}
+
+func (s *service) WriteUntimedCounter(
+	ctx context.Context,
+	pb *metricpb.CounterWithMetadatas,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.CounterWithMetadatas.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.CounterWithMetadatasType
+	err := s.aggregator.AddUntimed(
+		union.CounterWithMetadatas.ToUnion(),
+		union.CounterWithMetadatas.StagedMetadatas)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WriteUntimedBatchTimer(
+	ctx context.Context,
+	pb *metricpb.BatchTimerWithMetadatas,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.BatchTimerWithMetadatas.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.BatchTimerWithMetadatasType
+	err := s.aggregator.AddUntimed(
+		union.BatchTimerWithMetadatas.ToUnion(),
+		union.BatchTimerWithMetadatas.StagedMetadatas)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WriteUntimedGauge(
+	ctx context.Context,
+	pb *metricpb.GaugeWithMetadatas,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.GaugeWithMetadatas.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.GaugeWithMetadatasType
+	err := s.aggregator.AddUntimed(
+		union.GaugeWithMetadatas.ToUnion(),
+		union.GaugeWithMetadatas.StagedMetadatas)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WriteTimed(
+	ctx context.Context,
+	pb *metricpb.TimedMetricWithMetadata,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.TimedMetricWithMetadata.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.TimedMetricWithMetadataType
+	err := s.aggregator.AddTimed(
+		union.TimedMetricWithMetadata.Metric,
+		union.TimedMetricWithMetadata.TimedMetadata)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WriteTimedWithStagedMetadatas(
+	ctx context.Context,
+	pb *metricpb.TimedMetricWithMetadatas,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.TimedMetricWithMetadatas.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.TimedMetricWithMetadatasType
+	err := s.aggregator.AddTimedWithStagedMetadatas(
+		union.TimedMetricWithMetadatas.Metric,
+		union.TimedMetricWithMetadatas.StagedMetadatas)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WriteForwarded(
+	ctx context.Context,
+	pb *metricpb.ForwardedMetricWithMetadata,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.ForwardedMetricWithMetadata.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.ForwardedMetricWithMetadataType
+	err := s.aggregator.AddForwarded(
+		union.ForwardedMetricWithMetadata.ForwardedMetric,
+		union.ForwardedMetricWithMetadata.ForwardMetadata)
+	return s.respond(ctx, union, err)
+}
+
+func (s *service) WritePassthrough(
+	ctx context.Context,
+	pb *metricpb.TimedMetricWithStoragePolicy,
+) (*aggregatorpb.WriteResponse, error) {
+	var union encoding.UnaggregatedMessageUnion
+	if err := union.PassthroughMetricWithMetadata.FromProto(pb); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	union.Type = encoding.PassthroughMetricWithMetadataType
+	err := s.aggregator.AddPassthrough(
+		union.PassthroughMetricWithMetadata.Metric,
+		union.PassthroughMetricWithMetadata.StoragePolicy)
+	return s.respond(ctx, union, err)
+}
+
+// respond samples the ingested message and converts the error returned when
+// adding it to the aggregator, if any, into a gRPC error.
+func (s *service) respond(
+	ctx context.Context,
+	union encoding.UnaggregatedMessageUnion,
+	err error,
+) (*aggregatorpb.WriteResponse, error) {
+	if s.ingestSampler.Sample() {
+		s.ingestSampler.Record(audit.NewSample(union, remoteAddress(ctx), err))
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return writeResponse, nil
+}
+
+func remoteAddress(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return unknownRemoteHostAddress
+}
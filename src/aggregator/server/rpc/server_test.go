@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/aggregator/capture"
+	"github.com/m3db/m3/src/aggregator/generated/proto/aggregatorpb"
+	"github.com/m3db/m3/src/metrics/aggregation"
+	"github.com/m3db/m3/src/metrics/generated/proto/metricpb"
+	"github.com/m3db/m3/src/metrics/metadata"
+	"github.com/m3db/m3/src/metrics/metric"
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/metrics/policy"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testListenAddress = "127.0.0.1:0"
+)
+
+var (
+	testCounterWithMetadatas = unaggregated.CounterWithMetadatas{
+		Counter: unaggregated.Counter{
+			ID:    []byte("testCounter"),
+			Value: 123,
+		},
+		StagedMetadatas: metadata.DefaultStagedMetadatas,
+	}
+	testTimedMetricWithMetadata = aggregated.TimedMetricWithMetadata{
+		Metric: aggregated.Metric{
+			Type:      metric.CounterType,
+			ID:        []byte("testTimed"),
+			TimeNanos: 12345,
+			Value:     -13,
+		},
+		TimedMetadata: metadata.TimedMetadata{
+			AggregationID: aggregation.DefaultID,
+			StoragePolicy: policy.NewStoragePolicy(time.Minute, xtime.Minute, 12*time.Hour),
+		},
+	}
+)
+
+func TestServerWrite(t *testing.T) {
+	agg := capture.NewAggregator()
+	client, closer := testServerAndClient(t, agg)
+	defer closer()
+
+	var counterPB metricpb.CounterWithMetadatas
+	require.NoError(t, testCounterWithMetadatas.ToProto(&counterPB))
+	_, err := client.WriteUntimedCounter(context.Background(), &counterPB)
+	require.NoError(t, err)
+
+	var timedPB metricpb.TimedMetricWithMetadata
+	require.NoError(t, testTimedMetricWithMetadata.ToProto(&timedPB))
+	_, err = client.WriteTimed(context.Background(), &timedPB)
+	require.NoError(t, err)
+
+	passthroughPB := metricpb.TimedMetricWithStoragePolicy{}
+	passthrough := aggregated.PassthroughMetricWithMetadata{
+		Metric:        testTimedMetricWithMetadata.Metric,
+		StoragePolicy: testTimedMetricWithMetadata.StoragePolicy,
+	}
+	require.NoError(t, passthrough.ToProto(&passthroughPB))
+	_, err = client.WritePassthrough(context.Background(), &passthroughPB)
+	require.NoError(t, err)
+
+	snapshot := agg.Snapshot()
+	require.Equal(t, 1, len(snapshot.CountersWithMetadatas))
+	require.Equal(t, testCounterWithMetadatas.ID, snapshot.CountersWithMetadatas[0].ID)
+	require.Equal(t, testCounterWithMetadatas.Value, snapshot.CountersWithMetadatas[0].Value)
+	require.Equal(t, 1, len(snapshot.TimedMetricWithMetadata))
+	require.Equal(t, testTimedMetricWithMetadata.ID, snapshot.TimedMetricWithMetadata[0].ID)
+	require.Equal(t, testTimedMetricWithMetadata.Value, snapshot.TimedMetricWithMetadata[0].Value)
+	require.Equal(t, 1, len(snapshot.PassthroughMetricWithMetadata))
+	require.Equal(t, passthrough.ID, snapshot.PassthroughMetricWithMetadata[0].ID)
+	require.Equal(t, passthrough.StoragePolicy, snapshot.PassthroughMetricWithMetadata[0].StoragePolicy)
+}
+
+func TestServerWriteInvalidMetric(t *testing.T) {
+	agg := capture.NewAggregator()
+	client, closer := testServerAndClient(t, agg)
+	defer closer()
+
+	// A timed metric with an unknown metric type fails to convert.
+	pb := &metricpb.TimedMetricWithMetadata{
+		Metric: metricpb.TimedMetric{
+			Type: 1000,
+			Id:   []byte("testTimed"),
+		},
+	}
+	_, err := client.WriteTimed(context.Background(), pb)
+	require.Error(t, err)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, 0, agg.NumMetricsAdded())
+}
+
+func testServerAndClient(
+	t *testing.T,
+	agg capture.Aggregator,
+) (aggregatorpb.AggregatorClient, func()) {
+	listener, err := net.Listen("tcp", testListenAddress)
+	require.NoError(t, err)
+
+	s, err := NewServer(testListenAddress, agg, NewOptions().SetReflectionEnabled(true))
+	require.NoError(t, err)
+	require.NoError(t, s.Serve(listener))
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	return aggregatorpb.NewAggregatorClient(conn), func() {
+		conn.Close()
+		s.Close()
+	}
+}
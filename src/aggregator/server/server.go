@@ -21,6 +21,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -31,9 +33,14 @@ import (
 	"github.com/m3db/m3/src/aggregator/server/http"
 	"github.com/m3db/m3/src/aggregator/server/m3msg"
 	"github.com/m3db/m3/src/aggregator/server/rawtcp"
+	"github.com/m3db/m3/src/aggregator/server/rpc"
+	clusterclient "github.com/m3db/m3/src/cluster/client"
+	kvutil "github.com/m3db/m3/src/cluster/kv/util"
 	"github.com/m3db/m3/src/cmd/services/m3aggregator/config"
 	"github.com/m3db/m3/src/cmd/services/m3aggregator/serve"
+	"github.com/m3db/m3/src/x/clock"
 	xconfig "github.com/m3db/m3/src/x/config"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/instrument"
 
 	"go.uber.org/zap"
@@ -43,6 +50,8 @@ const (
 	gracefulShutdownTimeout = 15 * time.Second
 )
 
+var errElectionStateUnknown = errors.New("election state unknown")
+
 // RunOptions are the server options for running the aggregator server.
 type RunOptions struct {
 	Config config.Configuration
@@ -89,6 +98,8 @@ func Run(opts RunOptions) {
 		rawTCPServerOpts rawtcp.Options
 		httpAddr         string
 		httpServerOpts   http.Options
+		grpcAddr         string
+		grpcServerOpts   rpc.Options
 	)
 	if cfg.M3Msg != nil {
 		// Create the M3Msg server options.
@@ -110,13 +121,56 @@ func Run(opts RunOptions) {
 			SetMetricsScope(scope.
 				SubScope("rawtcp-server").
 				Tagged(map[string]string{"server": "rawtcp"}))
-		rawTCPServerOpts = cfg.RawTCP.NewServerOptions(rawTCPInstrumentOpts)
+		rawTCPServerOpts, err = cfg.RawTCP.NewServerOptions(rawTCPInstrumentOpts)
+		if err != nil {
+			logger.Fatal("could not create raw TCP server options", zap.Error(err))
+		}
 	}
 
+	// Create the health registry subsystems register their checks with.
+	healthRegistry := health.NewRegistry(health.NewOptions().
+		SetInstrumentOptions(instrumentOpts))
+
 	if cfg.HTTP != nil {
 		// Create the http server options.
 		httpAddr = cfg.HTTP.ListenAddress
-		httpServerOpts = cfg.HTTP.NewServerOptions()
+		httpServerOpts = cfg.HTTP.NewServerOptions().
+			SetHealthRegistry(healthRegistry)
+		if rawTCPServerOpts != nil {
+			// Serve the quarantine of raw TCP clients so it can be lifted manually.
+			httpServerOpts = httpServerOpts.SetQuarantine(rawTCPServerOpts.Quarantine())
+		}
+	}
+
+	if cfg.GRPC != nil {
+		// Create the gRPC server options.
+		grpcAddr = cfg.GRPC.ListenAddress
+		grpcInstrumentOpts := instrumentOpts.
+			SetMetricsScope(scope.
+				SubScope("grpc-server").
+				Tagged(map[string]string{"server": "grpc"}))
+		grpcServerOpts = cfg.GRPC.NewServerOptions(grpcInstrumentOpts).
+			SetHealthRegistry(healthRegistry)
+	}
+
+	if cfg.IngestSampling != nil {
+		// Create the ingest sampler shared by all servers.
+		ingestSampler, err := cfg.IngestSampling.NewSampler(clock.NewOptions())
+		if err != nil {
+			logger.Fatal("could not create ingest sampler", zap.Error(err))
+		}
+		if m3msgServerOpts != nil {
+			m3msgServerOpts = m3msgServerOpts.SetIngestSampler(ingestSampler)
+		}
+		if rawTCPServerOpts != nil {
+			rawTCPServerOpts = rawTCPServerOpts.SetIngestSampler(ingestSampler)
+		}
+		if httpServerOpts != nil {
+			httpServerOpts = httpServerOpts.SetIngestSampler(ingestSampler)
+		}
+		if grpcServerOpts != nil {
+			grpcServerOpts = grpcServerOpts.SetIngestSampler(ingestSampler)
+		}
 	}
 
 	// Create the kv client.
@@ -136,11 +190,49 @@ func Run(opts RunOptions) {
 	if err != nil {
 		logger.Fatal("error creating aggregator options", zap.Error(err))
 	}
+	if cfg.ShadowChecksums != nil {
+		// Create the checksums of flushed metrics whose source IDs are sampled.
+		shadowChecksums, err := cfg.ShadowChecksums.NewWindowChecksums()
+		if err != nil {
+			logger.Fatal("could not create shadow checksums", zap.Error(err))
+		}
+		aggregatorOpts = aggregatorOpts.SetShadowChecksums(shadowChecksums)
+		if httpServerOpts != nil {
+			httpServerOpts = httpServerOpts.SetShadowChecksums(shadowChecksums)
+		}
+	}
+	if cfg.WindowChecksums != nil {
+		// Create the checksums of flushed metrics per aggregation window.
+		windowChecksums, err := cfg.WindowChecksums.NewWindowChecksums()
+		if err != nil {
+			logger.Fatal("could not create window checksums", zap.Error(err))
+		}
+		aggregatorOpts = aggregatorOpts.SetWindowChecksums(windowChecksums)
+		if httpServerOpts != nil {
+			httpServerOpts = httpServerOpts.SetWindowChecksums(windowChecksums)
+		}
+	}
+	if cfg.WriteTracing != nil {
+		// Create the tracer of sampled writes.
+		writeTracer, err := cfg.WriteTracing.NewTracer(clock.NewOptions())
+		if err != nil {
+			logger.Fatal("could not create write tracer", zap.Error(err))
+		}
+		aggregatorOpts = aggregatorOpts.SetWriteTracer(writeTracer)
+		if httpServerOpts != nil {
+			httpServerOpts = httpServerOpts.SetWriteTracer(writeTracer)
+		}
+	}
 	aggregator := m3aggregator.NewAggregator(aggregatorOpts)
 	if err := aggregator.Open(); err != nil {
 		logger.Fatal("error opening the aggregator", zap.Error(err))
 	}
 
+	// Register the health checks now the aggregator is open.
+	if err := registerHealthChecks(healthRegistry, client, aggregator); err != nil {
+		logger.Fatal("error registering health checks", zap.Error(err))
+	}
+
 	// Watch runtime option changes after aggregator is open.
 	placementManager := aggregatorOpts.PlacementManager()
 	cfg.RuntimeOptions.WatchRuntimeOptionChanges(client, runtimeOptsManager, placementManager, logger)
@@ -155,6 +247,8 @@ func Run(opts RunOptions) {
 			rawTCPServerOpts,
 			httpAddr,
 			httpServerOpts,
+			grpcAddr,
+			grpcServerOpts,
 			aggregator,
 			doneCh,
 			instrumentOpts,
@@ -190,3 +284,33 @@ func Run(opts RunOptions) {
 		logger.Info("server closed due to timeout", zap.Duration("timeout", gracefulShutdownTimeout))
 	}
 }
+
+// registerHealthChecks registers the readiness checks of the aggregator,
+// which is ready once it can reach KV and its flush manager has joined the
+// election.
+func registerHealthChecks(
+	registry health.Registry,
+	client clusterclient.Client,
+	aggregator m3aggregator.Aggregator,
+) error {
+	err := registry.Register("kv", health.Readiness,
+		func(ctx context.Context) error {
+			store, err := client.KV()
+			if err != nil {
+				return err
+			}
+			return kvutil.NewHealthCheck(store)(ctx)
+		})
+	if err != nil {
+		return err
+	}
+
+	return registry.Register("flush-manager", health.Readiness,
+		func(context.Context) error {
+			state := aggregator.Status().FlushStatus.ElectionState
+			if state == m3aggregator.UnknownState {
+				return errElectionStateUnknown
+			}
+			return nil
+		})
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+// HandlerURL is the url of the window checksums debug handler.
+const HandlerURL = "/debug/flush/checksums"
+
+// Response is the response of the window checksums debug handler.
+type Response struct {
+	Checksums []WindowChecksum `json:"checksums"`
+}
+
+type handler struct {
+	checksums *WindowChecksums
+	logger    *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the retained window checksums.
+func NewHandler(checksums *WindowChecksums, logger *zap.Logger) http.Handler {
+	return &handler{checksums: checksums, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	xhttp.WriteJSONResponse(w, Response{Checksums: h.checksums.Checksums()}, h.logger)
+}
+
+// FetchChecksums fetches the window checksums retained by an aggregator
+// instance from the debug handler served at the given url.
+func FetchChecksums(client *http.Client, url string) ([]WindowChecksum, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching window checksums from %s: %d",
+			url, resp.StatusCode)
+	}
+	var res Response
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Checksums, nil
+}
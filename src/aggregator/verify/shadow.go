@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"hash/fnv"
+	"sort"
+
+	xsampler "github.com/m3db/m3/src/x/sampler"
+)
+
+// ShadowHandlerURL is the url of the shadow checksums debug handler.
+const ShadowHandlerURL = "/debug/shadow/checksums"
+
+// IsSampled returns true if the metric ID falls within the sampled fraction
+// of IDs. Sampling is determined by the ID alone so that the clients shadowing
+// writes and the aggregators checksumming flushed metrics agree on the IDs
+// sampled.
+func IsSampled(id []byte, rate xsampler.Rate) bool {
+	if rate <= 0 {
+		return false
+	}
+	if rate >= 1 {
+		return true
+	}
+	h := fnv.New64a()
+	h.Write(id) // nolint: errcheck
+	return float64(h.Sum64()>>11)/(1<<53) < rate.Value()
+}
+
+// ShadowConfiguration configures checksums of the flushed metrics whose
+// source IDs are sampled, used to compare the output of a shadow cluster
+// receiving the writes of the sampled IDs against the primary cluster.
+type ShadowConfiguration struct {
+	// SampleRate is the fraction of source IDs checksummed, this should match
+	// the sample rate of the clients shadowing writes.
+	SampleRate xsampler.Rate `yaml:"sampleRate"`
+
+	// Capacity is the number of most recent windows checksums are retained for.
+	Capacity int `yaml:"capacity"`
+}
+
+// NewWindowChecksums creates new window checksums of the flushed metrics
+// whose source IDs are sampled.
+func (c ShadowConfiguration) NewWindowChecksums() (*WindowChecksums, error) {
+	capacity := c.Capacity
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+	return NewSampledWindowChecksums(c.SampleRate, capacity)
+}
+
+// ShadowMismatch is a window whose checksum of the metrics flushed by the
+// shadow cluster does not match the checksum of the primary cluster.
+type ShadowMismatch struct {
+	Primary WindowChecksum `json:"primary"`
+	Shadow  WindowChecksum `json:"shadow"`
+}
+
+// CompareShadow compares the checksums of the windows earlier than
+// beforeNanos flushed by the primary and shadow clusters, each merged across
+// all instances of the cluster, and returns the windows that do not match.
+// Only windows retained by both clusters are compared since either cluster
+// may have started checksumming or evicted windows earlier than the other.
+func CompareShadow(
+	primary []WindowChecksum,
+	shadow []WindowChecksum,
+	beforeNanos int64,
+	sumTolerance float64,
+) []ShadowMismatch {
+	if len(primary) == 0 || len(shadow) == 0 {
+		return nil
+	}
+	earliestNanos := primary[0].TimeNanos
+	if shadow[0].TimeNanos > earliestNanos {
+		earliestNanos = shadow[0].TimeNanos
+	}
+
+	var (
+		primaryByKey = checksumsByKey(primary, earliestNanos, beforeNanos)
+		shadowByKey  = checksumsByKey(shadow, earliestNanos, beforeNanos)
+		mismatches   []ShadowMismatch
+	)
+	for key, p := range primaryByKey {
+		s, exists := shadowByKey[key]
+		if !exists {
+			s = WindowChecksum{TimeNanos: p.TimeNanos, StoragePolicy: p.StoragePolicy}
+		}
+		if !checksumsMatch(p, s, sumTolerance) {
+			mismatches = append(mismatches, ShadowMismatch{Primary: p, Shadow: s})
+		}
+	}
+	for key, s := range shadowByKey {
+		if _, exists := primaryByKey[key]; exists {
+			continue
+		}
+		p := WindowChecksum{TimeNanos: s.TimeNanos, StoragePolicy: s.StoragePolicy}
+		mismatches = append(mismatches, ShadowMismatch{Primary: p, Shadow: s})
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		a, b := mismatches[i].Primary, mismatches[j].Primary
+		if a.TimeNanos != b.TimeNanos {
+			return a.TimeNanos < b.TimeNanos
+		}
+		return a.StoragePolicy.String() < b.StoragePolicy.String()
+	})
+	return mismatches
+}
+
+// checksumsByKey returns the checksums of windows in [startNanos, endNanos)
+// keyed by window.
+func checksumsByKey(
+	checksums []WindowChecksum,
+	startNanos int64,
+	endNanos int64,
+) map[windowKey]WindowChecksum {
+	res := make(map[windowKey]WindowChecksum, len(checksums))
+	for _, checksum := range checksums {
+		if checksum.TimeNanos < startNanos || checksum.TimeNanos >= endNanos {
+			continue
+		}
+		key := windowKey{timeNanos: checksum.TimeNanos, storagePolicy: checksum.StoragePolicy}
+		res[key] = checksum
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSampled(t *testing.T) {
+	var sampled int
+	for i := 0; i < 10000; i++ {
+		metricID := []byte(fmt.Sprintf("foo%d", i))
+		require.False(t, IsSampled(metricID, 0))
+		require.True(t, IsSampled(metricID, 1))
+		if IsSampled(metricID, 0.1) {
+			sampled++
+			// Sampling is deterministic.
+			require.True(t, IsSampled(metricID, 0.1))
+		}
+	}
+	require.InDelta(t, 1000, sampled, 200)
+}
+
+func TestNewSampledWindowChecksumsInvalid(t *testing.T) {
+	_, err := NewSampledWindowChecksums(2, 10)
+	require.Error(t, err)
+	_, err = NewSampledWindowChecksums(0.5, 0)
+	require.Equal(t, errInvalidCapacity, err)
+}
+
+func TestSampledWindowChecksumsSampleSourceIDs(t *testing.T) {
+	c, err := NewSampledWindowChecksums(0.5, 10)
+	require.NoError(t, err)
+
+	// Flushed metrics are sampled on the source ID rather than the flushed
+	// ID, which includes the prefix and suffix of the aggregation.
+	var expected int64
+	for i := 0; i < 100; i++ {
+		sourceID := []byte(fmt.Sprintf("foo%d", i))
+		if IsSampled(sourceID, 0.5) {
+			expected++
+		}
+		c.AddChunked(sourceID, testChunkedMetric("stats.", string(sourceID), 10, 1))
+	}
+
+	checksums := c.Checksums()
+	require.Equal(t, 1, len(checksums))
+	require.Equal(t, expected, checksums[0].Count)
+	require.True(t, expected > 0 && expected < 100)
+}
+
+func TestCompareShadow(t *testing.T) {
+	var (
+		primary = []WindowChecksum{
+			{TimeNanos: 10, StoragePolicy: testStoragePolicy, Count: 1, Sum: 1, IDXor: 1},
+			{TimeNanos: 20, StoragePolicy: testStoragePolicy, Count: 2, Sum: 3, IDXor: 3},
+			{TimeNanos: 30, StoragePolicy: testStoragePolicy, Count: 1, Sum: 1, IDXor: 1},
+			{TimeNanos: 40, StoragePolicy: testStoragePolicy, Count: 1, Sum: 1, IDXor: 1},
+			{TimeNanos: 50, StoragePolicy: testStoragePolicy, Count: 1, Sum: 1, IDXor: 1},
+		}
+		shadow = []WindowChecksum{
+			{TimeNanos: 20, StoragePolicy: testStoragePolicy, Count: 2, Sum: 3.0000001, IDXor: 3},
+			{TimeNanos: 30, StoragePolicy: testStoragePolicy, Count: 1, Sum: 2, IDXor: 1},
+			{TimeNanos: 35, StoragePolicy: testStoragePolicy, Count: 1, Sum: 1, IDXor: 1},
+			{TimeNanos: 50, StoragePolicy: testStoragePolicy, Count: 2, Sum: 2, IDXor: 3},
+		}
+	)
+
+	// The window at 10 predates the shadow checksums and the window at 50 is
+	// not yet complete so neither is compared.
+	require.Equal(t, []ShadowMismatch{
+		{Primary: primary[2], Shadow: shadow[1]},
+		{
+			Primary: WindowChecksum{TimeNanos: 35, StoragePolicy: testStoragePolicy},
+			Shadow:  shadow[2],
+		},
+		{
+			Primary: primary[3],
+			Shadow:  WindowChecksum{TimeNanos: 40, StoragePolicy: testStoragePolicy},
+		},
+	}, CompareShadow(primary, shadow, 50, 1e-6))
+
+	require.Empty(t, CompareShadow(primary, nil, 50, 1e-6))
+}
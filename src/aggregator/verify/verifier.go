@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"math"
+
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+)
+
+// Mismatch is a window whose checksum computed from the received metrics does
+// not match the checksum of the flushed metrics.
+type Mismatch struct {
+	Flushed  WindowChecksum `json:"flushed"`
+	Received WindowChecksum `json:"received"`
+}
+
+// Verifier accumulates checksums of the metrics received downstream of the
+// aggregators and compares them against the checksums of the metrics the
+// aggregators flushed to detect metrics silently lost in transit.
+type Verifier struct {
+	received     *WindowChecksums
+	sumTolerance float64
+}
+
+// NewVerifier creates a new verifier retaining checksums of the received
+// metrics for up to capacity windows. Sums are compared within the relative
+// sumTolerance since floating point addition depends on the order metrics
+// are received in.
+func NewVerifier(capacity int, sumTolerance float64) (*Verifier, error) {
+	received, err := NewWindowChecksums(capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{
+		received:     received,
+		sumTolerance: sumTolerance,
+	}, nil
+}
+
+// Add adds a received metric.
+func (v *Verifier) Add(mp aggregated.MetricWithStoragePolicy) {
+	v.received.Add(mp)
+}
+
+// Verify compares the checksums of the windows earlier than beforeNanos
+// flushed by the aggregators, merged across all instances, against the
+// checksums of the received metrics and returns the windows that do not
+// match. Windows earlier than the earliest window received are skipped
+// since they may predate the verifier or have been evicted.
+func (v *Verifier) Verify(flushed []WindowChecksum, beforeNanos int64) []Mismatch {
+	received := v.received.Checksums()
+	if len(received) == 0 {
+		return nil
+	}
+	var (
+		earliestNanos = received[0].TimeNanos
+		receivedByKey = make(map[windowKey]WindowChecksum, len(received))
+		mismatches    []Mismatch
+	)
+	for _, checksum := range received {
+		key := windowKey{timeNanos: checksum.TimeNanos, storagePolicy: checksum.StoragePolicy}
+		receivedByKey[key] = checksum
+	}
+	for _, checksum := range flushed {
+		if checksum.TimeNanos < earliestNanos || checksum.TimeNanos >= beforeNanos {
+			continue
+		}
+		key := windowKey{timeNanos: checksum.TimeNanos, storagePolicy: checksum.StoragePolicy}
+		r, exists := receivedByKey[key]
+		if !exists {
+			r = WindowChecksum{TimeNanos: checksum.TimeNanos, StoragePolicy: checksum.StoragePolicy}
+		}
+		if !checksumsMatch(checksum, r, v.sumTolerance) {
+			mismatches = append(mismatches, Mismatch{Flushed: checksum, Received: r})
+		}
+	}
+	return mismatches
+}
+
+// checksumsMatch returns whether the checksums of a window match, comparing
+// sums within the relative sumTolerance.
+func checksumsMatch(a, b WindowChecksum, sumTolerance float64) bool {
+	if a.Count != b.Count || a.IDXor != b.IDXor {
+		return false
+	}
+	diff := math.Abs(a.Sum - b.Sum)
+	return diff <= sumTolerance*math.Max(math.Abs(a.Sum), math.Abs(b.Sum))
+}
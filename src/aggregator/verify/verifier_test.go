@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifierNoMismatches(t *testing.T) {
+	flushed, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	v, err := NewVerifier(10, 1e-9)
+	require.NoError(t, err)
+
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 10, 0.1))
+	flushed.AddChunked([]byte("bar"), testChunkedMetric("", "bar", 10, 0.2))
+	flushed.AddChunked([]byte("baz"), testChunkedMetric("", "baz", 10, 0.3))
+	v.Add(testMetric("baz", 10, 0.3))
+	v.Add(testMetric("foo", 10, 0.1))
+	v.Add(testMetric("bar", 10, 0.2))
+
+	require.Nil(t, v.Verify(flushed.Checksums(), 20))
+}
+
+func TestVerifierDetectsLoss(t *testing.T) {
+	flushed, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	v, err := NewVerifier(10, 1e-9)
+	require.NoError(t, err)
+
+	// Window 10 predates the first received window and is skipped.
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 10, 1))
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 20, 1))
+	flushed.AddChunked([]byte("bar"), testChunkedMetric("", "bar", 20, 2))
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 30, 1))
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 40, 1))
+	v.Add(testMetric("foo", 20, 1))
+	v.Add(testMetric("foo", 40, 1))
+
+	mismatches := v.Verify(flushed.Checksums(), 40)
+	require.Equal(t, 2, len(mismatches))
+	require.Equal(t, int64(20), mismatches[0].Flushed.TimeNanos)
+	require.Equal(t, int64(2), mismatches[0].Flushed.Count)
+	require.Equal(t, int64(1), mismatches[0].Received.Count)
+	require.Equal(t, int64(30), mismatches[1].Flushed.TimeNanos)
+	require.Equal(t, int64(0), mismatches[1].Received.Count)
+}
+
+func TestVerifierDetectsCorruptedValue(t *testing.T) {
+	flushed, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	v, err := NewVerifier(10, 1e-9)
+	require.NoError(t, err)
+
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 10, 1))
+	v.Add(testMetric("foo", 10, 2))
+
+	mismatches := v.Verify(flushed.Checksums(), 20)
+	require.Equal(t, 1, len(mismatches))
+}
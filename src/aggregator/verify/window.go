@@ -0,0 +1,241 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package verify provides rolling checksums of the metrics flushed for each
+// aggregation window, a verifier consumers can run against the metrics they
+// receive to detect metrics silently lost in transit, and checksums of the
+// metrics whose source IDs are sampled to compare the output of a shadow
+// aggregator cluster against the primary cluster before migrating traffic.
+package verify
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/metrics/policy"
+	xsampler "github.com/m3db/m3/src/x/sampler"
+)
+
+const defaultCapacity = 256
+
+var errInvalidCapacity = errors.New("window checksums capacity must be positive")
+
+// WindowChecksum is the checksum of the metrics flushed for an aggregation
+// window, identified by its timestamp and storage policy. All fields are
+// independent of the order metrics are flushed in, and checksums of disjoint
+// sets of metrics can be merged.
+type WindowChecksum struct {
+	TimeNanos     int64                `json:"timeNanos"`
+	StoragePolicy policy.StoragePolicy `json:"storagePolicy"`
+	Count         int64                `json:"count"`
+	Sum           float64              `json:"sum"`
+	IDXor         uint64               `json:"idXor"`
+}
+
+type windowKey struct {
+	timeNanos     int64
+	storagePolicy policy.StoragePolicy
+}
+
+// Configuration configures window checksums.
+type Configuration struct {
+	// Capacity is the number of most recent windows checksums are retained for.
+	Capacity int `yaml:"capacity"`
+}
+
+// NewWindowChecksums creates new window checksums.
+func (c Configuration) NewWindowChecksums() (*WindowChecksums, error) {
+	capacity := c.Capacity
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+	return NewWindowChecksums(capacity)
+}
+
+// WindowChecksums accumulates checksums of metrics per aggregation window,
+// optionally only of the flushed metrics whose source IDs are sampled. Only
+// checksums for the most recent windows are retained.
+type WindowChecksums struct {
+	sync.Mutex
+
+	capacity   int
+	sampleRate xsampler.Rate
+	checksums  map[windowKey]*WindowChecksum
+}
+
+// NewWindowChecksums creates new window checksums of all metrics retaining
+// checksums for up to capacity windows.
+func NewWindowChecksums(capacity int) (*WindowChecksums, error) {
+	return NewSampledWindowChecksums(1, capacity)
+}
+
+// NewSampledWindowChecksums creates new window checksums of the flushed
+// metrics whose source IDs are sampled at the sample rate, retaining
+// checksums for up to capacity windows.
+func NewSampledWindowChecksums(
+	sampleRate xsampler.Rate,
+	capacity int,
+) (*WindowChecksums, error) {
+	if err := sampleRate.Validate(); err != nil {
+		return nil, err
+	}
+	if capacity <= 0 {
+		return nil, errInvalidCapacity
+	}
+	return &WindowChecksums{
+		capacity:   capacity,
+		sampleRate: sampleRate,
+		checksums:  make(map[windowKey]*WindowChecksum, capacity),
+	}, nil
+}
+
+// AddChunked adds a flushed metric to the checksum of its window if the
+// source ID is sampled, a nil window checksums is a no-op. The source ID is
+// the ID of the metric written to the aggregator that the flushed metric was
+// aggregated from, so that writes shadowed by clients and flushed metrics
+// are sampled the same way.
+func (c *WindowChecksums) AddChunked(
+	sourceID []byte,
+	mp aggregated.ChunkedMetricWithStoragePolicy,
+) {
+	if c == nil || !IsSampled(sourceID, c.sampleRate) {
+		return
+	}
+	h := fnv.New64a()
+	h.Write(mp.Prefix) // nolint: errcheck
+	h.Write(mp.Data)   // nolint: errcheck
+	h.Write(mp.Suffix) // nolint: errcheck
+	c.add(mp.TimeNanos, mp.StoragePolicy, mp.Value, h.Sum64())
+}
+
+// Add adds a received metric to the checksum of its window regardless of the
+// sample rate since the source IDs of received metrics are not known, a nil
+// window checksums is a no-op.
+func (c *WindowChecksums) Add(mp aggregated.MetricWithStoragePolicy) {
+	if c == nil {
+		return
+	}
+	h := fnv.New64a()
+	h.Write(mp.ID) // nolint: errcheck
+	c.add(mp.TimeNanos, mp.StoragePolicy, mp.Value, h.Sum64())
+}
+
+// Checksums returns the retained checksums ordered by timestamp and storage
+// policy.
+func (c *WindowChecksums) Checksums() []WindowChecksum {
+	c.Lock()
+	res := make([]WindowChecksum, 0, len(c.checksums))
+	for _, checksum := range c.checksums {
+		res = append(res, *checksum)
+	}
+	c.Unlock()
+
+	sortChecksums(res)
+	return res
+}
+
+func (c *WindowChecksums) add(
+	timeNanos int64,
+	sp policy.StoragePolicy,
+	value float64,
+	idHash uint64,
+) {
+	key := windowKey{timeNanos: timeNanos, storagePolicy: sp}
+	c.Lock()
+	checksum, exists := c.checksums[key]
+	if !exists {
+		if !c.evictWithLock(timeNanos) {
+			c.Unlock()
+			return
+		}
+		checksum = &WindowChecksum{TimeNanos: timeNanos, StoragePolicy: sp}
+		c.checksums[key] = checksum
+	}
+	checksum.Count++
+	// NB: NaN values such as staleness markers are counted but not summed
+	// since a single NaN would otherwise make the sum of the window useless.
+	if !math.IsNaN(value) {
+		checksum.Sum += value
+	}
+	checksum.IDXor ^= idHash
+	c.Unlock()
+}
+
+// evictWithLock evicts the checksum of the earliest window if full, and
+// returns false if the timestamp is earlier than all retained windows.
+func (c *WindowChecksums) evictWithLock(timeNanos int64) bool {
+	if len(c.checksums) < c.capacity {
+		return true
+	}
+	var (
+		earliest    windowKey
+		hasEarliest bool
+	)
+	for key := range c.checksums {
+		if !hasEarliest || key.timeNanos < earliest.timeNanos {
+			earliest = key
+			hasEarliest = true
+		}
+	}
+	if timeNanos <= earliest.timeNanos {
+		return false
+	}
+	delete(c.checksums, earliest)
+	return true
+}
+
+// Merge merges checksums of disjoint sets of metrics, such as the checksums
+// returned by each aggregator instance, into a single checksum per window.
+func Merge(checksums ...[]WindowChecksum) []WindowChecksum {
+	merged := make(map[windowKey]*WindowChecksum)
+	for _, cs := range checksums {
+		for _, checksum := range cs {
+			key := windowKey{timeNanos: checksum.TimeNanos, storagePolicy: checksum.StoragePolicy}
+			existing, exists := merged[key]
+			if !exists {
+				checksum := checksum
+				merged[key] = &checksum
+				continue
+			}
+			existing.Count += checksum.Count
+			existing.Sum += checksum.Sum
+			existing.IDXor ^= checksum.IDXor
+		}
+	}
+	res := make([]WindowChecksum, 0, len(merged))
+	for _, checksum := range merged {
+		res = append(res, *checksum)
+	}
+	sortChecksums(res)
+	return res
+}
+
+func sortChecksums(checksums []WindowChecksum) {
+	sort.Slice(checksums, func(i, j int) bool {
+		if checksums[i].TimeNanos != checksums[j].TimeNanos {
+			return checksums[i].TimeNanos < checksums[j].TimeNanos
+		}
+		return checksums[i].StoragePolicy.String() < checksums[j].StoragePolicy.String()
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package verify
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/metrics/metric/aggregated"
+	"github.com/m3db/m3/src/metrics/metric/id"
+	"github.com/m3db/m3/src/metrics/policy"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testStoragePolicy = policy.NewStoragePolicy(10*time.Second, xtime.Second, 48*time.Hour)
+
+func testChunkedMetric(prefix, data string, timeNanos int64, value float64) aggregated.ChunkedMetricWithStoragePolicy {
+	return aggregated.ChunkedMetricWithStoragePolicy{
+		ChunkedMetric: aggregated.ChunkedMetric{
+			ChunkedID: id.ChunkedID{Prefix: []byte(prefix), Data: []byte(data)},
+			TimeNanos: timeNanos,
+			Value:     value,
+		},
+		StoragePolicy: testStoragePolicy,
+	}
+}
+
+func testMetric(metricID string, timeNanos int64, value float64) aggregated.MetricWithStoragePolicy {
+	return aggregated.MetricWithStoragePolicy{
+		Metric: aggregated.Metric{
+			ID:        id.RawID(metricID),
+			TimeNanos: timeNanos,
+			Value:     value,
+		},
+		StoragePolicy: testStoragePolicy,
+	}
+}
+
+func TestNewWindowChecksumsInvalid(t *testing.T) {
+	_, err := NewWindowChecksums(0)
+	require.Equal(t, errInvalidCapacity, err)
+}
+
+func TestWindowChecksumsChunkedMatchesReceived(t *testing.T) {
+	flushed, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	received, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+
+	flushed.AddChunked([]byte("foo"), testChunkedMetric("stats.", "foo", 10, 1))
+	flushed.AddChunked([]byte("bar"), testChunkedMetric("stats.", "bar", 10, 2))
+	flushed.AddChunked([]byte("baz"), testChunkedMetric("stats.", "baz", 20, math.NaN()))
+	received.Add(testMetric("stats.baz", 20, math.NaN()))
+	received.Add(testMetric("stats.bar", 10, 2))
+	received.Add(testMetric("stats.foo", 10, 1))
+
+	require.Equal(t, flushed.Checksums(), received.Checksums())
+	checksums := flushed.Checksums()
+	require.Equal(t, 2, len(checksums))
+	require.Equal(t, int64(2), checksums[0].Count)
+	require.Equal(t, 3.0, checksums[0].Sum)
+	require.Equal(t, int64(1), checksums[1].Count)
+	require.Equal(t, 0.0, checksums[1].Sum)
+}
+
+func TestWindowChecksumsEvictsEarliest(t *testing.T) {
+	c, err := NewWindowChecksums(2)
+	require.NoError(t, err)
+
+	c.Add(testMetric("foo", 20, 1))
+	c.Add(testMetric("foo", 30, 1))
+	c.Add(testMetric("foo", 10, 1))
+	c.Add(testMetric("foo", 40, 1))
+
+	checksums := c.Checksums()
+	require.Equal(t, 2, len(checksums))
+	require.Equal(t, int64(30), checksums[0].TimeNanos)
+	require.Equal(t, int64(40), checksums[1].TimeNanos)
+}
+
+func TestWindowChecksumsNil(t *testing.T) {
+	var c *WindowChecksums
+	c.Add(testMetric("foo", 10, 1))
+	c.AddChunked([]byte("foo"), testChunkedMetric("", "foo", 10, 1))
+}
+
+func TestMerge(t *testing.T) {
+	c1, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	c2, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	all, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+
+	for _, m := range []aggregated.MetricWithStoragePolicy{
+		testMetric("foo", 10, 1),
+		testMetric("bar", 20, 2),
+	} {
+		c1.Add(m)
+		all.Add(m)
+	}
+	for _, m := range []aggregated.MetricWithStoragePolicy{
+		testMetric("baz", 10, 3),
+		testMetric("qux", 30, 4),
+	} {
+		c2.Add(m)
+		all.Add(m)
+	}
+
+	require.Equal(t, all.Checksums(), Merge(c1.Checksums(), c2.Checksums()))
+}
+
+func TestHandlerAndFetchChecksums(t *testing.T) {
+	c, err := NewWindowChecksums(10)
+	require.NoError(t, err)
+	c.Add(testMetric("foo", 10, 1))
+	c.Add(testMetric("bar", 20, 2))
+
+	mux := http.NewServeMux()
+	mux.Handle(HandlerURL, NewHandler(c, zap.NewNop()))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	checksums, err := FetchChecksums(server.Client(), server.URL+HandlerURL)
+	require.NoError(t, err)
+	require.Equal(t, c.Checksums(), checksums)
+
+	resp, err := server.Client().Post(server.URL+HandlerURL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
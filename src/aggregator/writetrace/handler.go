@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writetrace
+
+import (
+	"fmt"
+	"net/http"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+// HandlerURL is the url of the write traces debug handler.
+const HandlerURL = "/debug/write/traces"
+
+// Response is the response of the write traces debug handler.
+type Response struct {
+	Breakdown  Breakdown `json:"breakdown"`
+	SlowTraces []Trace   `json:"slowTraces"`
+}
+
+type handler struct {
+	tracer *Tracer
+	logger *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the aggregated breakdown
+// and slow traces of traced writes on GET requests, and resets them on
+// DELETE requests so a latency spike can be traced in isolation.
+func NewHandler(tracer *Tracer, logger *zap.Logger) http.Handler {
+	return &handler{tracer: tracer, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodDelete:
+		h.tracer.Reset()
+	default:
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	xhttp.WriteJSONResponse(w, Response{
+		Breakdown:  h.tracer.Breakdown(),
+		SlowTraces: h.tracer.SlowTraces(),
+	}, h.logger)
+}
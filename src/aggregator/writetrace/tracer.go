@@ -0,0 +1,287 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package writetrace provides sampled tracing of the aggregator write path,
+// breaking down the wall time of 1-in-N writes into the phases of the write
+// to diagnose write latency spikes.
+package writetrace
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+)
+
+const defaultCapacity = 128
+
+var (
+	errInvalidSampleEvery = errors.New("write trace sample every must be positive")
+	errInvalidCapacity    = errors.New("write trace capacity must be positive")
+)
+
+// Phase is a phase of the write path.
+type Phase int
+
+// A list of supported phases.
+const (
+	// LookupPhase is the lookup or creation of the entry of the metric in
+	// the metric map.
+	LookupPhase Phase = iota
+	// MetadataPhase is the matching of the staged metadatas of the metric
+	// against the active metadata of the entry, and updating the aggregations
+	// of the entry if the metadata changed.
+	MetadataPhase
+	// UpdatePhase is the update of the aggregation elements of the entry.
+	UpdatePhase
+
+	numPhases
+)
+
+func (p Phase) String() string {
+	switch p {
+	case LookupPhase:
+		return "lookup"
+	case MetadataPhase:
+		return "metadata"
+	case UpdatePhase:
+		return "update"
+	default:
+		return "unknown"
+	}
+}
+
+// Configuration configures write tracing.
+type Configuration struct {
+	// SampleEvery traces one in every SampleEvery writes.
+	SampleEvery int `yaml:"sampleEvery" validate:"min=1"`
+
+	// SlowThreshold is the total wall time above which traced writes are
+	// retained individually, zero disables retaining traces.
+	SlowThreshold time.Duration `yaml:"slowThreshold"`
+
+	// Capacity is the number of most recent slow traces retained.
+	Capacity int `yaml:"capacity"`
+}
+
+// NewTracer creates a new write tracer.
+func (c Configuration) NewTracer(clockOpts clock.Options) (*Tracer, error) {
+	capacity := c.Capacity
+	if capacity == 0 {
+		capacity = defaultCapacity
+	}
+	return NewTracer(c.SampleEvery, c.SlowThreshold, capacity, clockOpts.NowFn())
+}
+
+// Trace is the wall time breakdown of a traced write.
+type Trace struct {
+	Time   time.Time                `json:"time"`
+	ID     string                   `json:"id"`
+	Total  time.Duration            `json:"total"`
+	Phases map[string]time.Duration `json:"phases"`
+
+	nowFn  clock.NowFn
+	last   time.Time
+	phases [numPhases]time.Duration
+}
+
+// Mark attributes the wall time since the trace started or was last marked
+// to the phase, a nil trace is a no-op so writes that are not traced need
+// not check whether they are.
+func (t *Trace) Mark(phase Phase) {
+	if t == nil {
+		return
+	}
+	now := t.nowFn()
+	t.phases[phase] += now.Sub(t.last)
+	t.last = now
+}
+
+// PhaseBreakdown is the aggregated wall time of a phase across traced writes.
+type PhaseBreakdown struct {
+	Phase string        `json:"phase"`
+	Total time.Duration `json:"total"`
+	Mean  time.Duration `json:"mean"`
+	Max   time.Duration `json:"max"`
+}
+
+// Breakdown is the aggregated wall time breakdown of traced writes.
+type Breakdown struct {
+	Traced int64            `json:"traced"`
+	Total  PhaseBreakdown   `json:"total"`
+	Phases []PhaseBreakdown `json:"phases"`
+}
+
+type durationStats struct {
+	total time.Duration
+	max   time.Duration
+}
+
+func (s *durationStats) add(d time.Duration) {
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+}
+
+func (s durationStats) breakdown(name string, count int64) PhaseBreakdown {
+	b := PhaseBreakdown{Phase: name, Total: s.total, Max: s.max}
+	if count > 0 {
+		b.Mean = s.total / time.Duration(count)
+	}
+	return b
+}
+
+// Tracer traces 1-in-N writes, aggregating the wall time breakdown of all
+// traced writes and retaining the most recent traces of slow writes.
+type Tracer struct {
+	sync.Mutex
+
+	sampleEvery   uint64
+	slowThreshold time.Duration
+	nowFn         clock.NowFn
+	writes        uint64
+
+	traced int64
+	total  durationStats
+	phases [numPhases]durationStats
+	slow   []Trace
+	next   int
+	full   bool
+}
+
+// NewTracer creates a new write tracer tracing one in every sampleEvery
+// writes and retaining up to capacity traces of writes slower than
+// slowThreshold.
+func NewTracer(
+	sampleEvery int,
+	slowThreshold time.Duration,
+	capacity int,
+	nowFn clock.NowFn,
+) (*Tracer, error) {
+	if sampleEvery <= 0 {
+		return nil, errInvalidSampleEvery
+	}
+	if capacity <= 0 {
+		return nil, errInvalidCapacity
+	}
+	return &Tracer{
+		sampleEvery:   uint64(sampleEvery),
+		slowThreshold: slowThreshold,
+		nowFn:         nowFn,
+		slow:          make([]Trace, capacity),
+	}, nil
+}
+
+// Start returns a trace if the write is sampled and nil otherwise, a nil
+// tracer never samples.
+func (t *Tracer) Start() *Trace {
+	if t == nil || atomic.AddUint64(&t.writes, 1)%t.sampleEvery != 0 {
+		return nil
+	}
+	now := t.nowFn()
+	return &Trace{Time: now, nowFn: t.nowFn, last: now}
+}
+
+// Finish records a finished trace of the write of the given metric ID, a nil
+// trace is a no-op.
+func (t *Tracer) Finish(trace *Trace, id []byte) {
+	if trace == nil {
+		return
+	}
+	var total time.Duration
+	for _, d := range trace.phases {
+		total += d
+	}
+
+	t.Lock()
+	defer t.Unlock()
+
+	t.traced++
+	t.total.add(total)
+	for i, d := range trace.phases {
+		t.phases[i].add(d)
+	}
+	if t.slowThreshold <= 0 || total < t.slowThreshold {
+		return
+	}
+	trace.ID = string(id)
+	trace.Total = total
+	trace.Phases = make(map[string]time.Duration, numPhases)
+	for i, d := range trace.phases {
+		trace.Phases[Phase(i).String()] = d
+	}
+	t.slow[t.next] = *trace
+	t.next++
+	if t.next == len(t.slow) {
+		t.next = 0
+		t.full = true
+	}
+}
+
+// Breakdown returns the aggregated wall time breakdown of traced writes.
+func (t *Tracer) Breakdown() Breakdown {
+	t.Lock()
+	defer t.Unlock()
+
+	b := Breakdown{
+		Traced: t.traced,
+		Total:  t.total.breakdown("total", t.traced),
+		Phases: make([]PhaseBreakdown, 0, numPhases),
+	}
+	for i, s := range t.phases {
+		b.Phases = append(b.Phases, s.breakdown(Phase(i).String(), t.traced))
+	}
+	return b
+}
+
+// SlowTraces returns the retained traces of slow writes, slowest first.
+func (t *Tracer) SlowTraces() []Trace {
+	t.Lock()
+	var traces []Trace
+	if t.full {
+		traces = append(traces, t.slow...)
+	} else {
+		traces = append(traces, t.slow[:t.next]...)
+	}
+	t.Unlock()
+
+	sort.Slice(traces, func(i, j int) bool {
+		return traces[i].Total > traces[j].Total
+	})
+	return traces
+}
+
+// Reset resets the aggregated breakdown and the retained slow traces.
+func (t *Tracer) Reset() {
+	t.Lock()
+	t.traced = 0
+	t.total = durationStats{}
+	t.phases = [numPhases]durationStats{}
+	for i := range t.slow {
+		t.slow[i] = Trace{}
+	}
+	t.next = 0
+	t.full = false
+	t.Unlock()
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writetrace
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestNewTracerInvalid(t *testing.T) {
+	_, err := NewTracer(0, 0, 1, time.Now)
+	require.Equal(t, errInvalidSampleEvery, err)
+	_, err = NewTracer(1, 0, 0, time.Now)
+	require.Equal(t, errInvalidCapacity, err)
+}
+
+func TestTracerNil(t *testing.T) {
+	var tracer *Tracer
+	trace := tracer.Start()
+	require.Nil(t, trace)
+	trace.Mark(LookupPhase)
+	tracer.Finish(trace, []byte("foo"))
+}
+
+func TestTracerSamplesOneInN(t *testing.T) {
+	tracer, err := NewTracer(3, 0, 1, time.Now)
+	require.NoError(t, err)
+
+	var sampled int
+	for i := 0; i < 9; i++ {
+		if trace := tracer.Start(); trace != nil {
+			sampled++
+			tracer.Finish(trace, []byte("foo"))
+		}
+	}
+	require.Equal(t, 3, sampled)
+	require.Equal(t, int64(3), tracer.Breakdown().Traced)
+	require.Equal(t, 0, len(tracer.SlowTraces()))
+}
+
+func TestTracerBreakdownAndSlowTraces(t *testing.T) {
+	clock := &testClock{now: time.Unix(1600000000, 0)}
+	tracer, err := NewTracer(1, 10*time.Millisecond, 2, clock.Now)
+	require.NoError(t, err)
+
+	for i, durations := range [][]time.Duration{
+		{time.Millisecond, time.Millisecond, time.Millisecond},
+		{time.Millisecond, 20 * time.Millisecond, time.Millisecond},
+		{5 * time.Millisecond, time.Millisecond, 10 * time.Millisecond},
+	} {
+		trace := tracer.Start()
+		require.NotNil(t, trace)
+		for phase, d := range durations {
+			clock.Advance(d)
+			trace.Mark(Phase(phase))
+		}
+		tracer.Finish(trace, []byte{byte('a' + i)})
+	}
+
+	b := tracer.Breakdown()
+	require.Equal(t, int64(3), b.Traced)
+	require.Equal(t, 41*time.Millisecond, b.Total.Total)
+	require.Equal(t, 41*time.Millisecond/3, b.Total.Mean)
+	require.Equal(t, 22*time.Millisecond, b.Total.Max)
+	require.Equal(t, []PhaseBreakdown{
+		{Phase: "lookup", Total: 7 * time.Millisecond, Mean: 7 * time.Millisecond / 3, Max: 5 * time.Millisecond},
+		{Phase: "metadata", Total: 22 * time.Millisecond, Mean: 22 * time.Millisecond / 3, Max: 20 * time.Millisecond},
+		{Phase: "update", Total: 12 * time.Millisecond, Mean: 4 * time.Millisecond, Max: 10 * time.Millisecond},
+	}, b.Phases)
+
+	slow := tracer.SlowTraces()
+	require.Equal(t, 2, len(slow))
+	require.Equal(t, "b", slow[0].ID)
+	require.Equal(t, 22*time.Millisecond, slow[0].Total)
+	require.Equal(t, 20*time.Millisecond, slow[0].Phases["metadata"])
+	require.Equal(t, "c", slow[1].ID)
+	require.Equal(t, 16*time.Millisecond, slow[1].Total)
+
+	tracer.Reset()
+	require.Equal(t, int64(0), tracer.Breakdown().Traced)
+	require.Equal(t, 0, len(tracer.SlowTraces()))
+}
+
+func TestHandler(t *testing.T) {
+	tracer, err := NewTracer(1, 0, 1, time.Now)
+	require.NoError(t, err)
+	tracer.Finish(tracer.Start(), []byte("foo"))
+
+	mux := http.NewServeMux()
+	mux.Handle(HandlerURL, NewHandler(tracer, zap.NewNop()))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + HandlerURL)
+	require.NoError(t, err)
+	var res Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	resp.Body.Close()
+	require.Equal(t, int64(1), res.Breakdown.Traced)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+HandlerURL, nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int64(0), tracer.Breakdown().Traced)
+
+	resp, err = http.Post(server.URL+HandlerURL, "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
@@ -75,6 +75,7 @@ func NewConfigServiceClient(opts Options) (client.Client, error) {
 		kvScope: scope.Tagged(map[string]string{"config_service": "kv"}),
 		sdScope: scope.Tagged(map[string]string{"config_service": "sd"}),
 		hbScope: scope.Tagged(map[string]string{"config_service": "hb"}),
+		ldScope: scope.Tagged(map[string]string{"config_service": "leader"}),
 		clis:    make(map[string]*clientv3.Client),
 		logger:  opts.InstrumentOptions().Logger(),
 		newFn:   newClient,
@@ -92,6 +93,7 @@ type csclient struct {
 	kvScope tally.Scope
 	sdScope tally.Scope
 	hbScope tally.Scope
+	ldScope tally.Scope
 	logger  *zap.Logger
 	newFn   newClientFn
 	retrier retry.Retrier
@@ -170,6 +172,7 @@ func (c *csclient) newkvOptions(
 			SetMetricsScope(c.kvScope)).
 		SetCacheFileFn(cacheFileFn(opts.Zone())).
 		SetWatchWithRevision(c.opts.WatchWithRevision()).
+		SetWatchChanResetJitter(c.opts.WatchChanResetJitter()).
 		SetNewDirectoryMode(c.opts.NewDirectoryMode())
 
 	if ns := opts.Namespace(); ns != "" {
@@ -222,16 +225,20 @@ func (c *csclient) heartbeatGen() services.HeartbeatGen {
 				return nil, err
 			}
 
-			opts := etcdheartbeat.NewOptions().
-				SetInstrumentsOptions(instrument.NewOptions().
-					SetLogger(c.logger).
-					SetMetricsScope(c.hbScope)).
-				SetServiceID(sid)
-			return etcdheartbeat.NewStore(cli, opts)
+			return etcdheartbeat.NewStore(cli, c.newHeartbeatOptions(sid))
 		},
 	)
 }
 
+func (c *csclient) newHeartbeatOptions(sid services.ServiceID) etcdheartbeat.Options {
+	return etcdheartbeat.NewOptions().
+		SetInstrumentsOptions(instrument.NewOptions().
+			SetLogger(c.logger).
+			SetMetricsScope(c.hbScope)).
+		SetWatchChanResetJitter(c.opts.WatchChanResetJitter()).
+		SetServiceID(sid)
+}
+
 func (c *csclient) leaderGen() services.LeaderGen {
 	return services.LeaderGen(
 		func(sid services.ServiceID, eo services.ElectionOptions) (services.LeaderService, error) {
@@ -242,7 +249,10 @@ func (c *csclient) leaderGen() services.LeaderGen {
 
 			opts := leader.NewOptions().
 				SetServiceID(sid).
-				SetElectionOpts(eo)
+				SetElectionOpts(eo).
+				SetInstrumentOptions(instrument.NewOptions().
+					SetLogger(c.logger).
+					SetMetricsScope(c.ldScope))
 
 			return leader.NewService(cli, opts)
 		},
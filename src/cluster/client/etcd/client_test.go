@@ -23,6 +23,7 @@ package etcd
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/cluster/services"
@@ -105,6 +106,18 @@ func TestKVAndHeartbeatServiceSharingETCDClient(t *testing.T) {
 	require.Equal(t, 2, len(c.clis))
 }
 
+func TestWatchChanResetJitterPropagated(t *testing.T) {
+	cs, err := NewConfigServiceClient(testOptions().SetWatchChanResetJitter(time.Second))
+	require.NoError(t, err)
+
+	c := cs.(*csclient)
+	kvOpts := c.newkvOptions(kv.NewOverrideOptions(), c.cacheFileFn())
+	require.Equal(t, time.Second, kvOpts.WatchChanResetJitter())
+
+	hbOpts := c.newHeartbeatOptions(services.NewServiceID().SetName("s1"))
+	require.Equal(t, time.Second, hbOpts.WatchChanResetJitter())
+}
+
 func TestClient(t *testing.T) {
 	_, err := NewConfigServiceClient(NewOptions())
 	require.Error(t, err)
@@ -90,14 +90,15 @@ func (c *KeepAliveConfig) NewOptions() KeepAliveOptions {
 
 // Configuration is for config service client.
 type Configuration struct {
-	Zone              string                 `yaml:"zone"`
-	Env               string                 `yaml:"env"`
-	Service           string                 `yaml:"service" validate:"nonzero"`
-	CacheDir          string                 `yaml:"cacheDir"`
-	ETCDClusters      []ClusterConfig        `yaml:"etcdClusters"`
-	SDConfig          services.Configuration `yaml:"m3sd"`
-	WatchWithRevision int64                  `yaml:"watchWithRevision"`
-	NewDirectoryMode  *os.FileMode           `yaml:"newDirectoryMode"`
+	Zone                 string                 `yaml:"zone"`
+	Env                  string                 `yaml:"env"`
+	Service              string                 `yaml:"service" validate:"nonzero"`
+	CacheDir             string                 `yaml:"cacheDir"`
+	ETCDClusters         []ClusterConfig        `yaml:"etcdClusters"`
+	SDConfig             services.Configuration `yaml:"m3sd"`
+	WatchWithRevision    int64                  `yaml:"watchWithRevision"`
+	WatchChanResetJitter time.Duration          `yaml:"watchChanResetJitter"`
+	NewDirectoryMode     *os.FileMode           `yaml:"newDirectoryMode"`
 }
 
 // NewClient creates a new config service client.
@@ -114,7 +115,8 @@ func (cfg Configuration) NewOptions() Options {
 		SetCacheDir(cfg.CacheDir).
 		SetClusters(cfg.etcdClusters()).
 		SetServicesOptions(cfg.SDConfig.NewOptions()).
-		SetWatchWithRevision(cfg.WatchWithRevision)
+		SetWatchWithRevision(cfg.WatchWithRevision).
+		SetWatchChanResetJitter(cfg.WatchChanResetJitter)
 
 	if v := cfg.NewDirectoryMode; v != nil {
 		opts = opts.SetNewDirectoryMode(*v)
@@ -54,6 +54,7 @@ zone: z1
 service: service1
 cacheDir: /tmp/cache.json
 watchWithRevision: 1
+watchChanResetJitter: 5s
 etcdClusters:
   - zone: z1
     endpoints:
@@ -92,6 +93,7 @@ m3sd:
 	require.Equal(t, "service1", cfg.Service)
 	require.Equal(t, "/tmp/cache.json", cfg.CacheDir)
 	require.Equal(t, int64(1), cfg.WatchWithRevision)
+	require.Equal(t, 5*time.Second, cfg.WatchChanResetJitter)
 	require.Equal(t, []ClusterConfig{
 		ClusterConfig{
 			Zone:      "z1",
@@ -125,6 +127,7 @@ m3sd:
 	require.Equal(t, 10*time.Second, *cfg.SDConfig.InitTimeout)
 
 	opts := cfg.NewOptions()
+	require.Equal(t, 5*time.Second, opts.WatchChanResetJitter())
 	cluster1, exists := opts.ClusterForZone("z1")
 	require.True(t, exists)
 	keepAliveOpts := cluster1.KeepAliveOptions()
@@ -183,16 +183,17 @@ func NewOptions() Options {
 }
 
 type options struct {
-	env               string
-	zone              string
-	service           string
-	cacheDir          string
-	watchWithRevision int64
-	sdOpts            services.Options
-	clusters          map[string]Cluster
-	iopts             instrument.Options
-	retryOpts         retry.Options
-	newDirectoryMode  os.FileMode
+	env                  string
+	zone                 string
+	service              string
+	cacheDir             string
+	watchWithRevision    int64
+	watchChanResetJitter time.Duration
+	sdOpts               services.Options
+	clusters             map[string]Cluster
+	iopts                instrument.Options
+	retryOpts            retry.Options
+	newDirectoryMode     os.FileMode
 }
 
 func (o options) Validate() error {
@@ -304,6 +305,15 @@ func (o options) SetWatchWithRevision(rev int64) Options {
 	return o
 }
 
+func (o options) WatchChanResetJitter() time.Duration {
+	return o.watchChanResetJitter
+}
+
+func (o options) SetWatchChanResetJitter(t time.Duration) Options {
+	o.watchChanResetJitter = t
+	return o
+}
+
 func (o options) SetNewDirectoryMode(fm os.FileMode) Options {
 	o.newDirectoryMode = fm
 	return o
@@ -102,7 +102,8 @@ func TestOptions(t *testing.T) {
 		SetService("app").
 		SetClusters([]Cluster{c1, c2}).
 		SetInstrumentOptions(iopts).
-		SetWatchWithRevision(1)
+		SetWatchWithRevision(1).
+		SetWatchChanResetJitter(time.Second)
 	assert.Equal(t, "env", opts.Env())
 	assert.Equal(t, "zone", opts.Zone())
 	assert.Equal(t, sdOpts, opts.ServicesOptions())
@@ -110,6 +111,7 @@ func TestOptions(t *testing.T) {
 	assert.Equal(t, "app", opts.Service())
 	assert.Equal(t, 2, len(opts.Clusters()))
 	assert.Equal(t, int64(1), opts.WatchWithRevision())
+	assert.Equal(t, time.Second, opts.WatchChanResetJitter())
 	c, ok := opts.ClusterForZone("z1")
 	assert.True(t, ok)
 	assert.Equal(t, c, c1)
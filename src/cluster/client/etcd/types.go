@@ -60,6 +60,9 @@ type Options interface {
 	WatchWithRevision() int64
 	SetWatchWithRevision(rev int64) Options
 
+	WatchChanResetJitter() time.Duration
+	SetWatchChanResetJitter(t time.Duration) Options
+
 	SetNewDirectoryMode(fm os.FileMode) Options
 	NewDirectoryMode() os.FileMode
 
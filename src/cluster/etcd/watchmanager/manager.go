@@ -23,6 +23,7 @@ package watchmanager
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"go.etcd.io/etcd/clientv3"
@@ -95,6 +96,17 @@ func (w *manager) watchChanWithTimeout(key string, rev int64) (clientv3.WatchCha
 	}
 }
 
+// resetInterval returns the delay before recreating a watch chan, with
+// jitter applied so that watches do not all reconnect at the same time
+// after an etcd outage.
+func (w *manager) resetInterval() time.Duration {
+	interval := w.opts.WatchChanResetInterval()
+	if jitter := w.opts.WatchChanResetJitter(); jitter > 0 {
+		interval += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	return interval
+}
+
 func (w *manager) Watch(key string) {
 	ticker := time.Tick(w.opts.WatchChanCheckInterval()) //nolint: megacheck
 
@@ -117,7 +129,7 @@ func (w *manager) Watch(key string) {
 					w.logger.Error("failed to get value for key", zap.String("key", key), zap.Error(err))
 				}
 				// avoid recreating watch channel too frequently
-				time.Sleep(w.opts.WatchChanResetInterval())
+				time.Sleep(w.resetInterval())
 				continue
 			}
 		}
@@ -133,7 +145,7 @@ func (w *manager) Watch(key string) {
 					zap.String("key", key))
 
 				// avoid recreating watch channel too frequently
-				time.Sleep(w.opts.WatchChanResetInterval())
+				time.Sleep(w.resetInterval())
 				w.m.etcdWatchReset.Inc(1)
 
 				continue
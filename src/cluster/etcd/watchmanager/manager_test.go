@@ -300,3 +300,16 @@ func testSetup(t *testing.T) (*manager, *clientv3.Client, *int32, *int32, chan s
 
 	return wh.(*manager), ec, &updateCalled, &shouldStop, doneCh, closer
 }
+
+func TestWatchResetIntervalJitter(t *testing.T) {
+	opts := NewOptions().SetWatchChanResetInterval(time.Second)
+	w := &manager{opts: opts}
+	require.Equal(t, time.Second, w.resetInterval())
+
+	w.opts = opts.SetWatchChanResetJitter(time.Second)
+	for i := 0; i < 100; i++ {
+		interval := w.resetInterval()
+		require.True(t, interval >= time.Second)
+		require.True(t, interval < 2*time.Second)
+	}
+}
@@ -61,6 +61,7 @@ type options struct {
 	wopts                  []clientv3.OpOption
 	watchChanCheckInterval time.Duration
 	watchChanResetInterval time.Duration
+	watchChanResetJitter   time.Duration
 	watchChanInitTimeout   time.Duration
 	iopts                  instrument.Options
 }
@@ -95,6 +96,16 @@ func (o *options) SetWatchChanResetInterval(t time.Duration) Options {
 	return &opts
 }
 
+func (o *options) WatchChanResetJitter() time.Duration {
+	return o.watchChanResetJitter
+}
+
+func (o *options) SetWatchChanResetJitter(t time.Duration) Options {
+	opts := *o
+	opts.watchChanResetJitter = t
+	return &opts
+}
+
 func (o *options) WatchChanInitTimeout() time.Duration {
 	return o.watchChanInitTimeout
 }
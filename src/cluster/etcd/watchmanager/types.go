@@ -74,6 +74,12 @@ type Options interface {
 	// SetWatchChanResetInterval sets the WatchChanResetInterval
 	SetWatchChanResetInterval(t time.Duration) Options
 
+	// WatchChanResetJitter is the maximum random delay added to the
+	// WatchChanResetInterval so that many watches do not reconnect at once
+	WatchChanResetJitter() time.Duration
+	// SetWatchChanResetJitter sets the WatchChanResetJitter
+	SetWatchChanResetJitter(t time.Duration) Options
+
 	// WatchChanInitTimeout is the timeout for a watchChan initialization
 	WatchChanInitTimeout() time.Duration
 	// SetWatchChanInitTimeout sets the WatchChanInitTimeout
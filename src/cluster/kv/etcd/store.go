@@ -93,6 +93,7 @@ func NewStore(etcdKV clientv3.KV, etcdWatcher clientv3.Watcher, opts Options) (k
 		SetWatchChanCheckInterval(opts.WatchChanCheckInterval()).
 		SetWatchChanInitTimeout(opts.WatchChanInitTimeout()).
 		SetWatchChanResetInterval(opts.WatchChanResetInterval()).
+		SetWatchChanResetJitter(opts.WatchChanResetJitter()).
 		SetInstrumentsOptions(opts.InstrumentsOptions())
 
 	wm, err := watchmanager.NewWatchManager(wOpts)
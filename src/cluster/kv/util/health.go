@@ -0,0 +1,46 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package util
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/x/health"
+)
+
+// HealthCheckKey is the key read by KV health checks, it need not exist.
+const HealthCheckKey = "_health"
+
+// NewHealthCheck returns a health check that fails when the KV store cannot
+// be read from.
+func NewHealthCheck(store kv.Store) health.CheckFn {
+	return func(context.Context) error {
+		if store == nil {
+			return errNilStore
+		}
+		_, err := store.Get(HealthCheckKey)
+		if err == kv.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/x/clock"
+)
+
+var errLeaseNotPositiveTTL = errors.New("lease ttl must be positive")
+
+// Lease is a leadership lease stored in a KV key, held by at most one holder
+// at a time. The lease is acquired and renewed with CheckAndSet so that
+// concurrent holders racing for it cannot both succeed.
+// NB: the lease expiry is compared against the local clock of each holder,
+// so the ttl should comfortably exceed the clock skew between holders.
+type Lease struct {
+	sync.Mutex
+
+	store  kv.Store
+	key    string
+	holder string
+	ttl    time.Duration
+	nowFn  clock.NowFn
+}
+
+// leaseValue is the value of a lease stored in KV, encoded as JSON.
+type leaseValue struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewLease returns a new lease stored in the given key for the given holder,
+// which must be unique among the holders competing for the lease.
+func NewLease(
+	store kv.Store,
+	key string,
+	holder string,
+	ttl time.Duration,
+	nowFn clock.NowFn,
+) (*Lease, error) {
+	if store == nil {
+		return nil, errNilStore
+	}
+	if ttl <= 0 {
+		return nil, errLeaseNotPositiveTTL
+	}
+	return &Lease{
+		store:  store,
+		key:    key,
+		holder: holder,
+		ttl:    ttl,
+		nowFn:  nowFn,
+	}, nil
+}
+
+// Acquire acquires the lease, or renews it if already held, and returns
+// whether the lease is held until at least the ttl from now.
+func (l *Lease) Acquire() (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := l.nowFn()
+	next := leaseValue{Holder: l.holder, ExpiresAt: now.Add(l.ttl)}
+
+	current, version, err := l.get()
+	if err == kv.ErrNotFound {
+		err = l.setIfNotExists(next)
+		if err == kv.ErrAlreadyExists {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if current.Holder != l.holder && now.Before(current.ExpiresAt) {
+		return false, nil
+	}
+	err = l.checkAndSet(version, next)
+	if err == kv.ErrVersionMismatch {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release releases the lease if held, so that another holder can acquire it
+// without waiting for it to expire.
+func (l *Lease) Release() error {
+	l.Lock()
+	defer l.Unlock()
+
+	current, version, err := l.get()
+	if err == kv.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Holder != l.holder {
+		return nil
+	}
+
+	err = l.checkAndSet(version, leaseValue{Holder: l.holder})
+	if err == kv.ErrVersionMismatch {
+		// Acquired by another holder in the meantime.
+		return nil
+	}
+	return err
+}
+
+func (l *Lease) get() (leaseValue, int, error) {
+	value, err := l.store.Get(l.key)
+	if err != nil {
+		return leaseValue{}, 0, err
+	}
+
+	var encoded commonpb.StringProto
+	if err := value.Unmarshal(&encoded); err != nil {
+		return leaseValue{}, 0, err
+	}
+	var lease leaseValue
+	if err := json.Unmarshal([]byte(encoded.Value), &lease); err != nil {
+		return leaseValue{}, 0, err
+	}
+	return lease, value.Version(), nil
+}
+
+func (l *Lease) setIfNotExists(lease leaseValue) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	_, err = l.store.SetIfNotExists(l.key, &commonpb.StringProto{Value: string(data)})
+	return err
+}
+
+func (l *Lease) checkAndSet(version int, lease leaseValue) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+	_, err = l.store.CheckAndSet(l.key, version, &commonpb.StringProto{Value: string(data)})
+	return err
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv/mem"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLease(t *testing.T) {
+	var (
+		store = mem.NewStore()
+		now   = time.Unix(1600000000, 0)
+		nowFn = func() time.Time { return now }
+	)
+	a, err := NewLease(store, "lease", "a", time.Minute, nowFn)
+	require.NoError(t, err)
+	b, err := NewLease(store, "lease", "b", time.Minute, nowFn)
+	require.NoError(t, err)
+
+	held, err := a.Acquire()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// The lease is held by a until it expires, unless renewed.
+	held, err = b.Acquire()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	now = now.Add(30 * time.Second)
+	held, err = a.Acquire()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	now = now.Add(45 * time.Second)
+	held, err = b.Acquire()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	now = now.Add(30 * time.Second)
+	held, err = b.Acquire()
+	require.NoError(t, err)
+	require.True(t, held)
+
+	// A released lease can be acquired without waiting for it to expire.
+	require.NoError(t, a.Release())
+	held, err = a.Acquire()
+	require.NoError(t, err)
+	require.False(t, held)
+
+	require.NoError(t, b.Release())
+	held, err = a.Acquire()
+	require.NoError(t, err)
+	require.True(t, held)
+}
+
+func TestNewLeaseInvalid(t *testing.T) {
+	_, err := NewLease(nil, "lease", "a", time.Minute, time.Now)
+	require.Equal(t, errNilStore, err)
+
+	_, err = NewLease(mem.NewStore(), "lease", "a", 0, time.Now)
+	require.Equal(t, errLeaseNotPositiveTTL, err)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package provider provides configuration that merges a base configuration,
+// typically loaded from file, with overrides stored in KV so that tunables
+// can be changed at runtime without a restart.
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/util/runtime"
+	xwatch "github.com/m3db/m3/src/x/watch"
+
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var errBaseNotPointer = errors.New("base config must be a non-nil pointer")
+
+// Provider provides a configuration and notifies watches whenever it
+// changes. Overrides are stored in KV as a YAML document in a string proto
+// and are applied on top of the base configuration, so that only the
+// overridden fields need to be set. Overrides that fail to parse or
+// validate are rejected and the current configuration is kept.
+type Provider interface {
+	// Get returns the current configuration, which has the same type as the
+	// base configuration and must not be modified.
+	Get() interface{}
+
+	// Watch returns the current configuration and a watch that is notified
+	// whenever the configuration changes.
+	Watch() (interface{}, xwatch.Watch, error)
+
+	// Close stops watching the overrides.
+	Close()
+}
+
+type provider struct {
+	baseType   reflect.Type
+	baseYAML   []byte
+	validateFn ValidateFn
+	log        *zap.Logger
+
+	value     runtime.Value
+	watchable xwatch.Watchable
+}
+
+// NewProvider creates a new provider with the given base configuration,
+// which must be a pointer to a struct that round trips through YAML, and
+// starts watching for overrides stored under the given key.
+func NewProvider(base interface{}, key string, opts Options) (Provider, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	baseValue := reflect.ValueOf(base)
+	if baseValue.Kind() != reflect.Ptr || baseValue.IsNil() {
+		return nil, errBaseNotPointer
+	}
+	// NB: keep the base as YAML so each merge starts from a deep copy.
+	baseYAML, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal base config: %v", err)
+	}
+
+	p := &provider{
+		baseType:   baseValue.Type().Elem(),
+		baseYAML:   baseYAML,
+		validateFn: opts.ValidateFn(),
+		log:        opts.InstrumentOptions().Logger(),
+		watchable:  xwatch.NewWatchable(),
+	}
+	if err := p.watchable.Update(base); err != nil {
+		return nil, err
+	}
+
+	valueOpts := runtime.NewOptions().
+		SetInstrumentOptions(opts.InstrumentOptions()).
+		SetInitWatchTimeout(opts.InitWatchTimeout()).
+		SetKVStore(opts.KVStore()).
+		SetUnmarshalFn(p.unmarshal).
+		SetProcessFn(p.process)
+	p.value = runtime.NewValue(key, valueOpts)
+	if err := p.value.Watch(); err != nil {
+		if _, ok := err.(xwatch.InitValueError); !ok {
+			return nil, err
+		}
+		// NB: the overrides are optional, keep watching in case they are
+		// set later.
+		p.log.Info("no config overrides found, using base config",
+			zap.String("key", key))
+	}
+
+	return p, nil
+}
+
+func (p *provider) Get() interface{} {
+	return p.watchable.Get()
+}
+
+func (p *provider) Watch() (interface{}, xwatch.Watch, error) {
+	return p.watchable.Watch()
+}
+
+func (p *provider) Close() {
+	p.value.Unwatch()
+	p.watchable.Close()
+}
+
+func (p *provider) unmarshal(value kv.Value) (interface{}, error) {
+	var overrides commonpb.StringProto
+	if err := value.Unmarshal(&overrides); err != nil {
+		return nil, err
+	}
+	return p.merge([]byte(overrides.Value))
+}
+
+func (p *provider) merge(overrides []byte) (interface{}, error) {
+	merged := reflect.New(p.baseType).Interface()
+	if err := yaml.Unmarshal(p.baseYAML, merged); err != nil {
+		return nil, fmt.Errorf("unable to copy base config: %v", err)
+	}
+	if err := yaml.UnmarshalStrict(overrides, merged); err != nil {
+		return nil, fmt.Errorf("unable to apply config overrides: %v", err)
+	}
+	if p.validateFn != nil {
+		if err := p.validateFn(merged); err != nil {
+			return nil, fmt.Errorf("invalid config overrides: %v", err)
+		}
+	}
+	return merged, nil
+}
+
+func (p *provider) process(value interface{}) error {
+	return p.watchable.Update(value)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	xwatch "github.com/m3db/m3/src/x/watch"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testKey = "testConfig"
+
+type testLimits struct {
+	MaxQueries int           `yaml:"maxQueries"`
+	QueueTime  time.Duration `yaml:"queueTime"`
+}
+
+type testConfig struct {
+	Level  string            `yaml:"level"`
+	Limits testLimits        `yaml:"limits"`
+	Tags   map[string]string `yaml:"tags"`
+}
+
+func newTestBase() *testConfig {
+	return &testConfig{
+		Level:  "info",
+		Limits: testLimits{MaxQueries: 1, QueueTime: time.Second},
+		Tags:   map[string]string{"foo": "bar"},
+	}
+}
+
+func newTestProvider(t *testing.T, store kv.Store, base *testConfig) Provider {
+	opts := NewOptions().
+		SetKVStore(store).
+		SetInitWatchTimeout(10 * time.Millisecond).
+		SetValidateFn(func(value interface{}) error {
+			if value.(*testConfig).Limits.MaxQueries < 0 {
+				return errors.New("negative max queries")
+			}
+			return nil
+		})
+	p, err := NewProvider(base, testKey, opts)
+	require.NoError(t, err)
+	return p
+}
+
+func setOverrides(t *testing.T, store kv.Store, overrides string) {
+	_, err := store.Set(testKey, &commonpb.StringProto{Value: overrides})
+	require.NoError(t, err)
+}
+
+func waitForConfig(t *testing.T, w xwatch.Watch, fn func(*testConfig) bool) *testConfig {
+	for {
+		select {
+		case <-w.C():
+			if cfg := w.Get().(*testConfig); fn(cfg) {
+				return cfg
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for config")
+		}
+	}
+}
+
+func TestNewProviderInvalid(t *testing.T) {
+	_, err := NewProvider(newTestBase(), testKey, NewOptions())
+	require.Equal(t, errNoKVStore, err)
+
+	opts := NewOptions().SetKVStore(mem.NewStore())
+	_, err = NewProvider(*newTestBase(), testKey, opts)
+	require.Equal(t, errBaseNotPointer, err)
+}
+
+func TestProviderNoOverrides(t *testing.T) {
+	base := newTestBase()
+	p := newTestProvider(t, mem.NewStore(), base)
+	defer p.Close()
+
+	require.Equal(t, base, p.Get())
+}
+
+func TestProviderAppliesOverrides(t *testing.T) {
+	var (
+		store = mem.NewStore()
+		base  = newTestBase()
+		p     = newTestProvider(t, store, base)
+	)
+	defer p.Close()
+
+	_, w, err := p.Watch()
+	require.NoError(t, err)
+	defer w.Close()
+
+	setOverrides(t, store, "level: debug\nlimits:\n  maxQueries: 5\n")
+	cfg := waitForConfig(t, w, func(cfg *testConfig) bool {
+		return cfg.Level == "debug"
+	})
+	require.Equal(t, &testConfig{
+		Level:  "debug",
+		Limits: testLimits{MaxQueries: 5, QueueTime: time.Second},
+		Tags:   map[string]string{"foo": "bar"},
+	}, cfg)
+
+	// The base config is left untouched.
+	require.Equal(t, newTestBase(), base)
+
+	// Each update is applied on top of the base config rather than on top
+	// of the previous overrides.
+	setOverrides(t, store, "tags:\n  baz: qux\n")
+	cfg = waitForConfig(t, w, func(cfg *testConfig) bool {
+		return cfg.Tags["baz"] == "qux"
+	})
+	require.Equal(t, &testConfig{
+		Level:  "info",
+		Limits: testLimits{MaxQueries: 1, QueueTime: time.Second},
+		Tags:   map[string]string{"foo": "bar", "baz": "qux"},
+	}, cfg)
+}
+
+func TestProviderRejectsInvalidOverrides(t *testing.T) {
+	var (
+		store = mem.NewStore()
+		p     = newTestProvider(t, store, newTestBase())
+	)
+	defer p.Close()
+
+	_, w, err := p.Watch()
+	require.NoError(t, err)
+	defer w.Close()
+
+	for _, overrides := range []string{
+		"unknownField: true\n",
+		"limits:\n  maxQueries: -1\n",
+		"level: warn\n",
+	} {
+		setOverrides(t, store, overrides)
+	}
+
+	// Only the valid overrides are ever applied.
+	waitForConfig(t, w, func(cfg *testConfig) bool {
+		require.True(t, cfg.Limits.MaxQueries >= 0)
+		return cfg.Level == "warn"
+	})
+}
@@ -36,6 +36,7 @@ type Configuration struct {
 	ShardStateMode      *ShardStateMode `yaml:"shardStateMode"`
 	IsMirrored          *bool           `yaml:"isMirrored"`
 	IsStaged            *bool           `yaml:"isStaged"`
+	StagedRollout       *bool           `yaml:"stagedRollout"`
 	ValidZone           *string         `yaml:"validZone"`
 }
 
@@ -63,6 +64,9 @@ func (c *Configuration) NewOptions() Options {
 	if value := c.IsStaged; value != nil {
 		opts = opts.SetIsStaged(*value)
 	}
+	if value := c.StagedRollout; value != nil {
+		opts = opts.SetStagedRollout(*value)
+	}
 	if value := c.ValidZone; value != nil {
 		opts = opts.SetValidZone(*value)
 	}
@@ -74,6 +74,7 @@ type options struct {
 	allowAllZones       bool
 	addAllCandidates    bool
 	dryrun              bool
+	stagedRollout       bool
 	isSharded           bool
 	isMirrored          bool
 	isStaged            bool
@@ -170,6 +171,15 @@ func (o options) SetDryrun(d bool) Options {
 	return o
 }
 
+func (o options) StagedRollout() bool {
+	return o.stagedRollout
+}
+
+func (o options) SetStagedRollout(v bool) Options {
+	o.stagedRollout = v
+	return o
+}
+
 func (o options) InstrumentOptions() instrument.Options {
 	return o.iopts
 }
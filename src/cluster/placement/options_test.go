@@ -46,6 +46,7 @@ func TestPlacementOptions(t *testing.T) {
 		assert.True(t, o.IsSharded())
 		assert.Equal(t, IncludeTransitionalShardStates, o.ShardStateMode())
 		assert.False(t, o.Dryrun())
+		assert.False(t, o.StagedRollout())
 		assert.False(t, o.IsMirrored())
 		assert.False(t, o.IsStaged())
 		assert.NotNil(t, o.InstrumentOptions())
@@ -72,6 +73,9 @@ func TestPlacementOptions(t *testing.T) {
 		o = o.SetDryrun(true)
 		assert.True(t, o.Dryrun())
 
+		o = o.SetStagedRollout(true)
+		assert.True(t, o.StagedRollout())
+
 		o = o.SetIsMirrored(true)
 		assert.True(t, o.IsMirrored())
 
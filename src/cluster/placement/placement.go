@@ -395,6 +395,24 @@ func Validate(p Placement) error {
 	return nil
 }
 
+// NumShardsMoved returns the number of shards that are newly initializing
+// on an instance in the next placement compared to the previous placement,
+// which is the number of shards that need to be streamed between instances
+// for the next placement to take effect.
+func NumShardsMoved(prev, next Placement) int {
+	moved := 0
+	for _, instance := range next.Instances() {
+		prevInstance, exists := prev.Instance(instance.ID())
+		for _, s := range instance.Shards().ShardsForState(shard.Initializing) {
+			if exists && prevInstance.Shards().Contains(s.ID()) {
+				continue
+			}
+			moved++
+		}
+	}
+	return moved
+}
+
 func convertShardSliceToMap(ids []uint32) map[uint32]int {
 	shardCounts := make(map[uint32]int)
 	for _, id := range ids {
@@ -1134,6 +1134,22 @@ func (mr *MockActiveStagedPlacementMockRecorder) ActivePlacement() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivePlacement", reflect.TypeOf((*MockActiveStagedPlacement)(nil).ActivePlacement))
 }
 
+// ActivePlacementsInRange mocks base method
+func (m *MockActiveStagedPlacement) ActivePlacementsInRange(startNanos, endNanos int64) (Placements, DoneFn, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivePlacementsInRange", startNanos, endNanos)
+	ret0, _ := ret[0].(Placements)
+	ret1, _ := ret[1].(DoneFn)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ActivePlacementsInRange indicates an expected call of ActivePlacementsInRange
+func (mr *MockActiveStagedPlacementMockRecorder) ActivePlacementsInRange(startNanos, endNanos interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivePlacementsInRange", reflect.TypeOf((*MockActiveStagedPlacement)(nil).ActivePlacementsInRange), startNanos, endNanos)
+}
+
 // Close mocks base method
 func (m *MockActiveStagedPlacement) Close() error {
 	m.ctrl.T.Helper()
@@ -1255,6 +1271,34 @@ func (mr *MockActiveStagedPlacementOptionsMockRecorder) OnPlacementsRemovedFn()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPlacementsRemovedFn", reflect.TypeOf((*MockActiveStagedPlacementOptions)(nil).OnPlacementsRemovedFn))
 }
 
+// SetExpiryDelay mocks base method
+func (m *MockActiveStagedPlacementOptions) SetExpiryDelay(value time.Duration) ActiveStagedPlacementOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExpiryDelay", value)
+	ret0, _ := ret[0].(ActiveStagedPlacementOptions)
+	return ret0
+}
+
+// SetExpiryDelay indicates an expected call of SetExpiryDelay
+func (mr *MockActiveStagedPlacementOptionsMockRecorder) SetExpiryDelay(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExpiryDelay", reflect.TypeOf((*MockActiveStagedPlacementOptions)(nil).SetExpiryDelay), value)
+}
+
+// ExpiryDelay mocks base method
+func (m *MockActiveStagedPlacementOptions) ExpiryDelay() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpiryDelay")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// ExpiryDelay indicates an expected call of ExpiryDelay
+func (mr *MockActiveStagedPlacementOptionsMockRecorder) ExpiryDelay() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpiryDelay", reflect.TypeOf((*MockActiveStagedPlacementOptions)(nil).ExpiryDelay))
+}
+
 // MockStagedPlacement is a mock of StagedPlacement interface
 type MockStagedPlacement struct {
 	ctrl     *gomock.Controller
@@ -1610,6 +1654,34 @@ func (mr *MockOptionsMockRecorder) SetDryrun(d interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDryrun", reflect.TypeOf((*MockOptions)(nil).SetDryrun), d)
 }
 
+// StagedRollout mocks base method
+func (m *MockOptions) StagedRollout() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StagedRollout")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// StagedRollout indicates an expected call of StagedRollout
+func (mr *MockOptionsMockRecorder) StagedRollout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StagedRollout", reflect.TypeOf((*MockOptions)(nil).StagedRollout))
+}
+
+// SetStagedRollout mocks base method
+func (m *MockOptions) SetStagedRollout(v bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStagedRollout", v)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetStagedRollout indicates an expected call of SetStagedRollout
+func (mr *MockOptionsMockRecorder) SetStagedRollout(v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStagedRollout", reflect.TypeOf((*MockOptions)(nil).SetStagedRollout), v)
+}
+
 // IsMirrored mocks base method
 func (m *MockOptions) IsMirrored() bool {
 	m.ctrl.T.Helper()
@@ -2391,6 +2463,50 @@ func (mr *MockServiceMockRecorder) MarkAllShardsAvailable() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkAllShardsAvailable", reflect.TypeOf((*MockService)(nil).MarkAllShardsAvailable))
 }
 
+// PendingPlacement mocks base method
+func (m *MockService) PendingPlacement() (Placement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingPlacement")
+	ret0, _ := ret[0].(Placement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingPlacement indicates an expected call of PendingPlacement
+func (mr *MockServiceMockRecorder) PendingPlacement() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingPlacement", reflect.TypeOf((*MockService)(nil).PendingPlacement))
+}
+
+// ApplyPendingPlacement mocks base method
+func (m *MockService) ApplyPendingPlacement() (Placement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyPendingPlacement")
+	ret0, _ := ret[0].(Placement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApplyPendingPlacement indicates an expected call of ApplyPendingPlacement
+func (mr *MockServiceMockRecorder) ApplyPendingPlacement() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyPendingPlacement", reflect.TypeOf((*MockService)(nil).ApplyPendingPlacement))
+}
+
+// DeletePendingPlacement mocks base method
+func (m *MockService) DeletePendingPlacement() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingPlacement")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingPlacement indicates an expected call of DeletePendingPlacement
+func (mr *MockServiceMockRecorder) DeletePendingPlacement() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingPlacement", reflect.TypeOf((*MockService)(nil).DeletePendingPlacement))
+}
+
 // MockAlgorithm is a mock of Algorithm interface
 type MockAlgorithm struct {
 	ctrl     *gomock.Controller
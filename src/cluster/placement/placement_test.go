@@ -682,3 +682,27 @@ func getProtoShards(ids []uint32) []*placementpb.Shard {
 	}
 	return r
 }
+
+func TestNumShardsMoved(t *testing.T) {
+	i1 := NewEmptyInstance("i1", "r1", "z1", "endpoint", 1)
+	i1.Shards().Add(shard.NewShard(1).SetState(shard.Available))
+	i1.Shards().Add(shard.NewShard(2).SetState(shard.Available))
+
+	i2 := NewEmptyInstance("i2", "r2", "z1", "endpoint", 1)
+	i2.Shards().Add(shard.NewShard(3).SetState(shard.Initializing))
+
+	prev := NewPlacement().SetInstances([]Instance{i1, i2}).SetShards([]uint32{1, 2, 3})
+
+	next := prev.Clone()
+	nextI1, ok := next.Instance("i1")
+	assert.True(t, ok)
+	nextI1.Shards().Add(shard.NewShard(2).SetState(shard.Leaving))
+
+	i3 := NewEmptyInstance("i3", "r3", "z1", "endpoint", 1)
+	i3.Shards().Add(shard.NewShard(2).SetState(shard.Initializing).SetSourceID("i1"))
+	next = next.SetInstances(append(next.Instances(), i3))
+
+	// Shard 3 was already initializing on i2, only shard 2 moves to i3.
+	assert.Equal(t, 1, NumShardsMoved(prev, next))
+	assert.Equal(t, 0, NumShardsMoved(next, next))
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rollout
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/m3db/m3/src/cluster/placement"
+
+	"go.uber.org/atomic"
+)
+
+type gateFn struct {
+	name string
+	fn   func(p placement.Placement) error
+}
+
+// NewGate returns a gate that checks the placement with a function.
+func NewGate(name string, fn func(p placement.Placement) error) Gate {
+	return gateFn{name: name, fn: fn}
+}
+
+func (g gateFn) Name() string {
+	return g.name
+}
+
+func (g gateFn) Check(p placement.Placement) error {
+	return g.fn(p)
+}
+
+// ErrorCounter counts requests and their errors, for an error rate gate.
+type ErrorCounter struct {
+	requests atomic.Int64
+	errors   atomic.Int64
+}
+
+// NewErrorCounter returns a new error counter.
+func NewErrorCounter() *ErrorCounter {
+	return &ErrorCounter{}
+}
+
+// Record records a request, counting it as an error if it failed. It is a
+// no-op on a nil counter.
+func (c *ErrorCounter) Record(failed bool) {
+	if c == nil {
+		return
+	}
+	c.requests.Inc()
+	if failed {
+		c.errors.Inc()
+	}
+}
+
+// Counts returns the number of requests and errors recorded.
+func (c *ErrorCounter) Counts() (requests, errors int64) {
+	return c.requests.Load(), c.errors.Load()
+}
+
+type errorRateGate struct {
+	sync.Mutex
+
+	counter      *ErrorCounter
+	maxRate      float64
+	minRequests  int64
+	lastRequests int64
+	lastErrors   int64
+}
+
+// NewErrorRateGate returns a gate that fails if the rate of errors recorded
+// by a counter since the previous check exceeds the max rate, once at least
+// the min number of requests have been recorded since the previous check.
+func NewErrorRateGate(counter *ErrorCounter, maxRate float64, minRequests int64) Gate {
+	requests, errors := counter.Counts()
+	return &errorRateGate{
+		counter:      counter,
+		maxRate:      maxRate,
+		minRequests:  minRequests,
+		lastRequests: requests,
+		lastErrors:   errors,
+	}
+}
+
+func (g *errorRateGate) Name() string {
+	return "error-rate"
+}
+
+func (g *errorRateGate) Check(placement.Placement) error {
+	g.Lock()
+	defer g.Unlock()
+
+	requests, errors := g.counter.Counts()
+	deltaRequests, deltaErrors := requests-g.lastRequests, errors-g.lastErrors
+	if deltaRequests == 0 || deltaRequests < g.minRequests {
+		// Keep accumulating until there are enough requests.
+		return nil
+	}
+	g.lastRequests, g.lastErrors = requests, errors
+
+	rate := float64(deltaErrors) / float64(deltaRequests)
+	if rate > g.maxRate {
+		return fmt.Errorf("error rate %.4f exceeds %.4f over %d requests",
+			rate, g.maxRate, deltaRequests)
+	}
+	return nil
+}
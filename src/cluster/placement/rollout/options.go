@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rollout
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultWindow        = time.Hour
+	defaultCheckInterval = 10 * time.Second
+)
+
+var (
+	errWindowNotPositive        = errors.New("rollout window must be positive")
+	errCheckIntervalNotPositive = errors.New("rollout check interval must be positive")
+	errMaxStallNegative         = errors.New("rollout max stall must not be negative")
+)
+
+type options struct {
+	window         time.Duration
+	maxStall       time.Duration
+	checkInterval  time.Duration
+	gates          []Gate
+	clockOpts      clock.Options
+	instrumentOpts instrument.Options
+}
+
+// NewOptions returns new rollout options.
+func NewOptions() Options {
+	return &options{
+		window:         defaultWindow,
+		checkInterval:  defaultCheckInterval,
+		clockOpts:      clock.NewOptions(),
+		instrumentOpts: instrument.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.window <= 0 {
+		return errWindowNotPositive
+	}
+	if o.checkInterval <= 0 {
+		return errCheckIntervalNotPositive
+	}
+	if o.maxStall < 0 {
+		return errMaxStallNegative
+	}
+	return nil
+}
+
+func (o *options) SetWindow(value time.Duration) Options {
+	opts := *o
+	opts.window = value
+	return &opts
+}
+
+func (o *options) Window() time.Duration {
+	return o.window
+}
+
+func (o *options) SetMaxStall(value time.Duration) Options {
+	opts := *o
+	opts.maxStall = value
+	return &opts
+}
+
+func (o *options) MaxStall() time.Duration {
+	return o.maxStall
+}
+
+func (o *options) SetCheckInterval(value time.Duration) Options {
+	opts := *o
+	opts.checkInterval = value
+	return &opts
+}
+
+func (o *options) CheckInterval() time.Duration {
+	return o.checkInterval
+}
+
+func (o *options) SetGates(value []Gate) Options {
+	opts := *o
+	opts.gates = value
+	return &opts
+}
+
+func (o *options) Gates() []Gate {
+	return o.gates
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
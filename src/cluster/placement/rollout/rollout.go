@@ -0,0 +1,342 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rollout
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const maxRollbackAttempts = 5
+
+var errRollbackConflict = errors.New("placement changed concurrently with rollback")
+
+type rolloutMetrics struct {
+	succeeded  tally.Counter
+	rolledBack tally.Counter
+	superseded tally.Counter
+	failed     tally.Counter
+}
+
+func newRolloutMetrics(scope tally.Scope) rolloutMetrics {
+	scope = scope.SubScope("placement-rollout")
+	return rolloutMetrics{
+		succeeded:  scope.Counter("succeeded"),
+		rolledBack: scope.Counter("rolled-back"),
+		superseded: scope.Counter("superseded"),
+		failed:     scope.Counter("failed"),
+	}
+}
+
+// Rollout is a placement change being monitored until every shard it moved
+// is available.
+type Rollout struct {
+	sync.RWMutex
+
+	service  placement.Service
+	opts     Options
+	logger   *zap.Logger
+	metrics  rolloutMetrics
+	previous placement.Placement
+	// initializing are the shards initializing on each instance when the
+	// rollout was applied, a placement with other initializing shards has
+	// been changed by another operation.
+	initializing map[string]map[uint32]struct{}
+	status       Status
+	closeCh      chan struct{}
+	doneCh       chan struct{}
+	closeOnce    sync.Once
+}
+
+// Start applies the next placement if the current placement is still at the
+// expected version, then monitors the rollout until every shard is available
+// or a health gate fails.
+func Start(
+	service placement.Service,
+	next placement.Placement,
+	expectedVersion int,
+	opts Options,
+) (*Rollout, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	previous, err := service.Placement()
+	if err != nil {
+		return nil, err
+	}
+	if previous.Version() != expectedVersion {
+		return nil, kv.ErrVersionMismatch
+	}
+	if n := numInitializing(previous); n > 0 {
+		return nil, fmt.Errorf(
+			"placement has %d initializing shards from a previous change", n)
+	}
+
+	applied, err := service.CheckAndSet(next, previous.Version())
+	if err != nil {
+		return nil, err
+	}
+
+	iOpts := opts.InstrumentOptions()
+	r := &Rollout{
+		service:      service,
+		opts:         opts,
+		logger:       iOpts.Logger(),
+		metrics:      newRolloutMetrics(iOpts.MetricsScope()),
+		previous:     previous,
+		initializing: initializingShards(applied),
+		status: Status{
+			State:           StateInProgress,
+			PreviousVersion: previous.Version(),
+			Version:         applied.Version(),
+			StartedAt:       opts.ClockOptions().NowFn()(),
+		},
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	r.status.InitializingShards = numInitializing(applied)
+	r.status.RemainingInitializingShards = r.status.InitializingShards
+
+	go r.monitor()
+	return r, nil
+}
+
+// Status returns the status of the rollout.
+func (r *Rollout) Status() Status {
+	r.RLock()
+	defer r.RUnlock()
+	return r.status
+}
+
+// Done returns a channel closed once the rollout is no longer monitored.
+func (r *Rollout) Done() <-chan struct{} {
+	return r.doneCh
+}
+
+// Close stops monitoring the rollout without rolling it back.
+func (r *Rollout) Close() {
+	r.closeOnce.Do(func() { close(r.closeCh) })
+	<-r.doneCh
+}
+
+func (r *Rollout) monitor() {
+	defer close(r.doneCh)
+
+	var (
+		nowFn        = r.opts.ClockOptions().NowFn()
+		start        = r.Status().StartedAt
+		deadline     = start.Add(r.opts.Window())
+		lastProgress = start
+		remaining    = r.Status().RemainingInitializingShards
+		ticker       = time.NewTicker(r.opts.CheckInterval())
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.closeCh:
+			r.finish(StateStopped, "rollout monitoring stopped", 0)
+			return
+		}
+
+		now := nowFn()
+		current, err := r.service.Placement()
+		if err != nil {
+			r.logger.Warn("could not get placement to check rollout", zap.Error(err))
+			if now.After(deadline) {
+				r.rollback(fmt.Sprintf("could not get placement: %v", err))
+				return
+			}
+			continue
+		}
+
+		if reason, ok := r.superseded(current); ok {
+			r.metrics.superseded.Inc(1)
+			r.finish(StateSuperseded, reason, 0)
+			return
+		}
+
+		n := numInitializing(current)
+		r.Lock()
+		r.status.RemainingInitializingShards = n
+		r.Unlock()
+		if n == 0 {
+			r.metrics.succeeded.Inc(1)
+			r.finish(StateSucceeded, "", 0)
+			return
+		}
+		if n < remaining {
+			remaining = n
+			lastProgress = now
+		}
+
+		if reason, failed := r.checkGates(current, now, deadline, lastProgress); failed {
+			r.rollback(reason)
+			return
+		}
+	}
+}
+
+func (r *Rollout) checkGates(
+	current placement.Placement,
+	now, deadline, lastProgress time.Time,
+) (string, bool) {
+	for _, gate := range r.opts.Gates() {
+		if err := gate.Check(current); err != nil {
+			return fmt.Sprintf("gate %s failed: %v", gate.Name(), err), true
+		}
+	}
+	if now.After(deadline) {
+		return fmt.Sprintf("shards not available within %v", r.opts.Window()), true
+	}
+	if maxStall := r.opts.MaxStall(); maxStall > 0 && now.Sub(lastProgress) > maxStall {
+		return fmt.Sprintf("no shard became available within %v", maxStall), true
+	}
+	return "", false
+}
+
+// superseded returns whether the placement has initializing shards that
+// were not initializing when the rollout was applied.
+func (r *Rollout) superseded(current placement.Placement) (string, bool) {
+	for _, instance := range current.Instances() {
+		for _, s := range instance.Shards().ShardsForState(shard.Initializing) {
+			if _, ok := r.initializing[instance.ID()][s.ID()]; !ok {
+				return fmt.Sprintf("shard %d initializing on instance %s by another change",
+					s.ID(), instance.ID()), true
+			}
+		}
+	}
+	return "", false
+}
+
+func (r *Rollout) rollback(reason string) {
+	r.logger.Warn("rolling back placement rollout", zap.String("reason", reason))
+
+	var err error
+	for attempt := 0; attempt < maxRollbackAttempts; attempt++ {
+		var current placement.Placement
+		current, err = r.service.Placement()
+		if err != nil {
+			continue
+		}
+		var rolledBack placement.Placement
+		rolledBack, err = RollbackPlacement(current, r.previous)
+		if err != nil {
+			break
+		}
+		rolledBack, err = r.service.CheckAndSet(rolledBack, current.Version())
+		if err == kv.ErrVersionMismatch {
+			// Instances may be marking shards available concurrently.
+			err = errRollbackConflict
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		r.metrics.rolledBack.Inc(1)
+		r.finish(StateRolledBack, reason, rolledBack.Version())
+		return
+	}
+
+	r.logger.Error("could not roll back placement rollout", zap.Error(err))
+	r.metrics.failed.Inc(1)
+	r.finish(StateFailed, fmt.Sprintf("%s, rollback failed: %v", reason, err), 0)
+}
+
+func (r *Rollout) finish(state State, reason string, rollbackVersion int) {
+	r.Lock()
+	r.status.State = state
+	r.status.Reason = reason
+	r.status.RollbackVersion = rollbackVersion
+	r.status.FinishedAt = r.opts.ClockOptions().NowFn()()
+	r.Unlock()
+}
+
+// RollbackPlacement returns a copy of the current placement with the shard
+// moves still in flight since the previous placement reverted: initializing
+// shards are removed and the shards leaving their source instances are
+// available again. Shards that already became available are kept, and the
+// instances added since the previous placement that are left without shards
+// are removed.
+func RollbackPlacement(current, previous placement.Placement) (placement.Placement, error) {
+	rolledBack := current.Clone()
+	for _, instance := range rolledBack.Instances() {
+		prevInstance, _ := previous.Instance(instance.ID())
+		for _, s := range instance.Shards().ShardsForState(shard.Initializing) {
+			if prevInstance != nil && prevInstance.Shards().Contains(s.ID()) {
+				continue
+			}
+			if source, ok := rolledBack.Instance(s.SourceID()); ok {
+				if leaving, ok := source.Shards().Shard(s.ID()); ok &&
+					leaving.State() == shard.Leaving {
+					leaving.SetState(shard.Available)
+				}
+			}
+			instance.Shards().Remove(s.ID())
+		}
+	}
+
+	instances := make([]placement.Instance, 0, rolledBack.NumInstances())
+	for _, instance := range rolledBack.Instances() {
+		_, existed := previous.Instance(instance.ID())
+		if !existed && instance.Shards().NumShards() == 0 {
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	rolledBack = rolledBack.SetInstances(instances)
+
+	if err := placement.Validate(rolledBack); err != nil {
+		return nil, fmt.Errorf("rolled back placement is invalid: %v", err)
+	}
+	return rolledBack, nil
+}
+
+func initializingShards(p placement.Placement) map[string]map[uint32]struct{} {
+	result := make(map[string]map[uint32]struct{}, p.NumInstances())
+	for _, instance := range p.Instances() {
+		shards := make(map[uint32]struct{})
+		for _, s := range instance.Shards().ShardsForState(shard.Initializing) {
+			shards[s.ID()] = struct{}{}
+		}
+		result[instance.ID()] = shards
+	}
+	return result
+}
+
+func numInitializing(p placement.Placement) int {
+	n := 0
+	for _, instance := range p.Instances() {
+		n += instance.Shards().NumShardsForState(shard.Initializing)
+	}
+	return n
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package rollout
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/placement/service"
+	"github.com/m3db/m3/src/cluster/placement/storage"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInstance(id, group string, shardIDs ...uint32) placement.Instance {
+	shards := make([]shard.Shard, 0, len(shardIDs))
+	for _, s := range shardIDs {
+		shards = append(shards, shard.NewShard(s).SetState(shard.Available))
+	}
+	return placement.NewEmptyInstance(id, group, "zone", id+":9000", 1).
+		SetShards(shard.NewShards(shards))
+}
+
+// newTestService returns a placement service whose placement has shards 0
+// and 1 on instances a and b and shard 2 on instances c and d.
+func newTestService(t *testing.T) placement.Service {
+	opts := placement.NewOptions()
+	svc := service.NewPlacementService(
+		storage.NewPlacementStorage(mem.NewStore(), "placement", opts), opts)
+	_, err := svc.Set(placement.NewPlacement().
+		SetInstances([]placement.Instance{
+			newTestInstance("a", "g1", 0, 1),
+			newTestInstance("b", "g2", 0, 1),
+			newTestInstance("c", "g1", 2),
+			newTestInstance("d", "g2", 2),
+		}).
+		SetShards([]uint32{0, 1, 2}).
+		SetReplicaFactor(2).
+		SetIsSharded(true))
+	require.NoError(t, err)
+	return svc
+}
+
+// moveShard returns a copy of the placement with a shard moving between two
+// instances.
+func moveShard(t *testing.T, p placement.Placement, id uint32, from, to string) placement.Placement {
+	p = p.Clone()
+	source, ok := p.Instance(from)
+	require.True(t, ok)
+	s, ok := source.Shards().Shard(id)
+	require.True(t, ok)
+	s.SetState(shard.Leaving)
+	target, ok := p.Instance(to)
+	require.True(t, ok)
+	target.Shards().Add(shard.NewShard(id).SetState(shard.Initializing).SetSourceID(from))
+	require.NoError(t, placement.Validate(p))
+	return p
+}
+
+func newTestOptions() Options {
+	return NewOptions().SetCheckInterval(5 * time.Millisecond)
+}
+
+func waitDone(t *testing.T, r *Rollout) Status {
+	select {
+	case <-r.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("rollout did not complete")
+	}
+	return r.Status()
+}
+
+func requireShardState(
+	t *testing.T,
+	p placement.Placement,
+	instanceID string,
+	id uint32,
+	expected shard.State,
+) {
+	instance, ok := p.Instance(instanceID)
+	require.True(t, ok)
+	s, ok := instance.Shards().Shard(id)
+	require.True(t, ok, "shard %d not on instance %s", id, instanceID)
+	require.Equal(t, expected, s.State())
+}
+
+func TestRolloutSucceeds(t *testing.T) {
+	svc := newTestService(t)
+	current, err := svc.Placement()
+	require.NoError(t, err)
+
+	r, err := Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version(), newTestOptions())
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Status().InitializingShards)
+
+	_, err = svc.MarkShardsAvailable("c", 0)
+	require.NoError(t, err)
+
+	status := waitDone(t, r)
+	require.Equal(t, StateSucceeded, status.State)
+	require.Equal(t, current.Version(), status.PreviousVersion)
+	require.Equal(t, current.Version()+1, status.Version)
+	require.Equal(t, 0, status.RemainingInitializingShards)
+}
+
+func TestRolloutRollsBackOnGateFailure(t *testing.T) {
+	svc := newTestService(t)
+	current, err := svc.Placement()
+	require.NoError(t, err)
+
+	gate := NewGate("unhealthy", func(placement.Placement) error {
+		return errors.New("unhealthy")
+	})
+	r, err := Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version(), newTestOptions().SetGates([]Gate{gate}))
+	require.NoError(t, err)
+
+	status := waitDone(t, r)
+	require.Equal(t, StateRolledBack, status.State)
+	require.Contains(t, status.Reason, "unhealthy")
+	require.Equal(t, current.Version()+2, status.RollbackVersion)
+
+	rolledBack, err := svc.Placement()
+	require.NoError(t, err)
+	requireShardState(t, rolledBack, "a", 0, shard.Available)
+	c, _ := rolledBack.Instance("c")
+	require.False(t, c.Shards().Contains(0))
+}
+
+func TestRolloutRollsBackAfterWindow(t *testing.T) {
+	svc := newTestService(t)
+	current, err := svc.Placement()
+	require.NoError(t, err)
+
+	r, err := Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version(), newTestOptions().SetWindow(time.Millisecond))
+	require.NoError(t, err)
+
+	status := waitDone(t, r)
+	require.Equal(t, StateRolledBack, status.State)
+	require.Contains(t, status.Reason, "not available within")
+}
+
+func TestRolloutSuperseded(t *testing.T) {
+	svc := newTestService(t)
+	current, err := svc.Placement()
+	require.NoError(t, err)
+
+	opts := newTestOptions().SetWindow(time.Minute)
+	r, err := Start(svc, moveShard(t, current, 0, "a", "c"), current.Version(), opts)
+	require.NoError(t, err)
+
+	applied, err := svc.Placement()
+	require.NoError(t, err)
+	_, err = svc.CheckAndSet(moveShard(t, applied, 2, "d", "b"), applied.Version())
+	require.NoError(t, err)
+
+	status := waitDone(t, r)
+	require.Equal(t, StateSuperseded, status.State)
+}
+
+func TestRolloutStartErrors(t *testing.T) {
+	svc := newTestService(t)
+	current, err := svc.Placement()
+	require.NoError(t, err)
+
+	_, err = Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version()+1, newTestOptions())
+	require.Equal(t, kv.ErrVersionMismatch, err)
+
+	_, err = Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version(), newTestOptions().SetWindow(0))
+	require.Error(t, err)
+
+	r, err := Start(svc, moveShard(t, current, 0, "a", "c"),
+		current.Version(), newTestOptions().SetWindow(time.Minute))
+	require.NoError(t, err)
+	r.Close()
+	require.Equal(t, StateStopped, r.Status().State)
+
+	// The shards of the stopped rollout are still initializing.
+	applied, err := svc.Placement()
+	require.NoError(t, err)
+	_, err = Start(svc, moveShard(t, applied, 1, "a", "c"),
+		applied.Version(), newTestOptions())
+	require.Error(t, err)
+}
+
+func TestRollbackPlacementRemovesAddedInstances(t *testing.T) {
+	previous := placement.NewPlacement().
+		SetInstances([]placement.Instance{
+			newTestInstance("a", "g1", 0, 1),
+			newTestInstance("b", "g2", 0, 1),
+		}).
+		SetShards([]uint32{0, 1}).
+		SetReplicaFactor(2).
+		SetIsSharded(true)
+
+	current := previous.Clone()
+	current = current.SetInstances(append(current.Instances(),
+		newTestInstance("e", "g1")))
+	current = moveShard(t, current, 1, "a", "e")
+
+	rolledBack, err := RollbackPlacement(current, previous)
+	require.NoError(t, err)
+	require.Equal(t, 2, rolledBack.NumInstances())
+	requireShardState(t, rolledBack, "a", 1, shard.Available)
+	_, ok := rolledBack.Instance("e")
+	require.False(t, ok)
+}
+
+func TestErrorRateGate(t *testing.T) {
+	counter := NewErrorCounter()
+	gate := NewErrorRateGate(counter, 0.1, 10)
+
+	for i := 0; i < 5; i++ {
+		counter.Record(true)
+	}
+	// Not enough requests to compute the rate yet.
+	require.NoError(t, gate.Check(nil))
+
+	for i := 0; i < 45; i++ {
+		counter.Record(false)
+	}
+	require.Error(t, gate.Check(nil))
+
+	for i := 0; i < 100; i++ {
+		counter.Record(i%20 == 0)
+	}
+	require.NoError(t, gate.Check(nil))
+
+	var nilCounter *ErrorCounter
+	nilCounter.Record(true)
+}
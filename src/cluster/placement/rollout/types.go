@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rollout applies placement changes in two phases, first applying
+// the placement with its moving shards initializing and then monitoring
+// health gates until every shard is available, rolling back the shard moves
+// still in flight if a gate fails.
+package rollout
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// State is the state of a rollout.
+type State string
+
+// List of rollout states.
+const (
+	// StateInProgress is the state of a rollout whose shards are still
+	// initializing.
+	StateInProgress State = "in-progress"
+	// StateSucceeded is the state of a rollout whose shards are all available.
+	StateSucceeded State = "succeeded"
+	// StateRolledBack is the state of a rollout whose in flight shard moves
+	// were rolled back after a health gate failed.
+	StateRolledBack State = "rolled-back"
+	// StateSuperseded is the state of a rollout that stopped being monitored
+	// because the placement was changed by another operation.
+	StateSuperseded State = "superseded"
+	// StateStopped is the state of a rollout that stopped being monitored
+	// before completing.
+	StateStopped State = "stopped"
+	// StateFailed is the state of a rollout that could not be rolled back.
+	StateFailed State = "failed"
+)
+
+// Status is the status of a rollout.
+type Status struct {
+	State State `json:"state"`
+	// PreviousVersion is the version of the placement the rollout changed.
+	PreviousVersion int `json:"previousVersion"`
+	// Version is the version of the placement the rollout applied.
+	Version int `json:"version"`
+	// RollbackVersion is the version of the placement that rolled back the
+	// rollout, if rolled back.
+	RollbackVersion int `json:"rollbackVersion,omitempty"`
+	// InitializingShards is the number of shards initializing when the
+	// rollout was applied and the number still initializing.
+	InitializingShards          int       `json:"initializingShards"`
+	RemainingInitializingShards int       `json:"remainingInitializingShards"`
+	StartedAt                   time.Time `json:"startedAt"`
+	FinishedAt                  time.Time `json:"finishedAt,omitempty"`
+	// Reason is why the rollout was rolled back, superseded or failed.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Done returns whether the rollout is no longer being monitored.
+func (s Status) Done() bool {
+	return s.State != StateInProgress
+}
+
+// Gate is a health gate checked while a rollout is in progress, the rollout
+// is rolled back when a gate fails.
+type Gate interface {
+	// Name returns the name of the gate.
+	Name() string
+
+	// Check returns an error if the gate fails for the current placement.
+	Check(p placement.Placement) error
+}
+
+// Options are the options of a rollout.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetWindow sets the time within which every shard must become available
+	// before the rollout is rolled back.
+	SetWindow(value time.Duration) Options
+
+	// Window returns the time within which every shard must become available
+	// before the rollout is rolled back.
+	Window() time.Duration
+
+	// SetMaxStall sets the time after which the rollout is rolled back if no
+	// shard became available, zero disables the check.
+	SetMaxStall(value time.Duration) Options
+
+	// MaxStall returns the time after which the rollout is rolled back if no
+	// shard became available.
+	MaxStall() time.Duration
+
+	// SetCheckInterval sets how often the placement and the gates are checked.
+	SetCheckInterval(value time.Duration) Options
+
+	// CheckInterval returns how often the placement and the gates are checked.
+	CheckInterval() time.Duration
+
+	// SetGates sets the health gates.
+	SetGates(value []Gate) Options
+
+	// Gates returns the health gates.
+	Gates() []Gate
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+}
@@ -21,27 +21,79 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/placement/algo"
 	"github.com/m3db/m3/src/cluster/placement/selector"
 	"github.com/m3db/m3/src/cluster/shard"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
+var (
+	errStagedRolloutDisabled    = errors.New("placement service is not configured for staged rollouts")
+	errNoPendingPlacement       = errors.New("no pending placement change")
+	errInvalidPendingPlacement  = errors.New("invalid pending placement change")
+	errPendingPlacementOutdated = errors.New("placement has changed since the pending placement change was staged")
+)
+
 type placementService struct {
 	placement.Storage
 
+	// pendingStore holds the pending placement change under pendingKey when
+	// staged rollouts are enabled.
+	pendingStore kv.Store
+	pendingKey   string
+
 	opts     placement.Options
 	algo     placement.Algorithm
 	selector placement.InstanceSelector
+	scope    tally.Scope
+	metrics  serviceMetrics
 	logger   *zap.Logger
 }
 
+type serviceMetrics struct {
+	pendingStaged  tally.Counter
+	pendingApplied tally.Counter
+}
+
+func newServiceMetrics(scope tally.Scope) serviceMetrics {
+	return serviceMetrics{
+		pendingStaged:  scope.Counter("pending-staged"),
+		pendingApplied: scope.Counter("pending-applied"),
+	}
+}
+
 // NewPlacementService returns an instance of placement service.
 func NewPlacementService(s placement.Storage, opts placement.Options) placement.Service {
+	return newPlacementService(s, nil, "", opts)
+}
+
+// NewStagedPlacementService returns an instance of placement service that
+// stages the placement changes adding, removing or replacing instances in
+// the given key of the pending store, they take effect once applied.
+func NewStagedPlacementService(
+	s placement.Storage,
+	pendingStore kv.Store,
+	pendingKey string,
+	opts placement.Options,
+) placement.Service {
+	return newPlacementService(s, pendingStore, pendingKey, opts)
+}
+
+func newPlacementService(
+	s placement.Storage,
+	pendingStore kv.Store,
+	pendingKey string,
+	opts placement.Options,
+) *placementService {
 	if opts == nil {
 		opts = placement.NewOptions()
 	}
@@ -51,12 +103,17 @@ func NewPlacementService(s placement.Storage, opts placement.Options) placement.
 		instanceSelector = selector.NewInstanceSelector(opts)
 	}
 
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("placement")
 	return &placementService{
-		Storage:  s,
-		opts:     opts,
-		algo:     algo.NewAlgorithm(opts),
-		selector: instanceSelector,
-		logger:   opts.InstrumentOptions().Logger(),
+		Storage:      s,
+		pendingStore: pendingStore,
+		pendingKey:   pendingKey,
+		opts:         opts,
+		algo:         algo.NewAlgorithm(opts),
+		selector:     instanceSelector,
+		scope:        scope,
+		metrics:      newServiceMetrics(scope),
+		logger:       opts.InstrumentOptions().Logger(),
 	}
 }
 
@@ -151,7 +208,7 @@ func (ps *placementService) AddInstances(
 		addingInstances[i] = addingInstance
 	}
 
-	newPlacement, err := ps.CheckAndSet(tempPlacement, curPlacement.Version())
+	newPlacement, err := ps.update("add-instances", curPlacement, tempPlacement)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -177,7 +234,7 @@ func (ps *placementService) RemoveInstances(instanceIDs []string) (placement.Pla
 		return nil, err
 	}
 
-	return ps.CheckAndSet(tempPlacement, curPlacement.Version())
+	return ps.update("remove-instances", curPlacement, tempPlacement)
 }
 
 func (ps *placementService) ReplaceInstances(
@@ -216,7 +273,7 @@ func (ps *placementService) ReplaceInstances(
 		addedInstances = append(addedInstances, addedInstance)
 	}
 
-	newPlacement, err := ps.CheckAndSet(tempPlacement, curPlacement.Version())
+	newPlacement, err := ps.update("replace-instances", curPlacement, tempPlacement)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -302,3 +359,133 @@ func (ps *placementService) MarkAllShardsAvailable() (placement.Placement, error
 
 	return ps.CheckAndSet(tempPlacement, curPlacement.Version())
 }
+
+// update persists the placement computed by an operation on the current
+// placement, or stages it as the pending placement change when staged
+// rollouts are enabled, in which case the pending placement is returned.
+func (ps *placementService) update(
+	op string,
+	curPlacement placement.Placement,
+	newPlacement placement.Placement,
+) (placement.Placement, error) {
+	shardsMoved := placement.NumShardsMoved(curPlacement, newPlacement)
+	ps.scope.Tagged(map[string]string{"operation": op}).
+		Counter("shards-moved").Inc(int64(shardsMoved))
+	ps.logger.Info("computed placement change",
+		zap.String("operation", op),
+		zap.Int("shardsMoved", shardsMoved),
+		zap.Int("numInstances", newPlacement.NumInstances()),
+		zap.Bool("staged", ps.pendingStore != nil),
+		zap.Bool("dryrun", ps.opts.Dryrun()))
+
+	if ps.pendingStore == nil {
+		return ps.CheckAndSet(newPlacement, curPlacement.Version())
+	}
+
+	// NB: the placement the change was computed from is staged along with
+	// it so that the change is only applied to the same placement.
+	snapshots, err := placement.Placements{curPlacement, newPlacement}.Proto()
+	if err != nil {
+		return nil, err
+	}
+	if ps.opts.Dryrun() {
+		ps.logger.Info("this is a dryrun, the pending placement change is not persisted")
+		return newPlacement, nil
+	}
+	if _, err := ps.pendingStore.Set(ps.pendingKey, snapshots); err != nil {
+		return nil, err
+	}
+	ps.metrics.pendingStaged.Inc(1)
+	return newPlacement, nil
+}
+
+func (ps *placementService) PendingPlacement() (placement.Placement, error) {
+	_, pending, err := ps.pendingPlacement()
+	return pending, err
+}
+
+func (ps *placementService) ApplyPendingPlacement() (placement.Placement, error) {
+	base, pending, err := ps.pendingPlacement()
+	if err != nil {
+		return nil, err
+	}
+
+	curPlacement, err := ps.Placement()
+	if err != nil {
+		return nil, err
+	}
+	curProto, err := curPlacement.Proto()
+	if err != nil {
+		return nil, err
+	}
+	if !proto.Equal(base, curProto) {
+		return nil, errPendingPlacementOutdated
+	}
+
+	newPlacement, err := ps.CheckAndSet(pending, curPlacement.Version())
+	if err != nil {
+		return nil, err
+	}
+	ps.metrics.pendingApplied.Inc(1)
+
+	if ps.opts.Dryrun() {
+		return newPlacement, nil
+	}
+	// NB: a pending change left behind is outdated once applied, so it can
+	// no longer be applied again.
+	if _, err := ps.pendingStore.Delete(ps.pendingKey); err != nil {
+		ps.logger.Warn("unable to delete applied pending placement change",
+			zap.Error(err))
+	}
+	return newPlacement, nil
+}
+
+func (ps *placementService) DeletePendingPlacement() error {
+	if ps.pendingStore == nil {
+		return errStagedRolloutDisabled
+	}
+	if ps.opts.Dryrun() {
+		ps.logger.Info("this is a dryrun, the operation is not persisted")
+		return nil
+	}
+
+	_, err := ps.pendingStore.Delete(ps.pendingKey)
+	if err == kv.ErrNotFound {
+		return errNoPendingPlacement
+	}
+	return err
+}
+
+// pendingPlacement returns the pending placement change along with the
+// placement it was computed from.
+func (ps *placementService) pendingPlacement() (
+	*placementpb.Placement,
+	placement.Placement,
+	error,
+) {
+	if ps.pendingStore == nil {
+		return nil, nil, errStagedRolloutDisabled
+	}
+
+	value, err := ps.pendingStore.Get(ps.pendingKey)
+	if err == kv.ErrNotFound {
+		return nil, nil, errNoPendingPlacement
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var snapshots placementpb.PlacementSnapshots
+	if err := value.Unmarshal(&snapshots); err != nil {
+		return nil, nil, err
+	}
+	if len(snapshots.Snapshots) != 2 {
+		return nil, nil, errInvalidPendingPlacement
+	}
+
+	pending, err := placement.NewPlacementFromProto(snapshots.Snapshots[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshots.Snapshots[0], pending, nil
+}
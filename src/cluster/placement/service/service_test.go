@@ -28,9 +28,11 @@ import (
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/placement/storage"
 	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/x/instrument"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 func TestGoodWorkflow(t *testing.T) {
@@ -927,6 +929,84 @@ func TestValidateFnBeforeUpdate(t *testing.T) {
 	assert.Equal(t, expectErr, err)
 }
 
+func TestStagedRollout(t *testing.T) {
+	var (
+		store = mem.NewStore()
+		scope = tally.NewTestScope("", nil)
+		opts  = placement.NewOptions().SetValidZone("z1").SetInstrumentOptions(
+			instrument.NewOptions().SetMetricsScope(scope))
+		ps = NewStagedPlacementService(
+			storage.NewPlacementStorage(store, "placement", opts),
+			store, "placement/pending", opts)
+	)
+
+	_, err := ps.PendingPlacement()
+	require.Equal(t, errNoPendingPlacement, err)
+	_, err = ps.ApplyPendingPlacement()
+	require.Equal(t, errNoPendingPlacement, err)
+
+	i1 := placement.NewEmptyInstance("i1", "r1", "z1", "endpoint", 1)
+	i2 := placement.NewEmptyInstance("i2", "r2", "z1", "endpoint", 1)
+	i3 := placement.NewEmptyInstance("i3", "r3", "z1", "endpoint", 1)
+	_, err = ps.BuildInitialPlacement([]placement.Instance{i1, i2}, 12, 1)
+	require.NoError(t, err)
+	markAllInstancesAvailable(t, ps)
+
+	// Adding an instance stages the change without applying it.
+	staged, _, err := ps.AddInstances([]placement.Instance{i3})
+	require.NoError(t, err)
+	_, ok := staged.Instance("i3")
+	require.True(t, ok)
+
+	current, err := ps.Placement()
+	require.NoError(t, err)
+	_, ok = current.Instance("i3")
+	require.False(t, ok)
+
+	pending, err := ps.PendingPlacement()
+	require.NoError(t, err)
+	_, ok = pending.Instance("i3")
+	require.True(t, ok)
+
+	applied, err := ps.ApplyPendingPlacement()
+	require.NoError(t, err)
+	assert.Equal(t, current.Version()+1, applied.Version())
+	current, err = ps.Placement()
+	require.NoError(t, err)
+	_, ok = current.Instance("i3")
+	require.True(t, ok)
+
+	_, err = ps.PendingPlacement()
+	require.Equal(t, errNoPendingPlacement, err)
+
+	key := "placement.shards-moved+operation=add-instances"
+	counter, ok := scope.Snapshot().Counters()[key]
+	require.True(t, ok)
+	assert.Equal(t, int64(4), counter.Value())
+
+	// A pending change is not applied once the placement has changed.
+	markAllInstancesAvailable(t, ps)
+	_, err = ps.RemoveInstances([]string{"i3"})
+	require.NoError(t, err)
+	_, err = ps.AddReplica()
+	require.NoError(t, err)
+	_, err = ps.ApplyPendingPlacement()
+	require.Equal(t, errPendingPlacementOutdated, err)
+
+	require.NoError(t, ps.DeletePendingPlacement())
+	require.Equal(t, errNoPendingPlacement, ps.DeletePendingPlacement())
+}
+
+func TestStagedRolloutDisabled(t *testing.T) {
+	ps := NewPlacementService(newMockStorage(), placement.NewOptions())
+
+	_, err := ps.PendingPlacement()
+	require.Equal(t, errStagedRolloutDisabled, err)
+	_, err = ps.ApplyPendingPlacement()
+	require.Equal(t, errStagedRolloutDisabled, err)
+	require.Equal(t, errStagedRolloutDisabled, ps.DeletePendingPlacement())
+}
+
 func newMockStorage() placement.Storage {
 	return storage.NewPlacementStorage(mem.NewStore(), "", nil)
 }
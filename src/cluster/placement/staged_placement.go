@@ -25,6 +25,7 @@ import (
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/x/clock"
@@ -40,6 +41,7 @@ type activeStagedPlacement struct {
 
 	placements            Placements
 	nowFn                 clock.NowFn
+	expiryDelay           time.Duration
 	onPlacementsAddedFn   OnPlacementsAddedFn
 	onPlacementsRemovedFn OnPlacementsRemovedFn
 
@@ -58,6 +60,7 @@ func newActiveStagedPlacement(
 	p := &activeStagedPlacement{
 		placements:            placements,
 		nowFn:                 opts.ClockOptions().NowFn(),
+		expiryDelay:           opts.ExpiryDelay(),
 		onPlacementsAddedFn:   opts.OnPlacementsAddedFn(),
 		onPlacementsRemovedFn: opts.OnPlacementsRemovedFn(),
 	}
@@ -80,6 +83,18 @@ func (p *activeStagedPlacement) ActivePlacement() (Placement, DoneFn, error) {
 	return placement, p.doneFn, nil
 }
 
+func (p *activeStagedPlacement) ActivePlacementsInRange(
+	startNanos, endNanos int64,
+) (Placements, DoneFn, error) {
+	p.RLock()
+	placements, err := p.activePlacementsInRangeWithLock(startNanos, endNanos)
+	if err != nil {
+		p.RUnlock()
+		return nil, nil, err
+	}
+	return placements, p.doneFn, nil
+}
+
 func (p *activeStagedPlacement) Close() error {
 	p.Lock()
 	defer p.Unlock()
@@ -104,12 +119,42 @@ func (p *activeStagedPlacement) activePlacementWithLock(timeNanos int64) (Placem
 	if idx < 0 {
 		return nil, errNoApplicablePlacement
 	}
-	placement := p.placements[idx]
-	// If the placement that's in effect is not the first placment, expire the stale ones.
-	if idx > 0 && atomic.CompareAndSwapInt32(&p.expiring, 0, 1) {
+	p.maybeExpireWithLock(idx, timeNanos)
+	return p.placements[idx], nil
+}
+
+func (p *activeStagedPlacement) activePlacementsInRangeWithLock(
+	startNanos, endNanos int64,
+) (Placements, error) {
+	if p.closed {
+		return nil, errActiveStagedPlacementClosed
+	}
+	endIdx := p.placements.ActiveIndex(endNanos)
+	if endIdx < 0 {
+		return nil, errNoApplicablePlacement
+	}
+	startIdx := p.placements.ActiveIndex(startNanos)
+	if startIdx < 0 {
+		startIdx = 0
+	}
+	nowNanos := p.nowFn().UnixNano()
+	p.maybeExpireWithLock(p.placements.ActiveIndex(nowNanos), nowNanos)
+	return p.placements[startIdx : endIdx+1], nil
+}
+
+// maybeExpireWithLock expires the stale placements in the background if the
+// placement that's in effect is not the first placement, and the placements
+// before it have been superseded for longer than the expiry delay.
+func (p *activeStagedPlacement) maybeExpireWithLock(activeIdx int, timeNanos int64) {
+	if activeIdx <= 0 {
+		return
+	}
+	if p.expiryDelay > 0 && p.placements.ActiveIndex(timeNanos-int64(p.expiryDelay)) <= 0 {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&p.expiring, 0, 1) {
 		go p.expire()
 	}
-	return placement, nil
 }
 
 func (p *activeStagedPlacement) expire() {
@@ -125,7 +170,7 @@ func (p *activeStagedPlacement) expire() {
 	if p.closed {
 		return
 	}
-	idx := p.placements.ActiveIndex(p.nowFn().UnixNano())
+	idx := p.placements.ActiveIndex(p.nowFn().UnixNano() - int64(p.expiryDelay))
 	if idx <= 0 {
 		return
 	}
@@ -21,6 +21,8 @@
 package placement
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/x/clock"
 )
 
@@ -28,6 +30,7 @@ type activeStagedPlacementOptions struct {
 	clockOpts             clock.Options
 	onPlacementsAddedFn   OnPlacementsAddedFn
 	onPlacementsRemovedFn OnPlacementsRemovedFn
+	expiryDelay           time.Duration
 }
 
 // NewActiveStagedPlacementOptions create a new set of active staged placement options.
@@ -66,3 +69,13 @@ func (o *activeStagedPlacementOptions) SetOnPlacementsRemovedFn(value OnPlacemen
 func (o *activeStagedPlacementOptions) OnPlacementsRemovedFn() OnPlacementsRemovedFn {
 	return o.onPlacementsRemovedFn
 }
+
+func (o *activeStagedPlacementOptions) SetExpiryDelay(value time.Duration) ActiveStagedPlacementOptions {
+	opts := *o
+	opts.expiryDelay = value
+	return &opts
+}
+
+func (o *activeStagedPlacementOptions) ExpiryDelay() time.Duration {
+	return o.expiryDelay
+}
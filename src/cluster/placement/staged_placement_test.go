@@ -21,6 +21,7 @@
 package placement
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -355,6 +356,57 @@ func TestActiveStagedPlacementActivePlacementFoundWithExpiry(t *testing.T) {
 	require.Equal(t, testActivePlacements[0].Instances(), removedInstances[0])
 }
 
+func TestActiveStagedPlacementActivePlacementsInRange(t *testing.T) {
+	p := &activeStagedPlacement{
+		placements: append([]Placement{}, testActivePlacements...),
+		nowFn:      func() time.Time { return time.Unix(0, 0) },
+	}
+	p.doneFn = p.onPlacementDone
+
+	_, _, err := p.ActivePlacementsInRange(0, 100)
+	require.Equal(t, errNoApplicablePlacement, err)
+
+	placements, doneFn, err := p.ActivePlacementsInRange(0, 99999)
+	require.NoError(t, err)
+	require.Equal(t, Placements(testActivePlacements), placements)
+	doneFn()
+
+	placements, doneFn, err = p.ActivePlacementsInRange(12345, 12345)
+	require.NoError(t, err)
+	require.Equal(t, Placements(testActivePlacements[:1]), placements)
+	doneFn()
+
+	placements, doneFn, err = p.ActivePlacementsInRange(99999, 99999)
+	require.NoError(t, err)
+	require.Equal(t, Placements(testActivePlacements[1:]), placements)
+	doneFn()
+}
+
+func TestActiveStagedPlacementActivePlacementWithExpiryDelay(t *testing.T) {
+	var removedInstances [][]Instance
+	p := &activeStagedPlacement{
+		placements:  append([]Placement{}, testActivePlacements...),
+		nowFn:       func() time.Time { return time.Unix(0, 99999) },
+		expiryDelay: time.Hour,
+		onPlacementsRemovedFn: func(placements []Placement) {
+			for _, placement := range placements {
+				removedInstances = append(removedInstances, placement.Instances())
+			}
+		},
+	}
+	p.doneFn = p.onPlacementDone
+	placement, doneFn, err := p.ActivePlacement()
+	require.NoError(t, err)
+	require.Equal(t, testActivePlacements[1], placement)
+	doneFn()
+
+	// The superseded placement is retained until the expiry delay has elapsed.
+	require.Equal(t, int32(0), atomic.LoadInt32(&p.expiring))
+	p.expire()
+	require.Nil(t, removedInstances)
+	require.Equal(t, 2, len(p.placements))
+}
+
 func TestActiveStagedPlacementCloseAlreadyClosed(t *testing.T) {
 	p := &activeStagedPlacement{
 		placements: append([]Placement{}, testActivePlacements...),
@@ -168,4 +168,8 @@ func (mp *mockPlacement) ActivePlacement() (Placement, DoneFn, error) {
 	return nil, func() {}, nil
 }
 
+func (mp *mockPlacement) ActivePlacementsInRange(int64, int64) (Placements, DoneFn, error) {
+	return nil, func() {}, nil
+}
+
 func (mp *mockPlacement) Close() error { return mp.closeFn() }
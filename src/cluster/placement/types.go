@@ -271,6 +271,12 @@ type ActiveStagedPlacement interface {
 	// function when the caller is done using the placement, and any errors encountered.
 	ActivePlacement() (Placement, DoneFn, error)
 
+	// ActivePlacementsInRange returns the placements that are active at any point
+	// in time between startNanos and endNanos (inclusive) ordered by their cutover
+	// times, the callback function when the caller is done using the placements,
+	// and any errors encountered.
+	ActivePlacementsInRange(startNanos, endNanos int64) (Placements, DoneFn, error)
+
 	// Close closes the active staged placement.
 	Close() error
 }
@@ -300,6 +306,14 @@ type ActiveStagedPlacementOptions interface {
 
 	// OnPlacementsRemovedFn returns the callback function for removing placement.
 	OnPlacementsRemovedFn() OnPlacementsRemovedFn
+
+	// SetExpiryDelay sets the delay after a newer placement cuts over before the
+	// placements it supersedes are expired.
+	SetExpiryDelay(value time.Duration) ActiveStagedPlacementOptions
+
+	// ExpiryDelay returns the delay after a newer placement cuts over before the
+	// placements it supersedes are expired.
+	ExpiryDelay() time.Duration
 }
 
 // StagedPlacement describes a series of placements applied in staged fashion.
@@ -389,6 +403,14 @@ type Options interface {
 	// SetDryrun sets whether the Dryrun value.
 	SetDryrun(d bool) Options
 
+	// StagedRollout returns whether placement changes that add, remove or
+	// replace instances are staged as a pending placement change, which
+	// only takes effect once applied, rather than being applied directly.
+	StagedRollout() bool
+
+	// SetStagedRollout sets StagedRollout.
+	SetStagedRollout(v bool) Options
+
 	// IsMirrored returns whether the shard distribution should be mirrored
 	// to support master/slave model.
 	IsMirrored() bool
@@ -545,6 +567,17 @@ type Service interface {
 
 	// MarkAllShardsAvailable marks shard states as available where applicable.
 	MarkAllShardsAvailable() (Placement, error)
+
+	// PendingPlacement returns the pending placement change staged by adding,
+	// removing or replacing instances when staged rollouts are enabled.
+	PendingPlacement() (Placement, error)
+
+	// ApplyPendingPlacement applies the pending placement change, as long as
+	// the placement has not changed since the pending change was staged.
+	ApplyPendingPlacement() (Placement, error)
+
+	// DeletePendingPlacement discards the pending placement change.
+	DeletePendingPlacement() error
 }
 
 // Algorithm places shards on instances.
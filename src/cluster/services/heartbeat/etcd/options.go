@@ -65,6 +65,12 @@ type Options interface {
 	// SetWatchChanResetInterval sets the WatchChanResetInterval
 	SetWatchChanResetInterval(t time.Duration) Options
 
+	// WatchChanResetJitter is the maximum random delay added to the
+	// WatchChanResetInterval so that many watches do not reconnect at once
+	WatchChanResetJitter() time.Duration
+	// SetWatchChanResetJitter sets the WatchChanResetJitter
+	SetWatchChanResetJitter(t time.Duration) Options
+
 	// WatchChanInitTimeout is the timeout for a watchChan initialization
 	WatchChanInitTimeout() time.Duration
 	// SetWatchChanInitTimeout sets the WatchChanInitTimeout
@@ -86,6 +92,7 @@ type options struct {
 	ropts                  retry.Options
 	watchChanCheckInterval time.Duration
 	watchChanResetInterval time.Duration
+	watchChanResetJitter   time.Duration
 	watchChanInitTimeout   time.Duration
 	sid                    services.ServiceID
 }
@@ -162,6 +169,15 @@ func (o options) SetWatchChanResetInterval(t time.Duration) Options {
 	return o
 }
 
+func (o options) WatchChanResetJitter() time.Duration {
+	return o.watchChanResetJitter
+}
+
+func (o options) SetWatchChanResetJitter(t time.Duration) Options {
+	o.watchChanResetJitter = t
+	return o
+}
+
 func (o options) WatchChanInitTimeout() time.Duration {
 	return o.watchChanInitTimeout
 }
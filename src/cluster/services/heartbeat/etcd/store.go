@@ -96,6 +96,7 @@ func NewStore(c *clientv3.Client, opts Options) (services.HeartbeatService, erro
 		SetWatchChanCheckInterval(opts.WatchChanCheckInterval()).
 		SetWatchChanInitTimeout(opts.WatchChanInitTimeout()).
 		SetWatchChanResetInterval(opts.WatchChanResetInterval()).
+		SetWatchChanResetJitter(opts.WatchChanResetJitter()).
 		SetInstrumentsOptions(opts.InstrumentsOptions())
 
 	wm, err := watchmanager.NewWatchManager(wOpts)
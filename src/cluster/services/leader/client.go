@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/cluster/services/leader/campaign"
 	"github.com/m3db/m3/src/cluster/services/leader/election"
 
+	"github.com/uber-go/tally"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/clientv3/concurrency"
 	"golang.org/x/net/context"
@@ -53,11 +54,36 @@ var (
 // NB(mschalle): when an etcd leader failover occurs, all current leases have
 // their TTLs refreshed: https://github.com/coreos/etcd/issues/2660
 
+type clientMetrics struct {
+	campaigns      tally.Counter
+	campaignErrors tally.Counter
+	elected        tally.Counter
+	sessionExpired tally.Counter
+	resigned       tally.Counter
+	resignErrors   tally.Counter
+}
+
+func newClientMetrics(scope tally.Scope, electionID string) clientMetrics {
+	if electionID == "" {
+		electionID = defaultElectionID
+	}
+	scope = scope.Tagged(map[string]string{"election_id": electionID})
+	return clientMetrics{
+		campaigns:      scope.Counter("campaigns"),
+		campaignErrors: scope.Counter("campaign-errors"),
+		elected:        scope.Counter("elected"),
+		sessionExpired: scope.Counter("session-expired"),
+		resigned:       scope.Counter("resigned"),
+		resignErrors:   scope.Counter("resign-errors"),
+	}
+}
+
 type client struct {
 	sync.RWMutex
 
 	client           *election.Client
 	opts             services.ElectionOptions
+	metrics          clientMetrics
 	campaignCancelFn context.CancelFunc
 	observeCancelFn  context.CancelFunc
 	observeCtx       context.Context
@@ -86,6 +112,7 @@ func newClient(cli *clientv3.Client, opts Options, electionID string) (*client,
 	return &client{
 		client:          ec,
 		opts:            opts.ElectionOpts(),
+		metrics:         newClientMetrics(opts.InstrumentOptions().MetricsScope(), electionID),
 		resignCh:        make(chan struct{}),
 		observeCtx:      ctx,
 		observeCancelFn: cancel,
@@ -106,6 +133,8 @@ func (c *client) campaign(opts services.CampaignOptions) (<-chan campaign.Status
 	c.campaignCancelFn = cancel
 	c.Unlock()
 
+	c.metrics.campaigns.Inc(1)
+
 	// buffer 1 to not block initial follower update
 	sc := make(chan campaign.Status, 1)
 
@@ -122,13 +151,16 @@ func (c *client) campaign(opts services.CampaignOptions) (<-chan campaign.Status
 		// that's closed if our session dies.
 		ch, err := c.client.Campaign(ctx, opts.LeaderValue())
 		if err != nil {
+			c.metrics.campaignErrors.Inc(1)
 			sc <- campaign.NewErrorStatus(err)
 			return
 		}
 
+		c.metrics.elected.Inc(1)
 		sc <- campaign.NewStatus(campaign.Leader)
 		select {
 		case <-ch:
+			c.metrics.sessionExpired.Inc(1)
 			sc <- campaign.NewErrorStatus(election.ErrSessionExpired)
 		case <-c.resignCh:
 			sc <- campaign.NewStatus(campaign.Follower)
@@ -154,9 +186,12 @@ func (c *client) resign() error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.opts.ResignTimeout())
 	defer cancel()
 	if err := c.client.Resign(ctx); err != nil {
+		c.metrics.resignErrors.Inc(1)
 		return err
 	}
 
+	c.metrics.resigned.Inc(1)
+
 	// if successfully resigned and there was a campaign in Leader state cancel
 	// it
 	select {
@@ -29,7 +29,9 @@ import (
 	"github.com/m3db/m3/src/cluster/services"
 	"github.com/m3db/m3/src/cluster/services/leader/campaign"
 	"github.com/m3db/m3/src/cluster/services/leader/election"
+	"github.com/m3db/m3/src/x/instrument"
 
+	"github.com/uber-go/tally"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/integration"
 	"github.com/stretchr/testify/assert"
@@ -170,6 +172,31 @@ func TestCampaign(t *testing.T) {
 	assert.NoError(t, waitForStates(sc, false, followerS))
 }
 
+func TestCampaignMetrics(t *testing.T) {
+	tc := newTestCluster(t)
+	defer tc.close()
+
+	scope := tally.NewTestScope("", nil)
+	opts := tc.options().SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	svc, err := newClient(tc.etcdClient(), opts, "")
+	require.NoError(t, err)
+
+	sc, err := svc.campaign(tc.opts("foo"))
+	require.NoError(t, err)
+	require.NoError(t, waitForStates(sc, true, followerS, leaderS))
+
+	require.NoError(t, svc.resign())
+	require.NoError(t, waitForStates(sc, false, followerS))
+
+	counters := scope.Snapshot().Counters()
+	for _, name := range []string{"campaigns", "elected", "resigned"} {
+		c, ok := counters[name+"+election_id=default"]
+		require.True(t, ok, name)
+		assert.Equal(t, int64(1), c.Value(), name)
+	}
+	assert.Equal(t, int64(0), counters["session-expired+election_id=default"].Value())
+}
+
 func TestCampaign_Override(t *testing.T) {
 	tc := newTestCluster(t)
 	defer tc.close()
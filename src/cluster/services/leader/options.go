@@ -24,6 +24,7 @@ import (
 	"errors"
 
 	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/x/instrument"
 )
 
 var (
@@ -40,19 +41,26 @@ type Options interface {
 	ElectionOpts() services.ElectionOptions
 	SetElectionOpts(e services.ElectionOptions) Options
 
+	// InstrumentOptions returns the instrument options used to report election
+	// metrics.
+	InstrumentOptions() instrument.Options
+	SetInstrumentOptions(iopts instrument.Options) Options
+
 	Validate() error
 }
 
 // NewOptions returns an instance of leader options.
 func NewOptions() Options {
 	return options{
-		eo: services.NewElectionOptions(),
+		eo:    services.NewElectionOptions(),
+		iopts: instrument.NewOptions(),
 	}
 }
 
 type options struct {
-	sid services.ServiceID
-	eo  services.ElectionOptions
+	sid   services.ServiceID
+	eo    services.ElectionOptions
+	iopts instrument.Options
 }
 
 func (o options) ServiceID() services.ServiceID {
@@ -73,6 +81,15 @@ func (o options) SetElectionOpts(eo services.ElectionOptions) Options {
 	return o
 }
 
+func (o options) InstrumentOptions() instrument.Options {
+	return o.iopts
+}
+
+func (o options) SetInstrumentOptions(iopts instrument.Options) Options {
+	o.iopts = iopts
+	return o
+}
+
 func (o options) Validate() error {
 	if o.sid == nil {
 		return errMissingSid
@@ -41,6 +41,10 @@ import (
 
 const (
 	defaultGaugeInterval = 10 * time.Second
+
+	// pendingPlacementKeySuffix is appended to the placement key to form
+	// the key of the pending placement change of staged rollouts.
+	pendingPlacementKeySuffix = "/pending"
 )
 
 var (
@@ -152,10 +156,13 @@ func (c *client) PlacementService(sid ServiceID, opts placement.Options) (placem
 		return nil, err
 	}
 
-	return ps.NewPlacementService(
-		storage.NewPlacementStorage(store, c.placementKeyFn(sid), opts),
-		opts,
-	), nil
+	key := c.placementKeyFn(sid)
+	placementStorage := storage.NewPlacementStorage(store, key, opts)
+	if opts != nil && opts.StagedRollout() {
+		return ps.NewStagedPlacementService(placementStorage, store,
+			key+pendingPlacementKeySuffix, opts), nil
+	}
+	return ps.NewPlacementService(placementStorage, opts), nil
 }
 
 func (c *client) Advertise(ad Advertisement) error {
@@ -191,16 +198,22 @@ func (c *client) Advertise(ad Advertisement) error {
 
 	go func() {
 		sid := ad.ServiceID()
-		errCounter := c.serviceTaggedScope(sid).Counter("heartbeat.error")
+		scope := c.serviceTaggedScope(sid)
+		errCounter := scope.Counter("heartbeat.error")
+		unhealthyCounter := scope.Counter("heartbeat.unhealthy")
 
 		tickFn := func() {
-			if isHealthy(ad) {
-				if err := hb.Heartbeat(pi, m.LivenessInterval()); err != nil {
-					c.logger.Error("could not heartbeat service",
-						zap.String("service", sid.String()),
-						zap.Error(err))
-					errCounter.Inc(1)
-				}
+			if !isHealthy(ad) {
+				// Skip heartbeating so the instance ages out of the heartbeat
+				// store and is filtered from healthy watches.
+				unhealthyCounter.Inc(1)
+				return
+			}
+			if err := hb.Heartbeat(pi, m.LivenessInterval()); err != nil {
+				c.logger.Error("could not heartbeat service",
+					zap.String("service", sid.String()),
+					zap.Error(err))
+				errCounter.Inc(1)
 			}
 		}
 
@@ -342,8 +355,10 @@ func (c *client) Watch(sid ServiceID, opts QueryOptions) (Watch, error) {
 			placementWatch.Close()
 			return nil, err
 		}
-		watchable.update(filterInstancesWithWatch(initService, heartbeatWatch))
-		go c.watchPlacementAndHeartbeat(watchable, placementWatch, heartbeatWatch, initValue, sid, initService, sdm.serviceUnmalshalErr)
+		healthyService := filterInstancesWithWatch(initService, heartbeatWatch)
+		sdm.healthyInstances.Update(float64(len(healthyService.Instances())))
+		watchable.update(healthyService)
+		go c.watchPlacementAndHeartbeat(watchable, placementWatch, heartbeatWatch, initValue, sid, initService, sdm)
 	} else {
 		watchable.update(initService)
 		go c.watchPlacement(watchable, placementWatch, initValue, sid, sdm.serviceUnmalshalErr)
@@ -476,12 +491,12 @@ func (c *client) watchPlacementAndHeartbeat(
 	initValue kv.Value,
 	sid ServiceID,
 	service Service,
-	errCounter tally.Counter,
+	sdm serviceDiscoveryMetrics,
 ) {
 	for {
 		select {
 		case <-vw.C():
-			newService := c.serviceFromUpdate(vw.Get(), initValue, sid, errCounter)
+			newService := c.serviceFromUpdate(vw.Get(), initValue, sid, sdm.serviceUnmalshalErr)
 			if newService == nil {
 				continue
 			}
@@ -490,7 +505,9 @@ func (c *client) watchPlacementAndHeartbeat(
 		case <-heartbeatWatch.C():
 			c.logger.Info("received heartbeat update")
 		}
-		w.update(filterInstancesWithWatch(service, heartbeatWatch))
+		healthyService := filterInstancesWithWatch(service, heartbeatWatch)
+		sdm.healthyInstances.Update(float64(len(healthyService.Instances())))
+		w.update(healthyService)
 	}
 }
 
@@ -643,12 +660,14 @@ func newServiceDiscoveryMetrics(m tally.Scope) serviceDiscoveryMetrics {
 	return serviceDiscoveryMetrics{
 		versionGauge:        m.Gauge("placement.version"),
 		serviceUnmalshalErr: m.Counter("placement.unmarshal.error"),
+		healthyInstances:    m.Gauge("instances.healthy"),
 	}
 }
 
 type serviceDiscoveryMetrics struct {
 	versionGauge        tally.Gauge
 	serviceUnmalshalErr tally.Counter
+	healthyInstances    tally.Gauge
 }
 
 // NewService creates a new Service.
@@ -40,6 +40,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 func TestConvertBetweenProtoAndService(t *testing.T) {
@@ -771,6 +772,52 @@ func TestWatchNotIncludeUnhealthy(t *testing.T) {
 	require.Equal(t, true, s.Sharding().IsSharded())
 }
 
+func TestWatchNotIncludeUnhealthyReportsHealthyInstances(t *testing.T) {
+	opts, m := testSetup()
+	scope := tally.NewTestScope("", nil)
+	opts = opts.
+		SetInitTimeout(defaultInitTimeout).
+		SetInstrumentsOptions(instrument.NewOptions().SetMetricsScope(scope))
+
+	sd, err := NewServices(opts)
+	require.NoError(t, err)
+
+	sid := NewServiceID().SetName("m3db").SetZone("zone1")
+	p := placement.NewPlacement().
+		SetInstances([]placement.Instance{
+			placement.NewInstance().SetID("i1").SetEndpoint("e1"),
+			placement.NewInstance().SetID("i2").SetEndpoint("e2"),
+		}).
+		SetReplicaFactor(2)
+
+	ps, err := sd.PlacementService(sid, placement.NewOptions())
+	require.NoError(t, err)
+	_, err = ps.Set(p)
+	require.NoError(t, err)
+
+	w, err := sd.Watch(sid, NewQueryOptions())
+	require.NoError(t, err)
+	<-w.C()
+
+	mockHB, ok := m.getMockStore(sid)
+	require.True(t, ok)
+	hbWatchable, ok := mockHB.getWatchable(serviceKey(sid))
+	require.True(t, ok)
+
+	hbWatchable.Update([]string{"i2"})
+	<-w.C()
+	require.Equal(t, 1, len(w.Get().(Service).Instances()))
+
+	var found bool
+	for _, g := range scope.Snapshot().Gauges() {
+		if g.Name() == "instances.healthy" && g.Tags()["sd_service"] == "m3db" {
+			found = true
+			require.Equal(t, float64(1), g.Value())
+		}
+	}
+	require.True(t, found)
+}
+
 func TestMultipleWatches(t *testing.T) {
 	opts, _ := testSetup()
 
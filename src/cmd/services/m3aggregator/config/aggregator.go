@@ -48,6 +48,7 @@ import (
 	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/config/hostid"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/retry"
 	"github.com/m3db/m3/src/x/sync"
@@ -148,9 +149,16 @@ type AggregatorConfiguration struct {
 	// EntryCheckBatchPercent determines the percentage of entries checked in a batch.
 	EntryCheckBatchPercent float64 `yaml:"entryCheckBatchPercent" validate:"min=0.0,max=1.0"`
 
+	// EntryMapShards determines the number of shards the entries of each metric map are split into.
+	EntryMapShards int `yaml:"entryMapShards" validate:"min=0"`
+
 	// MaxTimerBatchSizePerWrite determines the maximum timer batch size for each batched write.
 	MaxTimerBatchSizePerWrite int `yaml:"maxTimerBatchSizePerWrite" validate:"min=0"`
 
+	// GaugeDedupWindow determines the window within which identical gauge writes are deduplicated.
+	// Only gauges aggregated solely with Last, Min or Max are deduplicated.
+	GaugeDedupWindow time.Duration `yaml:"gaugeDedupWindow"`
+
 	// Default storage policies.
 	DefaultStoragePolicies []policy.StoragePolicy `yaml:"defaultStoragePolicies"`
 
@@ -160,6 +168,20 @@ type AggregatorConfiguration struct {
 	// Whether to discard NaN aggregated values.
 	DiscardNaNAggregatedValues *bool `yaml:"discardNaNAggregatedValues"`
 
+	// Whether to flush NaN staleness markers for series that stop reporting.
+	EmitStalenessMarkers bool `yaml:"emitStalenessMarkers"`
+
+	// Template for the ids of aggregated metrics, e.g. "dc1.{prefix}{id}{suffix}".
+	IDTemplate string `yaml:"idTemplate"`
+
+	// Heartbeat series written by each metric list on every flush.
+	Heartbeat *heartbeatConfiguration `yaml:"heartbeat"`
+
+	// Interning of the rollup ids of aggregated metrics, which are shared by
+	// every series rolled up into them. If not provided, interning is
+	// disabled.
+	RollupIDInterning *intern.Configuration `yaml:"rollupIDInterning"`
+
 	// Pool of counter elements.
 	CounterElemPool pool.ObjectPoolConfiguration `yaml:"counterElemPool"`
 
@@ -173,6 +195,16 @@ type AggregatorConfiguration struct {
 	EntryPool pool.ObjectPoolConfiguration `yaml:"entryPool"`
 }
 
+// heartbeatConfiguration configures the heartbeat series each metric list
+// writes alongside its flushed metrics.
+type heartbeatConfiguration struct {
+	// Name of the heartbeat series.
+	Name string `yaml:"name" validate:"nonzero"`
+
+	// Retention of the heartbeat series.
+	Retention time.Duration `yaml:"retention"`
+}
+
 // InstanceIDType is the instance ID type that defines how the
 // instance ID is constructed, which is then used to lookup the
 // aggregator instance in the placement.
@@ -409,9 +441,15 @@ func (c *AggregatorConfiguration) NewAggregatorOptions(
 	if c.EntryCheckBatchPercent != 0.0 {
 		opts = opts.SetEntryCheckBatchPercent(c.EntryCheckBatchPercent)
 	}
+	if c.EntryMapShards != 0 {
+		opts = opts.SetEntryMapShards(c.EntryMapShards)
+	}
 	if c.MaxTimerBatchSizePerWrite != 0 {
 		opts = opts.SetMaxTimerBatchSizePerWrite(c.MaxTimerBatchSizePerWrite)
 	}
+	if c.GaugeDedupWindow != 0 {
+		opts = opts.SetGaugeDedupWindow(c.GaugeDedupWindow)
+	}
 
 	// Set default storage policies.
 	storagePolicies := make([]policy.StoragePolicy, len(c.DefaultStoragePolicies))
@@ -427,6 +465,30 @@ func (c *AggregatorConfiguration) NewAggregatorOptions(
 	if c.DiscardNaNAggregatedValues != nil {
 		opts = opts.SetDiscardNaNAggregatedValues(*c.DiscardNaNAggregatedValues)
 	}
+	if c.EmitStalenessMarkers {
+		opts = opts.SetEmitStalenessMarkers(true)
+	}
+	if c.IDTemplate != "" {
+		idTemplate, err := aggregator.NewIDTemplate(c.IDTemplate)
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.SetIDTemplate(idTemplate)
+	}
+	if c.Heartbeat != nil {
+		opts = opts.SetHeartbeatName([]byte(c.Heartbeat.Name))
+		if c.Heartbeat.Retention != 0 {
+			opts = opts.SetHeartbeatRetention(c.Heartbeat.Retention)
+		}
+	}
+	if c.RollupIDInterning != nil {
+		iOpts = instrumentOpts.SetMetricsScope(scope.SubScope("rollup-id"))
+		rollupIDInterner, err := c.RollupIDInterning.NewCache(iOpts)
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.SetRollupIDInterner(rollupIDInterner)
+	}
 
 	// Set counter elem pool.
 	iOpts = instrumentOpts.SetMetricsScope(scope.SubScope("counter-elem-pool"))
@@ -21,6 +21,9 @@
 package config
 
 import (
+	"github.com/m3db/m3/src/aggregator/server/audit"
+	"github.com/m3db/m3/src/aggregator/verify"
+	"github.com/m3db/m3/src/aggregator/writetrace"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/log"
 )
@@ -45,6 +48,10 @@ type Configuration struct {
 	// Optional.
 	HTTP *HTTPServerConfiguration `yaml:"http"`
 
+	// gRPC server configuration.
+	// Optional.
+	GRPC *GRPCServerConfiguration `yaml:"grpc"`
+
 	// Client configuration for key value store.
 	KVClient KVClientConfiguration `yaml:"kvClient" validate:"nonzero"`
 
@@ -53,4 +60,22 @@ type Configuration struct {
 
 	// Aggregator configuration.
 	Aggregator AggregatorConfiguration `yaml:"aggregator"`
+
+	// Ingest sampling configuration, samples are served by the HTTP server.
+	// Optional.
+	IngestSampling *audit.Configuration `yaml:"ingestSampling"`
+
+	// Shadow checksums configuration, checksums of flushed metrics per
+	// aggregation window whose source IDs are sampled are served by the HTTP
+	// server to validate a shadow cluster. Optional.
+	ShadowChecksums *verify.ShadowConfiguration `yaml:"shadowChecksums"`
+
+	// Window checksums configuration, checksums of all flushed metrics per
+	// aggregation window are served by the HTTP server so consumers can verify
+	// delivery. Optional.
+	WindowChecksums *verify.Configuration `yaml:"windowChecksums"`
+
+	// Write tracing configuration, the wall time breakdown of sampled writes
+	// is served by the HTTP server. Optional.
+	WriteTracing *writetrace.Configuration `yaml:"writeTracing"`
 }
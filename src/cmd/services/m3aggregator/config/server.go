@@ -25,14 +25,19 @@ import (
 
 	"github.com/m3db/m3/src/aggregator/server/http"
 	"github.com/m3db/m3/src/aggregator/server/m3msg"
+	"github.com/m3db/m3/src/aggregator/server/quarantine"
 	"github.com/m3db/m3/src/aggregator/server/rawtcp"
+	"github.com/m3db/m3/src/aggregator/server/rpc"
 	"github.com/m3db/m3/src/metrics/encoding/msgpack"
 	"github.com/m3db/m3/src/metrics/encoding/protobuf"
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
 	"github.com/m3db/m3/src/msg/consumer"
+	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/retry"
 	xserver "github.com/m3db/m3/src/x/server"
+	xtls "github.com/m3db/m3/src/x/tls"
 )
 
 // M3MsgServerConfiguration contains M3Msg server configuration.
@@ -48,9 +53,13 @@ type M3MsgServerConfiguration struct {
 func (c *M3MsgServerConfiguration) NewServerOptions(
 	instrumentOpts instrument.Options,
 ) (m3msg.Options, error) {
+	serverOpts, err := c.Server.NewOptions(instrumentOpts)
+	if err != nil {
+		return nil, err
+	}
 	opts := m3msg.NewOptions().
 		SetInstrumentOptions(instrumentOpts).
-		SetServerOptions(c.Server.NewOptions(instrumentOpts)).
+		SetServerOptions(serverOpts).
 		SetConsumerOptions(c.Consumer.NewOptions(instrumentOpts))
 	if err := opts.Validate(); err != nil {
 		return nil, err
@@ -83,12 +92,21 @@ type RawTCPServerConfiguration struct {
 
 	// Protobuf iterator configuration.
 	ProtobufIterator protobufUnaggregatedIteratorConfiguration `yaml:"protobufIterator"`
+
+	// TLS configuration.
+	TLS *xtls.Configuration `yaml:"tls"`
+
+	// Quarantine configuration for clients with too many decode errors.
+	Quarantine *quarantine.Configuration `yaml:"quarantine"`
+
+	// Maximum number of untimed metrics added to the aggregator in a batch.
+	UntimedBatchSize *int `yaml:"untimedBatchSize"`
 }
 
 // NewServerOptions create a new set of raw TCP server options.
 func (c *RawTCPServerConfiguration) NewServerOptions(
 	instrumentOpts instrument.Options,
-) rawtcp.Options {
+) (rawtcp.Options, error) {
 	opts := rawtcp.NewOptions().SetInstrumentOptions(instrumentOpts)
 
 	// Set server options.
@@ -101,6 +119,11 @@ func (c *RawTCPServerConfiguration) NewServerOptions(
 	if c.KeepAlivePeriod != nil {
 		serverOpts = serverOpts.SetTCPConnectionKeepAlivePeriod(*c.KeepAlivePeriod)
 	}
+	tlsConfig, err := c.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	serverOpts = serverOpts.SetTLSConfig(tlsConfig)
 	opts = opts.SetServerOptions(serverOpts)
 
 	// Set msgpack iterator options.
@@ -117,7 +140,17 @@ func (c *RawTCPServerConfiguration) NewServerOptions(
 	if c.ErrorLogLimitPerSecond != nil {
 		opts = opts.SetErrorLogLimitPerSecond(*c.ErrorLogLimitPerSecond)
 	}
-	return opts
+	if c.UntimedBatchSize != nil {
+		opts = opts.SetUntimedBatchSize(*c.UntimedBatchSize)
+	}
+	if c.Quarantine != nil {
+		q, err := c.Quarantine.NewQuarantine(clock.NewOptions(), instrumentOpts)
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.SetQuarantine(q)
+	}
+	return opts, nil
 }
 
 // msgpackUnaggregatedIteratorConfiguration contains configuration for msgpack unaggregated iterator.
@@ -172,6 +205,10 @@ type protobufUnaggregatedIteratorConfiguration struct {
 
 	// Bytes pool.
 	BytesPool pool.BucketizedPoolConfiguration `yaml:"bytesPool"`
+
+	// Pool of metrics untimed metrics are decoded into, if set untimed metric
+	// ids are retained by the aggregation elements without being copied.
+	PooledMetricPool *pool.ObjectPoolConfiguration `yaml:"pooledMetricPool"`
 }
 
 func (c *protobufUnaggregatedIteratorConfiguration) NewOptions(
@@ -194,6 +231,14 @@ func (c *protobufUnaggregatedIteratorConfiguration) NewOptions(
 	opts = opts.SetBytesPool(bytesPool)
 	bytesPool.Init()
 
+	// Set pooled metric pool.
+	if c.PooledMetricPool != nil {
+		iOpts = instrumentOpts.SetMetricsScope(scope.SubScope("pooled-metric-pool"))
+		pooledMetricPool := unaggregated.NewPooledMetricPool(c.PooledMetricPool.NewObjectPoolOptions(iOpts))
+		opts = opts.SetPooledMetricPool(pooledMetricPool)
+		pooledMetricPool.Init()
+	}
+
 	return opts
 }
 
@@ -220,3 +265,22 @@ func (c *HTTPServerConfiguration) NewServerOptions() http.Options {
 	}
 	return opts
 }
+
+// GRPCServerConfiguration contains gRPC server configuration.
+type GRPCServerConfiguration struct {
+	// gRPC server listening address.
+	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
+
+	// Whether server reflection is enabled, useful for debugging with
+	// tools such as grpcurl.
+	ReflectionEnabled bool `yaml:"reflectionEnabled"`
+}
+
+// NewServerOptions create a new set of gRPC server options.
+func (c *GRPCServerConfiguration) NewServerOptions(
+	instrumentOpts instrument.Options,
+) rpc.Options {
+	return rpc.NewOptions().
+		SetInstrumentOptions(instrumentOpts).
+		SetReflectionEnabled(c.ReflectionEnabled)
+}
@@ -27,6 +27,7 @@ import (
 	httpserver "github.com/m3db/m3/src/aggregator/server/http"
 	m3msgserver "github.com/m3db/m3/src/aggregator/server/m3msg"
 	rawtcpserver "github.com/m3db/m3/src/aggregator/server/rawtcp"
+	rpcserver "github.com/m3db/m3/src/aggregator/server/rpc"
 	"github.com/m3db/m3/src/x/instrument"
 
 	"go.uber.org/zap"
@@ -40,6 +41,8 @@ func Serve(
 	rawTCPServerOpts rawtcpserver.Options,
 	httpAddr string,
 	httpServerOpts httpserver.Options,
+	grpcAddr string,
+	grpcServerOpts rpcserver.Options,
 	aggregator aggregator.Aggregator,
 	doneCh chan struct{},
 	iOpts instrument.Options,
@@ -77,6 +80,18 @@ func Serve(
 		log.Info("http server listening", zap.String("addr", httpAddr))
 	}
 
+	if grpcAddr != "" {
+		grpcServer, err := rpcserver.NewServer(grpcAddr, aggregator, grpcServerOpts)
+		if err != nil {
+			return fmt.Errorf("could not create gRPC server: addr=%s, err=%v", grpcAddr, err)
+		}
+		if err := grpcServer.ListenAndServe(); err != nil {
+			return fmt.Errorf("could not start gRPC server at: addr=%s, err=%v", grpcAddr, err)
+		}
+		defer grpcServer.Close()
+		log.Info("gRPC server listening", zap.String("addr", grpcAddr))
+	}
+
 	// Wait for exit signal.
 	<-doneCh
 
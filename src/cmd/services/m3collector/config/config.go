@@ -21,8 +21,11 @@
 package config
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/aggregator/client"
 	etcdclient "github.com/m3db/m3/src/cluster/client/etcd"
+	"github.com/m3db/m3/src/collector/reporter"
 	"github.com/m3db/m3/src/metrics/matcher"
 	"github.com/m3db/m3/src/metrics/matcher/cache"
 	"github.com/m3db/m3/src/x/clock"
@@ -40,6 +43,11 @@ type Configuration struct {
 	ListenAddress listenaddress.Configuration     `yaml:"listenAddress" validate:"nonzero"`
 	Etcd          etcdclient.Configuration        `yaml:"etcd"`
 	Reporter      ReporterConfiguration           `yaml:"reporter"`
+
+	// SelfReporting configures reporting the collector's own handler metrics
+	// through its reporter to the aggregator, rather than through the
+	// configured metrics reporter.
+	SelfReporting *SelfReportingConfiguration `yaml:"selfReporting"`
 }
 
 // ReporterConfiguration is the collector
@@ -50,3 +58,40 @@ type ReporterConfiguration struct {
 	SortedTagIteratorPool pool.ObjectPoolConfiguration `yaml:"sortedTagIteratorPool"`
 	Clock                 clock.Configuration          `yaml:"clock"`
 }
+
+// SelfReportingConfiguration configures reporting metrics through the
+// collector's reporter.
+type SelfReportingConfiguration struct {
+	// Prefix of the metrics reported.
+	Prefix string `yaml:"prefix"`
+
+	// ReportingInterval is the interval metrics are reported at.
+	ReportingInterval time.Duration `yaml:"reportingInterval" validate:"nonzero"`
+
+	// Tags are the common tags of the metrics reported.
+	Tags map[string]string `yaml:"tags"`
+
+	// MaxBatchSize is the number of metrics buffered before they are
+	// reported without waiting for the reporting interval.
+	MaxBatchSize int `yaml:"maxBatchSize"`
+
+	// MaxBufferedMetrics is the max number of metrics buffered while they
+	// cannot be reported, beyond which further metrics are dropped.
+	MaxBufferedMetrics int `yaml:"maxBufferedMetrics"`
+}
+
+// NewStatsReporterOptions returns the options of a stats reporter reporting
+// the metrics.
+func (c SelfReportingConfiguration) NewStatsReporterOptions(
+	instrumentOpts instrument.Options,
+) reporter.StatsReporterOptions {
+	opts := reporter.NewStatsReporterOptions().
+		SetInstrumentOptions(instrumentOpts)
+	if c.MaxBatchSize > 0 {
+		opts = opts.SetMaxBatchSize(c.MaxBatchSize)
+	}
+	if c.MaxBufferedMetrics > 0 {
+		opts = opts.SetMaxBufferedMetrics(c.MaxBufferedMetrics)
+	}
+	return opts
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/recording"
+
+	"github.com/prometheus/common/model"
+	pql "github.com/prometheus/prometheus/promql/parser"
+)
+
+const (
+	defaultInterval       = time.Minute
+	defaultResendDelay    = time.Minute
+	defaultWebhookTimeout = 10 * time.Second
+)
+
+// Configuration is the configuration for alerting rules, which
+// periodically evaluate PromQL conditions against storage and send
+// notifications to a webhook for the alerts that fire.
+// NB: when a KV store is available the rules are only evaluated by the
+// coordinator holding a lease in KV, so alerting rules can be enabled on
+// every coordinator of a cluster without sending duplicate notifications.
+type Configuration struct {
+	// Interval is how often the rules are evaluated.
+	Interval time.Duration `yaml:"interval"`
+
+	// ResendDelay is how long to wait before resending a notification for
+	// an alert that is still firing.
+	ResendDelay time.Duration `yaml:"resendDelay"`
+
+	// Webhook is the endpoint notifications are sent to.
+	Webhook WebhookConfiguration `yaml:"webhook"`
+
+	// Rules are the alerting rules to evaluate.
+	Rules []RuleConfiguration `yaml:"rules"`
+}
+
+// WebhookConfiguration is the configuration of the endpoint notifications
+// are sent to, notifications use the Alertmanager alerts API format so an
+// Alertmanager's /api/v2/alerts endpoint can be used directly.
+type WebhookConfiguration struct {
+	// URL is the URL notifications are posted to.
+	URL string `yaml:"url" validate:"nonzero"`
+
+	// Headers are added to each notification request.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout of each notification request.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// RuleConfiguration is the configuration of a single alerting rule.
+type RuleConfiguration struct {
+	// Alert is the name of the alert.
+	Alert string `yaml:"alert" validate:"nonzero"`
+
+	// Expr is the PromQL condition, each result of the expression is an
+	// active alert.
+	Expr string `yaml:"expr" validate:"nonzero"`
+
+	// For is how long an alert must be active before it fires.
+	For time.Duration `yaml:"for"`
+
+	// Labels are added to, or override, the labels of each alert. Values
+	// are templates, e.g. "{{ $labels.job }}" or "{{ $value }}".
+	Labels map[string]string `yaml:"labels"`
+
+	// Annotations are added to each alert. Values are templates, the same
+	// as label values.
+	Annotations map[string]string `yaml:"annotations"`
+}
+
+// NewEngine returns a new alerting rule engine, it must be started to
+// begin evaluating rules.
+func (c Configuration) NewEngine(opts EngineOptions) (Engine, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	interval := defaultInterval
+	if c.Interval > 0 {
+		interval = c.Interval
+	}
+	resendDelay := defaultResendDelay
+	if c.ResendDelay > 0 {
+		resendDelay = c.ResendDelay
+	}
+
+	rules := make([]*rule, 0, len(c.Rules))
+	for _, ruleCfg := range c.Rules {
+		r, err := ruleCfg.newRule()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	notifier, err := c.Webhook.newNotifier()
+	if err != nil {
+		return nil, err
+	}
+
+	query := recording.NewInstantQueryFn(opts.PromQLEngine, opts.Queryable)
+	return newEngine(rules, interval, resendDelay, query, notifier, opts)
+}
+
+func (c RuleConfiguration) newRule() (*rule, error) {
+	if !model.IsValidMetricName(model.LabelValue(c.Alert)) {
+		return nil, fmt.Errorf("invalid alerting rule name: %q", c.Alert)
+	}
+	if _, err := pql.ParseExpr(c.Expr); err != nil {
+		return nil, fmt.Errorf("invalid alerting rule %s expression: %v",
+			c.Alert, err)
+	}
+
+	labels, err := newTemplates(c.Alert, c.Labels)
+	if err != nil {
+		return nil, err
+	}
+	for name := range labels {
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid alerting rule %s label name: %q",
+				c.Alert, name)
+		}
+	}
+	annotations, err := newTemplates(c.Alert, c.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule{
+		name:        c.Alert,
+		expr:        c.Expr,
+		holdFor:     c.For,
+		labels:      labels,
+		annotations: annotations,
+		active:      make(map[string]*Alert),
+	}, nil
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/util"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/recording"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/prometheus/prometheus/promql"
+	promstorage "github.com/prometheus/prometheus/storage"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// leaseKVKey is the KV key of the lease held by the coordinator that
+	// evaluates the alerting rules.
+	leaseKVKey = "m3coordinator.alerting.lease"
+	// leaseIntervals is the number of evaluation intervals the lease is
+	// held for without being renewed.
+	leaseIntervals = 3
+)
+
+var (
+	errEngineAlreadyStarted = errors.New("alerting rule engine already started")
+	errEngineNotStarted     = errors.New("alerting rule engine not started")
+)
+
+// Engine periodically evaluates alerting rules and sends notifications
+// for the alerts that fire.
+type Engine interface {
+	// Start restores the persisted alert state and starts evaluating the
+	// alerting rules.
+	Start() error
+
+	// Stop stops evaluating the alerting rules and waits for any
+	// evaluation in progress to finish.
+	Stop() error
+}
+
+// EngineOptions are the options for an alerting rule engine.
+type EngineOptions struct {
+	PromQLEngine *promql.Engine
+	Queryable    promstorage.Queryable
+	// KVStore persists the alert state, so that pending and firing alerts
+	// survive restarts, and holds the lease that elects the coordinator
+	// evaluating the rules. Alert state is only kept in memory, and rules
+	// are evaluated by every coordinator, if not set.
+	KVStore kv.Store
+	// InstanceID identifies the coordinator competing for the lease, it
+	// defaults to the hostname and process ID.
+	InstanceID        string
+	NowFn             clock.NowFn
+	InstrumentOptions instrument.Options
+}
+
+func (o EngineOptions) validate() error {
+	if o.PromQLEngine == nil {
+		return errors.New("promql engine is not set")
+	}
+	if o.Queryable == nil {
+		return errors.New("queryable is not set")
+	}
+	if o.NowFn == nil {
+		return errors.New("now fn is not set")
+	}
+	if o.InstrumentOptions == nil {
+		return errors.New("instrument options are not set")
+	}
+	return nil
+}
+
+type ruleMetrics struct {
+	evaluations      tally.Counter
+	evaluationErrors tally.Counter
+	pending          tally.Gauge
+	firing           tally.Gauge
+}
+
+func newRuleMetrics(scope tally.Scope) ruleMetrics {
+	return ruleMetrics{
+		evaluations:      scope.Counter("evaluations"),
+		evaluationErrors: scope.Counter("evaluation-errors"),
+		pending:          scope.Gauge("pending-alerts"),
+		firing:           scope.Gauge("firing-alerts"),
+	}
+}
+
+type engineMetrics struct {
+	notificationsSent  tally.Counter
+	notificationErrors tally.Counter
+	stateErrors        tally.Counter
+	leaseErrors        tally.Counter
+	leader             tally.Gauge
+}
+
+func newEngineMetrics(scope tally.Scope) engineMetrics {
+	return engineMetrics{
+		notificationsSent:  scope.Counter("notifications-sent"),
+		notificationErrors: scope.Counter("notification-errors"),
+		stateErrors:        scope.Counter("state-errors"),
+		leaseErrors:        scope.Counter("lease-errors"),
+		leader:             scope.Gauge("leader"),
+	}
+}
+
+type engine struct {
+	sync.Mutex
+
+	rules       []*rule
+	interval    time.Duration
+	resendDelay time.Duration
+	query       recording.InstantQueryFn
+	notifier    notifier
+	store       kv.Store
+	lease       *util.Lease
+	nowFn       clock.NowFn
+	logger      *zap.Logger
+	metrics     engineMetrics
+
+	// leader is whether the lease was held at the last evaluation, the
+	// alert state is reloaded when the lease is acquired as it may have
+	// been changed by the previous holder.
+	leader bool
+	// savedState is the last alert state loaded or persisted, so that
+	// unchanged state is not persisted again.
+	savedState []byte
+
+	started bool
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+func newEngine(
+	rules []*rule,
+	interval time.Duration,
+	resendDelay time.Duration,
+	query recording.InstantQueryFn,
+	notifier notifier,
+	opts EngineOptions,
+) (*engine, error) {
+	scope := opts.InstrumentOptions.MetricsScope().SubScope("alerting-rules")
+	for _, r := range rules {
+		r.metrics = newRuleMetrics(scope.Tagged(map[string]string{
+			"alertname": r.name,
+		}))
+	}
+
+	var lease *util.Lease
+	if opts.KVStore != nil {
+		instanceID := opts.InstanceID
+		if instanceID == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, err
+			}
+			instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+
+		// NB: the lease outlives a couple of missed evaluations so that
+		// a slow evaluation does not hand the rules to another coordinator.
+		var err error
+		lease, err = util.NewLease(opts.KVStore, leaseKVKey, instanceID,
+			leaseIntervals*interval, opts.NowFn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &engine{
+		rules:       rules,
+		interval:    interval,
+		resendDelay: resendDelay,
+		query:       query,
+		notifier:    notifier,
+		store:       opts.KVStore,
+		lease:       lease,
+		nowFn:       opts.NowFn,
+		logger:      opts.InstrumentOptions.Logger(),
+		metrics:     newEngineMetrics(scope),
+	}, nil
+}
+
+func (e *engine) Start() error {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.started {
+		return errEngineAlreadyStarted
+	}
+
+	if e.store != nil {
+		e.loadState()
+	}
+
+	e.started = true
+	e.closeCh = make(chan struct{})
+	e.doneCh = make(chan struct{})
+
+	go e.evaluateLoop(e.closeCh, e.doneCh)
+	return nil
+}
+
+func (e *engine) Stop() error {
+	e.Lock()
+	if !e.started {
+		e.Unlock()
+		return errEngineNotStarted
+	}
+	e.started = false
+	close(e.closeCh)
+	doneCh := e.doneCh
+	e.Unlock()
+
+	<-doneCh
+
+	if e.lease != nil && e.leader {
+		// Hand the rules to another coordinator without waiting for the
+		// lease to expire.
+		if err := e.lease.Release(); err != nil {
+			e.logger.Error("unable to release alerting lease", zap.Error(err))
+		}
+		e.leader = false
+		e.metrics.leader.Update(0)
+	}
+	return nil
+}
+
+// loadState restores the persisted alert state.
+func (e *engine) loadState() {
+	// Alerts are evaluated from scratch if the state cannot be loaded,
+	// which only delays firing alerts by their for-duration.
+	state, data, err := loadState(e.store)
+	if err != nil {
+		e.metrics.stateErrors.Inc(1)
+		e.logger.Error("unable to load alert state", zap.Error(err))
+		return
+	}
+	restoreState(e.rules, state)
+	e.savedState = data
+}
+
+// acquireLease returns whether the rules should be evaluated by this
+// coordinator, which is only the case while it holds the lease.
+func (e *engine) acquireLease() bool {
+	if e.lease == nil {
+		return true
+	}
+
+	held, err := e.lease.Acquire()
+	if err != nil {
+		// NB: rules are not evaluated if the lease cannot be renewed since
+		// another coordinator may acquire it once it expires.
+		e.metrics.leaseErrors.Inc(1)
+		e.logger.Error("unable to acquire alerting lease", zap.Error(err))
+		held = false
+	}
+
+	if held && !e.leader {
+		e.logger.Info("acquired alerting lease, evaluating alerting rules")
+		e.loadState()
+	} else if !held && e.leader {
+		e.logger.Info("lost alerting lease, no longer evaluating alerting rules")
+	}
+	e.leader = held
+	if held {
+		e.metrics.leader.Update(1)
+	} else {
+		e.metrics.leader.Update(0)
+	}
+	return held
+}
+
+func (e *engine) evaluateLoop(closeCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-closeCh
+		cancel()
+	}()
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closeCh:
+			return
+		case <-ticker.C:
+			e.tick(ctx)
+		}
+	}
+}
+
+// tick evaluates all rules at the start of the current interval, then sends
+// notifications and persists the alert state. Rules are only evaluated by
+// the coordinator holding the lease, if any.
+func (e *engine) tick(ctx context.Context) {
+	if !e.acquireLease() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.interval)
+	defer cancel()
+
+	evaluationTime := e.nowFn().Truncate(e.interval)
+	for _, r := range e.rules {
+		r.metrics.evaluations.Inc(1)
+		value, err := e.query(ctx, r.expr, evaluationTime)
+		if err == nil {
+			err = r.eval(value, evaluationTime)
+		}
+		if err != nil {
+			// NB: alerts of a rule whose query failed are left unchanged
+			// rather than resolved.
+			r.metrics.evaluationErrors.Inc(1)
+			e.logger.Error("alerting rule evaluation failed",
+				zap.String("alertname", r.name),
+				zap.Time("evaluationTime", evaluationTime),
+				zap.Error(err))
+		}
+	}
+
+	e.notify(ctx, evaluationTime)
+
+	for _, r := range e.rules {
+		r.updateMetrics()
+	}
+
+	if e.store != nil {
+		e.saveState()
+	}
+}
+
+// saveState persists the alert state unless it is unchanged.
+func (e *engine) saveState() {
+	data, err := encodeState(e.rules)
+	if err == nil && bytes.Equal(data, e.savedState) {
+		return
+	}
+	if err == nil {
+		err = saveState(e.store, data)
+	}
+	if err != nil {
+		e.metrics.stateErrors.Inc(1)
+		e.logger.Error("unable to persist alert state", zap.Error(err))
+		return
+	}
+	e.savedState = data
+}
+
+func (e *engine) notify(ctx context.Context, t time.Time) {
+	var (
+		notifications []notification
+		toNotify      = make([][]*Alert, len(e.rules))
+		// Firing alerts are valid until several evaluations are missed, so
+		// the receiver resolves them if this coordinator stops sending.
+		validUntil = t.Add(4 * maxDuration(e.interval, e.resendDelay))
+	)
+	for i, r := range e.rules {
+		toNotify[i] = r.toNotify(t, e.resendDelay)
+		for _, alert := range toNotify[i] {
+			n := notification{
+				Labels:      alert.Labels,
+				Annotations: alert.Annotations,
+				StartsAt:    alert.FiredAt,
+				EndsAt:      validUntil,
+			}
+			if alert.State == AlertStateResolved {
+				n.EndsAt = alert.ResolvedAt
+			}
+			notifications = append(notifications, n)
+		}
+	}
+	if len(notifications) == 0 {
+		return
+	}
+
+	if err := e.notifier.notify(ctx, notifications); err != nil {
+		// Notifications are retried at the next evaluation.
+		e.metrics.notificationErrors.Inc(1)
+		e.logger.Error("unable to send alert notifications", zap.Error(err))
+		return
+	}
+
+	e.metrics.notificationsSent.Inc(int64(len(notifications)))
+	for i, r := range e.rules {
+		r.markSent(toNotify[i], t)
+	}
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+type testNotifier struct {
+	err  error
+	sent [][]notification
+}
+
+func (n *testNotifier) notify(_ context.Context, notifications []notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, notifications)
+	return nil
+}
+
+func newTestEngine(
+	t *testing.T,
+	store kv.Store,
+	clock *testClock,
+	notifier *testNotifier,
+	result promql.Value,
+) *engine {
+	return newTestEngineWithInstanceID(t, store, clock, notifier, result, "")
+}
+
+func newTestEngineWithInstanceID(
+	t *testing.T,
+	store kv.Store,
+	clock *testClock,
+	notifier *testNotifier,
+	result promql.Value,
+	instanceID string,
+) *engine {
+	r, err := RuleConfiguration{
+		Alert:  "InstanceDown",
+		Expr:   "up == 0",
+		Labels: map[string]string{"severity": "page"},
+	}.newRule()
+	require.NoError(t, err)
+
+	query := func(context.Context, string, time.Time) (promql.Value, error) {
+		return result, nil
+	}
+	e, err := newEngine([]*rule{r}, time.Minute, 5*time.Minute, query, notifier,
+		EngineOptions{
+			KVStore:           store,
+			InstanceID:        instanceID,
+			NowFn:             clock.Now,
+			InstrumentOptions: instrument.NewOptions(),
+		})
+	require.NoError(t, err)
+	return e
+}
+
+func TestEngineNotifiesAndPersistsState(t *testing.T) {
+	var (
+		store    = mem.NewStore()
+		clock    = &testClock{now: time.Unix(1600000020, 0)}
+		notifier = &testNotifier{err: errors.New("unavailable")}
+		result   = testVector(0, labels.MetricName, "up", "job", "api")
+		e        = newTestEngine(t, store, clock, notifier, result)
+	)
+
+	// Failed notifications are retried at the next evaluation.
+	e.tick(context.Background())
+	require.Empty(t, notifier.sent)
+
+	notifier.err = nil
+	clock.now = clock.now.Add(time.Minute)
+	e.tick(context.Background())
+	require.Equal(t, 1, len(notifier.sent))
+	require.Equal(t, 1, len(notifier.sent[0]))
+	sent := notifier.sent[0][0]
+	assert.Equal(t, map[string]string{
+		labels.AlertName: "InstanceDown",
+		"job":            "api",
+		"severity":       "page",
+	}, sent.Labels)
+	assert.Equal(t, time.Unix(1600000020, 0), sent.StartsAt)
+	assert.Equal(t, clock.now.Add(20*time.Minute), sent.EndsAt)
+
+	// A restarted engine restores the firing alert and does not notify
+	// again before the resend delay.
+	restarted := newTestEngine(t, store, clock, notifier, result)
+	require.NoError(t, restarted.Start())
+	require.NoError(t, restarted.Stop())
+
+	require.Equal(t, 1, len(restarted.rules[0].active))
+	for _, alert := range restarted.rules[0].active {
+		assert.Equal(t, AlertStateFiring, alert.State)
+		assert.True(t, alert.FiredAt.Equal(time.Unix(1600000020, 0)))
+		assert.True(t, alert.LastSentAt.Equal(clock.now))
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	restarted.tick(context.Background())
+	assert.Equal(t, 1, len(notifier.sent))
+
+	// Resolution is sent once the alert is no longer active.
+	resolved := newTestEngine(t, store, clock, notifier, promql.Vector{})
+	require.NoError(t, resolved.Start())
+	require.NoError(t, resolved.Stop())
+	clock.now = clock.now.Add(time.Minute)
+	resolved.tick(context.Background())
+	require.Equal(t, 2, len(notifier.sent))
+	assert.Equal(t, clock.now.Truncate(time.Minute), notifier.sent[1][0].EndsAt)
+	assert.Empty(t, resolved.rules[0].active)
+
+	state, _, err := loadState(store)
+	require.NoError(t, err)
+	assert.Empty(t, state.Rules[resolved.rules[0].id()])
+}
+
+func TestEngineOnlyLeaseHolderEvaluates(t *testing.T) {
+	var (
+		store    = mem.NewStore()
+		clock    = &testClock{now: time.Unix(1600000020, 0)}
+		notifier = &testNotifier{}
+		result   = testVector(0, labels.MetricName, "up", "job", "api")
+		a        = newTestEngineWithInstanceID(t, store, clock, notifier, result, "a")
+		b        = newTestEngineWithInstanceID(t, store, clock, notifier, result, "b")
+	)
+
+	a.tick(context.Background())
+	b.tick(context.Background())
+	require.Equal(t, 1, len(notifier.sent))
+	assert.True(t, a.leader)
+	assert.False(t, b.leader)
+	assert.Empty(t, b.rules[0].active)
+
+	// The alert state is unchanged until the resend delay so it is not
+	// persisted again.
+	value, err := store.Get(stateKVKey)
+	require.NoError(t, err)
+	clock.now = clock.now.Add(time.Minute)
+	a.tick(context.Background())
+	unchanged, err := store.Get(stateKVKey)
+	require.NoError(t, err)
+	assert.Equal(t, value.Version(), unchanged.Version())
+
+	// Once the lease expires the other coordinator takes over with the
+	// persisted state, and does not notify again before the resend delay.
+	clock.now = clock.now.Add(3 * time.Minute)
+	b.tick(context.Background())
+	assert.True(t, b.leader)
+	assert.Equal(t, 1, len(b.rules[0].active))
+	assert.Equal(t, 1, len(notifier.sent))
+
+	a.tick(context.Background())
+	assert.False(t, a.leader)
+}
+
+func TestEngineStartStop(t *testing.T) {
+	e := newTestEngine(t, nil, &testClock{now: time.Unix(1600000020, 0)},
+		&testNotifier{}, promql.Vector{})
+
+	require.Equal(t, errEngineNotStarted, e.Stop())
+	require.NoError(t, e.Start())
+	require.Equal(t, errEngineAlreadyStarted, e.Start())
+	require.NoError(t, e.Stop())
+}
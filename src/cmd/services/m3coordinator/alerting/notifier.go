@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+)
+
+// notification is an alert in the Alertmanager alerts API format.
+type notification struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+type notifier interface {
+	notify(ctx context.Context, notifications []notification) error
+}
+
+type webhookNotifier struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func (c WebhookConfiguration) newNotifier() (notifier, error) {
+	if c.URL == "" {
+		return nil, errors.New("alerting webhook url is not set")
+	}
+	if _, err := url.Parse(c.URL); err != nil {
+		return nil, fmt.Errorf("invalid alerting webhook url: %v", err)
+	}
+
+	clientOpts := xhttp.DefaultHTTPClientOptions()
+	clientOpts.RequestTimeout = defaultWebhookTimeout
+	if c.Timeout > 0 {
+		clientOpts.RequestTimeout = c.Timeout
+	}
+
+	return &webhookNotifier{
+		client:  xhttp.NewHTTPClient(clientOpts),
+		url:     c.URL,
+		headers: c.Headers,
+	}, nil
+}
+
+func (n *webhookNotifier) notify(
+	ctx context.Context,
+	notifications []notification,
+) error {
+	body, err := json.Marshal(notifications)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	for name, value := range n.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected alerting webhook response status: %d",
+			resp.StatusCode)
+	}
+	return nil
+}
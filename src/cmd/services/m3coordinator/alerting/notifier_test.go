@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		received []notification
+		header   http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			header = r.Header
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		}))
+	defer server.Close()
+
+	n, err := WebhookConfiguration{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}.newNotifier()
+	require.NoError(t, err)
+
+	startsAt := time.Unix(1600000020, 0).UTC()
+	notifications := []notification{
+		{
+			Labels:      map[string]string{"alertname": "InstanceDown"},
+			Annotations: map[string]string{"summary": "down"},
+			StartsAt:    startsAt,
+			EndsAt:      startsAt.Add(4 * time.Minute),
+		},
+	}
+	require.NoError(t, n.notify(context.Background(), notifications))
+	assert.Equal(t, notifications, received)
+	assert.Equal(t, xhttp.ContentTypeJSON, header.Get(xhttp.HeaderContentType))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+}
+
+func TestWebhookNotifierErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	defer server.Close()
+
+	n, err := WebhookConfiguration{URL: server.URL}.newNotifier()
+	require.NoError(t, err)
+	assert.Error(t, n.notify(context.Background(), []notification{{}}))
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+)
+
+// templatePrefix defines the template variables available to label and
+// annotation templates, the same as Prometheus alerting rules.
+const templatePrefix = "{{$labels := .Labels}}{{$value := .Value}}"
+
+// AlertState is the state of an alert.
+type AlertState string
+
+const (
+	// AlertStatePending is the state of an active alert that has not been
+	// active for the rule's for-duration yet.
+	AlertStatePending AlertState = "pending"
+	// AlertStateFiring is the state of an active alert that notifications
+	// are sent for.
+	AlertStateFiring AlertState = "firing"
+	// AlertStateResolved is the state of a firing alert that is no longer
+	// active until its resolution has been sent.
+	AlertStateResolved AlertState = "resolved"
+)
+
+// Alert is an active, or resolved but not yet notified, alert of a rule.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	State       AlertState        `json:"state"`
+	ActiveAt    time.Time         `json:"activeAt"`
+	FiredAt     time.Time         `json:"firedAt"`
+	ResolvedAt  time.Time         `json:"resolvedAt"`
+	LastSentAt  time.Time         `json:"lastSentAt"`
+}
+
+type templateData struct {
+	Labels map[string]string
+	Value  float64
+}
+
+type rule struct {
+	name        string
+	expr        string
+	holdFor     time.Duration
+	labels      map[string]*template.Template
+	annotations map[string]*template.Template
+
+	// Alerts keyed by their labels, only accessed by the evaluation loop.
+	active  map[string]*Alert
+	metrics ruleMetrics
+}
+
+// id identifies the rule in the persisted alert state.
+func (r *rule) id() string {
+	return r.name + "/" + r.expr
+}
+
+// eval updates the alerts of the rule with the result of evaluating its
+// expression at the given time.
+func (r *rule) eval(value promql.Value, t time.Time) error {
+	samples, ok := value.(promql.Vector)
+	if !ok {
+		return fmt.Errorf("unsupported result type: %s", value.Type())
+	}
+
+	var (
+		seen     = make(map[string]struct{}, len(samples))
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, sample := range samples {
+		metric := make(map[string]string, len(sample.Metric))
+		for _, l := range sample.Metric {
+			if l.Name == labels.MetricName {
+				continue
+			}
+			metric[l.Name] = l.Value
+		}
+
+		data := templateData{Labels: metric, Value: sample.V}
+		extraLabels, err := expandTemplates(r.labels, data)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		annotations, err := expandTemplates(r.annotations, data)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+
+		alertLabels := make(map[string]string, len(metric)+len(extraLabels)+1)
+		for name, v := range metric {
+			alertLabels[name] = v
+		}
+		for name, v := range extraLabels {
+			alertLabels[name] = v
+		}
+		alertLabels[labels.AlertName] = r.name
+
+		key := alertKey(alertLabels)
+		if _, ok := seen[key]; ok {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"duplicate alert labels after applying rule labels: %s", key))
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if alert, ok := r.active[key]; ok && alert.State != AlertStateResolved {
+			alert.Annotations = annotations
+			continue
+		}
+		r.active[key] = &Alert{
+			Labels:      alertLabels,
+			Annotations: annotations,
+			State:       AlertStatePending,
+			ActiveAt:    t,
+		}
+	}
+
+	for key, alert := range r.active {
+		if _, ok := seen[key]; ok {
+			if alert.State == AlertStatePending && t.Sub(alert.ActiveAt) >= r.holdFor {
+				alert.State = AlertStateFiring
+				alert.FiredAt = t
+			}
+			continue
+		}
+
+		switch alert.State {
+		case AlertStatePending:
+			delete(r.active, key)
+		case AlertStateFiring:
+			alert.State = AlertStateResolved
+			alert.ResolvedAt = t
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
+// toNotify returns the alerts that notifications should be sent for at the
+// given time.
+func (r *rule) toNotify(t time.Time, resendDelay time.Duration) []*Alert {
+	var alerts []*Alert
+	for _, alert := range r.active {
+		switch alert.State {
+		case AlertStateFiring:
+			if alert.LastSentAt.IsZero() || t.Sub(alert.LastSentAt) >= resendDelay {
+				alerts = append(alerts, alert)
+			}
+		case AlertStateResolved:
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+// markSent records that notifications were sent for the given alerts,
+// resolved alerts are no longer tracked once their resolution is sent.
+func (r *rule) markSent(alerts []*Alert, t time.Time) {
+	for _, alert := range alerts {
+		alert.LastSentAt = t
+		if alert.State == AlertStateResolved {
+			delete(r.active, alertKey(alert.Labels))
+		}
+	}
+}
+
+func (r *rule) updateMetrics() {
+	var pending, firing int
+	for _, alert := range r.active {
+		switch alert.State {
+		case AlertStatePending:
+			pending++
+		case AlertStateFiring:
+			firing++
+		}
+	}
+	r.metrics.pending.Update(float64(pending))
+	r.metrics.firing.Update(float64(firing))
+}
+
+func alertKey(alertLabels map[string]string) string {
+	return labels.FromMap(alertLabels).String()
+}
+
+func newTemplates(
+	alert string,
+	texts map[string]string,
+) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template, len(texts))
+	for name, text := range texts {
+		tmpl, err := template.New(name).
+			Option("missingkey=zero").
+			Parse(templatePrefix + text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alerting rule %s template %s: %v",
+				alert, name, err)
+		}
+		templates[name] = tmpl
+	}
+	return templates, nil
+}
+
+func expandTemplates(
+	templates map[string]*template.Template,
+	data templateData,
+) (map[string]string, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+
+	var (
+		expanded = make(map[string]string, len(templates))
+		buf      bytes.Buffer
+	)
+	for name, tmpl := range templates {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("unable to expand template %s: %v", name, err)
+		}
+		expanded[name] = buf.String()
+	}
+	return expanded, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testVector(value float64, pairs ...string) promql.Vector {
+	return promql.Vector{
+		{
+			Point:  promql.Point{V: value},
+			Metric: labels.FromStrings(pairs...),
+		},
+	}
+}
+
+func TestRuleConfigurationValidation(t *testing.T) {
+	_, err := RuleConfiguration{Alert: "bad-name", Expr: "up == 0"}.newRule()
+	assert.Error(t, err)
+
+	_, err = RuleConfiguration{Alert: "Down", Expr: "up =="}.newRule()
+	assert.Error(t, err)
+
+	_, err = RuleConfiguration{
+		Alert:  "Down",
+		Expr:   "up == 0",
+		Labels: map[string]string{"severity": "{{ $labels.job"},
+	}.newRule()
+	assert.Error(t, err)
+
+	_, err = RuleConfiguration{
+		Alert:  "Down",
+		Expr:   "up == 0",
+		Labels: map[string]string{"bad-label": "page"},
+	}.newRule()
+	assert.Error(t, err)
+}
+
+func TestRuleEvalLifecycle(t *testing.T) {
+	r, err := RuleConfiguration{
+		Alert: "InstanceDown",
+		Expr:  "up == 0",
+		For:   2 * time.Minute,
+		Labels: map[string]string{
+			"severity": "page",
+			"team":     "{{ $labels.job }}-oncall",
+		},
+		Annotations: map[string]string{
+			"summary": "{{ $labels.instance }} of {{ $labels.job }} is down ({{ $value }})",
+		},
+	}.newRule()
+	require.NoError(t, err)
+
+	var (
+		start  = time.Unix(1600000020, 0)
+		result = testVector(0, labels.MetricName, "up",
+			"job", "api", "instance", "host-1")
+		expectedLabels = map[string]string{
+			labels.AlertName: "InstanceDown",
+			"job":            "api",
+			"instance":       "host-1",
+			"severity":       "page",
+			"team":           "api-oncall",
+		}
+	)
+
+	require.NoError(t, r.eval(result, start))
+	require.Equal(t, 1, len(r.active))
+	alert := r.active[alertKey(expectedLabels)]
+	require.NotNil(t, alert)
+	assert.Equal(t, expectedLabels, alert.Labels)
+	assert.Equal(t, map[string]string{
+		"summary": "host-1 of api is down (0)",
+	}, alert.Annotations)
+	assert.Equal(t, AlertStatePending, alert.State)
+	assert.Equal(t, start, alert.ActiveAt)
+	assert.Empty(t, r.toNotify(start, time.Minute))
+
+	// Fires once active for the for-duration.
+	require.NoError(t, r.eval(result, start.Add(time.Minute)))
+	assert.Equal(t, AlertStatePending, alert.State)
+	firedAt := start.Add(2 * time.Minute)
+	require.NoError(t, r.eval(result, firedAt))
+	assert.Equal(t, AlertStateFiring, alert.State)
+	assert.Equal(t, firedAt, alert.FiredAt)
+
+	// Notifications are resent after the resend delay.
+	assert.Equal(t, []*Alert{alert}, r.toNotify(firedAt, 5*time.Minute))
+	r.markSent([]*Alert{alert}, firedAt)
+	assert.Empty(t, r.toNotify(firedAt.Add(time.Minute), 5*time.Minute))
+	assert.Equal(t, []*Alert{alert},
+		r.toNotify(firedAt.Add(5*time.Minute), 5*time.Minute))
+
+	// Resolves once no longer active and is dropped once notified.
+	resolvedAt := firedAt.Add(time.Minute)
+	require.NoError(t, r.eval(promql.Vector{}, resolvedAt))
+	assert.Equal(t, AlertStateResolved, alert.State)
+	assert.Equal(t, resolvedAt, alert.ResolvedAt)
+	assert.Equal(t, []*Alert{alert}, r.toNotify(resolvedAt, 5*time.Minute))
+	r.markSent([]*Alert{alert}, resolvedAt)
+	assert.Empty(t, r.active)
+}
+
+func TestRuleEvalPendingAlertDroppedWhenInactive(t *testing.T) {
+	r, err := RuleConfiguration{
+		Alert: "InstanceDown",
+		Expr:  "up == 0",
+		For:   5 * time.Minute,
+	}.newRule()
+	require.NoError(t, err)
+
+	start := time.Unix(1600000020, 0)
+	require.NoError(t, r.eval(testVector(0, "job", "api"), start))
+	require.Equal(t, 1, len(r.active))
+
+	require.NoError(t, r.eval(promql.Vector{}, start.Add(time.Minute)))
+	assert.Empty(t, r.active)
+}
+
+func TestRuleEvalDuplicateLabels(t *testing.T) {
+	r, err := RuleConfiguration{
+		Alert:  "InstanceDown",
+		Expr:   "up == 0",
+		Labels: map[string]string{"instance": "all"},
+	}.newRule()
+	require.NoError(t, err)
+
+	result := append(
+		testVector(0, "job", "api", "instance", "host-1"),
+		testVector(0, "job", "api", "instance", "host-2")...)
+	assert.Error(t, r.eval(result, time.Unix(1600000020, 0)))
+	assert.Equal(t, 1, len(r.active))
+}
+
+func TestRuleEvalUnsupportedResultType(t *testing.T) {
+	r, err := RuleConfiguration{Alert: "Scalar", Expr: "vector(1)"}.newRule()
+	require.NoError(t, err)
+	assert.Error(t, r.eval(promql.Scalar{V: 1}, time.Unix(1600000020, 0)))
+}
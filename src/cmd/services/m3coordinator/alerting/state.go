@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package alerting
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+)
+
+// stateKVKey is the KV key the alert state of all rules is stored under.
+const stateKVKey = "m3coordinator.alerting.state"
+
+// persistedState is the alert state stored in KV, encoded as JSON.
+type persistedState struct {
+	// Rules maps rule IDs to the alerts of the rule.
+	Rules map[string][]*Alert `json:"rules"`
+}
+
+// loadState returns the persisted alert state along with its encoding.
+func loadState(store kv.Store) (persistedState, []byte, error) {
+	value, err := store.Get(stateKVKey)
+	if err == kv.ErrNotFound {
+		return persistedState{}, nil, nil
+	}
+	if err != nil {
+		return persistedState{}, nil, err
+	}
+
+	var encoded commonpb.StringProto
+	if err := value.Unmarshal(&encoded); err != nil {
+		return persistedState{}, nil, err
+	}
+
+	var state persistedState
+	data := []byte(encoded.Value)
+	if err := json.Unmarshal(data, &state); err != nil {
+		return persistedState{}, nil, err
+	}
+	return state, data, nil
+}
+
+func encodeState(rules []*rule) ([]byte, error) {
+	state := persistedState{Rules: make(map[string][]*Alert, len(rules))}
+	for _, r := range rules {
+		// NB: alerts are sorted so that unchanged state has the same
+		// encoding and is not persisted again.
+		keys := make([]string, 0, len(r.active))
+		for key := range r.active {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		alerts := make([]*Alert, 0, len(r.active))
+		for _, key := range keys {
+			alerts = append(alerts, r.active[key])
+		}
+		state.Rules[r.id()] = alerts
+	}
+
+	return json.Marshal(state)
+}
+
+func saveState(store kv.Store, data []byte) error {
+	_, err := store.Set(stateKVKey, &commonpb.StringProto{Value: string(data)})
+	return err
+}
+
+// restoreState sets the alerts of the rules to the persisted ones, alerts
+// of rules that are no longer configured are dropped.
+func restoreState(rules []*rule, state persistedState) {
+	for _, r := range rules {
+		r.active = make(map[string]*Alert, len(state.Rules[r.id()]))
+		for _, alert := range state.Rules[r.id()] {
+			r.active[alertKey(alert.Labels)] = alert
+		}
+	}
+}
@@ -93,6 +93,9 @@ var (
 	errNoTagEncoderPoolOptions = errors.New("dynamic downsampling enabled with tag encoder pool options not set")
 	errNoTagDecoderPoolOptions = errors.New("dynamic downsampling enabled with tag decoder pool options not set")
 	errRollupRuleNoTransforms  = errors.New("rollup rule has no transforms set")
+	errTransformNoOperation    = errors.New("rollup rule transform has no operation set")
+	errTransformMultipleOps    = errors.New("rollup rule transform has more than one operation set")
+	errRollupNoMetricName      = errors.New("rollup operation has no metric name set")
 )
 
 // DownsamplerOptions is a set of required downsampler options.
@@ -389,7 +392,10 @@ func (r RollupRuleConfiguration) Rule() (view.RollupRule, error) {
 
 	ops := make([]pipeline.OpUnion, 0, len(r.Transforms))
 	for _, elem := range r.Transforms {
-		// TODO: make sure only one of "Rollup" or "Aggregate" or "Transform" is not nil
+		if err := elem.Validate(); err != nil {
+			return view.RollupRule{}, err
+		}
+
 		switch {
 		case elem.Rollup != nil:
 			cfg := elem.Rollup
@@ -474,6 +480,32 @@ type TransformConfiguration struct {
 	Transform *TransformOperationConfiguration `yaml:"transform"`
 }
 
+// Validate validates that exactly one operation is set on the transform.
+func (c TransformConfiguration) Validate() error {
+	numOps := 0
+	if c.Rollup != nil {
+		if c.Rollup.MetricName == "" {
+			return errRollupNoMetricName
+		}
+		numOps++
+	}
+	if c.Aggregate != nil {
+		numOps++
+	}
+	if c.Transform != nil {
+		numOps++
+	}
+
+	switch numOps {
+	case 0:
+		return errTransformNoOperation
+	case 1:
+		return nil
+	default:
+		return errTransformMultipleOps
+	}
+}
+
 // RollupOperationConfiguration is a rollup operation.
 type RollupOperationConfiguration struct {
 	// MetricName is the name of the new metric that is emitted after
@@ -1087,6 +1119,14 @@ func (c *aggregatorLocalAdminClient) WriteUntimedGauge(
 	return c.agg.AddUntimed(gauge.ToUnion(), metadatas)
 }
 
+// WriteUntimedBatch writes a batch of untimed metrics.
+func (c *aggregatorLocalAdminClient) WriteUntimedBatch(
+	metrics []unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) error {
+	return c.agg.AddUntimedBatch(metrics, metadatas)
+}
+
 // WriteTimed writes timed metrics.
 func (c *aggregatorLocalAdminClient) WriteTimed(
 	metric aggregated.Metric,
@@ -25,6 +25,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/metrics/aggregation"
+	"github.com/m3db/m3/src/metrics/transformation"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -52,3 +55,58 @@ func TestBufferForPastTimedMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestRollupRuleConfigurationValidatesTransforms(t *testing.T) {
+	rollup := &RollupOperationConfiguration{
+		MetricName:   "rolled_up",
+		GroupBy:      []string{"app"},
+		Aggregations: []aggregation.Type{aggregation.Sum},
+	}
+	perSecond := &TransformOperationConfiguration{Type: transformation.PerSecond}
+
+	tests := []struct {
+		name      string
+		transform TransformConfiguration
+		expectErr error
+	}{
+		{
+			name:      "rollup",
+			transform: TransformConfiguration{Rollup: rollup},
+		},
+		{
+			name:      "transform",
+			transform: TransformConfiguration{Transform: perSecond},
+		},
+		{
+			name:      "no operation",
+			transform: TransformConfiguration{},
+			expectErr: errTransformNoOperation,
+		},
+		{
+			name:      "multiple operations",
+			transform: TransformConfiguration{Rollup: rollup, Transform: perSecond},
+			expectErr: errTransformMultipleOps,
+		},
+		{
+			name: "rollup without metric name",
+			transform: TransformConfiguration{
+				Rollup: &RollupOperationConfiguration{GroupBy: []string{"app"}},
+			},
+			expectErr: errRollupNoMetricName,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := RollupRuleConfiguration{
+				Filter:     "app:foo",
+				Transforms: []TransformConfiguration{test.transform},
+			}
+			_, err := cfg.Rule()
+			if test.expectErr != nil {
+				require.Equal(t, test.expectErr, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
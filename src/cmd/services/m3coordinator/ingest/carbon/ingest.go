@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"sync"
 	"time"
 
@@ -64,6 +65,7 @@ var (
 	errCannotGenerateTagsFromEmptyName = errors.New("cannot generate tags from empty name")
 	errIOptsMustBeSet                  = errors.New("carbon ingester options: instrument options must be st")
 	errWorkerPoolMustBeSet             = errors.New("carbon ingester options: worker pool must be set")
+	errMappingNameMustBeSet            = errors.New("carbon mapping: name must be set")
 )
 
 // Options configures the ingester.
@@ -214,10 +216,19 @@ func (i *ingester) write(
 		WriteOverride:      true,
 	}
 
-	matched := 0
+	var (
+		matched  = 0
+		writeErr error
+	)
 	defer func() {
+		if writeErr != nil {
+			// Already logged and counted by writeWithOptions.
+			return
+		}
+
 		if matched == 0 {
 			// No policies matched.
+			i.metrics.unmatched.Inc(1)
 			debugLog := i.logger.Check(zapcore.DebugLevel, "no rules matched carbon metric, skipping")
 			if debugLog != nil {
 				debugLog.Write(zap.ByteString("name", resources.name))
@@ -249,9 +260,9 @@ func (i *ingester) write(
 
 			// Break because we only want to apply one rule per metric based on which
 			// ever one matches first.
-			err := i.writeWithOptions(ctx, resources, timestamp, value,
-				downsampleAndStoragePolicies)
-			if err != nil {
+			writeErr = i.writeWithOptions(ctx, resources, timestamp, value,
+				rule.mapping, downsampleAndStoragePolicies)
+			if writeErr != nil {
 				return false
 			}
 
@@ -272,10 +283,20 @@ func (i *ingester) writeWithOptions(
 	resources *lineResources,
 	timestamp time.Time,
 	value float64,
+	mapping *nameMapping,
 	opts ingest.WriteOptions,
 ) error {
 	resources.datapoints[0] = ts.Datapoint{Timestamp: timestamp, Value: value}
-	tags, err := GenerateTagsFromNameIntoSlice(resources.name, i.tagOpts, resources.tags)
+
+	var (
+		tags models.Tags
+		err  error
+	)
+	if mapping != nil {
+		tags, err = mapping.generateTags(resources.name)
+	} else {
+		tags, err = GenerateTagsFromNameIntoSlice(resources.name, i.tagOpts, resources.tags)
+	}
 	if err != nil {
 		i.logger.Error("err generating tags from carbon",
 			zap.String("name", string(resources.name)), zap.Error(err))
@@ -306,6 +327,7 @@ func newCarbonIngesterMetrics(m tally.Scope) carbonIngesterMetrics {
 		success:   m.Counter("success"),
 		err:       m.Counter("error"),
 		malformed: m.Counter("malformed"),
+		unmatched: m.Counter("unmatched"),
 	}
 }
 
@@ -313,6 +335,7 @@ type carbonIngesterMetrics struct {
 	success   tally.Counter
 	err       tally.Counter
 	malformed tally.Counter
+	unmatched tally.Counter
 }
 
 // GenerateTagsFromName accepts a carbon metric name and blows it up into a list of
@@ -393,6 +416,33 @@ func generateTagsFromName(
 	return models.Tags{Opts: opts, Tags: tags}, nil
 }
 
+// MapName generates the tags for a carbon metric name as the given rule
+// would when ingesting it, returning false if the rule does not match the
+// name. This allows verifying the mapping of a rule against known metric
+// names before deploying it.
+func MapName(
+	rule config.CarbonIngesterRuleConfiguration,
+	name []byte,
+) (models.Tags, bool, error) {
+	compiled, err := compileRule(rule)
+	if err != nil {
+		return models.EmptyTags(), false, err
+	}
+
+	if rule.Pattern != graphite.MatchAllPattern && !compiled.regexp.Match(name) {
+		return models.EmptyTags(), false, nil
+	}
+
+	if compiled.mapping != nil {
+		tags, err := compiled.mapping.generateTags(name)
+		return tags, err == nil, err
+	}
+
+	tagOpts := models.NewTagOptions().SetIDSchemeType(models.TypeGraphite)
+	tags, err := GenerateTagsFromName(name, tagOpts)
+	return tags, err == nil, err
+}
+
 // Compile all the carbon ingestion rules into regexp so that we can
 // perform matching. Also, generate all the mapping rules and storage
 // policies that we will need to pass to the DownsamplerAndWriter upfront
@@ -404,37 +454,54 @@ func generateTagsFromName(
 func compileRules(rules CarbonIngesterRules) ([]ruleAndRegex, error) {
 	compiledRules := []ruleAndRegex{}
 	for _, rule := range rules.Rules {
-		compiled, err := regexp.Compile(rule.Pattern)
+		compiledRule, err := compileRule(rule)
 		if err != nil {
 			return nil, err
 		}
 
-		storagePolicies := []policy.StoragePolicy{}
-		for _, currPolicy := range rule.Policies {
-			storagePolicy := policy.NewStoragePolicy(
-				currPolicy.Resolution, xtime.Second, currPolicy.Retention)
-			storagePolicies = append(storagePolicies, storagePolicy)
-		}
+		compiledRules = append(compiledRules, compiledRule)
+	}
+
+	return compiledRules, nil
+}
+
+func compileRule(rule config.CarbonIngesterRuleConfiguration) (ruleAndRegex, error) {
+	compiled, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return ruleAndRegex{}, err
+	}
+
+	storagePolicies := []policy.StoragePolicy{}
+	for _, currPolicy := range rule.Policies {
+		storagePolicy := policy.NewStoragePolicy(
+			currPolicy.Resolution, xtime.Second, currPolicy.Retention)
+		storagePolicies = append(storagePolicies, storagePolicy)
+	}
 
-		compiledRule := ruleAndRegex{
-			rule:   rule,
-			regexp: compiled,
+	compiledRule := ruleAndRegex{
+		rule:   rule,
+		regexp: compiled,
+	}
+
+	if rule.Mapping != nil {
+		compiledRule.mapping, err = newNameMapping(compiled, *rule.Mapping)
+		if err != nil {
+			return ruleAndRegex{}, err
 		}
+	}
 
-		if rule.Aggregation.EnabledOrDefault() {
-			compiledRule.mappingRules = []downsample.AutoMappingRule{
-				downsample.AutoMappingRule{
-					Aggregations: []aggregation.Type{rule.Aggregation.TypeOrDefault()},
-					Policies:     storagePolicies,
-				},
-			}
-		} else {
-			compiledRule.storagePolicies = storagePolicies
+	if rule.Aggregation.EnabledOrDefault() {
+		compiledRule.mappingRules = []downsample.AutoMappingRule{
+			downsample.AutoMappingRule{
+				Aggregations: []aggregation.Type{rule.Aggregation.TypeOrDefault()},
+				Policies:     storagePolicies,
+			},
 		}
-		compiledRules = append(compiledRules, compiledRule)
+	} else {
+		compiledRule.storagePolicies = storagePolicies
 	}
 
-	return compiledRules, nil
+	return compiledRule, nil
 }
 
 func (i *ingester) getLineResources() *lineResources {
@@ -474,4 +541,77 @@ type ruleAndRegex struct {
 	regexp          *regexp.Regexp
 	mappingRules    []downsample.AutoMappingRule
 	storagePolicies []policy.StoragePolicy
+	mapping         *nameMapping
+}
+
+// nameMapping maps carbon metric names to tagged metrics using the capture
+// groups of a rule pattern.
+type nameMapping struct {
+	regexp  *regexp.Regexp
+	name    []byte
+	tags    []models.Tag
+	tagOpts models.TagOptions
+}
+
+func newNameMapping(
+	compiled *regexp.Regexp,
+	cfg config.CarbonIngesterMappingConfiguration,
+) (*nameMapping, error) {
+	if cfg.Name == "" {
+		return nil, errMappingNameMustBeSet
+	}
+
+	tagOpts := models.NewTagOptions()
+	tags := make([]models.Tag, 0, len(cfg.Tags))
+	for name, value := range cfg.Tags {
+		if name == string(tagOpts.MetricName()) {
+			return nil, fmt.Errorf(
+				"carbon mapping: tag %s is reserved for the metric name", name)
+		}
+
+		tags = append(tags, models.Tag{Name: []byte(name), Value: []byte(value)})
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		return bytes.Compare(tags[i].Name, tags[j].Name) < 0
+	})
+
+	return &nameMapping{
+		regexp:  compiled,
+		name:    []byte(cfg.Name),
+		tags:    tags,
+		tagOpts: tagOpts,
+	}, nil
+}
+
+func (m *nameMapping) generateTags(name []byte) (models.Tags, error) {
+	match := m.regexp.FindSubmatchIndex(name)
+	if match == nil {
+		return models.EmptyTags(),
+			fmt.Errorf("carbon metric: %s does not match mapping pattern", string(name))
+	}
+
+	metricName := m.regexp.Expand(nil, m.name, name, match)
+	if len(metricName) == 0 {
+		return models.EmptyTags(),
+			fmt.Errorf("carbon metric: %s mapped to empty name", string(name))
+	}
+
+	tags := models.NewTags(len(m.tags)+1, m.tagOpts).
+		AddTagWithoutNormalizing(models.Tag{
+			Name:  m.tagOpts.MetricName(),
+			Value: metricName,
+		})
+	for _, tag := range m.tags {
+		value := m.regexp.Expand(nil, tag.Value, name, match)
+		if len(value) == 0 {
+			// NB: optional capture groups that did not participate in the match
+			// expand to nothing, in which case the tag is omitted.
+			continue
+		}
+
+		tags = tags.AddTagWithoutNormalizing(models.Tag{Name: tag.Name, Value: value})
+	}
+
+	return tags.Normalize(), nil
 }
@@ -49,6 +49,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -275,11 +276,19 @@ func TestIngesterHonorsPatterns(t *testing.T) {
 		"foo.match-regex2.bar.baz 2 2\n" +
 		"foo.match-regex3.bar.baz 3 3\n" +
 		"foo.match-not-regex.bar.baz 4 4")
+	scope := tally.NewTestScope("", nil)
+	opts := testOptions
+	opts.InstrumentOptions = instrument.NewOptions().SetMetricsScope(scope)
+
 	byteConn := &byteConn{b: bytes.NewBuffer(packet)}
-	ingester, err := NewIngester(mockDownsamplerAndWriter, testRulesWithPatterns, testOptions)
+	ingester, err := NewIngester(mockDownsamplerAndWriter, testRulesWithPatterns, opts)
 	require.NoError(t, err)
 	ingester.Handle(byteConn)
 
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(3), counters["success+"].Value())
+	require.Equal(t, int64(1), counters["unmatched+"].Value())
+
 	assertTestMetricsAreEqual(t, []testMetric{
 		{
 			metric:    []byte("foo.match-regex1.bar.baz"),
@@ -359,6 +368,117 @@ func TestGenerateTagsFromName(t *testing.T) {
 	}
 }
 
+func TestIngesterMapsNamesToTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+
+	var (
+		lock  = sync.Mutex{}
+		found = []testMetric{}
+	)
+	mockDownsamplerAndWriter.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), xtime.Second, gomock.Any(), gomock.Any()).DoAndReturn(func(
+		_ context.Context,
+		tags models.Tags,
+		dp ts.Datapoints,
+		unit xtime.Unit,
+		annotation []byte,
+		writeOpts ingest.WriteOptions,
+	) interface{} {
+		lock.Lock()
+		found = append(found, testMetric{
+			tags: tags.Clone(), timestamp: int(dp[0].Timestamp.Unix()), value: dp[0].Value})
+		lock.Unlock()
+		return nil
+	}).AnyTimes()
+
+	rules := CarbonIngesterRules{
+		Rules: []config.CarbonIngesterRuleConfiguration{
+			{
+				Pattern: `^stats\.(?P<service>[^.]+)\.requests\.([^.]+)$`,
+				Aggregation: config.CarbonIngesterAggregationConfiguration{
+					Enabled: falsePtr,
+				},
+				Policies: []config.CarbonIngesterStoragePolicyConfiguration{
+					{
+						Resolution: 10 * time.Second,
+						Retention:  48 * time.Hour,
+					},
+				},
+				Mapping: &config.CarbonIngesterMappingConfiguration{
+					Name: "requests",
+					Tags: map[string]string{
+						"service": "${service}",
+						"code":    "$2",
+					},
+				},
+			},
+		},
+	}
+
+	packet := []byte("" +
+		"stats.foo.requests.200 1 1\n" +
+		"stats.bar.requests.500 2 2\n" +
+		"stats.bar.latency 3 3")
+	byteConn := &byteConn{b: bytes.NewBuffer(packet)}
+	ingester, err := NewIngester(mockDownsamplerAndWriter, rules, testOptions)
+	require.NoError(t, err)
+	ingester.Handle(byteConn)
+
+	tagOpts := models.NewTagOptions()
+	newTags := func(service, code string) models.Tags {
+		return models.NewTags(3, tagOpts).AddTags([]models.Tag{
+			{Name: []byte("__name__"), Value: []byte("requests")},
+			{Name: []byte("code"), Value: []byte(code)},
+			{Name: []byte("service"), Value: []byte(service)},
+		})
+	}
+
+	assertTestMetricsAreEqual(t, []testMetric{
+		{tags: newTags("foo", "200"), timestamp: 1, value: 1},
+		{tags: newTags("bar", "500"), timestamp: 2, value: 2},
+	}, found)
+}
+
+func TestMapName(t *testing.T) {
+	rule := config.CarbonIngesterRuleConfiguration{
+		Pattern: `^stats\.([^.]+)\.(count|gauge)$`,
+		Mapping: &config.CarbonIngesterMappingConfiguration{
+			Name: "stats_$2",
+			Tags: map[string]string{"service": "$1"},
+		},
+	}
+
+	tags, ok, err := MapName(rule, []byte("stats.foo.count"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	name, ok := tags.Name()
+	require.True(t, ok)
+	assert.Equal(t, "stats_count", string(name))
+	service, ok := tags.Get([]byte("service"))
+	require.True(t, ok)
+	assert.Equal(t, "foo", string(service))
+
+	_, ok, err = MapName(rule, []byte("stats.foo.timer"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Rules without a mapping generate graphite tags.
+	rule.Mapping = nil
+	tags, ok, err = MapName(rule, []byte("stats.foo.count"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, mustGenerateTagsFromName(t, []byte("stats.foo.count")), tags)
+
+	// Mappings may not override the metric name tag.
+	rule.Mapping = &config.CarbonIngesterMappingConfiguration{
+		Name: "stats",
+		Tags: map[string]string{"__name__": "$1"},
+	}
+	_, _, err = MapName(rule, []byte("stats.foo.count"))
+	require.Error(t, err)
+}
+
 // byteConn implements the net.Conn interface so that we can test the handler without
 // going over the network.
 type byteConn struct {
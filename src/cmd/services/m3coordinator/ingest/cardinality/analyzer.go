@@ -0,0 +1,367 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const numSeriesShards = 64
+
+type analyzerOptions struct {
+	groupByLabels         []string
+	interval              time.Duration
+	seriesIdleTimeout     time.Duration
+	maxSeries             int
+	maxNewSeriesPerSecond float64
+	maxLimitedGroups      int
+}
+
+type analyzerMetrics struct {
+	newSeries     tally.Counter
+	limited       tally.Counter
+	evicted       tally.Counter
+	series        tally.Gauge
+	limitedGroups tally.Gauge
+}
+
+func newAnalyzerMetrics(scope tally.Scope) analyzerMetrics {
+	return analyzerMetrics{
+		newSeries:     scope.Counter("new-series"),
+		limited:       scope.Counter("limited-new-series"),
+		evicted:       scope.Counter("evicted-series"),
+		series:        scope.Gauge("series"),
+		limitedGroups: scope.Gauge("limited-groups"),
+	}
+}
+
+// groupKey identifies a group of series by either their metric name or the
+// value of a group by label.
+type groupKey struct {
+	metricName string
+	label      string
+	value      string
+}
+
+func (k groupKey) offender() Offender {
+	return Offender{
+		MetricName: k.metricName,
+		Label:      k.label,
+		LabelValue: k.value,
+	}
+}
+
+// groupState is the new series of a group in the current analysis interval,
+// along with the new series it may create if it is limited. The counts are
+// updated atomically so concurrent writes may slightly exceed the budget.
+type groupState struct {
+	newSeries int64
+	limited   int64
+	budget    int64
+	isLimited bool
+}
+
+func (s *groupState) exhausted() bool {
+	return s.isLimited && atomic.LoadInt64(&s.newSeries) >= s.budget
+}
+
+type rankedGroup struct {
+	key      groupKey
+	offender Offender
+}
+
+// seriesShard is the last write time of a shard of the series, sharded so
+// that writes of different series rarely contend.
+type seriesShard struct {
+	sync.Mutex
+
+	series map[uint64]time.Time
+}
+
+type analyzer struct {
+	// NB: lastAnalysisNanos is accessed atomically so must be 64-bit
+	// aligned.
+	lastAnalysisNanos int64
+
+	opts           analyzerOptions
+	groupByLabels  [][]byte
+	nowFn          clock.NowFn
+	maxShardSeries int
+	shards         [numSeriesShards]seriesShard
+	metrics        analyzerMetrics
+	closeCh        chan struct{}
+
+	// groupsLock guards the groups and offenders, which are replaced by
+	// each analysis.
+	groupsLock sync.RWMutex
+	groups     map[groupKey]*groupState
+	offenders  []Offender
+	closed     bool
+}
+
+func newAnalyzer(
+	opts analyzerOptions,
+	nowFn clock.NowFn,
+	iOpts instrument.Options,
+) *analyzer {
+	groupByLabels := make([][]byte, 0, len(opts.groupByLabels))
+	for _, label := range opts.groupByLabels {
+		groupByLabels = append(groupByLabels, []byte(label))
+	}
+	a := &analyzer{
+		opts:              opts,
+		groupByLabels:     groupByLabels,
+		nowFn:             nowFn,
+		lastAnalysisNanos: nowFn().UnixNano(),
+		metrics: newAnalyzerMetrics(
+			iOpts.MetricsScope().SubScope("ingest-cardinality")),
+		closeCh: make(chan struct{}),
+		groups:  make(map[groupKey]*groupState),
+	}
+	if opts.maxSeries > 0 {
+		a.maxShardSeries = int(math.Ceil(float64(opts.maxSeries) / numSeriesShards))
+	}
+	for i := range a.shards {
+		a.shards[i].series = make(map[uint64]time.Time)
+	}
+	return a
+}
+
+func (a *analyzer) Admit(tags models.Tags) error {
+	var (
+		id    = tags.HashedID()
+		now   = a.nowFn()
+		shard = &a.shards[id%numSeriesShards]
+	)
+
+	shard.Lock()
+	defer shard.Unlock()
+
+	if _, ok := shard.series[id]; ok {
+		shard.series[id] = now
+		return nil
+	}
+
+	var (
+		keys      = a.groupKeys(tags)
+		states    = a.groupStates(keys)
+		limitedBy *groupKey
+	)
+	for i, state := range states {
+		if state.exhausted() {
+			limitedBy = &keys[i]
+			break
+		}
+	}
+
+	if limitedBy != nil {
+		// Count the rejected series so that groups remain offenders while
+		// they keep attempting to create new series.
+		for _, state := range states {
+			atomic.AddInt64(&state.limited, 1)
+		}
+		a.metrics.limited.Inc(1)
+		nextAnalysis := time.Unix(0, atomic.LoadInt64(&a.lastAnalysisNanos)).
+			Add(a.opts.interval)
+		return &LimitedError{
+			Offender:   limitedBy.offender(),
+			Limit:      a.opts.maxNewSeriesPerSecond,
+			RetryAfter: nextAnalysis.Sub(now),
+		}
+	}
+
+	for _, state := range states {
+		atomic.AddInt64(&state.newSeries, 1)
+	}
+	if a.maxShardSeries > 0 && len(shard.series) >= a.maxShardSeries {
+		// Forget an arbitrary series to bound memory, it is counted as new
+		// again if written later.
+		for evict := range shard.series {
+			delete(shard.series, evict)
+			break
+		}
+		a.metrics.evicted.Inc(1)
+	}
+	shard.series[id] = now
+	a.metrics.newSeries.Inc(1)
+	return nil
+}
+
+// groupStates returns the states of the groups, creating those not yet
+// seen in the current analysis interval.
+func (a *analyzer) groupStates(keys []groupKey) []*groupState {
+	var (
+		states  = make([]*groupState, len(keys))
+		missing bool
+	)
+	a.groupsLock.RLock()
+	for i, key := range keys {
+		states[i] = a.groups[key]
+		missing = missing || states[i] == nil
+	}
+	a.groupsLock.RUnlock()
+	if !missing {
+		return states
+	}
+
+	a.groupsLock.Lock()
+	for i, key := range keys {
+		state, ok := a.groups[key]
+		if !ok {
+			state = &groupState{}
+			a.groups[key] = state
+		}
+		states[i] = state
+	}
+	a.groupsLock.Unlock()
+	return states
+}
+
+func (a *analyzer) TopOffenders(n int) []Offender {
+	a.groupsLock.RLock()
+	defer a.groupsLock.RUnlock()
+
+	if n <= 0 || n > len(a.offenders) {
+		n = len(a.offenders)
+	}
+	result := make([]Offender, n)
+	copy(result, a.offenders)
+	return result
+}
+
+func (a *analyzer) Close() {
+	a.groupsLock.Lock()
+	defer a.groupsLock.Unlock()
+
+	if a.closed {
+		return
+	}
+	a.closed = true
+	close(a.closeCh)
+}
+
+func (a *analyzer) run() {
+	ticker := time.NewTicker(a.opts.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.closeCh:
+			return
+		case <-ticker.C:
+			a.analyze(a.nowFn())
+		}
+	}
+}
+
+// analyze ranks the groups by their new series rate since the last
+// analysis and limits the worst offenders until the next analysis.
+func (a *analyzer) analyze(now time.Time) {
+	a.groupsLock.Lock()
+	lastAnalysis := time.Unix(0, atomic.LoadInt64(&a.lastAnalysisNanos))
+	elapsed := now.Sub(lastAnalysis).Seconds()
+	if elapsed <= 0 {
+		a.groupsLock.Unlock()
+		return
+	}
+	atomic.StoreInt64(&a.lastAnalysisNanos, now.UnixNano())
+
+	ranked := make([]rankedGroup, 0, len(a.groups))
+	for key, state := range a.groups {
+		offender := key.offender()
+		offender.NewSeriesPerSecond = float64(atomic.LoadInt64(&state.newSeries)+
+			atomic.LoadInt64(&state.limited)) / elapsed
+		ranked = append(ranked, rankedGroup{key: key, offender: offender})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].offender.NewSeriesPerSecond != ranked[j].offender.NewSeriesPerSecond {
+			return ranked[i].offender.NewSeriesPerSecond > ranked[j].offender.NewSeriesPerSecond
+		}
+		return groupKeyLess(ranked[i].key, ranked[j].key)
+	})
+
+	var (
+		groups    = make(map[groupKey]*groupState)
+		offenders = make([]Offender, 0, len(ranked))
+		budget    = int64(math.Ceil(a.opts.maxNewSeriesPerSecond * a.opts.interval.Seconds()))
+	)
+	for _, r := range ranked {
+		if a.opts.maxNewSeriesPerSecond > 0 &&
+			len(groups) < a.opts.maxLimitedGroups &&
+			r.offender.NewSeriesPerSecond > a.opts.maxNewSeriesPerSecond {
+			groups[r.key] = &groupState{isLimited: true, budget: budget}
+			r.offender.RateLimited = true
+		}
+		offenders = append(offenders, r.offender)
+	}
+	a.groups = groups
+	a.offenders = offenders
+	a.groupsLock.Unlock()
+
+	var numSeries int
+	for i := range a.shards {
+		shard := &a.shards[i]
+		shard.Lock()
+		for id, lastWrite := range shard.series {
+			if now.Sub(lastWrite) > a.opts.seriesIdleTimeout {
+				delete(shard.series, id)
+			}
+		}
+		numSeries += len(shard.series)
+		shard.Unlock()
+	}
+
+	a.metrics.series.Update(float64(numSeries))
+	a.metrics.limitedGroups.Update(float64(len(groups)))
+}
+
+func (a *analyzer) groupKeys(tags models.Tags) []groupKey {
+	keys := make([]groupKey, 0, 1+len(a.groupByLabels))
+	if name, ok := tags.Name(); ok {
+		keys = append(keys, groupKey{metricName: string(name)})
+	}
+	for _, label := range a.groupByLabels {
+		if value, ok := tags.Get(label); ok && len(value) > 0 {
+			keys = append(keys, groupKey{label: string(label), value: string(value)})
+		}
+	}
+	return keys
+}
+
+func groupKeyLess(a, b groupKey) bool {
+	if a.metricName != b.metricName {
+		return a.metricName < b.metricName
+	}
+	if a.label != b.label {
+		return a.label < b.label
+	}
+	return a.value < b.value
+}
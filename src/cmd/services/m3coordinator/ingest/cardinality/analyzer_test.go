@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTags(pairs ...string) models.Tags {
+	tags := models.NewTags(len(pairs)/2, models.NewTagOptions())
+	for i := 0; i < len(pairs); i += 2 {
+		tags = tags.AddTag(models.Tag{
+			Name:  []byte(pairs[i]),
+			Value: []byte(pairs[i+1]),
+		})
+	}
+	return tags
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Add(d time.Duration) time.Time {
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func newTestAnalyzer(opts analyzerOptions) (*analyzer, *testClock) {
+	clock := &testClock{now: time.Unix(1600000000, 0)}
+	if opts.interval == 0 {
+		opts.interval = 10 * time.Second
+	}
+	if opts.seriesIdleTimeout == 0 {
+		opts.seriesIdleTimeout = time.Hour
+	}
+	return newAnalyzer(opts, clock.Now, instrument.NewOptions()), clock
+}
+
+func TestAnalyzerTopOffenders(t *testing.T) {
+	a, clock := newTestAnalyzer(analyzerOptions{
+		groupByLabels: []string{"job"},
+	})
+
+	for i := 0; i < 30; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "requests",
+			"job", "api", "pod", fmt.Sprint(i))))
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "latency",
+			"job", "db", "pod", fmt.Sprint(i))))
+	}
+	// Writes to existing series do not count.
+	for i := 0; i < 10; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "latency",
+			"job", "db", "pod", fmt.Sprint(i))))
+	}
+
+	assert.Empty(t, a.TopOffenders(0))
+
+	a.analyze(clock.Add(10 * time.Second))
+	assert.Equal(t, []Offender{
+		{Label: "job", LabelValue: "api", NewSeriesPerSecond: 3},
+		{MetricName: "requests", NewSeriesPerSecond: 3},
+		{Label: "job", LabelValue: "db", NewSeriesPerSecond: 1},
+		{MetricName: "latency", NewSeriesPerSecond: 1},
+	}, a.TopOffenders(0))
+	assert.Len(t, a.TopOffenders(2), 2)
+
+	// Rates are reset each interval.
+	a.analyze(clock.Add(10 * time.Second))
+	assert.Empty(t, a.TopOffenders(0))
+}
+
+func TestAnalyzerRateLimit(t *testing.T) {
+	a, clock := newTestAnalyzer(analyzerOptions{
+		maxNewSeriesPerSecond: 0.5,
+		maxLimitedGroups:      1,
+	})
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "worst", "pod", fmt.Sprint(i))))
+	}
+	for i := 0; i < 10; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "second", "pod", fmt.Sprint(i))))
+	}
+
+	a.analyze(clock.Add(10 * time.Second))
+	offenders := a.TopOffenders(0)
+	require.Len(t, offenders, 2)
+	assert.True(t, offenders[0].RateLimited)
+	assert.False(t, offenders[1].RateLimited)
+
+	// The worst offender may create 5 new series in the next interval.
+	for i := 20; i < 25; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "worst", "pod", fmt.Sprint(i))))
+	}
+	err := a.Admit(testTags("__name__", "worst", "pod", "25"))
+	require.Error(t, err)
+	limited, ok := err.(*LimitedError)
+	require.True(t, ok)
+	assert.Equal(t, "worst", limited.Offender.MetricName)
+	assert.Equal(t, 10*time.Second, limited.RetryAfter)
+
+	// Existing series of the worst offender and new series of others are
+	// not limited.
+	require.NoError(t, a.Admit(testTags("__name__", "worst", "pod", "0")))
+	require.NoError(t, a.Admit(testTags("__name__", "second", "pod", "10")))
+
+	// The limit is lifted once the offender's rate drops.
+	a.analyze(clock.Add(10 * time.Second))
+	a.analyze(clock.Add(10 * time.Second))
+	require.NoError(t, a.Admit(testTags("__name__", "worst", "pod", "25")))
+}
+
+func TestAnalyzerForgetsIdleSeries(t *testing.T) {
+	a, clock := newTestAnalyzer(analyzerOptions{
+		seriesIdleTimeout: time.Minute,
+	})
+
+	require.NoError(t, a.Admit(testTags("__name__", "requests")))
+	a.analyze(clock.Add(2 * time.Minute))
+
+	require.NoError(t, a.Admit(testTags("__name__", "requests")))
+	a.analyze(clock.Add(10 * time.Second))
+	assert.Equal(t, []Offender{
+		{MetricName: "requests", NewSeriesPerSecond: 0.1},
+	}, a.TopOffenders(0))
+}
+
+func TestAnalyzerBoundsSeries(t *testing.T) {
+	a, clock := newTestAnalyzer(analyzerOptions{
+		maxSeries: numSeriesShards,
+	})
+
+	// Each shard remembers a single series.
+	for i := 0; i < 10*numSeriesShards; i++ {
+		require.NoError(t, a.Admit(testTags("__name__", "requests", "pod", fmt.Sprint(i))))
+	}
+
+	var numSeries int
+	for i := range a.shards {
+		require.True(t, len(a.shards[i].series) <= 1)
+		numSeries += len(a.shards[i].series)
+	}
+	assert.True(t, numSeries <= numSeriesShards)
+
+	a.analyze(clock.Add(10 * time.Second))
+	assert.Equal(t, []Offender{
+		{MetricName: "requests", NewSeriesPerSecond: numSeriesShards},
+	}, a.TopOffenders(0))
+}
+
+func TestAnalyzerConcurrentAdmit(t *testing.T) {
+	a, clock := newTestAnalyzer(analyzerOptions{
+		groupByLabels: []string{"job"},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				// Every series is written twice, only the first write is new.
+				pod := fmt.Sprintf("%d-%d", i, j/2)
+				assert.NoError(t, a.Admit(testTags("__name__", "requests",
+					"job", fmt.Sprint(i%2), "pod", pod)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	a.analyze(clock.Add(10 * time.Second))
+	assert.Equal(t, []Offender{
+		{MetricName: "requests", NewSeriesPerSecond: 40},
+		{Label: "job", LabelValue: "0", NewSeriesPerSecond: 20},
+		{Label: "job", LabelValue: "1", NewSeriesPerSecond: 20},
+	}, a.TopOffenders(0))
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultAnalysisInterval  = 10 * time.Second
+	defaultSeriesIdleTimeout = time.Hour
+	defaultMaxSeries         = 1000000
+	defaultMaxLimitedGroups  = 10
+)
+
+// Configuration is the configuration for detecting the groups of series
+// creating the most new series.
+type Configuration struct {
+	// GroupByLabels is the labels whose values group new series in addition
+	// to their metric name.
+	GroupByLabels []string `yaml:"groupByLabels"`
+
+	// AnalysisInterval is the interval over which new series rates are
+	// computed and offenders are ranked.
+	AnalysisInterval time.Duration `yaml:"analysisInterval"`
+
+	// SeriesIdleTimeout is how long a series is remembered after it was
+	// last written for the purpose of detecting new series.
+	SeriesIdleTimeout time.Duration `yaml:"seriesIdleTimeout"`
+
+	// MaxSeries bounds the number of series remembered, defaults to a
+	// million. Series forgotten to make room are counted as new series
+	// when next written.
+	MaxSeries int `yaml:"maxSeries"`
+
+	// RateLimit optionally limits the new series rate of the worst
+	// offenders.
+	RateLimit *RateLimitConfiguration `yaml:"rateLimit"`
+}
+
+// RateLimitConfiguration is the configuration for limiting the new series
+// rate of the worst offenders.
+type RateLimitConfiguration struct {
+	// MaxNewSeriesPerSecond is the new series rate above which a group is
+	// limited to this rate for the next analysis interval.
+	MaxNewSeriesPerSecond float64 `yaml:"maxNewSeriesPerSecond"`
+
+	// MaxLimitedGroups limits how many of the worst offenders are limited
+	// at once.
+	MaxLimitedGroups int `yaml:"maxLimitedGroups"`
+}
+
+// NewAnalyzer returns a new analyzer, which analyzes new series in the
+// background until closed.
+func (c Configuration) NewAnalyzer(
+	nowFn clock.NowFn,
+	iOpts instrument.Options,
+) Analyzer {
+	opts := analyzerOptions{
+		groupByLabels:     c.GroupByLabels,
+		interval:          defaultAnalysisInterval,
+		seriesIdleTimeout: defaultSeriesIdleTimeout,
+		maxSeries:         defaultMaxSeries,
+	}
+	if c.AnalysisInterval > 0 {
+		opts.interval = c.AnalysisInterval
+	}
+	if c.SeriesIdleTimeout > 0 {
+		opts.seriesIdleTimeout = c.SeriesIdleTimeout
+	}
+	if c.MaxSeries > 0 {
+		opts.maxSeries = c.MaxSeries
+	}
+	if rl := c.RateLimit; rl != nil && rl.MaxNewSeriesPerSecond > 0 {
+		opts.maxNewSeriesPerSecond = rl.MaxNewSeriesPerSecond
+		opts.maxLimitedGroups = defaultMaxLimitedGroups
+		if rl.MaxLimitedGroups > 0 {
+			opts.maxLimitedGroups = rl.MaxLimitedGroups
+		}
+	}
+
+	a := newAnalyzer(opts, nowFn, iOpts)
+	go a.run()
+	return a
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package cardinality detects the groups of series written to a coordinator
+// that create the most new series, optionally limiting their new series
+// rate.
+package cardinality
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// Offender is a group of series and its new series rate over the last
+// analysis interval. The group is either all series with a metric name, or
+// all series with a value of a group by label.
+type Offender struct {
+	MetricName         string  `json:"metricName,omitempty"`
+	Label              string  `json:"label,omitempty"`
+	LabelValue         string  `json:"labelValue,omitempty"`
+	NewSeriesPerSecond float64 `json:"newSeriesPerSecond"`
+	RateLimited        bool    `json:"rateLimited"`
+}
+
+// Analyzer tracks the rate at which groups of series create new series.
+type Analyzer interface {
+	// Admit records a write to a series, returning a LimitedError if the
+	// series is new and a group it belongs to has exceeded its limited new
+	// series rate.
+	Admit(tags models.Tags) error
+
+	// TopOffenders returns up to n of the groups with the highest new
+	// series rates over the last analysis interval, highest first.
+	TopOffenders(n int) []Offender
+
+	// Close stops analyzing new series.
+	Close()
+}
+
+// LimitedError is returned when a new series belongs to a rate limited
+// group.
+type LimitedError struct {
+	Offender Offender
+	Limit    float64
+	// RetryAfter is the time until the group's limit is next reevaluated.
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	group := fmt.Sprintf("metric %s", e.Offender.MetricName)
+	if e.Offender.Label != "" {
+		group = fmt.Sprintf("label %s=%s", e.Offender.Label, e.Offender.LabelValue)
+	}
+	return fmt.Sprintf("new series of %s limited to %v per second", group, e.Limit)
+}
+
+// GetLimitedError returns the LimitedError an error is or contains, if any.
+func GetLimitedError(err error) (*LimitedError, bool) {
+	for err != nil {
+		if limited, ok := err.(*LimitedError); ok {
+			return limited, true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return nil, false
+}
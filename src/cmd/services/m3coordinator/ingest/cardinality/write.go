@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+type downsamplerAndWriter struct {
+	ingest.DownsamplerAndWriter
+
+	analyzer Analyzer
+}
+
+// NewDownsamplerAndWriter returns a downsampler and writer that records the
+// series written with the given analyzer, rejecting the new series of rate
+// limited groups before passing them to the given downsampler and writer.
+// Rejected series return an invalid params error containing a LimitedError,
+// which GetLimitedError returns so that callers may ask clients to retry.
+func NewDownsamplerAndWriter(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	analyzer Analyzer,
+) ingest.DownsamplerAndWriter {
+	return &downsamplerAndWriter{
+		DownsamplerAndWriter: downsamplerAndWriter,
+		analyzer:             analyzer,
+	}
+}
+
+func (d *downsamplerAndWriter) Write(
+	ctx context.Context,
+	tags models.Tags,
+	datapoints ts.Datapoints,
+	unit xtime.Unit,
+	annotation []byte,
+	overrides ingest.WriteOptions,
+) error {
+	if err := d.analyzer.Admit(tags); err != nil {
+		return xerrors.NewInvalidParamsError(err)
+	}
+	return d.DownsamplerAndWriter.Write(ctx, tags, datapoints, unit,
+		annotation, overrides)
+}
+
+func (d *downsamplerAndWriter) WriteBatch(
+	ctx context.Context,
+	iter ingest.DownsampleAndWriteIter,
+	overrides ingest.WriteOptions,
+) ingest.BatchError {
+	var (
+		rejected map[int]struct{}
+		multiErr xerrors.MultiError
+	)
+	for i := 0; iter.Next(); i++ {
+		if err := d.analyzer.Admit(iter.Current().Tags); err != nil {
+			if rejected == nil {
+				rejected = make(map[int]struct{})
+			}
+			rejected[i] = struct{}{}
+			multiErr = multiErr.Add(xerrors.NewInvalidParamsError(err))
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return multiErr.Add(err)
+	}
+	if err := iter.Reset(); err != nil {
+		return multiErr.Add(err)
+	}
+
+	if rejected != nil {
+		iter = ingest.NewFilteredIter(iter, rejected)
+	}
+
+	if errs := d.DownsamplerAndWriter.WriteBatch(ctx, iter, overrides); errs != nil {
+		for _, err := range errs.Errors() {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	if multiErr.NumErrors() == 0 {
+		return nil
+	}
+	return multiErr
+}
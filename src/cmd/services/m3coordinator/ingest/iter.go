@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingest
+
+type filteredIter struct {
+	DownsampleAndWriteIter
+
+	idx  int
+	skip map[int]struct{}
+}
+
+// NewFilteredIter returns an iterator over the values of an iterator except
+// those at the given indexes.
+func NewFilteredIter(
+	iter DownsampleAndWriteIter,
+	skip map[int]struct{},
+) DownsampleAndWriteIter {
+	return &filteredIter{DownsampleAndWriteIter: iter, idx: -1, skip: skip}
+}
+
+func (i *filteredIter) Next() bool {
+	for i.DownsampleAndWriteIter.Next() {
+		i.idx++
+		if _, ok := i.skip[i.idx]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *filteredIter) Reset() error {
+	i.idx = -1
+	return i.DownsampleAndWriteIter.Reset()
+}
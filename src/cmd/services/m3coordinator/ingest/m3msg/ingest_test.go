@@ -65,6 +65,7 @@ func TestIngest(t *testing.T) {
 	id := newTestID(t, "__name__", "foo", "app", "bar")
 	metricNanos := int64(1234)
 	val := float64(1)
+	annotation := []byte("traceID")
 	sp := policy.MustParseStoragePolicy("1m:40d")
 	m := consumer.NewMockMessage(ctrl)
 	var wg sync.WaitGroup
@@ -72,14 +73,14 @@ func TestIngest(t *testing.T) {
 	callback := m3msg.NewProtobufCallback(m, protobuf.NewAggregatedDecoder(nil), &wg)
 
 	m.EXPECT().Ack()
-	ingester.Ingest(context.TODO(), id, metricNanos, 0, val, sp, callback)
+	ingester.Ingest(context.TODO(), id, metricNanos, 0, val, annotation, sp, callback)
 
 	for appender.cnt() != 1 {
 		time.Sleep(100 * time.Millisecond)
 	}
 
 	expected, err := storage.NewWriteQuery(storage.WriteQueryOptions{
-		Annotation: nil,
+		Annotation: annotation,
 		Attributes: storagemetadata.Attributes{
 			MetricsType: storagemetadata.AggregatedMetricsType,
 			Resolution:  time.Minute,
@@ -144,7 +145,7 @@ func TestIngestNonRetryableError(t *testing.T) {
 	callback := m3msg.NewProtobufCallback(m, protobuf.NewAggregatedDecoder(nil), &wg)
 
 	m.EXPECT().Ack()
-	ingester.Ingest(context.TODO(), id, metricNanos, 0, val, sp, callback)
+	ingester.Ingest(context.TODO(), id, metricNanos, 0, val, nil, sp, callback)
 
 	for appender.cntErr() != 1 {
 		time.Sleep(100 * time.Millisecond)
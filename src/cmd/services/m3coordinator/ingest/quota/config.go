@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultSeriesIdleTimeout = time.Hour
+	defaultMaxTenants        = 1000
+)
+
+// Configuration is the configuration for per-tenant ingest quotas. Zero or
+// negative quotas imply no limit. Quotas allow bursting up to a second's
+// worth of usage, and a single write request larger than that is admitted
+// when the tenant has not used its quota in the past second.
+type Configuration struct {
+	// TenantLabel identifies the tenant of each written series by the
+	// value of this label, series without the label and all series if it
+	// is not set are attributed to the tenant of the M3-Tenant header.
+	TenantLabel string `yaml:"tenantLabel"`
+
+	// MaxDatapointsPerSecond limits the datapoints each tenant may write
+	// per second.
+	MaxDatapointsPerSecond int `yaml:"maxDatapointsPerSecond"`
+
+	// MaxNewSeriesPerSecond limits the series each tenant may write per
+	// second that it has not written within the series idle timeout.
+	MaxNewSeriesPerSecond int `yaml:"maxNewSeriesPerSecond"`
+
+	// SeriesIdleTimeout is how long a series is remembered after it was
+	// last written for the purpose of detecting new series.
+	SeriesIdleTimeout time.Duration `yaml:"seriesIdleTimeout"`
+
+	// MaxTenants bounds the tenants whose quotas and metrics are tracked
+	// separately, defaults to 1000. Tenants seen beyond it share a single
+	// "_overflow" tenant unless their quotas are overridden. Tenants are
+	// forgotten once idle for the series idle timeout.
+	MaxTenants int `yaml:"maxTenants"`
+
+	// Overrides maps tenants to quotas that are used instead of the
+	// default quotas for that tenant.
+	Overrides map[string]TenantConfiguration `yaml:"overrides"`
+}
+
+// TenantConfiguration is the ingest quotas of a single tenant.
+type TenantConfiguration struct {
+	// MaxDatapointsPerSecond limits the datapoints the tenant may write
+	// per second.
+	MaxDatapointsPerSecond int `yaml:"maxDatapointsPerSecond"`
+
+	// MaxNewSeriesPerSecond limits the new series the tenant may write
+	// per second.
+	MaxNewSeriesPerSecond int `yaml:"maxNewSeriesPerSecond"`
+}
+
+// NewEnforcer returns a new ingest quota enforcer.
+func (c Configuration) NewEnforcer(
+	nowFn clock.NowFn,
+	iOpts instrument.Options,
+) Enforcer {
+	idleTimeout := defaultSeriesIdleTimeout
+	if c.SeriesIdleTimeout > 0 {
+		idleTimeout = c.SeriesIdleTimeout
+	}
+	maxTenants := defaultMaxTenants
+	if c.MaxTenants > 0 {
+		maxTenants = c.MaxTenants
+	}
+	return newEnforcer(c, idleTimeout, maxTenants, nowFn, iOpts)
+}
+
+func (c Configuration) tenantQuotas(tenant string) TenantConfiguration {
+	if override, ok := c.Overrides[tenant]; ok {
+		return override
+	}
+	return TenantConfiguration{
+		MaxDatapointsPerSecond: c.MaxDatapointsPerSecond,
+		MaxNewSeriesPerSecond:  c.MaxNewSeriesPerSecond,
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	datapointsQuota = "datapoints"
+	newSeriesQuota  = "new-series"
+
+	// overflowTenant is the tenant whose quotas and metrics are shared by
+	// the tenants seen beyond the max tenants.
+	overflowTenant = "_overflow"
+)
+
+type tenantMetrics struct {
+	datapoints         tally.Counter
+	newSeries          tally.Counter
+	rejectedDatapoints tally.Counter
+	rejectedNewSeries  tally.Counter
+}
+
+func newTenantMetrics(scope tally.Scope) tenantMetrics {
+	return tenantMetrics{
+		datapoints: scope.Counter("datapoints"),
+		newSeries:  scope.Counter("new-series"),
+		rejectedDatapoints: scope.Tagged(map[string]string{"quota": datapointsQuota}).
+			Counter("rejected"),
+		rejectedNewSeries: scope.Tagged(map[string]string{"quota": newSeriesQuota}).
+			Counter("rejected"),
+	}
+}
+
+// tokenBucket admits a limit of units per second on average, bursting up to
+// a second's worth of units. A request larger than a second's worth is
+// admitted once the bucket is full, leaving the bucket in debt until it
+// refills, so that such requests are not rejected indefinitely.
+type tokenBucket struct {
+	limit  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit int, now time.Time) tokenBucket {
+	return tokenBucket{limit: float64(limit), tokens: float64(limit), last: now}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.limit, b.tokens+elapsed.Seconds()*b.limit)
+		b.last = now
+	}
+}
+
+// allows returns whether n units can be taken from the bucket.
+func (b *tokenBucket) allows(n int) bool {
+	return n <= 0 || float64(n) <= b.tokens || b.tokens >= b.limit
+}
+
+func (b *tokenBucket) take(n int) {
+	b.tokens -= float64(n)
+}
+
+// retryAfter returns the time until n units can be taken from the bucket.
+func (b *tokenBucket) retryAfter(n int) time.Duration {
+	needed := math.Min(float64(n), b.limit) - b.tokens
+	if needed <= 0 {
+		return 0
+	}
+	return time.Duration(needed / b.limit * float64(time.Second))
+}
+
+// tenantState is the state of a tenant's quotas and their usage in the
+// current one second window, along with the last write time of each of its
+// series if its new series are limited.
+type tenantState struct {
+	quotas           TenantConfiguration
+	datapointsBucket tokenBucket
+	newSeriesBucket  tokenBucket
+	windowStart      time.Time
+	datapoints       int
+	newSeries        int
+	series           map[uint64]time.Time
+	lastWrite        time.Time
+	metrics          tenantMetrics
+}
+
+func (s *tenantState) roll(now time.Time) {
+	s.datapointsBucket.refill(now)
+	s.newSeriesBucket.refill(now)
+	if windowStart := now.Truncate(time.Second); windowStart.After(s.windowStart) {
+		s.windowStart = windowStart
+		s.datapoints = 0
+		s.newSeries = 0
+	}
+}
+
+// pendingWrites is the writes of a request attributed to a single tenant.
+type pendingWrites struct {
+	state      *tenantState
+	datapoints int
+	series     []uint64
+	newSeries  int
+	exceeded   *ExceededError
+}
+
+type enforcer struct {
+	sync.Mutex
+
+	cfg         Configuration
+	idleTimeout time.Duration
+	maxTenants  int
+	nowFn       clock.NowFn
+	scope       tally.Scope
+	tenants     map[string]*tenantState
+	// NB: tagged scopes are never released by tally, so the metrics of
+	// tenants are kept across eviction and bounded by the max tenants.
+	metrics   map[string]tenantMetrics
+	lastSweep time.Time
+}
+
+func newEnforcer(
+	cfg Configuration,
+	idleTimeout time.Duration,
+	maxTenants int,
+	nowFn clock.NowFn,
+	iOpts instrument.Options,
+) *enforcer {
+	return &enforcer{
+		cfg:         cfg,
+		idleTimeout: idleTimeout,
+		maxTenants:  maxTenants,
+		nowFn:       nowFn,
+		scope:       iOpts.MetricsScope().SubScope("ingest-quotas"),
+		tenants:     make(map[string]*tenantState),
+		metrics:     make(map[string]tenantMetrics),
+		lastSweep:   nowFn(),
+	}
+}
+
+func (e *enforcer) Admit(tenant string, writes []Write) ([]bool, error) {
+	// Resolve the tenant and ID of each series before taking the lock.
+	var (
+		pending      = make(map[string]*pendingWrites)
+		writeTenants = make([]*pendingWrites, 0, len(writes))
+	)
+	for _, w := range writes {
+		t := e.tenantOf(tenant, w.Tags)
+		p, ok := pending[t]
+		if !ok {
+			p = &pendingWrites{}
+			pending[t] = p
+		}
+		p.datapoints += w.Datapoints
+		p.series = append(p.series, w.Tags.HashedID())
+		writeTenants = append(writeTenants, p)
+	}
+
+	now := e.nowFn()
+
+	e.Lock()
+	defer e.Unlock()
+
+	e.maybeSweep(now)
+	var exceeded *ExceededError
+	for t, p := range pending {
+		s := e.tenant(t, now)
+		s.roll(now)
+		p.state = s
+		p.newSeries = e.countNewSeries(s, p.series, now)
+		p.exceeded = e.exceeded(t, p)
+		if p.exceeded == nil {
+			continue
+		}
+		// NB: return the error of the first tenant so that the error of a
+		// request is deterministic.
+		if exceeded == nil || t < exceeded.Tenant {
+			exceeded = p.exceeded
+		}
+	}
+
+	// Count the writes of the tenants within their quotas.
+	for _, p := range pending {
+		if p.exceeded != nil {
+			continue
+		}
+		s := p.state
+		s.datapointsBucket.take(p.datapoints)
+		s.newSeriesBucket.take(p.newSeries)
+		s.datapoints += p.datapoints
+		s.newSeries += p.newSeries
+		s.lastWrite = now
+		s.metrics.datapoints.Inc(int64(p.datapoints))
+		s.metrics.newSeries.Inc(int64(p.newSeries))
+		if s.series != nil {
+			for _, id := range p.series {
+				s.series[id] = now
+			}
+		}
+	}
+
+	admitted := make([]bool, len(writes))
+	for i, p := range writeTenants {
+		admitted[i] = p.exceeded == nil
+	}
+	if exceeded != nil {
+		return admitted, exceeded
+	}
+	return admitted, nil
+}
+
+// exceeded returns an error if the pending writes of a tenant exceed its
+// quotas, counting the rejected writes.
+func (e *enforcer) exceeded(tenant string, p *pendingWrites) *ExceededError {
+	s := p.state
+	if limit := s.quotas.MaxDatapointsPerSecond; limit > 0 &&
+		!s.datapointsBucket.allows(p.datapoints) {
+		s.metrics.rejectedDatapoints.Inc(int64(p.datapoints))
+		return &ExceededError{
+			Tenant:     tenant,
+			Quota:      datapointsQuota,
+			Limit:      limit,
+			RetryAfter: s.datapointsBucket.retryAfter(p.datapoints),
+		}
+	}
+	if limit := s.quotas.MaxNewSeriesPerSecond; limit > 0 &&
+		!s.newSeriesBucket.allows(p.newSeries) {
+		s.metrics.rejectedNewSeries.Inc(int64(p.newSeries))
+		return &ExceededError{
+			Tenant:     tenant,
+			Quota:      newSeriesQuota,
+			Limit:      limit,
+			RetryAfter: s.newSeriesBucket.retryAfter(p.newSeries),
+		}
+	}
+	return nil
+}
+
+func (e *enforcer) Usage() []TenantUsage {
+	now := e.nowFn()
+
+	e.Lock()
+	defer e.Unlock()
+
+	usage := make([]TenantUsage, 0, len(e.tenants))
+	for t, s := range e.tenants {
+		s.roll(now)
+		usage = append(usage, TenantUsage{
+			Tenant:                 t,
+			MaxDatapointsPerSecond: s.quotas.MaxDatapointsPerSecond,
+			MaxNewSeriesPerSecond:  s.quotas.MaxNewSeriesPerSecond,
+			Datapoints:             s.datapoints,
+			NewSeries:              s.newSeries,
+			ActiveSeries:           len(s.series),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Tenant < usage[j].Tenant
+	})
+	return usage
+}
+
+func (e *enforcer) tenantOf(tenant string, tags models.Tags) string {
+	if e.cfg.TenantLabel == "" {
+		return tenant
+	}
+	if value, ok := tags.Get([]byte(e.cfg.TenantLabel)); ok && len(value) > 0 {
+		return string(value)
+	}
+	return tenant
+}
+
+// tenant returns the state of a tenant, tenants seen beyond the max tenants
+// share the state of the overflow tenant unless their quotas are overridden.
+func (e *enforcer) tenant(tenant string, now time.Time) *tenantState {
+	if s, ok := e.tenants[tenant]; ok {
+		return s
+	}
+	if _, ok := e.cfg.Overrides[tenant]; !ok && len(e.tenants) >= e.maxTenants {
+		tenant = overflowTenant
+		if s, ok := e.tenants[tenant]; ok {
+			return s
+		}
+	}
+
+	quotas := e.cfg.tenantQuotas(tenant)
+	s := &tenantState{
+		quotas:           quotas,
+		datapointsBucket: newTokenBucket(quotas.MaxDatapointsPerSecond, now),
+		newSeriesBucket:  newTokenBucket(quotas.MaxNewSeriesPerSecond, now),
+		metrics:          e.tenantMetrics(tenant),
+	}
+	// NB: only remember the series of tenants whose new series are limited.
+	if s.quotas.MaxNewSeriesPerSecond > 0 {
+		s.series = make(map[uint64]time.Time)
+	}
+	e.tenants[tenant] = s
+	return s
+}
+
+func (e *enforcer) tenantMetrics(tenant string) tenantMetrics {
+	if m, ok := e.metrics[tenant]; ok {
+		return m
+	}
+	if _, ok := e.cfg.Overrides[tenant]; !ok && len(e.metrics) >= e.maxTenants {
+		tenant = overflowTenant
+		if m, ok := e.metrics[tenant]; ok {
+			return m
+		}
+	}
+	m := newTenantMetrics(e.scope.Tagged(map[string]string{"tenant": tenant}))
+	e.metrics[tenant] = m
+	return m
+}
+
+// countNewSeries returns the number of distinct series of the given IDs
+// that the tenant has not written within the series idle timeout.
+func (e *enforcer) countNewSeries(
+	s *tenantState,
+	ids []uint64,
+	now time.Time,
+) int {
+	if s.series == nil {
+		return 0
+	}
+
+	var (
+		newSeries int
+		seen      = make(map[uint64]struct{}, len(ids))
+	)
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		if lastWrite, ok := s.series[id]; !ok || now.Sub(lastWrite) > e.idleTimeout {
+			newSeries++
+		}
+	}
+	return newSeries
+}
+
+// maybeSweep forgets the series and tenants that have not been written
+// within the series idle timeout, at most once per idle timeout.
+func (e *enforcer) maybeSweep(now time.Time) {
+	if now.Sub(e.lastSweep) < e.idleTimeout {
+		return
+	}
+	e.lastSweep = now
+
+	for t, s := range e.tenants {
+		for id, lastWrite := range s.series {
+			if now.Sub(lastWrite) > e.idleTimeout {
+				delete(s.series, id)
+			}
+		}
+		// Forget tenants that have not written within the idle timeout,
+		// their quotas are full again by then.
+		if now.Sub(s.lastWrite) > e.idleTimeout && len(s.series) == 0 {
+			delete(e.tenants, t)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testWrite(datapoints int, pairs ...string) Write {
+	tags := models.NewTags(len(pairs)/2, models.NewTagOptions())
+	for i := 0; i < len(pairs); i += 2 {
+		tags = tags.AddTag(models.Tag{
+			Name:  []byte(pairs[i]),
+			Value: []byte(pairs[i+1]),
+		})
+	}
+	return Write{Tags: tags, Datapoints: datapoints}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func newTestEnforcer(cfg Configuration) (Enforcer, *testClock) {
+	clock := &testClock{now: time.Unix(1600000000, 0).Add(250 * time.Millisecond)}
+	return cfg.NewEnforcer(clock.Now, instrument.NewOptions()), clock
+}
+
+// admit admits the writes of a request, returning only the error.
+func admit(e Enforcer, tenant string, writes []Write) error {
+	_, err := e.Admit(tenant, writes)
+	return err
+}
+
+func requireExceeded(t *testing.T, err error, quota string) *ExceededError {
+	require.Error(t, err)
+	exceeded, ok := err.(*ExceededError)
+	require.True(t, ok)
+	assert.Equal(t, quota, exceeded.Quota)
+	return exceeded
+}
+
+func TestEnforcerDatapointsQuota(t *testing.T) {
+	e, clock := newTestEnforcer(Configuration{MaxDatapointsPerSecond: 10})
+
+	require.NoError(t, admit(e, "a", []Write{
+		testWrite(4, "__name__", "foo"),
+		testWrite(4, "__name__", "bar"),
+	}))
+
+	exceeded := requireExceeded(t,
+		admit(e, "a", []Write{testWrite(3, "__name__", "foo")}), datapointsQuota)
+	assert.Equal(t, "a", exceeded.Tenant)
+	assert.Equal(t, 10, exceeded.Limit)
+	assert.Equal(t, 100*time.Millisecond, exceeded.RetryAfter)
+
+	// Rejected writes are not counted and other tenants are unaffected.
+	require.NoError(t, admit(e, "a", []Write{testWrite(2, "__name__", "foo")}))
+	require.NoError(t, admit(e, "b", []Write{testWrite(10, "__name__", "foo")}))
+
+	// Quotas refill each second.
+	clock.now = clock.now.Add(time.Second)
+	require.NoError(t, admit(e, "a", []Write{testWrite(10, "__name__", "foo")}))
+}
+
+func TestEnforcerDatapointsQuotaBurst(t *testing.T) {
+	e, clock := newTestEnforcer(Configuration{MaxDatapointsPerSecond: 10})
+
+	// A request larger than the quota is admitted when the quota is unused,
+	// later requests are rejected until the excess is paid back.
+	require.NoError(t, admit(e, "a", []Write{testWrite(25, "__name__", "foo")}))
+	exceeded := requireExceeded(t,
+		admit(e, "a", []Write{testWrite(1, "__name__", "foo")}), datapointsQuota)
+	assert.Equal(t, 1600*time.Millisecond, exceeded.RetryAfter)
+
+	clock.now = clock.now.Add(2 * time.Second)
+	require.NoError(t, admit(e, "a", []Write{testWrite(5, "__name__", "foo")}))
+	requireExceeded(t,
+		admit(e, "a", []Write{testWrite(25, "__name__", "foo")}), datapointsQuota)
+}
+
+func TestEnforcerNewSeriesQuota(t *testing.T) {
+	e, clock := newTestEnforcer(Configuration{
+		MaxNewSeriesPerSecond: 2,
+		SeriesIdleTimeout:     time.Minute,
+	})
+
+	require.NoError(t, admit(e, "a", []Write{
+		testWrite(1, "__name__", "foo"),
+		testWrite(1, "__name__", "foo"),
+		testWrite(1, "__name__", "bar"),
+	}))
+	requireExceeded(t,
+		admit(e, "a", []Write{testWrite(1, "__name__", "baz")}), newSeriesQuota)
+
+	// Writes to known series are always admitted.
+	require.NoError(t, admit(e, "a", []Write{
+		testWrite(1, "__name__", "foo"),
+		testWrite(1, "__name__", "bar"),
+	}))
+
+	clock.now = clock.now.Add(time.Second)
+	require.NoError(t, admit(e, "a", []Write{testWrite(1, "__name__", "baz")}))
+
+	// Series written again after the idle timeout count as new.
+	clock.now = clock.now.Add(2 * time.Minute)
+	require.NoError(t, admit(e, "a", []Write{
+		testWrite(1, "__name__", "foo"),
+		testWrite(1, "__name__", "bar"),
+	}))
+	requireExceeded(t,
+		admit(e, "a", []Write{testWrite(1, "__name__", "baz")}), newSeriesQuota)
+
+	usage := e.Usage()
+	require.Equal(t, 1, len(usage))
+	assert.Equal(t, 2, usage[0].NewSeries)
+	// The sweep forgets series idle for longer than the timeout.
+	assert.Equal(t, 2, usage[0].ActiveSeries)
+}
+
+func TestEnforcerTenantLabelAndOverrides(t *testing.T) {
+	e, _ := newTestEnforcer(Configuration{
+		TenantLabel:            "tenant",
+		MaxDatapointsPerSecond: 5,
+		Overrides: map[string]TenantConfiguration{
+			"big": {MaxDatapointsPerSecond: 100},
+		},
+	})
+
+	require.NoError(t, admit(e, "default", []Write{
+		testWrite(50, "__name__", "foo", "tenant", "big"),
+		testWrite(5, "__name__", "foo", "tenant", "small"),
+		testWrite(5, "__name__", "foo"),
+	}))
+
+	// Only the writes of the tenant exceeding its quota are rejected.
+	admitted, err := e.Admit("default", []Write{
+		testWrite(1, "__name__", "bar", "tenant", "big"),
+		testWrite(1, "__name__", "bar", "tenant", "small"),
+		testWrite(1, "__name__", "baz", "tenant", "big"),
+	})
+	exceeded := requireExceeded(t, err, datapointsQuota)
+	assert.Equal(t, "small", exceeded.Tenant)
+	assert.Equal(t, []bool{true, false, true}, admitted)
+
+	assert.Equal(t, []TenantUsage{
+		{Tenant: "big", MaxDatapointsPerSecond: 100, Datapoints: 52},
+		{Tenant: "default", MaxDatapointsPerSecond: 5, Datapoints: 5},
+		{Tenant: "small", MaxDatapointsPerSecond: 5, Datapoints: 5},
+	}, e.Usage())
+}
+
+func TestEnforcerMaxTenants(t *testing.T) {
+	e, clock := newTestEnforcer(Configuration{
+		MaxDatapointsPerSecond: 10,
+		MaxTenants:             2,
+		SeriesIdleTimeout:      time.Minute,
+		Overrides: map[string]TenantConfiguration{
+			"big": {MaxDatapointsPerSecond: 100},
+		},
+	})
+
+	for _, tenant := range []string{"a", "b", "c", "d", "big"} {
+		require.NoError(t, admit(e, tenant, []Write{testWrite(5, "__name__", "foo")}))
+	}
+	// Tenants beyond the max share the quota of the overflow tenant, unless
+	// their quotas are overridden.
+	requireExceeded(t,
+		admit(e, "e", []Write{testWrite(1, "__name__", "foo")}), datapointsQuota)
+	assert.Equal(t, []TenantUsage{
+		{Tenant: overflowTenant, MaxDatapointsPerSecond: 10, Datapoints: 10},
+		{Tenant: "a", MaxDatapointsPerSecond: 10, Datapoints: 5},
+		{Tenant: "b", MaxDatapointsPerSecond: 10, Datapoints: 5},
+		{Tenant: "big", MaxDatapointsPerSecond: 100, Datapoints: 5},
+	}, e.Usage())
+
+	// Idle tenants are forgotten.
+	clock.now = clock.now.Add(2 * time.Minute)
+	require.NoError(t, admit(e, "e", []Write{testWrite(5, "__name__", "foo")}))
+	assert.Equal(t, []TenantUsage{
+		{Tenant: "e", MaxDatapointsPerSecond: 10, Datapoints: 5},
+	}, e.Usage())
+}
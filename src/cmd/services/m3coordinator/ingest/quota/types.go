@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quota enforces per-tenant quotas on the datapoints and new
+// series written to a coordinator.
+package quota
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+// Write is a write of a number of datapoints to a single series.
+type Write struct {
+	Tags       models.Tags
+	Datapoints int
+}
+
+// TenantUsage is the usage of a tenant's quotas in the current second.
+type TenantUsage struct {
+	Tenant                 string `json:"tenant"`
+	MaxDatapointsPerSecond int    `json:"maxDatapointsPerSecond"`
+	MaxNewSeriesPerSecond  int    `json:"maxNewSeriesPerSecond"`
+	Datapoints             int    `json:"datapoints"`
+	NewSeries              int    `json:"newSeries"`
+	ActiveSeries           int    `json:"activeSeries"`
+}
+
+// Enforcer enforces per-tenant ingest quotas.
+type Enforcer interface {
+	// Admit counts the writes of a request against the quotas of their
+	// tenants and returns whether each write was admitted. The writes of a
+	// tenant that would exceed one of its quotas are rejected together
+	// without being counted, while the writes of other tenants are still
+	// admitted, and an ExceededError is returned for one of the rejected
+	// tenants. The given tenant is the tenant of writes whose tenant is not
+	// identified by a label.
+	Admit(tenant string, writes []Write) ([]bool, error)
+
+	// Usage returns the quota usage of each tenant that has written,
+	// ordered by tenant.
+	Usage() []TenantUsage
+}
+
+// ExceededError is returned when writes would exceed a tenant's quota.
+type ExceededError struct {
+	Tenant string
+	Quota  string
+	Limit  int
+	// RetryAfter is the time until the tenant's quotas reset.
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("tenant %s exceeded quota of %d %s per second",
+		e.Tenant, e.Limit, e.Quota)
+}
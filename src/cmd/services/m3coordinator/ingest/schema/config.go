@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// UTF8Policy is the policy for the encoding of label names and values.
+type UTF8Policy string
+
+const (
+	// UTF8PolicyAllow allows any label names and values.
+	UTF8PolicyAllow UTF8Policy = "allow"
+	// UTF8PolicyValid requires label names and values to be valid UTF-8.
+	UTF8PolicyValid UTF8Policy = "valid"
+	// UTF8PolicyASCII requires label names and values to be printable ASCII.
+	UTF8PolicyASCII UTF8Policy = "ascii"
+)
+
+// Configuration is the configuration for the write schemas of namespaces.
+type Configuration struct {
+	// DryRun audits writes against the schemas of all namespaces without
+	// rejecting writes that violate them.
+	DryRun bool `yaml:"dryRun"`
+
+	// Namespaces maps namespace names to their write schemas, writes to
+	// namespaces without a schema are not validated.
+	Namespaces map[string]NamespaceConfiguration `yaml:"namespaces"`
+}
+
+// NamespaceConfiguration is the write schema of a single namespace.
+type NamespaceConfiguration struct {
+	// DryRun audits writes against the schema without rejecting writes
+	// that violate it.
+	DryRun bool `yaml:"dryRun"`
+
+	// MetricNamePattern is a regular expression the whole metric name must
+	// match.
+	MetricNamePattern string `yaml:"metricNamePattern"`
+
+	// AllowedLabelNames is the label names series may have besides the
+	// metric name, any label names are allowed if empty.
+	AllowedLabelNames []string `yaml:"allowedLabelNames"`
+
+	// MaxLabels limits the number of labels of a series, including the
+	// metric name. Zero implies no limit.
+	MaxLabels int `yaml:"maxLabels"`
+
+	// UTF8 is the policy for the encoding of label names and values,
+	// defaults to allow.
+	UTF8 UTF8Policy `yaml:"utf8"`
+
+	// MaxLabelValues limits the distinct values of the given labels seen
+	// within MaxLabelValuesTTL. The values are tracked by each coordinator
+	// instance, so the limit applies per instance rather than across all
+	// coordinators writing to the namespace.
+	MaxLabelValues map[string]int `yaml:"maxLabelValues"`
+
+	// MaxLabelValuesTTL is how long a label value is remembered after it
+	// was last written, defaults to an hour.
+	MaxLabelValuesTTL time.Duration `yaml:"maxLabelValuesTTL"`
+}
+
+// NewValidator returns a new write schema validator.
+func (c Configuration) NewValidator(
+	tagOpts models.TagOptions,
+	iOpts instrument.Options,
+) (Validator, error) {
+	scope := iOpts.MetricsScope().SubScope("ingest-schema")
+	v := &validator{
+		namespaces: make(map[string]*namespaceSchema, len(c.Namespaces)),
+		logger:     iOpts.Logger(),
+	}
+	for name, nsCfg := range c.Namespaces {
+		ns, err := newNamespaceSchema(name, nsCfg, c.DryRun || nsCfg.DryRun,
+			tagOpts, scope)
+		if err != nil {
+			return nil, fmt.Errorf("invalid write schema for namespace %s: %v",
+				name, err)
+		}
+		v.namespaces[name] = ns
+	}
+	return v, nil
+}
+
+func (p UTF8Policy) validate() error {
+	switch p {
+	case "", UTF8PolicyAllow, UTF8PolicyValid, UTF8PolicyASCII:
+		return nil
+	}
+	return fmt.Errorf("unknown utf8 policy %q, must be one of: %s, %s, %s",
+		p, UTF8PolicyAllow, UTF8PolicyValid, UTF8PolicyASCII)
+}
+
+func compileMetricNamePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	// Anchor the pattern so it must match the whole metric name.
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package schema validates the series written to a coordinator against
+// per-namespace write schemas.
+package schema
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	// MetricNameRule is violated by metric names not matching the
+	// namespace's metric name pattern.
+	MetricNameRule = "metric-name"
+	// LabelNameRule is violated by label names not allowed in the
+	// namespace.
+	LabelNameRule = "label-name"
+	// MaxLabelsRule is violated by series with too many labels.
+	MaxLabelsRule = "max-labels"
+	// UTF8Rule is violated by label names or values not allowed by the
+	// namespace's UTF-8 policy.
+	UTF8Rule = "utf8"
+	// LabelCardinalityRule is violated by new values of a label that has
+	// reached its maximum number of distinct values.
+	LabelCardinalityRule = "label-cardinality"
+)
+
+// Validator validates written series against the write schemas of
+// namespaces.
+type Validator interface {
+	// Validate validates a series written to a namespace, returning a
+	// ViolationError if the series violates the namespace's schema. Series
+	// written to namespaces without a schema, and violations of schemas in
+	// dry-run mode, are accepted.
+	Validate(namespace string, tags models.Tags) error
+}
+
+// ViolationError is returned when a series violates a namespace's schema.
+type ViolationError struct {
+	Namespace string
+	Rule      string
+	Message   string
+}
+
+func (e *ViolationError) Error() string {
+	return fmt.Sprintf("series violates %s rule of namespace %s write schema: %s",
+		e.Rule, e.Namespace, e.Message)
+}
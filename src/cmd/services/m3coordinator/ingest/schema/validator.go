@@ -0,0 +1,293 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultMaxLabelValuesTTL = time.Hour
+
+var rules = []string{
+	MetricNameRule,
+	LabelNameRule,
+	MaxLabelsRule,
+	UTF8Rule,
+	LabelCardinalityRule,
+}
+
+type ruleMetrics struct {
+	rejected tally.Counter
+	audited  tally.Counter
+}
+
+// labelValues is the distinct values recently seen of a label with a
+// limited number of distinct values. Values not seen for the TTL expire.
+type labelValues struct {
+	max        int
+	ttl        time.Duration
+	values     map[string]time.Time
+	nextExpiry time.Time
+}
+
+// has returns whether the value has been seen or there is room for it.
+func (l *labelValues) has(value []byte, now time.Time) bool {
+	if _, ok := l.values[string(value)]; ok {
+		return true
+	}
+	if len(l.values) < l.max {
+		return true
+	}
+	l.expire(now)
+	return len(l.values) < l.max
+}
+
+// add remembers the value as seen now.
+func (l *labelValues) add(value []byte, now time.Time) {
+	if len(l.values) == 0 {
+		l.nextExpiry = now.Add(l.ttl)
+	}
+	l.values[string(value)] = now
+}
+
+// expire forgets the values not seen for the TTL, at most once per TTL.
+func (l *labelValues) expire(now time.Time) {
+	if now.Before(l.nextExpiry) {
+		return
+	}
+
+	oldest := now
+	for value, seen := range l.values {
+		if !now.Before(seen.Add(l.ttl)) {
+			delete(l.values, value)
+			continue
+		}
+		if seen.Before(oldest) {
+			oldest = seen
+		}
+	}
+	l.nextExpiry = oldest.Add(l.ttl)
+}
+
+type namespaceSchema struct {
+	name              string
+	dryRun            bool
+	metricName        []byte
+	metricNamePattern *regexp.Regexp
+	allowedLabelNames map[string]struct{}
+	maxLabels         int
+	utf8              UTF8Policy
+	metrics           map[string]ruleMetrics
+	nowFn             func() time.Time
+
+	// labelValuesLock guards the values of all labels so the values of a
+	// series are only remembered once all of them are admitted.
+	labelValuesLock sync.Mutex
+	labelValues     map[string]*labelValues
+}
+
+func newNamespaceSchema(
+	name string,
+	cfg NamespaceConfiguration,
+	dryRun bool,
+	tagOpts models.TagOptions,
+	scope tally.Scope,
+) (*namespaceSchema, error) {
+	if err := cfg.UTF8.validate(); err != nil {
+		return nil, err
+	}
+
+	pattern, err := compileMetricNamePattern(cfg.MetricNamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metric name pattern: %v", err)
+	}
+
+	ns := &namespaceSchema{
+		name:              name,
+		dryRun:            dryRun,
+		metricName:        tagOpts.MetricName(),
+		metricNamePattern: pattern,
+		maxLabels:         cfg.MaxLabels,
+		utf8:              cfg.UTF8,
+		metrics:           make(map[string]ruleMetrics, len(rules)),
+		nowFn:             time.Now,
+		labelValues:       make(map[string]*labelValues, len(cfg.MaxLabelValues)),
+	}
+
+	if len(cfg.AllowedLabelNames) > 0 {
+		ns.allowedLabelNames = make(map[string]struct{}, len(cfg.AllowedLabelNames))
+		for _, labelName := range cfg.AllowedLabelNames {
+			ns.allowedLabelNames[labelName] = struct{}{}
+		}
+	}
+
+	ttl := cfg.MaxLabelValuesTTL
+	if ttl < 0 {
+		return nil, fmt.Errorf("max label values TTL must not be negative")
+	}
+	if ttl == 0 {
+		ttl = defaultMaxLabelValuesTTL
+	}
+	for labelName, max := range cfg.MaxLabelValues {
+		if max <= 0 {
+			return nil, fmt.Errorf("max values of label %s must be positive", labelName)
+		}
+		ns.labelValues[labelName] = &labelValues{
+			max:    max,
+			ttl:    ttl,
+			values: make(map[string]time.Time),
+		}
+	}
+
+	for _, rule := range rules {
+		ruleScope := scope.Tagged(map[string]string{
+			"namespace": name,
+			"rule":      rule,
+		})
+		ns.metrics[rule] = ruleMetrics{
+			rejected: ruleScope.Counter("rejected"),
+			audited:  ruleScope.Counter("audited"),
+		}
+	}
+
+	return ns, nil
+}
+
+// violation returns the first rule the series violates, if any.
+func (s *namespaceSchema) violation(tags models.Tags) (string, string) {
+	if s.maxLabels > 0 && tags.Len() > s.maxLabels {
+		return MaxLabelsRule, fmt.Sprintf("%d labels exceeds max of %d",
+			tags.Len(), s.maxLabels)
+	}
+
+	for _, tag := range tags.Tags {
+		if !s.validEncoding(tag.Name) || !s.validEncoding(tag.Value) {
+			return UTF8Rule, fmt.Sprintf("name or value of label %q not allowed by %s policy",
+				tag.Name, s.utf8)
+		}
+
+		if string(tag.Name) == string(s.metricName) {
+			if s.metricNamePattern != nil && !s.metricNamePattern.Match(tag.Value) {
+				return MetricNameRule, fmt.Sprintf("metric name %q does not match %s",
+					tag.Value, s.metricNamePattern.String())
+			}
+			continue
+		}
+
+		if s.allowedLabelNames != nil {
+			if _, ok := s.allowedLabelNames[string(tag.Name)]; !ok {
+				return LabelNameRule, fmt.Sprintf("label %q not allowed", tag.Name)
+			}
+		}
+	}
+
+	// Check cardinality last so that values of series rejected by the
+	// other rules are not remembered.
+	return s.admitLabelValues(tags)
+}
+
+// admitLabelValues returns whether the series has a new value of a label
+// that has reached its maximum number of distinct values. The values of the
+// series are only remembered if all of them are admitted.
+func (s *namespaceSchema) admitLabelValues(tags models.Tags) (string, string) {
+	if len(s.labelValues) == 0 {
+		return "", ""
+	}
+
+	s.labelValuesLock.Lock()
+	defer s.labelValuesLock.Unlock()
+
+	now := s.nowFn()
+	for _, tag := range tags.Tags {
+		values, ok := s.labelValues[string(tag.Name)]
+		if !ok {
+			continue
+		}
+		if !values.has(tag.Value, now) {
+			return LabelCardinalityRule, fmt.Sprintf(
+				"label %q exceeds max of %d distinct values", tag.Name, values.max)
+		}
+	}
+
+	for _, tag := range tags.Tags {
+		if values, ok := s.labelValues[string(tag.Name)]; ok {
+			values.add(tag.Value, now)
+		}
+	}
+	return "", ""
+}
+
+func (s *namespaceSchema) validEncoding(b []byte) bool {
+	switch s.utf8 {
+	case UTF8PolicyValid:
+		return utf8.Valid(b)
+	case UTF8PolicyASCII:
+		for _, c := range b {
+			if c < ' ' || c > '~' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type validator struct {
+	namespaces map[string]*namespaceSchema
+	logger     *zap.Logger
+}
+
+func (v *validator) Validate(namespace string, tags models.Tags) error {
+	ns, ok := v.namespaces[namespace]
+	if !ok {
+		return nil
+	}
+
+	rule, message := ns.violation(tags)
+	if rule == "" {
+		return nil
+	}
+
+	if ns.dryRun {
+		ns.metrics[rule].audited.Inc(1)
+		if ce := v.logger.Check(zapcore.DebugLevel, "series violates write schema"); ce != nil {
+			ce.Write(zap.String("namespace", namespace), zap.String("rule", rule),
+				zap.String("message", message), zap.String("tags", tags.String()))
+		}
+		return nil
+	}
+
+	ns.metrics[rule].rejected.Inc(1)
+	return &ViolationError{
+		Namespace: namespace,
+		Rule:      rule,
+		Message:   message,
+	}
+}
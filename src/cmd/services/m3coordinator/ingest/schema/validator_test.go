@@ -0,0 +1,230 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func testTags(pairs ...string) models.Tags {
+	tags := models.NewTags(len(pairs)/2, models.NewTagOptions())
+	for i := 0; i < len(pairs); i += 2 {
+		tags = tags.AddTag(models.Tag{
+			Name:  []byte(pairs[i]),
+			Value: []byte(pairs[i+1]),
+		})
+	}
+	return tags
+}
+
+func newTestValidator(
+	t *testing.T,
+	cfg Configuration,
+) (Validator, tally.TestScope) {
+	scope := tally.NewTestScope("", nil)
+	v, err := cfg.NewValidator(models.NewTagOptions(),
+		instrument.NewOptions().SetMetricsScope(scope))
+	require.NoError(t, err)
+	return v, scope
+}
+
+func requireViolation(t *testing.T, err error, rule string) {
+	require.Error(t, err)
+	violation, ok := err.(*ViolationError)
+	require.True(t, ok)
+	assert.Equal(t, rule, violation.Rule)
+	assert.Equal(t, "default", violation.Namespace)
+}
+
+func TestValidatorRules(t *testing.T) {
+	v, _ := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {
+				MetricNamePattern: "[a-z_]+_total",
+				AllowedLabelNames: []string{"job", "instance", "env"},
+				MaxLabels:         3,
+				UTF8:              UTF8PolicyASCII,
+			},
+		},
+	})
+
+	require.NoError(t, v.Validate("default",
+		testTags("__name__", "requests_total", "job", "api")))
+
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "requests", "job", "api")), MetricNameRule)
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "requests_total_x", "job", "api")), MetricNameRule)
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "requests_total", "pod", "a")), LabelNameRule)
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "requests_total", "job", "api", "env", "prod",
+			"instance", "a")), MaxLabelsRule)
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "requests_total", "job", "apï")), UTF8Rule)
+
+	// Namespaces without a schema are not validated.
+	require.NoError(t, v.Validate("other", testTags("__name__", "requests")))
+}
+
+func TestValidatorUTF8Policies(t *testing.T) {
+	v, _ := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {UTF8: UTF8PolicyValid},
+			"other":   {},
+		},
+	})
+
+	require.NoError(t, v.Validate("default", testTags("__name__", "café")))
+	requireViolation(t, v.Validate("default", testTags("__name__", "caf\xff")),
+		UTF8Rule)
+	require.NoError(t, v.Validate("other", testTags("__name__", "caf\xff")))
+}
+
+func TestValidatorLabelCardinality(t *testing.T) {
+	v, scope := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {
+				AllowedLabelNames: []string{"pod"},
+				MaxLabelValues:    map[string]int{"pod": 2},
+			},
+		},
+	})
+
+	require.NoError(t, v.Validate("default", testTags("__name__", "a", "pod", "1")))
+	require.NoError(t, v.Validate("default", testTags("__name__", "b", "pod", "2")))
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "a", "pod", "3")), LabelCardinalityRule)
+
+	// Values of series rejected by another rule are not remembered, and
+	// values that have been seen are still accepted.
+	requireViolation(t, v.Validate("default",
+		testTags("__name__", "c", "pod", "4", "job", "api")), LabelNameRule)
+	require.NoError(t, v.Validate("default", testTags("__name__", "c", "pod", "1")))
+
+	counters := scope.Snapshot().Counters()
+	rejected, ok := counters["ingest-schema.rejected+namespace=default,rule=label-cardinality"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), rejected.Value())
+}
+
+func TestValidatorLabelCardinalityOnlyRemembersAdmittedSeries(t *testing.T) {
+	v, _ := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {
+				MaxLabelValues: map[string]int{"pod": 2, "job": 1},
+			},
+		},
+	})
+
+	require.NoError(t, v.Validate("default", testTags("pod", "1", "job", "api")))
+
+	// The new pod value is not remembered since the job value is rejected.
+	requireViolation(t, v.Validate("default",
+		testTags("pod", "2", "job", "db")), LabelCardinalityRule)
+	require.NoError(t, v.Validate("default", testTags("pod", "3", "job", "api")))
+	requireViolation(t, v.Validate("default",
+		testTags("pod", "2", "job", "api")), LabelCardinalityRule)
+}
+
+func TestValidatorLabelCardinalityExpiresValues(t *testing.T) {
+	v, _ := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {
+				MaxLabelValues:    map[string]int{"pod": 2},
+				MaxLabelValuesTTL: time.Minute,
+			},
+		},
+	})
+
+	now := time.Now()
+	v.(*validator).namespaces["default"].nowFn = func() time.Time {
+		return now
+	}
+
+	require.NoError(t, v.Validate("default", testTags("pod", "1")))
+	now = now.Add(30 * time.Second)
+	require.NoError(t, v.Validate("default", testTags("pod", "2")))
+	requireViolation(t, v.Validate("default",
+		testTags("pod", "3")), LabelCardinalityRule)
+
+	// Only the first value has not been seen for the TTL.
+	now = now.Add(45 * time.Second)
+	require.NoError(t, v.Validate("default", testTags("pod", "3")))
+	requireViolation(t, v.Validate("default",
+		testTags("pod", "1")), LabelCardinalityRule)
+
+	// Seeing a value again extends its TTL.
+	now = now.Add(30 * time.Second)
+	require.NoError(t, v.Validate("default", testTags("pod", "3")))
+	now = now.Add(45 * time.Second)
+	require.NoError(t, v.Validate("default", testTags("pod", "1")))
+	requireViolation(t, v.Validate("default",
+		testTags("pod", "4")), LabelCardinalityRule)
+}
+
+func TestValidatorDryRun(t *testing.T) {
+	v, scope := newTestValidator(t, Configuration{
+		DryRun: true,
+		Namespaces: map[string]NamespaceConfiguration{
+			"default": {
+				MaxLabels:      1,
+				MaxLabelValues: map[string]int{"__name__": 1},
+			},
+		},
+	})
+
+	require.NoError(t, v.Validate("default", testTags("__name__", "a", "job", "api")))
+	require.NoError(t, v.Validate("default", testTags("__name__", "a")))
+	require.NoError(t, v.Validate("default", testTags("__name__", "b")))
+
+	counters := scope.Snapshot().Counters()
+	for _, rule := range []string{MaxLabelsRule, LabelCardinalityRule} {
+		audited, ok := counters["ingest-schema.audited+namespace=default,rule="+rule]
+		require.True(t, ok)
+		assert.Equal(t, int64(1), audited.Value(), rule)
+		rejected, ok := counters["ingest-schema.rejected+namespace=default,rule="+rule]
+		require.True(t, ok)
+		assert.Equal(t, int64(0), rejected.Value(), rule)
+	}
+}
+
+func TestConfigurationInvalid(t *testing.T) {
+	for _, nsCfg := range []NamespaceConfiguration{
+		{UTF8: "latin1"},
+		{MetricNamePattern: "("},
+		{MaxLabelValues: map[string]int{"pod": 0}},
+		{MaxLabelValues: map[string]int{"pod": 1}, MaxLabelValuesTTL: -time.Second},
+	} {
+		_, err := Configuration{
+			Namespaces: map[string]NamespaceConfiguration{"default": nsCfg},
+		}.NewValidator(models.NewTagOptions(), instrument.NewOptions())
+		require.Error(t, err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package schema
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/m3"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var unaggregatedStoragePolicy = policy.NewStoragePolicy(0, xtime.Unit(0), 0)
+
+// NamespaceResolver returns the name of the namespace written to with a
+// storage policy.
+type NamespaceResolver func(p policy.StoragePolicy) (string, bool)
+
+// NewClustersNamespaceResolver returns a namespace resolver for the
+// namespaces of M3DB clusters.
+func NewClustersNamespaceResolver(clusters m3.Clusters) NamespaceResolver {
+	return func(p policy.StoragePolicy) (string, bool) {
+		if p == unaggregatedStoragePolicy {
+			return clusters.UnaggregatedClusterNamespace().NamespaceID().String(), true
+		}
+		ns, ok := clusters.AggregatedClusterNamespace(m3.RetentionResolution{
+			Resolution: p.Resolution().Window,
+			Retention:  p.Retention().Duration(),
+		})
+		if !ok {
+			return "", false
+		}
+		return ns.NamespaceID().String(), true
+	}
+}
+
+type downsamplerAndWriter struct {
+	ingest.DownsamplerAndWriter
+
+	validator Validator
+	resolver  NamespaceResolver
+}
+
+// NewDownsamplerAndWriter returns a downsampler and writer that rejects
+// series violating the write schemas of the namespaces they are written
+// to before passing them to the given downsampler and writer. Series are
+// validated against the unaggregated namespace, or the namespaces of the
+// overridden storage policies if the write overrides them, and series
+// rejected there are not downsampled either.
+func NewDownsamplerAndWriter(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	validator Validator,
+	resolver NamespaceResolver,
+) ingest.DownsamplerAndWriter {
+	return &downsamplerAndWriter{
+		DownsamplerAndWriter: downsamplerAndWriter,
+		validator:            validator,
+		resolver:             resolver,
+	}
+}
+
+func (d *downsamplerAndWriter) Write(
+	ctx context.Context,
+	tags models.Tags,
+	datapoints ts.Datapoints,
+	unit xtime.Unit,
+	annotation []byte,
+	overrides ingest.WriteOptions,
+) error {
+	if err := d.validate(d.namespaces(overrides), tags); err != nil {
+		return err
+	}
+	return d.DownsamplerAndWriter.Write(ctx, tags, datapoints, unit,
+		annotation, overrides)
+}
+
+func (d *downsamplerAndWriter) WriteBatch(
+	ctx context.Context,
+	iter ingest.DownsampleAndWriteIter,
+	overrides ingest.WriteOptions,
+) ingest.BatchError {
+	var (
+		namespaces = d.namespaces(overrides)
+		rejected   map[int]struct{}
+		multiErr   xerrors.MultiError
+	)
+	for i := 0; iter.Next(); i++ {
+		if err := d.validate(namespaces, iter.Current().Tags); err != nil {
+			if rejected == nil {
+				rejected = make(map[int]struct{})
+			}
+			rejected[i] = struct{}{}
+			multiErr = multiErr.Add(err)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return multiErr.Add(err)
+	}
+	if err := iter.Reset(); err != nil {
+		return multiErr.Add(err)
+	}
+
+	if rejected != nil {
+		iter = ingest.NewFilteredIter(iter, rejected)
+	}
+
+	if errs := d.DownsamplerAndWriter.WriteBatch(ctx, iter, overrides); errs != nil {
+		for _, err := range errs.Errors() {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	if multiErr.NumErrors() == 0 {
+		return nil
+	}
+	return multiErr
+}
+
+// namespaces returns the namespaces written to directly with the given
+// write options.
+func (d *downsamplerAndWriter) namespaces(overrides ingest.WriteOptions) []string {
+	policies := []policy.StoragePolicy{unaggregatedStoragePolicy}
+	if overrides.WriteOverride && len(overrides.WriteStoragePolicies) > 0 {
+		policies = overrides.WriteStoragePolicies
+	}
+
+	namespaces := make([]string, 0, len(policies))
+	for _, p := range policies {
+		if ns, ok := d.resolver(p); ok {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+func (d *downsamplerAndWriter) validate(namespaces []string, tags models.Tags) error {
+	for _, ns := range namespaces {
+		if err := d.validator.Validate(ns, tags); err != nil {
+			return xerrors.NewInvalidParamsError(err)
+		}
+	}
+	return nil
+}
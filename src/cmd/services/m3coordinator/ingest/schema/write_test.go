@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package schema
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAggregatedStoragePolicy = policy.NewStoragePolicy(time.Minute,
+	xtime.Second, 48*time.Hour)
+
+func testResolver(p policy.StoragePolicy) (string, bool) {
+	switch p {
+	case unaggregatedStoragePolicy:
+		return "default", true
+	case testAggregatedStoragePolicy:
+		return "aggregated", true
+	}
+	return "", false
+}
+
+func newTestDownsamplerAndWriter(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (ingest.DownsamplerAndWriter, *ingest.MockDownsamplerAndWriter) {
+	v, _ := newTestValidator(t, Configuration{
+		Namespaces: map[string]NamespaceConfiguration{
+			"default":    {MetricNamePattern: "valid.*"},
+			"aggregated": {MaxLabels: 1},
+		},
+	})
+	inner := ingest.NewMockDownsamplerAndWriter(ctrl)
+	return NewDownsamplerAndWriter(inner, v, testResolver), inner
+}
+
+func TestDownsamplerAndWriterWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, inner := newTestDownsamplerAndWriter(t, ctrl)
+
+	valid := testTags("__name__", "valid", "job", "api")
+	inner.EXPECT().
+		Write(gomock.Any(), valid, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	require.NoError(t, d.Write(context.Background(), valid, nil, xtime.Second,
+		nil, ingest.WriteOptions{}))
+
+	err := d.Write(context.Background(), testTags("__name__", "invalid"), nil,
+		xtime.Second, nil, ingest.WriteOptions{})
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+
+	// Writes with overridden storage policies are validated against the
+	// namespaces of those policies.
+	err = d.Write(context.Background(), valid, nil, xtime.Second, nil,
+		ingest.WriteOptions{
+			WriteOverride:        true,
+			WriteStoragePolicies: []policy.StoragePolicy{testAggregatedStoragePolicy},
+		})
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+}
+
+type testIter struct {
+	values []ingest.IterValue
+	idx    int
+}
+
+func newTestIter(values ...ingest.IterValue) *testIter {
+	return &testIter{values: values, idx: -1}
+}
+
+func (i *testIter) Next() bool {
+	i.idx++
+	return i.idx < len(i.values)
+}
+
+func (i *testIter) Current() ingest.IterValue      { return i.values[i.idx] }
+func (i *testIter) Reset() error                   { i.idx = -1; return nil }
+func (i *testIter) Error() error                   { return nil }
+func (i *testIter) SetCurrentMetadata(ts.Metadata) {}
+
+func TestDownsamplerAndWriterWriteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, inner := newTestDownsamplerAndWriter(t, ctrl)
+
+	iter := newTestIter(
+		ingest.IterValue{Tags: testTags("__name__", "valid_a")},
+		ingest.IterValue{Tags: testTags("__name__", "invalid")},
+		ingest.IterValue{Tags: testTags("__name__", "valid_b")},
+	)
+
+	var written []string
+	inner.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			// Iterate twice to ensure resets skip rejected values too.
+			for pass := 0; pass < 2; pass++ {
+				for iter.Next() {
+					name, _ := iter.Current().Tags.Name()
+					written = append(written, string(name))
+				}
+				require.NoError(t, iter.Reset())
+			}
+			return nil
+		})
+
+	errs := d.WriteBatch(context.Background(), iter, ingest.WriteOptions{})
+	require.Error(t, errs)
+	require.Len(t, errs.Errors(), 1)
+	assert.True(t, xerrors.IsInvalidParams(errs.Errors()[0]))
+	assert.Equal(t, []string{"valid_a", "valid_b", "valid_a", "valid_b"}, written)
+}
@@ -0,0 +1,405 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ingestwavefront implements an ingester for metrics sent over TCP
+// in the Wavefront data format.
+package ingestwavefront
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/rate"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	m3xserver "github.com/m3db/m3/src/x/server"
+	xsync "github.com/m3db/m3/src/x/sync"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	sourceTagName = "source"
+	hostTagName   = "host"
+
+	// maxLineLength is the maximum length of a line, longer lines are
+	// dropped along with the rest of the connection.
+	maxLineLength = 64 * 1024
+
+	// minMillisecondsTimestamp is the smallest timestamp interpreted as
+	// milliseconds rather than seconds since the epoch, which would be a
+	// date in the year 33658.
+	minMillisecondsTimestamp = 1e12
+)
+
+var (
+	errIOptsMustBeSet      = errors.New("wavefront ingester options: instrument options must be set")
+	errWorkerPoolMustBeSet = errors.New("wavefront ingester options: worker pool must be set")
+	errMissingValue        = errors.New("missing metric value")
+	errMissingSource       = errors.New("missing source tag")
+	errUnterminatedQuote   = errors.New("unterminated quote")
+)
+
+// Options configures the ingester.
+type Options struct {
+	InstrumentOptions instrument.Options
+	WorkerPool        xsync.PooledWorkerPool
+	// MaxDatapointsPerSecond limits the datapoints ingested per second across
+	// all connections, datapoints over the limit are dropped. Zero disables
+	// the limit.
+	MaxDatapointsPerSecond int64
+	// NowFn returns the time assigned to datapoints without a timestamp.
+	NowFn clock.NowFn
+}
+
+// Validate validates the options struct.
+func (o *Options) Validate() error {
+	if o.InstrumentOptions == nil {
+		return errIOptsMustBeSet
+	}
+
+	if o.WorkerPool == nil {
+		return errWorkerPoolMustBeSet
+	}
+
+	return nil
+}
+
+// NewIngester returns an ingester for metrics in the Wavefront data format,
+// lines of the form "<metricName> <metricValue> [<timestamp>]
+// source=<source> [pointTags]". The metric name, source and point tags are
+// written as labels, with the host tag used as the source if the source is
+// not set.
+func NewIngester(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	opts Options,
+) (m3xserver.Handler, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	nowFn := opts.NowFn
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+
+	var limiter *rate.Limiter
+	if opts.MaxDatapointsPerSecond > 0 {
+		limiter = rate.NewLimiter(opts.MaxDatapointsPerSecond, nowFn)
+	}
+
+	return &ingester{
+		downsamplerAndWriter: downsamplerAndWriter,
+		opts:                 opts,
+		logger:               opts.InstrumentOptions.Logger(),
+		tagOpts:              models.NewTagOptions(),
+		nowFn:                nowFn,
+		limiter:              limiter,
+		metrics: newIngesterMetrics(
+			opts.InstrumentOptions.MetricsScope()),
+	}, nil
+}
+
+type ingester struct {
+	downsamplerAndWriter ingest.DownsamplerAndWriter
+	opts                 Options
+	logger               *zap.Logger
+	tagOpts              models.TagOptions
+	nowFn                clock.NowFn
+	limiter              *rate.Limiter
+	metrics              ingesterMetrics
+}
+
+func (i *ingester) Handle(conn net.Conn) {
+	var (
+		// Writes rely on the M3DB client timeouts rather than a context
+		// deadline, as with the carbon ingester.
+		ctx = context.Background()
+		wg  = sync.WaitGroup{}
+		s   = bufio.NewScanner(conn)
+	)
+	s.Buffer(make([]byte, 0, 4096), maxLineLength)
+
+	i.logger.Debug("handling new wavefront ingestion connection")
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		// NB: parsing copies the line since scanner bytes are recycled.
+		p, err := parseLine(line, i.nowFn(), i.tagOpts)
+		if err != nil {
+			i.metrics.malformed.Inc(1)
+			i.logger.Debug("malformed wavefront line",
+				zap.ByteString("line", line), zap.Error(err))
+			continue
+		}
+
+		if i.limiter != nil && !i.limiter.IsAllowed(1) {
+			i.metrics.rateLimited.Inc(1)
+			continue
+		}
+
+		wg.Add(1)
+		i.opts.WorkerPool.Go(func() {
+			err := i.downsamplerAndWriter.Write(ctx, p.tags,
+				ts.Datapoints{p.datapoint}, p.unit, nil, ingest.WriteOptions{})
+			if err != nil {
+				i.metrics.err.Inc(1)
+				i.logger.Error("err writing wavefront metric",
+					zap.String("tags", p.tags.String()), zap.Error(err))
+			} else {
+				i.metrics.success.Inc(1)
+			}
+			wg.Done()
+		})
+	}
+
+	if err := s.Err(); err != nil {
+		i.logger.Error("encountered error during wavefront ingestion when scanning connection",
+			zap.Error(err))
+	}
+
+	wg.Wait()
+	i.logger.Debug("all outstanding writes completed, shutting down wavefront ingestion handler")
+
+	// Don't close the connection, that is the server's responsibility.
+}
+
+func (i *ingester) Close() {
+	// No state is maintained in-between connections.
+}
+
+type ingesterMetrics struct {
+	success     tally.Counter
+	err         tally.Counter
+	malformed   tally.Counter
+	rateLimited tally.Counter
+}
+
+func newIngesterMetrics(m tally.Scope) ingesterMetrics {
+	return ingesterMetrics{
+		success:     m.Counter("success"),
+		err:         m.Counter("error"),
+		malformed:   m.Counter("malformed"),
+		rateLimited: m.Counter("rate-limited"),
+	}
+}
+
+type point struct {
+	tags      models.Tags
+	datapoint ts.Datapoint
+	unit      xtime.Unit
+}
+
+// parseLine parses a line in the Wavefront data format, datapoints without
+// a timestamp are assigned the current time.
+func parseLine(line []byte, now time.Time, tagOpts models.TagOptions) (point, error) {
+	fields, err := splitFields(line)
+	if err != nil {
+		return point{}, err
+	}
+	if len(fields) < 2 {
+		return point{}, errMissingValue
+	}
+
+	name, err := unquote(fields[0])
+	if err != nil {
+		return point{}, err
+	}
+
+	value, err := strconv.ParseFloat(string(fields[1]), 64)
+	if err != nil {
+		return point{}, fmt.Errorf("invalid metric value: %v", err)
+	}
+
+	result := point{
+		datapoint: ts.Datapoint{Timestamp: now.Truncate(time.Second), Value: value},
+		unit:      xtime.Second,
+	}
+
+	fields = fields[2:]
+	if len(fields) > 0 && bytes.IndexByte(fields[0], '=') < 0 {
+		timestamp, err := strconv.ParseInt(string(fields[0]), 10, 64)
+		if err != nil {
+			return point{}, fmt.Errorf("invalid timestamp: %v", err)
+		}
+		if timestamp >= minMillisecondsTimestamp {
+			result.datapoint.Timestamp = time.Unix(0, timestamp*int64(time.Millisecond))
+			result.unit = xtime.Millisecond
+		} else {
+			result.datapoint.Timestamp = time.Unix(timestamp, 0)
+		}
+		fields = fields[1:]
+	}
+
+	tags := models.NewTags(len(fields)+1, tagOpts)
+	tags = tags.AddTagWithoutNormalizing(models.Tag{
+		Name:  tagOpts.MetricName(),
+		Value: sanitize(name),
+	})
+
+	var (
+		seen = map[string]struct{}{
+			string(tagOpts.MetricName()): {},
+			sourceTagName:                {},
+			hostTagName:                  {},
+		}
+		source []byte
+		host   []byte
+	)
+	for _, field := range fields {
+		idx := bytes.IndexByte(field, '=')
+		if idx <= 0 {
+			return point{}, fmt.Errorf("invalid point tag: %s", field)
+		}
+
+		key, err := unquote(field[:idx])
+		if err != nil {
+			return point{}, err
+		}
+		value, err := unquote(field[idx+1:])
+		if err != nil {
+			return point{}, err
+		}
+
+		switch string(key) {
+		case sourceTagName:
+			source = value
+			continue
+		case hostTagName:
+			host = value
+			continue
+		}
+
+		key = sanitize(key)
+		if _, ok := seen[string(key)]; ok || len(value) == 0 {
+			continue
+		}
+		seen[string(key)] = struct{}{}
+		tags = tags.AddTagWithoutNormalizing(models.Tag{Name: key, Value: value})
+	}
+
+	// The host is an alias of the source, and is kept as a point tag if
+	// both are set.
+	switch {
+	case source != nil && host != nil:
+		tags = tags.AddTagWithoutNormalizing(models.Tag{
+			Name:  []byte(hostTagName),
+			Value: host,
+		})
+	case source == nil:
+		source = host
+	}
+	if len(source) == 0 {
+		return point{}, errMissingSource
+	}
+	tags = tags.AddTagWithoutNormalizing(models.Tag{
+		Name:  []byte(sourceTagName),
+		Value: source,
+	})
+
+	result.tags = tags.Normalize()
+	return result, nil
+}
+
+// splitFields splits a line into whitespace separated fields, whitespace
+// within double quotes does not separate fields.
+func splitFields(line []byte) ([][]byte, error) {
+	var (
+		fields   [][]byte
+		start    = -1
+		inQuotes bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inQuotes && c == '\\':
+			// Skip the escaped character.
+			i++
+		case c == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && (c == ' ' || c == '\t'):
+			if start >= 0 {
+				fields = append(fields, line[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if inQuotes {
+		return nil, errUnterminatedQuote
+	}
+	if start >= 0 {
+		fields = append(fields, line[start:])
+	}
+	return fields, nil
+}
+
+// unquote returns a copy of a field with surrounding double quotes and
+// escapes within them removed.
+func unquote(field []byte) ([]byte, error) {
+	if len(field) == 0 || field[0] != '"' {
+		return append([]byte(nil), field...), nil
+	}
+	if len(field) < 2 || field[len(field)-1] != '"' {
+		return nil, errUnterminatedQuote
+	}
+
+	field = field[1 : len(field)-1]
+	result := make([]byte, 0, len(field))
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+1 < len(field) {
+			i++
+		}
+		result = append(result, field[i])
+	}
+	return result, nil
+}
+
+// sanitize replaces characters not valid in Prometheus metric and label
+// names with underscores in place.
+func sanitize(name []byte) []byte {
+	for i, c := range name {
+		valid := c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			name[i] = '_'
+		}
+	}
+	return name
+}
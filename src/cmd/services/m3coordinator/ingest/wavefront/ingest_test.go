@@ -0,0 +1,273 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestwavefront
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	m3xserver "github.com/m3db/m3/src/x/server"
+	xsync "github.com/m3db/m3/src/x/sync"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Unix(1600000000, 500000000)
+
+func testTags(pairs ...string) map[string]string {
+	result := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		result[pairs[i]] = pairs[i+1]
+	}
+	return result
+}
+
+func tagsToMap(tags models.Tags) map[string]string {
+	result := make(map[string]string, tags.Len())
+	for _, tag := range tags.Tags {
+		result[string(tag.Name)] = string(tag.Value)
+	}
+	return result
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		tags      map[string]string
+		value     float64
+		timestamp time.Time
+		unit      xtime.Unit
+	}{
+		{
+			name:      "no timestamp",
+			line:      "system.cpu.load 1.5 source=web01",
+			tags:      testTags("__name__", "system_cpu_load", "source", "web01"),
+			value:     1.5,
+			timestamp: time.Unix(1600000000, 0),
+			unit:      xtime.Second,
+		},
+		{
+			name:      "seconds timestamp and point tags",
+			line:      "requests 10 1599999990 source=web01 env=prod dc=us-east",
+			tags:      testTags("__name__", "requests", "source", "web01", "env", "prod", "dc", "us-east"),
+			value:     10,
+			timestamp: time.Unix(1599999990, 0),
+			unit:      xtime.Second,
+		},
+		{
+			name:      "milliseconds timestamp",
+			line:      "requests 10 1599999990123 source=web01",
+			tags:      testTags("__name__", "requests", "source", "web01"),
+			value:     10,
+			timestamp: time.Unix(1599999990, 123000000),
+			unit:      xtime.Millisecond,
+		},
+		{
+			name:      "quoted name and tags",
+			line:      `"disk.used bytes" 42 source="web 01" "mount.point"="/var \"log\""`,
+			tags:      testTags("__name__", "disk_used_bytes", "source", "web 01", "mount_point", `/var "log"`),
+			value:     42,
+			timestamp: time.Unix(1600000000, 0),
+			unit:      xtime.Second,
+		},
+		{
+			name:      "host used as source",
+			line:      "requests 1 host=web02",
+			tags:      testTags("__name__", "requests", "source", "web02"),
+			value:     1,
+			timestamp: time.Unix(1600000000, 0),
+			unit:      xtime.Second,
+		},
+		{
+			name:      "host kept when source set",
+			line:      "requests 1 host=web02 source=lb",
+			tags:      testTags("__name__", "requests", "source", "lb", "host", "web02"),
+			value:     1,
+			timestamp: time.Unix(1600000000, 0),
+			unit:      xtime.Second,
+		},
+		{
+			name:      "duplicate and empty tags dropped",
+			line:      "requests 1 source=web01 env=prod env=dev empty=",
+			tags:      testTags("__name__", "requests", "source", "web01", "env", "prod"),
+			value:     1,
+			timestamp: time.Unix(1600000000, 0),
+			unit:      xtime.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := parseLine([]byte(test.line), testNow, models.NewTagOptions())
+			require.NoError(t, err)
+			assert.Equal(t, test.tags, tagsToMap(p.tags))
+			assert.Equal(t, test.value, p.datapoint.Value)
+			assert.True(t, test.timestamp.Equal(p.datapoint.Timestamp),
+				"expected %v, got %v", test.timestamp, p.datapoint.Timestamp)
+			assert.Equal(t, test.unit, p.unit)
+		})
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	lines := []string{
+		"requests",
+		"requests abc source=web01",
+		"requests 1 abc source=web01",
+		"requests 1",
+		"requests 1 source=",
+		"requests 1 source=web01 invalid",
+		`requests 1 source="web01`,
+	}
+
+	for _, line := range lines {
+		t.Run(line, func(t *testing.T) {
+			_, err := parseLine([]byte(line), testNow, models.NewTagOptions())
+			require.Error(t, err)
+		})
+	}
+}
+
+func newTestIngester(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	maxDatapointsPerSecond int64,
+) (m3xserver.Handler, *ingest.MockDownsamplerAndWriter) {
+	workerPool, err := xsync.NewPooledWorkerPool(16,
+		xsync.NewPooledWorkerPoolOptions())
+	require.NoError(t, err)
+	workerPool.Init()
+
+	writer := ingest.NewMockDownsamplerAndWriter(ctrl)
+	handler, err := NewIngester(writer, Options{
+		InstrumentOptions:      instrument.NewOptions(),
+		WorkerPool:             workerPool,
+		MaxDatapointsPerSecond: maxDatapointsPerSecond,
+		NowFn:                  func() time.Time { return testNow },
+	})
+	require.NoError(t, err)
+	return handler, writer
+}
+
+type writtenPoint struct {
+	name  string
+	value float64
+}
+
+func TestIngesterHandle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, writer := newTestIngester(t, ctrl, 0)
+
+	var (
+		lock    sync.Mutex
+		written []writtenPoint
+	)
+	writer.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			tags models.Tags,
+			datapoints ts.Datapoints,
+			_ xtime.Unit,
+			_ []byte,
+			_ ingest.WriteOptions,
+		) error {
+			name, _ := tags.Name()
+			lock.Lock()
+			written = append(written, writtenPoint{
+				name:  string(name),
+				value: datapoints[0].Value,
+			})
+			lock.Unlock()
+			return nil
+		}).Times(2)
+
+	var buf bytes.Buffer
+	buf.WriteString("# comment\n")
+	buf.WriteString("first 1 source=web01\n")
+	buf.WriteString("\n")
+	buf.WriteString("malformed\n")
+	buf.WriteString("second 2 source=web01\n")
+
+	handler.Handle(newTestConn(buf.Bytes()))
+
+	sort.Slice(written, func(i, j int) bool {
+		return written[i].name < written[j].name
+	})
+	assert.Equal(t, []writtenPoint{
+		{name: "first", value: 1},
+		{name: "second", value: 2},
+	}, written)
+}
+
+func TestIngesterRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, writer := newTestIngester(t, ctrl, 2)
+	writer.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+
+	var buf bytes.Buffer
+	for i := 0; i < 5; i++ {
+		buf.WriteString("requests 1 source=web01\n")
+	}
+
+	handler.Handle(newTestConn(buf.Bytes()))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	_, err := NewIngester(nil, Options{})
+	require.Equal(t, errIOptsMustBeSet, err)
+
+	_, err = NewIngester(nil, Options{InstrumentOptions: instrument.NewOptions()})
+	require.Equal(t, errWorkerPoolMustBeSet, err)
+}
+
+type testConn struct {
+	net.Conn
+	r *bytes.Reader
+}
+
+func newTestConn(b []byte) net.Conn {
+	return &testConn{r: bytes.NewReader(b)}
+}
+
+func (c *testConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
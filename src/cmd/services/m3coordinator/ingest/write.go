@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
@@ -32,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/query/ts"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/instrument"
+	xretry "github.com/m3db/m3/src/x/retry"
 	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -113,6 +115,7 @@ type downsamplerAndWriter struct {
 	store       storage.Storage
 	downsampler downsample.Downsampler
 	workerPool  xsync.PooledWorkerPool
+	retrier     xretry.Retrier
 
 	metrics downsamplerAndWriterMetrics
 }
@@ -123,12 +126,28 @@ func NewDownsamplerAndWriter(
 	downsampler downsample.Downsampler,
 	workerPool xsync.PooledWorkerPool,
 	instrumentOpts instrument.Options,
+) DownsamplerAndWriter {
+	return NewDownsamplerAndWriterWithRetrier(store, downsampler, workerPool,
+		nil, instrumentOpts)
+}
+
+// NewDownsamplerAndWriterWithRetrier creates a new downsampler and writer
+// that retries failed writes to storage with the given retrier, so that
+// brief storage unavailability is not surfaced to clients. Writes that fail
+// due to a bad request are never retried. A nil retrier disables retries.
+func NewDownsamplerAndWriterWithRetrier(
+	store storage.Storage,
+	downsampler downsample.Downsampler,
+	workerPool xsync.PooledWorkerPool,
+	retrier xretry.Retrier,
+	instrumentOpts instrument.Options,
 ) DownsamplerAndWriter {
 	scope := instrumentOpts.MetricsScope().SubScope("downsampler")
 	return &downsamplerAndWriter{
 		store:       store,
 		downsampler: downsampler,
 		workerPool:  workerPool,
+		retrier:     retrier,
 		metrics: downsamplerAndWriterMetrics{
 			dropped: scope.Counter("metrics_dropped"),
 		},
@@ -340,7 +359,40 @@ func (d *downsamplerAndWriter) writeWithOptions(
 	if err != nil {
 		return err
 	}
-	return d.store.Write(ctx, writeQuery)
+
+	if d.retrier == nil {
+		return d.store.Write(ctx, writeQuery)
+	}
+
+	err = d.retrier.AttemptWhile(func(int) bool {
+		return ctx.Err() == nil
+	}, func() error {
+		err := d.store.Write(ctx, writeQuery)
+		if err != nil && isBadRequestError(err) {
+			return xerrors.NewNonRetryableError(err)
+		}
+		return err
+	})
+	if inner := xerrors.GetInnerNonRetryableError(err); inner != nil {
+		return inner
+	}
+	return err
+}
+
+// isBadRequestError returns true if the write failed only due to bad
+// requests, such as invalid params, which will fail again if retried.
+func isBadRequestError(err error) bool {
+	multiErr, ok := err.(xerrors.MultiError)
+	if !ok {
+		return client.IsBadRequestError(err) || xerrors.IsInvalidParams(err)
+	}
+
+	for _, err := range multiErr.Errors() {
+		if !isBadRequestError(err) {
+			return false
+		}
+	}
+	return !multiErr.Empty()
 }
 
 func (d *downsamplerAndWriter) WriteBatch(
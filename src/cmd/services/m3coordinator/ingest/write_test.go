@@ -22,6 +22,7 @@ package ingest
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -35,8 +36,10 @@ import (
 	"github.com/m3db/m3/src/query/storage/m3"
 	testm3 "github.com/m3db/m3/src/query/test/m3"
 	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	xretry "github.com/m3db/m3/src/x/retry"
 	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -411,6 +414,72 @@ func TestDownsampleAndWriteNoDownsampler(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDownsampleAndWriteRetriesStorageErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	downAndWrite, _, session := newTestDownsamplerAndWriter(t, ctrl,
+		testDownsamplerAndWriterOptions{})
+	downAndWrite.downsampler = nil
+	downAndWrite.retrier = xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxRetries(2))
+
+	datapoints := testDatapoints1[:1]
+	gomock.InOrder(
+		session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), datapoints[0].Value, gomock.Any(), testAnnotation1).
+			Return(errors.New("unavailable")),
+		session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), datapoints[0].Value, gomock.Any(), testAnnotation1).
+			Return(nil),
+	)
+
+	err := downAndWrite.Write(
+		context.Background(), testTags1, datapoints, xtime.Second, testAnnotation1, defaultOverride)
+	require.NoError(t, err)
+}
+
+func TestDownsampleAndWriteDoesNotRetryBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	downAndWrite, _, session := newTestDownsamplerAndWriter(t, ctrl,
+		testDownsamplerAndWriterOptions{})
+	downAndWrite.downsampler = nil
+	downAndWrite.retrier = xretry.NewRetrier(xretry.NewOptions().
+		SetInitialBackoff(time.Millisecond).
+		SetMaxRetries(2))
+
+	datapoints := testDatapoints1[:1]
+	session.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), datapoints[0].Value, gomock.Any(), testAnnotation1).
+		Return(xerrors.NewInvalidParamsError(errors.New("bad")))
+
+	err := downAndWrite.Write(
+		context.Background(), testTags1, datapoints, xtime.Second, testAnnotation1, defaultOverride)
+	require.Error(t, err)
+	require.True(t, client.IsBadRequestError(err))
+	require.False(t, xerrors.IsNonRetryableError(err))
+}
+
+func TestIsBadRequestError(t *testing.T) {
+	badRequest := xerrors.NewInvalidParamsError(errors.New("bad"))
+	require.True(t, isBadRequestError(badRequest))
+	require.False(t, isBadRequestError(errors.New("unavailable")))
+
+	multiErr := xerrors.NewMultiError().
+		Add(badRequest).
+		Add(xerrors.NewInvalidParamsError(errors.New("also bad")))
+	require.True(t, isBadRequestError(multiErr))
+
+	// A write that also failed for a transient reason is retried.
+	multiErr = multiErr.Add(errors.New("unavailable"))
+	require.False(t, isBadRequestError(multiErr))
+
+	require.False(t, isBadRequestError(xerrors.NewMultiError()))
+}
+
 func TestDownsampleAndWriteBatch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
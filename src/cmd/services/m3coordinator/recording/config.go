@@ -0,0 +1,135 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recording
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/metrics/policy"
+
+	"github.com/prometheus/common/model"
+	pql "github.com/prometheus/prometheus/promql/parser"
+)
+
+const defaultInterval = time.Minute
+
+// Configuration is the configuration for recording rules, which
+// periodically evaluate PromQL expressions against storage and write the
+// results back as new series.
+// NB: when a KV store is available the rules are only evaluated by the
+// coordinator holding a lease in KV, so recording rules can be enabled on
+// every coordinator of a cluster without writing duplicate results.
+type Configuration struct {
+	// Interval is how often rules without an interval of their own are
+	// evaluated.
+	Interval time.Duration `yaml:"interval"`
+
+	// Rules are the recording rules to evaluate.
+	Rules []RuleConfiguration `yaml:"rules"`
+}
+
+// RuleConfiguration is the configuration of a single recording rule.
+type RuleConfiguration struct {
+	// Record is the metric name of the series written with the results.
+	Record string `yaml:"record" validate:"nonzero"`
+
+	// Expr is the PromQL expression to evaluate, it must evaluate to an
+	// instant vector or a scalar.
+	Expr string `yaml:"expr" validate:"nonzero"`
+
+	// Interval is how often the rule is evaluated, defaults to the
+	// interval of the recording rules configuration.
+	Interval time.Duration `yaml:"interval"`
+
+	// Labels are added to, or override, the labels of each result.
+	Labels map[string]string `yaml:"labels"`
+
+	// StoragePolicies are the storage policies the results are written
+	// to, results are written the same way as any other write if not set.
+	StoragePolicies []policy.StoragePolicy `yaml:"storagePolicies"`
+}
+
+// NewEngine returns a new recording rule engine, it must be started to
+// begin evaluating rules.
+func (c Configuration) NewEngine(opts EngineOptions) (Engine, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	defaultRuleInterval := defaultInterval
+	if c.Interval > 0 {
+		defaultRuleInterval = c.Interval
+	}
+
+	rules := make([]*rule, 0, len(c.Rules))
+	for _, ruleCfg := range c.Rules {
+		r, err := ruleCfg.newRule(defaultRuleInterval)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	query := NewInstantQueryFn(opts.PromQLEngine, opts.Queryable)
+	return newEngine(rules, query, opts)
+}
+
+func (c RuleConfiguration) newRule(defaultInterval time.Duration) (*rule, error) {
+	if !model.IsValidMetricName(model.LabelValue(c.Record)) {
+		return nil, fmt.Errorf("invalid recording rule metric name: %q", c.Record)
+	}
+	if _, err := pql.ParseExpr(c.Expr); err != nil {
+		return nil, fmt.Errorf("invalid recording rule %s expression: %v",
+			c.Record, err)
+	}
+	for name := range c.Labels {
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid recording rule %s label name: %q",
+				c.Record, name)
+		}
+	}
+
+	interval := defaultInterval
+	if c.Interval > 0 {
+		interval = c.Interval
+	}
+
+	var writeOpts ingest.WriteOptions
+	if len(c.StoragePolicies) > 0 {
+		// Results are only written to the configured storage policies, they
+		// are not downsampled by the mapping rules.
+		writeOpts = ingest.WriteOptions{
+			DownsampleOverride:   true,
+			WriteOverride:        true,
+			WriteStoragePolicies: c.StoragePolicies,
+		}
+	}
+
+	return &rule{
+		record:    c.Record,
+		expr:      c.Expr,
+		interval:  interval,
+		labels:    c.Labels,
+		writeOpts: writeOpts,
+	}, nil
+}
@@ -0,0 +1,488 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/util"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/prometheus"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/clock"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	promstorage "github.com/prometheus/prometheus/storage"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// leaseKVKey is the KV key of the lease held by the coordinator that
+	// evaluates the recording rules.
+	leaseKVKey = "m3coordinator.recording.lease"
+	// leaseIntervals is the number of evaluation intervals of the most
+	// frequent rule the lease is held for without being renewed.
+	leaseIntervals = 3
+)
+
+var (
+	errEngineAlreadyStarted = errors.New("recording rule engine already started")
+	errEngineNotStarted     = errors.New("recording rule engine not started")
+)
+
+// Engine periodically evaluates recording rules.
+type Engine interface {
+	// Start starts evaluating the recording rules.
+	Start() error
+
+	// Stop stops evaluating the recording rules and waits for any
+	// evaluations in progress to finish.
+	Stop() error
+}
+
+// EngineOptions are the options for a recording rule engine.
+type EngineOptions struct {
+	PromQLEngine *promql.Engine
+	Queryable    promstorage.Queryable
+	Writer       ingest.DownsamplerAndWriter
+	TagOptions   models.TagOptions
+	// KVStore holds the lease that elects the coordinator evaluating the
+	// rules, rules are evaluated by every coordinator if not set.
+	KVStore kv.Store
+	// InstanceID identifies the coordinator competing for the lease, it
+	// defaults to the hostname and process ID.
+	InstanceID        string
+	NowFn             clock.NowFn
+	InstrumentOptions instrument.Options
+}
+
+func (o EngineOptions) validate() error {
+	if o.PromQLEngine == nil {
+		return errors.New("promql engine is not set")
+	}
+	if o.Queryable == nil {
+		return errors.New("queryable is not set")
+	}
+	if o.Writer == nil {
+		return errors.New("writer is not set")
+	}
+	if o.TagOptions == nil {
+		return errors.New("tag options are not set")
+	}
+	if o.NowFn == nil {
+		return errors.New("now fn is not set")
+	}
+	if o.InstrumentOptions == nil {
+		return errors.New("instrument options are not set")
+	}
+	return nil
+}
+
+// InstantQueryFn evaluates an instant query at the given time.
+type InstantQueryFn func(
+	ctx context.Context,
+	expr string,
+	t time.Time,
+) (promql.Value, error)
+
+// NewInstantQueryFn returns a function that evaluates instant queries with
+// the given engine against the given queryable.
+func NewInstantQueryFn(
+	engine *promql.Engine,
+	queryable promstorage.Queryable,
+) InstantQueryFn {
+	return func(ctx context.Context, expr string, t time.Time) (promql.Value, error) {
+		// NB: the queryable reads the fetch options and result metadata from
+		// the context, the same as the Prometheus query handlers.
+		var resultMetadata block.ResultMetadata
+		ctx = context.WithValue(ctx, prometheus.FetchOptionsContextKey,
+			storage.NewFetchOptions())
+		ctx = context.WithValue(ctx, prometheus.BlockResultMetadataKey,
+			&resultMetadata)
+
+		qry, err := engine.NewInstantQuery(queryable, expr, t)
+		if err != nil {
+			return nil, err
+		}
+		defer qry.Close()
+
+		res := qry.Exec(ctx)
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Value, nil
+	}
+}
+
+type rule struct {
+	record    string
+	expr      string
+	interval  time.Duration
+	labels    map[string]string
+	writeOpts ingest.WriteOptions
+
+	// Only accessed by the goroutine evaluating the rule.
+	lastEvaluation time.Time
+	lastSuccess    time.Time
+	metrics        ruleMetrics
+}
+
+type ruleMetrics struct {
+	evaluations        tally.Counter
+	evaluationErrors   tally.Counter
+	missedEvaluations  tally.Counter
+	samplesWritten     tally.Counter
+	evaluationDuration tally.Timer
+	staleness          tally.Gauge
+}
+
+func newRuleMetrics(scope tally.Scope) ruleMetrics {
+	return ruleMetrics{
+		evaluations:        scope.Counter("evaluations"),
+		evaluationErrors:   scope.Counter("evaluation-errors"),
+		missedEvaluations:  scope.Counter("missed-evaluations"),
+		samplesWritten:     scope.Counter("samples-written"),
+		evaluationDuration: scope.Timer("evaluation-duration"),
+		staleness:          scope.Gauge("staleness-seconds"),
+	}
+}
+
+type engineMetrics struct {
+	leaseErrors tally.Counter
+	leader      tally.Gauge
+}
+
+func newEngineMetrics(scope tally.Scope) engineMetrics {
+	return engineMetrics{
+		leaseErrors: scope.Counter("lease-errors"),
+		leader:      scope.Gauge("leader"),
+	}
+}
+
+type engine struct {
+	sync.Mutex
+
+	rules      []*rule
+	query      InstantQueryFn
+	writer     ingest.DownsamplerAndWriter
+	tagOptions models.TagOptions
+	nowFn      clock.NowFn
+	logger     *zap.Logger
+	metrics    engineMetrics
+
+	// leaseLock guards the lease state, which is shared by the goroutines
+	// evaluating each rule.
+	leaseLock sync.Mutex
+	lease     *util.Lease
+	// leaseCheckInterval is how often the lease is acquired or renewed,
+	// rules evaluated in between reuse the result of the last check.
+	leaseCheckInterval time.Duration
+	lastLeaseCheck     time.Time
+	leader             bool
+
+	started bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newEngine(
+	rules []*rule,
+	query InstantQueryFn,
+	opts EngineOptions,
+) (*engine, error) {
+	scope := opts.InstrumentOptions.MetricsScope().SubScope("recording-rules")
+	startTime := opts.NowFn()
+	var minInterval time.Duration
+	for _, r := range rules {
+		r.metrics = newRuleMetrics(scope.Tagged(map[string]string{
+			"rule": r.record,
+		}))
+		// Staleness is measured from the engine start until the first
+		// successful evaluation.
+		r.lastSuccess = startTime
+		if minInterval == 0 || r.interval < minInterval {
+			minInterval = r.interval
+		}
+	}
+
+	var lease *util.Lease
+	if opts.KVStore != nil && len(rules) > 0 {
+		instanceID := opts.InstanceID
+		if instanceID == "" {
+			hostname, err := os.Hostname()
+			if err != nil {
+				return nil, err
+			}
+			instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+
+		// NB: the lease outlives a couple of missed evaluations of the
+		// most frequent rule so that a slow evaluation does not hand the
+		// rules to another coordinator.
+		var err error
+		lease, err = util.NewLease(opts.KVStore, leaseKVKey, instanceID,
+			leaseIntervals*minInterval, opts.NowFn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &engine{
+		rules:              rules,
+		query:              query,
+		writer:             opts.Writer,
+		tagOptions:         opts.TagOptions,
+		nowFn:              opts.NowFn,
+		logger:             opts.InstrumentOptions.Logger(),
+		metrics:            newEngineMetrics(scope),
+		lease:              lease,
+		leaseCheckInterval: minInterval,
+	}, nil
+}
+
+func (e *engine) Start() error {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.started {
+		return errEngineAlreadyStarted
+	}
+	e.started = true
+	e.closeCh = make(chan struct{})
+
+	for _, r := range e.rules {
+		e.wg.Add(1)
+		go e.evaluateLoop(r, e.closeCh)
+	}
+	return nil
+}
+
+func (e *engine) Stop() error {
+	e.Lock()
+	if !e.started {
+		e.Unlock()
+		return errEngineNotStarted
+	}
+	e.started = false
+	close(e.closeCh)
+	e.Unlock()
+
+	e.wg.Wait()
+
+	e.leaseLock.Lock()
+	defer e.leaseLock.Unlock()
+	if e.lease != nil && e.leader {
+		// Hand the rules to another coordinator without waiting for the
+		// lease to expire.
+		if err := e.lease.Release(); err != nil {
+			e.logger.Error("unable to release recording lease", zap.Error(err))
+		}
+		e.leader = false
+		e.lastLeaseCheck = time.Time{}
+		e.metrics.leader.Update(0)
+	}
+	return nil
+}
+
+// acquireLease returns whether the rules should be evaluated by this
+// coordinator, which is only the case while it holds the lease.
+func (e *engine) acquireLease(now time.Time) bool {
+	if e.lease == nil {
+		return true
+	}
+
+	e.leaseLock.Lock()
+	defer e.leaseLock.Unlock()
+
+	// NB: every rule checks the lease before evaluating, only go to KV
+	// once per check interval rather than once per rule evaluation.
+	if !e.lastLeaseCheck.IsZero() && now.Sub(e.lastLeaseCheck) < e.leaseCheckInterval {
+		return e.leader
+	}
+	e.lastLeaseCheck = now
+
+	held, err := e.lease.Acquire()
+	if err != nil {
+		// NB: rules are not evaluated if the lease cannot be renewed since
+		// another coordinator may acquire it once it expires.
+		e.metrics.leaseErrors.Inc(1)
+		e.logger.Error("unable to acquire recording lease", zap.Error(err))
+		held = false
+	}
+
+	if held && !e.leader {
+		e.logger.Info("acquired recording lease, evaluating recording rules")
+	} else if !held && e.leader {
+		e.logger.Info("lost recording lease, no longer evaluating recording rules")
+	}
+	e.leader = held
+	if held {
+		e.metrics.leader.Update(1)
+	} else {
+		e.metrics.leader.Update(0)
+	}
+	return held
+}
+
+func (e *engine) evaluateLoop(r *rule, closeCh chan struct{}) {
+	defer e.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-closeCh
+		cancel()
+	}()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closeCh:
+			return
+		case <-ticker.C:
+			e.tick(ctx, r)
+		}
+	}
+}
+
+// tick evaluates the rule at the start of the current interval, so that
+// results are evenly spaced regardless of when the ticker fires. Rules are
+// only evaluated by the coordinator holding the lease, if any.
+func (e *engine) tick(ctx context.Context, r *rule) {
+	now := e.nowFn()
+	evaluationTime := now.Truncate(r.interval)
+
+	if !e.acquireLease(now) {
+		// Another coordinator evaluates the rule, so intervals skipped
+		// until the lease is acquired are neither missed nor stale.
+		r.lastEvaluation = time.Time{}
+		r.lastSuccess = now
+		r.metrics.staleness.Update(0)
+		return
+	}
+
+	if !r.lastEvaluation.IsZero() {
+		if !evaluationTime.After(r.lastEvaluation) {
+			// Already evaluated at this time.
+			return
+		}
+		// Intervals skipped since the last evaluation, e.g. because an
+		// evaluation took longer than the interval.
+		if missed := int64(evaluationTime.Sub(r.lastEvaluation)/r.interval) - 1; missed > 0 {
+			r.metrics.missedEvaluations.Inc(missed)
+		}
+	}
+	r.lastEvaluation = evaluationTime
+
+	// Evaluations must not overrun the next one.
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+
+	r.metrics.evaluations.Inc(1)
+	err := e.evaluate(ctx, r, evaluationTime)
+	r.metrics.evaluationDuration.Record(e.nowFn().Sub(now))
+	if err != nil {
+		r.metrics.evaluationErrors.Inc(1)
+		e.logger.Error("recording rule evaluation failed",
+			zap.String("rule", r.record),
+			zap.Time("evaluationTime", evaluationTime),
+			zap.Error(err))
+	} else {
+		r.lastSuccess = evaluationTime
+	}
+
+	r.metrics.staleness.Update(e.nowFn().Sub(r.lastSuccess).Seconds())
+}
+
+func (e *engine) evaluate(
+	ctx context.Context,
+	r *rule,
+	evaluationTime time.Time,
+) error {
+	value, err := e.query(ctx, r.expr, evaluationTime)
+	if err != nil {
+		return err
+	}
+
+	var samples promql.Vector
+	switch v := value.(type) {
+	case promql.Vector:
+		samples = v
+	case promql.Scalar:
+		samples = promql.Vector{
+			{Point: promql.Point{T: v.T, V: v.V}},
+		}
+	default:
+		return fmt.Errorf("unsupported result type: %s", value.Type())
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, sample := range samples {
+		tags := e.resultTags(r, sample.Metric)
+		datapoints := ts.Datapoints{
+			{Timestamp: evaluationTime, Value: sample.V},
+		}
+		err := e.writer.Write(ctx, tags, datapoints, xtime.Millisecond, nil,
+			r.writeOpts)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		r.metrics.samplesWritten.Inc(1)
+	}
+	return multiErr.FinalError()
+}
+
+func (e *engine) resultTags(r *rule, metric labels.Labels) models.Tags {
+	tags := models.NewTags(len(metric)+len(r.labels)+1, e.tagOptions)
+	for _, l := range metric {
+		if l.Name == labels.MetricName {
+			continue
+		}
+		tags = tags.AddTag(models.Tag{
+			Name:  []byte(l.Name),
+			Value: []byte(l.Value),
+		})
+	}
+	for name, value := range r.labels {
+		tags = tags.AddOrUpdateTag(models.Tag{
+			Name:  []byte(name),
+			Value: []byte(value),
+		})
+	}
+	return tags.SetName([]byte(r.record))
+}
@@ -0,0 +1,340 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recording
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+type testQuery struct {
+	value promql.Value
+	err   error
+	times []time.Time
+}
+
+func (q *testQuery) query(
+	_ context.Context,
+	_ string,
+	t time.Time,
+) (promql.Value, error) {
+	q.times = append(q.times, t)
+	return q.value, q.err
+}
+
+func newTestEngine(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	cfg RuleConfiguration,
+	query *testQuery,
+) (*engine, *ingest.MockDownsamplerAndWriter, *testClock, tally.TestScope) {
+	var (
+		writer = ingest.NewMockDownsamplerAndWriter(ctrl)
+		clock  = &testClock{now: time.Unix(1600000020, 0)}
+		scope  = tally.NewTestScope("", nil)
+	)
+	e := newTestEngineWithLease(t, cfg, query, writer, clock, scope, nil, "")
+	return e, writer, clock, scope
+}
+
+func newTestEngineWithLease(
+	t *testing.T,
+	cfg RuleConfiguration,
+	query *testQuery,
+	writer ingest.DownsamplerAndWriter,
+	clock *testClock,
+	scope tally.Scope,
+	store kv.Store,
+	instanceID string,
+) *engine {
+	r, err := cfg.newRule(time.Minute)
+	require.NoError(t, err)
+
+	e, err := newEngine([]*rule{r}, query.query, EngineOptions{
+		Writer:            writer,
+		TagOptions:        models.NewTagOptions(),
+		KVStore:           store,
+		InstanceID:        instanceID,
+		NowFn:             clock.Now,
+		InstrumentOptions: instrument.NewOptions().SetMetricsScope(scope),
+	})
+	require.NoError(t, err)
+	return e
+}
+
+func counterValue(scope tally.TestScope, name string) int64 {
+	key := "recording-rules." + name + "+rule=job:requests:rate1m"
+	counter, ok := scope.Snapshot().Counters()[key]
+	if !ok {
+		return 0
+	}
+	return counter.Value()
+}
+
+func gaugeValue(scope tally.TestScope, name string) float64 {
+	key := "recording-rules." + name + "+rule=job:requests:rate1m"
+	return scope.Snapshot().Gauges()[key].Value()
+}
+
+func TestRuleConfigurationValidation(t *testing.T) {
+	_, err := RuleConfiguration{Record: "bad-name", Expr: "up"}.newRule(time.Minute)
+	assert.Error(t, err)
+
+	_, err = RuleConfiguration{Record: "good", Expr: "sum(up"}.newRule(time.Minute)
+	assert.Error(t, err)
+
+	_, err = RuleConfiguration{
+		Record: "good",
+		Expr:   "up",
+		Labels: map[string]string{"bad-label": "x"},
+	}.newRule(time.Minute)
+	assert.Error(t, err)
+
+	r, err := RuleConfiguration{Record: "good", Expr: "up"}.newRule(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, r.interval)
+	assert.Equal(t, ingest.WriteOptions{}, r.writeOpts)
+
+	r, err = RuleConfiguration{
+		Record:   "good",
+		Expr:     "up",
+		Interval: 10 * time.Second,
+		StoragePolicies: []policy.StoragePolicy{
+			policy.MustParseStoragePolicy("1m:40d"),
+		},
+	}.newRule(time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, r.interval)
+	assert.True(t, r.writeOpts.DownsampleOverride)
+	assert.True(t, r.writeOpts.WriteOverride)
+	assert.Equal(t, []policy.StoragePolicy{
+		policy.MustParseStoragePolicy("1m:40d"),
+	}, r.writeOpts.WriteStoragePolicies)
+}
+
+func TestEngineWritesResults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	query := &testQuery{
+		value: promql.Vector{
+			{
+				Point: promql.Point{V: 2.5},
+				Metric: labels.FromStrings(labels.MetricName, "requests",
+					"job", "api", "env", "staging"),
+			},
+		},
+	}
+	e, writer, clock, scope := newTestEngine(t, ctrl, RuleConfiguration{
+		Record: "job:requests:rate1m",
+		Expr:   "sum by (job, env) (rate(requests[1m]))",
+		Labels: map[string]string{"env": "prod", "source": "recording"},
+	}, query)
+
+	clock.now = clock.now.Add(90 * time.Second)
+	evaluationTime := clock.now.Truncate(time.Minute)
+
+	writer.EXPECT().
+		Write(gomock.Any(), gomock.Any(), ts.Datapoints{
+			{Timestamp: evaluationTime, Value: 2.5},
+		}, xtime.Millisecond, nil, ingest.WriteOptions{}).
+		DoAndReturn(func(
+			_ context.Context,
+			tags models.Tags,
+			_ ts.Datapoints,
+			_ xtime.Unit,
+			_ []byte,
+			_ ingest.WriteOptions,
+		) error {
+			name, ok := tags.Name()
+			require.True(t, ok)
+			assert.Equal(t, "job:requests:rate1m", string(name))
+			for tag, expected := range map[string]string{
+				"job":    "api",
+				"env":    "prod",
+				"source": "recording",
+			} {
+				value, ok := tags.Get([]byte(tag))
+				require.True(t, ok)
+				assert.Equal(t, expected, string(value))
+			}
+			assert.Equal(t, 4, len(tags.Tags))
+			return nil
+		})
+
+	e.tick(context.Background(), e.rules[0])
+
+	assert.Equal(t, []time.Time{evaluationTime}, query.times)
+	assert.Equal(t, int64(1), counterValue(scope, "evaluations"))
+	assert.Equal(t, int64(1), counterValue(scope, "samples-written"))
+	assert.Equal(t, int64(0), counterValue(scope, "evaluation-errors"))
+	assert.Equal(t, 30.0, gaugeValue(scope, "staleness-seconds"))
+
+	// Ticking again within the same interval does not evaluate again.
+	e.tick(context.Background(), e.rules[0])
+	assert.Equal(t, 1, len(query.times))
+}
+
+func TestEngineWritesScalarResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	query := &testQuery{value: promql.Scalar{V: 7}}
+	e, writer, clock, _ := newTestEngine(t, ctrl, RuleConfiguration{
+		Record: "job:requests:rate1m",
+		Expr:   "scalar(sum(requests))",
+	}, query)
+
+	writer.EXPECT().
+		Write(gomock.Any(), gomock.Any(), ts.Datapoints{
+			{Timestamp: clock.now, Value: 7},
+		}, xtime.Millisecond, nil, ingest.WriteOptions{}).
+		DoAndReturn(func(
+			_ context.Context,
+			tags models.Tags,
+			_ ts.Datapoints,
+			_ xtime.Unit,
+			_ []byte,
+			_ ingest.WriteOptions,
+		) error {
+			assert.Equal(t, 1, len(tags.Tags))
+			return nil
+		})
+
+	e.tick(context.Background(), e.rules[0])
+}
+
+func TestEngineMissedEvaluationsAndStaleness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	query := &testQuery{value: promql.Vector{}}
+	e, _, clock, scope := newTestEngine(t, ctrl, RuleConfiguration{
+		Record: "job:requests:rate1m",
+		Expr:   "sum(rate(requests[1m]))",
+	}, query)
+
+	e.tick(context.Background(), e.rules[0])
+	assert.Equal(t, int64(0), counterValue(scope, "missed-evaluations"))
+	assert.Equal(t, 0.0, gaugeValue(scope, "staleness-seconds"))
+
+	// Three intervals later, two evaluations were missed.
+	clock.now = clock.now.Add(3 * time.Minute)
+	query.err = errors.New("storage unavailable")
+	e.tick(context.Background(), e.rules[0])
+	assert.Equal(t, int64(2), counterValue(scope, "missed-evaluations"))
+	assert.Equal(t, int64(1), counterValue(scope, "evaluation-errors"))
+	assert.Equal(t, 180.0, gaugeValue(scope, "staleness-seconds"))
+
+	clock.now = clock.now.Add(time.Minute)
+	query.err = nil
+	e.tick(context.Background(), e.rules[0])
+	assert.Equal(t, int64(2), counterValue(scope, "missed-evaluations"))
+	assert.Equal(t, 0.0, gaugeValue(scope, "staleness-seconds"))
+}
+
+func TestEngineOnlyLeaseHolderEvaluates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		store  = mem.NewStore()
+		clock  = &testClock{now: time.Unix(1600000020, 0)}
+		writer = ingest.NewMockDownsamplerAndWriter(ctrl)
+		cfg    = RuleConfiguration{
+			Record: "job:requests:rate1m",
+			Expr:   "sum(rate(requests[1m]))",
+		}
+		queryA = &testQuery{value: promql.Vector{}}
+		queryB = &testQuery{value: promql.Vector{}}
+		scopeB = tally.NewTestScope("", nil)
+		a      = newTestEngineWithLease(t, cfg, queryA, writer, clock,
+			tally.NoopScope, store, "a")
+		b = newTestEngineWithLease(t, cfg, queryB, writer, clock,
+			scopeB, store, "b")
+	)
+
+	a.tick(context.Background(), a.rules[0])
+	b.tick(context.Background(), b.rules[0])
+	assert.Equal(t, 1, len(queryA.times))
+	assert.Equal(t, 0, len(queryB.times))
+
+	clock.now = clock.now.Add(time.Minute)
+	a.tick(context.Background(), a.rules[0])
+	b.tick(context.Background(), b.rules[0])
+	assert.Equal(t, 2, len(queryA.times))
+	assert.Equal(t, 0, len(queryB.times))
+
+	// Once the lease expires the other coordinator takes over, without
+	// counting the intervals evaluated by the previous holder as missed.
+	clock.now = clock.now.Add(4 * time.Minute)
+	b.tick(context.Background(), b.rules[0])
+	a.tick(context.Background(), a.rules[0])
+	assert.Equal(t, 2, len(queryA.times))
+	assert.Equal(t, 1, len(queryB.times))
+	assert.Equal(t, int64(0), counterValue(scopeB, "missed-evaluations"))
+
+	// Stopping releases the lease without waiting for it to expire.
+	require.NoError(t, b.Start())
+	require.NoError(t, b.Stop())
+	clock.now = clock.now.Add(time.Minute)
+	a.tick(context.Background(), a.rules[0])
+	assert.Equal(t, 3, len(queryA.times))
+}
+
+func TestEngineStartStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e, _, _, _ := newTestEngine(t, ctrl, RuleConfiguration{
+		Record: "job:requests:rate1m",
+		Expr:   "sum(rate(requests[1m]))",
+	}, &testQuery{value: promql.Vector{}})
+
+	require.Equal(t, errEngineNotStarted, e.Stop())
+	require.NoError(t, e.Start())
+	require.Equal(t, errEngineAlreadyStarted, e.Start())
+	require.NoError(t, e.Stop())
+}
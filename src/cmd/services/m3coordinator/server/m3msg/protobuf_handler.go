@@ -100,7 +100,7 @@ func (h *pbHandler) Process(msg consumer.Message) {
 
 	h.wg.Add(1)
 	r := NewProtobufCallback(msg, dec, h.wg)
-	h.writeFn(h.ctx, dec.ID(), dec.TimeNanos(), dec.EncodeNanos(), dec.Value(), sp, r)
+	h.writeFn(h.ctx, dec.ID(), dec.TimeNanos(), dec.EncodeNanos(), dec.Value(), dec.Annotation(), sp, r)
 }
 
 func (h *pbHandler) Close() { h.wg.Wait() }
@@ -69,10 +69,11 @@ func TestM3msgServerWithProtobufHandler(t *testing.T) {
 	require.NoError(t, err)
 	m1 := aggregated.MetricWithStoragePolicy{
 		Metric: aggregated.Metric{
-			ID:        []byte(testID),
-			TimeNanos: 1000,
-			Value:     1,
-			Type:      metric.GaugeType,
+			ID:         []byte(testID),
+			TimeNanos:  1000,
+			Value:      1,
+			Type:       metric.GaugeType,
+			Annotation: []byte("traceID"),
 		},
 		StoragePolicy: validStoragePolicy,
 	}
@@ -116,6 +117,7 @@ func TestM3msgServerWithProtobufHandler(t *testing.T) {
 	require.Equal(t, m1.TimeNanos, payload.metricNanos)
 	require.Equal(t, 2000, int(payload.encodeNanos))
 	require.Equal(t, m1.Value, payload.value)
+	require.Equal(t, string(m1.Annotation), payload.annotation)
 	require.Equal(t, m1.StoragePolicy, payload.sp)
 
 	payload, ok = w.m[key(string(m2.ID), 3000)]
@@ -124,6 +126,7 @@ func TestM3msgServerWithProtobufHandler(t *testing.T) {
 	require.Equal(t, m2.TimeNanos, payload.metricNanos)
 	require.Equal(t, 3000, int(payload.encodeNanos))
 	require.Equal(t, m2.Value, payload.value)
+	require.Equal(t, "", payload.annotation)
 	require.Equal(t, m2.StoragePolicy, payload.sp)
 }
 
@@ -139,6 +142,7 @@ func (m *mockWriter) write(
 	name []byte,
 	metricNanos, encodeNanos int64,
 	value float64,
+	annotation []byte,
 	sp policy.StoragePolicy,
 	callbackable Callbackable,
 ) {
@@ -149,6 +153,7 @@ func (m *mockWriter) write(
 		metricNanos: metricNanos,
 		encodeNanos: encodeNanos,
 		value:       value,
+		annotation:  string(annotation),
 		sp:          sp,
 	}
 	m.m[key(payload.id, encodeNanos)] = payload
@@ -172,5 +177,6 @@ type payload struct {
 	metricNanos int64
 	encodeNanos int64
 	value       float64
+	annotation  string
 	sp          policy.StoragePolicy
 }
@@ -44,6 +44,10 @@ import (
 var (
 	// defaultNumProcessorsPerCPU is the default number of processors per CPU.
 	defaultNumProcessorsPerCPU = 0.125
+
+	// defaultBootstrapReadyPercent is the default percentage of shards that
+	// must be bootstrapped before the node reports ready.
+	defaultBootstrapReadyPercent = 100.0
 )
 
 // BootstrapConfiguration specifies the config for bootstrappers.
@@ -64,12 +68,29 @@ type BootstrapConfiguration struct {
 	// CacheSeriesMetadata determines whether individual bootstrappers cache
 	// series metadata across all calls (namespaces / shards / blocks).
 	CacheSeriesMetadata *bool `yaml:"cacheSeriesMetadata"`
+
+	// ReadyPercent is the percentage of the shards of every namespace that
+	// must be bootstrapped before the node reports ready, defaults to 100.
+	ReadyPercent *float64 `yaml:"readyPercent"`
+}
+
+// ReadyPercentOrDefault returns the percentage of the shards of every
+// namespace that must be bootstrapped before the node reports ready.
+func (bsc BootstrapConfiguration) ReadyPercentOrDefault() float64 {
+	if v := bsc.ReadyPercent; v != nil {
+		return *v
+	}
+	return defaultBootstrapReadyPercent
 }
 
 // BootstrapFilesystemConfiguration specifies config for the fs bootstrapper.
 type BootstrapFilesystemConfiguration struct {
-	// NumProcessorsPerCPU is the number of processors per CPU.
+	// NumProcessorsPerCPU is the number of processors per CPU, used to load
+	// the data file sets of shards in parallel.
 	NumProcessorsPerCPU float64 `yaml:"numProcessorsPerCPU" validate:"min=0.0"`
+	// ReadLimitMbps is the limit in megabits per second on reading fileset
+	// data, defaults to no limit.
+	ReadLimitMbps float64 `yaml:"readLimitMbps" validate:"min=0.0"`
 }
 
 func (c BootstrapFilesystemConfiguration) numCPUs() int {
@@ -91,6 +112,9 @@ type BootstrapCommitlogConfiguration struct {
 	// this to true allows the node to attempt a repair if the peers bootstrapper is configured
 	// after the commitlog bootstrapper.
 	ReturnUnfulfilledForCorruptCommitLogFiles bool `yaml:"returnUnfulfilledForCorruptCommitLogFiles"`
+	// AccumulateConcurrency controls how many goroutines in parallel accumulate
+	// the data read from the commit log. Defaults to: numCPU.
+	AccumulateConcurrency int `yaml:"accumulateConcurrency" validate:"min=0"`
 }
 
 func newDefaultBootstrapCommitlogConfiguration() BootstrapCommitlogConfiguration {
@@ -109,6 +133,9 @@ type BootstrapPeersConfiguration struct {
 	// for historical data being streamed between peers (historical blocks).
 	// Defaults to: numCPU / 2.
 	StreamPersistShardConcurrency int `yaml:"streamPersistShardConcurrency"`
+	// StreamLimitMbps is the limit in megabits per second on streaming
+	// blocks from peers, defaults to no limit.
+	StreamLimitMbps float64 `yaml:"streamLimitMbps" validate:"min=0.0"`
 }
 
 func newDefaultBootstrapPeersConfiguration() BootstrapPeersConfiguration {
@@ -182,7 +209,8 @@ func (bsc BootstrapConfiguration) New(
 				SetCompactor(compactor).
 				SetBoostrapDataNumProcessors(fsCfg.numCPUs()).
 				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
-				SetIdentifierPool(opts.IdentifierPool())
+				SetIdentifierPool(opts.IdentifierPool()).
+				SetReadLimitMbps(fsCfg.ReadLimitMbps)
 			if err := validator.ValidateFilesystemBootstrapperOptions(fsbOpts); err != nil {
 				return nil, err
 			}
@@ -197,6 +225,9 @@ func (bsc BootstrapConfiguration) New(
 				SetCommitLogOptions(opts.CommitLogOptions()).
 				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
 				SetReturnUnfulfilledForCorruptCommitLogFiles(cCfg.ReturnUnfulfilledForCorruptCommitLogFiles)
+			if cCfg.AccumulateConcurrency > 0 {
+				cOpts = cOpts.SetAccumulateConcurrency(cCfg.AccumulateConcurrency)
+			}
 			if err := validator.ValidateCommitLogBootstrapperOptions(cOpts); err != nil {
 				return nil, err
 			}
@@ -220,7 +251,8 @@ func (bsc BootstrapConfiguration) New(
 				SetRuntimeOptionsManager(opts.RuntimeOptionsManager()).
 				SetContextPool(opts.ContextPool()).
 				SetDefaultShardConcurrency(pCfg.StreamShardConcurrency).
-				SetShardPersistenceConcurrency(pCfg.StreamPersistShardConcurrency)
+				SetShardPersistenceConcurrency(pCfg.StreamPersistShardConcurrency).
+				SetStreamLimitMbps(pCfg.StreamLimitMbps)
 			if err := validator.ValidatePeersBootstrapperOptions(pOpts); err != nil {
 				return nil, err
 			}
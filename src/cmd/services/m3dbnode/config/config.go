@@ -30,12 +30,20 @@ import (
 	"time"
 
 	coordinatorcfg "github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/dbnode/capacity"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/persist/fs/tiering"
+	"github.com/m3db/m3/src/dbnode/storage/cardinality"
+	"github.com/m3db/m3/src/dbnode/storage/downsample"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/x/config/hostid"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
 	xlog "github.com/m3db/m3/src/x/log"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/opentracing"
 
 	"go.etcd.io/etcd/embed"
@@ -104,6 +112,10 @@ type DBConfiguration struct {
 	// The initial garbage collection target percentage.
 	GCPercentage int `yaml:"gcPercentage" validate:"max=100"`
 
+	// GCTuning, if set, tunes the garbage collection target percentage
+	// against a soft memory limit and the bootstrap phase.
+	GCTuning *memory.GCTunerConfiguration `yaml:"gcTuning"`
+
 	// TODO(V1): Move to `limits`.
 	// Write new series limit per second to limit overwhelming during new ID bursts.
 	WriteNewSeriesLimitPerSecond int `yaml:"writeNewSeriesLimitPerSecond"`
@@ -133,6 +145,9 @@ type DBConfiguration struct {
 	// The repair policy for repairing data within a cluster.
 	Repair *RepairPolicy `yaml:"repair"`
 
+	// The scrub policy for validating filesets on disk.
+	Scrub *ScrubPolicy `yaml:"scrub"`
+
 	// The replication policy for replicating data between clusters.
 	Replication *ReplicationPolicy `yaml:"replication"`
 
@@ -160,6 +175,42 @@ type DBConfiguration struct {
 
 	// TChannel exposes TChannel config options.
 	TChannel *TChannelConfiguration `yaml:"tchannel"`
+
+	// TagInterning configures interning of the tag names and values of indexed
+	// series that are not part of the series ID. If not provided, interning is
+	// disabled.
+	TagInterning *intern.Configuration `yaml:"tagInterning"`
+
+	// IndexCardinality configures the estimates of the number of unique series
+	// indexed per namespace and tag name served on the debug listen address.
+	// If not provided, no estimates are maintained.
+	IndexCardinality *cardinality.Configuration `yaml:"indexCardinality"`
+
+	// HotShards configures the detection of the busiest shards, which are
+	// logged periodically and served on the debug listen address. If not
+	// provided, shard activity is only reported as metrics.
+	HotShards *hotshards.Configuration `yaml:"hotShards"`
+
+	// Tiering configures moving the data files of fileset volumes older than
+	// an age to object storage, they are restored and cached locally when
+	// read. If not provided, data files are only stored locally.
+	Tiering *tiering.Configuration `yaml:"tiering"`
+
+	// FlushDownsample configures writing coarser resolution copies of series
+	// blocks to namespaces with longer retention as the blocks are flushed. If
+	// not provided, flushed blocks are not downsampled.
+	FlushDownsample *downsample.Configuration `yaml:"flushDownsample"`
+
+	// Capacity configures publishing the resource usage of the node to KV for
+	// the capacity planner API of the coordinator. If not provided, the node
+	// does not publish its resource usage.
+	Capacity *capacity.Configuration `yaml:"capacity"`
+
+	// FaultInjection enables injecting faults into the node tchannel
+	// connections and the commit log and snapshot file syncs, controllable
+	// from the debug listen address. It must only be set in test and
+	// non-production deployments. If not provided, no faults are injected.
+	FaultInjection *fault.Configuration `yaml:"faultInjection"`
 }
 
 // InitDefaultsAndValidate initializes all default values and validates the Configuration.
@@ -217,6 +268,12 @@ type IndexConfiguration struct {
 	// block boundaries by eagerly writing the series to the next block
 	// preemptively.
 	ForwardIndexThreshold float64 `yaml:"forwardIndexThreshold" validate:"min=0.0,max=1.0"`
+
+	// FlushMaxSegmentDocs is the max number of documents written to a single
+	// segment when flushing an index block, larger blocks are written as
+	// multiple segments of the block's volume so the entire block is not
+	// built in memory at once. Defaults to no limit.
+	FlushMaxSegmentDocs int `yaml:"flushMaxSegmentDocs" validate:"min=0"`
 }
 
 // TransformConfiguration contains configuration options that can transform
@@ -332,6 +389,19 @@ type RepairPolicy struct {
 	DebugShadowComparisonsPercentage float64 `yaml:"debugShadowComparisonsPercentage"`
 }
 
+// ScrubPolicy is the fileset scrub policy.
+type ScrubPolicy struct {
+	// Enabled or disabled.
+	Enabled bool `yaml:"enabled"`
+
+	// The interval between scrubs of all filesets, defaults to a day.
+	Interval time.Duration `yaml:"interval"`
+
+	// Whether to schedule a repair from peers for blocks with corrupt
+	// filesets, requires repair to be enabled.
+	RepairCorrupt bool `yaml:"repairCorrupt"`
+}
+
 // ReplicationPolicy is the replication policy.
 type ReplicationPolicy struct {
 	Clusters []ReplicatedCluster `yaml:"clusters"`
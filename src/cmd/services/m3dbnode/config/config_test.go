@@ -345,6 +345,7 @@ func TestConfiguration(t *testing.T) {
     maxQueryIDsConcurrency: 0
     forwardIndexProbability: 0
     forwardIndexThreshold: 0
+    flushMaxSegmentDocs: 0
   transforms:
     truncateBy: 0
     forceValue: null
@@ -409,6 +410,7 @@ func TestConfiguration(t *testing.T) {
     useV2BatchAPIs: null
     writeTimestampOffset: null
   gcPercentage: 100
+  gcTuning: null
   writeNewSeriesLimitPerSecond: 1048576
   writeNewSeriesBackoffDuration: 2ms
   tick: null
@@ -419,10 +421,13 @@ func TestConfiguration(t *testing.T) {
     - noop-all
     fs:
       numProcessorsPerCPU: 0.42
+      readLimitMbps: 0
     commitlog:
       returnUnfulfilledForCorruptCommitLogFiles: false
+      accumulateConcurrency: 0
     peers: null
     cacheSeriesMetadata: null
+    readyPercent: null
   blockRetrieve: null
   cache:
     series: null
@@ -444,6 +449,7 @@ func TestConfiguration(t *testing.T) {
     force_index_summaries_mmap_memory: true
     force_bloom_filter_mmap_memory: true
     bloomFilterFalsePositivePercent: null
+    readMode: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -458,6 +464,7 @@ func TestConfiguration(t *testing.T) {
     checkInterval: 1m0s
     debugShadowComparisonsEnabled: false
     debugShadowComparisonsPercentage: 0
+  scrub: null
   replication: null
   pooling:
     blockAllocSize: 16
@@ -717,8 +724,19 @@ func TestConfiguration(t *testing.T) {
     maxRecentlyQueriedSeriesBlocks: null
     maxOutstandingWriteRequests: 0
     maxOutstandingReadRequests: 0
+    maxFetchTaggedResultBytes: 0
+    maxFetchTaggedDuration: 0s
+    maxIndexQueryTerms: 0
     maxOutstandingRepairedBytes: 0
+    memory: null
   tchannel: null
+  tagInterning: null
+  indexCardinality: null
+  hotShards: null
+  tiering: null
+  flushDownsample: null
+  capacity: null
+  faultInjection: null
 coordinator: null
 `
 
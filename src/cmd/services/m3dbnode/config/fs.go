@@ -23,6 +23,8 @@ package config
 import (
 	"fmt"
 	"os"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 )
 
 const (
@@ -98,6 +100,11 @@ type FilesystemConfiguration struct {
 	// BloomFilterFalsePositivePercent controls the target false positive percentage
 	// for the bloom filters for the fileset files.
 	BloomFilterFalsePositivePercent *float64 `yaml:"bloomFilterFalsePositivePercent"`
+
+	// ReadMode is the mode used to read fileset files when bootstrapping and
+	// repairing, one of mmap, mmap_sequential or direct. The non-default modes
+	// avoid these large sequential reads evicting the page cache used by queries.
+	ReadMode *fs.ReadMode `yaml:"readMode"`
 }
 
 // Validate validates the Filesystem configuration. We use this method to validate
@@ -257,6 +264,15 @@ func (f FilesystemConfiguration) BloomFilterFalsePositivePercentOrDefault() floa
 	return defaultBloomFilterFalsePositivePercent
 }
 
+// ReadModeOrDefault returns the configured fileset read mode if configured, or a
+// default value otherwise.
+func (f FilesystemConfiguration) ReadModeOrDefault() fs.ReadMode {
+	if f.ReadMode != nil {
+		return *f.ReadMode
+	}
+	return fs.DefaultReadMode
+}
+
 // MmapConfiguration is the mmap configuration.
 type MmapConfiguration struct {
 	// HugeTLB is the huge pages configuration which will only take affect
@@ -20,7 +20,11 @@
 
 package config
 
-import "time"
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/memory"
+)
 
 // LimitsConfiguration contains configuration for configurable limits that can be applied to M3DB.
 type LimitsConfiguration struct {
@@ -41,12 +45,35 @@ type LimitsConfiguration struct {
 	// this value is independent of the number of time series being read.
 	MaxOutstandingReadRequests int `yaml:"maxOutstandingReadRequests" validate:"min=0"`
 
+	// MaxFetchTaggedResultBytes controls the maximum number of encoded bytes that a single
+	// fetch tagged request will return. Once the limit is reached the remaining series are
+	// dropped and the result is returned as non-exhaustive so callers can detect partial
+	// results instead of the node buffering an unbounded amount of data.
+	MaxFetchTaggedResultBytes int64 `yaml:"maxFetchTaggedResultBytes" validate:"min=0"`
+
+	// MaxFetchTaggedDuration controls the maximum time that a single fetch tagged request
+	// will spend reading results. Once the limit is reached the remaining series are dropped
+	// and the result is returned as non-exhaustive, or as a query limit exceeded error if
+	// the request requires an exhaustive result.
+	MaxFetchTaggedDuration time.Duration `yaml:"maxFetchTaggedDuration"`
+
+	// MaxIndexQueryTerms controls the maximum number of index terms that a single query
+	// or aggregate query will scan in each index block. Once the limit is reached the
+	// terms left unscanned are skipped and the result is returned as non-exhaustive, so
+	// that broad regexp matches return partial results instead of scanning every term.
+	MaxIndexQueryTerms int `yaml:"maxIndexQueryTerms" validate:"min=0"`
+
 	// MaxOutstandingRepairedBytes controls the maximum number of bytes that can be loaded into memory
 	// as part of the repair process. For example if the value was set to 2^31 then up to 2GiB of
 	// repaired data could be "outstanding" in memory at one time. Once that limit was hit, the repair
 	// process would pause until some of the repaired bytes had been persisted to disk (and subsequently
 	// evicted from memory) at which point it would resume.
 	MaxOutstandingRepairedBytes int64 `yaml:"maxOutstandingRepairedBytes" validate:"min=0"`
+
+	// Memory configures load shedding as heap usage approaches a limit, writes
+	// of new series are rejected once usage reaches the reject new series
+	// watermark.
+	Memory *memory.Configuration `yaml:"memory"`
 }
 
 // MaxRecentlyQueriedSeriesBlocksConfiguration sets the upper limit on time
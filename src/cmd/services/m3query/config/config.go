@@ -26,14 +26,21 @@ import (
 	"time"
 
 	etcdclient "github.com/m3db/m3/src/cluster/client/etcd"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/alerting"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
 	ingestm3msg "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/m3msg"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/schema"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/recording"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/server/m3msg"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/graphite/graphite"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
 	"github.com/m3db/m3/src/query/storage/m3"
 	"github.com/m3db/m3/src/query/storage/m3/consolidators"
 	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
@@ -43,7 +50,10 @@ import (
 	xdocs "github.com/m3db/m3/src/x/docs"
 	"github.com/m3db/m3/src/x/instrument"
 	xlog "github.com/m3db/m3/src/x/log"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/opentracing"
+	"github.com/m3db/m3/src/x/retry"
+	xtls "github.com/m3db/m3/src/x/tls"
 )
 
 // BackendStorageType is an enum for different backends.
@@ -60,8 +70,10 @@ const (
 	// coordinators used only to serve m3admin APIs.
 	NoopEtcdStorageType BackendStorageType = "noop-etcd"
 
-	defaultCarbonIngesterListenAddress = "0.0.0.0:7204"
-	errNoIDGenerationScheme            = "error: a recent breaking change means that an ID " +
+	defaultCarbonIngesterListenAddress    = "0.0.0.0:7204"
+	defaultWavefrontIngesterListenAddress = "0.0.0.0:2878"
+	defaultOTLPGRPCListenAddress          = "0.0.0.0:4317"
+	errNoIDGenerationScheme               = "error: a recent breaking change means that an ID " +
 		"generation scheme is required in coordinator configuration settings. " +
 		"More information is available here: %s"
 
@@ -106,6 +118,9 @@ type Configuration struct {
 	// ListenAddress is the server listen address.
 	ListenAddress *listenaddress.Configuration `yaml:"listenAddress" validate:"nonzero"`
 
+	// TLS configures TLS for the HTTP API server (optional).
+	TLS *xtls.Configuration `yaml:"tls"`
+
 	// Filter is the read/write/complete tags filter configuration.
 	Filter FilterConfiguration `yaml:"filter"`
 
@@ -124,6 +139,10 @@ type Configuration struct {
 	// WriteWorkerPool is the worker pool policy for write requests.
 	WriteWorkerPool xconfig.WorkerPoolPolicy `yaml:"writeWorkerPoolPolicy"`
 
+	// WriteRetry is an optional retry policy for writes to storage, which
+	// when set allows writes to succeed through brief storage unavailability.
+	WriteRetry *retry.Configuration `yaml:"writeRetry"`
+
 	// WriteForwarding is the write forwarding options.
 	WriteForwarding WriteForwardingConfiguration `yaml:"writeForwarding"`
 
@@ -136,12 +155,29 @@ type Configuration struct {
 	// Carbon is the carbon configuration.
 	Carbon *CarbonConfiguration `yaml:"carbon"`
 
+	// Wavefront is the Wavefront data format ingestion configuration.
+	Wavefront *WavefrontConfiguration `yaml:"wavefront"`
+
+	// OTLP is the OpenTelemetry OTLP metrics ingestion configuration.
+	OTLP *OTLPConfiguration `yaml:"otlp"`
+
 	// Query is the query configuration.
 	Query QueryConfiguration `yaml:"query"`
 
 	// Limits specifies limits on per-query resource usage.
 	Limits LimitsConfiguration `yaml:"limits"`
 
+	// Auth configures authentication and authorization of the HTTP API.
+	Auth AuthConfiguration `yaml:"auth"`
+
+	// AccessLog configures structured access logs of the query and write
+	// endpoints.
+	AccessLog AccessLogConfiguration `yaml:"accessLog"`
+
+	// ConfigOverrides configures overrides of this configuration stored in
+	// KV that are applied at runtime without a restart (optional).
+	ConfigOverrides *ConfigOverridesConfiguration `yaml:"configOverrides"`
+
 	// LookbackDuration determines the lookback duration for queries
 	LookbackDuration *time.Duration `yaml:"lookbackDuration"`
 
@@ -162,6 +198,34 @@ type Configuration struct {
 
 	// MultiProcess is the multi-process configuration.
 	MultiProcess MultiProcessConfiguration `yaml:"multiProcess"`
+
+	// Exemplars is an optional configuration for retaining exemplars
+	// received via Prometheus remote write in memory so they can be
+	// queried.
+	Exemplars *exemplar.Configuration `yaml:"exemplars"`
+
+	// IngestQuotas is an optional configuration for per-tenant quotas on
+	// the datapoints and new series written via Prometheus remote write.
+	IngestQuotas *quota.Configuration `yaml:"ingestQuotas"`
+
+	// WriteSchemas is an optional configuration for validating the series
+	// written to each namespace, rejecting series that violate it.
+	WriteSchemas *schema.Configuration `yaml:"writeSchemas"`
+
+	// CardinalityAnalyzer is an optional configuration for detecting the
+	// groups of series creating the most new series, and optionally
+	// limiting their new series rate.
+	CardinalityAnalyzer *cardinality.Configuration `yaml:"cardinalityAnalyzer"`
+
+	// RecordingRules is an optional configuration for rules that
+	// periodically evaluate PromQL expressions and write the results back
+	// as new series.
+	RecordingRules *recording.Configuration `yaml:"recordingRules"`
+
+	// AlertingRules is an optional configuration for rules that
+	// periodically evaluate PromQL conditions and send notifications for
+	// the alerts that fire to a webhook.
+	AlertingRules *alerting.Configuration `yaml:"alertingRules"`
 }
 
 // WriteForwardingConfiguration is the write forwarding configuration.
@@ -206,6 +270,69 @@ type ResultOptions struct {
 	// KeepNans keeps NaNs before returning query results.
 	// The default is false, which matches Prometheus
 	KeepNans bool `yaml:"keepNans"`
+
+	// Decimation enables consolidating the results of range queries whose
+	// step is much finer than the resolution of the data they read.
+	Decimation *DecimationConfiguration `yaml:"decimation"`
+}
+
+const defaultDecimationMinFactor = 10
+
+// DecimationConfiguration is the configuration for decimating the results
+// of range queries. Results are only decimated if the resolution of all
+// data read is known, i.e. only when reading aggregated namespaces.
+type DecimationConfiguration struct {
+	// MinFactor is the minimum ratio of the resolution of the data a range
+	// query reads to its step at which results are decimated, defaults to 10.
+	// Decimated results have a step of the largest multiple of the query
+	// step that does not exceed the resolution of the data.
+	MinFactor int `yaml:"minFactor"`
+
+	// Consolidation is the function that consolidates the values of each
+	// decimated step, defaults to last.
+	Consolidation DecimationConsolidation `yaml:"consolidation"`
+}
+
+// MinFactorOrDefault returns the configured minimum decimation factor or the
+// default.
+func (c DecimationConfiguration) MinFactorOrDefault() int {
+	if c.MinFactor > 1 {
+		return c.MinFactor
+	}
+	return defaultDecimationMinFactor
+}
+
+// DecimationConsolidation is a function that consolidates the values of
+// each decimated step.
+type DecimationConsolidation string
+
+const (
+	// DecimationConsolidationLast takes the last value of each step.
+	DecimationConsolidationLast DecimationConsolidation = "last"
+	// DecimationConsolidationAvg takes the average value of each step.
+	DecimationConsolidationAvg DecimationConsolidation = "avg"
+	// DecimationConsolidationMin takes the minimum value of each step.
+	DecimationConsolidationMin DecimationConsolidation = "min"
+	// DecimationConsolidationMax takes the maximum value of each step.
+	DecimationConsolidationMax DecimationConsolidation = "max"
+)
+
+// UnmarshalYAML unmarshals a decimation consolidation, defaulting to last.
+func (c *DecimationConsolidation) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	switch consolidation := DecimationConsolidation(str); consolidation {
+	case "":
+		*c = DecimationConsolidationLast
+	case DecimationConsolidationLast, DecimationConsolidationAvg,
+		DecimationConsolidationMin, DecimationConsolidationMax:
+		*c = consolidation
+	default:
+		return fmt.Errorf("invalid decimation consolidation: %s", str)
+	}
+	return nil
 }
 
 // QueryConfiguration is the query configuration.
@@ -221,6 +348,18 @@ type QueryConfiguration struct {
 	// RestrictTags is an optional configuration that can be set to restrict
 	// all queries with certain tags by.
 	RestrictTags *RestrictTagsConfiguration `yaml:"restrictTags"`
+	// ResultCache is an optional configuration for caching range query results.
+	ResultCache *ResultCacheConfiguration `yaml:"resultCache"`
+}
+
+// ResultCacheConfiguration is the configuration for the range query
+// result cache.
+type ResultCacheConfiguration struct {
+	// MaxBytes is the maximum estimated size of the cached query results.
+	MaxBytes int `yaml:"maxBytes" validate:"min=1"`
+	// MinAge is how far in the past a step must be for its result to be
+	// cached, so that steps still receiving writes are always evaluated.
+	MinAge time.Duration `yaml:"minAge"`
 }
 
 // TimeoutOrDefault returns the configured timeout or default value.
@@ -275,6 +414,11 @@ type StringMatch struct {
 type ConsolidationConfiguration struct {
 	// MatchType determines the options by which series should match.
 	MatchType consolidators.MatchType `yaml:"matchType"`
+	// StitchStoragePolicies enables stitching together results for metrics
+	// stored under multiple storage policies by default, reading recent
+	// parts of a query from the most granular namespace and older parts from
+	// namespaces with longer retention. Can be overridden per query.
+	StitchStoragePolicies bool `yaml:"stitchStoragePolicies"`
 }
 
 // PrometheusQueryConfiguration is the prometheus query engine configuration.
@@ -302,6 +446,19 @@ type LimitsConfiguration struct {
 	// instance.
 	Global GlobalLimitsConfiguration `yaml:"global"`
 
+	// PerTenant configures limits which apply to the queries of each tenant,
+	// as identified by the M3-Tenant header, running on this instance.
+	PerTenant PerTenantLimitsConfiguration `yaml:"perTenant"`
+
+	// Memory configures load shedding as heap usage approaches a limit, the
+	// lowest priority queries are rejected once usage reaches the shed
+	// queries watermark.
+	Memory *memory.Configuration `yaml:"memory"`
+
+	// Blocklist lists query patterns that are rejected or deprioritized,
+	// stored in KV config overrides it can be changed without a restart.
+	Blocklist []BlockedQueryConfiguration `yaml:"blocklist"`
+
 	// deprecated: use PerQuery.MaxComputedDatapoints instead.
 	DeprecatedMaxComputedDatapoints int `yaml:"maxComputedDatapoints"`
 }
@@ -319,6 +476,133 @@ func (lc LimitsConfiguration) MaxComputedDatapoints() int {
 	return lc.DeprecatedMaxComputedDatapoints
 }
 
+// PerTenantLimitsConfiguration represents limits on the queries of each
+// tenant running on a query instance. Zero or negative values imply no limit.
+type PerTenantLimitsConfiguration struct {
+	// MaxConcurrentQueries limits the number of queries a single tenant may
+	// have executing at once.
+	MaxConcurrentQueries int `yaml:"maxConcurrentQueries"`
+
+	// MaxQueueTime limits how long a query may wait for one of its tenant's
+	// slots to become available before it is rejected.
+	MaxQueueTime time.Duration `yaml:"maxQueueTime"`
+
+	// Overrides maps tenants to a max concurrent queries limit that is used
+	// instead of MaxConcurrentQueries for that tenant.
+	Overrides map[string]int `yaml:"overrides"`
+
+	// MaxTotalConcurrentQueries limits the number of queries of all tenants
+	// executing at once. Once reached, queued queries are admitted in
+	// weighted fair order: the next query admitted is that of the tenant
+	// with the fewest executing queries relative to its weight.
+	MaxTotalConcurrentQueries int `yaml:"maxTotalConcurrentQueries"`
+
+	// Weights maps tenants to their share of MaxTotalConcurrentQueries
+	// relative to other tenants, tenants without a weight have a weight of 1.
+	Weights map[string]int `yaml:"weights"`
+
+	// MaxTenants limits the number of tenants whose queries are tracked
+	// separately, defaults to 1000. Queries of further tenants share the
+	// limits of the default tenant until idle tenants are forgotten.
+	MaxTenants int `yaml:"maxTenants"`
+
+	// TenantIdleTimeout is how long a tenant without queries is tracked
+	// before it is forgotten, defaults to 10 minutes.
+	TenantIdleTimeout time.Duration `yaml:"tenantIdleTimeout"`
+}
+
+// BlockedQueryAction is the action applied to queries matching a blocked
+// query pattern.
+type BlockedQueryAction string
+
+const (
+	// BlockedQueryReject rejects matching queries.
+	BlockedQueryReject BlockedQueryAction = "reject"
+	// BlockedQueryDeprioritize marks matching queries as low priority, so
+	// they are the first to be shed under memory pressure.
+	BlockedQueryDeprioritize BlockedQueryAction = "deprioritize"
+)
+
+// UnmarshalYAML unmarshals a blocked query action, defaulting to reject.
+func (a *BlockedQueryAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	switch action := BlockedQueryAction(str); action {
+	case "":
+		*a = BlockedQueryReject
+	case BlockedQueryReject, BlockedQueryDeprioritize:
+		*a = action
+	default:
+		return fmt.Errorf("invalid blocked query action: %s", str)
+	}
+	return nil
+}
+
+// BlockedQueryConfiguration is a query pattern, identified by the fingerprint
+// of the matchers of its selectors as reported by the running queries API.
+type BlockedQueryConfiguration struct {
+	// Fingerprint is the fingerprint of the query pattern.
+	Fingerprint string `yaml:"fingerprint" validate:"nonzero"`
+
+	// Action is the action applied to matching queries, defaults to reject.
+	Action BlockedQueryAction `yaml:"action"`
+}
+
+// AuthConfiguration configures authentication and authorization of the HTTP
+// API. Roles are one of read, write or admin, each role granting the access
+// of the roles before it.
+type AuthConfiguration struct {
+	// Enabled enables authentication and authorization of requests.
+	Enabled bool `yaml:"enabled"`
+
+	// APIKeys maps API keys, sent as a bearer token in the Authorization
+	// header, to the principal they identify.
+	APIKeys map[string]string `yaml:"apiKeys"`
+
+	// PrincipalHeader is a header trusted to carry the principal of the
+	// request, only set this when requests are authenticated by a proxy.
+	PrincipalHeader string `yaml:"principalHeader"`
+
+	// Principals maps principals to their role. Principals may also be
+	// identified by the common name of a verified TLS client certificate.
+	Principals map[string]string `yaml:"principals"`
+
+	// DefaultRole is the role of authenticated principals that are not
+	// listed in Principals, if empty such principals are denied.
+	DefaultRole string `yaml:"defaultRole"`
+
+	// Routes maps path prefixes to the role required to access them,
+	// overriding the default role of the route. The longest matching prefix
+	// applies and the role none allows unauthenticated access.
+	Routes map[string]string `yaml:"routes"`
+}
+
+// AccessLogConfiguration configures structured access logs of the query and
+// write endpoints. Access logs are disabled if neither a slow threshold nor a
+// sample rate is set.
+type AccessLogConfiguration struct {
+	// SlowThreshold logs every request that takes at least this long.
+	SlowThreshold time.Duration `yaml:"slowThreshold"`
+
+	// SampleRate logs one in every SampleRate requests that are faster than
+	// the slow threshold.
+	SampleRate int `yaml:"sampleRate" validate:"min=0"`
+}
+
+// ConfigOverridesConfiguration is the configuration for overrides of the
+// configuration stored in KV. Overrides are a YAML document stored as a
+// string proto, of which only the log level and per-tenant limits are
+// currently applied at runtime.
+type ConfigOverridesConfiguration struct {
+	// Key is the KV key the overrides are stored under.
+	Key string `yaml:"key" validate:"nonzero"`
+
+	// KVConfig configures the KV store the overrides are stored in.
+	KVConfig kv.OverrideConfiguration `yaml:"kvConfig"`
+}
+
 // GlobalLimitsConfiguration represents limits on resource usage across a query
 // instance. Zero or negative values imply no limit.
 type GlobalLimitsConfiguration struct {
@@ -422,6 +706,55 @@ type CarbonIngesterConfiguration struct {
 	Rules           []CarbonIngesterRuleConfiguration `yaml:"rules"`
 }
 
+// WavefrontConfiguration is the configuration for the Wavefront server.
+type WavefrontConfiguration struct {
+	Ingester *WavefrontIngesterConfiguration `yaml:"ingester"`
+}
+
+// WavefrontIngesterConfiguration is the configuration struct for Wavefront
+// data format ingestion.
+type WavefrontIngesterConfiguration struct {
+	ListenAddress  string `yaml:"listenAddress"`
+	MaxConcurrency int    `yaml:"maxConcurrency"`
+	// MaxDatapointsPerSecond limits the datapoints ingested per second across
+	// all connections, datapoints over the limit are dropped. Zero disables
+	// the limit.
+	MaxDatapointsPerSecond int64 `yaml:"maxDatapointsPerSecond"`
+}
+
+// ListenAddressOrDefault returns the specified Wavefront ingester listen
+// address if provided, or the default value if not.
+func (c *WavefrontIngesterConfiguration) ListenAddressOrDefault() string {
+	if c.ListenAddress != "" {
+		return c.ListenAddress
+	}
+
+	return defaultWavefrontIngesterListenAddress
+}
+
+// OTLPConfiguration is the configuration for OpenTelemetry OTLP metrics
+// ingestion, the OTLP/HTTP endpoint is always served by the API server.
+type OTLPConfiguration struct {
+	// GRPC is the OTLP/gRPC metrics service configuration, the service is
+	// not served if not set.
+	GRPC *OTLPGRPCConfiguration `yaml:"grpc"`
+}
+
+// OTLPGRPCConfiguration is the configuration for the OTLP/gRPC metrics service.
+type OTLPGRPCConfiguration struct {
+	ListenAddress string `yaml:"listenAddress"`
+}
+
+// ListenAddressOrDefault returns the specified OTLP/gRPC listen address if
+// provided, or the default OTLP/gRPC port if not.
+func (c *OTLPGRPCConfiguration) ListenAddressOrDefault() string {
+	if c.ListenAddress != "" {
+		return c.ListenAddress
+	}
+
+	return defaultOTLPGRPCListenAddress
+}
+
 // LookbackDurationOrDefault validates the LookbackDuration
 func (c Configuration) LookbackDurationOrDefault() (time.Duration, error) {
 	if c.LookbackDuration == nil {
@@ -494,6 +827,16 @@ type CarbonIngesterRuleConfiguration struct {
 	Continue    bool                                       `yaml:"continue"`
 	Aggregation CarbonIngesterAggregationConfiguration     `yaml:"aggregation"`
 	Policies    []CarbonIngesterStoragePolicyConfiguration `yaml:"policies"`
+	Mapping     *CarbonIngesterMappingConfiguration        `yaml:"mapping"`
+}
+
+// CarbonIngesterMappingConfiguration is the configuration struct for mapping
+// carbon metrics matched by a rule to tagged metrics rather than graphite
+// style metrics. The name and tag values are templates expanded with the
+// capture groups of the rule pattern, i.e. "$1" or "${service}".
+type CarbonIngesterMappingConfiguration struct {
+	Name string            `yaml:"name" validate:"nonzero"`
+	Tags map[string]string `yaml:"tags"`
 }
 
 // CarbonIngesterAggregationConfiguration is the configuration struct
@@ -282,3 +282,17 @@ func TestKeepNaNsDefault(t *testing.T) {
 	r = ResultOptions{}
 	assert.Equal(t, false, r.KeepNans)
 }
+
+func TestDecimationConfiguration(t *testing.T) {
+	var cfg DecimationConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte("consolidation: max"), &cfg))
+	assert.Equal(t, DecimationConsolidationMax, cfg.Consolidation)
+	assert.Equal(t, defaultDecimationMinFactor, cfg.MinFactorOrDefault())
+
+	cfg = DecimationConfiguration{}
+	require.NoError(t, yaml.Unmarshal([]byte("minFactor: 5\nconsolidation: \"\""), &cfg))
+	assert.Equal(t, DecimationConsolidationLast, cfg.Consolidation)
+	assert.Equal(t, 5, cfg.MinFactorOrDefault())
+
+	require.Error(t, yaml.Unmarshal([]byte("consolidation: median"), &cfg))
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	etcdclient "github.com/m3db/m3/src/cluster/client/etcd"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/dbnode/backup"
+	xconfig "github.com/m3db/m3/src/x/config"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"go.uber.org/zap"
+)
+
+const backupIDTimeFormat = "20060102T150405Z"
+
+var (
+	optAction = flag.String("action", "", "Action: backup, restore, restore-kv or list")
+	optStore  = flag.String("object-store-path", "",
+		"Object store directory, for instance a mounted S3 or GCS bucket")
+	optPathPrefix = flag.String("path-prefix", "",
+		"Fileset path prefix, empty to skip filesets")
+	optHostID   = flag.String("host-id", "", "ID of the host backed up or restored to")
+	optBackupID = flag.String("backup-id", "",
+		"Backup point ID, defaults to the current time when backing up")
+	optSourceHostID = flag.String("source-host-id", "",
+		"ID of the host to restore filesets from, defaults to the host ID")
+	optOverwrite = flag.Bool("overwrite", false,
+		"Overwrite local fileset files that differ from the backup")
+	optEtcdConfig = flag.String("etcd-config", "",
+		"Cluster client config file, empty to skip KV state")
+	optPlacementServices = flag.String("placement-services", "m3db",
+		"Comma separated services whose placements are backed up")
+)
+
+func main() {
+	flag.Parse()
+	if *optAction == "" || *optStore == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	rawLogger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("unable to create logger: %+v", err)
+	}
+	logger := rawLogger.Sugar()
+
+	store, err := objectstore.NewFilesystemStore(*optStore)
+	if err != nil {
+		logger.Fatalf("unable to create object store: %v", err)
+	}
+
+	iOpts := instrument.NewOptions().SetLogger(rawLogger)
+	opts := backup.NewOptions().
+		SetObjectStore(store).
+		SetFilePathPrefix(*optPathPrefix).
+		SetHostID(*optHostID).
+		SetInstrumentOptions(iOpts)
+	if *optEtcdConfig != "" {
+		opts, err = withKVStores(opts, *optEtcdConfig, iOpts)
+		if err != nil {
+			logger.Fatalf("unable to create KV stores: %v", err)
+		}
+	}
+
+	coordinator, err := backup.NewCoordinator(opts)
+	if err != nil {
+		logger.Fatalf("unable to create backup coordinator: %v", err)
+	}
+
+	switch *optAction {
+	case "backup":
+		backupID := *optBackupID
+		if backupID == "" {
+			backupID = time.Now().UTC().Format(backupIDTimeFormat)
+		}
+		result, err := coordinator.Backup(backupID)
+		if err != nil {
+			logger.Fatalf("unable to back up: %v", err)
+		}
+		logger.Infof("created backup %s, uploaded %d files (%d bytes)",
+			backupID, result.FilesUploaded, result.BytesUploaded)
+	case "restore":
+		sourceHostID := *optSourceHostID
+		if sourceHostID == "" {
+			sourceHostID = *optHostID
+		}
+		result, err := coordinator.RestoreNode(*optBackupID, sourceHostID,
+			backup.RestoreOptions{Overwrite: *optOverwrite})
+		if err != nil {
+			logger.Fatalf("unable to restore filesets: %v", err)
+		}
+		logger.Infof("restored %d files (%d bytes), %d files already present",
+			result.FilesRestored, result.BytesRestored, result.FilesSkipped)
+	case "restore-kv":
+		manifest, err := coordinator.RestoreKV(*optBackupID)
+		if err != nil {
+			logger.Fatalf("unable to restore KV state: %v", err)
+		}
+		logger.Infof("restored %d KV keys", len(manifest.Values))
+	case "list":
+		backupIDs, err := coordinator.Backups()
+		if err != nil {
+			logger.Fatalf("unable to list backups: %v", err)
+		}
+		for _, backupID := range backupIDs {
+			hosts, err := coordinator.Hosts(backupID)
+			if err != nil {
+				logger.Fatalf("unable to list hosts of backup %s: %v", backupID, err)
+			}
+			logger.Infof("backup %s: hosts %v", backupID, hosts)
+		}
+	default:
+		logger.Fatalf("unknown action: %s", *optAction)
+	}
+}
+
+func withKVStores(
+	opts backup.Options,
+	configFile string,
+	iOpts instrument.Options,
+) (backup.Options, error) {
+	var cfg etcdclient.Configuration
+	if err := xconfig.LoadFile(&cfg, configFile, xconfig.Options{}); err != nil {
+		return nil, err
+	}
+	client, err := cfg.NewClient(iOpts)
+	if err != nil {
+		return nil, err
+	}
+	kvStore, err := client.KV()
+	if err != nil {
+		return nil, err
+	}
+	svcs, err := client.Services(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		entries  = backup.DefaultKVEntries()
+		storages = make(map[string]placement.Storage)
+	)
+	for _, name := range strings.Split(*optPlacementServices, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		sid := services.NewServiceID().
+			SetName(name).
+			SetEnvironment(cfg.Env).
+			SetZone(cfg.Zone)
+		storage, err := svcs.PlacementService(sid, placement.NewOptions())
+		if err != nil {
+			return nil, err
+		}
+		entry := backup.PlacementKVEntry(cfg.Env, name)
+		entries = append(entries, entry)
+		storages[entry.Key] = storage
+	}
+
+	return opts.
+		SetKVStores(map[string]kv.Store{
+			backup.KVStoreDefault:   kvStore,
+			backup.KVStorePlacement: backup.NewPlacementKVStore(storages),
+		}).
+		SetKVEntries(entries), nil
+}
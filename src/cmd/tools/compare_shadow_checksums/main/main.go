@@ -0,0 +1,100 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// compare_shadow_checksums compares the checksums of the metrics flushed by
+// the aggregators of a primary cluster against those of a shadow cluster
+// receiving the shadowed writes of sampled metric IDs, and exits with a non
+// zero status if any aggregation window does not match.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/verify"
+)
+
+func main() {
+	var (
+		primaryArg   = flag.String("primary", "", "Primary aggregator HTTP addresses, comma separated")
+		shadowArg    = flag.String("shadow", "", "Shadow aggregator HTTP addresses, comma separated")
+		settleArg    = flag.Duration("settle", 5*time.Minute, "Windows more recent than this are not compared since they may still be flushed")
+		toleranceArg = flag.Float64("tolerance", 1e-9, "Relative tolerance comparing the sums of windows")
+		timeoutArg   = flag.Duration("timeout", 30*time.Second, "Timeout fetching checksums from each aggregator")
+	)
+	flag.Parse()
+
+	if *primaryArg == "" || *shadowArg == "" || *settleArg < 0 || *toleranceArg < 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	client := &http.Client{Timeout: *timeoutArg}
+	primary, err := fetchCluster(client, *primaryArg)
+	if err != nil {
+		log.Fatalf("could not fetch primary checksums: %v", err)
+	}
+	shadow, err := fetchCluster(client, *shadowArg)
+	if err != nil {
+		log.Fatalf("could not fetch shadow checksums: %v", err)
+	}
+
+	beforeNanos := time.Now().Add(-*settleArg).UnixNano()
+	mismatches := verify.CompareShadow(primary, shadow, beforeNanos, *toleranceArg)
+	if len(mismatches) == 0 {
+		log.Printf("all windows match: primary windows=%d, shadow windows=%d",
+			len(primary), len(shadow))
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(mismatches); err != nil {
+		log.Fatalf("could not encode mismatches: %v", err)
+	}
+	log.Printf("%d windows do not match", len(mismatches))
+	os.Exit(2)
+}
+
+// fetchCluster fetches the shadow checksums of each aggregator of a cluster
+// and merges them into a single checksum per window.
+func fetchCluster(client *http.Client, addrs string) ([]verify.WindowChecksum, error) {
+	var checksums [][]verify.WindowChecksum
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+			addr = "http://" + addr
+		}
+		res, err := verify.FetchChecksums(client, addr+verify.ShadowHandlerURL)
+		if err != nil {
+			return nil, err
+		}
+		checksums = append(checksums, res)
+	}
+	return verify.Merge(checksums...), nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs/importer"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	optSrcFile        = flag.String("src-file", "", "Source CSV file, or - for stdin")
+	optPathPrefix     = flag.String("path-prefix", "/var/lib/m3db", "Destination Path prefix")
+	optNamespace      = flag.String("namespace", "metrics", "Destination Namespace")
+	optShard          = flag.Uint("shard", 0, "Destination Shard ID")
+	optBlockstart     = flag.Int64("block-start", 0, "Destination Block Start Time [in nsec]")
+	optBlockSize      = flag.Duration("block-size", 0, "Destination Block Size")
+	optIndexBlockSize = flag.Duration("index-block-size", 0, "Destination Index Block Size, 0 if the namespace is not indexed")
+)
+
+func main() {
+	flag.Parse()
+	if *optSrcFile == "" ||
+		*optPathPrefix == "" ||
+		*optNamespace == "" ||
+		*optBlockstart <= 0 ||
+		*optBlockSize <= 0 ||
+		*optIndexBlockSize < 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	rawLogger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("unable to create logger: %+v", err)
+	}
+	logger := rawLogger.Sugar()
+
+	src := os.Stdin
+	if *optSrcFile != "-" {
+		src, err = os.Open(*optSrcFile)
+		if err != nil {
+			logger.Fatalf("unable to open source file: %v", err)
+		}
+		defer src.Close()
+	}
+
+	dest := importer.FileSetID{
+		PathPrefix: *optPathPrefix,
+		Namespace:  *optNamespace,
+		Shard:      uint32(*optShard),
+		Blockstart: xtime.FromNanoseconds(*optBlockstart),
+	}
+
+	logger.Infof("destination: %+v", dest)
+
+	opts := importer.NewOptions()
+	result, err := importer.New(opts).ImportCSV(src, dest, *optBlockSize,
+		*optIndexBlockSize)
+	if err != nil {
+		logger.Fatalf("unable to import: %v", err)
+	}
+
+	logger.Infof("successfully imported %d series with %d datapoints into volume %d",
+		result.NumSeries, result.NumDatapoints, result.VolumeIndex)
+}
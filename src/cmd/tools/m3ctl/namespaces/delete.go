@@ -21,12 +21,15 @@
 package namespaces
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/m3db/m3/src/cmd/tools/m3ctl/client"
 	"go.uber.org/zap"
 )
 
+var errNamespaceNameRequired = errors.New("namespace name is required")
+
 // DoDelete calls the delete namespaces api on the backend
 func DoDelete(
 	endpoint string,
@@ -34,6 +37,9 @@ func DoDelete(
 	nsName string,
 	logger *zap.Logger,
 ) ([]byte, error) {
+	if nsName == "" {
+		return nil, errNamespaceNameRequired
+	}
 	url := fmt.Sprintf("%s%s/%s", endpoint, "/api/v1/services/m3db/namespace", nsName)
 	return client.DoDelete(url, headers, logger)
 }
@@ -21,11 +21,14 @@
 package namespaces
 
 import (
+	"bytes"
 	"fmt"
 
-	"go.uber.org/zap"
-
 	"github.com/m3db/m3/src/cmd/tools/m3ctl/client"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"go.uber.org/zap"
 )
 
 // DoGet calls the backend get namespace api
@@ -37,3 +40,22 @@ func DoGet(
 	url := fmt.Sprintf("%s%s?%s", endpoint, DefaultPath, DebugQS)
 	return client.DoGet(url, headers, logger)
 }
+
+// Get calls the backend get namespace api and returns the decoded response
+func Get(
+	endpoint string,
+	headers map[string]string,
+	logger *zap.Logger,
+) (*admin.NamespaceGetResponse, error) {
+	url := fmt.Sprintf("%s%s", endpoint, DefaultPath)
+	data, err := client.DoGet(url, headers, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &admin.NamespaceGetResponse{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), resp); err != nil {
+		return nil, fmt.Errorf("unable to decode namespaces: %v", err)
+	}
+	return resp, nil
+}
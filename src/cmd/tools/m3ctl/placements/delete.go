@@ -21,6 +21,7 @@
 package placements
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/m3db/m3/src/cmd/tools/m3ctl/client"
@@ -28,6 +29,8 @@ import (
 	"go.uber.org/zap"
 )
 
+var errNodeNameRequired = errors.New("node name is required to delete a node")
+
 // DoDelete does the delete api calls for placements
 func DoDelete(
 	endpoint string,
@@ -40,6 +43,9 @@ func DoDelete(
 		url := fmt.Sprintf("%s%s", endpoint, DefaultPath)
 		return client.DoDelete(url, headers, logger)
 	}
+	if nodeName == "" {
+		return nil, errNodeNameRequired
+	}
 	url := fmt.Sprintf("%s%s/%s", endpoint, DefaultPath, nodeName)
 	return client.DoDelete(url, headers, logger)
 }
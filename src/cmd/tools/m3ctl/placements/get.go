@@ -21,10 +21,13 @@
 package placements
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/m3db/m3/src/cmd/tools/m3ctl/client"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"go.uber.org/zap"
 )
 
@@ -37,3 +40,21 @@ func DoGet(
 	url := fmt.Sprintf("%s%s", endpoint, DefaultPath)
 	return client.DoGet(url, headers, logger)
 }
+
+// Get calls the backend api for get placements and returns the decoded response
+func Get(
+	endpoint string,
+	headers map[string]string,
+	logger *zap.Logger,
+) (*admin.PlacementGetResponse, error) {
+	data, err := DoGet(endpoint, headers, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &admin.PlacementGetResponse{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), resp); err != nil {
+		return nil, fmt.Errorf("unable to decode placement: %v", err)
+	}
+	return resp, nil
+}
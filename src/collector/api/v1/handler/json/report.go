@@ -28,6 +28,7 @@ import (
 	"net/http"
 
 	"github.com/m3db/m3/src/collector/reporter"
+	metrictype "github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/id"
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/models"
@@ -99,6 +100,7 @@ func (h *reportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	metrics := make([]reporter.Metric, 0, len(req.Metrics))
 	for _, metric := range req.Metrics {
 		id, err := h.newMetricID(metric)
 		if err != nil {
@@ -106,10 +108,17 @@ func (h *reportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := h.reportMetric(id, metric); err != nil {
+		m, err := newReporterMetric(id, metric)
+		if err != nil {
 			xhttp.Error(w, err.Inner(), err.Code())
 			return
 		}
+		metrics = append(metrics, m)
+	}
+
+	if err := h.reporter.ReportBatch(metrics); err != nil {
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
 	}
 
 	resp := &reportResponse{Reported: len(req.Metrics)}
@@ -160,28 +169,28 @@ func (h *reportHandler) newMetricID(metric metricValue) (id.ID, *xhttp.ParseErro
 	return metricTagsIter, nil
 }
 
-func (h *reportHandler) reportMetric(id id.ID, metric metricValue) *xhttp.ParseError {
-	var err error
+func newReporterMetric(id id.ID, metric metricValue) (reporter.Metric, *xhttp.ParseError) {
+	m := reporter.Metric{ID: id}
 	switch metric.Type {
 	case counterType:
 		roundedValue := math.Ceil(metric.Value)
 		if roundedValue != metric.Value {
 			// Not an int
 			badReqErr := fmt.Errorf("counter value not a float: %v", metric.Value)
-			return xhttp.NewParseError(badReqErr, http.StatusBadRequest)
+			return reporter.Metric{}, xhttp.NewParseError(badReqErr, http.StatusBadRequest)
 		}
 
-		err = h.reporter.ReportCounter(id, int64(roundedValue))
+		m.Type = metrictype.CounterType
+		m.CounterVal = int64(roundedValue)
 	case gaugeType:
-		err = h.reporter.ReportGauge(id, metric.Value)
+		m.Type = metrictype.GaugeType
+		m.GaugeVal = metric.Value
 	case timerType:
-		err = h.reporter.ReportBatchTimer(id, []float64{metric.Value})
+		m.Type = metrictype.TimerType
+		m.BatchTimerVal = []float64{metric.Value}
 	default:
 		badReqErr := fmt.Errorf("invalid metric type: %s", metric.Type)
-		return xhttp.NewParseError(badReqErr, http.StatusBadRequest)
-	}
-	if err != nil {
-		return xhttp.NewParseError(err, http.StatusInternalServerError)
+		return reporter.Metric{}, xhttp.NewParseError(badReqErr, http.StatusBadRequest)
 	}
-	return nil
+	return m, nil
 }
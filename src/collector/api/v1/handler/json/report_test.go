@@ -29,7 +29,7 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/collector/reporter"
-	"github.com/m3db/m3/src/metrics/metric/id"
+	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/serialize"
@@ -69,38 +69,32 @@ func TestReportHandler(t *testing.T) {
 
 	test := newTestReportHandler(ctrl)
 	handler := test.handler
-	reporter := test.reporter
+	mockReporter := test.reporter
 
 	req := newTestReportHandlerRequest(t, reportRequestJSON)
 
-	reporter.EXPECT().
-		ReportCounter(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(id id.ID, value int64) error {
-			tagValue, ok := id.TagValue([]byte("foo"))
+	mockReporter.EXPECT().
+		ReportBatch(gomock.Any()).
+		DoAndReturn(func(metrics []reporter.Metric) error {
+			require.Equal(t, 3, len(metrics))
+
+			tagValue, ok := metrics[0].ID.TagValue([]byte("foo"))
 			require.True(t, ok)
 			assert.Equal(t, "bar", string(tagValue))
-			assert.Equal(t, int64(1), value)
-			return nil
-		})
+			assert.Equal(t, metric.CounterType, metrics[0].Type)
+			assert.Equal(t, int64(1), metrics[0].CounterVal)
 
-	reporter.EXPECT().
-		ReportGauge(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(id id.ID, value float64) error {
-			tagValue, ok := id.TagValue([]byte("foo"))
+			tagValue, ok = metrics[1].ID.TagValue([]byte("foo"))
 			require.True(t, ok)
 			assert.Equal(t, "baz", string(tagValue))
-			assert.Equal(t, 2.42, value)
-			return nil
-		})
+			assert.Equal(t, metric.GaugeType, metrics[1].Type)
+			assert.Equal(t, 2.42, metrics[1].GaugeVal)
 
-	reporter.EXPECT().
-		ReportBatchTimer(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(id id.ID, values []float64) error {
-			tagValue, ok := id.TagValue([]byte("foo"))
+			tagValue, ok = metrics[2].ID.TagValue([]byte("foo"))
 			require.True(t, ok)
 			assert.Equal(t, "qux", string(tagValue))
-			require.Equal(t, 1, len(values))
-			assert.Equal(t, 3.42, values[0])
+			assert.Equal(t, metric.TimerType, metrics[2].Type)
+			assert.Equal(t, []float64{3.42}, metrics[2].BatchTimerVal)
 			return nil
 		})
 
@@ -49,6 +49,7 @@ type reporterMetrics struct {
 	reportCounter    instrument.MethodMetrics
 	reportBatchTimer instrument.MethodMetrics
 	reportGauge      instrument.MethodMetrics
+	reportBatch      instrument.MethodMetrics
 	reportPending    tally.Gauge
 	flush            instrument.MethodMetrics
 }
@@ -67,6 +68,7 @@ func newReporterMetrics(instrumentOpts instrument.Options) reporterMetrics {
 		reportCounter:    instrument.NewMethodMetrics(scope, "report-counter", timerOpts),
 		reportBatchTimer: instrument.NewMethodMetrics(scope, "report-batch-timer", timerOpts),
 		reportGauge:      instrument.NewMethodMetrics(scope, "report-gauge", timerOpts),
+		reportBatch:      instrument.NewMethodMetrics(scope, "report-batch", timerOpts),
 		flush:            instrument.NewMethodMetrics(scope, "flush", timerOpts),
 		reportPending:    hostScope.Gauge("report-pending"),
 	}
@@ -229,6 +231,55 @@ func (r *reporter) ReportGauge(id id.ID, value float64) error {
 	return err
 }
 
+// ReportBatch matches each metric of the batch and writes the metrics that
+// resolve to the same staged metadatas to the aggregator in a single batch.
+func (r *reporter) ReportBatch(metrics []creporter.Metric) error {
+	var (
+		reportAt  = r.nowFn()
+		fromNanos = reportAt.Add(-r.maxNegativeSkew).UnixNano()
+		toNanos   = reportAt.Add(r.maxPositiveSkew).UnixNano()
+		batches   untimedBatches
+		multiErr  = xerrors.NewMultiError()
+	)
+	r.incrementReportPending()
+	for _, m := range metrics {
+		mu := unaggregated.MetricUnion{
+			Type:          m.Type,
+			ID:            m.ID.Bytes(),
+			CounterVal:    m.CounterVal,
+			BatchTimerVal: m.BatchTimerVal,
+			GaugeVal:      m.GaugeVal,
+		}
+		matchResult := r.matcher.ForwardMatch(m.ID, fromNanos, toNanos)
+
+		stagedMetadatas := matchResult.ForExistingIDAt(fromNanos)
+		if !stagedMetadatas.IsDropPolicyApplied() {
+			batches = batches.add(mu, stagedMetadatas)
+		}
+
+		for idx := 0; idx < matchResult.NumNewRollupIDs(); idx++ {
+			rollupIDWithMetadatas := matchResult.ForNewRollupIDsAt(idx, fromNanos)
+			if isTombstoned(rollupIDWithMetadatas.Metadatas, fromNanos) {
+				continue
+			}
+			rollup := mu
+			rollup.ID = rollupIDWithMetadatas.ID
+			batches = batches.add(rollup, rollupIDWithMetadatas.Metadatas)
+		}
+	}
+
+	for _, batch := range batches {
+		if err := r.client.WriteUntimedBatch(batch.metrics, batch.metadatas); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	err := multiErr.FinalError()
+	r.metrics.reportBatch.ReportSuccessOrError(err, r.nowFn().Sub(reportAt))
+	r.decrementReportPending()
+	return err
+}
+
 func (r *reporter) Flush() error {
 	callStart := r.nowFn()
 	err := r.client.Flush()
@@ -271,6 +322,32 @@ func (r *reporter) reportMetrics() {
 	}
 }
 
+// untimedBatch is a batch of untimed metrics sharing the same staged metadatas.
+type untimedBatch struct {
+	metadatas metadata.StagedMetadatas
+	metrics   []unaggregated.MetricUnion
+}
+
+type untimedBatches []untimedBatch
+
+// add adds the metric to the batch with the same staged metadatas, there are
+// few distinct metadatas in a batch so they are searched linearly.
+func (b untimedBatches) add(
+	metric unaggregated.MetricUnion,
+	metadatas metadata.StagedMetadatas,
+) untimedBatches {
+	for i := range b {
+		if b[i].metadatas.Equal(metadatas) {
+			b[i].metrics = append(b[i].metrics, metric)
+			return b
+		}
+	}
+	return append(b, untimedBatch{
+		metadatas: metadatas,
+		metrics:   []unaggregated.MetricUnion{metric},
+	})
+}
+
 // isTombstoned checks to see if the last metadata is currently active and indicates
 // the metric ID has been tombstoned. This is a small optimization so that we don't
 // send tombstoned rollup metrics to the m3aggregator only to be rejected there to
@@ -31,9 +31,11 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/aggregator/client"
+	creporter "github.com/m3db/m3/src/collector/reporter"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/matcher"
 	"github.com/m3db/m3/src/metrics/metadata"
+	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/id"
 	"github.com/m3db/m3/src/metrics/metric/unaggregated"
 	"github.com/m3db/m3/src/metrics/policy"
@@ -433,6 +435,63 @@ func TestReporterReportGauge(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestReporterReportBatch(t *testing.T) {
+	leakCheck := leaktest.Check(t)
+	defer leakCheck()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	type untimedBatch struct {
+		metrics   []unaggregated.MetricUnion
+		metadatas metadata.StagedMetadatas
+	}
+	var actual []untimedBatch
+
+	counterID := id.NewMockID(ctrl)
+	counterID.EXPECT().Bytes().Return([]byte("testCounter"))
+	gaugeID := id.NewMockID(ctrl)
+	gaugeID.EXPECT().Bytes().Return([]byte("testGauge"))
+	mockMatcher := matcher.NewMockMatcher(ctrl)
+	mockMatcher.EXPECT().ForwardMatch(counterID, testFromNanos, testToNanos).Return(testMatchResult)
+	mockMatcher.EXPECT().ForwardMatch(gaugeID, testFromNanos, testToNanos).Return(testMatchResult)
+	mockMatcher.EXPECT().Close().Return(nil).AnyTimes()
+	mockClient := client.NewMockClient(ctrl)
+	mockClient.EXPECT().
+		WriteUntimedBatch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(metrics []unaggregated.MetricUnion, metadatas metadata.StagedMetadatas) error {
+			actual = append(actual, untimedBatch{metrics: metrics, metadatas: metadatas})
+			return nil
+		}).Times(2)
+	mockClient.EXPECT().Close().Return(nil).AnyTimes()
+	reporter := NewReporter(mockMatcher, mockClient, testReporterOptions)
+	defer reporter.Close()
+
+	require.NoError(t, reporter.ReportBatch([]creporter.Metric{
+		{ID: counterID, Type: metric.CounterType, CounterVal: 1234},
+		{ID: gaugeID, Type: metric.GaugeType, GaugeVal: 5.6},
+	}))
+
+	// The metrics and their rollups are each written in a single batch.
+	expected := []untimedBatch{
+		{
+			metrics: []unaggregated.MetricUnion{
+				{Type: metric.CounterType, ID: []byte("testCounter"), CounterVal: 1234},
+				{Type: metric.GaugeType, ID: []byte("testGauge"), GaugeVal: 5.6},
+			},
+			metadatas: testMatchForExistingID,
+		},
+		{
+			metrics: []unaggregated.MetricUnion{
+				{Type: metric.CounterType, ID: []byte("foo"), CounterVal: 1234},
+				{Type: metric.GaugeType, ID: []byte("foo"), GaugeVal: 5.6},
+			},
+			metadatas: metadata.DefaultStagedMetadatas,
+		},
+	}
+	require.Equal(t, expected, actual)
+}
+
 func TestReporterFlush(t *testing.T) {
 	leakCheck := leaktest.Check(t)
 	defer leakCheck()
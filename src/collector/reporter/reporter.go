@@ -21,9 +21,19 @@
 package reporter
 
 import (
+	"github.com/m3db/m3/src/metrics/metric"
 	"github.com/m3db/m3/src/metrics/metric/id"
 )
 
+// Metric is a metric reported as part of a batch.
+type Metric struct {
+	ID            id.ID
+	Type          metric.Type
+	CounterVal    int64
+	BatchTimerVal []float64
+	GaugeVal      float64
+}
+
 // Reporter reports aggregated metrics.
 type Reporter interface {
 	// ReportCounter reports a counter metric.
@@ -35,6 +45,9 @@ type Reporter interface {
 	// ReportGauge reports a gauge metric.
 	ReportGauge(id id.ID, value float64) error
 
+	// ReportBatch reports a batch of metrics.
+	ReportBatch(metrics []Metric) error
+
 	// Flush flushes any buffered metrics.
 	Flush() error
 
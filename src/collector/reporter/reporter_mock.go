@@ -83,6 +83,20 @@ func (mr *MockReporterMockRecorder) Flush() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockReporter)(nil).Flush))
 }
 
+// ReportBatch mocks base method
+func (m *MockReporter) ReportBatch(arg0 []Metric) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReportBatch", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReportBatch indicates an expected call of ReportBatch
+func (mr *MockReporterMockRecorder) ReportBatch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReportBatch", reflect.TypeOf((*MockReporter)(nil).ReportBatch), arg0)
+}
+
 // ReportBatchTimer mocks base method
 func (m *MockReporter) ReportBatchTimer(arg0 id.ID, arg1 []float64) error {
 	m.ctrl.T.Helper()
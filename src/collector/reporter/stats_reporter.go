@@ -0,0 +1,325 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reporter
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/serialize"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMetricNameTag is the tag the metric name is reported under.
+	DefaultMetricNameTag = "__name__"
+
+	// maxReportAttempts is the number of times a metric is reported before
+	// it is dropped.
+	maxReportAttempts = 3
+)
+
+var errEncoderNoBytes = errors.New("tags encoder has no access to bytes")
+
+type statsReporterMetrics struct {
+	reportErrors tally.Counter
+	flushErrors  tally.Counter
+	dropped      tally.Counter
+	buffered     tally.Gauge
+}
+
+func newStatsReporterMetrics(scope tally.Scope) statsReporterMetrics {
+	return statsReporterMetrics{
+		reportErrors: scope.Counter("report-errors"),
+		flushErrors:  scope.Counter("flush-errors"),
+		dropped:      scope.Counter("dropped"),
+		buffered:     scope.Gauge("buffered"),
+	}
+}
+
+type bufferedMetricType int
+
+const (
+	bufferedCounter bufferedMetricType = iota
+	bufferedGauge
+	bufferedTimer
+)
+
+// bufferedMetric is a metric yet to be reported through the reporter. The
+// tags are those of the tally scope, which are never mutated.
+type bufferedMetric struct {
+	metricType bufferedMetricType
+	name       string
+	tags       map[string]string
+	counter    int64
+	value      float64
+	attempts   int
+}
+
+// statsReporter is a tally stats reporter that reports metrics through
+// a reporter, so applications can emit tagged metrics straight to the
+// aggregator using a regular tally scope without encoding metric IDs.
+type statsReporter struct {
+	sync.Mutex
+
+	reporter           Reporter
+	encoderPool        serialize.TagEncoderPool
+	metricTagsIterPool serialize.MetricTagsIteratorPool
+	maxBatchSize       int
+	maxBufferedMetrics int
+	logger             *zap.Logger
+	metrics            statsReporterMetrics
+
+	buffered    []bufferedMetric
+	unavailable bool
+}
+
+// NewStatsReporter returns a tally stats reporter that reports counters,
+// gauges and timers through the given reporter. The metric name is added
+// to the tags of each metric under the DefaultMetricNameTag tag and timers
+// are reported in seconds. Histograms are not supported.
+//
+// Metrics are buffered and reported in batches, either once a batch is
+// buffered or when the scope is flushed. Metrics that fail to be reported,
+// such as while the reporter's client is reconnecting to the aggregator,
+// remain buffered and are retried on subsequent flushes.
+func NewStatsReporter(
+	reporter Reporter,
+	opts StatsReporterOptions,
+) (tally.StatsReporter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	instrumentOpts := opts.InstrumentOptions()
+	scope := instrumentOpts.MetricsScope().SubScope("stats-reporter")
+	return &statsReporter{
+		reporter:           reporter,
+		encoderPool:        opts.TagEncoderPool(),
+		metricTagsIterPool: opts.MetricTagsIteratorPool(),
+		maxBatchSize:       opts.MaxBatchSize(),
+		maxBufferedMetrics: opts.MaxBufferedMetrics(),
+		logger:             instrumentOpts.Logger(),
+		metrics:            newStatsReporterMetrics(scope),
+		buffered:           make([]bufferedMetric, 0, opts.MaxBatchSize()),
+	}, nil
+}
+
+func (r *statsReporter) ReportCounter(
+	name string,
+	tags map[string]string,
+	value int64,
+) {
+	r.buffer(bufferedMetric{
+		metricType: bufferedCounter,
+		name:       name,
+		tags:       tags,
+		counter:    value,
+	})
+}
+
+func (r *statsReporter) ReportGauge(
+	name string,
+	tags map[string]string,
+	value float64,
+) {
+	r.buffer(bufferedMetric{
+		metricType: bufferedGauge,
+		name:       name,
+		tags:       tags,
+		value:      value,
+	})
+}
+
+func (r *statsReporter) ReportTimer(
+	name string,
+	tags map[string]string,
+	interval time.Duration,
+) {
+	r.buffer(bufferedMetric{
+		metricType: bufferedTimer,
+		name:       name,
+		tags:       tags,
+		value:      interval.Seconds(),
+	})
+}
+func (r *statsReporter) ReportHistogramValueSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound float64,
+	samples int64,
+) {
+}
+
+func (r *statsReporter) ReportHistogramDurationSamples(
+	name string,
+	tags map[string]string,
+	buckets tally.Buckets,
+	bucketLowerBound,
+	bucketUpperBound time.Duration,
+	samples int64,
+) {
+}
+
+func (r *statsReporter) Capabilities() tally.Capabilities {
+	return r
+}
+
+func (r *statsReporter) Reporting() bool {
+	return true
+}
+
+func (r *statsReporter) Tagging() bool {
+	return true
+}
+
+func (r *statsReporter) Flush() {
+	r.Lock()
+	r.reportBuffered()
+	r.Unlock()
+
+	if err := r.reporter.Flush(); err != nil {
+		r.metrics.flushErrors.Inc(1)
+		r.logger.Error("could not flush reporter", zap.Error(err))
+	}
+}
+
+func (r *statsReporter) buffer(m bufferedMetric) {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(r.buffered) >= r.maxBufferedMetrics {
+		r.metrics.dropped.Inc(1)
+		return
+	}
+	r.buffered = append(r.buffered, m)
+
+	// Wait for the next flush to retry if the reporter was unavailable
+	// when the last batch was reported.
+	if len(r.buffered) >= r.maxBatchSize && !r.unavailable {
+		r.reportBuffered()
+	}
+}
+
+// reportBuffered reports the buffered metrics in order, stopping at the
+// first metric that fails to be reported so that it and the metrics after
+// it are retried on the next flush.
+func (r *statsReporter) reportBuffered() {
+	r.unavailable = false
+
+	reported := 0
+	for i := range r.buffered {
+		m := &r.buffered[i]
+		if err := r.report(m); err != nil {
+			r.handleReportError(m.name, err)
+			m.attempts++
+			if m.attempts < maxReportAttempts {
+				r.unavailable = true
+				break
+			}
+			r.metrics.dropped.Inc(1)
+		}
+		reported++
+	}
+
+	n := copy(r.buffered, r.buffered[reported:])
+	for i := n; i < len(r.buffered); i++ {
+		// Release the references to the tags of the metrics reported.
+		r.buffered[i] = bufferedMetric{}
+	}
+	r.buffered = r.buffered[:n]
+	r.metrics.buffered.Update(float64(n))
+}
+
+func (r *statsReporter) report(m *bufferedMetric) error {
+	metricID, err := r.newMetricID(m.name, m.tags)
+	if err != nil {
+		// The metric can never be encoded so is not retried.
+		m.attempts = maxReportAttempts
+		return err
+	}
+	defer metricID.Close()
+
+	switch m.metricType {
+	case bufferedCounter:
+		return r.reporter.ReportCounter(metricID, m.counter)
+	case bufferedGauge:
+		return r.reporter.ReportGauge(metricID, m.value)
+	default:
+		return r.reporter.ReportBatchTimer(metricID, []float64{m.value})
+	}
+}
+
+func (r *statsReporter) handleReportError(name string, err error) {
+	r.metrics.reportErrors.Inc(1)
+	r.logger.Error("could not report metric",
+		zap.String("name", name), zap.Error(err))
+}
+
+func (r *statsReporter) newMetricID(
+	name string,
+	tags map[string]string,
+) (serialize.MetricTagsIterator, error) {
+	names := make([]string, 0, len(tags))
+	for n := range tags {
+		if n == DefaultMetricNameTag {
+			continue
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	identTags := make([]ident.Tag, 0, len(names)+1)
+	identTags = append(identTags, ident.StringTag(DefaultMetricNameTag, name))
+	for _, n := range names {
+		identTags = append(identTags, ident.StringTag(n, tags[n]))
+	}
+	tagsIter := ident.NewTagsIterator(ident.NewTags(identTags...))
+	defer tagsIter.Close()
+
+	encoder := r.encoderPool.Get()
+	encoder.Reset()
+	defer encoder.Finalize()
+
+	if err := encoder.Encode(tagsIter); err != nil {
+		return nil, err
+	}
+
+	data, ok := encoder.Data()
+	if !ok {
+		return nil, errEncoderNoBytes
+	}
+
+	// Take a copy of the pooled encoder's bytes, the returned ID must be
+	// closed to return its decoder to the pool.
+	bytes := append([]byte(nil), data.Bytes()...)
+
+	metricTagsIter := r.metricTagsIterPool.Get()
+	metricTagsIter.Reset(bytes)
+	return metricTagsIter, nil
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package reporter
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/pool"
+	"github.com/m3db/m3/src/x/serialize"
+)
+
+const (
+	defaultMaxBatchSize       = 1024
+	defaultMaxBufferedMetrics = 65536
+)
+
+var (
+	errNoTagEncoderPool         = errors.New("no tag encoder pool set")
+	errNoMetricTagsIteratorPool = errors.New("no metric tags iterator pool set")
+	errInvalidMaxBatchSize      = errors.New("max batch size must be positive")
+	errInvalidMaxBuffered       = errors.New(
+		"max buffered metrics must be at least the max batch size")
+)
+
+// StatsReporterOptions provide a set of options for the stats reporter.
+type StatsReporterOptions interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetTagEncoderPool sets the tag encoder pool.
+	SetTagEncoderPool(value serialize.TagEncoderPool) StatsReporterOptions
+
+	// TagEncoderPool returns the tag encoder pool.
+	TagEncoderPool() serialize.TagEncoderPool
+
+	// SetMetricTagsIteratorPool sets the pool of the metric IDs reported.
+	SetMetricTagsIteratorPool(
+		value serialize.MetricTagsIteratorPool,
+	) StatsReporterOptions
+
+	// MetricTagsIteratorPool returns the pool of the metric IDs reported.
+	MetricTagsIteratorPool() serialize.MetricTagsIteratorPool
+
+	// SetMaxBatchSize sets the number of metrics buffered before they are
+	// reported without waiting for a flush.
+	SetMaxBatchSize(value int) StatsReporterOptions
+
+	// MaxBatchSize returns the number of metrics buffered before they are
+	// reported without waiting for a flush.
+	MaxBatchSize() int
+
+	// SetMaxBufferedMetrics sets the max number of metrics buffered while
+	// they cannot be reported, beyond which further metrics are dropped.
+	SetMaxBufferedMetrics(value int) StatsReporterOptions
+
+	// MaxBufferedMetrics returns the max number of metrics buffered while
+	// they cannot be reported, beyond which further metrics are dropped.
+	MaxBufferedMetrics() int
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) StatsReporterOptions
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+}
+
+type statsReporterOptions struct {
+	encoderPool        serialize.TagEncoderPool
+	metricTagsIterPool serialize.MetricTagsIteratorPool
+	maxBatchSize       int
+	maxBufferedMetrics int
+	instrumentOpts     instrument.Options
+}
+
+// NewStatsReporterOptions creates a new set of stats reporter options.
+func NewStatsReporterOptions() StatsReporterOptions {
+	encoderPool := serialize.NewTagEncoderPool(
+		serialize.NewTagEncoderOptions(), pool.NewObjectPoolOptions())
+	encoderPool.Init()
+
+	decoderPool := serialize.NewTagDecoderPool(
+		serialize.NewTagDecoderOptions(serialize.TagDecoderOptionsConfig{}),
+		pool.NewObjectPoolOptions())
+	decoderPool.Init()
+
+	metricTagsIterPool := serialize.NewMetricTagsIteratorPool(decoderPool,
+		pool.NewObjectPoolOptions())
+	metricTagsIterPool.Init()
+
+	return &statsReporterOptions{
+		encoderPool:        encoderPool,
+		metricTagsIterPool: metricTagsIterPool,
+		maxBatchSize:       defaultMaxBatchSize,
+		maxBufferedMetrics: defaultMaxBufferedMetrics,
+		instrumentOpts:     instrument.NewOptions(),
+	}
+}
+
+func (o *statsReporterOptions) Validate() error {
+	if o.encoderPool == nil {
+		return errNoTagEncoderPool
+	}
+	if o.metricTagsIterPool == nil {
+		return errNoMetricTagsIteratorPool
+	}
+	if o.maxBatchSize <= 0 {
+		return errInvalidMaxBatchSize
+	}
+	if o.maxBufferedMetrics < o.maxBatchSize {
+		return errInvalidMaxBuffered
+	}
+	return nil
+}
+
+func (o *statsReporterOptions) SetTagEncoderPool(
+	value serialize.TagEncoderPool,
+) StatsReporterOptions {
+	opts := *o
+	opts.encoderPool = value
+	return &opts
+}
+
+func (o *statsReporterOptions) TagEncoderPool() serialize.TagEncoderPool {
+	return o.encoderPool
+}
+
+func (o *statsReporterOptions) SetMetricTagsIteratorPool(
+	value serialize.MetricTagsIteratorPool,
+) StatsReporterOptions {
+	opts := *o
+	opts.metricTagsIterPool = value
+	return &opts
+}
+
+func (o *statsReporterOptions) MetricTagsIteratorPool() serialize.MetricTagsIteratorPool {
+	return o.metricTagsIterPool
+}
+
+func (o *statsReporterOptions) SetMaxBatchSize(value int) StatsReporterOptions {
+	opts := *o
+	opts.maxBatchSize = value
+	return &opts
+}
+
+func (o *statsReporterOptions) MaxBatchSize() int {
+	return o.maxBatchSize
+}
+
+func (o *statsReporterOptions) SetMaxBufferedMetrics(value int) StatsReporterOptions {
+	opts := *o
+	opts.maxBufferedMetrics = value
+	return &opts
+}
+
+func (o *statsReporterOptions) MaxBufferedMetrics() int {
+	return o.maxBufferedMetrics
+}
+
+func (o *statsReporterOptions) SetInstrumentOptions(
+	value instrument.Options,
+) StatsReporterOptions {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *statsReporterOptions) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
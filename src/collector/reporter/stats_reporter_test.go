@@ -0,0 +1,265 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reporter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/metrics/metric/id"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/pool"
+	"github.com/m3db/m3/src/x/serialize"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestStatsReporterOptions(scope tally.Scope) StatsReporterOptions {
+	poolOpts := pool.NewObjectPoolOptions().SetSize(1)
+	tagEncoderPool := serialize.NewTagEncoderPool(
+		serialize.NewTagEncoderOptions(),
+		poolOpts)
+	tagEncoderPool.Init()
+	tagDecoderPool := serialize.NewTagDecoderPool(
+		serialize.NewTagDecoderOptions(serialize.TagDecoderOptionsConfig{}),
+		poolOpts)
+	tagDecoderPool.Init()
+	metricTagsIterPool := serialize.NewMetricTagsIteratorPool(tagDecoderPool,
+		poolOpts)
+	metricTagsIterPool.Init()
+
+	return NewStatsReporterOptions().
+		SetTagEncoderPool(tagEncoderPool).
+		SetMetricTagsIteratorPool(metricTagsIterPool).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+}
+
+func newTestStatsReporter(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	opts StatsReporterOptions,
+) (*MockReporter, tally.StatsReporter) {
+	reporter := NewMockReporter(ctrl)
+	statsReporter, err := NewStatsReporter(reporter, opts)
+	require.NoError(t, err)
+	return reporter, statsReporter
+}
+
+func requireTagValue(t *testing.T, id id.ID, name, expected string) {
+	value, ok := id.TagValue([]byte(name))
+	require.True(t, ok)
+	assert.Equal(t, expected, string(value))
+}
+
+func TestStatsReporterReportsMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reporter, statsReporter := newTestStatsReporter(t, ctrl,
+		newTestStatsReporterOptions(tally.NoopScope))
+	tags := map[string]string{"foo": "bar"}
+
+	reporter.EXPECT().
+		ReportCounter(gomock.Any(), int64(3)).
+		DoAndReturn(func(id id.ID, _ int64) error {
+			requireTagValue(t, id, DefaultMetricNameTag, "requests")
+			requireTagValue(t, id, "foo", "bar")
+			return nil
+		})
+	reporter.EXPECT().
+		ReportGauge(gomock.Any(), 4.2).
+		DoAndReturn(func(id id.ID, _ float64) error {
+			requireTagValue(t, id, DefaultMetricNameTag, "inflight")
+			return nil
+		})
+	reporter.EXPECT().
+		ReportBatchTimer(gomock.Any(), []float64{1.5}).
+		DoAndReturn(func(id id.ID, _ []float64) error {
+			requireTagValue(t, id, DefaultMetricNameTag, "latency")
+			return nil
+		})
+	reporter.EXPECT().Flush().Return(nil)
+
+	statsReporter.ReportCounter("requests", tags, 3)
+	statsReporter.ReportGauge("inflight", tags, 4.2)
+	statsReporter.ReportTimer("latency", tags, 1500*time.Millisecond)
+	statsReporter.Flush()
+}
+
+func TestStatsReporterCountsErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl,
+		newTestStatsReporterOptions(scope))
+
+	reporter.EXPECT().
+		ReportCounter(gomock.Any(), gomock.Any()).
+		Return(errors.New("boom"))
+	reporter.EXPECT().Flush().Return(errors.New("boom"))
+
+	statsReporter.ReportCounter("requests", nil, 1)
+	statsReporter.Flush()
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1),
+		counters["stats-reporter.report-errors+"].Value())
+	require.Equal(t, int64(1),
+		counters["stats-reporter.flush-errors+"].Value())
+}
+
+func TestStatsReporterRetriesUnreportedMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl,
+		newTestStatsReporterOptions(scope))
+
+	// The gauge is only reported once the counter before it is reported.
+	gomock.InOrder(
+		reporter.EXPECT().ReportCounter(gomock.Any(), int64(1)).
+			Return(errors.New("not connected")),
+		reporter.EXPECT().Flush().Return(nil),
+		reporter.EXPECT().ReportCounter(gomock.Any(), int64(1)).Return(nil),
+		reporter.EXPECT().ReportGauge(gomock.Any(), 2.0).Return(nil),
+		reporter.EXPECT().Flush().Return(nil),
+	)
+
+	statsReporter.ReportCounter("requests", nil, 1)
+	statsReporter.ReportGauge("inflight", nil, 2)
+	statsReporter.Flush()
+	statsReporter.Flush()
+
+	// Nothing remains to be reported.
+	reporter.EXPECT().Flush().Return(nil)
+	statsReporter.Flush()
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1),
+		counters["stats-reporter.report-errors+"].Value())
+}
+
+func TestStatsReporterDropsMetricsAfterMaxAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl,
+		newTestStatsReporterOptions(scope))
+
+	reporter.EXPECT().ReportCounter(gomock.Any(), int64(1)).
+		Return(errors.New("boom")).Times(maxReportAttempts)
+	reporter.EXPECT().Flush().Return(nil).Times(maxReportAttempts + 1)
+
+	statsReporter.ReportCounter("requests", nil, 1)
+	for i := 0; i <= maxReportAttempts; i++ {
+		statsReporter.Flush()
+	}
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1),
+		counters["stats-reporter.dropped+"].Value())
+}
+
+func TestStatsReporterReportsFullBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newTestStatsReporterOptions(tally.NoopScope).
+		SetMaxBatchSize(2)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl, opts)
+
+	statsReporter.ReportCounter("requests", nil, 1)
+
+	// Reported without a flush once the batch is full.
+	reporter.EXPECT().ReportCounter(gomock.Any(), gomock.Any()).
+		Return(nil).Times(2)
+	statsReporter.ReportCounter("requests", nil, 2)
+}
+
+func TestStatsReporterDropsMetricsWhenBufferFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := newTestStatsReporterOptions(scope).
+		SetMaxBatchSize(1).
+		SetMaxBufferedMetrics(2)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl, opts)
+
+	gomock.InOrder(
+		reporter.EXPECT().ReportCounter(gomock.Any(), int64(1)).
+			Return(errors.New("not connected")),
+		reporter.EXPECT().ReportCounter(gomock.Any(), int64(1)).Return(nil),
+		reporter.EXPECT().ReportCounter(gomock.Any(), int64(2)).Return(nil),
+		reporter.EXPECT().Flush().Return(nil),
+	)
+
+	// The reporter is unavailable so the second metric is buffered without
+	// being reported and the third is dropped.
+	statsReporter.ReportCounter("requests", nil, 1)
+	statsReporter.ReportCounter("requests", nil, 2)
+	statsReporter.ReportCounter("requests", nil, 3)
+	statsReporter.Flush()
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1),
+		counters["stats-reporter.dropped+"].Value())
+}
+
+func TestStatsReporterReturnsMetricIDsToPool(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tagDecoderPool := serialize.NewTagDecoderPool(
+		serialize.NewTagDecoderOptions(serialize.TagDecoderOptionsConfig{}),
+		pool.NewObjectPoolOptions().SetSize(1))
+	tagDecoderPool.Init()
+
+	metricTagsIterPool := serialize.NewMockMetricTagsIteratorPool(ctrl)
+	metricTagsIter := serialize.NewMetricTagsIterator(tagDecoderPool.Get(),
+		metricTagsIterPool)
+	metricTagsIterPool.EXPECT().Get().Return(metricTagsIter)
+	metricTagsIterPool.EXPECT().Put(metricTagsIter)
+
+	opts := newTestStatsReporterOptions(tally.NoopScope).
+		SetMetricTagsIteratorPool(metricTagsIterPool)
+	reporter, statsReporter := newTestStatsReporter(t, ctrl, opts)
+
+	reporter.EXPECT().ReportCounter(metricTagsIter, int64(1)).Return(nil)
+	reporter.EXPECT().Flush().Return(nil)
+
+	statsReporter.ReportCounter("requests", nil, 1)
+	statsReporter.Flush()
+}
+
+func TestStatsReporterOptionsValidate(t *testing.T) {
+	require.NoError(t, NewStatsReporterOptions().Validate())
+	require.Error(t, NewStatsReporterOptions().SetMaxBatchSize(0).Validate())
+	require.Error(t, NewStatsReporterOptions().
+		SetMaxBatchSize(2).SetMaxBufferedMetrics(1).Validate())
+}
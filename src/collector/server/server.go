@@ -31,13 +31,14 @@ import (
 	clusterclient "github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/cmd/services/m3collector/config"
 	"github.com/m3db/m3/src/collector/api/v1/httpd"
-	"github.com/m3db/m3/src/collector/reporter"
+	creporter "github.com/m3db/m3/src/collector/reporter"
 	"github.com/m3db/m3/src/collector/reporter/m3aggregator"
 	xconfig "github.com/m3db/m3/src/x/config"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/serialize"
 
+	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
@@ -90,6 +91,30 @@ func Run(runOpts RunOptions) {
 	if err != nil {
 		logger.Fatal("could not create reporter", zap.Error(err))
 	}
+	defer func() {
+		if err := reporter.Close(); err != nil {
+			logger.Error("could not close reporter", zap.Error(err))
+		}
+	}()
+
+	handlerInstrumentOpts := instrumentOpts
+	if selfCfg := cfg.SelfReporting; selfCfg != nil {
+		logger.Info("creating self reporting metrics scope")
+		statsReporter, err := creporter.NewStatsReporter(reporter,
+			selfCfg.NewStatsReporterOptions(instrumentOpts))
+		if err != nil {
+			logger.Fatal("could not create stats reporter", zap.Error(err))
+		}
+
+		selfScope, selfCloser := tally.NewRootScope(tally.ScopeOptions{
+			Prefix:   selfCfg.Prefix,
+			Tags:     selfCfg.Tags,
+			Reporter: statsReporter,
+		}, selfCfg.ReportingInterval)
+		defer selfCloser.Close()
+
+		handlerInstrumentOpts = instrumentOpts.SetMetricsScope(selfScope)
+	}
 
 	tagEncoderOptions := serialize.NewTagEncoderOptions()
 	tagDecoderOptions := serialize.NewTagDecoderOptions(serialize.TagDecoderOptionsConfig{})
@@ -110,7 +135,7 @@ func Run(runOpts RunOptions) {
 
 	logger.Info("creating http handlers and registering routes")
 	handler, err := httpd.NewHandler(reporter, tagEncoderPool,
-		tagDecoderPool, instrumentOpts)
+		tagDecoderPool, handlerInstrumentOpts)
 	if err != nil {
 		logger.Fatal("unable to set up handlers", zap.Error(err))
 	}
@@ -167,7 +192,7 @@ func newReporter(
 	cfg config.ReporterConfiguration,
 	clusterClient clusterclient.Client,
 	instrumentOpts instrument.Options,
-) (reporter.Reporter, error) {
+) (creporter.Reporter, error) {
 	scope := instrumentOpts.MetricsScope()
 	logger := instrumentOpts.Logger()
 	clockOpts := cfg.Clock.NewOptions()
@@ -0,0 +1,474 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	backupsPrefix        = "backups/"
+	filesPrefix          = "files/"
+	hostsDir             = "hosts"
+	kvManifestName       = "kv.json"
+	manifestSuffix       = ".json"
+	filesetFilePrefix    = "fileset-"
+	filesetFileSuffix    = ".db"
+	checkpointFileSuffix = "-checkpoint.db"
+)
+
+var errInvalidBackupID = errors.New("backup ID must be non-empty and not contain slashes")
+
+type coordinatorMetrics struct {
+	filesUploaded  tally.Counter
+	bytesUploaded  tally.Counter
+	filesRestored  tally.Counter
+	bytesRestored  tally.Counter
+	volumesSkipped tally.Counter
+}
+
+func newCoordinatorMetrics(scope tally.Scope) coordinatorMetrics {
+	return coordinatorMetrics{
+		filesUploaded:  scope.Counter("files-uploaded"),
+		bytesUploaded:  scope.Counter("bytes-uploaded"),
+		filesRestored:  scope.Counter("files-restored"),
+		bytesRestored:  scope.Counter("bytes-restored"),
+		volumesSkipped: scope.Counter("volumes-skipped"),
+	}
+}
+
+type coordinator struct {
+	opts    Options
+	store   objectstore.Store
+	nowFn   clock.NowFn
+	logger  *zap.Logger
+	metrics coordinatorMetrics
+}
+
+// NewCoordinator returns a new backup coordinator.
+func NewCoordinator(opts Options) (Coordinator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	iOpts := opts.InstrumentOptions()
+	return &coordinator{
+		opts:    opts,
+		store:   opts.ObjectStore(),
+		nowFn:   opts.ClockOptions().NowFn(),
+		logger:  iOpts.Logger(),
+		metrics: newCoordinatorMetrics(iOpts.MetricsScope().SubScope("backup")),
+	}, nil
+}
+
+func (c *coordinator) Backup(backupID string) (BackupResult, error) {
+	if err := validateBackupID(backupID); err != nil {
+		return BackupResult{}, err
+	}
+
+	var result BackupResult
+	if c.opts.FilePathPrefix() != "" {
+		manifest, err := c.backupNode(backupID, &result)
+		if err != nil {
+			return BackupResult{}, err
+		}
+		result.Node = &manifest
+	}
+	if len(c.opts.KVStores()) > 0 {
+		manifest, err := c.backupKV(backupID)
+		if err != nil {
+			return BackupResult{}, err
+		}
+		result.KV = &manifest
+	}
+	return result, nil
+}
+
+func (c *coordinator) backupNode(
+	backupID string,
+	result *BackupResult,
+) (NodeManifest, error) {
+	filePathPrefix := c.opts.FilePathPrefix()
+	volumes, err := completeFilesetVolumes(filePathPrefix)
+	if err != nil {
+		return NodeManifest{}, err
+	}
+
+	manifest := NodeManifest{
+		BackupID:  backupID,
+		HostID:    c.opts.HostID(),
+		CreatedAt: c.nowFn(),
+	}
+	for _, volume := range volumes {
+		files, err := c.backupVolume(volume, result)
+		if os.IsNotExist(err) {
+			// The volume was removed by a cleanup since it was listed.
+			c.metrics.volumesSkipped.Inc(1)
+			c.logger.Info("skipping removed fileset volume",
+				zap.Strings("files", volume), zap.Error(err))
+			continue
+		}
+		if err != nil {
+			return NodeManifest{}, err
+		}
+		manifest.Files = append(manifest.Files, files...)
+	}
+
+	// The manifest is written last, a backup point only exists once all of
+	// its files are uploaded.
+	key := nodeManifestKey(backupID, manifest.HostID)
+	if err := putJSON(c.store, key, manifest); err != nil {
+		return NodeManifest{}, err
+	}
+	c.logger.Info("backed up fileset volumes",
+		zap.String("backupID", backupID),
+		zap.Int("volumes", len(volumes)),
+		zap.Int("files", len(manifest.Files)),
+		zap.Int("filesUploaded", result.FilesUploaded),
+		zap.Int64("bytesUploaded", result.BytesUploaded))
+	return manifest, nil
+}
+
+func (c *coordinator) backupVolume(volume []string, result *BackupResult) ([]File, error) {
+	files := make([]File, 0, len(volume))
+	for _, relPath := range volume {
+		file, uploaded, err := c.backupFile(relPath)
+		if err != nil {
+			return nil, err
+		}
+		if uploaded {
+			result.FilesUploaded++
+			result.BytesUploaded += file.Size
+			c.metrics.filesUploaded.Inc(1)
+			c.metrics.bytesUploaded.Inc(file.Size)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (c *coordinator) backupFile(relPath string) (File, bool, error) {
+	localPath := filepath.Join(c.opts.FilePathPrefix(), filepath.FromSlash(relPath))
+	size, checksum, err := fileChecksum(localPath)
+	if err != nil {
+		return File{}, false, err
+	}
+
+	file := File{
+		Path:     relPath,
+		Size:     size,
+		Checksum: checksum,
+		Key: fmt.Sprintf("%s%s/%s.%08x", filesPrefix, c.opts.HostID(),
+			relPath, checksum),
+	}
+
+	// Files are stored by content, skip files uploaded by earlier backups.
+	info, err := c.store.Stat(file.Key)
+	if err == nil && info.Size == file.Size {
+		return file, false, nil
+	}
+	if err != nil && err != objectstore.ErrNotFound {
+		return File{}, false, err
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return File{}, false, err
+	}
+	defer f.Close()
+
+	var (
+		hash   = adler32.New()
+		reader = io.TeeReader(f, hash)
+	)
+	if err := c.store.Put(file.Key, reader); err != nil {
+		return File{}, false, err
+	}
+	if hash.Sum32() != checksum {
+		// Fileset files are immutable, this is only possible if the volume
+		// was removed and rewritten while being backed up.
+		c.store.Delete(file.Key)
+		return File{}, false, fmt.Errorf("fileset file %s changed while being backed up",
+			relPath)
+	}
+	return file, true, nil
+}
+
+func (c *coordinator) Backups() ([]string, error) {
+	objects, err := c.store.List(backupsPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		seen   = make(map[string]struct{})
+		result []string
+	)
+	for _, obj := range objects {
+		id := strings.SplitN(strings.TrimPrefix(obj.Key, backupsPrefix), "/", 2)[0]
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (c *coordinator) Hosts(backupID string) ([]string, error) {
+	if err := validateBackupID(backupID); err != nil {
+		return nil, err
+	}
+	prefix := path.Join(backupsPrefix, backupID, hostsDir) + "/"
+	objects, err := c.store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Key, prefix)
+		if strings.HasSuffix(name, manifestSuffix) {
+			result = append(result, strings.TrimSuffix(name, manifestSuffix))
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (c *coordinator) backupKV(backupID string) (KVManifest, error) {
+	var (
+		stores   = c.opts.KVStores()
+		queue    = append([]KVEntry(nil), c.opts.KVEntries()...)
+		seen     = make(map[KVEntry]struct{}, len(queue))
+		manifest = KVManifest{
+			BackupID:  backupID,
+			CreatedAt: c.nowFn(),
+		}
+	)
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		id := KVEntry{Store: entry.Store, Key: entry.Key}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		value, err := stores[entry.Store].Get(entry.Key)
+		if err == kv.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return KVManifest{}, fmt.Errorf("unable to get KV key %s: %v", entry.Key, err)
+		}
+		var raw rawMessage
+		if err := value.Unmarshal(&raw); err != nil {
+			return KVManifest{}, fmt.Errorf("unable to read KV key %s: %v", entry.Key, err)
+		}
+		manifest.Values = append(manifest.Values, KVValue{
+			Store:   entry.Store,
+			Key:     entry.Key,
+			Version: value.Version(),
+			Value:   raw.value,
+		})
+
+		if entry.Expand == nil {
+			continue
+		}
+		keys, err := entry.Expand(raw.value)
+		if err != nil {
+			return KVManifest{}, fmt.Errorf("unable to expand KV key %s: %v", entry.Key, err)
+		}
+		for _, key := range keys {
+			queue = append(queue, KVEntry{Store: entry.Store, Key: key})
+		}
+	}
+
+	if err := putJSON(c.store, kvManifestKey(backupID), manifest); err != nil {
+		return KVManifest{}, err
+	}
+	c.logger.Info("backed up KV state",
+		zap.String("backupID", backupID),
+		zap.Int("keys", len(manifest.Values)))
+	return manifest, nil
+}
+
+// completeFilesetVolumes returns the paths, relative to the file path prefix,
+// of the files of each data and index fileset volume with a checkpoint file.
+// Volumes without a checkpoint file are still being written.
+func completeFilesetVolumes(filePathPrefix string) ([][]string, error) {
+	var (
+		volumes  = make(map[string][]string)
+		complete = make(map[string]bool)
+	)
+	for _, dir := range []string{
+		fs.DataDirPath(filePathPrefix),
+		fs.IndexDataDirPath(filePathPrefix),
+	} {
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				if p == dir && os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			name, ok := filesetVolumeName(info.Name())
+			if !ok {
+				return nil
+			}
+			relPath, err := filepath.Rel(filePathPrefix, p)
+			if err != nil {
+				return err
+			}
+			volume := filepath.Join(filepath.Dir(p), name)
+			volumes[volume] = append(volumes[volume], filepath.ToSlash(relPath))
+			if strings.HasSuffix(info.Name(), checkpointFileSuffix) {
+				complete[volume] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(complete))
+	for volume := range complete {
+		names = append(names, volume)
+	}
+	sort.Strings(names)
+	result := make([][]string, 0, len(names))
+	for _, volume := range names {
+		result = append(result, volumes[volume])
+	}
+	return result, nil
+}
+
+// filesetVolumeName returns the name shared by the files of a fileset volume,
+// "fileset-<block start>-<volume index>", or "fileset-<block start>" for
+// legacy data filesets without a volume index.
+func filesetVolumeName(fileName string) (string, bool) {
+	if !strings.HasPrefix(fileName, filesetFilePrefix) ||
+		!strings.HasSuffix(fileName, filesetFileSuffix) {
+		return "", false
+	}
+	components := strings.Split(strings.TrimSuffix(fileName, filesetFileSuffix), "-")
+	if len(components) < 3 {
+		return "", false
+	}
+	if _, err := strconv.ParseInt(components[1], 10, 64); err != nil {
+		return "", false
+	}
+	n := 2
+	if len(components) > 3 {
+		if _, err := strconv.Atoi(components[2]); err == nil {
+			n = 3
+		}
+	}
+	return strings.Join(components[:n], "-"), true
+}
+
+func fileChecksum(filePath string) (int64, uint32, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	hash := adler32.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return size, hash.Sum32(), nil
+}
+
+func validateBackupID(backupID string) error {
+	if backupID == "" || strings.Contains(backupID, "/") {
+		return errInvalidBackupID
+	}
+	return nil
+}
+
+func nodeManifestKey(backupID, hostID string) string {
+	return path.Join(backupsPrefix, backupID, hostsDir, hostID+manifestSuffix)
+}
+
+func kvManifestKey(backupID string) string {
+	return path.Join(backupsPrefix, backupID, kvManifestName)
+}
+
+func putJSON(store objectstore.Store, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return store.Put(key, bytes.NewReader(data))
+}
+
+func getJSON(store objectstore.Store, key string, v interface{}) error {
+	r, err := store.Get(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(v)
+}
+
+// rawMessage is a protobuf message holding a marshalled value, it allows KV
+// values to be backed up and restored without knowledge of their types.
+type rawMessage struct {
+	value []byte
+}
+
+func (m *rawMessage) Reset()         { m.value = nil }
+func (m *rawMessage) String() string { return fmt.Sprintf("%x", m.value) }
+func (m *rawMessage) ProtoMessage()  {}
+
+func (m *rawMessage) Marshal() ([]byte, error) {
+	return m.value, nil
+}
+
+func (m *rawMessage) Unmarshal(data []byte) error {
+	m.value = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,239 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/metrics/generated/proto/rulepb"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(contents), 0666))
+	}
+}
+
+func readTestFile(t *testing.T, dir, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		srcPrefix  = filepath.Join(dir, "src")
+		destPrefix = filepath.Join(dir, "dest")
+	)
+	writeTestFiles(t, srcPrefix, map[string]string{
+		"data/metrics/0/fileset-1000-0-info.db":       "info",
+		"data/metrics/0/fileset-1000-0-data.db":       "data",
+		"data/metrics/0/fileset-1000-0-checkpoint.db": "checkpoint",
+		// Legacy volume without a volume index.
+		"data/metrics/1/fileset-1000-info.db":       "legacy-info",
+		"data/metrics/1/fileset-1000-checkpoint.db": "legacy-checkpoint",
+		// Volume still being written.
+		"data/metrics/0/fileset-2000-0-info.db":               "incomplete",
+		"index/data/metrics/fileset-1000-0-segment-0-docs.db": "docs",
+		"index/data/metrics/fileset-1000-0-checkpoint.db":     "index-checkpoint",
+		"commitlogs/commitlog-0-0.db":                         "commitlog",
+	})
+
+	objects, err := objectstore.NewFilesystemStore(filepath.Join(dir, "bucket"))
+	require.NoError(t, err)
+
+	srcKV := mem.NewStore()
+	_, err = srcKV.Set(kvconfig.NamespacesKey, &rulepb.Namespace{Name: "registry"})
+	require.NoError(t, err)
+	_, err = srcKV.Set(defaultRuleNamespacesKey, &rulepb.Namespaces{
+		Namespaces: []*rulepb.Namespace{{Name: "foo"}},
+	})
+	require.NoError(t, err)
+	_, err = srcKV.Set("/ruleset/foo", &rulepb.RuleSet{Namespace: "foo", Uuid: "abc"})
+	require.NoError(t, err)
+	srcPlacement := mem.NewStore()
+	_, err = srcPlacement.Set("_sd.placement/prod/m3db", &placementpb.Placement{
+		ReplicaFactor: 3,
+		NumShards:     64,
+	})
+	require.NoError(t, err)
+
+	opts := NewOptions().
+		SetObjectStore(objects).
+		SetFilePathPrefix(srcPrefix).
+		SetHostID("host0").
+		SetKVStores(map[string]kv.Store{
+			KVStoreDefault:   srcKV,
+			KVStorePlacement: srcPlacement,
+		}).
+		SetKVEntries(append(DefaultKVEntries(), PlacementKVEntry("prod", "m3db")))
+	coordinator, err := NewCoordinator(opts)
+	require.NoError(t, err)
+
+	result, err := coordinator.Backup("b1")
+	require.NoError(t, err)
+	require.NotNil(t, result.Node)
+	require.NotNil(t, result.KV)
+	assert.Len(t, result.Node.Files, 7)
+	assert.Equal(t, 7, result.FilesUploaded)
+	assert.Len(t, result.KV.Values, 4)
+
+	// Backing up again only uploads the volumes completed since.
+	result, err = coordinator.Backup("b2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.FilesUploaded)
+
+	writeTestFiles(t, srcPrefix, map[string]string{
+		"data/metrics/0/fileset-2000-0-checkpoint.db": "checkpoint",
+	})
+	result, err = coordinator.Backup("b3")
+	require.NoError(t, err)
+	assert.Len(t, result.Node.Files, 9)
+	assert.Equal(t, 2, result.FilesUploaded)
+
+	backups, err := coordinator.Backups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b1", "b2", "b3"}, backups)
+	hosts, err := coordinator.Hosts("b1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"host0"}, hosts)
+
+	// Rebuild a replacement node and cluster state from the first backup.
+	var (
+		destKV        = mem.NewStore()
+		destPlacement = mem.NewStore()
+	)
+	restorer, err := NewCoordinator(opts.
+		SetFilePathPrefix(destPrefix).
+		SetHostID("host1").
+		SetKVStores(map[string]kv.Store{
+			KVStoreDefault:   destKV,
+			KVStorePlacement: destPlacement,
+		}))
+	require.NoError(t, err)
+
+	restored, err := restorer.RestoreNode("b1", "host0", RestoreOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 7, restored.FilesRestored)
+	assert.Equal(t, "data", readTestFile(t, destPrefix, "data/metrics/0/fileset-1000-0-data.db"))
+	assert.Equal(t, "docs", readTestFile(t, destPrefix,
+		"index/data/metrics/fileset-1000-0-segment-0-docs.db"))
+	_, err = os.Stat(filepath.Join(destPrefix, "data/metrics/0/fileset-2000-0-info.db"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(destPrefix, "commitlogs"))
+	assert.True(t, os.IsNotExist(err))
+
+	restored, err = restorer.RestoreNode("b1", "host0", RestoreOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, restored.FilesRestored)
+	assert.Equal(t, 7, restored.FilesSkipped)
+
+	// Local files differing from the backup are only replaced on request.
+	writeTestFiles(t, destPrefix, map[string]string{
+		"data/metrics/0/fileset-1000-0-data.db": "corrupt",
+	})
+	_, err = restorer.RestoreNode("b1", "host0", RestoreOptions{})
+	require.Error(t, err)
+	restored, err = restorer.RestoreNode("b1", "host0", RestoreOptions{Overwrite: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored.FilesRestored)
+	assert.Equal(t, "data", readTestFile(t, destPrefix, "data/metrics/0/fileset-1000-0-data.db"))
+
+	_, err = restorer.RestoreKV("b1")
+	require.NoError(t, err)
+	value, err := destKV.Get("/ruleset/foo")
+	require.NoError(t, err)
+	var ruleSet rulepb.RuleSet
+	require.NoError(t, value.Unmarshal(&ruleSet))
+	assert.True(t, proto.Equal(&rulepb.RuleSet{Namespace: "foo", Uuid: "abc"}, &ruleSet))
+	_, err = destKV.Get(kvconfig.NamespacesKey)
+	require.NoError(t, err)
+	value, err = destPlacement.Get("_sd.placement/prod/m3db")
+	require.NoError(t, err)
+	var placement placementpb.Placement
+	require.NoError(t, value.Unmarshal(&placement))
+	assert.Equal(t, uint32(3), placement.ReplicaFactor)
+	_, err = destKV.Get("/ruleset/bar")
+	assert.Equal(t, kv.ErrNotFound, err)
+}
+
+func TestBackupInvalid(t *testing.T) {
+	objects, err := objectstore.NewFilesystemStore(os.TempDir())
+	require.NoError(t, err)
+
+	_, err = NewCoordinator(NewOptions())
+	require.Equal(t, errObjectStoreNotSet, err)
+	_, err = NewCoordinator(NewOptions().SetObjectStore(objects))
+	require.Equal(t, errNothingToBackup, err)
+	_, err = NewCoordinator(NewOptions().SetObjectStore(objects).SetFilePathPrefix("/var/lib/m3db"))
+	require.Equal(t, errHostIDNotSet, err)
+
+	_, err = NewCoordinator(NewOptions().
+		SetObjectStore(objects).
+		SetKVStores(map[string]kv.Store{KVStoreDefault: mem.NewStore()}).
+		SetKVEntries([]KVEntry{PlacementKVEntry("prod", "m3db")}))
+	require.Error(t, err)
+
+	coordinator, err := NewCoordinator(NewOptions().
+		SetObjectStore(objects).
+		SetKVStores(map[string]kv.Store{KVStoreDefault: mem.NewStore()}))
+	require.NoError(t, err)
+	_, err = coordinator.Backup("a/b")
+	require.Equal(t, errInvalidBackupID, err)
+	_, err = coordinator.RestoreNode("a", "host0", RestoreOptions{})
+	require.Equal(t, errRestoreFilePathPrefixNotSet, err)
+}
+
+func TestFilesetVolumeName(t *testing.T) {
+	for _, test := range []struct {
+		fileName string
+		volume   string
+	}{
+		{fileName: "fileset-1000-0-data.db", volume: "fileset-1000-0"},
+		{fileName: "fileset-1000-12-segment-0-docs.db", volume: "fileset-1000-12"},
+		{fileName: "fileset-1000-checkpoint.db", volume: "fileset-1000"},
+		{fileName: "commitlog-0-0.db"},
+		{fileName: "fileset-abc-0-data.db"},
+		{fileName: "fileset-1000.db"},
+	} {
+		volume, ok := filesetVolumeName(test.fileName)
+		assert.Equal(t, test.volume != "", ok, test.fileName)
+		assert.Equal(t, test.volume, volume, test.fileName)
+	}
+}
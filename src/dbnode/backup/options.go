@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/metrics/generated/proto/rulepb"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	// KVStoreDefault is the name of the KV store of the environment, holding
+	// the namespaces and rules.
+	KVStoreDefault = "kv"
+	// KVStorePlacement is the name of the KV store of the placements.
+	KVStorePlacement = "placement"
+
+	placementKeyPrefix = "_sd.placement"
+
+	defaultRuleNamespacesKey = "/namespaces"
+	defaultRuleSetKeyFormat  = "/ruleset/%s"
+)
+
+var (
+	errObjectStoreNotSet = errors.New("backup object store not set")
+	errHostIDNotSet      = errors.New("backup host ID not set")
+	errNothingToBackup   = errors.New("backup requires a file path prefix or KV stores")
+)
+
+// DefaultKVEntries returns the KV keys backed up by default, the namespaces
+// of the M3DB nodes and the rules of the default rules store keys.
+func DefaultKVEntries() []KVEntry {
+	return []KVEntry{
+		{Store: KVStoreDefault, Key: kvconfig.NamespacesKey},
+		RulesKVEntry(defaultRuleNamespacesKey, defaultRuleSetKeyFormat),
+	}
+}
+
+// PlacementKVEntry returns the KV entry of the placement of a service in an
+// environment, stored in the default placement namespace.
+func PlacementKVEntry(environment, service string) KVEntry {
+	key := path.Join(placementKeyPrefix, environment, service)
+	return KVEntry{Store: KVStorePlacement, Key: key}
+}
+
+// RulesKVEntry returns the KV entry of the rule namespaces, which expands to
+// the rule set of each namespace.
+func RulesKVEntry(namespacesKey, ruleSetKeyFormat string) KVEntry {
+	return KVEntry{
+		Store: KVStoreDefault,
+		Key:   namespacesKey,
+		Expand: func(value []byte) ([]string, error) {
+			var namespaces rulepb.Namespaces
+			if err := proto.Unmarshal(value, &namespaces); err != nil {
+				return nil, fmt.Errorf("unable to unmarshal rule namespaces: %v", err)
+			}
+			keys := make([]string, 0, len(namespaces.Namespaces))
+			for _, ns := range namespaces.Namespaces {
+				keys = append(keys, fmt.Sprintf(ruleSetKeyFormat, ns.Name))
+			}
+			return keys, nil
+		},
+	}
+}
+
+type options struct {
+	objectStore    objectstore.Store
+	filePathPrefix string
+	hostID         string
+	kvStores       map[string]kv.Store
+	kvEntries      []KVEntry
+	clockOpts      clock.Options
+	instrumentOpts instrument.Options
+}
+
+// NewOptions returns new backup options.
+func NewOptions() Options {
+	return &options{
+		kvEntries:      DefaultKVEntries(),
+		clockOpts:      clock.NewOptions(),
+		instrumentOpts: instrument.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.objectStore == nil {
+		return errObjectStoreNotSet
+	}
+	if o.filePathPrefix == "" && len(o.kvStores) == 0 {
+		return errNothingToBackup
+	}
+	if o.filePathPrefix != "" && o.hostID == "" {
+		return errHostIDNotSet
+	}
+	if len(o.kvStores) == 0 {
+		return nil
+	}
+	for _, entry := range o.kvEntries {
+		if _, ok := o.kvStores[entry.Store]; !ok {
+			return fmt.Errorf("no KV store %q for backup of KV key %s", entry.Store, entry.Key)
+		}
+	}
+	return nil
+}
+
+func (o *options) SetObjectStore(value objectstore.Store) Options {
+	opts := *o
+	opts.objectStore = value
+	return &opts
+}
+
+func (o *options) ObjectStore() objectstore.Store {
+	return o.objectStore
+}
+
+func (o *options) SetFilePathPrefix(value string) Options {
+	opts := *o
+	opts.filePathPrefix = value
+	return &opts
+}
+
+func (o *options) FilePathPrefix() string {
+	return o.filePathPrefix
+}
+
+func (o *options) SetHostID(value string) Options {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *options) HostID() string {
+	return o.hostID
+}
+
+func (o *options) SetKVStores(value map[string]kv.Store) Options {
+	opts := *o
+	opts.kvStores = value
+	return &opts
+}
+
+func (o *options) KVStores() map[string]kv.Store {
+	return o.kvStores
+}
+
+func (o *options) SetKVEntries(value []KVEntry) Options {
+	opts := *o
+	opts.kvEntries = value
+	return &opts
+}
+
+func (o *options) KVEntries() []KVEntry {
+	return o.kvEntries
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/placement"
+
+	"github.com/golang/protobuf/proto"
+)
+
+type placementKVStore struct {
+	// Embedded for the remaining kv.Store methods, which are not used by
+	// backups and are not supported.
+	kv.Store
+
+	storages map[string]placement.Storage
+}
+
+// NewPlacementKVStore returns a KV store for backups backed by the placement
+// storages of services keyed by their placement KV key, see
+// PlacementKVEntry. Placements are stored outside of the namespaces of the
+// KV stores returned by cluster clients and are read and written through the
+// placement storage instead. Only Get and Set are supported.
+func NewPlacementKVStore(storages map[string]placement.Storage) kv.Store {
+	return &placementKVStore{storages: storages}
+}
+
+func (s *placementKVStore) Get(key string) (kv.Value, error) {
+	storage, ok := s.storages[key]
+	if !ok {
+		return nil, kv.ErrNotFound
+	}
+	p, version, err := storage.Proto()
+	if err != nil {
+		return nil, err
+	}
+	return placementValue{version: version, placement: p}, nil
+}
+
+func (s *placementKVStore) Set(key string, v proto.Message) (int, error) {
+	storage, ok := s.storages[key]
+	if !ok {
+		return 0, fmt.Errorf("no placement storage for key %s", key)
+	}
+	data, err := proto.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	var p placementpb.Placement
+	if err := proto.Unmarshal(data, &p); err != nil {
+		return 0, err
+	}
+	return storage.SetProto(&p)
+}
+
+type placementValue struct {
+	version   int
+	placement proto.Message
+}
+
+func (v placementValue) Unmarshal(msg proto.Message) error {
+	data, err := proto.Marshal(v.placement)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (v placementValue) Version() int {
+	return v.version
+}
+
+func (v placementValue) IsNewer(other kv.Value) bool {
+	return v.version > other.Version()
+}
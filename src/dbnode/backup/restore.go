@@ -0,0 +1,196 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const (
+	restoreTempFileSuffix = ".restore-tmp"
+	restoreDirMode        = os.ModeDir | os.FileMode(0755)
+	restoreFileMode       = os.FileMode(0666)
+)
+
+var (
+	errRestoreFilePathPrefixNotSet = errors.New("restore requires a file path prefix")
+	errRestoreKVStoreNotSet        = errors.New("restore requires KV stores")
+)
+
+func (c *coordinator) RestoreNode(
+	backupID string,
+	hostID string,
+	opts RestoreOptions,
+) (RestoreResult, error) {
+	filePathPrefix := c.opts.FilePathPrefix()
+	if filePathPrefix == "" {
+		return RestoreResult{}, errRestoreFilePathPrefixNotSet
+	}
+	if err := validateBackupID(backupID); err != nil {
+		return RestoreResult{}, err
+	}
+
+	var manifest NodeManifest
+	if err := getJSON(c.store, nodeManifestKey(backupID, hostID), &manifest); err != nil {
+		return RestoreResult{}, fmt.Errorf("unable to read manifest of host %s in backup %s: %v",
+			hostID, backupID, err)
+	}
+
+	// Checkpoint files are restored last so that an interrupted restore does
+	// not leave behind volumes that appear complete.
+	var (
+		files       = make([]File, 0, len(manifest.Files))
+		checkpoints []File
+	)
+	for _, file := range manifest.Files {
+		if strings.HasSuffix(file.Path, checkpointFileSuffix) {
+			checkpoints = append(checkpoints, file)
+		} else {
+			files = append(files, file)
+		}
+	}
+	files = append(files, checkpoints...)
+
+	var result RestoreResult
+	for _, file := range files {
+		restored, err := c.restoreFile(filePathPrefix, file, opts)
+		if err != nil {
+			return RestoreResult{}, err
+		}
+		if !restored {
+			result.FilesSkipped++
+			continue
+		}
+		result.FilesRestored++
+		result.BytesRestored += file.Size
+		c.metrics.filesRestored.Inc(1)
+		c.metrics.bytesRestored.Inc(file.Size)
+	}
+
+	c.logger.Info("restored fileset volumes",
+		zap.String("backupID", backupID),
+		zap.String("hostID", hostID),
+		zap.Int("filesRestored", result.FilesRestored),
+		zap.Int("filesSkipped", result.FilesSkipped),
+		zap.Int64("bytesRestored", result.BytesRestored))
+	return result, nil
+}
+
+func (c *coordinator) restoreFile(
+	filePathPrefix string,
+	file File,
+	opts RestoreOptions,
+) (bool, error) {
+	cleaned := path.Clean(file.Path)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return false, fmt.Errorf("invalid path in backup manifest: %s", file.Path)
+	}
+	localPath := filepath.Join(filePathPrefix, filepath.FromSlash(cleaned))
+
+	size, checksum, err := fileChecksum(localPath)
+	switch {
+	case err == nil && size == file.Size && checksum == file.Checksum:
+		return false, nil
+	case err == nil && !opts.Overwrite:
+		return false, fmt.Errorf("file %s differs from the backup", localPath)
+	case err != nil && !os.IsNotExist(err):
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), restoreDirMode); err != nil {
+		return false, err
+	}
+	r, err := c.store.Get(file.Key)
+	if err != nil {
+		return false, fmt.Errorf("unable to get object %s: %v", file.Key, err)
+	}
+	defer r.Close()
+
+	tempPath := localPath + restoreTempFileSuffix
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, restoreFileMode)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tempPath)
+
+	hash := adler32.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err != nil {
+		f.Close()
+		return false, err
+	}
+	if n != file.Size || hash.Sum32() != file.Checksum {
+		f.Close()
+		return false, fmt.Errorf("object %s does not match the backup manifest", file.Key)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tempPath, localPath); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *coordinator) RestoreKV(backupID string) (KVManifest, error) {
+	stores := c.opts.KVStores()
+	if len(stores) == 0 {
+		return KVManifest{}, errRestoreKVStoreNotSet
+	}
+	if err := validateBackupID(backupID); err != nil {
+		return KVManifest{}, err
+	}
+
+	var manifest KVManifest
+	if err := getJSON(c.store, kvManifestKey(backupID), &manifest); err != nil {
+		return KVManifest{}, fmt.Errorf("unable to read KV manifest of backup %s: %v",
+			backupID, err)
+	}
+	for _, value := range manifest.Values {
+		if _, ok := stores[value.Store]; !ok {
+			return KVManifest{}, fmt.Errorf("no KV store %q to restore KV key %s",
+				value.Store, value.Key)
+		}
+	}
+	for _, value := range manifest.Values {
+		if _, err := stores[value.Store].Set(value.Key, &rawMessage{value: value.Value}); err != nil {
+			return KVManifest{}, fmt.Errorf("unable to set KV key %s: %v", value.Key, err)
+		}
+	}
+
+	c.logger.Info("restored KV state",
+		zap.String("backupID", backupID),
+		zap.Int("keys", len(manifest.Values)))
+	return manifest, nil
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package backup backs up fileset volumes and cluster KV state to object
+// storage and restores nodes and clusters from backup points.
+package backup
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+)
+
+// Coordinator backs up and restores fileset volumes and cluster KV state.
+//
+// A backup point consists of a manifest per host listing the complete
+// fileset volumes of the host and a snapshot of the KV state. Fileset files
+// are immutable once their volume is checkpointed, they are stored by
+// content and shared between backup points so that each backup only uploads
+// the volumes flushed since the previous one.
+type Coordinator interface {
+	// Backup uploads the complete fileset volumes of the node, if a file path
+	// prefix and host ID are set, and the KV state, if KV stores are set, to
+	// a backup point. Uploading the same backup point from each host of a
+	// cluster yields a backup of the cluster.
+	Backup(backupID string) (BackupResult, error)
+
+	// Backups returns the IDs of the backup points, sorted.
+	Backups() ([]string, error)
+
+	// Hosts returns the IDs of the hosts backed up in a backup point, sorted.
+	Hosts(backupID string) ([]string, error)
+
+	// RestoreNode downloads the fileset volumes backed up by a host to the
+	// file path prefix. Restoring the volumes of another host rebuilds a
+	// replacement node from the backup.
+	RestoreNode(backupID, hostID string, opts RestoreOptions) (RestoreResult, error)
+
+	// RestoreKV writes the KV state of a backup point back to the KV store.
+	RestoreKV(backupID string) (KVManifest, error)
+}
+
+// RestoreOptions are the options of a node restore.
+type RestoreOptions struct {
+	// Overwrite replaces local files that differ from the backup, otherwise
+	// the restore fails when such files exist.
+	Overwrite bool
+}
+
+// BackupResult is the result of a backup.
+type BackupResult struct {
+	// Node is the node manifest, nil if no filesets were backed up.
+	Node *NodeManifest
+	// KV is the KV manifest, nil if no KV state was backed up.
+	KV *KVManifest
+	// FilesUploaded is the number of files uploaded.
+	FilesUploaded int
+	// BytesUploaded is the number of bytes uploaded.
+	BytesUploaded int64
+}
+
+// RestoreResult is the result of a node restore.
+type RestoreResult struct {
+	// FilesRestored is the number of files downloaded.
+	FilesRestored int
+	// BytesRestored is the number of bytes downloaded.
+	BytesRestored int64
+	// FilesSkipped is the number of files already present locally.
+	FilesSkipped int
+}
+
+// NodeManifest lists the fileset files backed up by a host.
+type NodeManifest struct {
+	BackupID  string    `json:"backupID"`
+	HostID    string    `json:"hostID"`
+	CreatedAt time.Time `json:"createdAt"`
+	Files     []File    `json:"files"`
+}
+
+// File is a backed up fileset file.
+type File struct {
+	// Path is the slash separated path of the file relative to the file path
+	// prefix.
+	Path string `json:"path"`
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+	// Checksum is the adler32 checksum of the file.
+	Checksum uint32 `json:"checksum"`
+	// Key is the key of the object holding the file.
+	Key string `json:"key"`
+}
+
+// KVManifest is a snapshot of KV state.
+type KVManifest struct {
+	BackupID  string    `json:"backupID"`
+	CreatedAt time.Time `json:"createdAt"`
+	Values    []KVValue `json:"values"`
+}
+
+// KVValue is a backed up KV value.
+type KVValue struct {
+	Store   string `json:"store"`
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+	// Value is the marshalled protobuf value.
+	Value []byte `json:"value"`
+}
+
+// KVEntry is a KV key to back up.
+type KVEntry struct {
+	// Store is the name of the KV store of the key.
+	Store string
+	// Key is the KV key.
+	Key string
+	// Expand optionally returns further keys to back up given the marshalled
+	// value of the key, for instance the rule set keys of rule namespaces.
+	Expand func(value []byte) ([]string, error)
+}
+
+// Options are the options of a backup coordinator.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetObjectStore sets the object store backups are written to.
+	SetObjectStore(value objectstore.Store) Options
+
+	// ObjectStore returns the object store backups are written to.
+	ObjectStore() objectstore.Store
+
+	// SetFilePathPrefix sets the file path prefix of the filesets.
+	SetFilePathPrefix(value string) Options
+
+	// FilePathPrefix returns the file path prefix of the filesets.
+	FilePathPrefix() string
+
+	// SetHostID sets the ID of the host backed up.
+	SetHostID(value string) Options
+
+	// HostID returns the ID of the host backed up.
+	HostID() string
+
+	// SetKVStores sets the KV stores of the cluster state by name.
+	SetKVStores(value map[string]kv.Store) Options
+
+	// KVStores returns the KV stores of the cluster state by name.
+	KVStores() map[string]kv.Store
+
+	// SetKVEntries sets the KV keys backed up.
+	SetKVEntries(value []KVEntry) Options
+
+	// KVEntries returns the KV keys backed up.
+	KVEntries() []KVEntry
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capacity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+)
+
+// List of resources the capacity of a cluster is planned for.
+const (
+	ResourceDisk   = "disk"
+	ResourceSeries = "series"
+	ResourceWrites = "writes"
+)
+
+const defaultStaleAfter = 5 * time.Minute
+
+var errNoReports = errors.New("no instance in the placement has a capacity report")
+
+// PlanOptions are the options of a capacity plan.
+type PlanOptions struct {
+	// Headroom is the fraction of each resource that should remain unused,
+	// between zero inclusive and one exclusive.
+	Headroom float64
+	// MaxSeriesPerInstance is the number of series an instance can hold, the
+	// series are not planned for if not positive.
+	MaxSeriesPerInstance int64
+	// MaxWritesPerSecPerInstance is the write rate an instance can sustain,
+	// the write rate is not planned for if not positive.
+	MaxWritesPerSecPerInstance float64
+	// StaleAfter is the age after which a report is considered stale and the
+	// usage of its instance is estimated, defaults to five minutes.
+	StaleAfter time.Duration
+	// Now is the time the plan is computed at.
+	Now time.Time
+}
+
+// Validate validates the plan options.
+func (o PlanOptions) Validate() error {
+	if o.Headroom < 0 || o.Headroom >= 1 {
+		return fmt.Errorf("headroom must be in [0, 1): %v", o.Headroom)
+	}
+	return nil
+}
+
+// InstanceUtilization is the utilization of an instance of the placement.
+type InstanceUtilization struct {
+	ID             string  `json:"id"`
+	IsolationGroup string  `json:"isolationGroup"`
+	Shards         int     `json:"shards"`
+	Series         int64   `json:"series"`
+	WritesPerSec   float64 `json:"writesPerSec"`
+	DiskUsedBytes  int64   `json:"diskUsedBytes"`
+	DiskTotalBytes int64   `json:"diskTotalBytes"`
+	// Utilization is the fraction used of each planned resource.
+	Utilization map[string]float64 `json:"utilization"`
+	// Reported is false if the instance has no report, or a stale report, in
+	// which case its usage is estimated from the other instances.
+	Reported   bool      `json:"reported"`
+	ReportedAt time.Time `json:"reportedAt,omitempty"`
+}
+
+// ResourcePlan is the plan of a single resource.
+type ResourcePlan struct {
+	Used                float64 `json:"used"`
+	CapacityPerInstance float64 `json:"capacityPerInstance"`
+	Utilization         float64 `json:"utilization"`
+	MaxUtilization      float64 `json:"maxUtilization"`
+	RequiredInstances   int     `json:"requiredInstances"`
+	AdditionalInstances int     `json:"additionalInstances"`
+}
+
+// Plan is the capacity plan of a cluster.
+type Plan struct {
+	Instances     []InstanceUtilization   `json:"instances"`
+	ReplicaFactor int                     `json:"replicaFactor"`
+	Headroom      float64                 `json:"headroom"`
+	Resources     map[string]ResourcePlan `json:"resources"`
+	// LimitingResource is the resource requiring the most instances.
+	LimitingResource string `json:"limitingResource"`
+	// RecommendedAdditionalInstances is the number of instances to add to
+	// keep the headroom of every resource, a multiple of the replica factor
+	// so that each isolation group grows evenly.
+	RecommendedAdditionalInstances int `json:"recommendedAdditionalInstances"`
+}
+
+// NewPlan computes the capacity plan of a placement from the reports of its
+// instances, keyed by instance ID. The usage of instances with missing or
+// stale reports is estimated as the average usage per shard of the reporting
+// instances.
+func NewPlan(
+	p placement.Placement,
+	reports map[string]NodeReport,
+	opts PlanOptions,
+) (Plan, error) {
+	if err := opts.Validate(); err != nil {
+		return Plan{}, err
+	}
+	staleAfter := opts.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
+
+	var (
+		instances = p.Instances()
+		plan      = Plan{
+			Instances:     make([]InstanceUtilization, 0, len(instances)),
+			ReplicaFactor: p.ReplicaFactor(),
+			Headroom:      opts.Headroom,
+			Resources:     make(map[string]ResourcePlan),
+		}
+		reportedShards   int
+		reportedSeries   int64
+		reportedWrites   float64
+		reportedDisk     int64
+		diskTotal        int64
+		reportedInstance int
+	)
+	for _, instance := range instances {
+		u := InstanceUtilization{
+			ID:             instance.ID(),
+			IsolationGroup: instance.IsolationGroup(),
+			Shards:         instance.Shards().NumShards(),
+			Utilization:    make(map[string]float64),
+		}
+		report, ok := reports[instance.ID()]
+		if ok && opts.Now.Sub(report.ReportedAt) <= staleAfter {
+			u.Reported = true
+			u.ReportedAt = report.ReportedAt
+			u.Series = report.Series()
+			u.WritesPerSec = report.WritesPerSec()
+			u.DiskUsedBytes = diskUsed(report)
+			u.DiskTotalBytes = report.DiskTotalBytes
+
+			reportedInstance++
+			reportedShards += u.Shards
+			reportedSeries += u.Series
+			reportedWrites += u.WritesPerSec
+			reportedDisk += u.DiskUsedBytes
+			diskTotal += u.DiskTotalBytes
+		}
+		plan.Instances = append(plan.Instances, u)
+	}
+	if reportedInstance == 0 {
+		return Plan{}, errNoReports
+	}
+
+	// Estimate the usage of the instances without a report from the average
+	// usage per shard, or per instance if the reporting instances own no
+	// shards yet.
+	var (
+		perUnitSeries = float64(reportedSeries)
+		perUnitWrites = reportedWrites
+		perUnitDisk   = float64(reportedDisk)
+		avgDiskTotal  = diskTotal / int64(reportedInstance)
+	)
+	if reportedShards > 0 {
+		perUnitSeries /= float64(reportedShards)
+		perUnitWrites /= float64(reportedShards)
+		perUnitDisk /= float64(reportedShards)
+	} else {
+		perUnitSeries /= float64(reportedInstance)
+		perUnitWrites /= float64(reportedInstance)
+		perUnitDisk /= float64(reportedInstance)
+	}
+	for i := range plan.Instances {
+		u := &plan.Instances[i]
+		if u.Reported {
+			continue
+		}
+		units := float64(u.Shards)
+		if reportedShards == 0 {
+			units = 1
+		}
+		u.Series = int64(perUnitSeries * units)
+		u.WritesPerSec = perUnitWrites * units
+		u.DiskUsedBytes = int64(perUnitDisk * units)
+		u.DiskTotalBytes = avgDiskTotal
+	}
+
+	var (
+		current   = len(plan.Instances)
+		resources = map[string]struct {
+			capacity float64
+			usage    func(u InstanceUtilization) (float64, float64)
+		}{
+			ResourceDisk: {
+				capacity: float64(avgDiskTotal),
+				usage: func(u InstanceUtilization) (float64, float64) {
+					return float64(u.DiskUsedBytes), float64(u.DiskTotalBytes)
+				},
+			},
+			ResourceSeries: {
+				capacity: float64(opts.MaxSeriesPerInstance),
+				usage: func(u InstanceUtilization) (float64, float64) {
+					return float64(u.Series), float64(opts.MaxSeriesPerInstance)
+				},
+			},
+			ResourceWrites: {
+				capacity: opts.MaxWritesPerSecPerInstance,
+				usage: func(u InstanceUtilization) (float64, float64) {
+					return u.WritesPerSec, opts.MaxWritesPerSecPerInstance
+				},
+			},
+		}
+	)
+	for name, resource := range resources {
+		if resource.capacity <= 0 {
+			continue
+		}
+
+		var rp ResourcePlan
+		rp.CapacityPerInstance = resource.capacity
+		for i := range plan.Instances {
+			used, capacity := resource.usage(plan.Instances[i])
+			rp.Used += used
+			if capacity <= 0 {
+				continue
+			}
+			utilization := used / capacity
+			plan.Instances[i].Utilization[name] = utilization
+			rp.MaxUtilization = math.Max(rp.MaxUtilization, utilization)
+		}
+		rp.Utilization = rp.Used / (resource.capacity * float64(current))
+
+		usable := resource.capacity * (1 - opts.Headroom)
+		rp.RequiredInstances = roundUpToMultiple(
+			int(math.Ceil(rp.Used/usable)), plan.ReplicaFactor)
+		if rp.RequiredInstances > current {
+			rp.AdditionalInstances = roundUpToMultiple(
+				rp.RequiredInstances-current, plan.ReplicaFactor)
+		}
+		plan.Resources[name] = rp
+	}
+
+	names := make([]string, 0, len(plan.Resources))
+	for name := range plan.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rp := plan.Resources[name]
+		if plan.LimitingResource == "" ||
+			rp.RequiredInstances > plan.Resources[plan.LimitingResource].RequiredInstances {
+			plan.LimitingResource = name
+			plan.RecommendedAdditionalInstances = rp.AdditionalInstances
+		}
+	}
+
+	sort.Slice(plan.Instances, func(i, j int) bool {
+		return plan.Instances[i].ID < plan.Instances[j].ID
+	})
+	return plan, nil
+}
+
+// diskUsed returns the disk usage of a node, which is the used space of the
+// filesystem when known since other files count against its capacity too.
+func diskUsed(report NodeReport) int64 {
+	if report.DiskTotalBytes > 0 {
+		return report.DiskTotalBytes - report.DiskFreeBytes
+	}
+	return report.DiskUsedBytes
+}
+
+func roundUpToMultiple(n, multiple int) int {
+	if multiple <= 1 || n%multiple == 0 {
+		return n
+	}
+	return n + multiple - n%multiple
+}
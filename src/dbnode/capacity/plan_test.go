@@ -0,0 +1,155 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInstance(id, group string, shardIDs ...uint32) placement.Instance {
+	shards := make([]shard.Shard, 0, len(shardIDs))
+	for _, s := range shardIDs {
+		shards = append(shards, shard.NewShard(s).SetState(shard.Available))
+	}
+	return placement.NewEmptyInstance(id, group, "zone", id+":9000", 1).
+		SetShards(shard.NewShards(shards))
+}
+
+func newTestShardReports(series int64, writesPerSec float64, shards ...uint32) []ShardReport {
+	reports := make([]ShardReport, 0, len(shards))
+	for _, s := range shards {
+		reports = append(reports, ShardReport{
+			Shard:        s,
+			Series:       series,
+			WritesPerSec: writesPerSec,
+		})
+	}
+	return reports
+}
+
+func TestNewPlan(t *testing.T) {
+	var (
+		now = time.Now()
+		p   = placement.NewPlacement().
+			SetReplicaFactor(3).
+			SetInstances([]placement.Instance{
+				newTestInstance("a", "g1", 0, 1, 2, 3),
+				newTestInstance("b", "g2", 0, 1, 2, 3),
+				newTestInstance("c", "g3", 0, 1, 2, 3),
+			})
+		reports = map[string]NodeReport{
+			"a": {
+				HostID:         "a",
+				ReportedAt:     now.Add(-time.Minute),
+				DiskTotalBytes: 1000,
+				DiskFreeBytes:  400,
+				Shards:         newTestShardReports(25, 10, 0, 1, 2, 3),
+			},
+			"b": {
+				HostID:         "b",
+				ReportedAt:     now.Add(-time.Minute),
+				DiskTotalBytes: 1000,
+				DiskFreeBytes:  300,
+				Shards:         newTestShardReports(30, 12.5, 0, 1, 2, 3),
+			},
+			"c": {
+				HostID:         "c",
+				ReportedAt:     now.Add(-time.Hour),
+				DiskTotalBytes: 1000,
+				Shards:         newTestShardReports(1, 1, 0, 1, 2, 3),
+			},
+		}
+	)
+
+	plan, err := NewPlan(p, reports, PlanOptions{
+		Headroom:                   0.2,
+		MaxSeriesPerInstance:       100,
+		MaxWritesPerSecPerInstance: 100,
+		Now:                        now,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, plan.Instances, 3)
+	c := plan.Instances[2]
+	require.Equal(t, "c", c.ID)
+	require.False(t, c.Reported)
+	require.Equal(t, int64(110), c.Series)
+	require.Equal(t, int64(650), c.DiskUsedBytes)
+	require.InDelta(t, 45, c.WritesPerSec, 0.001)
+
+	disk := plan.Resources[ResourceDisk]
+	require.InDelta(t, 0.65, disk.Utilization, 0.001)
+	require.InDelta(t, 0.7, disk.MaxUtilization, 0.001)
+	require.Equal(t, 3, disk.RequiredInstances)
+	require.Equal(t, 0, disk.AdditionalInstances)
+
+	series := plan.Resources[ResourceSeries]
+	require.Equal(t, 6, series.RequiredInstances)
+	require.Equal(t, 3, series.AdditionalInstances)
+
+	require.Equal(t, 0, plan.Resources[ResourceWrites].AdditionalInstances)
+	require.Equal(t, ResourceSeries, plan.LimitingResource)
+	require.Equal(t, 3, plan.RecommendedAdditionalInstances)
+}
+
+func TestNewPlanErrors(t *testing.T) {
+	p := placement.NewPlacement().
+		SetReplicaFactor(1).
+		SetInstances([]placement.Instance{newTestInstance("a", "g1", 0)})
+
+	_, err := NewPlan(p, nil, PlanOptions{Now: time.Now()})
+	require.Equal(t, errNoReports, err)
+
+	_, err = NewPlan(p, nil, PlanOptions{Headroom: 1})
+	require.Error(t, err)
+}
+
+func TestPublishAndGetReport(t *testing.T) {
+	store := mem.NewStore()
+
+	_, ok, err := GetReport(store, "a")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	report := NodeReport{
+		HostID:         "a",
+		ReportedAt:     time.Unix(1600000000, 0).UTC(),
+		DiskUsedBytes:  10,
+		DiskTotalBytes: 100,
+		DiskFreeBytes:  50,
+		Shards:         newTestShardReports(5, 2, 0, 1),
+	}
+	require.NoError(t, PublishReport(store, report))
+
+	got, ok, err := GetReport(store, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, report, got)
+	require.Equal(t, int64(10), got.Series())
+	require.Equal(t, float64(4), got.WritesPerSec())
+}
@@ -0,0 +1,370 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capacity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+)
+
+const (
+	defaultSkewThreshold = 0.1
+	defaultMaxMoves      = 16
+	defaultDiskHeadroom  = 0.1
+)
+
+var (
+	errRebalanceInProgress = errors.New("placement has shards that are not available")
+	errRebalanceMirrored   = errors.New("rebalancing mirrored placements is not supported")
+)
+
+// RebalanceOptions are the options of a rebalance.
+type RebalanceOptions struct {
+	// Resource is the resource the shards are balanced by, one of disk,
+	// series or writes. Defaults to series.
+	Resource string
+	// SkewThreshold is the skew of the most loaded instance above the average
+	// load that is tolerated, e.g. 0.1 for ten percent. Defaults to 0.1.
+	SkewThreshold float64
+	// MaxMoves is the maximum number of shard moves proposed. Defaults to 16.
+	MaxMoves int
+	// DiskHeadroom is the fraction of the disk of an instance that must
+	// remain free after moving shards to it. Defaults to 0.1.
+	DiskHeadroom float64
+	// StaleAfter is the age after which a report is considered stale,
+	// defaults to five minutes.
+	StaleAfter time.Duration
+	// Now is the time the rebalance is computed at.
+	Now time.Time
+}
+
+func (o RebalanceOptions) withDefaults() (RebalanceOptions, error) {
+	if o.Resource == "" {
+		o.Resource = ResourceSeries
+	}
+	switch o.Resource {
+	case ResourceDisk, ResourceSeries, ResourceWrites:
+	default:
+		return o, fmt.Errorf("unknown resource: %s", o.Resource)
+	}
+	if o.SkewThreshold < 0 {
+		return o, fmt.Errorf("skew threshold must not be negative: %v", o.SkewThreshold)
+	}
+	if o.SkewThreshold == 0 {
+		o.SkewThreshold = defaultSkewThreshold
+	}
+	if o.MaxMoves <= 0 {
+		o.MaxMoves = defaultMaxMoves
+	}
+	if o.DiskHeadroom < 0 || o.DiskHeadroom >= 1 {
+		return o, fmt.Errorf("disk headroom must be in [0, 1): %v", o.DiskHeadroom)
+	}
+	if o.DiskHeadroom == 0 {
+		o.DiskHeadroom = defaultDiskHeadroom
+	}
+	if o.StaleAfter <= 0 {
+		o.StaleAfter = defaultStaleAfter
+	}
+	return o, nil
+}
+
+// ShardMove is the move of a replica of a shard between two instances.
+type ShardMove struct {
+	Shard uint32  `json:"shard"`
+	From  string  `json:"from"`
+	To    string  `json:"to"`
+	Load  float64 `json:"load"`
+}
+
+// InstanceLoad is the load of an instance before and after a rebalance.
+type InstanceLoad struct {
+	ID             string  `json:"id"`
+	IsolationGroup string  `json:"isolationGroup"`
+	Before         float64 `json:"before"`
+	After          float64 `json:"after"`
+	ShardsBefore   int     `json:"shardsBefore"`
+	ShardsAfter    int     `json:"shardsAfter"`
+}
+
+// Rebalance is a set of shard moves that reduce the load skew of a
+// placement, along with the load of each instance before and after.
+type Rebalance struct {
+	Resource   string         `json:"resource"`
+	SkewBefore float64        `json:"skewBefore"`
+	SkewAfter  float64        `json:"skewAfter"`
+	Moves      []ShardMove    `json:"moves"`
+	Instances  []InstanceLoad `json:"instances"`
+}
+
+type rebalanceInstance struct {
+	id        string
+	group     string
+	load      float64
+	diskUsed  float64
+	diskLimit float64
+	shards    map[uint32]float64
+	shardDisk map[uint32]float64
+}
+
+// NewRebalance computes the shard moves that bring the load of the most
+// loaded instance of a placement within the skew threshold of the average.
+// The moves are chosen greedily, each moving the shard replica that best
+// evens the load of the most loaded instance with a less loaded one, while
+// keeping the replicas of every shard in distinct isolation groups, moving
+// at most one replica of each shard and leaving the disk headroom free on
+// the target instances. Every instance must have a recent report.
+func NewRebalance(
+	p placement.Placement,
+	reports map[string]NodeReport,
+	opts RebalanceOptions,
+) (Rebalance, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return Rebalance{}, err
+	}
+	if p.IsMirrored() {
+		return Rebalance{}, errRebalanceMirrored
+	}
+
+	var (
+		instances = p.Instances()
+		byID      = make(map[string]*rebalanceInstance, len(instances))
+		ordered   = make([]*rebalanceInstance, 0, len(instances))
+	)
+	for _, instance := range instances {
+		shards := instance.Shards()
+		if shards.NumShardsForState(shard.Available) != shards.NumShards() {
+			return Rebalance{}, errRebalanceInProgress
+		}
+		report, ok := reports[instance.ID()]
+		if !ok || opts.Now.Sub(report.ReportedAt) > opts.StaleAfter {
+			return Rebalance{}, fmt.Errorf(
+				"instance %s has no recent capacity report", instance.ID())
+		}
+
+		shardReports := make(map[uint32]ShardReport, len(report.Shards))
+		for _, s := range report.Shards {
+			shardReports[s.Shard] = s
+		}
+		ri := &rebalanceInstance{
+			id:        instance.ID(),
+			group:     instance.IsolationGroup(),
+			diskUsed:  float64(diskUsed(report)),
+			diskLimit: math.Inf(1),
+			shards:    make(map[uint32]float64, shards.NumShards()),
+			shardDisk: make(map[uint32]float64, shards.NumShards()),
+		}
+		if report.DiskTotalBytes > 0 {
+			ri.diskLimit = float64(report.DiskTotalBytes) * (1 - opts.DiskHeadroom)
+		}
+		for _, id := range shards.AllIDs() {
+			s := shardReports[id]
+			load := shardLoad(s, opts.Resource)
+			ri.shards[id] = load
+			ri.shardDisk[id] = float64(s.DiskUsedBytes)
+			ri.load += load
+		}
+		byID[ri.id] = ri
+		ordered = append(ordered, ri)
+	}
+	if len(ordered) < 2 {
+		return Rebalance{Resource: opts.Resource, Moves: []ShardMove{}}, nil
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].id < ordered[j].id })
+
+	result := Rebalance{
+		Resource:  opts.Resource,
+		Moves:     []ShardMove{},
+		Instances: make([]InstanceLoad, 0, len(ordered)),
+	}
+	for _, ri := range ordered {
+		result.Instances = append(result.Instances, InstanceLoad{
+			ID:             ri.id,
+			IsolationGroup: ri.group,
+			Before:         ri.load,
+			ShardsBefore:   len(ri.shards),
+		})
+	}
+	result.SkewBefore = loadSkew(ordered)
+
+	moved := make(map[uint32]struct{})
+	for len(result.Moves) < opts.MaxMoves {
+		source := ordered[0]
+		for _, ri := range ordered[1:] {
+			if ri.load > source.load {
+				source = ri
+			}
+		}
+		if loadSkew(ordered) <= opts.SkewThreshold {
+			break
+		}
+
+		move, ok := bestMove(source, ordered, moved)
+		if !ok {
+			break
+		}
+		target := byID[move.To]
+		load, disk := source.shards[move.Shard], source.shardDisk[move.Shard]
+		delete(source.shards, move.Shard)
+		delete(source.shardDisk, move.Shard)
+		source.load -= load
+		source.diskUsed -= disk
+		target.shards[move.Shard] = load
+		target.shardDisk[move.Shard] = disk
+		target.load += load
+		target.diskUsed += disk
+		moved[move.Shard] = struct{}{}
+		result.Moves = append(result.Moves, move)
+	}
+
+	for i, ri := range ordered {
+		result.Instances[i].After = ri.load
+		result.Instances[i].ShardsAfter = len(ri.shards)
+	}
+	result.SkewAfter = loadSkew(ordered)
+	return result, nil
+}
+
+// bestMove returns the move of a shard from the source to another instance
+// that minimizes the larger of their loads after the move.
+func bestMove(
+	source *rebalanceInstance,
+	instances []*rebalanceInstance,
+	moved map[uint32]struct{},
+) (ShardMove, bool) {
+	var (
+		best      ShardMove
+		bestPeak  = source.load
+		shardIDs  = make([]uint32, 0, len(source.shards))
+		groupsFor = func(id uint32) map[string]struct{} {
+			groups := make(map[string]struct{})
+			for _, ri := range instances {
+				if _, ok := ri.shards[id]; ok && ri != source {
+					groups[ri.group] = struct{}{}
+				}
+			}
+			return groups
+		}
+	)
+	for id := range source.shards {
+		shardIDs = append(shardIDs, id)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+
+	for _, id := range shardIDs {
+		if _, ok := moved[id]; ok {
+			continue
+		}
+		var (
+			load   = source.shards[id]
+			disk   = source.shardDisk[id]
+			groups = groupsFor(id)
+		)
+		if load <= 0 {
+			continue
+		}
+		for _, target := range instances {
+			if target == source {
+				continue
+			}
+			if _, ok := target.shards[id]; ok {
+				continue
+			}
+			if _, ok := groups[target.group]; ok {
+				continue
+			}
+			if target.diskUsed+disk > target.diskLimit {
+				continue
+			}
+			peak := math.Max(source.load-load, target.load+load)
+			if peak < bestPeak {
+				bestPeak = peak
+				best = ShardMove{Shard: id, From: source.id, To: target.id, Load: load}
+			}
+		}
+	}
+	return best, bestPeak < source.load
+}
+
+// loadSkew returns how far the load of the most loaded instance is above
+// the average load, as a fraction of the average.
+func loadSkew(instances []*rebalanceInstance) float64 {
+	var total, max float64
+	for _, ri := range instances {
+		total += ri.load
+		max = math.Max(max, ri.load)
+	}
+	if total == 0 {
+		return 0
+	}
+	return max/(total/float64(len(instances))) - 1
+}
+
+func shardLoad(s ShardReport, resource string) float64 {
+	switch resource {
+	case ResourceDisk:
+		return float64(s.DiskUsedBytes)
+	case ResourceWrites:
+		return s.WritesPerSec
+	default:
+		return float64(s.Series)
+	}
+}
+
+// ApplyRebalance returns a copy of the placement with the moves of a
+// rebalance applied, the moved shards are leaving their source instances and
+// initializing on their target instances.
+func ApplyRebalance(p placement.Placement, moves []ShardMove) (placement.Placement, error) {
+	updated := p.Clone()
+	for _, move := range moves {
+		from, ok := updated.Instance(move.From)
+		if !ok {
+			return nil, fmt.Errorf("instance %s not in placement", move.From)
+		}
+		to, ok := updated.Instance(move.To)
+		if !ok {
+			return nil, fmt.Errorf("instance %s not in placement", move.To)
+		}
+		s, ok := from.Shards().Shard(move.Shard)
+		if !ok || s.State() != shard.Available {
+			return nil, fmt.Errorf("shard %d is not available on instance %s",
+				move.Shard, move.From)
+		}
+		if to.Shards().Contains(move.Shard) {
+			return nil, fmt.Errorf("shard %d is already on instance %s",
+				move.Shard, move.To)
+		}
+		s.SetState(shard.Leaving)
+		to.Shards().Add(shard.NewShard(move.Shard).
+			SetState(shard.Initializing).
+			SetSourceID(move.From))
+	}
+	if err := placement.Validate(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
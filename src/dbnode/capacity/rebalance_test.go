@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRebalancePlacement(
+	rf int,
+	instances ...placement.Instance,
+) (placement.Placement, map[string]NodeReport, time.Time) {
+	var (
+		now     = time.Now()
+		reports = make(map[string]NodeReport, len(instances))
+		ids     = make(map[uint32]struct{})
+	)
+	for _, instance := range instances {
+		shardIDs := instance.Shards().AllIDs()
+		for _, id := range shardIDs {
+			ids[id] = struct{}{}
+		}
+		reports[instance.ID()] = NodeReport{
+			HostID:     instance.ID(),
+			ReportedAt: now,
+			Shards:     newTestShardReports(100, 0, shardIDs...),
+		}
+	}
+	shardIDs := make([]uint32, 0, len(ids))
+	for id := range ids {
+		shardIDs = append(shardIDs, id)
+	}
+	p := placement.NewPlacement().
+		SetInstances(instances).
+		SetShards(shardIDs).
+		SetReplicaFactor(rf).
+		SetIsSharded(true)
+	return p, reports, now
+}
+
+func TestNewRebalance(t *testing.T) {
+	p, reports, now := newTestRebalancePlacement(2,
+		newTestInstance("a", "g1", 0, 1, 2),
+		newTestInstance("b", "g2", 0, 1),
+		newTestInstance("c", "g1", 3),
+		newTestInstance("d", "g2", 2, 3),
+	)
+
+	rebalance, err := NewRebalance(p, reports, RebalanceOptions{Now: now})
+	require.NoError(t, err)
+	require.Equal(t, ResourceSeries, rebalance.Resource)
+	require.InDelta(t, 0.5, rebalance.SkewBefore, 0.001)
+	require.InDelta(t, 0, rebalance.SkewAfter, 0.001)
+	require.Equal(t, []ShardMove{{Shard: 0, From: "a", To: "c", Load: 100}},
+		rebalance.Moves)
+	require.Equal(t, InstanceLoad{
+		ID:             "c",
+		IsolationGroup: "g1",
+		Before:         100,
+		After:          200,
+		ShardsBefore:   1,
+		ShardsAfter:    2,
+	}, rebalance.Instances[2])
+
+	updated, err := ApplyRebalance(p, rebalance.Moves)
+	require.NoError(t, err)
+
+	a, ok := updated.Instance("a")
+	require.True(t, ok)
+	s, ok := a.Shards().Shard(0)
+	require.True(t, ok)
+	require.Equal(t, shard.Leaving, s.State())
+
+	c, ok := updated.Instance("c")
+	require.True(t, ok)
+	s, ok = c.Shards().Shard(0)
+	require.True(t, ok)
+	require.Equal(t, shard.Initializing, s.State())
+	require.Equal(t, "a", s.SourceID())
+
+	// The original placement is not modified.
+	a, _ = p.Instance("a")
+	s, _ = a.Shards().Shard(0)
+	require.Equal(t, shard.Available, s.State())
+
+	// The rebalance cannot be computed while shards are moving.
+	_, err = NewRebalance(updated, reports, RebalanceOptions{Now: now})
+	require.Equal(t, errRebalanceInProgress, err)
+}
+
+func TestNewRebalanceKeepsReplicasInDistinctIsolationGroups(t *testing.T) {
+	p, reports, now := newTestRebalancePlacement(2,
+		newTestInstance("a", "g1", 0, 1),
+		newTestInstance("b", "g2", 0, 1),
+		newTestInstance("c", "g2"),
+	)
+
+	rebalance, err := NewRebalance(p, reports, RebalanceOptions{Now: now})
+	require.NoError(t, err)
+	require.Empty(t, rebalance.Moves)
+	require.Equal(t, rebalance.SkewBefore, rebalance.SkewAfter)
+}
+
+func TestNewRebalanceRespectsDiskHeadroom(t *testing.T) {
+	p, reports, now := newTestRebalancePlacement(2,
+		newTestInstance("a", "g1", 0, 1, 2),
+		newTestInstance("b", "g2", 0, 1),
+		newTestInstance("c", "g1", 3),
+		newTestInstance("d", "g2", 2, 3),
+	)
+	for id, report := range reports {
+		report.DiskTotalBytes = 1000
+		report.DiskFreeBytes = 500
+		for i := range report.Shards {
+			report.Shards[i].DiskUsedBytes = 200
+		}
+		reports[id] = report
+	}
+
+	rebalance, err := NewRebalance(p, reports, RebalanceOptions{
+		Now:          now,
+		DiskHeadroom: 0.4,
+	})
+	require.NoError(t, err)
+	require.Empty(t, rebalance.Moves)
+}
+
+func TestNewRebalanceRequiresRecentReports(t *testing.T) {
+	p, reports, now := newTestRebalancePlacement(1,
+		newTestInstance("a", "g1", 0),
+		newTestInstance("b", "g2", 1),
+	)
+	delete(reports, "b")
+
+	_, err := NewRebalance(p, reports, RebalanceOptions{Now: now})
+	require.Error(t, err)
+
+	_, err = NewRebalance(p, reports, RebalanceOptions{Now: now, Resource: "cpu"})
+	require.Error(t, err)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package capacity plans the capacity of a cluster from the resource usage
+// reported by each of its nodes.
+package capacity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+)
+
+// ReportKeyPrefix is the prefix of the KV keys the nodes publish their
+// reports to, suffixed by the host ID of the node.
+const ReportKeyPrefix = "m3db.node.capacity-report/"
+
+// ReportKey returns the KV key of the report of a node.
+func ReportKey(hostID string) string {
+	return ReportKeyPrefix + hostID
+}
+
+// NodeReport is the resource usage of a node.
+type NodeReport struct {
+	HostID     string    `json:"hostID"`
+	ReportedAt time.Time `json:"reportedAt"`
+	// DiskUsedBytes is the size of the files under the file path prefix.
+	DiskUsedBytes int64 `json:"diskUsedBytes"`
+	// DiskTotalBytes and DiskFreeBytes are the size and free space of the
+	// filesystem holding the file path prefix.
+	DiskTotalBytes int64         `json:"diskTotalBytes"`
+	DiskFreeBytes  int64         `json:"diskFreeBytes"`
+	Shards         []ShardReport `json:"shards"`
+}
+
+// ShardReport is the resource usage of a shard of a node, summed across
+// namespaces.
+type ShardReport struct {
+	Shard         uint32  `json:"shard"`
+	Series        int64   `json:"series"`
+	DiskUsedBytes int64   `json:"diskUsedBytes"`
+	WritesPerSec  float64 `json:"writesPerSec"`
+}
+
+// Series returns the number of series of the node.
+func (r NodeReport) Series() int64 {
+	var series int64
+	for _, s := range r.Shards {
+		series += s.Series
+	}
+	return series
+}
+
+// WritesPerSec returns the write rate of the node.
+func (r NodeReport) WritesPerSec() float64 {
+	var writes float64
+	for _, s := range r.Shards {
+		writes += s.WritesPerSec
+	}
+	return writes
+}
+
+// PublishReport publishes the report of a node to KV.
+func PublishReport(store kv.Store, report NodeReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = store.Set(ReportKey(report.HostID),
+		&commonpb.StringProto{Value: string(data)})
+	return err
+}
+
+// GetReport returns the report of a node from KV, or false if the node has
+// not published a report.
+func GetReport(store kv.Store, hostID string) (NodeReport, bool, error) {
+	value, err := store.Get(ReportKey(hostID))
+	if err == kv.ErrNotFound {
+		return NodeReport{}, false, nil
+	}
+	if err != nil {
+		return NodeReport{}, false, err
+	}
+
+	var proto commonpb.StringProto
+	if err := value.Unmarshal(&proto); err != nil {
+		return NodeReport{}, false, err
+	}
+	var report NodeReport
+	if err := json.Unmarshal([]byte(proto.Value), &report); err != nil {
+		return NodeReport{}, false, err
+	}
+	return report, true, nil
+}
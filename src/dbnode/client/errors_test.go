@@ -26,12 +26,23 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xerrors "github.com/m3db/m3/src/x/errors"
 
 	"github.com/stretchr/testify/assert"
 )
 
+func TestIsResourceExhaustedError(t *testing.T) {
+	rpcErr := tterrors.NewResourceExhaustedError(fmt.Errorf("query limit exceeded"))
+	assert.True(t, IsResourceExhaustedError(rpcErr))
+	assert.True(t, IsBadRequestError(rpcErr))
+	assert.True(t, IsResourceExhaustedError(xerrors.NewRenamedError(rpcErr, fmt.Errorf("renamed"))))
+
+	assert.False(t, IsResourceExhaustedError(tterrors.NewBadRequestError(fmt.Errorf("bad request"))))
+	assert.False(t, IsResourceExhaustedError(fmt.Errorf("foo")))
+}
+
 func TestConsistencyResultError(t *testing.T) {
 	badReqErr := xerrors.NewRenamedError(&rpc.Error{
 		Type: rpc.ErrorType_BAD_REQUEST,
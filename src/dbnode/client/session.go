@@ -170,12 +170,14 @@ type shardMetricsKey struct {
 type sessionMetrics struct {
 	sync.RWMutex
 	writeSuccess                         tally.Counter
+	writeSuccessPartial                  tally.Counter
 	writeErrorsBadRequest                tally.Counter
 	writeErrorsInternalError             tally.Counter
 	writeLatencyHistogram                tally.Histogram
 	writeNodesRespondingErrors           []tally.Counter
 	writeNodesRespondingBadRequestErrors []tally.Counter
 	fetchSuccess                         tally.Counter
+	fetchSuccessDegraded                 tally.Counter
 	fetchErrorsBadRequest                tally.Counter
 	fetchErrorsInternalError             tally.Counter
 	fetchLatencyHistogram                tally.Histogram
@@ -183,12 +185,16 @@ type sessionMetrics struct {
 	fetchNodesRespondingBadRequestErrors []tally.Counter
 	topologyUpdatedSuccess               tally.Counter
 	topologyUpdatedError                 tally.Counter
+	topologyHostQueuesReused             tally.Counter
+	topologyHostQueuesCreated            tally.Counter
+	topologyHostQueuesClosed             tally.Counter
 	streamFromPeersMetrics               map[shardMetricsKey]streamFromPeersMetrics
 }
 
 func newSessionMetrics(scope tally.Scope) sessionMetrics {
 	return sessionMetrics{
-		writeSuccess: scope.Counter("write.success"),
+		writeSuccess:        scope.Counter("write.success"),
+		writeSuccessPartial: scope.Counter("write.success-partial"),
 		writeErrorsBadRequest: scope.Tagged(map[string]string{
 			"error_type": "bad_request",
 		}).Counter("write.errors"),
@@ -197,6 +203,7 @@ func newSessionMetrics(scope tally.Scope) sessionMetrics {
 		}).Counter("write.errors"),
 		writeLatencyHistogram: histogramWithDurationBuckets(scope, "write.latency"),
 		fetchSuccess:          scope.Counter("fetch.success"),
+		fetchSuccessDegraded:  scope.Counter("fetch.success-degraded"),
 		fetchErrorsBadRequest: scope.Tagged(map[string]string{
 			"error_type": "bad_request",
 		}).Counter("fetch.errors"),
@@ -206,6 +213,15 @@ func newSessionMetrics(scope tally.Scope) sessionMetrics {
 		fetchLatencyHistogram:  histogramWithDurationBuckets(scope, "fetch.latency"),
 		topologyUpdatedSuccess: scope.Counter("topology.updated-success"),
 		topologyUpdatedError:   scope.Counter("topology.updated-error"),
+		topologyHostQueuesReused: scope.Tagged(map[string]string{
+			"action": "reused",
+		}).Counter("topology.host-queues"),
+		topologyHostQueuesCreated: scope.Tagged(map[string]string{
+			"action": "created",
+		}).Counter("topology.host-queues"),
+		topologyHostQueuesClosed: scope.Tagged(map[string]string{
+			"action": "closed",
+		}).Counter("topology.host-queues"),
 		streamFromPeersMetrics: make(map[shardMetricsKey]streamFromPeersMetrics),
 	}
 }
@@ -452,6 +468,11 @@ func (s *session) recordWriteMetrics(consistencyResultErr error, respErrs int32,
 	}
 	if consistencyResultErr == nil {
 		s.metrics.writeSuccess.Inc(1)
+		if respErrs > 0 {
+			// Consistency level was met but at least one replica failed the
+			// write, leaving it to be repaired.
+			s.metrics.writeSuccessPartial.Inc(1)
+		}
 	} else if IsBadRequestError(consistencyResultErr) {
 		s.metrics.writeErrorsBadRequest.Inc(1)
 	} else {
@@ -863,17 +884,33 @@ func (s *session) setTopologyWithLock(topoMap topology.Map, queues []hostQueue,
 		}
 	}
 
+	// Only queues for hosts that are new to the topology need to be connected,
+	// queues for existing hosts are carried over untouched.
+	closeQueues := make([]hostQueue, 0, len(prevQueues))
+	for _, queue := range prevQueues {
+		newQueue, ok := newQueuesByHostID[queue.Host().ID()]
+		if !ok || newQueue != queue {
+			closeQueues = append(closeQueues, queue)
+		}
+	}
+	numReused := len(prevQueues) - len(closeQueues)
+	numCreated := len(queues) - numReused
+	s.metrics.topologyHostQueuesReused.Inc(int64(numReused))
+	s.metrics.topologyHostQueuesCreated.Inc(int64(numCreated))
+	s.metrics.topologyHostQueuesClosed.Inc(int64(len(closeQueues)))
+
 	// Asynchronously close the set of host queues no longer in use
 	go func() {
-		for _, queue := range prevQueues {
-			newQueue, ok := newQueuesByHostID[queue.Host().ID()]
-			if !ok || newQueue != queue {
-				queue.Close()
-			}
+		for _, queue := range closeQueues {
+			queue.Close()
 		}
 	}()
 
-	s.log.Info("successfully updated topology", zap.Int("numHosts", topoMap.HostsLen()))
+	s.log.Info("successfully updated topology",
+		zap.Int("numHosts", topoMap.HostsLen()),
+		zap.Int("hostQueuesReused", numReused),
+		zap.Int("hostQueuesCreated", numCreated),
+		zap.Int("hostQueuesClosed", len(closeQueues)))
 }
 
 func (s *session) newHostQueue(host topology.Host, topoMap topology.Map) (hostQueue, error) {
@@ -1622,6 +1659,11 @@ func (s *session) fetchIDsAttempt(
 					// Avoid decoding more data than is required to satisfy the consistency guarantees.
 					numItersToInclude = numDesired
 				}
+				if numItersToInclude < numDesired {
+					// Consistency was satisfied by an unstrict level with fewer
+					// replicas than it would ideally merge.
+					s.metrics.fetchSuccessDegraded.Inc(1)
+				}
 
 				itersToInclude := results[:numItersToInclude]
 				resultsLock.RUnlock()
@@ -2934,6 +2976,26 @@ func (s *session) selectPeersFromPerPeerBlockMetadatas(
 	return currEligible, pooled
 }
 
+// fetchBlocksRawResultBytes returns the size of the segments of the blocks
+// streamed from a peer.
+func fetchBlocksRawResultBytes(result *rpc.FetchBlocksRawResult_) int {
+	var bytes int
+	for _, elem := range result.Elements {
+		for _, block := range elem.Blocks {
+			if block.Segments == nil {
+				continue
+			}
+			if seg := block.Segments.Merged; seg != nil {
+				bytes += len(seg.Head) + len(seg.Tail)
+			}
+			for _, seg := range block.Segments.Unmerged {
+				bytes += len(seg.Head) + len(seg.Tail)
+			}
+		}
+	}
+	return bytes
+}
+
 func (s *session) streamBlocksBatchFromPeer(
 	namespaceMetadata namespace.Metadata,
 	shard uint32,
@@ -2996,6 +3058,10 @@ func (s *session) streamBlocksBatchFromPeer(
 		return
 	}
 
+	if throttle := opts.StreamThrottle(); throttle != nil {
+		throttle.Wait(fetchBlocksRawResultBytes(result))
+	}
+
 	// Parse and act on result
 	tooManyIDsLogged := false
 	for i := range result.Elements {
@@ -2531,3 +2531,29 @@ func assertEnqueueChannel(
 
 	close(enqueueCh.peersMetadataCh)
 }
+
+func TestFetchBlocksRawResultBytes(t *testing.T) {
+	result := &rpc.FetchBlocksRawResult_{
+		Elements: []*rpc.Blocks{
+			{
+				Blocks: []*rpc.Block{
+					{Segments: &rpc.Segments{
+						Merged: &rpc.Segment{Head: []byte{1, 2}, Tail: []byte{3}},
+					}},
+					{},
+				},
+			},
+			{
+				Blocks: []*rpc.Block{
+					{Segments: &rpc.Segments{
+						Unmerged: []*rpc.Segment{
+							{Head: []byte{1}, Tail: []byte{2}},
+							{Head: []byte{3, 4}},
+						},
+					}},
+				},
+			},
+		},
+	}
+	assert.Equal(t, 7, fetchBlocksRawResultBytes(result))
+}
@@ -412,14 +412,21 @@ func testFetchConsistencyLevel(
 
 	assert.NoError(t, session.Close())
 
-	counters := reporter.Counters()
-	for counters["fetch.success"] == 0 && counters["fetch.errors"] == 0 {
-		time.Sleep(time.Millisecond)
-		counters = reporter.Counters()
-	}
+	counters := waitForCounters(t, reporter, func(counters map[string]int64) bool {
+		return counters["fetch.success"] != 0 || counters["fetch.errors"] != 0
+	})
 	if expected == outcomeSuccess {
 		assert.Equal(t, 1, int(counters["fetch.success"]))
 		assert.Equal(t, 0, int(counters["fetch.errors"]))
+
+		expectedDegraded := 0
+		if 3-failures < topology.NumDesiredForReadConsistency(level, 3, 2) {
+			expectedDegraded = 1
+		}
+		counters = waitForCounters(t, reporter, func(counters map[string]int64) bool {
+			return int(counters["fetch.success-degraded"]) >= expectedDegraded
+		})
+		assert.Equal(t, expectedDegraded, int(counters["fetch.success-degraded"]))
 	} else {
 		assert.Equal(t, 0, int(counters["fetch.success"]))
 		assert.Equal(t, 1, int(counters["fetch.errors"]))
@@ -437,6 +444,26 @@ func testFetchConsistencyLevel(
 	}
 }
 
+// waitForCounters waits for the reported counters to satisfy the given
+// condition and fails the test if they do not within a minute.
+func waitForCounters(
+	t *testing.T,
+	reporter xmetrics.TestStatsReporter,
+	fn func(counters map[string]int64) bool,
+) map[string]int64 {
+	deadline := time.Now().Add(time.Minute)
+	for {
+		counters := reporter.Counters()
+		if fn(counters) {
+			return counters
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for counters, reported: %v", counters)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func prepareTestFetchEnqueuesWithErrors(
 	t *testing.T,
 	ctrl *gomock.Controller,
@@ -166,4 +166,18 @@ func TestSessionTopologyChangeCreatesNewClosesOldHostQueues(t *testing.T) {
 	require.Equal(t, 1, len(createdQueues.get("testhost1")))
 	require.Equal(t, 1, len(createdQueues.get("testhost2")))
 	require.Equal(t, 1, len(closedQueues.get("testhost0")))
+
+	// Assert only the moved host was reconnected
+	counters := scope.Snapshot().Counters()
+	for action, expected := range map[string]int64{
+		"created": 3,
+		"reused":  1,
+		"closed":  1,
+	} {
+		key := tally.KeyForPrefixedStringMap("topology.host-queues",
+			map[string]string{"action": action})
+		counter, ok := counters[key]
+		require.True(t, ok, action)
+		assert.Equal(t, expected, counter.Value(), action)
+	}
 }
@@ -247,6 +247,51 @@ func TestSessionWriteBadRequestErrorIsNonRetryable(t *testing.T) {
 	assert.NoError(t, session.Close())
 }
 
+func TestSessionWriteConsistencyLevelMajorityPartialSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	opts := newSessionTestOptions().
+		SetWriteConsistencyLevel(topology.ConsistencyLevelMajority).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	session := newTestSession(t, opts).(*session)
+
+	var completionFn completionFn
+	enqueueWg := mockHostQueues(ctrl, session, sessionTestReplicas, []testEnqueueFn{func(idx int, op op) {
+		completionFn = op.CompletionFn()
+	}})
+
+	assert.NoError(t, session.Open())
+
+	var (
+		resultErr error
+		writeWg   sync.WaitGroup
+	)
+	writeWg.Add(1)
+	go func() {
+		resultErr = session.Write(ident.StringID("testNs"), ident.StringID("foo"),
+			time.Now(), 1.0, xtime.Second, nil)
+		writeWg.Done()
+	}()
+
+	// Fail one replica before the remaining two succeed.
+	enqueueWg.Wait()
+	host := session.state.topoMap.Hosts()[0]
+	completionFn(host, errors.New("a specific write error"))
+	completionFn(host, nil)
+	completionFn(host, nil)
+
+	writeWg.Wait()
+	require.NoError(t, resultErr)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["write.success+"].Value())
+	assert.Equal(t, int64(1), counters["write.success-partial+"].Value())
+
+	assert.NoError(t, session.Close())
+}
+
 func TestSessionWriteRetry(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
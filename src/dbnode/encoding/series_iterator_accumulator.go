@@ -43,6 +43,8 @@ type seriesIteratorAccumulator struct {
 	err             error
 	firstNext       bool
 	closed          bool
+
+	allowMultipleNamespaces bool
 }
 
 // SeriesAccumulatorOptions are options for a SeriesIteratorAccumulator.
@@ -50,6 +52,11 @@ type SeriesAccumulatorOptions struct {
 	// RetainTags determines if tags should be preserved after the accumulator is
 	// exhausted. If set to true, the accumulator retains a copy of the tags.
 	RetainTags bool
+	// AllowMultipleNamespaces allows iterators from different namespaces to
+	// be accumulated, such as when stitching together the results of reading
+	// different time ranges from different namespaces. The accumulator uses
+	// the namespace of the first iterator.
+	AllowMultipleNamespaces bool
 }
 
 // NewSeriesIteratorAccumulator creates a new series iterator.
@@ -63,6 +70,8 @@ func NewSeriesIteratorAccumulator(
 		id:              ident.StringID(iter.ID().String()),
 		nsID:            ident.StringID(iter.Namespace().String()),
 		seriesIterators: make([]SeriesIterator, 0, 2),
+
+		allowMultipleNamespaces: opts.AllowMultipleNamespaces,
 	}
 
 	if opts.RetainTags {
@@ -82,7 +91,7 @@ func (it *seriesIteratorAccumulator) Add(iter SeriesIterator) error {
 		return it.err
 	}
 
-	if newNs := iter.Namespace(); !newNs.Equal(it.nsID) {
+	if newNs := iter.Namespace(); !it.allowMultipleNamespaces && !newNs.Equal(it.nsID) {
 		return fmt.Errorf("cannot add iterator with namespace %s to accumulator %s",
 			newNs.String(), it.nsID.String())
 	}
@@ -292,3 +292,53 @@ func TestAccumulatorMocked(t *testing.T) {
 	assert.Equal(t, 1, i)
 	it.Close()
 }
+
+func TestAccumulatorMultipleNamespaces(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now()
+	newIter := func(ns string, t time.Time, v float64) *MockSeriesIterator {
+		iter := NewMockSeriesIterator(ctrl)
+		iter.EXPECT().ID().Return(ident.StringID("id")).AnyTimes()
+		iter.EXPECT().Namespace().Return(ident.StringID(ns)).AnyTimes()
+		iter.EXPECT().Next().Return(true)
+		dp := ts.Datapoint{TimestampNanos: xtime.ToUnixNano(t), Value: v}
+		iter.EXPECT().Current().Return(dp, xtime.Second, nil).AnyTimes()
+		iter.EXPECT().Next().Return(false).AnyTimes()
+		iter.EXPECT().Start().Return(t).AnyTimes()
+		iter.EXPECT().End().Return(t.Add(time.Minute)).AnyTimes()
+		iter.EXPECT().Err().Return(nil).AnyTimes()
+		iter.EXPECT().Close().AnyTimes()
+		return iter
+	}
+
+	coarse := newIter("coarse", start, 1)
+	fine := newIter("fine", start.Add(time.Hour), 2)
+
+	it, err := NewSeriesIteratorAccumulator(coarse, SeriesAccumulatorOptions{})
+	require.NoError(t, err)
+	require.Error(t, it.Add(fine))
+	it.Close()
+
+	coarse = newIter("coarse", start, 1)
+	fine = newIter("fine", start.Add(time.Hour), 2)
+	it, err = NewSeriesIteratorAccumulator(coarse, SeriesAccumulatorOptions{
+		AllowMultipleNamespaces: true,
+	})
+	require.NoError(t, err)
+	require.NoError(t, it.Add(fine))
+	assert.Equal(t, "coarse", it.Namespace().String())
+	assert.Equal(t, start, it.Start())
+	assert.Equal(t, start.Add(time.Hour+time.Minute), it.End())
+
+	var values []float64
+	for it.Next() {
+		dp, _, _ := it.Current()
+		values = append(values, dp.Value)
+	}
+
+	require.NoError(t, it.Err())
+	assert.Equal(t, []float64{1, 2}, values)
+	it.Close()
+}
@@ -35,6 +35,9 @@ enum ErrorType {
 exception Error {
 	1: required ErrorType type = ErrorType.INTERNAL_ERROR
 	2: required string message
+	// flags is a bitmask of error flags that further classify the error,
+	// for instance an exceeded resource limit.
+	3: optional i64 flags = 0
 }
 
 exception WriteBatchRawErrors {
@@ -81,6 +84,9 @@ service Node {
 
 	// Debug endpoints
 	DebugIndexMemorySegmentsResult debugIndexMemorySegments(1: DebugIndexMemorySegmentsRequest req) throws (1: Error err)
+
+	// Import endpoints
+	ImportFileSetResult importFileSet(1: ImportFileSetRequest req) throws (1: Error err)
 }
 
 struct FetchRequest {
@@ -495,3 +501,16 @@ struct DebugIndexMemorySegmentsRequest {
 
 struct DebugIndexMemorySegmentsResult {
 }
+
+struct ImportFileSetRequest {
+	1: required binary nameSpace
+	2: required i32 shard
+	3: required i64 blockStart
+	4: required binary csv
+}
+
+struct ImportFileSetResult {
+	1: required i64 numSeries
+	2: required i64 numDatapoints
+	3: required i64 volumeIndex
+}
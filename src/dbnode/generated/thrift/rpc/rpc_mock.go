@@ -420,6 +420,21 @@ func (mr *MockTChanNodeMockRecorder) Health(ctx interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockTChanNode)(nil).Health), ctx)
 }
 
+// ImportFileSet mocks base method
+func (m *MockTChanNode) ImportFileSet(ctx thrift.Context, req *ImportFileSetRequest) (*ImportFileSetResult_, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportFileSet", ctx, req)
+	ret0, _ := ret[0].(*ImportFileSetResult_)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportFileSet indicates an expected call of ImportFileSet
+func (mr *MockTChanNodeMockRecorder) ImportFileSet(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportFileSet", reflect.TypeOf((*MockTChanNode)(nil).ImportFileSet), ctx, req)
+}
+
 // Query mocks base method
 func (m *MockTChanNode) Query(ctx thrift.Context, req *QueryRequest) (*QueryResult_, error) {
 	m.ctrl.T.Helper()
@@ -61,6 +61,7 @@ type TChanNode interface {
 	GetWriteNewSeriesBackoffDuration(ctx thrift.Context) (*NodeWriteNewSeriesBackoffDurationResult_, error)
 	GetWriteNewSeriesLimitPerShardPerSecond(ctx thrift.Context) (*NodeWriteNewSeriesLimitPerShardPerSecondResult_, error)
 	Health(ctx thrift.Context) (*NodeHealthResult_, error)
+	ImportFileSet(ctx thrift.Context, req *ImportFileSetRequest) (*ImportFileSetResult_, error)
 	Query(ctx thrift.Context, req *QueryRequest) (*QueryResult_, error)
 	Repair(ctx thrift.Context) error
 	SetPersistRateLimit(ctx thrift.Context, req *NodeSetPersistRateLimitRequest) (*NodePersistRateLimitResult_, error)
@@ -754,6 +755,24 @@ func (c *tchanNodeClient) Health(ctx thrift.Context) (*NodeHealthResult_, error)
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) ImportFileSet(ctx thrift.Context, req *ImportFileSetRequest) (*ImportFileSetResult_, error) {
+	var resp NodeImportFileSetResult
+	args := NodeImportFileSetArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "importFileSet", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for importFileSet")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Query(ctx thrift.Context, req *QueryRequest) (*QueryResult_, error) {
 	var resp NodeQueryResult
 	args := NodeQueryArgs{
@@ -1020,6 +1039,7 @@ func (s *tchanNodeServer) Methods() []string {
 		"getWriteNewSeriesBackoffDuration",
 		"getWriteNewSeriesLimitPerShardPerSecond",
 		"health",
+		"importFileSet",
 		"query",
 		"repair",
 		"setPersistRateLimit",
@@ -1070,6 +1090,8 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleGetWriteNewSeriesLimitPerShardPerSecond(ctx, protocol)
 	case "health":
 		return s.handleHealth(ctx, protocol)
+	case "importFileSet":
+		return s.handleImportFileSet(ctx, protocol)
 	case "query":
 		return s.handleQuery(ctx, protocol)
 	case "repair":
@@ -1550,6 +1572,34 @@ func (s *tchanNodeServer) handleHealth(ctx thrift.Context, protocol athrift.TPro
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleImportFileSet(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeImportFileSetArgs
+	var res NodeImportFileSetResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.ImportFileSet(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleQuery(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeQueryArgs
 	var res NodeQueryResult
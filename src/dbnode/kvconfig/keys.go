@@ -33,6 +33,16 @@ const (
 	// configuration specifying a hard limit for a cluster new series insertions.
 	ClusterNewSeriesInsertLimitKey = "m3db.node.cluster-new-series-insert-limit"
 
+	// NamespaceStatesKey is the KV config key for the runtime configuration
+	// specifying the states of namespaces as a comma separated list of
+	// namespace=state pairs, e.g. "metrics=read-only".
+	NamespaceStatesKey = "m3db.node.namespace-states"
+
+	// SeriesTombstonesKey is the KV config key for the runtime configuration
+	// specifying the series tombstones as a JSON array, see
+	// namespace.ParseSeriesTombstones.
+	SeriesTombstonesKey = "m3db.node.series-tombstones"
+
 	// ClientBootstrapConsistencyLevel is the KV config key for the runtime
 	// configuration specifying the client bootstrap consistency level
 	ClientBootstrapConsistencyLevel = "m3db.client.bootstrap-consistency-level"
@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errStateUnspecified = errors.New("namespace state unspecified")
+
+// State is the administrative state of a namespace, which gates the writes
+// and reads it accepts.
+type State uint
+
+const (
+	// StateActive specifies that the namespace accepts writes and reads.
+	StateActive State = iota
+	// StateReadOnly specifies that the namespace rejects writes but remains
+	// queryable, e.g. to freeze a namespace during a migration.
+	StateReadOnly
+	// StateQuiesced specifies that the namespace rejects both writes and
+	// reads, data is still flushed and streamed to peers.
+	StateQuiesced
+)
+
+// ValidStates returns the valid namespace states.
+func ValidStates() []State {
+	return []State{StateActive, StateReadOnly, StateQuiesced}
+}
+
+func (s State) String() string {
+	switch s {
+	case StateActive:
+		return "active"
+	case StateReadOnly:
+		return "read-only"
+	case StateQuiesced:
+		return "quiesced"
+	}
+	return "unknown"
+}
+
+// AcceptsWrites returns whether a namespace in the state accepts writes.
+func (s State) AcceptsWrites() bool {
+	return s == StateActive
+}
+
+// AcceptsReads returns whether a namespace in the state accepts reads.
+func (s State) AcceptsReads() bool {
+	return s != StateQuiesced
+}
+
+// ParseState parses a State from a string.
+func ParseState(str string) (State, error) {
+	var r State
+	if str == "" {
+		return r, errStateUnspecified
+	}
+	for _, valid := range ValidStates() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return r, fmt.Errorf("invalid namespace State '%s' valid types are: %v",
+		str, ValidStates())
+}
+
+// ParseStates parses the states of namespaces from a comma separated list of
+// namespace=state pairs, e.g. "metrics=read-only,archive=quiesced".
+// Namespaces not listed are active.
+func ParseStates(str string) (map[string]State, error) {
+	states := make(map[string]State)
+	for _, pair := range strings.Split(str, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.Split(pair, "=")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid namespace state '%s' expected namespace=state", pair)
+		}
+		state, err := ParseState(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+		states[strings.TrimSpace(parts[0])] = state
+	}
+	return states, nil
+}
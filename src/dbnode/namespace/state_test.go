@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseState(t *testing.T) {
+	for _, valid := range ValidStates() {
+		state, err := ParseState(valid.String())
+		require.NoError(t, err)
+		require.Equal(t, valid, state)
+	}
+
+	_, err := ParseState("")
+	require.Error(t, err)
+	_, err = ParseState("frozen")
+	require.Error(t, err)
+}
+
+func TestStateAccepts(t *testing.T) {
+	require.True(t, StateActive.AcceptsWrites())
+	require.True(t, StateActive.AcceptsReads())
+	require.False(t, StateReadOnly.AcceptsWrites())
+	require.True(t, StateReadOnly.AcceptsReads())
+	require.False(t, StateQuiesced.AcceptsWrites())
+	require.False(t, StateQuiesced.AcceptsReads())
+}
+
+func TestParseStates(t *testing.T) {
+	states, err := ParseStates(" metrics=read-only, archive = quiesced,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]State{
+		"metrics": StateReadOnly,
+		"archive": StateQuiesced,
+	}, states)
+
+	states, err = ParseStates("")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(states))
+
+	_, err = ParseStates("metrics")
+	require.Error(t, err)
+	_, err = ParseStates("=read-only")
+	require.Error(t, err)
+	_, err = ParseStates("metrics=frozen")
+	require.Error(t, err)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var (
+	errTombstoneIDUnspecified        = errors.New("series tombstone ID unspecified")
+	errTombstoneNamespaceUnspecified = errors.New("series tombstone namespace unspecified")
+	errTombstoneNoSeries             = errors.New("series tombstone has no series")
+)
+
+// SeriesTombstone marks the datapoints of a set of series within a time range
+// of a namespace as deleted. The series are resolved from matchers when the
+// tombstone is created, series written afterwards are not affected.
+type SeriesTombstone struct {
+	// ID uniquely identifies the tombstone.
+	ID string `json:"id"`
+	// Namespace is the namespace of the series.
+	Namespace string `json:"namespace"`
+	// Matchers are the selectors the series were resolved from, these are
+	// informational only.
+	Matchers []string `json:"matchers,omitempty"`
+	// SeriesIDs are the IDs of the deleted series.
+	SeriesIDs []string `json:"seriesIDs"`
+	// Start is the inclusive start of the deleted time range.
+	Start time.Time `json:"start"`
+	// End is the exclusive end of the deleted time range.
+	End time.Time `json:"end"`
+	// CreatedAt is the time the tombstone was created.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Validate validates the tombstone.
+func (t SeriesTombstone) Validate() error {
+	if t.ID == "" {
+		return errTombstoneIDUnspecified
+	}
+	if t.Namespace == "" {
+		return errTombstoneNamespaceUnspecified
+	}
+	if len(t.SeriesIDs) == 0 {
+		return errTombstoneNoSeries
+	}
+	if !t.Start.Before(t.End) {
+		return fmt.Errorf("series tombstone %s start %v is not before end %v",
+			t.ID, t.Start, t.End)
+	}
+	return nil
+}
+
+// SeriesTombstones is an immutable set of series tombstones indexed by
+// namespace and series ID. A nil set contains no tombstones.
+type SeriesTombstones struct {
+	tombstones []SeriesTombstone
+	ranges     map[string]map[string][]xtime.Range
+}
+
+// NewSeriesTombstones returns a new set of series tombstones.
+func NewSeriesTombstones(tombstones []SeriesTombstone) (*SeriesTombstones, error) {
+	set := &SeriesTombstones{
+		tombstones: tombstones,
+		ranges:     make(map[string]map[string][]xtime.Range),
+	}
+	ids := make(map[string]struct{}, len(tombstones))
+	for _, t := range tombstones {
+		if err := t.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := ids[t.ID]; ok {
+			return nil, fmt.Errorf("duplicate series tombstone %s", t.ID)
+		}
+		ids[t.ID] = struct{}{}
+
+		series, ok := set.ranges[t.Namespace]
+		if !ok {
+			series = make(map[string][]xtime.Range, len(t.SeriesIDs))
+			set.ranges[t.Namespace] = series
+		}
+		r := xtime.Range{Start: t.Start, End: t.End}
+		for _, id := range t.SeriesIDs {
+			series[id] = append(series[id], r)
+		}
+	}
+	return set, nil
+}
+
+// ParseSeriesTombstones parses a set of series tombstones from a JSON array,
+// an empty string is an empty set.
+func ParseSeriesTombstones(str string) (*SeriesTombstones, error) {
+	var tombstones []SeriesTombstone
+	if strings.TrimSpace(str) != "" {
+		if err := json.Unmarshal([]byte(str), &tombstones); err != nil {
+			return nil, fmt.Errorf("invalid series tombstones: %v", err)
+		}
+	}
+	return NewSeriesTombstones(tombstones)
+}
+
+// MarshalSeriesTombstones marshals series tombstones to the JSON array
+// parsed by ParseSeriesTombstones.
+func MarshalSeriesTombstones(tombstones []SeriesTombstone) (string, error) {
+	if tombstones == nil {
+		tombstones = []SeriesTombstone{}
+	}
+	b, err := json.Marshal(tombstones)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// Tombstones returns the tombstones of the set.
+func (s *SeriesTombstones) Tombstones() []SeriesTombstone {
+	if s == nil {
+		return nil
+	}
+	return s.tombstones
+}
+
+// HasNamespace returns whether any tombstone deletes series of a namespace.
+func (s *SeriesTombstones) HasNamespace(namespace ident.ID) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.ranges[string(namespace.Bytes())]
+	return ok
+}
+
+// NamespaceRanges returns the deleted time ranges of any series of a
+// namespace, which may overlap.
+func (s *SeriesTombstones) NamespaceRanges(namespace ident.ID) []xtime.Range {
+	if s == nil {
+		return nil
+	}
+	var (
+		nsID   = string(namespace.Bytes())
+		ranges []xtime.Range
+	)
+	for _, t := range s.tombstones {
+		if t.Namespace == nsID {
+			ranges = append(ranges, xtime.Range{Start: t.Start, End: t.End})
+		}
+	}
+	return ranges
+}
+
+// SeriesRanges returns the deleted time ranges of a series, which may
+// overlap.
+func (s *SeriesTombstones) SeriesRanges(namespace, id ident.ID) []xtime.Range {
+	if s == nil {
+		return nil
+	}
+	series, ok := s.ranges[string(namespace.Bytes())]
+	if !ok {
+		return nil
+	}
+	return series[string(id.Bytes())]
+}
+
+// OverlappingRanges returns the ranges overlapping a time range.
+func OverlappingRanges(ranges []xtime.Range, r xtime.Range) []xtime.Range {
+	var result []xtime.Range
+	for _, curr := range ranges {
+		if curr.Overlaps(r) {
+			result = append(result, curr)
+		}
+	}
+	return result
+}
+
+// RangesCover returns whether the ranges together cover a time range.
+func RangesCover(ranges []xtime.Range, r xtime.Range) bool {
+	if len(ranges) == 0 {
+		return false
+	}
+	remaining := xtime.NewRanges(r)
+	for _, curr := range ranges {
+		remaining.RemoveRange(curr)
+	}
+	return remaining.IsEmpty()
+}
+
+// RangesContain returns whether any of the ranges contains a time.
+func RangesContain(ranges []xtime.Range, t time.Time) bool {
+	for _, r := range ranges {
+		if !t.Before(r.Start) && t.Before(r.End) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesTombstonesRoundTrip(t *testing.T) {
+	start := time.Unix(1600000000, 0).UTC()
+	tombstones := []SeriesTombstone{
+		{
+			ID:        "a",
+			Namespace: "metrics",
+			Matchers:  []string{`{user="alice"}`},
+			SeriesIDs: []string{"foo", "bar"},
+			Start:     start,
+			End:       start.Add(time.Hour),
+			CreatedAt: start.Add(2 * time.Hour),
+		},
+		{
+			ID:        "b",
+			Namespace: "metrics",
+			SeriesIDs: []string{"foo"},
+			Start:     start.Add(2 * time.Hour),
+			End:       start.Add(3 * time.Hour),
+			CreatedAt: start.Add(3 * time.Hour),
+		},
+	}
+
+	str, err := MarshalSeriesTombstones(tombstones)
+	require.NoError(t, err)
+	set, err := ParseSeriesTombstones(str)
+	require.NoError(t, err)
+	require.Equal(t, tombstones, set.Tombstones())
+
+	ns := ident.StringID("metrics")
+	require.True(t, set.HasNamespace(ns))
+	require.False(t, set.HasNamespace(ident.StringID("other")))
+	require.Len(t, set.NamespaceRanges(ns), 2)
+
+	ranges := set.SeriesRanges(ns, ident.StringID("foo"))
+	require.Equal(t, []xtime.Range{
+		{Start: start, End: start.Add(time.Hour)},
+		{Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour)},
+	}, ranges)
+	require.Len(t, set.SeriesRanges(ns, ident.StringID("bar")), 1)
+	require.Nil(t, set.SeriesRanges(ns, ident.StringID("baz")))
+
+	require.True(t, RangesContain(ranges, start))
+	require.False(t, RangesContain(ranges, start.Add(time.Hour)))
+	require.True(t, RangesContain(ranges, start.Add(150*time.Minute)))
+
+	overlapping := OverlappingRanges(ranges, xtime.Range{
+		Start: start.Add(30 * time.Minute),
+		End:   start.Add(90 * time.Minute),
+	})
+	require.Equal(t, ranges[:1], overlapping)
+}
+
+func TestSeriesTombstonesNil(t *testing.T) {
+	var set *SeriesTombstones
+	require.False(t, set.HasNamespace(ident.StringID("metrics")))
+	require.Nil(t, set.SeriesRanges(ident.StringID("metrics"), ident.StringID("foo")))
+	require.Nil(t, set.Tombstones())
+
+	set, err := ParseSeriesTombstones("")
+	require.NoError(t, err)
+	require.Empty(t, set.Tombstones())
+}
+
+func TestSeriesTombstonesInvalid(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	valid := SeriesTombstone{
+		ID:        "a",
+		Namespace: "metrics",
+		SeriesIDs: []string{"foo"},
+		Start:     start,
+		End:       start.Add(time.Hour),
+	}
+
+	_, err := NewSeriesTombstones([]SeriesTombstone{valid, valid})
+	require.Error(t, err)
+
+	noNamespace := valid
+	noNamespace.Namespace = ""
+	_, err = NewSeriesTombstones([]SeriesTombstone{noNamespace})
+	require.Error(t, err)
+
+	emptyRange := valid
+	emptyRange.End = emptyRange.Start
+	_, err = NewSeriesTombstones([]SeriesTombstone{emptyRange})
+	require.Error(t, err)
+
+	_, err = ParseSeriesTombstones("{")
+	require.Error(t, err)
+}
+
+func TestRangesCover(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	r := xtime.Range{Start: start, End: start.Add(2 * time.Hour)}
+
+	require.False(t, RangesCover(nil, r))
+	require.False(t, RangesCover([]xtime.Range{
+		{Start: start, End: start.Add(time.Hour)},
+	}, r))
+	require.True(t, RangesCover([]xtime.Range{
+		{Start: start.Add(30 * time.Minute), End: start.Add(3 * time.Hour)},
+		{Start: start.Add(-time.Hour), End: start.Add(time.Hour)},
+	}, r))
+}
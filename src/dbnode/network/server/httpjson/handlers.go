@@ -29,6 +29,8 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	xerrors "github.com/m3db/m3/src/x/errors"
 
 	apachethrift "github.com/apache/thrift/lib/go/thrift"
@@ -257,6 +259,17 @@ func writeError(w http.ResponseWriter, errValue interface{}) {
 	switch v := errValue.(type) {
 	case Error:
 		w.WriteHeader(v.StatusCode())
+	case *rpc.Error:
+		switch {
+		case tterrors.IsResourceExhaustedErrorFlag(v):
+			// NB: The request exceeded one of its limits, such as a query
+			// limit, and will fail again unless it is narrowed.
+			w.WriteHeader(http.StatusTooManyRequests)
+		case tterrors.IsBadRequestError(v):
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
 	case error:
 		if xerrors.IsInvalidParams(v) {
 			w.WriteHeader(http.StatusBadRequest)
@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -189,6 +190,9 @@ func ToRPCError(err error) *rpc.Error {
 	if err == nil {
 		return nil
 	}
+	if m3dberrors.IsQueryLimitExceededError(err) {
+		return tterrors.NewResourceExhaustedError(err)
+	}
 	if xerrors.IsInvalidParams(err) {
 		return tterrors.NewBadRequestError(err)
 	}
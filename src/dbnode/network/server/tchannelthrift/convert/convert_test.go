@@ -21,15 +21,19 @@
 package convert_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/m3ninx/idx"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
 
@@ -257,6 +261,22 @@ func newTestPools() *testPools {
 	return &testPools{id, wrapper}
 }
 
+func TestToRPCError(t *testing.T) {
+	require.Nil(t, convert.ToRPCError(nil))
+
+	rpcErr := convert.ToRPCError(errors.New("foo"))
+	assert.True(t, tterrors.IsInternalError(rpcErr))
+	assert.False(t, tterrors.IsResourceExhaustedErrorFlag(rpcErr))
+
+	rpcErr = convert.ToRPCError(xerrors.NewInvalidParamsError(errors.New("foo")))
+	assert.True(t, tterrors.IsBadRequestError(rpcErr))
+	assert.False(t, tterrors.IsResourceExhaustedErrorFlag(rpcErr))
+
+	rpcErr = convert.ToRPCError(m3dberrors.NewQueryLimitExceededError("foo"))
+	assert.True(t, tterrors.IsBadRequestError(rpcErr))
+	assert.True(t, tterrors.IsResourceExhaustedErrorFlag(rpcErr))
+}
+
 var _ convert.FetchTaggedConversionPools = &testPools{}
 
 func (t *testPools) ID() ident.Pool                                     { return t.id }
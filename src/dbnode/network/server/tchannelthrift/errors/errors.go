@@ -26,6 +26,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 )
 
+const (
+	// ErrorFlagResourceExhausted is set on errors returned when a request
+	// exceeded one of its resource limits, such as a query limit.
+	ErrorFlagResourceExhausted int64 = 1 << iota
+)
+
 func newError(errType rpc.ErrorType, err error) *rpc.Error {
 	rpcErr := rpc.NewError()
 	rpcErr.Type = errType
@@ -43,6 +49,12 @@ func IsBadRequestError(err *rpc.Error) bool {
 	return err != nil && err.Type == rpc.ErrorType_BAD_REQUEST
 }
 
+// IsResourceExhaustedErrorFlag returns whether the error is flagged as
+// a resource exhausted error
+func IsResourceExhaustedErrorFlag(err *rpc.Error) bool {
+	return err != nil && err.Flags&ErrorFlagResourceExhausted != 0
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_INTERNAL_ERROR, err)
@@ -53,6 +65,15 @@ func NewBadRequestError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_BAD_REQUEST, err)
 }
 
+// NewResourceExhaustedError creates a new bad request error flagged as a
+// resource exhausted error, so that it is not retried and callers can tell
+// a request exceeded its limits apart from an otherwise invalid request
+func NewResourceExhaustedError(err error) *rpc.Error {
+	rpcErr := newError(rpc.ErrorType_BAD_REQUEST, err)
+	rpcErr.Flags |= ErrorFlagResourceExhausted
+	return rpcErr
+}
+
 // NewWriteBatchRawError creates a new write batch error
 func NewWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	batchErr := rpc.NewWriteBatchRawError()
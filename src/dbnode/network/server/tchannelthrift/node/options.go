@@ -22,6 +22,7 @@ package node
 
 import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/instrument"
 
 	"github.com/uber/tchannel-go"
@@ -60,12 +61,21 @@ type Options interface {
 
 	// InstrumentOptions returns the instrumentation options.
 	InstrumentOptions() instrument.Options
+
+	// SetFaultInjector sets the fault injector applied to accepted
+	// connections, nil if faults are not injected.
+	SetFaultInjector(value *fault.Injector) Options
+
+	// FaultInjector returns the fault injector applied to accepted
+	// connections, nil if faults are not injected.
+	FaultInjector() *fault.Injector
 }
 
 type options struct {
 	channelOptions    *tchannel.ChannelOptions
 	instrumentOpts    instrument.Options
 	tchanNodeServerFn NewTChanNodeServerFn
+	faultInjector     *fault.Injector
 }
 
 // NewOptions creates a new options.
@@ -104,3 +114,13 @@ func (o *options) SetInstrumentOptions(value instrument.Options) Options {
 func (o *options) InstrumentOptions() instrument.Options {
 	return o.instrumentOpts
 }
+
+func (o *options) SetFaultInjector(value *fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() *fault.Injector {
+	return o.faultInjector
+}
@@ -21,10 +21,13 @@
 package node
 
 import (
+	"net"
+
 	ns "github.com/m3db/m3/src/dbnode/network/server"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/node/channel"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 
 	"github.com/uber/tchannel-go"
 )
@@ -61,7 +64,19 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 	iOpts := s.opts.InstrumentOptions()
 	server := s.opts.TChanNodeServerFn()(s.service, iOpts)
 	tchannelthrift.RegisterServer(channel, server, s.contextPool)
-	channel.ListenAndServe(s.address)
+
+	injector := s.opts.FaultInjector()
+	if injector == nil {
+		channel.ListenAndServe(s.address)
+		return channel.Close, nil
+	}
+
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		channel.Close()
+		return nil, err
+	}
+	channel.Serve(fault.NewListener(listener, injector))
 
 	return channel.Close, nil
 }
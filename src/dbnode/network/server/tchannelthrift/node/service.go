@@ -21,6 +21,7 @@
 package node
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"sort"
@@ -33,8 +34,11 @@ import (
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/importer"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts/writes"
@@ -85,6 +89,14 @@ var (
 	// errNodeIsNotBootstrapped
 	errNodeIsNotBootstrapped = errors.New("node is not bootstrapped")
 
+	// errImportColdWritesEnabled raised when importing into a namespace
+	// whose cold flushes could reuse the imported volume index.
+	errImportColdWritesEnabled = errors.New("cannot import into a namespace with cold writes enabled")
+
+	// errImportBlockNotFlushed raised when importing into a block that has
+	// not been flushed yet, a later warm flush would shadow the import.
+	errImportBlockNotFlushed = errors.New("cannot import into a block that has not been flushed")
+
 	// errDatabaseIsNotInitializedYet is raised when an RPC attempt is made before the database
 	// has been set.
 	errDatabaseIsNotInitializedYet = errors.New("database is not yet initialized")
@@ -109,6 +121,7 @@ type serviceMetrics struct {
 	fetchBlocksMetadata     instrument.MethodMetrics
 	repair                  instrument.MethodMetrics
 	truncate                instrument.MethodMetrics
+	importFileSet           instrument.MethodMetrics
 	fetchBatchRawRPCS       tally.Counter
 	fetchBatchRaw           instrument.BatchMethodMetrics
 	writeBatchRawRPCs       tally.Counter
@@ -116,6 +129,9 @@ type serviceMetrics struct {
 	writeTaggedBatchRawRPCs tally.Counter
 	writeTaggedBatchRaw     instrument.BatchMethodMetrics
 	overloadRejected        tally.Counter
+	fetchTaggedBytesLimited tally.Counter
+	fetchTaggedTimeLimited  tally.Counter
+	fetchTaggedCancelled    tally.Counter
 }
 
 func newServiceMetrics(scope tally.Scope, opts instrument.TimerOptions) serviceMetrics {
@@ -129,6 +145,7 @@ func newServiceMetrics(scope tally.Scope, opts instrument.TimerOptions) serviceM
 		fetchBlocksMetadata:     instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", opts),
 		repair:                  instrument.NewMethodMetrics(scope, "repair", opts),
 		truncate:                instrument.NewMethodMetrics(scope, "truncate", opts),
+		importFileSet:           instrument.NewMethodMetrics(scope, "importFileSet", opts),
 		fetchBatchRawRPCS:       scope.Counter("fetchBatchRaw-rpcs"),
 		fetchBatchRaw:           instrument.NewBatchMethodMetrics(scope, "fetchBatchRaw", opts),
 		writeBatchRawRPCs:       scope.Counter("writeBatchRaw-rpcs"),
@@ -136,6 +153,9 @@ func newServiceMetrics(scope tally.Scope, opts instrument.TimerOptions) serviceM
 		writeTaggedBatchRawRPCs: scope.Counter("writeTaggedBatchRaw-rpcs"),
 		writeTaggedBatchRaw:     instrument.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", opts),
 		overloadRejected:        scope.Counter("overload-rejected"),
+		fetchTaggedBytesLimited: scope.Counter("fetchTagged-bytes-limit-exceeded"),
+		fetchTaggedTimeLimited:  scope.Counter("fetchTagged-time-limit-exceeded"),
+		fetchTaggedCancelled:    scope.Counter("fetchTagged-cancelled"),
 	}
 }
 
@@ -674,13 +694,17 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 	if fetchData {
 		encodedDataResults = make([][][]xio.BlockReader, results.Size())
 	}
-	if err := s.fetchReadEncoded(ctx, db, response, results, nsID, nsIDBytes, callStart, opts, fetchData, encodedDataResults); err != nil {
+	deadline := s.fetchTaggedDeadline(callStart)
+	if err := s.fetchReadEncoded(ctx, db, response, results, nsID, nsIDBytes, callStart, deadline, opts, fetchData, encodedDataResults); err != nil {
 		return nil, err
 	}
 
 	// Step 2: If fetching data read the results of the asynchronuous block readers.
 	if fetchData {
-		s.fetchReadResults(ctx, response, nsID, encodedDataResults)
+		if err := s.fetchReadResults(ctx, response, nsID, deadline, opts, encodedDataResults); err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return nil, err
+		}
 	}
 
 	s.metrics.fetchTagged.ReportSuccess(s.nowFn().Sub(callStart))
@@ -694,6 +718,7 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 	nsID ident.ID,
 	nsIDBytes []byte,
 	callStart time.Time,
+	deadline time.Time,
 	opts index.QueryOptions,
 	fetchData bool,
 	encodedDataResults [][][]xio.BlockReader,
@@ -707,11 +732,44 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 	}
 	defer sp.Finish()
 
-	i := 0
+	var (
+		bytesLimit = s.opts.MaxFetchTaggedResultBytes()
+		bytesRead  int64
+		i          = 0
+	)
 	for _, entry := range results.Map().Iter() {
 		idx := i
 		i++
 
+		// NB: Check the request is still wanted and within its limits before
+		// reading the next series rather than after it has been loaded.
+		if err := s.fetchTaggedCancelled(ctx); err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return err
+		}
+		if s.fetchTaggedDeadlineExceeded(deadline) {
+			err := s.fetchTaggedLimitExceeded(response, opts, s.metrics.fetchTaggedTimeLimited,
+				fmt.Sprintf("time_limit=%v", s.opts.MaxFetchTaggedDuration()))
+			if err != nil {
+				s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			}
+			return err
+		}
+		if bytesLimit > 0 && bytesRead >= bytesLimit {
+			err := s.fetchTaggedLimitExceeded(response, opts, s.metrics.fetchTaggedBytesLimited,
+				fmt.Sprintf("bytes_limit=%d, bytes_read=%d", bytesLimit, bytesRead))
+			if err != nil {
+				s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			}
+			if sampled {
+				sp.LogFields(
+					opentracinglog.Int64("bytesLimit", bytesLimit),
+					opentracinglog.Int("truncatedElementCount", len(response.Elements)),
+				)
+			}
+			return err
+		}
+
 		tsID := entry.Key()
 		tags := entry.Value()
 		enc := s.pools.tagEncoder.Get()
@@ -736,8 +794,12 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 			opts.StartInclusive, opts.EndExclusive)
 		if err != nil {
 			elem.Err = convert.ToRPCError(err)
-		} else {
-			encodedDataResults[idx] = encoded
+			continue
+		}
+
+		encodedDataResults[idx] = encoded
+		if bytesLimit > 0 {
+			bytesRead += blockReadersSize(encoded)
 		}
 	}
 	return nil
@@ -746,8 +808,10 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 func (s *service) fetchReadResults(ctx context.Context,
 	response *rpc.FetchTaggedResult_,
 	nsID ident.ID,
+	deadline time.Time,
+	opts index.QueryOptions,
 	encodedDataResults [][][]xio.BlockReader,
-) {
+) error {
 	ctx, sp, sampled := ctx.StartSampledTraceSpan(tracepoint.FetchReadResults)
 	if sampled {
 		sp.LogFields(
@@ -758,6 +822,20 @@ func (s *service) fetchReadResults(ctx context.Context,
 	defer sp.Finish()
 
 	for idx, elem := range response.Elements {
+		if err := s.fetchTaggedCancelled(ctx); err != nil {
+			return err
+		}
+		if s.fetchTaggedDeadlineExceeded(deadline) {
+			err := s.fetchTaggedLimitExceeded(response, opts, s.metrics.fetchTaggedTimeLimited,
+				fmt.Sprintf("time_limit=%v", s.opts.MaxFetchTaggedDuration()))
+			if err != nil {
+				return err
+			}
+			// NB: Return the series read so far as a partial result.
+			response.Elements = response.Elements[:idx]
+			return nil
+		}
+
 		if elem.Err != nil {
 			continue
 		}
@@ -770,6 +848,74 @@ func (s *service) fetchReadResults(ctx context.Context,
 
 		response.Elements[idx].Segments = segments
 	}
+
+	return nil
+}
+
+// fetchTaggedDeadline returns the time by which a fetch tagged request must
+// have read its results, or the zero time if requests have no time limit.
+func (s *service) fetchTaggedDeadline(callStart time.Time) time.Time {
+	limit := s.opts.MaxFetchTaggedDuration()
+	if limit <= 0 {
+		return time.Time{}
+	}
+	return callStart.Add(limit)
+}
+
+func (s *service) fetchTaggedDeadlineExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && !s.nowFn().Before(deadline)
+}
+
+// fetchTaggedCancelled returns an error if the caller abandoned the request
+// or it timed out, since this is not a server fault the error is returned
+// as a bad request so that it is not retried.
+func (s *service) fetchTaggedCancelled(ctx context.Context) error {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		return nil
+	}
+	if err := goCtx.Err(); err != nil {
+		s.metrics.fetchTaggedCancelled.Inc(1)
+		return tterrors.NewBadRequestError(fmt.Errorf("fetch tagged cancelled: %v", err))
+	}
+	return nil
+}
+
+// fetchTaggedLimitExceeded marks the result of a fetch tagged request that
+// exceeded one of its limits as not exhaustive so the series read so far
+// are returned as a partial result, or returns a query limit exceeded error
+// if the request requires an exhaustive result.
+func (s *service) fetchTaggedLimitExceeded(
+	response *rpc.FetchTaggedResult_,
+	opts index.QueryOptions,
+	limitExceeded tally.Counter,
+	limit string,
+) error {
+	limitExceeded.Inc(1)
+	if opts.RequireExhaustive {
+		return convert.ToRPCError(m3dberrors.NewQueryLimitExceededError(fmt.Sprintf(
+			"require_exhaustive=%v, %s", opts.RequireExhaustive, limit)))
+	}
+	response.Exhaustive = false
+	return nil
+}
+
+func blockReadersSize(blockReaders [][]xio.BlockReader) int64 {
+	var size int64
+	for _, readers := range blockReaders {
+		for _, reader := range readers {
+			if reader.SegmentReader == nil {
+				continue
+			}
+			segment, err := reader.Segment()
+			if err != nil {
+				// NB: The read error is returned once the result is read.
+				continue
+			}
+			size += int64(segment.Len())
+		}
+	}
+	return size
 }
 
 func (s *service) Aggregate(tctx thrift.Context, req *rpc.AggregateQueryRequest) (*rpc.AggregateQueryResult_, error) {
@@ -2010,6 +2156,97 @@ func (s *service) DebugIndexMemorySegments(
 	return &rpc.DebugIndexMemorySegmentsResult_{}, nil
 }
 
+func (s *service) ImportFileSet(
+	tctx thrift.Context,
+	req *rpc.ImportFileSetRequest,
+) (*rpc.ImportFileSetResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	callStart := s.nowFn()
+	result, err := s.importFileSet(tchannelthrift.Context(tctx), db, req)
+	if err != nil {
+		s.metrics.importFileSet.ReportError(s.nowFn().Sub(callStart))
+		return nil, err
+	}
+
+	s.metrics.importFileSet.ReportSuccess(s.nowFn().Sub(callStart))
+
+	return &rpc.ImportFileSetResult_{
+		NumSeries:     int64(result.NumSeries),
+		NumDatapoints: int64(result.NumDatapoints),
+		VolumeIndex:   int64(result.VolumeIndex),
+	}, nil
+}
+
+func (s *service) importFileSet(
+	ctx context.Context,
+	db storage.Database,
+	req *rpc.ImportFileSetRequest,
+) (importer.Result, error) {
+	nsID := s.newID(ctx, req.NameSpace)
+	ns, ok := db.Namespace(nsID)
+	if !ok {
+		return importer.Result{}, tterrors.NewBadRequestError(
+			fmt.Errorf("unknown namespace: %s", nsID.String()))
+	}
+
+	nsOpts := ns.Options()
+	if nsOpts.ColdWritesEnabled() {
+		return importer.Result{}, tterrors.NewBadRequestError(errImportColdWritesEnabled)
+	}
+
+	var (
+		blockSize  = nsOpts.RetentionOptions().BlockSize()
+		blockStart = time.Unix(0, req.BlockStart)
+		shard      = uint32(req.Shard)
+	)
+	if !blockStart.Equal(blockStart.Truncate(blockSize)) {
+		return importer.Result{}, tterrors.NewBadRequestError(
+			fmt.Errorf("block start %v is not aligned to block size %v",
+				blockStart, blockSize))
+	}
+
+	// NB: only import into blocks that already have a flushed volume, the
+	// warm flush of an unflushed block would otherwise write a volume that
+	// conflicts with the imported one.
+	fsOpts := db.Options().CommitLogOptions().FilesystemOptions()
+	files, err := fs.DataFiles(fsOpts.FilePathPrefix(), nsID, shard)
+	if err != nil {
+		return importer.Result{}, convert.ToRPCError(err)
+	}
+	if _, ok := files.LatestVolumeForBlock(blockStart); !ok {
+		return importer.Result{}, tterrors.NewBadRequestError(errImportBlockNotFlushed)
+	}
+
+	var indexBlockSize time.Duration
+	if indexOpts := nsOpts.IndexOptions(); indexOpts.Enabled() {
+		indexBlockSize = indexOpts.BlockSize()
+	}
+
+	opts := importer.NewOptions().
+		SetBufferSize(fsOpts.WriterBufferSize()).
+		SetFileMode(fsOpts.NewFileMode()).
+		SetDirMode(fsOpts.NewDirectoryMode())
+	result, err := importer.New(opts).ImportCSV(
+		bytes.NewReader(req.Csv),
+		importer.FileSetID{
+			PathPrefix: fsOpts.FilePathPrefix(),
+			Namespace:  nsID.String(),
+			Shard:      shard,
+			Blockstart: blockStart,
+		},
+		blockSize,
+		indexBlockSize,
+	)
+	if err != nil {
+		return importer.Result{}, convert.ToRPCError(err)
+	}
+	return result, nil
+}
+
 func (s *service) SetDatabase(db storage.Database) error {
 	s.state.Lock()
 	defer s.state.Unlock()
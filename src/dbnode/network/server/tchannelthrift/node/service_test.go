@@ -25,6 +25,8 @@ import (
 	gocontext "context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sort"
 	"sync"
 	"testing"
@@ -35,6 +37,8 @@ import (
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/fs/importer"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
@@ -1662,6 +1666,312 @@ func TestServiceFetchTagged(t *testing.T) {
 	assert.Equal(t, "root", spans[7].OperationName)
 }
 
+func TestServiceFetchTaggedResultBytesLimit(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	opts := testTChannelThriftOptions.SetMaxFetchTaggedResultBytes(1)
+	service := NewService(mockDB, opts).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+
+	nsID := "metrics"
+
+	ids := []string{"foo", "bar"}
+	for i, id := range ids {
+		enc := testStorageOpts.EncoderPool().Get()
+		enc.Reset(start, 0, nil)
+		dp := ts.Datapoint{
+			Timestamp: start.Add(10 * time.Second),
+			Value:     float64(i),
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+
+		stream, _ := enc.Stream(ctx)
+		// NB: Results are read in map order and the limit is reached after
+		// the first series, so only one of the series is read.
+		mockDB.EXPECT().
+			ReadEncoded(gomock.Any(), ident.NewIDMatcher(nsID), ident.NewIDMatcher(id), start, end).
+			Return([][]xio.BlockReader{{
+				xio.BlockReader{
+					SegmentReader: stream,
+				},
+			}}, nil).
+			MaxTimes(1)
+	}
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	for _, id := range ids {
+		resMap.Map().Set(ident.StringID(id), ident.NewTagsIterator(ident.NewTags(
+			ident.StringTag("foo", "bar"),
+		)))
+	}
+
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		}).Return(index.QueryResult{Results: resMap, Exhaustive: true}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+	r, err := service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		FetchData:  true,
+	})
+	require.NoError(t, err)
+
+	// The first series read reaches the limit so the next series is not read.
+	require.Equal(t, 1, len(r.Elements))
+	assert.False(t, r.Exhaustive)
+	require.Equal(t, 1, len(r.Elements[0].Segments))
+	assert.NotNil(t, r.Elements[0].Segments[0].Merged)
+}
+
+func TestServiceFetchTaggedResultBytesLimitRequireExhaustive(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	opts := testTChannelThriftOptions.SetMaxFetchTaggedResultBytes(1)
+	service := NewService(mockDB, opts).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+
+	nsID := "metrics"
+
+	ids := []string{"foo", "bar"}
+	for i, id := range ids {
+		enc := testStorageOpts.EncoderPool().Get()
+		enc.Reset(start, 0, nil)
+		dp := ts.Datapoint{
+			Timestamp: start.Add(10 * time.Second),
+			Value:     float64(i),
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+
+		stream, _ := enc.Stream(ctx)
+		mockDB.EXPECT().
+			ReadEncoded(gomock.Any(), ident.NewIDMatcher(nsID), ident.NewIDMatcher(id), start, end).
+			Return([][]xio.BlockReader{{
+				xio.BlockReader{
+					SegmentReader: stream,
+				},
+			}}, nil).
+			MaxTimes(1)
+	}
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	for _, id := range ids {
+		resMap.Map().Set(ident.StringID(id), ident.NewTagsIterator(ident.NewTags(
+			ident.StringTag("foo", "bar"),
+		)))
+	}
+
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive:    start,
+			EndExclusive:      end,
+			RequireExhaustive: true,
+		}).Return(index.QueryResult{Results: resMap, Exhaustive: true}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+	_, err = service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:         []byte(nsID),
+		Query:             data,
+		RangeStart:        startNanos,
+		RangeEnd:          endNanos,
+		FetchData:         true,
+		RequireExhaustive: true,
+	})
+	require.Error(t, err)
+	assert.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+	assert.True(t, tterrors.IsResourceExhaustedErrorFlag(err.(*rpc.Error)))
+}
+
+func TestServiceFetchTaggedCancelled(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	goCtx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	ctx.SetGoContext(goCtx)
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+
+	nsID := "metrics"
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	resMap.Map().Set(ident.StringID("foo"), ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("foo", "bar"),
+	)))
+
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		}).Return(index.QueryResult{Results: resMap, Exhaustive: true}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+
+	// NB: No ReadEncoded expectations are set since a cancelled request
+	// must not read any series data.
+	_, err = service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		FetchData:  true,
+	})
+	require.Error(t, err)
+	assert.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceFetchTaggedTimeLimit(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	opts := testTChannelThriftOptions.SetMaxFetchTaggedDuration(time.Second)
+	service := NewService(mockDB, opts).(*service)
+
+	// NB: Every call to now after the call starts is past the time limit.
+	now := time.Now()
+	service.nowFn = func() time.Time {
+		curr := now
+		now = now.Add(time.Minute)
+		return curr
+	}
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+
+	nsID := "metrics"
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	resMap := index.NewQueryResults(ident.StringID(nsID),
+		index.QueryResultsOptions{}, testIndexOptions)
+	resMap.Map().Set(ident.StringID("foo"), ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("foo", "bar"),
+	)))
+
+	mockDB.EXPECT().QueryIDs(
+		gomock.Any(),
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		}).Return(index.QueryResult{Results: resMap, Exhaustive: true}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+
+	// NB: No ReadEncoded expectations are set since a request past its
+	// time limit must not read any series data.
+	r, err := service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		FetchData:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(r.Elements))
+	assert.False(t, r.Exhaustive)
+}
+
 func TestServiceFetchTaggedIsOverloaded(t *testing.T) {
 	ctrl := xtest.NewController(t)
 	defer ctrl.Finish()
@@ -2884,6 +3194,110 @@ func TestServiceTruncate(t *testing.T) {
 	assert.Equal(t, truncated, r.NumSeries)
 }
 
+func TestServiceImportFileSet(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "import-fileset")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		nsID       = "metrics"
+		shard      = uint32(3)
+		blockSize  = testNamespaceOptions.RetentionOptions().BlockSize()
+		blockStart = time.Now().Add(-24 * time.Hour).Truncate(blockSize)
+		csv        = func(id string, t time.Time, v float64) []byte {
+			return []byte(fmt.Sprintf("%s,a=b,%d,%v\n", id, t.UnixNano(), v))
+		}
+	)
+
+	fsOpts := testStorageOpts.CommitLogOptions().FilesystemOptions().
+		SetFilePathPrefix(dir)
+	opts := testStorageOpts.SetCommitLogOptions(
+		testStorageOpts.CommitLogOptions().SetFilesystemOptions(fsOpts))
+
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().Options().Return(testNamespaceOptions).AnyTimes()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(opts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	req := &rpc.ImportFileSetRequest{
+		NameSpace:  []byte(nsID),
+		Shard:      int32(shard),
+		BlockStart: blockStart.UnixNano(),
+		Csv:        csv("foo", blockStart.Add(time.Minute), 1),
+	}
+
+	// Refuse to import into a block that has not been flushed.
+	_, err = service.ImportFileSet(tctx, req)
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+
+	_, err = importer.New(importer.NewOptions()).ImportCSV(
+		bytes.NewReader(csv("bar", blockStart.Add(2*time.Minute), 2)),
+		importer.FileSetID{
+			PathPrefix: dir,
+			Namespace:  nsID,
+			Shard:      shard,
+			Blockstart: blockStart,
+		}, blockSize, 0)
+	require.NoError(t, err)
+
+	r, err := service.ImportFileSet(tctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), r.NumSeries)
+	assert.Equal(t, int64(1), r.NumDatapoints)
+	assert.Equal(t, int64(1), r.VolumeIndex)
+
+	files, err := fs.DataFiles(dir, ident.StringID(nsID), shard)
+	require.NoError(t, err)
+	latest, ok := files.LatestVolumeForBlock(blockStart)
+	require.True(t, ok)
+	assert.Equal(t, 1, latest.ID.VolumeIndex)
+
+	// Refuse to import into a block start that is not aligned.
+	req.BlockStart = blockStart.Add(time.Minute).UnixNano()
+	_, err = service.ImportFileSet(tctx, req)
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+func TestServiceImportFileSetColdWritesEnabled(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := "metrics"
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().Options().Return(testNamespaceOptions.SetColdWritesEnabled(true))
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true)
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	_, err := service.ImportFileSet(tctx, &rpc.ImportFileSetRequest{
+		NameSpace: []byte(nsID),
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
 func TestServiceSetPersistRateLimit(t *testing.T) {
 	ctrl := xtest.NewController(t)
 	defer ctrl.Finish()
@@ -21,6 +21,8 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -42,6 +44,8 @@ type options struct {
 	checkedBytesWrapperPool     xpool.CheckedBytesWrapperPool
 	maxOutstandingWriteRequests int
 	maxOutstandingReadRequests  int
+	maxFetchTaggedResultBytes   int64
+	maxFetchTaggedDuration      time.Duration
 }
 
 // NewOptions creates new options
@@ -195,3 +199,23 @@ func (o *options) SetMaxOutstandingReadRequests(value int) Options {
 func (o *options) MaxOutstandingReadRequests() int {
 	return o.maxOutstandingReadRequests
 }
+
+func (o *options) SetMaxFetchTaggedResultBytes(value int64) Options {
+	opts := *o
+	opts.maxFetchTaggedResultBytes = value
+	return &opts
+}
+
+func (o *options) MaxFetchTaggedResultBytes() int64 {
+	return o.maxFetchTaggedResultBytes
+}
+
+func (o *options) SetMaxFetchTaggedDuration(value time.Duration) Options {
+	opts := *o
+	opts.maxFetchTaggedDuration = value
+	return &opts
+}
+
+func (o *options) MaxFetchTaggedDuration() time.Duration {
+	return o.maxFetchTaggedDuration
+}
@@ -21,6 +21,8 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -100,4 +102,24 @@ type Options interface {
 	// MaxOutstandingReadRequests returns the maxinum number of allowed
 	// outstanding read requests.
 	MaxOutstandingReadRequests() int
+
+	// SetMaxFetchTaggedResultBytes sets the maximum number of encoded bytes
+	// returned by a single fetch tagged request before the result is
+	// truncated and marked as not exhaustive, zero means unlimited.
+	SetMaxFetchTaggedResultBytes(value int64) Options
+
+	// MaxFetchTaggedResultBytes returns the maximum number of encoded bytes
+	// returned by a single fetch tagged request before the result is
+	// truncated and marked as not exhaustive, zero means unlimited.
+	MaxFetchTaggedResultBytes() int64
+
+	// SetMaxFetchTaggedDuration sets the maximum time a single fetch tagged
+	// request may spend reading results before the result is truncated and
+	// marked as not exhaustive, zero means unlimited.
+	SetMaxFetchTaggedDuration(value time.Duration) Options
+
+	// MaxFetchTaggedDuration returns the maximum time a single fetch tagged
+	// request may spend reading results before the result is truncated and
+	// marked as not exhaustive, zero means unlimited.
+	MaxFetchTaggedDuration() time.Duration
 }
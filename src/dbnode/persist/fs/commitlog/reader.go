@@ -41,7 +41,8 @@ import (
 )
 
 var (
-	commitLogFileReadCounter = atomic.NewUint64(0)
+	commitLogFileReadCounter  = atomic.NewUint64(0)
+	commitLogBytesReadCounter = atomic.NewUint64(0)
 
 	// var instead of const so we can modify them in tests.
 	defaultDecodeEntryBufSize = 1024
@@ -204,9 +205,16 @@ func (r *reader) readLogEntry() error {
 		return err
 	}
 
+	commitLogBytesReadCounter.Add(size)
 	return nil
 }
 
+// BytesRead returns the number of log entry bytes read from commit logs by
+// this process, which while bootstrapping is the number of bytes replayed.
+func BytesRead() uint64 {
+	return commitLogBytesReadCounter.Load()
+}
+
 func (r *reader) namespaceIDReused(id []byte) ident.ID {
 	var namespaceID ident.ID
 	for _, ns := range r.namespacesRead {
@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/fault"
 	xos "github.com/m3db/m3/src/x/os"
 	xtime "github.com/m3db/m3/src/x/time"
 )
@@ -121,7 +122,7 @@ func newCommitLogWriter(
 		newFileMode:         opts.FilesystemOptions().NewFileMode(),
 		newDirectoryMode:    opts.FilesystemOptions().NewDirectoryMode(),
 		nowFn:               opts.ClockOptions().NowFn(),
-		chunkWriter:         newChunkWriter(flushFn, shouldFsync),
+		chunkWriter:         newChunkWriter(flushFn, shouldFsync, opts.FilesystemOptions().FaultInjector()),
 		chunkReserveHeader:  make([]byte, chunkHeaderLen),
 		buffer:              bufio.NewWriterSize(nil, opts.FlushSize()),
 		sizeBuffer:          make([]byte, binary.MaxVarintLen64),
@@ -290,17 +291,23 @@ func (w *writer) write(data []byte) error {
 }
 
 type fsChunkWriter struct {
-	fd      xos.File
-	flushFn flushFn
-	buff    []byte
-	fsync   bool
+	fd       xos.File
+	flushFn  flushFn
+	buff     []byte
+	fsync    bool
+	injector *fault.Injector
 }
 
-func newChunkWriter(flushFn flushFn, fsync bool) chunkWriter {
+func newChunkWriter(
+	flushFn flushFn,
+	fsync bool,
+	injector *fault.Injector,
+) chunkWriter {
 	return &fsChunkWriter{
-		flushFn: flushFn,
-		buff:    make([]byte, chunkHeaderLen),
-		fsync:   fsync,
+		flushFn:  flushFn,
+		buff:     make([]byte, chunkHeaderLen),
+		fsync:    fsync,
+		injector: injector,
 	}
 }
 
@@ -319,6 +326,9 @@ func (w *fsChunkWriter) isOpen() bool {
 }
 
 func (w *fsChunkWriter) sync() error {
+	if w.injector != nil {
+		return w.injector.Sync(w.fd)
+	}
 	return w.fd.Sync()
 }
 
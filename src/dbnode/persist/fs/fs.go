@@ -31,6 +31,7 @@ const (
 	digestFileSuffix         = "digest"
 	checkpointFileSuffix     = "checkpoint"
 	metadataFileSuffix       = "metadata"
+	remoteFileSuffix         = "remote"
 	filesetFilePrefix        = "fileset"
 	commitLogFilePrefix      = "commitlog"
 	segmentFileSetFilePrefix = "segment"
@@ -0,0 +1,466 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
+	idxpersist "github.com/m3db/m3/src/m3ninx/persist"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	csvNumFields      = 4
+	csvTagsSeparator  = ";"
+	csvTagKVSeparator = "="
+)
+
+var errEmptyID = errors.New("series id must not be empty")
+
+type importSeries struct {
+	doc        doc.Document
+	datapoints []ts.Datapoint
+}
+
+type importer struct {
+	opts Options
+}
+
+// New creates a new fileset importer
+func New(opts Options) FileSetImporter {
+	return &importer{
+		opts: opts,
+	}
+}
+
+func (i *importer) ImportCSV(
+	r io.Reader,
+	dest FileSetID,
+	blockSize time.Duration,
+	indexBlockSize time.Duration,
+) (Result, error) {
+	blockEnd := dest.Blockstart.Add(blockSize)
+	imported, err := readCSV(r, dest.Blockstart, blockEnd)
+	if err != nil {
+		return Result{}, xerrors.NewInvalidParamsError(err)
+	}
+
+	var (
+		fsOpts = fs.NewOptions().
+			SetFilePathPrefix(dest.PathPrefix).
+			SetWriterBufferSize(i.opts.BufferSize()).
+			SetNewFileMode(i.opts.FileMode()).
+			SetNewDirectoryMode(i.opts.DirMode())
+		nsID = ident.StringID(dest.Namespace)
+	)
+	files, err := fs.DataFiles(dest.PathPrefix, nsID, dest.Shard)
+	if err != nil {
+		return Result{}, fmt.Errorf("unable to list filesets: %v", err)
+	}
+
+	// NB: reads only use the latest volume of a block, so rather than write
+	// into an existing volume the imported datapoints are merged with the
+	// latest volume and written out as the next volume.
+	var (
+		all         = imported
+		volumeIndex = 0
+	)
+	if latest, ok := files.LatestVolumeForBlock(dest.Blockstart); ok {
+		volumeIndex = latest.ID.VolumeIndex + 1
+		existing, err := i.readVolume(fsOpts, latest.ID)
+		if err != nil {
+			return Result{}, fmt.Errorf("unable to read volume %d: %v",
+				latest.ID.VolumeIndex, err)
+		}
+		all = mergeSeries(existing, imported)
+	}
+
+	// NB: write the index volume before the data volume so that a failed
+	// import never leaves a complete data volume with unindexed series, a
+	// retried import writes another index volume for the same series.
+	if indexBlockSize > 0 {
+		if err := i.writeIndexVolume(fsOpts, nsID, dest, indexBlockSize, imported); err != nil {
+			return Result{}, fmt.Errorf("unable to write index volume: %v", err)
+		}
+	}
+
+	id := fs.FileSetFileIdentifier{
+		Namespace:   nsID,
+		Shard:       dest.Shard,
+		BlockStart:  dest.Blockstart,
+		VolumeIndex: volumeIndex,
+	}
+	if err := i.writeDataVolume(fsOpts, id, blockSize, all); err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		NumSeries:   len(imported),
+		VolumeIndex: volumeIndex,
+	}
+	for _, series := range imported {
+		result.NumDatapoints += len(series.datapoints)
+	}
+	return result, nil
+}
+
+func (i *importer) readVolume(
+	fsOpts fs.Options,
+	id fs.FileSetFileIdentifier,
+) ([]*importSeries, error) {
+	reader, err := fs.NewReader(nil, fsOpts)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.Open(fs.DataReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var result []*importSeries
+	for {
+		id, tags, data, _, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		series, err := i.readSeries(id, tags, data)
+		id.Finalize()
+		tags.Close()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, series)
+	}
+}
+
+func (i *importer) readSeries(
+	id ident.ID,
+	tags ident.TagIterator,
+	data checked.Bytes,
+) (*importSeries, error) {
+	d, err := convert.FromSeriesIDAndTagIter(id, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	data.IncRef()
+	defer data.DecRef()
+
+	series := &importSeries{doc: d}
+	iter := m3tsz.NewReaderIterator(bytes.NewReader(data.Bytes()),
+		i.opts.IntOptimizationEnabled(), i.opts.EncodingOptions())
+	defer iter.Close()
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		series.datapoints = append(series.datapoints, dp)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (i *importer) writeIndexVolume(
+	fsOpts fs.Options,
+	nsID ident.ID,
+	dest FileSetID,
+	indexBlockSize time.Duration,
+	series []*importSeries,
+) error {
+	indexBlockStart := dest.Blockstart.Truncate(indexBlockSize)
+	volumeIndex, err := fs.NextIndexFileSetVolumeIndex(dest.PathPrefix,
+		nsID, indexBlockStart)
+	if err != nil {
+		return err
+	}
+
+	b, err := builder.NewBuilderFromDocuments(builder.NewOptions())
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	for _, s := range series {
+		if _, err := b.Insert(s.doc); err != nil {
+			return err
+		}
+	}
+
+	segmentWriter, err := idxpersist.NewMutableSegmentFileSetWriter()
+	if err != nil {
+		return err
+	}
+	if err := segmentWriter.Reset(b); err != nil {
+		return err
+	}
+
+	writer, err := fs.NewIndexWriter(fsOpts)
+	if err != nil {
+		return err
+	}
+	// NB: the index writer only writes its checkpoint file on Close, so it
+	// is not closed if writing the segment fails.
+	if err := writer.Open(fs.IndexWriterOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			FileSetContentType: persist.FileSetIndexContentType,
+			Namespace:          nsID,
+			BlockStart:         indexBlockStart,
+			VolumeIndex:        volumeIndex,
+		},
+		BlockSize:       indexBlockSize,
+		FileSetType:     persist.FileSetFlushType,
+		Shards:          map[uint32]struct{}{dest.Shard: struct{}{}},
+		IndexVolumeType: idxpersist.DefaultIndexVolumeType,
+	}); err != nil {
+		return err
+	}
+	if err := writer.WriteSegmentFileSet(segmentWriter); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func (i *importer) writeDataVolume(
+	fsOpts fs.Options,
+	id fs.FileSetFileIdentifier,
+	blockSize time.Duration,
+	series []*importSeries,
+) error {
+	writer, err := fs.NewWriter(fsOpts)
+	if err != nil {
+		return fmt.Errorf("unable to create fileset writer: %v", err)
+	}
+	if err := writer.Open(fs.DataWriterOpenOptions{
+		BlockSize:   blockSize,
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return fmt.Errorf("unable to open fileset writer: %v", err)
+	}
+
+	closed := false
+	defer func() {
+		if !closed {
+			// NB: close the files without writing the checkpoint file so
+			// that the partially written volume is never read.
+			writer.DeferClose()
+		}
+	}()
+
+	encoder := m3tsz.NewEncoder(id.BlockStart, nil,
+		i.opts.IntOptimizationEnabled(), i.opts.EncodingOptions())
+	defer encoder.Close()
+
+	for _, s := range series {
+		if err := i.writeSeries(writer, encoder, id.BlockStart, s); err != nil {
+			return err
+		}
+	}
+
+	closed = true
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("unable to finalize writer: %v", err)
+	}
+	return nil
+}
+
+func (i *importer) writeSeries(
+	writer fs.DataFileSetWriter,
+	encoder encoding.Encoder,
+	blockStart time.Time,
+	series *importSeries,
+) error {
+	encoder.Reset(blockStart, len(series.datapoints), nil)
+	for _, dp := range series.datapoints {
+		if err := encoder.Encode(dp, i.opts.TimeUnit(), nil); err != nil {
+			return fmt.Errorf("unable to encode datapoint for %s: %v",
+				series.doc.ID, err)
+		}
+	}
+
+	ctx := context.NewContext()
+	defer ctx.BlockingClose()
+
+	stream, ok := encoder.Stream(ctx)
+	if !ok {
+		return nil
+	}
+	segment, err := stream.Segment()
+	if err != nil {
+		return err
+	}
+	var (
+		data     = []checked.Bytes{segment.Head, segment.Tail}
+		checksum = segment.CalculateChecksum()
+		metadata = persist.NewMetadata(series.doc)
+	)
+	if err := writer.WriteAll(metadata, data, checksum); err != nil {
+		return fmt.Errorf("unexpected error while writing data: %v", err)
+	}
+	return nil
+}
+
+func readCSV(r io.Reader, blockStart, blockEnd time.Time) ([]*importSeries, error) {
+	var (
+		seriesByID = make(map[string]*importSeries)
+		result     []*importSeries
+		reader     = csv.NewReader(r)
+	)
+	reader.FieldsPerRecord = csvNumFields
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read csv: %v", err)
+		}
+
+		id := record[0]
+		if id == "" {
+			return nil, fmt.Errorf("line %d: %v", line, errEmptyID)
+		}
+		nanos, err := strconv.ParseInt(record[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid timestamp: %v", line, err)
+		}
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid value: %v", line, err)
+		}
+
+		timestamp := xtime.FromNanoseconds(nanos)
+		if timestamp.Before(blockStart) || !timestamp.Before(blockEnd) {
+			return nil, fmt.Errorf(
+				"line %d: timestamp %s outside of block [%s, %s)", line,
+				timestamp.String(), blockStart.String(), blockEnd.String())
+		}
+
+		series, ok := seriesByID[id]
+		if !ok {
+			tags, err := parseTags(record[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			d, err := convert.FromSeriesIDAndTags(ident.StringID(id), tags)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			series = &importSeries{doc: d}
+			seriesByID[id] = series
+			result = append(result, series)
+		}
+		series.datapoints = append(series.datapoints, ts.Datapoint{
+			Timestamp:      timestamp,
+			TimestampNanos: xtime.UnixNano(nanos),
+			Value:          value,
+		})
+	}
+
+	for _, series := range result {
+		series.datapoints = dedupeSorted(series.datapoints)
+	}
+	return result, nil
+}
+
+// mergeSeries merges the imported series into the existing series of a
+// volume, imported datapoints take precedence over existing datapoints with
+// the same timestamp.
+func mergeSeries(existing, imported []*importSeries) []*importSeries {
+	byID := make(map[string]*importSeries, len(existing))
+	for _, series := range existing {
+		byID[string(series.doc.ID)] = series
+	}
+
+	result := existing
+	for _, series := range imported {
+		curr, ok := byID[string(series.doc.ID)]
+		if !ok {
+			result = append(result, series)
+			continue
+		}
+		merged := make([]ts.Datapoint, 0, len(curr.datapoints)+len(series.datapoints))
+		merged = append(merged, curr.datapoints...)
+		merged = append(merged, series.datapoints...)
+		curr.datapoints = dedupeSorted(merged)
+	}
+	return result
+}
+
+func parseTags(str string) (ident.Tags, error) {
+	if str == "" {
+		return ident.Tags{}, nil
+	}
+	pairs := strings.Split(str, csvTagsSeparator)
+	tags := make([]ident.Tag, 0, len(pairs))
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, csvTagKVSeparator, 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return ident.Tags{}, fmt.Errorf("invalid tag: %s", pair)
+		}
+		tags = append(tags, ident.StringTag(kv[0], kv[1]))
+	}
+	return ident.NewTags(tags...), nil
+}
+
+// dedupeSorted sorts datapoints by time, keeping the last written value
+// for any duplicate timestamps.
+func dedupeSorted(datapoints []ts.Datapoint) []ts.Datapoint {
+	sort.SliceStable(datapoints, func(i, j int) bool {
+		return datapoints[i].TimestampNanos < datapoints[j].TimestampNanos
+	})
+	result := datapoints[:0]
+	for _, dp := range datapoints {
+		if n := len(result); n > 0 && result[n-1].TimestampNanos == dp.TimestampNanos {
+			result[n-1] = dp
+			continue
+		}
+		result = append(result, dp)
+	}
+	return result
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package importer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testBlockSize      = 2 * time.Hour
+	testIndexBlockSize = 4 * time.Hour
+)
+
+var testBlockStart = time.Unix(1500000000, 0).Truncate(testIndexBlockSize)
+
+func testNanos(d time.Duration) string {
+	return strconv.FormatInt(testBlockStart.Add(d).UnixNano(), 10)
+}
+
+func testDest(dir string) FileSetID {
+	return FileSetID{
+		PathPrefix: dir,
+		Namespace:  "testns",
+		Shard:      7,
+		Blockstart: testBlockStart,
+	}
+}
+
+func readTestVolume(
+	t *testing.T,
+	dest FileSetID,
+	volumeIndex int,
+) map[string][]float64 {
+	reader, err := fs.NewReader(nil, fs.NewOptions().SetFilePathPrefix(dest.PathPrefix))
+	require.NoError(t, err)
+	require.NoError(t, reader.Open(fs.DataReaderOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:   ident.StringID(dest.Namespace),
+			Shard:       dest.Shard,
+			BlockStart:  dest.Blockstart,
+			VolumeIndex: volumeIndex,
+		},
+	}))
+	defer reader.Close()
+
+	values := make(map[string][]float64)
+	for {
+		id, tags, data, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+
+		if id.String() == "foo" {
+			expected := ident.NewTagsIterator(ident.NewTags(
+				ident.StringTag("city", "nyc"),
+				ident.StringTag("host", "a"),
+			))
+			assert.True(t, ident.NewTagIterMatcher(expected).Matches(tags))
+		}
+
+		data.IncRef()
+		iter := m3tsz.NewReaderIterator(bytes.NewReader(data.Bytes()),
+			m3tsz.DefaultIntOptimizationEnabled, encoding.NewOptions())
+		for iter.Next() {
+			dp, _, _ := iter.Current()
+			values[id.String()] = append(values[id.String()], dp.Value)
+		}
+		require.NoError(t, iter.Err())
+		data.DecRef()
+	}
+	return values
+}
+
+func TestImportCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		dest = testDest(dir)
+		csv  = strings.Join([]string{
+			"foo,city=nyc;host=a," + testNanos(time.Minute) + ",2",
+			"foo,city=nyc;host=a," + testNanos(0) + ",1",
+			"bar,," + testNanos(time.Minute) + ",3.5",
+			"foo,city=nyc;host=a," + testNanos(time.Minute) + ",4",
+		}, "\n")
+	)
+
+	result, err := New(NewOptions()).ImportCSV(strings.NewReader(csv), dest,
+		testBlockSize, testIndexBlockSize)
+	require.NoError(t, err)
+	assert.Equal(t, Result{NumSeries: 2, NumDatapoints: 3}, result)
+
+	assert.Equal(t, map[string][]float64{
+		"foo": {1, 4},
+		"bar": {3.5},
+	}, readTestVolume(t, dest, 0))
+
+	segments, err := fs.ReadIndexSegments(fs.ReadIndexSegmentsOptions{
+		ReaderOptions: fs.IndexReaderOpenOptions{
+			Identifier: fs.FileSetFileIdentifier{
+				FileSetContentType: persist.FileSetIndexContentType,
+				Namespace:          ident.StringID(dest.Namespace),
+				BlockStart:         testBlockStart,
+			},
+			FileSetType: persist.FileSetFlushType,
+		},
+		FilesystemOptions: fs.NewOptions().SetFilePathPrefix(dir),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(segments))
+	defer segments[0].Close()
+
+	assert.Equal(t, int64(2), segments[0].Size())
+	for _, id := range []string{"foo", "bar"} {
+		ok, err := segments[0].ContainsID([]byte(id))
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+}
+
+func TestImportCSVMergesExistingVolume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		dest     = testDest(dir)
+		importer = New(NewOptions())
+	)
+	_, err = importer.ImportCSV(strings.NewReader(strings.Join([]string{
+		"foo,city=nyc;host=a," + testNanos(0) + ",1",
+		"foo,city=nyc;host=a," + testNanos(time.Minute) + ",2",
+		"bar,," + testNanos(0) + ",3",
+	}, "\n")), dest, testBlockSize, 0)
+	require.NoError(t, err)
+
+	result, err := importer.ImportCSV(strings.NewReader(strings.Join([]string{
+		"foo,city=nyc;host=a," + testNanos(time.Minute) + ",5",
+		"baz,," + testNanos(0) + ",6",
+	}, "\n")), dest, testBlockSize, 0)
+	require.NoError(t, err)
+	assert.Equal(t, Result{NumSeries: 2, NumDatapoints: 2, VolumeIndex: 1}, result)
+
+	// The existing volume is left intact and the next volume holds the merge.
+	assert.Equal(t, map[string][]float64{
+		"foo": {1, 2},
+		"bar": {3},
+	}, readTestVolume(t, dest, 0))
+	assert.Equal(t, map[string][]float64{
+		"foo": {1, 5},
+		"bar": {3},
+		"baz": {6},
+	}, readTestVolume(t, dest, 1))
+}
+
+func TestImportCSVOutsideBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	csv := "foo,," + testNanos(testBlockSize) + ",1"
+	_, err = New(NewOptions()).ImportCSV(strings.NewReader(csv), testDest(dir),
+		testBlockSize, testIndexBlockSize)
+	require.Error(t, err)
+	assert.True(t, xerrors.IsInvalidParams(err))
+	assert.Contains(t, err.Error(), "outside of block")
+
+	files, err := fs.DataFiles(dir, ident.StringID("testns"), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestImportCSVInvalidTags(t *testing.T) {
+	_, err := parseTags("foo=bar;baz")
+	require.Error(t, err)
+
+	tags, err := parseTags("foo=bar;baz=")
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(tags.Values()))
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package importer
+
+import (
+	"os"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	defaultBufferSize = 65536
+	defaultFileMode   = os.FileMode(0666)
+	defaultDirMode    = os.ModeDir | os.FileMode(0755)
+	defaultTimeUnit   = xtime.Second
+)
+
+type opts struct {
+	encodingOpts encoding.Options
+	intOptimized bool
+	timeUnit     xtime.Unit
+	bufferSize   int
+	fileMode     os.FileMode
+	dirMode      os.FileMode
+}
+
+// NewOptions returns the new options
+func NewOptions() Options {
+	return &opts{
+		encodingOpts: encoding.NewOptions(),
+		intOptimized: m3tsz.DefaultIntOptimizationEnabled,
+		timeUnit:     defaultTimeUnit,
+		bufferSize:   defaultBufferSize,
+		fileMode:     defaultFileMode,
+		dirMode:      defaultDirMode,
+	}
+}
+
+func (o *opts) SetEncodingOptions(value encoding.Options) Options {
+	o.encodingOpts = value
+	return o
+}
+
+func (o *opts) EncodingOptions() encoding.Options {
+	return o.encodingOpts
+}
+
+func (o *opts) SetIntOptimizationEnabled(value bool) Options {
+	o.intOptimized = value
+	return o
+}
+
+func (o *opts) IntOptimizationEnabled() bool {
+	return o.intOptimized
+}
+
+func (o *opts) SetTimeUnit(value xtime.Unit) Options {
+	o.timeUnit = value
+	return o
+}
+
+func (o *opts) TimeUnit() xtime.Unit {
+	return o.timeUnit
+}
+
+func (o *opts) SetBufferSize(b int) Options {
+	o.bufferSize = b
+	return o
+}
+
+func (o *opts) BufferSize() int {
+	return o.bufferSize
+}
+
+func (o *opts) SetFileMode(f os.FileMode) Options {
+	o.fileMode = f
+	return o
+}
+
+func (o *opts) FileMode() os.FileMode {
+	return o.fileMode
+}
+
+func (o *opts) SetDirMode(d os.FileMode) Options {
+	o.dirMode = d
+	return o
+}
+
+func (o *opts) DirMode() os.FileMode {
+	return o.dirMode
+}
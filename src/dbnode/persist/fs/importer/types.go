@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package importer
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// FileSetID is the collection of identifiers required to
+// uniquely identify the block to import into.
+type FileSetID struct {
+	PathPrefix string
+	Namespace  string
+	Shard      uint32
+	Blockstart time.Time
+}
+
+// Result describes the outcome of an import.
+type Result struct {
+	NumSeries     int
+	NumDatapoints int
+	VolumeIndex   int
+}
+
+// FileSetImporter writes historical datapoints directly as a fileset
+// volume, bypassing the commit log and the in-memory write path.
+type FileSetImporter interface {
+	// ImportCSV reads rows of the form `id,tags,timestamp,value` from the
+	// reader and writes them out as a new fileset volume. Tags are
+	// encoded as `name=value` pairs separated by semicolons and timestamps
+	// are in nanoseconds since the unix epoch. All datapoints must fall
+	// within the destination block. If the block already has a volume the
+	// datapoints are merged with it into the next volume. If the index
+	// block size is non-zero the series are also written to a new index
+	// volume of the index block containing the destination block.
+	ImportCSV(
+		r io.Reader,
+		dest FileSetID,
+		blockSize time.Duration,
+		indexBlockSize time.Duration,
+	) (Result, error)
+}
+
+// Options represents the knobs available while importing.
+type Options interface {
+	// SetEncodingOptions sets the encoding options
+	SetEncodingOptions(value encoding.Options) Options
+
+	// EncodingOptions returns the encoding options
+	EncodingOptions() encoding.Options
+
+	// SetIntOptimizationEnabled sets whether int optimization is enabled
+	SetIntOptimizationEnabled(value bool) Options
+
+	// IntOptimizationEnabled returns whether int optimization is enabled
+	IntOptimizationEnabled() bool
+
+	// SetTimeUnit sets the time unit datapoints are encoded with
+	SetTimeUnit(value xtime.Unit) Options
+
+	// TimeUnit returns the time unit datapoints are encoded with
+	TimeUnit() xtime.Unit
+
+	// SetBufferSize sets the buffer size
+	SetBufferSize(int) Options
+
+	// BufferSize returns the buffer size
+	BufferSize() int
+
+	// SetFileMode sets the fileMode used for file creation
+	SetFileMode(os.FileMode) Options
+
+	// FileMode returns the fileMode used for file creation
+	FileMode() os.FileMode
+
+	// SetDirMode sets the file mode used for dir creation
+	SetDirMode(os.FileMode) Options
+
+	// DirMode returns the file mode used for dir creation
+	DirMode() os.FileMode
+}
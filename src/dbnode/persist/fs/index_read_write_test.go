@@ -142,6 +142,57 @@ func TestIndexSimpleReadWrite(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestIndexUnclosedVolumeIgnoredAndOverwritten(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newIndexWriteTestSetup(t)
+	defer test.cleanup()
+
+	openOpts := IndexWriterOpenOptions{
+		Identifier:  test.fileSetID,
+		BlockSize:   test.blockSize,
+		FileSetType: persist.FileSetFlushType,
+		Shards:      shardsSet(1, 3, 5),
+	}
+	testSegments := []testIndexSegment{
+		{
+			segmentType:  idxpersist.IndexSegmentType("fst"),
+			majorVersion: 1,
+			minorVersion: 2,
+			files: []testIndexSegmentFile{
+				{idxpersist.IndexSegmentFileType("first"), randDataFactorOfBuffSize(t, 1.5)},
+			},
+		},
+	}
+
+	// Write segments without closing the volume, as an interrupted flush does.
+	writer := newTestIndexWriter(t, test.filePathPrefix)
+	require.NoError(t, writer.Open(openOpts))
+	writeTestIndexSegments(t, ctrl, writer, testSegments)
+
+	// The volume has no checkpoint so it is not read and its index is reused.
+	infoFiles := ReadIndexInfoFiles(test.filePathPrefix, test.fileSetID.Namespace,
+		testDefaultOpts.InfoReaderBufferSize())
+	require.Equal(t, 0, len(infoFiles))
+	volumeIndex, err := NextIndexFileSetVolumeIndex(test.filePathPrefix,
+		test.fileSetID.Namespace, test.blockStart)
+	require.NoError(t, err)
+	require.Equal(t, test.fileSetID.VolumeIndex, volumeIndex)
+
+	// Retrying overwrites the incomplete volume.
+	writer = newTestIndexWriter(t, test.filePathPrefix)
+	require.NoError(t, writer.Open(openOpts))
+	writeTestIndexSegments(t, ctrl, writer, append(testSegments, testSegments...))
+	require.NoError(t, writer.Close())
+
+	infoFiles = ReadIndexInfoFiles(test.filePathPrefix, test.fileSetID.Namespace,
+		testDefaultOpts.InfoReaderBufferSize())
+	require.Equal(t, 1, len(infoFiles))
+	require.NoError(t, infoFiles[0].Err.Error())
+	require.Equal(t, 2, len(infoFiles[0].Info.Segments))
+}
+
 func newTestIndexWriter(t *testing.T, filePathPrefix string) IndexFileSetWriter {
 	writer, err := NewIndexWriter(testDefaultOpts.
 		SetFilePathPrefix(filePathPrefix).
@@ -46,6 +46,7 @@ type merger struct {
 	encoderPool    encoding.EncoderPool
 	contextPool    context.Pool
 	nsOpts         namespace.Options
+	tombstones     *namespace.SeriesTombstones
 }
 
 // NewMerger returns a new Merger. This implementation is in charge of merging
@@ -53,6 +54,9 @@ type merger struct {
 // at a timestamp exists both on disk and the merge target, data from the merge
 // target will be used. This merged data is then persisted.
 //
+// Datapoints deleted by the series tombstones, which may be nil, are dropped
+// from the merged data.
+//
 // Note that the merger does not know how or where this merged data is
 // persisted since it just uses the flushPreparer that is passed in. Further,
 // it does not signal to the database of the existence of the newly persisted
@@ -66,6 +70,7 @@ func NewMerger(
 	encoderPool encoding.EncoderPool,
 	contextPool context.Pool,
 	nsOpts namespace.Options,
+	tombstones *namespace.SeriesTombstones,
 ) Merger {
 	return &merger{
 		reader:         reader,
@@ -76,6 +81,7 @@ func NewMerger(
 		encoderPool:    encoderPool,
 		contextPool:    contextPool,
 		nsOpts:         nsOpts,
+		tombstones:     tombstones,
 	}
 }
 
@@ -97,6 +103,7 @@ func (m *merger) Merge(
 		multiIterPool  = m.multiIterPool
 		encoderPool    = m.encoderPool
 		nsOpts         = m.nsOpts
+		tombstones     = m.tombstones
 
 		nsID       = fileID.Namespace
 		shard      = fileID.Shard
@@ -104,6 +111,7 @@ func (m *merger) Merge(
 		volume     = fileID.VolumeIndex
 		blockSize  = nsOpts.RetentionOptions().BlockSize()
 		blockStart = xtime.ToUnixNano(startTime)
+		blockRange = xtime.Range{Start: startTime, End: startTime.Add(blockSize)}
 		openOpts   = DataReaderOpenOptions{
 			Identifier: FileSetFileIdentifier{
 				Namespace:   nsID,
@@ -206,10 +214,19 @@ func (m *merger) Merge(
 				FinalizeTagIterator: true,
 			})
 
-		// In the special (but common) case that we're just copying the series data from the old file
-		// into the new one without merging or adding any additional data we can avoid recalculating
-		// the checksum.
-		if len(segmentReaders) == 1 && hasInMemoryData == false {
+		// Datapoints deleted by series tombstones require re-encoding the
+		// series to drop them. Otherwise in the special (but common) case that
+		// we're just copying the series data from the old file into the new one
+		// without merging or adding any additional data we can avoid
+		// recalculating the checksum.
+		deleted := namespace.OverlappingRanges(
+			tombstones.SeriesRanges(nsID, id), blockRange)
+		if len(deleted) > 0 {
+			if err := persistIterWithoutDeleted(metadata, segmentReaders, deleted,
+				iterResources, prepared.Persist); err != nil {
+				return closer, err
+			}
+		} else if len(segmentReaders) == 1 && hasInMemoryData == false {
 			segment, err := segmentReaders[0].Segment()
 			if err != nil {
 				return closer, err
@@ -239,7 +256,15 @@ func (m *merger) Merge(
 			segmentReaders = appendBlockReadersToSegmentReaders(segmentReaders, mergeWithData.Blocks)
 
 			metadata := persist.NewMetadata(seriesMetadata)
-			err := persistSegmentReaders(metadata, segmentReaders, iterResources, prepared.Persist)
+			deleted := namespace.OverlappingRanges(
+				tombstones.SeriesRanges(nsID, ident.BytesID(seriesMetadata.ID)), blockRange)
+			var err error
+			if len(deleted) > 0 {
+				err = persistIterWithoutDeleted(metadata, segmentReaders, deleted,
+					iterResources, prepared.Persist)
+			} else {
+				err = persistSegmentReaders(metadata, segmentReaders, iterResources, prepared.Persist)
+			}
 
 			if err == nil {
 				err = onFlush.OnFlushNewSeries(persist.OnFlushNewSeriesEvent{
@@ -325,6 +350,46 @@ func persistIter(
 	return persistSegment(metadata, segment, persistFn)
 }
 
+// persistIterWithoutDeleted persists the datapoints of the segment readers
+// outside of the deleted time ranges, the series is not persisted at all if
+// all of its datapoints were deleted.
+func persistIterWithoutDeleted(
+	metadata persist.Metadata,
+	segReaders []xio.SegmentReader,
+	deleted []xtime.Range,
+	ir iterResources,
+	persistFn persist.DataFn,
+) error {
+	it := ir.multiIter
+	it.Reset(segReaders, ir.blockStart, ir.blockSize, ir.schema)
+	encoder := ir.encoderPool.Get()
+	encoder.Reset(ir.blockStart, ir.blockAllocSize, ir.schema)
+	encoded := 0
+	for it.Next() {
+		dp, unit, annotation := it.Current()
+		if namespace.RangesContain(deleted, dp.Timestamp) {
+			continue
+		}
+		if err := encoder.Encode(dp, unit, annotation); err != nil {
+			encoder.Close()
+			return err
+		}
+		encoded++
+	}
+	if err := it.Err(); err != nil {
+		encoder.Close()
+		return err
+	}
+	if encoded == 0 {
+		encoder.Close()
+		metadata.Finalize()
+		return nil
+	}
+
+	segment := encoder.Discard()
+	return persistSegment(metadata, segment, persistFn)
+}
+
 func persistSegmentReader(
 	metadata persist.Metadata,
 	segmentReader xio.SegmentReader,
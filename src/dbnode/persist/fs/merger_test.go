@@ -427,11 +427,79 @@ func TestMergeWithNoData(t *testing.T) {
 	testMergeWith(t, diskData, mergeTargetData, expected)
 }
 
+func TestMergeWithTombstones(t *testing.T) {
+	// This test scenario is when series tombstones delete datapoints on disk
+	// and in the merge target.
+	// id0 has datapoints deleted on disk without data to merge, id1 has all
+	// of its datapoints deleted, id2 is not deleted and id3 has datapoints
+	// deleted in the merge target.
+	diskData := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	diskData.Set(id0, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(0 * time.Second), Value: 0},
+		{Timestamp: startTime.Add(1 * time.Second), Value: 1},
+		{Timestamp: startTime.Add(2 * time.Second), Value: 2},
+	}))
+	diskData.Set(id1, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(2 * time.Second), Value: 3},
+	}))
+	diskData.Set(id2, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(1 * time.Second), Value: 4},
+	}))
+
+	mergeTargetData := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	mergeTargetData.Set(id3, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(2 * time.Second), Value: 5},
+		{Timestamp: startTime.Add(4 * time.Second), Value: 6},
+	}))
+
+	expected := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	expected.Set(id0, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(0 * time.Second), Value: 0},
+		{Timestamp: startTime.Add(2 * time.Second), Value: 2},
+	}))
+	expected.Set(id2, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(1 * time.Second), Value: 4},
+	}))
+	expected.Set(id3, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(4 * time.Second), Value: 6},
+	}))
+
+	tombstones, err := namespace.NewSeriesTombstones([]namespace.SeriesTombstone{
+		{
+			ID:        "a",
+			Namespace: "test-ns",
+			SeriesIDs: []string{id0.String(), id1.String(), id3.String()},
+			Start:     startTime.Add(1 * time.Second),
+			End:       startTime.Add(3 * time.Second),
+		},
+		{
+			ID:        "b",
+			Namespace: "other-ns",
+			SeriesIDs: []string{id2.String()},
+			Start:     startTime,
+			End:       startTime.Add(blockSize),
+		},
+	})
+	require.NoError(t, err)
+
+	testMergeWithTombstones(t, diskData, mergeTargetData, expected, tombstones)
+}
+
 func testMergeWith(
 	t *testing.T,
 	diskData *checkedBytesMap,
 	mergeTargetData *checkedBytesMap,
 	expectedData *checkedBytesMap,
+) {
+	testMergeWithTombstones(t, diskData, mergeTargetData, expectedData, nil)
+}
+
+func testMergeWithTombstones(
+	t *testing.T,
+	diskData *checkedBytesMap,
+	mergeTargetData *checkedBytesMap,
+	expectedData *checkedBytesMap,
+	tombstones *namespace.SeriesTombstones,
 ) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -464,7 +532,7 @@ func testMergeWith(
 
 	nsOpts := namespace.NewOptions()
 	merger := NewMerger(reader, 0, srPool, multiIterPool,
-		identPool, encoderPool, contextPool, nsOpts)
+		identPool, encoderPool, contextPool, nsOpts, tombstones)
 	fsID := FileSetFileIdentifier{
 		Namespace:  ident.StringID("test-ns"),
 		Shard:      uint32(8),
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
@@ -100,6 +101,9 @@ type options struct {
 	forceBloomFilterMmapMemory           bool
 	mmapEnableHugePages                  bool
 	mmapReporter                         mmap.Reporter
+	readMode                             ReadMode
+	tieredStorage                        TieredStorage
+	faultInjector                        *fault.Injector
 }
 
 // NewOptions creates a new set of fs options
@@ -134,6 +138,7 @@ func NewOptions() Options {
 		tagEncoderPool:                       tagEncoderPool,
 		tagDecoderPool:                       tagDecoderPool,
 		fstOptions:                           fstOptions,
+		readMode:                             DefaultReadMode,
 	}
 }
 
@@ -154,6 +159,9 @@ func (o *options) Validate() error {
 	if o.tagDecoderPool == nil {
 		return errTagDecoderPoolNotSet
 	}
+	if err := ValidateReadMode(o.readMode); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -366,3 +374,33 @@ func (o *options) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 func (o *options) MmapReporter() mmap.Reporter {
 	return o.mmapReporter
 }
+
+func (o *options) SetReadMode(value ReadMode) Options {
+	opts := *o
+	opts.readMode = value
+	return &opts
+}
+
+func (o *options) ReadMode() ReadMode {
+	return o.readMode
+}
+
+func (o *options) SetTieredStorage(value TieredStorage) Options {
+	opts := *o
+	opts.tieredStorage = value
+	return &opts
+}
+
+func (o *options) TieredStorage() TieredStorage {
+	return o.tieredStorage
+}
+
+func (o *options) SetFaultInjector(value *fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() *fault.Injector {
+	return o.faultInjector
+}
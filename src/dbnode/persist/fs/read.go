@@ -21,10 +21,12 @@
 package fs
 
 import (
+	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"sort"
 	"time"
@@ -80,6 +82,13 @@ type reader struct {
 	dataMmap   mmap.Descriptor
 	dataReader digest.ReaderWithDigest
 
+	// tieredDataFilepath is the path of the data file if it was moved to
+	// object storage, it is only restored and opened once data is read.
+	tieredDataFilepath string
+
+	indexDirectReader *directReader
+	dataDirectReader  *directReader
+
 	bloomFilterFd *os.File
 
 	entries         int
@@ -207,13 +216,67 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		r.digestFdWithDigestContents.Close()
 	}()
 
-	result, err := mmap.Files(os.Open, map[string]mmap.FileDesc{
+	// Reading the metadata of a volume moved to object storage only
+	// requires the index, the data file is restored on first read of data.
+	dataTiered, err := isTieredDataFile(dataFilepath)
+	if err != nil {
+		return err
+	}
+	if dataTiered {
+		r.tieredDataFilepath = dataFilepath
+		dataFilepath = ""
+	}
+
+	switch r.opts.ReadMode() {
+	case ReadModeDirect:
+		err = r.openDirect(indexFilepath, dataFilepath)
+	default:
+		err = r.openMmap(indexFilepath, dataFilepath)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.readDigest(); err != nil {
+		// Try to close if failed to read
+		r.Close()
+		return err
+	}
+	infoStat, err := infoFd.Stat()
+	if err != nil {
+		r.Close()
+		return err
+	}
+	if err := r.readInfo(int(infoStat.Size())); err != nil {
+		r.Close()
+		return err
+	}
+	if err := r.readIndexAndSortByOffsetAsc(); err != nil {
+		r.Close()
+		return err
+	}
+
+	r.open = true
+	r.namespace = namespace
+	r.shard = shard
+
+	return nil
+}
+
+func (r *reader) openMmap(indexFilepath, dataFilepath string) error {
+	advice := mmap.AdviceNormal
+	if r.opts.ReadMode() == ReadModeMmapSequential {
+		advice = mmap.AdviceSequential
+	}
+
+	files := map[string]mmap.FileDesc{
 		indexFilepath: mmap.FileDesc{
 			File:       &r.indexFd,
 			Descriptor: &r.indexMmap,
 			Options: mmap.Options{
 				Read:    true,
 				HugeTLB: r.hugePagesOpts,
+				Advice:  advice,
 				ReporterOptions: mmap.ReporterOptions{
 					Context: mmap.Context{
 						Name: mmapPersistFsDataIndexName,
@@ -222,12 +285,15 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 				},
 			},
 		},
-		dataFilepath: mmap.FileDesc{
+	}
+	if dataFilepath != "" {
+		files[dataFilepath] = mmap.FileDesc{
 			File:       &r.dataFd,
 			Descriptor: &r.dataMmap,
 			Options: mmap.Options{
 				Read:    true,
 				HugeTLB: r.hugePagesOpts,
+				Advice:  advice,
 				ReporterOptions: mmap.ReporterOptions{
 					Context: mmap.Context{
 						Name: mmapPersistFsDataName,
@@ -235,8 +301,10 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 					Reporter: r.opts.MmapReporter(),
 				},
 			},
-		},
-	})
+		}
+	}
+
+	result, err := mmap.Files(os.Open, files)
 	if err != nil {
 		return err
 	}
@@ -247,31 +315,65 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 	}
 
 	r.indexDecoderStream.Reset(r.indexMmap.Bytes)
-	r.dataReader.Reset(bytes.NewReader(r.dataMmap.Bytes))
+	if dataFilepath != "" {
+		r.dataReader.Reset(bytes.NewReader(r.dataMmap.Bytes))
+	}
+	return nil
+}
 
-	if err := r.readDigest(); err != nil {
-		// Try to close if failed to read
-		r.Close()
+func (r *reader) openDirect(indexFilepath, dataFilepath string) error {
+	files := map[string]**os.File{
+		indexFilepath: &r.indexFd,
+	}
+	if dataFilepath != "" {
+		files[dataFilepath] = &r.dataFd
+	}
+	if err := openFiles(openDirect, files); err != nil {
 		return err
 	}
-	infoStat, err := infoFd.Stat()
+
+	if r.indexDirectReader == nil {
+		r.indexDirectReader = newDirectReader(r.opts.DataReaderBufferSize())
+		r.dataDirectReader = newDirectReader(r.opts.DataReaderBufferSize())
+	}
+
+	// NB: The index is decoded in full on open so read it all up front,
+	// the data is streamed as it is read.
+	r.indexDirectReader.Reset(r.indexFd)
+	indexBytes, err := ioutil.ReadAll(r.indexDirectReader)
+	r.indexDirectReader.Reset(nil)
 	if err != nil {
-		r.Close()
+		r.indexFd.Close()
+		if r.dataFd != nil {
+			r.dataFd.Close()
+		}
+		r.indexFd, r.dataFd = nil, nil
 		return err
 	}
-	if err := r.readInfo(int(infoStat.Size())); err != nil {
-		r.Close()
+
+	r.indexDecoderStream.Reset(indexBytes)
+	if dataFilepath != "" {
+		r.dataDirectReader.Reset(r.dataFd)
+		r.dataReader.Reset(r.dataDirectReader)
+	}
+	return nil
+}
+
+// openTieredData restores and opens the data file of a volume moved to object
+// storage on first read of data.
+func (r *reader) openTieredData() error {
+	if r.tieredDataFilepath == "" || r.dataFd != nil {
+		return nil
+	}
+	if err := fetchTieredDataFile(r.opts.TieredStorage(), r.tieredDataFilepath); err != nil {
 		return err
 	}
-	if err := r.readIndexAndSortByOffsetAsc(); err != nil {
-		r.Close()
+	fd, err := os.Open(r.tieredDataFilepath)
+	if err != nil {
 		return err
 	}
-
-	r.open = true
-	r.namespace = namespace
-	r.shard = shard
-
+	r.dataFd = fd
+	r.dataReader.Reset(bufio.NewReaderSize(fd, r.opts.DataReaderBufferSize()))
 	return nil
 }
 
@@ -355,6 +457,9 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 	if r.entriesRead >= r.entries {
 		return nil, nil, nil, 0, io.EOF
 	}
+	if err := r.openTieredData(); err != nil {
+		return nil, nil, nil, 0, err
+	}
 
 	entry := r.indexEntriesByOffsetAsc[r.entriesRead]
 
@@ -463,6 +568,9 @@ func (r *reader) ValidateMetadata() error {
 // NB(xichen): ValidateData should be called after all data is read because
 // the digest is calculated for the entire data file.
 func (r *reader) ValidateData() error {
+	if err := r.openTieredData(); err != nil {
+		return fmt.Errorf("could not open data file: %v", err)
+	}
 	err := r.dataReader.Validate(r.expectedDataDigest)
 	if err != nil {
 		return fmt.Errorf("could not validate data file: %v", err)
@@ -489,13 +597,24 @@ func (r *reader) MetadataRead() int {
 func (r *reader) Close() error {
 	// Close and prepare resources that are to be reused
 	multiErr := xerrors.NewMultiError()
+	if r.opts.ReadMode() == ReadModeMmapSequential {
+		// Drop the files from the page cache so that reading them once
+		// does not evict the pages used by queries.
+		multiErr = multiErr.Add(dropPageCache(r.indexFd))
+		multiErr = multiErr.Add(dropPageCache(r.dataFd))
+	}
 	multiErr = multiErr.Add(mmap.Munmap(r.indexMmap))
 	multiErr = multiErr.Add(mmap.Munmap(r.dataMmap))
 	multiErr = multiErr.Add(r.indexFd.Close())
-	multiErr = multiErr.Add(r.dataFd.Close())
+	if r.dataFd != nil {
+		multiErr = multiErr.Add(r.dataFd.Close())
+	}
 	multiErr = multiErr.Add(r.bloomFilterFd.Close())
 	r.indexDecoderStream.Reset(nil)
 	r.dataReader.Reset(nil)
+	if r.dataDirectReader != nil {
+		r.dataDirectReader.Reset(nil)
+	}
 	for i := 0; i < len(r.indexEntriesByOffsetAsc); i++ {
 		r.indexEntriesByOffsetAsc[i].ID = nil
 	}
@@ -510,6 +629,8 @@ func (r *reader) Close() error {
 	bloomFilterWithDigest := r.bloomFilterWithDigest
 	indexDecoderStream := r.indexDecoderStream
 	dataReader := r.dataReader
+	indexDirectReader := r.indexDirectReader
+	dataDirectReader := r.dataDirectReader
 	decoder := r.decoder
 	digestBuf := r.digestBuf
 	bytesPool := r.bytesPool
@@ -528,6 +649,8 @@ func (r *reader) Close() error {
 	r.bloomFilterWithDigest = bloomFilterWithDigest
 	r.indexDecoderStream = indexDecoderStream
 	r.dataReader = dataReader
+	r.indexDirectReader = indexDirectReader
+	r.dataDirectReader = dataDirectReader
 	r.decoder = decoder
 	r.digestBuf = digestBuf
 	r.bytesPool = bytesPool
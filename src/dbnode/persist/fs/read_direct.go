@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// directIOAlignment is the alignment of buffers used for direct IO, which
+// must be a multiple of the logical block size of the underlying device.
+const directIOAlignment = 4096
+
+// directReader streams a file opened for direct IO through a buffer aligned
+// as direct IO requires.
+type directReader struct {
+	fd  *os.File
+	buf []byte
+	pos int
+	end int
+	eof bool
+}
+
+func newDirectReader(bufferSize int) *directReader {
+	// Round the buffer size up to a multiple of the alignment.
+	size := (bufferSize + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+	if size < directIOAlignment {
+		size = directIOAlignment
+	}
+	return &directReader{buf: newAlignedBuffer(size)}
+}
+
+func newAlignedBuffer(size int) []byte {
+	buf := make([]byte, size+directIOAlignment)
+	var offset int
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) % directIOAlignment); rem != 0 {
+		offset = directIOAlignment - rem
+	}
+	return buf[offset : offset+size : offset+size]
+}
+
+func (r *directReader) Reset(fd *os.File) {
+	r.fd = fd
+	r.pos = 0
+	r.end = 0
+	r.eof = fd == nil
+}
+
+func (r *directReader) Read(p []byte) (int, error) {
+	if r.pos == r.end {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if err := r.fill(); err != nil {
+			return 0, err
+		}
+		if r.pos == r.end {
+			return 0, io.EOF
+		}
+	}
+	n := copy(p, r.buf[r.pos:r.end])
+	r.pos += n
+	return n, nil
+}
+
+func (r *directReader) fill() error {
+	n, err := r.fd.Read(r.buf)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	r.pos, r.end = 0, n
+	// NB: A short read only happens at the end of the file, and reading
+	// again from the unaligned offset it leaves is not valid for direct IO.
+	if err == io.EOF || n < len(r.buf) {
+		r.eof = true
+	}
+	return nil
+}
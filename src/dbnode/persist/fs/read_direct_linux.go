@@ -0,0 +1,47 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// openDirect opens a file for direct IO, falling back to regular reads if
+// the filesystem does not support direct IO.
+func openDirect(filePath string) (*os.File, error) {
+	fd, err := os.OpenFile(filePath, os.O_RDONLY|unix.O_DIRECT, 0)
+	if pathErr, ok := err.(*os.PathError); ok && pathErr.Err == syscall.EINVAL {
+		return os.Open(filePath)
+	}
+	return fd, err
+}
+
+// dropPageCache advises the kernel that the file's pages in the page cache
+// will not be needed again, so they can be dropped ahead of hotter pages.
+func dropPageCache(fd *os.File) error {
+	if fd == nil {
+		return nil
+	}
+	return unix.Fadvise(int(fd.Fd()), 0, 0, unix.FADV_DONTNEED)
+}
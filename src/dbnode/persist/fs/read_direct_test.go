@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectReaderReadsWholeFile(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	// Use a size that is not a multiple of the alignment so the last read
+	// is short.
+	data := make([]byte, 3*directIOAlignment+123)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	filePath := filepath.Join(dir, "data")
+	require.NoError(t, ioutil.WriteFile(filePath, data, 0666))
+
+	fd, err := openDirect(filePath)
+	require.NoError(t, err)
+	defer fd.Close()
+
+	r := newDirectReader(100)
+	require.Equal(t, directIOAlignment, len(r.buf))
+
+	r.Reset(fd)
+	read, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, data, read)
+}
+
+func TestDirectReaderAlignedBuffer(t *testing.T) {
+	for _, size := range []int{1, directIOAlignment, 2*directIOAlignment + 1} {
+		r := newDirectReader(size)
+		require.Equal(t, 0, len(r.buf)%directIOAlignment)
+		require.Equal(t, uintptr(0), uintptr(unsafe.Pointer(&r.buf[0]))%directIOAlignment)
+		require.Equal(t, len(r.buf), cap(r.buf))
+	}
+}
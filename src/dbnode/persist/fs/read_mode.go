@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errReadModeUnspecified = errors.New("fileset read mode unspecified")
+)
+
+// ReadMode is the mode used by fileset readers to read the index and data
+// files of filesets, which are read sequentially by bootstraps and repairs.
+// It does not affect the seekers used to serve queries.
+type ReadMode uint
+
+const (
+	// ReadModeMmap reads the files using mmap.
+	ReadModeMmap ReadMode = iota
+	// ReadModeMmapSequential reads the files using mmap advised for sequential
+	// access, dropping the files from the page cache once read.
+	ReadModeMmapSequential
+	// ReadModeDirect reads the files using direct IO on platforms that support
+	// it, bypassing the page cache entirely.
+	ReadModeDirect
+
+	// DefaultReadMode is the default read mode.
+	DefaultReadMode = ReadModeMmap
+)
+
+// ValidReadModes returns the valid fileset read modes.
+func ValidReadModes() []ReadMode {
+	return []ReadMode{ReadModeMmap, ReadModeMmapSequential, ReadModeDirect}
+}
+
+func (m ReadMode) String() string {
+	switch m {
+	case ReadModeMmap:
+		return "mmap"
+	case ReadModeMmapSequential:
+		return "mmap_sequential"
+	case ReadModeDirect:
+		return "direct"
+	}
+	return "unknown"
+}
+
+// ValidateReadMode validates a fileset read mode.
+func ValidateReadMode(v ReadMode) error {
+	for _, valid := range ValidReadModes() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid fileset ReadMode '%d' valid types are: %v",
+		uint(v), ValidReadModes())
+}
+
+// ParseReadMode parses a ReadMode from a string.
+func ParseReadMode(str string) (ReadMode, error) {
+	var r ReadMode
+	if str == "" {
+		return r, errReadModeUnspecified
+	}
+	for _, valid := range ValidReadModes() {
+		if str == valid.String() {
+			return valid, nil
+		}
+	}
+	return r, fmt.Errorf("invalid fileset ReadMode '%s' valid types are: %v",
+		str, ValidReadModes())
+}
+
+// UnmarshalYAML unmarshals a ReadMode into a valid type from string.
+func (m *ReadMode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseReadMode(str)
+	if err != nil {
+		return err
+	}
+	*m = r
+	return nil
+}
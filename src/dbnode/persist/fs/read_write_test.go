@@ -336,6 +336,41 @@ func TestReadWithReusedReader(t *testing.T) {
 	readTestData(t, r, 0, testWriterStart, entries)
 }
 
+func TestReadWithReadModes(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", nil, make([]byte, 65536)},
+		{"cat", nil, make([]byte, 100000)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	for _, mode := range ValidReadModes() {
+		t.Run(mode.String(), func(t *testing.T) {
+			r, err := NewReader(testBytesPool, testDefaultOpts.
+				SetFilePathPrefix(filePathPrefix).
+				SetInfoReaderBufferSize(testReaderBufferSize).
+				SetDataReaderBufferSize(testReaderBufferSize).
+				SetReadMode(mode))
+			require.NoError(t, err)
+
+			readTestData(t, r, 0, testWriterStart, entries)
+			// Reuse the reader to read again
+			readTestData(t, r, 0, testWriterStart, entries)
+		})
+	}
+}
+
 func TestInfoReadWrite(t *testing.T) {
 	dir := createTempDir(t)
 	filePathPrefix := filepath.Join(dir, "")
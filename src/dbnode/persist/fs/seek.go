@@ -167,6 +167,14 @@ func (s *seeker) Open(
 		}
 	}
 
+	// Restore the data file if it was moved to object storage, queries read
+	// the data of the volume.
+	if err := fetchTieredDataFile(s.opts.opts.TieredStorage(),
+		dataFilesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, dataFileSuffix, isLegacy),
+	); err != nil {
+		return err
+	}
+
 	// Open necessary files
 	if err := openFiles(os.Open, map[string]**os.File{
 		dataFilesetPathFromTimeAndIndex(shardDir, blockStart, volumeIndex, infoFileSuffix, isLegacy):        &infoFd,
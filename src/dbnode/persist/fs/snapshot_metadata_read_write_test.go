@@ -26,6 +26,7 @@ import (
 	"testing"
 
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/x/fault"
 
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/require"
@@ -81,3 +82,27 @@ func TestSnapshotMetadataWriteAndRead(t *testing.T) {
 		}, snapshotMetadata)
 	}
 }
+
+func TestSnapshotMetadataWriteInjectedSyncFailure(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	injector := fault.NewInjector()
+	injector.SetFaults(fault.Faults{FailSyncs: true})
+	opts := testDefaultOpts.
+		SetFilePathPrefix(dir).
+		SetFaultInjector(injector)
+
+	writer := NewSnapshotMetadataWriter(opts)
+	err := writer.Write(SnapshotMetadataWriteArgs{
+		ID: SnapshotMetadataIdentifier{
+			Index: 0,
+			UUID:  uuid.Parse("6645a373-bf82-42e7-84a6-f8452b137549"),
+		},
+		CommitlogIdentifier: persist.CommitLogFile{
+			FilePath: "some_path",
+			Index:    1,
+		},
+	})
+	require.Equal(t, fault.ErrInjectedSync, err)
+}
@@ -158,10 +158,17 @@ func (w *SnapshotMetadataWriter) Write(args SnapshotMetadataWriteArgs) (finalErr
 // sync ensures that the provided file is persisted to disk by syncing it, as well
 // as its parent directory (ensuring the file is discoverable in the parent inode.)
 func (w *SnapshotMetadataWriter) sync(file, parent *os.File) error {
-	err := file.Sync()
+	err := w.syncFile(file)
 	if err != nil {
 		return err
 	}
 
-	return parent.Sync()
+	return w.syncFile(parent)
+}
+
+func (w *SnapshotMetadataWriter) syncFile(file *os.File) error {
+	if injector := w.opts.FaultInjector(); injector != nil {
+		return injector.Sync(file)
+	}
+	return file.Sync()
 }
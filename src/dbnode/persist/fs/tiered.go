@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"strings"
+)
+
+// TieredStubFilePath returns the path of the stub file left in place of a
+// data file moved to object storage.
+func TieredStubFilePath(dataFilePath string) string {
+	return strings.TrimSuffix(dataFilePath, dataFileSuffix+fileSuffix) +
+		remoteFileSuffix + fileSuffix
+}
+
+// IsDataFilePath returns whether a path is the path of a data file.
+func IsDataFilePath(filePath string) bool {
+	return strings.HasSuffix(filePath, separator+dataFileSuffix+fileSuffix)
+}
+
+// isTieredDataFile returns whether a data file was moved to object storage
+// and is not cached locally.
+func isTieredDataFile(dataFilePath string) (bool, error) {
+	if _, err := os.Stat(dataFilePath); err == nil || !os.IsNotExist(err) {
+		return false, err
+	}
+	_, err := os.Stat(TieredStubFilePath(dataFilePath))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// fetchTieredDataFile restores a data file moved to object storage, if any.
+func fetchTieredDataFile(tiered TieredStorage, dataFilePath string) error {
+	if tiered == nil {
+		return nil
+	}
+	return tiered.Fetch(dataFilePath)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTieredStorage struct {
+	dir     string
+	fetched []string
+}
+
+func (s *testTieredStorage) Fetch(dataFilePath string) error {
+	s.fetched = append(s.fetched, dataFilePath)
+	tiered, err := isTieredDataFile(dataFilePath)
+	if err != nil || !tiered {
+		return err
+	}
+	return os.Rename(filepath.Join(s.dir, filepath.Base(dataFilePath)), dataFilePath)
+}
+
+func TestReaderRestoresTieredDataFileOnRead(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	filePathPrefix := filepath.Join(dir, "data")
+
+	entries := []testEntry{
+		{id: "bar", data: []byte{1, 2, 3}},
+		{id: "foo", data: []byte{4, 5, 6}},
+	}
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+
+	// Move the data file away leaving a stub in its place.
+	tiered := &testTieredStorage{dir: filepath.Join(dir, "tiered")}
+	require.NoError(t, os.MkdirAll(tiered.dir, 0755))
+	dataFilePath := dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0),
+		testWriterStart, 0, dataFileSuffix, false)
+	require.NoError(t, os.Rename(dataFilePath,
+		filepath.Join(tiered.dir, filepath.Base(dataFilePath))))
+	require.NoError(t, ioutil.WriteFile(TieredStubFilePath(dataFilePath), []byte("{}"), 0666))
+
+	r, err := NewReader(nil, testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetTieredStorage(tiered))
+	require.NoError(t, err)
+	openOpts := DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+
+	// Reading the metadata does not restore the data file.
+	require.NoError(t, r.Open(openOpts))
+	for range entries {
+		id, tags, _, _, err := r.ReadMetadata()
+		require.NoError(t, err)
+		id.Finalize()
+		tags.Close()
+	}
+	require.NoError(t, r.ValidateMetadata())
+	require.NoError(t, r.Close())
+	require.Empty(t, tiered.fetched)
+
+	require.NoError(t, r.Open(openOpts))
+	for _, entry := range entries {
+		id, tags, data, _, err := r.Read()
+		require.NoError(t, err)
+		data.IncRef()
+		require.Equal(t, entry.id, id.String())
+		require.True(t, bytes.Equal(entry.data, data.Bytes()))
+		id.Finalize()
+		tags.Close()
+		data.DecRef()
+		data.Finalize()
+	}
+	require.NoError(t, r.Validate())
+	require.NoError(t, r.Close())
+	require.Equal(t, []string{dataFilePath}, tiered.fetched)
+}
+
+func TestSeekerRestoresTieredDataFileOnOpen(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+	filePathPrefix := filepath.Join(dir, "data")
+
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, []testEntry{
+		{id: "foo", data: []byte{1, 2, 3}},
+	}, persist.FileSetFlushType)
+
+	tiered := &testTieredStorage{dir: filepath.Join(dir, "tiered")}
+	require.NoError(t, os.MkdirAll(tiered.dir, 0755))
+	dataFilePath := dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0),
+		testWriterStart, 0, dataFileSuffix, false)
+	require.NoError(t, os.Rename(dataFilePath,
+		filepath.Join(tiered.dir, filepath.Base(dataFilePath))))
+	require.NoError(t, ioutil.WriteFile(TieredStubFilePath(dataFilePath), []byte("{}"), 0666))
+
+	s := NewSeeker(filePathPrefix, testReaderBufferSize, testReaderBufferSize,
+		testBytesPool, false, testDefaultOpts.SetTieredStorage(tiered))
+	resources := newTestReusableSeekerResources()
+	require.NoError(t, s.Open(testNs1ID, 0, testWriterStart, 0, resources))
+	require.NoError(t, s.Close())
+	require.Equal(t, []string{dataFilePath}, tiered.fetched)
+}
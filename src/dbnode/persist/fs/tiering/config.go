@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tiering
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+)
+
+const defaultInterval = 10 * time.Minute
+
+// Configuration configures moving the data files of aged fileset volumes to
+// object storage.
+type Configuration struct {
+	// ObjectStorePath is the directory of the object store, for instance a
+	// mounted S3 or GCS bucket.
+	ObjectStorePath string `yaml:"objectStorePath" validate:"nonzero"`
+
+	// Age is the age of block starts after which data files are moved.
+	Age time.Duration `yaml:"age" validate:"nonzero"`
+
+	// CacheMaxBytes is the maximum size of the data files restored from
+	// object storage kept locally.
+	CacheMaxBytes int64 `yaml:"cacheMaxBytes" validate:"min=0"`
+
+	// Interval is the interval data files are moved and evicted at.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// IntervalOrDefault returns the tiering interval or the default.
+func (c Configuration) IntervalOrDefault() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return defaultInterval
+}
+
+// NewTierer returns a new tierer for the filesets of a host.
+func (c Configuration) NewTierer(
+	filePathPrefix string,
+	hostID string,
+	clockOpts clock.Options,
+	instrumentOpts instrument.Options,
+) (Tierer, error) {
+	store, err := objectstore.NewFilesystemStore(c.ObjectStorePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewTierer(NewOptions().
+		SetObjectStore(store).
+		SetFilePathPrefix(filePathPrefix).
+		SetHostID(hostID).
+		SetAge(c.Age).
+		SetCacheMaxBytes(c.CacheMaxBytes).
+		SetClockOptions(clockOpts).
+		SetInstrumentOptions(instrumentOpts))
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tiering
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+)
+
+var (
+	errObjectStoreNotSet    = errors.New("tiering object store not set")
+	errFilePathPrefixNotSet = errors.New("tiering file path prefix not set")
+	errHostIDNotSet         = errors.New("tiering host ID not set")
+	errAgeNotPositive       = errors.New("tiering age must be positive")
+	errCacheMaxBytesInvalid = errors.New("tiering cache max bytes must not be negative")
+)
+
+type options struct {
+	objectStore    objectstore.Store
+	filePathPrefix string
+	hostID         string
+	age            time.Duration
+	cacheMaxBytes  int64
+	clockOpts      clock.Options
+	instrumentOpts instrument.Options
+}
+
+// NewOptions returns new tiering options.
+func NewOptions() Options {
+	return &options{
+		clockOpts:      clock.NewOptions(),
+		instrumentOpts: instrument.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.objectStore == nil {
+		return errObjectStoreNotSet
+	}
+	if o.filePathPrefix == "" {
+		return errFilePathPrefixNotSet
+	}
+	if o.hostID == "" {
+		return errHostIDNotSet
+	}
+	if o.age <= 0 {
+		return errAgeNotPositive
+	}
+	if o.cacheMaxBytes < 0 {
+		return errCacheMaxBytesInvalid
+	}
+	return nil
+}
+
+func (o *options) SetObjectStore(value objectstore.Store) Options {
+	opts := *o
+	opts.objectStore = value
+	return &opts
+}
+
+func (o *options) ObjectStore() objectstore.Store {
+	return o.objectStore
+}
+
+func (o *options) SetFilePathPrefix(value string) Options {
+	opts := *o
+	opts.filePathPrefix = value
+	return &opts
+}
+
+func (o *options) FilePathPrefix() string {
+	return o.filePathPrefix
+}
+
+func (o *options) SetHostID(value string) Options {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *options) HostID() string {
+	return o.hostID
+}
+
+func (o *options) SetAge(value time.Duration) Options {
+	opts := *o
+	opts.age = value
+	return &opts
+}
+
+func (o *options) Age() time.Duration {
+	return o.age
+}
+
+func (o *options) SetCacheMaxBytes(value int64) Options {
+	opts := *o
+	opts.cacheMaxBytes = value
+	return &opts
+}
+
+func (o *options) CacheMaxBytes() int64 {
+	return o.cacheMaxBytes
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
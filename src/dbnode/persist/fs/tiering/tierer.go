@@ -0,0 +1,487 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tiering
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	objectsPrefix        = "tiered"
+	dataFileSuffix       = "data.db"
+	checkpointFileSuffix = "checkpoint.db"
+	stubFileSuffix       = "-remote.db"
+	fetchTempFileSuffix  = ".fetch-tmp"
+	stubTempFileSuffix   = ".tmp"
+
+	// recentAccessWindow is the time a data file is kept locally after it
+	// was accessed, so that readers opening it after restoring it do not
+	// race with its removal.
+	recentAccessWindow = time.Minute
+)
+
+var errTiererClosed = errors.New("tierer is closed")
+
+// stub is the contents of the stub file left in place of a data file moved
+// to object storage.
+type stub struct {
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+	Checksum uint32 `json:"checksum"`
+}
+
+type tiererMetrics struct {
+	uploads     tally.Counter
+	uploadBytes tally.Counter
+	fetches     tally.Counter
+	fetchBytes  tally.Counter
+	fetchErrors tally.Counter
+	evictions   tally.Counter
+	removed     tally.Counter
+	cachedBytes tally.Gauge
+}
+
+func newTiererMetrics(scope tally.Scope) tiererMetrics {
+	return tiererMetrics{
+		uploads:     scope.Counter("uploads"),
+		uploadBytes: scope.Counter("upload-bytes"),
+		fetches:     scope.Counter("fetches"),
+		fetchBytes:  scope.Counter("fetch-bytes"),
+		fetchErrors: scope.Counter("fetch-errors"),
+		evictions:   scope.Counter("evictions"),
+		removed:     scope.Counter("objects-removed"),
+		cachedBytes: scope.Gauge("cached-bytes"),
+	}
+}
+
+type tierer struct {
+	sync.Mutex
+
+	opts    Options
+	store   objectstore.Store
+	nowFn   clock.NowFn
+	logger  *zap.Logger
+	metrics tiererMetrics
+
+	// tierLock serializes tiering passes.
+	tierLock sync.Mutex
+	fetching map[string]chan struct{}
+	accessed map[string]time.Time
+	closed   bool
+	doneCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewTierer returns a new tierer.
+func NewTierer(opts Options) (Tierer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	iOpts := opts.InstrumentOptions()
+	return &tierer{
+		opts:     opts,
+		store:    opts.ObjectStore(),
+		nowFn:    opts.ClockOptions().NowFn(),
+		logger:   iOpts.Logger(),
+		metrics:  newTiererMetrics(iOpts.MetricsScope().SubScope("tiering")),
+		fetching: make(map[string]chan struct{}),
+		accessed: make(map[string]time.Time),
+		doneCh:   make(chan struct{}),
+	}, nil
+}
+
+func (t *tierer) Fetch(dataFilePath string) error {
+	for {
+		t.Lock()
+		if wait, ok := t.fetching[dataFilePath]; ok {
+			// Another reader is restoring the data file, wait and re-check.
+			t.Unlock()
+			<-wait
+			continue
+		}
+		done := make(chan struct{})
+		t.fetching[dataFilePath] = done
+		t.Unlock()
+
+		err := t.fetch(dataFilePath)
+
+		t.Lock()
+		delete(t.fetching, dataFilePath)
+		if err == nil {
+			t.accessed[dataFilePath] = t.nowFn()
+		}
+		t.Unlock()
+		close(done)
+
+		if err != nil {
+			t.metrics.fetchErrors.Inc(1)
+		}
+		return err
+	}
+}
+
+func (t *tierer) fetch(dataFilePath string) error {
+	if _, err := os.Stat(dataFilePath); err == nil || !os.IsNotExist(err) {
+		return err
+	}
+	s, err := readStub(fs.TieredStubFilePath(dataFilePath))
+	if os.IsNotExist(err) {
+		// Not moved to object storage.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	r, err := t.store.Get(s.Key)
+	if err != nil {
+		return fmt.Errorf("unable to get tiered data file %s: %v", s.Key, err)
+	}
+	defer r.Close()
+
+	tempPath := dataFilePath + fetchTempFileSuffix
+	defer os.Remove(tempPath)
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	hash := adler32.New()
+	n, err := io.Copy(io.MultiWriter(f, hash), r)
+	if err == nil && (n != s.Size || hash.Sum32() != s.Checksum) {
+		err = fmt.Errorf("tiered data file %s does not match its stub", s.Key)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, dataFilePath); err != nil {
+		return err
+	}
+
+	t.metrics.fetches.Inc(1)
+	t.metrics.fetchBytes.Inc(n)
+	return nil
+}
+
+type cachedFile struct {
+	path     string
+	size     int64
+	accessed time.Time
+}
+
+func (t *tierer) Tier() error {
+	t.tierLock.Lock()
+	defer t.tierLock.Unlock()
+
+	var (
+		filePathPrefix = t.opts.FilePathPrefix()
+		dataDir        = fs.DataDirPath(filePathPrefix)
+		cutoff         = t.nowFn().Add(-t.opts.Age())
+		referenced     = make(map[string]struct{})
+		cached         []cachedFile
+	)
+	err := filepath.Walk(dataDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == dataDir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !fs.IsDataFilePath(p) {
+			return nil
+		}
+
+		_, err = os.Stat(fs.TieredStubFilePath(p))
+		if err == nil {
+			// Restored from object storage and cached locally.
+			cached = append(cached, cachedFile{
+				path:     p,
+				size:     info.Size(),
+				accessed: info.ModTime(),
+			})
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+
+		blockStart, _, err := fs.TimeAndVolumeIndexFromDataFileSetFilename(p)
+		if err != nil || !blockStart.Before(cutoff) {
+			return nil
+		}
+		checkpointPath := strings.TrimSuffix(p, dataFileSuffix) + checkpointFileSuffix
+		if _, err := os.Stat(checkpointPath); err != nil {
+			// The volume is still being written.
+			return nil
+		}
+		return t.tierDataFile(filePathPrefix, p)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Objects are referenced by the stubs of their data files, stubs are
+	// removed along with the other files of their volumes.
+	err = filepath.Walk(dataDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if p == dataDir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !isStubFilePath(p) {
+			return nil
+		}
+		s, err := readStub(p)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		referenced[s.Key] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	t.evict(cached)
+	return t.removeUnreferenced(referenced)
+}
+
+// tierDataFile uploads a data file to object storage, writes its stub and
+// removes it locally.
+func (t *tierer) tierDataFile(filePathPrefix, dataFilePath string) error {
+	relPath, err := filepath.Rel(filePathPrefix, dataFilePath)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(dataFilePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		key    = path.Join(objectsPrefix, t.opts.HostID(), filepath.ToSlash(relPath))
+		hash   = adler32.New()
+		reader = &countingReader{r: io.TeeReader(f, hash)}
+	)
+	if err := t.store.Put(key, reader); err != nil {
+		return err
+	}
+	s := stub{Key: key, Size: reader.n, Checksum: hash.Sum32()}
+	if err := writeStub(fs.TieredStubFilePath(dataFilePath), s); err != nil {
+		return err
+	}
+	t.metrics.uploads.Inc(1)
+	t.metrics.uploadBytes.Inc(s.Size)
+
+	t.Lock()
+	defer t.Unlock()
+	if t.recentlyAccessedWithLock(dataFilePath) {
+		// Kept as a cached data file until evicted.
+		return nil
+	}
+	return os.Remove(dataFilePath)
+}
+
+// evict removes the least recently accessed data files restored from object
+// storage until the cached data files fit the cache size.
+func (t *tierer) evict(cached []cachedFile) {
+	t.Lock()
+	defer t.Unlock()
+
+	var total int64
+	for i, f := range cached {
+		if accessed, ok := t.accessed[f.path]; ok {
+			cached[i].accessed = accessed
+		}
+		total += f.size
+	}
+	sort.Slice(cached, func(i, j int) bool {
+		return cached[i].accessed.Before(cached[j].accessed)
+	})
+	for _, f := range cached {
+		if total <= t.opts.CacheMaxBytes() {
+			break
+		}
+		if t.recentlyAccessedWithLock(f.path) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			t.logger.Warn("unable to evict tiered data file",
+				zap.String("path", f.path), zap.Error(err))
+			continue
+		}
+		delete(t.accessed, f.path)
+		total -= f.size
+		t.metrics.evictions.Inc(1)
+	}
+	t.metrics.cachedBytes.Update(float64(total))
+
+	// Forget data files that were removed by cleanups.
+	now := t.nowFn()
+	for p, accessed := range t.accessed {
+		if now.Sub(accessed) < recentAccessWindow {
+			continue
+		}
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			delete(t.accessed, p)
+		}
+	}
+}
+
+// removeUnreferenced removes the objects of volumes that were removed locally.
+func (t *tierer) removeUnreferenced(referenced map[string]struct{}) error {
+	objects, err := t.store.List(path.Join(objectsPrefix, t.opts.HostID()) + "/")
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if _, ok := referenced[obj.Key]; ok {
+			continue
+		}
+		if err := t.store.Delete(obj.Key); err != nil {
+			return err
+		}
+		t.metrics.removed.Inc(1)
+	}
+	return nil
+}
+
+func (t *tierer) recentlyAccessedWithLock(dataFilePath string) bool {
+	if _, ok := t.fetching[dataFilePath]; ok {
+		return true
+	}
+	accessed, ok := t.accessed[dataFilePath]
+	return ok && t.nowFn().Sub(accessed) < recentAccessWindow
+}
+
+func (t *tierer) Start(interval time.Duration) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.doneCh:
+				return
+			case <-ticker.C:
+				if err := t.Tier(); err != nil {
+					t.logger.Error("unable to tier data files", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (t *tierer) Close() error {
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return errTiererClosed
+	}
+	t.closed = true
+	t.Unlock()
+
+	close(t.doneCh)
+	t.wg.Wait()
+	return nil
+}
+
+func isStubFilePath(p string) bool {
+	return strings.HasSuffix(p, stubFileSuffix)
+}
+
+func readStub(stubPath string) (stub, error) {
+	data, err := ioutil.ReadFile(stubPath)
+	if err != nil {
+		return stub{}, err
+	}
+	var s stub
+	if err := json.Unmarshal(data, &s); err != nil {
+		return stub{}, fmt.Errorf("invalid tiered stub file %s: %v", stubPath, err)
+	}
+	return s, nil
+}
+
+func writeStub(stubPath string, s stub) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tempPath := stubPath + stubTempFileSuffix
+	defer os.Remove(tempPath)
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, bytes.NewReader(data))
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempPath, stubPath)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
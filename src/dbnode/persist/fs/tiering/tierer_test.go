@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tiering
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/objectstore"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestVolume(t *testing.T, shardDir string, blockStart time.Time, complete bool) string {
+	require.NoError(t, os.MkdirAll(shardDir, 0755))
+	name := func(suffix string) string {
+		return filepath.Join(shardDir,
+			fmt.Sprintf("fileset-%d-0-%s.db", blockStart.UnixNano(), suffix))
+	}
+	require.NoError(t, ioutil.WriteFile(name("info"), []byte("info"), 0666))
+	require.NoError(t, ioutil.WriteFile(name("data"), []byte("data-"+blockStart.String()), 0666))
+	if complete {
+		require.NoError(t, ioutil.WriteFile(name("checkpoint"), []byte("checkpoint"), 0666))
+	}
+	return name("data")
+}
+
+func TestTierer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tiering")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		filePathPrefix = filepath.Join(dir, "m3db")
+		shardDir       = filepath.Join(filePathPrefix, "data", "metrics", "0")
+		now            = time.Unix(1600000000, 0)
+		nowFn          = func() time.Time { return now }
+		old            = writeTestVolume(t, shardDir, now.Add(-72*time.Hour), true)
+		oldIncomplete  = writeTestVolume(t, shardDir, now.Add(-70*time.Hour), false)
+		recent         = writeTestVolume(t, shardDir, now.Add(-2*time.Hour), true)
+	)
+	store, err := objectstore.NewFilesystemStore(filepath.Join(dir, "bucket"))
+	require.NoError(t, err)
+
+	tierer, err := NewTierer(NewOptions().
+		SetObjectStore(store).
+		SetFilePathPrefix(filePathPrefix).
+		SetHostID("host0").
+		SetAge(48 * time.Hour).
+		SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time {
+			return nowFn()
+		})))
+	require.NoError(t, err)
+
+	require.NoError(t, tierer.Tier())
+
+	// Only the data file of the aged complete volume is moved.
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(fs.TieredStubFilePath(old))
+	assert.NoError(t, err)
+	for _, p := range []string{oldIncomplete, recent} {
+		_, err = os.Stat(p)
+		assert.NoError(t, err)
+	}
+	objects, err := store.List("")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	// Fetching restores the data file.
+	require.NoError(t, tierer.Fetch(old))
+	data, err := ioutil.ReadFile(old)
+	require.NoError(t, err)
+	assert.Equal(t, "data-"+now.Add(-72*time.Hour).String(), string(data))
+	require.NoError(t, tierer.Fetch(old))
+	require.NoError(t, tierer.Fetch(recent))
+
+	// Recently accessed data files are not evicted.
+	require.NoError(t, tierer.Tier())
+	_, err = os.Stat(old)
+	assert.NoError(t, err)
+
+	now = now.Add(2 * recentAccessWindow)
+	require.NoError(t, tierer.Tier())
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	objects, err = store.List("")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	// Objects of volumes removed locally are removed.
+	require.NoError(t, os.Remove(fs.TieredStubFilePath(old)))
+	require.NoError(t, tierer.Tier())
+	objects, err = store.List("")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestTiererFetchCorruptObject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tiering")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		filePathPrefix = filepath.Join(dir, "m3db")
+		shardDir       = filepath.Join(filePathPrefix, "data", "metrics", "0")
+		now            = time.Unix(1600000000, 0)
+		dataFilePath   = writeTestVolume(t, shardDir, now.Add(-72*time.Hour), true)
+	)
+	store, err := objectstore.NewFilesystemStore(filepath.Join(dir, "bucket"))
+	require.NoError(t, err)
+
+	tierer, err := NewTierer(NewOptions().
+		SetObjectStore(store).
+		SetFilePathPrefix(filePathPrefix).
+		SetHostID("host0").
+		SetAge(48 * time.Hour).
+		SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time {
+			return now
+		})))
+	require.NoError(t, err)
+	require.NoError(t, tierer.Tier())
+
+	s, err := readStub(fs.TieredStubFilePath(dataFilePath))
+	require.NoError(t, err)
+	objectPath := filepath.Join(dir, "bucket", filepath.FromSlash(s.Key))
+	require.NoError(t, ioutil.WriteFile(objectPath, []byte("corrupt"), 0666))
+
+	require.Error(t, tierer.Fetch(dataFilePath))
+	_, err = os.Stat(dataFilePath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTiererStartClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tiering")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := objectstore.NewFilesystemStore(dir)
+	require.NoError(t, err)
+	tierer, err := NewTierer(NewOptions().
+		SetObjectStore(store).
+		SetFilePathPrefix(filepath.Join(dir, "m3db")).
+		SetHostID("host0").
+		SetAge(time.Hour))
+	require.NoError(t, err)
+
+	tierer.Start(time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, tierer.Close())
+	require.Error(t, tierer.Close())
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tiering moves the data files of aged fileset volumes to object
+// storage and restores them on demand.
+package tiering
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/objectstore"
+)
+
+// Tierer moves the data files of fileset volumes with block starts older
+// than an age to object storage. The info, index, summaries, bloom filter,
+// digest and checkpoint files stay local so that volumes can still be
+// bootstrapped and looked up, a stub file is left in place of each data file.
+// Data files are restored on demand when their data is read and cached
+// locally until evicted.
+type Tierer interface {
+	fs.TieredStorage
+
+	// Tier moves the data files of aged volumes to object storage, evicts
+	// cached data files beyond the cache size and removes the objects of
+	// volumes that were removed locally.
+	Tier() error
+
+	// Start tiers every interval in the background until closed.
+	Start(interval time.Duration)
+
+	// Close stops tiering in the background.
+	Close() error
+}
+
+// Options are the options of a tierer.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetObjectStore sets the object store data files are moved to.
+	SetObjectStore(value objectstore.Store) Options
+
+	// ObjectStore returns the object store data files are moved to.
+	ObjectStore() objectstore.Store
+
+	// SetFilePathPrefix sets the file path prefix of the filesets.
+	SetFilePathPrefix(value string) Options
+
+	// FilePathPrefix returns the file path prefix of the filesets.
+	FilePathPrefix() string
+
+	// SetHostID sets the ID of the host, which prefixes the object keys.
+	SetHostID(value string) Options
+
+	// HostID returns the ID of the host, which prefixes the object keys.
+	HostID() string
+
+	// SetAge sets the age of block starts after which data files are moved to
+	// object storage.
+	SetAge(value time.Duration) Options
+
+	// Age returns the age of block starts after which data files are moved to
+	// object storage.
+	Age() time.Duration
+
+	// SetCacheMaxBytes sets the maximum size of the data files restored from
+	// object storage kept locally, zero to evict all restored data files when
+	// tiering.
+	SetCacheMaxBytes(value int64) Options
+
+	// CacheMaxBytes returns the maximum size of the data files restored from
+	// object storage kept locally.
+	CacheMaxBytes() int64
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+}
@@ -40,6 +40,7 @@ import (
 	idxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/mmap"
@@ -488,6 +489,37 @@ type Options interface {
 
 	// MmapReporter returns the mmap reporter.
 	MmapReporter() mmap.Reporter
+
+	// SetReadMode sets the mode used by readers to read fileset index and data files.
+	SetReadMode(value ReadMode) Options
+
+	// ReadMode returns the mode used by readers to read fileset index and data files.
+	ReadMode() ReadMode
+
+	// SetTieredStorage sets the tiered storage data files of aged volumes are
+	// moved to, nil if data files are only stored locally.
+	SetTieredStorage(value TieredStorage) Options
+
+	// TieredStorage returns the tiered storage data files of aged volumes are
+	// moved to, nil if data files are only stored locally.
+	TieredStorage() TieredStorage
+
+	// SetFaultInjector sets the fault injector applied to file syncs, nil if
+	// faults are not injected.
+	SetFaultInjector(value *fault.Injector) Options
+
+	// FaultInjector returns the fault injector applied to file syncs, nil if
+	// faults are not injected.
+	FaultInjector() *fault.Injector
+}
+
+// TieredStorage restores the data files of fileset volumes that were moved to
+// object storage, leaving a stub file in their place.
+type TieredStorage interface {
+	// Fetch restores a data file moved to object storage to its local path,
+	// caching it locally until evicted. It is a no-op if the data file exists
+	// locally or was not moved to object storage.
+	Fetch(dataFilePath string) error
 }
 
 // BlockRetrieverOptions represents the options for block retrieval
@@ -574,6 +606,7 @@ type NewMergerFn func(
 	encoderPool encoding.EncoderPool,
 	contextPool context.Pool,
 	nsOpts namespace.Options,
+	tombstones *namespace.SeriesTombstones,
 ) Merger
 
 // Segments represents on index segments on disk for an index volume.
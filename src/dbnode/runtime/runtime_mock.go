@@ -28,6 +28,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/ratelimit"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/x/close"
@@ -408,6 +409,104 @@ func (mr *MockOptionsMockRecorder) IndexDefaultQueryTimeout() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexDefaultQueryTimeout", reflect.TypeOf((*MockOptions)(nil).IndexDefaultQueryTimeout))
 }
 
+// SetIndexMaxQueryTermsLimit mocks base method
+func (m *MockOptions) SetIndexMaxQueryTermsLimit(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIndexMaxQueryTermsLimit", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetIndexMaxQueryTermsLimit indicates an expected call of SetIndexMaxQueryTermsLimit
+func (mr *MockOptionsMockRecorder) SetIndexMaxQueryTermsLimit(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIndexMaxQueryTermsLimit", reflect.TypeOf((*MockOptions)(nil).SetIndexMaxQueryTermsLimit), value)
+}
+
+// IndexMaxQueryTermsLimit mocks base method
+func (m *MockOptions) IndexMaxQueryTermsLimit() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexMaxQueryTermsLimit")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// IndexMaxQueryTermsLimit indicates an expected call of IndexMaxQueryTermsLimit
+func (mr *MockOptionsMockRecorder) IndexMaxQueryTermsLimit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexMaxQueryTermsLimit", reflect.TypeOf((*MockOptions)(nil).IndexMaxQueryTermsLimit))
+}
+
+// SetNamespaceStates mocks base method
+func (m *MockOptions) SetNamespaceStates(value map[string]namespace.State) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNamespaceStates", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetNamespaceStates indicates an expected call of SetNamespaceStates
+func (mr *MockOptionsMockRecorder) SetNamespaceStates(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNamespaceStates", reflect.TypeOf((*MockOptions)(nil).SetNamespaceStates), value)
+}
+
+// NamespaceStates mocks base method
+func (m *MockOptions) NamespaceStates() map[string]namespace.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceStates")
+	ret0, _ := ret[0].(map[string]namespace.State)
+	return ret0
+}
+
+// NamespaceStates indicates an expected call of NamespaceStates
+func (mr *MockOptionsMockRecorder) NamespaceStates() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceStates", reflect.TypeOf((*MockOptions)(nil).NamespaceStates))
+}
+
+// NamespaceState mocks base method
+func (m *MockOptions) NamespaceState(id string) namespace.State {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceState", id)
+	ret0, _ := ret[0].(namespace.State)
+	return ret0
+}
+
+// NamespaceState indicates an expected call of NamespaceState
+func (mr *MockOptionsMockRecorder) NamespaceState(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceState", reflect.TypeOf((*MockOptions)(nil).NamespaceState), id)
+}
+
+// SetSeriesTombstones mocks base method
+func (m *MockOptions) SetSeriesTombstones(value *namespace.SeriesTombstones) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSeriesTombstones", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetSeriesTombstones indicates an expected call of SetSeriesTombstones
+func (mr *MockOptionsMockRecorder) SetSeriesTombstones(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSeriesTombstones", reflect.TypeOf((*MockOptions)(nil).SetSeriesTombstones), value)
+}
+
+// SeriesTombstones mocks base method
+func (m *MockOptions) SeriesTombstones() *namespace.SeriesTombstones {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SeriesTombstones")
+	ret0, _ := ret[0].(*namespace.SeriesTombstones)
+	return ret0
+}
+
+// SeriesTombstones indicates an expected call of SeriesTombstones
+func (mr *MockOptionsMockRecorder) SeriesTombstones() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeriesTombstones", reflect.TypeOf((*MockOptions)(nil).SeriesTombstones))
+}
+
 // MockOptionsManager is a mock of OptionsManager interface
 type MockOptionsManager struct {
 	ctrl     *gomock.Controller
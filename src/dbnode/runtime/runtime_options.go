@@ -24,6 +24,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/ratelimit"
 	"github.com/m3db/m3/src/dbnode/topology"
 )
@@ -75,6 +76,9 @@ type options struct {
 	clientReadConsistencyLevel           topology.ReadConsistencyLevel
 	clientWriteConsistencyLevel          topology.ConsistencyLevel
 	indexDefaultQueryTimeout             time.Duration
+	indexMaxQueryTermsLimit              int
+	namespaceStates                      map[string]namespace.State
+	seriesTombstones                     *namespace.SeriesTombstones
 }
 
 // NewOptions creates a new set of runtime options with defaults
@@ -239,3 +243,41 @@ func (o *options) SetIndexDefaultQueryTimeout(value time.Duration) Options {
 func (o *options) IndexDefaultQueryTimeout() time.Duration {
 	return o.indexDefaultQueryTimeout
 }
+
+func (o *options) SetIndexMaxQueryTermsLimit(value int) Options {
+	opts := *o
+	opts.indexMaxQueryTermsLimit = value
+	return &opts
+}
+
+func (o *options) IndexMaxQueryTermsLimit() int {
+	return o.indexMaxQueryTermsLimit
+}
+
+func (o *options) SetNamespaceStates(value map[string]namespace.State) Options {
+	opts := *o
+	opts.namespaceStates = value
+	return &opts
+}
+
+func (o *options) NamespaceStates() map[string]namespace.State {
+	return o.namespaceStates
+}
+
+func (o *options) NamespaceState(id string) namespace.State {
+	state, ok := o.namespaceStates[id]
+	if !ok {
+		return namespace.StateActive
+	}
+	return state
+}
+
+func (o *options) SetSeriesTombstones(value *namespace.SeriesTombstones) Options {
+	opts := *o
+	opts.seriesTombstones = value
+	return &opts
+}
+
+func (o *options) SeriesTombstones() *namespace.SeriesTombstones {
+	return o.seriesTombstones
+}
@@ -23,6 +23,8 @@ package runtime
 import (
 	"testing"
 
+	"github.com/m3db/m3/src/dbnode/namespace"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,3 +32,14 @@ func TestRuntimeOptionsDefaultsIsValid(t *testing.T) {
 	v := NewOptions()
 	assert.NoError(t, v.Validate())
 }
+
+func TestRuntimeOptionsNamespaceState(t *testing.T) {
+	v := NewOptions()
+	assert.Equal(t, namespace.StateActive, v.NamespaceState("metrics"))
+
+	v = v.SetNamespaceStates(map[string]namespace.State{
+		"metrics": namespace.StateReadOnly,
+	})
+	assert.Equal(t, namespace.StateReadOnly, v.NamespaceState("metrics"))
+	assert.Equal(t, namespace.StateActive, v.NamespaceState("other"))
+}
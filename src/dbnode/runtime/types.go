@@ -23,6 +23,7 @@ package runtime
 import (
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/ratelimit"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xclose "github.com/m3db/m3/src/x/close"
@@ -168,6 +169,31 @@ type Options interface {
 	// IndexDefaultQueryTimeout is the hard timeout value to use if none is
 	// specified for a specific query, zero specifies to use no timeout at all.
 	IndexDefaultQueryTimeout() time.Duration
+
+	// SetIndexMaxQueryTermsLimit sets the max terms an index query may scan in
+	// each index block before returning partial results, zero specifies no limit.
+	SetIndexMaxQueryTermsLimit(value int) Options
+
+	// IndexMaxQueryTermsLimit returns the max terms an index query may scan in
+	// each index block before returning partial results, zero specifies no limit.
+	IndexMaxQueryTermsLimit() int
+
+	// SetNamespaceStates sets the states of namespaces by namespace ID, which
+	// gate the writes and reads they accept, namespaces not set are active.
+	SetNamespaceStates(value map[string]namespace.State) Options
+
+	// NamespaceStates returns the states of namespaces by namespace ID.
+	NamespaceStates() map[string]namespace.State
+
+	// NamespaceState returns the state of a namespace.
+	NamespaceState(id string) namespace.State
+
+	// SetSeriesTombstones sets the series tombstones, the datapoints they
+	// delete are filtered from reads and purged when blocks are merged.
+	SetSeriesTombstones(value *namespace.SeriesTombstones) Options
+
+	// SeriesTombstones returns the series tombstones.
+	SeriesTombstones() *namespace.SeriesTombstones
 }
 
 // OptionsManager updates and supplies runtime options.
@@ -63,7 +63,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cardinality"
 	"github.com/m3db/m3/src/dbnode/storage/cluster"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/stats"
@@ -81,9 +83,12 @@ import (
 	xcontext "github.com/m3db/m3/src/x/context"
 	xdebug "github.com/m3db/m3/src/x/debug"
 	xdocs "github.com/m3db/m3/src/x/docs"
+	"github.com/m3db/m3/src/x/fault"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/lockfile"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/mmap"
 	xos "github.com/m3db/m3/src/x/os"
 	"github.com/m3db/m3/src/x/pool"
@@ -109,9 +114,13 @@ const (
 	skipRaiseProcessLimitsEnvVar     = "SKIP_PROCESS_LIMITS_RAISE"
 	skipRaiseProcessLimitsEnvVarTrue = "true"
 	mmapReporterMetricName           = "mmap-mapped-bytes"
+	mmapReporterMapsMetricName       = "mmap-maps"
+	mmapReporterUnmapsMetricName     = "mmap-unmaps"
 	mmapReporterTagName              = "map-name"
 )
 
+var errNotBootstrapped = errors.New("database not bootstrapped")
+
 // RunOptions provides options for running the server
 // with backwards compatibility if only solely adding fields.
 type RunOptions struct {
@@ -233,6 +242,20 @@ func Run(runOpts RunOptions) {
 		logger.Fatal("could not connect to metrics", zap.Error(err))
 	}
 
+	var gcTuner memory.GCTuner
+	if gcCfg := cfg.GCTuning; gcCfg != nil {
+		gcTuner, err = gcCfg.NewGCTuner(instrument.NewOptions().
+			SetLogger(logger).
+			SetMetricsScope(scope))
+		if err != nil {
+			logger.Fatal("unable to create gc tuner", zap.Error(err))
+		}
+		if err := gcTuner.Start(); err != nil {
+			logger.Fatal("unable to start gc tuner", zap.Error(err))
+		}
+		defer gcTuner.Stop()
+	}
+
 	hostID, err := cfg.HostID.Resolve()
 	if err != nil {
 		logger.Fatal("could not resolve local host ID", zap.Error(err))
@@ -347,6 +370,18 @@ func Run(runOpts RunOptions) {
 		opts = opts.SetMemoryTracker(memTracker)
 	}
 
+	if memCfg := cfg.Limits.Memory; memCfg != nil {
+		memMonitor, err := memCfg.NewMonitor(iopts)
+		if err != nil {
+			logger.Fatal("unable to create memory monitor", zap.Error(err))
+		}
+		if err := memMonitor.Start(); err != nil {
+			logger.Fatal("unable to start memory monitor", zap.Error(err))
+		}
+		defer memMonitor.Stop()
+		opts = opts.SetMemoryMonitor(memMonitor)
+	}
+
 	opentracing.SetGlobalTracer(tracer)
 
 	if cfg.Index.MaxQueryIDsConcurrency != 0 {
@@ -389,7 +424,8 @@ func Run(runOpts RunOptions) {
 			SetLimitMbps(cfg.Filesystem.ThroughputLimitMbpsOrDefault()).
 			SetLimitCheckEvery(cfg.Filesystem.ThroughputCheckEveryOrDefault())).
 		SetWriteNewSeriesAsync(cfg.WriteNewSeriesAsync).
-		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration)
+		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration).
+		SetIndexMaxQueryTermsLimit(cfg.Limits.MaxIndexQueryTerms)
 	if lruCfg := cfg.Cache.SeriesConfiguration().LRU; lruCfg != nil {
 		runtimeOpts = runtimeOpts.SetMaxWiredBlocks(lruCfg.MaxBlocks)
 	}
@@ -448,6 +484,25 @@ func Run(runOpts RunOptions) {
 		SetQueryStats(queryStats)
 	opts = opts.SetIndexOptions(indexOpts)
 
+	if cfg.IndexCardinality != nil {
+		cardinalityEstimator, err := cfg.IndexCardinality.NewEstimator(iopts)
+		if err != nil {
+			logger.Fatal("could not create cardinality estimator", zap.Error(err))
+		}
+		opts = opts.SetCardinalityEstimator(cardinalityEstimator)
+	}
+
+	if cfg.HotShards != nil {
+		opts = opts.SetHotShardDetector(
+			cfg.HotShards.NewDetector(iopts, opts.ClockOptions()))
+	}
+
+	var faultInjector *fault.Injector
+	if cfg.FaultInjection != nil {
+		logger.Warn("fault injection enabled, do not use in production")
+		faultInjector = cfg.FaultInjection.NewInjector()
+	}
+
 	if tick := cfg.Tick; tick != nil {
 		runtimeOpts = runtimeOpts.
 			SetTickSeriesBatchSize(tick.SeriesBatchSize).
@@ -502,7 +557,20 @@ func Run(runOpts RunOptions) {
 		SetForceIndexSummariesMmapMemory(cfg.Filesystem.ForceIndexSummariesMmapMemoryOrDefault()).
 		SetForceBloomFilterMmapMemory(cfg.Filesystem.ForceBloomFilterMmapMemoryOrDefault()).
 		SetIndexBloomFilterFalsePositivePercent(cfg.Filesystem.BloomFilterFalsePositivePercentOrDefault()).
-		SetMmapReporter(mmapReporter)
+		SetMmapReporter(mmapReporter).
+		SetReadMode(cfg.Filesystem.ReadModeOrDefault()).
+		SetFaultInjector(faultInjector)
+
+	if cfg.Tiering != nil {
+		tierer, err := cfg.Tiering.NewTierer(fsopts.FilePathPrefix(), hostID,
+			opts.ClockOptions(), iopts)
+		if err != nil {
+			logger.Fatal("could not create tierer", zap.Error(err))
+		}
+		fsopts = fsopts.SetTieredStorage(tierer)
+		tierer.Start(cfg.Tiering.IntervalOrDefault())
+		defer tierer.Close()
+	}
 
 	var commitLogQueueSize int
 	specified := cfg.CommitLog.Queue.Size
@@ -539,6 +607,17 @@ func Run(runOpts RunOptions) {
 	// Apply pooling options.
 	opts = withEncodingAndPoolingOptions(cfg, logger, opts, cfg.PoolingPolicy)
 
+	if cfg.FlushDownsample != nil {
+		// The database is set as the writer of the downsampler once it has
+		// been constructed.
+		downsampler, err := cfg.FlushDownsample.NewDownsampler(
+			opts.ReaderIteratorPool(), iopts)
+		if err != nil {
+			logger.Fatal("could not create flush downsampler", zap.Error(err))
+		}
+		opts = opts.SetFlushDownsampler(downsampler)
+	}
+
 	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
 		SetInstrumentOptions(opts.InstrumentOptions()).
 		SetFilesystemOptions(fsopts).
@@ -633,7 +712,9 @@ func Run(runOpts RunOptions) {
 		SetTagDecoderPool(tagDecoderPool).
 		SetCheckedBytesWrapperPool(opts.CheckedBytesWrapperPool()).
 		SetMaxOutstandingWriteRequests(cfg.Limits.MaxOutstandingWriteRequests).
-		SetMaxOutstandingReadRequests(cfg.Limits.MaxOutstandingReadRequests)
+		SetMaxOutstandingReadRequests(cfg.Limits.MaxOutstandingReadRequests).
+		SetMaxFetchTaggedResultBytes(cfg.Limits.MaxFetchTaggedResultBytes).
+		SetMaxFetchTaggedDuration(cfg.Limits.MaxFetchTaggedDuration)
 
 	// Start servers before constructing the DB so orchestration tools can check health endpoints
 	// before topology is set.
@@ -649,7 +730,8 @@ func Run(runOpts RunOptions) {
 		tchannelOpts.IdleCheckInterval = cfg.TChannel.IdleCheckInterval
 	}
 	tchanOpts := ttnode.NewOptions(tchannelOpts).
-		SetInstrumentOptions(opts.InstrumentOptions())
+		SetInstrumentOptions(opts.InstrumentOptions()).
+		SetFaultInjector(faultInjector)
 	if fn := runOpts.StorageOptions.TChanNodeServerFn; fn != nil {
 		tchanOpts = tchanOpts.SetTChanNodeServerFn(fn)
 	}
@@ -671,6 +753,13 @@ func Run(runOpts RunOptions) {
 	defer httpjsonNodeClose()
 	logger.Info("node httpjson: listening", zap.String("address", cfg.HTTPNodeListenAddress))
 
+	healthRegistry := health.NewRegistry(health.NewOptions().
+		SetInstrumentOptions(iopts))
+	if err := healthRegistry.Register("kv", health.Readiness,
+		util.NewHealthCheck(syncCfg.KVStore)); err != nil {
+		logger.Fatal("could not register kv health check", zap.Error(err))
+	}
+
 	if cfg.DebugListenAddress != "" {
 		var debugWriter xdebug.ZipWriter
 		handlerOpts, err := placement.NewHandlerOptions(syncCfg.ClusterClient,
@@ -704,8 +793,27 @@ func Run(runOpts RunOptions) {
 			}
 		}
 
+		cardinalityEstimator := opts.CardinalityEstimator()
+		hotShardDetector := opts.HotShardDetector()
 		go func() {
-			mux := http.DefaultServeMux
+			mux := http.NewServeMux()
+			mux.Handle("/", http.DefaultServeMux)
+			mux.Handle(health.LivenessURL,
+				health.NewHandler(healthRegistry, health.Liveness))
+			mux.Handle(health.ReadinessURL,
+				health.NewHandler(healthRegistry, health.Readiness))
+			if cardinalityEstimator != nil {
+				mux.Handle(cardinality.HandlerURL,
+					cardinality.NewHandler(cardinalityEstimator, logger))
+			}
+			if hotShardDetector != nil {
+				mux.Handle(hotshards.HandlerURL,
+					hotshards.NewHandler(hotShardDetector, logger))
+			}
+			if faultInjector != nil {
+				mux.Handle(fault.HandlerURL,
+					fault.NewHandler(faultInjector, logger))
+			}
 			if debugWriter != nil {
 				if err := debugWriter.RegisterHandler(xdebug.DebugURL, mux); err != nil {
 					logger.Error("unable to register debug writer endpoint", zap.Error(err))
@@ -825,6 +933,14 @@ func Run(runOpts RunOptions) {
 		opts = opts.SetRepairEnabled(false)
 	}
 
+	if cfg.Scrub != nil {
+		opts = opts.SetScrubOptions(storage.ScrubOptions{
+			Enabled:       cfg.Scrub.Enabled,
+			Interval:      cfg.Scrub.Interval,
+			RepairCorrupt: cfg.Scrub.RepairCorrupt,
+		})
+	}
+
 	if runOpts.StorageOptions.OnColdFlush != nil {
 		opts = opts.SetOnColdFlush(runOpts.StorageOptions.OnColdFlush)
 	}
@@ -885,6 +1001,9 @@ func Run(runOpts RunOptions) {
 			}
 		})
 
+	kvWatchNamespaceStates(syncCfg.KVStore, logger, runtimeOptsMgr)
+	kvWatchSeriesTombstones(syncCfg.KVStore, logger, runtimeOptsMgr)
+
 	// Start the cluster services now that the M3DB client is available.
 	tchannelthriftClusterClose, err := ttcluster.NewServer(m3dbClient,
 		cfg.ClusterListenAddress, contextPool, tchannelOpts).ListenAndServe()
@@ -921,6 +1040,9 @@ func Run(runOpts RunOptions) {
 	leaseVerifier := storage.NewLeaseVerifier(db)
 	blockLeaseManager.SetLeaseVerifier(leaseVerifier)
 
+	// Likewise the database writes the datapoints of downsampled blocks.
+	opts.FlushDownsampler().SetWriter(db)
+
 	if err := db.Open(); err != nil {
 		logger.Fatal("could not open database", zap.Error(err))
 	}
@@ -928,6 +1050,37 @@ func Run(runOpts RunOptions) {
 	// Now that we've initialized the database we can set it on the service.
 	service.SetDatabase(db)
 
+	if cfg.Capacity != nil {
+		capacityReporter := storage.NewCapacityReporter(db, syncCfg.KVStore, hostID)
+		capacityReporter.Start(cfg.Capacity.ReportIntervalOrDefault())
+		defer capacityReporter.Close()
+	}
+
+	readyPercent := cfg.Bootstrap.ReadyPercentOrDefault()
+	if readyPercent < 0 || readyPercent > 100 {
+		logger.Fatal("bootstrap ready percent must be between 0 and 100",
+			zap.Float64("readyPercent", readyPercent))
+	}
+	if err := healthRegistry.Register("bootstrap", health.Readiness,
+		func(context.Context) error {
+			if db.IsBootstrapped() {
+				return nil
+			}
+			progress := storage.NewBootstrapProgress(db.BootstrapState())
+			if len(progress.Namespaces) == 0 {
+				return errNotBootstrapped
+			}
+			err := progress.CheckPercent(readyPercent)
+			if err == nil && readyPercent >= 100 {
+				// NB: require the database to complete bootstrapping, not
+				// just its current shards.
+				return errNotBootstrapped
+			}
+			return err
+		}); err != nil {
+		logger.Fatal("could not register bootstrap health check", zap.Error(err))
+	}
+
 	go func() {
 		if runOpts.BootstrapCh != nil {
 			// Notify on bootstrap chan if specified.
@@ -937,9 +1090,15 @@ func Run(runOpts RunOptions) {
 		}
 
 		// Bootstrap asynchronously so we can handle interrupt.
+		if gcTuner != nil {
+			gcTuner.SetPhase(memory.PhaseBootstrap)
+		}
 		if err := db.Bootstrap(); err != nil {
 			logger.Fatal("could not bootstrap database", zap.Error(err))
 		}
+		if gcTuner != nil {
+			gcTuner.SetPhase(memory.PhaseSteadyState)
+		}
 		logger.Info("bootstrapped")
 
 		// Only set the write new series limit after bootstrapping
@@ -1133,6 +1292,48 @@ func kvWatchClientConsistencyLevels(
 		})
 }
 
+func kvWatchNamespaceStates(
+	store kv.Store,
+	logger *zap.Logger,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.NamespaceStatesKey,
+		func(value string) error {
+			states, err := namespace.ParseStates(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetNamespaceStates(states))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetNamespaceStates(nil))
+		})
+}
+
+func kvWatchSeriesTombstones(
+	store kv.Store,
+	logger *zap.Logger,
+	runtimeOptsMgr m3dbruntime.OptionsManager,
+) {
+	kvWatchStringValue(store, logger,
+		kvconfig.SeriesTombstonesKey,
+		func(value string) error {
+			tombstones, err := namespace.ParseSeriesTombstones(value)
+			if err != nil {
+				return err
+			}
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetSeriesTombstones(tombstones))
+		},
+		func() error {
+			return runtimeOptsMgr.Update(runtimeOptsMgr.Get().
+				SetSeriesTombstones(nil))
+		})
+}
+
 func kvWatchStringValue(
 	store kv.Store,
 	logger *zap.Logger,
@@ -1496,6 +1697,14 @@ func withEncodingAndPoolingOptions(
 		SetRetrieveRequestPool(retrieveRequestPool).
 		SetCheckedBytesWrapperPool(bytesWrapperPool)
 
+	if cfg.TagInterning != nil {
+		tagInterner, err := cfg.TagInterning.NewCache(iopts)
+		if err != nil {
+			logger.Fatal("could not create tag interner", zap.Error(err))
+		}
+		opts = opts.SetTagInterner(tagInterner)
+	}
+
 	blockOpts := opts.DatabaseBlockOptions().
 		SetDatabaseBlockAllocSize(policy.BlockAllocSizeOrDefault()).
 		SetContextPool(contextPool).
@@ -1589,7 +1798,8 @@ func withEncodingAndPoolingOptions(
 		SetAggregateResultsPool(aggregateQueryResultsPool).
 		SetAggregateValuesPool(aggregateQueryValuesPool).
 		SetForwardIndexProbability(cfg.Index.ForwardIndexProbability).
-		SetForwardIndexThreshold(cfg.Index.ForwardIndexThreshold)
+		SetForwardIndexThreshold(cfg.Index.ForwardIndexThreshold).
+		SetFlushMaxSegmentDocs(cfg.Index.FlushMaxSegmentDocs)
 
 	queryResultsPool.Init(func() index.QueryResults {
 		// NB(r): Need to initialize after setting the index opts so
@@ -1815,8 +2025,10 @@ type mmapReporter struct {
 }
 
 type mmapReporterEntry struct {
-	value int64
-	gauge tally.Gauge
+	value  int64
+	gauge  tally.Gauge
+	maps   tally.Counter
+	unmaps tally.Counter
 }
 
 func newMmapReporter(scope tally.Scope) *mmapReporter {
@@ -1874,13 +2086,17 @@ func (r *mmapReporter) ReportMap(ctx mmap.Context) error {
 
 	entry, ok := r.entries[entryKey]
 	if !ok {
+		scope := r.scope.Tagged(entryTags)
 		entry = &mmapReporterEntry{
-			gauge: r.scope.Tagged(entryTags).Gauge(mmapReporterMetricName),
+			gauge:  scope.Gauge(mmapReporterMetricName),
+			maps:   scope.Counter(mmapReporterMapsMetricName),
+			unmaps: scope.Counter(mmapReporterUnmapsMetricName),
 		}
 		r.entries[entryKey] = entry
 	}
 
 	entry.value += ctx.Size
+	entry.maps.Inc(1)
 
 	return nil
 }
@@ -1901,9 +2117,12 @@ func (r *mmapReporter) ReportUnmap(ctx mmap.Context) error {
 	}
 
 	entry.value -= ctx.Size
+	entry.unmaps.Inc(1)
 
 	if entry.value == 0 {
 		// No more similar mmaps active for this context name, garbage collect
+		// and report nothing mapped as the gauge is no longer updated.
+		entry.gauge.Update(0)
 		delete(r.entries, entryKey)
 	}
 
@@ -66,6 +66,7 @@ type options struct {
 	bootstrapIndexNumProcessors int
 	runtimeOptsMgr              runtime.OptionsManager
 	identifierPool              ident.Pool
+	readLimitMbps               float64
 }
 
 // NewOptions creates new bootstrap options
@@ -201,3 +202,13 @@ func (o *options) SetIdentifierPool(value ident.Pool) Options {
 func (o *options) IdentifierPool() ident.Pool {
 	return o.identifierPool
 }
+
+func (o *options) SetReadLimitMbps(value float64) Options {
+	opts := *o
+	opts.readLimitMbps = value
+	return &opts
+}
+
+func (o *options) ReadLimitMbps() float64 {
+	return o.readLimitMbps
+}
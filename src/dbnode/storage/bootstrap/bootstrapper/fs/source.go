@@ -46,6 +46,7 @@ import (
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
+	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
@@ -74,6 +75,7 @@ type fileSystemSource struct {
 	newReaderPoolOpts bootstrapper.NewReaderPoolOptions
 	persistManager    *bootstrapper.SharedPersistManager
 	compactor         *bootstrapper.SharedCompactor
+	readThrottle      *bootstrapper.Throttle
 	metrics           fileSystemSourceMetrics
 }
 
@@ -104,6 +106,7 @@ func newFileSystemSource(opts Options) (bootstrap.Source, error) {
 		compactor: &bootstrapper.SharedCompactor{
 			Compactor: opts.Compactor(),
 		},
+		readThrottle: bootstrapper.NewThrottle(opts.ReadLimitMbps(), time.Now),
 		metrics: fileSystemSourceMetrics{
 			persistedIndexBlocksRead:  scope.Counter("persist-index-blocks-read"),
 			persistedIndexBlocksWrite: scope.Counter("persist-index-blocks-write"),
@@ -347,16 +350,14 @@ func (s *fileSystemSource) loadShardReadersDataIntoShardResult(
 		batch                = docsPool.Get()
 		totalEntries         int
 		totalFulfilledRanges = result.NewShardTimeRanges()
+		resultLock           sync.Mutex
 	)
 	defer docsPool.Put(batch)
 
 	requestedRanges := timeWindowReaders.Ranges
 	remainingRanges := requestedRanges.Copy()
 	shardReaders := timeWindowReaders.Readers
-	for shard, shardReaders := range shardReaders {
-		shard := uint32(shard)
-		readers := shardReaders.Readers
-
+	loadShard := func(shard uint32, readers []fs.DataFileSetReader) {
 		for _, r := range readers {
 			var (
 				timeRange = r.Range()
@@ -435,6 +436,7 @@ func (s *fileSystemSource) loadShardReadersDataIntoShardResult(
 				}
 			}
 
+			resultLock.Lock()
 			if err == nil {
 				fulfilled := result.NewShardTimeRanges().Set(shard, xtime.NewRanges(timeRange))
 				totalFulfilledRanges.AddRanges(fulfilled)
@@ -444,9 +446,34 @@ func (s *fileSystemSource) loadShardReadersDataIntoShardResult(
 					zap.Time("timeRangeStart", timeRange.Start))
 				timesWithErrors = append(timesWithErrors, timeRange.Start)
 			}
+			resultLock.Unlock()
 		}
 	}
 
+	// NB: the index run shares a single index builder which is not safe for
+	// concurrent use, the data run loads each shard's series independently.
+	concurrency := s.opts.BoostrapDataNumProcessors()
+	if run != bootstrapDataRunType || concurrency <= 1 || len(shardReaders) <= 1 {
+		for shard, shardReaders := range shardReaders {
+			loadShard(uint32(shard), shardReaders.Readers)
+		}
+	} else {
+		var (
+			wg      sync.WaitGroup
+			workers = xsync.NewWorkerPool(concurrency)
+		)
+		workers.Init()
+		for shard, shardReaders := range shardReaders {
+			shard, readers := uint32(shard), shardReaders.Readers
+			wg.Add(1)
+			workers.Go(func() {
+				defer wg.Done()
+				loadShard(shard, readers)
+			})
+		}
+		wg.Wait()
+	}
+
 	var (
 		noneRemaining      = remainingRanges.IsEmpty()
 		shouldBuildSegment = run == bootstrapIndexRunType &&
@@ -622,6 +649,9 @@ func (s *fileSystemSource) readNextEntryAndRecordBlock(
 	if err != nil {
 		return fmt.Errorf("error reading data file: %v", err)
 	}
+	if data != nil {
+		s.readThrottle.Wait(data.Len())
+	}
 
 	ref, owned, err := accumulator.CheckoutSeriesWithLock(shardID, id, tagsIter)
 	if err != nil {
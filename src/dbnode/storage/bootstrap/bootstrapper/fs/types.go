@@ -101,4 +101,12 @@ type Options interface {
 
 	// IndexOptions returns the indexing options.
 	IndexOptions() index.Options
+
+	// SetReadLimitMbps sets the limit in megabits per second on reading
+	// fileset data while bootstrapping, zero or less means no limit.
+	SetReadLimitMbps(value float64) Options
+
+	// ReadLimitMbps returns the limit in megabits per second on reading
+	// fileset data while bootstrapping, zero or less means no limit.
+	ReadLimitMbps() float64
 }
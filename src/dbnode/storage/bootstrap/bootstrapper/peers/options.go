@@ -71,6 +71,7 @@ type options struct {
 	fsOpts                      fs.Options
 	indexOpts                   index.Options
 	compactor                   *compaction.Compactor
+	streamLimitMbps             float64
 }
 
 // NewOptions creates new bootstrap options.
@@ -218,3 +219,13 @@ func (o *options) SetIndexOptions(value index.Options) Options {
 func (o *options) IndexOptions() index.Options {
 	return o.indexOpts
 }
+
+func (o *options) SetStreamLimitMbps(value float64) Options {
+	opts := *o
+	opts.streamLimitMbps = value
+	return &opts
+}
+
+func (o *options) StreamLimitMbps() float64 {
+	return o.streamLimitMbps
+}
@@ -59,6 +59,7 @@ type peersSource struct {
 	nowFn          clock.NowFn
 	persistManager *bootstrapper.SharedPersistManager
 	compactor      *bootstrapper.SharedCompactor
+	streamThrottle *bootstrapper.Throttle
 }
 
 type persistenceFlush struct {
@@ -74,16 +75,18 @@ func newPeersSource(opts Options) (bootstrap.Source, error) {
 	}
 
 	iopts := opts.ResultOptions().InstrumentOptions()
+	nowFn := opts.ResultOptions().ClockOptions().NowFn()
 	return &peersSource{
 		opts:  opts,
 		log:   iopts.Logger().With(zap.String("bootstrapper", "peers")),
-		nowFn: opts.ResultOptions().ClockOptions().NowFn(),
+		nowFn: nowFn,
 		persistManager: &bootstrapper.SharedPersistManager{
 			Mgr: opts.PersistManager(),
 		},
 		compactor: &bootstrapper.SharedCompactor{
 			Compactor: opts.Compactor(),
 		},
+		streamThrottle: bootstrapper.NewThrottle(opts.StreamLimitMbps(), nowFn),
 	}, nil
 }
 
@@ -267,6 +270,11 @@ func (s *peersSource) readData(
 	if shouldPersist {
 		concurrency = s.opts.ShardPersistenceConcurrency()
 	}
+	if s.streamThrottle != nil {
+		// NB: the session waits on the throttle as it streams each batch of
+		// blocks so that the limit applies while a shard is being streamed.
+		resultOpts = resultOpts.SetStreamThrottle(s.streamThrottle)
+	}
 
 	s.log.Info("peers bootstrapper bootstrapping shards for ranges",
 		zap.Int("shards", count),
@@ -115,4 +115,11 @@ type Options interface {
 
 	// IndexOptions returns the indexing options.
 	IndexOptions() index.Options
+	// SetStreamLimitMbps sets the limit in megabits per second on streaming
+	// blocks from peers while bootstrapping, zero or less means no limit.
+	SetStreamLimitMbps(value float64) Options
+
+	// StreamLimitMbps returns the limit in megabits per second on streaming
+	// blocks from peers while bootstrapping, zero or less means no limit.
+	StreamLimitMbps() float64
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bootstrapper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+)
+
+const (
+	bytesPerMegabit = 1024 * 1024 / 8
+
+	// maxThrottleBurst is the most throughput unused while idle that can be
+	// used in a burst, so that a later bootstrap does not run unthrottled.
+	maxThrottleBurst = time.Second
+)
+
+// Throttle caps the throughput of bytes read by a bootstrapper, shared by
+// all of its concurrent readers. Callers are slept as needed to keep the
+// average rate since the first read under the limit, allowing at most a
+// second of bursting after being idle. All methods are safe to call on a nil
+// throttle, which does not throttle.
+type Throttle struct {
+	sync.Mutex
+
+	bytesPerSecond float64
+	nowFn          clock.NowFn
+	sleepFn        func(time.Duration)
+
+	start time.Time
+	bytes int64
+}
+
+// NewThrottle returns a new throttle limiting throughput to the given
+// megabits per second, or nil if the limit is not positive.
+func NewThrottle(limitMbps float64, nowFn clock.NowFn) *Throttle {
+	if limitMbps <= 0 {
+		return nil
+	}
+	return &Throttle{
+		bytesPerSecond: limitMbps * bytesPerMegabit,
+		nowFn:          nowFn,
+		sleepFn:        time.Sleep,
+	}
+}
+
+// Wait records the given number of bytes read and sleeps until reading them
+// is within the limit.
+func (t *Throttle) Wait(bytes int) {
+	if t == nil {
+		return
+	}
+
+	t.Lock()
+	now := t.nowFn()
+	if t.start.IsZero() {
+		t.start = now
+	}
+	if target := t.durationWithLock(); now.Sub(t.start)-target > maxThrottleBurst {
+		// Forget the throughput unused while idle beyond the max burst.
+		t.start = now.Add(-target - maxThrottleBurst)
+	}
+	t.bytes += int64(bytes)
+	wait := t.durationWithLock() - now.Sub(t.start)
+	t.Unlock()
+
+	if wait > 0 {
+		t.sleepFn(wait)
+	}
+}
+
+// durationWithLock returns the time reading the bytes read so far takes at
+// the limit.
+func (t *Throttle) durationWithLock() time.Duration {
+	return time.Duration(float64(time.Second) * float64(t.bytes) / t.bytesPerSecond)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bootstrapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	require.Nil(t, NewThrottle(0, time.Now))
+
+	var (
+		now   = time.Now()
+		slept time.Duration
+	)
+	throttle := NewThrottle(8, func() time.Time { return now })
+	throttle.sleepFn = func(d time.Duration) {
+		slept = d
+		now = now.Add(d)
+	}
+
+	// 8Mbps is 1MiB per second.
+	throttle.Wait(1024 * 1024)
+	require.Equal(t, time.Second, slept)
+
+	// Time idle counts towards the limit, up to the max burst.
+	slept = 0
+	now = now.Add(2 * time.Second)
+	throttle.Wait(1024 * 1024)
+	require.Equal(t, time.Duration(0), slept)
+	throttle.Wait(1024 * 1024)
+	require.Equal(t, time.Second, slept)
+
+	var nilThrottle *Throttle
+	nilThrottle.Wait(1024)
+}
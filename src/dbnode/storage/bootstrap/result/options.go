@@ -38,6 +38,7 @@ type options struct {
 	newBlocksLen              int
 	seriesCachePolicy         series.CachePolicy
 	documentsBuilderAllocator DocumentsBuilderAllocator
+	streamThrottle            StreamThrottle
 }
 
 // NewOptions creates new bootstrap options
@@ -111,3 +112,13 @@ func (o *options) SetIndexDocumentsBuilderAllocator(value DocumentsBuilderAlloca
 func (o *options) IndexDocumentsBuilderAllocator() DocumentsBuilderAllocator {
 	return o.documentsBuilderAllocator
 }
+
+func (o *options) SetStreamThrottle(value StreamThrottle) Options {
+	opts := *o
+	opts.streamThrottle = value
+	return &opts
+}
+
+func (o *options) StreamThrottle() StreamThrottle {
+	return o.streamThrottle
+}
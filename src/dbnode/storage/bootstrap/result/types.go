@@ -224,4 +224,18 @@ type Options interface {
 
 	// IndexDocumentsBuilderAllocator returns the index documents builder allocator.
 	IndexDocumentsBuilderAllocator() DocumentsBuilderAllocator
+
+	// SetStreamThrottle sets the throttle of blocks streamed from peers.
+	SetStreamThrottle(value StreamThrottle) Options
+
+	// StreamThrottle returns the throttle of blocks streamed from peers,
+	// nil if streaming is not throttled.
+	StreamThrottle() StreamThrottle
+}
+
+// StreamThrottle throttles the throughput of blocks streamed from peers.
+type StreamThrottle interface {
+	// Wait records the given number of bytes streamed and blocks until
+	// streaming them is within the limit.
+	Wait(bytes int)
 }
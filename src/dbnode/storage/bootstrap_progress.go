@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+)
+
+// NamespaceBootstrapProgress is the progress of bootstrapping the shards of
+// a namespace.
+type NamespaceBootstrapProgress struct {
+	Namespace          string
+	ShardsBootstrapped int
+	ShardsTotal        int
+}
+
+// Percent returns the percentage of the shards of the namespace that are
+// bootstrapped, a namespace with no shards is fully bootstrapped.
+func (p NamespaceBootstrapProgress) Percent() float64 {
+	if p.ShardsTotal == 0 {
+		return 100
+	}
+	return 100 * float64(p.ShardsBootstrapped) / float64(p.ShardsTotal)
+}
+
+// BootstrapProgress is the progress of bootstrapping the database.
+type BootstrapProgress struct {
+	Namespaces []NamespaceBootstrapProgress
+	// ReplayedBytes is the number of commit log bytes replayed.
+	ReplayedBytes uint64
+}
+
+// NewBootstrapProgress returns the bootstrap progress of the given snapshot
+// of the database bootstrap state.
+func NewBootstrapProgress(state DatabaseBootstrapState) BootstrapProgress {
+	progress := BootstrapProgress{
+		Namespaces:    make([]NamespaceBootstrapProgress, 0, len(state.NamespaceBootstrapStates)),
+		ReplayedBytes: commitlog.BytesRead(),
+	}
+	for ns, shards := range state.NamespaceBootstrapStates {
+		nsProgress := NamespaceBootstrapProgress{
+			Namespace:   ns,
+			ShardsTotal: len(shards),
+		}
+		for _, shardState := range shards {
+			if shardState == Bootstrapped {
+				nsProgress.ShardsBootstrapped++
+			}
+		}
+		progress.Namespaces = append(progress.Namespaces, nsProgress)
+	}
+	sort.Slice(progress.Namespaces, func(i, j int) bool {
+		return progress.Namespaces[i].Namespace < progress.Namespaces[j].Namespace
+	})
+	return progress
+}
+
+// CheckPercent returns an error describing the progress unless every
+// namespace has bootstrapped at least the given percentage of its shards.
+func (p BootstrapProgress) CheckPercent(percent float64) error {
+	var pending []string
+	for _, ns := range p.Namespaces {
+		if ns.Percent() >= percent {
+			continue
+		}
+		pending = append(pending, fmt.Sprintf("%s: %d/%d shards",
+			ns.Namespace, ns.ShardsBootstrapped, ns.ShardsTotal))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	return fmt.Errorf("bootstrapped less than %.1f%% of shards (%s), replayed %d commit log bytes",
+		percent, strings.Join(pending, ", "), p.ReplayedBytes)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapProgress(t *testing.T) {
+	progress := NewBootstrapProgress(DatabaseBootstrapState{
+		NamespaceBootstrapStates: NamespaceBootstrapStates{
+			"foo": ShardBootstrapStates{
+				0: Bootstrapped,
+				1: Bootstrapped,
+				2: Bootstrapping,
+				3: BootstrapNotStarted,
+			},
+			"bar": ShardBootstrapStates{
+				0: Bootstrapped,
+			},
+			"baz": ShardBootstrapStates{},
+		},
+	})
+
+	require.Equal(t, 3, len(progress.Namespaces))
+	require.Equal(t, NamespaceBootstrapProgress{
+		Namespace:          "bar",
+		ShardsBootstrapped: 1,
+		ShardsTotal:        1,
+	}, progress.Namespaces[0])
+	require.Equal(t, 100.0, progress.Namespaces[1].Percent())
+	require.Equal(t, "foo", progress.Namespaces[2].Namespace)
+	require.Equal(t, 50.0, progress.Namespaces[2].Percent())
+
+	require.NoError(t, progress.CheckPercent(50))
+	err := progress.CheckPercent(75)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "foo: 2/4 shards")
+	require.NotContains(t, err.Error(), "bar")
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
+	"go.uber.org/zap"
+)
+
+// CapacityReporter periodically publishes the resource usage of the node to
+// KV, where it is read by the capacity planner of the coordinator.
+type CapacityReporter struct {
+	db      Database
+	store   kv.Store
+	hostID  string
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewCapacityReporter returns a new capacity reporter for the database.
+func NewCapacityReporter(
+	db Database,
+	store kv.Store,
+	hostID string,
+) *CapacityReporter {
+	return &CapacityReporter{
+		db:      db,
+		store:   store,
+		hostID:  hostID,
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Start starts publishing the report of the node every interval.
+func (r *CapacityReporter) Start(interval time.Duration) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := r.Publish(); err != nil {
+				r.db.Options().InstrumentOptions().Logger().Warn(
+					"could not publish capacity report", zap.Error(err))
+			}
+			select {
+			case <-ticker.C:
+			case <-r.closeCh:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops publishing reports.
+func (r *CapacityReporter) Close() {
+	close(r.closeCh)
+	r.wg.Wait()
+}
+
+// Publish publishes the current report of the node.
+func (r *CapacityReporter) Publish() error {
+	report, err := r.Report()
+	if err != nil {
+		return err
+	}
+	return capacity.PublishReport(r.store, report)
+}
+
+// Report returns the current resource usage of the node. The write rates of
+// the shards are only known if hot shard detection is enabled.
+func (r *CapacityReporter) Report() (capacity.NodeReport, error) {
+	var (
+		opts   = r.db.Options()
+		prefix = opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+		shards = make(map[uint32]*capacity.ShardReport)
+		report = capacity.NodeReport{
+			HostID:     r.hostID,
+			ReportedAt: opts.ClockOptions().NowFn()(),
+		}
+	)
+	shardReport := func(id uint32) *capacity.ShardReport {
+		s, ok := shards[id]
+		if !ok {
+			s = &capacity.ShardReport{Shard: id}
+			shards[id] = s
+		}
+		return s
+	}
+
+	for _, ns := range r.db.Namespaces() {
+		for _, shard := range ns.Shards() {
+			s := shardReport(shard.ID())
+			s.Series += shard.NumSeries()
+			used, err := dirUsedBytes(fs.ShardDataDirPath(prefix, ns.ID(), shard.ID()))
+			if err != nil {
+				return capacity.NodeReport{}, err
+			}
+			s.DiskUsedBytes += used
+		}
+	}
+	for _, activity := range opts.HotShardDetector().Top(0) {
+		if s, ok := shards[activity.Shard]; ok {
+			s.WritesPerSec += activity.WritesPerSec
+		}
+	}
+
+	report.Shards = make([]capacity.ShardReport, 0, len(shards))
+	for _, s := range shards {
+		report.Shards = append(report.Shards, *s)
+	}
+	sort.Slice(report.Shards, func(i, j int) bool {
+		return report.Shards[i].Shard < report.Shards[j].Shard
+	})
+
+	used, err := dirUsedBytes(prefix)
+	if err != nil {
+		return capacity.NodeReport{}, err
+	}
+	report.DiskUsedBytes = used
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(prefix, &stat); err != nil {
+		return capacity.NodeReport{}, err
+	}
+	report.DiskTotalBytes = int64(stat.Blocks) * int64(stat.Bsize)
+	report.DiskFreeBytes = int64(stat.Bavail) * int64(stat.Bsize)
+	return report, nil
+}
+
+// dirUsedBytes returns the size of the files under a directory, which is zero
+// if the directory does not exist.
+func dirUsedBytes(dir string) (int64, error) {
+	var used int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files may be removed by cleanup while walking.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			used += info.Size()
+		}
+		return nil
+	})
+	return used, err
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	xtest "github.com/m3db/m3/src/x/test"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCapacityReporterPublish(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "capacity-reporter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		now      = time.Unix(1600000000, 0)
+		nsID     = ident.StringID("metrics")
+		detector = hotshards.NewDetector(10, 0, time.Now, zap.NewNop())
+		opts     = DefaultTestOptions()
+		fsOpts   = opts.CommitLogOptions().FilesystemOptions().
+				SetFilePathPrefix(dir)
+	)
+	opts = opts.
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time { return now })).
+		SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(fsOpts)).
+		SetHotShardDetector(detector)
+	detector.Update(hotshards.Activity{Namespace: "metrics", Shard: 1, WritesPerSec: 20})
+
+	shardDir := fs.ShardDataDirPath(dir, nsID, 1)
+	require.NoError(t, os.MkdirAll(shardDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(shardDir, "data"),
+		make([]byte, 100), 0644))
+
+	shards := make([]Shard, 0, 2)
+	for id, series := range map[uint32]int64{0: 5, 1: 10} {
+		shard := NewMockShard(ctrl)
+		shard.EXPECT().ID().Return(id).AnyTimes()
+		shard.EXPECT().NumSeries().Return(series)
+		shards = append(shards, shard)
+	}
+	ns := NewMockNamespace(ctrl)
+	ns.EXPECT().ID().Return(nsID).AnyTimes()
+	ns.EXPECT().Shards().Return(shards)
+
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Options().Return(opts).AnyTimes()
+	db.EXPECT().Namespaces().Return([]Namespace{ns})
+
+	store := mem.NewStore()
+	reporter := NewCapacityReporter(db, store, "host0")
+	require.NoError(t, reporter.Publish())
+
+	report, ok, err := capacity.GetReport(store, "host0")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "host0", report.HostID)
+	require.True(t, now.Equal(report.ReportedAt))
+	require.Equal(t, int64(100), report.DiskUsedBytes)
+	require.True(t, report.DiskTotalBytes > 0)
+	require.Equal(t, []capacity.ShardReport{
+		{Shard: 0, Series: 5},
+		{Shard: 1, Series: 10, DiskUsedBytes: 100, WritesPerSec: 20},
+	}, report.Shards)
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package cardinality estimates the number of unique series indexed per
+// namespace and per tag name using HyperLogLog sketches.
+package cardinality
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/hll"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultPrecision   = 14
+	defaultMaxTagNames = 1024
+
+	// boundsStandardErrors is the number of standard errors either side of an
+	// estimate reported as its bounds, i.e. a ~95% confidence interval.
+	boundsStandardErrors = 2
+)
+
+var errInvalidMaxTagNames = errors.New("max tag names must be positive")
+
+// Configuration configures the cardinality estimator.
+type Configuration struct {
+	// Precision is the precision of each sketch, the relative standard error
+	// of the estimates is 1.04/sqrt(2^precision). Defaults to 14, i.e. ~0.8%
+	// with 16KiB per sketch.
+	Precision int `yaml:"precision"`
+
+	// MaxTagNames is the maximum number of tag names tracked per namespace,
+	// series with tag names beyond the limit only count towards the namespace
+	// estimate.
+	MaxTagNames int `yaml:"maxTagNames"`
+}
+
+// NewEstimator creates a new cardinality estimator.
+func (c Configuration) NewEstimator(iOpts instrument.Options) (*Estimator, error) {
+	precision := defaultPrecision
+	if c.Precision != 0 {
+		precision = c.Precision
+	}
+	maxTagNames := defaultMaxTagNames
+	if c.MaxTagNames != 0 {
+		maxTagNames = c.MaxTagNames
+	}
+	return NewEstimator(precision, maxTagNames,
+		iOpts.MetricsScope().SubScope("cardinality-estimator"))
+}
+
+// Estimate is the estimated number of unique series of a namespace, or of
+// the series with a given tag name in a namespace.
+type Estimate struct {
+	Namespace string `json:"namespace"`
+	// TagName is empty for the estimate of all series in the namespace.
+	TagName       string  `json:"tagName,omitempty"`
+	Estimate      uint64  `json:"estimate"`
+	RelativeError float64 `json:"relativeError"`
+	LowerBound    uint64  `json:"lowerBound"`
+	UpperBound    uint64  `json:"upperBound"`
+}
+
+type namespaceSketches struct {
+	series   *hll.Sketch
+	tagNames map[string]*hll.Sketch
+}
+
+type estimatorMetrics struct {
+	tagNamesDropped tally.Counter
+}
+
+// Estimator maintains sketches of the series indexed per namespace and tag
+// name. All methods are safe to call on a nil estimator, which is a no-op.
+type Estimator struct {
+	sync.Mutex
+
+	precision   int
+	maxTagNames int
+	namespaces  map[string]*namespaceSketches
+	metrics     estimatorMetrics
+}
+
+// NewEstimator returns a new cardinality estimator.
+func NewEstimator(precision, maxTagNames int, scope tally.Scope) (*Estimator, error) {
+	if maxTagNames <= 0 {
+		return nil, errInvalidMaxTagNames
+	}
+	// Validate the precision upfront rather than on the first add.
+	if _, err := hll.NewSketch(precision); err != nil {
+		return nil, err
+	}
+	return &Estimator{
+		precision:   precision,
+		maxTagNames: maxTagNames,
+		namespaces:  make(map[string]*namespaceSketches),
+		metrics: estimatorMetrics{
+			tagNamesDropped: scope.Counter("tag-names-dropped"),
+		},
+	}, nil
+}
+
+// Add adds an indexed series of a namespace to the estimates.
+func (e *Estimator) Add(namespace []byte, d doc.Document) {
+	if e == nil {
+		return
+	}
+
+	hash := xxhash.Sum64(d.ID)
+	e.Lock()
+	ns, ok := e.namespaces[string(namespace)]
+	if !ok {
+		ns = &namespaceSketches{
+			series:   e.newSketchWithLock(),
+			tagNames: make(map[string]*hll.Sketch),
+		}
+		e.namespaces[string(namespace)] = ns
+	}
+	ns.series.AddHash(hash)
+	for _, field := range d.Fields {
+		sketch, ok := ns.tagNames[string(field.Name)]
+		if !ok {
+			if len(ns.tagNames) >= e.maxTagNames {
+				e.metrics.tagNamesDropped.Inc(1)
+				continue
+			}
+			sketch = e.newSketchWithLock()
+			ns.tagNames[string(field.Name)] = sketch
+		}
+		sketch.AddHash(hash)
+	}
+	e.Unlock()
+}
+
+// Estimates returns the estimates of the given namespace, or of all
+// namespaces if empty, ordered by namespace then tag name.
+func (e *Estimator) Estimates(namespace string) []Estimate {
+	if e == nil {
+		return nil
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	var estimates []Estimate
+	for name, ns := range e.namespaces {
+		if namespace != "" && namespace != name {
+			continue
+		}
+		estimates = append(estimates, newEstimate(name, "", ns.series))
+		for tagName, sketch := range ns.tagNames {
+			estimates = append(estimates, newEstimate(name, tagName, sketch))
+		}
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].Namespace != estimates[j].Namespace {
+			return estimates[i].Namespace < estimates[j].Namespace
+		}
+		return estimates[i].TagName < estimates[j].TagName
+	})
+	return estimates
+}
+
+func (e *Estimator) newSketchWithLock() *hll.Sketch {
+	// NB: the precision is validated on construction.
+	sketch, _ := hll.NewSketch(e.precision)
+	return sketch
+}
+
+func newEstimate(namespace, tagName string, sketch *hll.Sketch) Estimate {
+	var (
+		estimate = sketch.Estimate()
+		relErr   = sketch.RelativeError()
+		delta    = boundsStandardErrors * relErr * float64(estimate)
+	)
+	return Estimate{
+		Namespace:     namespace,
+		TagName:       tagName,
+		Estimate:      estimate,
+		RelativeError: relErr,
+		LowerBound:    uint64(math.Max(0, math.Floor(float64(estimate)-delta))),
+		UpperBound:    uint64(math.Ceil(float64(estimate) + delta)),
+	}
+}
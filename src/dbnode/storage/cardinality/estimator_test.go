@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+func testDoc(id string, tagNames ...string) doc.Document {
+	d := doc.Document{ID: []byte(id)}
+	for _, name := range tagNames {
+		d.Fields = append(d.Fields, doc.Field{
+			Name:  []byte(name),
+			Value: []byte(id),
+		})
+	}
+	return d
+}
+
+func TestNewEstimatorInvalid(t *testing.T) {
+	_, err := NewEstimator(14, 0, tally.NoopScope)
+	require.Equal(t, errInvalidMaxTagNames, err)
+	_, err = NewEstimator(2, 10, tally.NoopScope)
+	require.Error(t, err)
+}
+
+func TestEstimatorEstimates(t *testing.T) {
+	e, err := NewEstimator(14, 2, tally.NoopScope)
+	require.NoError(t, err)
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("series%d", i)
+		if i%2 == 0 {
+			e.Add([]byte("foo"), testDoc(id, "host", "dc"))
+		} else {
+			e.Add([]byte("foo"), testDoc(id, "host", "region"))
+		}
+		e.Add([]byte("bar"), testDoc(id, "host"))
+	}
+
+	estimates := e.Estimates("foo")
+	// The region tag name exceeds the limit of tag names.
+	require.Equal(t, 3, len(estimates))
+	expected := []struct {
+		tagName string
+		count   float64
+	}{
+		{tagName: "", count: 1000},
+		{tagName: "dc", count: 500},
+		{tagName: "host", count: 1000},
+	}
+	for i, ex := range expected {
+		require.Equal(t, "foo", estimates[i].Namespace)
+		require.Equal(t, ex.tagName, estimates[i].TagName)
+		require.InDelta(t, ex.count, float64(estimates[i].Estimate), ex.count*0.05)
+		require.True(t, estimates[i].LowerBound <= estimates[i].Estimate)
+		require.True(t, estimates[i].UpperBound >= estimates[i].Estimate)
+		require.InDelta(t, 0.008, estimates[i].RelativeError, 0.001)
+	}
+
+	require.Equal(t, 5, len(e.Estimates("")))
+	require.Equal(t, 0, len(e.Estimates("baz")))
+}
+
+func TestEstimatorNil(t *testing.T) {
+	var e *Estimator
+	e.Add([]byte("foo"), testDoc("series"))
+	require.Nil(t, e.Estimates(""))
+}
+
+func TestHandler(t *testing.T) {
+	e, err := NewEstimator(14, 10, tally.NoopScope)
+	require.NoError(t, err)
+	e.Add([]byte("foo"), testDoc("series", "host"))
+	e.Add([]byte("bar"), testDoc("series", "host"))
+	h := NewHandler(e, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HandlerURL+"?namespace=foo", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, e.Estimates("foo"), resp.Estimates)
+	require.Equal(t, 2, len(resp.Estimates))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, HandlerURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
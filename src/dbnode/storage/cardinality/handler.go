@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cardinality
+
+import (
+	"fmt"
+	"net/http"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// HandlerURL is the url of the cardinality estimates debug handler.
+	HandlerURL = "/debug/index/cardinality"
+
+	namespaceParam = "namespace"
+)
+
+// Response is the response of the cardinality estimates debug handler.
+type Response struct {
+	Estimates []Estimate `json:"estimates"`
+}
+
+type handler struct {
+	estimator *Estimator
+	logger    *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the cardinality estimates
+// of the namespace given by the namespace query parameter, or of all
+// namespaces if not provided.
+func NewHandler(estimator *Estimator, logger *zap.Logger) http.Handler {
+	return &handler{estimator: estimator, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+	namespace := r.URL.Query().Get(namespaceParam)
+	xhttp.WriteJSONResponse(w, Response{
+		Estimates: h.estimator.Estimates(namespace),
+	}, h.logger)
+}
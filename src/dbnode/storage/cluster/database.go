@@ -51,16 +51,22 @@ type newStorageDatabaseFn func(
 ) (storage.Database, error)
 
 type databaseMetrics struct {
-	initializing tally.Gauge
-	leaving      tally.Gauge
-	available    tally.Gauge
+	initializing        tally.Gauge
+	leaving             tally.Gauge
+	available           tally.Gauge
+	markedAvailable     tally.Counter
+	markAvailableErrors tally.Counter
+	pendingBootstrapped tally.Gauge
 }
 
 func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 	return databaseMetrics{
-		initializing: scope.Gauge("shards.initializing"),
-		leaving:      scope.Gauge("shards.leaving"),
-		available:    scope.Gauge("shards.available"),
+		initializing:        scope.Gauge("shards.initializing"),
+		leaving:             scope.Gauge("shards.leaving"),
+		available:           scope.Gauge("shards.available"),
+		markedAvailable:     scope.Counter("shards.marked-available"),
+		markAvailableErrors: scope.Counter("shards.mark-available-errors"),
+		pendingBootstrapped: scope.Gauge("shards.initializing-pending-durable"),
 	}
 }
 
@@ -375,8 +381,12 @@ func (d *clusterDB) analyzeAndReportShardStates() {
 
 	// Call IsBootstrappedAndDurable on storage database, not cluster.
 	if !d.Database.IsBootstrappedAndDurable() {
+		// Track how many initializing shards are held back from taking
+		// traffic until the node is durably bootstrapped.
+		d.metrics.pendingBootstrapped.Update(float64(len(d.initializing)))
 		return
 	}
+	d.metrics.pendingBootstrapped.Update(0)
 
 	// Count if initializing shards have bootstrapped in all namespaces. This
 	// check is redundant with the database check above, but we do it for
@@ -422,11 +432,14 @@ func (d *clusterDB) analyzeAndReportShardStates() {
 	}
 
 	if err := topo.MarkShardsAvailable(d.hostID, markAvailable...); err != nil {
+		d.metrics.markAvailableErrors.Inc(1)
 		d.log.Error("cluster db failed marking shards available",
 			zap.Uint32s("shards", markAvailable), zap.Error(err))
 		return
 	}
 
+	d.metrics.markedAvailable.Inc(int64(len(markAvailable)))
+
 	d.log.Info("cluster db successfully marked shards as available",
 		zap.Uint32s("shards", markAvailable))
 }
@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Configuration configures downsampling of blocks as they are flushed.
+type Configuration struct {
+	// Rules are the downsampling rules, a source namespace may be downsampled
+	// into several target namespaces at different resolutions.
+	Rules []RuleConfiguration `yaml:"rules"`
+}
+
+// RuleConfiguration configures a downsampling rule.
+type RuleConfiguration struct {
+	// SourceNamespace is the namespace whose flushed blocks are downsampled.
+	SourceNamespace string `yaml:"sourceNamespace" validate:"nonzero"`
+
+	// TargetNamespace is the namespace the downsampled datapoints are written
+	// to, it must have cold writes enabled.
+	TargetNamespace string `yaml:"targetNamespace" validate:"nonzero"`
+
+	// Resolution is the resolution of the downsampled datapoints.
+	Resolution time.Duration `yaml:"resolution" validate:"nonzero"`
+
+	// Aggregation combines the datapoints within each resolution window, one
+	// of last, mean, sum, min or max. Defaults to last.
+	Aggregation Aggregation `yaml:"aggregation"`
+}
+
+// NewDownsampler creates a new downsampler.
+func (c Configuration) NewDownsampler(
+	iterPool encoding.ReaderIteratorPool,
+	iOpts instrument.Options,
+) (*Downsampler, error) {
+	rules := make([]Rule, 0, len(c.Rules))
+	for _, rule := range c.Rules {
+		aggregation := rule.Aggregation
+		if aggregation == "" {
+			aggregation = AggregationLast
+		}
+		rules = append(rules, Rule{
+			SourceNamespace: ident.StringID(rule.SourceNamespace),
+			TargetNamespace: ident.StringID(rule.TargetNamespace),
+			Resolution:      rule.Resolution,
+			Aggregation:     aggregation,
+		})
+	}
+	return NewDownsampler(rules, iterPool, iOpts)
+}
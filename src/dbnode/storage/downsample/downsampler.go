@@ -0,0 +1,317 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package downsample computes coarser resolution copies of series blocks as
+// they are flushed, writing them to namespaces with longer retention so the
+// data does not need to be read back from disk to be downsampled later.
+package downsample
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+)
+
+var errWriterNotSet = errors.New("downsampler writer not set")
+
+// Aggregation is the function used to combine the datapoints that fall
+// within a downsampled resolution window.
+type Aggregation string
+
+// List of supported aggregations.
+const (
+	AggregationLast Aggregation = "last"
+	AggregationMean Aggregation = "mean"
+	AggregationSum  Aggregation = "sum"
+	AggregationMin  Aggregation = "min"
+	AggregationMax  Aggregation = "max"
+)
+
+// Validate validates the aggregation.
+func (a Aggregation) Validate() error {
+	switch a {
+	case AggregationLast, AggregationMean, AggregationSum,
+		AggregationMin, AggregationMax:
+		return nil
+	}
+	return fmt.Errorf("unknown downsample aggregation: %q", string(a))
+}
+
+// Rule downsamples the blocks flushed by a source namespace into a target
+// namespace at a coarser resolution.
+type Rule struct {
+	SourceNamespace ident.ID
+	TargetNamespace ident.ID
+	Resolution      time.Duration
+	Aggregation     Aggregation
+}
+
+// Validate validates the rule.
+func (r Rule) Validate() error {
+	if r.SourceNamespace == nil || r.TargetNamespace == nil {
+		return errors.New("downsample rule requires source and target namespace")
+	}
+	if r.SourceNamespace.Equal(r.TargetNamespace) {
+		return fmt.Errorf("downsample rule source and target namespace are both %s",
+			r.SourceNamespace.String())
+	}
+	if r.Resolution < time.Second || r.Resolution%time.Second != 0 {
+		return fmt.Errorf("downsample resolution must be a whole number of seconds: %v",
+			r.Resolution)
+	}
+	return r.Aggregation.Validate()
+}
+
+// Writer writes downsampled datapoints, it is satisfied by the database.
+type Writer interface {
+	WriteTagged(
+		ctx context.Context,
+		namespace ident.ID,
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		value float64,
+		unit xtime.Unit,
+		annotation []byte,
+	) error
+}
+
+type downsamplerMetrics struct {
+	series     tally.Counter
+	datapoints tally.Counter
+	errors     tally.Counter
+}
+
+func newDownsamplerMetrics(scope tally.Scope) downsamplerMetrics {
+	scope = scope.SubScope("flush-downsample")
+	return downsamplerMetrics{
+		series:     scope.Counter("series"),
+		datapoints: scope.Counter("datapoints"),
+		errors:     scope.Counter("errors"),
+	}
+}
+
+// Downsampler downsamples flushed series blocks according to its rules. All
+// methods are safe to call on a nil downsampler, which is a no-op.
+//
+// Downsampled datapoints are written to the target namespaces for times that
+// have already been flushed by the source namespace, so the target namespaces
+// must have cold writes enabled.
+type Downsampler struct {
+	sync.RWMutex
+
+	rules    map[string][]Rule
+	iterPool encoding.ReaderIteratorPool
+	writer   Writer
+	metrics  downsamplerMetrics
+}
+
+// NewDownsampler returns a new downsampler.
+func NewDownsampler(
+	rules []Rule,
+	iterPool encoding.ReaderIteratorPool,
+	iOpts instrument.Options,
+) (*Downsampler, error) {
+	bySource := make(map[string][]Rule, len(rules))
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		source := rule.SourceNamespace.String()
+		bySource[source] = append(bySource[source], rule)
+	}
+	return &Downsampler{
+		rules:    bySource,
+		iterPool: iterPool,
+		metrics:  newDownsamplerMetrics(iOpts.MetricsScope()),
+	}, nil
+}
+
+// SetWriter sets the writer of downsampled datapoints, it must be set before
+// any blocks are flushed.
+func (d *Downsampler) SetWriter(writer Writer) {
+	if d == nil {
+		return
+	}
+	d.Lock()
+	d.writer = writer
+	d.Unlock()
+}
+
+// Downsamples returns whether blocks flushed by a namespace are downsampled.
+func (d *Downsampler) Downsamples(namespace ident.ID) bool {
+	if d == nil {
+		return false
+	}
+	return len(d.rules[namespace.String()]) > 0
+}
+
+// Downsample downsamples a flushed series block of a namespace into each of
+// the target namespaces of the rules for the namespace.
+func (d *Downsampler) Downsample(
+	nsID ident.ID,
+	metadata persist.Metadata,
+	segment ts.Segment,
+	nsCtx namespace.Context,
+) error {
+	if d == nil {
+		return nil
+	}
+	rules := d.rules[nsID.String()]
+	if len(rules) == 0 {
+		return nil
+	}
+
+	d.RLock()
+	writer := d.writer
+	d.RUnlock()
+	if writer == nil {
+		d.metrics.errors.Inc(1)
+		return errWriterNotSet
+	}
+
+	err := d.downsample(writer, rules, metadata, segment, nsCtx)
+	if err != nil {
+		d.metrics.errors.Inc(1)
+		return err
+	}
+	d.metrics.series.Inc(1)
+	return nil
+}
+
+func (d *Downsampler) downsample(
+	writer Writer,
+	rules []Rule,
+	metadata persist.Metadata,
+	segment ts.Segment,
+	nsCtx namespace.Context,
+) error {
+	tags, err := metadata.ResetOrReturnProvidedTagIterator(
+		ident.NewTagsIterator(ident.Tags{}))
+	if err != nil {
+		return err
+	}
+
+	var (
+		id  = ident.BytesID(metadata.BytesID())
+		ctx = context.NewContext()
+	)
+	defer ctx.BlockingClose()
+
+	windows := make([]window, 0, len(rules))
+	for _, rule := range rules {
+		windows = append(windows, window{rule: rule})
+	}
+
+	emit := func(w *window) error {
+		if w.count == 0 {
+			return nil
+		}
+		value := w.value()
+		w.count = 0
+		writeTags := tags.Duplicate()
+		defer writeTags.Close()
+		d.metrics.datapoints.Inc(1)
+		return writer.WriteTagged(ctx, w.rule.TargetNamespace, id, writeTags,
+			w.start, value, xtime.Second, nil)
+	}
+
+	iter := d.iterPool.Get()
+	iter.Reset(xio.NewSegmentReader(segment), nsCtx.Schema)
+	defer iter.Close()
+
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		for i := range windows {
+			w := &windows[i]
+			start := dp.Timestamp.Truncate(w.rule.Resolution)
+			if w.count > 0 && !start.Equal(w.start) {
+				if err := emit(w); err != nil {
+					return err
+				}
+			}
+			w.add(start, dp.Value)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	for i := range windows {
+		if err := emit(&windows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// window accumulates the datapoints of a single resolution window, the
+// downsampled datapoint is timestamped with the start of the window.
+type window struct {
+	rule  Rule
+	start time.Time
+	count int
+	sum   float64
+	min   float64
+	max   float64
+	last  float64
+}
+
+func (w *window) add(start time.Time, value float64) {
+	if w.count == 0 {
+		w.start = start
+		w.sum = 0
+		w.min = math.Inf(1)
+		w.max = math.Inf(-1)
+	}
+	w.count++
+	w.sum += value
+	w.min = math.Min(w.min, value)
+	w.max = math.Max(w.max, value)
+	w.last = value
+}
+
+func (w *window) value() float64 {
+	switch w.rule.Aggregation {
+	case AggregationMean:
+		return w.sum / float64(w.count)
+	case AggregationSum:
+		return w.sum
+	case AggregationMin:
+		return w.min
+	case AggregationMax:
+		return w.max
+	default:
+		return w.last
+	}
+}
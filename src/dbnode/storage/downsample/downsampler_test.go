@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package downsample
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type write struct {
+	namespace string
+	id        string
+	tags      map[string]string
+	timestamp time.Time
+	value     float64
+}
+
+type testWriter struct {
+	writes []write
+}
+
+func (w *testWriter) WriteTagged(
+	_ context.Context,
+	namespace ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	_ xtime.Unit,
+	_ []byte,
+) error {
+	tagMap := make(map[string]string)
+	for tags.Next() {
+		tag := tags.Current()
+		tagMap[tag.Name.String()] = tag.Value.String()
+	}
+	w.writes = append(w.writes, write{
+		namespace: namespace.String(),
+		id:        id.String(),
+		tags:      tagMap,
+		timestamp: timestamp,
+		value:     value,
+	})
+	return tags.Err()
+}
+
+func newTestIteratorPool() encoding.ReaderIteratorPool {
+	pool := encoding.NewReaderIteratorPool(nil)
+	pool.Init(func(r io.Reader, _ namespace.SchemaDescr) encoding.ReaderIterator {
+		return m3tsz.NewReaderIterator(r, m3tsz.DefaultIntOptimizationEnabled,
+			encoding.NewOptions())
+	})
+	return pool
+}
+
+func newTestSegment(t *testing.T, start time.Time, values []float64) ts.Segment {
+	enc := m3tsz.NewEncoder(start, nil, m3tsz.DefaultIntOptimizationEnabled,
+		encoding.NewOptions())
+	for i, v := range values {
+		dp := ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * 10 * time.Second),
+			Value:     v,
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+	}
+	return enc.Discard()
+}
+
+func TestDownsamplerDownsample(t *testing.T) {
+	d, err := NewDownsampler([]Rule{
+		{
+			SourceNamespace: ident.StringID("raw"),
+			TargetNamespace: ident.StringID("agg_1m"),
+			Resolution:      time.Minute,
+			Aggregation:     AggregationMean,
+		},
+		{
+			SourceNamespace: ident.StringID("raw"),
+			TargetNamespace: ident.StringID("agg_2m"),
+			Resolution:      2 * time.Minute,
+			Aggregation:     AggregationMax,
+		},
+	}, newTestIteratorPool(), instrument.NewOptions())
+	require.NoError(t, err)
+
+	require.True(t, d.Downsamples(ident.StringID("raw")))
+	require.False(t, d.Downsamples(ident.StringID("agg_1m")))
+
+	var (
+		start    = time.Unix(1600000000, 0).Truncate(2 * time.Hour)
+		segment  = newTestSegment(t, start, []float64{1, 2, 3, 4, 5, 6, 7, 8, 9})
+		metadata = persist.NewMetadataFromIDAndTags(ident.StringID("foo"),
+			ident.NewTags(ident.StringTag("city", "nyc")), persist.MetadataOptions{})
+		nsCtx = namespace.Context{ID: ident.StringID("raw")}
+	)
+
+	require.Error(t, d.Downsample(nsCtx.ID, metadata, segment, nsCtx))
+
+	writer := &testWriter{}
+	d.SetWriter(writer)
+	require.NoError(t, d.Downsample(nsCtx.ID, metadata, segment, nsCtx))
+
+	tags := map[string]string{"city": "nyc"}
+	require.Equal(t, []write{
+		{namespace: "agg_1m", id: "foo", tags: tags, timestamp: start, value: 3.5},
+		{namespace: "agg_1m", id: "foo", tags: tags, timestamp: start.Add(time.Minute), value: 8},
+		{namespace: "agg_2m", id: "foo", tags: tags, timestamp: start, value: 9},
+	}, writer.writes)
+}
+
+func TestDownsamplerNil(t *testing.T) {
+	var d *Downsampler
+	d.SetWriter(&testWriter{})
+	require.False(t, d.Downsamples(ident.StringID("raw")))
+	require.NoError(t, d.Downsample(ident.StringID("raw"), persist.Metadata{},
+		ts.Segment{}, namespace.Context{}))
+}
+
+func TestNewDownsamplerInvalidRules(t *testing.T) {
+	iOpts := instrument.NewOptions()
+	for _, rule := range []Rule{
+		{
+			SourceNamespace: ident.StringID("raw"),
+			TargetNamespace: ident.StringID("raw"),
+			Resolution:      time.Minute,
+			Aggregation:     AggregationLast,
+		},
+		{
+			SourceNamespace: ident.StringID("raw"),
+			TargetNamespace: ident.StringID("agg"),
+			Resolution:      1500 * time.Millisecond,
+			Aggregation:     AggregationLast,
+		},
+		{
+			SourceNamespace: ident.StringID("raw"),
+			TargetNamespace: ident.StringID("agg"),
+			Resolution:      time.Minute,
+			Aggregation:     Aggregation("p99"),
+		},
+	} {
+		_, err := NewDownsampler([]Rule{rule}, newTestIteratorPool(), iOpts)
+		require.Error(t, err)
+	}
+}
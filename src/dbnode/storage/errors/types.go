@@ -57,3 +57,27 @@ func IsUnknownNamespaceError(err error) bool {
 	_, ok := nsErr.(unknownNamespace)
 	return ok
 }
+
+// NewQueryLimitExceededError returns a new error indicating a query exceeded
+// one of its configured limits, the error is not retryable.
+func NewQueryLimitExceededError(msg string) error {
+	return xerrors.NewInvalidParamsError(queryLimitExceeded{msg})
+}
+
+type queryLimitExceeded struct {
+	msg string
+}
+
+func (e queryLimitExceeded) Error() string {
+	return fmt.Sprintf("query exceeded limit: %s", e.msg)
+}
+
+// IsQueryLimitExceededError returns true if this is a query limit exceeded error.
+func IsQueryLimitExceededError(err error) bool {
+	limitErr := xerrors.GetInnerInvalidParamsError(err)
+	if limitErr == nil {
+		return false
+	}
+	_, ok := limitErr.(queryLimitExceeded)
+	return ok
+}
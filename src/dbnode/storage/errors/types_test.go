@@ -31,3 +31,11 @@ func TestUnknownNamespaceError(t *testing.T) {
 	require.Equal(t, "unknown namespace: ns", err.Error())
 	require.True(t, IsUnknownNamespaceError(err))
 }
+
+func TestQueryLimitExceededError(t *testing.T) {
+	err := NewQueryLimitExceededError("series_limit=10")
+	require.Equal(t, "query exceeded limit: series_limit=10", err.Error())
+	require.True(t, IsQueryLimitExceededError(err))
+	require.False(t, IsUnknownNamespaceError(err))
+	require.False(t, IsQueryLimitExceededError(NewUnknownNamespaceError("ns")))
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hotshards tracks the activity of each shard owned by the node and
+// detects the busiest shards, to guide placement rebalancing.
+package hotshards
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTopN        = 10
+	defaultLogInterval = time.Minute
+)
+
+// Configuration configures the hot shard detector.
+type Configuration struct {
+	// TopN is the number of busiest shards logged and returned by default by
+	// the debug handler. Defaults to 10.
+	TopN int `yaml:"topN"`
+
+	// LogInterval is how often the busiest shards are logged, a negative
+	// interval disables logging. Defaults to one minute.
+	LogInterval time.Duration `yaml:"logInterval"`
+}
+
+// NewDetector creates a new hot shard detector.
+func (c Configuration) NewDetector(
+	iOpts instrument.Options,
+	clockOpts clock.Options,
+) *Detector {
+	topN := defaultTopN
+	if c.TopN > 0 {
+		topN = c.TopN
+	}
+	logInterval := defaultLogInterval
+	if c.LogInterval != 0 {
+		logInterval = c.LogInterval
+	}
+	return NewDetector(topN, logInterval, clockOpts.NowFn(), iOpts.Logger())
+}
+
+// Activity is the activity of a shard over its last tick.
+type Activity struct {
+	Namespace        string  `json:"namespace"`
+	Shard            uint32  `json:"shard"`
+	WritesPerSec     float64 `json:"writesPerSec"`
+	ReadsPerSec      float64 `json:"readsPerSec"`
+	InsertQueueDepth int     `json:"insertQueueDepth"`
+	// LockWait is the time spent waiting on the shard lock by reads and
+	// writes, per second.
+	LockWait time.Duration `json:"lockWait"`
+}
+
+// OpsPerSec returns the reads and writes per second of the shard, which the
+// shards are ranked by.
+func (a Activity) OpsPerSec() float64 {
+	return a.WritesPerSec + a.ReadsPerSec
+}
+
+type shardKey struct {
+	namespace string
+	shard     uint32
+}
+
+// Detector keeps the last reported activity of each shard. All methods are
+// safe to call on a nil detector, which is a no-op.
+type Detector struct {
+	sync.Mutex
+
+	topN        int
+	logInterval time.Duration
+	nowFn       clock.NowFn
+	logger      *zap.Logger
+	lastLog     time.Time
+	shards      map[shardKey]Activity
+}
+
+// NewDetector returns a new hot shard detector that logs the topN busiest
+// shards every log interval, or never if the interval is not positive.
+func NewDetector(
+	topN int,
+	logInterval time.Duration,
+	nowFn clock.NowFn,
+	logger *zap.Logger,
+) *Detector {
+	return &Detector{
+		topN:        topN,
+		logInterval: logInterval,
+		nowFn:       nowFn,
+		logger:      logger,
+		lastLog:     nowFn(),
+		shards:      make(map[shardKey]Activity),
+	}
+}
+
+// TopN returns the default number of shards returned by the debug handler.
+func (d *Detector) TopN() int {
+	if d == nil {
+		return 0
+	}
+	return d.topN
+}
+
+// Update records the activity of a shard, replacing its previous activity.
+func (d *Detector) Update(activity Activity) {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	d.shards[shardKey{namespace: activity.Namespace, shard: activity.Shard}] = activity
+	now := d.nowFn()
+	if d.logInterval <= 0 || now.Sub(d.lastLog) < d.logInterval {
+		d.Unlock()
+		return
+	}
+	d.lastLog = now
+	top := d.topWithLock(d.topN)
+	d.Unlock()
+
+	for i, a := range top {
+		d.logger.Info("hot shard",
+			zap.Int("rank", i+1),
+			zap.String("namespace", a.Namespace),
+			zap.Uint32("shard", a.Shard),
+			zap.Float64("writesPerSec", a.WritesPerSec),
+			zap.Float64("readsPerSec", a.ReadsPerSec),
+			zap.Int("insertQueueDepth", a.InsertQueueDepth),
+			zap.Duration("lockWait", a.LockWait))
+	}
+}
+
+// Remove removes a shard that is no longer owned.
+func (d *Detector) Remove(namespace string, shard uint32) {
+	if d == nil {
+		return
+	}
+
+	d.Lock()
+	delete(d.shards, shardKey{namespace: namespace, shard: shard})
+	d.Unlock()
+}
+
+// Top returns up to n shards ordered by descending operations per second,
+// all shards are returned if n is not positive.
+func (d *Detector) Top(n int) []Activity {
+	if d == nil {
+		return nil
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	return d.topWithLock(n)
+}
+
+func (d *Detector) topWithLock(n int) []Activity {
+	top := make([]Activity, 0, len(d.shards))
+	for _, a := range d.shards {
+		top = append(top, a)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].OpsPerSec() != top[j].OpsPerSec() {
+			return top[i].OpsPerSec() > top[j].OpsPerSec()
+		}
+		if top[i].Namespace != top[j].Namespace {
+			return top[i].Namespace < top[j].Namespace
+		}
+		return top[i].Shard < top[j].Shard
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hotshards
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDetectorTop(t *testing.T) {
+	d := NewDetector(2, 0, time.Now, zap.NewNop())
+	d.Update(Activity{Namespace: "foo", Shard: 0, WritesPerSec: 10})
+	d.Update(Activity{Namespace: "foo", Shard: 1, WritesPerSec: 5, ReadsPerSec: 20})
+	d.Update(Activity{Namespace: "bar", Shard: 0, ReadsPerSec: 1})
+
+	top := d.Top(d.TopN())
+	require.Equal(t, 2, len(top))
+	require.Equal(t, uint32(1), top[0].Shard)
+	require.Equal(t, uint32(0), top[1].Shard)
+	require.Equal(t, "foo", top[1].Namespace)
+
+	// Updates replace the previous activity of the shard.
+	d.Update(Activity{Namespace: "bar", Shard: 0, ReadsPerSec: 100})
+	require.Equal(t, "bar", d.Top(1)[0].Namespace)
+
+	d.Remove("bar", 0)
+	require.Equal(t, 2, len(d.Top(0)))
+	require.Equal(t, "foo", d.Top(1)[0].Namespace)
+}
+
+func TestDetectorLogsTopN(t *testing.T) {
+	var (
+		core, logs = observer.New(zapcore.InfoLevel)
+		now        = time.Now()
+		nowFn      = func() time.Time { return now }
+		d          = NewDetector(1, time.Minute, nowFn, zap.New(core))
+	)
+	d.Update(Activity{Namespace: "foo", Shard: 0, WritesPerSec: 10})
+	require.Equal(t, 0, logs.Len())
+
+	now = now.Add(time.Minute)
+	d.Update(Activity{Namespace: "foo", Shard: 1, WritesPerSec: 20})
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, uint32(1), logs.All()[0].ContextMap()["shard"])
+}
+
+func TestDetectorNil(t *testing.T) {
+	var d *Detector
+	d.Update(Activity{Namespace: "foo"})
+	d.Remove("foo", 0)
+	require.Nil(t, d.Top(1))
+	require.Equal(t, 0, d.TopN())
+}
+
+func TestHandler(t *testing.T) {
+	d := NewDetector(10, 0, time.Now, zap.NewNop())
+	d.Update(Activity{Namespace: "foo", Shard: 0, WritesPerSec: 10})
+	d.Update(Activity{Namespace: "foo", Shard: 1, WritesPerSec: 20})
+	h := NewHandler(d, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HandlerURL+"?limit=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, d.Top(1), resp.Shards)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, HandlerURL+"?limit=x", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, HandlerURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -172,6 +172,7 @@ type nsIndexRuntimeOptions struct {
 	insertMode          index.InsertMode
 	maxQuerySeriesLimit int64
 	maxQueryDocsLimit   int64
+	maxQueryTermsLimit  int64
 	defaultQueryTimeout time.Duration
 }
 
@@ -397,6 +398,7 @@ func newNamespaceIndexWithOptions(
 func (i *nsIndex) SetRuntimeOptions(value runtime.Options) {
 	i.state.Lock()
 	i.state.runtimeOpts.defaultQueryTimeout = value.IndexDefaultQueryTimeout()
+	i.state.runtimeOpts.maxQueryTermsLimit = int64(value.IndexMaxQueryTermsLimit())
 	i.state.Unlock()
 }
 
@@ -1068,28 +1070,22 @@ func (i *nsIndex) flushBlock(
 		return nil, err
 	}
 
-	var closed bool
-	defer func() {
-		if !closed {
-			segments, _ := preparedPersist.Close()
-			// NB(r): Safe to for over a nil array so disregard error here.
-			for _, segment := range segments {
-				segment.Close()
-			}
-		}
-	}()
-
-	// Flush a single block segment.
+	// Flush the block segments, if this fails the volume is deliberately not
+	// closed. Closing writes the checkpoint file that marks the volume as
+	// complete, so leaving it unclosed means bootstrap ignores the segments
+	// written so far and the next flush of the block overwrites them.
 	if err := i.flushBlockSegment(preparedPersist, indexBlock, shards, builder); err != nil {
 		return nil, err
 	}
 
-	closed = true
-
 	// Now return the immutable segments
 	return preparedPersist.Close()
 }
 
+// flushBlockSegment reads the series for the block from the shards into the
+// builder and persists them as segments of the prepared volume, persisting
+// a segment each time the builder reaches the max segment docs so that large
+// blocks are not built entirely in memory before being persisted.
 func (i *nsIndex) flushBlockSegment(
 	preparedPersist persist.PreparedIndexPersist,
 	indexBlock index.Block,
@@ -1099,6 +1095,10 @@ func (i *nsIndex) flushBlockSegment(
 	// Reset the builder
 	builder.Reset(0)
 
+	var (
+		maxSegmentDocs = i.opts.IndexOptions().FlushMaxSegmentDocs()
+		segments       int
+	)
 	ctx := i.opts.ContextPool().Get()
 	for _, shard := range shards {
 		var (
@@ -1146,10 +1146,22 @@ func (i *nsIndex) flushBlockSegment(
 			// Use BlockingCloseReset so that we can reuse the context without
 			// it going back to the pool.
 			ctx.BlockingCloseReset()
+
+			if maxSegmentDocs > 0 && len(builder.Docs()) >= maxSegmentDocs {
+				if err := preparedPersist.Persist(builder); err != nil {
+					return err
+				}
+				segments++
+				builder.Reset(0)
+			}
 		}
 	}
 
-	// Finally flush this segment
+	// Finally flush the remaining series, always flushing at least one segment
+	// so that the volume is not empty.
+	if segments > 0 && len(builder.Docs()) == 0 {
+		return nil
+	}
 	return preparedPersist.Persist(builder)
 }
 
@@ -1306,16 +1318,15 @@ func (i *nsIndex) query(
 			i.metrics.queryNonExhaustiveLimitError.Inc(1)
 		}
 
-		err := fmt.Errorf(
-			"query exceeded limit: require_exhaustive=%v, series_limit=%d, series_matched=%d, docs_limit=%d, docs_matched=%d",
+		// NB(r): Make sure error is not retried and returns as bad request.
+		return exhaustive, m3dberrors.NewQueryLimitExceededError(fmt.Sprintf(
+			"require_exhaustive=%v, series_limit=%d, series_matched=%d, docs_limit=%d, docs_matched=%d",
 			opts.RequireExhaustive,
 			opts.SeriesLimit,
 			seriesCount,
 			opts.DocsLimit,
 			docsCount,
-		)
-		// NB(r): Make sure error is not retried and returns as bad request.
-		return exhaustive, xerrors.NewInvalidParamsError(err)
+		))
 	}
 
 	// Otherwise non-exhaustive but not required to be.
@@ -1592,6 +1603,13 @@ func (i *nsIndex) overriddenOptsForQueryWithRLock(
 			zap.Int64("maxAllowed", i.state.runtimeOpts.maxQueryDocsLimit)) // FOLLOWUP(prateek): log query too once it's serializable.
 		opts.DocsLimit = int(i.state.runtimeOpts.maxQueryDocsLimit)
 	}
+	if i.state.runtimeOpts.maxQueryTermsLimit > 0 && (opts.TermsLimit == 0 ||
+		int64(opts.TermsLimit) > i.state.runtimeOpts.maxQueryTermsLimit) {
+		i.logger.Debug("overriding query terms limit",
+			zap.Int("requested", opts.TermsLimit),
+			zap.Int64("maxAllowed", i.state.runtimeOpts.maxQueryTermsLimit)) // FOLLOWUP(prateek): log query too once it's serializable.
+		opts.TermsLimit = int(i.state.runtimeOpts.maxQueryTermsLimit)
+	}
 	return opts
 }
 
@@ -95,7 +95,7 @@ func (s blockState) String() string {
 	return "unknown"
 }
 
-type newExecutorFn func() (search.Executor, error)
+type newExecutorFn func(termsLimit int) (search.Executor, error)
 
 type shardRangesSegmentsByVolumeType map[persist.IndexVolumeType][]blockShardRangesSegments
 
@@ -302,7 +302,7 @@ func (b *block) writesAcceptedWithRLock() bool {
 		b.nsMD.Options().ColdWritesEnabled()
 }
 
-func (b *block) executorWithRLock() (search.Executor, error) {
+func (b *block) executorWithRLock(termsLimit int) (search.Executor, error) {
 	expectedReaders := b.mutableSegments.Len()
 	for _, coldSeg := range b.coldMutableSegments {
 		expectedReaders += coldSeg.Len()
@@ -352,7 +352,7 @@ func (b *block) executorWithRLock() (search.Executor, error) {
 	}
 
 	success = true
-	return executor.NewExecutor(readers), nil
+	return executor.NewExecutor(newTermsLimitedReaders(readers, termsLimit)), nil
 }
 
 func (b *block) segmentsWithRLock() []segment.Segment {
@@ -423,7 +423,7 @@ func (b *block) queryWithSpan(
 		return false, ErrUnableToQueryBlockClosed
 	}
 
-	exec, err := b.newExecutorWithRLockFn()
+	exec, err := b.newExecutorWithRLockFn(opts.TermsLimit)
 	if err != nil {
 		return false, err
 	}
@@ -439,6 +439,10 @@ func (b *block) queryWithSpan(
 
 	// FOLLOWUP(prateek): push down QueryOptions to restrict results
 	iter, err := exec.Execute(query.Query.SearchQuery())
+	if err == m3ninxindex.ErrTermsLimitExceeded {
+		// The terms limit was exceeded before any documents matched.
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
@@ -501,14 +505,21 @@ func (b *block) queryWithSpan(
 		}
 	}
 
-	if err := iter.Err(); err != nil {
+	termsLimitExceeded := false
+	if err := iter.Err(); err == m3ninxindex.ErrTermsLimitExceeded {
+		// Keep the documents matched by the segments searched before the
+		// terms limit was exceeded and return them as partial results.
+		termsLimitExceeded = true
+	} else if err != nil {
 		return false, err
 	}
 	if err := iterCloser.Close(); err != nil {
 		return false, err
 	}
 
-	exhaustive := !opts.SeriesLimitExceeded(size) && !opts.DocsLimitExceeded(docsCount)
+	exhaustive := !termsLimitExceeded &&
+		!opts.SeriesLimitExceeded(size) &&
+		!opts.DocsLimitExceeded(docsCount)
 	return exhaustive, nil
 }
 
@@ -626,11 +637,12 @@ func (b *block) aggregateWithSpan(
 	}
 
 	var (
-		size       = results.Size()
-		docsCount  = results.TotalDocsCount()
-		batch      = b.opts.AggregateResultsEntryArrayPool().Get()
-		batchSize  = cap(batch)
-		iterClosed = false // tracking whether we need to free the iterator at the end.
+		size         = results.Size()
+		docsCount    = results.TotalDocsCount()
+		termsScanned = 0
+		batch        = b.opts.AggregateResultsEntryArrayPool().Get()
+		batchSize    = cap(batch)
+		iterClosed   = false // tracking whether we need to free the iterator at the end.
 	)
 	if batchSize == 0 {
 		batchSize = defaultAggregateResultsEntryBatchSize
@@ -646,7 +658,8 @@ func (b *block) aggregateWithSpan(
 
 	segs := b.segmentsWithRLock()
 	for _, s := range segs {
-		if opts.SeriesLimitExceeded(size) || opts.DocsLimitExceeded(docsCount) {
+		if opts.SeriesLimitExceeded(size) || opts.DocsLimitExceeded(docsCount) ||
+			opts.TermsLimitExceeded(termsScanned) {
 			break
 		}
 
@@ -657,10 +670,12 @@ func (b *block) aggregateWithSpan(
 		iterClosed = false // only once the iterator has been successfully Reset().
 
 		for iter.Next() {
-			if opts.SeriesLimitExceeded(size) || opts.DocsLimitExceeded(docsCount) {
+			if opts.SeriesLimitExceeded(size) || opts.DocsLimitExceeded(docsCount) ||
+				opts.TermsLimitExceeded(termsScanned) {
 				break
 			}
 
+			termsScanned++
 			field, term := iter.Current()
 			batch = b.appendFieldAndTermToBatch(batch, field, term, iterateTerms)
 			if len(batch) < batchSize {
@@ -691,7 +706,9 @@ func (b *block) aggregateWithSpan(
 		}
 	}
 
-	exhaustive := !opts.SeriesLimitExceeded(size) && !opts.DocsLimitExceeded(docsCount)
+	exhaustive := !opts.SeriesLimitExceeded(size) &&
+		!opts.DocsLimitExceeded(docsCount) &&
+		!opts.TermsLimitExceeded(termsScanned)
 	return exhaustive, nil
 }
 
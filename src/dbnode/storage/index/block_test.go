@@ -393,7 +393,7 @@ func TestBlockQueryExecutorError(t *testing.T) {
 	b, ok := blk.(*block)
 	require.True(t, ok)
 
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return nil, fmt.Errorf("random-err")
 	}
 
@@ -477,7 +477,7 @@ func TestBlockMockQueryExecutorExecError(t *testing.T) {
 
 	// dIter:= doc.NewMockIterator(ctrl)
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 	gomock.InOrder(
@@ -502,7 +502,7 @@ func TestBlockMockQueryExecutorExecIterErr(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -542,7 +542,7 @@ func TestBlockMockQueryExecutorExecLimit(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -592,7 +592,7 @@ func TestBlockMockQueryExecutorExecIterCloseErr(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -630,7 +630,7 @@ func TestBlockMockQuerySeriesLimitNonExhaustive(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -679,7 +679,7 @@ func TestBlockMockQuerySeriesLimitExhaustive(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -730,7 +730,7 @@ func TestBlockMockQueryDocsLimitNonExhaustive(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -766,6 +766,87 @@ func TestBlockMockQueryDocsLimitNonExhaustive(t *testing.T) {
 	ctx.BlockingClose()
 }
 
+func TestBlockMockQueryTermsLimitNonExhaustive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	termsLimit := 10
+	exec := search.NewMockExecutor(ctrl)
+	b.newExecutorWithRLockFn = func(limit int) (search.Executor, error) {
+		require.Equal(t, termsLimit, limit)
+		return exec, nil
+	}
+
+	dIter := doc.NewMockIterator(ctrl)
+	gomock.InOrder(
+		exec.EXPECT().Execute(gomock.Any()).Return(dIter, nil),
+		dIter.EXPECT().Next().Return(true),
+		dIter.EXPECT().Current().Return(testDoc1()),
+		dIter.EXPECT().Next().Return(false),
+		dIter.EXPECT().Err().Return(index.ErrTermsLimitExceeded),
+		dIter.EXPECT().Close().Return(nil),
+		exec.EXPECT().Close().Return(nil),
+	)
+	results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+
+	ctx := context.NewContext()
+
+	exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+		defaultQuery, QueryOptions{TermsLimit: termsLimit}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+
+	require.Equal(t, 1, results.Map().Len())
+	_, ok = results.Map().Get(ident.StringID(string(testDoc1().ID)))
+	require.True(t, ok)
+
+	// NB(r): Make sure to call finalizers blockingly (to finish
+	// the expected close calls)
+	ctx.BlockingClose()
+}
+
+func TestBlockMockQueryTermsLimitExceededBeforeMatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	exec := search.NewMockExecutor(ctrl)
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
+		return exec, nil
+	}
+
+	gomock.InOrder(
+		exec.EXPECT().Execute(gomock.Any()).Return(nil, index.ErrTermsLimitExceeded),
+		exec.EXPECT().Close().Return(nil),
+	)
+	results := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+
+	ctx := context.NewContext()
+
+	exhaustive, err := b.Query(ctx, resource.NewCancellableLifetime(),
+		defaultQuery, QueryOptions{TermsLimit: 1}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+	require.Equal(t, 0, results.Map().Len())
+
+	ctx.BlockingClose()
+}
+
 func TestBlockMockQueryDocsLimitExhaustive(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -779,7 +860,7 @@ func TestBlockMockQueryDocsLimitExhaustive(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -831,7 +912,7 @@ func TestBlockMockQueryMergeResultsMapLimit(t *testing.T) {
 	require.NoError(t, b.Seal())
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -883,7 +964,7 @@ func TestBlockMockQueryMergeResultsDupeID(t *testing.T) {
 	require.True(t, ok)
 
 	exec := search.NewMockExecutor(ctrl)
-	b.newExecutorWithRLockFn = func() (search.Executor, error) {
+	b.newExecutorWithRLockFn = func(int) (search.Executor, error) {
 		return exec, nil
 	}
 
@@ -1893,6 +1974,61 @@ func TestBlockAggregateNotExhaustive(t *testing.T) {
 	require.Equal(t, tracepoint.BlockAggregate, spans[0].OperationName)
 }
 
+func TestBlockAggregateTermsLimitNotExhaustive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	seg1 := segment.NewMockMutableSegment(ctrl)
+	seg2 := segment.NewMockMutableSegment(ctrl)
+
+	b.mutableSegments.foregroundSegments = []*readableSeg{
+		newReadableSeg(seg1, testOpts),
+		newReadableSeg(seg2, testOpts),
+	}
+	iter := NewMockfieldsAndTermsIterator(ctrl)
+	b.newFieldsAndTermsIteratorFn = func(
+		s segment.Segment, opts fieldsAndTermsIteratorOpts) (fieldsAndTermsIterator, error) {
+		return iter, nil
+	}
+
+	results := NewAggregateResults(ident.StringID("ns"), AggregateResultsOptions{
+		Type: AggregateTagNamesAndValues,
+	}, testOpts)
+
+	// Expect the second segment to be skipped once two terms are scanned.
+	gomock.InOrder(
+		iter.EXPECT().Reset(seg1, gomock.Any()).Return(nil),
+		iter.EXPECT().Next().Return(true),
+		iter.EXPECT().Current().Return([]byte("f1"), []byte("t1")),
+		iter.EXPECT().Next().Return(true),
+		iter.EXPECT().Current().Return([]byte("f1"), []byte("t2")),
+		iter.EXPECT().Next().Return(true),
+		iter.EXPECT().Err().Return(nil),
+		iter.EXPECT().Close().Return(nil),
+	)
+
+	ctx := context.NewContext()
+	defer ctx.BlockingClose()
+
+	exhaustive, err := b.Aggregate(ctx, resource.NewCancellableLifetime(),
+		QueryOptions{TermsLimit: 2}, results, emptyLogFields)
+	require.NoError(t, err)
+	require.False(t, exhaustive)
+
+	assertAggregateResultsMapEquals(t, map[string][]string{
+		"f1": []string{"t1", "t2"},
+	}, results)
+}
+
 func TestBlockE2EInsertAggregate(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
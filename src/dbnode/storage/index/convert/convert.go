@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/intern"
 	"github.com/m3db/m3/src/x/pool"
 )
 
@@ -107,26 +108,22 @@ func ValidateSeriesTag(tag ident.Tag) error {
 
 // FromSeriesIDAndTags converts the provided series id+tags into a document.
 func FromSeriesIDAndTags(id ident.ID, tags ident.Tags) (doc.Document, error) {
+	return FromSeriesIDAndTagsWithInterner(id, tags, nil)
+}
+
+// FromSeriesIDAndTagsWithInterner converts the provided series id+tags into
+// a document, interning tag names and values that are not part of the id.
+func FromSeriesIDAndTagsWithInterner(
+	id ident.ID,
+	tags ident.Tags,
+	interner *intern.Cache,
+) (doc.Document, error) {
 	clonedID := clone(id)
 	fields := make([]doc.Field, 0, len(tags.Values()))
 	for _, tag := range tags.Values() {
-		nameBytes, valueBytes := tag.Name.Bytes(), tag.Value.Bytes()
-
-		var clonedName, clonedValue []byte
-		if idx := bytes.Index(clonedID, nameBytes); idx != -1 {
-			clonedName = clonedID[idx : idx+len(nameBytes)]
-		} else {
-			clonedName = append([]byte(nil), nameBytes...)
-		}
-		if idx := bytes.Index(clonedID, valueBytes); idx != -1 {
-			clonedValue = clonedID[idx : idx+len(valueBytes)]
-		} else {
-			clonedValue = append([]byte(nil), valueBytes...)
-		}
-
 		fields = append(fields, doc.Field{
-			Name:  clonedName,
-			Value: clonedValue,
+			Name:  cloneFromID(clonedID, tag.Name.Bytes(), interner),
+			Value: cloneFromID(clonedID, tag.Value.Bytes(), interner),
 		})
 	}
 
@@ -142,27 +139,23 @@ func FromSeriesIDAndTags(id ident.ID, tags ident.Tags) (doc.Document, error) {
 
 // FromSeriesIDAndTagIter converts the provided series id+tags into a document.
 func FromSeriesIDAndTagIter(id ident.ID, tags ident.TagIterator) (doc.Document, error) {
+	return FromSeriesIDAndTagIterWithInterner(id, tags, nil)
+}
+
+// FromSeriesIDAndTagIterWithInterner converts the provided series id+tags
+// into a document, interning tag names and values that are not part of the id.
+func FromSeriesIDAndTagIterWithInterner(
+	id ident.ID,
+	tags ident.TagIterator,
+	interner *intern.Cache,
+) (doc.Document, error) {
 	clonedID := clone(id)
 	fields := make([]doc.Field, 0, tags.Remaining())
 	for tags.Next() {
 		tag := tags.Current()
-		nameBytes, valueBytes := tag.Name.Bytes(), tag.Value.Bytes()
-
-		var clonedName, clonedValue []byte
-		if idx := bytes.Index(clonedID, nameBytes); idx != -1 {
-			clonedName = clonedID[idx : idx+len(nameBytes)]
-		} else {
-			clonedName = append([]byte(nil), nameBytes...)
-		}
-		if idx := bytes.Index(clonedID, valueBytes); idx != -1 {
-			clonedValue = clonedID[idx : idx+len(valueBytes)]
-		} else {
-			clonedValue = append([]byte(nil), valueBytes...)
-		}
-
 		fields = append(fields, doc.Field{
-			Name:  clonedName,
-			Value: clonedValue,
+			Name:  cloneFromID(clonedID, tag.Name.Bytes(), interner),
+			Value: cloneFromID(clonedID, tag.Value.Bytes(), interner),
 		})
 	}
 	if err := tags.Err(); err != nil {
@@ -242,6 +235,15 @@ func TagsFromTagsIter(
 	return tags, nil
 }
 
+// cloneFromID returns the bytes referencing the cloned id if they are part
+// of it, otherwise the interned bytes which are copied if interning is off.
+func cloneFromID(clonedID []byte, b []byte, interner *intern.Cache) []byte {
+	if idx := bytes.Index(clonedID, b); idx != -1 {
+		return clonedID[idx : idx+len(b)]
+	}
+	return interner.Intern(b)
+}
+
 // NB(prateek): we take an independent copy of the bytes underlying
 // any ids provided, as we need to maintain the lifecycle of the indexed
 // bytes separately from the rest of the storage subsystem.
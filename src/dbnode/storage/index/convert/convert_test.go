@@ -28,10 +28,12 @@ import (
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/intern"
 	"github.com/m3db/m3/src/x/pool"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 var (
@@ -93,6 +95,29 @@ func TestFromSeriesIDAndTagIterValid(t *testing.T) {
 	assert.Equal(t, "baz", string(d.Fields[0].Value))
 }
 
+func TestFromSeriesIDAndTagsWithInternerSharesTags(t *testing.T) {
+	interner, err := intern.NewCache(16, 16, tally.NoopScope)
+	require.NoError(t, err)
+
+	tags := ident.NewTags(
+		ident.StringTag("bar", "baz"),
+	)
+	d1, err := convert.FromSeriesIDAndTagsWithInterner(ident.StringID("foo"), tags, interner)
+	require.NoError(t, err)
+	d2, err := convert.FromSeriesIDAndTagIterWithInterner(ident.StringID("qux"),
+		ident.NewTagsIterator(tags), interner)
+	require.NoError(t, err)
+
+	for _, d := range []doc.Document{d1, d2} {
+		require.Len(t, d.Fields, 1)
+		assert.Equal(t, "bar", string(d.Fields[0].Name))
+		assert.Equal(t, "baz", string(d.Fields[0].Value))
+	}
+	// Tags not part of the id are shared across documents.
+	assert.True(t, &d1.Fields[0].Name[0] == &d2.Fields[0].Name[0])
+	assert.True(t, &d1.Fields[0].Value[0] == &d2.Fields[0].Value[0])
+}
+
 func TestToSeriesValid(t *testing.T) {
 	d := doc.Document{
 		ID: []byte("foo"),
@@ -1682,3 +1682,31 @@ func (mr *MockOptionsMockRecorder) QueryStats() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryStats", reflect.TypeOf((*MockOptions)(nil).QueryStats))
 }
+
+// SetFlushMaxSegmentDocs mocks base method
+func (m *MockOptions) SetFlushMaxSegmentDocs(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFlushMaxSegmentDocs", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushMaxSegmentDocs indicates an expected call of SetFlushMaxSegmentDocs
+func (mr *MockOptionsMockRecorder) SetFlushMaxSegmentDocs(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushMaxSegmentDocs", reflect.TypeOf((*MockOptions)(nil).SetFlushMaxSegmentDocs), value)
+}
+
+// FlushMaxSegmentDocs mocks base method
+func (m *MockOptions) FlushMaxSegmentDocs() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushMaxSegmentDocs")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// FlushMaxSegmentDocs indicates an expected call of FlushMaxSegmentDocs
+func (mr *MockOptionsMockRecorder) FlushMaxSegmentDocs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushMaxSegmentDocs", reflect.TypeOf((*MockOptions)(nil).FlushMaxSegmentDocs))
+}
@@ -125,6 +125,7 @@ type opts struct {
 	readThroughSegmentOptions       ReadThroughSegmentOptions
 	mmapReporter                    mmap.Reporter
 	queryStats                      stats.QueryStats
+	flushMaxSegmentDocs             int
 }
 
 var undefinedUUIDFn = func() ([]byte, error) { return nil, errIDGenerationDisabled }
@@ -431,3 +432,13 @@ func (o *opts) SetQueryStats(value stats.QueryStats) Options {
 func (o *opts) QueryStats() stats.QueryStats {
 	return o.queryStats
 }
+
+func (o *opts) SetFlushMaxSegmentDocs(value int) Options {
+	opts := *o
+	opts.flushMaxSegmentDocs = value
+	return &opts
+}
+
+func (o *opts) FlushMaxSegmentDocs() int {
+	return o.flushMaxSegmentDocs
+}
@@ -41,6 +41,9 @@ var (
 // and mmap's can be freed.
 var _ segment.ImmutableSegment = (*ReadThroughSegment)(nil)
 
+// Ensure the reader can bound the terms scanned by regexp matches.
+var _ index.LimitedRegexpReadable = (*readThroughSegmentReader)(nil)
+
 // ReadThroughSegment wraps a segment with a postings list cache so that
 // queries can be transparently cached in a read through manner. In addition,
 // the postings lists returned by the segments may not be safe to use once the
@@ -204,6 +207,40 @@ func (s *readThroughSegmentReader) MatchRegexp(
 	return pl, err
 }
 
+// MatchRegexpWithLimit returns a cached posting list or queries the underlying
+// segment with the given terms limit if their is a cache miss, cached posting
+// lists do not count towards the terms scanned.
+func (s *readThroughSegmentReader) MatchRegexpWithLimit(
+	field []byte,
+	c index.CompiledRegex,
+	limit int,
+) (postings.List, int, error) {
+	limited, ok := s.reader.(index.LimitedRegexpReadable)
+	if !ok {
+		// Unable to bound the underlying scan, count it as a single term.
+		pl, err := s.MatchRegexp(field, c)
+		return pl, 1, err
+	}
+
+	if s.postingsListCache == nil || !s.opts.CacheRegexp {
+		return limited.MatchRegexpWithLimit(field, c, limit)
+	}
+
+	// TODO(rartoul): Would be nice to not allocate strings here.
+	fieldStr := string(field)
+	patternStr := c.FSTSyntax.String()
+	pl, ok := s.postingsListCache.GetRegexp(s.uuid, fieldStr, patternStr)
+	if ok {
+		return pl, 0, nil
+	}
+
+	pl, n, err := limited.MatchRegexpWithLimit(field, c, limit)
+	if err == nil {
+		s.postingsListCache.PutRegexp(s.uuid, fieldStr, patternStr, pl)
+	}
+	return pl, n, err
+}
+
 // MatchTerm returns a cached posting list or queries the underlying
 // segment if their is a cache miss.
 func (s *readThroughSegmentReader) MatchTerm(
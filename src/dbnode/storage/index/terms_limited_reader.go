@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/postings"
+)
+
+// termsLimit tracks the terms scanned by a single query across all the
+// readers of a block, it is not safe for concurrent use as the executor
+// searches its readers one at a time.
+type termsLimit struct {
+	limit   int
+	scanned int
+}
+
+func (l *termsLimit) remaining() int {
+	return l.limit - l.scanned
+}
+
+func (l *termsLimit) add(n int) error {
+	l.scanned += n
+	if l.scanned > l.limit {
+		return index.ErrTermsLimitExceeded
+	}
+	return nil
+}
+
+// newTermsLimitedReaders wraps the readers so that the query they are used
+// for fails with index.ErrTermsLimitExceeded once it has scanned more than
+// limit terms, a zero limit returns the readers as is.
+func newTermsLimitedReaders(readers []index.Reader, limit int) []index.Reader {
+	if limit <= 0 {
+		return readers
+	}
+	l := &termsLimit{limit: limit}
+	for i, reader := range readers {
+		readers[i] = &termsLimitedReader{reader: reader, limit: l}
+	}
+	return readers
+}
+
+type termsLimitedReader struct {
+	// reader is explicitly not embedded at the top level
+	// of the struct to force new methods added to index.Reader
+	// to be explicitly accounted for by the terms limit.
+	reader index.Reader
+	limit  *termsLimit
+}
+
+func (r *termsLimitedReader) MatchField(field []byte) (postings.List, error) {
+	if err := r.limit.add(1); err != nil {
+		return nil, err
+	}
+	return r.reader.MatchField(field)
+}
+
+func (r *termsLimitedReader) MatchTerm(field, term []byte) (postings.List, error) {
+	if err := r.limit.add(1); err != nil {
+		return nil, err
+	}
+	return r.reader.MatchTerm(field, term)
+}
+
+func (r *termsLimitedReader) MatchRegexp(
+	field []byte,
+	c index.CompiledRegex,
+) (postings.List, error) {
+	limited, ok := r.reader.(index.LimitedRegexpReadable)
+	if !ok {
+		// Unable to bound the underlying scan, count it as a single term.
+		if err := r.limit.add(1); err != nil {
+			return nil, err
+		}
+		return r.reader.MatchRegexp(field, c)
+	}
+
+	remaining := r.limit.remaining()
+	if remaining <= 0 {
+		// NB: a zero limit means no limit to the underlying reader.
+		return nil, index.ErrTermsLimitExceeded
+	}
+
+	pl, n, err := limited.MatchRegexpWithLimit(field, c, remaining)
+	r.limit.scanned += n
+	return pl, err
+}
+
+func (r *termsLimitedReader) MatchAll() (postings.MutableList, error) {
+	return r.reader.MatchAll()
+}
+
+func (r *termsLimitedReader) Doc(id postings.ID) (doc.Document, error) {
+	return r.reader.Doc(id)
+}
+
+func (r *termsLimitedReader) Docs(pl postings.List) (doc.Iterator, error) {
+	return r.reader.Docs(pl)
+}
+
+func (r *termsLimitedReader) AllDocs() (index.IDDocIterator, error) {
+	return r.reader.AllDocs()
+}
+
+func (r *termsLimitedReader) Close() error {
+	return r.reader.Close()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/index"
+	"github.com/m3db/m3/src/m3ninx/postings"
+	"github.com/m3db/m3/src/m3ninx/postings/roaring"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testLimitedRegexpReader struct {
+	*index.MockReader
+
+	terms int
+}
+
+func (r *testLimitedRegexpReader) MatchRegexpWithLimit(
+	field []byte,
+	c index.CompiledRegex,
+	limit int,
+) (postings.List, int, error) {
+	if limit > 0 && r.terms > limit {
+		return nil, limit, index.ErrTermsLimitExceeded
+	}
+	return roaring.NewPostingsList(), r.terms, nil
+}
+
+func TestTermsLimitedReadersNoLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	reader := index.NewMockReader(ctrl)
+	readers := newTermsLimitedReaders([]index.Reader{reader}, 0)
+	require.Equal(t, []index.Reader{reader}, readers)
+}
+
+func TestTermsLimitedReadersMatchTerm(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		r1 = index.NewMockReader(ctrl)
+		r2 = index.NewMockReader(ctrl)
+		pl = roaring.NewPostingsList()
+	)
+	r1.EXPECT().MatchTerm([]byte("foo"), []byte("bar")).Return(pl, nil)
+	r2.EXPECT().MatchField([]byte("foo")).Return(pl, nil)
+
+	// The limit is shared across the readers of a query.
+	readers := newTermsLimitedReaders([]index.Reader{r1, r2}, 2)
+	_, err := readers[0].MatchTerm([]byte("foo"), []byte("bar"))
+	require.NoError(t, err)
+	_, err = readers[1].MatchField([]byte("foo"))
+	require.NoError(t, err)
+	_, err = readers[1].MatchTerm([]byte("foo"), []byte("baz"))
+	require.Equal(t, index.ErrTermsLimitExceeded, err)
+}
+
+func TestTermsLimitedReadersMatchRegexp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	re, err := index.CompileRegex([]byte("b.*"))
+	require.NoError(t, err)
+
+	var (
+		r1 = &testLimitedRegexpReader{MockReader: index.NewMockReader(ctrl), terms: 3}
+		r2 = &testLimitedRegexpReader{MockReader: index.NewMockReader(ctrl), terms: 3}
+	)
+	readers := newTermsLimitedReaders([]index.Reader{r1, r2}, 5)
+	_, err = readers[0].MatchRegexp([]byte("foo"), re)
+	require.NoError(t, err)
+	_, err = readers[1].MatchRegexp([]byte("foo"), re)
+	require.Equal(t, index.ErrTermsLimitExceeded, err)
+
+	// Once exhausted the limit must not be passed down as unlimited.
+	_, err = readers[1].MatchRegexp([]byte("foo"), re)
+	require.Equal(t, index.ErrTermsLimitExceeded, err)
+}
+
+func TestTermsLimitedReadersMatchRegexpNotLimitable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	re, err := index.CompileRegex([]byte("b.*"))
+	require.NoError(t, err)
+
+	reader := index.NewMockReader(ctrl)
+	reader.EXPECT().MatchRegexp([]byte("foo"), gomock.Any()).
+		Return(roaring.NewPostingsList(), nil)
+
+	readers := newTermsLimitedReaders([]index.Reader{reader}, 1)
+	_, err = readers[0].MatchRegexp([]byte("foo"), re)
+	require.NoError(t, err)
+	_, err = readers[0].MatchRegexp([]byte("foo"), re)
+	require.Equal(t, index.ErrTermsLimitExceeded, err)
+}
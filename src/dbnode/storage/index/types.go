@@ -84,6 +84,7 @@ type QueryOptions struct {
 	EndExclusive      time.Time
 	SeriesLimit       int
 	DocsLimit         int
+	TermsLimit        int
 	RequireExhaustive bool
 	IterationOptions  IterationOptions
 }
@@ -105,6 +106,13 @@ func (o QueryOptions) DocsLimitExceeded(size int) bool {
 	return o.DocsLimit > 0 && size >= o.DocsLimit
 }
 
+// TermsLimitExceeded returns whether a given number of terms scanned exceeds
+// the terms limit the query options imposes, if it is enabled. The limit
+// applies to the terms scanned within each index block queried.
+func (o QueryOptions) TermsLimitExceeded(size int) bool {
+	return o.TermsLimit > 0 && size >= o.TermsLimit
+}
+
 // AggregationOptions enables users to specify constraints on aggregations.
 type AggregationOptions struct {
 	QueryOptions
@@ -940,4 +948,12 @@ type Options interface {
 
 	// QueryStats returns the current query stats.
 	QueryStats() stats.QueryStats
+
+	// SetFlushMaxSegmentDocs sets the max number of documents written to a
+	// single segment of an index volume when flushing, zero means no limit.
+	SetFlushMaxSegmentDocs(value int) Options
+
+	// FlushMaxSegmentDocs returns the max number of documents written to a
+	// single segment of an index volume when flushing, zero means no limit.
+	FlushMaxSegmentDocs() int
 }
@@ -21,6 +21,7 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -36,6 +37,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
 	idxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
@@ -312,6 +314,135 @@ func TestNamespaceIndexFlushShardStateNotSuccess(t *testing.T) {
 	require.NoError(t, idx.WarmFlush(mockFlush, shards))
 }
 
+func TestNamespaceIndexFlushMaxSegmentDocs(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+
+	now := time.Now().Truncate(test.indexBlockSize)
+	idx := test.index.(*nsIndex)
+	idx.opts = idx.opts.SetIndexOptions(idx.opts.IndexOptions().SetFlushMaxSegmentDocs(2))
+
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	blockStart := now.Add(-test.indexBlockSize)
+	mockBlock := index.NewMockBlock(ctrl)
+	mockBlock.EXPECT().StartTime().Return(blockStart).AnyTimes()
+	mockBlock.EXPECT().EndTime().Return(blockStart.Add(test.indexBlockSize)).AnyTimes()
+
+	// Return three series over two pages.
+	mockShard := NewMockdatabaseShard(ctrl)
+	mockShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	opts := block.FetchBlocksMetadataOptions{OnlyDisk: true}
+	gomock.InOrder(
+		mockShard.EXPECT().FetchBlocksMetadataV2(gomock.Any(), blockStart,
+			blockStart.Add(test.indexBlockSize), gomock.Any(), PageToken(nil), opts).
+			Return(newTestFlushResults("foo", "bar"), PageToken("token"), nil),
+		mockShard.EXPECT().FetchBlocksMetadataV2(gomock.Any(), blockStart,
+			blockStart.Add(test.indexBlockSize), gomock.Any(), PageToken("token"), opts).
+			Return(newTestFlushResults("baz"), nil, nil),
+	)
+
+	// The block is written as a single volume with a segment per page.
+	var segmentDocs []int
+	mockFlush := persist.NewMockIndexFlush(ctrl)
+	mockFlush.EXPECT().PrepareIndex(xtest.CmpMatcher(persist.IndexPrepareOptions{
+		NamespaceMetadata: idx.nsMetadata,
+		BlockStart:        blockStart,
+		FileSetType:       persist.FileSetFlushType,
+		Shards:            map[uint32]struct{}{0: struct{}{}},
+		IndexVolumeType:   idxpersist.DefaultIndexVolumeType,
+	})).Return(persist.PreparedIndexPersist{
+		Persist: func(b segment.Builder) error {
+			segmentDocs = append(segmentDocs, len(b.Docs()))
+			return nil
+		},
+		Close: func() ([]segment.Segment, error) {
+			return []segment.Segment{
+				segment.NewMockSegment(ctrl),
+				segment.NewMockSegment(ctrl),
+			}, nil
+		},
+	}, nil)
+
+	b, err := builder.NewBuilderFromDocuments(idx.opts.IndexOptions().SegmentBuilderOptions())
+	require.NoError(t, err)
+	defer b.Close()
+
+	segments, err := idx.flushBlock(mockFlush, mockBlock, []databaseShard{mockShard}, b)
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	require.Equal(t, []int{2, 1}, segmentDocs)
+}
+
+func TestNamespaceIndexFlushMaxSegmentDocsErrorLeavesVolumeIncomplete(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+
+	now := time.Now().Truncate(test.indexBlockSize)
+	idx := test.index.(*nsIndex)
+	idx.opts = idx.opts.SetIndexOptions(idx.opts.IndexOptions().SetFlushMaxSegmentDocs(2))
+
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	blockStart := now.Add(-test.indexBlockSize)
+	mockBlock := index.NewMockBlock(ctrl)
+	mockBlock.EXPECT().StartTime().Return(blockStart).AnyTimes()
+	mockBlock.EXPECT().EndTime().Return(blockStart.Add(test.indexBlockSize)).AnyTimes()
+
+	// Fail reading the second page after the first segment is persisted.
+	mockShard := NewMockdatabaseShard(ctrl)
+	mockShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	opts := block.FetchBlocksMetadataOptions{OnlyDisk: true}
+	gomock.InOrder(
+		mockShard.EXPECT().FetchBlocksMetadataV2(gomock.Any(), blockStart,
+			blockStart.Add(test.indexBlockSize), gomock.Any(), PageToken(nil), opts).
+			Return(newTestFlushResults("foo", "bar"), PageToken("token"), nil),
+		mockShard.EXPECT().FetchBlocksMetadataV2(gomock.Any(), blockStart,
+			blockStart.Add(test.indexBlockSize), gomock.Any(), PageToken("token"), opts).
+			Return(block.FetchBlocksMetadataResults(nil), nil, errors.New("read error")),
+	)
+
+	// The volume must not be closed, which would write its checkpoint file
+	// and mark the partially written volume as complete.
+	var persisted int
+	mockFlush := persist.NewMockIndexFlush(ctrl)
+	mockFlush.EXPECT().PrepareIndex(gomock.Any()).Return(persist.PreparedIndexPersist{
+		Persist: func(b segment.Builder) error {
+			persisted++
+			return nil
+		},
+		Close: func() ([]segment.Segment, error) {
+			require.FailNow(t, "unexpected close of incomplete volume")
+			return nil, nil
+		},
+	}, nil)
+
+	b, err := builder.NewBuilderFromDocuments(idx.opts.IndexOptions().SegmentBuilderOptions())
+	require.NoError(t, err)
+	defer b.Close()
+
+	_, err = idx.flushBlock(mockFlush, mockBlock, []databaseShard{mockShard}, b)
+	require.Error(t, err)
+	require.Equal(t, 1, persisted)
+}
+
+func newTestFlushResults(ids ...string) block.FetchBlocksMetadataResults {
+	results := block.NewFetchBlocksMetadataResults()
+	for _, id := range ids {
+		results.Add(block.NewFetchBlocksMetadataResult(ident.StringID(id),
+			ident.EmptyTagIterator, nil))
+	}
+	return results
+}
+
 func TestNamespaceIndexQueryNoMatchingBlocks(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{T: t})
 	defer ctrl.Finish()
@@ -83,6 +83,7 @@ type mediator struct {
 	databaseTickManager
 	databaseRepairer
 
+	scrubber                 databaseScrubber
 	opts                     Options
 	nowFn                    clock.NowFn
 	sleepFn                  sleepFn
@@ -125,6 +126,11 @@ func newMediator(database database, commitlog commitlog.CommitLog, opts Options)
 		}
 	}
 
+	d.scrubber = newNoopDatabaseScrubber()
+	if opts.ScrubOptions().Enabled {
+		d.scrubber = newDatabaseScrubber(database, d.databaseRepairer, opts)
+	}
+
 	d.databaseTickManager = newTickManager(database, opts)
 	d.databaseBootstrapManager = newBootstrapManager(database, d, opts)
 	return d, nil
@@ -141,6 +147,7 @@ func (m *mediator) Open() error {
 	go m.ongoingFileSystemProcesses()
 	go m.ongoingTick()
 	m.databaseRepairer.Start()
+	m.scrubber.Start()
 	return nil
 }
 
@@ -159,6 +166,7 @@ func (m *mediator) EnableFileOps() {
 func (m *mediator) Report() {
 	m.databaseBootstrapManager.Report()
 	m.databaseRepairer.Report()
+	m.scrubber.Report()
 	m.databaseFileSystemManager.Report()
 }
 
@@ -186,6 +194,7 @@ func (m *mediator) Close() error {
 	m.state = mediatorClosed
 	close(m.closedCh)
 	m.databaseRepairer.Stop()
+	m.scrubber.Stop()
 	return nil
 }
 
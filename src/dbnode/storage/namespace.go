@@ -26,12 +26,14 @@ import (
 	"math"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
@@ -122,6 +124,11 @@ type dbNamespace struct {
 	schemaListener xclose.SimpleCloser
 	schemaDescr    namespace.SchemaDescr
 
+	// state is the namespace.State gating writes and reads, it is read on
+	// every write so is accessed atomically rather than under the lock.
+	state                   uint32
+	runtimeOptsListenCloser xclose.SimpleCloser
+
 	// Contains an entry to all shards for fast shard lookup, an
 	// entry will be nil when this shard does not belong to current database
 	shards []databaseShard
@@ -163,6 +170,8 @@ type databaseNamespaceMetrics struct {
 	fetchBlocksMetadata instrument.MethodMetrics
 	queryIDs            instrument.MethodMetrics
 	aggregateQuery      instrument.MethodMetrics
+	writesRejected      tally.Counter
+	readsRejected       tally.Counter
 	unfulfilled         tally.Counter
 	bootstrapStart      tally.Counter
 	bootstrapEnd        tally.Counter
@@ -245,6 +254,8 @@ func newDatabaseNamespaceMetrics(
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", opts),
 		queryIDs:            instrument.NewMethodMetrics(scope, "queryIDs", opts),
 		aggregateQuery:      instrument.NewMethodMetrics(scope, "aggregateQuery", opts),
+		writesRejected:      scope.Counter("state.writes-rejected"),
+		readsRejected:       scope.Counter("state.reads-rejected"),
 		unfulfilled:         scope.Counter("bootstrap.unfulfilled"),
 		bootstrapStart:      scope.Counter("bootstrap.start"),
 		bootstrapEnd:        scope.Counter("bootstrap.end"),
@@ -365,6 +376,7 @@ func newDatabaseNamespace(
 			metadata.ID().String(), err)
 	}
 	n.schemaListener = sl
+	n.runtimeOptsListenCloser = opts.RuntimeOptionsManager().RegisterListener(n)
 	n.assignShardSet(shardSet, assignShardSetOptions{
 		needsBootstrap:    nopts.BootstrapEnabled(),
 		initialAssignment: true,
@@ -395,6 +407,38 @@ func (n *dbNamespace) SetSchemaHistory(value namespace.SchemaHistory) {
 	n.metadata = metadata
 }
 
+// SetRuntimeOptions implements runtime.OptionsListener.
+func (n *dbNamespace) SetRuntimeOptions(value m3dbruntime.Options) {
+	state := value.NamespaceState(n.id.String())
+	prev := namespace.State(atomic.SwapUint32(&n.state, uint32(state)))
+	if prev != state {
+		n.log.Info("namespace state changed",
+			zap.Stringer("from", prev), zap.Stringer("to", state))
+	}
+}
+
+// checkAcceptsWrites returns an error if the namespace state rejects writes.
+func (n *dbNamespace) checkAcceptsWrites() error {
+	state := namespace.State(atomic.LoadUint32(&n.state))
+	if state.AcceptsWrites() {
+		return nil
+	}
+	n.metrics.writesRejected.Inc(1)
+	return xerrors.NewInvalidParamsError(fmt.Errorf(
+		"namespace %s is %s and does not accept writes", n.id.String(), state))
+}
+
+// checkAcceptsReads returns an error if the namespace state rejects reads.
+func (n *dbNamespace) checkAcceptsReads() error {
+	state := namespace.State(atomic.LoadUint32(&n.state))
+	if state.AcceptsReads() {
+		return nil
+	}
+	n.metrics.readsRejected.Inc(1)
+	return xerrors.NewInvalidParamsError(fmt.Errorf(
+		"namespace %s is %s and does not accept reads", n.id.String(), state))
+}
+
 func (n *dbNamespace) reportStatusLoop(reportInterval time.Duration) {
 	ticker := time.NewTicker(reportInterval)
 	defer ticker.Stop()
@@ -666,6 +710,10 @@ func (n *dbNamespace) Write(
 	annotation []byte,
 ) (SeriesWrite, error) {
 	callStart := n.nowFn()
+	if err := n.checkAcceptsWrites(); err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return SeriesWrite{}, err
+	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
@@ -695,6 +743,10 @@ func (n *dbNamespace) WriteTagged(
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
 		return SeriesWrite{}, errNamespaceIndexingDisabled
 	}
+	if err := n.checkAcceptsWrites(); err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return SeriesWrite{}, err
+	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
@@ -765,6 +817,12 @@ func (n *dbNamespace) QueryIDs(
 		return index.QueryResult{}, err
 	}
 
+	if err := n.checkAcceptsReads(); err != nil {
+		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
+		sp.LogFields(opentracinglog.Error(err))
+		return index.QueryResult{}, err
+	}
+
 	if n.reverseIndex.BootstrapsDone() < 1 {
 		// Similar to reading shard data, return not bootstrapped
 		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
@@ -777,11 +835,40 @@ func (n *dbNamespace) QueryIDs(
 	res, err := n.reverseIndex.Query(ctx, query, opts)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
+	} else {
+		n.removeTombstonedSeries(res.Results, opts)
 	}
 	n.metrics.queryIDs.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return res, err
 }
 
+// removeTombstonedSeries removes the series whose datapoints within the query
+// range are all deleted by series tombstones, the index keeps their IDs and
+// tags until the series expire from the index.
+func (n *dbNamespace) removeTombstonedSeries(
+	results index.QueryResults,
+	opts index.QueryOptions,
+) {
+	tombstones := n.opts.RuntimeOptionsManager().Get().SeriesTombstones()
+	if results == nil || !tombstones.HasNamespace(n.id) {
+		return
+	}
+
+	var (
+		queryRange = xtime.Range{Start: opts.StartInclusive, End: opts.EndExclusive}
+		removed    []ident.ID
+	)
+	for _, entry := range results.Map().Iter() {
+		id := entry.Key()
+		if namespace.RangesCover(tombstones.SeriesRanges(n.id, id), queryRange) {
+			removed = append(removed, id)
+		}
+	}
+	for _, id := range removed {
+		results.Map().Delete(id)
+	}
+}
+
 func (n *dbNamespace) AggregateQuery(
 	ctx context.Context,
 	query index.Query,
@@ -793,6 +880,11 @@ func (n *dbNamespace) AggregateQuery(
 		return index.AggregateQueryResult{}, errNamespaceIndexingDisabled
 	}
 
+	if err := n.checkAcceptsReads(); err != nil {
+		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
+		return index.AggregateQueryResult{}, err
+	}
+
 	if n.reverseIndex.BootstrapsDone() < 1 {
 		// Similar to reading shard data, return not bootstrapped
 		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
@@ -849,6 +941,10 @@ func (n *dbNamespace) ReadEncoded(
 	start, end time.Time,
 ) ([][]xio.BlockReader, error) {
 	callStart := n.nowFn()
+	if err := n.checkAcceptsReads(); err != nil {
+		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
+		return nil, err
+	}
 	shard, nsCtx, err := n.readableShardFor(id)
 	if err != nil {
 		n.metrics.read.ReportError(n.nowFn().Sub(callStart))
@@ -1172,8 +1268,11 @@ func (n *dbNamespace) ColdFlush(flushPersist persist.FlushPreparer) error {
 	n.RUnlock()
 
 	// If repair is enabled we still need cold flush regardless of whether cold writes is
-	// enabled since repairs are dependent on the cold flushing logic.
-	if !n.nopts.ColdWritesEnabled() && !n.nopts.RepairEnabled() {
+	// enabled since repairs are dependent on the cold flushing logic, the same
+	// applies to purging datapoints deleted by series tombstones.
+	tombstones := n.opts.RuntimeOptionsManager().Get().SeriesTombstones()
+	if !n.nopts.ColdWritesEnabled() && !n.nopts.RepairEnabled() &&
+		!tombstones.HasNamespace(n.id) {
 		n.metrics.flushColdData.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
@@ -1547,6 +1646,9 @@ func (n *dbNamespace) Close() error {
 	n.shardSet = sharding.NewEmptyShardSet(sharding.DefaultHashFn(1))
 	n.Unlock()
 	n.namespaceReaderMgr.close()
+	if n.runtimeOptsListenCloser != nil {
+		n.runtimeOptsListenCloser.Close()
+	}
 	n.closeShards(shards, true)
 	close(n.shutdownCh)
 	if n.reverseIndex != nil {
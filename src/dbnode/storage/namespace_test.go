@@ -279,6 +279,52 @@ func TestNamespaceReadEncodedShardOwned(t *testing.T) {
 	require.Equal(t, errShardNotBootstrappedToRead, xerrors.GetInnerRetryableError(err))
 }
 
+func TestNamespaceStateGatesWritesAndReads(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	id := ident.StringID("foo")
+	now := time.Now()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	setState := func(state namespace.State) {
+		ns.SetRuntimeOptions(runtime.NewOptions().
+			SetNamespaceStates(map[string]namespace.State{
+				ns.ID().String(): state,
+			}))
+	}
+
+	setState(namespace.StateReadOnly)
+	_, err := ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+	shard.EXPECT().ReadEncoded(ctx, id, now, now, gomock.Any()).Return(nil, nil)
+	_, err = ns.ReadEncoded(ctx, id, now, now)
+	require.NoError(t, err)
+
+	setState(namespace.StateQuiesced)
+	_, err = ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.Error(t, err)
+	_, err = ns.ReadEncoded(ctx, id, now, now)
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+
+	setState(namespace.StateActive)
+	shard.EXPECT().Write(ctx, id, now, 1.0, xtime.Second, nil, gomock.Any()).
+		Return(SeriesWrite{WasWritten: true}, nil)
+	_, err = ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+}
+
 func TestNamespaceFetchBlocksShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -39,6 +39,9 @@ import (
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
+	"github.com/m3db/m3/src/dbnode/storage/cardinality"
+	"github.com/m3db/m3/src/dbnode/storage/downsample"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -48,6 +51,8 @@ import (
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
 	xsync "github.com/m3db/m3/src/x/sync"
@@ -131,6 +136,7 @@ type options struct {
 	transformOptions               series.WriteTransformOptions
 	indexOpts                      index.Options
 	repairOpts                     repair.Options
+	scrubOpts                      ScrubOptions
 	newEncoderFn                   encoding.NewEncoderFn
 	newDecoderFn                   encoding.NewDecoderFn
 	bootstrapProcessProvider       bootstrap.ProcessProvider
@@ -147,6 +153,10 @@ type options struct {
 	readerIteratorPool             encoding.ReaderIteratorPool
 	multiReaderIteratorPool        encoding.MultiReaderIteratorPool
 	identifierPool                 ident.Pool
+	tagInterner                    *intern.Cache
+	cardinalityEstimator           *cardinality.Estimator
+	hotShardDetector               *hotshards.Detector
+	flushDownsampler               *downsample.Downsampler
 	fetchBlockMetadataResultsPool  block.FetchBlockMetadataResultsPool
 	fetchBlocksMetadataResultsPool block.FetchBlocksMetadataResultsPool
 	queryIDsWorkerPool             xsync.WorkerPool
@@ -159,6 +169,7 @@ type options struct {
 	blockLeaseManager              block.LeaseManager
 	onColdFlush                    OnColdFlush
 	memoryTracker                  MemoryTracker
+	memoryMonitor                  memory.Monitor
 	mmapReporter                   mmap.Reporter
 	doNotIndexWithFieldsMap        map[string]string
 }
@@ -431,6 +442,16 @@ func (o *options) RepairOptions() repair.Options {
 	return o.repairOpts
 }
 
+func (o *options) SetScrubOptions(value ScrubOptions) Options {
+	opts := *o
+	opts.scrubOpts = value
+	return &opts
+}
+
+func (o *options) ScrubOptions() ScrubOptions {
+	return o.scrubOpts
+}
+
 func (o *options) SetEncodingM3TSZPooled() Options {
 	opts := *o
 
@@ -644,6 +665,46 @@ func (o *options) IdentifierPool() ident.Pool {
 	return o.identifierPool
 }
 
+func (o *options) SetTagInterner(value *intern.Cache) Options {
+	opts := *o
+	opts.tagInterner = value
+	return &opts
+}
+
+func (o *options) TagInterner() *intern.Cache {
+	return o.tagInterner
+}
+
+func (o *options) SetCardinalityEstimator(value *cardinality.Estimator) Options {
+	opts := *o
+	opts.cardinalityEstimator = value
+	return &opts
+}
+
+func (o *options) CardinalityEstimator() *cardinality.Estimator {
+	return o.cardinalityEstimator
+}
+
+func (o *options) SetHotShardDetector(value *hotshards.Detector) Options {
+	opts := *o
+	opts.hotShardDetector = value
+	return &opts
+}
+
+func (o *options) HotShardDetector() *hotshards.Detector {
+	return o.hotShardDetector
+}
+
+func (o *options) SetFlushDownsampler(value *downsample.Downsampler) Options {
+	opts := *o
+	opts.flushDownsampler = value
+	return &opts
+}
+
+func (o *options) FlushDownsampler() *downsample.Downsampler {
+	return o.flushDownsampler
+}
+
 func (o *options) SetFetchBlockMetadataResultsPool(value block.FetchBlockMetadataResultsPool) Options {
 	opts := *o
 	opts.fetchBlockMetadataResultsPool = value
@@ -764,6 +825,16 @@ func (o *options) MemoryTracker() MemoryTracker {
 	return o.memoryTracker
 }
 
+func (o *options) SetMemoryMonitor(value memory.Monitor) Options {
+	opts := *o
+	opts.memoryMonitor = value
+	return &opts
+}
+
+func (o *options) MemoryMonitor() memory.Monitor {
+	return o.memoryMonitor
+}
+
 func (o *options) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 	opts := *o
 	opts.mmapReporter = mmapReporter
@@ -506,6 +506,22 @@ func (r repairStatesByNs) setRepairState(
 	nsRepairState[xtime.ToUnixNano(t)] = state
 }
 
+func (r repairStatesByNs) deleteRepairState(
+	namespace ident.ID,
+	t time.Time,
+) {
+	nsRepairState, ok := r[namespace.String()]
+	if !ok {
+		return
+	}
+	delete(nsRepairState, xtime.ToUnixNano(t))
+}
+
+type scheduledRepair struct {
+	namespace  ident.ID
+	blockStart time.Time
+}
+
 // NB(prateek): dbRepairer.Repair(...) guarantees atomicity of execution, so all other
 // state does not need to be thread safe. One exception - `dbRepairer.closed` is used
 // for early termination if `dbRepairer.Stop()` is called during a repair, so we guard
@@ -528,6 +544,9 @@ type dbRepairer struct {
 	closedLock sync.Mutex
 	running    int32
 	closed     bool
+
+	scheduledLock sync.Mutex
+	scheduled     []scheduledRepair
 }
 
 func newDatabaseRepairer(database database, opts Options) (databaseRepairer, error) {
@@ -628,6 +647,15 @@ func (r *dbRepairer) Repair() error {
 		atomic.StoreInt32(&r.running, 0)
 	}()
 
+	// Forget the repair state of scheduled blocks so that they are repaired
+	// again ahead of the blocks that have already been repaired.
+	r.scheduledLock.Lock()
+	for _, scheduled := range r.scheduled {
+		r.repairStatesByNs.deleteRepairState(scheduled.namespace, scheduled.blockStart)
+	}
+	r.scheduled = nil
+	r.scheduledLock.Unlock()
+
 	multiErr := xerrors.NewMultiError()
 	namespaces, err := r.database.OwnedNamespaces()
 	if err != nil {
@@ -708,6 +736,15 @@ func (r *dbRepairer) Repair() error {
 	return multiErr.FinalError()
 }
 
+func (r *dbRepairer) ScheduleRepair(namespace ident.ID, blockStart time.Time) {
+	r.scheduledLock.Lock()
+	r.scheduled = append(r.scheduled, scheduledRepair{
+		namespace:  namespace,
+		blockStart: blockStart,
+	})
+	r.scheduledLock.Unlock()
+}
+
 func (r *dbRepairer) Report() {
 	if atomic.LoadInt32(&r.running) == 1 {
 		r.status.Update(1)
@@ -761,6 +798,8 @@ func (r repairerNoOp) Stop()         {}
 func (r repairerNoOp) Repair() error { return nil }
 func (r repairerNoOp) Report()       {}
 
+func (r repairerNoOp) ScheduleRepair(namespace ident.ID, blockStart time.Time) {}
+
 func (r shardRepairer) shadowCompare(
 	start time.Time,
 	end time.Time,
@@ -0,0 +1,294 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultScrubInterval is the default interval between scrubs.
+	defaultScrubInterval = 24 * time.Hour
+)
+
+var (
+	errScrubInProgress = errors.New("scrub already in progress")
+)
+
+type scrubMetrics struct {
+	status           tally.Gauge
+	filesetsValid    tally.Counter
+	filesetsCorrupt  tally.Counter
+	repairsTriggered tally.Counter
+}
+
+func newScrubMetrics(scope tally.Scope) scrubMetrics {
+	return scrubMetrics{
+		status:           scope.Gauge("scrub"),
+		filesetsValid:    scope.Counter("filesets-valid"),
+		filesetsCorrupt:  scope.Counter("filesets-corrupt"),
+		repairsTriggered: scope.Counter("repairs-triggered"),
+	}
+}
+
+// dbScrubber periodically validates the data filesets of the owned shards
+// against the digests in their checkpoint and digest files, so corrupt
+// volumes are found before they are needed by a read or a bootstrap.
+type dbScrubber struct {
+	database    database
+	repairer    databaseRepairer
+	opts        Options
+	scrubOpts   ScrubOptions
+	fsOpts      fs.Options
+	newReaderFn newFSReaderFn
+	sleepFn     sleepFn
+	logger      *zap.Logger
+	metrics     scrubMetrics
+
+	closedLock sync.Mutex
+	running    int32
+	closed     bool
+}
+
+type newFSReaderFn func(opts Options, fsOpts fs.Options) (fs.DataFileSetReader, error)
+
+func newFSReader(opts Options, fsOpts fs.Options) (fs.DataFileSetReader, error) {
+	return fs.NewReader(opts.BytesPool(), fsOpts)
+}
+
+func newDatabaseScrubber(
+	database database,
+	repairer databaseRepairer,
+	opts Options,
+) databaseScrubber {
+	scrubOpts := opts.ScrubOptions()
+	if scrubOpts.Interval <= 0 {
+		scrubOpts.Interval = defaultScrubInterval
+	}
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("scrub")
+	return &dbScrubber{
+		database:    database,
+		repairer:    repairer,
+		opts:        opts,
+		scrubOpts:   scrubOpts,
+		fsOpts:      opts.CommitLogOptions().FilesystemOptions(),
+		newReaderFn: newFSReader,
+		sleepFn:     time.Sleep,
+		logger:      opts.InstrumentOptions().Logger(),
+		metrics:     newScrubMetrics(scope),
+	}
+}
+
+func (s *dbScrubber) Start() {
+	go s.run()
+}
+
+func (s *dbScrubber) Stop() {
+	s.closedLock.Lock()
+	s.closed = true
+	s.closedLock.Unlock()
+}
+
+func (s *dbScrubber) isClosed() bool {
+	s.closedLock.Lock()
+	closed := s.closed
+	s.closedLock.Unlock()
+	return closed
+}
+
+func (s *dbScrubber) run() {
+	for !s.isClosed() {
+		s.sleepFn(s.scrubOpts.Interval)
+
+		if err := s.Scrub(); err != nil {
+			s.logger.Error("error scrubbing filesets", zap.Error(err))
+		}
+	}
+}
+
+func (s *dbScrubber) Report() {
+	if atomic.LoadInt32(&s.running) == 1 {
+		s.metrics.status.Update(1)
+	} else {
+		s.metrics.status.Update(0)
+	}
+}
+
+func (s *dbScrubber) Scrub() error {
+	// Don't scrub until the database is bootstrapped, the filesets are still
+	// being read and written by the bootstrap.
+	if !s.database.IsBootstrapped() {
+		return nil
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		return errScrubInProgress
+	}
+
+	defer func() {
+		atomic.StoreInt32(&s.running, 0)
+	}()
+
+	namespaces, err := s.database.OwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	reader, err := s.newReaderFn(s.opts, s.fsOpts)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range namespaces {
+		for _, shard := range n.OwnedShards() {
+			if s.isClosed() {
+				return nil
+			}
+			if err := s.scrubShard(reader, n, shard.ID()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *dbScrubber) scrubShard(
+	reader fs.DataFileSetReader,
+	n databaseNamespace,
+	shard uint32,
+) error {
+	filePathPrefix := s.fsOpts.FilePathPrefix()
+	filesets, err := fs.DataFiles(filePathPrefix, n.ID(), shard)
+	if err != nil {
+		return err
+	}
+
+	for _, fileset := range filesets {
+		// Filesets without a complete checkpoint file are still being
+		// written, or were never completed and are ignored by reads.
+		if !fileset.HasCompleteCheckpointFile() {
+			continue
+		}
+
+		err := s.validateFileset(reader, fileset.ID)
+		if err == nil {
+			s.metrics.filesetsValid.Inc(1)
+			continue
+		}
+
+		// Filesets can be removed by cleanup while being validated which
+		// is not corruption.
+		exists, existsErr := fs.DataFileSetExists(filePathPrefix, n.ID(),
+			shard, fileset.ID.BlockStart, fileset.ID.VolumeIndex)
+		if existsErr != nil {
+			return existsErr
+		}
+		if !exists {
+			continue
+		}
+
+		s.metrics.filesetsCorrupt.Inc(1)
+		s.logger.Error("scrub found corrupt fileset",
+			zap.Stringer("namespace", n.ID()),
+			zap.Uint32("shard", shard),
+			zap.Time("blockStart", fileset.ID.BlockStart),
+			zap.Int("volume", fileset.ID.VolumeIndex),
+			zap.Error(err))
+
+		if s.scrubOpts.RepairCorrupt {
+			s.metrics.repairsTriggered.Inc(1)
+			s.repairer.ScheduleRepair(n.ID(), fileset.ID.BlockStart)
+		}
+	}
+
+	return nil
+}
+
+func (s *dbScrubber) validateFileset(
+	reader fs.DataFileSetReader,
+	id fs.FileSetFileIdentifier,
+) (err error) {
+	// Open validates the info and digest files against the checkpoint file.
+	if err := reader.Open(fs.DataReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	if err := reader.ValidateMetadata(); err != nil {
+		return err
+	}
+
+	for {
+		id, tags, data, checksum, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		data.IncRef()
+		actual := digest.Checksum(data.Bytes())
+		data.DecRef()
+
+		id.Finalize()
+		tags.Close()
+		data.Finalize()
+
+		if actual != checksum {
+			return fmt.Errorf("entry checksum mismatch: expected=%d, actual=%d",
+				checksum, actual)
+		}
+	}
+
+	return reader.ValidateData()
+}
+
+var noOpScrubber databaseScrubber = scrubberNoOp{}
+
+type scrubberNoOp struct{}
+
+func newNoopDatabaseScrubber() databaseScrubber { return noOpScrubber }
+
+func (s scrubberNoOp) Start()       {}
+func (s scrubberNoOp) Stop()        {}
+func (s scrubberNoOp) Scrub() error { return nil }
+func (s scrubberNoOp) Report()      {}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	xtest "github.com/m3db/m3/src/x/test"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubberScrubShardFindsCorruptFileset(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	opts := DefaultTestOptions()
+	fsOpts := opts.CommitLogOptions().FilesystemOptions().
+		SetFilePathPrefix(dir)
+	opts = opts.
+		SetCommitLogOptions(opts.CommitLogOptions().SetFilesystemOptions(fsOpts)).
+		SetScrubOptions(ScrubOptions{Enabled: true, RepairCorrupt: true})
+
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+
+	var (
+		blockSize   = 2 * time.Hour
+		start       = time.Now().Truncate(blockSize)
+		blockStarts = []time.Time{start.Add(-2 * blockSize), start.Add(-blockSize)}
+	)
+	for _, blockStart := range blockStarts {
+		require.NoError(t, writer.Open(fs.DataWriterOpenOptions{
+			FileSetType: persist.FileSetFlushType,
+			BlockSize:   blockSize,
+			Identifier: fs.FileSetFileIdentifier{
+				Namespace:  defaultTestNs1ID,
+				Shard:      0,
+				BlockStart: blockStart,
+			},
+		}))
+		data := checked.NewBytes([]byte{1, 2, 3}, nil)
+		data.IncRef()
+		metadata := persist.NewMetadataFromIDAndTags(ident.StringID("foo"),
+			ident.Tags{}, persist.MetadataOptions{})
+		require.NoError(t, writer.Write(metadata, data, digest.Checksum(data.Bytes())))
+		require.NoError(t, writer.Close())
+	}
+
+	// Corrupt the data file of the second block.
+	filesets, err := fs.DataFiles(dir, defaultTestNs1ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(filesets))
+	var corrupted bool
+	for _, fileset := range filesets {
+		if !fileset.ID.BlockStart.Equal(blockStarts[1]) {
+			continue
+		}
+		for _, path := range fileset.AbsoluteFilePaths {
+			if !strings.HasSuffix(path, "-data.db") {
+				continue
+			}
+			require.NoError(t, ioutil.WriteFile(path, []byte{4, 5, 6}, 0666))
+			corrupted = true
+		}
+	}
+	require.True(t, corrupted)
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+
+	var scheduled []time.Time
+	repairer := NewMockdatabaseRepairer(ctrl)
+	repairer.EXPECT().ScheduleRepair(defaultTestNs1ID, gomock.Any()).
+		Do(func(_ ident.ID, blockStart time.Time) {
+			scheduled = append(scheduled, blockStart)
+		})
+
+	scrubber := newDatabaseScrubber(nil, repairer, opts).(*dbScrubber)
+	reader, err := scrubber.newReaderFn(opts, scrubber.fsOpts)
+	require.NoError(t, err)
+
+	require.NoError(t, scrubber.scrubShard(reader, ns, 0))
+	require.Equal(t, 1, len(scheduled))
+	require.True(t, scheduled[0].Equal(blockStarts[1]))
+}
@@ -286,6 +286,7 @@ func (b *dbBuffer) Write(
 	case timestamp.Before(pastLimit):
 		writeType = ColdWrite
 		if !b.opts.ColdWritesEnabled() {
+			b.opts.Stats().IncColdWritesRejected()
 			return false, writeType, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in past: "+
 					"id=%s, off_by=%s, timestamp=%s, past_limit=%s, "+
@@ -299,6 +300,7 @@ func (b *dbBuffer) Write(
 	case !futureLimit.After(timestamp):
 		writeType = ColdWrite
 		if !b.opts.ColdWritesEnabled() {
+			b.opts.Stats().IncColdWritesRejected()
 			return false, writeType, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in future: "+
 					"id=%s, off_by=%s, timestamp=%s, future_limit=%s, "+
@@ -322,6 +324,7 @@ func (b *dbBuffer) Write(
 			retentionLimit = retentionLimit.Truncate(blockSize)
 		}
 		if retentionLimit.After(timestamp) {
+			b.opts.Stats().IncOutOfRetentionWrites()
 			if wOpts.SkipOutOfRetention {
 				// Allow for datapoint to be skipped since caller does not
 				// want writes out of retention to fail.
@@ -339,6 +342,7 @@ func (b *dbBuffer) Write(
 
 		futureRetentionLimit := now.Add(ropts.FutureRetentionPeriod())
 		if !futureRetentionLimit.After(timestamp) {
+			b.opts.Stats().IncOutOfRetentionWrites()
 			if wOpts.SkipOutOfRetention {
 				// Allow for datapoint to be skipped since caller does not
 				// want writes out of retention to fail.
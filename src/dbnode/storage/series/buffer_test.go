@@ -44,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 var (
@@ -168,6 +169,37 @@ func TestBufferWriteTooPast(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), "past_limit="))
 }
 
+func TestBufferWriteColdDisabledIncrementsRejectedStat(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := newBufferTestOptions().SetStats(NewStats(scope))
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		Options: opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	wasWritten, writeType, err := buffer.Write(ctx, testID,
+		curr.Add(-1*rops.BufferPast()-time.Second), 1, xtime.Second,
+		nil, WriteOptions{})
+	assert.False(t, wasWritten)
+	assert.Equal(t, ColdWrite, writeType)
+	require.Error(t, err)
+
+	counters := scope.Snapshot().Counters()
+	rejected, ok := counters["series.cold-writes-rejected+"]
+	require.True(t, ok)
+	assert.Equal(t, int64(1), rejected.Value())
+	coldWrites, ok := counters["series.cold-writes+"]
+	require.True(t, ok)
+	assert.Equal(t, int64(0), coldWrites.Value())
+}
+
 func maxDuration(a, b time.Duration) time.Duration {
 	if a > b {
 		return a
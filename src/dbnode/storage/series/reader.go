@@ -104,6 +104,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 	var (
 		nowFn        = r.opts.ClockOptions().NowFn()
 		now          = nowFn()
+		stats        = r.opts.Stats()
 		cachePolicy  = r.opts.CachePolicy()
 		ropts        = r.opts.RetentionOptions()
 		size         = ropts.BlockSize()
@@ -157,6 +158,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 					}
 				}
 				retrievedFromDiskCache = true
+				stats.IncBlockCacheHits()
 			}
 		}
 
@@ -172,6 +174,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 					return nil, err
 				}
 				if isRetrievable {
+					stats.IncBlockCacheMisses()
 					streamedBlock, err := r.retriever.Stream(ctx, r.id, blockAt, r.onRetrieve, nsCtx)
 					if err != nil {
 						return nil, err
@@ -36,6 +36,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 func TestReaderUsingRetrieverReadEncoded(t *testing.T) {
@@ -74,6 +75,9 @@ func TestReaderUsingRetrieverReadEncoded(t *testing.T) {
 			start.Add(ropts.BlockSize()), onRetrieveBlock, gomock.Any()).
 		Return(blockReaders[1], nil)
 
+	scope := tally.NewTestScope("", nil)
+	opts = opts.SetStats(NewStats(scope))
+
 	reader := NewReaderUsingRetriever(
 		ident.StringID("foo"), retriever, onRetrieveBlock, nil, opts)
 
@@ -85,6 +89,11 @@ func TestReaderUsingRetrieverReadEncoded(t *testing.T) {
 		require.Equal(t, 1, len(readers))
 		assert.Equal(t, blockReaders[i], readers[0])
 	}
+
+	// Both blocks were read from disk.
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["series.block-cache-misses+"].Value())
+	assert.Equal(t, int64(0), counters["series.block-cache-hits+"].Value())
 }
 
 type readTestCase struct {
@@ -357,16 +357,24 @@ type Options interface {
 
 // Stats is passed down from namespace/shard to avoid allocations per series.
 type Stats struct {
-	encoderCreated tally.Counter
-	coldWrites     tally.Counter
+	encoderCreated       tally.Counter
+	coldWrites           tally.Counter
+	coldWritesRejected   tally.Counter
+	outOfRetentionWrites tally.Counter
+	blockCacheHits       tally.Counter
+	blockCacheMisses     tally.Counter
 }
 
 // NewStats returns a new Stats for the provided scope.
 func NewStats(scope tally.Scope) Stats {
 	subScope := scope.SubScope("series")
 	return Stats{
-		encoderCreated: subScope.Counter("encoder-created"),
-		coldWrites:     subScope.Counter("cold-writes"),
+		encoderCreated:       subScope.Counter("encoder-created"),
+		coldWrites:           subScope.Counter("cold-writes"),
+		coldWritesRejected:   subScope.Counter("cold-writes-rejected"),
+		outOfRetentionWrites: subScope.Counter("out-of-retention-writes"),
+		blockCacheHits:       subScope.Counter("block-cache-hits"),
+		blockCacheMisses:     subScope.Counter("block-cache-misses"),
 	}
 }
 
@@ -380,6 +388,29 @@ func (s Stats) IncColdWrites() {
 	s.coldWrites.Inc(1)
 }
 
+// IncColdWritesRejected incs the ColdWritesRejected stat, tracking writes
+// that fell outside the buffer window while cold writes are disabled.
+func (s Stats) IncColdWritesRejected() {
+	s.coldWritesRejected.Inc(1)
+}
+
+// IncOutOfRetentionWrites incs the OutOfRetentionWrites stat.
+func (s Stats) IncOutOfRetentionWrites() {
+	s.outOfRetentionWrites.Inc(1)
+}
+
+// IncBlockCacheHits incs the BlockCacheHits stat, tracking block reads
+// served from blocks already held in memory.
+func (s Stats) IncBlockCacheHits() {
+	s.blockCacheHits.Inc(1)
+}
+
+// IncBlockCacheMisses incs the BlockCacheMisses stat, tracking block
+// reads that had to be retrieved from disk.
+func (s Stats) IncBlockCacheMisses() {
+	s.blockCacheMisses.Inc(1)
+}
+
 // WriteType is an enum for warm/cold write types.
 type WriteType int
 
@@ -38,6 +38,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -54,6 +55,7 @@ import (
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/memory"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/gogo/protobuf/proto"
@@ -77,6 +79,7 @@ var (
 	errNewShardEntryTagsIterNotAtIndexZero = errors.New("new shard entry options error: tags iter not at index zero")
 	errShardIsNotBootstrapped              = errors.New("shard is not bootstrapped")
 	errShardAlreadyBootstrapped            = errors.New("shard is already bootstrapped")
+	errNewSeriesInsertMemoryLimitExceeded  = errors.New("shard insert of new series exceeds memory limit")
 	errFlushStateIsNotInitialized          = errors.New("shard flush state is not initialized")
 	errFlushStateAlreadyInitialized        = errors.New("shard flush state is already initialized")
 	errTriedToLoadNilSeries                = errors.New("tried to load nil series into shard")
@@ -180,8 +183,10 @@ type dbShard struct {
 	tickWg                   *sync.WaitGroup
 	runtimeOptsListenClosers []xclose.SimpleCloser
 	currRuntimeOptions       dbShardRuntimeOptions
+	tombstonesPurged         map[xtime.UnixNano]*namespace.SeriesTombstones
 	logger                   *zap.Logger
 	metrics                  dbShardMetrics
+	activity                 *shardActivity
 	ticking                  bool
 	shard                    uint32
 	coldWritesEnabled        bool
@@ -196,6 +201,7 @@ type dbShardRuntimeOptions struct {
 	writeNewSeriesAsync      bool
 	tickSleepSeriesBatchSize int
 	tickSleepPerSeries       time.Duration
+	seriesTombstones         *namespace.SeriesTombstones
 }
 
 type dbShardMetrics struct {
@@ -204,22 +210,32 @@ type dbShardMetrics struct {
 	closeStart                          tally.Counter
 	closeLatency                        tally.Timer
 	seriesTicked                        tally.Gauge
+	writesPerSec                        tally.Gauge
+	readsPerSec                         tally.Gauge
+	insertQueueDepth                    tally.Gauge
+	lockWait                            tally.Gauge
 	insertAsyncInsertErrors             tally.Counter
 	insertAsyncWriteInternalErrors      tally.Counter
 	insertAsyncWriteInvalidParamsErrors tally.Counter
 	insertAsyncIndexErrors              tally.Counter
+	insertNewSeriesMemoryRejected       tally.Counter
 }
 
 func newDatabaseShardMetrics(shardID uint32, scope tally.Scope) dbShardMetrics {
 	const insertErrorName = "insert-async.errors"
+	shardScope := scope.Tagged(map[string]string{
+		"shard": fmt.Sprintf("%d", shardID),
+	})
 	return dbShardMetrics{
-		create:       scope.Counter("create"),
-		close:        scope.Counter("close"),
-		closeStart:   scope.Counter("close-start"),
-		closeLatency: scope.Timer("close-latency"),
-		seriesTicked: scope.Tagged(map[string]string{
-			"shard": fmt.Sprintf("%d", shardID),
-		}).Gauge("series-ticked"),
+		create:           scope.Counter("create"),
+		close:            scope.Counter("close"),
+		closeStart:       scope.Counter("close-start"),
+		closeLatency:     scope.Timer("close-latency"),
+		seriesTicked:     shardScope.Gauge("series-ticked"),
+		writesPerSec:     shardScope.Gauge("writes-per-sec"),
+		readsPerSec:      shardScope.Gauge("reads-per-sec"),
+		insertQueueDepth: shardScope.Gauge("insert-queue-depth"),
+		lockWait:         shardScope.Gauge("lock-wait-per-sec"),
 		insertAsyncInsertErrors: scope.Tagged(map[string]string{
 			"error_type":    "insert-series",
 			"suberror_type": "shard-entry-insert-error",
@@ -236,6 +252,9 @@ func newDatabaseShardMetrics(shardID uint32, scope tally.Scope) dbShardMetrics {
 			"error_type":    "reverse-index",
 			"suberror_type": "write-batch-error",
 		}).Counter(insertErrorName),
+		insertNewSeriesMemoryRejected: scope.Tagged(map[string]string{
+			"reason": "memory-limit",
+		}).Counter("insert-new-series.rejected"),
 	}
 }
 
@@ -294,11 +313,13 @@ func newDatabaseShard(
 		identifierPool:       opts.IdentifierPool(),
 		contextPool:          opts.ContextPool(),
 		flushState:           newShardFlushState(),
+		tombstonesPurged:     make(map[xtime.UnixNano]*namespace.SeriesTombstones),
 		tickWg:               &sync.WaitGroup{},
 		coldWritesEnabled:    namespaceMetadata.Options().ColdWritesEnabled(),
 		logger:               opts.InstrumentOptions().Logger(),
 		metrics:              newDatabaseShardMetrics(shard, scope),
 	}
+	s.activity = newShardActivity(s.nowFn())
 	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
 		s.nowFn, scope, opts.InstrumentOptions().Logger())
 
@@ -341,6 +362,7 @@ func (s *dbShard) SetRuntimeOptions(value runtime.Options) {
 		writeNewSeriesAsync:      value.WriteNewSeriesAsync(),
 		tickSleepSeriesBatchSize: value.TickSeriesBatchSize(),
 		tickSleepPerSeries:       value.TickPerSeriesSleepDuration(),
+		seriesTombstones:         value.SeriesTombstones(),
 	}
 	s.Unlock()
 }
@@ -639,6 +661,7 @@ func (s *dbShard) Close() error {
 	// to dbShardStateClosing, which triggers an early termination of
 	// any active ticks.
 	s.tickWg.Wait()
+	s.opts.HotShardDetector().Remove(s.namespace.ID().String(), s.shard)
 
 	// NB(r): Asynchronously we purge expired series to ensure pressure on the
 	// GC is not placed all at one time.  If the deadline is too low and still
@@ -662,7 +685,32 @@ func (s *dbShard) isClosingWithLock() bool {
 
 func (s *dbShard) Tick(c context.Cancellable, startTime time.Time, nsCtx namespace.Context) (tickResult, error) {
 	s.removeAnyFlushStatesTooEarly(startTime)
-	return s.tickAndExpire(c, tickPolicyRegular, nsCtx)
+	r, err := s.tickAndExpire(c, tickPolicyRegular, nsCtx)
+	if err == nil {
+		s.reportActivity()
+	}
+	return r, err
+}
+
+// reportActivity reports the shard activity since the last tick to the
+// shard metrics and the hot shard detector.
+func (s *dbShard) reportActivity() {
+	var (
+		sample     = s.activity.sample(s.nowFn())
+		queueDepth = s.insertQueue.Len()
+	)
+	s.metrics.writesPerSec.Update(sample.writesPerSec)
+	s.metrics.readsPerSec.Update(sample.readsPerSec)
+	s.metrics.insertQueueDepth.Update(float64(queueDepth))
+	s.metrics.lockWait.Update(sample.lockWait.Seconds())
+	s.opts.HotShardDetector().Update(hotshards.Activity{
+		Namespace:        s.namespace.ID().String(),
+		Shard:            s.shard,
+		WritesPerSec:     sample.writesPerSec,
+		ReadsPerSec:      sample.readsPerSec,
+		InsertQueueDepth: queueDepth,
+		LockWait:         sample.lockWait,
+	})
 }
 
 func (s *dbShard) tickAndExpire(
@@ -866,6 +914,13 @@ func (s *dbShard) Write(
 		value, unit, annotation, wOpts, false)
 }
 
+// rejectNewSeries returns true if heap usage is high enough that writes
+// creating new series should be rejected.
+func (s *dbShard) rejectNewSeries() bool {
+	m := s.opts.MemoryMonitor()
+	return m != nil && m.Stage() >= memory.StageRejectNewSeries
+}
+
 func (s *dbShard) writeAndIndex(
 	ctx context.Context,
 	id ident.ID,
@@ -877,6 +932,8 @@ func (s *dbShard) writeAndIndex(
 	wOpts series.WriteOptions,
 	shouldReverseIndex bool,
 ) (SeriesWrite, error) {
+	s.activity.recordWrite()
+
 	// Prepare write
 	entry, opts, err := s.tryRetrieveWritableSeries(id)
 	if err != nil {
@@ -884,6 +941,10 @@ func (s *dbShard) writeAndIndex(
 	}
 
 	writable := entry != nil
+	if !writable && s.rejectNewSeries() {
+		s.metrics.insertNewSeriesMemoryRejected.Inc(1)
+		return SeriesWrite{}, xerrors.NewRetryableError(errNewSeriesInsertMemoryLimitExceeded)
+	}
 
 	// If no entry and we are not writing new series asynchronously.
 	if !writable && !opts.writeNewSeriesAsync {
@@ -1063,7 +1124,11 @@ func (s *dbShard) ReadEncoded(
 	start, end time.Time,
 	nsCtx namespace.Context,
 ) ([][]xio.BlockReader, error) {
+	s.activity.recordRead()
+
+	lockStart := s.nowFn()
 	s.RLock()
+	s.activity.recordLockWait(s.nowFn().Sub(lockStart))
 	entry, _, err := s.lookupEntryWithLock(id)
 	if entry != nil {
 		// NB(r): Ensure readers have consistent view of this series, do
@@ -1071,6 +1136,7 @@ func (s *dbShard) ReadEncoded(
 		entry.IncrementReaderWriterCount()
 		defer entry.DecrementReaderWriterCount()
 	}
+	tombstones := s.currRuntimeOptions.seriesTombstones
 	s.RUnlock()
 
 	if err == errShardEntryNotFound {
@@ -1083,15 +1149,29 @@ func (s *dbShard) ReadEncoded(
 		return nil, err
 	}
 
+	var blocks [][]xio.BlockReader
 	if entry != nil {
-		return entry.Series.ReadEncoded(ctx, start, end, nsCtx)
+		blocks, err = entry.Series.ReadEncoded(ctx, start, end, nsCtx)
+	} else {
+		retriever := s.seriesBlockRetriever
+		onRetrieve := s.seriesOnRetrieveBlock
+		opts := s.seriesOpts
+		reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, nil, opts)
+		blocks, err = reader.ReadEncoded(ctx, start, end, nsCtx)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	retriever := s.seriesBlockRetriever
-	onRetrieve := s.seriesOnRetrieveBlock
-	opts := s.seriesOpts
-	reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, nil, opts)
-	return reader.ReadEncoded(ctx, start, end, nsCtx)
+	// Datapoints deleted by series tombstones are filtered from reads until
+	// the blocks holding them are merged and the datapoints purged.
+	deleted := namespace.OverlappingRanges(
+		tombstones.SeriesRanges(s.namespace.ID(), id),
+		xtime.Range{Start: start, End: end})
+	if len(deleted) == 0 {
+		return blocks, nil
+	}
+	return filterTombstonedBlocks(ctx, blocks, deleted, s.opts, nsCtx)
 }
 
 // lookupEntryWithLock returns the entry for a given id while holding a read lock or a write lock.
@@ -1138,7 +1218,9 @@ func (s *dbShard) tryRetrieveWritableSeries(id ident.ID) (
 	writableSeriesOptions,
 	error,
 ) {
+	lockStart := s.nowFn()
 	s.RLock()
+	s.activity.recordLockWait(s.nowFn().Sub(lockStart))
 	opts := writableSeriesOptions{
 		writeNewSeriesAsync: s.currRuntimeOptions.writeNewSeriesAsync,
 	}
@@ -1181,14 +1263,16 @@ func (s *dbShard) newShardEntry(
 		// Pass nil for the identifier pool because the pool will force us to use an array
 		// with a large capacity to store the tags. Since these tags are long-lived, it's
 		// better to allocate an array of the exact size to save memory.
-		seriesMetadata, err = convert.FromSeriesIDAndTagIter(id, tagsIter)
+		seriesMetadata, err = convert.FromSeriesIDAndTagIterWithInterner(id, tagsIter,
+			s.opts.TagInterner())
 		tagsIter.Close()
 		if err != nil {
 			return nil, err
 		}
 
 	case tagsArg:
-		seriesMetadata, err = convert.FromSeriesIDAndTags(id, tagsArgOpts.tags)
+		seriesMetadata, err = convert.FromSeriesIDAndTagsWithInterner(id, tagsArgOpts.tags,
+			s.opts.TagInterner())
 		if err != nil {
 			return nil, err
 		}
@@ -1197,6 +1281,8 @@ func (s *dbShard) newShardEntry(
 		return nil, errNewShardEntryTagsTypeInvalid
 	}
 
+	s.opts.CardinalityEstimator().Add(s.namespace.ID().Bytes(), seriesMetadata)
+
 	// Use the same bytes as the series metadata for the ID.
 	seriesID := ident.BytesID(seriesMetadata.ID)
 
@@ -1571,6 +1657,7 @@ func (s *dbShard) FetchBlocks(
 		entry.IncrementReaderWriterCount()
 		defer entry.DecrementReaderWriterCount()
 	}
+	tombstones := s.currRuntimeOptions.seriesTombstones
 	s.RUnlock()
 
 	if err == errShardEntryNotFound {
@@ -1583,18 +1670,31 @@ func (s *dbShard) FetchBlocks(
 		return nil, err
 	}
 
+	var results []block.FetchBlockResult
 	if entry != nil {
-		return entry.Series.FetchBlocks(ctx, starts, nsCtx)
+		results, err = entry.Series.FetchBlocks(ctx, starts, nsCtx)
+	} else {
+		retriever := s.seriesBlockRetriever
+		onRetrieve := s.seriesOnRetrieveBlock
+		opts := s.seriesOpts
+		// Nil for onRead callback because we don't want peer bootstrapping to impact
+		// the behavior of the LRU
+		var onReadCb block.OnReadBlock
+		reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, onReadCb, opts)
+		results, err = reader.FetchBlocks(ctx, starts, nsCtx)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	retriever := s.seriesBlockRetriever
-	onRetrieve := s.seriesOnRetrieveBlock
-	opts := s.seriesOpts
-	// Nil for onRead callback because we don't want peer bootstrapping to impact
-	// the behavior of the LRU
-	var onReadCb block.OnReadBlock
-	reader := series.NewReaderUsingRetriever(id, retriever, onRetrieve, onReadCb, opts)
-	return reader.FetchBlocks(ctx, starts, nsCtx)
+	// NB: peers must not stream datapoints deleted by series tombstones,
+	// otherwise bootstrapping or repairing from them restores the datapoints.
+	deleted := tombstones.SeriesRanges(s.namespace.ID(), id)
+	if len(deleted) == 0 {
+		return results, nil
+	}
+	filterTombstonedFetchBlocks(ctx, results, deleted, s.opts, nsCtx)
+	return results, nil
 }
 
 func (s *dbShard) FetchBlocksForColdFlush(
@@ -1620,6 +1720,7 @@ func (s *dbShard) fetchActiveBlocksMetadata(
 	limit int64,
 	indexCursor int64,
 	opts series.FetchBlocksMetadataOptions,
+	tombstones *namespace.SeriesTombstones,
 ) (block.FetchBlocksMetadataResults, *int64, error) {
 	var (
 		res             = s.opts.FetchBlocksMetadataResultsPool().Get()
@@ -1661,6 +1762,12 @@ func (s *dbShard) fetchActiveBlocksMetadata(
 			return true
 		}
 
+		deleted := tombstones.SeriesRanges(s.namespace.ID(), metadata.ID)
+		if len(deleted) > 0 {
+			omitTombstonedBlockMetadata(metadata.Blocks.Results(), deleted,
+				s.namespace.Options().RetentionOptions().BlockSize())
+		}
+
 		// Otherwise add it to the result which takes care of closing the metadata
 		res.Add(metadata)
 
@@ -1710,6 +1817,9 @@ func (s *dbShard) FetchBlocksMetadataV2(
 		activePhase  = token.ActiveSeriesPhase
 		flushedPhase = token.FlushedSeriesPhase
 	)
+	s.RLock()
+	tombstones := s.currRuntimeOptions.seriesTombstones
+	s.RUnlock()
 	if flushedPhase == nil {
 		// If first phase started or no phases started then return active
 		// series metadata until we find a block start time that we have fileset
@@ -1724,7 +1834,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			FetchBlocksMetadataOptions: opts,
 		}
 		result, nextIndexCursor, err := s.fetchActiveBlocksMetadata(ctx, start, end,
-			limit, indexCursor, seriesFetchBlocksMetadataOpts)
+			limit, indexCursor, seriesFetchBlocksMetadataOpts, tombstones)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1805,6 +1915,13 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			return nil, nil, err
 		}
 
+		// Blocks merged with the current series tombstones no longer hold
+		// the deleted datapoints so their checksums are still accurate.
+		s.RLock()
+		purged := tombstones == nil ||
+			s.tombstonesPurged[xtime.ToUnixNano(blockStart)] == tombstones
+		s.RUnlock()
+
 		for numResults < limit {
 			id, tags, size, checksum, err := reader.ReadMetadata()
 			if err == io.EOF {
@@ -1838,6 +1955,11 @@ func (s *dbShard) FetchBlocksMetadataV2(
 				value.Checksum = &v
 			}
 			blockResult.Add(value)
+			if !purged {
+				if deleted := tombstones.SeriesRanges(s.namespace.ID(), id); len(deleted) > 0 {
+					omitTombstonedBlockMetadata(blockResult.Results(), deleted, blockSize)
+				}
+			}
 
 			numResults++
 			result.Add(block.NewFetchBlocksMetadataResult(id, tags,
@@ -2178,13 +2300,34 @@ func (s *dbShard) WarmFlush(
 	var multiErr xerrors.MultiError
 	flushCtx := s.contextPool.Get() // From pool so finalizers are from pool.
 
+	var (
+		persistFn         = prepared.Persist
+		downsampleErrs    int
+		lastDownsampleErr error
+	)
+	if downsampler := s.opts.FlushDownsampler(); downsampler.Downsamples(s.namespace.ID()) {
+		// Downsample each series block once it has been persisted, errors are
+		// logged rather than failing the flush since the fileset is intact.
+		persistFn = func(metadata persist.Metadata, segment ts.Segment, checksum uint32) error {
+			if err := prepared.Persist(metadata, segment, checksum); err != nil {
+				return err
+			}
+			err := downsampler.Downsample(s.namespace.ID(), metadata, segment, nsCtx)
+			if err != nil {
+				downsampleErrs++
+				lastDownsampleErr = err
+			}
+			return nil
+		}
+	}
+
 	flushResult := dbShardFlushResult{}
 	s.forEachShardEntry(func(entry *lookup.Entry) bool {
 		curr := entry.Series
 		// Use a temporary context here so the stream readers can be returned to
 		// the pool after we finish fetching flushing the series.
 		flushCtx.Reset()
-		flushOutcome, err := curr.WarmFlush(flushCtx, blockStart, prepared.Persist, nsCtx)
+		flushOutcome, err := curr.WarmFlush(flushCtx, blockStart, persistFn, nsCtx)
 		// Use BlockingCloseReset so context doesn't get returned to the pool.
 		flushCtx.BlockingCloseReset()
 
@@ -2201,6 +2344,14 @@ func (s *dbShard) WarmFlush(
 	})
 
 	s.logFlushResult(flushResult)
+	if downsampleErrs > 0 {
+		s.logger.Error("could not downsample flushed series blocks",
+			zap.Stringer("namespace", s.namespace.ID()),
+			zap.Uint32("shard", s.ID()),
+			zap.Time("blockStart", blockStart),
+			zap.Int("numSeries", downsampleErrs),
+			zap.Error(lastDownsampleErr))
+	}
 
 	if err := prepared.Close(); err != nil {
 		multiErr = multiErr.Add(err)
@@ -2223,6 +2374,7 @@ func (s *dbShard) ColdFlush(
 	}
 	// Use blockStatesSnapshotWithRLock to avoid having to re-acquire read lock.
 	blockStates := s.blockStatesSnapshotWithRLock()
+	tombstones := s.currRuntimeOptions.seriesTombstones
 	s.RUnlock()
 
 	resources.reset()
@@ -2283,12 +2435,25 @@ func (s *dbShard) ColdFlush(
 		return shardColdFlush{}, loopErr
 	}
 
+	// Blocks holding datapoints deleted by series tombstones are merged even
+	// without cold writes to purge the deleted datapoints.
+	purgeBlockStarts := s.blockStartsToPurge(tombstones, blockStatesSnapshot)
 	if dirtySeries.Len() == 0 {
-		// Early exit if there is nothing dirty to merge. dirtySeriesToWrite
-		// may be non-empty when dirtySeries is empty because we purposely
-		// leave empty seriesLists in the dirtySeriesToWrite map to avoid having
-		// to reallocate them in subsequent usages of the shared resource.
-		return shardColdFlush{}, nil
+		if len(purgeBlockStarts) == 0 {
+			// Early exit if there is nothing dirty to merge. dirtySeriesToWrite
+			// may be non-empty when dirtySeries is empty because we purposely
+			// leave empty seriesLists in the dirtySeriesToWrite map to avoid having
+			// to reallocate them in subsequent usages of the shared resource.
+			return shardColdFlush{}, nil
+		}
+		// Only merge the blocks to purge rather than every block with an
+		// empty seriesList left from a previous usage.
+		dirtySeriesToWrite = make(map[xtime.UnixNano]*idList, len(purgeBlockStarts))
+	}
+	for _, blockStart := range purgeBlockStarts {
+		if dirtySeriesToWrite[blockStart] == nil {
+			dirtySeriesToWrite[blockStart] = newIDList(idElementPool)
+		}
 	}
 
 	flush := shardColdFlush{
@@ -2297,7 +2462,8 @@ func (s *dbShard) ColdFlush(
 	}
 	merger := s.newMergerFn(resources.fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
 		s.opts.SegmentReaderPool(), s.opts.MultiReaderIteratorPool(),
-		s.opts.IdentifierPool(), s.opts.EncoderPool(), s.opts.ContextPool(), s.namespace.Options())
+		s.opts.IdentifierPool(), s.opts.EncoderPool(), s.opts.ContextPool(), s.namespace.Options(),
+		tombstones)
 	mergeWithMem := s.newFSMergeWithMemFn(s, s, dirtySeries, dirtySeriesToWrite)
 	// Loop through each block that we know has ColdWrites. Since each block
 	// has its own fileset, if we encounter an error while trying to persist
@@ -2326,6 +2492,7 @@ func (s *dbShard) ColdFlush(
 		flush.doneFns = append(flush.doneFns, shardColdFlushDone{
 			startTime:   startTime,
 			nextVersion: nextVersion,
+			tombstones:  tombstones,
 			close:       close,
 		})
 	}
@@ -2553,6 +2720,7 @@ func (s *dbShard) logFlushResult(r dbShardFlushResult) {
 type shardColdFlushDone struct {
 	startTime   time.Time
 	nextVersion int
+	tombstones  *namespace.SeriesTombstones
 	close       persist.DataCloser
 }
 
@@ -2598,6 +2766,7 @@ func (s shardColdFlush) Done() error {
 		// which would increase the complexity of the code to address a situation that is probably not
 		// recoverable (failure to UpdateOpenLeases is an invariant violated error).
 		s.shard.setFlushStateColdVersionRetrievable(startTime, nextVersion)
+		s.shard.setTombstonesPurged(startTime, done.tombstones)
 		if err != nil {
 			instrument.EmitAndLogInvariantViolation(s.shard.opts.InstrumentOptions(), func(l *zap.Logger) {
 				l.With(
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardActivity counts the reads, writes and time spent waiting on the shard
+// lock between ticks so that each tick can report the rates since the last.
+type shardActivity struct {
+	writes        int64
+	reads         int64
+	lockWaitNanos int64
+
+	sync.Mutex
+	lastSample time.Time
+}
+
+func newShardActivity(now time.Time) *shardActivity {
+	return &shardActivity{lastSample: now}
+}
+
+func (a *shardActivity) recordWrite() {
+	atomic.AddInt64(&a.writes, 1)
+}
+
+func (a *shardActivity) recordRead() {
+	atomic.AddInt64(&a.reads, 1)
+}
+
+func (a *shardActivity) recordLockWait(d time.Duration) {
+	atomic.AddInt64(&a.lockWaitNanos, int64(d))
+}
+
+type shardActivitySample struct {
+	writesPerSec float64
+	readsPerSec  float64
+	// lockWait is the time spent waiting on the shard lock per second.
+	lockWait time.Duration
+}
+
+// sample returns the rates since the last sample and resets the counters.
+func (a *shardActivity) sample(now time.Time) shardActivitySample {
+	a.Lock()
+	elapsed := now.Sub(a.lastSample).Seconds()
+	a.lastSample = now
+	a.Unlock()
+
+	var (
+		writes   = atomic.SwapInt64(&a.writes, 0)
+		reads    = atomic.SwapInt64(&a.reads, 0)
+		lockWait = atomic.SwapInt64(&a.lockWaitNanos, 0)
+	)
+	if elapsed <= 0 {
+		return shardActivitySample{}
+	}
+	return shardActivitySample{
+		writesPerSec: float64(writes) / elapsed,
+		readsPerSec:  float64(reads) / elapsed,
+		lockWait:     time.Duration(float64(lockWait) / elapsed),
+	}
+}
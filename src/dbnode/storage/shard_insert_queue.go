@@ -300,6 +300,14 @@ func (q *dbShardInsertQueue) Stop() error {
 	return nil
 }
 
+// Len returns the number of inserts waiting for the next batch.
+func (q *dbShardInsertQueue) Len() int {
+	q.RLock()
+	n := len(q.currBatch.inserts)
+	q.RUnlock()
+	return n
+}
+
 func (q *dbShardInsertQueue) Insert(insert dbShardInsert) (*sync.WaitGroup, error) {
 	windowNanos := q.nowFn().Truncate(time.Second).UnixNano()
 
@@ -40,6 +40,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -48,7 +49,9 @@ import (
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/pool"
 	xtest "github.com/m3db/m3/src/x/test"
 	xtime "github.com/m3db/m3/src/x/time"
@@ -57,6 +60,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
+	"go.uber.org/zap"
 )
 
 type testIncreasingIndex struct {
@@ -715,6 +719,75 @@ func TestShardColdFlushNoMergeIfNothingDirty(t *testing.T) {
 	}
 }
 
+func TestShardColdFlushPurgesTombstonedBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Now()
+	nowFn := func() time.Time {
+		return now
+	}
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+	blockSize := opts.SeriesOptions().RetentionOptions().BlockSize()
+	shard := testDatabaseShard(t, opts)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	require.NoError(t, shard.Bootstrap(ctx))
+
+	shard.newMergerFn = newMergerTestFn
+	shard.newFSMergeWithMemFn = newFSMergeWithMemTestFn
+
+	t0 := now.Truncate(blockSize).Add(-10 * blockSize)
+	t1 := t0.Add(1 * blockSize)
+	t2 := t0.Add(2 * blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+	shard.markWarmFlushStateSuccess(t1)
+	shard.markWarmFlushStateSuccess(t2)
+
+	tombstones, err := namespace.NewSeriesTombstones([]namespace.SeriesTombstone{
+		{
+			ID:        "a",
+			Namespace: defaultTestNs1ID.String(),
+			SeriesIDs: []string{"foo"},
+			Start:     t1.Add(time.Minute),
+			End:       t1.Add(2 * time.Minute),
+		},
+	})
+	require.NoError(t, err)
+	shard.SetRuntimeOptions(runtime.NewOptions().SetSeriesTombstones(tombstones))
+
+	idElementPool := newIDElementPool(nil)
+	dirtySeriesToWrite := make(map[xtime.UnixNano]*idList)
+	dirtySeriesToWrite[xtime.ToUnixNano(t0)] = newIDList(idElementPool)
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(),
+		dirtySeriesToWrite: dirtySeriesToWrite,
+		idElementPool:      idElementPool,
+		fsReader:           fs.NewMockDataFileSetReader(ctrl),
+	}
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	nsCtx := namespace.Context{}
+
+	// Only the block holding deleted datapoints should be merged, and only
+	// once for the same tombstones.
+	for i := 0; i < 2; i++ {
+		shardColdFlush, err := shard.ColdFlush(preparer, resources, nsCtx, &persist.NoOpColdFlushNamespace{})
+		require.NoError(t, err)
+		require.NoError(t, shardColdFlush.Done())
+		for _, blockStart := range []time.Time{t0, t1, t2} {
+			coldVersion, err := shard.RetrievableBlockColdVersion(blockStart)
+			require.NoError(t, err)
+			expected := 0
+			if blockStart.Equal(t1) {
+				expected = 1
+			}
+			assert.Equal(t, expected, coldVersion)
+		}
+	}
+}
+
 func newMergerTestFn(
 	reader fs.DataFileSetReader,
 	blockAllocSize int,
@@ -724,6 +797,7 @@ func newMergerTestFn(
 	encoderPool encoding.EncoderPool,
 	contextPool context.Pool,
 	nsOpts namespace.Options,
+	tombstones *namespace.SeriesTombstones,
 ) fs.Merger {
 	return &noopMerger{}
 }
@@ -889,6 +963,39 @@ func writeShardAndVerify(
 	assert.Equal(t, expectedIdx, seriesWrite.Series.UniqueIndex)
 }
 
+type testMemoryMonitor struct {
+	stage memory.Stage
+}
+
+func (m *testMemoryMonitor) Start() error                            { return nil }
+func (m *testMemoryMonitor) Stop() error                             { return nil }
+func (m *testMemoryMonitor) Stage() memory.Stage                     { return m.stage }
+func (m *testMemoryMonitor) RegisterListener(memory.StageListenerFn) {}
+
+func TestShardWriteRejectsNewSeriesOverMemoryLimit(t *testing.T) {
+	monitor := &testMemoryMonitor{stage: memory.StageNone}
+	opts := DefaultTestOptions().SetMemoryMonitor(monitor)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	shard := testDatabaseShard(t, opts)
+	shard.Bootstrap(ctx)
+	defer shard.Close()
+
+	now := time.Now()
+	writeShardAndVerify(ctx, t, shard, "foo", now, 1.0, true, 0)
+
+	monitor.stage = memory.StageRejectNewSeries
+	_, err := shard.Write(ctx, ident.StringID("bar"),
+		now, 2.0, xtime.Second, nil, series.WriteOptions{})
+	require.Error(t, err)
+	require.True(t, xerrors.IsRetryableError(err))
+
+	// Writes to existing series are still accepted.
+	writeShardAndVerify(ctx, t, shard, "foo", now.Add(time.Second), 3.0, true, 0)
+}
+
 func TestShardTick(t *testing.T) {
 	dir, err := ioutil.TempDir("", "testdir")
 	require.NoError(t, err)
@@ -980,6 +1087,57 @@ func TestShardTick(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestShardTickReportsActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		now      = time.Now()
+		nowFn    = func() time.Time { return now }
+		detector = hotshards.NewDetector(1, 0, nowFn, zap.NewNop())
+		opts     = DefaultTestOptions().SetHotShardDetector(detector)
+	)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	shard := testDatabaseShard(t, opts)
+	shard.Bootstrap(ctx)
+	retriever := series.NewMockQueryableBlockRetriever(ctrl)
+	retriever.EXPECT().IsBlockRetrievable(gomock.Any()).Return(false, nil).AnyTimes()
+	shard.seriesBlockRetriever = retriever
+
+	writeShardAndVerify(ctx, t, shard, "foo", nowFn(), 1.0, true, 0)
+	writeShardAndVerify(ctx, t, shard, "foo", nowFn().Add(time.Second), 2.0, true, 0)
+	writeShardAndVerify(ctx, t, shard, "bar", nowFn(), 3.0, true, 1)
+	writeShardAndVerify(ctx, t, shard, "bar", nowFn().Add(time.Second), 4.0, true, 1)
+	_, err := shard.ReadEncoded(ctx, ident.StringID("foo"), nowFn(),
+		nowFn().Add(time.Minute), namespace.Context{})
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Second)
+	_, err = shard.Tick(context.NewNoOpCanncellable(), nowFn(), namespace.Context{})
+	require.NoError(t, err)
+
+	top := detector.Top(0)
+	require.Equal(t, 1, len(top))
+	require.Equal(t, shard.shard, top[0].Shard)
+	require.Equal(t, defaultTestNs1ID.String(), top[0].Namespace)
+	require.Equal(t, 2.0, top[0].WritesPerSec)
+	require.Equal(t, 0.5, top[0].ReadsPerSec)
+	require.Equal(t, 0, top[0].InsertQueueDepth)
+
+	// The next tick only reports the activity since the last tick.
+	now = now.Add(time.Second)
+	_, err = shard.Tick(context.NewNoOpCanncellable(), nowFn(), namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, detector.Top(1)[0].OpsPerSec())
+
+	require.NoError(t, shard.Close())
+	require.Equal(t, 0, len(detector.Top(0)))
+}
+
 type testWrite struct {
 	id         string
 	value      float64
@@ -1487,6 +1645,93 @@ func TestShardFetchBlocksIDExists(t *testing.T) {
 	require.Equal(t, expected, res)
 }
 
+func TestShardFetchBlocksFiltersTombstonedDatapoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions()
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	var (
+		id        = ident.StringID("foo")
+		blockSize = opts.SeriesOptions().RetentionOptions().BlockSize()
+		start     = time.Now().Truncate(blockSize).Add(-blockSize)
+		encoder   = opts.EncoderPool().Get()
+	)
+	encoder.Reset(start, 0, nil)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, encoder.Encode(ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Value:     float64(i),
+		}, xtime.Second, nil))
+	}
+	fetched := []block.FetchBlockResult{block.NewFetchBlockResult(start,
+		[]xio.BlockReader{{
+			SegmentReader: xio.NewSegmentReader(encoder.Discard()),
+			Start:         start,
+			BlockSize:     blockSize,
+		}}, nil)}
+
+	series := addMockSeries(ctrl, shard, id, ident.Tags{}, 0)
+	series.EXPECT().FetchBlocks(ctx, []time.Time{start}, gomock.Any()).
+		Return(fetched, nil)
+
+	tombstones, err := namespace.NewSeriesTombstones([]namespace.SeriesTombstone{
+		{
+			ID:        "a",
+			Namespace: defaultTestNs1ID.String(),
+			SeriesIDs: []string{"foo"},
+			Start:     start.Add(time.Minute),
+			End:       start.Add(2 * time.Minute),
+		},
+	})
+	require.NoError(t, err)
+	shard.SetRuntimeOptions(runtime.NewOptions().SetSeriesTombstones(tombstones))
+
+	res, err := shard.FetchBlocks(ctx, id, []time.Time{start}, namespace.Context{})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.NoError(t, res[0].Err)
+
+	iter := opts.MultiReaderIteratorPool().Get()
+	defer iter.Close()
+	readers := make([]xio.SegmentReader, 0, len(res[0].Blocks))
+	for _, br := range res[0].Blocks {
+		readers = append(readers, br.SegmentReader)
+	}
+	iter.Reset(readers, start, blockSize, nil)
+	var values []float64
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		values = append(values, dp.Value)
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, []float64{0, 2}, values)
+}
+
+func TestOmitTombstonedBlockMetadata(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		start     = time.Unix(1600000000, 0).Truncate(blockSize)
+		checksum  = uint32(1)
+		results   = []block.FetchBlockMetadataResult{
+			{Start: start, Size: 10, Checksum: &checksum},
+			{Start: start.Add(blockSize), Size: 10, Checksum: &checksum},
+		}
+	)
+	omitTombstonedBlockMetadata(results, []xtime.Range{
+		{Start: start.Add(blockSize), End: start.Add(blockSize + time.Minute)},
+	}, blockSize)
+	require.Equal(t, []block.FetchBlockMetadataResult{
+		{Start: start, Size: 10, Checksum: &checksum},
+		{Start: start.Add(blockSize)},
+	}, results)
+}
+
 func TestShardCleanupExpiredFileSets(t *testing.T) {
 	opts := DefaultTestOptions()
 	shard := testDatabaseShard(t, opts)
@@ -0,0 +1,232 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// blockStartsToPurge returns the warm flushed blocks holding datapoints
+// deleted by series tombstones which have not been merged since the
+// tombstones last changed. Merging a block drops its deleted datapoints.
+func (s *dbShard) blockStartsToPurge(
+	tombstones *namespace.SeriesTombstones,
+	snapshot series.BootstrappedBlockStateSnapshot,
+) []xtime.UnixNano {
+	ranges := tombstones.NamespaceRanges(s.namespace.ID())
+
+	s.Lock()
+	defer s.Unlock()
+
+	// Forget blocks that have expired since they were purged.
+	for blockStart := range s.tombstonesPurged {
+		if _, ok := snapshot.Snapshot[blockStart]; !ok {
+			delete(s.tombstonesPurged, blockStart)
+		}
+	}
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	var (
+		blockSize = s.namespace.Options().RetentionOptions().BlockSize()
+		result    []xtime.UnixNano
+	)
+	for blockStart, state := range snapshot.Snapshot {
+		if !state.WarmRetrievable || s.tombstonesPurged[blockStart] == tombstones {
+			continue
+		}
+		start := blockStart.ToTime()
+		blockRange := xtime.Range{Start: start, End: start.Add(blockSize)}
+		if len(namespace.OverlappingRanges(ranges, blockRange)) > 0 {
+			result = append(result, blockStart)
+		}
+	}
+	return result
+}
+
+// setTombstonesPurged records that a block was merged with the series
+// tombstones applied.
+func (s *dbShard) setTombstonesPurged(
+	blockStart time.Time,
+	tombstones *namespace.SeriesTombstones,
+) {
+	if tombstones == nil {
+		return
+	}
+	s.Lock()
+	s.tombstonesPurged[xtime.ToUnixNano(blockStart)] = tombstones
+	s.Unlock()
+}
+
+// filterTombstonedBlocks removes the datapoints within deleted time ranges
+// from the blocks read for a series. Blocks without deleted datapoints are
+// returned as is, others are re-encoded and dropped if no datapoints remain.
+func filterTombstonedBlocks(
+	ctx context.Context,
+	blocks [][]xio.BlockReader,
+	deleted []xtime.Range,
+	opts Options,
+	nsCtx namespace.Context,
+) ([][]xio.BlockReader, error) {
+	filtered := blocks[:0]
+	for _, group := range blocks {
+		if len(group) == 0 {
+			continue
+		}
+		var (
+			start      = group[0].Start
+			blockSize  = group[0].BlockSize
+			blockRange = xtime.Range{Start: start, End: start.Add(blockSize)}
+		)
+		if len(namespace.OverlappingRanges(deleted, blockRange)) == 0 {
+			filtered = append(filtered, group)
+			continue
+		}
+
+		reader, ok, err := encodeWithoutDeleted(group, deleted, opts, nsCtx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		ctx.RegisterFinalizer(reader)
+		filtered = append(filtered, []xio.BlockReader{{
+			SegmentReader: reader,
+			Start:         start,
+			BlockSize:     blockSize,
+		}})
+	}
+	return filtered, nil
+}
+
+// filterTombstonedFetchBlocks removes the datapoints within deleted time
+// ranges from the blocks fetched for a series by peers.
+func filterTombstonedFetchBlocks(
+	ctx context.Context,
+	results []block.FetchBlockResult,
+	deleted []xtime.Range,
+	opts Options,
+	nsCtx namespace.Context,
+) {
+	for i := range results {
+		result := &results[i]
+		if result.Err != nil || len(result.Blocks) == 0 {
+			continue
+		}
+		blockSize := result.Blocks[0].BlockSize
+		blockRange := xtime.Range{Start: result.Start, End: result.Start.Add(blockSize)}
+		if len(namespace.OverlappingRanges(deleted, blockRange)) == 0 {
+			continue
+		}
+
+		reader, ok, err := encodeWithoutDeleted(result.Blocks, deleted, opts, nsCtx)
+		if err != nil {
+			result.Blocks = nil
+			result.Err = err
+			continue
+		}
+		if !ok {
+			result.Blocks = nil
+			continue
+		}
+		ctx.RegisterFinalizer(reader)
+		result.Blocks = []xio.BlockReader{{
+			SegmentReader: reader,
+			Start:         result.Blocks[0].Start,
+			BlockSize:     blockSize,
+		}}
+	}
+}
+
+// omitTombstonedBlockMetadata clears the sizes and checksums of the blocks
+// holding datapoints deleted by series tombstones, they describe datapoints
+// which are filtered when the blocks are fetched. Peers treat blocks without
+// checksums as mismatched and fetch them to compare.
+func omitTombstonedBlockMetadata(
+	results []block.FetchBlockMetadataResult,
+	deleted []xtime.Range,
+	blockSize time.Duration,
+) {
+	for i := range results {
+		blockRange := xtime.Range{
+			Start: results[i].Start,
+			End:   results[i].Start.Add(blockSize),
+		}
+		if len(namespace.OverlappingRanges(deleted, blockRange)) == 0 {
+			continue
+		}
+		results[i].Size = 0
+		results[i].Checksum = nil
+	}
+}
+
+func encodeWithoutDeleted(
+	group []xio.BlockReader,
+	deleted []xtime.Range,
+	opts Options,
+	nsCtx namespace.Context,
+) (xio.SegmentReader, bool, error) {
+	var (
+		start     = group[0].Start
+		blockSize = group[0].BlockSize
+		readers   = make([]xio.SegmentReader, 0, len(group))
+		iter      = opts.MultiReaderIteratorPool().Get()
+		encoder   = opts.EncoderPool().Get()
+		encoded   int
+	)
+	for _, br := range group {
+		readers = append(readers, br.SegmentReader)
+	}
+	iter.Reset(readers, start, blockSize, nsCtx.Schema)
+	defer iter.Close()
+
+	encoder.Reset(start, opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+		nsCtx.Schema)
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		if namespace.RangesContain(deleted, dp.Timestamp) {
+			continue
+		}
+		if err := encoder.Encode(dp, unit, annotation); err != nil {
+			encoder.Close()
+			return nil, false, err
+		}
+		encoded++
+	}
+	if err := iter.Err(); err != nil {
+		encoder.Close()
+		return nil, false, err
+	}
+	if encoded == 0 {
+		encoder.Close()
+		return nil, false, nil
+	}
+	return xio.NewSegmentReader(encoder.Discard()), true, nil
+}
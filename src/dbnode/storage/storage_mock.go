@@ -40,6 +40,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cardinality"
+	"github.com/m3db/m3/src/dbnode/storage/downsample"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -49,6 +52,8 @@ import (
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
 	sync0 "github.com/m3db/m3/src/x/sync"
@@ -2810,6 +2815,91 @@ func (mr *MockdatabaseRepairerMockRecorder) Report() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockdatabaseRepairer)(nil).Report))
 }
 
+// ScheduleRepair mocks base method
+func (m *MockdatabaseRepairer) ScheduleRepair(namespace ident.ID, blockStart time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ScheduleRepair", namespace, blockStart)
+}
+
+// ScheduleRepair indicates an expected call of ScheduleRepair
+func (mr *MockdatabaseRepairerMockRecorder) ScheduleRepair(namespace, blockStart interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRepair", reflect.TypeOf((*MockdatabaseRepairer)(nil).ScheduleRepair), namespace, blockStart)
+}
+
+// MockdatabaseScrubber is a mock of databaseScrubber interface
+type MockdatabaseScrubber struct {
+	ctrl     *gomock.Controller
+	recorder *MockdatabaseScrubberMockRecorder
+}
+
+// MockdatabaseScrubberMockRecorder is the mock recorder for MockdatabaseScrubber
+type MockdatabaseScrubberMockRecorder struct {
+	mock *MockdatabaseScrubber
+}
+
+// NewMockdatabaseScrubber creates a new mock instance
+func NewMockdatabaseScrubber(ctrl *gomock.Controller) *MockdatabaseScrubber {
+	mock := &MockdatabaseScrubber{ctrl: ctrl}
+	mock.recorder = &MockdatabaseScrubberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockdatabaseScrubber) EXPECT() *MockdatabaseScrubberMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method
+func (m *MockdatabaseScrubber) Start() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start")
+}
+
+// Start indicates an expected call of Start
+func (mr *MockdatabaseScrubberMockRecorder) Start() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockdatabaseScrubber)(nil).Start))
+}
+
+// Stop mocks base method
+func (m *MockdatabaseScrubber) Stop() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Stop")
+}
+
+// Stop indicates an expected call of Stop
+func (mr *MockdatabaseScrubberMockRecorder) Stop() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockdatabaseScrubber)(nil).Stop))
+}
+
+// Scrub mocks base method
+func (m *MockdatabaseScrubber) Scrub() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scrub")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Scrub indicates an expected call of Scrub
+func (mr *MockdatabaseScrubberMockRecorder) Scrub() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scrub", reflect.TypeOf((*MockdatabaseScrubber)(nil).Scrub))
+}
+
+// Report mocks base method
+func (m *MockdatabaseScrubber) Report() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Report")
+}
+
+// Report indicates an expected call of Report
+func (mr *MockdatabaseScrubberMockRecorder) Report() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockdatabaseScrubber)(nil).Report))
+}
+
 // MockdatabaseTickManager is a mock of databaseTickManager interface
 type MockdatabaseTickManager struct {
 	ctrl     *gomock.Controller
@@ -3537,6 +3627,34 @@ func (mr *MockOptionsMockRecorder) RepairOptions() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairOptions", reflect.TypeOf((*MockOptions)(nil).RepairOptions))
 }
 
+// SetScrubOptions mocks base method
+func (m *MockOptions) SetScrubOptions(value ScrubOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetScrubOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetScrubOptions indicates an expected call of SetScrubOptions
+func (mr *MockOptionsMockRecorder) SetScrubOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetScrubOptions", reflect.TypeOf((*MockOptions)(nil).SetScrubOptions), value)
+}
+
+// ScrubOptions mocks base method
+func (m *MockOptions) ScrubOptions() ScrubOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScrubOptions")
+	ret0, _ := ret[0].(ScrubOptions)
+	return ret0
+}
+
+// ScrubOptions indicates an expected call of ScrubOptions
+func (mr *MockOptionsMockRecorder) ScrubOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScrubOptions", reflect.TypeOf((*MockOptions)(nil).ScrubOptions))
+}
+
 // SetBootstrapProcessProvider mocks base method
 func (m *MockOptions) SetBootstrapProcessProvider(value bootstrap.ProcessProvider) Options {
 	m.ctrl.T.Helper()
@@ -3901,6 +4019,118 @@ func (mr *MockOptionsMockRecorder) IdentifierPool() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentifierPool", reflect.TypeOf((*MockOptions)(nil).IdentifierPool))
 }
 
+// SetTagInterner mocks base method
+func (m *MockOptions) SetTagInterner(value *intern.Cache) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTagInterner", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetTagInterner indicates an expected call of SetTagInterner
+func (mr *MockOptionsMockRecorder) SetTagInterner(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTagInterner", reflect.TypeOf((*MockOptions)(nil).SetTagInterner), value)
+}
+
+// TagInterner mocks base method
+func (m *MockOptions) TagInterner() *intern.Cache {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TagInterner")
+	ret0, _ := ret[0].(*intern.Cache)
+	return ret0
+}
+
+// TagInterner indicates an expected call of TagInterner
+func (mr *MockOptionsMockRecorder) TagInterner() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagInterner", reflect.TypeOf((*MockOptions)(nil).TagInterner))
+}
+
+// SetCardinalityEstimator mocks base method
+func (m *MockOptions) SetCardinalityEstimator(value *cardinality.Estimator) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCardinalityEstimator", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetCardinalityEstimator indicates an expected call of SetCardinalityEstimator
+func (mr *MockOptionsMockRecorder) SetCardinalityEstimator(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCardinalityEstimator", reflect.TypeOf((*MockOptions)(nil).SetCardinalityEstimator), value)
+}
+
+// CardinalityEstimator mocks base method
+func (m *MockOptions) CardinalityEstimator() *cardinality.Estimator {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CardinalityEstimator")
+	ret0, _ := ret[0].(*cardinality.Estimator)
+	return ret0
+}
+
+// CardinalityEstimator indicates an expected call of CardinalityEstimator
+func (mr *MockOptionsMockRecorder) CardinalityEstimator() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CardinalityEstimator", reflect.TypeOf((*MockOptions)(nil).CardinalityEstimator))
+}
+
+// SetHotShardDetector mocks base method
+func (m *MockOptions) SetHotShardDetector(value *hotshards.Detector) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHotShardDetector", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetHotShardDetector indicates an expected call of SetHotShardDetector
+func (mr *MockOptionsMockRecorder) SetHotShardDetector(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHotShardDetector", reflect.TypeOf((*MockOptions)(nil).SetHotShardDetector), value)
+}
+
+// HotShardDetector mocks base method
+func (m *MockOptions) HotShardDetector() *hotshards.Detector {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HotShardDetector")
+	ret0, _ := ret[0].(*hotshards.Detector)
+	return ret0
+}
+
+// HotShardDetector indicates an expected call of HotShardDetector
+func (mr *MockOptionsMockRecorder) HotShardDetector() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HotShardDetector", reflect.TypeOf((*MockOptions)(nil).HotShardDetector))
+}
+
+// SetFlushDownsampler mocks base method
+func (m *MockOptions) SetFlushDownsampler(value *downsample.Downsampler) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFlushDownsampler", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFlushDownsampler indicates an expected call of SetFlushDownsampler
+func (mr *MockOptionsMockRecorder) SetFlushDownsampler(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFlushDownsampler", reflect.TypeOf((*MockOptions)(nil).SetFlushDownsampler), value)
+}
+
+// FlushDownsampler mocks base method
+func (m *MockOptions) FlushDownsampler() *downsample.Downsampler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushDownsampler")
+	ret0, _ := ret[0].(*downsample.Downsampler)
+	return ret0
+}
+
+// FlushDownsampler indicates an expected call of FlushDownsampler
+func (mr *MockOptionsMockRecorder) FlushDownsampler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushDownsampler", reflect.TypeOf((*MockOptions)(nil).FlushDownsampler))
+}
+
 // SetFetchBlockMetadataResultsPool mocks base method
 func (m *MockOptions) SetFetchBlockMetadataResultsPool(value block.FetchBlockMetadataResultsPool) Options {
 	m.ctrl.T.Helper()
@@ -4237,6 +4467,34 @@ func (mr *MockOptionsMockRecorder) MemoryTracker() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemoryTracker", reflect.TypeOf((*MockOptions)(nil).MemoryTracker))
 }
 
+// SetMemoryMonitor mocks base method
+func (m *MockOptions) SetMemoryMonitor(value memory.Monitor) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemoryMonitor", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetMemoryMonitor indicates an expected call of SetMemoryMonitor
+func (mr *MockOptionsMockRecorder) SetMemoryMonitor(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemoryMonitor", reflect.TypeOf((*MockOptions)(nil).SetMemoryMonitor), value)
+}
+
+// MemoryMonitor mocks base method
+func (m *MockOptions) MemoryMonitor() memory.Monitor {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MemoryMonitor")
+	ret0, _ := ret[0].(memory.Monitor)
+	return ret0
+}
+
+// MemoryMonitor indicates an expected call of MemoryMonitor
+func (mr *MockOptionsMockRecorder) MemoryMonitor() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemoryMonitor", reflect.TypeOf((*MockOptions)(nil).MemoryMonitor))
+}
+
 // SetMmapReporter mocks base method
 func (m *MockOptions) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 	m.ctrl.T.Helper()
@@ -36,6 +36,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/cardinality"
+	"github.com/m3db/m3/src/dbnode/storage/downsample"
+	"github.com/m3db/m3/src/dbnode/storage/hotshards"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -47,6 +50,8 @@ import (
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/intern"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
 	xsync "github.com/m3db/m3/src/x/sync"
@@ -819,6 +824,25 @@ type databaseRepairer interface {
 
 	// Report reports runtime information.
 	Report()
+
+	// ScheduleRepair schedules a block to be repaired again, ahead of blocks
+	// that have already been repaired.
+	ScheduleRepair(namespace ident.ID, blockStart time.Time)
+}
+
+// databaseScrubber validates filesets on disk in the background.
+type databaseScrubber interface {
+	// Start starts the scrub process.
+	Start()
+
+	// Stop stops the scrub process.
+	Stop()
+
+	// Scrub validates the filesets of the owned namespaces and shards.
+	Scrub() error
+
+	// Report reports runtime information.
+	Report()
 }
 
 // databaseTickManager performs periodic ticking.
@@ -881,6 +905,19 @@ type OnColdFlushNamespace interface {
 	Done() error
 }
 
+// ScrubOptions are the options for the background fileset scrubber.
+type ScrubOptions struct {
+	// Enabled enables the scrubber.
+	Enabled bool
+
+	// Interval is the interval between scrubs of all filesets.
+	Interval time.Duration
+
+	// RepairCorrupt schedules blocks with corrupt filesets to be repaired
+	// from peers, which requires repair to be enabled.
+	RepairCorrupt bool
+}
+
 // Options represents the options for storage.
 type Options interface {
 	// Validate validates assumptions baked into the code.
@@ -969,6 +1006,12 @@ type Options interface {
 	// RepairOptions returns the repair options.
 	RepairOptions() repair.Options
 
+	// SetScrubOptions sets the fileset scrub options.
+	SetScrubOptions(value ScrubOptions) Options
+
+	// ScrubOptions returns the fileset scrub options.
+	ScrubOptions() ScrubOptions
+
 	// SetBootstrapProcessProvider sets the bootstrap process provider for the database.
 	SetBootstrapProcessProvider(value bootstrap.ProcessProvider) Options
 
@@ -1058,6 +1101,36 @@ type Options interface {
 	// IDPool returns the ID pool.
 	IdentifierPool() ident.Pool
 
+	// SetTagInterner sets the cache interning tag names and values of indexed
+	// series that are not part of the series ID, nil disables interning.
+	SetTagInterner(value *intern.Cache) Options
+
+	// TagInterner returns the cache interning tag names and values of indexed
+	// series.
+	TagInterner() *intern.Cache
+
+	// SetCardinalityEstimator sets the estimator of the number of unique
+	// series indexed, nil disables the estimates.
+	SetCardinalityEstimator(value *cardinality.Estimator) Options
+
+	// CardinalityEstimator returns the estimator of the number of unique
+	// series indexed.
+	CardinalityEstimator() *cardinality.Estimator
+
+	// SetHotShardDetector sets the detector of the busiest shards, nil
+	// disables the detection.
+	SetHotShardDetector(value *hotshards.Detector) Options
+
+	// HotShardDetector returns the detector of the busiest shards.
+	HotShardDetector() *hotshards.Detector
+
+	// SetFlushDownsampler sets the downsampler of flushed blocks, nil
+	// disables downsampling at flush time.
+	SetFlushDownsampler(value *downsample.Downsampler) Options
+
+	// FlushDownsampler returns the downsampler of flushed blocks.
+	FlushDownsampler() *downsample.Downsampler
+
 	// SetFetchBlockMetadataResultsPool sets the fetchBlockMetadataResultsPool.
 	SetFetchBlockMetadataResultsPool(value block.FetchBlockMetadataResultsPool) Options
 
@@ -1130,6 +1203,13 @@ type Options interface {
 	// MemoryTracker returns the MemoryTracker.
 	MemoryTracker() MemoryTracker
 
+	// SetMemoryMonitor sets the monitor of heap usage, writes of new series
+	// are rejected once it reaches the reject new series stage.
+	SetMemoryMonitor(value memory.Monitor) Options
+
+	// MemoryMonitor returns the monitor of heap usage.
+	MemoryMonitor() memory.Monitor
+
 	// SetMmapReporter sets the mmap reporter.
 	SetMmapReporter(mmapReporter mmap.Reporter) Options
 
@@ -65,6 +65,7 @@ type opAgent struct {
 	configPath          string
 	newProcessMonitorFn newProcessMonitorFn
 	processMonitor      exec.ProcessMonitor
+	processStartTime    time.Time
 	heartbeater         *heatbeater
 
 	running            int32
@@ -145,6 +146,7 @@ func (o *opAgent) reportMetrics() {
 			updateBoolGauge(state.running, o.metrics.running)
 			updateBoolGauge(state.executablePath != "", o.metrics.execTransferred)
 			updateBoolGauge(state.configPath != "", o.metrics.confTransferred)
+			o.metrics.processUptime.Update(state.processUptime.Seconds())
 		case <-o.closeCh:
 			reportTicker.Stop()
 			o.doneCh <- struct{}{}
@@ -161,16 +163,21 @@ type opAgentState struct {
 	running        bool
 	executablePath string
 	configPath     string
+	processUptime  time.Duration
 }
 
 func (o *opAgent) state() opAgentState {
 	o.RLock()
 	defer o.RUnlock()
-	return opAgentState{
+	state := opAgentState{
 		running:        o.Running(),
 		executablePath: o.executablePath,
 		configPath:     o.configPath,
 	}
+	if state.running {
+		state.processUptime = o.opts.NowFn()().Sub(o.processStartTime)
+	}
+	return state
 }
 
 func (o *opAgent) Start(ctx context.Context, request *m3em.StartRequest) (*m3em.StartResponse, error) {
@@ -204,8 +211,11 @@ func (o *opAgent) onProcessTerminate(err error) {
 		err = fmt.Errorf("test process terminated with error: %v", err)
 	}
 	o.logger.Warn(err.Error())
-	if stopping := atomic.LoadInt32(&o.stopping); stopping == 0 && o.heartbeater != nil {
-		o.heartbeater.notifyProcessTermination(err.Error())
+	if stopping := atomic.LoadInt32(&o.stopping); stopping == 0 {
+		o.metrics.processTerminated.Inc(1)
+		if o.heartbeater != nil {
+			o.heartbeater.notifyProcessTermination(err.Error())
+		}
 	}
 	atomic.StoreInt32(&o.running, 0)
 }
@@ -240,6 +250,7 @@ func (o *opAgent) startWithLock() error {
 	}
 	atomic.StoreInt32(&o.running, 1)
 	o.processMonitor = pm
+	o.processStartTime = o.opts.NowFn()()
 	return nil
 }
 
@@ -679,17 +690,20 @@ func (o *opAgent) sendLocalFileWithRLock(localPath string, chunkSize int64, maxB
 }
 
 type opAgentMetrics struct {
-	// TODO(prateek): process monitor opts, metric for process uptime
-	running         tally.Gauge
-	execTransferred tally.Gauge
-	confTransferred tally.Gauge
+	running           tally.Gauge
+	execTransferred   tally.Gauge
+	confTransferred   tally.Gauge
+	processUptime     tally.Gauge
+	processTerminated tally.Counter
 }
 
 func newAgentMetrics(scope tally.Scope) *opAgentMetrics {
 	subscope := scope.SubScope("agent")
 	return &opAgentMetrics{
-		running:         subscope.Gauge("running"),
-		execTransferred: subscope.Gauge("exec_transferred"),
-		confTransferred: subscope.Gauge("conf_transferred"),
+		running:           subscope.Gauge("running"),
+		execTransferred:   subscope.Gauge("exec_transferred"),
+		confTransferred:   subscope.Gauge("conf_transferred"),
+		processUptime:     subscope.Gauge("process_uptime"),
+		processTerminated: subscope.Counter("process_terminated"),
 	}
 }
@@ -26,6 +26,7 @@ import (
 	"net"
 	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -39,6 +40,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 	context "golang.org/x/net/context"
 )
 
@@ -158,6 +160,52 @@ func TestProgramCrashNotify(t *testing.T) {
 	}
 }
 
+func TestAgentProcessMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := newTempDir(t)
+	defer os.RemoveAll(tempDir)
+
+	var (
+		scope    = tally.NewTestScope("", nil)
+		nowNanos = time.Now().UnixNano()
+		nowFn    = func() time.Time { return time.Unix(0, atomic.LoadInt64(&nowNanos)) }
+	)
+	opts := newTestOptions(tempDir).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetNowFn(nowFn)
+	testAgent, err := New(opts)
+	require.NoError(t, err)
+	rawAgent, ok := testAgent.(*opAgent)
+	require.True(t, ok)
+	defer rawAgent.Close()
+
+	var testListener exec.ProcessListener
+	pm := mockexec.NewMockProcessMonitor(ctrl)
+	rawAgent.newProcessMonitorFn = func(c exec.Cmd, l exec.ProcessListener) (exec.ProcessMonitor, error) {
+		testListener = l
+		return pm, nil
+	}
+
+	rawAgent.executablePath = "someString"
+	rawAgent.configPath = "otherString"
+
+	pm.EXPECT().Start().Return(nil)
+	_, err = rawAgent.Start(context.Background(), &m3em.StartRequest{})
+	require.NoError(t, err)
+
+	atomic.AddInt64(&nowNanos, int64(time.Minute))
+	require.Equal(t, time.Minute, rawAgent.state().processUptime)
+
+	// Unexpected process termination is counted and resets the uptime.
+	testListener.OnComplete()
+	require.Equal(t, time.Duration(0), rawAgent.state().processUptime)
+	counter, ok := scope.Snapshot().Counters()["agent.process_terminated+"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), counter.Value())
+}
+
 func TestTooManyFailedHeartbeatsUnsetup(t *testing.T) {
 	tempDir := newTempDir(t)
 	defer os.RemoveAll(tempDir)
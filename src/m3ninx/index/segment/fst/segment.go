@@ -415,7 +415,8 @@ func (r *fsSegment) matchFieldNotClosedMaybeFinalizedWithRLock(
 
 	if !r.data.Version.supportsFieldPostingsList() {
 		// i.e. don't have the field level postings list, so fall back to regexp
-		return r.matchRegexpNotClosedMaybeFinalizedWithRLock(field, index.DotStarCompiledRegex())
+		pl, _, err := r.matchRegexpNotClosedMaybeFinalizedWithRLock(field, index.DotStarCompiledRegex(), 0)
+		return pl, err
 	}
 
 	termsFSTOffset, exists, err := r.fieldsFST.Get(field)
@@ -503,32 +504,34 @@ func (r *fsSegment) MatchRegexp(
 	if r.closed {
 		return nil, errReaderClosed
 	}
-	return r.matchRegexpNotClosedMaybeFinalizedWithRLock(field, compiled)
+	pl, _, err := r.matchRegexpNotClosedMaybeFinalizedWithRLock(field, compiled, 0)
+	return pl, err
 }
 
 func (r *fsSegment) matchRegexpNotClosedMaybeFinalizedWithRLock(
 	field []byte,
 	compiled index.CompiledRegex,
-) (postings.List, error) {
+	limit int,
+) (postings.List, int, error) {
 	// NB(r): Not closed, but could be finalized (i.e. closed segment reader)
 	// calling match field after this segment is finalized.
 	if r.finalized {
-		return nil, errReaderFinalized
+		return nil, 0, errReaderFinalized
 	}
 
 	re := compiled.FST
 	if re == nil {
-		return nil, errReaderNilRegexp
+		return nil, 0, errReaderNilRegexp
 	}
 
 	termsFST, exists, err := r.retrieveTermsFSTWithRLock(field)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if !exists {
 		// i.e. we don't know anything about the field, so can early return an empty postings list
-		return r.opts.PostingsListPool().Get(), nil
+		return r.opts.PostingsListPool().Get(), 0, nil
 	}
 
 	var (
@@ -549,13 +552,17 @@ func (r *fsSegment) matchRegexpNotClosedMaybeFinalizedWithRLock(
 		}
 
 		if iterErr != nil {
-			return nil, iterErr
+			return nil, len(pls), iterErr
+		}
+
+		if limit > 0 && len(pls) >= limit {
+			return nil, len(pls), index.ErrTermsLimitExceeded
 		}
 
 		_, postingsOffset := iter.Current()
 		nextPl, err := r.retrievePostingsListWithRLock(postingsOffset)
 		if err != nil {
-			return nil, err
+			return nil, len(pls), err
 		}
 		pls = append(pls, nextPl)
 		iterErr = iter.Next()
@@ -563,18 +570,18 @@ func (r *fsSegment) matchRegexpNotClosedMaybeFinalizedWithRLock(
 
 	pl, err := roaring.Union(pls)
 	if err != nil {
-		return nil, err
+		return nil, len(pls), err
 	}
 
 	if err := iterCloser.Close(); err != nil {
-		return nil, err
+		return nil, len(pls), err
 	}
 
 	if err := fstCloser.Close(); err != nil {
-		return nil, err
+		return nil, len(pls), err
 	}
 
-	return pl, nil
+	return pl, len(pls), nil
 }
 
 func (r *fsSegment) MatchAll() (postings.MutableList, error) {
@@ -833,7 +840,10 @@ func (r *fsSegment) retrieveBytesWithRLock(base []byte, offset uint64) ([]byte,
 	return base[payloadStart:payloadEnd], nil
 }
 
-var _ index.Reader = (*fsSegmentReader)(nil)
+var (
+	_ index.Reader                = (*fsSegmentReader)(nil)
+	_ index.LimitedRegexpReadable = (*fsSegmentReader)(nil)
+)
 
 // fsSegmentReader is not thread safe for use and relies on the underlying
 // segment for synchronization.
@@ -886,11 +896,27 @@ func (sr *fsSegmentReader) MatchRegexp(
 	// NB(r): We are allowed to call match field after Close called on
 	// the segment but not after it is finalized.
 	sr.fsSegment.RLock()
-	pl, err := sr.fsSegment.matchRegexpNotClosedMaybeFinalizedWithRLock(field, compiled)
+	pl, _, err := sr.fsSegment.matchRegexpNotClosedMaybeFinalizedWithRLock(field, compiled, 0)
 	sr.fsSegment.RUnlock()
 	return pl, err
 }
 
+func (sr *fsSegmentReader) MatchRegexpWithLimit(
+	field []byte,
+	compiled index.CompiledRegex,
+	limit int,
+) (postings.List, int, error) {
+	if sr.closed {
+		return nil, 0, errReaderClosed
+	}
+	// NB(r): We are allowed to call match field after Close called on
+	// the segment but not after it is finalized.
+	sr.fsSegment.RLock()
+	pl, n, err := sr.fsSegment.matchRegexpNotClosedMaybeFinalizedWithRLock(field, compiled, limit)
+	sr.fsSegment.RUnlock()
+	return pl, n, err
+}
+
 func (sr *fsSegmentReader) MatchAll() (postings.MutableList, error) {
 	if sr.closed {
 		return nil, errReaderClosed
@@ -568,6 +568,36 @@ func TestSegmentReaderValidUntilClose(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestSegmentReaderMatchRegexpWithLimit(t *testing.T) {
+	_, fstSeg := newTestSegments(t, fewTestDocuments)
+
+	reader, err := fstSeg.Reader()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, reader.Close())
+	}()
+
+	limited, ok := reader.(index.LimitedRegexpReadable)
+	require.True(t, ok)
+
+	re, err := index.CompileRegex([]byte("^.*apple$"))
+	require.NoError(t, err)
+
+	list, n, err := limited.MatchRegexpWithLimit([]byte("fruit"), re, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	assertPostingsList(t, list, []postings.ID{1, 2})
+
+	list, n, err = limited.MatchRegexpWithLimit([]byte("fruit"), re, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	assertPostingsList(t, list, []postings.ID{1, 2})
+
+	_, n, err = limited.MatchRegexpWithLimit([]byte("fruit"), re, 1)
+	require.Equal(t, index.ErrTermsLimitExceeded, err)
+	require.Equal(t, 1, n)
+}
+
 func newTestSegments(t *testing.T, docs []doc.Document) (memSeg sgmt.MutableSegment, fstSeg sgmt.Segment) {
 	s := newTestMemSegment(t)
 	for _, d := range docs {
@@ -35,6 +35,10 @@ import (
 // ErrDocNotFound is the error returned when there is no document for a given postings ID.
 var ErrDocNotFound = errors.New("no document with given postings ID")
 
+// ErrTermsLimitExceeded is the error returned when matching a query scans more
+// terms than the limit allows.
+var ErrTermsLimitExceeded = errors.New("terms limit exceeded")
+
 // Index is a collection of searchable documents.
 type Index interface {
 	Writer
@@ -84,6 +88,16 @@ type Readable interface {
 	AllDocs() (IDDocIterator, error)
 }
 
+// LimitedRegexpReadable is implemented by readers which can stop matching a
+// regular expression once a given number of terms have been scanned.
+type LimitedRegexpReadable interface {
+	// MatchRegexpWithLimit returns a postings list over all documents which match
+	// the given regular expression and the number of terms that matched. It returns
+	// ErrTermsLimitExceeded once more than limit terms match, a zero limit
+	// specifies no limit.
+	MatchRegexpWithLimit(field []byte, c CompiledRegex, limit int) (postings.List, int, error)
+}
+
 // CompiledRegex is a collection of regexp compiled structs to allow
 // amortisation of regexp construction costs.
 type CompiledRegex struct {
@@ -34,10 +34,11 @@ import (
 var (
 	testAggregatedMetric1 = aggregated.MetricWithStoragePolicy{
 		Metric: aggregated.Metric{
-			Type:      metric.CounterType,
-			ID:        []byte("foo"),
-			TimeNanos: 1234,
-			Value:     100,
+			Type:       metric.CounterType,
+			ID:         []byte("foo"),
+			TimeNanos:  1234,
+			Value:      100,
+			Annotation: []byte("traceID"),
 		},
 		StoragePolicy: policy.MustParseStoragePolicy("10s:2d"),
 	}
@@ -63,6 +64,7 @@ func TestAggregatedEncoderDecoder_RoundTrip(t *testing.T) {
 	require.Equal(t, string(testAggregatedMetric1.ID), string(dec.ID()))
 	require.Equal(t, testAggregatedMetric1.TimeNanos, dec.TimeNanos())
 	require.Equal(t, testAggregatedMetric1.Value, dec.Value())
+	require.Equal(t, string(testAggregatedMetric1.Annotation), string(dec.Annotation()))
 }
 
 func TestAggregatedEncoderDecoder_WithBytesPool(t *testing.T) {
@@ -83,6 +85,7 @@ func TestAggregatedEncoderDecoder_WithBytesPool(t *testing.T) {
 	require.Equal(t, string(testAggregatedMetric1.ID), string(dec.ID()))
 	require.Equal(t, testAggregatedMetric1.TimeNanos, dec.TimeNanos())
 	require.Equal(t, testAggregatedMetric1.Value, dec.Value())
+	require.Equal(t, string(testAggregatedMetric1.Annotation), string(dec.Annotation()))
 }
 
 func TestAggregatedEncoderDecoder_ResetProtobuf(t *testing.T) {
@@ -111,4 +114,5 @@ func TestAggregatedEncoderDecoder_ResetProtobuf(t *testing.T) {
 	require.Equal(t, string(testAggregatedMetric2.ID), string(dec.ID()))
 	require.Equal(t, testAggregatedMetric2.TimeNanos, dec.TimeNanos())
 	require.Equal(t, testAggregatedMetric2.Value, dec.Value())
+	require.Equal(t, 0, len(dec.Annotation()))
 }
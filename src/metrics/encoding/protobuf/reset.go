@@ -158,6 +158,7 @@ func resetTimedMetric(pb *metricpb.TimedMetric) {
 	pb.Id = pb.Id[:0]
 	pb.TimeNanos = 0
 	pb.Value = 0
+	pb.Annotation = pb.Annotation[:0]
 }
 
 func resetMetadatas(pb *metricpb.StagedMetadatas) {
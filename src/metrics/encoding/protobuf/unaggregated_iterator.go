@@ -27,6 +27,8 @@ import (
 
 	"github.com/m3db/m3/src/metrics/encoding"
 	"github.com/m3db/m3/src/metrics/generated/proto/metricpb"
+	"github.com/m3db/m3/src/metrics/metric"
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
 	"github.com/m3db/m3/src/x/pool"
 )
 
@@ -46,9 +48,10 @@ type UnaggregatedIterator interface {
 }
 
 type unaggregatedIterator struct {
-	reader         encoding.ByteReadScanner
-	bytesPool      pool.BytesPool
-	maxMessageSize int
+	reader           encoding.ByteReadScanner
+	bytesPool        pool.BytesPool
+	pooledMetricPool unaggregated.PooledMetricPool
+	maxMessageSize   int
 
 	closed bool
 	pb     metricpb.MetricWithMetadatas
@@ -64,10 +67,11 @@ func NewUnaggregatedIterator(
 ) UnaggregatedIterator {
 	bytesPool := opts.BytesPool()
 	return &unaggregatedIterator{
-		reader:         reader,
-		bytesPool:      bytesPool,
-		maxMessageSize: opts.MaxMessageSize(),
-		buf:            allocate(bytesPool, opts.InitBufferSize()),
+		reader:           reader,
+		bytesPool:        bytesPool,
+		pooledMetricPool: opts.PooledMetricPool(),
+		maxMessageSize:   opts.MaxMessageSize(),
+		buf:              allocate(bytesPool, opts.InitBufferSize()),
 	}
 }
 
@@ -83,6 +87,7 @@ func (it *unaggregatedIterator) Close() {
 		it.bytesPool.Put(it.buf)
 	}
 	it.bytesPool = nil
+	it.pooledMetricPool = nil
 	it.buf = nil
 	it.err = nil
 }
@@ -129,6 +134,7 @@ func (it *unaggregatedIterator) decodeMessage(size int) error {
 		return err
 	}
 	ReuseMetricWithMetadatasProto(&it.pb)
+	it.msg.PooledMetric = nil
 	if err := it.pb.Unmarshal(it.buf[:size]); err != nil {
 		it.err = err
 		return err
@@ -158,5 +164,38 @@ func (it *unaggregatedIterator) decodeMessage(size int) error {
 	default:
 		it.err = fmt.Errorf("unrecognized message type: %v", it.pb.Type)
 	}
+	if it.err == nil && it.pooledMetricPool != nil {
+		it.decodePooledMetric()
+	}
 	return it.err
 }
+
+// decodePooledMetric moves the id and values of the decoded untimed metric
+// into a pooled metric without copying, handing the spare buffers of the
+// pooled metric to the proto message for the next message to be decoded into.
+func (it *unaggregatedIterator) decodePooledMetric() {
+	var m *unaggregated.PooledMetric
+	switch it.msg.Type {
+	case encoding.CounterWithMetadatasType:
+		pb := &it.pb.CounterWithMetadatas.Counter
+		m = it.pooledMetricPool.Get()
+		m.Type = metric.CounterType
+		m.CounterVal = pb.Value
+		m.ID, pb.Id = pb.Id, m.ID
+	case encoding.BatchTimerWithMetadatasType:
+		pb := &it.pb.BatchTimerWithMetadatas.BatchTimer
+		m = it.pooledMetricPool.Get()
+		m.Type = metric.TimerType
+		m.ID, pb.Id = pb.Id, m.ID
+		m.BatchTimerVal, pb.Values = pb.Values, m.BatchTimerVal
+	case encoding.GaugeWithMetadatasType:
+		pb := &it.pb.GaugeWithMetadatas.Gauge
+		m = it.pooledMetricPool.Get()
+		m.Type = metric.GaugeType
+		m.GaugeVal = pb.Value
+		m.ID, pb.Id = pb.Id, m.ID
+	default:
+		return
+	}
+	it.msg.PooledMetric = m
+}
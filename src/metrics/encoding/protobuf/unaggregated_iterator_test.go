@@ -475,6 +475,81 @@ func TestUnaggregatedIteratorDecodeStress(t *testing.T) {
 	require.Equal(t, len(inputs)*numIter, i)
 }
 
+func TestUnaggregatedIteratorDecodePooledMetrics(t *testing.T) {
+	inputs := []encoding.UnaggregatedMessageUnion{
+		{
+			Type: encoding.CounterWithMetadatasType,
+			CounterWithMetadatas: unaggregated.CounterWithMetadatas{
+				Counter:         testCounter1,
+				StagedMetadatas: testStagedMetadatas1,
+			},
+		},
+		{
+			Type: encoding.BatchTimerWithMetadatasType,
+			BatchTimerWithMetadatas: unaggregated.BatchTimerWithMetadatas{
+				BatchTimer:      testBatchTimer1,
+				StagedMetadatas: testStagedMetadatas1,
+			},
+		},
+		{
+			Type: encoding.GaugeWithMetadatasType,
+			GaugeWithMetadatas: unaggregated.GaugeWithMetadatas{
+				Gauge:           testGauge1,
+				StagedMetadatas: testStagedMetadatas2,
+			},
+		},
+		{
+			Type: encoding.PassthroughMetricWithMetadataType,
+			PassthroughMetricWithMetadata: aggregated.PassthroughMetricWithMetadata{
+				Metric:        testPassthroughMetric1,
+				StoragePolicy: testPassthroughMetadata1,
+			},
+		},
+	}
+	expected := []unaggregated.MetricUnion{
+		testCounter1.ToUnion(),
+		testBatchTimer1.ToUnion(),
+		testGauge1.ToUnion(),
+	}
+
+	enc := NewUnaggregatedEncoder(NewUnaggregatedOptions())
+	for _, input := range inputs {
+		require.NoError(t, enc.EncodeMessage(input))
+	}
+	dataBuf := enc.Relinquish()
+	defer dataBuf.Close()
+
+	metricPool := unaggregated.NewPooledMetricPool(nil)
+	metricPool.Init()
+	opts := NewUnaggregatedOptions().SetPooledMetricPool(metricPool)
+	it := NewUnaggregatedIterator(bytes.NewReader(dataBuf.Bytes()), opts)
+	defer it.Close()
+
+	var pooled []*unaggregated.PooledMetric
+	for it.Next() {
+		res := it.Current()
+		if res.Type == encoding.PassthroughMetricWithMetadataType {
+			require.Nil(t, res.PooledMetric)
+			continue
+		}
+		require.NotNil(t, res.PooledMetric)
+		pooled = append(pooled, res.PooledMetric)
+	}
+	require.Equal(t, io.EOF, it.Err())
+	require.Equal(t, len(expected), len(pooled))
+
+	// The pooled metrics must be unaffected by decoding subsequent messages.
+	for i, m := range pooled {
+		require.Equal(t, expected[i].Type, m.Type)
+		require.Equal(t, expected[i].ID, m.ID)
+		require.Equal(t, expected[i].CounterVal, m.CounterVal)
+		require.Equal(t, expected[i].BatchTimerVal, m.BatchTimerVal)
+		require.Equal(t, expected[i].GaugeVal, m.GaugeVal)
+		require.True(t, m.Ref == m)
+		m.DecRef()
+	}
+}
+
 func TestUnaggregatedIteratorMessageTooLarge(t *testing.T) {
 	input := unaggregated.GaugeWithMetadatas{
 		Gauge:           testGauge1,
@@ -20,7 +20,10 @@
 
 package protobuf
 
-import "github.com/m3db/m3/src/x/pool"
+import (
+	"github.com/m3db/m3/src/metrics/metric/unaggregated"
+	"github.com/m3db/m3/src/x/pool"
+)
 
 const (
 	defaultInitBufferSize = 2880
@@ -51,12 +54,20 @@ type UnaggregatedOptions interface {
 
 	// MaxMessageSize returns the maximum message size.
 	MaxMessageSize() int
+
+	// SetPooledMetricPool sets the pool untimed metrics are decoded into,
+	// if not set untimed metrics are not decoded into pooled metrics.
+	SetPooledMetricPool(value unaggregated.PooledMetricPool) UnaggregatedOptions
+
+	// PooledMetricPool returns the pool untimed metrics are decoded into.
+	PooledMetricPool() unaggregated.PooledMetricPool
 }
 
 type unaggregatedOptions struct {
-	bytesPool      pool.BytesPool
-	initBufferSize int
-	maxMessageSize int
+	bytesPool        pool.BytesPool
+	initBufferSize   int
+	maxMessageSize   int
+	pooledMetricPool unaggregated.PooledMetricPool
 }
 
 // NewUnaggregatedOptions create a new set of unaggregated options.
@@ -99,3 +110,13 @@ func (o *unaggregatedOptions) SetMaxMessageSize(value int) UnaggregatedOptions {
 func (o *unaggregatedOptions) MaxMessageSize() int {
 	return o.maxMessageSize
 }
+
+func (o *unaggregatedOptions) SetPooledMetricPool(value unaggregated.PooledMetricPool) UnaggregatedOptions {
+	opts := *o
+	opts.pooledMetricPool = value
+	return &opts
+}
+
+func (o *unaggregatedOptions) PooledMetricPool() unaggregated.PooledMetricPool {
+	return o.pooledMetricPool
+}
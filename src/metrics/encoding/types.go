@@ -46,6 +46,10 @@ const (
 // A message union may contain at most one type of message that is determined
 // by the `Type` field of the union, which in turn determines which one
 // of the field in the union contains the corresponding message data.
+//
+// If the decoder is configured with a pooled metric pool, untimed metrics are
+// also decoded into PooledMetric, whose id and values back the untimed message.
+// The caller owns the pooled metric and must release it once done.
 type UnaggregatedMessageUnion struct {
 	Type                          UnaggregatedMessageType
 	CounterWithMetadatas          unaggregated.CounterWithMetadatas
@@ -55,6 +59,7 @@ type UnaggregatedMessageUnion struct {
 	TimedMetricWithMetadata       aggregated.TimedMetricWithMetadata
 	TimedMetricWithMetadatas      aggregated.TimedMetricWithMetadatas
 	PassthroughMetricWithMetadata aggregated.PassthroughMetricWithMetadata
+	PooledMetric                  *unaggregated.PooledMetric
 }
 
 // ByteReadScanner is capable of reading and scanning bytes.
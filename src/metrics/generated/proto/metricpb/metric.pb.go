@@ -136,10 +136,11 @@ func (m *Gauge) GetValue() float64 {
 }
 
 type TimedMetric struct {
-	Type      MetricType `protobuf:"varint,1,opt,name=type,proto3,enum=metricpb.MetricType" json:"type,omitempty"`
-	Id        []byte     `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
-	TimeNanos int64      `protobuf:"varint,3,opt,name=time_nanos,json=timeNanos,proto3" json:"time_nanos,omitempty"`
-	Value     float64    `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Type       MetricType `protobuf:"varint,1,opt,name=type,proto3,enum=metricpb.MetricType" json:"type,omitempty"`
+	Id         []byte     `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	TimeNanos  int64      `protobuf:"varint,3,opt,name=time_nanos,json=timeNanos,proto3" json:"time_nanos,omitempty"`
+	Value      float64    `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+	Annotation []byte     `protobuf:"bytes,5,opt,name=annotation,proto3" json:"annotation,omitempty"`
 }
 
 func (m *TimedMetric) Reset()                    { *m = TimedMetric{} }
@@ -175,6 +176,13 @@ func (m *TimedMetric) GetValue() float64 {
 	return 0
 }
 
+func (m *TimedMetric) GetAnnotation() []byte {
+	if m != nil {
+		return m.Annotation
+	}
+	return nil
+}
+
 type ForwardedMetric struct {
 	Type      MetricType `protobuf:"varint,1,opt,name=type,proto3,enum=metricpb.MetricType" json:"type,omitempty"`
 	Id        []byte     `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
@@ -353,6 +361,12 @@ func (m *TimedMetric) MarshalTo(dAtA []byte) (int, error) {
 		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Value))))
 		i += 8
 	}
+	if len(m.Annotation) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintMetric(dAtA, i, uint64(len(m.Annotation)))
+		i += copy(dAtA[i:], m.Annotation)
+	}
 	return i, nil
 }
 
@@ -464,6 +478,10 @@ func (m *TimedMetric) Size() (n int) {
 	if m.Value != 0 {
 		n += 9
 	}
+	l = len(m.Annotation)
+	if l > 0 {
+		n += 1 + l + sovMetric(uint64(l))
+	}
 	return n
 }
 
@@ -927,6 +945,37 @@ func (m *TimedMetric) Unmarshal(dAtA []byte) error {
 			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
 			iNdEx += 8
 			m.Value = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Annotation", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMetric
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthMetric
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Annotation = append(m.Annotation[:0], dAtA[iNdEx:postIndex]...)
+			if m.Annotation == nil {
+				m.Annotation = []byte{}
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMetric(dAtA[iNdEx:])
@@ -1223,27 +1272,28 @@ func init() {
 }
 
 var fileDescriptorMetric = []byte{
-	// 340 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x72, 0x49, 0xcf, 0x2c, 0xc9,
-	0x28, 0x4d, 0xd2, 0x4b, 0xce, 0xcf, 0xd5, 0xcf, 0x35, 0x4e, 0x49, 0xd2, 0xcf, 0x35, 0xd6, 0x2f,
-	0x2e, 0x4a, 0xd6, 0xcf, 0x4d, 0x2d, 0x29, 0xca, 0x4c, 0x2e, 0xd6, 0x4f, 0x4f, 0xcd, 0x4b, 0x2d,
-	0x4a, 0x2c, 0x49, 0x4d, 0xd1, 0x2f, 0x28, 0xca, 0x2f, 0xc9, 0x87, 0x8a, 0x17, 0x24, 0x41, 0x19,
-	0x7a, 0x60, 0x51, 0x21, 0x0e, 0x98, 0xb0, 0x92, 0x3e, 0x17, 0xbb, 0x73, 0x7e, 0x69, 0x5e, 0x49,
-	0x6a, 0x91, 0x10, 0x1f, 0x17, 0x53, 0x66, 0x8a, 0x04, 0xa3, 0x02, 0xa3, 0x06, 0x4f, 0x10, 0x53,
-	0x66, 0x8a, 0x90, 0x08, 0x17, 0x6b, 0x59, 0x62, 0x4e, 0x69, 0xaa, 0x04, 0x93, 0x02, 0xa3, 0x06,
-	0x73, 0x10, 0x84, 0xa3, 0x64, 0xc2, 0xc5, 0xe5, 0x94, 0x58, 0x92, 0x9c, 0x11, 0x92, 0x99, 0x8b,
-	0x45, 0x8f, 0x18, 0x17, 0x1b, 0x58, 0x59, 0xb1, 0x04, 0x93, 0x02, 0xb3, 0x06, 0x63, 0x10, 0x94,
-	0xa7, 0xa4, 0xcb, 0xc5, 0xea, 0x9e, 0x58, 0x9a, 0x9e, 0x8a, 0xdf, 0x12, 0x46, 0x98, 0x25, 0x35,
-	0x5c, 0xdc, 0x20, 0xf3, 0x53, 0x7c, 0xc1, 0xce, 0x14, 0xd2, 0xe0, 0x62, 0x29, 0xa9, 0x2c, 0x48,
-	0x05, 0x6b, 0xe3, 0x33, 0x12, 0xd1, 0x83, 0xb9, 0x5e, 0x0f, 0x22, 0x1f, 0x52, 0x59, 0x90, 0x1a,
-	0x04, 0x56, 0x01, 0x35, 0x9e, 0x09, 0x6e, 0xbc, 0x2c, 0x17, 0x57, 0x49, 0x66, 0x6e, 0x6a, 0x7c,
-	0x5e, 0x62, 0x5e, 0x7e, 0xb1, 0x04, 0x33, 0xd8, 0x23, 0x9c, 0x20, 0x11, 0x3f, 0x90, 0x00, 0xc2,
-	0x76, 0x16, 0x64, 0xdb, 0x9b, 0x18, 0xb9, 0xf8, 0xdd, 0xf2, 0x8b, 0xca, 0x13, 0x8b, 0x52, 0x68,
-	0xef, 0x04, 0x44, 0x88, 0xb1, 0x20, 0x87, 0x98, 0x96, 0x0d, 0x17, 0x17, 0xc2, 0x68, 0x21, 0x6e,
-	0x2e, 0xf6, 0x50, 0x3f, 0x6f, 0x3f, 0xff, 0x70, 0x3f, 0x01, 0x06, 0x10, 0xc7, 0xd9, 0x3f, 0xd4,
-	0x2f, 0xc4, 0x35, 0x48, 0x80, 0x51, 0x88, 0x93, 0x8b, 0x35, 0xc4, 0xd3, 0xd7, 0x35, 0x48, 0x80,
-	0x09, 0xc4, 0x74, 0x77, 0x0c, 0x75, 0x77, 0x15, 0x60, 0x76, 0xf2, 0x3c, 0xf1, 0x48, 0x8e, 0xf1,
-	0xc2, 0x23, 0x39, 0xc6, 0x07, 0x8f, 0xe4, 0x18, 0x27, 0x3c, 0x96, 0x63, 0x88, 0x32, 0x27, 0x33,
-	0xe1, 0x24, 0xb1, 0x81, 0xf9, 0xc6, 0x80, 0x00, 0x00, 0x00, 0xff, 0xff, 0x24, 0x3b, 0xb0, 0x62,
-	0x7a, 0x02, 0x00, 0x00,
+	// 358 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x92, 0xc1, 0x4a, 0xeb, 0x40,
+	0x14, 0x86, 0x3b, 0x49, 0xd3, 0xde, 0x9e, 0x5e, 0x7a, 0x43, 0x28, 0x97, 0x6c, 0x0c, 0x25, 0xab,
+	0x20, 0x98, 0x80, 0x15, 0xdc, 0xb8, 0xb1, 0xb5, 0x96, 0x22, 0x4d, 0x21, 0xa4, 0x08, 0x6e, 0x64,
+	0x92, 0x0c, 0x6d, 0xc0, 0x64, 0xc2, 0x64, 0xa2, 0x74, 0xeb, 0x13, 0xf8, 0x00, 0x3e, 0x90, 0x4b,
+	0x1f, 0x41, 0xea, 0x8b, 0x48, 0xa6, 0xa9, 0xed, 0x42, 0x5c, 0x08, 0xee, 0xce, 0xff, 0xe5, 0x9c,
+	0xff, 0x3f, 0x39, 0x0c, 0x5c, 0x2c, 0x62, 0xbe, 0x2c, 0x02, 0x3b, 0xa4, 0x89, 0x93, 0xf4, 0xa3,
+	0xc0, 0x49, 0xfa, 0x4e, 0xce, 0x42, 0x27, 0x21, 0x9c, 0xc5, 0x61, 0xee, 0x2c, 0x48, 0x4a, 0x18,
+	0xe6, 0x24, 0x72, 0x32, 0x46, 0x39, 0xad, 0x78, 0x16, 0x54, 0x85, 0x2d, 0xa8, 0xf6, 0x67, 0x8b,
+	0x4d, 0x07, 0x9a, 0x43, 0x5a, 0xa4, 0x9c, 0x30, 0xad, 0x03, 0x52, 0x1c, 0xe9, 0xa8, 0x87, 0xac,
+	0xbf, 0x9e, 0x14, 0x47, 0x5a, 0x17, 0x94, 0x7b, 0x7c, 0x57, 0x10, 0x5d, 0xea, 0x21, 0x4b, 0xf6,
+	0x36, 0xc2, 0x3c, 0x01, 0x18, 0x60, 0x1e, 0x2e, 0xfd, 0x38, 0xf9, 0x62, 0xe6, 0x3f, 0x34, 0x44,
+	0x5b, 0xae, 0x4b, 0x3d, 0xd9, 0x42, 0x5e, 0xa5, 0xcc, 0x23, 0x50, 0xc6, 0xb8, 0x58, 0x90, 0xef,
+	0x43, 0xd0, 0x36, 0xe4, 0x19, 0x41, 0xbb, 0x0c, 0x88, 0xa6, 0x62, 0x4f, 0xcd, 0x82, 0x3a, 0x5f,
+	0x65, 0x44, 0xcc, 0x75, 0x8e, 0xbb, 0xf6, 0x76, 0x7d, 0x7b, 0xf3, 0xdd, 0x5f, 0x65, 0xc4, 0x13,
+	0x1d, 0x95, 0xbf, 0xf4, 0xe9, 0x7f, 0x00, 0xc0, 0xe3, 0x84, 0xdc, 0xa6, 0x38, 0xa5, 0xb9, 0x2e,
+	0x8b, 0x3f, 0x69, 0x95, 0xc4, 0x2d, 0xc1, 0x2e, 0xbe, 0xbe, 0x17, 0xaf, 0x19, 0x00, 0x38, 0x4d,
+	0x29, 0xc7, 0x3c, 0xa6, 0xa9, 0xae, 0x08, 0xb3, 0x3d, 0x62, 0x3e, 0x22, 0xf8, 0x77, 0x49, 0xd9,
+	0x03, 0x66, 0xd1, 0xef, 0xaf, 0xb8, 0x3b, 0x69, 0x7d, 0xff, 0xa4, 0x87, 0x67, 0x00, 0x3b, 0x6b,
+	0xad, 0x0d, 0xcd, 0xb9, 0x7b, 0xe5, 0xce, 0xae, 0x5d, 0xb5, 0x56, 0x8a, 0xe1, 0x6c, 0xee, 0xfa,
+	0x23, 0x4f, 0x45, 0x5a, 0x0b, 0x14, 0x7f, 0x32, 0x1d, 0x79, 0xaa, 0x54, 0x96, 0xe3, 0xf3, 0xf9,
+	0x78, 0xa4, 0xca, 0x83, 0xc9, 0xcb, 0xda, 0x40, 0xaf, 0x6b, 0x03, 0xbd, 0xad, 0x0d, 0xf4, 0xf4,
+	0x6e, 0xd4, 0x6e, 0x4e, 0x7f, 0xf8, 0xb2, 0x82, 0x86, 0xd0, 0xfd, 0x8f, 0x01, 0x00, 0x71, 0x1d,
+	0xac, 0x7f, 0x9b, 0x02, 0x00, 0x00,
 }
@@ -114,6 +114,7 @@ func (m PipelineMetadata) Clone() PipelineMetadata {
 		AggregationID:   m.AggregationID,
 		StoragePolicies: m.StoragePolicies.Clone(),
 		Pipeline:        m.Pipeline.Clone(),
+		DropPolicy:      m.DropPolicy,
 	}
 }
 
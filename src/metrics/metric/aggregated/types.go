@@ -44,6 +44,9 @@ type Metric struct {
 	ID        id.RawID
 	TimeNanos int64
 	Value     float64
+	// Annotation is optional opaque bytes attached to the datapoint, e.g. the
+	// trace ID of an exemplar.
+	Annotation []byte
 }
 
 // ToProto converts the metric to a protobuf message in place.
@@ -54,6 +57,7 @@ func (m Metric) ToProto(pb *metricpb.TimedMetric) error {
 	pb.Id = m.ID
 	pb.TimeNanos = m.TimeNanos
 	pb.Value = m.Value
+	pb.Annotation = m.Annotation
 	return nil
 }
 
@@ -65,6 +69,7 @@ func (m *Metric) FromProto(pb metricpb.TimedMetric) error {
 	m.ID = pb.Id
 	m.TimeNanos = pb.TimeNanos
 	m.Value = pb.Value
+	m.Annotation = pb.Annotation
 	return nil
 }
 
@@ -81,8 +86,9 @@ func (m Metric) String() string {
 // ChunkedMetric is a metric with a chunked ID.
 type ChunkedMetric struct {
 	id.ChunkedID
-	TimeNanos int64
-	Value     float64
+	TimeNanos  int64
+	Value      float64
+	Annotation []byte
 }
 
 // RawMetric is a metric in its raw form (e.g., encoded bytes associated with
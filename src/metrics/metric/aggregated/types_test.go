@@ -49,10 +49,11 @@ var (
 		Value:     33.87,
 	}
 	testMetric2 = Metric{
-		Type:      metric.TimerType,
-		ID:        []byte("testMetric2"),
-		TimeNanos: 67890,
-		Value:     21.99,
+		Type:       metric.TimerType,
+		ID:         []byte("testMetric2"),
+		TimeNanos:  67890,
+		Value:      21.99,
+		Annotation: []byte("traceID"),
 	}
 	testBadMetric = Metric{
 		Type: 999,
@@ -122,10 +123,11 @@ var (
 		Value:     33.87,
 	}
 	testMetric2Proto = metricpb.TimedMetric{
-		Type:      metricpb.MetricType_TIMER,
-		Id:        []byte("testMetric2"),
-		TimeNanos: 67890,
-		Value:     21.99,
+		Type:       metricpb.MetricType_TIMER,
+		Id:         []byte("testMetric2"),
+		TimeNanos:  67890,
+		Value:      21.99,
+		Annotation: []byte("traceID"),
 	}
 	testForwardedMetric1Proto = metricpb.ForwardedMetric{
 		Type:      metricpb.MetricType_COUNTER,
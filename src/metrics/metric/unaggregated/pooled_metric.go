@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unaggregated
+
+import (
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/x/pool"
+)
+
+// PooledMetric is a reference counted metric union allocated from a pool. The
+// pooled metric owns its id and timer values, so holders can retain them by
+// taking a reference instead of making a copy. The metric is reset and returned
+// to its pool once the last reference is released.
+type PooledMetric struct {
+	MetricUnion
+
+	refs int32
+	pool PooledMetricPool
+}
+
+// NewPooledMetric creates a new pooled metric with a single reference.
+func NewPooledMetric(p PooledMetricPool) *PooledMetric {
+	m := &PooledMetric{refs: 1, pool: p}
+	m.Ref = m
+	return m
+}
+
+// IncRef increments the reference count.
+func (m *PooledMetric) IncRef() { atomic.AddInt32(&m.refs, 1) }
+
+// DecRef decrements the reference count, returning the metric to its pool
+// when there are no references left.
+func (m *PooledMetric) DecRef() {
+	refs := atomic.AddInt32(&m.refs, -1)
+	if refs > 0 {
+		return
+	}
+	if refs < 0 {
+		panic("pooled metric reference count is negative")
+	}
+
+	// NB: the id and timer values buffers are kept so they can be reused
+	// when the metric is next decoded into.
+	m.MetricUnion = MetricUnion{
+		ID:            m.ID[:0],
+		BatchTimerVal: m.BatchTimerVal[:0],
+		Ref:           m,
+	}
+	if m.pool != nil {
+		m.pool.Put(m)
+	}
+}
+
+// PooledMetricPool is a pool of pooled metrics.
+type PooledMetricPool interface {
+	// Init initializes the pool.
+	Init()
+
+	// Get returns a pooled metric with a single reference.
+	Get() *PooledMetric
+
+	// Put returns a pooled metric to the pool.
+	Put(m *PooledMetric)
+}
+
+type pooledMetricPool struct {
+	pool pool.ObjectPool
+}
+
+// NewPooledMetricPool creates a new pool of pooled metrics.
+func NewPooledMetricPool(opts pool.ObjectPoolOptions) PooledMetricPool {
+	return &pooledMetricPool{pool: pool.NewObjectPool(opts)}
+}
+
+func (p *pooledMetricPool) Init() {
+	p.pool.Init(func() interface{} {
+		return NewPooledMetric(p)
+	})
+}
+
+func (p *pooledMetricPool) Get() *PooledMetric {
+	m := p.pool.Get().(*PooledMetric)
+	atomic.StoreInt32(&m.refs, 1)
+	return m
+}
+
+func (p *pooledMetricPool) Put(m *PooledMetric) {
+	p.pool.Put(m)
+}
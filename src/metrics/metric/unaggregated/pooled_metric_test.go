@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package unaggregated
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/metrics/metric"
+	"github.com/m3db/m3/src/x/pool"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPooledMetricRefCount(t *testing.T) {
+	p := NewPooledMetricPool(pool.NewObjectPoolOptions().SetSize(1))
+	p.Init()
+
+	m := p.Get()
+	require.True(t, m.Ref == m)
+	m.Type = metric.TimerType
+	m.ID = append(m.ID, "foo"...)
+	m.BatchTimerVal = append(m.BatchTimerVal, 1.0, 2.0)
+
+	m.IncRef()
+	m.DecRef()
+	require.Equal(t, []byte("foo"), []byte(m.ID))
+	require.Equal(t, []float64{1.0, 2.0}, m.BatchTimerVal)
+
+	// Releasing the last reference resets the metric but keeps its buffers.
+	m.DecRef()
+	require.Equal(t, metric.UnknownType, m.Type)
+	require.Equal(t, 0, len(m.ID))
+	require.True(t, cap(m.ID) >= 3)
+	require.Equal(t, 0, len(m.BatchTimerVal))
+	require.True(t, m.Ref == m)
+
+	require.Panics(t, func() { m.DecRef() })
+}
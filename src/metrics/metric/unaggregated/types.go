@@ -223,6 +223,8 @@ func (gm *GaugeWithMetadatas) FromProto(pb *metricpb.GaugeWithMetadatas) error {
 // which determines which value field is valid. Note that if the timer values are
 // allocated from a pool, the TimerValPool should be set to the originating pool,
 // and the caller is responsible for returning the timer values to the pool.
+// If the metric union was decoded into a pooled metric, Ref is set to it and
+// the id may be retained beyond the call by taking a reference on it.
 type MetricUnion struct {
 	Type          metric.Type
 	ID            id.RawID
@@ -230,6 +232,7 @@ type MetricUnion struct {
 	BatchTimerVal []float64
 	GaugeVal      float64
 	TimerValPool  pool.FloatsPool
+	Ref           *PooledMetric
 }
 
 var emptyMetricUnion MetricUnion
@@ -35,6 +35,8 @@ type Configuration struct {
 	MessagePool               *MessagePoolConfiguration `yaml:"messagePool"`
 	AckFlushInterval          *time.Duration            `yaml:"ackFlushInterval"`
 	AckBufferSize             *int                      `yaml:"ackBufferSize"`
+	MaxInflightMessages       *int                      `yaml:"maxInflightMessages"`
+	MaxInflightDuration       *time.Duration            `yaml:"maxInflightDuration"`
 	ConnectionWriteBufferSize *int                      `yaml:"connectionWriteBufferSize"`
 	ConnectionReadBufferSize  *int                      `yaml:"connectionReadBufferSize"`
 }
@@ -86,6 +88,12 @@ func (c *Configuration) NewOptions(iOpts instrument.Options) Options {
 	if c.AckBufferSize != nil {
 		opts = opts.SetAckBufferSize(*c.AckBufferSize)
 	}
+	if c.MaxInflightMessages != nil {
+		opts = opts.SetMaxInflightMessages(*c.MaxInflightMessages)
+	}
+	if c.MaxInflightDuration != nil {
+		opts = opts.SetMaxInflightDuration(*c.MaxInflightDuration)
+	}
 	if c.ConnectionWriteBufferSize != nil {
 		opts = opts.SetConnectionWriteBufferSize(*c.ConnectionWriteBufferSize)
 	}
@@ -39,6 +39,8 @@ ackFlushInterval: 100ms
 ackBufferSize: 100
 connectionWriteBufferSize: 200
 connectionReadBufferSize: 300
+maxInflightMessages: 10
+maxInflightDuration: 5s
 encoder:
   maxMessageSize: 100
   bytesPool:
@@ -61,6 +63,8 @@ decoder:
 	require.Equal(t, 100, opts.AckBufferSize())
 	require.Equal(t, 200, opts.ConnectionWriteBufferSize())
 	require.Equal(t, 300, opts.ConnectionReadBufferSize())
+	require.Equal(t, 10, opts.MaxInflightMessages())
+	require.Equal(t, 5*time.Second, opts.MaxInflightDuration())
 	require.Equal(t, 100, opts.EncoderOptions().MaxMessageSize())
 	require.NotNil(t, opts.EncoderOptions().BytesPool())
 	require.Equal(t, 200, opts.DecoderOptions().MaxMessageSize())
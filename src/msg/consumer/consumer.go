@@ -22,6 +22,7 @@ package consumer
 
 import (
 	"bufio"
+	"errors"
 	"net"
 	"sync"
 	"time"
@@ -32,6 +33,8 @@ import (
 	"github.com/uber-go/tally"
 )
 
+var errConsumerClosed = errors.New("consumer closed")
+
 type listener struct {
 	net.Listener
 
@@ -68,20 +71,26 @@ func (l *listener) Accept() (Consumer, error) {
 }
 
 type metrics struct {
-	messageReceived    tally.Counter
-	messageDecodeError tally.Counter
-	ackSent            tally.Counter
-	ackEncodeError     tally.Counter
-	ackWriteError      tally.Counter
+	messageReceived       tally.Counter
+	messageDecodeError    tally.Counter
+	messageInflightLimit  tally.Counter
+	messageInflightExpiry tally.Counter
+	messageProcessLatency tally.Timer
+	ackSent               tally.Counter
+	ackEncodeError        tally.Counter
+	ackWriteError         tally.Counter
 }
 
 func newConsumerMetrics(scope tally.Scope) metrics {
 	return metrics{
-		messageReceived:    scope.Counter("message-received"),
-		messageDecodeError: scope.Counter("message-decode-error"),
-		ackSent:            scope.Counter("ack-sent"),
-		ackEncodeError:     scope.Counter("ack-encode-error"),
-		ackWriteError:      scope.Counter("ack-write-error"),
+		messageReceived:       scope.Counter("message-received"),
+		messageDecodeError:    scope.Counter("message-decode-error"),
+		messageInflightLimit:  scope.Counter("message-inflight-limit"),
+		messageInflightExpiry: scope.Counter("message-inflight-expiry"),
+		messageProcessLatency: scope.Timer("message-process-latency"),
+		ackSent:               scope.Counter("ack-sent"),
+		ackEncodeError:        scope.Counter("ack-encode-error"),
+		ackWriteError:         scope.Counter("ack-write-error"),
 	}
 }
 
@@ -100,6 +109,10 @@ type consumer struct {
 	doneCh chan struct{}
 	wg     sync.WaitGroup
 	m      metrics
+
+	inflight     chan struct{}
+	inflightLock sync.Mutex
+	inflightMsgs map[*message]time.Time
 }
 
 func newConsumer(
@@ -108,6 +121,14 @@ func newConsumer(
 	opts Options,
 	m metrics,
 ) *consumer {
+	var (
+		inflight     chan struct{}
+		inflightMsgs map[*message]time.Time
+	)
+	if limit := opts.MaxInflightMessages(); limit > 0 {
+		inflight = make(chan struct{}, limit)
+		inflightMsgs = make(map[*message]time.Time, limit)
+	}
 	return &consumer{
 		opts:    opts,
 		mPool:   mPool,
@@ -116,11 +137,13 @@ func newConsumer(
 			bufio.NewReaderSize(conn, opts.ConnectionReadBufferSize()),
 			opts.DecoderOptions(),
 		),
-		w:      bufio.NewWriterSize(conn, opts.ConnectionWriteBufferSize()),
-		conn:   conn,
-		closed: false,
-		doneCh: make(chan struct{}),
-		m:      m,
+		w:            bufio.NewWriterSize(conn, opts.ConnectionWriteBufferSize()),
+		conn:         conn,
+		closed:       false,
+		doneCh:       make(chan struct{}),
+		m:            m,
+		inflight:     inflight,
+		inflightMsgs: inflightMsgs,
 	}
 }
 
@@ -133,17 +156,99 @@ func (c *consumer) Init() {
 }
 
 func (c *consumer) Message() (Message, error) {
+	if err := c.acquireInflight(); err != nil {
+		return nil, err
+	}
 	m := c.mPool.Get()
 	m.reset(c)
 	if err := c.decoder.Decode(m); err != nil {
 		c.mPool.Put(m)
+		c.releaseInflight()
 		c.m.messageDecodeError.Inc(1)
 		return nil, err
 	}
+	m.receivedAt = time.Now()
+	c.trackInflight(m)
 	c.m.messageReceived.Inc(1)
 	return m, nil
 }
 
+func (c *consumer) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// acquireInflight blocks until the number of unacked messages on the
+// connection is below the limit, so that slow processing applies
+// backpressure to the producer rather than growing memory unbounded.
+func (c *consumer) acquireInflight() error {
+	if c.inflight == nil {
+		return nil
+	}
+	select {
+	case c.inflight <- struct{}{}:
+		return nil
+	default:
+	}
+	c.m.messageInflightLimit.Inc(1)
+	select {
+	case c.inflight <- struct{}{}:
+		return nil
+	case <-c.doneCh:
+		return errConsumerClosed
+	}
+}
+
+func (c *consumer) releaseInflight() {
+	if c.inflight == nil {
+		return
+	}
+	<-c.inflight
+}
+
+func (c *consumer) trackInflight(m *message) {
+	if c.inflight == nil {
+		return
+	}
+	c.inflightLock.Lock()
+	c.inflightMsgs[m] = m.receivedAt
+	c.inflightLock.Unlock()
+}
+
+// releaseMessage releases the inflight slot of a message unless it has
+// already been released because it expired.
+func (c *consumer) releaseMessage(m *message) {
+	if c.inflight == nil {
+		return
+	}
+	c.inflightLock.Lock()
+	if _, ok := c.inflightMsgs[m]; ok {
+		delete(c.inflightMsgs, m)
+		c.releaseInflight()
+	}
+	c.inflightLock.Unlock()
+}
+
+// expireInflight releases the inflight slots of messages that have not been
+// acked within the max inflight duration. Messages that are never acked are
+// retried by the producer, so without expiry they would hold their slots
+// forever and eventually block the connection.
+func (c *consumer) expireInflight(now time.Time) {
+	maxInflightDur := c.opts.MaxInflightDuration()
+	if c.inflight == nil || maxInflightDur <= 0 {
+		return
+	}
+	c.inflightLock.Lock()
+	for m, receivedAt := range c.inflightMsgs {
+		if now.Sub(receivedAt) < maxInflightDur {
+			continue
+		}
+		delete(c.inflightMsgs, m)
+		c.releaseInflight()
+		c.m.messageInflightExpiry.Inc(1)
+	}
+	c.inflightLock.Unlock()
+}
+
 // This function could be called concurrently if messages are being
 // processed concurrently.
 func (c *consumer) tryAck(m msgpb.Metadata) {
@@ -172,6 +277,7 @@ func (c *consumer) ackUntilClose() {
 		select {
 		case <-flushTicker.C:
 			c.tryAckAndFlush()
+			c.expireInflight(time.Now())
 		case <-c.doneCh:
 			c.tryAckAndFlush()
 			return
@@ -221,8 +327,9 @@ func (c *consumer) Close() {
 type message struct {
 	msgpb.Message
 
-	mPool *messagePool
-	c     *consumer
+	mPool      *messagePool
+	c          *consumer
+	receivedAt time.Time
 }
 
 func newMessage(p *messagePool) *message {
@@ -234,6 +341,8 @@ func (m *message) Bytes() []byte {
 }
 
 func (m *message) Ack() {
+	m.c.m.messageProcessLatency.Record(time.Since(m.receivedAt))
+	m.c.releaseMessage(m)
 	m.c.tryAck(m.Metadata)
 	if m.mPool != nil {
 		m.mPool.Put(m)
@@ -294,6 +294,109 @@ func TestConsumerAckAfterClosed(t *testing.T) {
 	m2.Ack()
 }
 
+func TestConsumerMaxInflightMessages(t *testing.T) {
+	defer leaktest.Check(t)()
+
+	opts := testOptions().SetMaxInflightMessages(1)
+	l, err := NewListener("127.0.0.1:0", opts)
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	c, err := l.Accept()
+	require.NoError(t, err)
+
+	require.NoError(t, produce(conn, &testMsg1))
+	require.NoError(t, produce(conn, &testMsg2))
+
+	m1, err := c.Message()
+	require.NoError(t, err)
+	require.Equal(t, testMsg1.Value, m1.Bytes())
+
+	received := make(chan Message)
+	go func() {
+		m, err := c.Message()
+		require.NoError(t, err)
+		received <- m
+	}()
+
+	// The second message is not read until the first is acked.
+	select {
+	case <-received:
+		require.FailNow(t, "received message over the inflight limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	m1.Ack()
+	m2 := <-received
+	require.Equal(t, testMsg2.Value, m2.Bytes())
+
+	// Blocked reads are released when the consumer is closed.
+	errCh := make(chan error)
+	go func() {
+		_, err := c.Message()
+		errCh <- err
+	}()
+	c.Close()
+	require.Equal(t, errConsumerClosed, <-errCh)
+}
+
+func TestConsumerExpiresMessagesNeverAcked(t *testing.T) {
+	defer leaktest.Check(t)()
+
+	maxInflightDur := 300 * time.Millisecond
+	opts := testOptions().
+		SetMaxInflightMessages(1).
+		SetMaxInflightDuration(maxInflightDur)
+	l, err := NewListener("127.0.0.1:0", opts)
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+
+	c, err := l.Accept()
+	require.NoError(t, err)
+	c.Init()
+	defer c.Close()
+
+	require.NoError(t, produce(conn, &testMsg1))
+	require.NoError(t, produce(conn, &testMsg2))
+	require.NoError(t, produce(conn, &testMsg1))
+
+	m1, err := c.Message()
+	require.NoError(t, err)
+	start := time.Now()
+
+	// The first message is never acked so the second is only read once the
+	// first has expired.
+	m2, err := c.Message()
+	require.NoError(t, err)
+	require.Equal(t, testMsg2.Value, m2.Bytes())
+	require.True(t, time.Since(start) >= maxInflightDur)
+
+	// Acking the expired message does not release the slot of the second.
+	m1.Ack()
+	received := make(chan Message, 1)
+	go func() {
+		if m, err := c.Message(); err == nil {
+			received <- m
+		}
+	}()
+	select {
+	case <-received:
+		require.FailNow(t, "received message over the inflight limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	m2.Ack()
+	m3 := <-received
+	require.Equal(t, testMsg1.Value, m3.Bytes())
+	m3.Ack()
+}
+
 func TestConsumerTimeBasedFlush(t *testing.T) {
 	defer leaktest.Check(t)()
 
@@ -31,6 +31,7 @@ import (
 var (
 	defaultAckBufferSize        = 1048576
 	defaultAckFlushInterval     = 200 * time.Millisecond
+	defaultMaxInflightDuration  = time.Minute
 	defaultConnectionBufferSize = 1048576
 )
 
@@ -40,6 +41,8 @@ type options struct {
 	messagePoolOpts  MessagePoolOptions
 	ackFlushInterval time.Duration
 	ackBufferSize    int
+	maxInflight      int
+	maxInflightDur   time.Duration
 	writeBufferSize  int
 	readBufferSize   int
 	iOpts            instrument.Options
@@ -53,6 +56,7 @@ func NewOptions() Options {
 		messagePoolOpts:  MessagePoolOptions{PoolOptions: pool.NewObjectPoolOptions()},
 		ackFlushInterval: defaultAckFlushInterval,
 		ackBufferSize:    defaultAckBufferSize,
+		maxInflightDur:   defaultMaxInflightDuration,
 		writeBufferSize:  defaultConnectionBufferSize,
 		readBufferSize:   defaultConnectionBufferSize,
 		iOpts:            instrument.NewOptions(),
@@ -109,6 +113,26 @@ func (opts *options) SetAckBufferSize(value int) Options {
 	return &o
 }
 
+func (opts *options) MaxInflightMessages() int {
+	return opts.maxInflight
+}
+
+func (opts *options) SetMaxInflightMessages(value int) Options {
+	o := *opts
+	o.maxInflight = value
+	return &o
+}
+
+func (opts *options) MaxInflightDuration() time.Duration {
+	return opts.maxInflightDur
+}
+
+func (opts *options) SetMaxInflightDuration(value time.Duration) Options {
+	o := *opts
+	o.maxInflightDur = value
+	return &o
+}
+
 func (opts *options) ConnectionWriteBufferSize() int {
 	return opts.writeBufferSize
 }
@@ -45,6 +45,9 @@ type Consumer interface {
 	// Init initializes the consumer.
 	Init()
 
+	// RemoteAddr returns the address of the producer.
+	RemoteAddr() net.Addr
+
 	// Close closes the consumer.
 	Close()
 }
@@ -94,6 +97,26 @@ type Options interface {
 	// SetAckBufferSize sets the ack buffer size.
 	SetAckBufferSize(value int) Options
 
+	// MaxInflightMessages returns the max number of messages that may be
+	// received but not yet acked on a single connection, after which no more
+	// messages are read from the connection until some are acked. A zero
+	// value means no limit.
+	MaxInflightMessages() int
+
+	// SetMaxInflightMessages sets the max number of unacked messages per
+	// connection.
+	SetMaxInflightMessages(value int) Options
+
+	// MaxInflightDuration returns the max duration a message counts towards
+	// the max inflight messages without being acked, so that messages that
+	// are never acked, such as those left for the producer to retry, do not
+	// block the connection. A zero value means no limit.
+	MaxInflightDuration() time.Duration
+
+	// SetMaxInflightDuration sets the max duration a message counts towards
+	// the max inflight messages without being acked.
+	SetMaxInflightDuration(value time.Duration) Options
+
 	// ConnectionWriteBufferSize returns the size of buffer before a write or a read.
 	ConnectionWriteBufferSize() int
 
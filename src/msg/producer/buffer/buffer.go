@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/msg/producer"
+	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/retry"
 
@@ -47,8 +48,9 @@ var (
 )
 
 type bufferMetrics struct {
-	messageDropped    counterPerNumRefBuckets
-	byteDropped       counterPerNumRefBuckets
+	droppedFull       bufferDropMetrics
+	droppedClose      bufferDropMetrics
+	droppedTTL        bufferDropMetrics
 	messageTooLarge   tally.Counter
 	cleanupNoProgress tally.Counter
 	dropOldestSync    tally.Counter
@@ -103,13 +105,33 @@ func (c counterPerNumRefBuckets) Inc(numRef int32, delta int64) {
 	c.unknownBucket.Inc(delta)
 }
 
+type bufferDropMetrics struct {
+	messageDropped counterPerNumRefBuckets
+	byteDropped    counterPerNumRefBuckets
+}
+
+func newBufferDropMetrics(scope tally.Scope, reason string) bufferDropMetrics {
+	scope = scope.Tagged(map[string]string{"reason": reason})
+	return bufferDropMetrics{
+		messageDropped: newCounterPerNumRefBuckets(scope, "buffer-message-dropped", 10),
+		byteDropped:    newCounterPerNumRefBuckets(scope, "buffer-byte-dropped", 10),
+	}
+}
+
+func (m bufferDropMetrics) Inc(rm *producer.RefCountedMessage) {
+	numRef := rm.NumRef()
+	m.messageDropped.Inc(numRef, 1)
+	m.byteDropped.Inc(numRef, int64(rm.Size()))
+}
+
 func newBufferMetrics(
 	scope tally.Scope,
 	opts instrument.TimerOptions,
 ) bufferMetrics {
 	return bufferMetrics{
-		messageDropped:    newCounterPerNumRefBuckets(scope, "buffer-message-dropped", 10),
-		byteDropped:       newCounterPerNumRefBuckets(scope, "buffer-byte-dropped", 10),
+		droppedFull:       newBufferDropMetrics(scope, "buffer-full"),
+		droppedClose:      newBufferDropMetrics(scope, "closed"),
+		droppedTTL:        newBufferDropMetrics(scope, "ttl-expire"),
 		messageTooLarge:   scope.Counter("message-too-large"),
 		cleanupNoProgress: scope.Counter("cleanup-no-progress"),
 		dropOldestSync:    scope.Counter("drop-oldest-sync"),
@@ -120,6 +142,14 @@ func newBufferMetrics(
 	}
 }
 
+// bufferedMessage is a message in the buffer along with the time it was
+// added to the buffer.
+type bufferedMessage struct {
+	*producer.RefCountedMessage
+
+	addedAtNanos int64
+}
+
 // nolint: maligned
 type buffer struct {
 	sync.RWMutex
@@ -130,9 +160,11 @@ type buffer struct {
 	maxBufferSize    uint64
 	maxSpilloverSize uint64
 	maxMessageSize   int
+	messageTTLNanos  int64
 	onFinalizeFn     producer.OnFinalizeFn
 	retrier          retry.Retrier
 	m                bufferMetrics
+	nowFn            clock.NowFn
 
 	size         *atomic.Uint64
 	isClosed     bool
@@ -157,12 +189,14 @@ func NewBuffer(opts Options) (producer.Buffer, error) {
 		maxBufferSize:    maxBufferSize,
 		maxSpilloverSize: uint64(allowedSpillover) + maxBufferSize,
 		maxMessageSize:   opts.MaxMessageSize(),
+		messageTTLNanos:  int64(opts.MessageTTL()),
 		opts:             opts,
 		retrier:          retry.NewRetrier(opts.CleanupRetryOptions()),
 		m: newBufferMetrics(
 			opts.InstrumentOptions().MetricsScope(),
 			opts.InstrumentOptions().TimerOptions(),
 		),
+		nowFn:        time.Now,
 		size:         atomic.NewUint64(0),
 		isClosed:     false,
 		dropOldestCh: make(chan struct{}, 1),
@@ -193,7 +227,10 @@ func (b *buffer) Add(m producer.Message) (*producer.RefCountedMessage, error) {
 	}
 	rm := producer.NewRefCountedMessage(m, b.onFinalizeFn)
 	b.listLock.Lock()
-	b.bufferList.PushBack(rm)
+	b.bufferList.PushBack(bufferedMessage{
+		RefCountedMessage: rm,
+		addedAtNanos:      b.nowFn().UnixNano(),
+	})
 	b.listLock.Unlock()
 	b.RUnlock()
 	return rm, nil
@@ -315,6 +352,7 @@ func (b *buffer) cleanupBatchWithListLock(
 		iterated int
 		next     *list.Element
 		removed  int
+		nowNanos = b.nowFn().UnixNano()
 	)
 	for e := start; e != nil; e = next {
 		iterated++
@@ -322,24 +360,28 @@ func (b *buffer) cleanupBatchWithListLock(
 			break
 		}
 		next = e.Next()
-		rm := e.Value.(*producer.RefCountedMessage)
-		if rm.IsDroppedOrConsumed() {
+		bm := e.Value.(bufferedMessage)
+		if bm.IsDroppedOrConsumed() {
 			b.bufferList.Remove(e)
 			removed++
 			continue
 		}
-		if !forceDrop {
+		expired := b.messageTTLNanos > 0 &&
+			bm.addedAtNanos+b.messageTTLNanos <= nowNanos
+		if !forceDrop && !expired {
 			continue
 		}
 		// There is a chance that the message is consumed right before
 		// the drop call which will lead drop to return false.
-		if rm.Drop() {
+		if bm.Drop() {
 			b.bufferList.Remove(e)
 			removed++
 
-			numRef := rm.NumRef()
-			b.m.messageDropped.Inc(numRef, 1)
-			b.m.byteDropped.Inc(numRef, int64(rm.Size()))
+			if forceDrop {
+				b.m.droppedClose.Inc(bm.RefCountedMessage)
+			} else {
+				b.m.droppedTTL.Inc(bm.RefCountedMessage)
+			}
 		}
 	}
 	return next, removed
@@ -387,18 +429,16 @@ func (b *buffer) dropOldestBatchUntilTargetWithListLock(
 			return true
 		}
 		next := e.Next()
-		rm := e.Value.(*producer.RefCountedMessage)
+		bm := e.Value.(bufferedMessage)
 		b.bufferList.Remove(e)
 		e = next
-		if rm.IsDroppedOrConsumed() {
+		if bm.IsDroppedOrConsumed() {
 			continue
 		}
 		// There is a chance that the message is consumed right before
 		// the drop call which will lead drop to return false.
-		if rm.Drop() {
-			numRef := rm.NumRef()
-			b.m.messageDropped.Inc(numRef, 1)
-			b.m.byteDropped.Inc(numRef, int64(rm.Size()))
+		if bm.Drop() {
+			b.m.droppedFull.Inc(bm.RefCountedMessage)
 		}
 	}
 	return false
@@ -26,17 +26,21 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/msg/producer"
+	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/retry"
 
 	"github.com/fortytw2/leaktest"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 func TestOptionsValidation(t *testing.T) {
 	opts := NewOptions()
 	require.NoError(t, opts.Validate())
 
+	require.Equal(t, errNegativeMessageTTL, opts.SetMessageTTL(-1).Validate())
+
 	opts = opts.SetMaxMessageSize(100).SetMaxBufferSize(1)
 	require.Equal(t, errInvalidMaxMessageSize, opts.Validate())
 
@@ -186,11 +190,11 @@ func TestCleanupBatch(t *testing.T) {
 
 	mm1.EXPECT().Finalize(gomock.Eq(producer.Dropped))
 	front := b.bufferList.Front()
-	front.Value.(*producer.RefCountedMessage).Drop()
+	front.Value.(bufferedMessage).Drop()
 
 	require.Equal(t, 3, b.bufferLen())
 	e, removed := b.cleanupBatchWithListLock(front, 2, false)
-	require.Equal(t, 3, int(e.Value.(*producer.RefCountedMessage).Size()))
+	require.Equal(t, 3, int(e.Value.(bufferedMessage).Size()))
 	require.Equal(t, 2, b.bufferLen())
 	require.Equal(t, 1, removed)
 
@@ -200,6 +204,54 @@ func TestCleanupBatch(t *testing.T) {
 	require.Equal(t, 0, removed)
 }
 
+func TestCleanupBatchDropsExpiredMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mm1 := producer.NewMockMessage(ctrl)
+	mm1.EXPECT().Size().Return(1).AnyTimes()
+
+	mm2 := producer.NewMockMessage(ctrl)
+	mm2.EXPECT().Size().Return(2).AnyTimes()
+
+	scope := tally.NewTestScope("", nil)
+	b := mustNewBuffer(t, NewOptions().
+		SetMessageTTL(time.Minute).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)))
+
+	now := time.Now()
+	b.nowFn = func() time.Time { return now }
+	_, err := b.Add(mm1)
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Second)
+	_, err = b.Add(mm2)
+	require.NoError(t, err)
+
+	// Neither message has expired yet.
+	e, removed := b.cleanupBatchWithListLock(b.bufferList.Front(), 2, false)
+	require.Nil(t, e)
+	require.Equal(t, 0, removed)
+	require.Equal(t, 2, b.bufferLen())
+
+	// Only the first message has expired.
+	now = now.Add(30 * time.Second)
+	mm1.EXPECT().Finalize(gomock.Eq(producer.Dropped))
+	_, removed = b.cleanupBatchWithListLock(b.bufferList.Front(), 2, false)
+	require.Equal(t, 1, removed)
+	require.Equal(t, 1, b.bufferLen())
+	require.Equal(t, 2, int(b.bufferList.Front().Value.(bufferedMessage).Size()))
+	require.Equal(t, uint64(2), b.size.Load())
+
+	counters := scope.Snapshot().Counters()
+	c, ok := counters["buffer-message-dropped+num-replicas=0,reason=ttl-expire"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), c.Value())
+	c, ok = counters["buffer-byte-dropped+num-replicas=0,reason=ttl-expire"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), c.Value())
+}
+
 func TestCleanupBatchWithElementBeingRemovedByOtherThread(t *testing.T) {
 	defer leaktest.Check(t)()
 
@@ -225,12 +277,12 @@ func TestCleanupBatchWithElementBeingRemovedByOtherThread(t *testing.T) {
 	require.Error(t, b.cleanup())
 
 	mm1.EXPECT().Finalize(gomock.Eq(producer.Dropped))
-	b.bufferList.Front().Value.(*producer.RefCountedMessage).Drop()
+	b.bufferList.Front().Value.(bufferedMessage).Drop()
 
 	require.Equal(t, 3, b.bufferLen())
 	e, removed := b.cleanupBatchWithListLock(b.bufferList.Front(), 1, false)
 	// e stopped at message 2.
-	require.Equal(t, 2, int(e.Value.(*producer.RefCountedMessage).Size()))
+	require.Equal(t, 2, int(e.Value.(bufferedMessage).Size()))
 	require.Equal(t, 2, b.bufferLen())
 	require.Equal(t, 1, removed)
 	require.NotNil(t, e)
@@ -243,7 +295,7 @@ func TestCleanupBatchWithElementBeingRemovedByOtherThread(t *testing.T) {
 
 	// Mark message 3 as dropped, so it's ready to be removed.
 	mm3.EXPECT().Finalize(gomock.Eq(producer.Dropped))
-	b.bufferList.Front().Value.(*producer.RefCountedMessage).Drop()
+	b.bufferList.Front().Value.(bufferedMessage).Drop()
 
 	// But next clean batch from the removed element is going to do nothing
 	// because the starting element is already removed.
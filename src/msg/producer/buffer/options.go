@@ -44,6 +44,7 @@ var (
 	errInvalidMaxMessageSize  = errors.New("invalid max message size")
 	errNegativeMaxBufferSize  = errors.New("negative max buffer size")
 	errNegativeMaxMessageSize = errors.New("negative max message size")
+	errNegativeMessageTTL     = errors.New("negative message ttl")
 )
 
 type bufferOptions struct {
@@ -54,6 +55,7 @@ type bufferOptions struct {
 	dropOldestInterval    time.Duration
 	scanBatchSize         int
 	allowedSpilloverRatio float64
+	messageTTL            time.Duration
 	rOpts                 retry.Options
 	iOpts                 instrument.Options
 }
@@ -146,6 +148,16 @@ func (opts *bufferOptions) SetAllowedSpilloverRatio(value float64) Options {
 	return &o
 }
 
+func (opts *bufferOptions) MessageTTL() time.Duration {
+	return opts.messageTTL
+}
+
+func (opts *bufferOptions) SetMessageTTL(value time.Duration) Options {
+	o := *opts
+	o.messageTTL = value
+	return &o
+}
+
 func (opts *bufferOptions) CleanupRetryOptions() retry.Options {
 	return opts.rOpts
 }
@@ -176,6 +188,9 @@ func (opts *bufferOptions) Validate() error {
 	if opts.MaxMessageSize() <= 0 {
 		return errNegativeMaxMessageSize
 	}
+	if opts.MessageTTL() < 0 {
+		return errNegativeMessageTTL
+	}
 	if opts.MaxMessageSize() > opts.MaxBufferSize() {
 		// Max message size can only be as large as max buffer size.
 		return errInvalidMaxMessageSize
@@ -90,6 +90,14 @@ type Options interface {
 	// SetAllowedSpilloverRatio sets the ratio for allowed buffer spill over.
 	SetAllowedSpilloverRatio(value float64) Options
 
+	// MessageTTL returns the max duration a message may stay in the buffer,
+	// after which it is dropped regardless of whether the buffer is full.
+	// A zero value means messages never expire.
+	MessageTTL() time.Duration
+
+	// SetMessageTTL sets the max duration a message may stay in the buffer.
+	SetMessageTTL(value time.Duration) Options
+
 	// CleanupRetryOptions returns the cleanup retry options.
 	CleanupRetryOptions() retry.Options
 
@@ -37,6 +37,7 @@ type BufferConfiguration struct {
 	DropOldestInterval    *time.Duration         `yaml:"dropOldestInterval"`
 	ScanBatchSize         *int                   `yaml:"scanBatchSize"`
 	AllowedSpilloverRatio *float64               `yaml:"allowedSpilloverRatio"`
+	MessageTTL            *time.Duration         `yaml:"messageTTL"`
 	CleanupRetry          *retry.Configuration   `yaml:"cleanupRetry"`
 }
 
@@ -64,6 +65,9 @@ func (c *BufferConfiguration) NewOptions(iOpts instrument.Options) buffer.Option
 	if c.AllowedSpilloverRatio != nil {
 		opts = opts.SetAllowedSpilloverRatio(*c.AllowedSpilloverRatio)
 	}
+	if c.MessageTTL != nil {
+		opts = opts.SetMessageTTL(*c.MessageTTL)
+	}
 	if c.CleanupRetry != nil {
 		opts = opts.SetCleanupRetryOptions(c.CleanupRetry.NewOptions(iOpts.MetricsScope()))
 	}
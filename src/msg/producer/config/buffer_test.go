@@ -41,6 +41,7 @@ closeCheckInterval: 3s
 scanBatchSize: 128
 dropOldestInterval: 500ms
 allowedSpilloverRatio: 0.1
+messageTTL: 1m
 cleanupRetry:
   initialBackoff: 2s
 `
@@ -56,6 +57,7 @@ cleanupRetry:
 	require.Equal(t, 128, bOpts.ScanBatchSize())
 	require.Equal(t, 500*time.Millisecond, bOpts.DropOldestInterval())
 	require.Equal(t, 0.1, bOpts.AllowedSpilloverRatio())
+	require.Equal(t, time.Minute, bOpts.MessageTTL())
 	require.Equal(t, 2*time.Second, bOpts.CleanupRetryOptions().InitialBackoff())
 }
 
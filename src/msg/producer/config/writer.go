@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
 	"github.com/m3db/m3/src/x/retry"
+	xtls "github.com/m3db/m3/src/x/tls"
 
 	"github.com/uber-go/tally"
 )
@@ -48,10 +49,13 @@ type ConnectionConfiguration struct {
 	FlushInterval   *time.Duration       `yaml:"flushInterval"`
 	WriteBufferSize *int                 `yaml:"writeBufferSize"`
 	ReadBufferSize  *int                 `yaml:"readBufferSize"`
+	TLS             *xtls.Configuration  `yaml:"tls"`
 }
 
 // NewOptions creates connection options.
-func (c *ConnectionConfiguration) NewOptions(iOpts instrument.Options) writer.ConnectionOptions {
+func (c *ConnectionConfiguration) NewOptions(
+	iOpts instrument.Options,
+) (writer.ConnectionOptions, error) {
 	opts := writer.NewConnectionOptions()
 	if c.NumConnections != nil {
 		opts = opts.SetNumConnections(*c.NumConnections)
@@ -80,7 +84,11 @@ func (c *ConnectionConfiguration) NewOptions(iOpts instrument.Options) writer.Co
 	if c.ReadBufferSize != nil {
 		opts = opts.SetReadBufferSize(*c.ReadBufferSize)
 	}
-	return opts.SetInstrumentOptions(iOpts)
+	tlsConfig, err := c.TLS.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return opts.SetTLSConfig(tlsConfig).SetInstrumentOptions(iOpts), nil
 }
 
 // WriterConfiguration configs the writer options.
@@ -173,7 +181,11 @@ func (c *WriterConfiguration) NewOptions(
 		opts = opts.SetDecoderOptions(c.Decoder.NewOptions(iOpts))
 	}
 	if c.Connection != nil {
-		opts = opts.SetConnectionOptions(c.Connection.NewOptions(iOpts))
+		connOpts, err := c.Connection.NewOptions(iOpts)
+		if err != nil {
+			return nil, err
+		}
+		opts = opts.SetConnectionOptions(connOpts)
 	}
 
 	return opts, nil
@@ -51,7 +51,8 @@ readBufferSize: 200
 	var cfg ConnectionConfiguration
 	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
 
-	cOpts := cfg.NewOptions(instrument.NewOptions())
+	cOpts, err := cfg.NewOptions(instrument.NewOptions())
+	require.NoError(t, err)
 	require.Equal(t, 3*time.Second, cOpts.DialTimeout())
 	require.Equal(t, 2*time.Second, cOpts.WriteTimeout())
 	require.Equal(t, 20*time.Second, cOpts.KeepAlivePeriod())
@@ -61,6 +62,7 @@ readBufferSize: 200
 	require.Equal(t, 2*time.Second, cOpts.FlushInterval())
 	require.Equal(t, 100, cOpts.WriteBufferSize())
 	require.Equal(t, 200, cOpts.ReadBufferSize())
+	require.Nil(t, cOpts.TLSConfig())
 }
 
 func TestWriterConfiguration(t *testing.T) {
@@ -22,6 +22,7 @@ package writer
 
 import (
 	"bufio"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -74,6 +75,7 @@ type consumerWriterMetrics struct {
 	connectError            tally.Counter
 	setKeepAliveError       tally.Counter
 	setKeepAlivePeriodError tally.Counter
+	tlsHandshakeError       tally.Counter
 }
 
 func newConsumerWriterMetrics(scope tally.Scope) consumerWriterMetrics {
@@ -89,6 +91,7 @@ func newConsumerWriterMetrics(scope tally.Scope) consumerWriterMetrics {
 		connectError:            scope.Counter("connect-error"),
 		setKeepAliveError:       scope.Counter("set-keep-alive-error"),
 		setKeepAlivePeriodError: scope.Counter("set-keep-alive-period-error"),
+		tlsHandshakeError:       scope.Counter("tls-handshake-error"),
 	}
 }
 
@@ -449,6 +452,12 @@ func (w *consumerWriterImpl) connectNoRetry(addr string) (io.ReadWriteCloser, er
 	if err = tcpConn.SetKeepAlive(true); err != nil {
 		w.m.setKeepAliveError.Inc(1)
 	}
+	if tlsConfig := w.connOpts.TLSConfig(); tlsConfig != nil {
+		if conn, err = w.tlsHandshake(conn, addr, tlsConfig); err != nil {
+			w.m.tlsHandshakeError.Inc(1)
+			return nil, err
+		}
+	}
 	keepAlivePeriod := w.connOpts.KeepAlivePeriod()
 	if keepAlivePeriod <= 0 {
 		return conn, nil
@@ -459,6 +468,39 @@ func (w *consumerWriterImpl) connectNoRetry(addr string) (io.ReadWriteCloser, er
 	return newReadWriterWithTimeout(conn, w.connOpts.WriteTimeout(), w.nowFn), nil
 }
 
+// tlsHandshake performs the TLS handshake eagerly, bounded by the dial
+// timeout, so that handshake failures surface as connect errors. When the
+// config does not name the server, the certificate is verified against the
+// dialed host.
+func (w *consumerWriterImpl) tlsHandshake(
+	conn net.Conn,
+	addr string,
+	tlsConfig *tls.Config,
+) (net.Conn, error) {
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.SetDeadline(w.nowFn().Add(w.connOpts.DialTimeout())); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
 type connectOptions struct {
 	retry bool
 }
@@ -21,7 +21,14 @@
 package writer
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"io"
+	"math/big"
 	"net"
 	"sync"
 	"testing"
@@ -385,6 +392,62 @@ func TestConsumerWriterResetWhileDecoding(t *testing.T) {
 	})
 }
 
+func TestConsumerWriterTLSServerNameFromAddress(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "consumer"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template,
+		&key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	require.NoError(t, err)
+	defer lis.Close()
+
+	serverErrCh := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			serverErrCh <- err
+			return
+		}
+		defer conn.Close()
+		serverErrCh <- conn.(*tls.Conn).Handshake()
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	// ServerName is deliberately left unset.
+	clientConfig := &tls.Config{RootCAs: roots}
+
+	opts := testOptions()
+	w := newConsumerWriter(lis.Addr().String(), nil, opts.SetConnectionOptions(
+		opts.ConnectionOptions().SetTLSConfig(clientConfig),
+	), testConsumerWriterMetrics()).(*consumerWriterImpl)
+
+	conn, err := w.connectNoRetry(lis.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, <-serverErrCh)
+
+	// The shared config must not be mutated.
+	require.Equal(t, "", clientConfig.ServerName)
+}
+
 func testOptions() Options {
 	return NewOptions().
 		SetTopicName("topicName").
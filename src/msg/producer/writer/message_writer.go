@@ -95,6 +95,7 @@ type messageWriterMetrics struct {
 	writeAfterCutoff         tally.Counter
 	writeBeforeCutover       tally.Counter
 	messageAcked             tally.Counter
+	messageAckedDuplicate    tally.Counter
 	messageClosed            tally.Counter
 	messageDroppedBufferFull tally.Counter
 	messageDroppedTTLExpire  tally.Counter
@@ -124,8 +125,9 @@ func newMessageWriterMetrics(
 		writeBeforeCutover: scope.
 			Tagged(map[string]string{"reason": "before-cutover"}).
 			Counter("invalid-write"),
-		messageAcked:  scope.Counter("message-acked"),
-		messageClosed: scope.Counter("message-closed"),
+		messageAcked:          scope.Counter("message-acked"),
+		messageAckedDuplicate: scope.Counter("message-acked-duplicate"),
+		messageClosed:         scope.Counter("message-closed"),
 		messageDroppedBufferFull: scope.Tagged(
 			map[string]string{"reason": "buffer-full"},
 		).Counter("message-dropped"),
@@ -309,6 +311,9 @@ func (w *messageWriterImpl) Ack(meta metadata) bool {
 		w.m.messageAcked.Inc(1)
 		return true
 	}
+	// NB: the message was already acked or removed from the queue, which
+	// happens when a retried message is consumed more than once.
+	w.m.messageAckedDuplicate.Inc(1)
 	return false
 }
 
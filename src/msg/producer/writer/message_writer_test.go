@@ -479,6 +479,34 @@ func TestMessageWriterCleanupAckedMessage(t *testing.T) {
 	require.Equal(t, meta, m.Metadata())
 }
 
+func TestMessageWriterAckDuplicate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	scope := tally.NewTestScope("", nil)
+	metrics := newMessageWriterMetrics(scope, instrument.TimerOptions{})
+	w := newMessageWriter(200, nil, testOptions(), metrics).(*messageWriterImpl)
+
+	mm := producer.NewMockMessage(ctrl)
+	mm.EXPECT().Size().Return(3)
+	mm.EXPECT().Bytes().Return([]byte("foo"))
+	mm.EXPECT().Finalize(producer.Consumed)
+	rm := producer.NewRefCountedMessage(mm, nil)
+	rm.IncRef()
+
+	meta := metadata{id: 1, shard: 200}
+	m := newMessage()
+	m.Set(meta, rm, time.Now().UnixNano())
+	w.acks.add(meta, m)
+
+	require.True(t, w.Ack(meta))
+	require.False(t, w.Ack(meta))
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["message-acked+"].Value())
+	require.Equal(t, int64(1), counters["message-acked-duplicate+"].Value())
+}
+
 func TestMessageWriterCutoverCutoff(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -21,6 +21,7 @@
 package writer
 
 import (
+	"crypto/tls"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/placement"
@@ -113,6 +114,13 @@ type ConnectionOptions interface {
 
 	// SetInstrumentOptions sets the instrument options.
 	SetInstrumentOptions(value instrument.Options) ConnectionOptions
+
+	// TLSConfig returns the TLS config, when set connections are made
+	// over TLS.
+	TLSConfig() *tls.Config
+
+	// SetTLSConfig sets the TLS config.
+	SetTLSConfig(value *tls.Config) ConnectionOptions
 }
 
 type connectionOptions struct {
@@ -126,6 +134,7 @@ type connectionOptions struct {
 	writeBufferSize int
 	readBufferSize  int
 	iOpts           instrument.Options
+	tlsConfig       *tls.Config
 }
 
 // NewConnectionOptions creates ConnectionOptions.
@@ -244,6 +253,16 @@ func (opts *connectionOptions) SetInstrumentOptions(value instrument.Options) Co
 	return &o
 }
 
+func (opts *connectionOptions) TLSConfig() *tls.Config {
+	return opts.tlsConfig
+}
+
+func (opts *connectionOptions) SetTLSConfig(value *tls.Config) ConnectionOptions {
+	o := *opts
+	o.tlsConfig = value
+	return &o
+}
+
 // Options configs the writer.
 type Options interface {
 	// TopicName returns the topic name.
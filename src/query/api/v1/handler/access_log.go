@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+// AccessLogger logs structured access logs of requests, logging every
+// request slower than the slow threshold and a sample of the others.
+type AccessLogger struct {
+	// NB: accessed atomically, kept first for 64-bit alignment.
+	requests uint64
+
+	cfg            config.AccessLogConfiguration
+	nowFn          clock.NowFn
+	instrumentOpts instrument.Options
+	slow           tally.Counter
+}
+
+// NewAccessLogger returns a new access logger.
+func NewAccessLogger(
+	cfg config.AccessLogConfiguration,
+	nowFn clock.NowFn,
+	instrumentOpts instrument.Options,
+) *AccessLogger {
+	return &AccessLogger{
+		cfg:            cfg,
+		nowFn:          nowFn,
+		instrumentOpts: instrumentOpts,
+		slow: instrumentOpts.MetricsScope().
+			SubScope("access-log").Counter("slow-requests"),
+	}
+}
+
+// Wrap returns a handler that logs the requests to the given handler,
+// handlers report the series and datapoints of a request with
+// logging.RecordRequestStats. Requests are not wrapped if access logs are
+// disabled.
+func (l *AccessLogger) Wrap(next http.Handler) http.Handler {
+	if l.cfg.SlowThreshold <= 0 && l.cfg.SampleRate <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant := r.Header.Get(handleroptions.TenantHeader)
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+		start := l.nowFn()
+		ctx, stats := logging.NewContextWithRequestStats(r.Context())
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		req := r.WithContext(ctx)
+		next.ServeHTTP(sw.withCloseNotifier(), req)
+		latency := l.nowFn().Sub(start)
+
+		slow := l.cfg.SlowThreshold > 0 && latency >= l.cfg.SlowThreshold
+		if !slow && !l.sample() {
+			return
+		}
+
+		// NB: only fingerprint logged requests since it parses the query, use
+		// the request the handler served so a form it parsed from the body
+		// is reused rather than read again.
+		fingerprint := requestFingerprint(req)
+
+		logger := logging.WithContext(r.Context(), l.instrumentOpts)
+		fields := []zap.Field{
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Int("status", sw.status),
+			zap.String("tenant", tenant),
+			zap.String("fingerprint", fingerprint),
+			zap.Int64("series", stats.Series()),
+			zap.Int64("datapoints", stats.Datapoints()),
+			zap.Duration("latency", latency),
+		}
+		if slow {
+			l.slow.Inc(1)
+			logger.Warn("slow request", fields...)
+			return
+		}
+		logger.Info("request", fields...)
+	})
+}
+
+// sample returns true for one in every sample rate requests.
+func (l *AccessLogger) sample() bool {
+	if l.cfg.SampleRate <= 0 {
+		return false
+	}
+	return atomic.AddUint64(&l.requests, 1)%uint64(l.cfg.SampleRate) == 0
+}
+
+// statusResponseWriter records the status code of a response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// withCloseNotifier returns the writer, exposing the http.CloseNotifier of
+// the underlying writer if it has one so handlers can still be cancelled
+// when the client disconnects.
+func (w *statusResponseWriter) withCloseNotifier() http.ResponseWriter {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return &closeNotifierStatusResponseWriter{
+			statusResponseWriter: w,
+			CloseNotifier:        notifier,
+		}
+	}
+	return w
+}
+
+type closeNotifierStatusResponseWriter struct {
+	*statusResponseWriter
+	http.CloseNotifier
+}
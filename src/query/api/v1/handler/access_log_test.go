@@ -0,0 +1,158 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAccessLoggerSlowAndSampled(t *testing.T) {
+	var (
+		core, logs = observer.New(zapcore.InfoLevel)
+		now        = time.Now()
+		logger     = NewAccessLogger(config.AccessLogConfiguration{
+			SlowThreshold: 100 * time.Millisecond,
+			SampleRate:    2,
+		}, func() time.Time { return now },
+			instrument.NewOptions().SetLogger(zap.New(core)))
+	)
+
+	serve := func(latency time.Duration, status int) {
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.RecordRequestStats(r.Context(), 3, 30)
+			now = now.Add(latency)
+			w.WriteHeader(status)
+		})
+		req := httptest.NewRequest(http.MethodGet,
+			"/query?query="+url.QueryEscape(`up{job="api"}`), nil)
+		req.Header.Set(handleroptions.TenantHeader, "tenant-a")
+		logger.Wrap(next).ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Only one in every two fast requests is logged.
+	serve(10*time.Millisecond, http.StatusOK)
+	assert.Equal(t, 0, logs.Len())
+	serve(10*time.Millisecond, http.StatusOK)
+	require.Equal(t, 1, logs.FilterMessage("request").Len())
+
+	fields := logs.FilterMessage("request").All()[0].ContextMap()
+	assert.Equal(t, "/query", fields["path"])
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.Equal(t, "tenant-a", fields["tenant"])
+	assert.NotEmpty(t, fields["fingerprint"])
+	assert.Equal(t, int64(3), fields["series"])
+	assert.Equal(t, int64(30), fields["datapoints"])
+	assert.Equal(t, 10*time.Millisecond, fields["latency"])
+
+	// Slow requests are always logged.
+	serve(200*time.Millisecond, http.StatusInternalServerError)
+	slow := logs.FilterMessage("slow request").All()
+	require.Equal(t, 1, len(slow))
+	assert.Equal(t, zapcore.WarnLevel, slow[0].Level)
+	assert.Equal(t, int64(http.StatusInternalServerError), slow[0].ContextMap()["status"])
+}
+
+func TestAccessLoggerFingerprintsFormBody(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewAccessLogger(config.AccessLogConfiguration{SampleRate: 1},
+		time.Now, instrument.NewOptions().SetLogger(zap.New(core)))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, `up{job="api"}`, r.FormValue(queryParam))
+	})
+
+	body := url.Values{queryParam: []string{`up{job="api"}`}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	logger.Wrap(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	expected, err := QueryFingerprint(`up{job="api"}`)
+	require.NoError(t, err)
+	entries := logs.FilterMessage("request").All()
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, expected, entries[0].ContextMap()["fingerprint"])
+}
+
+type closeNotifyingRecorder struct {
+	*httptest.ResponseRecorder
+	closed chan bool
+}
+
+func (r *closeNotifyingRecorder) CloseNotify() <-chan bool {
+	return r.closed
+}
+
+func TestAccessLoggerForwardsCloseNotifier(t *testing.T) {
+	logger := NewAccessLogger(config.AccessLogConfiguration{SampleRate: 1},
+		time.Now, instrument.NewOptions())
+
+	w := &closeNotifyingRecorder{
+		ResponseRecorder: httptest.NewRecorder(),
+		closed:           make(chan bool),
+	}
+	var notifier http.CloseNotifier
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var ok bool
+		notifier, ok = w.(http.CloseNotifier)
+		require.True(t, ok)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	logger.Wrap(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/query", nil))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, (<-chan bool)(w.closed), notifier.CloseNotify())
+
+	// Writers without a close notifier are not given one.
+	next = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, ok := w.(http.CloseNotifier)
+		assert.False(t, ok)
+	})
+	logger.Wrap(next).ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/query", nil))
+}
+
+func TestAccessLoggerDisabled(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewAccessLogger(config.AccessLogConfiguration{}, time.Now,
+		instrument.NewOptions().SetLogger(zap.New(core)))
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	logger.Wrap(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/query", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, 0, logs.Len())
+}
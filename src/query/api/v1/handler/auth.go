@@ -0,0 +1,298 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+// AuthRole is the role required to access a route, each role granting the
+// access of the roles before it.
+type AuthRole int
+
+const (
+	// AuthRoleNone allows unauthenticated access.
+	AuthRoleNone AuthRole = iota
+	// AuthRoleRead allows reading data and cluster state.
+	AuthRoleRead
+	// AuthRoleWrite allows writing data.
+	AuthRoleWrite
+	// AuthRoleAdmin allows changing cluster state.
+	AuthRoleAdmin
+)
+
+var authRoleNames = map[AuthRole]string{
+	AuthRoleNone:  "none",
+	AuthRoleRead:  "read",
+	AuthRoleWrite: "write",
+	AuthRoleAdmin: "admin",
+}
+
+func (r AuthRole) String() string {
+	if name, ok := authRoleNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseAuthRole parses an auth role from its name.
+func ParseAuthRole(str string) (AuthRole, error) {
+	for role, name := range authRoleNames {
+		if name == str {
+			return role, nil
+		}
+	}
+	return AuthRoleNone, fmt.Errorf("invalid auth role: %s", str)
+}
+
+// AuthRoleFn returns the role required to access the route of a request.
+type AuthRoleFn func(r *http.Request) AuthRole
+
+const bearerPrefix = "Bearer "
+
+var (
+	errUnauthenticated = errors.New("request is not authenticated")
+	errForbidden       = errors.New("principal is not authorized for this route")
+)
+
+type authMetrics struct {
+	authorized      tally.Counter
+	unauthenticated tally.Counter
+	forbidden       tally.Counter
+	adminMutations  tally.Counter
+}
+
+func newAuthMetrics(scope tally.Scope) authMetrics {
+	return authMetrics{
+		authorized: scope.Counter("authorized"),
+		unauthenticated: scope.Tagged(map[string]string{"reason": "unauthenticated"}).
+			Counter("rejected"),
+		forbidden: scope.Tagged(map[string]string{"reason": "forbidden"}).
+			Counter("rejected"),
+		adminMutations: scope.Counter("admin-mutations"),
+	}
+}
+
+type authRoutePolicy struct {
+	prefix string
+	role   AuthRole
+}
+
+// Authorizer authenticates requests by TLS client certificate, API key or
+// a trusted principal header and authorizes them against the role required
+// by their route, audit logging every admin mutation.
+type Authorizer struct {
+	enabled         bool
+	apiKeys         map[string]string
+	principalHeader string
+	principals      map[string]AuthRole
+	defaultRole     AuthRole
+	policies        []authRoutePolicy
+	defaultRoleFn   AuthRoleFn
+	logger          *zap.Logger
+	metrics         authMetrics
+}
+
+// NewAuthorizer returns a new authorizer, the default role fn returns the
+// role required by routes that are not overridden by the configuration.
+func NewAuthorizer(
+	cfg config.AuthConfiguration,
+	defaultRoleFn AuthRoleFn,
+	instrumentOpts instrument.Options,
+) (*Authorizer, error) {
+	a := &Authorizer{
+		enabled:         cfg.Enabled,
+		apiKeys:         cfg.APIKeys,
+		principalHeader: cfg.PrincipalHeader,
+		principals:      make(map[string]AuthRole, len(cfg.Principals)),
+		defaultRoleFn:   defaultRoleFn,
+		logger:          instrumentOpts.Logger(),
+		metrics:         newAuthMetrics(instrumentOpts.MetricsScope().SubScope("auth")),
+	}
+
+	for principal, name := range cfg.Principals {
+		role, err := ParseAuthRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("principal %s: %v", principal, err)
+		}
+		a.principals[principal] = role
+	}
+
+	if cfg.DefaultRole != "" {
+		role, err := ParseAuthRole(cfg.DefaultRole)
+		if err != nil {
+			return nil, fmt.Errorf("default role: %v", err)
+		}
+		a.defaultRole = role
+	}
+
+	for prefix, name := range cfg.Routes {
+		role, err := ParseAuthRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %v", prefix, err)
+		}
+		a.policies = append(a.policies, authRoutePolicy{prefix: prefix, role: role})
+	}
+	// NB: sort longest prefix first so the most specific policy matches.
+	sort.Slice(a.policies, func(i, j int) bool {
+		return len(a.policies[i].prefix) > len(a.policies[j].prefix)
+	})
+
+	return a, nil
+}
+
+// Enabled returns true if requests are authorized.
+func (a *Authorizer) Enabled() bool {
+	return a.enabled
+}
+
+// RequiredRole returns the role required to access the route of a request.
+func (a *Authorizer) RequiredRole(r *http.Request) AuthRole {
+	for _, policy := range a.policies {
+		if strings.HasPrefix(r.URL.Path, policy.prefix) {
+			return policy.role
+		}
+	}
+	if a.defaultRoleFn == nil {
+		return AuthRoleRead
+	}
+	return a.defaultRoleFn(r)
+}
+
+// Principal returns the authenticated principal of a request, or false if
+// the request is not authenticated.
+func (a *Authorizer) Principal(r *http.Request) (string, bool) {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 &&
+		len(r.TLS.VerifiedChains[0]) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn, true
+		}
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, bearerPrefix) {
+		if principal, ok := a.apiKeys[strings.TrimPrefix(auth, bearerPrefix)]; ok {
+			return principal, true
+		}
+		return "", false
+	}
+
+	if a.principalHeader != "" {
+		if principal := r.Header.Get(a.principalHeader); principal != "" {
+			return principal, true
+		}
+	}
+
+	return "", false
+}
+
+func (a *Authorizer) role(principal string) AuthRole {
+	if role, ok := a.principals[principal]; ok {
+		return role
+	}
+	return a.defaultRole
+}
+
+// Middleware returns a handler that only serves requests to the given
+// handler that are authorized to access their route.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	if !a.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		required := a.RequiredRole(r)
+		if required == AuthRoleNone {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := a.Principal(r)
+		if !ok {
+			a.metrics.unauthenticated.Inc(1)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			xhttp.Error(w, errUnauthenticated, http.StatusUnauthorized)
+			return
+		}
+
+		if role := a.role(principal); role < required {
+			a.metrics.forbidden.Inc(1)
+			a.logger.Warn("forbidden API request",
+				zap.String("principal", principal),
+				zap.Stringer("role", role),
+				zap.Stringer("requiredRole", required),
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path))
+			xhttp.Error(w, fmt.Errorf("%s: %v", principal, errForbidden),
+				http.StatusForbidden)
+			return
+		}
+
+		a.metrics.authorized.Inc(1)
+		if required < AuthRoleAdmin || isReadOnlyMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		a.metrics.adminMutations.Inc(1)
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		a.logger.Info("admin API mutation",
+			zap.String("principal", principal),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.String("remoteAddr", r.RemoteAddr),
+			zap.Int("status", sw.status))
+	})
+}
+
+func isReadOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestAuthorizer(t *testing.T, scope tally.Scope) *Authorizer {
+	a, err := NewAuthorizer(config.AuthConfiguration{
+		Enabled:         true,
+		APIKeys:         map[string]string{"reader-key": "reader", "admin-key": "admin"},
+		PrincipalHeader: "X-Principal",
+		Principals: map[string]string{
+			"reader": "read",
+			"writer": "write",
+			"admin":  "admin",
+		},
+		Routes: map[string]string{
+			"/public":       "none",
+			"/public/admin": "admin",
+		},
+	}, func(r *http.Request) AuthRole {
+		if r.Method == http.MethodPost {
+			return AuthRoleAdmin
+		}
+		return AuthRoleRead
+	}, instrument.NewOptions().SetMetricsScope(scope))
+	require.NoError(t, err)
+	return a
+}
+
+func TestNewAuthorizerInvalidRole(t *testing.T) {
+	_, err := NewAuthorizer(config.AuthConfiguration{
+		Principals: map[string]string{"foo": "superuser"},
+	}, nil, instrument.NewOptions())
+	require.Error(t, err)
+
+	_, err = NewAuthorizer(config.AuthConfiguration{
+		Routes: map[string]string{"/foo": "bar"},
+	}, nil, instrument.NewOptions())
+	require.Error(t, err)
+}
+
+func TestAuthorizerDisabled(t *testing.T) {
+	a, err := NewAuthorizer(config.AuthConfiguration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	assert.False(t, a.Enabled())
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	a.Middleware(next).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/foo", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthorizerRequiredRole(t *testing.T) {
+	a := newTestAuthorizer(t, tally.NoopScope)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected AuthRole
+	}{
+		{method: http.MethodGet, path: "/foo", expected: AuthRoleRead},
+		{method: http.MethodPost, path: "/foo", expected: AuthRoleAdmin},
+		{method: http.MethodPost, path: "/public/foo", expected: AuthRoleNone},
+		{method: http.MethodGet, path: "/public/admin/foo", expected: AuthRoleAdmin},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		assert.Equal(t, test.expected, a.RequiredRole(r), test.path)
+	}
+}
+
+func TestAuthorizerPrincipal(t *testing.T) {
+	a := newTestAuthorizer(t, tally.NoopScope)
+
+	r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+	_, ok := a.Principal(r)
+	assert.False(t, ok)
+
+	r.Header.Set("X-Principal", "writer")
+	principal, ok := a.Principal(r)
+	require.True(t, ok)
+	assert.Equal(t, "writer", principal)
+
+	// API keys take precedence over the principal header and unknown keys
+	// are not authenticated.
+	r.Header.Set("Authorization", "Bearer reader-key")
+	principal, ok = a.Principal(r)
+	require.True(t, ok)
+	assert.Equal(t, "reader", principal)
+
+	r.Header.Set("Authorization", "Bearer unknown")
+	_, ok = a.Principal(r)
+	assert.False(t, ok)
+
+	// Verified client certificates take precedence over everything else.
+	r.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "admin"}},
+		}},
+	}
+	principal, ok = a.Principal(r)
+	require.True(t, ok)
+	assert.Equal(t, "admin", principal)
+}
+
+func TestAuthorizerMiddleware(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	a := newTestAuthorizer(t, scope)
+
+	h := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	serve := func(method, path, key string) int {
+		r := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			r.Header.Set("Authorization", "Bearer "+key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/foo", ""))
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost, "/public/foo", ""))
+	assert.Equal(t, http.StatusCreated, serve(http.MethodGet, "/foo", "reader-key"))
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/foo", "reader-key"))
+	assert.Equal(t, http.StatusCreated, serve(http.MethodPost, "/foo", "admin-key"))
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["auth.authorized+"].Value())
+	assert.Equal(t, int64(1), counters["auth.rejected+reason=unauthenticated"].Value())
+	assert.Equal(t, int64(1), counters["auth.rejected+reason=forbidden"].Value())
+	assert.Equal(t, int64(1), counters["auth.admin-mutations+"].Value())
+}
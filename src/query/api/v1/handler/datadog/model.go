@@ -0,0 +1,326 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package datadog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The types below mirror the payloads of the Datadog series APIs, keeping
+// only the fields required to translate metrics into series. Series of the
+// v1 API are converted to series of the v2 API, which carries the host and
+// device as resources.
+
+const (
+	hostResourceType   = "host"
+	deviceResourceType = "device"
+)
+
+type seriesPayload struct {
+	Series []metricSeries `json:"series"`
+}
+
+type metricSeries struct {
+	Metric    string     `json:"metric"`
+	Tags      []string   `json:"tags"`
+	Resources []resource `json:"resources"`
+	Points    []point    `json:"points"`
+}
+
+type resource struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+type point struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type seriesPayloadV1 struct {
+	Series []metricSeriesV1 `json:"series"`
+}
+
+type metricSeriesV1 struct {
+	Metric string    `json:"metric"`
+	Points []pointV1 `json:"points"`
+	Tags   []string  `json:"tags"`
+	Host   string    `json:"host"`
+	Device string    `json:"device"`
+}
+
+// pointV1 is a point of the v1 API, encoded as a [timestamp, value] pair.
+type pointV1 point
+
+func (p *pointV1) UnmarshalJSON(b []byte) error {
+	var pair []float64
+	if err := json.Unmarshal(b, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("invalid point %s: expected [timestamp, value]", b)
+	}
+
+	p.Timestamp = int64(pair[0])
+	p.Value = pair[1]
+	return nil
+}
+
+func (p seriesPayloadV1) toV2() seriesPayload {
+	result := seriesPayload{Series: make([]metricSeries, 0, len(p.Series))}
+	for _, s := range p.Series {
+		series := metricSeries{
+			Metric: s.Metric,
+			Tags:   s.Tags,
+			Points: make([]point, 0, len(s.Points)),
+		}
+		if s.Host != "" {
+			series.Resources = append(series.Resources,
+				resource{Type: hostResourceType, Name: s.Host})
+		}
+		if s.Device != "" {
+			series.Resources = append(series.Resources,
+				resource{Type: deviceResourceType, Name: s.Device})
+		}
+		for _, dp := range s.Points {
+			series.Points = append(series.Points, point(dp))
+		}
+		result.Series = append(result.Series, series)
+	}
+	return result
+}
+
+// Protobuf field numbers of the v2 MetricPayload message.
+const (
+	payloadSeriesField = 1
+
+	seriesResourcesField = 1
+	seriesMetricField    = 2
+	seriesTagsField      = 3
+	seriesPointsField    = 4
+
+	resourceTypeField = 1
+	resourceNameField = 2
+
+	pointValueField     = 1
+	pointTimestampField = 2
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncatedProtobuf = errors.New("truncated protobuf message")
+
+// unmarshalProtobuf decodes a v2 MetricPayload protobuf message, fields that
+// are not required are skipped.
+func (p *seriesPayload) unmarshalProtobuf(b []byte) error {
+	return decodeFields(b, func(field int, r *protoReader) error {
+		if field != payloadSeriesField {
+			return r.skip()
+		}
+
+		msg, err := r.bytes()
+		if err != nil {
+			return err
+		}
+
+		var series metricSeries
+		if err := series.unmarshalProtobuf(msg); err != nil {
+			return err
+		}
+		p.Series = append(p.Series, series)
+		return nil
+	})
+}
+
+func (s *metricSeries) unmarshalProtobuf(b []byte) error {
+	return decodeFields(b, func(field int, r *protoReader) error {
+		switch field {
+		case seriesResourcesField:
+			msg, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			var res resource
+			if err := res.unmarshalProtobuf(msg); err != nil {
+				return err
+			}
+			s.Resources = append(s.Resources, res)
+		case seriesMetricField:
+			v, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			s.Metric = string(v)
+		case seriesTagsField:
+			v, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			s.Tags = append(s.Tags, string(v))
+		case seriesPointsField:
+			msg, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			var dp point
+			if err := dp.unmarshalProtobuf(msg); err != nil {
+				return err
+			}
+			s.Points = append(s.Points, dp)
+		default:
+			return r.skip()
+		}
+		return nil
+	})
+}
+
+func (res *resource) unmarshalProtobuf(b []byte) error {
+	return decodeFields(b, func(field int, r *protoReader) error {
+		switch field {
+		case resourceTypeField:
+			v, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			res.Type = string(v)
+		case resourceNameField:
+			v, err := r.bytes()
+			if err != nil {
+				return err
+			}
+			res.Name = string(v)
+		default:
+			return r.skip()
+		}
+		return nil
+	})
+}
+
+func (p *point) unmarshalProtobuf(b []byte) error {
+	return decodeFields(b, func(field int, r *protoReader) error {
+		switch field {
+		case pointValueField:
+			v, err := r.fixed64()
+			if err != nil {
+				return err
+			}
+			p.Value = math.Float64frombits(v)
+		case pointTimestampField:
+			v, err := r.varint()
+			if err != nil {
+				return err
+			}
+			p.Timestamp = int64(v)
+		default:
+			return r.skip()
+		}
+		return nil
+	})
+}
+
+// protoReader reads the fields of a protobuf message.
+type protoReader struct {
+	buf      []byte
+	wireType int
+}
+
+func decodeFields(b []byte, fn func(field int, r *protoReader) error) error {
+	r := &protoReader{buf: b}
+	for len(r.buf) > 0 {
+		key, err := r.varint()
+		if err != nil {
+			return err
+		}
+
+		r.wireType = int(key & 0x7)
+		if err := fn(int(key>>3), r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, errTruncatedProtobuf
+	}
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *protoReader) fixed64() (uint64, error) {
+	if r.wireType != wireFixed64 {
+		return 0, fmt.Errorf("unexpected protobuf wire type %d", r.wireType)
+	}
+	if len(r.buf) < 8 {
+		return 0, errTruncatedProtobuf
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v, nil
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	if r.wireType != wireBytes {
+		return nil, fmt.Errorf("unexpected protobuf wire type %d", r.wireType)
+	}
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)) < n {
+		return nil, errTruncatedProtobuf
+	}
+	v := r.buf[:n]
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *protoReader) skip() error {
+	switch r.wireType {
+	case wireVarint:
+		_, err := r.varint()
+		return err
+	case wireFixed64:
+		_, err := r.fixed64()
+		return err
+	case wireBytes:
+		_, err := r.bytes()
+		return err
+	case wireFixed32:
+		if len(r.buf) < 4 {
+			return errTruncatedProtobuf
+		}
+		r.buf = r.buf[4:]
+		return nil
+	}
+	return fmt.Errorf("unsupported protobuf wire type %d", r.wireType)
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package datadog implements an intake for the series submitted by the
+// Datadog agent, so that agents can dual ship metrics to M3.
+package datadog
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// URLPrefix is the prefix of the Datadog intake URLs, set the agent's
+	// dd_url to this path on the coordinator (or add it to the agent's
+	// additional_endpoints to dual ship).
+	URLPrefix = handler.RoutePrefixV1 + "/datadog"
+
+	// SeriesV1WriteURL is the URL of the v1 series API, accepting JSON.
+	SeriesV1WriteURL = URLPrefix + "/api/v1/series"
+
+	// SeriesV2WriteURL is the URL of the v2 series API, accepting JSON or
+	// protobuf.
+	SeriesV2WriteURL = URLPrefix + "/api/v2/series"
+
+	// SeriesWriteHTTPMethod is the HTTP method used with the series APIs.
+	SeriesWriteHTTPMethod = http.MethodPost
+
+	// ValidateURL is the URL the agent validates its API key against.
+	ValidateURL = URLPrefix + "/api/v1/validate"
+
+	// ValidateHTTPMethod is the HTTP method used with the validate API.
+	ValidateHTTPMethod = http.MethodGet
+
+	// valuelessTagValue is the label value of tags without a value.
+	valuelessTagValue = "true"
+)
+
+var (
+	defaultValue = ingest.IterValue{
+		Tags:       models.EmptyTags(),
+		Attributes: ts.DefaultSeriesAttributes(),
+		Metadata:   ts.Metadata{},
+	}
+)
+
+type apiVersion int
+
+const (
+	apiVersionV1 apiVersion = iota + 1
+	apiVersionV2
+)
+
+type writeHandler struct {
+	handlerOpts options.HandlerOptions
+	tagOpts     models.TagOptions
+	version     apiVersion
+	metrics     writeMetrics
+}
+
+type writeMetrics struct {
+	writeSuccess      tally.Counter
+	writeErrorsServer tally.Counter
+	writeErrorsClient tally.Counter
+	datapoints        tally.Counter
+}
+
+func newWriteMetrics(scope tally.Scope) writeMetrics {
+	return writeMetrics{
+		writeSuccess:      scope.SubScope("write").Counter("success"),
+		writeErrorsServer: scope.SubScope("write").Tagged(map[string]string{"code": "5XX"}).Counter("errors"),
+		writeErrorsClient: scope.SubScope("write").Tagged(map[string]string{"code": "4XX"}).Counter("errors"),
+		datapoints:        scope.SubScope("write").Counter("datapoints"),
+	}
+}
+
+// NewSeriesV1WriteHandler returns a new write handler for the v1 series API.
+func NewSeriesV1WriteHandler(opts options.HandlerOptions) http.Handler {
+	return newWriteHandler(opts, apiVersionV1)
+}
+
+// NewSeriesV2WriteHandler returns a new write handler for the v2 series API.
+func NewSeriesV2WriteHandler(opts options.HandlerOptions) http.Handler {
+	return newWriteHandler(opts, apiVersionV2)
+}
+
+// newWriteHandler returns a new series write handler. Each point is written
+// as a gauge since Datadog counts and rates are per interval rather than
+// cumulative, the host and other resources as well as tags are written as
+// labels.
+func newWriteHandler(opts options.HandlerOptions, version apiVersion) http.Handler {
+	scope := opts.InstrumentOpts().MetricsScope().
+		Tagged(map[string]string{
+			"handler": "datadog-write",
+			"version": fmt.Sprintf("v%d", version),
+		})
+	return &writeHandler{
+		handlerOpts: opts,
+		tagOpts:     opts.TagOptions(),
+		version:     version,
+		metrics:     newWriteMetrics(scope),
+	}
+}
+
+func (h *writeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, status, err := h.parseRequest(r)
+	if err != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		xhttp.Error(w, err, status)
+		return
+	}
+
+	iter := newSeriesIter(req, h.tagOpts)
+	h.metrics.datapoints.Inc(int64(len(iter.series)))
+
+	batchErr := h.handlerOpts.DownsamplerAndWriter().
+		WriteBatch(r.Context(), iter, ingest.WriteOptions{})
+	if batchErr == nil {
+		h.metrics.writeSuccess.Inc(1)
+		// The agent expects the intake to accept payloads asynchronously.
+		w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"errors":[]}`))
+		return
+	}
+
+	var (
+		errs          = batchErr.Errors()
+		lastErr       error
+		numBadRequest int
+	)
+	for _, err := range errs {
+		if client.IsBadRequestError(err) || xerrors.IsInvalidParams(err) {
+			numBadRequest++
+		}
+		lastErr = err
+	}
+
+	status = http.StatusInternalServerError
+	if numBadRequest == len(errs) {
+		status = http.StatusBadRequest
+		h.metrics.writeErrorsClient.Inc(1)
+	} else {
+		h.metrics.writeErrorsServer.Inc(1)
+	}
+
+	logger := logging.WithContext(r.Context(), h.handlerOpts.InstrumentOpts())
+	logger.Error("write error",
+		zap.String("remoteAddr", r.RemoteAddr),
+		zap.Int("httpResponseStatusCode", status),
+		zap.Int("numErrors", len(errs)),
+		zap.Int("numBadRequestErrors", numBadRequest),
+		zap.Error(lastErr))
+
+	xhttp.Error(w, fmt.Errorf("errors: count=%d, bad_request=%d, last=%v",
+		len(errs), numBadRequest, lastErr), status)
+}
+
+func (h *writeHandler) parseRequest(
+	r *http.Request,
+) (seriesPayload, int, error) {
+	var (
+		req  seriesPayload
+		body io.Reader = r.Body
+	)
+	// NB: the agent compresses payloads with zlib and sends them with a
+	// deflate content encoding, zstd compression must be disabled on the
+	// agent with serializer_compressor_kind: zlib.
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
+	case "":
+	case "deflate":
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return req, http.StatusBadRequest, err
+		}
+		defer zr.Close()
+		body = zr
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return req, http.StatusBadRequest, err
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		return req, http.StatusUnsupportedMediaType,
+			fmt.Errorf("unsupported content encoding: %s", encoding)
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return req, http.StatusBadRequest, err
+	}
+
+	contentType := r.Header.Get(xhttp.HeaderContentType)
+	switch {
+	case h.version == apiVersionV2 &&
+		strings.HasPrefix(contentType, xhttp.ContentTypeProtobuf):
+		err = req.unmarshalProtobuf(data)
+	case h.version == apiVersionV2:
+		err = json.Unmarshal(data, &req)
+	default:
+		var reqV1 seriesPayloadV1
+		err = json.Unmarshal(data, &reqV1)
+		req = reqV1.toV2()
+	}
+	if err != nil {
+		return req, http.StatusBadRequest, err
+	}
+
+	return req, 0, nil
+}
+
+type series struct {
+	tags      models.Tags
+	datapoint ts.Datapoint
+}
+
+func newSeriesTags(s metricSeries, tagOpts models.TagOptions) models.Tags {
+	tags := models.NewTags(len(s.Resources)+len(s.Tags)+1, tagOpts)
+	seen := make(map[string]struct{}, cap(tags.Tags))
+	addTag := func(name string, value string) {
+		if _, ok := seen[name]; ok || value == "" {
+			return
+		}
+		seen[name] = struct{}{}
+		tags = tags.AddTagWithoutNormalizing(models.Tag{
+			Name:  []byte(name),
+			Value: []byte(value),
+		})
+	}
+
+	addTag(string(tagOpts.MetricName()), sanitize(s.Metric))
+	// Resources such as the host take precedence over tags of the same
+	// name, and the first value of a tag with multiple values is used.
+	for _, res := range s.Resources {
+		addTag(sanitize(res.Type), res.Name)
+	}
+	for _, tag := range s.Tags {
+		name, value := tag, valuelessTagValue
+		if idx := strings.IndexByte(tag, ':'); idx >= 0 {
+			name, value = tag[:idx], tag[idx+1:]
+		}
+		addTag(sanitize(name), value)
+	}
+
+	return tags.Normalize()
+}
+
+// sanitize replaces characters not valid in Prometheus metric and label
+// names with underscores.
+func sanitize(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+type seriesIter struct {
+	idx       int
+	series    []series
+	metadatas []ts.Metadata
+}
+
+func newSeriesIter(req seriesPayload, tagOpts models.TagOptions) *seriesIter {
+	var result []series
+	for _, s := range req.Series {
+		if s.Metric == "" {
+			continue
+		}
+
+		tags := newSeriesTags(s, tagOpts)
+		for _, dp := range s.Points {
+			result = append(result, series{
+				tags: tags,
+				datapoint: ts.Datapoint{
+					Timestamp: time.Unix(dp.Timestamp, 0),
+					Value:     dp.Value,
+				},
+			})
+		}
+	}
+
+	return &seriesIter{idx: -1, series: result}
+}
+
+func (i *seriesIter) Next() bool {
+	i.idx++
+	return i.idx < len(i.series)
+}
+
+func (i *seriesIter) Current() ingest.IterValue {
+	if i.idx < 0 || i.idx >= len(i.series) {
+		return defaultValue
+	}
+
+	s := i.series[i.idx]
+	value := ingest.IterValue{
+		Tags:       s.tags,
+		Datapoints: ts.Datapoints{s.datapoint},
+		Attributes: ts.DefaultSeriesAttributes(),
+		Unit:       xtime.Second,
+	}
+	if i.idx < len(i.metadatas) {
+		value.Metadata = i.metadatas[i.idx]
+	}
+	return value
+}
+
+func (i *seriesIter) Reset() error {
+	i.idx = -1
+	return nil
+}
+
+func (i *seriesIter) Error() error {
+	return nil
+}
+
+func (i *seriesIter) SetCurrentMetadata(metadata ts.Metadata) {
+	if len(i.metadatas) == 0 {
+		i.metadatas = make([]ts.Metadata, len(i.series))
+	}
+	if i.idx < 0 || i.idx >= len(i.metadatas) {
+		return
+	}
+	i.metadatas[i.idx] = metadata
+}
+
+type validateHandler struct{}
+
+// NewValidateHandler returns a new handler for the API key validation the
+// agent performs on startup, any API key is accepted.
+func NewValidateHandler() http.Handler {
+	return validateHandler{}
+}
+
+func (validateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	w.Write([]byte(`{"valid":true}`))
+}
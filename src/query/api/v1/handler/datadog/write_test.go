@@ -0,0 +1,240 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package datadog
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRequestV1 = `{
+  "series": [
+    {
+      "metric": "system.cpu.user",
+      "points": [[1600000000, 1.5], [1600000010, 2]],
+      "tags": ["env:prod", "role:db", "role:cache", "canary"],
+      "host": "web-1",
+      "type": "gauge",
+      "interval": 10
+    },
+    {
+      "metric": "system.disk.free",
+      "points": [[1600000000, 100]],
+      "host": "web-1",
+      "device": "/dev/sda1"
+    }
+  ]
+}`
+
+const testRequestV2 = `{
+  "series": [
+    {
+      "metric": "requests.count",
+      "type": 1,
+      "points": [{"timestamp": 1600000000, "value": 3}],
+      "tags": ["host:tagged", "code:200"],
+      "resources": [{"type": "host", "name": "web-2"}]
+    }
+  ]
+}`
+
+var expectedV1 = []string{
+	"__name__: system_cpu_user, canary: true, env: prod, host: web-1, role: db 1.5 1600000000",
+	"__name__: system_cpu_user, canary: true, env: prod, host: web-1, role: db 2 1600000010",
+	"__name__: system_disk_free, device: /dev/sda1, host: web-1 100 1600000000",
+}
+
+var expectedV2 = []string{
+	"__name__: requests_count, code: 200, host: web-2 3 1600000000",
+}
+
+func newTestHandlers(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (http.Handler, http.Handler, *[]string) {
+	var written []string
+	writer := ingest.NewMockDownsamplerAndWriter(ctrl)
+	writer.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			for iter.Next() {
+				value := iter.Current()
+				require.Equal(t, 1, len(value.Datapoints))
+				dp := value.Datapoints[0]
+				written = append(written, value.Tags.String()+" "+
+					strconv.FormatFloat(dp.Value, 'g', -1, 64)+" "+
+					strconv.FormatInt(dp.Timestamp.Unix(), 10))
+			}
+			return nil
+		}).AnyTimes()
+
+	opts := options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions()).
+		SetDownsamplerAndWriter(writer)
+	return NewSeriesV1WriteHandler(opts), NewSeriesV2WriteHandler(opts), &written
+}
+
+func TestSeriesV1WriteHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _, written := newTestHandlers(t, ctrl)
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, err := zw.Write([]byte(testRequestV1))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	req := httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV1WriteURL, &buf)
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	req.Header.Set("Content-Encoding", "deflate")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	sort.Strings(*written)
+	assert.Equal(t, expectedV1, *written)
+}
+
+func TestSeriesV2WriteHandlerJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, handler, written := newTestHandlers(t, ctrl)
+
+	req := httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV2WriteURL,
+		strings.NewReader(testRequestV2))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, expectedV2, *written)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendVarint(b, uint64(field<<3|wireBytes))
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func TestSeriesV2WriteHandlerProtobuf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	_, handler, written := newTestHandlers(t, ctrl)
+
+	var resource []byte
+	resource = appendBytesField(resource, resourceTypeField, []byte("host"))
+	resource = appendBytesField(resource, resourceNameField, []byte("web-2"))
+
+	var dp [8]byte
+	binary.LittleEndian.PutUint64(dp[:], math.Float64bits(3))
+	var point []byte
+	point = appendVarint(point, pointValueField<<3|wireFixed64)
+	point = append(point, dp[:]...)
+	point = appendVarint(point, pointTimestampField<<3|wireVarint)
+	point = appendVarint(point, 1600000000)
+
+	var series []byte
+	series = appendBytesField(series, seriesResourcesField, resource)
+	series = appendBytesField(series, seriesMetricField, []byte("requests.count"))
+	series = appendBytesField(series, seriesTagsField, []byte("host:tagged"))
+	series = appendBytesField(series, seriesTagsField, []byte("code:200"))
+	series = appendBytesField(series, seriesPointsField, point)
+	// The metric type and unit are skipped.
+	series = appendVarint(series, 5<<3|wireVarint)
+	series = appendVarint(series, 1)
+	series = appendBytesField(series, 6, []byte("request"))
+
+	payload := appendBytesField(nil, payloadSeriesField, series)
+
+	req := httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV2WriteURL,
+		bytes.NewReader(payload))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeProtobuf)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, expectedV2, *written)
+
+	// Truncated payloads are rejected.
+	req = httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV2WriteURL,
+		bytes.NewReader(payload[:len(payload)-3]))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeProtobuf)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestSeriesWriteHandlerBadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	v1, _, _ := newTestHandlers(t, ctrl)
+
+	req := httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV1WriteURL,
+		strings.NewReader(`{"series": [{"metric": "foo", "points": [[1]]}]}`))
+	recorder := httptest.NewRecorder()
+	v1.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = httptest.NewRequest(SeriesWriteHTTPMethod, SeriesV1WriteURL,
+		strings.NewReader(testRequestV1))
+	req.Header.Set("Content-Encoding", "zstd")
+	recorder = httptest.NewRecorder()
+	v1.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+func TestValidateHandler(t *testing.T) {
+	req := httptest.NewRequest(ValidateHTTPMethod, ValidateURL, nil)
+	recorder := httptest.NewRecorder()
+	NewValidateHandler().ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, `{"valid":true}`, recorder.Body.String())
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graphite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	ingestcarbon "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/carbon"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/graphite/graphite"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MappingTestURL is the url for testing the mapping of carbon metric
+	// names by the carbon ingestion rules.
+	MappingTestURL = handler.RoutePrefixV1 + "/graphite/mapping/test"
+
+	maxMappingTestNames = 1000
+)
+
+var (
+	// MappingTestHTTPMethods are the HTTP methods for this handler.
+	MappingTestHTTPMethods = []string{http.MethodPost}
+
+	errNoMappingTestNames      = errors.New("no names to map")
+	errTooManyMappingTestNames = fmt.Errorf(
+		"too many names to map, the maximum is %d", maxMappingTestNames)
+)
+
+// MappingTestRule is a carbon ingestion rule to test.
+type MappingTestRule struct {
+	Pattern  string                  `json:"pattern"`
+	Continue bool                    `json:"continue"`
+	Mapping  *MappingTestRuleMapping `json:"mapping,omitempty"`
+}
+
+// MappingTestRuleMapping is the mapping of a carbon ingestion rule to test.
+type MappingTestRuleMapping struct {
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags"`
+}
+
+// MappingTestRequest is the request to test the mapping of carbon metric
+// names, by the given rules or the configured carbon ingestion rules if
+// none are given.
+type MappingTestRequest struct {
+	Rules []MappingTestRule `json:"rules"`
+	Names []string          `json:"names"`
+}
+
+// MappingTestResult is the mapping of a carbon metric name by each of the
+// rules applied to it when ingested, which is empty if no rule matches.
+type MappingTestResult struct {
+	Name     string               `json:"name"`
+	Mappings []MappingTestMapping `json:"mappings"`
+}
+
+// MappingTestMapping is the tags a carbon metric name is mapped to by a rule.
+type MappingTestMapping struct {
+	Rule int               `json:"rule"`
+	Tags map[string]string `json:"tags"`
+}
+
+// MappingTestResponse is the response that gets returned to the user.
+type MappingTestResponse struct {
+	Results []MappingTestResult `json:"results"`
+}
+
+type mappingTestHandler struct {
+	rules          []config.CarbonIngesterRuleConfiguration
+	instrumentOpts instrument.Options
+}
+
+// NewMappingTestHandler returns a new instance of handler.
+func NewMappingTestHandler(opts options.HandlerOptions) http.Handler {
+	var rules []config.CarbonIngesterRuleConfiguration
+	if carbon := opts.Config().Carbon; carbon != nil && carbon.Ingester != nil {
+		rules = carbon.Ingester.Rules
+	}
+	if len(rules) == 0 {
+		// NB: without rules every metric is ingested with graphite tags.
+		rules = []config.CarbonIngesterRuleConfiguration{
+			{Pattern: graphite.MatchAllPattern},
+		}
+	}
+
+	return &mappingTestHandler{
+		rules:          rules,
+		instrumentOpts: opts.InstrumentOpts(),
+	}
+}
+
+func (h *mappingTestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+	w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+
+	var req MappingTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if len(req.Names) == 0 {
+		xhttp.Error(w, errNoMappingTestNames, http.StatusBadRequest)
+		return
+	}
+	if len(req.Names) > maxMappingTestNames {
+		xhttp.Error(w, errTooManyMappingTestNames, http.StatusBadRequest)
+		return
+	}
+
+	rules := h.rules
+	if len(req.Rules) > 0 {
+		rules = make([]config.CarbonIngesterRuleConfiguration, 0, len(req.Rules))
+		for _, rule := range req.Rules {
+			ruleCfg := config.CarbonIngesterRuleConfiguration{
+				Pattern:  rule.Pattern,
+				Continue: rule.Continue,
+			}
+			if rule.Mapping != nil {
+				ruleCfg.Mapping = &config.CarbonIngesterMappingConfiguration{
+					Name: rule.Mapping.Name,
+					Tags: rule.Mapping.Tags,
+				}
+			}
+			rules = append(rules, ruleCfg)
+		}
+	}
+
+	results := make([]MappingTestResult, 0, len(req.Names))
+	for _, name := range req.Names {
+		result, err := mapName(rules, name)
+		if err != nil {
+			logger.Error("unable to map carbon metric name", zap.Error(err))
+			xhttp.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		results = append(results, result)
+	}
+
+	xhttp.WriteJSONResponse(w, MappingTestResponse{Results: results}, logger)
+}
+
+// mapName maps the name by the rules the same way as carbon ingestion,
+// where the first matching rule applies along with the following matching
+// rules for as long as the matching rules continue.
+func mapName(
+	rules []config.CarbonIngesterRuleConfiguration,
+	name string,
+) (MappingTestResult, error) {
+	result := MappingTestResult{
+		Name:     name,
+		Mappings: []MappingTestMapping{},
+	}
+	for i, rule := range rules {
+		tags, ok, err := ingestcarbon.MapName(rule, []byte(name))
+		if err != nil {
+			return MappingTestResult{}, fmt.Errorf("rule %d: %v", i, err)
+		}
+		if !ok {
+			continue
+		}
+
+		mapping := MappingTestMapping{
+			Rule: i,
+			Tags: make(map[string]string, tags.Len()),
+		}
+		for _, tag := range tags.Tags {
+			mapping.Tags[string(tag.Name)] = string(tag.Value)
+		}
+		result.Mappings = append(result.Mappings, mapping)
+
+		if !rule.Continue {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package graphite
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/options"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMappingTestRequest(t *testing.T, req MappingTestRequest) *http.Request {
+	body, err := json.Marshal(req)
+	require.NoError(t, err)
+	return httptest.NewRequest(MappingTestHTTPMethods[0], MappingTestURL,
+		bytes.NewReader(body))
+}
+
+func TestMappingTestHandlerConfiguredRules(t *testing.T) {
+	opts := options.EmptyHandlerOptions().SetConfig(config.Configuration{
+		Carbon: &config.CarbonConfiguration{
+			Ingester: &config.CarbonIngesterConfiguration{
+				Rules: []config.CarbonIngesterRuleConfiguration{
+					{
+						Pattern:  `^stats\.([^.]+)\.count$`,
+						Continue: true,
+						Mapping: &config.CarbonIngesterMappingConfiguration{
+							Name: "stats_count",
+							Tags: map[string]string{"service": "$1"},
+						},
+					},
+					{Pattern: `^stats\.`},
+					{Pattern: `.*`},
+				},
+			},
+		},
+	})
+	handler := NewMappingTestHandler(opts)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newMappingTestRequest(t, MappingTestRequest{
+		Names: []string{"stats.foo.count", "stats.foo.timer", "other"},
+	}))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp MappingTestResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, MappingTestResponse{
+		Results: []MappingTestResult{
+			{
+				Name: "stats.foo.count",
+				Mappings: []MappingTestMapping{
+					{
+						Rule: 0,
+						Tags: map[string]string{
+							"__name__": "stats_count",
+							"service":  "foo",
+						},
+					},
+					{
+						Rule: 1,
+						Tags: map[string]string{
+							"__g0__": "stats",
+							"__g1__": "foo",
+							"__g2__": "count",
+						},
+					},
+				},
+			},
+			{
+				Name: "stats.foo.timer",
+				Mappings: []MappingTestMapping{
+					{
+						Rule: 1,
+						Tags: map[string]string{
+							"__g0__": "stats",
+							"__g1__": "foo",
+							"__g2__": "timer",
+						},
+					},
+				},
+			},
+			{
+				Name: "other",
+				Mappings: []MappingTestMapping{
+					{
+						Rule: 2,
+						Tags: map[string]string{"__g0__": "other"},
+					},
+				},
+			},
+		},
+	}, resp)
+}
+
+func TestMappingTestHandlerRequestRules(t *testing.T) {
+	handler := NewMappingTestHandler(options.EmptyHandlerOptions())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, newMappingTestRequest(t, MappingTestRequest{
+		Rules: []MappingTestRule{
+			{
+				Pattern: `^([^.]+)\.latency$`,
+				Mapping: &MappingTestRuleMapping{
+					Name: "latency",
+					Tags: map[string]string{"service": "$1"},
+				},
+			},
+		},
+		Names: []string{"foo.latency", "foo.errors"},
+	}))
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp MappingTestResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, MappingTestResponse{
+		Results: []MappingTestResult{
+			{
+				Name: "foo.latency",
+				Mappings: []MappingTestMapping{
+					{
+						Rule: 0,
+						Tags: map[string]string{
+							"__name__": "latency",
+							"service":  "foo",
+						},
+					},
+				},
+			},
+			{
+				Name:     "foo.errors",
+				Mappings: []MappingTestMapping{},
+			},
+		},
+	}, resp)
+}
+
+func TestMappingTestHandlerErrors(t *testing.T) {
+	handler := NewMappingTestHandler(options.EmptyHandlerOptions())
+
+	tests := []struct {
+		name string
+		req  MappingTestRequest
+	}{
+		{
+			name: "no names",
+			req:  MappingTestRequest{},
+		},
+		{
+			name: "invalid pattern",
+			req: MappingTestRequest{
+				Rules: []MappingTestRule{{Pattern: "("}},
+				Names: []string{"foo"},
+			},
+		},
+		{
+			name: "mapping overrides name tag",
+			req: MappingTestRequest{
+				Rules: []MappingTestRule{
+					{
+						Pattern: `^(foo)$`,
+						Mapping: &MappingTestRuleMapping{
+							Name: "foo",
+							Tags: map[string]string{"__name__": "$1"},
+						},
+					},
+				},
+				Names: []string{"foo"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, newMappingTestRequest(t, tt.req))
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
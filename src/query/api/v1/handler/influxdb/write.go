@@ -49,8 +49,25 @@ const (
 
 	// InfluxWriteHTTPMethod is the HTTP method used with this resource
 	InfluxWriteHTTPMethod = http.MethodPost
+
+	// precisionParam is the query parameter specifying the unit of the
+	// timestamps in the request body, defaulting to nanoseconds.
+	precisionParam = "precision"
 )
 
+// validPrecisions are the timestamp precisions understood by the InfluxDB
+// line protocol parser.
+var validPrecisions = map[string]struct{}{
+	"":   {},
+	"n":  {},
+	"ns": {},
+	"u":  {},
+	"ms": {},
+	"s":  {},
+	"m":  {},
+	"h":  {},
+}
+
 var defaultValue = ingest.IterValue{
 	Tags:       models.EmptyTags(),
 	Attributes: ts.DefaultSeriesAttributes(),
@@ -282,14 +299,22 @@ func NewInfluxWriterHandler(options options.HandlerOptions) http.Handler {
 }
 
 func (iwh *ingestWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	precision := r.URL.Query().Get(precisionParam)
+	if _, ok := validPrecisions[precision]; !ok {
+		err := fmt.Errorf("invalid %s: %q", precisionParam, precision)
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
 	bytes, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		xhttp.Error(w, err, http.StatusInternalServerError)
 		return
 	}
-	points, err := imodels.ParsePoints(bytes)
+	// Points without a timestamp are assigned the time they were received.
+	now := iwh.handlerOpts.NowFn()().UTC()
+	points, err := imodels.ParsePointsWithPrecision(bytes, now, precision)
 	if err != nil {
-		xhttp.Error(w, err, http.StatusInternalServerError)
+		xhttp.Error(w, err, http.StatusBadRequest)
 		return
 	}
 	opts := ingest.WriteOptions{}
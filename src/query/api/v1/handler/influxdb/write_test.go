@@ -21,10 +21,19 @@
 package influxdb
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/golang/mock/gomock"
 	imodels "github.com/influxdata/influxdb/models"
 	xtime "github.com/m3db/m3/src/x/time"
 	"github.com/stretchr/testify/assert"
@@ -127,3 +136,53 @@ func TestDetermineTimeUnit(t *testing.T) {
 	assert.Equal(t, determineTimeUnit(zerot.Add(4*time.Nanosecond)), xtime.Nanosecond)
 
 }
+
+func TestInfluxWriterHandlerPrecision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var timestamps []time.Time
+	writer := ingest.NewMockDownsamplerAndWriter(ctrl)
+	writer.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			for iter.Next() {
+				timestamps = append(timestamps, iter.Current().Datapoints[0].Timestamp)
+			}
+			return nil
+		})
+
+	opts := options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions()).
+		SetDownsamplerAndWriter(writer)
+	handler := NewInfluxWriterHandler(opts)
+
+	req := httptest.NewRequest(InfluxWriteHTTPMethod, InfluxWriteURL+"?precision=s",
+		strings.NewReader("measure,lab=foo k1=1 1574838670\n"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	require.Equal(t, 1, len(timestamps))
+	require.True(t, time.Unix(1574838670, 0).Equal(timestamps[0]))
+}
+
+func TestInfluxWriterHandlerBadRequest(t *testing.T) {
+	handler := NewInfluxWriterHandler(options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions()))
+
+	for _, url := range []string{
+		InfluxWriteURL + "?precision=d",
+		InfluxWriteURL,
+	} {
+		req := httptest.NewRequest(InfluxWriteHTTPMethod, url,
+			strings.NewReader("measure,lab=foo"))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, url)
+	}
+}
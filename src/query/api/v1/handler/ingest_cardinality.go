@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+)
+
+const (
+	// IngestCardinalityOffendersURL is the url of the cardinality offenders
+	// handler.
+	IngestCardinalityOffendersURL = RoutePrefixV1 + "/ingest/cardinality/offenders"
+
+	// IngestCardinalityOffendersHTTPMethod is the HTTP method used with this
+	// resource.
+	IngestCardinalityOffendersHTTPMethod = http.MethodGet
+
+	defaultCardinalityOffendersLimit = 10
+)
+
+// IngestCardinalityOffendersResponse is the response of the cardinality
+// offenders handler.
+type IngestCardinalityOffendersResponse struct {
+	Enabled   bool                   `json:"enabled"`
+	Offenders []cardinality.Offender `json:"offenders"`
+}
+
+type ingestCardinalityOffendersHandler struct {
+	analyzer       cardinality.Analyzer
+	instrumentOpts instrument.Options
+}
+
+// NewIngestCardinalityOffendersHandler returns a handler that lists the
+// groups of series with the highest new series rates, limited by the limit
+// query parameter.
+func NewIngestCardinalityOffendersHandler(opts options.HandlerOptions) http.Handler {
+	return &ingestCardinalityOffendersHandler{
+		analyzer:       opts.CardinalityAnalyzer(),
+		instrumentOpts: opts.InstrumentOpts(),
+	}
+}
+
+func (h *ingestCardinalityOffendersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+
+	limit := defaultCardinalityOffendersLimit
+	if str := r.URL.Query().Get("limit"); str != "" {
+		var err error
+		limit, err = strconv.Atoi(str)
+		if err != nil || limit <= 0 {
+			xhttp.Error(w, fmt.Errorf("invalid limit: %s", str), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := IngestCardinalityOffendersResponse{Offenders: []cardinality.Offender{}}
+	if h.analyzer != nil {
+		resp.Enabled = true
+		resp.Offenders = h.analyzer.TopOffenders(limit)
+	}
+	xhttp.WriteJSONResponse(w, resp, logger)
+}
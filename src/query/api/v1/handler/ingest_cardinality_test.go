@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCardinalityAnalyzer struct {
+	offenders []cardinality.Offender
+}
+
+func (a *testCardinalityAnalyzer) Admit(models.Tags) error { return nil }
+func (a *testCardinalityAnalyzer) Close()                  {}
+
+func (a *testCardinalityAnalyzer) TopOffenders(n int) []cardinality.Offender {
+	if n > len(a.offenders) {
+		n = len(a.offenders)
+	}
+	return a.offenders[:n]
+}
+
+func TestIngestCardinalityOffendersHandler(t *testing.T) {
+	analyzer := &testCardinalityAnalyzer{offenders: []cardinality.Offender{
+		{MetricName: "foo", NewSeriesPerSecond: 10, RateLimited: true},
+		{Label: "job", LabelValue: "api", NewSeriesPerSecond: 5},
+	}}
+	h := NewIngestCardinalityOffendersHandler(options.EmptyHandlerOptions().
+		SetCardinalityAnalyzer(analyzer))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(IngestCardinalityOffendersHTTPMethod,
+		IngestCardinalityOffendersURL+"?limit=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp IngestCardinalityOffendersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.Equal(t, analyzer.offenders[:1], resp.Offenders)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(IngestCardinalityOffendersHTTPMethod,
+		IngestCardinalityOffendersURL+"?limit=abc", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIngestCardinalityOffendersHandlerDisabled(t *testing.T) {
+	h := NewIngestCardinalityOffendersHandler(options.EmptyHandlerOptions())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(IngestCardinalityOffendersHTTPMethod,
+		IngestCardinalityOffendersURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp IngestCardinalityOffendersResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Enabled)
+	assert.Empty(t, resp.Offenders)
+}
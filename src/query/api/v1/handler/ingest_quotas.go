@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+)
+
+const (
+	// IngestQuotasURL is the url of the ingest quotas handler.
+	IngestQuotasURL = RoutePrefixV1 + "/ingest/quotas"
+
+	// IngestQuotasHTTPMethod is the HTTP method used with this resource.
+	IngestQuotasHTTPMethod = http.MethodGet
+)
+
+// IngestQuotasResponse is the response of the ingest quotas handler.
+type IngestQuotasResponse struct {
+	Enabled bool                `json:"enabled"`
+	Tenants []quota.TenantUsage `json:"tenants"`
+}
+
+type ingestQuotasHandler struct {
+	quotas         quota.Enforcer
+	instrumentOpts instrument.Options
+}
+
+// NewIngestQuotasHandler returns a handler that lists the usage of the
+// ingest quotas of each tenant that has written in the current second.
+func NewIngestQuotasHandler(opts options.HandlerOptions) http.Handler {
+	return &ingestQuotasHandler{
+		quotas:         opts.IngestQuotas(),
+		instrumentOpts: opts.InstrumentOpts(),
+	}
+}
+
+func (h *ingestQuotasHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+	resp := IngestQuotasResponse{Tenants: []quota.TenantUsage{}}
+	if h.quotas != nil {
+		resp.Enabled = true
+		resp.Tenants = h.quotas.Usage()
+	}
+	xhttp.WriteJSONResponse(w, resp, logger)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestQuotasHandler(t *testing.T) {
+	now := time.Now()
+	quotas := quota.Configuration{MaxDatapointsPerSecond: 10}.
+		NewEnforcer(func() time.Time { return now }, instrument.NewOptions())
+	tags := models.NewTags(1, models.NewTagOptions()).
+		SetName([]byte("foo"))
+	_, err := quotas.Admit("tenant-a", []quota.Write{
+		{Tags: tags, Datapoints: 3},
+	})
+	require.NoError(t, err)
+
+	h := NewIngestQuotasHandler(options.EmptyHandlerOptions().
+		SetIngestQuotas(quotas))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(IngestQuotasHTTPMethod, IngestQuotasURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp IngestQuotasResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.Enabled)
+	assert.Equal(t, []quota.TenantUsage{{
+		Tenant:                 "tenant-a",
+		MaxDatapointsPerSecond: 10,
+		Datapoints:             3,
+	}}, resp.Tenants)
+}
+
+func TestIngestQuotasHandlerDisabled(t *testing.T) {
+	h := NewIngestQuotasHandler(options.EmptyHandlerOptions())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(IngestQuotasHTTPMethod, IngestQuotasURL, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp IngestQuotasResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Enabled)
+	assert.Empty(t, resp.Tenants)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/memory"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/uber-go/tally"
+)
+
+var errQueryShedMemory = errors.New("low priority query shed due to memory pressure")
+
+// MemoryQueryShedder rejects the lowest priority queries, as set by the
+// query priority header, while heap usage is in the shed queries stage so
+// that higher priority queries can still be served.
+type MemoryQueryShedder struct {
+	monitor memory.Monitor
+	shed    tally.Counter
+}
+
+// NewMemoryQueryShedder returns a new memory query shedder.
+func NewMemoryQueryShedder(
+	monitor memory.Monitor,
+	instrumentOpts instrument.Options,
+) *MemoryQueryShedder {
+	return &MemoryQueryShedder{
+		monitor: monitor,
+		shed: instrumentOpts.MetricsScope().
+			Tagged(map[string]string{"reason": "memory"}).
+			Counter("queries-shed"),
+	}
+}
+
+// Wrap returns a handler that sheds low priority requests to the given
+// handler while heap usage is high.
+func (s *MemoryQueryShedder) Wrap(next http.Handler) http.Handler {
+	if s.monitor == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority := r.Header.Get(handleroptions.QueryPriorityHeader)
+		if priority == handleroptions.QueryPriorityLow &&
+			s.monitor.Stage() >= memory.StageShedQueries {
+			s.shed.Inc(1)
+			w.Header().Set(handleroptions.RetryHeader, "true")
+			xhttp.Error(w, errQueryShedMemory, http.StatusServiceUnavailable)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
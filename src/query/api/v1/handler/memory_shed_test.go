@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber-go/tally"
+)
+
+type testMemoryMonitor struct {
+	stage memory.Stage
+}
+
+func (m *testMemoryMonitor) Start() error                            { return nil }
+func (m *testMemoryMonitor) Stop() error                             { return nil }
+func (m *testMemoryMonitor) Stage() memory.Stage                     { return m.stage }
+func (m *testMemoryMonitor) RegisterListener(memory.StageListenerFn) {}
+
+func TestMemoryQueryShedder(t *testing.T) {
+	var (
+		scope   = tally.NewTestScope("", nil)
+		monitor = &testMemoryMonitor{stage: memory.StageFreeMemory}
+		shedder = NewMemoryQueryShedder(monitor,
+			instrument.NewOptions().SetMetricsScope(scope))
+		next = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		h = shedder.Wrap(next)
+	)
+
+	serve := func(priority string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/query", nil)
+		if priority != "" {
+			req.Header.Set(handleroptions.QueryPriorityHeader, priority)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Queries are only shed in the shed queries stage.
+	assert.Equal(t, http.StatusOK, serve(handleroptions.QueryPriorityLow).Code)
+
+	monitor.stage = memory.StageShedQueries
+	w := serve(handleroptions.QueryPriorityLow)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "true", w.Header().Get(handleroptions.RetryHeader))
+
+	// Queries without the low priority are still served.
+	assert.Equal(t, http.StatusOK, serve("").Code)
+	assert.Equal(t, http.StatusOK, serve("high").Code)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["queries-shed+reason=memory"].Value())
+}
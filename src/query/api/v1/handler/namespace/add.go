@@ -158,6 +158,10 @@ func (h *AddHandler) Add(
 	}
 
 	protoRegistry := namespace.ToProto(nsMap)
+	if opts.DryRun {
+		return *protoRegistry, nil
+	}
+
 	_, err = store.CheckAndSet(M3DBNodeNamespacesKey, version, protoRegistry)
 	if err != nil {
 		return emptyReg, fmt.Errorf("failed to add namespace: %v", err)
@@ -31,6 +31,7 @@ import (
 	clusterclient "github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/util/logging"
 	"github.com/m3db/m3/src/x/instrument"
 	xhttp "github.com/m3db/m3/src/x/net/http"
@@ -87,7 +88,8 @@ func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.Delete(id)
+	dryRun := strings.TrimSpace(r.Header.Get(handleroptions.HeaderDryRun)) == "true"
+	err := h.delete(id, dryRun)
 	if err != nil {
 		logger.Error("unable to delete namespace", zap.Error(err))
 		if err == errNamespaceNotFound {
@@ -100,13 +102,21 @@ func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(struct {
 		Deleted bool `json:"deleted"`
+		DryRun  bool `json:"dryRun,omitempty"`
 	}{
-		Deleted: true,
+		Deleted: !dryRun,
+		DryRun:  dryRun,
 	})
 }
 
 // Delete deletes a namespace.
 func (h *DeleteHandler) Delete(id string) error {
+	return h.delete(id, false)
+}
+
+// delete deletes a namespace, or only checks that it can be deleted if
+// dry run is set.
+func (h *DeleteHandler) delete(id string, dryRun bool) error {
 	store, err := h.client.KV()
 	if err != nil {
 		return err
@@ -129,6 +139,10 @@ func (h *DeleteHandler) Delete(id string) error {
 		return errNamespaceNotFound
 	}
 
+	if dryRun {
+		return nil
+	}
+
 	// If metadatas are empty, remove the key
 	if len(metadatas) == 1 {
 		if _, err = store.Delete(M3DBNodeNamespacesKey); err != nil {
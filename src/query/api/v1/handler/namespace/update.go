@@ -210,6 +210,10 @@ func (h *UpdateHandler) Update(
 	}
 
 	protoRegistry := namespace.ToProto(nsMap)
+	if opts.DryRun {
+		return *protoRegistry, nil
+	}
+
 	_, err = store.CheckAndSet(M3DBNodeNamespacesKey, version, protoRegistry)
 	if err != nil {
 		return emptyReg, fmt.Errorf("failed to update namespace: %w", err)
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/m3db/m3/src/query/api/v1/options"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	// Register the gzip compressor used by default by OTLP/gRPC exporters.
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metricsServiceName   = "opentelemetry.proto.collector.metrics.v1.MetricsService"
+	metricsExportMethod  = "Export"
+	metricsServiceProto  = "opentelemetry/proto/collector/metrics/v1/metrics_service.proto"
+	metricsExportFullURL = "/" + metricsServiceName + "/" + metricsExportMethod
+)
+
+// grpcExportRequest is an OTLP ExportMetricsServiceRequest, it implements the
+// proto.Message and proto.Unmarshaler interfaces so that the default gRPC
+// codec decodes it with unmarshalExportMetricsRequest.
+type grpcExportRequest struct {
+	req exportMetricsRequest
+}
+
+func (r *grpcExportRequest) Reset()         { *r = grpcExportRequest{} }
+func (r *grpcExportRequest) String() string { return fmt.Sprintf("%+v", r.req) }
+func (r *grpcExportRequest) ProtoMessage()  {}
+
+func (r *grpcExportRequest) Unmarshal(data []byte) error {
+	return unmarshalExportMetricsRequest(data, &r.req)
+}
+
+// grpcExportResponse is an OTLP ExportMetricsServiceResponse, it implements
+// the proto.Message and proto.Marshaler interfaces so that the default gRPC
+// codec encodes it with marshalExportMetricsResponse.
+type grpcExportResponse struct {
+	resp exportMetricsResponse
+}
+
+func (r *grpcExportResponse) Reset()         { *r = grpcExportResponse{} }
+func (r *grpcExportResponse) String() string { return fmt.Sprintf("%+v", r.resp) }
+func (r *grpcExportResponse) ProtoMessage()  {}
+
+func (r *grpcExportResponse) Marshal() ([]byte, error) {
+	return marshalExportMetricsResponse(r.resp), nil
+}
+
+type metricsServiceServer interface {
+	Export(ctx context.Context, req *grpcExportRequest) (*grpcExportResponse, error)
+}
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: metricsServiceName,
+	HandlerType: (*metricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: metricsExportMethod,
+			Handler:    metricsServiceExportHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: metricsServiceProto,
+}
+
+func metricsServiceExportHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(grpcExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(metricsServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: metricsExportFullURL,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(metricsServiceServer).Export(ctx, req.(*grpcExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// NewOTLPGRPCServer returns a new gRPC server serving the OTLP/gRPC metrics
+// service, requests are translated into series the same way as requests to
+// the OTLP/HTTP write handler.
+func NewOTLPGRPCServer(opts options.HandlerOptions) *grpc.Server {
+	server := grpc.NewServer()
+	server.RegisterService(&metricsServiceDesc, newWriteHandler(opts))
+	return server
+}
+
+// Export implements the OTLP/gRPC metrics service.
+func (h *writeHandler) Export(
+	ctx context.Context,
+	req *grpcExportRequest,
+) (*grpcExportResponse, error) {
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok {
+		remoteAddr = p.Addr.String()
+	}
+
+	resp, httpStatus, err := h.write(ctx, remoteAddr, req.req)
+	if err != nil {
+		// NB: OTLP exporters retry Unavailable errors and drop the data for
+		// InvalidArgument errors, which will fail again if retried.
+		code := codes.Unavailable
+		if httpStatus == http.StatusBadRequest {
+			code = codes.InvalidArgument
+		}
+		return nil, status.Error(code, err.Error())
+	}
+	return &grpcExportResponse{resp: resp}, nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package otlp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestOTLPGRPCExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, written := newTestHandler(t, ctrl)
+
+	var req grpcExportRequest
+	require.NoError(t, req.Unmarshal(testProtobufRequest()))
+
+	resp, err := handler.Export(context.Background(), &req)
+	require.NoError(t, err)
+	assert.Nil(t, resp.resp.PartialSuccess)
+	assert.Equal(t, 6, len(*written))
+
+	data, err := resp.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(data))
+}
+
+func TestOTLPGRPCExportBadRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer := ingest.NewMockDownsamplerAndWriter(ctrl)
+	writer.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(xerrors.NewMultiError().Add(
+			xerrors.NewInvalidParamsError(errors.New("bad"))))
+
+	handler := newWriteHandler(options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions()).
+		SetDownsamplerAndWriter(writer))
+
+	var req grpcExportRequest
+	require.NoError(t, req.Unmarshal(testProtobufRequest()))
+
+	_, err := handler.Export(context.Background(), &req)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
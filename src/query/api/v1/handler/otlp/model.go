@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The types below mirror the JSON encoding of the OTLP
+// ExportMetricsServiceRequest, keeping only the fields required to translate
+// metrics into series. Protobuf encoded requests are decoded into the same
+// types, see proto.go and opentelemetry-proto metrics/v1/metrics.proto.
+
+type exportMetricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+	// InstrumentationLibraryMetrics is the pre 0.15 name of ScopeMetrics
+	// still sent by older collectors.
+	InstrumentationLibraryMetrics []scopeMetrics `json:"instrumentationLibraryMetrics"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeMetrics struct {
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name                 string                `json:"name"`
+	Gauge                *gauge                `json:"gauge"`
+	Sum                  *sum                  `json:"sum"`
+	Histogram            *histogram            `json:"histogram"`
+	ExponentialHistogram *exponentialHistogram `json:"exponentialHistogram"`
+}
+
+type gauge struct {
+	DataPoints []numberDataPoint `json:"dataPoints"`
+}
+
+type sum struct {
+	DataPoints             []numberDataPoint      `json:"dataPoints"`
+	AggregationTemporality aggregationTemporality `json:"aggregationTemporality"`
+	IsMonotonic            bool                   `json:"isMonotonic"`
+}
+
+type histogram struct {
+	DataPoints             []histogramDataPoint   `json:"dataPoints"`
+	AggregationTemporality aggregationTemporality `json:"aggregationTemporality"`
+}
+
+type exponentialHistogram struct {
+	DataPoints             []exponentialHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality aggregationTemporality          `json:"aggregationTemporality"`
+}
+
+type numberDataPoint struct {
+	Attributes   []keyValue  `json:"attributes"`
+	TimeUnixNano int64Value  `json:"timeUnixNano"`
+	AsDouble     *float64    `json:"asDouble"`
+	AsInt        *int64Value `json:"asInt"`
+}
+
+type histogramDataPoint struct {
+	Attributes     []keyValue   `json:"attributes"`
+	TimeUnixNano   int64Value   `json:"timeUnixNano"`
+	Count          int64Value   `json:"count"`
+	Sum            *float64     `json:"sum"`
+	BucketCounts   []int64Value `json:"bucketCounts"`
+	ExplicitBounds []float64    `json:"explicitBounds"`
+}
+
+type exponentialHistogramDataPoint struct {
+	Attributes   []keyValue         `json:"attributes"`
+	TimeUnixNano int64Value         `json:"timeUnixNano"`
+	Count        int64Value         `json:"count"`
+	Sum          *float64           `json:"sum"`
+	Scale        int32              `json:"scale"`
+	ZeroCount    int64Value         `json:"zeroCount"`
+	Positive     exponentialBuckets `json:"positive"`
+	Negative     exponentialBuckets `json:"negative"`
+}
+
+type exponentialBuckets struct {
+	Offset       int32        `json:"offset"`
+	BucketCounts []int64Value `json:"bucketCounts"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue"`
+	BoolValue   *bool       `json:"boolValue"`
+	IntValue    *int64Value `json:"intValue"`
+	DoubleValue *float64    `json:"doubleValue"`
+}
+
+func (v anyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	}
+	return ""
+}
+
+// aggregationTemporality is the OTLP AggregationTemporality enum, which the
+// JSON encoding represents as a number, the enum name is also accepted.
+type aggregationTemporality int32
+
+const (
+	aggregationTemporalityUnspecified aggregationTemporality = 0
+	aggregationTemporalityDelta       aggregationTemporality = 1
+	aggregationTemporalityCumulative  aggregationTemporality = 2
+)
+
+var aggregationTemporalityNames = map[string]aggregationTemporality{
+	"AGGREGATION_TEMPORALITY_UNSPECIFIED": aggregationTemporalityUnspecified,
+	"AGGREGATION_TEMPORALITY_DELTA":       aggregationTemporalityDelta,
+	"AGGREGATION_TEMPORALITY_CUMULATIVE":  aggregationTemporalityCumulative,
+}
+
+func (t *aggregationTemporality) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		v, ok := aggregationTemporalityNames[s]
+		if !ok {
+			return fmt.Errorf("invalid aggregation temporality %s", s)
+		}
+		*t = v
+		return nil
+	}
+
+	n, err := strconv.ParseInt(string(b), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid aggregation temporality %s: %v", b, err)
+	}
+	*t = aggregationTemporality(n)
+	return nil
+}
+
+// exportMetricsResponse mirrors the JSON encoding of the OTLP
+// ExportMetricsServiceResponse, an empty response signals full success.
+type exportMetricsResponse struct {
+	PartialSuccess *exportMetricsPartialSuccess `json:"partialSuccess,omitempty"`
+}
+
+type exportMetricsPartialSuccess struct {
+	RejectedDataPoints int64  `json:"rejectedDataPoints,string"`
+	ErrorMessage       string `json:"errorMessage"`
+}
+
+// int64Value is a 64 bit integer which the OTLP JSON encoding represents as
+// a decimal string, plain numbers are also accepted.
+type int64Value int64
+
+func (v *int64Value) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		b = []byte(s)
+	}
+
+	n, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 value %s: %v", b, err)
+	}
+	*v = int64Value(n)
+	return nil
+}
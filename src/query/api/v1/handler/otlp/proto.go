@@ -0,0 +1,580 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package otlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// The functions below decode protobuf encoded OTLP ExportMetricsServiceRequest
+// messages into the types in model.go, skipping the fields not required to
+// translate metrics into series. Field numbers are those of
+// opentelemetry-proto collector/metrics/v1/metrics_service.proto and
+// metrics/v1/metrics.proto.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errInvalidProto = errors.New("invalid protobuf encoded OTLP message")
+
+type protoReader struct {
+	buf []byte
+}
+
+func (r *protoReader) more() bool {
+	return len(r.buf) > 0
+}
+
+func (r *protoReader) key() (int, int, error) {
+	v, err := r.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 7), nil
+}
+
+func (r *protoReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		return 0, errInvalidProto
+	}
+	r.buf = r.buf[n:]
+	return v, nil
+}
+
+func (r *protoReader) fixed64() (uint64, error) {
+	if len(r.buf) < 8 {
+		return 0, errInvalidProto
+	}
+	v := binary.LittleEndian.Uint64(r.buf)
+	r.buf = r.buf[8:]
+	return v, nil
+}
+
+func (r *protoReader) bytes() ([]byte, error) {
+	n, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.buf)) < n {
+		return nil, errInvalidProto
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b, nil
+}
+
+func (r *protoReader) skip(wireType int) error {
+	var err error
+	switch wireType {
+	case wireVarint:
+		_, err = r.varint()
+	case wireFixed64:
+		_, err = r.fixed64()
+	case wireBytes:
+		_, err = r.bytes()
+	case wireFixed32:
+		if len(r.buf) < 4 {
+			return errInvalidProto
+		}
+		r.buf = r.buf[4:]
+	default:
+		// Groups are deprecated and not used by OTLP.
+		err = errInvalidProto
+	}
+	return err
+}
+
+func (r *protoReader) readVarint(wireType int) (uint64, error) {
+	if wireType != wireVarint {
+		return 0, errInvalidProto
+	}
+	return r.varint()
+}
+
+func (r *protoReader) readFixed64(wireType int) (uint64, error) {
+	if wireType != wireFixed64 {
+		return 0, errInvalidProto
+	}
+	return r.fixed64()
+}
+
+func (r *protoReader) readBytes(wireType int) ([]byte, error) {
+	if wireType != wireBytes {
+		return nil, errInvalidProto
+	}
+	return r.bytes()
+}
+
+func (r *protoReader) readString(wireType int) (string, error) {
+	b, err := r.readBytes(wireType)
+	return string(b), err
+}
+
+func (r *protoReader) readDouble(wireType int) (float64, error) {
+	v, err := r.readFixed64(wireType)
+	return math.Float64frombits(v), err
+}
+
+func (r *protoReader) readSint32(wireType int) (int32, error) {
+	v, err := r.readVarint(wireType)
+	return int32(uint32(v>>1) ^ -uint32(v&1)), err
+}
+
+// readRepeatedFixed64 reads a packed or unpacked repeated fixed64 field.
+func (r *protoReader) readRepeatedFixed64(wireType int, fn func(uint64)) error {
+	if wireType == wireFixed64 {
+		v, err := r.fixed64()
+		if err != nil {
+			return err
+		}
+		fn(v)
+		return nil
+	}
+
+	b, err := r.readBytes(wireType)
+	if err != nil {
+		return err
+	}
+	if len(b)%8 != 0 {
+		return errInvalidProto
+	}
+	for ; len(b) > 0; b = b[8:] {
+		fn(binary.LittleEndian.Uint64(b))
+	}
+	return nil
+}
+
+// readRepeatedVarint reads a packed or unpacked repeated varint field.
+func (r *protoReader) readRepeatedVarint(wireType int, fn func(uint64)) error {
+	if wireType == wireVarint {
+		v, err := r.varint()
+		if err != nil {
+			return err
+		}
+		fn(v)
+		return nil
+	}
+
+	b, err := r.readBytes(wireType)
+	if err != nil {
+		return err
+	}
+	packed := protoReader{buf: b}
+	for packed.more() {
+		v, err := packed.varint()
+		if err != nil {
+			return err
+		}
+		fn(v)
+	}
+	return nil
+}
+
+// decodeMessage calls fn for each field of the encoded message, fields that
+// fn does not decode are skipped.
+func decodeMessage(
+	buf []byte,
+	fn func(r *protoReader, field, wireType int) (bool, error),
+) error {
+	r := protoReader{buf: buf}
+	for r.more() {
+		field, wireType, err := r.key()
+		if err != nil {
+			return err
+		}
+		decoded, err := fn(&r, field, wireType)
+		if err != nil {
+			return err
+		}
+		if decoded {
+			continue
+		}
+		if err := r.skip(wireType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalExportMetricsRequest(buf []byte, req *exportMetricsRequest) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		if field != 1 {
+			return false, nil
+		}
+		b, err := r.readBytes(wireType)
+		if err != nil {
+			return true, err
+		}
+		var rm resourceMetrics
+		if err := unmarshalResourceMetrics(b, &rm); err != nil {
+			return true, err
+		}
+		req.ResourceMetrics = append(req.ResourceMetrics, rm)
+		return true, nil
+	})
+}
+
+func unmarshalResourceMetrics(buf []byte, rm *resourceMetrics) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 1:
+			b, err := r.readBytes(wireType)
+			if err != nil {
+				return true, err
+			}
+			return true, unmarshalAttributes(b, 1, &rm.Resource.Attributes)
+		case 2, 1000:
+			// Field 1000 is the deprecated instrumentation library metrics
+			// still sent by older collectors, it shares the layout of scope
+			// metrics.
+			b, err := r.readBytes(wireType)
+			if err != nil {
+				return true, err
+			}
+			var sm scopeMetrics
+			if err := unmarshalScopeMetrics(b, &sm); err != nil {
+				return true, err
+			}
+			rm.ScopeMetrics = append(rm.ScopeMetrics, sm)
+			return true, nil
+		}
+		return false, nil
+	})
+}
+
+func unmarshalScopeMetrics(buf []byte, sm *scopeMetrics) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		if field != 2 {
+			return false, nil
+		}
+		b, err := r.readBytes(wireType)
+		if err != nil {
+			return true, err
+		}
+		var m metric
+		if err := unmarshalMetric(b, &m); err != nil {
+			return true, err
+		}
+		sm.Metrics = append(sm.Metrics, m)
+		return true, nil
+	})
+}
+
+func unmarshalMetric(buf []byte, m *metric) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		var err error
+		switch field {
+		case 1:
+			m.Name, err = r.readString(wireType)
+		case 5:
+			m.Gauge = &gauge{}
+			err = r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+				if field != 1 {
+					return false, nil
+				}
+				return true, r.appendNumberDataPoint(wireType, &m.Gauge.DataPoints)
+			})
+		case 7:
+			m.Sum = &sum{}
+			err = r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+				switch field {
+				case 1:
+					return true, r.appendNumberDataPoint(wireType, &m.Sum.DataPoints)
+				case 2:
+					return true, r.readTemporality(wireType, &m.Sum.AggregationTemporality)
+				case 3:
+					v, err := r.readVarint(wireType)
+					m.Sum.IsMonotonic = v != 0
+					return true, err
+				}
+				return false, nil
+			})
+		case 9:
+			m.Histogram = &histogram{}
+			err = r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+				switch field {
+				case 1:
+					b, err := r.readBytes(wireType)
+					if err != nil {
+						return true, err
+					}
+					var dp histogramDataPoint
+					if err := unmarshalHistogramDataPoint(b, &dp); err != nil {
+						return true, err
+					}
+					m.Histogram.DataPoints = append(m.Histogram.DataPoints, dp)
+					return true, nil
+				case 2:
+					return true, r.readTemporality(wireType, &m.Histogram.AggregationTemporality)
+				}
+				return false, nil
+			})
+		case 10:
+			m.ExponentialHistogram = &exponentialHistogram{}
+			err = r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+				switch field {
+				case 1:
+					b, err := r.readBytes(wireType)
+					if err != nil {
+						return true, err
+					}
+					var dp exponentialHistogramDataPoint
+					if err := unmarshalExponentialHistogramDataPoint(b, &dp); err != nil {
+						return true, err
+					}
+					m.ExponentialHistogram.DataPoints = append(m.ExponentialHistogram.DataPoints, dp)
+					return true, nil
+				case 2:
+					return true, r.readTemporality(wireType, &m.ExponentialHistogram.AggregationTemporality)
+				}
+				return false, nil
+			})
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+func (r *protoReader) decodeSubMessage(
+	wireType int,
+	fn func(r *protoReader, field, wireType int) (bool, error),
+) error {
+	b, err := r.readBytes(wireType)
+	if err != nil {
+		return err
+	}
+	return decodeMessage(b, fn)
+}
+
+func (r *protoReader) readTemporality(wireType int, t *aggregationTemporality) error {
+	v, err := r.readVarint(wireType)
+	*t = aggregationTemporality(v)
+	return err
+}
+
+func (r *protoReader) appendNumberDataPoint(wireType int, dps *[]numberDataPoint) error {
+	var dp numberDataPoint
+	err := r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 3:
+			v, err := r.readFixed64(wireType)
+			dp.TimeUnixNano = int64Value(v)
+			return true, err
+		case 4:
+			v, err := r.readDouble(wireType)
+			dp.AsDouble = &v
+			return true, err
+		case 6:
+			v, err := r.readFixed64(wireType)
+			asInt := int64Value(v)
+			dp.AsInt = &asInt
+			return true, err
+		case 7:
+			return true, r.appendAttribute(wireType, &dp.Attributes)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	*dps = append(*dps, dp)
+	return nil
+}
+
+func unmarshalHistogramDataPoint(buf []byte, dp *histogramDataPoint) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 3:
+			v, err := r.readFixed64(wireType)
+			dp.TimeUnixNano = int64Value(v)
+			return true, err
+		case 4:
+			v, err := r.readFixed64(wireType)
+			dp.Count = int64Value(v)
+			return true, err
+		case 5:
+			v, err := r.readDouble(wireType)
+			dp.Sum = &v
+			return true, err
+		case 6:
+			return true, r.readRepeatedFixed64(wireType, func(v uint64) {
+				dp.BucketCounts = append(dp.BucketCounts, int64Value(v))
+			})
+		case 7:
+			return true, r.readRepeatedFixed64(wireType, func(v uint64) {
+				dp.ExplicitBounds = append(dp.ExplicitBounds, math.Float64frombits(v))
+			})
+		case 9:
+			return true, r.appendAttribute(wireType, &dp.Attributes)
+		}
+		return false, nil
+	})
+}
+
+func unmarshalExponentialHistogramDataPoint(
+	buf []byte,
+	dp *exponentialHistogramDataPoint,
+) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 1:
+			return true, r.appendAttribute(wireType, &dp.Attributes)
+		case 3:
+			v, err := r.readFixed64(wireType)
+			dp.TimeUnixNano = int64Value(v)
+			return true, err
+		case 4:
+			v, err := r.readFixed64(wireType)
+			dp.Count = int64Value(v)
+			return true, err
+		case 5:
+			v, err := r.readDouble(wireType)
+			dp.Sum = &v
+			return true, err
+		case 6:
+			v, err := r.readSint32(wireType)
+			dp.Scale = v
+			return true, err
+		case 7:
+			v, err := r.readFixed64(wireType)
+			dp.ZeroCount = int64Value(v)
+			return true, err
+		case 8:
+			return true, r.readBuckets(wireType, &dp.Positive)
+		case 9:
+			return true, r.readBuckets(wireType, &dp.Negative)
+		}
+		return false, nil
+	})
+}
+
+func (r *protoReader) readBuckets(wireType int, buckets *exponentialBuckets) error {
+	return r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 1:
+			v, err := r.readSint32(wireType)
+			buckets.Offset = v
+			return true, err
+		case 2:
+			return true, r.readRepeatedVarint(wireType, func(v uint64) {
+				buckets.BucketCounts = append(buckets.BucketCounts, int64Value(v))
+			})
+		}
+		return false, nil
+	})
+}
+
+func unmarshalAttributes(buf []byte, attributesField int, attrs *[]keyValue) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (bool, error) {
+		if field != attributesField {
+			return false, nil
+		}
+		return true, r.appendAttribute(wireType, attrs)
+	})
+}
+
+func (r *protoReader) appendAttribute(wireType int, attrs *[]keyValue) error {
+	var kv keyValue
+	err := r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+		switch field {
+		case 1:
+			v, err := r.readString(wireType)
+			kv.Key = v
+			return true, err
+		case 2:
+			return true, r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+				switch field {
+				case 1:
+					v, err := r.readString(wireType)
+					kv.Value.StringValue = &v
+					return true, err
+				case 2:
+					v, err := r.readVarint(wireType)
+					b := v != 0
+					kv.Value.BoolValue = &b
+					return true, err
+				case 3:
+					v, err := r.readVarint(wireType)
+					i := int64Value(v)
+					kv.Value.IntValue = &i
+					return true, err
+				case 4:
+					v, err := r.readDouble(wireType)
+					kv.Value.DoubleValue = &v
+					return true, err
+				}
+				return false, nil
+			})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	*attrs = append(*attrs, kv)
+	return nil
+}
+
+// marshalExportMetricsResponse encodes an OTLP ExportMetricsServiceResponse.
+func marshalExportMetricsResponse(resp exportMetricsResponse) []byte {
+	if resp.PartialSuccess == nil {
+		return nil
+	}
+
+	var partialSuccess []byte
+	if v := resp.PartialSuccess.RejectedDataPoints; v != 0 {
+		partialSuccess = appendVarintField(partialSuccess, 1, uint64(v))
+	}
+	if v := resp.PartialSuccess.ErrorMessage; v != "" {
+		partialSuccess = appendBytesField(partialSuccess, 2, []byte(v))
+	}
+	return appendBytesField(nil, 1, partialSuccess)
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func appendKey(b []byte, field, wireType int) []byte {
+	return appendUvarint(b, uint64(field)<<3|uint64(wireType))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	b = appendKey(b, field, wireVarint)
+	return appendUvarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendKey(b, field, wireBytes)
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
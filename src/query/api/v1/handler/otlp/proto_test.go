@@ -0,0 +1,213 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package otlp
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendFixed64Field(b []byte, field int, v uint64) []byte {
+	b = appendKey(b, field, wireFixed64)
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendDoubleField(b []byte, field int, v float64) []byte {
+	return appendFixed64Field(b, field, math.Float64bits(v))
+}
+
+func appendAttribute(b []byte, field int, key string, value []byte) []byte {
+	kv := appendBytesField(nil, 1, []byte(key))
+	kv = appendBytesField(kv, 2, value)
+	return appendBytesField(b, field, kv)
+}
+
+// testProtobufRequest returns the protobuf encoding of the gauge, sum and
+// histogram metrics of testRequest.
+func testProtobufRequest() []byte {
+	resource := appendAttribute(nil, 1, "service.name",
+		appendBytesField(nil, 1, []byte("api")))
+
+	gaugeDP := appendFixed64Field(nil, 3, 1e9)
+	gaugeDP = appendFixed64Field(gaugeDP, 6, 7)
+	gaugeMetric := appendBytesField(nil, 1, []byte("queue.depth"))
+	gaugeMetric = appendBytesField(gaugeMetric, 5, appendBytesField(nil, 1, gaugeDP))
+
+	sumDP := appendFixed64Field(nil, 3, 1e9)
+	sumDP = appendDoubleField(sumDP, 4, 3.5)
+	sumDP = appendAttribute(sumDP, 7, "code", appendVarintField(nil, 3, 200))
+	sum := appendBytesField(nil, 1, sumDP)
+	sum = appendVarintField(sum, 2, uint64(aggregationTemporalityCumulative))
+	sum = appendVarintField(sum, 3, 1)
+	sumMetric := appendBytesField(nil, 1, []byte("requests"))
+	sumMetric = appendBytesField(sumMetric, 7, sum)
+
+	// Bucket counts and explicit bounds are packed.
+	var counts, bounds []byte
+	counts = appendFixed64Field(counts, 1, 1)[1:]
+	counts = append(counts, appendFixed64Field(nil, 1, 2)[1:]...)
+	bounds = appendDoubleField(bounds, 1, 1.5)[1:]
+	histDP := appendFixed64Field(nil, 3, 1e9)
+	histDP = appendFixed64Field(histDP, 4, 3)
+	histDP = appendDoubleField(histDP, 5, 6)
+	histDP = appendBytesField(histDP, 6, counts)
+	histDP = appendBytesField(histDP, 7, bounds)
+	hist := appendBytesField(nil, 1, histDP)
+	hist = appendVarintField(hist, 2, uint64(aggregationTemporalityCumulative))
+	histMetric := appendBytesField(nil, 1, []byte("latency"))
+	histMetric = appendBytesField(histMetric, 9, hist)
+
+	scope := appendBytesField(nil, 2, gaugeMetric)
+	scope = appendBytesField(scope, 2, sumMetric)
+	scope = appendBytesField(scope, 2, histMetric)
+
+	rm := appendBytesField(nil, 1, resource)
+	rm = appendBytesField(rm, 2, scope)
+	return appendBytesField(nil, 1, rm)
+}
+
+func TestUnmarshalExportMetricsRequest(t *testing.T) {
+	var req exportMetricsRequest
+	require.NoError(t, unmarshalExportMetricsRequest(testProtobufRequest(), &req))
+
+	require.Equal(t, 1, len(req.ResourceMetrics))
+	rm := req.ResourceMetrics[0]
+	require.Equal(t, 1, len(rm.Resource.Attributes))
+	assert.Equal(t, "service.name", rm.Resource.Attributes[0].Key)
+	assert.Equal(t, "api", rm.Resource.Attributes[0].Value.String())
+
+	require.Equal(t, 1, len(rm.ScopeMetrics))
+	metrics := rm.ScopeMetrics[0].Metrics
+	require.Equal(t, 3, len(metrics))
+
+	assert.Equal(t, "queue.depth", metrics[0].Name)
+	require.NotNil(t, metrics[0].Gauge)
+	require.Equal(t, 1, len(metrics[0].Gauge.DataPoints))
+	require.NotNil(t, metrics[0].Gauge.DataPoints[0].AsInt)
+	assert.Equal(t, int64Value(7), *metrics[0].Gauge.DataPoints[0].AsInt)
+	assert.Equal(t, int64Value(1e9), metrics[0].Gauge.DataPoints[0].TimeUnixNano)
+
+	assert.Equal(t, "requests", metrics[1].Name)
+	require.NotNil(t, metrics[1].Sum)
+	assert.True(t, metrics[1].Sum.IsMonotonic)
+	assert.Equal(t, aggregationTemporalityCumulative, metrics[1].Sum.AggregationTemporality)
+	require.Equal(t, 1, len(metrics[1].Sum.DataPoints))
+	require.NotNil(t, metrics[1].Sum.DataPoints[0].AsDouble)
+	assert.Equal(t, 3.5, *metrics[1].Sum.DataPoints[0].AsDouble)
+	require.Equal(t, 1, len(metrics[1].Sum.DataPoints[0].Attributes))
+	assert.Equal(t, "200", metrics[1].Sum.DataPoints[0].Attributes[0].Value.String())
+
+	assert.Equal(t, "latency", metrics[2].Name)
+	require.NotNil(t, metrics[2].Histogram)
+	require.Equal(t, 1, len(metrics[2].Histogram.DataPoints))
+	dp := metrics[2].Histogram.DataPoints[0]
+	assert.Equal(t, int64Value(3), dp.Count)
+	require.NotNil(t, dp.Sum)
+	assert.Equal(t, 6.0, *dp.Sum)
+	assert.Equal(t, []int64Value{1, 2}, dp.BucketCounts)
+	assert.Equal(t, []float64{1.5}, dp.ExplicitBounds)
+}
+
+func TestUnmarshalExponentialHistogram(t *testing.T) {
+	// Scale and offset are zigzag encoded, bucket counts are packed and
+	// unknown fields are skipped.
+	var buckets []byte
+	buckets = appendVarintField(buckets, 1, 2)
+	buckets = appendBytesField(buckets, 2, []byte{2, 1})
+	dp := appendFixed64Field(nil, 3, 1e9)
+	dp = appendFixed64Field(dp, 4, 4)
+	dp = appendDoubleField(dp, 5, 10)
+	dp = appendVarintField(dp, 6, 1)
+	dp = appendFixed64Field(dp, 7, 1)
+	dp = appendBytesField(dp, 8, buckets)
+	dp = appendVarintField(dp, 99, 1)
+	hist := appendBytesField(nil, 1, dp)
+	hist = appendVarintField(hist, 2, uint64(aggregationTemporalityDelta))
+	metricBytes := appendBytesField(nil, 1, []byte("size"))
+	metricBytes = appendBytesField(metricBytes, 10, hist)
+
+	var m metric
+	require.NoError(t, unmarshalMetric(metricBytes, &m))
+	require.NotNil(t, m.ExponentialHistogram)
+	assert.Equal(t, aggregationTemporalityDelta, m.ExponentialHistogram.AggregationTemporality)
+	require.Equal(t, 1, len(m.ExponentialHistogram.DataPoints))
+	assert.Equal(t, exponentialHistogramDataPoint{
+		TimeUnixNano: 1e9,
+		Count:        4,
+		Sum:          m.ExponentialHistogram.DataPoints[0].Sum,
+		Scale:        -1,
+		ZeroCount:    1,
+		Positive: exponentialBuckets{
+			Offset:       1,
+			BucketCounts: []int64Value{2, 1},
+		},
+	}, m.ExponentialHistogram.DataPoints[0])
+	assert.Equal(t, 10.0, *m.ExponentialHistogram.DataPoints[0].Sum)
+}
+
+func TestUnmarshalExportMetricsRequestInvalid(t *testing.T) {
+	valid := testProtobufRequest()
+	for _, data := range [][]byte{
+		valid[:len(valid)-1],
+		{0xff},
+		// A string field encoded as a varint.
+		appendVarintField(nil, 1, 1),
+	} {
+		var req exportMetricsRequest
+		assert.Error(t, unmarshalExportMetricsRequest(data, &req))
+	}
+}
+
+func TestMarshalExportMetricsResponse(t *testing.T) {
+	assert.Equal(t, 0, len(marshalExportMetricsResponse(exportMetricsResponse{})))
+
+	data := marshalExportMetricsResponse(exportMetricsResponse{
+		PartialSuccess: &exportMetricsPartialSuccess{
+			RejectedDataPoints: 3,
+			ErrorMessage:       "foo",
+		},
+	})
+
+	var (
+		rejected uint64
+		message  string
+	)
+	require.NoError(t, decodeMessage(data, func(r *protoReader, field, wireType int) (bool, error) {
+		require.Equal(t, 1, field)
+		return true, r.decodeSubMessage(wireType, func(r *protoReader, field, wireType int) (bool, error) {
+			var err error
+			switch field {
+			case 1:
+				rejected, err = r.readVarint(wireType)
+			case 2:
+				message, err = r.readString(wireType)
+			}
+			return true, err
+		})
+	}))
+	assert.Equal(t, uint64(3), rejected)
+	assert.Equal(t, "foo", message)
+}
@@ -0,0 +1,504 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package otlp implements an OpenTelemetry OTLP/HTTP and OTLP/gRPC metrics
+// receiver.
+package otlp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// OTLPWriteURL is the OTLP/HTTP metrics write handler URL, point an OTLP
+	// HTTP exporter's metrics endpoint at this path.
+	OTLPWriteURL = handler.RoutePrefixV1 + "/otlp/v1/metrics"
+
+	// OTLPWriteHTTPMethod is the HTTP method used with this resource.
+	OTLPWriteHTTPMethod = http.MethodPost
+
+	bucketSuffix = "_bucket"
+	sumSuffix    = "_sum"
+	countSuffix  = "_count"
+	leTagName    = "le"
+	infBound     = "+Inf"
+)
+
+var (
+	errDeltaTemporality = errors.New("delta aggregation temporality is not supported, " +
+		"configure the exporter to use cumulative temporality")
+
+	defaultValue = ingest.IterValue{
+		Tags:       models.EmptyTags(),
+		Attributes: ts.DefaultSeriesAttributes(),
+		Metadata:   ts.Metadata{},
+	}
+)
+
+type writeHandler struct {
+	handlerOpts options.HandlerOptions
+	tagOpts     models.TagOptions
+	metrics     writeMetrics
+}
+
+type writeMetrics struct {
+	writeSuccess       tally.Counter
+	writeErrorsServer  tally.Counter
+	writeErrorsClient  tally.Counter
+	datapoints         tally.Counter
+	datapointsRejected tally.Counter
+}
+
+func newWriteMetrics(scope tally.Scope) writeMetrics {
+	return writeMetrics{
+		writeSuccess:       scope.SubScope("write").Counter("success"),
+		writeErrorsServer:  scope.SubScope("write").Tagged(map[string]string{"code": "5XX"}).Counter("errors"),
+		writeErrorsClient:  scope.SubScope("write").Tagged(map[string]string{"code": "4XX"}).Counter("errors"),
+		datapoints:         scope.SubScope("write").Counter("datapoints"),
+		datapointsRejected: scope.SubScope("write").Counter("datapoints-rejected"),
+	}
+}
+
+// NewOTLPWriteHandler returns a new OTLP/HTTP metrics write handler accepting
+// JSON and protobuf encoded requests. Gauges and cumulative sums are written
+// as a series per data point, cumulative histograms and exponential
+// histograms are written as Prometheus style _bucket, _sum and _count series.
+// Data points with delta temporality are rejected and reported in a partial
+// success response.
+func NewOTLPWriteHandler(opts options.HandlerOptions) http.Handler {
+	return newWriteHandler(opts)
+}
+
+func newWriteHandler(opts options.HandlerOptions) *writeHandler {
+	scope := opts.InstrumentOpts().MetricsScope().
+		Tagged(map[string]string{"handler": "otlp-write"})
+	return &writeHandler{
+		handlerOpts: opts,
+		tagOpts:     opts.TagOptions(),
+		metrics:     newWriteMetrics(scope),
+	}
+}
+
+func (h *writeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, isProtobuf, status, err := h.parseRequest(r)
+	if err != nil {
+		h.metrics.writeErrorsClient.Inc(1)
+		xhttp.Error(w, err, status)
+		return
+	}
+
+	resp, status, err := h.write(r.Context(), r.RemoteAddr, req)
+	if err != nil {
+		xhttp.Error(w, err, status)
+		return
+	}
+
+	if isProtobuf {
+		w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeProtobuf)
+		w.WriteHeader(http.StatusOK)
+		w.Write(marshalExportMetricsResponse(resp))
+		return
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// write writes the series of the request, returning the HTTP status code
+// of the failure if the write fails.
+func (h *writeHandler) write(
+	ctx context.Context,
+	remoteAddr string,
+	req exportMetricsRequest,
+) (exportMetricsResponse, int, error) {
+	iter := newSeriesIter(req, h.tagOpts)
+	h.metrics.datapoints.Inc(int64(len(iter.series)))
+
+	// An empty ExportMetricsServiceResponse signals full success.
+	var resp exportMetricsResponse
+	if iter.rejected > 0 {
+		h.metrics.datapointsRejected.Inc(iter.rejected)
+		resp.PartialSuccess = &exportMetricsPartialSuccess{
+			RejectedDataPoints: iter.rejected,
+			ErrorMessage:       errDeltaTemporality.Error(),
+		}
+	}
+
+	batchErr := h.handlerOpts.DownsamplerAndWriter().
+		WriteBatch(ctx, iter, ingest.WriteOptions{})
+	if batchErr == nil {
+		h.metrics.writeSuccess.Inc(1)
+		return resp, http.StatusOK, nil
+	}
+
+	var (
+		errs          = batchErr.Errors()
+		lastErr       error
+		numBadRequest int
+	)
+	for _, err := range errs {
+		if client.IsBadRequestError(err) || xerrors.IsInvalidParams(err) {
+			numBadRequest++
+		}
+		lastErr = err
+	}
+
+	status := http.StatusInternalServerError
+	if numBadRequest == len(errs) {
+		status = http.StatusBadRequest
+		h.metrics.writeErrorsClient.Inc(1)
+	} else {
+		h.metrics.writeErrorsServer.Inc(1)
+	}
+
+	logger := logging.WithContext(ctx, h.handlerOpts.InstrumentOpts())
+	logger.Error("write error",
+		zap.String("remoteAddr", remoteAddr),
+		zap.Int("httpResponseStatusCode", status),
+		zap.Int("numErrors", len(errs)),
+		zap.Int("numBadRequestErrors", numBadRequest),
+		zap.Error(lastErr))
+
+	return resp, status, fmt.Errorf("errors: count=%d, bad_request=%d, last=%v",
+		len(errs), numBadRequest, lastErr)
+}
+
+// parseRequest decodes a JSON or protobuf encoded request depending on its
+// content type, returning whether the request was protobuf encoded so the
+// response can be encoded the same way.
+func (h *writeHandler) parseRequest(
+	r *http.Request,
+) (exportMetricsRequest, bool, int, error) {
+	var (
+		req        exportMetricsRequest
+		isProtobuf = strings.HasPrefix(r.Header.Get(xhttp.HeaderContentType),
+			xhttp.ContentTypeProtobuf)
+	)
+
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return req, isProtobuf, http.StatusBadRequest, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return req, isProtobuf, http.StatusBadRequest, err
+	}
+
+	if isProtobuf {
+		err = unmarshalExportMetricsRequest(data, &req)
+	} else {
+		err = json.Unmarshal(data, &req)
+	}
+	if err != nil {
+		return req, isProtobuf, http.StatusBadRequest, err
+	}
+
+	return req, isProtobuf, 0, nil
+}
+
+type series struct {
+	tags       models.Tags
+	datapoint  ts.Datapoint
+	attributes ts.SeriesAttributes
+}
+
+type seriesBuilder struct {
+	tagOpts       models.TagOptions
+	resourceAttrs []keyValue
+	series        []series
+	rejected      int64
+}
+
+func (b *seriesBuilder) add(
+	name string,
+	attrs []keyValue,
+	extra []models.Tag,
+	timeUnixNano int64Value,
+	value float64,
+	metricType ts.MetricType,
+) {
+	tags := models.NewTags(len(b.resourceAttrs)+len(attrs)+len(extra)+1, b.tagOpts)
+	seen := make(map[string]struct{}, cap(tags.Tags))
+	addTag := func(name string, value []byte) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		tags = tags.AddTagWithoutNormalizing(models.Tag{Name: []byte(name), Value: value})
+	}
+
+	addTag(string(b.tagOpts.MetricName()), []byte(sanitize(name)))
+	// Extra tags and data point attributes take precedence over resource
+	// attributes of the same name.
+	for _, tag := range extra {
+		addTag(string(tag.Name), tag.Value)
+	}
+	for _, attr := range attrs {
+		addTag(sanitize(attr.Key), []byte(attr.Value.String()))
+	}
+	for _, attr := range b.resourceAttrs {
+		addTag(sanitize(attr.Key), []byte(attr.Value.String()))
+	}
+
+	b.series = append(b.series, series{
+		tags: tags.Normalize(),
+		datapoint: ts.Datapoint{
+			Timestamp: time.Unix(0, int64(timeUnixNano)),
+			Value:     value,
+		},
+		attributes: ts.SeriesAttributes{
+			Type:   metricType,
+			Source: ts.SourceTypePrometheus,
+		},
+	})
+}
+
+func (b *seriesBuilder) addMetric(m metric) {
+	switch {
+	case m.Gauge != nil:
+		b.addNumberDataPoints(m.Name, m.Gauge.DataPoints, ts.MetricTypeGauge)
+	case m.Sum != nil:
+		if m.Sum.AggregationTemporality == aggregationTemporalityDelta {
+			b.rejected += int64(len(m.Sum.DataPoints))
+			return
+		}
+		metricType := ts.MetricTypeGauge
+		if m.Sum.IsMonotonic {
+			metricType = ts.MetricTypeCounter
+		}
+		b.addNumberDataPoints(m.Name, m.Sum.DataPoints, metricType)
+	case m.Histogram != nil:
+		if m.Histogram.AggregationTemporality == aggregationTemporalityDelta {
+			b.rejected += int64(len(m.Histogram.DataPoints))
+			return
+		}
+		for _, dp := range m.Histogram.DataPoints {
+			var (
+				cumulative int64
+				numBounds  = len(dp.ExplicitBounds)
+			)
+			for i, count := range dp.BucketCounts {
+				cumulative += int64(count)
+				bound := infBound
+				if i < numBounds {
+					bound = formatBound(dp.ExplicitBounds[i])
+				}
+				b.addBucket(m.Name, dp.Attributes, dp.TimeUnixNano, bound, cumulative)
+			}
+			b.addSumAndCount(m.Name, dp.Attributes, dp.TimeUnixNano, dp.Sum, dp.Count)
+		}
+	case m.ExponentialHistogram != nil:
+		if m.ExponentialHistogram.AggregationTemporality == aggregationTemporalityDelta {
+			b.rejected += int64(len(m.ExponentialHistogram.DataPoints))
+			return
+		}
+		for _, dp := range m.ExponentialHistogram.DataPoints {
+			// Negative and zero observations fall below every positive bound.
+			cumulative := int64(dp.ZeroCount)
+			for _, count := range dp.Negative.BucketCounts {
+				cumulative += int64(count)
+			}
+
+			// Bucket index i covers (base^i, base^(i+1)] where
+			// base = 2^(2^-scale).
+			base := math.Exp2(math.Exp2(-float64(dp.Scale)))
+			for i, count := range dp.Positive.BucketCounts {
+				cumulative += int64(count)
+				index := int(dp.Positive.Offset) + i
+				bound := formatBound(math.Pow(base, float64(index+1)))
+				b.addBucket(m.Name, dp.Attributes, dp.TimeUnixNano, bound, cumulative)
+			}
+			b.addBucket(m.Name, dp.Attributes, dp.TimeUnixNano, infBound, int64(dp.Count))
+			b.addSumAndCount(m.Name, dp.Attributes, dp.TimeUnixNano, dp.Sum, dp.Count)
+		}
+	}
+}
+
+func (b *seriesBuilder) addNumberDataPoints(
+	name string,
+	dps []numberDataPoint,
+	metricType ts.MetricType,
+) {
+	for _, dp := range dps {
+		var value float64
+		switch {
+		case dp.AsDouble != nil:
+			value = *dp.AsDouble
+		case dp.AsInt != nil:
+			value = float64(*dp.AsInt)
+		default:
+			// No value recorded for this data point.
+			continue
+		}
+		b.add(name, dp.Attributes, nil, dp.TimeUnixNano, value, metricType)
+	}
+}
+
+func (b *seriesBuilder) addBucket(
+	name string,
+	attrs []keyValue,
+	timeUnixNano int64Value,
+	bound string,
+	cumulative int64,
+) {
+	le := []models.Tag{{Name: []byte(leTagName), Value: []byte(bound)}}
+	b.add(name+bucketSuffix, attrs, le, timeUnixNano,
+		float64(cumulative), ts.MetricTypeCounter)
+}
+
+func (b *seriesBuilder) addSumAndCount(
+	name string,
+	attrs []keyValue,
+	timeUnixNano int64Value,
+	sum *float64,
+	count int64Value,
+) {
+	if sum != nil {
+		b.add(name+sumSuffix, attrs, nil, timeUnixNano, *sum, ts.MetricTypeCounter)
+	}
+	b.add(name+countSuffix, attrs, nil, timeUnixNano, float64(count), ts.MetricTypeCounter)
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sanitize replaces characters not valid in Prometheus metric and label
+// names with underscores.
+func sanitize(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+type seriesIter struct {
+	idx       int
+	series    []series
+	metadatas []ts.Metadata
+	// rejected is the number of data points that were not translated into
+	// series since they have delta temporality, writing them as if they were
+	// cumulative values would produce wrong results.
+	rejected int64
+}
+
+func newSeriesIter(req exportMetricsRequest, tagOpts models.TagOptions) *seriesIter {
+	b := &seriesBuilder{tagOpts: tagOpts}
+	for _, rm := range req.ResourceMetrics {
+		b.resourceAttrs = rm.Resource.Attributes
+		for _, scopes := range [][]scopeMetrics{
+			rm.ScopeMetrics,
+			rm.InstrumentationLibraryMetrics,
+		} {
+			for _, sm := range scopes {
+				for _, m := range sm.Metrics {
+					b.addMetric(m)
+				}
+			}
+		}
+	}
+
+	return &seriesIter{idx: -1, series: b.series, rejected: b.rejected}
+}
+
+func (i *seriesIter) Next() bool {
+	i.idx++
+	return i.idx < len(i.series)
+}
+
+func (i *seriesIter) Current() ingest.IterValue {
+	if i.idx < 0 || i.idx >= len(i.series) {
+		return defaultValue
+	}
+
+	s := i.series[i.idx]
+	value := ingest.IterValue{
+		Tags:       s.tags,
+		Datapoints: ts.Datapoints{s.datapoint},
+		Attributes: s.attributes,
+		Unit:       xtime.Nanosecond,
+	}
+	if i.idx < len(i.metadatas) {
+		value.Metadata = i.metadatas[i.idx]
+	}
+	return value
+}
+
+func (i *seriesIter) Reset() error {
+	i.idx = -1
+	return nil
+}
+
+func (i *seriesIter) Error() error {
+	return nil
+}
+
+func (i *seriesIter) SetCurrentMetadata(metadata ts.Metadata) {
+	if len(i.metadatas) == 0 {
+		i.metadatas = make([]ts.Metadata, len(i.series))
+	}
+	if i.idx < 0 || i.idx >= len(i.metadatas) {
+		return
+	}
+	i.metadatas[i.idx] = metadata
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRequest = `{
+  "resourceMetrics": [{
+    "resource": {
+      "attributes": [{"key": "service.name", "value": {"stringValue": "api"}}]
+    },
+    "scopeMetrics": [{
+      "metrics": [
+        {
+          "name": "queue.depth",
+          "gauge": {"dataPoints": [
+            {"timeUnixNano": "1000000000", "asInt": "7"}
+          ]}
+        },
+        {
+          "name": "requests",
+          "sum": {"isMonotonic": true, "dataPoints": [
+            {"timeUnixNano": "1000000000", "asDouble": 3.5,
+             "attributes": [{"key": "code", "value": {"intValue": "200"}}]}
+          ]}
+        },
+        {
+          "name": "latency",
+          "histogram": {"dataPoints": [
+            {"timeUnixNano": "1000000000", "count": "3", "sum": 6,
+             "bucketCounts": ["1", "2"], "explicitBounds": [1.5]}
+          ]}
+        },
+        {
+          "name": "size",
+          "exponentialHistogram": {"dataPoints": [
+            {"timeUnixNano": "1000000000", "count": "4", "sum": 10, "scale": 0,
+             "zeroCount": "1", "positive": {"offset": 1, "bucketCounts": ["2", "1"]}}
+          ]}
+        }
+      ]
+    }]
+  }]
+}`
+
+func newTestHandler(t *testing.T, ctrl *gomock.Controller) (*writeHandler, *[]string) {
+	var written []string
+	writer := ingest.NewMockDownsamplerAndWriter(ctrl)
+	writer.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			for iter.Next() {
+				value := iter.Current()
+				require.Equal(t, 1, len(value.Datapoints))
+				written = append(written, value.Tags.String()+" "+
+					formatBound(value.Datapoints[0].Value))
+			}
+			return nil
+		}).AnyTimes()
+
+	opts := options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions()).
+		SetDownsamplerAndWriter(writer)
+	return newWriteHandler(opts), &written
+}
+
+func TestOTLPWriteHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, written := newTestHandler(t, ctrl)
+
+	req := httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL,
+		strings.NewReader(testRequest))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	sort.Strings(*written)
+	assert.Equal(t, []string{
+		"__name__: latency_bucket, le: +Inf, service_name: api 3",
+		"__name__: latency_bucket, le: 1.5, service_name: api 1",
+		"__name__: latency_count, service_name: api 3",
+		"__name__: latency_sum, service_name: api 6",
+		"__name__: queue_depth, service_name: api 7",
+		"__name__: requests, code: 200, service_name: api 3.5",
+		"__name__: size_bucket, le: +Inf, service_name: api 4",
+		"__name__: size_bucket, le: 4, service_name: api 3",
+		"__name__: size_bucket, le: 8, service_name: api 4",
+		"__name__: size_count, service_name: api 4",
+		"__name__: size_sum, service_name: api 10",
+	}, *written)
+}
+
+func TestOTLPWriteHandlerGzip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, written := newTestHandler(t, ctrl)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(testRequest))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req := httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL, &buf)
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	req.Header.Set("Content-Encoding", "gzip")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, 11, len(*written))
+}
+
+func TestOTLPWriteHandlerBadRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _ := newTestHandler(t, ctrl)
+
+	req := httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL,
+		strings.NewReader("not json"))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL,
+		strings.NewReader("not protobuf"))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeProtobuf)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestOTLPWriteHandlerProtobuf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, written := newTestHandler(t, ctrl)
+
+	req := httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL,
+		bytes.NewReader(testProtobufRequest()))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeProtobuf)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, xhttp.ContentTypeProtobuf, recorder.Header().Get(xhttp.HeaderContentType))
+	assert.Equal(t, 0, recorder.Body.Len())
+
+	sort.Strings(*written)
+	assert.Equal(t, []string{
+		"__name__: latency_bucket, le: +Inf, service_name: api 3",
+		"__name__: latency_bucket, le: 1.5, service_name: api 1",
+		"__name__: latency_count, service_name: api 3",
+		"__name__: latency_sum, service_name: api 6",
+		"__name__: queue_depth, service_name: api 7",
+		"__name__: requests, code: 200, service_name: api 3.5",
+	}, *written)
+}
+
+func TestOTLPWriteHandlerRejectsDeltaTemporality(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, written := newTestHandler(t, ctrl)
+
+	req := httptest.NewRequest(OTLPWriteHTTPMethod, OTLPWriteURL,
+		strings.NewReader(`{
+  "resourceMetrics": [{
+    "scopeMetrics": [{
+      "metrics": [
+        {
+          "name": "requests",
+          "sum": {"isMonotonic": true, "aggregationTemporality": 1, "dataPoints": [
+            {"timeUnixNano": "1000000000", "asInt": "1"},
+            {"timeUnixNano": "2000000000", "asInt": "2"}
+          ]}
+        },
+        {
+          "name": "latency",
+          "histogram": {"aggregationTemporality": "AGGREGATION_TEMPORALITY_DELTA", "dataPoints": [
+            {"timeUnixNano": "1000000000", "count": "1", "bucketCounts": ["1"]}
+          ]}
+        },
+        {
+          "name": "errors",
+          "sum": {"isMonotonic": true, "aggregationTemporality": 2, "dataPoints": [
+            {"timeUnixNano": "1000000000", "asInt": "3"}
+          ]}
+        }
+      ]
+    }]
+  }]
+}`))
+	req.Header.Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// Only the cumulative sum is written, the delta data points are
+	// reported as rejected.
+	assert.Equal(t, []string{"__name__: errors 3"}, *written)
+
+	var resp exportMetricsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.NotNil(t, resp.PartialSuccess)
+	assert.Equal(t, int64(3), resp.PartialSuccess.RejectedDataPoints)
+	assert.Equal(t, errDeltaTemporality.Error(), resp.PartialSuccess.ErrorMessage)
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "http_server_duration", sanitize("http.server.duration"))
+	assert.Equal(t, "_xx:y_z", sanitize("1xx:y-z"))
+}
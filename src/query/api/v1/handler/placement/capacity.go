@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// CapacityHTTPMethod is the HTTP method used with this resource.
+	CapacityHTTPMethod = http.MethodGet
+
+	// CapacityPathName is the capacity part of the API path.
+	CapacityPathName = "capacity"
+
+	defaultCapacityHeadroom = 0.2
+)
+
+// M3DBCapacityURL is the url for the capacity planner handler of the M3DB
+// service.
+var M3DBCapacityURL = path.Join(handler.RoutePrefixV1, ServicesPathName,
+	handleroptions.M3DBServiceName, CapacityPathName)
+
+// CapacityHandler is the handler for capacity plans, which combine the
+// placement with the resource usage published by each instance to report
+// the utilization of the instances and how many instances to add to keep
+// the target headroom.
+type CapacityHandler Handler
+
+// NewCapacityHandler returns a new instance of CapacityHandler.
+func NewCapacityHandler(opts HandlerOptions) *CapacityHandler {
+	return &CapacityHandler{HandlerOptions: opts, nowFn: time.Now}
+}
+
+func (h *CapacityHandler) ServeHTTP(
+	svc handleroptions.ServiceNameAndDefaults,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	var (
+		ctx    = r.Context()
+		logger = logging.WithContext(ctx, h.instrumentOptions)
+	)
+
+	planOpts, err := h.parsePlanOptions(r)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	getHandler := (*GetHandler)(h)
+	p, badRequest, err := getHandler.Get(svc, nil)
+	if err != nil && badRequest {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		xhttp.Error(w, err, http.StatusNotFound)
+		return
+	}
+	if p == nil {
+		xhttp.Error(w, errPlacementDoesNotExist, http.StatusNotFound)
+		return
+	}
+
+	store, err := h.clusterClient.KV()
+	if err != nil {
+		logger.Error("unable to get kv store", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	reports := make(map[string]capacity.NodeReport, p.NumInstances())
+	for _, instance := range p.Instances() {
+		report, ok, err := capacity.GetReport(store, instance.ID())
+		if err != nil {
+			logger.Error("unable to get capacity report",
+				zap.String("instance", instance.ID()), zap.Error(err))
+			xhttp.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		if ok {
+			reports[instance.ID()] = report
+		}
+	}
+
+	plan, err := capacity.NewPlan(p, reports, planOpts)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusNotFound)
+		return
+	}
+
+	xhttp.WriteJSONResponse(w, plan, logger)
+}
+
+func (h *CapacityHandler) parsePlanOptions(r *http.Request) (capacity.PlanOptions, error) {
+	opts := capacity.PlanOptions{
+		Headroom: defaultCapacityHeadroom,
+		Now:      h.nowFn(),
+	}
+	if v := r.FormValue("headroom"); v != "" {
+		headroom, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, xerrors.NewInvalidParamsError(
+				fmt.Errorf("invalid headroom: %v", err))
+		}
+		opts.Headroom = headroom
+	}
+	if v := r.FormValue("maxSeriesPerInstance"); v != "" {
+		maxSeries, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return opts, xerrors.NewInvalidParamsError(
+				fmt.Errorf("invalid maxSeriesPerInstance: %v", err))
+		}
+		opts.MaxSeriesPerInstance = maxSeries
+	}
+	if v := r.FormValue("maxWritesPerSecPerInstance"); v != "" {
+		maxWrites, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, xerrors.NewInvalidParamsError(
+				fmt.Errorf("invalid maxWritesPerSecPerInstance: %v", err))
+		}
+		opts.MaxWritesPerSecPerInstance = maxWrites
+	}
+	if v := r.FormValue("staleAfter"); v != "" {
+		staleAfter, err := time.ParseDuration(v)
+		if err != nil {
+			return opts, xerrors.NewInvalidParamsError(
+				fmt.Errorf("invalid staleAfter: %v", err))
+		}
+		opts.StaleAfter = staleAfter
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, xerrors.NewInvalidParamsError(err)
+	}
+	return opts, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		now       = time.Now()
+		instances = make([]placement.Instance, 0, 3)
+		store     = mem.NewStore()
+	)
+	for _, id := range []string{"host1", "host2", "host3"} {
+		instances = append(instances, placement.NewEmptyInstance(id, id, "zone", id+":9000", 1).
+			SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(0).SetState(shard.Available),
+			})))
+		require.NoError(t, capacity.PublishReport(store, capacity.NodeReport{
+			HostID:         id,
+			ReportedAt:     now,
+			DiskTotalBytes: 1000,
+			DiskFreeBytes:  500,
+			Shards:         []capacity.ShardReport{{Shard: 0, Series: 150}},
+		}))
+	}
+	p := placement.NewPlacement().
+		SetInstances(instances).
+		SetReplicaFactor(3).
+		SetShards([]uint32{0}).
+		SetIsSharded(true)
+
+	mockClient, mockPlacementService := SetupPlacementTest(t, ctrl)
+	mockClient.EXPECT().KV().Return(store, nil)
+	mockPlacementService.EXPECT().Placement().Return(p, nil)
+
+	handlerOpts, err := NewHandlerOptions(
+		mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewCapacityHandler(handlerOpts)
+	handler.nowFn = func() time.Time { return now }
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(CapacityHTTPMethod,
+		M3DBCapacityURL+"?headroom=0.25&maxSeriesPerInstance=100", nil)
+	handler.ServeHTTP(svc, w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var plan capacity.Plan
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &plan))
+	require.Len(t, plan.Instances, 3)
+	require.Equal(t, capacity.ResourceSeries, plan.LimitingResource)
+	require.Equal(t, 6, plan.Resources[capacity.ResourceSeries].RequiredInstances)
+	require.Equal(t, 3, plan.RecommendedAdditionalInstances)
+	require.Equal(t, 0, plan.Resources[capacity.ResourceDisk].AdditionalInstances)
+}
+
+func TestCapacityHandlerInvalidParams(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient, _ := SetupPlacementTest(t, ctrl)
+	handlerOpts, err := NewHandlerOptions(
+		mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewCapacityHandler(handlerOpts)
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	for _, query := range []string{"headroom=1.5", "headroom=x", "staleAfter=soon"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(CapacityHTTPMethod, M3DBCapacityURL+"?"+query, nil)
+		handler.ServeHTTP(svc, w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
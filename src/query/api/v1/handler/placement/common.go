@@ -34,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/placement/algo"
+	"github.com/m3db/m3/src/cluster/placement/rollout"
 	"github.com/m3db/m3/src/cluster/services"
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
@@ -80,6 +81,7 @@ type HandlerOptions struct {
 
 	m3AggServiceOptions *handleroptions.M3AggServiceOptions
 	instrumentOptions   instrument.Options
+	writeErrorCounter   *rollout.ErrorCounter
 }
 
 // NewHandlerOptions is the constructor function for HandlerOptions.
@@ -100,6 +102,13 @@ func NewHandlerOptions(
 	}, nil
 }
 
+// SetWriteErrorCounter returns the options with the counter of write
+// requests and their errors, which placement rollouts can be gated on.
+func (o HandlerOptions) SetWriteErrorCounter(value *rollout.ErrorCounter) HandlerOptions {
+	o.writeErrorCounter = value
+	return o
+}
+
 // Handler represents a generic handler for placement endpoints.
 type Handler struct {
 	HandlerOptions
@@ -298,6 +307,27 @@ func RegisterRoutes(
 	r.HandleFunc(M3DBSetURL, setFn).Methods(SetHTTPMethod)
 	r.HandleFunc(M3AggSetURL, setFn).Methods(SetHTTPMethod)
 	r.HandleFunc(M3CoordinatorSetURL, setFn).Methods(SetHTTPMethod)
+
+	// Capacity
+	var (
+		capacityHandler = NewCapacityHandler(opts)
+		capacityFn      = applyMiddleware(capacityHandler.ServeHTTP, defaults, opts.instrumentOptions)
+	)
+	r.HandleFunc(M3DBCapacityURL, capacityFn).Methods(CapacityHTTPMethod)
+
+	// Rebalance
+	var (
+		rebalanceHandler = NewRebalanceHandler(opts)
+		rebalanceFn      = applyMiddleware(rebalanceHandler.ServeHTTP, defaults, opts.instrumentOptions)
+	)
+	r.HandleFunc(M3DBRebalanceURL, rebalanceFn).Methods(RebalanceHTTPMethod)
+
+	// Rollout
+	var (
+		rolloutHandler = NewRolloutHandler(opts)
+		rolloutFn      = applyMiddleware(rolloutHandler.ServeHTTP, defaults, opts.instrumentOptions)
+	)
+	r.HandleFunc(M3DBRolloutURL, rolloutFn).Methods(RolloutHTTPMethods...)
 }
 
 func newPlacementCutoverNanosFn(
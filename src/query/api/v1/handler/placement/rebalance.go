@@ -0,0 +1,202 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RebalanceHTTPMethod is the HTTP method used with this resource.
+	RebalanceHTTPMethod = http.MethodPost
+
+	rebalancePathName = "rebalance"
+)
+
+// M3DBRebalanceURL is the url for the m3db placement rebalance handler
+// (method POST).
+var M3DBRebalanceURL = path.Join(handler.RoutePrefixV1,
+	M3DBServicePlacementPathName, rebalancePathName)
+
+// RebalanceRequest is the request to rebalance a placement, see
+// capacity.RebalanceOptions for the options. The moves are only applied if
+// the request is confirmed, otherwise the rebalance is a dry run.
+type RebalanceRequest struct {
+	Resource      string  `json:"resource"`
+	SkewThreshold float64 `json:"skewThreshold"`
+	MaxMoves      int     `json:"maxMoves"`
+	DiskHeadroom  float64 `json:"diskHeadroom"`
+	StaleAfter    string  `json:"staleAfter"`
+	Confirm       bool    `json:"confirm"`
+}
+
+// RebalanceResponse is the response of a rebalance, the version is the
+// version the placement has, or would have for a dry run, with the moves
+// applied.
+type RebalanceResponse struct {
+	capacity.Rebalance
+	Version int  `json:"version"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// RebalanceHandler is the handler for placement rebalances, which move the
+// shards of the most loaded instances, by the resource usage published by
+// each instance, to less loaded instances.
+type RebalanceHandler Handler
+
+// NewRebalanceHandler returns a new instance of RebalanceHandler.
+func NewRebalanceHandler(opts HandlerOptions) *RebalanceHandler {
+	return &RebalanceHandler{HandlerOptions: opts, nowFn: time.Now}
+}
+
+func (h *RebalanceHandler) ServeHTTP(
+	svc handleroptions.ServiceNameAndDefaults,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx, h.instrumentOptions)
+
+	req, rebalanceOpts, pErr := h.parseRequest(r)
+	if pErr != nil {
+		xhttp.Error(w, pErr.Inner(), pErr.Code())
+		return
+	}
+
+	serviceOpts := handleroptions.NewServiceOptions(svc,
+		r.Header, h.m3AggServiceOptions)
+	service, err := Service(h.clusterClient, serviceOpts, h.nowFn(), nil)
+	if err != nil {
+		logger.Error("unable to create placement service", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	curPlacement, err := service.Placement()
+	if err == kv.ErrNotFound {
+		xhttp.Error(w, errPlacementDoesNotExist, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("unable to get current placement", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	store, err := h.clusterClient.KV()
+	if err != nil {
+		logger.Error("unable to get kv store", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	reports := make(map[string]capacity.NodeReport, curPlacement.NumInstances())
+	for _, instance := range curPlacement.Instances() {
+		report, ok, err := capacity.GetReport(store, instance.ID())
+		if err != nil {
+			logger.Error("unable to get capacity report",
+				zap.String("instance", instance.ID()), zap.Error(err))
+			xhttp.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		if ok {
+			reports[instance.ID()] = report
+		}
+	}
+
+	rebalance, err := capacity.NewRebalance(curPlacement, reports, rebalanceOpts)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	resp := RebalanceResponse{
+		Rebalance: rebalance,
+		Version:   curPlacement.Version(),
+		DryRun:    !req.Confirm,
+	}
+	if len(rebalance.Moves) > 0 {
+		newPlacement, err := capacity.ApplyRebalance(curPlacement, rebalance.Moves)
+		if err != nil {
+			logger.Error("unable to apply rebalance", zap.Error(err))
+			xhttp.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		resp.Version = curPlacement.Version() + 1
+		if req.Confirm {
+			logger.Info("rebalancing placement",
+				zap.Int("moves", len(rebalance.Moves)),
+				zap.Float64("skewBefore", rebalance.SkewBefore),
+				zap.Float64("skewAfter", rebalance.SkewAfter))
+			// Ensure the placement we're updating is still the one the moves
+			// were computed from.
+			updated, err := service.CheckAndSet(newPlacement, curPlacement.Version())
+			if err != nil {
+				logger.Error("unable to update placement", zap.Error(err))
+				xhttp.Error(w, err, http.StatusInternalServerError)
+				return
+			}
+			resp.Version = updated.Version()
+		}
+	}
+
+	xhttp.WriteJSONResponse(w, resp, logger)
+}
+
+func (h *RebalanceHandler) parseRequest(
+	r *http.Request,
+) (RebalanceRequest, capacity.RebalanceOptions, *xhttp.ParseError) {
+	defer r.Body.Close()
+
+	var req RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, capacity.RebalanceOptions{},
+			xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	opts := capacity.RebalanceOptions{
+		Resource:      req.Resource,
+		SkewThreshold: req.SkewThreshold,
+		MaxMoves:      req.MaxMoves,
+		DiskHeadroom:  req.DiskHeadroom,
+		Now:           h.nowFn(),
+	}
+	if req.StaleAfter != "" {
+		staleAfter, err := time.ParseDuration(req.StaleAfter)
+		if err != nil {
+			return req, opts, xhttp.NewParseError(err, http.StatusBadRequest)
+		}
+		opts.StaleAfter = staleAfter
+	}
+	return req, opts, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalanceHandler(t *testing.T) {
+	var (
+		now       = time.Now()
+		store     = mem.NewStore()
+		instances []placement.Instance
+	)
+	for id, shardIDs := range map[string][]uint32{
+		"a": {0, 1, 2},
+		"b": {0, 1},
+		"c": {3},
+		"d": {2, 3},
+	} {
+		group := "g1"
+		if id == "b" || id == "d" {
+			group = "g2"
+		}
+		shards := make([]shard.Shard, 0, len(shardIDs))
+		reports := make([]capacity.ShardReport, 0, len(shardIDs))
+		for _, s := range shardIDs {
+			shards = append(shards, shard.NewShard(s).SetState(shard.Available))
+			reports = append(reports, capacity.ShardReport{Shard: s, Series: 100})
+		}
+		instances = append(instances, placement.NewEmptyInstance(id, group, "zone", id+":9000", 1).
+			SetShards(shard.NewShards(shards)))
+		require.NoError(t, capacity.PublishReport(store, capacity.NodeReport{
+			HostID:     id,
+			ReportedAt: now,
+			Shards:     reports,
+		}))
+	}
+	initPlacement := placement.NewPlacement().
+		SetInstances(instances).
+		SetShards([]uint32{0, 1, 2, 3}).
+		SetReplicaFactor(2).
+		SetIsSharded(true)
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	for _, confirm := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+
+		mockClient := setupPlacementTest(t, ctrl, initPlacement)
+		mockClient.EXPECT().KV().Return(store, nil)
+		handlerOpts, err := NewHandlerOptions(
+			mockClient, config.Configuration{}, nil, instrument.NewOptions())
+		require.NoError(t, err)
+		handler := NewRebalanceHandler(handlerOpts)
+		handler.nowFn = func() time.Time { return now }
+
+		body := `{"resource":"series"}`
+		if confirm {
+			body = `{"resource":"series","confirm":true}`
+		}
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(RebalanceHTTPMethod, M3DBRebalanceURL,
+			strings.NewReader(body))
+		handler.ServeHTTP(svc, w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp RebalanceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Equal(t, !confirm, resp.DryRun)
+		require.Equal(t, []capacity.ShardMove{
+			{Shard: 0, From: "a", To: "c", Load: 100},
+		}, resp.Moves)
+		require.Equal(t, 2, resp.Version)
+
+		ctrl.Finish()
+	}
+}
+
+func TestRebalanceHandlerInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient, _ := SetupPlacementTest(t, ctrl)
+	handlerOpts, err := NewHandlerOptions(
+		mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewRebalanceHandler(handlerOpts)
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	for _, body := range []string{`{`, `{"staleAfter":"soon"}`} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(RebalanceHTTPMethod, M3DBRebalanceURL,
+			strings.NewReader(body))
+		handler.ServeHTTP(svc, w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/placement/rollout"
+	"github.com/m3db/m3/src/dbnode/capacity"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"go.uber.org/zap"
+)
+
+const rolloutPathName = "rollout"
+
+var (
+	// M3DBRolloutURL is the url for the m3db placement rollout handler, a
+	// POST starts a rollout and a GET returns the status of the last one.
+	M3DBRolloutURL = path.Join(handler.RoutePrefixV1,
+		M3DBServicePlacementPathName, rolloutPathName)
+
+	// RolloutHTTPMethods are the HTTP methods used with this resource.
+	RolloutHTTPMethods = []string{http.MethodPost, http.MethodGet}
+
+	errRolloutInProgress     = errors.New("a placement rollout is already in progress")
+	errNoRollout             = errors.New("no placement rollout has been started")
+	errRolloutPlacementUnset = errors.New("rollout placement not set")
+)
+
+// RolloutRequest is the request to roll out a placement. The placement is
+// applied if the current placement is at the expected version, and its
+// in flight shard moves are rolled back if its shards do not all become
+// available within the window or a health gate fails.
+type RolloutRequest struct {
+	// Placement is the placement to roll out, as placement protobuf JSON.
+	Placement json.RawMessage `json:"placement"`
+	// Version is the expected version of the current placement.
+	Version int `json:"version"`
+	// Window, MaxStall and CheckInterval are durations, see rollout.Options.
+	Window        string `json:"window"`
+	MaxStall      string `json:"maxStall"`
+	CheckInterval string `json:"checkInterval"`
+	// MaxWriteErrorRate gates the rollout on the rate of server errors of
+	// the writes received by this coordinator, once at least the min write
+	// requests have been received between checks.
+	MaxWriteErrorRate float64 `json:"maxWriteErrorRate"`
+	MinWriteRequests  int64   `json:"minWriteRequests"`
+	// MaxReportAge gates the rollout on every instance having published a
+	// capacity report within the age, as a liveness check.
+	MaxReportAge string `json:"maxReportAge"`
+}
+
+// RolloutHandler is the handler for placement rollouts, it monitors one
+// rollout at a time. The rollout is monitored by the coordinator that
+// started it, if the coordinator stops the rollout is no longer monitored.
+type RolloutHandler struct {
+	Handler
+
+	sync.Mutex
+	current *rollout.Rollout
+}
+
+// NewRolloutHandler returns a new instance of RolloutHandler.
+func NewRolloutHandler(opts HandlerOptions) *RolloutHandler {
+	return &RolloutHandler{Handler: Handler{HandlerOptions: opts, nowFn: time.Now}}
+}
+
+func (h *RolloutHandler) ServeHTTP(
+	svc handleroptions.ServiceNameAndDefaults,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx, h.instrumentOptions)
+
+	if r.Method == http.MethodGet {
+		h.Lock()
+		current := h.current
+		h.Unlock()
+		if current == nil {
+			xhttp.Error(w, errNoRollout, http.StatusNotFound)
+			return
+		}
+		xhttp.WriteJSONResponse(w, current.Status(), logger)
+		return
+	}
+
+	req, next, pErr := h.parseRequest(r)
+	if pErr != nil {
+		xhttp.Error(w, pErr.Inner(), pErr.Code())
+		return
+	}
+
+	serviceOpts := handleroptions.NewServiceOptions(svc,
+		r.Header, h.m3AggServiceOptions)
+	service, err := Service(h.clusterClient, serviceOpts, h.nowFn(), nil)
+	if err != nil {
+		logger.Error("unable to create placement service", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	opts, err := h.rolloutOptions(req)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	if h.current != nil && !h.current.Status().Done() {
+		xhttp.Error(w, errRolloutInProgress, http.StatusConflict)
+		return
+	}
+
+	started, err := rollout.Start(service, next, req.Version, opts)
+	if err == kv.ErrNotFound {
+		xhttp.Error(w, errPlacementDoesNotExist, http.StatusNotFound)
+		return
+	}
+	if err == kv.ErrVersionMismatch {
+		xhttp.Error(w, err, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.Error("unable to start placement rollout", zap.Error(err))
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	h.current = started
+
+	status := started.Status()
+	logger.Info("started placement rollout",
+		zap.Int("previousVersion", status.PreviousVersion),
+		zap.Int("version", status.Version),
+		zap.Int("initializingShards", status.InitializingShards))
+	xhttp.WriteJSONResponse(w, status, logger)
+}
+
+func (h *RolloutHandler) rolloutOptions(req RolloutRequest) (rollout.Options, error) {
+	opts := rollout.NewOptions().
+		SetInstrumentOptions(h.instrumentOptions)
+	if req.Window != "" {
+		window, err := time.ParseDuration(req.Window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window: %v", err)
+		}
+		opts = opts.SetWindow(window)
+	}
+	if req.MaxStall != "" {
+		maxStall, err := time.ParseDuration(req.MaxStall)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxStall: %v", err)
+		}
+		opts = opts.SetMaxStall(maxStall)
+	}
+	if req.CheckInterval != "" {
+		checkInterval, err := time.ParseDuration(req.CheckInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkInterval: %v", err)
+		}
+		opts = opts.SetCheckInterval(checkInterval)
+	}
+
+	var gates []rollout.Gate
+	if req.MaxWriteErrorRate > 0 && h.writeErrorCounter != nil {
+		gates = append(gates, rollout.NewErrorRateGate(h.writeErrorCounter,
+			req.MaxWriteErrorRate, req.MinWriteRequests))
+	}
+	if req.MaxReportAge != "" {
+		maxAge, err := time.ParseDuration(req.MaxReportAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxReportAge: %v", err)
+		}
+		gates = append(gates, rollout.NewGate("capacity-reports",
+			h.reportsGate(maxAge)))
+	}
+	opts = opts.SetGates(gates)
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// reportsGate returns a gate that fails if an instance of the placement has
+// not published a capacity report within the max age.
+func (h *RolloutHandler) reportsGate(maxAge time.Duration) func(placement.Placement) error {
+	return func(p placement.Placement) error {
+		store, err := h.clusterClient.KV()
+		if err != nil {
+			return err
+		}
+		now := h.nowFn()
+		for _, instance := range p.Instances() {
+			report, ok, err := capacity.GetReport(store, instance.ID())
+			if err != nil {
+				return err
+			}
+			if !ok || now.Sub(report.ReportedAt) > maxAge {
+				return fmt.Errorf("instance %s has not reported within %v",
+					instance.ID(), maxAge)
+			}
+		}
+		return nil
+	}
+}
+
+func (h *RolloutHandler) parseRequest(
+	r *http.Request,
+) (RolloutRequest, placement.Placement, *xhttp.ParseError) {
+	defer r.Body.Close()
+
+	var req RolloutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+	if len(req.Placement) == 0 {
+		return req, nil, xhttp.NewParseError(errRolloutPlacementUnset,
+			http.StatusBadRequest)
+	}
+
+	var proto placementpb.Placement
+	if err := jsonpb.Unmarshal(bytes.NewReader(req.Placement), &proto); err != nil {
+		return req, nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+	next, err := placement.NewPlacementFromProto(&proto)
+	if err != nil {
+		return req, nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+	return req, next, nil
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package placement
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRolloutHandlerNoRollout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient, _ := SetupPlacementTest(t, ctrl)
+	handlerOpts, err := NewHandlerOptions(
+		mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewRolloutHandler(handlerOpts)
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, M3DBRolloutURL, nil)
+	handler.ServeHTTP(svc, w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRolloutHandlerInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient, _ := SetupPlacementTest(t, ctrl)
+	handlerOpts, err := NewHandlerOptions(
+		mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewRolloutHandler(handlerOpts)
+
+	svc := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+	for _, body := range []string{
+		`{`,
+		`{"version":1}`,
+		`{"placement":{"instances":"bad"},"version":1}`,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, M3DBRolloutURL,
+			strings.NewReader(body))
+		handler.ServeHTTP(svc, w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
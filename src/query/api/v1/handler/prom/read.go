@@ -32,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/query/api/v1/options"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/storage/prometheus"
+	"github.com/m3db/m3/src/query/util/logging"
 
 	"github.com/prometheus/prometheus/promql"
 	promstorage "github.com/prometheus/prometheus/storage"
@@ -118,6 +119,7 @@ func (h *readHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordResultStats(ctx, res.Value)
 	handleroptions.AddWarningHeaders(w, resultMetadata)
 
 	respond(w, &queryData{
@@ -125,3 +127,20 @@ func (h *readHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ResultType: res.Value.Type(),
 	}, res.Warnings)
 }
+
+// recordResultStats reports the series and datapoints of a query result in
+// the access log of the request.
+func recordResultStats(ctx context.Context, value promql.Value) {
+	switch v := value.(type) {
+	case promql.Matrix:
+		var datapoints int
+		for _, series := range v {
+			datapoints += len(series.Points)
+		}
+		logging.RecordRequestStats(ctx, len(v), datapoints)
+	case promql.Vector:
+		logging.RecordRequestStats(ctx, len(v), len(v))
+	case promql.Scalar:
+		logging.RecordRequestStats(ctx, 1, 1)
+	}
+}
@@ -123,6 +123,7 @@ func (h *readInstantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	recordResultStats(ctx, res.Value)
 	handleroptions.AddWarningHeaders(w, resultMetadata)
 
 	respond(w, &queryData{
@@ -29,9 +29,11 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/functions/utils"
 	"github.com/m3db/m3/src/query/models"
 	xpromql "github.com/m3db/m3/src/query/parser/promql"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
 	"github.com/m3db/m3/src/query/storage/m3/consolidators"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util"
@@ -52,6 +54,10 @@ const (
 
 var (
 	roleName = []byte("role")
+
+	// ExemplarTraceIDLabel is the exemplar label under which datapoint
+	// annotations are returned.
+	ExemplarTraceIDLabel = "trace_id"
 )
 
 // TimeoutOpts stores options related to various timeout configurations.
@@ -230,6 +236,33 @@ func parseTimeWithDefault(
 	return defaultTime, nil
 }
 
+// ParseTimeRange parses the optional start and end params from the request,
+// defaulting to a range spanning all time up until the given default end.
+func ParseTimeRange(
+	r *http.Request,
+	defaultEnd time.Time,
+) (time.Time, time.Time, *xhttp.ParseError) {
+	start, err := parseTimeWithDefault(r, "start", time.Time{})
+	if err != nil {
+		return time.Time{}, time.Time{},
+			xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	end, err := parseTimeWithDefault(r, "end", defaultEnd)
+	if err != nil {
+		return time.Time{}, time.Time{},
+			xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	if start.After(end) {
+		return time.Time{}, time.Time{}, xhttp.NewParseError(
+			fmt.Errorf("start %v must not be after end %v", start, end),
+			http.StatusBadRequest)
+	}
+
+	return start, end, nil
+}
+
 // ParseSeriesMatchQuery parses all params from the GET request.
 func ParseSeriesMatchQuery(
 	r *http.Request,
@@ -274,6 +307,46 @@ func ParseSeriesMatchQuery(
 	return queries, nil
 }
 
+// ParseExemplarsQuery parses the series selector in the query parameter of
+// an exemplars request into a fetch query.
+func ParseExemplarsQuery(
+	r *http.Request,
+	tagOptions models.TagOptions,
+) (*storage.FetchQuery, *xhttp.ParseError) {
+	r.ParseForm()
+	query := r.Form.Get(queryParam)
+	if query == "" {
+		return nil, xhttp.NewParseError(errors.ErrNoQueryFound, http.StatusBadRequest)
+	}
+
+	start, err := parseTimeWithDefault(r, "start", time.Time{})
+	if err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	end, err := parseTimeWithDefault(r, "end", time.Now())
+	if err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	promMatchers, err := promql.ParseMetricSelector(query)
+	if err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	matchers, err := xpromql.LabelMatchersToModelMatcher(promMatchers, tagOptions)
+	if err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	return &storage.FetchQuery{
+		Raw:         fmt.Sprintf("%s=%s", queryParam, query),
+		TagMatchers: matchers,
+		Start:       start,
+		End:         end,
+	}, nil
+}
+
 func renderNameOnlyTagCompletionResultsJSON(
 	w io.Writer,
 	results []consolidators.CompletedTag,
@@ -449,6 +522,66 @@ func RenderSeriesMatchResultsJSON(
 	return jw.Close()
 }
 
+// RenderExemplarsResultsJSON renders exemplars in the Prometheus exemplars
+// query response format.
+func RenderExemplarsResultsJSON(
+	w io.Writer,
+	results []exemplar.SeriesExemplars,
+	dropRole bool,
+) error {
+	jw := json.NewWriter(w)
+	jw.BeginObject()
+
+	jw.BeginObjectField("status")
+	jw.WriteString("success")
+
+	jw.BeginObjectField("data")
+	jw.BeginArray()
+
+	for _, series := range results {
+		jw.BeginObject()
+		jw.BeginObjectField("seriesLabels")
+		jw.BeginObject()
+		for _, tag := range series.Tags.Tags {
+			if bytes.Equal(tag.Name, roleName) && dropRole {
+				continue
+			}
+			jw.BeginObjectField(string(tag.Name))
+			jw.WriteString(string(tag.Value))
+		}
+		jw.EndObject()
+
+		jw.BeginObjectField("exemplars")
+		jw.BeginArray()
+		for _, e := range series.Exemplars {
+			jw.BeginObject()
+			jw.BeginObjectField("labels")
+			jw.BeginObject()
+			for _, label := range e.Labels {
+				jw.BeginObjectField(string(label.Name))
+				jw.WriteString(string(label.Value))
+			}
+			jw.EndObject()
+
+			jw.BeginObjectField("value")
+			jw.WriteString(utils.FormatFloat(e.Value))
+
+			// NB: Prometheus returns exemplar timestamps as fractional seconds.
+			jw.BeginObjectField("timestamp")
+			jw.WriteFloat64(float64(e.Timestamp.UnixNano()) / float64(time.Second))
+			jw.EndObject()
+		}
+		jw.EndArray()
+
+		jw.EndObject()
+	}
+
+	jw.EndArray()
+	jw.EndObject()
+
+	return jw.Close()
+}
+
 // FilterSeriesByOptions removes series tags based on options.
 func FilterSeriesByOptions(
 	series []*ts.Series,
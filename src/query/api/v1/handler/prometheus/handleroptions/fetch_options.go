@@ -129,6 +129,33 @@ func ParseRequireExhaustive(req *http.Request, defaultValue bool) (bool, error)
 	return defaultValue, nil
 }
 
+// ParseStitchStoragePolicies parses whether to stitch together results read
+// from namespaces with different storage policies from header or query
+// string, returning the default fanout option if neither is set.
+func ParseStitchStoragePolicies(req *http.Request) (storage.FanoutOption, error) {
+	str := req.Header.Get(StitchStoragePoliciesHeader)
+	if str == "" {
+		str = req.FormValue("stitch")
+	}
+
+	if str == "" {
+		return storage.FanoutDefault, nil
+	}
+
+	v, err := strconv.ParseBool(str)
+	if err != nil {
+		err = fmt.Errorf(
+			"could not parse stitch storage policies: input=%s, err=%v", str, err)
+		return storage.FanoutDefault, err
+	}
+
+	if v {
+		return storage.FanoutForceEnable, nil
+	}
+
+	return storage.FanoutForceDisable, nil
+}
+
 // NewFetchOptions parses an http request into fetch options.
 func (b fetchOptionsBuilder) NewFetchOptions(
 	req *http.Request,
@@ -156,6 +183,13 @@ func (b fetchOptionsBuilder) NewFetchOptions(
 
 	fetchOpts.RequireExhaustive = requireExhaustive
 
+	stitch, err := ParseStitchStoragePolicies(req)
+	if err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+
+	fetchOpts.FanoutOptions.Stitch = stitch
+
 	if str := req.Header.Get(MetricsTypeHeader); str != "" {
 		mt, err := storagemetadata.ParseMetricsType(str)
 		if err != nil {
@@ -393,6 +393,47 @@ func TestFetchOptionsWithHeader(t *testing.T) {
 	require.Equal(t, ex, opts.RestrictQueryOptions)
 }
 
+func TestParseStitchStoragePolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		query    string
+		expected storage.FanoutOption
+		err      bool
+	}{
+		{name: "default", expected: storage.FanoutDefault},
+		{name: "header enabled", header: "true", expected: storage.FanoutForceEnable},
+		{name: "header disabled", header: "false", expected: storage.FanoutForceDisable},
+		{name: "query enabled", query: "stitch=true", expected: storage.FanoutForceEnable},
+		{
+			name:     "header overrides query",
+			header:   "false",
+			query:    "stitch=true",
+			expected: storage.FanoutForceDisable,
+		},
+		{name: "bad value", header: "foo", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/?"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Add(StitchStoragePoliciesHeader, tt.header)
+			}
+
+			builder := NewFetchOptionsBuilder(FetchOptionsBuilderOptions{})
+			opts, err := builder.NewFetchOptions(req)
+			if tt.err {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			assert.Equal(t, tt.expected, opts.FanoutOptions.Stitch)
+		})
+	}
+}
+
 func stripSpace(str string) string {
 	return regexp.MustCompile(`\s+`).ReplaceAllString(str, "")
 }
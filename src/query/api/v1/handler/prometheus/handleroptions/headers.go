@@ -90,6 +90,33 @@ const (
 	// ensure M3 returns an error if the results set is not exhaustive.
 	LimitRequireExhaustiveHeader = M3HeaderPrefix + "Limit-Require-Exhaustive"
 
+	// TenantHeader is the M3 tenant header that identifies the tenant issuing
+	// a query or write, used to apply per-tenant query limits and ingest
+	// quotas.
+	TenantHeader = M3HeaderPrefix + "Tenant"
+
+	// QueryPriorityHeader is the M3 query priority header, queries with the
+	// low priority are the first to be shed when the coordinator is low on
+	// memory.
+	QueryPriorityHeader = M3HeaderPrefix + "Query-Priority"
+
+	// QueryPriorityLow is the lowest query priority.
+	QueryPriorityLow = "low"
+
+	// QueryIDHeader is the M3 query ID header, set on responses to the ID a
+	// query is listed under by the running queries API while it executes.
+	QueryIDHeader = M3HeaderPrefix + "Query-ID"
+
+	// StitchStoragePoliciesHeader is the M3 stitch storage policies header,
+	// overrides whether results for metrics stored under multiple storage
+	// policies are stitched together by time range.
+	StitchStoragePoliciesHeader = M3HeaderPrefix + "Stitch-Storage-Policies"
+
+	// DecimatedStepHeader is the M3 decimated step header, set on range
+	// query responses to the step of the results if they were decimated to
+	// a coarser step than requested.
+	DecimatedStepHeader = M3HeaderPrefix + "Decimated-Step"
+
 	// UnaggregatedStoragePolicy specifies the unaggregated storage policy.
 	UnaggregatedStoragePolicy = "unaggregated"
 
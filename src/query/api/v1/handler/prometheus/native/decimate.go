@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"math"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/ts"
+)
+
+type decimationFn func(values []float64) float64
+
+type decimator struct {
+	minFactor   int
+	consolidate decimationFn
+}
+
+func newDecimator(cfg config.DecimationConfiguration) *decimator {
+	return &decimator{
+		minFactor:   cfg.MinFactorOrDefault(),
+		consolidate: decimationFnFor(cfg.Consolidation),
+	}
+}
+
+func decimationFnFor(consolidation config.DecimationConsolidation) decimationFn {
+	switch consolidation {
+	case config.DecimationConsolidationAvg:
+		return func(values []float64) float64 {
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			return sum / float64(len(values))
+		}
+	case config.DecimationConsolidationMin:
+		return func(values []float64) float64 {
+			min := values[0]
+			for _, v := range values[1:] {
+				min = math.Min(min, v)
+			}
+			return min
+		}
+	case config.DecimationConsolidationMax:
+		return func(values []float64) float64 {
+			max := values[0]
+			for _, v := range values[1:] {
+				max = math.Max(max, v)
+			}
+			return max
+		}
+	default:
+		return func(values []float64) float64 {
+			return values[len(values)-1]
+		}
+	}
+}
+
+// factor returns the number of steps to consolidate into each decimated
+// step, or one if the results should not be decimated. The decimated step
+// is the largest multiple of the step that does not exceed the finest
+// resolution of the data read.
+func (d *decimator) factor(meta block.ResultMetadata, step time.Duration) int {
+	if step <= 0 || len(meta.Resolutions) == 0 {
+		return 1
+	}
+
+	var resolution time.Duration
+	for _, r := range meta.Resolutions {
+		// NB: unaggregated data has no known resolution.
+		if r <= 0 {
+			return 1
+		}
+		if res := time.Duration(r); resolution == 0 || res < resolution {
+			resolution = res
+		}
+	}
+
+	factor := int(resolution / step)
+	if factor < d.minFactor {
+		return 1
+	}
+	return factor
+}
+
+// decimate consolidates the values of every factor steps of each series.
+// Decimated steps are aligned to the end of the results, so each decimated
+// step is at the time of the last step it consolidates and the most recent
+// step is kept.
+func (d *decimator) decimate(series []*ts.Series, factor int) []*ts.Series {
+	decimated := make([]*ts.Series, 0, len(series))
+	buf := make([]float64, 0, factor)
+	for _, s := range series {
+		values, ok := s.Values().(ts.FixedResolutionMutableValues)
+		if !ok || values.Len() == 0 {
+			decimated = append(decimated, s)
+			continue
+		}
+
+		var (
+			numSteps  = values.Len()
+			numGroups = (numSteps + factor - 1) / factor
+			// Index of the last step of the first, possibly partial, group.
+			firstEnd = numSteps - 1 - (numGroups-1)*factor
+			result   = ts.NewFixedStepValues(
+				values.Resolution()*time.Duration(factor), numGroups,
+				math.NaN(), values.StartTimeForStep(firstEnd))
+		)
+		for group := 0; group < numGroups; group++ {
+			end := firstEnd + group*factor
+			start := end - factor + 1
+			if start < 0 {
+				start = 0
+			}
+
+			buf = buf[:0]
+			for i := start; i <= end; i++ {
+				if v := values.ValueAt(i); !math.IsNaN(v) {
+					buf = append(buf, v)
+				}
+			}
+			if len(buf) > 0 {
+				result.SetValueAt(group, d.consolidate(buf))
+			}
+		}
+
+		decimated = append(decimated, ts.NewSeries(s.Name(), result, s.Tags))
+	}
+	return decimated
+}
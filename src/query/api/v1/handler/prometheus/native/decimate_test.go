@@ -0,0 +1,161 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDecimationSeries(start time.Time, values ...float64) *ts.Series {
+	vals := ts.NewFixedStepValues(time.Second, len(values), math.NaN(), start)
+	for i, v := range values {
+		vals.SetValueAt(i, v)
+	}
+
+	return ts.NewSeries([]byte("foo"), vals, models.EmptyTags())
+}
+
+func TestDecimatorFactor(t *testing.T) {
+	d := newDecimator(config.DecimationConfiguration{})
+
+	tests := []struct {
+		name        string
+		resolutions []int64
+		step        time.Duration
+		expected    int
+	}{
+		{
+			name:     "no resolutions",
+			step:     time.Second,
+			expected: 1,
+		},
+		{
+			name:        "unaggregated",
+			resolutions: []int64{int64(time.Minute), 0},
+			step:        time.Second,
+			expected:    1,
+		},
+		{
+			name:        "below min factor",
+			resolutions: []int64{int64(time.Minute)},
+			step:        10 * time.Second,
+			expected:    1,
+		},
+		{
+			name:        "finest resolution",
+			resolutions: []int64{int64(time.Hour), int64(time.Minute)},
+			step:        time.Second,
+			expected:    60,
+		},
+		{
+			name:        "uneven step below min factor",
+			resolutions: []int64{int64(time.Minute)},
+			step:        7 * time.Second,
+			expected:    1,
+		},
+		{
+			name:        "multiple of step",
+			resolutions: []int64{int64(time.Minute)},
+			step:        5 * time.Second,
+			expected:    12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := block.NewResultMetadata()
+			meta.Resolutions = tt.resolutions
+			assert.Equal(t, tt.expected, d.factor(meta, tt.step))
+		})
+	}
+}
+
+func TestDecimatorFactorMinFactor(t *testing.T) {
+	d := newDecimator(config.DecimationConfiguration{MinFactor: 4})
+	meta := block.NewResultMetadata()
+	meta.Resolutions = []int64{int64(time.Minute)}
+
+	assert.Equal(t, 6, d.factor(meta, 10*time.Second))
+	assert.Equal(t, 1, d.factor(meta, 20*time.Second))
+}
+
+func TestDecimatorDecimate(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	nan := math.NaN()
+
+	tests := []struct {
+		consolidation config.DecimationConsolidation
+		expected      []float64
+	}{
+		{config.DecimationConsolidationLast, []float64{1, 4, 6}},
+		{config.DecimationConsolidationAvg, []float64{1, 3, 5.5}},
+		{config.DecimationConsolidationMin, []float64{1, 2, 5}},
+		{config.DecimationConsolidationMax, []float64{1, 4, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.consolidation), func(t *testing.T) {
+			d := newDecimator(config.DecimationConfiguration{
+				Consolidation: tt.consolidation,
+			})
+
+			series := newTestDecimationSeries(start, 1, 2, 3, 4, 5, nan, 6)
+			decimated := d.decimate([]*ts.Series{series}, 3)
+			require.Equal(t, 1, len(decimated))
+			assert.Equal(t, []byte("foo"), decimated[0].Name())
+
+			values, ok := decimated[0].Values().(ts.FixedResolutionMutableValues)
+			require.True(t, ok)
+			assert.Equal(t, 3*time.Second, values.Resolution())
+			// NB: the first group is partial so the most recent step is kept.
+			assert.Equal(t, start, values.StartTimeForStep(0))
+			assert.Equal(t, start.Add(6*time.Second), values.StartTimeForStep(2))
+			require.Equal(t, len(tt.expected), values.Len())
+			for i, v := range tt.expected {
+				assert.Equal(t, v, values.ValueAt(i))
+			}
+		})
+	}
+}
+
+func TestDecimatorDecimateAllNaNs(t *testing.T) {
+	nan := math.NaN()
+	d := newDecimator(config.DecimationConfiguration{})
+	series := newTestDecimationSeries(time.Unix(1600000000, 0), 1, 2, nan, nan)
+
+	decimated := d.decimate([]*ts.Series{series}, 2)
+	require.Equal(t, 1, len(decimated))
+
+	values := decimated[0].Values()
+	require.Equal(t, 2, values.Len())
+	assert.Equal(t, 2.0, values.ValueAt(0))
+	assert.True(t, math.IsNaN(values.ValueAt(1)))
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/client"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/parser/promql"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/m3"
+	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	pql "github.com/prometheus/prometheus/promql/parser"
+	"go.uber.org/zap"
+)
+
+const (
+	// PromEstimateURL is the url for the query cost estimate handler, which
+	// estimates the cost of a query from index statistics without executing
+	// the query.
+	PromEstimateURL = handler.RoutePrefixV1 + "/query_estimate"
+
+	// scrapeIntervalParam is the interval datapoints are assumed to be
+	// written at for unaggregated namespaces.
+	scrapeIntervalParam = "scrape_interval"
+
+	defaultEstimateScrapeInterval = 15 * time.Second
+	defaultEstimateBlockSize      = 2 * time.Hour
+
+	// estimatedBytesPerDatapoint is the typical size of a compressed
+	// datapoint.
+	estimatedBytesPerDatapoint = 1.45
+)
+
+var (
+	// PromEstimateHTTPMethods are the HTTP methods for this handler.
+	PromEstimateHTTPMethods = []string{http.MethodGet, http.MethodPost}
+)
+
+// QueryCostEstimate is the estimated cost of executing a query.
+type QueryCostEstimate struct {
+	// Series is the number of series the query touches.
+	Series int `json:"series"`
+	// Blocks is the number of series blocks the query reads.
+	Blocks int `json:"blocks"`
+	// Datapoints is the number of datapoints the query reads.
+	Datapoints int64 `json:"datapoints"`
+	// Bytes is the number of bytes the query scans.
+	Bytes int64 `json:"bytes"`
+	// Exhaustive is false if the series of a selector exceeded the series
+	// limit, in which case the estimate is a lower bound.
+	Exhaustive bool `json:"exhaustive"`
+	// Selectors are the estimates of each series selector in the query.
+	Selectors []SelectorCostEstimate `json:"selectors"`
+}
+
+// SelectorCostEstimate is the estimated cost of a single series selector
+// of a query.
+type SelectorCostEstimate struct {
+	Selector   string    `json:"selector"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Series     int       `json:"series"`
+	Blocks     int       `json:"blocks"`
+	Datapoints int64     `json:"datapoints"`
+	Bytes      int64     `json:"bytes"`
+	Exhaustive bool      `json:"exhaustive"`
+}
+
+type promEstimateHandler struct {
+	opts           options.HandlerOptions
+	storage        storage.Storage
+	tagOptions     models.TagOptions
+	clusters       m3.Clusters
+	clusterClient  client.Client
+	instrumentOpts instrument.Options
+}
+
+// NewPromEstimateHandler returns a new query cost estimate handler.
+func NewPromEstimateHandler(opts options.HandlerOptions) http.Handler {
+	return &promEstimateHandler{
+		opts:           opts,
+		storage:        opts.Storage(),
+		tagOptions:     opts.TagOptions(),
+		clusters:       opts.Clusters(),
+		clusterClient:  opts.ClusterClient(),
+		instrumentOpts: opts.InstrumentOpts(),
+	}
+}
+
+func (h *promEstimateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+
+	if err := r.ParseForm(); err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Queries without a start are estimated as instant queries.
+	instant := r.Form.Get(startParam) == ""
+	parsed, rErr := ParseRequest(ctx, r, instant, h.opts)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	scrapeInterval := defaultEstimateScrapeInterval
+	if v := r.Form.Get(scrapeIntervalParam); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			xhttp.Error(w, fmt.Errorf("invalid %s: %s", scrapeIntervalParam, v),
+				http.StatusBadRequest)
+			return
+		}
+		scrapeInterval = d
+	}
+
+	selectors, err := querySelectors(parsed.Params, h.tagOptions)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	var (
+		blockSizes = h.namespaceBlockSizes(logger)
+		meta       = block.NewResultMetadata()
+		estimate   = QueryCostEstimate{
+			Exhaustive: true,
+			Selectors:  make([]SelectorCostEstimate, 0, len(selectors)),
+		}
+	)
+	for _, selector := range selectors {
+		result, err := h.storage.SearchSeries(ctx, selector.query, parsed.FetchOpts)
+		if err != nil {
+			logger.Error("unable to search series for estimate", zap.Error(err))
+			xhttp.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		meta = meta.CombineMetadata(result.Metadata)
+
+		ns := resolveEstimateNamespace(h.clusters, selector.query.Start,
+			parsed.Params.Now, scrapeInterval, blockSizes)
+		selectorEstimate := estimateSelector(selector, len(result.Metrics), ns)
+		selectorEstimate.Exhaustive = result.Metadata.Exhaustive
+
+		estimate.Series += selectorEstimate.Series
+		estimate.Blocks += selectorEstimate.Blocks
+		estimate.Datapoints += selectorEstimate.Datapoints
+		estimate.Bytes += selectorEstimate.Bytes
+		estimate.Exhaustive = estimate.Exhaustive && selectorEstimate.Exhaustive
+		estimate.Selectors = append(estimate.Selectors, selectorEstimate)
+	}
+
+	handleroptions.AddWarningHeaders(w, meta)
+	xhttp.WriteJSONResponse(w, estimate, logger)
+}
+
+// namespaceBlockSizes returns the block sizes of the namespaces in the
+// namespace registry, estimates use a default block size for namespaces
+// that are not found.
+func (h *promEstimateHandler) namespaceBlockSizes(
+	logger *zap.Logger,
+) map[string]time.Duration {
+	if h.clusterClient == nil {
+		return nil
+	}
+
+	store, err := h.clusterClient.KV()
+	if err != nil {
+		logger.Warn("unable to get KV store for namespace block sizes",
+			zap.Error(err))
+		return nil
+	}
+	metadatas, _, err := namespace.Metadata(store)
+	if err != nil {
+		logger.Warn("unable to get namespace block sizes", zap.Error(err))
+		return nil
+	}
+
+	blockSizes := make(map[string]time.Duration, len(metadatas))
+	for _, md := range metadatas {
+		blockSizes[md.ID().String()] =
+			md.Options().RetentionOptions().BlockSize()
+	}
+	return blockSizes
+}
+
+type estimateSelectorQuery struct {
+	selector string
+	query    *storage.FetchQuery
+}
+
+// querySelectors returns a fetch query for each series selector of the
+// query, covering the time range the selector reads once offsets, ranges,
+// subqueries and the lookback are applied.
+func querySelectors(
+	params models.RequestParams,
+	tagOptions models.TagOptions,
+) ([]estimateSelectorQuery, error) {
+	expr, err := pql.ParseExpr(params.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	var selectors []estimateSelectorQuery
+	err = pql.Walk(estimateVisitor(func(node pql.Node, path []pql.Node) error {
+		selector, ok := node.(*pql.VectorSelector)
+		if !ok {
+			return nil
+		}
+
+		// Instant vector selectors read the lookback before each step, range
+		// vector selectors read their range instead.
+		lookback := params.LookbackDuration
+		if len(path) > 0 {
+			if matrix, ok := path[len(path)-1].(*pql.MatrixSelector); ok {
+				lookback = matrix.Range
+			}
+		}
+
+		// Subqueries evaluate their expression over their range before each
+		// step, offset by their own offset.
+		offset := selector.Offset
+		for _, parent := range path {
+			if subquery, ok := parent.(*pql.SubqueryExpr); ok {
+				offset += subquery.Offset
+				lookback += subquery.Range
+			}
+		}
+
+		matchers, err := promql.LabelMatchersToModelMatcher(
+			selector.LabelMatchers, tagOptions)
+		if err != nil {
+			return err
+		}
+
+		selectors = append(selectors, estimateSelectorQuery{
+			selector: selector.String(),
+			query: &storage.FetchQuery{
+				Raw:         selector.String(),
+				TagMatchers: matchers,
+				Start:       params.Start.Add(-offset - lookback),
+				End:         params.End.Add(-offset),
+			},
+		})
+		return nil
+	}), expr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectors, nil
+}
+
+type estimateVisitor func(node pql.Node, path []pql.Node) error
+
+func (f estimateVisitor) Visit(node pql.Node, path []pql.Node) (pql.Visitor, error) {
+	return f, f(node, path)
+}
+
+type estimateNamespace struct {
+	name      string
+	interval  time.Duration
+	blockSize time.Duration
+}
+
+// resolveEstimateNamespace returns the namespace a query starting at the
+// given time reads, preferring the unaggregated namespace if its retention
+// covers the query and otherwise the finest resolution aggregated namespace
+// whose retention covers the query.
+func resolveEstimateNamespace(
+	clusters m3.Clusters,
+	start time.Time,
+	now time.Time,
+	scrapeInterval time.Duration,
+	blockSizes map[string]time.Duration,
+) estimateNamespace {
+	result := estimateNamespace{
+		interval:  scrapeInterval,
+		blockSize: defaultEstimateBlockSize,
+	}
+	if clusters == nil {
+		return result
+	}
+
+	var (
+		queryRetention = now.Sub(start)
+		resolved       m3.ClusterNamespace
+	)
+	if ns := clusters.UnaggregatedClusterNamespace(); ns != nil &&
+		ns.Options().Attributes().Retention >= queryRetention {
+		resolved = ns
+	} else {
+		var longest m3.ClusterNamespace
+		for _, ns := range clusters.ClusterNamespaces() {
+			attrs := ns.Options().Attributes()
+			if attrs.MetricsType != storagemetadata.AggregatedMetricsType {
+				continue
+			}
+			if longest == nil ||
+				attrs.Retention > longest.Options().Attributes().Retention {
+				longest = ns
+			}
+			if attrs.Retention < queryRetention {
+				continue
+			}
+			if resolved == nil ||
+				attrs.Resolution < resolved.Options().Attributes().Resolution {
+				resolved = ns
+			}
+		}
+		if resolved == nil {
+			resolved = longest
+		}
+	}
+	if resolved == nil {
+		return result
+	}
+
+	result.name = resolved.NamespaceID().String()
+	attrs := resolved.Options().Attributes()
+	if attrs.MetricsType == storagemetadata.AggregatedMetricsType &&
+		attrs.Resolution > 0 {
+		result.interval = attrs.Resolution
+	}
+	if blockSize, ok := blockSizes[result.name]; ok && blockSize > 0 {
+		result.blockSize = blockSize
+	}
+	return result
+}
+
+func estimateSelector(
+	selector estimateSelectorQuery,
+	series int,
+	ns estimateNamespace,
+) SelectorCostEstimate {
+	var (
+		start = selector.query.Start
+		end   = selector.query.End
+		// Blocks are aligned to the block size, so a range may overlap
+		// a block it only partially covers at either end.
+		blocksPerSeries = int(end.Truncate(ns.blockSize).
+				Sub(start.Truncate(ns.blockSize))/ns.blockSize) + 1
+		datapointsPerSeries = int64(end.Sub(start) / ns.interval)
+		datapoints          = datapointsPerSeries * int64(series)
+	)
+	return SelectorCostEstimate{
+		Selector:   selector.selector,
+		Namespace:  ns.name,
+		Start:      start,
+		End:        end,
+		Series:     series,
+		Blocks:     blocksPerSeries * series,
+		Datapoints: datapoints,
+		Bytes:      int64(math.Ceil(float64(datapoints) * estimatedBytesPerDatapoint)),
+		Exhaustive: true,
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	xtest "github.com/m3db/m3/src/x/test"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuerySelectorsTimeRanges(t *testing.T) {
+	var (
+		start  = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		end    = start.Add(time.Hour)
+		params = models.RequestParams{
+			Start:            start,
+			End:              end,
+			LookbackDuration: 5 * time.Minute,
+		}
+	)
+
+	params.Query = `rate(http_requests_total{job="api"}[10m] offset 1h) / up` +
+		` + max_over_time(rate(errors_total[1m])[30m:1m] offset 1d)`
+	selectors, err := querySelectors(params, models.NewTagOptions())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(selectors))
+
+	assert.Equal(t, `http_requests_total{job="api"} offset 1h`, selectors[0].selector)
+	assert.Equal(t, start.Add(-time.Hour-10*time.Minute), selectors[0].query.Start)
+	assert.Equal(t, end.Add(-time.Hour), selectors[0].query.End)
+	assert.Equal(t, 2, len(selectors[0].query.TagMatchers))
+
+	assert.Equal(t, "up", selectors[1].selector)
+	assert.Equal(t, start.Add(-5*time.Minute), selectors[1].query.Start)
+	assert.Equal(t, end, selectors[1].query.End)
+
+	assert.Equal(t, "errors_total", selectors[2].selector)
+	assert.Equal(t, start.Add(-24*time.Hour-31*time.Minute), selectors[2].query.Start)
+	assert.Equal(t, end.Add(-24*time.Hour), selectors[2].query.End)
+
+	params.Query = "sum(rate(foo[5m]"
+	_, err = querySelectors(params, models.NewTagOptions())
+	assert.Error(t, err)
+}
+
+func TestEstimateSelector(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	selector := estimateSelectorQuery{
+		selector: "up",
+		query: &storage.FetchQuery{
+			Start: start.Add(-5 * time.Minute),
+			End:   start.Add(time.Hour),
+		},
+	}
+
+	estimate := estimateSelector(selector, 3, estimateNamespace{
+		name:      "default",
+		interval:  15 * time.Second,
+		blockSize: 2 * time.Hour,
+	})
+	assert.Equal(t, SelectorCostEstimate{
+		Selector:   "up",
+		Namespace:  "default",
+		Start:      start.Add(-5 * time.Minute),
+		End:        start.Add(time.Hour),
+		Series:     3,
+		Blocks:     6,
+		Datapoints: 780,
+		Bytes:      1131,
+		Exhaustive: true,
+	}, estimate)
+
+	estimate = estimateSelector(selector, 3, estimateNamespace{
+		interval:  30 * time.Second,
+		blockSize: 24 * time.Hour,
+	})
+	assert.Equal(t, 3, estimate.Blocks)
+	assert.Equal(t, int64(390), estimate.Datapoints)
+	assert.Equal(t, int64(566), estimate.Bytes)
+}
+
+func TestPromEstimateHandler(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		setup = newTestSetup()
+		store = storage.NewMockStorage(ctrl)
+		start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		end   = start.Add(time.Hour)
+	)
+	store.EXPECT().
+		SearchSeries(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			query *storage.FetchQuery,
+			_ *storage.FetchOptions,
+		) (*storage.SearchResults, error) {
+			assert.Equal(t, start.Add(-5*time.Minute), query.Start)
+			assert.Equal(t, end, query.End)
+
+			meta := block.NewResultMetadata()
+			meta.Exhaustive = false
+			return &storage.SearchResults{
+				Metrics: models.Metrics{
+					{ID: []byte("a")}, {ID: []byte("b")}, {ID: []byte("c")},
+				},
+				Metadata: meta,
+			}, nil
+		})
+
+	h := NewPromEstimateHandler(setup.options.SetStorage(store))
+
+	params := url.Values{}
+	params.Set(queryParam, "sum(rate(http_requests_total[5m]))")
+	params.Set(startParam, start.Format(time.RFC3339))
+	params.Set(endParam, end.Format(time.RFC3339))
+	params.Set(handleroptions.StepParam, time.Minute.String())
+	params.Set(scrapeIntervalParam, "30s")
+	req := httptest.NewRequest(http.MethodGet, PromEstimateURL+"?"+params.Encode(), nil)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var estimate QueryCostEstimate
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &estimate))
+	assert.Equal(t, 3, estimate.Series)
+	assert.Equal(t, 6, estimate.Blocks)
+	assert.Equal(t, int64(390), estimate.Datapoints)
+	assert.Equal(t, int64(566), estimate.Bytes)
+	assert.False(t, estimate.Exhaustive)
+	require.Equal(t, 1, len(estimate.Selectors))
+	assert.Equal(t, "http_requests_total", estimate.Selectors[0].Selector)
+}
+
+func TestPromEstimateHandlerInvalidScrapeInterval(t *testing.T) {
+	setup := newTestSetup()
+	h := NewPromEstimateHandler(setup.options)
+
+	params := defaultParams()
+	params.Set(scrapeIntervalParam, "-1s")
+	req := httptest.NewRequest(http.MethodGet, PromEstimateURL+"?"+params.Encode(), nil)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/arrow"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PromExportURL is the url for the export handler, which returns the
+	// results of a range query as Apache Arrow record batches.
+	PromExportURL = handler.RoutePrefixV1 + "/export"
+
+	// ContentTypeArrowStream is the Content-Type value for the Arrow IPC
+	// streaming format.
+	ContentTypeArrowStream = "application/vnd.apache.arrow.stream"
+
+	exportFormatParam = "format"
+	exportFormatArrow = "arrow"
+
+	exportTimestampField = "timestamp"
+	exportValueField     = "value"
+	exportLabelPrefix    = "label_"
+
+	// exportBatchSize is the number of rows written per record batch.
+	exportBatchSize = 8192
+)
+
+var (
+	// PromExportHTTPMethods are the HTTP methods for this handler.
+	PromExportHTTPMethods = []string{http.MethodGet, http.MethodPost}
+)
+
+type promExportHandler struct {
+	opts options.HandlerOptions
+}
+
+// NewPromExportHandler returns a new export handler.
+func NewPromExportHandler(opts options.HandlerOptions) http.Handler {
+	return &promExportHandler{opts: opts}
+}
+
+func (h *promExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.opts.InstrumentOpts())
+
+	if format := r.FormValue(exportFormatParam); format != "" &&
+		format != exportFormatArrow {
+		xhttp.Error(w, fmt.Errorf("unsupported export format: %s", format),
+			http.StatusBadRequest)
+		return
+	}
+
+	parsedOptions, rErr := ParseRequest(ctx, r, false, h.opts)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	watcher := handler.NewResponseWriterCanceller(w, h.opts.InstrumentOpts())
+	parsedOptions.CancelWatcher = watcher
+
+	result, err := read(ctx, parsedOptions, h.opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if xerrors.IsInvalidParams(err) {
+			status = http.StatusBadRequest
+		}
+		logger.Error("export query error",
+			zap.Error(err),
+			zap.Int("httpResponseStatusCode", status))
+		xhttp.Error(w, err, status)
+		return
+	}
+
+	w.Header().Set(xhttp.HeaderContentType, ContentTypeArrowStream)
+	handleroptions.AddWarningHeaders(w, result.Meta)
+
+	err = RenderResultsArrow(w, result, RenderResultsOptions{
+		Start:    parsedOptions.Params.Start,
+		End:      parsedOptions.Params.End,
+		KeepNaNs: h.opts.Config().ResultOptions.KeepNans,
+	})
+	if err != nil {
+		logger.Error("failed to render export results", zap.Error(err))
+	}
+}
+
+// RenderResultsArrow renders results as an Arrow IPC stream with a row for
+// each datapoint, with columns for the timestamp, the value and each label
+// of the series. Labels that are not set for a series are null.
+func RenderResultsArrow(
+	w io.Writer,
+	result ReadResult,
+	opts RenderResultsOptions,
+) error {
+	series := result.Series
+	if !opts.KeepNaNs {
+		series = filterNaNSeries(series, opts.Start, opts.End)
+	}
+
+	fields, labelColumns := exportFields(series)
+	var (
+		writer = arrow.NewStreamWriter(w, fields)
+		batch  = arrow.NewRecordBatch(fields)
+		labels = make([]string, len(fields))
+		isSet  = make([]bool, len(fields))
+	)
+	for _, s := range series {
+		for i := range isSet {
+			isSet[i] = false
+		}
+		for _, t := range s.Tags.Tags {
+			col := labelColumns[string(t.Name)]
+			labels[col], isSet[col] = string(t.Value), true
+		}
+
+		vals := s.Values()
+		for i := 0; i < s.Len(); i++ {
+			dp := vals.DatapointAt(i)
+			if !opts.KeepNaNs && math.IsNaN(dp.Value) {
+				continue
+			}
+			if dp.Timestamp.Before(opts.Start) {
+				continue
+			}
+
+			batch.AppendTimestamp(0, dp.Timestamp)
+			batch.AppendFloat64(1, dp.Value)
+			for col := 2; col < len(fields); col++ {
+				if isSet[col] {
+					batch.AppendString(col, labels[col])
+				} else {
+					batch.AppendNull(col)
+				}
+			}
+
+			if batch.Len() < exportBatchSize {
+				continue
+			}
+			if err := writer.Write(batch); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+
+	if batch.Len() > 0 {
+		if err := writer.Write(batch); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// exportFields returns the schema of exported series, with a column for
+// each label name sorted by name, and the column of each label name.
+func exportFields(series []*ts.Series) ([]arrow.Field, map[string]int) {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, s := range series {
+		for _, t := range s.Tags.Tags {
+			name := string(t.Name)
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fields := make([]arrow.Field, 0, len(names)+2)
+	fields = append(fields,
+		arrow.Field{Name: exportTimestampField, Type: arrow.TimestampType},
+		arrow.Field{Name: exportValueField, Type: arrow.Float64Type})
+
+	columns := make(map[string]int, len(names))
+	for _, name := range names {
+		fieldName := name
+		if name == exportTimestampField || name == exportValueField {
+			// NB: avoid labels shadowing the datapoint columns.
+			fieldName = exportLabelPrefix + name
+		}
+		columns[name] = len(fields)
+		fields = append(fields, arrow.Field{
+			Name:     fieldName,
+			Type:     arrow.StringType,
+			Nullable: true,
+		})
+	}
+
+	return fields, columns
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/query/util/arrow"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportFields(t *testing.T) {
+	series := []*ts.Series{
+		ts.NewSeries([]byte("foo"), ts.NewFixedStepValues(time.Second, 1, 1,
+			time.Unix(0, 0)), test.TagSliceToTags([]models.Tag{
+			{Name: []byte("job"), Value: []byte("api")},
+			{Name: []byte("value"), Value: []byte("bar")},
+		})),
+		ts.NewSeries([]byte("bar"), ts.NewFixedStepValues(time.Second, 1, 1,
+			time.Unix(0, 0)), test.TagSliceToTags([]models.Tag{
+			{Name: []byte("dc"), Value: []byte("east")},
+			{Name: []byte("job"), Value: []byte("db")},
+		})),
+	}
+
+	fields, columns := exportFields(series)
+	assert.Equal(t, []arrow.Field{
+		{Name: "timestamp", Type: arrow.TimestampType},
+		{Name: "value", Type: arrow.Float64Type},
+		{Name: "dc", Type: arrow.StringType, Nullable: true},
+		{Name: "job", Type: arrow.StringType, Nullable: true},
+		{Name: "label_value", Type: arrow.StringType, Nullable: true},
+	}, fields)
+	assert.Equal(t, map[string]int{"dc": 2, "job": 3, "value": 4}, columns)
+}
+
+func TestRenderResultsArrow(t *testing.T) {
+	start := time.Unix(1535948880, 0)
+	valsWithNaN := ts.NewFixedStepValues(10*time.Second, 2, 1, start)
+	valsWithNaN.SetValueAt(1, math.NaN())
+
+	series := []*ts.Series{
+		ts.NewSeries([]byte("foo"), valsWithNaN,
+			test.TagSliceToTags([]models.Tag{
+				{Name: []byte("bar"), Value: []byte("baz")},
+			})),
+		ts.NewSeries([]byte("bar"),
+			ts.NewFixedStepValues(10*time.Second, 2, math.NaN(), start),
+			test.TagSliceToTags([]models.Tag{
+				{Name: []byte("qux"), Value: []byte("qaz")},
+			})),
+	}
+
+	var buffer bytes.Buffer
+	err := RenderResultsArrow(&buffer, ReadResult{Series: series},
+		RenderResultsOptions{Start: start, End: start.Add(time.Minute)})
+	require.NoError(t, err)
+
+	// NB: the schema and a single record batch are followed by the end of
+	// stream marker.
+	out := buffer.Bytes()
+	require.True(t, len(out) > 16)
+	assert.Equal(t, uint32(0xFFFFFFFF), binary.LittleEndian.Uint32(out))
+	assert.Equal(t, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0},
+		out[len(out)-8:])
+
+	// NB: the all NaN series and its labels are dropped.
+	assert.False(t, bytes.Contains(out, []byte("qaz")))
+	assert.True(t, bytes.Contains(out, []byte("baz")))
+}
+
+func TestPromExportHandlerUnsupportedFormat(t *testing.T) {
+	setup := newTestSetup()
+	h := NewPromExportHandler(setup.options)
+
+	params := defaultParams()
+	params.Set(exportFormatParam, "parquet")
+	req := httptest.NewRequest(http.MethodGet, PromExportURL, nil)
+	req.URL.RawQuery = params.Encode()
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
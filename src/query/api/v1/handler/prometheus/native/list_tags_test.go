@@ -42,7 +42,8 @@ import (
 )
 
 type listTagsMatcher struct {
-	now time.Time
+	start time.Time
+	now   time.Time
 }
 
 func (m *listTagsMatcher) String() string { return "list tags query" }
@@ -52,7 +53,7 @@ func (m *listTagsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
-	if !q.Start.Equal(time.Time{}) {
+	if !q.Start.Equal(m.start) {
 		return false
 	}
 
@@ -182,3 +183,39 @@ func TestListErrorTags(t *testing.T) {
 		require.Equal(t, ex, string(r))
 	}
 }
+
+func TestListTagsTimeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStorage(ctrl)
+	fb := handleroptions.
+		NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{})
+	opts := options.EmptyHandlerOptions().
+		SetStorage(store).
+		SetFetchOptionsBuilder(fb).
+		SetNowFn(time.Now)
+	h := NewListTagsHandler(opts)
+
+	start := time.Unix(1000, 0)
+	end := time.Unix(2000, 0)
+	matcher := &listTagsMatcher{start: start, now: end}
+	store.EXPECT().CompleteTags(gomock.Any(), matcher, gomock.Any()).
+		Return(&consolidators.CompleteTagsResult{CompleteNameOnly: true}, nil)
+
+	req := httptest.NewRequest("GET", "/labels?start=1000&end=2000", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	for _, url := range []string{
+		"/labels?start=foo",
+		"/labels?end=foo",
+		"/labels?start=2000&end=1000",
+	} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Result().StatusCode, url)
+	}
+}
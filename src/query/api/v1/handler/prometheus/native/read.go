@@ -23,12 +23,14 @@ package native
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/api/v1/options"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	xhttp "github.com/m3db/m3/src/x/net/http"
 	xopentracing "github.com/m3db/m3/src/x/opentracing"
 
@@ -66,6 +68,8 @@ var (
 type promReadHandler struct {
 	instant         bool
 	promReadMetrics promReadMetrics
+	resultCache     *resultCache
+	decimator       *decimator
 	opts            options.HandlerOptions
 }
 
@@ -94,6 +98,15 @@ func newHandler(opts options.HandlerOptions, instant bool) http.Handler {
 		instant:         instant,
 	}
 
+	// NB: instant queries are evaluated relative to now so are not cached.
+	if cacheCfg := opts.Config().Query.ResultCache; cacheCfg != nil && !instant {
+		h.resultCache = newResultCache(*cacheCfg, taggedScope)
+	}
+
+	if decimationCfg := opts.Config().ResultOptions.Decimation; decimationCfg != nil && !instant {
+		h.decimator = newDecimator(*decimationCfg)
+	}
+
 	maxDatapoints := opts.Config().Limits.MaxComputedDatapoints()
 	h.promReadMetrics.maxDatapoints.Update(float64(maxDatapoints))
 	return h
@@ -114,20 +127,35 @@ func (h *promReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.decimator != nil {
+		// The resolution of the data read determines whether results are
+		// decimated.
+		parsedOptions.FetchOpts.IncludeResolution = true
+	}
+
 	watcher := handler.NewResponseWriterCanceller(w, h.opts.InstrumentOpts())
 	parsedOptions.CancelWatcher = watcher
 
-	result, err := read(ctx, parsedOptions, h.opts)
+	result, err := h.read(ctx, parsedOptions)
 	if err != nil {
 		sp := xopentracing.SpanFromContextOrNoop(ctx)
 		sp.LogFields(opentracinglog.Error(err))
 		opentracingext.Error.Set(sp, true)
+		status := http.StatusInternalServerError
+		if xerrors.IsInvalidParams(err) {
+			// Invalid queries and exceeded limits are the caller's fault and
+			// will not succeed on retry.
+			status = http.StatusBadRequest
+			h.promReadMetrics.fetchErrorsClient.Inc(1)
+		} else {
+			h.promReadMetrics.fetchErrorsServer.Inc(1)
+		}
 		logger.Error("range query error",
 			zap.Error(err),
+			zap.Int("httpResponseStatusCode", status),
 			zap.Any("parsedOptions", parsedOptions))
-		h.promReadMetrics.fetchErrorsServer.Inc(1)
 
-		xhttp.Error(w, err, http.StatusInternalServerError)
+		xhttp.Error(w, err, status)
 		return
 	}
 
@@ -135,6 +163,22 @@ func (h *promReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	handleroptions.AddWarningHeaders(w, result.Meta)
 	h.promReadMetrics.fetchSuccess.Inc(1)
 
+	if h.decimator != nil && parsedOptions.Params.FormatType != models.FormatM3QL {
+		step := parsedOptions.Params.Step
+		if factor := h.decimator.factor(result.Meta, step); factor > 1 {
+			result.Series = h.decimator.decimate(result.Series, factor)
+			w.Header().Set(handleroptions.DecimatedStepHeader,
+				(step * time.Duration(factor)).String())
+			h.promReadMetrics.decimated.Inc(1)
+		}
+	}
+
+	var datapoints int
+	for _, series := range result.Series {
+		datapoints += series.Len()
+	}
+	logging.RecordRequestStats(ctx, len(result.Series), datapoints)
+
 	if h.instant {
 		renderResultsInstantaneousJSON(w, result, h.opts.Config().ResultOptions.KeepNans)
 		return
@@ -158,3 +202,19 @@ func (h *promReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+func (h *promReadHandler) read(
+	ctx context.Context,
+	parsedOptions ParsedOptions,
+) (ReadResult, error) {
+	if h.resultCache == nil {
+		return read(ctx, parsedOptions, h.opts)
+	}
+
+	return h.resultCache.read(ctx, parsedOptions, func(
+		ctx context.Context,
+		parsedOptions ParsedOptions,
+	) (ReadResult, error) {
+		return read(ctx, parsedOptions, h.opts)
+	})
+}
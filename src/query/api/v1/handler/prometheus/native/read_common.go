@@ -35,6 +35,7 @@ import (
 	"github.com/m3db/m3/src/query/parser/promql"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	xhttp "github.com/m3db/m3/src/x/net/http"
 	xopentracing "github.com/m3db/m3/src/x/opentracing"
 	"github.com/uber-go/tally"
@@ -48,6 +49,7 @@ type promReadMetrics struct {
 	fetchErrorsClient tally.Counter
 	fetchTimerSuccess tally.Timer
 	maxDatapoints     tally.Gauge
+	decimated         tally.Counter
 }
 
 func newPromReadMetrics(scope tally.Scope) promReadMetrics {
@@ -59,6 +61,7 @@ func newPromReadMetrics(scope tally.Scope) promReadMetrics {
 			Counter("fetch.errors"),
 		fetchTimerSuccess: scope.Timer("fetch.success.latency"),
 		maxDatapoints:     scope.Gauge("max_datapoints"),
+		decimated:         scope.Counter("decimated"),
 	}
 }
 
@@ -186,7 +189,7 @@ func read(
 	parseOpts := engine.Options().ParseOptions()
 	parser, err := promql.Parse(params.Query, params.Step, tagOpts, parseOpts)
 	if err != nil {
-		return emptyResult, err
+		return emptyResult, xerrors.NewInvalidParamsError(err)
 	}
 
 	// Detect clients closing connections.
@@ -23,6 +23,7 @@ package native
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -40,6 +41,7 @@ import (
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/storage/mock"
 	"github.com/m3db/m3/src/query/test"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/instrument"
 
 	"github.com/stretchr/testify/assert"
@@ -262,6 +264,18 @@ func TestPromReadHandlerServeHTTPMaxComputedDatapoints(t *testing.T) {
 	assert.Equal(t, expected, errResp.Error)
 }
 
+func TestPromReadHandlerServeHTTPInvalidParamsError(t *testing.T) {
+	setup := newTestSetup()
+	setup.Storage.SetFetchBlocksResult(block.Result{},
+		xerrors.NewInvalidParamsError(errors.New("query limit exceeded")))
+
+	req := newReadRequest(t, defaultParams())
+	recorder := httptest.NewRecorder()
+	setup.Handlers.read.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Result().StatusCode)
+}
+
 func TestPromReadHandler_validateRequest(t *testing.T) {
 	dt := func(year int, month time.Month, day, hour int) time.Time {
 		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
@@ -0,0 +1,462 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// resultCacheExtentOverhead and resultCacheSeriesOverhead are rough
+	// estimates of the memory used by an extent and each of its series
+	// beyond their values, names and tags.
+	resultCacheExtentOverhead = 128
+	resultCacheSeriesOverhead = 64
+)
+
+// resultCacheKey identifies a query independently of its time range, the
+// results of each step of queries with the same key are interchangeable.
+type resultCacheKey struct {
+	query       string
+	step        time.Duration
+	lookback    time.Duration
+	seriesLimit int
+	docsLimit   int
+}
+
+// resultCacheSeries is a series with one value per step of an extent.
+type resultCacheSeries struct {
+	name   []byte
+	tags   models.Tags
+	values []float64
+}
+
+// resultCacheExtent is the result of a query for the steps within
+// [start, end], both inclusive.
+type resultCacheExtent struct {
+	start     time.Time
+	end       time.Time
+	step      time.Duration
+	series    []resultCacheSeries
+	meta      block.ResultMetadata
+	blockType block.BlockType
+}
+
+// newResultCacheExtent copies the result of a query for the steps within
+// [start, end] into an extent.
+func newResultCacheExtent(
+	result ReadResult,
+	start, end time.Time,
+	step time.Duration,
+) *resultCacheExtent {
+	steps := numResultCacheSteps(start, end, step)
+	series := make([]resultCacheSeries, 0, len(result.Series))
+	for _, s := range result.Series {
+		var (
+			vals   = s.Values()
+			values = make([]float64, steps)
+		)
+		for i := range values {
+			values[i] = math.NaN()
+			if i < vals.Len() {
+				values[i] = vals.ValueAt(i)
+			}
+		}
+		series = append(series, resultCacheSeries{
+			name:   s.Name(),
+			tags:   s.Tags,
+			values: values,
+		})
+	}
+
+	return &resultCacheExtent{
+		start:     start,
+		end:       end,
+		step:      step,
+		series:    series,
+		meta:      result.Meta,
+		blockType: result.BlockType,
+	}
+}
+
+// mergeResultCacheExtents merges contiguous extents, in time order, that
+// together cover the steps within [start, end]. Series are matched by their
+// tags, series missing from an extent have no values for its steps.
+func mergeResultCacheExtents(
+	start, end time.Time,
+	step time.Duration,
+	extents []*resultCacheExtent,
+) *resultCacheExtent {
+	var (
+		steps  = numResultCacheSteps(start, end, step)
+		index  = make(map[string]int)
+		merged = &resultCacheExtent{
+			start:     start,
+			end:       end,
+			step:      step,
+			meta:      block.NewResultMetadata(),
+			blockType: block.BlockEmpty,
+		}
+	)
+	for _, extent := range extents {
+		offset := int(extent.start.Sub(start) / step)
+		for _, s := range extent.series {
+			id := string(s.tags.ID())
+			i, ok := index[id]
+			if !ok {
+				values := make([]float64, steps)
+				for j := range values {
+					values[j] = math.NaN()
+				}
+				i = len(merged.series)
+				index[id] = i
+				merged.series = append(merged.series, resultCacheSeries{
+					name:   s.name,
+					tags:   s.tags,
+					values: values,
+				})
+			}
+			copy(merged.series[i].values[offset:], s.values)
+		}
+
+		merged.meta = merged.meta.CombineMetadata(extent.meta)
+		if len(extent.series) > 0 {
+			merged.blockType = extent.blockType
+		}
+	}
+	return merged
+}
+
+// slice returns a copy of the extent restricted to the steps within
+// [start, end], which must be within the extent.
+func (e *resultCacheExtent) slice(start, end time.Time) *resultCacheExtent {
+	var (
+		from   = int(start.Sub(e.start) / e.step)
+		to     = from + numResultCacheSteps(start, end, e.step)
+		series = make([]resultCacheSeries, 0, len(e.series))
+	)
+	for _, s := range e.series {
+		values := make([]float64, to-from)
+		copy(values, s.values[from:to])
+		series = append(series, resultCacheSeries{
+			name:   s.name,
+			tags:   s.tags,
+			values: values,
+		})
+	}
+
+	return &resultCacheExtent{
+		start:     start,
+		end:       end,
+		step:      e.step,
+		series:    series,
+		meta:      e.meta,
+		blockType: e.blockType,
+	}
+}
+
+// size returns an estimate of the memory used by the extent.
+func (e *resultCacheExtent) size() int {
+	size := resultCacheExtentOverhead
+	for _, s := range e.series {
+		size += resultCacheSeriesOverhead + len(s.name) + 8*len(s.values)
+		for _, tag := range s.tags.Tags {
+			size += len(tag.Name) + len(tag.Value)
+		}
+	}
+	return size
+}
+
+func (e *resultCacheExtent) readResult() ReadResult {
+	series := make([]*ts.Series, 0, len(e.series))
+	for _, s := range e.series {
+		values := ts.NewFixedStepValues(e.step, len(s.values), math.NaN(), e.start)
+		for i, v := range s.values {
+			values.SetValueAt(i, v)
+		}
+		series = append(series, ts.NewSeries(s.name, values, s.tags))
+	}
+
+	return ReadResult{
+		Series:    series,
+		Meta:      e.meta,
+		BlockType: e.blockType,
+	}
+}
+
+func numResultCacheSteps(start, end time.Time, step time.Duration) int {
+	return int(end.Sub(start)/step) + 1
+}
+
+type resultCacheEntry struct {
+	key    resultCacheKey
+	extent *resultCacheExtent
+	size   int
+}
+
+type resultCacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	uncached  tally.Counter
+	evictions tally.Counter
+	size      tally.Gauge
+}
+
+func newResultCacheMetrics(scope tally.Scope) resultCacheMetrics {
+	scope = scope.SubScope("result-cache")
+	return resultCacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		uncached:  scope.Counter("uncached"),
+		evictions: scope.Counter("evictions"),
+		size:      scope.Gauge("size-bytes"),
+	}
+}
+
+// resultCacheReadFn reads the result of a query.
+type resultCacheReadFn func(context.Context, ParsedOptions) (ReadResult, error)
+
+// resultCache is an LRU cache of the per-step results of range queries,
+// bounded by their estimated size. Queries whose start is aligned to their
+// step evaluate at the same steps as earlier queries with the same
+// expression and step, so only the steps not already cached are evaluated
+// and are merged with the cached ones. This lets dashboards refreshing a
+// sliding window only evaluate its newest steps.
+// NB: each step is assumed to be evaluated independently of the query
+// range, as with Prometheus range queries. Steps within the minimum age of
+// now may still receive writes so are always evaluated.
+type resultCache struct {
+	sync.Mutex
+
+	maxBytes int
+	minAge   time.Duration
+	bytes    int
+	entries  map[resultCacheKey]*list.Element
+	lru      *list.List
+	metrics  resultCacheMetrics
+}
+
+func newResultCache(
+	cfg config.ResultCacheConfiguration,
+	scope tally.Scope,
+) *resultCache {
+	return &resultCache{
+		maxBytes: cfg.MaxBytes,
+		minAge:   cfg.MinAge,
+		entries:  make(map[resultCacheKey]*list.Element),
+		lru:      list.New(),
+		metrics:  newResultCacheMetrics(scope),
+	}
+}
+
+// cacheKey returns the cache key for the parsed request, and false if the
+// request is not eligible for caching.
+func (c *resultCache) cacheKey(parsed ParsedOptions) (resultCacheKey, bool) {
+	params := parsed.Params
+	if params.Step <= 0 ||
+		params.Start.UnixNano()%int64(params.Step) != 0 {
+		return resultCacheKey{}, false
+	}
+
+	key := resultCacheKey{
+		query:    params.Query,
+		step:     params.Step,
+		lookback: params.LookbackDuration,
+	}
+
+	if fetchOpts := parsed.FetchOpts; fetchOpts != nil {
+		// NB: restricted queries may be served from a different set of
+		// storage policies so are not shared with unrestricted ones.
+		if fetchOpts.RestrictQueryOptions != nil {
+			return resultCacheKey{}, false
+		}
+
+		key.seriesLimit = fetchOpts.SeriesLimit
+		key.docsLimit = fetchOpts.DocsLimit
+	}
+
+	return key, true
+}
+
+// read returns the result of the query, evaluating only the steps that are
+// not cached, and caches the result of the steps older than the minimum age.
+func (c *resultCache) read(
+	ctx context.Context,
+	parsed ParsedOptions,
+	readFn resultCacheReadFn,
+) (ReadResult, error) {
+	key, ok := c.cacheKey(parsed)
+	if !ok {
+		return readFn(ctx, parsed)
+	}
+
+	var (
+		params = parsed.Params
+		step   = params.Step
+		start  = params.Start
+		// NB: the same steps as the query bounds, which exclude the end.
+		steps = int(params.ExclusiveEnd().Sub(start) / step)
+		end   = start.Add(time.Duration(steps-1) * step)
+		// Steps after stableEnd may still receive writes.
+		stableEnd = lastResultCacheStep(start, params.Now.Add(-c.minAge), step)
+	)
+	if end.Before(start) || stableEnd.Before(start) {
+		return readFn(ctx, parsed)
+	}
+	if stableEnd.After(end) {
+		stableEnd = end
+	}
+
+	var (
+		extents []*resultCacheExtent
+		next    = start
+	)
+	if cached, ok := c.get(key); ok {
+		from, to := cached.start, cached.end
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(stableEnd) {
+			to = stableEnd
+		}
+		if !to.Before(from) {
+			c.metrics.hits.Inc(1)
+			if from.After(start) {
+				extent, err := c.readSteps(ctx, parsed, readFn, start, from.Add(-step))
+				if err != nil {
+					return ReadResult{}, err
+				}
+				extents = append(extents, extent)
+			}
+			extents = append(extents, cached.slice(from, to))
+			next = to.Add(step)
+		} else {
+			c.metrics.misses.Inc(1)
+		}
+	} else {
+		c.metrics.misses.Inc(1)
+	}
+
+	if !next.After(end) {
+		extent, err := c.readSteps(ctx, parsed, readFn, next, end)
+		if err != nil {
+			return ReadResult{}, err
+		}
+		extents = append(extents, extent)
+	}
+
+	merged := mergeResultCacheExtents(start, end, step, extents)
+	c.set(key, merged.slice(start, stableEnd))
+	return merged.readResult(), nil
+}
+
+// readSteps reads the result of the query for the steps within [start, end].
+func (c *resultCache) readSteps(
+	ctx context.Context,
+	parsed ParsedOptions,
+	readFn resultCacheReadFn,
+	start, end time.Time,
+) (*resultCacheExtent, error) {
+	parsed.Params.Start = start
+	parsed.Params.End = end
+	parsed.Params.IncludeEnd = true
+	result, err := readFn(ctx, parsed)
+	if err != nil {
+		return nil, err
+	}
+	return newResultCacheExtent(result, start, end, parsed.Params.Step), nil
+}
+
+// lastResultCacheStep returns the last step at or before t of a query
+// starting at start.
+func lastResultCacheStep(start, t time.Time, step time.Duration) time.Time {
+	if t.Before(start) {
+		return start.Add(-step)
+	}
+	return start.Add(t.Sub(start) / step * step)
+}
+
+func (c *resultCache) get(key resultCacheKey) (*resultCacheExtent, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	// NB: cached extents are never modified so can be read without the lock.
+	return elem.Value.(*resultCacheEntry).extent, true
+}
+
+func (c *resultCache) set(key resultCacheKey, extent *resultCacheExtent) {
+	// NB: partial results must be recomputed rather than served from cache
+	// since the failure that caused them may have been transient.
+	if !extent.meta.Exhaustive || len(extent.meta.Warnings) > 0 {
+		c.metrics.uncached.Inc(1)
+		return
+	}
+
+	size := extent.size()
+	if size > c.maxBytes {
+		c.metrics.uncached.Inc(1)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*resultCacheEntry)
+		c.bytes += size - entry.size
+		entry.extent = extent
+		entry.size = size
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.lru.PushFront(&resultCacheEntry{
+			key:    key,
+			extent: extent,
+			size:   size,
+		})
+		c.bytes += size
+	}
+
+	for c.bytes > c.maxBytes {
+		oldest := c.lru.Back()
+		entry := c.lru.Remove(oldest).(*resultCacheEntry)
+		delete(c.entries, entry.key)
+		c.bytes -= entry.size
+		c.metrics.evictions.Inc(1)
+	}
+	c.metrics.size.Update(float64(c.bytes))
+}
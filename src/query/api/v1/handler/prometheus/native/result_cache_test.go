@@ -0,0 +1,220 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package native
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestResultCacheOptions(query string, start time.Time) ParsedOptions {
+	return ParsedOptions{
+		FetchOpts: storage.NewFetchOptions(),
+		Params: models.RequestParams{
+			Query:      query,
+			Start:      start,
+			End:        start.Add(time.Hour),
+			Now:        start.Add(time.Hour),
+			Step:       time.Minute,
+			IncludeEnd: true,
+		},
+	}
+}
+
+type testResultCacheReads struct {
+	ranges [][2]time.Time
+	meta   block.ResultMetadata
+}
+
+// read returns a series whose value at each step is the step's unix time,
+// and a second series with values only from the given time onwards.
+func (r *testResultCacheReads) read(
+	_ context.Context,
+	parsed ParsedOptions,
+) (ReadResult, error) {
+	params := parsed.Params
+	r.ranges = append(r.ranges, [2]time.Time{params.Start, params.End})
+
+	steps := int(params.ExclusiveEnd().Sub(params.Start) / params.Step)
+	values := ts.NewFixedStepValues(params.Step, steps, math.NaN(), params.Start)
+	for i := 0; i < steps; i++ {
+		values.SetValueAt(i, float64(values.StartTimeForStep(i).Unix()))
+	}
+	tags := models.NewTags(1, models.NewTagOptions()).AddTag(models.Tag{
+		Name:  []byte("__name__"),
+		Value: []byte("foo"),
+	})
+	return ReadResult{
+		Series:    []*ts.Series{ts.NewSeries([]byte("foo"), values, tags)},
+		Meta:      r.meta,
+		BlockType: block.BlockM3TSZCompressed,
+	}, nil
+}
+
+func requireTestResultCacheValues(
+	t *testing.T,
+	result ReadResult,
+	start time.Time,
+	steps int,
+) {
+	require.Equal(t, 1, len(result.Series))
+	values := result.Series[0].Values()
+	require.Equal(t, steps, values.Len())
+	for i := 0; i < steps; i++ {
+		dp := values.DatapointAt(i)
+		expected := start.Add(time.Duration(i) * time.Minute)
+		require.Equal(t, expected, dp.Timestamp)
+		require.Equal(t, float64(expected.Unix()), dp.Value)
+	}
+}
+
+func TestResultCacheKey(t *testing.T) {
+	cache := newResultCache(config.ResultCacheConfiguration{
+		MaxBytes: 1 << 20,
+	}, tally.NoopScope)
+
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+	key, ok := cache.cacheKey(newTestResultCacheOptions("foo", start))
+	assert.True(t, ok)
+
+	// The key does not depend on the time range of the query.
+	later, ok := cache.cacheKey(newTestResultCacheOptions("foo", start.Add(time.Hour)))
+	assert.True(t, ok)
+	assert.Equal(t, key, later)
+
+	unaligned := newTestResultCacheOptions("foo", start.Add(time.Second))
+	_, ok = cache.cacheKey(unaligned)
+	assert.False(t, ok)
+
+	restricted := newTestResultCacheOptions("foo", start)
+	restricted.FetchOpts.RestrictQueryOptions = &storage.RestrictQueryOptions{}
+	_, ok = cache.cacheKey(restricted)
+	assert.False(t, ok)
+}
+
+func TestResultCacheReadMergesCachedSteps(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	cache := newResultCache(config.ResultCacheConfiguration{
+		MaxBytes: 1 << 20,
+		MinAge:   5 * time.Minute,
+	}, scope)
+	reads := &testResultCacheReads{meta: block.NewResultMetadata()}
+	ctx := context.Background()
+
+	// A query ending at now caches all but its most recent steps.
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+	result, err := cache.read(ctx, newTestResultCacheOptions("foo", start), reads.read)
+	require.NoError(t, err)
+	requireTestResultCacheValues(t, result, start, 61)
+	require.Equal(t, [][2]time.Time{{start, start.Add(time.Hour)}}, reads.ranges)
+
+	// Refreshing the sliding window only evaluates the steps not cached.
+	reads.ranges = nil
+	refreshed := newTestResultCacheOptions("foo", start.Add(10*time.Minute))
+	result, err = cache.read(ctx, refreshed, reads.read)
+	require.NoError(t, err)
+	requireTestResultCacheValues(t, result, start.Add(10*time.Minute), 61)
+	require.Equal(t, [][2]time.Time{
+		{start.Add(56 * time.Minute), start.Add(70 * time.Minute)},
+	}, reads.ranges)
+
+	// Steps before the cached ones are evaluated and merged too.
+	reads.ranges = nil
+	earlier := newTestResultCacheOptions("foo", start)
+	earlier.Params.End = start.Add(30 * time.Minute)
+	earlier.Params.Now = start.Add(70 * time.Minute)
+	result, err = cache.read(ctx, earlier, reads.read)
+	require.NoError(t, err)
+	requireTestResultCacheValues(t, result, start, 31)
+	require.Equal(t, [][2]time.Time{
+		{start, start.Add(9 * time.Minute)},
+	}, reads.ranges)
+
+	// Queries entirely within the minimum age are not cached.
+	reads.ranges = nil
+	recent := newTestResultCacheOptions("bar", start)
+	recent.Params.End = start.Add(2 * time.Minute)
+	recent.Params.Now = start.Add(2 * time.Minute)
+	_, err = cache.read(ctx, recent, reads.read)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(reads.ranges))
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["result-cache.hits+"].Value())
+	assert.Equal(t, int64(1), counters["result-cache.misses+"].Value())
+}
+
+func TestResultCacheSetBoundsBytes(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	reads := &testResultCacheReads{meta: block.NewResultMetadata()}
+	start := time.Unix(0, 0).Add(1000 * time.Hour)
+
+	result, err := reads.read(context.Background(),
+		newTestResultCacheOptions("foo", start))
+	require.NoError(t, err)
+	extent := newResultCacheExtent(result, start, start.Add(time.Hour), time.Minute)
+
+	cache := newResultCache(config.ResultCacheConfiguration{
+		MaxBytes: extent.size() + extent.size()/2,
+	}, scope)
+	fooKey, ok := cache.cacheKey(newTestResultCacheOptions("foo", start))
+	require.True(t, ok)
+	barKey, ok := cache.cacheKey(newTestResultCacheOptions("bar", start))
+	require.True(t, ok)
+
+	// Non-exhaustive results are never cached.
+	partial := *extent
+	partial.meta.Exhaustive = false
+	cache.set(fooKey, &partial)
+	_, ok = cache.get(fooKey)
+	assert.False(t, ok)
+
+	cache.set(fooKey, extent)
+	_, ok = cache.get(fooKey)
+	require.True(t, ok)
+
+	// Adding another extent exceeds the bytes bound and evicts the least
+	// recently used one.
+	cache.set(barKey, extent)
+	_, ok = cache.get(fooKey)
+	assert.False(t, ok)
+	_, ok = cache.get(barKey)
+	assert.True(t, ok)
+
+	snapshot := scope.Snapshot()
+	counters := snapshot.Counters()
+	assert.Equal(t, int64(1), counters["result-cache.uncached+"].Value())
+	assert.Equal(t, int64(1), counters["result-cache.evictions+"].Value())
+	assert.Equal(t, float64(extent.size()),
+		snapshot.Gauges()["result-cache.size-bytes+"].Value())
+}
@@ -0,0 +1,455 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	clusterclient "github.com/m3db/m3/src/cluster/client"
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/dbnode/kvconfig"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	nshandler "github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/m3"
+	"github.com/m3db/m3/src/query/util/logging"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DeleteSeriesURL is the url for the delete series handler.
+	DeleteSeriesURL = handler.RoutePrefixV1 + "/admin/tsdb/delete_series"
+
+	// SeriesTombstonesURL is the url for the series tombstones handler.
+	SeriesTombstonesURL = handler.RoutePrefixV1 + "/admin/tsdb/tombstones"
+
+	maxTombstonesUpdateAttempts = 5
+
+	// maxSeriesTombstonesBytes bounds the size of the KV value holding the
+	// series tombstones, which every M3DB node watches and holds in memory,
+	// well below the etcd request size limit.
+	maxSeriesTombstonesBytes = 1 << 20
+)
+
+var (
+	// DeleteSeriesHTTPMethods are the HTTP methods for the delete series
+	// handler.
+	DeleteSeriesHTTPMethods = []string{http.MethodPost, http.MethodPut}
+
+	// SeriesTombstonesHTTPMethods are the HTTP methods for the series
+	// tombstones handler.
+	SeriesTombstonesHTTPMethods = []string{http.MethodGet, http.MethodDelete}
+
+	errDeleteSeriesNoClusters = errors.New(
+		"deleting series requires connecting to M3DB clusters directly")
+	errTombstoneIDRequired    = errors.New("tombstone id is required")
+	errTombstonesUpdateFailed = errors.New(
+		"series tombstones were concurrently updated, retry the request")
+)
+
+// SeriesTombstonesResponse is the response of the series tombstones
+// handlers.
+type SeriesTombstonesResponse struct {
+	Tombstones []namespace.SeriesTombstone `json:"tombstones"`
+}
+
+// DeleteSeriesHandler represents a handler for the delete series endpoint.
+type DeleteSeriesHandler struct {
+	clusters            m3.Clusters
+	clusterClient       clusterclient.Client
+	tagOptions          models.TagOptions
+	fetchOptionsBuilder handleroptions.FetchOptionsBuilder
+	nowFn               func() time.Time
+	instrumentOpts      instrument.Options
+}
+
+// NewDeleteSeriesHandler returns a new handler that deletes the datapoints
+// of the series matching the match[] selectors within the start and end
+// params. The matching series of each namespace are recorded in a series
+// tombstone, which M3DB nodes filter from reads immediately and purge from
+// disk when the blocks holding the datapoints are next merged.
+func NewDeleteSeriesHandler(opts options.HandlerOptions) http.Handler {
+	return &DeleteSeriesHandler{
+		clusters:            opts.Clusters(),
+		clusterClient:       opts.ClusterClient(),
+		tagOptions:          opts.TagOptions(),
+		fetchOptionsBuilder: opts.FetchOptionsBuilder(),
+		nowFn:               opts.NowFn(),
+		instrumentOpts:      opts.InstrumentOpts(),
+	}
+}
+
+func (h *DeleteSeriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+
+	if h.clusters == nil || h.clusterClient == nil {
+		xhttp.Error(w, errDeleteSeriesNoClusters, http.StatusBadRequest)
+		return
+	}
+
+	queries, rErr := prometheus.ParseSeriesMatchQuery(r, h.tagOptions)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	opts, rErr := h.fetchOptionsBuilder.NewFetchOptions(r)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	tombstones, err := h.newTombstones(r, queries, opts)
+	if err != nil {
+		logger.Error("unable to resolve series to delete", zap.Error(err))
+		code := http.StatusInternalServerError
+		if xerrors.IsInvalidParams(err) {
+			code = http.StatusBadRequest
+		}
+		xhttp.Error(w, err, code)
+		return
+	}
+
+	if len(tombstones) > 0 {
+		store, err := h.clusterClient.KV()
+		if err != nil {
+			logger.Error("unable to get kv store", zap.Error(err))
+			xhttp.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		_, err = updateSeriesTombstones(store,
+			func(current []namespace.SeriesTombstone) []namespace.SeriesTombstone {
+				return append(current, tombstones...)
+			})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if xerrors.IsInvalidParams(err) {
+				code = http.StatusBadRequest
+			} else {
+				logger.Error("unable to update series tombstones", zap.Error(err))
+			}
+			xhttp.Error(w, err, code)
+			return
+		}
+	}
+
+	if tombstones == nil {
+		tombstones = []namespace.SeriesTombstone{}
+	}
+	xhttp.WriteJSONResponse(w, SeriesTombstonesResponse{
+		Tombstones: tombstones,
+	}, logger)
+}
+
+// newTombstones resolves the series matching the queries in each namespace
+// to a tombstone per namespace.
+func (h *DeleteSeriesHandler) newTombstones(
+	r *http.Request,
+	queries []*storage.FetchQuery,
+	opts *storage.FetchOptions,
+) ([]namespace.SeriesTombstone, error) {
+	var (
+		now      = h.nowFn()
+		matchers = r.Form["match[]"]
+		// Queries share the same time range.
+		start, end = queries[0].Start, queries[0].End
+		tombstones []namespace.SeriesTombstone
+	)
+	if !start.Before(end) {
+		return nil, xerrors.NewInvalidParamsError(fmt.Errorf(
+			"start %v must be before end %v", start, end))
+	}
+
+	for _, ns := range h.clusters.ClusterNamespaces() {
+		seriesIDs := make(map[string]struct{})
+		for _, query := range queries {
+			if err := resolveSeriesIDs(ns, query, opts, seriesIDs); err != nil {
+				return nil, err
+			}
+		}
+		if len(seriesIDs) == 0 {
+			continue
+		}
+
+		nsID := ns.NamespaceID().String()
+		tombstone := namespace.SeriesTombstone{
+			ID:        strconv.FormatInt(now.UnixNano(), 36) + "-" + nsID,
+			Namespace: nsID,
+			Matchers:  matchers,
+			SeriesIDs: make([]string, 0, len(seriesIDs)),
+			Start:     start,
+			End:       end,
+			CreatedAt: now,
+		}
+		for id := range seriesIDs {
+			tombstone.SeriesIDs = append(tombstone.SeriesIDs, id)
+		}
+		sort.Strings(tombstone.SeriesIDs)
+		tombstones = append(tombstones, tombstone)
+	}
+	return tombstones, nil
+}
+
+func resolveSeriesIDs(
+	ns m3.ClusterNamespace,
+	query *storage.FetchQuery,
+	opts *storage.FetchOptions,
+	seriesIDs map[string]struct{},
+) error {
+	m3query, err := storage.FetchQueryToM3Query(query, opts)
+	if err != nil {
+		return err
+	}
+	queryOpts := storage.FetchOptionsToM3Options(opts, query)
+
+	iter, metadata, err := ns.Session().FetchTaggedIDs(ns.NamespaceID(),
+		m3query, queryOpts)
+	if err != nil {
+		return err
+	}
+	defer iter.Finalize()
+
+	for iter.Next() {
+		_, id, _ := iter.Current()
+		seriesIDs[id.String()] = struct{}{}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if !metadata.Exhaustive {
+		// Deleting a subset of the matching series is never intended.
+		return xerrors.NewInvalidParamsError(fmt.Errorf(
+			"%s matches more series than the series limit in namespace %s, "+
+				"narrow the matchers or raise the limit",
+			query.Raw, ns.NamespaceID().String()))
+	}
+	return nil
+}
+
+// SeriesTombstonesHandler represents a handler for the series tombstones
+// endpoint.
+type SeriesTombstonesHandler struct {
+	clusterClient  clusterclient.Client
+	nowFn          func() time.Time
+	instrumentOpts instrument.Options
+}
+
+// NewSeriesTombstonesHandler returns a new handler that lists the series
+// tombstones, or removes the tombstone with the id param once the datapoints
+// it deletes have expired.
+func NewSeriesTombstonesHandler(opts options.HandlerOptions) http.Handler {
+	return &SeriesTombstonesHandler{
+		clusterClient:  opts.ClusterClient(),
+		nowFn:          opts.NowFn(),
+		instrumentOpts: opts.InstrumentOpts(),
+	}
+}
+
+func (h *SeriesTombstonesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+
+	if h.clusterClient == nil {
+		xhttp.Error(w, errDeleteSeriesNoClusters, http.StatusBadRequest)
+		return
+	}
+
+	store, err := h.clusterClient.KV()
+	if err != nil {
+		logger.Error("unable to get kv store", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	var tombstones []namespace.SeriesTombstone
+	switch r.Method {
+	case http.MethodDelete:
+		id := r.FormValue("id")
+		if id == "" {
+			xhttp.Error(w, errTombstoneIDRequired, http.StatusBadRequest)
+			return
+		}
+		if err := h.checkTombstoneExpired(store, id); err != nil {
+			code := http.StatusInternalServerError
+			if xerrors.IsInvalidParams(err) {
+				code = http.StatusBadRequest
+			} else {
+				logger.Error("unable to check series tombstone", zap.Error(err))
+			}
+			xhttp.Error(w, err, code)
+			return
+		}
+		found := false
+		tombstones, err = updateSeriesTombstones(store,
+			func(current []namespace.SeriesTombstone) []namespace.SeriesTombstone {
+				found = false
+				updated := make([]namespace.SeriesTombstone, 0, len(current))
+				for _, t := range current {
+					if t.ID == id {
+						found = true
+						continue
+					}
+					updated = append(updated, t)
+				}
+				return updated
+			})
+		if err == nil && !found {
+			xhttp.Error(w, fmt.Errorf("tombstone %s not found", id),
+				http.StatusNotFound)
+			return
+		}
+	default:
+		tombstones, _, err = getSeriesTombstones(store)
+	}
+	if err != nil {
+		logger.Error("unable to access series tombstones", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if tombstones == nil {
+		tombstones = []namespace.SeriesTombstone{}
+	}
+	xhttp.WriteJSONResponse(w, SeriesTombstonesResponse{
+		Tombstones: tombstones,
+	}, logger)
+}
+
+// checkTombstoneExpired returns an error unless the datapoints deleted by the
+// tombstone have expired from its namespace, since removing the tombstone
+// before then makes any datapoints not yet purged from disk readable again.
+// Tombstones of namespaces which no longer exist can always be removed.
+func (h *SeriesTombstonesHandler) checkTombstoneExpired(
+	store kv.Store,
+	id string,
+) error {
+	tombstones, _, err := getSeriesTombstones(store)
+	if err != nil {
+		return err
+	}
+	metadatas, _, err := nshandler.Metadata(store)
+	if err != nil {
+		return err
+	}
+
+	now := h.nowFn()
+	for _, t := range tombstones {
+		if t.ID != id {
+			continue
+		}
+		for _, md := range metadatas {
+			if md.ID().String() != t.Namespace {
+				continue
+			}
+			expiresAt := t.End.Add(md.Options().RetentionOptions().RetentionPeriod())
+			if now.Before(expiresAt) {
+				return xerrors.NewInvalidParamsError(fmt.Errorf(
+					"tombstone %s deletes datapoints retained until %v",
+					id, expiresAt))
+			}
+		}
+	}
+	return nil
+}
+
+// getSeriesTombstones returns the series tombstones and the version of the
+// KV key holding them, which is zero if the key is not set.
+func getSeriesTombstones(
+	store kv.Store,
+) ([]namespace.SeriesTombstone, int, error) {
+	value, err := store.Get(kvconfig.SeriesTombstonesKey)
+	if err == kv.ErrNotFound {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var proto commonpb.StringProto
+	if err := value.Unmarshal(&proto); err != nil {
+		return nil, 0, err
+	}
+	tombstones, err := namespace.ParseSeriesTombstones(proto.Value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return tombstones.Tombstones(), value.Version(), nil
+}
+
+// updateSeriesTombstones applies an update to the series tombstones, retrying
+// if they are concurrently updated.
+func updateSeriesTombstones(
+	store kv.Store,
+	update func(current []namespace.SeriesTombstone) []namespace.SeriesTombstone,
+) ([]namespace.SeriesTombstone, error) {
+	for attempt := 0; attempt < maxTombstonesUpdateAttempts; attempt++ {
+		current, version, err := getSeriesTombstones(store)
+		if err != nil {
+			return nil, err
+		}
+
+		updated := update(current)
+		if _, err := namespace.NewSeriesTombstones(updated); err != nil {
+			return nil, err
+		}
+		str, err := namespace.MarshalSeriesTombstones(updated)
+		if err != nil {
+			return nil, err
+		}
+		if len(str) > maxSeriesTombstonesBytes && len(updated) >= len(current) {
+			return nil, xerrors.NewInvalidParamsError(fmt.Errorf(
+				"series tombstones exceed %d bytes, narrow the matchers or "+
+					"remove tombstones of expired datapoints", maxSeriesTombstonesBytes))
+		}
+
+		value := &commonpb.StringProto{Value: str}
+		if version == 0 {
+			_, err = store.SetIfNotExists(kvconfig.SeriesTombstonesKey, value)
+		} else {
+			_, err = store.CheckAndSet(kvconfig.SeriesTombstonesKey, version, value)
+		}
+		if err == kv.ErrAlreadyExists || err == kv.ErrVersionMismatch {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+	return nil, errTombstonesUpdateFailed
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	clusterclient "github.com/m3db/m3/src/cluster/client"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	nshandler "github.com/m3db/m3/src/query/api/v1/handler/namespace"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/storage/m3"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTaggedIDsIterator(
+	ctrl *gomock.Controller,
+	ns string,
+	ids ...string,
+) client.TaggedIDsIterator {
+	iter := client.NewMockTaggedIDsIterator(ctrl)
+	for _, id := range ids {
+		iter.EXPECT().Next().Return(true)
+		iter.EXPECT().Current().Return(ident.StringID(ns), ident.StringID(id),
+			ident.EmptyTagIterator)
+	}
+	iter.EXPECT().Next().Return(false)
+	iter.EXPECT().Err().Return(nil)
+	iter.EXPECT().Finalize()
+	return iter
+}
+
+func TestDeleteSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	clusters, err := m3.NewClusters(m3.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("unagg"),
+		Retention:   48 * time.Hour,
+		Session:     session,
+	}, m3.AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("agg"),
+		Retention:   720 * time.Hour,
+		Resolution:  time.Minute,
+		Session:     session,
+	})
+	require.NoError(t, err)
+
+	session.EXPECT().
+		FetchTaggedIDs(ident.NewIDMatcher("unagg"), gomock.Any(), gomock.Any()).
+		Return(newTestTaggedIDsIterator(ctrl, "unagg", "foo", "bar"),
+			client.FetchResponseMetadata{Exhaustive: true}, nil)
+	session.EXPECT().
+		FetchTaggedIDs(ident.NewIDMatcher("unagg"), gomock.Any(), gomock.Any()).
+		Return(newTestTaggedIDsIterator(ctrl, "unagg", "foo"),
+			client.FetchResponseMetadata{Exhaustive: true}, nil)
+	for i := 0; i < 2; i++ {
+		session.EXPECT().
+			FetchTaggedIDs(ident.NewIDMatcher("agg"), gomock.Any(), gomock.Any()).
+			Return(newTestTaggedIDsIterator(ctrl, "agg"),
+				client.FetchResponseMetadata{Exhaustive: true}, nil)
+	}
+
+	store := mem.NewStore()
+	clusterClient := clusterclient.NewMockClient(ctrl)
+	clusterClient.EXPECT().KV().Return(store, nil).AnyTimes()
+
+	now := time.Unix(1600000000, 0)
+	opts := options.EmptyHandlerOptions().
+		SetClusters(clusters).
+		SetClusterClient(clusterClient).
+		SetNowFn(func() time.Time { return now }).
+		SetFetchOptionsBuilder(handleroptions.
+			NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost,
+		DeleteSeriesURL+"?match[]=foo&match[]=bar&start=1599990000&end=1599996000", nil)
+	NewDeleteSeriesHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp SeriesTombstonesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Tombstones, 1)
+	tombstone := resp.Tombstones[0]
+	assert.Equal(t, "unagg", tombstone.Namespace)
+	assert.Equal(t, []string{"bar", "foo"}, tombstone.SeriesIDs)
+	assert.Equal(t, []string{"foo", "bar"}, tombstone.Matchers)
+	assert.True(t, time.Unix(1599990000, 0).Equal(tombstone.Start))
+	assert.True(t, time.Unix(1599996000, 0).Equal(tombstone.End))
+
+	// The tombstone is written to KV for the M3DB nodes to apply.
+	stored, _, err := getSeriesTombstones(store)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, tombstone.ID, stored[0].ID)
+
+	// List and remove the tombstone.
+	tombstonesHandler := NewSeriesTombstonesHandler(opts)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, SeriesTombstonesURL, nil)
+	tombstonesHandler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Tombstones, 1)
+
+	// The tombstone can't be removed until the datapoints it deletes expire.
+	md, err := namespace.NewMetadata(ident.StringID("unagg"), namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().SetRetentionPeriod(48*time.Hour)))
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+	require.NoError(t, err)
+	_, err = store.Set(nshandler.M3DBNodeNamespacesKey, namespace.ToProto(nsMap))
+	require.NoError(t, err)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete,
+		SeriesTombstonesURL+"?id="+tombstone.ID, nil)
+	tombstonesHandler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	now = time.Unix(1599996000, 0).Add(48 * time.Hour)
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete,
+		SeriesTombstonesURL+"?id="+tombstone.ID, nil)
+	tombstonesHandler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Tombstones)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodDelete,
+		SeriesTombstonesURL+"?id="+tombstone.ID, nil)
+	tombstonesHandler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteSeriesNotExhaustive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	clusters, err := m3.NewClusters(m3.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("unagg"),
+		Retention:   48 * time.Hour,
+		Session:     session,
+	})
+	require.NoError(t, err)
+
+	session.EXPECT().
+		FetchTaggedIDs(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(newTestTaggedIDsIterator(ctrl, "unagg", "foo"),
+			client.FetchResponseMetadata{Exhaustive: false}, nil)
+
+	opts := options.EmptyHandlerOptions().
+		SetClusters(clusters).
+		SetClusterClient(clusterclient.NewMockClient(ctrl)).
+		SetFetchOptionsBuilder(handleroptions.
+			NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, DeleteSeriesURL+"?match[]=foo", nil)
+	NewDeleteSeriesHandler(opts).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateSeriesTombstonesInvalid(t *testing.T) {
+	_, err := updateSeriesTombstones(mem.NewStore(),
+		func(current []namespace.SeriesTombstone) []namespace.SeriesTombstone {
+			return append(current, namespace.SeriesTombstone{ID: "a"})
+		})
+	require.Error(t, err)
+}
+
+func TestUpdateSeriesTombstonesTooLarge(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	seriesIDs := make([]string, 0, maxSeriesTombstonesBytes/8)
+	for i := 0; i < cap(seriesIDs); i++ {
+		seriesIDs = append(seriesIDs, fmt.Sprintf("series-%d", i))
+	}
+	_, err := updateSeriesTombstones(mem.NewStore(),
+		func(current []namespace.SeriesTombstone) []namespace.SeriesTombstone {
+			return append(current, namespace.SeriesTombstone{
+				ID:        "a",
+				Namespace: "unagg",
+				SeriesIDs: seriesIDs,
+				Start:     start,
+				End:       start.Add(time.Hour),
+			})
+		})
+	require.Error(t, err)
+	require.True(t, xerrors.IsInvalidParams(err))
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PromExemplarsURL is the url for the prom exemplars handler.
+	PromExemplarsURL = handler.RoutePrefixV1 + "/query_exemplars"
+)
+
+var (
+	// PromExemplarsHTTPMethods are the HTTP methods for this handler.
+	PromExemplarsHTTPMethods = []string{http.MethodGet, http.MethodPost}
+)
+
+// PromExemplarsHandler represents a handler for the prometheus exemplars
+// endpoint, returning exemplars received via remote write along with
+// datapoint annotations as exemplar trace IDs.
+type PromExemplarsHandler struct {
+	storage             storage.Storage
+	exemplarStore       exemplar.Store
+	tagOptions          models.TagOptions
+	fetchOptionsBuilder handleroptions.FetchOptionsBuilder
+	instrumentOpts      instrument.Options
+}
+
+// NewPromExemplarsHandler returns a new instance of handler.
+func NewPromExemplarsHandler(opts options.HandlerOptions) http.Handler {
+	return &PromExemplarsHandler{
+		tagOptions:          opts.TagOptions(),
+		storage:             opts.Storage(),
+		exemplarStore:       opts.ExemplarStore(),
+		fetchOptionsBuilder: opts.FetchOptionsBuilder(),
+		instrumentOpts:      opts.InstrumentOpts(),
+	}
+}
+
+func (h *PromExemplarsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+	w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	query, err := prometheus.ParseExemplarsQuery(r, h.tagOptions)
+	if err != nil {
+		logger.Error("unable to parse exemplars query", zap.Error(err))
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	opts, rErr := h.fetchOptionsBuilder.NewFetchOptions(r)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	result, fetchErr := h.storage.FetchProm(ctx, query, opts)
+	if fetchErr != nil {
+		logger.Error("unable to fetch exemplars", zap.Error(fetchErr))
+		xhttp.Error(w, fetchErr, http.StatusBadRequest)
+		return
+	}
+
+	results := annotatedSamplesToExemplars(result.PromResult, h.tagOptions)
+	if h.exemplarStore != nil {
+		stored, err := h.exemplarStore.Query(query.TagMatchers, query.Start, query.End)
+		if err != nil {
+			logger.Error("unable to query exemplar store", zap.Error(err))
+			xhttp.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		results = mergeExemplars(results, stored)
+	}
+
+	handleroptions.AddWarningHeaders(w, result.Metadata)
+	if err := prometheus.RenderExemplarsResultsJSON(w, results, false); err != nil {
+		logger.Error("unable to write exemplars", zap.Error(err))
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+}
+
+// annotatedSamplesToExemplars returns the annotated samples of a fetch
+// result as exemplars, with the annotation as the trace ID.
+func annotatedSamplesToExemplars(
+	result *prompb.QueryResult,
+	tagOptions models.TagOptions,
+) []exemplar.SeriesExemplars {
+	var results []exemplar.SeriesExemplars
+	for _, series := range result.GetTimeseries() {
+		var exemplars []exemplar.Exemplar
+		for _, sample := range series.GetSamples() {
+			if len(sample.Annotation) == 0 {
+				continue
+			}
+			exemplars = append(exemplars, exemplar.Exemplar{
+				Labels: []models.Tag{{
+					Name:  []byte(prometheus.ExemplarTraceIDLabel),
+					Value: sample.Annotation,
+				}},
+				Value:     sample.Value,
+				Timestamp: storage.PromTimestampToTime(sample.Timestamp),
+			})
+		}
+		if len(exemplars) == 0 {
+			continue
+		}
+		results = append(results, exemplar.SeriesExemplars{
+			Tags:      storage.PromLabelsToM3Tags(series.GetLabels(), tagOptions),
+			Exemplars: exemplars,
+		})
+	}
+	return results
+}
+
+// mergeExemplars merges the exemplars of series present in both results.
+func mergeExemplars(
+	results []exemplar.SeriesExemplars,
+	others []exemplar.SeriesExemplars,
+) []exemplar.SeriesExemplars {
+	if len(results) == 0 {
+		return others
+	}
+
+	byID := make(map[string]int, len(results))
+	for i, series := range results {
+		byID[string(series.Tags.ID())] = i
+	}
+	for _, series := range others {
+		idx, ok := byID[string(series.Tags.ID())]
+		if !ok {
+			results = append(results, series)
+			continue
+		}
+		merged := append(results[idx].Exemplars, series.Exemplars...)
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].Timestamp.Before(merged[j].Timestamp)
+		})
+		results[idx].Exemplars = merged
+	}
+	return results
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
+	"github.com/m3db/m3/src/x/instrument"
+	xtest "github.com/m3db/m3/src/x/test"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromExemplarsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStorage(ctrl)
+	fb := handleroptions.
+		NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{})
+	opts := options.EmptyHandlerOptions().
+		SetStorage(store).
+		SetTagOptions(models.NewTagOptions()).
+		SetFetchOptionsBuilder(fb)
+
+	result := storage.PromResult{
+		PromResult: &prompb.QueryResult{
+			Timeseries: []*prompb.TimeSeries{
+				{
+					Labels: []prompb.Label{
+						{Name: []byte("__name__"), Value: []byte("requests")},
+						{Name: []byte("job"), Value: []byte("api")},
+					},
+					Samples: []prompb.Sample{
+						{Timestamp: 1000, Value: 1},
+						{Timestamp: 1500, Value: 2.5, Annotation: []byte("abc123")},
+					},
+				},
+				{
+					Labels: []prompb.Label{
+						{Name: []byte("__name__"), Value: []byte("requests")},
+						{Name: []byte("job"), Value: []byte("db")},
+					},
+					Samples: []prompb.Sample{{Timestamp: 1000, Value: 3}},
+				},
+			},
+		},
+		Metadata: block.NewResultMetadata(),
+	}
+
+	store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ interface{},
+			q *storage.FetchQuery,
+			_ *storage.FetchOptions,
+		) (storage.PromResult, error) {
+			require.Equal(t, 1, len(q.TagMatchers))
+			assert.Equal(t, "requests", string(q.TagMatchers[0].Value))
+			return result, nil
+		})
+
+	req := httptest.NewRequest(http.MethodGet,
+		PromExemplarsURL+"?query=requests&start=0&end=10", nil)
+	w := httptest.NewRecorder()
+	NewPromExemplarsHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expected := xtest.MustPrettyJSONString(t, `{
+		"status": "success",
+		"data": [
+			{
+				"seriesLabels": {"__name__": "requests", "job": "api"},
+				"exemplars": [
+					{"labels": {"trace_id": "abc123"}, "value": "2.5", "timestamp": 1.5}
+				]
+			}
+		]
+	}`)
+	actual := xtest.MustPrettyJSONString(t, w.Body.String())
+	assert.Equal(t, expected, actual, xtest.Diff(expected, actual))
+}
+
+func TestPromExemplarsHandlerMergesStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStorage(ctrl)
+	exemplarStore := exemplar.Configuration{}.NewStore(instrument.NewOptions())
+	fb := handleroptions.
+		NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{})
+	opts := options.EmptyHandlerOptions().
+		SetStorage(store).
+		SetExemplarStore(exemplarStore).
+		SetTagOptions(models.NewTagOptions()).
+		SetFetchOptionsBuilder(fb)
+
+	labels := []prompb.Label{
+		{Name: []byte("__name__"), Value: []byte("requests")},
+		{Name: []byte("job"), Value: []byte("api")},
+	}
+	exemplarStore.Add(
+		storage.PromLabelsToM3Tags(labels, models.NewTagOptions()),
+		[]exemplar.Exemplar{{
+			Labels: []models.Tag{
+				{Name: []byte("trace_id"), Value: []byte("def456")},
+			},
+			Value:     4,
+			Timestamp: time.Unix(1, 0),
+		}},
+	)
+
+	store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(storage.PromResult{
+			PromResult: &prompb.QueryResult{
+				Timeseries: []*prompb.TimeSeries{{
+					Labels: labels,
+					Samples: []prompb.Sample{
+						{Timestamp: 2000, Value: 2, Annotation: []byte("abc123")},
+					},
+				}},
+			},
+			Metadata: block.NewResultMetadata(),
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodGet,
+		PromExemplarsURL+"?query=requests&start=0&end=10", nil)
+	w := httptest.NewRecorder()
+	NewPromExemplarsHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	expected := xtest.MustPrettyJSONString(t, `{
+		"status": "success",
+		"data": [
+			{
+				"seriesLabels": {"__name__": "requests", "job": "api"},
+				"exemplars": [
+					{"labels": {"trace_id": "def456"}, "value": "4", "timestamp": 1},
+					{"labels": {"trace_id": "abc123"}, "value": "2", "timestamp": 2}
+				]
+			}
+		]
+	}`)
+	actual := xtest.MustPrettyJSONString(t, w.Body.String())
+	assert.Equal(t, expected, actual, xtest.Diff(expected, actual))
+}
+
+func TestPromExemplarsHandlerMissingQuery(t *testing.T) {
+	opts := options.EmptyHandlerOptions().
+		SetTagOptions(models.NewTagOptions())
+
+	req := httptest.NewRequest(http.MethodGet, PromExemplarsURL, nil)
+	w := httptest.NewRecorder()
+	NewPromExemplarsHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+)
+
+const (
+	bucketSuffix = "_bucket"
+	sumSuffix    = "_sum"
+	countSuffix  = "_count"
+	leTagName    = "le"
+	infBound     = "+Inf"
+)
+
+type nativeHistogramSeries struct {
+	tags       models.Tags
+	datapoints ts.Datapoints
+	attributes ts.SeriesAttributes
+}
+
+type nativeHistogramBuilder struct {
+	base       models.Tags
+	name       []byte
+	attributes ts.SeriesAttributes
+	series     []nativeHistogramSeries
+	indexes    map[string]int
+}
+
+// expandNativeHistograms expands the native histograms of a time series
+// into classic cumulative histogram series, that is one "_bucket" series
+// per "le" bound along with "_sum" and "_count" series, so that they can
+// be stored as regular series and evaluated with histogram_quantile.
+func expandNativeHistograms(
+	promTS prompb.TimeSeries,
+	attributes ts.SeriesAttributes,
+	tagOpts models.TagOptions,
+) []nativeHistogramSeries {
+	base := storage.PromLabelsToM3Tags(promTS.Labels, tagOpts)
+	name, ok := base.Name()
+	if !ok || len(promTS.Histograms) == 0 {
+		return nil
+	}
+
+	b := &nativeHistogramBuilder{
+		base:       base,
+		name:       name,
+		attributes: attributes,
+		indexes:    make(map[string]int),
+	}
+	for _, h := range promTS.Histograms {
+		b.addHistogram(h)
+	}
+	return b.series
+}
+
+func (b *nativeHistogramBuilder) addHistogram(h prompb.Histogram) {
+	var (
+		timestamp  = storage.PromTimestampToTime(h.Timestamp)
+		metricType = ts.MetricTypeCounter
+		count      = float64(h.CountInt)
+		zeroCount  = float64(h.ZeroCountInt)
+	)
+	if h.ResetHint == prompb.Histogram_GAUGE {
+		metricType = ts.MetricTypeGauge
+	}
+	if isFloatHistogram(h) {
+		count = h.CountFloat
+		zeroCount = h.ZeroCountFloat
+	}
+
+	// Negative and zero observations fall below every positive bound.
+	cumulative := zeroCount
+	_, negative := expandBuckets(h.NegativeSpans, h.NegativeDeltas, h.NegativeCounts)
+	for _, v := range negative {
+		cumulative += v
+	}
+
+	// Bucket index i covers (base^(i-1), base^i] where
+	// base = 2^(2^-schema).
+	base := math.Exp2(math.Exp2(-float64(h.Schema)))
+	indexes, positive := expandBuckets(h.PositiveSpans, h.PositiveDeltas, h.PositiveCounts)
+	for i, v := range positive {
+		cumulative += v
+		bound := formatBound(math.Pow(base, float64(indexes[i])))
+		b.add(bucketSuffix, bound, timestamp, cumulative, metricType)
+	}
+	b.add(bucketSuffix, infBound, timestamp, count, metricType)
+	b.add(sumSuffix, "", timestamp, h.Sum, metricType)
+	b.add(countSuffix, "", timestamp, count, metricType)
+}
+
+func (b *nativeHistogramBuilder) add(
+	suffix string,
+	bound string,
+	timestamp time.Time,
+	value float64,
+	metricType ts.MetricType,
+) {
+	key := suffix + bound
+	idx, ok := b.indexes[key]
+	if !ok {
+		tags := b.base.Clone().SetName([]byte(string(b.name) + suffix))
+		if bound != "" {
+			tags = tags.AddOrUpdateTag(models.Tag{
+				Name:  []byte(leTagName),
+				Value: []byte(bound),
+			})
+		}
+		attributes := b.attributes
+		attributes.Type = metricType
+
+		idx = len(b.series)
+		b.indexes[key] = idx
+		b.series = append(b.series, nativeHistogramSeries{
+			tags:       tags,
+			attributes: attributes,
+		})
+	}
+
+	b.series[idx].datapoints = append(b.series[idx].datapoints, ts.Datapoint{
+		Timestamp: timestamp,
+		Value:     value,
+	})
+}
+
+// isFloatHistogram returns whether the histogram uses float counts rather
+// than integer counts and deltas.
+func isFloatHistogram(h prompb.Histogram) bool {
+	return len(h.PositiveCounts) > 0 || len(h.NegativeCounts) > 0 ||
+		h.CountFloat != 0 || h.ZeroCountFloat != 0
+}
+
+// expandBuckets resolves the spans of a native histogram into the absolute
+// bucket indexes and counts, counts are given either as deltas to the
+// previous bucket for integer histograms or as absolute float counts.
+func expandBuckets(
+	spans []prompb.BucketSpan,
+	deltas []int64,
+	counts []float64,
+) ([]int32, []float64) {
+	n := len(deltas)
+	if len(counts) > 0 {
+		n = len(counts)
+	}
+
+	var (
+		indexes = make([]int32, 0, n)
+		values  = make([]float64, 0, n)
+		index   int32
+		current int64
+	)
+	for _, span := range spans {
+		// The offset of the first span is the index of its first bucket,
+		// subsequent offsets are the gap to the end of the previous span.
+		index += span.Offset
+		for j := uint32(0); j < span.Length; j++ {
+			i := len(values)
+			if i >= n {
+				return indexes, values
+			}
+			if len(counts) > 0 {
+				values = append(values, counts[i])
+			} else {
+				current += deltas[i]
+				values = append(values, float64(current))
+			}
+			indexes = append(indexes, index)
+			index++
+		}
+	}
+	return indexes, values
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
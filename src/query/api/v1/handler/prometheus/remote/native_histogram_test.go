@@ -0,0 +1,159 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/require"
+)
+
+type expectedSeries struct {
+	name   string
+	le     string
+	values []float64
+}
+
+func requireSeries(t *testing.T, expected []expectedSeries, iter *promTSIter) {
+	require.Equal(t, len(expected), len(iter.tags))
+	for i, ex := range expected {
+		name, ok := iter.tags[i].Name()
+		require.True(t, ok)
+		require.Equal(t, ex.name, string(name))
+
+		le, ok := iter.tags[i].Get([]byte(leTagName))
+		require.Equal(t, ex.le != "", ok)
+		require.Equal(t, ex.le, string(le))
+
+		job, ok := iter.tags[i].Get([]byte("job"))
+		require.True(t, ok)
+		require.Equal(t, "api", string(job))
+
+		require.Equal(t, ex.values, iter.datapoints[i].Values())
+	}
+}
+
+func TestNativeHistogramIntegerBuckets(t *testing.T) {
+	iter, err := newPromTSIter([]prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: []byte("__name__"), Value: []byte("latency")},
+			{Name: []byte("job"), Value: []byte("api")},
+		},
+		Histograms: []prompb.Histogram{{
+			CountInt:       7,
+			Sum:            10,
+			Schema:         0,
+			ZeroThreshold:  0.001,
+			ZeroCountInt:   2,
+			NegativeSpans:  []prompb.BucketSpan{{Offset: 0, Length: 1}},
+			NegativeDeltas: []int64{1},
+			// Buckets at indexes 0, 1 and 3 with counts 1, 2 and 1.
+			PositiveSpans: []prompb.BucketSpan{
+				{Offset: 0, Length: 2},
+				{Offset: 1, Length: 1},
+			},
+			PositiveDeltas: []int64{1, 1, -1},
+			Timestamp:      1000,
+		}},
+	}}, models.NewTagOptions())
+	require.NoError(t, err)
+
+	requireSeries(t, []expectedSeries{
+		{name: "latency_bucket", le: "1", values: []float64{4}},
+		{name: "latency_bucket", le: "2", values: []float64{6}},
+		{name: "latency_bucket", le: "8", values: []float64{7}},
+		{name: "latency_bucket", le: "+Inf", values: []float64{7}},
+		{name: "latency_sum", values: []float64{10}},
+		{name: "latency_count", values: []float64{7}},
+	}, iter)
+	for _, attrs := range iter.attributes {
+		require.Equal(t, ts.MetricTypeCounter, attrs.Type)
+	}
+}
+
+func TestNativeHistogramFloatBuckets(t *testing.T) {
+	iter, err := newPromTSIter([]prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: []byte("__name__"), Value: []byte("queue")},
+			{Name: []byte("job"), Value: []byte("api")},
+		},
+		Histograms: []prompb.Histogram{
+			{
+				CountFloat:     2.5,
+				Sum:            9,
+				Schema:         -1,
+				ZeroCountFloat: 0.5,
+				PositiveSpans:  []prompb.BucketSpan{{Offset: 1, Length: 2}},
+				PositiveCounts: []float64{1.5, 0.5},
+				ResetHint:      prompb.Histogram_GAUGE,
+				Timestamp:      1000,
+			},
+			{
+				CountFloat:     1,
+				Sum:            4,
+				Schema:         -1,
+				PositiveSpans:  []prompb.BucketSpan{{Offset: 1, Length: 1}},
+				PositiveCounts: []float64{1},
+				ResetHint:      prompb.Histogram_GAUGE,
+				Timestamp:      2000,
+			},
+		},
+	}}, models.NewTagOptions())
+	require.NoError(t, err)
+
+	requireSeries(t, []expectedSeries{
+		{name: "queue_bucket", le: "4", values: []float64{2, 1}},
+		{name: "queue_bucket", le: "16", values: []float64{2.5}},
+		{name: "queue_bucket", le: "+Inf", values: []float64{2.5, 1}},
+		{name: "queue_sum", values: []float64{9, 4}},
+		{name: "queue_count", values: []float64{2.5, 1}},
+	}, iter)
+	for _, attrs := range iter.attributes {
+		require.Equal(t, ts.MetricTypeGauge, attrs.Type)
+	}
+}
+
+func TestNativeHistogramKeepsSamples(t *testing.T) {
+	iter, err := newPromTSIter([]prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: []byte("__name__"), Value: []byte("requests")},
+			{Name: []byte("job"), Value: []byte("api")},
+		},
+		Samples: []prompb.Sample{{Value: 3, Timestamp: 1000}},
+		Histograms: []prompb.Histogram{{
+			CountInt:  1,
+			Sum:       1,
+			Timestamp: 1000,
+		}},
+	}}, models.NewTagOptions())
+	require.NoError(t, err)
+
+	requireSeries(t, []expectedSeries{
+		{name: "requests", values: []float64{3}},
+		{name: "requests_bucket", le: "+Inf", values: []float64{1}},
+		{name: "requests_sum", values: []float64{1}},
+		{name: "requests_count", values: []float64{1}},
+	}, iter)
+}
@@ -121,15 +121,33 @@ func (h *promReadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	cancelWatcher := handler.NewResponseWriterCanceller(w, h.opts.InstrumentOpts())
 	readResult, err := Read(ctx, cancelWatcher, req, fetchOpts, h.opts)
 	if err != nil {
-		h.promReadMetrics.fetchErrorsServer.Inc(1)
+		status := http.StatusInternalServerError
+		if isInvalidParams(err) {
+			// Bad matchers or exceeded query limits will fail again if
+			// retried, so surface them as client errors.
+			status = http.StatusBadRequest
+			h.promReadMetrics.fetchErrorsClient.Inc(1)
+		} else {
+			h.promReadMetrics.fetchErrorsServer.Inc(1)
+		}
 		logger.Error("remote read query error",
 			zap.Error(err),
+			zap.Int("httpResponseStatusCode", status),
 			zap.Any("req", req),
 			zap.Any("fetchOpts", fetchOpts))
-		xhttp.Error(w, err, http.StatusInternalServerError)
+		xhttp.Error(w, err, status)
 		return
 	}
 
+	var series, datapoints int
+	for _, result := range readResult.Result {
+		series += len(result.Timeseries)
+		for _, s := range result.Timeseries {
+			datapoints += len(s.Samples)
+		}
+	}
+	logging.RecordRequestStats(ctx, series, datapoints)
+
 	// NB: if this errors, all relevant headers and information should already
 	// be sent to the writer; so it is not necessary to do anything here other
 	// than increment success/failure metrics.
@@ -431,7 +449,7 @@ func Read(
 			query, err := storage.PromReadQueryToM3(promQuery)
 			if err != nil {
 				mu.Lock()
-				multiErr = multiErr.Add(err)
+				multiErr = multiErr.Add(xerrors.NewInvalidParamsError(err))
 				mu.Unlock()
 				return
 			}
@@ -470,6 +488,22 @@ func Read(
 	return ReadResult{Result: queryResults, Meta: meta}, nil
 }
 
+// isInvalidParams returns whether the error, or every error of a multi
+// error from reading several queries, is an invalid params error.
+func isInvalidParams(err error) bool {
+	multiErr, ok := err.(xerrors.MultiError)
+	if !ok {
+		return xerrors.IsInvalidParams(err)
+	}
+
+	for _, err := range multiErr.Errors() {
+		if !xerrors.IsInvalidParams(err) {
+			return false
+		}
+	}
+	return !multiErr.Empty()
+}
+
 // filterResults removes series tags based on options.
 func filterResults(
 	series []*prompb.TimeSeries,
@@ -23,6 +23,7 @@ package remote
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -49,6 +50,7 @@ import (
 	"github.com/m3db/m3/src/query/test"
 	"github.com/m3db/m3/src/query/test/m3"
 	xclock "github.com/m3db/m3/src/x/clock"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/instrument"
 	xhttp "github.com/m3db/m3/src/x/net/http"
 	xtest "github.com/m3db/m3/src/x/test"
@@ -316,6 +318,53 @@ func TestReadErrorMetricsCount(t *testing.T) {
 	require.True(t, foundMetric)
 }
 
+func TestReadInvalidParamsErrorIsClientError(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	engine := executor.NewMockEngine(ctrl)
+	engine.EXPECT().
+		ExecuteProm(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(storage.PromResult{}, xerrors.NewInvalidParamsError(errors.New("limit exceeded")))
+
+	scope := tally.NewTestScope("", nil)
+	buildOpts := handleroptions.FetchOptionsBuilderOptions{
+		Limits: handleroptions.FetchOptionsBuilderLimitsOptions{
+			SeriesLimit: 100,
+		},
+	}
+	opts := options.EmptyHandlerOptions().
+		SetEngine(engine).
+		SetTimeoutOpts(&prometheus.TimeoutOpts{FetchTimeout: time.Minute}).
+		SetFetchOptionsBuilder(handleroptions.NewFetchOptionsBuilder(buildOpts))
+	promRead := &promReadHandler{
+		promReadMetrics: newPromReadMetrics(scope),
+		opts:            opts,
+	}
+
+	req := httptest.NewRequest("POST", PromReadURL, test.GeneratePromReadBody(t))
+	recorder := httptest.NewRecorder()
+	promRead.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["fetch.errors+code=4XX"].Value())
+	assert.Equal(t, int64(0), counters["fetch.errors+code=5XX"].Value())
+}
+
+func TestReadIsInvalidParams(t *testing.T) {
+	invalid := xerrors.NewInvalidParamsError(errors.New("bad matcher"))
+	other := errors.New("storage error")
+
+	assert.True(t, isInvalidParams(invalid))
+	assert.False(t, isInvalidParams(other))
+	assert.True(t, isInvalidParams(xerrors.NewMultiError().Add(invalid).FinalError()))
+	assert.True(t, isInvalidParams(xerrors.NewMultiError().
+		Add(invalid).Add(invalid).FinalError()))
+	assert.False(t, isInvalidParams(xerrors.NewMultiError().
+		Add(invalid).Add(other).FinalError()))
+}
+
 func TestMultipleRead(t *testing.T) {
 	ctrl := xtest.NewController(t)
 	defer ctrl.Finish()
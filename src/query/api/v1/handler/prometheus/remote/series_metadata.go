@@ -0,0 +1,338 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/m3"
+	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PromSeriesMetadataURL is the url for the series metadata handler.
+	PromSeriesMetadataURL = handler.RoutePrefixV1 + "/series/metadata"
+
+	unaggregatedStoragePolicyName = "unaggregated"
+)
+
+var (
+	// PromSeriesMetadataHTTPMethods are the HTTP methods for this handler.
+	PromSeriesMetadataHTTPMethods = []string{http.MethodGet, http.MethodPost}
+
+	errSeriesMetadataNoClusters = errors.New(
+		"series metadata requires connecting to M3DB clusters directly")
+)
+
+// SeriesMetadataResponse is the response of the series metadata handler.
+type SeriesMetadataResponse struct {
+	Series []SeriesMetadata `json:"series"`
+}
+
+// SeriesMetadata is the metadata of a series across the namespaces holding
+// its data.
+type SeriesMetadata struct {
+	Labels          map[string]string       `json:"labels"`
+	FirstTimestamp  time.Time               `json:"firstTimestamp"`
+	LastTimestamp   time.Time               `json:"lastTimestamp"`
+	Datapoints      int                     `json:"datapoints"`
+	StoragePolicies []StoragePolicyMetadata `json:"storagePolicies"`
+}
+
+// StoragePolicyMetadata is the metadata of a series in a single namespace.
+type StoragePolicyMetadata struct {
+	Namespace      string    `json:"namespace"`
+	StoragePolicy  string    `json:"storagePolicy"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+	Datapoints     int       `json:"datapoints"`
+}
+
+// PromSeriesMetadataHandler represents a handler for the series metadata
+// endpoint.
+type PromSeriesMetadataHandler struct {
+	clusters            m3.Clusters
+	tagOptions          models.TagOptions
+	fetchOptionsBuilder handleroptions.FetchOptionsBuilder
+	instrumentOpts      instrument.Options
+}
+
+// NewPromSeriesMetadataHandler returns a new handler that returns, for the
+// series matching the match[] selectors within the start and end params,
+// the first and last timestamps and the approximate number of datapoints
+// written to each namespace holding data for the series.
+func NewPromSeriesMetadataHandler(opts options.HandlerOptions) http.Handler {
+	return &PromSeriesMetadataHandler{
+		clusters:            opts.Clusters(),
+		tagOptions:          opts.TagOptions(),
+		fetchOptionsBuilder: opts.FetchOptionsBuilder(),
+		instrumentOpts:      opts.InstrumentOpts(),
+	}
+}
+
+func (h *PromSeriesMetadataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+
+	if h.clusters == nil {
+		xhttp.Error(w, errSeriesMetadataNoClusters, http.StatusBadRequest)
+		return
+	}
+
+	queries, err := prometheus.ParseSeriesMatchQuery(r, h.tagOptions)
+	if err != nil {
+		xhttp.Error(w, err.Inner(), err.Code())
+		return
+	}
+
+	opts, rErr := h.fetchOptionsBuilder.NewFetchOptions(r)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	series := make(map[string]*SeriesMetadata)
+	for _, query := range queries {
+		if err := h.fetchMetadata(query, opts, series); err != nil {
+			logger.Error("unable to fetch series metadata", zap.Error(err))
+			xhttp.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	ids := make([]string, 0, len(series))
+	for id := range series {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	resp := SeriesMetadataResponse{Series: make([]SeriesMetadata, 0, len(ids))}
+	for _, id := range ids {
+		resp.Series = append(resp.Series, *series[id])
+	}
+	xhttp.WriteJSONResponse(w, resp, logger)
+}
+
+// fetchMetadata adds the metadata of the series matching a query in each
+// namespace to the metadata of all series.
+func (h *PromSeriesMetadataHandler) fetchMetadata(
+	query *storage.FetchQuery,
+	opts *storage.FetchOptions,
+	series map[string]*SeriesMetadata,
+) error {
+	m3query, err := storage.FetchQueryToM3Query(query, opts)
+	if err != nil {
+		return err
+	}
+	queryOpts := storage.FetchOptionsToM3Options(opts, query)
+
+	for _, ns := range h.clusters.ClusterNamespaces() {
+		iters, _, err := ns.Session().FetchTagged(ns.NamespaceID(), m3query, queryOpts)
+		if err != nil {
+			return err
+		}
+
+		err = addSeriesMetadata(ns, iters, series)
+		iters.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addSeriesMetadata(
+	ns m3.ClusterNamespace,
+	iters encoding.SeriesIterators,
+	series map[string]*SeriesMetadata,
+) error {
+	var (
+		namespace  = ns.NamespaceID().String()
+		policyName = storagePolicyName(ns.Options().Attributes())
+	)
+	for _, iter := range iters.Iters() {
+		id := iter.ID().String()
+		meta, ok := series[id]
+		if ok && meta.hasNamespace(namespace) {
+			// Already matched by another selector.
+			continue
+		}
+
+		policyMeta, err := approximateStoragePolicyMetadata(iter)
+		if err != nil {
+			return err
+		}
+		if policyMeta.Datapoints == 0 {
+			continue
+		}
+		policyMeta.Namespace = namespace
+		policyMeta.StoragePolicy = policyName
+
+		if !ok {
+			meta = &SeriesMetadata{Labels: make(map[string]string)}
+			tags := iter.Tags().Duplicate()
+			for tags.Next() {
+				tag := tags.Current()
+				meta.Labels[tag.Name.String()] = tag.Value.String()
+			}
+			err := tags.Err()
+			tags.Close()
+			if err != nil {
+				return err
+			}
+			series[id] = meta
+		}
+
+		if meta.Datapoints == 0 || policyMeta.FirstTimestamp.Before(meta.FirstTimestamp) {
+			meta.FirstTimestamp = policyMeta.FirstTimestamp
+		}
+		if policyMeta.LastTimestamp.After(meta.LastTimestamp) {
+			meta.LastTimestamp = policyMeta.LastTimestamp
+		}
+		meta.Datapoints += policyMeta.Datapoints
+		meta.StoragePolicies = append(meta.StoragePolicies, policyMeta)
+	}
+	return nil
+}
+
+func (m *SeriesMetadata) hasNamespace(namespace string) bool {
+	for _, p := range m.StoragePolicies {
+		if p.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// approximateStoragePolicyMetadata returns the first and last timestamps and
+// the approximate number of datapoints of a series without decoding all of
+// its blocks. Only the first and last blocks of a single replica are
+// decoded, and the datapoints of the blocks in between are estimated from
+// their compressed size, assuming they compress as well as the decoded ones.
+// The timestamps and counts cover the whole blocks overlapping the query.
+func approximateStoragePolicyMetadata(
+	iter encoding.SeriesIterator,
+) (StoragePolicyMetadata, error) {
+	var meta StoragePolicyMetadata
+	replicas, err := iter.Replicas()
+	if err != nil || len(replicas) == 0 {
+		return meta, err
+	}
+
+	var blocks [][]ts.Segment
+	readers := replicas[0].Readers()
+	for next := readers != nil; next; next = readers.Next() {
+		l, _, _ := readers.CurrentReaders()
+		segments := make([]ts.Segment, 0, l)
+		for i := 0; i < l; i++ {
+			segment, err := readers.CurrentReaderAt(i).Segment()
+			if err != nil {
+				return meta, err
+			}
+			if segment.Len() > 0 {
+				segments = append(segments, segment)
+			}
+		}
+		if len(segments) > 0 {
+			blocks = append(blocks, segments)
+		}
+	}
+	if len(blocks) == 0 {
+		return meta, nil
+	}
+
+	var decodedBytes, decodedDatapoints, estimatedBytes int
+	for i, segments := range blocks {
+		if i != 0 && i != len(blocks)-1 {
+			for _, segment := range segments {
+				estimatedBytes += segment.Len()
+			}
+			continue
+		}
+
+		for _, segment := range segments {
+			decodedBytes += segment.Len()
+			if err := decodeSegmentMetadata(segment, &meta); err != nil {
+				return meta, err
+			}
+		}
+	}
+
+	decodedDatapoints = meta.Datapoints
+	if decodedBytes > 0 {
+		meta.Datapoints += int(math.Round(float64(estimatedBytes) *
+			float64(decodedDatapoints) / float64(decodedBytes)))
+	}
+	return meta, nil
+}
+
+// decodeSegmentMetadata adds the timestamps and number of datapoints of a
+// segment to the metadata of a series.
+func decodeSegmentMetadata(
+	segment ts.Segment,
+	meta *StoragePolicyMetadata,
+) error {
+	iter := m3tsz.NewReaderIterator(xio.NewSegmentReader(segment),
+		m3tsz.DefaultIntOptimizationEnabled, encoding.NewOptions())
+	defer iter.Close()
+
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		if meta.Datapoints == 0 || dp.Timestamp.Before(meta.FirstTimestamp) {
+			meta.FirstTimestamp = dp.Timestamp
+		}
+		if dp.Timestamp.After(meta.LastTimestamp) {
+			meta.LastTimestamp = dp.Timestamp
+		}
+		meta.Datapoints++
+	}
+	return iter.Err()
+}
+
+func storagePolicyName(attrs storagemetadata.Attributes) string {
+	if attrs.MetricsType != storagemetadata.AggregatedMetricsType {
+		return unaggregatedStoragePolicyName
+	}
+	_, precision := xtime.MaxUnitForDuration(attrs.Resolution)
+	return policy.NewStoragePolicy(attrs.Resolution, precision, attrs.Retention).String()
+}
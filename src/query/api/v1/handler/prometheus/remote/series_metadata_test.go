@@ -0,0 +1,190 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/storage/m3"
+	"github.com/m3db/m3/src/query/test"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	clusters, err := m3.NewClusters(m3.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("unagg"),
+		Retention:   48 * time.Hour,
+		Session:     session,
+	}, m3.AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("agg"),
+		Retention:   720 * time.Hour,
+		Resolution:  time.Minute,
+		Session:     session,
+	})
+	require.NoError(t, err)
+
+	start := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	newIters := func(
+		ns string,
+		dps map[string][]test.Datapoint,
+	) encoding.SeriesIterators {
+		var iters []encoding.SeriesIterator
+		for id, seriesDps := range dps {
+			iter, _, err := test.BuildCustomIterator(
+				[][]test.Datapoint{seriesDps},
+				map[string]string{"__name__": id},
+				id, ns, start, time.Hour, time.Minute)
+			require.NoError(t, err)
+			iters = append(iters, iter)
+		}
+		return encoding.NewSeriesIterators(iters, nil)
+	}
+
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher("unagg"), gomock.Any(), gomock.Any()).
+		Return(newIters("unagg", map[string][]test.Datapoint{
+			"foo": {{Value: 1, Offset: 30 * time.Minute}, {Value: 2, Offset: 40 * time.Minute}},
+			"bar": {},
+		}), client.FetchResponseMetadata{Exhaustive: true}, nil)
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher("agg"), gomock.Any(), gomock.Any()).
+		Return(newIters("agg", map[string][]test.Datapoint{
+			"foo": {{Value: 1, Offset: 0}, {Value: 2, Offset: time.Minute}, {Value: 3, Offset: 2 * time.Minute}},
+		}), client.FetchResponseMetadata{Exhaustive: true}, nil)
+
+	opts := options.EmptyHandlerOptions().
+		SetClusters(clusters).
+		SetFetchOptionsBuilder(handleroptions.
+			NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet,
+		PromSeriesMetadataURL+"?match[]=foo", nil)
+	NewPromSeriesMetadataHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp SeriesMetadataResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Series, 1)
+
+	series := resp.Series[0]
+	assert.Equal(t, map[string]string{"__name__": "foo"}, series.Labels)
+	assert.True(t, start.Equal(series.FirstTimestamp))
+	assert.True(t, start.Add(40*time.Minute).Equal(series.LastTimestamp))
+	assert.Equal(t, 5, series.Datapoints)
+
+	require.Len(t, series.StoragePolicies, 2)
+	policies := make(map[string]StoragePolicyMetadata)
+	for _, p := range series.StoragePolicies {
+		policies[p.StoragePolicy] = p
+	}
+	assert.Equal(t, "unagg", policies["unaggregated"].Namespace)
+	assert.Equal(t, 2, policies["unaggregated"].Datapoints)
+	assert.Equal(t, "agg", policies["1m:30d"].Namespace)
+	assert.Equal(t, 3, policies["1m:30d"].Datapoints)
+	assert.True(t, start.Add(2*time.Minute).Equal(policies["1m:30d"].LastTimestamp))
+}
+
+func TestSeriesMetadataApproximatesAndDedupesAcrossSelectors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	clusters, err := m3.NewClusters(m3.UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("unagg"),
+		Retention:   48 * time.Hour,
+		Session:     session,
+	})
+	require.NoError(t, err)
+
+	var (
+		start = time.Now().Truncate(time.Hour).Add(-4 * time.Hour)
+		block = []test.Datapoint{
+			{Value: 1, Offset: 0},
+			{Value: 2, Offset: time.Minute},
+			{Value: 3, Offset: 2 * time.Minute},
+			{Value: 4, Offset: 3 * time.Minute},
+		}
+	)
+	// Both selectors match the same series.
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher("unagg"), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			interface{}, interface{}, interface{},
+		) (encoding.SeriesIterators, client.FetchResponseMetadata, error) {
+			iter, _, err := test.BuildCustomIterator(
+				[][]test.Datapoint{block, block, block},
+				map[string]string{"__name__": "foo"},
+				"foo", "unagg", start, time.Hour, time.Minute)
+			require.NoError(t, err)
+			return encoding.NewSeriesIterators([]encoding.SeriesIterator{iter}, nil),
+				client.FetchResponseMetadata{Exhaustive: true}, nil
+		}).
+		Times(2)
+
+	opts := options.EmptyHandlerOptions().
+		SetClusters(clusters).
+		SetFetchOptionsBuilder(handleroptions.
+			NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{}))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet,
+		PromSeriesMetadataURL+"?match[]=foo&match[]=%7B__name__%3D%22foo%22%7D", nil)
+	NewPromSeriesMetadataHandler(opts).ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp SeriesMetadataResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Series, 1)
+
+	// The middle block is not decoded, its datapoints are estimated from the
+	// decoded blocks which compress to the same size.
+	series := resp.Series[0]
+	assert.True(t, start.Equal(series.FirstTimestamp))
+	assert.True(t, start.Add(2*time.Hour+3*time.Minute).Equal(series.LastTimestamp))
+	assert.Equal(t, 12, series.Datapoints)
+	require.Len(t, series.StoragePolicies, 1)
+	assert.Equal(t, 12, series.StoragePolicies[0].Datapoints)
+}
+
+func TestSeriesMetadataNoClusters(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet,
+		PromSeriesMetadataURL+"?match[]=foo", nil)
+	NewPromSeriesMetadataHandler(options.EmptyHandlerOptions()).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
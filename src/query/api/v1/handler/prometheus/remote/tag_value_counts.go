@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/errors"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	// TagValueCountsURL is the url for the count of series per tag value.
+	TagValueCountsURL = handler.RoutePrefixV1 +
+		"/label/{" + NameReplace + "}/counts"
+
+	// TagValueCountsHTTPMethod is the HTTP method used with this resource.
+	TagValueCountsHTTPMethod = http.MethodGet
+
+	limitParam = "limit"
+)
+
+// TagValueCountsHandler represents a handler returning the number of series
+// matching each value of a tag.
+type TagValueCountsHandler struct {
+	storage             storage.Storage
+	fetchOptionsBuilder handleroptions.FetchOptionsBuilder
+	nowFn               clock.NowFn
+	instrumentOpts      instrument.Options
+}
+
+// TagValueCount is the number of series with a given tag value.
+type TagValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// TagValueCountsResponse is the response that gets returned to the user.
+type TagValueCountsResponse struct {
+	Status string          `json:"status"`
+	Data   []TagValueCount `json:"data"`
+}
+
+// NewTagValueCountsHandler returns a new instance of handler.
+func NewTagValueCountsHandler(options options.HandlerOptions) http.Handler {
+	return &TagValueCountsHandler{
+		storage:             options.Storage(),
+		fetchOptionsBuilder: options.FetchOptionsBuilder(),
+		nowFn:               options.NowFn(),
+		instrumentOpts:      options.InstrumentOpts(),
+	}
+}
+
+func (h *TagValueCountsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), handler.HeaderKey, r.Header)
+	logger := logging.WithContext(ctx, h.instrumentOpts)
+	w.Header().Set(xhttp.HeaderContentType, xhttp.ContentTypeJSON)
+
+	name, query, err := h.parseTagValueCountsToQuery(r)
+	if err != nil {
+		logger.Error("unable to parse tag value counts to query", zap.Error(err))
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseLimit(r)
+	if err != nil {
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	opts, rErr := h.fetchOptionsBuilder.NewFetchOptions(r)
+	if rErr != nil {
+		xhttp.Error(w, rErr.Inner(), rErr.Code())
+		return
+	}
+
+	result, err := h.storage.SearchSeries(ctx, query, opts)
+	if err != nil {
+		logger.Error("unable to get tag value counts", zap.Error(err))
+		xhttp.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	counts, meta := countTagValues(name, result, limit)
+	handleroptions.AddWarningHeaders(w, meta)
+	xhttp.WriteJSONResponse(w, TagValueCountsResponse{
+		Status: "success",
+		Data:   counts,
+	}, logger)
+}
+
+func (h *TagValueCountsHandler) parseTagValueCountsToQuery(
+	r *http.Request,
+) ([]byte, *storage.FetchQuery, error) {
+	vars := mux.Vars(r)
+	name, ok := vars[NameReplace]
+	if !ok || len(name) == 0 {
+		return nil, nil, errors.ErrNoName
+	}
+
+	// NB: spans the entire timerange for the index unless bounded by the request.
+	start, end, rErr := prometheus.ParseTimeRange(r, h.nowFn())
+	if rErr != nil {
+		return nil, nil, rErr.Inner()
+	}
+
+	nameBytes := []byte(name)
+	return nameBytes, &storage.FetchQuery{
+		Start: start,
+		End:   end,
+		TagMatchers: models.Matchers{
+			models.Matcher{
+				Type: models.MatchField,
+				Name: nameBytes,
+			},
+		},
+	}, nil
+}
+
+func parseLimit(r *http.Request) (int, error) {
+	str := r.URL.Query().Get(limitParam)
+	if str == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(str)
+}
+
+// countTagValues counts the distinct series per value of the named tag,
+// returning the counts ordered by descending count. If limit is positive only
+// the largest limit counts are returned and the result is marked as not
+// exhaustive when values were dropped.
+func countTagValues(
+	name []byte,
+	result *storage.SearchResults,
+	limit int,
+) ([]TagValueCount, block.ResultMetadata) {
+	var (
+		meta   = result.Metadata
+		seen   = make(map[string]struct{}, len(result.Metrics))
+		counts = make(map[string]int)
+	)
+	for _, metric := range result.Metrics {
+		// NB: the same series may be returned by more than one namespace.
+		if _, ok := seen[string(metric.ID)]; ok {
+			continue
+		}
+		seen[string(metric.ID)] = struct{}{}
+
+		value, ok := metric.Tags.Get(name)
+		if !ok {
+			continue
+		}
+		counts[string(value)]++
+	}
+
+	values := make([]TagValueCount, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, TagValueCount{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+
+	if limit > 0 && len(values) > limit {
+		values = values[:limit]
+		meta.Exhaustive = false
+	}
+
+	return values, meta
+}
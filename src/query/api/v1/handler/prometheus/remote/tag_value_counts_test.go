@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTagValueCountsMetric(id, name, value string) models.Metric {
+	tags := models.NewTags(1, models.NewTagOptions()).
+		AddTag(models.Tag{Name: b(name), Value: b(value)})
+	return models.Metric{ID: b(id), Tags: tags}
+}
+
+func TestTagValueCounts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := storage.NewMockStorage(ctrl)
+	now := time.Now()
+	fb := handleroptions.
+		NewFetchOptionsBuilder(handleroptions.FetchOptionsBuilderOptions{})
+	opts := options.EmptyHandlerOptions().
+		SetStorage(store).
+		SetNowFn(func() time.Time { return now }).
+		SetFetchOptionsBuilder(fb)
+
+	storeResult := &storage.SearchResults{
+		Metrics: models.Metrics{
+			testTagValueCountsMetric("foo1", "host", "a"),
+			testTagValueCountsMetric("foo2", "host", "b"),
+			testTagValueCountsMetric("foo3", "host", "a"),
+			testTagValueCountsMetric("foo4", "host", "c"),
+			// Duplicate series from another namespace.
+			testTagValueCountsMetric("foo4", "host", "c"),
+		},
+		Metadata: block.NewResultMetadata(),
+	}
+	store.EXPECT().SearchSeries(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ interface{},
+			query *storage.FetchQuery,
+			_ *storage.FetchOptions,
+		) (*storage.SearchResults, error) {
+			require.Equal(t, 1, len(query.TagMatchers))
+			require.Equal(t, models.MatchField, query.TagMatchers[0].Type)
+			require.Equal(t, "host", string(query.TagMatchers[0].Name))
+			require.True(t, query.End.Equal(now))
+			return storeResult, nil
+		}).Times(2)
+
+	router := mux.NewRouter()
+	router.HandleFunc(fmt.Sprintf("/label/{%s}/counts", NameReplace),
+		NewTagValueCountsHandler(opts).ServeHTTP)
+
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, "/label/host/counts", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(rr, req)
+
+	read, err := ioutil.ReadAll(rr.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"success","data":[`+
+		`{"value":"a","count":2},{"value":"b","count":1},{"value":"c","count":1}]}`,
+		string(read))
+	assert.Equal(t, "", rr.Header().Get(handleroptions.LimitHeader))
+
+	rr = httptest.NewRecorder()
+	req, err = http.NewRequest(http.MethodGet, "/label/host/counts?limit=1", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(rr, req)
+
+	read, err = ioutil.ReadAll(rr.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"success","data":[{"value":"a","count":2}]}`,
+		string(read))
+	assert.Equal(t, handleroptions.LimitHeaderSeriesLimitApplied,
+		rr.Header().Get(handleroptions.LimitHeader))
+}
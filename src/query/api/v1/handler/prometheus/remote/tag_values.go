@@ -23,7 +23,6 @@ package remote
 import (
 	"context"
 	"net/http"
-	"time"
 
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
@@ -120,11 +119,16 @@ func (h *TagValuesHandler) parseTagValuesToQuery(
 		return nil, errors.ErrNoName
 	}
 
+	// NB: spans the entire timerange for the index unless bounded by the request.
+	start, end, rErr := prometheus.ParseTimeRange(r, h.nowFn())
+	if rErr != nil {
+		return nil, rErr.Inner()
+	}
+
 	nameBytes := []byte(name)
 	return &storage.CompleteTagsQuery{
-		// NB: necessarily spans the entire timerange for the index.
-		Start:            time.Time{},
-		End:              h.nowFn(),
+		Start:            start,
+		End:              end,
 		CompleteNameOnly: false,
 		FilterNameTags:   [][]byte{nameBytes},
 		TagMatchers: models.Matchers{
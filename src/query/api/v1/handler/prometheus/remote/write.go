@@ -27,11 +27,16 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/m3db/m3/src/cluster/placement/rollout"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/query/api/v1/handler"
@@ -41,6 +46,7 @@ import (
 	"github.com/m3db/m3/src/query/generated/proto/prompb"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
 	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/query/util/logging"
@@ -104,6 +110,9 @@ type PromWriteHandler struct {
 	forwardingBoundWorkers xsync.WorkerPool
 	forwardContext         context.Context
 	forwardRetrier         retry.Retrier
+	exemplarStore          exemplar.Store
+	ingestQuotas           quota.Enforcer
+	writeErrorCounter      *rollout.ErrorCounter
 	nowFn                  clock.NowFn
 	instrumentOpts         instrument.Options
 	metrics                promWriteMetrics
@@ -173,6 +182,9 @@ func NewPromWriteHandler(options options.HandlerOptions) (http.Handler, error) {
 		forwardingBoundWorkers: forwardingBoundWorkers,
 		forwardContext:         context.Background(),
 		forwardRetrier:         retry.NewRetrier(forwardRetryOpts),
+		exemplarStore:          options.ExemplarStore(),
+		ingestQuotas:           options.IngestQuotas(),
+		writeErrorCounter:      options.WriteErrorCounter(),
 		nowFn:                  nowFn,
 		metrics:                metrics,
 		instrumentOpts:         instrumentOpts,
@@ -183,6 +195,8 @@ type promWriteMetrics struct {
 	writeSuccess             tally.Counter
 	writeErrorsServer        tally.Counter
 	writeErrorsClient        tally.Counter
+	writeErrorsQuota         tally.Counter
+	writeQuotaDropped        tally.Counter
 	writeBatchLatency        tally.Histogram
 	writeBatchLatencyBuckets tally.DurationBuckets
 	ingestLatency            tally.Histogram
@@ -243,6 +257,8 @@ func newPromWriteMetrics(scope tally.Scope) (promWriteMetrics, error) {
 		writeSuccess:             scope.SubScope("write").Counter("success"),
 		writeErrorsServer:        scope.SubScope("write").Tagged(map[string]string{"code": "5XX"}).Counter("errors"),
 		writeErrorsClient:        scope.SubScope("write").Tagged(map[string]string{"code": "4XX"}).Counter("errors"),
+		writeErrorsQuota:         scope.SubScope("write").Tagged(map[string]string{"code": "429"}).Counter("errors"),
+		writeQuotaDropped:        scope.SubScope("write").Counter("quota-dropped-series"),
 		writeBatchLatency:        scope.SubScope("write").Histogram("batch-latency", writeLatencyBuckets),
 		writeBatchLatencyBuckets: writeLatencyBuckets,
 		ingestLatency:            scope.SubScope("ingest").Histogram("latency", ingestLatencyBuckets),
@@ -265,6 +281,21 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.admit(r, req); err != nil {
+		h.metrics.writeErrorsQuota.Inc(1)
+		if exceeded, ok := err.(*quota.ExceededError); ok {
+			setRetryAfter(w, exceeded.RetryAfter)
+		}
+		xhttp.Error(w, err, http.StatusTooManyRequests)
+		return
+	}
+
+	var datapoints int
+	for _, series := range req.Timeseries {
+		datapoints += len(series.Samples) + len(series.Histograms)
+	}
+	logging.RecordRequestStats(r.Context(), len(req.Timeseries), datapoints)
+
 	// Begin async forwarding.
 	// NB(r): Be careful about not returning buffers to pool
 	// if the request bodies ever get pooled until after
@@ -314,6 +345,7 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	h.addExemplars(req)
 	batchErr := h.write(r.Context(), req, opts)
 
 	// Record ingestion delay latency
@@ -330,10 +362,24 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			errs              = batchErr.Errors()
 			lastRegularErr    string
 			lastBadRequestErr string
+			lastLimitedErr    string
 			numRegular        int
 			numBadRequest     int
+			numLimited        int
+			retryAfter        time.Duration
 		)
 		for _, err := range errs {
+			if limited, ok := cardinality.GetLimitedError(err); ok {
+				// Rate limited series may be written once the limit is
+				// lifted, so must not be rejected as bad requests.
+				numLimited++
+				lastLimitedErr = err.Error()
+				if limited.RetryAfter > retryAfter {
+					retryAfter = limited.RetryAfter
+				}
+				continue
+			}
+
 			switch {
 			case client.IsBadRequestError(err):
 				numBadRequest++
@@ -349,12 +395,20 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		var status int
 		switch {
+		case numLimited > 0 && numRegular == 0:
+			status = http.StatusTooManyRequests
+			h.metrics.writeErrorsQuota.Inc(1)
+			setRetryAfter(w, retryAfter)
 		case numBadRequest == len(errs):
 			status = http.StatusBadRequest
 			h.metrics.writeErrorsClient.Inc(1)
 		default:
 			status = http.StatusInternalServerError
 			h.metrics.writeErrorsServer.Inc(1)
+			h.writeErrorCounter.Record(true)
+			// Let clients know the batch contained errors that are safe to
+			// retry, bad request errors will fail again if resent.
+			w.Header().Set(handleroptions.RetryHeader, "true")
 		}
 
 		logger := logging.WithContext(r.Context(), h.instrumentOpts)
@@ -363,8 +417,10 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			zap.Int("httpResponseStatusCode", status),
 			zap.Int("numRegularErrors", numRegular),
 			zap.Int("numBadRequestErrors", numBadRequest),
+			zap.Int("numLimitedErrors", numLimited),
 			zap.String("lastRegularError", lastRegularErr),
-			zap.String("lastBadRequestErr", lastBadRequestErr))
+			zap.String("lastBadRequestErr", lastBadRequestErr),
+			zap.String("lastLimitedErr", lastLimitedErr))
 
 		var resultErr string
 		if lastRegularErr != "" {
@@ -379,6 +435,14 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			resultErr = fmt.Sprintf("%s%sbad_request_errors: count=%d, last=%s",
 				resultErr, sep, numBadRequest, lastBadRequestErr)
 		}
+		if lastLimitedErr != "" {
+			var sep string
+			if resultErr != "" {
+				sep = ", "
+			}
+			resultErr = fmt.Sprintf("%s%srate_limited_errors: count=%d, last=%s",
+				resultErr, sep, numLimited, lastLimitedErr)
+		}
 		xhttp.Error(w, errors.New(resultErr), status)
 		return
 	}
@@ -388,6 +452,17 @@ func (h *PromWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// shows up as error.
 	w.WriteHeader(200)
 	h.metrics.writeSuccess.Inc(1)
+	h.writeErrorCounter.Record(false)
+}
+
+// setRetryAfter sets the Retry-After header to the given duration, rounded
+// up to at least a second.
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	retryAfter := int(math.Ceil(d.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set(xhttp.HeaderRetryAfter, strconv.Itoa(retryAfter))
 }
 
 // parseRequest extracts the Prometheus write request from the request body and
@@ -498,6 +573,112 @@ func (h *PromWriteHandler) write(
 	return h.downsamplerAndWriter.WriteBatch(ctx, iter, opts)
 }
 
+// admit counts the writes of the request against the ingest quotas of their
+// tenants, if ingest quotas are enforced. The series of tenants exceeding
+// their quotas are removed from the request so that the series of other
+// tenants are still written, and an error is only returned if no series of
+// the request were admitted.
+func (h *PromWriteHandler) admit(r *http.Request, req *prompb.WriteRequest) error {
+	if h.ingestQuotas == nil {
+		return nil
+	}
+
+	tenant := r.Header.Get(handleroptions.TenantHeader)
+	if tenant == "" {
+		tenant = handler.DefaultTenant
+	}
+
+	var (
+		writes = make([]quota.Write, 0, len(req.Timeseries))
+		// writeSeries is the index of the series of each write.
+		writeSeries = make([]int, 0, len(req.Timeseries))
+	)
+	for i, series := range req.Timeseries {
+		if len(series.Samples) > 0 || len(series.Histograms) == 0 {
+			writes = append(writes, quota.Write{
+				Tags:       storage.PromLabelsToM3Tags(series.Labels, h.tagOptions),
+				Datapoints: len(series.Samples),
+			})
+			writeSeries = append(writeSeries, i)
+		}
+		if len(series.Histograms) == 0 {
+			continue
+		}
+
+		// Native histograms are written as a series per bucket plus the
+		// +Inf bucket, sum and count series so count each of them. The
+		// attributes only determine the type of the expanded series.
+		attributes, err := storage.PromTimeSeriesToSeriesAttributes(series)
+		if err != nil {
+			attributes = ts.DefaultSeriesAttributes()
+		}
+		for _, expanded := range expandNativeHistograms(series, attributes, h.tagOptions) {
+			writes = append(writes, quota.Write{
+				Tags:       expanded.tags,
+				Datapoints: len(expanded.datapoints),
+			})
+			writeSeries = append(writeSeries, i)
+		}
+	}
+
+	admitted, err := h.ingestQuotas.Admit(tenant, writes)
+	if err == nil {
+		return nil
+	}
+
+	// The writes of a series all belong to the same tenant so a series is
+	// either entirely admitted or rejected.
+	rejected := make(map[int]struct{})
+	for i, ok := range admitted {
+		if !ok {
+			rejected[writeSeries[i]] = struct{}{}
+		}
+	}
+	if len(rejected) == len(req.Timeseries) {
+		return err
+	}
+
+	h.metrics.writeQuotaDropped.Inc(int64(len(rejected)))
+	filtered := req.Timeseries[:0]
+	for i, series := range req.Timeseries {
+		if _, ok := rejected[i]; !ok {
+			filtered = append(filtered, series)
+		}
+	}
+	req.Timeseries = filtered
+	return nil
+}
+
+// addExemplars retains the exemplars of the request, if any, so that they
+// can be queried. Exemplars are dropped if there is no exemplar store.
+func (h *PromWriteHandler) addExemplars(r *prompb.WriteRequest) {
+	if h.exemplarStore == nil {
+		return
+	}
+
+	for _, series := range r.Timeseries {
+		if len(series.Exemplars) == 0 {
+			continue
+		}
+
+		exemplars := make([]exemplar.Exemplar, 0, len(series.Exemplars))
+		for _, e := range series.Exemplars {
+			labels := make([]models.Tag, 0, len(e.Labels))
+			for _, l := range e.Labels {
+				labels = append(labels, models.Tag{Name: l.Name, Value: l.Value})
+			}
+			exemplars = append(exemplars, exemplar.Exemplar{
+				Labels:    labels,
+				Value:     e.Value,
+				Timestamp: storage.PromTimestampToTime(e.Timestamp),
+			})
+		}
+
+		tags := storage.PromLabelsToM3Tags(series.Labels, h.tagOptions)
+		h.exemplarStore.Add(tags, exemplars)
+	}
+}
+
 func (h *PromWriteHandler) forward(
 	ctx context.Context,
 	request prometheus.ParsePromCompressedRequestResult,
@@ -565,9 +746,19 @@ func newPromTSIter(timeseries []prompb.TimeSeries, tagOpts models.TagOptions) (*
 		if err != nil {
 			return nil, err
 		}
-		seriesAttributes = append(seriesAttributes, attributes)
-		tags = append(tags, storage.PromLabelsToM3Tags(promTS.Labels, tagOpts))
-		datapoints = append(datapoints, storage.PromSamplesToM3Datapoints(promTS.Samples))
+		if len(promTS.Samples) > 0 || len(promTS.Histograms) == 0 {
+			seriesAttributes = append(seriesAttributes, attributes)
+			tags = append(tags, storage.PromLabelsToM3Tags(promTS.Labels, tagOpts))
+			datapoints = append(datapoints, storage.PromSamplesToM3Datapoints(promTS.Samples))
+		}
+
+		// Native histograms are stored as their equivalent classic
+		// histogram series.
+		for _, series := range expandNativeHistograms(promTS, attributes, tagOpts) {
+			seriesAttributes = append(seriesAttributes, series.attributes)
+			tags = append(tags, series.tags)
+			datapoints = append(datapoints, series.datapoints)
+		}
 	}
 
 	return &promTSIter{
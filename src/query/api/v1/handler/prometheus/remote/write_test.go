@@ -22,6 +22,7 @@ package remote
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -32,12 +33,16 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/metrics/policy"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote/test"
 	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
 	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage/exemplar"
 	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
 	xclock "github.com/m3db/m3/src/x/clock"
 	xerrors "github.com/m3db/m3/src/x/errors"
@@ -102,6 +107,185 @@ func TestPromWrite(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestPromWriteExemplars(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.
+		EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any())
+
+	store := exemplar.Configuration{}.NewStore(instrument.NewOptions())
+	opts := makeOptions(mockDownsamplerAndWriter).SetExemplarStore(store)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	now := time.Now().Truncate(time.Millisecond)
+	promReq := test.GeneratePromWriteRequest()
+	promReq.Timeseries[0].Exemplars = []prompb.Exemplar{{
+		Labels:    []prompb.Label{{Name: []byte("trace_id"), Value: []byte("abc123")}},
+		Value:     1,
+		Timestamp: now.UnixNano() / int64(time.Millisecond),
+	}}
+	promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+	resp := writer.Result()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	matcher, err := models.NewMatcher(models.MatchEqual,
+		[]byte("__name__"), []byte("first"))
+	require.NoError(t, err)
+	result, err := store.Query(models.Matchers{matcher}, now, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result))
+	require.Equal(t, 1, len(result[0].Exemplars))
+	require.Equal(t, "abc123", string(result[0].Exemplars[0].Labels[0].Value))
+	require.True(t, now.Equal(result[0].Exemplars[0].Timestamp))
+}
+
+func TestPromWriteIngestQuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.
+		EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(2)
+
+	now := time.Now()
+	quotas := quota.Configuration{MaxDatapointsPerSecond: 4}.
+		NewEnforcer(func() time.Time { return now }, instrument.NewOptions())
+	opts := makeOptions(mockDownsamplerAndWriter).SetIngestQuotas(quotas)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	write := func(tenant string) *http.Response {
+		promReq := test.GeneratePromWriteRequest()
+		promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+		req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+		req.Header.Set(handleroptions.TenantHeader, tenant)
+
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, req)
+		return writer.Result()
+	}
+
+	require.Equal(t, http.StatusOK, write("a").StatusCode)
+
+	resp := write("a")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	// Other tenants have their own quota.
+	require.Equal(t, http.StatusOK, write("b").StatusCode)
+}
+
+func TestPromWriteIngestQuotaCountsNativeHistogramSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.
+		EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1)
+
+	now := time.Now()
+	quotas := quota.Configuration{MaxNewSeriesPerSecond: 5}.
+		NewEnforcer(func() time.Time { return now }, instrument.NewOptions())
+	opts := makeOptions(mockDownsamplerAndWriter).SetIngestQuotas(quotas)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	write := func(promReq *prompb.WriteRequest) int {
+		promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+		req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+		writer := httptest.NewRecorder()
+		handler.ServeHTTP(writer, req)
+		return writer.Result().StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, write(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels:  []prompb.Label{{Name: []byte("__name__"), Value: []byte("up")}},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+		}},
+	}))
+
+	// The histogram is written as three bucket series plus the +Inf bucket,
+	// sum and count series, exceeding the remaining four new series.
+	require.Equal(t, http.StatusTooManyRequests, write(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels: []prompb.Label{{Name: []byte("__name__"), Value: []byte("latency")}},
+			Histograms: []prompb.Histogram{{
+				CountInt:       3,
+				Sum:            10,
+				PositiveSpans:  []prompb.BucketSpan{{Offset: 0, Length: 3}},
+				PositiveDeltas: []int64{1, 0, 0},
+				Timestamp:      1000,
+			}},
+		}},
+	}))
+}
+
+func TestPromWriteIngestQuotaRejectsOnlyExceedingTenants(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var written []string
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.
+		EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			for iter.Next() {
+				tenant, _ := iter.Current().Tags.Get([]byte("tenant"))
+				written = append(written, string(tenant))
+			}
+			return nil
+		})
+
+	now := time.Now()
+	quotas := quota.Configuration{TenantLabel: "tenant", MaxDatapointsPerSecond: 1}.
+		NewEnforcer(func() time.Time { return now }, instrument.NewOptions())
+	opts := makeOptions(mockDownsamplerAndWriter).SetIngestQuotas(quotas)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	series := func(tenant string, samples int) prompb.TimeSeries {
+		s := prompb.TimeSeries{
+			Labels: []prompb.Label{
+				{Name: []byte("__name__"), Value: []byte("up")},
+				{Name: []byte("tenant"), Value: []byte(tenant)},
+			},
+		}
+		for i := 0; i < samples; i++ {
+			s.Samples = append(s.Samples, prompb.Sample{Value: 1, Timestamp: int64(1000 * (i + 1))})
+		}
+		return s
+	}
+	promReq := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{series("a", 1), series("b", 2)},
+	}
+	promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+
+	// Tenant b exceeds its quota so only the series of tenant a is written.
+	require.Equal(t, http.StatusOK, writer.Result().StatusCode)
+	require.Equal(t, []string{"a"}, written)
+}
+
 func TestPromWriteError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -127,12 +311,74 @@ func TestPromWriteError(t *testing.T) {
 	handler.ServeHTTP(writer, req)
 	resp := writer.Result()
 	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(handleroptions.RetryHeader))
 
 	body, err := ioutil.ReadAll(resp.Body)
 	require.NoError(t, err)
 	require.True(t, bytes.Contains(body, []byte(batchErr.Error())))
 }
 
+func TestPromWriteBadRequestErrorNotRetryable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	multiErr := xerrors.NewMultiError().
+		Add(xerrors.NewInvalidParamsError(errors.New("bad datapoint")))
+	batchErr := ingest.BatchError(multiErr)
+
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(batchErr)
+
+	opts := makeOptions(mockDownsamplerAndWriter)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	promReq := test.GeneratePromWriteRequest()
+	promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+	resp := writer.Result()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, "", resp.Header.Get(handleroptions.RetryHeader))
+}
+
+func TestPromWriteCardinalityLimitedReturnsTooManyRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	multiErr := xerrors.NewMultiError().
+		Add(xerrors.NewInvalidParamsError(errors.New("bad datapoint"))).
+		Add(xerrors.NewInvalidParamsError(&cardinality.LimitedError{
+			Offender:   cardinality.Offender{MetricName: "requests"},
+			Limit:      10,
+			RetryAfter: 2500 * time.Millisecond,
+		}))
+	batchErr := ingest.BatchError(multiErr)
+
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	mockDownsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(batchErr)
+
+	opts := makeOptions(mockDownsamplerAndWriter)
+	handler, err := NewPromWriteHandler(opts)
+	require.NoError(t, err)
+
+	promReq := test.GeneratePromWriteRequest()
+	promReqBody := test.GeneratePromWriteRequestBody(t, promReq)
+	req := httptest.NewRequest(PromWriteHTTPMethod, PromWriteURL, promReqBody)
+
+	writer := httptest.NewRecorder()
+	handler.ServeHTTP(writer, req)
+	resp := writer.Result()
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, "3", resp.Header.Get("Retry-After"))
+}
+
 func TestWriteErrorMetricCount(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
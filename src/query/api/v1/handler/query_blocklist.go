@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/cespare/xxhash/v2"
+	pql "github.com/prometheus/prometheus/promql/parser"
+	"github.com/uber-go/tally"
+)
+
+const queryParam = "query"
+
+var errQueryBlocked = errors.New("query matches a blocked query pattern")
+
+// QueryFingerprint returns the fingerprint of the matchers of the selectors
+// of a PromQL query. The fingerprint ignores the functions, ranges and
+// offsets applied to the selectors so that the same expensive selection is
+// matched however it is aggregated.
+func QueryFingerprint(query string) (string, error) {
+	expr, err := pql.ParseExpr(query)
+	if err != nil {
+		return "", err
+	}
+
+	var selectors []string
+	pql.Inspect(expr, func(node pql.Node, _ []pql.Node) error {
+		vs, ok := node.(*pql.VectorSelector)
+		if !ok {
+			return nil
+		}
+		matchers := make([]string, 0, len(vs.LabelMatchers))
+		for _, m := range vs.LabelMatchers {
+			matchers = append(matchers, m.String())
+		}
+		sort.Strings(matchers)
+		selectors = append(selectors, strings.Join(matchers, ","))
+		return nil
+	})
+	sort.Strings(selectors)
+
+	hash := xxhash.Sum64String(strings.Join(selectors, ";"))
+	return fmt.Sprintf("%016x", hash), nil
+}
+
+// requestFingerprint returns the fingerprint of the query of a request, or
+// an empty string if the request has no query or it fails to parse, in
+// which case the query handler reports the error.
+func requestFingerprint(r *http.Request) string {
+	query := r.FormValue(queryParam)
+	if query == "" {
+		return ""
+	}
+	fingerprint, err := QueryFingerprint(query)
+	if err != nil {
+		return ""
+	}
+	return fingerprint
+}
+
+type queryBlocklistMetrics struct {
+	rejected      tally.Counter
+	deprioritized tally.Counter
+}
+
+// QueryBlocklist rejects or deprioritizes queries whose fingerprint matches
+// a blocked query pattern.
+type QueryBlocklist struct {
+	sync.RWMutex
+
+	actions map[string]config.BlockedQueryAction
+	metrics queryBlocklistMetrics
+}
+
+// NewQueryBlocklist returns a new query blocklist.
+func NewQueryBlocklist(
+	blocked []config.BlockedQueryConfiguration,
+	instrumentOpts instrument.Options,
+) *QueryBlocklist {
+	scope := instrumentOpts.MetricsScope().SubScope("query-blocklist")
+	b := &QueryBlocklist{
+		metrics: queryBlocklistMetrics{
+			rejected:      scope.Counter("rejected"),
+			deprioritized: scope.Counter("deprioritized"),
+		},
+	}
+	b.SetConfig(blocked)
+	return b
+}
+
+// SetConfig replaces the blocked query patterns.
+func (b *QueryBlocklist) SetConfig(blocked []config.BlockedQueryConfiguration) {
+	actions := make(map[string]config.BlockedQueryAction, len(blocked))
+	for _, q := range blocked {
+		action := q.Action
+		if action == "" {
+			action = config.BlockedQueryReject
+		}
+		actions[q.Fingerprint] = action
+	}
+
+	b.Lock()
+	b.actions = actions
+	b.Unlock()
+}
+
+// Wrap returns a handler that rejects requests to the given handler that
+// match a rejected query pattern, and marks those that match a deprioritized
+// query pattern with the low query priority.
+func (b *QueryBlocklist) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.RLock()
+		empty := len(b.actions) == 0
+		b.RUnlock()
+		if empty {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		fingerprint := requestFingerprint(r)
+		b.RLock()
+		action, ok := b.actions[fingerprint]
+		b.RUnlock()
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch action {
+		case config.BlockedQueryDeprioritize:
+			b.metrics.deprioritized.Inc(1)
+			r.Header.Set(handleroptions.QueryPriorityHeader,
+				handleroptions.QueryPriorityLow)
+			next.ServeHTTP(w, r)
+		default:
+			b.metrics.rejected.Inc(1)
+			xhttp.Error(w, fmt.Errorf("%v: %s", errQueryBlocked, fingerprint),
+				http.StatusForbidden)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	fp, err := QueryFingerprint(`sum(rate(foo{a="1",b=~"x.*"}[5m]))`)
+	require.NoError(t, err)
+
+	// Functions, ranges and matcher order do not change the fingerprint.
+	same, err := QueryFingerprint(`foo{b=~"x.*",a="1"}`)
+	require.NoError(t, err)
+	assert.Equal(t, fp, same)
+
+	other, err := QueryFingerprint(`foo{a="2",b=~"x.*"}`)
+	require.NoError(t, err)
+	assert.NotEqual(t, fp, other)
+
+	_, err = QueryFingerprint(`foo{`)
+	require.Error(t, err)
+}
+
+func TestQueryBlocklist(t *testing.T) {
+	rejected, err := QueryFingerprint(`foo`)
+	require.NoError(t, err)
+	deprioritized, err := QueryFingerprint(`bar`)
+	require.NoError(t, err)
+
+	var priority string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		priority = r.Header.Get(handleroptions.QueryPriorityHeader)
+		w.WriteHeader(http.StatusOK)
+	})
+	blocklist := NewQueryBlocklist([]config.BlockedQueryConfiguration{
+		{Fingerprint: rejected},
+		{Fingerprint: deprioritized, Action: config.BlockedQueryDeprioritize},
+	}, instrument.NewOptions())
+	h := blocklist.Wrap(next)
+
+	serve := func(query string) int {
+		priority = ""
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+			"/query?query="+url.QueryEscape(query), nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(`sum(foo)`))
+	assert.Equal(t, http.StatusOK, serve(`sum(bar)`))
+	assert.Equal(t, handleroptions.QueryPriorityLow, priority)
+	assert.Equal(t, http.StatusOK, serve(`sum(baz)`))
+	assert.Equal(t, "", priority)
+
+	blocklist.SetConfig(nil)
+	assert.Equal(t, http.StatusOK, serve(`sum(foo)`))
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// RunningQueriesURL is the url of the running queries handler.
+	RunningQueriesURL = RoutePrefixV1 + "/queries/running"
+
+	idParam = "id"
+)
+
+var (
+	// RunningQueriesHTTPMethods are the HTTP methods used with this resource.
+	RunningQueriesHTTPMethods = []string{http.MethodGet, http.MethodDelete}
+)
+
+// RunningQuery is a query executing on this instance.
+type RunningQuery struct {
+	ID          string        `json:"id"`
+	Tenant      string        `json:"tenant"`
+	Path        string        `json:"path"`
+	Query       string        `json:"query,omitempty"`
+	Fingerprint string        `json:"fingerprint,omitempty"`
+	Start       time.Time     `json:"start"`
+	Elapsed     time.Duration `json:"elapsed"`
+}
+
+// RunningQueriesResponse is the response of the running queries handler.
+type RunningQueriesResponse struct {
+	Queries []RunningQuery `json:"queries"`
+}
+
+type runningQuery struct {
+	query  RunningQuery
+	cancel context.CancelFunc
+}
+
+// RunningQueries tracks the queries executing on this instance so that they
+// can be listed and cancelled.
+type RunningQueries struct {
+	sync.Mutex
+
+	nextID    uint64
+	queries   map[string]*runningQuery
+	nowFn     clock.NowFn
+	cancelled tally.Counter
+}
+
+// NewRunningQueries returns a new running queries registry.
+func NewRunningQueries(
+	nowFn clock.NowFn,
+	instrumentOpts instrument.Options,
+) *RunningQueries {
+	return &RunningQueries{
+		queries: make(map[string]*runningQuery),
+		nowFn:   nowFn,
+		cancelled: instrumentOpts.MetricsScope().
+			SubScope("running-queries").Counter("cancelled"),
+	}
+}
+
+// Wrap returns a handler that tracks requests to the given handler while
+// they execute, setting the ID they are tracked under on the response.
+func (q *RunningQueries) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		tenant := r.Header.Get(handleroptions.TenantHeader)
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+		query := r.FormValue(queryParam)
+		id := q.add(RunningQuery{
+			Tenant:      tenant,
+			Path:        r.URL.Path,
+			Query:       query,
+			Fingerprint: requestFingerprint(r),
+		}, cancel)
+		defer q.remove(id)
+
+		w.Header().Set(handleroptions.QueryIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Queries returns the running queries, longest running first.
+func (q *RunningQueries) Queries() []RunningQuery {
+	now := q.nowFn()
+	q.Lock()
+	queries := make([]RunningQuery, 0, len(q.queries))
+	for _, rq := range q.queries {
+		query := rq.query
+		query.Elapsed = now.Sub(query.Start)
+		queries = append(queries, query)
+	}
+	q.Unlock()
+
+	sort.Slice(queries, func(i, j int) bool {
+		return queries[i].Start.Before(queries[j].Start)
+	})
+	return queries
+}
+
+// Cancel cancels the running query with the given ID, returning false if no
+// such query is running.
+func (q *RunningQueries) Cancel(id string) bool {
+	q.Lock()
+	rq, ok := q.queries[id]
+	q.Unlock()
+	if !ok {
+		return false
+	}
+	rq.cancel()
+	q.cancelled.Inc(1)
+	return true
+}
+
+func (q *RunningQueries) add(query RunningQuery, cancel context.CancelFunc) string {
+	q.Lock()
+	q.nextID++
+	query.ID = strconv.FormatUint(q.nextID, 10)
+	query.Start = q.nowFn()
+	q.queries[query.ID] = &runningQuery{query: query, cancel: cancel}
+	q.Unlock()
+	return query.ID
+}
+
+func (q *RunningQueries) remove(id string) {
+	q.Lock()
+	delete(q.queries, id)
+	q.Unlock()
+}
+
+type runningQueriesHandler struct {
+	queries        *RunningQueries
+	instrumentOpts instrument.Options
+}
+
+// NewRunningQueriesHandler returns a handler that lists the running queries
+// on GET requests, and cancels the query given by the id query parameter on
+// DELETE requests.
+func NewRunningQueriesHandler(
+	queries *RunningQueries,
+	instrumentOpts instrument.Options,
+) http.Handler {
+	return &runningQueriesHandler{
+		queries:        queries,
+		instrumentOpts: instrumentOpts,
+	}
+}
+
+func (h *runningQueriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+	switch r.Method {
+	case http.MethodGet:
+		xhttp.WriteJSONResponse(w, RunningQueriesResponse{
+			Queries: h.queries.Queries(),
+		}, logger)
+	case http.MethodDelete:
+		id := r.URL.Query().Get(idParam)
+		if id == "" {
+			xhttp.Error(w, fmt.Errorf("missing %s parameter", idParam),
+				http.StatusBadRequest)
+			return
+		}
+		if !h.queries.Cancel(id) {
+			xhttp.Error(w, fmt.Errorf("query is not running: %s", id),
+				http.StatusNotFound)
+			return
+		}
+		logger.Info("cancelled running query", zap.String("id", id))
+		xhttp.WriteJSONResponse(w, RunningQueriesResponse{
+			Queries: h.queries.Queries(),
+		}, logger)
+	default:
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunningQueries(t *testing.T) {
+	var (
+		now     = time.Now()
+		queries = NewRunningQueries(func() time.Time { return now },
+			instrument.NewOptions())
+		started = make(chan struct{})
+		done    = make(chan error, 1)
+		served  = make(chan struct{})
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		done <- r.Context().Err()
+	})
+	h := queries.Wrap(next)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/query?query=foo", nil)
+	req.Header.Set(handleroptions.TenantHeader, "tenant-a")
+	go func() {
+		h.ServeHTTP(w, req)
+		close(served)
+	}()
+	<-started
+
+	now = now.Add(time.Second)
+	handler := NewRunningQueriesHandler(queries, instrument.NewOptions())
+	list := httptest.NewRecorder()
+	handler.ServeHTTP(list, httptest.NewRequest(http.MethodGet, RunningQueriesURL, nil))
+	require.Equal(t, http.StatusOK, list.Code)
+	var resp RunningQueriesResponse
+	require.NoError(t, json.Unmarshal(list.Body.Bytes(), &resp))
+	require.Equal(t, 1, len(resp.Queries))
+	running := resp.Queries[0]
+	assert.Equal(t, "1", running.ID)
+	assert.Equal(t, "tenant-a", running.Tenant)
+	assert.Equal(t, "foo", running.Query)
+	assert.NotEqual(t, "", running.Fingerprint)
+	assert.Equal(t, time.Second, running.Elapsed)
+
+	cancel := httptest.NewRecorder()
+	handler.ServeHTTP(cancel, httptest.NewRequest(http.MethodDelete,
+		RunningQueriesURL+"?id=2", nil))
+	assert.Equal(t, http.StatusNotFound, cancel.Code)
+
+	cancel = httptest.NewRecorder()
+	handler.ServeHTTP(cancel, httptest.NewRequest(http.MethodDelete,
+		RunningQueriesURL+"?id=1", nil))
+	assert.Equal(t, http.StatusOK, cancel.Code)
+	require.Error(t, <-done)
+
+	// The query is no longer listed once its handler returns.
+	<-served
+	assert.Equal(t, 0, len(queries.Queries()))
+	assert.Equal(t, "1", w.Header().Get(handleroptions.QueryIDHeader))
+}
@@ -0,0 +1,422 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	// DefaultTenant is the tenant that queries without a tenant header are
+	// attributed to.
+	DefaultTenant = "default"
+
+	// otherTenants tags the metrics of tenants without a configured limit or
+	// weight, so that the tenants tagging metrics are bounded.
+	otherTenants = "other"
+
+	defaultMaxTenants        = 1000
+	defaultTenantIdleTimeout = 10 * time.Minute
+)
+
+var errTenantQueueTimeout = errors.New("timed out waiting for tenant query slot")
+
+type tenantQueryMetrics struct {
+	admitted        tally.Counter
+	rejectedTimeout tally.Counter
+	rejectedCancel  tally.Counter
+	queueLatency    tally.Timer
+}
+
+func newTenantQueryMetrics(scope tally.Scope) tenantQueryMetrics {
+	return tenantQueryMetrics{
+		admitted: scope.Counter("admitted"),
+		rejectedTimeout: scope.Tagged(map[string]string{"reason": "queue-timeout"}).
+			Counter("rejected"),
+		rejectedCancel: scope.Tagged(map[string]string{"reason": "cancelled"}).
+			Counter("rejected"),
+		queueLatency: scope.Timer("queue-latency"),
+	}
+}
+
+// TenantQueryLimiter limits the number of queries each tenant may execute
+// concurrently, and optionally the number of queries of all tenants, so that
+// no one tenant can starve the others. Queries over a limit are queued until
+// they can execute or the max queue time elapses, and queued queries are
+// admitted in weighted fair order.
+type TenantQueryLimiter struct {
+	sync.RWMutex
+
+	scheduler *tenantScheduler
+	scope     tally.Scope
+
+	metricsLock sync.Mutex
+	metrics     map[string]tenantQueryMetrics
+}
+
+// NewTenantQueryLimiter returns a new tenant query limiter.
+func NewTenantQueryLimiter(
+	cfg config.PerTenantLimitsConfiguration,
+	instrumentOpts instrument.Options,
+) *TenantQueryLimiter {
+	l := &TenantQueryLimiter{
+		scope:   instrumentOpts.MetricsScope().SubScope("tenant-queries"),
+		metrics: make(map[string]tenantQueryMetrics),
+	}
+	l.SetConfig(cfg)
+	return l
+}
+
+// SetConfig replaces the limits, taking effect for queries admitted after
+// it returns. Queries already admitted or queued are subject to the limits
+// they were admitted or queued with until they complete.
+func (l *TenantQueryLimiter) SetConfig(cfg config.PerTenantLimitsConfiguration) {
+	var scheduler *tenantScheduler
+	if limitsEnabled(cfg) {
+		scheduler = newTenantScheduler(cfg, l.tenantMetrics)
+	}
+
+	l.Lock()
+	l.scheduler = scheduler
+	l.Unlock()
+}
+
+// Enabled returns true if any tenant, or all tenants, have a concurrent
+// query limit.
+func (l *TenantQueryLimiter) Enabled() bool {
+	return l.currentScheduler() != nil
+}
+
+func (l *TenantQueryLimiter) currentScheduler() *tenantScheduler {
+	l.RLock()
+	defer l.RUnlock()
+	return l.scheduler
+}
+
+func limitsEnabled(cfg config.PerTenantLimitsConfiguration) bool {
+	if cfg.MaxConcurrentQueries > 0 || cfg.MaxTotalConcurrentQueries > 0 {
+		return true
+	}
+
+	for _, limit := range cfg.Overrides {
+		if limit > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Wrap returns a handler that admits requests to the given handler subject
+// to the limits of the tenant issuing them. Requests are passed straight to
+// the given handler while no limits are set, but are still wrapped so that
+// limits set later with SetConfig take effect.
+func (l *TenantQueryLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheduler := l.currentScheduler()
+		if scheduler == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tenant := r.Header.Get(handleroptions.TenantHeader)
+		if tenant == "" {
+			tenant = DefaultTenant
+		}
+
+		release, err := scheduler.acquire(r, tenant)
+		if err != nil {
+			w.Header().Set(handleroptions.RetryHeader, "true")
+			xhttp.Error(w, fmt.Errorf("tenant %s: %v", tenant, err),
+				http.StatusTooManyRequests)
+			return
+		}
+
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tenantMetrics returns the metrics of the given tenant tag.
+func (l *TenantQueryLimiter) tenantMetrics(tag string) tenantQueryMetrics {
+	l.metricsLock.Lock()
+	defer l.metricsLock.Unlock()
+
+	m, ok := l.metrics[tag]
+	if !ok {
+		m = newTenantQueryMetrics(l.scope.Tagged(map[string]string{"tenant": tag}))
+		l.metrics[tag] = m
+	}
+	return m
+}
+
+// tenantQueries is the executing and queued queries of a tenant.
+type tenantQueries struct {
+	limit    int
+	weight   int
+	running  int
+	queue    []*queuedQuery
+	lastUsed time.Time
+	metrics  tenantQueryMetrics
+}
+
+func (t *tenantQueries) canRun() bool {
+	return t.limit <= 0 || t.running < t.limit
+}
+
+// before returns whether the next queued query of the tenant should be
+// admitted before that of the other tenant, preferring the tenant with the
+// fewest executing queries relative to its weight and then the query that
+// has been queued the longest.
+func (t *tenantQueries) before(other *tenantQueries) bool {
+	share, otherShare := t.running*other.weight, other.running*t.weight
+	if share != otherShare {
+		return share < otherShare
+	}
+	return t.queue[0].enqueued.Before(other.queue[0].enqueued)
+}
+
+type queuedQuery struct {
+	enqueued   time.Time
+	admitted   chan struct{}
+	isAdmitted bool
+}
+
+// tenantScheduler admits the queries of tenants subject to a single
+// configuration of limits.
+type tenantScheduler struct {
+	sync.Mutex
+
+	cfg         config.PerTenantLimitsConfiguration
+	maxTenants  int
+	idleTimeout time.Duration
+	metricsFn   func(tag string) tenantQueryMetrics
+	tenants     map[string]*tenantQueries
+	waiting     map[*tenantQueries]struct{}
+	running     int
+	lastSweep   time.Time
+}
+
+func newTenantScheduler(
+	cfg config.PerTenantLimitsConfiguration,
+	metricsFn func(tag string) tenantQueryMetrics,
+) *tenantScheduler {
+	s := &tenantScheduler{
+		cfg:         cfg,
+		maxTenants:  defaultMaxTenants,
+		idleTimeout: defaultTenantIdleTimeout,
+		metricsFn:   metricsFn,
+		tenants:     make(map[string]*tenantQueries),
+		waiting:     make(map[*tenantQueries]struct{}),
+		lastSweep:   time.Now(),
+	}
+	if cfg.MaxTenants > 0 {
+		s.maxTenants = cfg.MaxTenants
+	}
+	if cfg.TenantIdleTimeout > 0 {
+		s.idleTimeout = cfg.TenantIdleTimeout
+	}
+	return s
+}
+
+func (s *tenantScheduler) acquire(
+	r *http.Request,
+	tenant string,
+) (func(), error) {
+	start := time.Now()
+	t, q := s.enqueue(tenant, start)
+	release := func() { s.release(t) }
+	select {
+	case <-q.admitted:
+		t.metrics.admitted.Inc(1)
+		return release, nil
+	default:
+	}
+
+	var timeout <-chan time.Time
+	if s.cfg.MaxQueueTime > 0 {
+		timer := time.NewTimer(s.cfg.MaxQueueTime)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-q.admitted:
+	case <-timeout:
+		if s.dequeue(t, q) {
+			t.metrics.rejectedTimeout.Inc(1)
+			return nil, errTenantQueueTimeout
+		}
+	case <-r.Context().Done():
+		if s.dequeue(t, q) {
+			t.metrics.rejectedCancel.Inc(1)
+			return nil, r.Context().Err()
+		}
+	}
+
+	// NB: the query may have been admitted while timing out.
+	t.metrics.queueLatency.Record(time.Since(start))
+	t.metrics.admitted.Inc(1)
+	return release, nil
+}
+
+// enqueue queues a query of the given tenant, admitting it straight away
+// if it may execute.
+func (s *tenantScheduler) enqueue(
+	tenant string,
+	now time.Time,
+) (*tenantQueries, *queuedQuery) {
+	s.Lock()
+	defer s.Unlock()
+
+	var (
+		t = s.tenant(tenant, now)
+		q = &queuedQuery{enqueued: now, admitted: make(chan struct{})}
+	)
+	t.queue = append(t.queue, q)
+	s.waiting[t] = struct{}{}
+	s.dispatch()
+	return t, q
+}
+
+func (s *tenantScheduler) release(t *tenantQueries) {
+	s.Lock()
+	t.running--
+	t.lastUsed = time.Now()
+	s.running--
+	s.dispatch()
+	s.Unlock()
+}
+
+// dequeue removes a query that has not been admitted from its tenant's
+// queue, returning false if it has been admitted.
+func (s *tenantScheduler) dequeue(t *tenantQueries, q *queuedQuery) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if q.isAdmitted {
+		return false
+	}
+
+	for i, queued := range t.queue {
+		if queued == q {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			break
+		}
+	}
+	if len(t.queue) == 0 {
+		delete(s.waiting, t)
+	}
+	return true
+}
+
+// dispatch admits queued queries in weighted fair order until no more
+// queries may execute.
+func (s *tenantScheduler) dispatch() {
+	for {
+		if max := s.cfg.MaxTotalConcurrentQueries; max > 0 && s.running >= max {
+			return
+		}
+
+		var next *tenantQueries
+		for t := range s.waiting {
+			if t.canRun() && (next == nil || t.before(next)) {
+				next = t
+			}
+		}
+		if next == nil {
+			return
+		}
+
+		q := next.queue[0]
+		next.queue[0] = nil
+		next.queue = next.queue[1:]
+		if len(next.queue) == 0 {
+			delete(s.waiting, next)
+		}
+		next.running++
+		s.running++
+		q.isAdmitted = true
+		close(q.admitted)
+	}
+}
+
+// tenant returns the queries of the given tenant, forgetting idle tenants
+// and attributing the queries of tenants over the max tenants to the
+// default tenant.
+func (s *tenantScheduler) tenant(tenant string, now time.Time) *tenantQueries {
+	if now.Sub(s.lastSweep) >= s.idleTimeout {
+		s.lastSweep = now
+		for name, t := range s.tenants {
+			if t.running == 0 && len(t.queue) == 0 &&
+				now.Sub(t.lastUsed) >= s.idleTimeout {
+				delete(s.tenants, name)
+			}
+		}
+	}
+
+	t, ok := s.tenants[tenant]
+	if !ok && len(s.tenants) >= s.maxTenants && tenant != DefaultTenant {
+		tenant = DefaultTenant
+		t, ok = s.tenants[tenant]
+	}
+	if !ok {
+		t = s.newTenantQueries(tenant)
+		s.tenants[tenant] = t
+	}
+	t.lastUsed = now
+	return t
+}
+
+func (s *tenantScheduler) newTenantQueries(tenant string) *tenantQueries {
+	var (
+		limit                    = s.cfg.MaxConcurrentQueries
+		weight                   = 1
+		override, isOverridden   = s.cfg.Overrides[tenant]
+		tenantWeight, isWeighted = s.cfg.Weights[tenant]
+		tag                      = otherTenants
+	)
+	if isOverridden {
+		limit = override
+	}
+	if isWeighted && tenantWeight > 0 {
+		weight = tenantWeight
+	}
+	if isOverridden || isWeighted || tenant == DefaultTenant {
+		tag = tenant
+	}
+	return &tenantQueries{
+		limit:   limit,
+		weight:  weight,
+		metrics: s.metricsFn(tag),
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestTenantQueryLimiterDisabled(t *testing.T) {
+	limiter := NewTenantQueryLimiter(config.PerTenantLimitsConfiguration{},
+		instrument.NewOptions())
+	assert.False(t, limiter.Enabled())
+	assert.Nil(t, limiter.currentScheduler())
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	limiter.Wrap(next).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/query", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTenantQueryLimiter(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	limiter := NewTenantQueryLimiter(config.PerTenantLimitsConfiguration{
+		MaxConcurrentQueries: 1,
+		MaxQueueTime:         10 * time.Millisecond,
+		Overrides:            map[string]int{"foo": 1, "unlimited": 0},
+	}, instrument.NewOptions().SetMetricsScope(scope))
+	require.True(t, limiter.Enabled())
+
+	var (
+		started = make(chan struct{})
+		unblock = make(chan struct{})
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("block") != "" {
+			started <- struct{}{}
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := limiter.Wrap(next)
+
+	serve := func(tenant string, block bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/query", nil)
+		if tenant != "" {
+			req.Header.Set(handleroptions.TenantHeader, tenant)
+		}
+		if block {
+			req.Header.Set("block", "true")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, http.StatusOK, serve("foo", true).Code)
+	}()
+	<-started
+
+	// Tenant foo is at its limit so queues then is rejected.
+	w := serve("foo", false)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "true", w.Header().Get(handleroptions.RetryHeader))
+
+	// Other tenants are unaffected.
+	assert.Equal(t, http.StatusOK, serve("", false).Code)
+	assert.Equal(t, http.StatusOK, serve("bar", false).Code)
+	assert.Equal(t, http.StatusOK, serve("unlimited", false).Code)
+
+	close(unblock)
+	<-done
+	assert.Equal(t, http.StatusOK, serve("foo", false).Code)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2),
+		counters["tenant-queries.admitted+tenant=foo"].Value())
+	assert.Equal(t, int64(1),
+		counters["tenant-queries.rejected+reason=queue-timeout,tenant=foo"].Value())
+	assert.Equal(t, int64(1),
+		counters["tenant-queries.admitted+tenant=default"].Value())
+}
+
+func TestTenantQueryLimiterSetConfig(t *testing.T) {
+	limiter := NewTenantQueryLimiter(config.PerTenantLimitsConfiguration{},
+		instrument.NewOptions())
+	require.False(t, limiter.Enabled())
+
+	var (
+		started = make(chan struct{})
+		unblock = make(chan struct{})
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("block") != "" {
+			started <- struct{}{}
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := limiter.Wrap(next)
+
+	serve := func(block bool) int {
+		req := httptest.NewRequest(http.MethodGet, "/query", nil)
+		if block {
+			req.Header.Set("block", "true")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Limits set after the handler is wrapped still apply.
+	limiter.SetConfig(config.PerTenantLimitsConfiguration{
+		MaxConcurrentQueries: 1,
+		MaxQueueTime:         10 * time.Millisecond,
+	})
+	require.True(t, limiter.Enabled())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, http.StatusOK, serve(true))
+	}()
+	<-started
+	assert.Equal(t, http.StatusTooManyRequests, serve(false))
+
+	// Lifting the limits admits queries straight away.
+	limiter.SetConfig(config.PerTenantLimitsConfiguration{})
+	require.False(t, limiter.Enabled())
+	assert.Equal(t, http.StatusOK, serve(false))
+
+	close(unblock)
+	<-done
+}
+
+func TestTenantQueryLimiterFoldsTenantsOverMax(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	limiter := NewTenantQueryLimiter(config.PerTenantLimitsConfiguration{
+		MaxConcurrentQueries: 1,
+		MaxQueueTime:         10 * time.Millisecond,
+		MaxTenants:           1,
+	}, instrument.NewOptions().SetMetricsScope(scope))
+
+	var (
+		started = make(chan struct{})
+		unblock = make(chan struct{})
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("block") != "" {
+			started <- struct{}{}
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := limiter.Wrap(next)
+
+	serve := func(tenant string, block bool) int {
+		req := httptest.NewRequest(http.MethodGet, "/query", nil)
+		if tenant != "" {
+			req.Header.Set(handleroptions.TenantHeader, tenant)
+		}
+		if block {
+			req.Header.Set("block", "true")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("foo", false))
+
+	// Tenant bar is over the max tenants so shares the default tenant's
+	// limit.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, http.StatusOK, serve("bar", true))
+	}()
+	<-started
+	assert.Equal(t, http.StatusTooManyRequests, serve("", false))
+	assert.Equal(t, http.StatusTooManyRequests, serve("baz", false))
+	assert.Equal(t, http.StatusOK, serve("foo", false))
+
+	close(unblock)
+	<-done
+
+	// Only tenants with a configured limit or weight tag metrics.
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2),
+		counters["tenant-queries.admitted+tenant=other"].Value())
+	assert.Equal(t, int64(1),
+		counters["tenant-queries.admitted+tenant=default"].Value())
+	assert.Equal(t, int64(2),
+		counters["tenant-queries.rejected+reason=queue-timeout,tenant=default"].Value())
+}
+
+func TestTenantSchedulerForgetsIdleTenants(t *testing.T) {
+	s := newTenantScheduler(config.PerTenantLimitsConfiguration{
+		MaxConcurrentQueries: 1,
+		TenantIdleTimeout:    time.Minute,
+	}, func(string) tenantQueryMetrics {
+		return newTenantQueryMetrics(tally.NoopScope)
+	})
+
+	now := time.Now()
+	foo, _ := s.enqueue("foo", now)
+	s.enqueue("bar", now)
+	s.release(foo)
+
+	// Tenant bar is still executing a query so is not forgotten.
+	s.enqueue("baz", now.Add(2*time.Minute))
+	_, fooTracked := s.tenants["foo"]
+	_, barTracked := s.tenants["bar"]
+	assert.False(t, fooTracked)
+	assert.True(t, barTracked)
+}
+
+func TestTenantSchedulerWeightedFairOrder(t *testing.T) {
+	s := newTenantScheduler(config.PerTenantLimitsConfiguration{
+		MaxTotalConcurrentQueries: 3,
+		Weights:                   map[string]int{"a": 2},
+	}, func(string) tenantQueryMetrics {
+		return newTenantQueryMetrics(tally.NoopScope)
+	})
+
+	var (
+		now     = time.Now()
+		queries = make(map[string]*queuedQuery)
+		tenants = make(map[string]*tenantQueries)
+	)
+	for i, name := range []string{"a1", "a2", "b1", "a3", "a4", "b2", "b3"} {
+		tenant := name[:1]
+		tenants[tenant], queries[name] = s.enqueue(tenant,
+			now.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	admitted := func() []string {
+		var result []string
+		for _, name := range []string{"a1", "a2", "b1", "a3", "a4", "b2", "b3"} {
+			if queries[name].isAdmitted {
+				result = append(result, name)
+			}
+		}
+		return result
+	}
+	assert.Equal(t, []string{"a1", "a2", "b1"}, admitted())
+
+	// Tenant a may execute twice as many queries as tenant b.
+	s.release(tenants["b"])
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2"}, admitted())
+	s.release(tenants["a"])
+	assert.Equal(t, []string{"a1", "a2", "b1", "a3", "b2"}, admitted())
+	s.release(tenants["a"])
+	assert.Equal(t, []string{"a1", "a2", "b1", "a3", "a4", "b2"}, admitted())
+	s.release(tenants["b"])
+	assert.Equal(t, []string{"a1", "a2", "b1", "a3", "a4", "b2", "b3"}, admitted())
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package httpd
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/m3db/m3/src/query/api/experimental/annotated"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/datadog"
+	"github.com/m3db/m3/src/query/api/v1/handler/influxdb"
+	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/remote"
+	"github.com/m3db/m3/src/x/health"
+)
+
+var (
+	writeRoutes = map[string]struct{}{
+		remote.PromWriteURL:      {},
+		influxdb.InfluxWriteURL:  {},
+		otlp.OTLPWriteURL:        {},
+		m3json.WriteJSONURL:      {},
+		annotated.WriteURL:       {},
+		datadog.SeriesV1WriteURL: {},
+		datadog.SeriesV2WriteURL: {},
+	}
+
+	// adminRoutePrefixes are the prefixes of the routes managing cluster
+	// state, reading cluster state only requires the read role.
+	adminRoutePrefixes = []string{
+		handler.RoutePrefixV1 + "/services/",
+		handler.RoutePrefixV1 + "/namespace",
+		handler.RoutePrefixV1 + "/placement",
+		handler.RoutePrefixV1 + "/database/",
+		handler.RoutePrefixV1 + "/topic",
+		handler.RoutePrefixV1 + "/admin/",
+		handler.RunningQueriesURL,
+	}
+)
+
+// defaultAuthRole returns the role required by a route when it is not
+// overridden by the auth configuration.
+func defaultAuthRole(r *http.Request) handler.AuthRole {
+	path := r.URL.Path
+	if path == healthURL || path == health.ReadinessURL {
+		return handler.AuthRoleNone
+	}
+	// NB: debug endpoints expose profiles and cluster internals.
+	if strings.HasPrefix(path, "/debug/") {
+		return handler.AuthRoleAdmin
+	}
+	if _, ok := writeRoutes[path]; ok {
+		return handler.AuthRoleWrite
+	}
+	for _, prefix := range adminRoutePrefixes {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return handler.AuthRoleRead
+		}
+		return handler.AuthRoleAdmin
+	}
+	return handler.AuthRoleRead
+}
@@ -27,14 +27,17 @@ import (
 	_ "net/http/pprof" // needed for pprof handler registration
 	"time"
 
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/query/api/experimental/annotated"
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/handler/database"
+	"github.com/m3db/m3/src/query/api/v1/handler/datadog"
 	"github.com/m3db/m3/src/query/api/v1/handler/graphite"
 	"github.com/m3db/m3/src/query/api/v1/handler/influxdb"
 	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
 	"github.com/m3db/m3/src/query/api/v1/handler/namespace"
 	"github.com/m3db/m3/src/query/api/v1/handler/openapi"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
 	"github.com/m3db/m3/src/query/api/v1/handler/placement"
 	"github.com/m3db/m3/src/query/api/v1/handler/prom"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
@@ -44,6 +47,7 @@ import (
 	"github.com/m3db/m3/src/query/api/v1/options"
 	"github.com/m3db/m3/src/query/util/logging"
 	xdebug "github.com/m3db/m3/src/x/debug"
+	"github.com/m3db/m3/src/x/health"
 	xhttp "github.com/m3db/m3/src/x/net/http"
 	"github.com/m3db/m3/src/x/net/http/cors"
 
@@ -78,6 +82,8 @@ type Handler struct {
 	handler        http.Handler
 	options        options.HandlerOptions
 	customHandlers []options.CustomHandler
+	tenantLimiter  *handler.TenantQueryLimiter
+	queryBlocklist *handler.QueryBlocklist
 }
 
 // Router returns the http handler registered with all relevant routes for query.
@@ -101,6 +107,17 @@ func NewHandler(
 	}
 }
 
+// UpdateConfig applies the tunables of the given config that can be changed
+// at runtime to the registered routes.
+func (h *Handler) UpdateConfig(cfg config.Configuration) {
+	if h.tenantLimiter != nil {
+		h.tenantLimiter.SetConfig(cfg.Limits.PerTenant)
+	}
+	if h.queryBlocklist != nil {
+		h.queryBlocklist.SetConfig(cfg.Limits.Blocklist)
+	}
+}
+
 func applyMiddleware(base *mux.Router, tracer opentracing.Tracer) http.Handler {
 	withMiddleware := http.Handler(&cors.Handler{
 		Handler: base,
@@ -138,12 +155,24 @@ func (h *Handler) RegisterRoutes() error {
 		}
 	)
 
+	// Authorize requests to all routes, including custom ones.
+	authorizer, err := handler.NewAuthorizer(h.options.Config().Auth,
+		defaultAuthRole, instrumentOpts)
+	if err != nil {
+		return fmt.Errorf("unable to create authorizer: %v", err)
+	}
+	h.router.Use(authorizer.Middleware)
+
 	h.router.HandleFunc(openapi.URL,
 		wrapped(openapi.NewDocHandler(instrumentOpts)).ServeHTTP,
 	).Methods(openapi.HTTPMethod)
 	h.router.PathPrefix(openapi.StaticURLPrefix).
 		Handler(wrapped(openapi.StaticHandler()))
 
+	// Log the requests to the query and write endpoints.
+	accessLogger := handler.NewAccessLogger(h.options.Config().AccessLog,
+		h.options.NowFn(), instrumentOpts)
+
 	// Prometheus remote read/write endpoints.
 	remoteSourceOpts := h.options.SetInstrumentOpts(instrumentOpts.
 		SetMetricsScope(instrumentOpts.MetricsScope().
@@ -177,10 +206,25 @@ func (h *Handler) RegisterRoutes() error {
 	opts := prom.Options{
 		PromQLEngine: h.options.PrometheusEngine(),
 	}
-	promqlQueryHandler := wrapped(prom.NewReadHandler(opts, nativeSourceOpts))
-	promqlInstantQueryHandler := wrapped(prom.NewReadInstantHandler(opts, nativeSourceOpts))
-	nativePromReadHandler := wrapped(native.NewPromReadHandler(nativeSourceOpts))
-	nativePromReadInstantHandler := wrapped(native.NewPromReadInstantHandler(nativeSourceOpts))
+	// Apply access logs, the query blocklist, per-tenant query limits and
+	// memory load shedding to the query endpoints, and track the admitted queries so
+	// they can be listed and cancelled.
+	h.queryBlocklist = handler.NewQueryBlocklist(
+		h.options.Config().Limits.Blocklist, instrumentOpts)
+	h.tenantLimiter = handler.NewTenantQueryLimiter(
+		h.options.Config().Limits.PerTenant, instrumentOpts)
+	memoryShedder := handler.NewMemoryQueryShedder(
+		h.options.MemoryMonitor(), instrumentOpts)
+	runningQueries := handler.NewRunningQueries(h.options.NowFn(), instrumentOpts)
+	queryWrapped := func(n http.Handler) http.Handler {
+		return wrapped(accessLogger.Wrap(h.queryBlocklist.Wrap(
+			memoryShedder.Wrap(h.tenantLimiter.Wrap(runningQueries.Wrap(n))))))
+	}
+
+	promqlQueryHandler := queryWrapped(prom.NewReadHandler(opts, nativeSourceOpts))
+	promqlInstantQueryHandler := queryWrapped(prom.NewReadInstantHandler(opts, nativeSourceOpts))
+	nativePromReadHandler := queryWrapped(native.NewPromReadHandler(nativeSourceOpts))
+	nativePromReadInstantHandler := queryWrapped(native.NewPromReadInstantHandler(nativeSourceOpts))
 
 	h.options.QueryRouter().Setup(options.QueryRouterOptions{
 		DefaultQueryEngine: h.options.DefaultQueryEngine(),
@@ -205,24 +249,45 @@ func (h *Handler) RegisterRoutes() error {
 	h.router.HandleFunc("/prometheus"+native.PromReadInstantURL, promqlInstantQueryHandler.ServeHTTP).Methods(native.PromReadInstantHTTPMethods...)
 
 	h.router.HandleFunc(remote.PromReadURL,
-		wrapped(promRemoteReadHandler).ServeHTTP,
+		queryWrapped(promRemoteReadHandler).ServeHTTP,
 	).Methods(remote.PromReadHTTPMethods...)
+	h.router.HandleFunc(handler.RunningQueriesURL,
+		wrapped(handler.NewRunningQueriesHandler(runningQueries, instrumentOpts)).ServeHTTP,
+	).Methods(handler.RunningQueriesHTTPMethods...)
 	h.router.HandleFunc(remote.PromWriteURL,
-		panicOnly(promRemoteWriteHandler).ServeHTTP,
+		panicOnly(accessLogger.Wrap(promRemoteWriteHandler)).ServeHTTP,
 	).Methods(remote.PromWriteHTTPMethod)
+	h.router.HandleFunc(handler.IngestQuotasURL,
+		wrapped(handler.NewIngestQuotasHandler(h.options)).ServeHTTP,
+	).Methods(handler.IngestQuotasHTTPMethod)
+	h.router.HandleFunc(handler.IngestCardinalityOffendersURL,
+		wrapped(handler.NewIngestCardinalityOffendersHandler(h.options)).ServeHTTP,
+	).Methods(handler.IngestCardinalityOffendersHTTPMethod)
 	h.router.HandleFunc("/m3query"+native.PromReadURL, nativePromReadHandler.ServeHTTP).Methods(native.PromReadHTTPMethods...)
 	h.router.HandleFunc("/m3query"+native.PromReadInstantURL, nativePromReadInstantHandler.ServeHTTP).Methods(native.PromReadInstantHTTPMethods...)
 
 	// InfluxDB write endpoint.
 	h.router.HandleFunc(influxdb.InfluxWriteURL,
-		wrapped(influxdb.NewInfluxWriterHandler(h.options)).ServeHTTP).Methods(influxdb.InfluxWriteHTTPMethod)
+		wrapped(accessLogger.Wrap(influxdb.NewInfluxWriterHandler(h.options))).ServeHTTP).Methods(influxdb.InfluxWriteHTTPMethod)
+
+	// Datadog agent intake endpoints.
+	h.router.HandleFunc(datadog.SeriesV1WriteURL,
+		wrapped(accessLogger.Wrap(datadog.NewSeriesV1WriteHandler(h.options))).ServeHTTP).Methods(datadog.SeriesWriteHTTPMethod)
+	h.router.HandleFunc(datadog.SeriesV2WriteURL,
+		wrapped(accessLogger.Wrap(datadog.NewSeriesV2WriteHandler(h.options))).ServeHTTP).Methods(datadog.SeriesWriteHTTPMethod)
+	h.router.HandleFunc(datadog.ValidateURL,
+		wrapped(datadog.NewValidateHandler()).ServeHTTP).Methods(datadog.ValidateHTTPMethod)
+
+	// OpenTelemetry OTLP/HTTP metrics write endpoint.
+	h.router.HandleFunc(otlp.OTLPWriteURL,
+		wrapped(accessLogger.Wrap(otlp.NewOTLPWriteHandler(h.options))).ServeHTTP).Methods(otlp.OTLPWriteHTTPMethod)
 
 	// Native M3 search and write endpoints.
 	h.router.HandleFunc(handler.SearchURL,
 		wrapped(handler.NewSearchHandler(h.options)).ServeHTTP,
 	).Methods(handler.SearchHTTPMethod)
 	h.router.HandleFunc(m3json.WriteJSONURL,
-		wrapped(m3json.NewWriteJSONHandler(h.options)).ServeHTTP,
+		wrapped(accessLogger.Wrap(m3json.NewWriteJSONHandler(h.options))).ServeHTTP,
 	).Methods(m3json.JSONWriteHTTPMethod)
 
 	// Tag completion endpoints.
@@ -232,6 +297,9 @@ func (h *Handler) RegisterRoutes() error {
 	h.router.HandleFunc(remote.TagValuesURL,
 		wrapped(remote.NewTagValuesHandler(h.options)).ServeHTTP,
 	).Methods(remote.TagValuesHTTPMethod)
+	h.router.HandleFunc(remote.TagValueCountsURL,
+		wrapped(remote.NewTagValueCountsHandler(h.options)).ServeHTTP,
+	).Methods(remote.TagValueCountsHTTPMethod)
 
 	// List tag endpoints.
 	h.router.HandleFunc(native.ListTagsURL,
@@ -246,10 +314,36 @@ func (h *Handler) RegisterRoutes() error {
 		wrapped(native.NewPromThresholdHandler(h.options)).ServeHTTP,
 	).Methods(native.PromThresholdHTTPMethod)
 
+	// Query cost estimate endpoint.
+	h.router.HandleFunc(native.PromEstimateURL,
+		wrapped(native.NewPromEstimateHandler(h.options)).ServeHTTP,
+	).Methods(native.PromEstimateHTTPMethods...)
+
+	// Export endpoint.
+	h.router.HandleFunc(native.PromExportURL,
+		wrapped(native.NewPromExportHandler(h.options)).ServeHTTP,
+	).Methods(native.PromExportHTTPMethods...)
+
 	// Series match endpoints.
 	h.router.HandleFunc(remote.PromSeriesMatchURL,
 		wrapped(remote.NewPromSeriesMatchHandler(h.options)).ServeHTTP,
 	).Methods(remote.PromSeriesMatchHTTPMethods...)
+	h.router.HandleFunc(remote.PromSeriesMetadataURL,
+		queryWrapped(remote.NewPromSeriesMetadataHandler(h.options)).ServeHTTP,
+	).Methods(remote.PromSeriesMetadataHTTPMethods...)
+
+	// Delete series endpoints.
+	h.router.HandleFunc(remote.DeleteSeriesURL,
+		wrapped(remote.NewDeleteSeriesHandler(h.options)).ServeHTTP,
+	).Methods(remote.DeleteSeriesHTTPMethods...)
+	h.router.HandleFunc(remote.SeriesTombstonesURL,
+		wrapped(remote.NewSeriesTombstonesHandler(h.options)).ServeHTTP,
+	).Methods(remote.SeriesTombstonesHTTPMethods...)
+
+	// Exemplars endpoint.
+	h.router.HandleFunc(remote.PromExemplarsURL,
+		queryWrapped(remote.NewPromExemplarsHandler(h.options)).ServeHTTP,
+	).Methods(remote.PromExemplarsHTTPMethods...)
 
 	// Graphite endpoints.
 	h.router.HandleFunc(graphite.ReadURL,
@@ -260,6 +354,10 @@ func (h *Handler) RegisterRoutes() error {
 		wrapped(graphite.NewFindHandler(h.options)).ServeHTTP,
 	).Methods(graphite.FindHTTPMethods...)
 
+	h.router.HandleFunc(graphite.MappingTestURL,
+		wrapped(graphite.NewMappingTestHandler(h.options)).ServeHTTP,
+	).Methods(graphite.MappingTestHTTPMethods...)
+
 	placementOpts, err := h.placementOpts()
 	if err != nil {
 		return err
@@ -331,12 +429,16 @@ func (h *Handler) RegisterRoutes() error {
 }
 
 func (h *Handler) placementOpts() (placement.HandlerOptions, error) {
-	return placement.NewHandlerOptions(
+	opts, err := placement.NewHandlerOptions(
 		h.options.ClusterClient(),
 		h.options.Config(),
 		h.m3AggServiceOptions(),
 		h.options.InstrumentOpts(),
 	)
+	if err != nil {
+		return placement.HandlerOptions{}, err
+	}
+	return opts.SetWriteErrorCounter(h.options.WriteErrorCounter()), nil
 }
 
 func (h *Handler) m3AggServiceOptions() *handleroptions.M3AggServiceOptions {
@@ -362,15 +464,26 @@ func (h *Handler) m3AggServiceOptions() *handleroptions.M3AggServiceOptions {
 	}
 }
 
-// Endpoints useful for profiling the service.
+// Endpoints used by orchestrators to check liveness and readiness.
 func (h *Handler) registerHealthEndpoints() {
+	registry := h.options.HealthRegistry()
+	if registry == nil {
+		registry = health.NewRegistry(health.NewOptions().
+			SetInstrumentOptions(h.options.InstrumentOpts()))
+	}
+
 	h.router.HandleFunc(healthURL, func(w http.ResponseWriter, r *http.Request) {
-		json.NewEncoder(w).Encode(struct {
+		report := registry.Check(r.Context(), health.Liveness)
+		health.WriteReport(w, report, struct {
 			Uptime string `json:"uptime"`
+			health.Report
 		}{
 			Uptime: time.Since(h.options.CreatedAt()).String(),
+			Report: report,
 		})
 	}).Methods(http.MethodGet)
+	h.router.Handle(health.ReadinessURL,
+		health.NewHandler(registry, health.Readiness)).Methods(http.MethodGet)
 }
 
 // Endpoints useful for profiling the service.
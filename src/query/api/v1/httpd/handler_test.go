@@ -21,7 +21,9 @@
 package httpd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -32,6 +34,8 @@ import (
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/datadog"
 	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/native"
@@ -42,6 +46,7 @@ import (
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test/m3"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/instrument"
 	xsync "github.com/m3db/m3/src/x/sync"
 
@@ -303,6 +308,35 @@ func TestHealthGet(t *testing.T) {
 	assert.True(t, result > 0)
 }
 
+func TestReadyGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage, _ := m3.NewStorageAndSession(t, ctrl)
+
+	h, err := setupHandler(storage)
+	require.NoError(t, err, "unable to setup handler")
+
+	registry := health.NewRegistry(health.NewOptions())
+	require.NoError(t, registry.Register("bootstrap", health.Readiness,
+		func(context.Context) error { return errors.New("bootstrapping") }))
+	h.options = h.options.SetHealthRegistry(registry)
+	require.NoError(t, h.RegisterRoutes())
+
+	// Failed readiness checks fail the readiness endpoint only.
+	res := httptest.NewRecorder()
+	h.Router().ServeHTTP(res, httptest.NewRequest("GET", health.ReadinessURL, nil))
+	require.Equal(t, http.StatusServiceUnavailable, res.Code)
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+	require.False(t, report.Healthy)
+	require.Equal(t, 1, len(report.Checks))
+	assert.Equal(t, "bootstrapping", report.Checks[0].Error)
+
+	res = httptest.NewRecorder()
+	h.Router().ServeHTTP(res, httptest.NewRequest("GET", healthURL, nil))
+	require.Equal(t, http.StatusOK, res.Code)
+}
+
 func TestCORSMiddleware(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	s, _ := m3.NewStorageAndSession(t, ctrl)
@@ -417,3 +451,31 @@ func TestCustomRoutes(t *testing.T) {
 	handler.Router().ServeHTTP(res, req)
 	require.Equal(t, res.Code, http.StatusOK)
 }
+
+func TestDefaultAuthRole(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected handler.AuthRole
+	}{
+		{method: http.MethodGet, path: healthURL, expected: handler.AuthRoleNone},
+		{method: http.MethodGet, path: health.ReadinessURL, expected: handler.AuthRoleNone},
+		{method: http.MethodGet, path: native.PromReadURL, expected: handler.AuthRoleRead},
+		{method: http.MethodPost, path: remote.PromWriteURL, expected: handler.AuthRoleWrite},
+		{method: http.MethodPost, path: datadog.SeriesV1WriteURL, expected: handler.AuthRoleWrite},
+		{method: http.MethodPost, path: datadog.SeriesV2WriteURL, expected: handler.AuthRoleWrite},
+		{method: http.MethodGet, path: "/api/v1/services/m3db/namespace", expected: handler.AuthRoleRead},
+		{method: http.MethodPost, path: "/api/v1/services/m3db/namespace", expected: handler.AuthRoleAdmin},
+		{method: http.MethodDelete, path: "/api/v1/topic", expected: handler.AuthRoleAdmin},
+		{method: http.MethodGet, path: "/debug/pprof/heap", expected: handler.AuthRoleAdmin},
+		{method: http.MethodGet, path: handler.RunningQueriesURL, expected: handler.AuthRoleRead},
+		{method: http.MethodDelete, path: handler.RunningQueriesURL, expected: handler.AuthRoleAdmin},
+		{method: http.MethodPost, path: remote.DeleteSeriesURL, expected: handler.AuthRoleAdmin},
+		{method: http.MethodDelete, path: remote.SeriesTombstonesURL, expected: handler.AuthRoleAdmin},
+		{method: http.MethodGet, path: remote.SeriesTombstonesURL, expected: handler.AuthRoleRead},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, nil)
+		assert.Equal(t, test.expected, defaultAuthRole(r), test.method+" "+test.path)
+	}
+}
@@ -27,7 +27,10 @@ import (
 	"time"
 
 	clusterclient "github.com/m3db/m3/src/cluster/client"
+	"github.com/m3db/m3/src/cluster/placement/rollout"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/quota"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
@@ -36,10 +39,13 @@ import (
 	"github.com/m3db/m3/src/query/executor"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/storage/exemplar"
 	"github.com/m3db/m3/src/query/storage/m3"
 	"github.com/m3db/m3/src/query/ts"
 	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/memory"
 	"github.com/prometheus/prometheus/promql"
 )
 
@@ -200,6 +206,44 @@ type HandlerOptions interface {
 	InstantQueryRouter() QueryRouter
 	// SetInstantQueryRouter sets query router for instant queries.
 	SetInstantQueryRouter(value QueryRouter) HandlerOptions
+
+	// HealthRegistry returns the registry of health checks reported by the
+	// health and readiness endpoints.
+	HealthRegistry() health.Registry
+	// SetHealthRegistry sets the registry of health checks.
+	SetHealthRegistry(value health.Registry) HandlerOptions
+
+	// MemoryMonitor returns the monitor of heap usage used to shed low
+	// priority queries.
+	MemoryMonitor() memory.Monitor
+	// SetMemoryMonitor sets the monitor of heap usage.
+	SetMemoryMonitor(value memory.Monitor) HandlerOptions
+
+	// ExemplarStore returns the store of exemplars received via remote write,
+	// nil if exemplars are not retained.
+	ExemplarStore() exemplar.Store
+	// SetExemplarStore sets the store of exemplars.
+	SetExemplarStore(value exemplar.Store) HandlerOptions
+
+	// IngestQuotas returns the enforcer of per-tenant ingest quotas, nil if
+	// ingest quotas are not enforced.
+	IngestQuotas() quota.Enforcer
+	// SetIngestQuotas sets the enforcer of per-tenant ingest quotas.
+	SetIngestQuotas(value quota.Enforcer) HandlerOptions
+
+	// CardinalityAnalyzer returns the analyzer of the groups of series
+	// creating the most new series, nil if new series are not analyzed.
+	CardinalityAnalyzer() cardinality.Analyzer
+	// SetCardinalityAnalyzer sets the analyzer of the groups of series
+	// creating the most new series.
+	SetCardinalityAnalyzer(value cardinality.Analyzer) HandlerOptions
+
+	// WriteErrorCounter returns the counter of write requests and their
+	// server errors, used to gate placement rollouts on the write error rate.
+	WriteErrorCounter() *rollout.ErrorCounter
+	// SetWriteErrorCounter sets the counter of write requests and their
+	// server errors.
+	SetWriteErrorCounter(value *rollout.ErrorCounter) HandlerOptions
 }
 
 // HandlerOptions represents handler options.
@@ -226,6 +270,12 @@ type handlerOptions struct {
 	nowFn                 clock.NowFn
 	queryRouter           QueryRouter
 	instantQueryRouter    QueryRouter
+	healthRegistry        health.Registry
+	memoryMonitor         memory.Monitor
+	exemplarStore         exemplar.Store
+	ingestQuotas          quota.Enforcer
+	cardinalityAnalyzer   cardinality.Analyzer
+	writeErrorCounter     *rollout.ErrorCounter
 }
 
 // EmptyHandlerOptions returns  default handler options.
@@ -288,6 +338,7 @@ func NewHandlerOptions(
 		},
 		queryRouter:        queryRouter,
 		instantQueryRouter: instantQueryRouter,
+		writeErrorCounter:  rollout.NewErrorCounter(),
 	}, nil
 }
 
@@ -529,3 +580,63 @@ func (o *handlerOptions) SetInstantQueryRouter(value QueryRouter) HandlerOptions
 	opts.instantQueryRouter = value
 	return &opts
 }
+
+func (o *handlerOptions) HealthRegistry() health.Registry {
+	return o.healthRegistry
+}
+
+func (o *handlerOptions) SetHealthRegistry(value health.Registry) HandlerOptions {
+	opts := *o
+	opts.healthRegistry = value
+	return &opts
+}
+
+func (o *handlerOptions) MemoryMonitor() memory.Monitor {
+	return o.memoryMonitor
+}
+
+func (o *handlerOptions) SetMemoryMonitor(value memory.Monitor) HandlerOptions {
+	opts := *o
+	opts.memoryMonitor = value
+	return &opts
+}
+
+func (o *handlerOptions) ExemplarStore() exemplar.Store {
+	return o.exemplarStore
+}
+
+func (o *handlerOptions) SetExemplarStore(value exemplar.Store) HandlerOptions {
+	opts := *o
+	opts.exemplarStore = value
+	return &opts
+}
+
+func (o *handlerOptions) IngestQuotas() quota.Enforcer {
+	return o.ingestQuotas
+}
+
+func (o *handlerOptions) SetIngestQuotas(value quota.Enforcer) HandlerOptions {
+	opts := *o
+	opts.ingestQuotas = value
+	return &opts
+}
+
+func (o *handlerOptions) CardinalityAnalyzer() cardinality.Analyzer {
+	return o.cardinalityAnalyzer
+}
+
+func (o *handlerOptions) SetCardinalityAnalyzer(value cardinality.Analyzer) HandlerOptions {
+	opts := *o
+	opts.cardinalityAnalyzer = value
+	return &opts
+}
+
+func (o *handlerOptions) WriteErrorCounter() *rollout.ErrorCounter {
+	return o.writeErrorCounter
+}
+
+func (o *handlerOptions) SetWriteErrorCounter(value *rollout.ErrorCounter) HandlerOptions {
+	opts := *o
+	opts.writeErrorCounter = value
+	return &opts
+}
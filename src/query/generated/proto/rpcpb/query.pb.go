@@ -1674,6 +1674,7 @@ type QueryClient interface {
 	Fetch(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (Query_FetchClient, error)
 	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (Query_SearchClient, error)
 	CompleteTags(ctx context.Context, in *CompleteTagsRequest, opts ...grpc.CallOption) (Query_CompleteTagsClient, error)
+	FetchStream(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (Query_FetchStreamClient, error)
 }
 
 type queryClient struct {
@@ -1789,6 +1790,38 @@ func (x *queryCompleteTagsClient) Recv() (*CompleteTagsResponse, error) {
 	return m, nil
 }
 
+func (c *queryClient) FetchStream(ctx context.Context, in *FetchRequest, opts ...grpc.CallOption) (Query_FetchStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Query_serviceDesc.Streams[3], c.cc, "/rpc.Query/FetchStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &queryFetchStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Query_FetchStreamClient interface {
+	Recv() (*FetchResponse, error)
+	grpc.ClientStream
+}
+
+type queryFetchStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *queryFetchStreamClient) Recv() (*FetchResponse, error) {
+	m := new(FetchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for Query service
 
 type QueryServer interface {
@@ -1796,6 +1829,7 @@ type QueryServer interface {
 	Fetch(*FetchRequest, Query_FetchServer) error
 	Search(*SearchRequest, Query_SearchServer) error
 	CompleteTags(*CompleteTagsRequest, Query_CompleteTagsServer) error
+	FetchStream(*FetchRequest, Query_FetchStreamServer) error
 }
 
 func RegisterQueryServer(s *grpc.Server, srv QueryServer) {
@@ -1883,6 +1917,27 @@ func (x *queryCompleteTagsServer) Send(m *CompleteTagsResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Query_FetchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServer).FetchStream(m, &queryFetchStreamServer{stream})
+}
+
+type Query_FetchStreamServer interface {
+	Send(*FetchResponse) error
+	grpc.ServerStream
+}
+
+type queryFetchStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *queryFetchStreamServer) Send(m *FetchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Query_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "rpc.Query",
 	HandlerType: (*QueryServer)(nil),
@@ -1908,6 +1963,11 @@ var _Query_serviceDesc = grpc.ServiceDesc{
 			Handler:       _Query_CompleteTags_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "FetchStream",
+			Handler:       _Query_FetchStream_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "github.com/m3db/m3/src/query/generated/proto/rpcpb/query.proto",
 }
@@ -7804,109 +7864,108 @@ func init() {
 }
 
 var fileDescriptorQuery = []byte{
-	// 1662 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x58, 0xdb, 0x72, 0x1b, 0x49,
-	0x19, 0xd6, 0x68, 0xac, 0xd3, 0xaf, 0x83, 0xe5, 0xb6, 0xd9, 0xc8, 0x26, 0x18, 0xd5, 0x00, 0x8b,
-	0xf1, 0x06, 0x2b, 0x91, 0xb3, 0x2c, 0x4b, 0x15, 0x07, 0xd9, 0x52, 0x6c, 0xd7, 0xda, 0x92, 0xb7,
-	0x35, 0x26, 0x81, 0x82, 0x32, 0xad, 0x51, 0x67, 0x3c, 0x65, 0xcd, 0x61, 0x67, 0x46, 0xcb, 0x3a,
-	0xc5, 0x1b, 0x70, 0x43, 0x51, 0x3c, 0x01, 0x14, 0x3c, 0x41, 0x1e, 0x81, 0x0b, 0x2e, 0x79, 0x04,
-	0x2a, 0x70, 0xc1, 0x63, 0x6c, 0x75, 0x4f, 0xcf, 0x49, 0x33, 0xae, 0xa4, 0x72, 0x37, 0xff, 0xb9,
-	0xff, 0xbf, 0xbf, 0xfe, 0xba, 0x25, 0xf8, 0x99, 0x6e, 0xf8, 0x37, 0xcb, 0xd9, 0x81, 0x66, 0x9b,
-	0x3d, 0xf3, 0x70, 0x3e, 0xeb, 0x99, 0x87, 0x3d, 0xcf, 0xd5, 0x7a, 0x5f, 0x2c, 0xa9, 0x7b, 0xd7,
-	0xd3, 0xa9, 0x45, 0x5d, 0xe2, 0xd3, 0x79, 0xcf, 0x71, 0x6d, 0xdf, 0xee, 0xb9, 0x8e, 0xe6, 0xcc,
-	0x02, 0xdb, 0x01, 0xd7, 0x20, 0xd9, 0x75, 0xb4, 0x9d, 0xe1, 0x3d, 0x49, 0x4c, 0xea, 0xbb, 0x86,
-	0xe6, 0x65, 0xd2, 0x38, 0xf6, 0xc2, 0xd0, 0xee, 0x9c, 0x99, 0xf8, 0x08, 0x52, 0x29, 0xeb, 0xd0,
-	0x3c, 0xa5, 0x64, 0xe1, 0xdf, 0x60, 0xfa, 0xc5, 0x92, 0x7a, 0xbe, 0xf2, 0x12, 0x5a, 0xa1, 0xc2,
-	0x73, 0x6c, 0xcb, 0xa3, 0xe8, 0x43, 0x68, 0x2d, 0x1d, 0xdf, 0x30, 0xe9, 0x70, 0xe9, 0x12, 0xdf,
-	0xb0, 0xad, 0x8e, 0xd4, 0x95, 0xf6, 0x6a, 0x78, 0x45, 0x8b, 0x1e, 0xc1, 0x46, 0xa0, 0x19, 0x13,
-	0xcb, 0xf6, 0xa8, 0x66, 0x5b, 0x73, 0xaf, 0x53, 0xec, 0x4a, 0x7b, 0x32, 0xce, 0x1a, 0x94, 0xbf,
-	0x4b, 0xd0, 0x78, 0x46, 0x7d, 0x2d, 0x2c, 0x8c, 0xb6, 0xa0, 0xe4, 0xf9, 0xc4, 0xf5, 0x79, 0x76,
-	0x19, 0x07, 0x02, 0x6a, 0x83, 0x4c, 0xad, 0xb9, 0x48, 0xc3, 0x3e, 0xd1, 0x53, 0xa8, 0xfb, 0x44,
-	0xbf, 0x20, 0xbe, 0x76, 0x43, 0x5d, 0xaf, 0x23, 0x77, 0xa5, 0xbd, 0x7a, 0xbf, 0x7d, 0xe0, 0x3a,
-	0xda, 0x81, 0x1a, 0xeb, 0x4f, 0x0b, 0x38, 0xe9, 0x86, 0x3e, 0x82, 0x8a, 0xed, 0xb0, 0x65, 0x7a,
-	0x9d, 0x35, 0x1e, 0xb1, 0xc1, 0x23, 0xf8, 0x0a, 0x26, 0x81, 0x01, 0x87, 0x1e, 0x47, 0x00, 0x55,
-	0x53, 0x04, 0x2a, 0xbf, 0x80, 0x7a, 0x22, 0x2d, 0x7a, 0x92, 0xae, 0x2e, 0x75, 0xe5, 0xbd, 0x7a,
-	0x7f, 0x7d, 0xa5, 0x7a, 0xaa, 0xb4, 0xf2, 0x1b, 0x80, 0xd8, 0x84, 0x10, 0xac, 0x59, 0xc4, 0xa4,
-	0xbc, 0xcb, 0x06, 0xe6, 0xdf, 0xac, 0xf5, 0x2f, 0xc9, 0x62, 0x49, 0x79, 0x9b, 0x0d, 0x1c, 0x08,
-	0xe8, 0xbb, 0xb0, 0xe6, 0xdf, 0x39, 0x94, 0x77, 0xd8, 0x12, 0x1d, 0x8a, 0x2c, 0xea, 0x9d, 0x43,
-	0x31, 0xb7, 0x2a, 0xff, 0x2b, 0x8a, 0x39, 0x8a, 0x2e, 0x58, 0xb2, 0x85, 0x61, 0x1a, 0xd1, 0x1c,
-	0xb9, 0x80, 0x3e, 0x86, 0xaa, 0x4b, 0x3d, 0x86, 0x0c, 0x9f, 0x57, 0xa9, 0xf7, 0xb7, 0x79, 0x42,
-	0x2c, 0x94, 0x9f, 0x33, 0x78, 0x85, 0x83, 0x88, 0x5c, 0xd1, 0x3e, 0xb4, 0x17, 0xb6, 0x7d, 0x3b,
-	0x23, 0xda, 0x6d, 0xb4, 0xfb, 0x32, 0xcf, 0x9b, 0xd1, 0xa3, 0x8f, 0xa1, 0xb1, 0xb4, 0x88, 0xae,
-	0xbb, 0x54, 0x67, 0xb0, 0xe3, 0x73, 0x6e, 0x85, 0x73, 0x26, 0x96, 0xbd, 0xf4, 0x83, 0xfc, 0x38,
-	0xe5, 0x86, 0x9e, 0x00, 0x24, 0x82, 0x4a, 0xf7, 0x05, 0x25, 0x9c, 0xd0, 0x31, 0x6c, 0xc6, 0x12,
-	0xb3, 0x9b, 0xc6, 0x2b, 0x3a, 0xef, 0x94, 0xef, 0x8b, 0xcd, 0xf3, 0x66, 0x70, 0x35, 0x2c, 0x6d,
-	0xb1, 0x9c, 0x53, 0x4c, 0x3d, 0x7b, 0xb1, 0xe4, 0xbd, 0x55, 0xba, 0xd2, 0x5e, 0x15, 0x67, 0x0d,
-	0xca, 0x5f, 0x25, 0xd8, 0xca, 0x9b, 0x15, 0x1a, 0xc2, 0x86, 0x9b, 0xd4, 0xab, 0xe1, 0x96, 0xd5,
-	0xfb, 0x1f, 0x64, 0x27, 0xcc, 0x37, 0x2e, 0x1b, 0x90, 0xcd, 0x42, 0xf4, 0x10, 0xa8, 0x79, 0x59,
-	0x88, 0xee, 0xe1, 0x6c, 0x80, 0xf2, 0x17, 0x09, 0x36, 0x32, 0xe5, 0x50, 0x1f, 0xea, 0x82, 0x13,
-	0xf8, 0xda, 0xa4, 0x24, 0x9c, 0x62, 0x3d, 0x4e, 0x3a, 0xa1, 0xcf, 0x60, 0x4b, 0x88, 0x53, 0xdf,
-	0x76, 0x89, 0x4e, 0x2f, 0x39, 0x69, 0x08, 0xe8, 0x3c, 0x38, 0x08, 0xc9, 0xe4, 0x20, 0x65, 0xc6,
-	0xb9, 0x41, 0xca, 0xf3, 0xd5, 0x55, 0x11, 0xdd, 0x43, 0x8f, 0x12, 0x80, 0x94, 0xf2, 0xcf, 0x70,
-	0x02, 0x87, 0x9c, 0x1c, 0x5c, 0xc3, 0xe9, 0x14, 0xbb, 0x32, 0x3b, 0x21, 0x5c, 0x50, 0x7e, 0x0b,
-	0x4d, 0x41, 0x21, 0x82, 0xaa, 0xbe, 0x03, 0x65, 0x8f, 0xba, 0x06, 0x0d, 0x0f, 0x66, 0x9d, 0xa7,
-	0x9c, 0x72, 0x15, 0x16, 0x26, 0xf4, 0x7d, 0x58, 0x33, 0xa9, 0x4f, 0x44, 0x2f, 0x9b, 0xe1, 0x78,
-	0x97, 0x0b, 0xff, 0x82, 0xfa, 0x64, 0x4e, 0x7c, 0x82, 0xb9, 0x83, 0xf2, 0x5a, 0x82, 0xf2, 0x34,
-	0x1d, 0x23, 0x25, 0x62, 0x02, 0x53, 0x3a, 0x06, 0xfd, 0x14, 0x1a, 0x73, 0xaa, 0xd9, 0xa6, 0xe3,
-	0x52, 0xcf, 0xa3, 0xf3, 0x68, 0x60, 0x2c, 0x60, 0x98, 0x30, 0x04, 0xc1, 0xa7, 0x05, 0x9c, 0x72,
-	0x47, 0x9f, 0x02, 0x24, 0x82, 0xe5, 0x44, 0xf0, 0xc5, 0xe1, 0x71, 0x36, 0x38, 0xe1, 0x7c, 0x54,
-	0x11, 0x24, 0xa2, 0xbc, 0x80, 0x56, 0x7a, 0x69, 0xa8, 0x05, 0x45, 0x63, 0x2e, 0x18, 0xa7, 0x68,
-	0xcc, 0xd1, 0x43, 0xa8, 0x71, 0x76, 0x55, 0x0d, 0x93, 0x0a, 0x6a, 0x8d, 0x15, 0xa8, 0x03, 0x15,
-	0x6a, 0xcd, 0xb9, 0x2d, 0x38, 0xea, 0xa1, 0xa8, 0xcc, 0x00, 0x65, 0x7b, 0x40, 0x07, 0x00, 0xac,
-	0x8a, 0x63, 0x1b, 0x96, 0x1f, 0x0e, 0xbe, 0x15, 0x34, 0x1c, 0xaa, 0x71, 0xc2, 0x03, 0x3d, 0x84,
-	0x35, 0x9f, 0xc1, 0xbb, 0xc8, 0x3d, 0xab, 0xe1, 0xae, 0x63, 0xae, 0x55, 0x7e, 0x0e, 0xb5, 0x28,
-	0x8c, 0x2d, 0x94, 0xdd, 0x1b, 0x9e, 0x4f, 0x4c, 0x47, 0xf0, 0x59, 0xac, 0x48, 0xd3, 0xa6, 0x24,
-	0x68, 0x53, 0xe9, 0x81, 0xac, 0x12, 0xfd, 0xdd, 0x79, 0x56, 0xf9, 0x0a, 0x50, 0x76, 0xb8, 0xec,
-	0xd6, 0x8b, 0x3b, 0xe5, 0xc7, 0x31, 0xc8, 0xb4, 0xa2, 0x45, 0x3f, 0x61, 0x38, 0x76, 0x16, 0x86,
-	0x46, 0xc2, 0x8e, 0x76, 0x33, 0xfb, 0xf5, 0x4b, 0x56, 0xc7, 0xc3, 0x81, 0x1b, 0x8e, 0xfc, 0x95,
-	0x53, 0xd8, 0xbe, 0xd7, 0x0d, 0x7d, 0x04, 0x55, 0x8f, 0xea, 0x26, 0x8d, 0x87, 0xba, 0x2e, 0x12,
-	0x4f, 0x85, 0x1a, 0x47, 0x0e, 0xca, 0xef, 0x00, 0x62, 0x3d, 0xfa, 0x10, 0xca, 0x26, 0x75, 0x75,
-	0x3a, 0x17, 0x78, 0x6d, 0xa5, 0x03, 0xb1, 0xb0, 0xa2, 0x7d, 0xa8, 0x2e, 0x2d, 0xe1, 0x59, 0x4c,
-	0xec, 0x5b, 0xec, 0x19, 0xd9, 0x95, 0x3f, 0x4a, 0x50, 0x8b, 0xf4, 0x6c, 0xba, 0x37, 0x94, 0x84,
-	0x98, 0xe2, 0xdf, 0x4c, 0xe7, 0x13, 0x63, 0x21, 0x86, 0xcb, 0xbf, 0xd3, 0x48, 0x93, 0x57, 0x91,
-	0xf6, 0x10, 0x6a, 0xb3, 0x85, 0xad, 0xdd, 0x4e, 0x8d, 0x57, 0x94, 0xb3, 0x9d, 0x8c, 0x63, 0x05,
-	0xda, 0x81, 0xaa, 0x76, 0x43, 0xb5, 0x5b, 0x6f, 0x69, 0xf2, 0x6b, 0xa1, 0x89, 0x23, 0x59, 0xf9,
-	0x87, 0x04, 0xcd, 0x29, 0x25, 0x6e, 0xfc, 0x7c, 0x78, 0xba, 0x7a, 0x31, 0xbf, 0xd3, 0xb3, 0x20,
-	0x7a, 0x74, 0x14, 0x73, 0x1e, 0x1d, 0x72, 0xfc, 0xe8, 0x78, 0xef, 0xe7, 0xc3, 0x09, 0x34, 0x2f,
-	0x0e, 0x55, 0xa2, 0x5f, 0xba, 0xb6, 0x43, 0x5d, 0xff, 0x2e, 0x73, 0x16, 0xb3, 0x38, 0x2b, 0xe6,
-	0xe1, 0x4c, 0x19, 0xc1, 0x7a, 0x32, 0x11, 0x83, 0x68, 0x1f, 0xc0, 0x89, 0x24, 0x81, 0x11, 0x24,
-	0x36, 0x30, 0x51, 0x12, 0x27, 0xbc, 0x94, 0x4f, 0xf8, 0x73, 0x26, 0x5a, 0x4d, 0x1b, 0xe4, 0x5b,
-	0x7a, 0x27, 0x96, 0xc3, 0x3e, 0xd1, 0x07, 0x50, 0xe6, 0xc7, 0x22, 0x5c, 0x87, 0x90, 0x94, 0x01,
-	0x34, 0xd3, 0xd5, 0x1f, 0xe7, 0x54, 0x8f, 0xe6, 0x9d, 0x5b, 0xfb, 0xb5, 0xc4, 0x98, 0x29, 0xd8,
-	0x34, 0x41, 0xd8, 0x3f, 0x5e, 0xa1, 0xcb, 0x60, 0xdb, 0xd0, 0x4a, 0x9a, 0x3c, 0xa6, 0xfc, 0x51,
-	0x8a, 0x29, 0x03, 0x9a, 0xdd, 0xca, 0x34, 0x9f, 0xa1, 0xc9, 0x88, 0xc9, 0xe5, 0xb7, 0xb0, 0x7f,
-	0xcc, 0xa7, 0xff, 0x94, 0x60, 0x87, 0x1d, 0xd2, 0x05, 0xf5, 0x29, 0xbf, 0x79, 0x03, 0xc4, 0x85,
-	0x0f, 0x80, 0x1f, 0x88, 0x67, 0x5a, 0x70, 0xaf, 0x7e, 0x83, 0x27, 0x4c, 0xba, 0xc7, 0x6f, 0x35,
-	0xb6, 0xd7, 0x2f, 0x8d, 0x85, 0x4f, 0xdd, 0x31, 0x31, 0xa9, 0x1a, 0x72, 0x60, 0x03, 0xaf, 0x68,
-	0x63, 0x54, 0xca, 0x39, 0xa8, 0x5c, 0xcb, 0x45, 0x65, 0xe9, 0x6d, 0xa8, 0x54, 0xfe, 0x2c, 0xc1,
-	0x66, 0x4e, 0x1b, 0xef, 0x79, 0x70, 0x3e, 0x8d, 0x4b, 0x07, 0xb3, 0xff, 0x76, 0xa6, 0xf1, 0xf4,
-	0x9c, 0xf2, 0x8f, 0x47, 0x17, 0xaa, 0x2a, 0xd1, 0x59, 0xe3, 0xbc, 0x6b, 0xc6, 0xd2, 0x01, 0x96,
-	0x1a, 0x38, 0x10, 0x94, 0xa7, 0xdc, 0x83, 0x53, 0xe3, 0x5b, 0xd0, 0x2a, 0x27, 0xd0, 0xda, 0x87,
-	0x5a, 0x18, 0xe5, 0xa1, 0xef, 0x45, 0x4e, 0x01, 0x4a, 0x9b, 0x61, 0x73, 0xdc, 0x1e, 0xc5, 0xfc,
-	0x4d, 0x82, 0xad, 0xf4, 0xfa, 0x05, 0x48, 0xf7, 0xa1, 0x32, 0xa7, 0x2f, 0xc9, 0x72, 0xe1, 0xa7,
-	0xf8, 0x34, 0x2a, 0x70, 0x5a, 0xc0, 0xa1, 0x03, 0xfa, 0x21, 0xd4, 0xf8, 0xba, 0x27, 0xd6, 0x22,
-	0x7c, 0x2d, 0x45, 0xe5, 0x78, 0x9b, 0xa7, 0x05, 0x1c, 0x7b, 0xbc, 0x07, 0x1a, 0xff, 0x00, 0xad,
-	0xb4, 0x03, 0xda, 0x05, 0xa0, 0x5f, 0xdd, 0x90, 0xa5, 0xe7, 0x1b, 0x5f, 0x06, 0x30, 0xac, 0xe2,
-	0x84, 0x06, 0xed, 0x41, 0xf5, 0xf7, 0xc4, 0xb5, 0x0c, 0x2b, 0xba, 0x73, 0x1b, 0xbc, 0xce, 0xf3,
-	0x40, 0x89, 0x23, 0x2b, 0xea, 0x42, 0xdd, 0x8d, 0x9e, 0xbc, 0xec, 0xa7, 0x95, 0xbc, 0x27, 0xe3,
-	0xa4, 0x4a, 0xf9, 0x04, 0x2a, 0x22, 0x2c, 0xf7, 0x82, 0xed, 0x40, 0xc5, 0xa4, 0x9e, 0x47, 0xf4,
-	0xf0, 0x8a, 0x0d, 0xc5, 0x7d, 0x0a, 0xf5, 0xc4, 0x6f, 0x17, 0x54, 0x83, 0xd2, 0xe8, 0xf3, 0xab,
-	0xc1, 0x79, 0xbb, 0x80, 0x1a, 0x50, 0x1d, 0x4f, 0xd4, 0x40, 0x92, 0x10, 0x40, 0x19, 0x8f, 0x4e,
-	0x46, 0x2f, 0x2e, 0xdb, 0x45, 0xd4, 0x84, 0xda, 0x78, 0xa2, 0x0a, 0x51, 0x66, 0xa6, 0xd1, 0x8b,
-	0xb3, 0xa9, 0x3a, 0x6d, 0xaf, 0x09, 0x93, 0x10, 0x4b, 0xa8, 0x02, 0xf2, 0xe0, 0xfc, 0xbc, 0x5d,
-	0xde, 0xd7, 0xa0, 0x9e, 0x78, 0xd3, 0xa2, 0x0e, 0x6c, 0x5d, 0x8d, 0x3f, 0x1b, 0x4f, 0x9e, 0x8f,
-	0xaf, 0x2f, 0x46, 0x2a, 0x3e, 0x3b, 0x9e, 0x5e, 0xab, 0xbf, 0xba, 0x1c, 0xb5, 0x0b, 0xe8, 0x5b,
-	0xb0, 0x7d, 0x35, 0x1e, 0x9c, 0x9c, 0xe0, 0xd1, 0xc9, 0x40, 0x1d, 0x0d, 0xd3, 0x66, 0x09, 0x7d,
-	0x13, 0x1e, 0xdc, 0x67, 0x2c, 0xee, 0x9f, 0x41, 0x23, 0xf9, 0xf3, 0x02, 0x21, 0x68, 0x0d, 0x47,
-	0xcf, 0x06, 0x57, 0xe7, 0xea, 0xf5, 0xe4, 0x52, 0x3d, 0x9b, 0x8c, 0xdb, 0x05, 0xb4, 0x01, 0xcd,
-	0x67, 0x13, 0x7c, 0x3c, 0xba, 0x1e, 0x8d, 0x07, 0x47, 0xe7, 0xa3, 0x61, 0x5b, 0x62, 0x6e, 0x81,
-	0x6a, 0x78, 0x36, 0x0d, 0x74, 0xc5, 0xfd, 0x47, 0xd0, 0x5e, 0xe5, 0x0a, 0x54, 0x87, 0x8a, 0x48,
-	0xd7, 0x2e, 0x30, 0x41, 0x1d, 0x9c, 0x8c, 0x07, 0x17, 0xa3, 0xb6, 0xd4, 0xff, 0xbf, 0x04, 0x25,
-	0xfe, 0x82, 0x46, 0x4f, 0xa0, 0x1c, 0xfc, 0x4a, 0x47, 0x01, 0x57, 0xa6, 0x7e, 0xc3, 0xef, 0x6c,
-	0xa6, 0x74, 0x02, 0xc5, 0x8f, 0xa1, 0xc4, 0x89, 0x01, 0x25, 0x48, 0x22, 0x0c, 0x40, 0x49, 0x55,
-	0xe0, 0xff, 0x58, 0x42, 0x87, 0xec, 0xf9, 0xcb, 0xe8, 0x5a, 0x14, 0x49, 0x5d, 0xb8, 0x3b, 0x9b,
-	0x29, 0x5d, 0x14, 0x34, 0x82, 0x46, 0xb2, 0x23, 0xd4, 0xb9, 0x8f, 0x17, 0x76, 0xb6, 0x73, 0x2c,
-	0x61, 0x9a, 0xa3, 0x07, 0xff, 0x7a, 0xb3, 0x2b, 0xfd, 0xfb, 0xcd, 0xae, 0xf4, 0x9f, 0x37, 0xbb,
-	0xd2, 0x9f, 0xfe, 0xbb, 0x5b, 0xf8, 0x75, 0x89, 0xff, 0x0f, 0x32, 0x2b, 0xf3, 0xff, 0x2d, 0x0e,
-	0xbf, 0x0e, 0x00, 0x00, 0xff, 0xff, 0x18, 0x0b, 0xe1, 0x7c, 0x44, 0x11, 0x00, 0x00,
+	// 1644 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9d, 0x58, 0xeb, 0x6e, 0x1b, 0x45,
+	0x14, 0xee, 0x7a, 0xe3, 0xdb, 0xf1, 0x25, 0xce, 0x24, 0xb4, 0x49, 0x80, 0x10, 0x2d, 0xb7, 0x12,
+	0x4a, 0x52, 0x92, 0x52, 0x28, 0x12, 0x17, 0x27, 0x76, 0xd3, 0xa8, 0x89, 0x93, 0x8e, 0x1d, 0x5a,
+	0x10, 0x28, 0xac, 0xed, 0xa9, 0xb3, 0x8a, 0xed, 0x5d, 0x76, 0xd7, 0xa5, 0x41, 0xbc, 0x01, 0x7f,
+	0x10, 0xe2, 0x01, 0x10, 0x08, 0x9e, 0x80, 0x47, 0xe0, 0x07, 0x3f, 0x79, 0x04, 0x04, 0x3c, 0x08,
+	0x67, 0x67, 0x66, 0x6f, 0xde, 0x8d, 0x5a, 0xf5, 0x47, 0xa2, 0x9d, 0x73, 0x3f, 0x67, 0xce, 0x7c,
+	0x73, 0xc6, 0xf0, 0xe1, 0xc0, 0x70, 0x4f, 0x27, 0xdd, 0xf5, 0x9e, 0x39, 0xda, 0x18, 0x6d, 0xf5,
+	0xbb, 0xf8, 0x6f, 0xc3, 0xb1, 0x7b, 0x1b, 0x5f, 0x4d, 0x98, 0x7d, 0xbe, 0x31, 0x60, 0x63, 0x66,
+	0xeb, 0x2e, 0xeb, 0x6f, 0x58, 0xb6, 0xe9, 0x9a, 0x1b, 0xb6, 0xd5, 0xb3, 0xba, 0x82, 0xb7, 0xce,
+	0x29, 0x44, 0x45, 0xd2, 0x72, 0xe3, 0x02, 0x23, 0x23, 0xe6, 0xda, 0x46, 0xcf, 0x49, 0x98, 0xb1,
+	0xcc, 0xa1, 0xd1, 0x3b, 0x47, 0x4b, 0xe2, 0x43, 0x98, 0xd2, 0x66, 0xa1, 0x72, 0x87, 0xe9, 0x43,
+	0xf7, 0x94, 0x32, 0xf4, 0xe0, 0xb8, 0xda, 0x43, 0xa8, 0xfa, 0x04, 0xc7, 0x32, 0xc7, 0x0e, 0x23,
+	0xaf, 0x41, 0x75, 0x62, 0xb9, 0xc6, 0x88, 0x35, 0x26, 0x68, 0xcf, 0x30, 0xc7, 0x8b, 0xca, 0xaa,
+	0x72, 0xb5, 0x48, 0xa7, 0xa8, 0xe4, 0x1a, 0xcc, 0x09, 0x4a, 0x4b, 0x1f, 0x9b, 0x0e, 0xeb, 0x99,
+	0xe3, 0xbe, 0xb3, 0x98, 0x41, 0x51, 0x95, 0x26, 0x19, 0xda, 0xaf, 0x0a, 0x94, 0x6f, 0x33, 0xb7,
+	0xe7, 0x3b, 0x26, 0x0b, 0x90, 0x75, 0x5c, 0xdd, 0x76, 0xb9, 0x75, 0x95, 0x8a, 0x05, 0xa9, 0x81,
+	0xca, 0xc6, 0x7d, 0x69, 0xc6, 0xfb, 0x24, 0x37, 0xa0, 0xe4, 0xea, 0x83, 0x03, 0x1d, 0x55, 0x99,
+	0xed, 0x2c, 0xaa, 0xc8, 0x29, 0x6d, 0xd6, 0xd6, 0xb1, 0x24, 0xeb, 0x9d, 0x90, 0x7e, 0xe7, 0x12,
+	0x8d, 0x8a, 0x91, 0x37, 0x21, 0x6f, 0x5a, 0x5e, 0x98, 0xce, 0xe2, 0x0c, 0xd7, 0x98, 0xe3, 0x1a,
+	0x3c, 0x82, 0x43, 0xc1, 0xa0, 0xbe, 0xc4, 0x36, 0x40, 0x61, 0x24, 0x15, 0xb5, 0x8f, 0xa1, 0x14,
+	0x31, 0x4b, 0xde, 0x8e, 0x7b, 0x57, 0x56, 0x55, 0xb4, 0x35, 0x3b, 0xe5, 0x3d, 0xe6, 0x5a, 0xfb,
+	0x1c, 0x20, 0x64, 0x11, 0x02, 0x33, 0x63, 0x7d, 0xc4, 0x78, 0x96, 0x65, 0xca, 0xbf, 0xbd, 0xd4,
+	0x1f, 0xe9, 0xc3, 0x09, 0xe3, 0x69, 0x96, 0xa9, 0x58, 0x90, 0x57, 0x60, 0xc6, 0x3d, 0xb7, 0x18,
+	0xcf, 0xb0, 0x2a, 0x33, 0x94, 0x56, 0x3a, 0x48, 0xa7, 0x9c, 0xab, 0xfd, 0x97, 0x91, 0x75, 0x94,
+	0x59, 0x78, 0xc6, 0x86, 0xc6, 0xc8, 0x08, 0xea, 0xc8, 0x17, 0xe4, 0x1d, 0x28, 0xd8, 0x58, 0x65,
+	0xec, 0x0c, 0x97, 0x7b, 0x29, 0x6d, 0x2e, 0x71, 0x83, 0x54, 0x12, 0xef, 0x79, 0xed, 0xe5, 0x17,
+	0x22, 0x10, 0x25, 0x6b, 0x50, 0x1b, 0x9a, 0xe6, 0x59, 0x57, 0xef, 0x9d, 0x05, 0xbb, 0xaf, 0x72,
+	0xbb, 0x09, 0x3a, 0xba, 0x28, 0x4f, 0xc6, 0xfa, 0x60, 0x60, 0xb3, 0x81, 0xd7, 0x76, 0xbc, 0xce,
+	0x55, 0xbf, 0xce, 0xb8, 0xf3, 0x13, 0x57, 0xd8, 0xa7, 0x31, 0x31, 0xac, 0x28, 0x44, 0x94, 0xb2,
+	0x17, 0x29, 0x45, 0x84, 0xc8, 0x0e, 0xcc, 0x87, 0x2b, 0x8f, 0x3f, 0x32, 0xbe, 0x41, 0xdd, 0xdc,
+	0x45, 0xba, 0x69, 0xd2, 0x5e, 0xbb, 0x1a, 0xe3, 0xde, 0x70, 0xd2, 0x67, 0x58, 0x03, 0x73, 0x38,
+	0xe1, 0xb9, 0xe5, 0xd1, 0x44, 0x81, 0x26, 0x19, 0xda, 0xcf, 0x0a, 0x2c, 0xa4, 0xd5, 0x8a, 0x34,
+	0x60, 0xce, 0x8e, 0xd2, 0x3b, 0xfe, 0x96, 0x95, 0x36, 0x2f, 0x27, 0x2b, 0xcc, 0x37, 0x2e, 0xa9,
+	0x90, 0xb4, 0xa2, 0x0f, 0xfc, 0x46, 0x4d, 0xb3, 0x82, 0x5c, 0x9a, 0x54, 0xd0, 0x7e, 0x54, 0x60,
+	0x2e, 0xe1, 0x8e, 0x6c, 0x42, 0x49, 0x62, 0x02, 0x8f, 0x4d, 0x89, 0xb6, 0x53, 0x48, 0xa7, 0x51,
+	0x21, 0x72, 0x17, 0x16, 0xe4, 0xb2, 0xed, 0x9a, 0xb6, 0x3e, 0x60, 0x47, 0x1c, 0x34, 0x64, 0xeb,
+	0x5c, 0x59, 0xf7, 0xc1, 0x64, 0x3d, 0xc6, 0xa6, 0xa9, 0x4a, 0xda, 0xfd, 0xe9, 0xa8, 0x30, 0x56,
+	0x2c, 0x7f, 0xd8, 0x90, 0x4a, 0xfa, 0x19, 0x8e, 0xf4, 0x21, 0x07, 0x07, 0xdb, 0xb0, 0x30, 0x00,
+	0xd5, 0x3b, 0x21, 0x7c, 0xa1, 0x7d, 0x01, 0x15, 0x09, 0x21, 0x12, 0xaa, 0x5e, 0x86, 0x9c, 0xc3,
+	0x6c, 0x83, 0xf9, 0x07, 0xb3, 0xc4, 0x4d, 0xb6, 0x39, 0x89, 0x4a, 0x16, 0x79, 0x1d, 0x66, 0x30,
+	0x4c, 0x5d, 0xe6, 0x32, 0xef, 0x97, 0x77, 0x32, 0x74, 0xb1, 0x1c, 0x7a, 0x5f, 0x77, 0x75, 0xca,
+	0x05, 0xb4, 0xdf, 0x15, 0xc8, 0xb5, 0xe3, 0x3a, 0x4a, 0x44, 0x47, 0xb0, 0xe2, 0x3a, 0xe4, 0x03,
+	0x28, 0xf7, 0x11, 0xe1, 0x46, 0x16, 0x86, 0xee, 0xb0, 0x7e, 0x50, 0x30, 0x4f, 0xa1, 0x11, 0x61,
+	0x08, 0x65, 0x44, 0xa9, 0x98, 0x38, 0xb9, 0x05, 0x10, 0x51, 0x56, 0x23, 0xca, 0x07, 0x5b, 0x3b,
+	0x49, 0xe5, 0x88, 0xf0, 0x76, 0x5e, 0x82, 0x88, 0xf6, 0x00, 0xaa, 0xf1, 0xd0, 0x48, 0x15, 0x32,
+	0x46, 0x5f, 0x22, 0x0e, 0x7e, 0x91, 0x17, 0xa0, 0xc8, 0xd1, 0xb5, 0x83, 0x98, 0x2c, 0xa1, 0x35,
+	0x24, 0x90, 0x45, 0xc8, 0x23, 0xce, 0x72, 0x9e, 0x38, 0xea, 0xfe, 0x52, 0xeb, 0x02, 0x49, 0xe6,
+	0x40, 0xd6, 0x01, 0x3c, 0x2f, 0x96, 0x69, 0x8c, 0x5d, 0xbf, 0xf0, 0x55, 0x91, 0xb0, 0x4f, 0xa6,
+	0x11, 0x09, 0xf4, 0x3e, 0xe3, 0x7a, 0xed, 0x9d, 0xe1, 0x92, 0x05, 0x7f, 0xd7, 0x29, 0xa7, 0x6a,
+	0x1f, 0x41, 0x31, 0x50, 0xf3, 0x02, 0xf5, 0xee, 0x0d, 0x8c, 0x6d, 0x64, 0x49, 0x3c, 0x0b, 0x09,
+	0x71, 0xd8, 0x54, 0x24, 0x6c, 0x6a, 0x1b, 0xa0, 0xa2, 0xb5, 0xa7, 0xc7, 0x59, 0xed, 0x31, 0x90,
+	0x64, 0x71, 0xbd, 0x5b, 0x2f, 0xcc, 0x94, 0x1f, 0x47, 0x61, 0x69, 0x8a, 0x4a, 0xde, 0xf7, 0xfa,
+	0xd8, 0xc2, 0x3e, 0xd7, 0xfd, 0x8c, 0x56, 0x12, 0xfb, 0xf5, 0x89, 0xe7, 0xc7, 0xa1, 0x42, 0x8c,
+	0x06, 0xf2, 0xda, 0x1d, 0x58, 0xba, 0x50, 0x0c, 0x6f, 0xac, 0x82, 0xc3, 0x06, 0x23, 0x16, 0x16,
+	0x75, 0x56, 0x1a, 0x6e, 0x4b, 0x32, 0x0d, 0x04, 0xb4, 0x2f, 0x01, 0x42, 0x3a, 0xc6, 0x9e, 0x1b,
+	0x31, 0x7b, 0xc0, 0xfa, 0xb2, 0x5f, 0xab, 0x71, 0x45, 0x2a, 0xb9, 0x88, 0xee, 0x85, 0xc9, 0x58,
+	0x4a, 0x66, 0x22, 0xfb, 0x16, 0x4a, 0x06, 0x7c, 0xed, 0x3b, 0x05, 0x8a, 0x01, 0xdd, 0xab, 0xee,
+	0x29, 0xd3, 0xfd, 0x9e, 0xe2, 0xdf, 0x1e, 0xcd, 0xd5, 0x8d, 0xa1, 0x2c, 0x2e, 0xff, 0x8e, 0x77,
+	0x9a, 0x3a, 0xdd, 0x69, 0xc8, 0xed, 0x0e, 0xcd, 0xde, 0x59, 0x1b, 0x01, 0x99, 0xa3, 0x1d, 0x72,
+	0x03, 0x02, 0x59, 0x86, 0x02, 0xc2, 0x40, 0xef, 0xcc, 0x99, 0x8c, 0xf8, 0xb5, 0x50, 0xa1, 0xc1,
+	0x5a, 0xfb, 0x4d, 0x81, 0x4a, 0x9b, 0xe9, 0x76, 0x38, 0x3e, 0xdc, 0x98, 0xbe, 0x98, 0x9f, 0x6a,
+	0x2c, 0x08, 0x86, 0x8e, 0x4c, 0xca, 0xd0, 0xa1, 0x86, 0x43, 0xc7, 0x33, 0x8f, 0x0f, 0xbb, 0x50,
+	0x39, 0xd8, 0xc2, 0x00, 0x8e, 0x6c, 0xd3, 0x62, 0xb6, 0x7b, 0x9e, 0x38, 0x8b, 0xc9, 0x3e, 0xcb,
+	0xa4, 0xf5, 0x99, 0xd6, 0x84, 0xd9, 0xa8, 0x21, 0xaf, 0x45, 0x37, 0x01, 0xac, 0x60, 0x25, 0x7b,
+	0x84, 0xc8, 0x0d, 0x8c, 0xb8, 0xa4, 0x11, 0x29, 0xed, 0x5d, 0x3e, 0xce, 0x04, 0xd1, 0x60, 0xa6,
+	0x67, 0xec, 0x5c, 0x86, 0xe3, 0x7d, 0x92, 0xcb, 0x90, 0xe3, 0xc7, 0xc2, 0x8f, 0x43, 0xae, 0xb4,
+	0x3a, 0x54, 0xe2, 0xde, 0xaf, 0xa7, 0x78, 0x0f, 0xea, 0x9d, 0xea, 0x1b, 0xf1, 0xb4, 0xea, 0x6f,
+	0x9a, 0x04, 0xec, 0xf7, 0xa6, 0xe0, 0x52, 0x6c, 0x1b, 0x99, 0x32, 0x93, 0x86, 0x94, 0x37, 0x63,
+	0x48, 0x29, 0x60, 0x76, 0x21, 0x91, 0x7c, 0x02, 0x26, 0x03, 0x24, 0x57, 0x9f, 0x80, 0xfe, 0x21,
+	0x9e, 0xfe, 0xa1, 0xc0, 0xb2, 0x77, 0x48, 0x87, 0xcc, 0x65, 0xfc, 0xe6, 0x15, 0x1d, 0xe7, 0x0f,
+	0x00, 0x6f, 0xc8, 0x31, 0x4d, 0xdc, 0xab, 0xcf, 0x71, 0x83, 0x51, 0xf1, 0x70, 0x56, 0xf3, 0xf6,
+	0xfa, 0xa1, 0x31, 0x74, 0x99, 0xdd, 0x42, 0x34, 0xea, 0xf8, 0x18, 0x88, 0x7b, 0x1d, 0xa7, 0x86,
+	0x5d, 0xa9, 0xa6, 0x74, 0xe5, 0x4c, 0x6a, 0x57, 0x66, 0x9f, 0xd4, 0x95, 0xda, 0x0f, 0x0a, 0xcc,
+	0xa7, 0xa4, 0xf1, 0x8c, 0x07, 0xe7, 0x56, 0xe8, 0x5a, 0xd4, 0xfe, 0xa5, 0x44, 0xe2, 0xf1, 0x3a,
+	0xa5, 0x1f, 0x8f, 0x55, 0x28, 0xa0, 0xa8, 0x97, 0x38, 0xcf, 0xda, 0x43, 0x69, 0xd1, 0x4b, 0x88,
+	0xce, 0x7c, 0xa1, 0xdd, 0xe0, 0x12, 0x1c, 0x1a, 0x9f, 0xd0, 0xad, 0x6a, 0xa4, 0x5b, 0x37, 0xa1,
+	0xe8, 0x6b, 0x39, 0xe4, 0xd5, 0x40, 0x48, 0x74, 0x69, 0xc5, 0x4f, 0x8e, 0xf3, 0x03, 0x9d, 0x5f,
+	0x70, 0xc4, 0x8b, 0xc7, 0x2f, 0x9b, 0x74, 0x0d, 0xf2, 0x7d, 0xf6, 0x50, 0xc7, 0x16, 0x89, 0xe1,
+	0x69, 0xe0, 0x00, 0x6b, 0xe3, 0x0b, 0x90, 0xb7, 0xa0, 0xc8, 0xe3, 0x3e, 0x1c, 0x0f, 0xfd, 0x69,
+	0x29, 0x70, 0xc7, 0xd3, 0x44, 0xe1, 0x50, 0xe2, 0x19, 0xba, 0xf1, 0x5b, 0xa8, 0xc6, 0x05, 0xc8,
+	0x0a, 0x00, 0x7b, 0x7c, 0xaa, 0x4f, 0x1c, 0xd7, 0x78, 0x24, 0xda, 0xb0, 0x40, 0x23, 0x14, 0x72,
+	0x15, 0x0a, 0x5f, 0xeb, 0xf6, 0xd8, 0x18, 0x07, 0x77, 0x6e, 0x99, 0xfb, 0xb9, 0x2f, 0x88, 0x34,
+	0xe0, 0x92, 0x55, 0x28, 0xd9, 0xc1, 0xc8, 0xeb, 0x3d, 0xad, 0x54, 0xec, 0xb4, 0x28, 0x09, 0xe1,
+	0x23, 0x2f, 0xd5, 0x52, 0x2f, 0x58, 0x1c, 0x1d, 0x30, 0x33, 0x07, 0x47, 0x3f, 0x89, 0x1e, 0xfe,
+	0x72, 0x8d, 0x41, 0x29, 0xf2, 0x76, 0x21, 0x45, 0xc8, 0x36, 0xef, 0x1d, 0xd7, 0xf7, 0x6b, 0x97,
+	0x48, 0x19, 0x0a, 0xad, 0xc3, 0x8e, 0x58, 0x29, 0x04, 0x20, 0x47, 0x9b, 0xbb, 0xcd, 0x07, 0x47,
+	0xb5, 0x0c, 0xa9, 0x40, 0x11, 0x39, 0x72, 0xa9, 0x7a, 0xac, 0xe6, 0x83, 0xbd, 0x76, 0xa7, 0x5d,
+	0x9b, 0x91, 0x2c, 0xb9, 0xcc, 0x92, 0x3c, 0xa8, 0xf5, 0xfd, 0xfd, 0x5a, 0x6e, 0xad, 0x87, 0x6e,
+	0x22, 0x63, 0xec, 0x22, 0x2c, 0x1c, 0xb7, 0xee, 0xb6, 0x0e, 0xef, 0xb7, 0x4e, 0x0e, 0x9a, 0x1d,
+	0xba, 0xb7, 0xd3, 0x3e, 0xe9, 0x7c, 0x7a, 0xd4, 0x44, 0xaf, 0x2f, 0xc2, 0xd2, 0x71, 0xab, 0xbe,
+	0xbb, 0x8b, 0xd6, 0xeb, 0x9d, 0x66, 0x23, 0xce, 0x56, 0xc8, 0xf3, 0x70, 0xe5, 0x22, 0x66, 0x66,
+	0x6d, 0x0f, 0x5f, 0x5c, 0x91, 0xe7, 0x05, 0x56, 0xa2, 0xda, 0x68, 0xde, 0xae, 0x1f, 0xef, 0x77,
+	0x4e, 0x0e, 0x8f, 0x3a, 0x7b, 0x87, 0x2d, 0xb4, 0x3f, 0x87, 0xa3, 0xe9, 0x21, 0xdd, 0x69, 0x9e,
+	0x34, 0x5b, 0xf5, 0xed, 0xfd, 0x66, 0x03, 0x6d, 0xa2, 0x98, 0x20, 0x35, 0xf6, 0xda, 0x82, 0x96,
+	0x59, 0xbb, 0x06, 0xb5, 0x69, 0xac, 0x20, 0x25, 0xc8, 0x4b, 0x73, 0x68, 0x07, 0x17, 0x9d, 0xfa,
+	0x6e, 0xab, 0x7e, 0x80, 0x51, 0x6d, 0xfe, 0x94, 0x81, 0x2c, 0x9f, 0xa0, 0xf1, 0xd1, 0x94, 0x13,
+	0xaf, 0x74, 0x22, 0xb0, 0x32, 0xf6, 0x86, 0x5f, 0x9e, 0x8f, 0xd1, 0x64, 0x17, 0x5f, 0x87, 0x2c,
+	0x07, 0x06, 0x12, 0x01, 0x09, 0x5f, 0x81, 0x44, 0x49, 0x42, 0xfe, 0xba, 0x42, 0xb6, 0xbc, 0xf1,
+	0xd7, 0x83, 0x6b, 0xe9, 0x24, 0x76, 0xe1, 0x2e, 0xcf, 0xc7, 0x68, 0x81, 0x52, 0x13, 0xca, 0xd1,
+	0x8c, 0xc8, 0xe2, 0x45, 0xb8, 0xb0, 0xbc, 0x94, 0xc2, 0x09, 0xcc, 0xdc, 0x84, 0x12, 0x0f, 0xa7,
+	0xed, 0xda, 0x4c, 0x1f, 0x3d, 0x75, 0xcc, 0xdb, 0x57, 0xfe, 0xfc, 0x67, 0x45, 0xf9, 0x0b, 0xff,
+	0xfe, 0xc6, 0xbf, 0xef, 0xff, 0x5d, 0xb9, 0xf4, 0x59, 0x96, 0xff, 0x7e, 0xd2, 0xcd, 0xf1, 0xdf,
+	0x3b, 0xb6, 0xfe, 0x07, 0xa9, 0x6b, 0xf9, 0x9e, 0x7c, 0x11, 0x00, 0x00,
 }
@@ -39,6 +39,8 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Client is the remote GRPC client.
@@ -143,19 +145,133 @@ func (c *grpcClient) fetchRaw(
 	// TODO: replace id propagation with opentracing
 	id := logging.ReadContextID(ctx)
 	mdCtx := encodeMetadata(ctx, id)
-	fetchClient, err := c.client.Fetch(mdCtx, request)
+	seriesIterators, meta, err := c.fetchStream(ctx, mdCtx, request, pools)
+	if status.Code(err) == codes.Unimplemented {
+		// Fall back to a batched fetch from servers that cannot stream.
+		seriesIterators, meta, err = c.fetchBatched(ctx, mdCtx, request, pools)
+	}
 	if err != nil {
 		return fetchResult, err
 	}
 
+	return consolidators.NewSeriesFetchResult(
+		encoding.NewSeriesIterators(
+			seriesIterators,
+			pools.MutableSeriesIterators(),
+		),
+		nil,
+		meta,
+	)
+}
+
+// fetchStream fetches the series of a query with FetchStream, which sends
+// the tags of every series before the values of any series so the server
+// does not need to buffer the entire result. The series are closed on error.
+func (c *grpcClient) fetchStream(
+	ctx context.Context,
+	mdCtx context.Context,
+	request *rpc.FetchRequest,
+	pools encoding.IteratorPools,
+) ([]encoding.SeriesIterator, block.ResultMetadata, error) {
+	meta := block.NewResultMetadata()
+	fetchClient, err := c.client.FetchStream(mdCtx, request)
+	if err != nil {
+		return nil, meta, err
+	}
+
 	defer fetchClient.CloseSend()
+	var (
+		tags            = make(map[string][]byte, initResultSize)
+		seriesIterators = make([]encoding.SeriesIterator, 0, initResultSize)
+		success         = false
+	)
+	defer func() {
+		if success {
+			return
+		}
+
+		for _, iter := range seriesIterators {
+			iter.Close()
+		}
+	}()
+
+	for {
+		select {
+		// If query is killed during gRPC streaming, close the channel
+		case <-ctx.Done():
+			return nil, meta, ctx.Err()
+		default:
+		}
+
+		result, err := fetchClient.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, meta, err
+		}
+
+		// NB: the metadata is only known once every series has been sent so
+		// it is only set on the final response.
+		if result.GetMeta() != nil {
+			meta = meta.CombineMetadata(decodeResultMetadata(result.GetMeta()))
+		}
+
+		for _, series := range result.GetSeries() {
+			compressed := series.GetCompressed()
+			if compressed == nil {
+				continue
+			}
+
+			id := string(series.GetMeta().GetId())
+			if len(compressed.GetCompressedTags()) > 0 {
+				tags[id] = compressed.GetCompressedTags()
+				continue
+			}
+
+			seriesTags, ok := tags[id]
+			if !ok {
+				return nil, meta, errors.ErrFetchResponseOrder
+			}
+
+			delete(tags, id)
+			compressed.CompressedTags = seriesTags
+			iter, err := seriesIteratorFromCompressedSeries(compressed,
+				series.GetMeta(), pools)
+			if err != nil {
+				return nil, meta, err
+			}
+
+			seriesIterators = append(seriesIterators, iter)
+		}
+	}
+
+	success = true
+	return seriesIterators, meta, nil
+}
+
+// fetchBatched fetches the series of a query with Fetch, which sends the
+// tags and values of each series together.
+func (c *grpcClient) fetchBatched(
+	ctx context.Context,
+	mdCtx context.Context,
+	request *rpc.FetchRequest,
+	pools encoding.IteratorPools,
+) ([]encoding.SeriesIterator, block.ResultMetadata, error) {
 	meta := block.NewResultMetadata()
+	fetchClient, err := c.client.Fetch(mdCtx, request)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	defer fetchClient.CloseSend()
 	seriesIterators := make([]encoding.SeriesIterator, 0, initResultSize)
 	for {
 		select {
 		// If query is killed during gRPC streaming, close the channel
 		case <-ctx.Done():
-			return fetchResult, ctx.Err()
+			return nil, meta, ctx.Err()
 		default:
 		}
 
@@ -165,27 +281,20 @@ func (c *grpcClient) fetchRaw(
 		}
 
 		if err != nil {
-			return fetchResult, err
+			return nil, meta, err
 		}
 
 		receivedMeta := decodeResultMetadata(result.GetMeta())
 		meta = meta.CombineMetadata(receivedMeta)
 		iters, err := decodeCompressedFetchResponse(result, pools)
 		if err != nil {
-			return fetchResult, err
+			return nil, meta, err
 		}
 
 		seriesIterators = append(seriesIterators, iters.Iters()...)
 	}
 
-	return consolidators.NewSeriesFetchResult(
-		encoding.NewSeriesIterators(
-			seriesIterators,
-			pools.MutableSeriesIterators(),
-		),
-		nil,
-		meta,
-	)
+	return seriesIterators, meta, nil
 }
 
 func (c *grpcClient) FetchBlocks(
@@ -155,6 +155,81 @@ func compressedSeriesFromSeriesIterator(
 	it encoding.SeriesIterator,
 	iterPools encoding.IteratorPools,
 ) (*rpc.Series, error) {
+	compressedReplicas, err := compressedReplicasFromSeriesIterator(it)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := buildTags(it.Tags(), iterPools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.Series{
+		Meta: compressedSeriesMetadataFromSeriesIterator(it),
+		Value: &rpc.Series_Compressed{
+			Compressed: &rpc.M3CompressedSeries{
+				CompressedTags: tags,
+				Replicas:       compressedReplicas,
+			},
+		},
+	}, nil
+}
+
+// compressedSeriesTagsFromSeriesIterator builds compressed rpc series with
+// only the tags of a SeriesIterator and no values.
+func compressedSeriesTagsFromSeriesIterator(
+	it encoding.SeriesIterator,
+	iterPools encoding.IteratorPools,
+) (*rpc.Series, error) {
+	tags, err := buildTags(it.Tags(), iterPools)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.Series{
+		Meta: compressedSeriesMetadataFromSeriesIterator(it),
+		Value: &rpc.Series_Compressed{
+			Compressed: &rpc.M3CompressedSeries{
+				CompressedTags: tags,
+			},
+		},
+	}, nil
+}
+
+// compressedSeriesValuesFromSeriesIterator builds compressed rpc series with
+// only the values of a SeriesIterator and no tags.
+func compressedSeriesValuesFromSeriesIterator(
+	it encoding.SeriesIterator,
+) (*rpc.Series, error) {
+	compressedReplicas, err := compressedReplicasFromSeriesIterator(it)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpc.Series{
+		Meta: compressedSeriesMetadataFromSeriesIterator(it),
+		Value: &rpc.Series_Compressed{
+			Compressed: &rpc.M3CompressedSeries{
+				Replicas: compressedReplicas,
+			},
+		},
+	}, nil
+}
+
+func compressedSeriesMetadataFromSeriesIterator(
+	it encoding.SeriesIterator,
+) *rpc.SeriesMetadata {
+	return &rpc.SeriesMetadata{
+		Id:        it.ID().Bytes(),
+		StartTime: xtime.ToNanoseconds(it.Start()),
+		EndTime:   xtime.ToNanoseconds(it.End()),
+	}
+}
+
+func compressedReplicasFromSeriesIterator(
+	it encoding.SeriesIterator,
+) ([]*rpc.M3CompressedValuesReplica, error) {
 	replicas, err := it.Replicas()
 	if err != nil {
 		return nil, err
@@ -178,27 +253,7 @@ func compressedSeriesFromSeriesIterator(
 		})
 	}
 
-	start := xtime.ToNanoseconds(it.Start())
-	end := xtime.ToNanoseconds(it.End())
-
-	tags, err := buildTags(it.Tags(), iterPools)
-	if err != nil {
-		return nil, err
-	}
-
-	return &rpc.Series{
-		Meta: &rpc.SeriesMetadata{
-			Id:        it.ID().Bytes(),
-			StartTime: start,
-			EndTime:   end,
-		},
-		Value: &rpc.Series_Compressed{
-			Compressed: &rpc.M3CompressedSeries{
-				CompressedTags: tags,
-				Replicas:       compressedReplicas,
-			},
-		},
-	}, nil
+	return compressedReplicas, nil
 }
 
 // encodeToCompressedSeries encodes SeriesIterators to compressed series.
@@ -26,13 +26,17 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/query/block"
 	rpc "github.com/m3db/m3/src/query/generated/proto/rpcpb"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/pools"
+	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/storage/m3"
 	"github.com/m3db/m3/src/query/storage/m3/consolidators"
 	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -111,29 +115,11 @@ func (s *grpcServer) Fetch(
 ) error {
 	ctx := retrieveMetadata(stream.Context(), s.instrumentOpts)
 	logger := logging.WithContext(ctx, s.instrumentOpts)
-	storeQuery, err := decodeFetchRequest(message)
-	if err != nil {
-		logger.Error("unable to decode fetch query", zap.Error(err))
-		return err
-	}
-
-	fetchOpts, err := decodeFetchOptions(message.GetOptions())
+	storeQuery, fetchOpts, err := s.decodeFetch(message, logger)
 	if err != nil {
-		logger.Error("unable to decode options", zap.Error(err))
 		return err
 	}
 
-	fetchOpts.Remote = true
-	if fetchOpts.SeriesLimit == 0 {
-		// Allow default to be set if not explicitly passed.
-		fetchOpts.SeriesLimit = s.queryContextOpts.LimitMaxTimeseries
-	}
-
-	if fetchOpts.DocsLimit == 0 {
-		// Allow default to be set if not explicitly passed.
-		fetchOpts.DocsLimit = s.queryContextOpts.LimitMaxDocs
-	}
-
 	result, cleanup, err := s.querier.FetchCompressed(ctx, storeQuery, fetchOpts)
 	defer cleanup()
 	if err != nil {
@@ -172,6 +158,183 @@ func (s *grpcServer) Fetch(
 	return nil
 }
 
+// FetchStream streams compressed series from M3 storage, first sending the
+// tags of every series and then the values of each series. Values are
+// fetched from storage a batch at a time as they are sent when the querier
+// supports streaming, so sends block on slow consumers rather than the
+// entire result being buffered. The metadata of the fetch is sent in a
+// final response once every series has been sent.
+func (s *grpcServer) FetchStream(
+	message *rpc.FetchRequest,
+	stream rpc.Query_FetchStreamServer,
+) error {
+	ctx := retrieveMetadata(stream.Context(), s.instrumentOpts)
+	logger := logging.WithContext(ctx, s.instrumentOpts)
+	storeQuery, fetchOpts, err := s.decodeFetch(message, logger)
+	if err != nil {
+		return err
+	}
+
+	pools, err := s.waitForPools()
+	if err != nil {
+		logger.Error("unable to get pools", zap.Error(err))
+		return err
+	}
+
+	handler := &fetchStreamHandler{
+		stream: stream,
+		pools:  pools,
+		start:  xtime.ToNanoseconds(storeQuery.Start),
+		end:    xtime.ToNanoseconds(storeQuery.End),
+	}
+
+	var meta block.ResultMetadata
+	if querier, ok := s.querier.(m3.StreamingQuerier); ok {
+		meta, err = querier.FetchCompressedStream(ctx, storeQuery, fetchOpts,
+			defaultBatch, handler)
+	} else {
+		meta, err = s.fetchAndStream(ctx, storeQuery, fetchOpts, handler)
+	}
+	if err != nil {
+		logger.Error("unable to stream local query", zap.Error(err))
+		return err
+	}
+
+	if err := handler.flush(); err != nil {
+		logger.Error("unable to send fetch stream result", zap.Error(err))
+		return err
+	}
+
+	if err := stream.Send(&rpc.FetchResponse{
+		Meta: encodeResultMetadata(meta),
+	}); err != nil {
+		logger.Error("unable to send fetch stream metadata", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// fetchAndStream fetches the entire result of a query from a querier that
+// cannot stream and then streams it to the handler.
+func (s *grpcServer) fetchAndStream(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+	handler m3.FetchStreamHandler,
+) (block.ResultMetadata, error) {
+	result, cleanup, err := s.querier.FetchCompressed(ctx, query, options)
+	defer cleanup()
+	if err != nil {
+		return result.Metadata, err
+	}
+
+	return result.Metadata, m3.StreamSeriesIterators(ctx,
+		result.SeriesIterators(), defaultBatch, handler)
+}
+
+// fetchStreamHandler sends the series of a streamed fetch, batching the
+// tags of series and sending the values of each batch of series as soon
+// as they are fetched.
+type fetchStreamHandler struct {
+	stream rpc.Query_FetchStreamServer
+	pools  encoding.IteratorPools
+	start  int64
+	end    int64
+	batch  []*rpc.Series
+}
+
+var _ m3.FetchStreamHandler = (*fetchStreamHandler)(nil)
+
+func (h *fetchStreamHandler) OnSeries(id ident.ID, tags ident.TagIterator) error {
+	compressedTags, err := buildTags(tags, h.pools)
+	if err != nil {
+		return err
+	}
+
+	h.batch = append(h.batch, &rpc.Series{
+		Meta: &rpc.SeriesMetadata{
+			// NB: the ID is only valid for the duration of the call.
+			Id:        append([]byte(nil), id.Bytes()...),
+			StartTime: h.start,
+			EndTime:   h.end,
+		},
+		Value: &rpc.Series_Compressed{
+			Compressed: &rpc.M3CompressedSeries{
+				CompressedTags: compressedTags,
+			},
+		},
+	})
+	if len(h.batch) < defaultBatch {
+		return nil
+	}
+
+	return h.flush()
+}
+
+func (h *fetchStreamHandler) OnValues(iters []encoding.SeriesIterator) error {
+	// Send any remaining tags so the tags of every series are received
+	// before their values.
+	if err := h.flush(); err != nil {
+		return err
+	}
+
+	for _, iter := range iters {
+		series, err := compressedSeriesValuesFromSeriesIterator(iter)
+		if err != nil {
+			return err
+		}
+
+		h.batch = append(h.batch, series)
+	}
+
+	// NB: the iterators are only valid for the duration of the call so
+	// the batch must be sent before returning.
+	return h.flush()
+}
+
+func (h *fetchStreamHandler) flush() error {
+	if len(h.batch) == 0 {
+		return nil
+	}
+
+	err := h.stream.Send(&rpc.FetchResponse{Series: h.batch})
+	h.batch = nil
+	return err
+}
+
+// decodeFetch decodes a fetch request, applying the default limits to
+// any limits not explicitly passed.
+func (s *grpcServer) decodeFetch(
+	message *rpc.FetchRequest,
+	logger *zap.Logger,
+) (*storage.FetchQuery, *storage.FetchOptions, error) {
+	storeQuery, err := decodeFetchRequest(message)
+	if err != nil {
+		logger.Error("unable to decode fetch query", zap.Error(err))
+		return nil, nil, err
+	}
+
+	fetchOpts, err := decodeFetchOptions(message.GetOptions())
+	if err != nil {
+		logger.Error("unable to decode options", zap.Error(err))
+		return nil, nil, err
+	}
+
+	fetchOpts.Remote = true
+	if fetchOpts.SeriesLimit == 0 {
+		// Allow default to be set if not explicitly passed.
+		fetchOpts.SeriesLimit = s.queryContextOpts.LimitMaxTimeseries
+	}
+
+	if fetchOpts.DocsLimit == 0 {
+		// Allow default to be set if not explicitly passed.
+		fetchOpts.DocsLimit = s.queryContextOpts.LimitMaxDocs
+	}
+
+	return storeQuery, fetchOpts, nil
+}
+
 func (s *grpcServer) Search(
 	message *rpc.SearchRequest,
 	stream rpc.Query_SearchServer,
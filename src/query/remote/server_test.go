@@ -24,6 +24,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"runtime"
 	"sync"
@@ -49,6 +50,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -426,6 +429,157 @@ func TestBatchedFetch(t *testing.T) {
 	}
 }
 
+func TestBatchedFetchStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, read, readOpts := createCtxReadOpts(t)
+	request, err := encodeFetchRequest(read, readOpts)
+	require.NoError(t, err)
+
+	pools, err := poolsWrapper.WaitForIteratorPools(poolTimeout)
+	require.NoError(t, err)
+
+	sizes := []int{0, 1, defaultBatch - 1, defaultBatch,
+		defaultBatch + 1, defaultBatch*2 + 1}
+	for _, size := range sizes {
+		var (
+			msg     = fmt.Sprintf("batch size: %d", size)
+			iters   = make([]encoding.SeriesIterator, 0, size)
+			cleaned = false
+		)
+
+		for i := 0; i < size; i++ {
+			id := fmt.Sprintf("%s_%d", seriesID, i)
+			it, err := test.BuildTestSeriesIterator(id)
+			require.NoError(t, err, msg)
+			iters = append(iters, it)
+		}
+
+		store := newMockStorage(t, ctrl, mockStorageOptions{
+			iters: encoding.NewSeriesIterators(iters, nil),
+			cleanup: func() error {
+				require.False(t, cleaned, msg)
+				cleaned = true
+				return nil
+			},
+		})
+
+		listener := startServer(t, ctrl, store)
+		serverClient := buildClient(t, []string{listener.Addr().String()})
+		defer func() {
+			assert.NoError(t, serverClient.Close())
+		}()
+
+		client, ok := serverClient.(*grpcClient)
+		require.True(t, ok, msg)
+
+		stream, err := client.client.FetchStream(ctx, request)
+		require.NoError(t, err, msg)
+
+		var (
+			tags   = make(map[string][]byte, size)
+			values = 0
+			metas  = 0
+		)
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+
+			require.NoError(t, err, msg)
+			require.True(t, len(response.GetSeries()) <= defaultBatch, msg)
+			if response.GetMeta() != nil {
+				// NB: the metadata is sent once after every series.
+				require.Equal(t, 0, len(response.GetSeries()), msg)
+				require.Equal(t, size, values, msg)
+				assert.True(t, response.GetMeta().GetExhaustive(), msg)
+				metas++
+			}
+
+			for _, series := range response.GetSeries() {
+				id := string(series.GetMeta().GetId())
+				compressed := series.GetCompressed()
+				if len(compressed.GetCompressedTags()) > 0 {
+					// NB: all tags must be sent before any values.
+					require.Equal(t, 0, values, msg)
+					tags[id] = compressed.GetCompressedTags()
+					continue
+				}
+
+				require.Nil(t, compressed.GetCompressedTags(), msg)
+				seriesTags, ok := tags[id]
+				require.True(t, ok, msg)
+				values++
+
+				compressed.CompressedTags = seriesTags
+				it, err := seriesIteratorFromCompressedSeries(compressed,
+					series.GetMeta(), pools)
+				require.NoError(t, err, msg)
+				assert.Equal(t, id, it.ID().String(), msg)
+
+				var actual []float64
+				for it.Next() {
+					dp, _, _ := it.Current()
+					actual = append(actual, dp.Value)
+				}
+
+				require.NoError(t, it.Err(), msg)
+				require.Equal(t, expectedValues(), actual, msg)
+				it.Close()
+			}
+		}
+
+		require.Equal(t, size, len(tags), msg)
+		require.Equal(t, size, values, msg)
+		require.Equal(t, 1, metas, msg)
+		require.True(t, cleaned, msg)
+	}
+}
+
+type fetchOnlyServer struct {
+	rpc.QueryServer
+}
+
+func (s fetchOnlyServer) FetchStream(
+	*rpc.FetchRequest,
+	rpc.Query_FetchStreamServer,
+) error {
+	return status.Error(codes.Unimplemented, "unknown method FetchStream")
+}
+
+func TestFetchFallsBackWhenStreamUnimplemented(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := newMockStorage(t, ctrl, mockStorageOptions{})
+	server := grpc.NewServer()
+	rpc.RegisterQueryServer(server, fetchOnlyServer{
+		QueryServer: &grpcServer{
+			createAt:       time.Now(),
+			querier:        store,
+			poolWrapper:    poolsWrapper,
+			instrumentOpts: instrument.NewOptions(),
+		},
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		server.Serve(listener)
+	}()
+	defer server.Stop()
+
+	client := buildClient(t, []string{listener.Addr().String()})
+	defer func() {
+		assert.NoError(t, client.Close())
+	}()
+
+	ctx, read, readOpts := createCtxReadOpts(t)
+	checkFetch(ctx, t, client, read, readOpts)
+}
+
 func TestBatchedSearch(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
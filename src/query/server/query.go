@@ -22,6 +22,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"math/rand"
 	"net"
@@ -33,14 +34,23 @@ import (
 
 	clusterclient "github.com/m3db/m3/src/cluster/client"
 	etcdclient "github.com/m3db/m3/src/cluster/client/etcd"
+	"github.com/m3db/m3/src/cluster/kv"
+	kvutil "github.com/m3db/m3/src/cluster/kv/util"
+	"github.com/m3db/m3/src/cluster/kv/util/provider"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/alerting"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
 	ingestcarbon "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/carbon"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/cardinality"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/schema"
+	ingestwavefront "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/wavefront"
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/recording"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/metrics/aggregation"
 	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/api/v1/handler/otlp"
 	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
 	"github.com/m3db/m3/src/query/api/v1/httpd"
 	"github.com/m3db/m3/src/query/api/v1/options"
@@ -56,20 +66,24 @@ import (
 	"github.com/m3db/m3/src/query/storage/m3"
 	queryconsolidators "github.com/m3db/m3/src/query/storage/m3/consolidators"
 	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
+	"github.com/m3db/m3/src/query/storage/prometheus"
 	"github.com/m3db/m3/src/query/storage/remote"
 	"github.com/m3db/m3/src/query/stores/m3db"
 	tsdb "github.com/m3db/m3/src/query/ts/m3db"
 	"github.com/m3db/m3/src/query/ts/m3db/consolidators"
 	"github.com/m3db/m3/src/x/clock"
 	xconfig "github.com/m3db/m3/src/x/config"
+	"github.com/m3db/m3/src/x/health"
 	"github.com/m3db/m3/src/x/instrument"
 	xnet "github.com/m3db/m3/src/x/net"
 	xos "github.com/m3db/m3/src/x/os"
 	"github.com/m3db/m3/src/x/pool"
+	xretry "github.com/m3db/m3/src/x/retry"
 	"github.com/m3db/m3/src/x/serialize"
 	xserver "github.com/m3db/m3/src/x/server"
 	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
+	xtls "github.com/m3db/m3/src/x/tls"
 
 	"github.com/go-kit/kit/log"
 	kitlogzap "github.com/go-kit/kit/log/zap"
@@ -80,12 +94,14 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 const (
-	serviceName        = "m3query"
-	cpuProfileDuration = 5 * time.Second
+	serviceName         = "m3query"
+	cpuProfileDuration  = 5 * time.Second
+	healthWatchInterval = 5 * time.Second
 )
 
 var (
@@ -101,6 +117,7 @@ var (
 
 	defaultDownsamplerAndWriterWorkerPoolSize = 1024
 	defaultCarbonIngesterWorkerPoolSize       = 1024
+	defaultWavefrontIngesterWorkerPoolSize    = 1024
 	defaultPerCPUMultiProcess                 = 0.5
 )
 
@@ -182,7 +199,7 @@ func Run(runOpts RunOptions) {
 		listenerOpts = xnet.NewListenerOptions()
 	)
 
-	logger, err := cfg.Logging.BuildLogger()
+	logger, logLevel, err := cfg.Logging.BuildLoggerWithLevel()
 	if err != nil {
 		// NB(r): Use fmt.Fprintf(os.Stderr, ...) to avoid etcd.SetGlobals()
 		// sending stdlib "log" to black hole. Don't remove unless with good reason.
@@ -248,6 +265,9 @@ func Run(runOpts RunOptions) {
 		SetLogger(logger).
 		SetTracer(tracer)
 
+	healthRegistry := health.NewRegistry(health.NewOptions().
+		SetInstrumentOptions(instrumentOptions))
+
 	if runOpts.InstrumentOptionsReadyCh != nil {
 		runOpts.InstrumentOptionsReadyCh <- InstrumentOptionsReady{
 			InstrumentOptions: instrumentOptions,
@@ -327,7 +347,8 @@ func Run(runOpts RunOptions) {
 		SetConsolidationFunc(consolidators.TakeLast).
 		SetReadWorkerPool(readWorkerPool).
 		SetWriteWorkerPool(writeWorkerPool).
-		SetSeriesConsolidationMatchOptions(matchOptions)
+		SetSeriesConsolidationMatchOptions(matchOptions).
+		SetStitchStoragePolicies(cfg.Query.ConsolidationConfiguration.StitchStoragePolicies)
 
 	if runOpts.ApplyCustomTSDBOptions != nil {
 		tsdbOpts = runOpts.ApplyCustomTSDBOptions(tsdbOpts)
@@ -388,7 +409,7 @@ func Run(runOpts RunOptions) {
 		backendStorage, clusterClient, downsampler, cleanup, err = newM3DBStorage(
 			cfg, m3dbClusters, m3dbPoolWrapper,
 			runOpts, queryCtxOpts, tsdbOpts,
-			runOpts.DownsamplerReadyCh, instrumentOptions)
+			runOpts.DownsamplerReadyCh, healthRegistry, instrumentOptions)
 
 		if err != nil {
 			logger.Fatal("unable to setup m3db backend", zap.Error(err))
@@ -425,12 +446,33 @@ func Run(runOpts RunOptions) {
 	downsamplerAndWriter, err := newDownsamplerAndWriter(
 		backendStorage,
 		downsampler,
+		cfg.WriteRetry,
 		instrumentOptions,
 	)
 	if err != nil {
 		logger.Fatal("unable to create new downsampler and writer", zap.Error(err))
 	}
 
+	if schemasCfg := cfg.WriteSchemas; schemasCfg != nil {
+		if m3dbClusters == nil {
+			logger.Fatal("write schemas are only supported when connecting to M3DB clusters directly")
+		}
+		validator, err := schemasCfg.NewValidator(tagOptions, instrumentOptions)
+		if err != nil {
+			logger.Fatal("unable to create write schema validator", zap.Error(err))
+		}
+		downsamplerAndWriter = schema.NewDownsamplerAndWriter(downsamplerAndWriter,
+			validator, schema.NewClustersNamespaceResolver(m3dbClusters))
+	}
+
+	var cardinalityAnalyzer cardinality.Analyzer
+	if cardinalityCfg := cfg.CardinalityAnalyzer; cardinalityCfg != nil {
+		cardinalityAnalyzer = cardinalityCfg.NewAnalyzer(time.Now, instrumentOptions)
+		defer cardinalityAnalyzer.Close()
+		downsamplerAndWriter = cardinality.NewDownsamplerAndWriter(
+			downsamplerAndWriter, cardinalityAnalyzer)
+	}
+
 	var serviceOptionDefaults []handleroptions.ServiceOptionsDefault
 	if dbCfg := runOpts.DBConfig; dbCfg != nil {
 		cluster, err := dbCfg.EnvironmentConfig.Services.SyncCluster()
@@ -457,6 +499,106 @@ func Run(runOpts RunOptions) {
 		logger.Fatal("unable to set up handler options", zap.Error(err))
 	}
 
+	if clusterClient != nil {
+		err := healthRegistry.Register("kv", health.Readiness,
+			func(ctx context.Context) error {
+				store, err := clusterClient.KV()
+				if err != nil {
+					return err
+				}
+				return kvutil.NewHealthCheck(store)(ctx)
+			})
+		if err != nil {
+			logger.Fatal("unable to register kv health check", zap.Error(err))
+		}
+	}
+	handlerOptions = handlerOptions.SetHealthRegistry(healthRegistry)
+
+	if memCfg := cfg.Limits.Memory; memCfg != nil {
+		memMonitor, err := memCfg.NewMonitor(instrumentOptions)
+		if err != nil {
+			logger.Fatal("unable to create memory monitor", zap.Error(err))
+		}
+		if err := memMonitor.Start(); err != nil {
+			logger.Fatal("unable to start memory monitor", zap.Error(err))
+		}
+		defer memMonitor.Stop()
+		handlerOptions = handlerOptions.SetMemoryMonitor(memMonitor)
+	}
+
+	if exemplarsCfg := cfg.Exemplars; exemplarsCfg != nil {
+		handlerOptions = handlerOptions.SetExemplarStore(
+			exemplarsCfg.NewStore(instrumentOptions))
+	}
+
+	if quotasCfg := cfg.IngestQuotas; quotasCfg != nil {
+		handlerOptions = handlerOptions.SetIngestQuotas(
+			quotasCfg.NewEnforcer(handlerOptions.NowFn(), instrumentOptions))
+	}
+
+	if cardinalityAnalyzer != nil {
+		handlerOptions = handlerOptions.SetCardinalityAnalyzer(cardinalityAnalyzer)
+	}
+
+	rulesQueryable := prometheus.NewPrometheusQueryable(
+		prometheus.PrometheusOptions{
+			Storage:           backendStorage,
+			InstrumentOptions: instrumentOptions,
+		})
+	if rulesCfg := cfg.RecordingRules; rulesCfg != nil {
+		var leaseStore kv.Store
+		if clusterClient != nil {
+			leaseStore, err = clusterClient.KV()
+			if err != nil {
+				logger.Fatal("unable to get KV store for recording rules lease", zap.Error(err))
+			}
+		} else {
+			logger.Warn("no cluster client, recording rules will be evaluated by every coordinator")
+		}
+		recordingEngine, err := rulesCfg.NewEngine(recording.EngineOptions{
+			PromQLEngine:      prometheusEngine,
+			Queryable:         rulesQueryable,
+			Writer:            downsamplerAndWriter,
+			TagOptions:        tagOptions,
+			KVStore:           leaseStore,
+			NowFn:             handlerOptions.NowFn(),
+			InstrumentOptions: instrumentOptions,
+		})
+		if err != nil {
+			logger.Fatal("unable to create recording rule engine", zap.Error(err))
+		}
+		if err := recordingEngine.Start(); err != nil {
+			logger.Fatal("unable to start recording rule engine", zap.Error(err))
+		}
+		defer recordingEngine.Stop()
+	}
+
+	if rulesCfg := cfg.AlertingRules; rulesCfg != nil {
+		var alertStore kv.Store
+		if clusterClient != nil {
+			alertStore, err = clusterClient.KV()
+			if err != nil {
+				logger.Fatal("unable to get KV store for alert state", zap.Error(err))
+			}
+		} else {
+			logger.Warn("no cluster client, alert state will not be persisted")
+		}
+		alertingEngine, err := rulesCfg.NewEngine(alerting.EngineOptions{
+			PromQLEngine:      prometheusEngine,
+			Queryable:         rulesQueryable,
+			KVStore:           alertStore,
+			NowFn:             handlerOptions.NowFn(),
+			InstrumentOptions: instrumentOptions,
+		})
+		if err != nil {
+			logger.Fatal("unable to create alerting rule engine", zap.Error(err))
+		}
+		if err := alertingEngine.Start(); err != nil {
+			logger.Fatal("unable to start alerting rule engine", zap.Error(err))
+		}
+		defer alertingEngine.Stop()
+	}
+
 	if fn := runOpts.CustomHandlerOptions.OptionTransformFn; fn != nil {
 		handlerOptions = fn(handlerOptions)
 	}
@@ -467,6 +609,18 @@ func Run(runOpts RunOptions) {
 		logger.Fatal("unable to register routes", zap.Error(err))
 	}
 
+	if cfg.ConfigOverrides != nil {
+		if clusterClient == nil {
+			logger.Fatal("config overrides require a cluster client")
+		}
+		overrides, err := watchConfigOverrides(cfg, clusterClient, logLevel,
+			handler, instrumentOptions)
+		if err != nil {
+			logger.Fatal("unable to watch config overrides", zap.Error(err))
+		}
+		defer overrides.Close()
+	}
+
 	listenAddress, err := cfg.ListenAddress.Resolve()
 	if err != nil {
 		logger.Fatal("unable to get listen address", zap.Error(err))
@@ -486,6 +640,13 @@ func Run(runOpts RunOptions) {
 			zap.String("address", listenAddress),
 			zap.Error(err))
 	}
+	tlsConfig, err := cfg.TLS.ServerConfig()
+	if err != nil {
+		logger.Fatal("unable to create TLS config", zap.Error(err))
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 	if runOpts.ListenerCh != nil {
 		runOpts.ListenerCh <- listener
 	}
@@ -541,12 +702,84 @@ func Run(runOpts RunOptions) {
 		}
 	}
 
+	if cfg.Wavefront != nil && cfg.Wavefront.Ingester != nil {
+		server := startWavefrontIngestion(cfg.Wavefront.Ingester, listenerOpts,
+			instrumentOptions, logger, downsamplerAndWriter)
+		defer server.Close()
+	}
+
+	if cfg.OTLP != nil && cfg.OTLP.GRPC != nil {
+		server := startOTLPGRPCServer(cfg.OTLP.GRPC, listenerOpts,
+			handlerOptions, logger)
+		defer server.GracefulStop()
+	}
+
 	// Wait for process interrupt.
 	xos.WaitForInterrupt(logger, xos.InterruptOptions{
 		InterruptCh: runOpts.InterruptCh,
 	})
 }
 
+// watchConfigOverrides watches for overrides of the config stored in KV and
+// applies the tunables that can be changed at runtime whenever they change.
+func watchConfigOverrides(
+	cfg config.Configuration,
+	clusterClient clusterclient.Client,
+	logLevel zap.AtomicLevel,
+	handler *httpd.Handler,
+	instrumentOpts instrument.Options,
+) (provider.Provider, error) {
+	kvOpts, err := cfg.ConfigOverrides.KVConfig.NewOverrideOptions()
+	if err != nil {
+		return nil, err
+	}
+	store, err := clusterClient.Store(kvOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := instrumentOpts.Logger()
+	opts := provider.NewOptions().
+		SetKVStore(store).
+		SetInstrumentOptions(instrumentOpts).
+		SetValidateFn(func(value interface{}) error {
+			newCfg := value.(*config.Configuration)
+			if newCfg.Logging.Level == "" {
+				return nil
+			}
+			var level zapcore.Level
+			return level.UnmarshalText([]byte(newCfg.Logging.Level))
+		})
+	p, err := provider.NewProvider(&cfg, cfg.ConfigOverrides.Key, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	_, w, err := p.Watch()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	go func() {
+		defer w.Close()
+		for range w.C() {
+			newCfg := w.Get().(*config.Configuration)
+			level := zap.InfoLevel
+			if newCfg.Logging.Level != "" {
+				// NB: the level was checked by the validate function.
+				_ = level.UnmarshalText([]byte(newCfg.Logging.Level))
+			}
+			logLevel.SetLevel(level)
+			handler.UpdateConfig(*newCfg)
+			logger.Info("applied config overrides",
+				zap.Stringer("logLevel", level))
+		}
+	}()
+
+	return p, nil
+}
+
 // make connections to the m3db cluster(s) and generate sessions for those clusters along with the storage
 func newM3DBStorage(
 	cfg config.Configuration,
@@ -556,6 +789,7 @@ func newM3DBStorage(
 	queryContextOptions models.QueryContextOptions,
 	tsdbOpts tsdb.Options,
 	downsamplerReadyCh chan<- struct{},
+	healthRegistry health.Registry,
 	instrumentOptions instrument.Options,
 ) (storage.Storage, clusterclient.Client, downsample.Downsampler, cleanupFn, error) {
 	var (
@@ -599,7 +833,7 @@ func newM3DBStorage(
 	}
 
 	fanoutStorage, storageCleanup, err := newStorages(clusters, cfg,
-		poolWrapper, queryContextOptions, tsdbOpts, instrumentOptions)
+		poolWrapper, queryContextOptions, tsdbOpts, healthRegistry, instrumentOptions)
 	if err != nil {
 		return nil, nil, nil, nil, errors.Wrap(err, "unable to set up storages")
 	}
@@ -831,6 +1065,7 @@ func newStorages(
 	poolWrapper *pools.PoolWrapper,
 	queryContextOptions models.QueryContextOptions,
 	opts tsdb.Options,
+	healthRegistry health.Registry,
 	instrumentOpts instrument.Options,
 ) (storage.Storage, cleanupFn, error) {
 	var (
@@ -849,7 +1084,7 @@ func newStorages(
 	if remoteOpts.ServeEnabled() {
 		logger.Info("rpc serve enabled")
 		server, err := startGRPCServer(localStorage, queryContextOptions,
-			poolWrapper, remoteOpts, instrumentOpts)
+			poolWrapper, remoteOpts, healthRegistry, instrumentOpts)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -983,6 +1218,7 @@ func startGRPCServer(
 	queryContextOptions models.QueryContextOptions,
 	poolWrapper *pools.PoolWrapper,
 	opts config.RemoteOptions,
+	healthRegistry health.Registry,
 	instrumentOpts instrument.Options,
 ) (*grpc.Server, error) {
 	logger := instrumentOpts.Logger()
@@ -990,6 +1226,8 @@ func startGRPCServer(
 	logger.Info("creating gRPC server")
 	server := tsdbRemote.NewGRPCServer(storage,
 		queryContextOptions, poolWrapper, instrumentOpts)
+	healthpb.RegisterHealthServer(server,
+		health.NewGRPCServer(healthRegistry, healthWatchInterval))
 
 	if opts.ReflectionEnabled() {
 		reflection.Register(server)
@@ -1011,6 +1249,33 @@ func startGRPCServer(
 	return server, nil
 }
 
+func startOTLPGRPCServer(
+	cfg *config.OTLPGRPCConfiguration,
+	listenerOpts xnet.ListenerOptions,
+	handlerOpts options.HandlerOptions,
+	logger *zap.Logger,
+) *grpc.Server {
+	listenAddress := cfg.ListenAddressOrDefault()
+	logger.Info("otlp grpc ingestion enabled",
+		zap.String("address", listenAddress))
+
+	listener, err := listenerOpts.Listen("tcp", listenAddress)
+	if err != nil {
+		logger.Fatal("unable to listen on otlp grpc listen address",
+			zap.String("address", listenAddress),
+			zap.Error(err))
+	}
+
+	server := otlp.NewOTLPGRPCServer(handlerOpts)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error("error from serving otlp grpc server", zap.Error(err))
+		}
+	}()
+
+	return server
+}
+
 func startCarbonIngestion(
 	cfg *config.CarbonConfiguration,
 	listenerOpts xnet.ListenerOptions,
@@ -1139,9 +1404,78 @@ func startCarbonIngestion(
 	return carbonServer, true
 }
 
+func startWavefrontIngestion(
+	cfg *config.WavefrontIngesterConfiguration,
+	listenerOpts xnet.ListenerOptions,
+	iOpts instrument.Options,
+	logger *zap.Logger,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+) xserver.Server {
+	logger.Info("wavefront ingestion enabled, configuring ingester")
+
+	// Setup worker pool.
+	var (
+		wavefrontIOpts = iOpts.SetMetricsScope(
+			iOpts.MetricsScope().SubScope("ingest-wavefront"))
+		workerPoolOpts xsync.PooledWorkerPoolOptions
+		workerPoolSize int
+	)
+	if cfg.MaxConcurrency > 0 {
+		// Use a bounded worker pool if they requested a specific maximum concurrency.
+		workerPoolOpts = xsync.NewPooledWorkerPoolOptions().
+			SetGrowOnDemand(false).
+			SetInstrumentOptions(wavefrontIOpts)
+		workerPoolSize = cfg.MaxConcurrency
+	} else {
+		workerPoolOpts = xsync.NewPooledWorkerPoolOptions().
+			SetGrowOnDemand(true).
+			SetKillWorkerProbability(0.001)
+		workerPoolSize = defaultWavefrontIngesterWorkerPoolSize
+	}
+	workerPool, err := xsync.NewPooledWorkerPool(workerPoolSize, workerPoolOpts)
+	if err != nil {
+		logger.Fatal("unable to create worker pool for wavefront ingester", zap.Error(err))
+	}
+	workerPool.Init()
+
+	// Create ingester.
+	ingester, err := ingestwavefront.NewIngester(
+		downsamplerAndWriter, ingestwavefront.Options{
+			InstrumentOptions:      wavefrontIOpts,
+			WorkerPool:             workerPool,
+			MaxDatapointsPerSecond: cfg.MaxDatapointsPerSecond,
+		})
+	if err != nil {
+		logger.Fatal("unable to create wavefront ingester", zap.Error(err))
+	}
+
+	// Start server.
+	var (
+		serverOpts = xserver.NewOptions().
+				SetInstrumentOptions(wavefrontIOpts).
+				SetListenerOptions(listenerOpts)
+		listenAddress   = cfg.ListenAddressOrDefault()
+		wavefrontServer = xserver.NewServer(listenAddress, ingester, serverOpts)
+	)
+	if strings.TrimSpace(listenAddress) == "" {
+		logger.Fatal("no listen address specified for wavefront ingester")
+	}
+
+	logger.Info("starting wavefront ingestion server", zap.String("listenAddress", listenAddress))
+	if err := wavefrontServer.ListenAndServe(); err != nil {
+		logger.Fatal("unable to start wavefront ingestion server at listen address",
+			zap.String("listenAddress", listenAddress), zap.Error(err))
+	}
+
+	logger.Info("started wavefront ingestion server", zap.String("listenAddress", listenAddress))
+
+	return wavefrontServer
+}
+
 func newDownsamplerAndWriter(
 	storage storage.Storage,
 	downsampler downsample.Downsampler,
+	retryCfg *xretry.Configuration,
 	iOpts instrument.Options,
 ) (ingest.DownsamplerAndWriter, error) {
 	// Make sure the downsampler and writer gets its own PooledWorkerPool and that its not shared with any other
@@ -1156,7 +1490,14 @@ func newDownsamplerAndWriter(
 	}
 	downAndWriteWorkerPool.Init()
 
-	return ingest.NewDownsamplerAndWriter(storage, downsampler, downAndWriteWorkerPool, iOpts), nil
+	var retrier xretry.Retrier
+	if retryCfg != nil {
+		retrier = xretry.NewRetrier(retryCfg.NewOptions(
+			iOpts.MetricsScope().SubScope("write-retry")))
+	}
+
+	return ingest.NewDownsamplerAndWriterWithRetrier(storage, downsampler,
+		downAndWriteWorkerPool, retrier, iOpts), nil
 }
 
 func newPromQLEngine(
@@ -684,3 +684,13 @@ func (s *queryServer) CompleteTags(
 	s.tagCompletes++
 	return nil
 }
+
+func (s *queryServer) FetchStream(
+	*rpc.FetchRequest,
+	rpc.Query_FetchStreamServer,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package exemplar
+
+import (
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultMaxSeries             = 100000
+	defaultMaxExemplarsPerSeries = 10
+)
+
+// Configuration is the configuration for the exemplar store.
+type Configuration struct {
+	// MaxSeries is the maximum number of series to retain exemplars for,
+	// the least recently appended series is evicted to make room for a new
+	// series once it is reached.
+	MaxSeries int `yaml:"maxSeries" validate:"min=0"`
+
+	// MaxExemplarsPerSeries is the size of each series' exemplar ring, the
+	// oldest exemplar of a series is replaced once it is reached.
+	MaxExemplarsPerSeries int `yaml:"maxExemplarsPerSeries" validate:"min=0"`
+}
+
+// NewStore returns a new exemplar store.
+func (c Configuration) NewStore(iOpts instrument.Options) Store {
+	maxSeries := defaultMaxSeries
+	if c.MaxSeries > 0 {
+		maxSeries = c.MaxSeries
+	}
+	maxExemplars := defaultMaxExemplarsPerSeries
+	if c.MaxExemplarsPerSeries > 0 {
+		maxExemplars = c.MaxExemplarsPerSeries
+	}
+	return newStore(maxSeries, maxExemplars, iOpts)
+}
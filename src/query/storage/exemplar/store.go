@@ -0,0 +1,254 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package exemplar
+
+import (
+	"bytes"
+	"container/list"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+type storeMetrics struct {
+	added         tally.Counter
+	duplicate     tally.Counter
+	evictedSeries tally.Counter
+	series        tally.Gauge
+}
+
+func newStoreMetrics(scope tally.Scope) storeMetrics {
+	return storeMetrics{
+		added:         scope.Counter("added"),
+		duplicate:     scope.Counter("duplicate"),
+		evictedSeries: scope.Counter("evicted-series"),
+		series:        scope.Gauge("series"),
+	}
+}
+
+// seriesRing is a fixed size ring of the most recent exemplars of a series.
+type seriesRing struct {
+	id        string
+	tags      models.Tags
+	exemplars []Exemplar
+	next      int
+	// elem is the series' element in the store's least recently appended
+	// list.
+	elem *list.Element
+}
+
+func (r *seriesRing) add(e Exemplar, capacity int) bool {
+	if n := len(r.exemplars); n > 0 {
+		// Remote write resends the last exemplar of a series with every
+		// request until a new one is observed, skip repeats.
+		last := r.exemplars[(r.next+n-1)%n]
+		if last.Timestamp.Equal(e.Timestamp) && last.Value == e.Value {
+			return false
+		}
+	}
+
+	if len(r.exemplars) < capacity {
+		r.exemplars = append(r.exemplars, e)
+		return true
+	}
+	r.exemplars[r.next] = e
+	r.next = (r.next + 1) % capacity
+	return true
+}
+
+// inRange returns the exemplars within [start, end] in ascending time order.
+func (r *seriesRing) inRange(start, end time.Time) []Exemplar {
+	var result []Exemplar
+	n := len(r.exemplars)
+	for i := 0; i < n; i++ {
+		e := r.exemplars[(r.next+i)%n]
+		if e.Timestamp.Before(start) || e.Timestamp.After(end) {
+			continue
+		}
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	return result
+}
+
+type store struct {
+	sync.RWMutex
+
+	maxSeries    int
+	maxExemplars int
+	series       map[string]*seriesRing
+	// lru orders the series from the most to the least recently appended,
+	// the least recently appended series is evicted to make room for a new
+	// series once maxSeries is reached.
+	lru     *list.List
+	metrics storeMetrics
+}
+
+func newStore(maxSeries, maxExemplars int, iOpts instrument.Options) *store {
+	scope := iOpts.MetricsScope().SubScope("exemplars")
+	return &store{
+		maxSeries:    maxSeries,
+		maxExemplars: maxExemplars,
+		series:       make(map[string]*seriesRing),
+		lru:          list.New(),
+		metrics:      newStoreMetrics(scope),
+	}
+}
+
+func (s *store) Add(tags models.Tags, exemplars []Exemplar) {
+	if len(exemplars) == 0 {
+		return
+	}
+
+	id := string(tags.ID())
+	s.Lock()
+	defer s.Unlock()
+
+	ring, ok := s.series[id]
+	if ok {
+		s.lru.MoveToFront(ring.elem)
+	} else {
+		if len(s.series) >= s.maxSeries {
+			s.evictWithLock()
+		}
+		ring = &seriesRing{id: id, tags: tags.Clone()}
+		ring.elem = s.lru.PushFront(ring)
+		s.series[id] = ring
+		s.metrics.series.Update(float64(len(s.series)))
+	}
+
+	for _, e := range exemplars {
+		labels := make([]models.Tag, 0, len(e.Labels))
+		for _, l := range e.Labels {
+			labels = append(labels, l.Clone())
+		}
+		e.Labels = labels
+
+		if ring.add(e, s.maxExemplars) {
+			s.metrics.added.Inc(1)
+		} else {
+			s.metrics.duplicate.Inc(1)
+		}
+	}
+}
+
+// evictWithLock evicts the least recently appended series.
+func (s *store) evictWithLock() {
+	elem := s.lru.Back()
+	if elem == nil {
+		return
+	}
+	ring := s.lru.Remove(elem).(*seriesRing)
+	delete(s.series, ring.id)
+	s.metrics.evictedSeries.Inc(1)
+}
+
+func (s *store) Query(
+	matchers models.Matchers,
+	start, end time.Time,
+) ([]SeriesExemplars, error) {
+	compiled, err := compileMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	var result []SeriesExemplars
+	for _, ring := range s.series {
+		if !compiled.matches(ring.tags) {
+			continue
+		}
+		exemplars := ring.inRange(start, end)
+		if len(exemplars) == 0 {
+			continue
+		}
+		result = append(result, SeriesExemplars{
+			Tags:      ring.tags,
+			Exemplars: exemplars,
+		})
+	}
+
+	// Return series in a deterministic order.
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Tags.ID(), result[j].Tags.ID()) < 0
+	})
+	return result, nil
+}
+
+type compiledMatcher struct {
+	models.Matcher
+	re *regexp.Regexp
+}
+
+type compiledMatchers []compiledMatcher
+
+func compileMatchers(matchers models.Matchers) (compiledMatchers, error) {
+	compiled := make(compiledMatchers, 0, len(matchers))
+	for _, m := range matchers {
+		c := compiledMatcher{Matcher: m}
+		if m.Type == models.MatchRegexp || m.Type == models.MatchNotRegexp {
+			re, err := regexp.Compile("^(?:" + string(m.Value) + ")$")
+			if err != nil {
+				return nil, err
+			}
+			c.re = re
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+func (c compiledMatchers) matches(tags models.Tags) bool {
+	for _, m := range c {
+		value, exists := tags.Get(m.Name)
+		var matched bool
+		switch m.Type {
+		case models.MatchEqual:
+			matched = bytes.Equal(m.Value, value)
+		case models.MatchNotEqual:
+			matched = !bytes.Equal(m.Value, value)
+		case models.MatchRegexp:
+			matched = m.re.Match(value)
+		case models.MatchNotRegexp:
+			matched = !m.re.Match(value)
+		case models.MatchField:
+			matched = exists
+		case models.MatchNotField:
+			matched = !exists
+		case models.MatchAll:
+			matched = true
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
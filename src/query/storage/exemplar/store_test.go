@@ -0,0 +1,172 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package exemplar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func testTags(pairs ...string) models.Tags {
+	tags := models.NewTags(len(pairs)/2, models.NewTagOptions())
+	for i := 0; i < len(pairs); i += 2 {
+		tags = tags.AddTag(models.Tag{
+			Name:  []byte(pairs[i]),
+			Value: []byte(pairs[i+1]),
+		})
+	}
+	return tags
+}
+
+func testExemplar(traceID string, value float64, t time.Time) Exemplar {
+	return Exemplar{
+		Labels:    []models.Tag{{Name: []byte("trace_id"), Value: []byte(traceID)}},
+		Value:     value,
+		Timestamp: t,
+	}
+}
+
+func mustMatcher(t *testing.T, typ models.MatchType, name, value string) models.Matcher {
+	m, err := models.NewMatcher(typ, []byte(name), []byte(value))
+	require.NoError(t, err)
+	return m
+}
+
+func TestStoreRingKeepsMostRecent(t *testing.T) {
+	s := Configuration{MaxExemplarsPerSeries: 2}.NewStore(instrument.NewOptions())
+
+	now := time.Now().Truncate(time.Second)
+	tags := testTags("__name__", "requests", "job", "api")
+	s.Add(tags, []Exemplar{
+		testExemplar("a", 1, now),
+		testExemplar("b", 2, now.Add(time.Second)),
+	})
+	// Repeat of the last exemplar is skipped.
+	s.Add(tags, []Exemplar{testExemplar("b", 2, now.Add(time.Second))})
+	s.Add(tags, []Exemplar{testExemplar("c", 3, now.Add(2*time.Second))})
+
+	matchers := models.Matchers{
+		mustMatcher(t, models.MatchEqual, "__name__", "requests"),
+	}
+	result, err := s.Query(matchers, now, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result))
+	assert.Equal(t, tags.ID(), result[0].Tags.ID())
+	require.Equal(t, 2, len(result[0].Exemplars))
+	assert.Equal(t, "b", string(result[0].Exemplars[0].Labels[0].Value))
+	assert.Equal(t, "c", string(result[0].Exemplars[1].Labels[0].Value))
+
+	// Only exemplars within the range are returned.
+	result, err = s.Query(matchers, now, now.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result))
+	require.Equal(t, 1, len(result[0].Exemplars))
+	assert.Equal(t, "b", string(result[0].Exemplars[0].Labels[0].Value))
+}
+
+func TestStoreQueryMatchers(t *testing.T) {
+	s := Configuration{}.NewStore(instrument.NewOptions())
+
+	now := time.Now()
+	s.Add(testTags("__name__", "requests", "job", "api"),
+		[]Exemplar{testExemplar("a", 1, now)})
+	s.Add(testTags("__name__", "requests", "job", "db"),
+		[]Exemplar{testExemplar("b", 1, now)})
+	s.Add(testTags("__name__", "errors", "job", "api"),
+		[]Exemplar{testExemplar("c", 1, now)})
+
+	tests := []struct {
+		matchers models.Matchers
+		expected []string
+	}{
+		{
+			matchers: models.Matchers{
+				mustMatcher(t, models.MatchEqual, "__name__", "requests"),
+			},
+			expected: []string{"a", "b"},
+		},
+		{
+			matchers: models.Matchers{
+				mustMatcher(t, models.MatchEqual, "__name__", "requests"),
+				mustMatcher(t, models.MatchNotEqual, "job", "api"),
+			},
+			expected: []string{"b"},
+		},
+		{
+			matchers: models.Matchers{
+				mustMatcher(t, models.MatchRegexp, "job", "a.*"),
+			},
+			expected: []string{"c", "a"},
+		},
+		{
+			matchers: models.Matchers{
+				mustMatcher(t, models.MatchNotField, "job", ""),
+			},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		result, err := s.Query(tt.matchers, now.Add(-time.Minute), now)
+		require.NoError(t, err)
+
+		var actual []string
+		for _, series := range result {
+			for _, e := range series.Exemplars {
+				actual = append(actual, string(e.Labels[0].Value))
+			}
+		}
+		assert.Equal(t, tt.expected, actual, tt.matchers.String())
+	}
+}
+
+func TestStoreMaxSeriesEvictsLeastRecentlyAppended(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	s := Configuration{MaxSeries: 2}.NewStore(
+		instrument.NewOptions().SetMetricsScope(scope))
+
+	now := time.Now()
+	s.Add(testTags("__name__", "requests"), []Exemplar{testExemplar("a", 1, now)})
+	s.Add(testTags("__name__", "errors"), []Exemplar{testExemplar("b", 1, now)})
+	// Appending to requests makes errors the least recently appended.
+	s.Add(testTags("__name__", "requests"), []Exemplar{testExemplar("c", 2, now)})
+	s.Add(testTags("__name__", "latency"), []Exemplar{testExemplar("d", 1, now)})
+
+	result, err := s.Query(models.Matchers{
+		mustMatcher(t, models.MatchAll, "", ""),
+	}, now, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(result))
+	assert.Equal(t, "latency", string(result[0].Tags.Tags[0].Value))
+	assert.Equal(t, "requests", string(result[1].Tags.Tags[0].Value))
+	assert.Equal(t, 2, len(result[1].Exemplars))
+
+	evicted := scope.Snapshot().Counters()["exemplars.evicted-series+"]
+	require.NotNil(t, evicted)
+	assert.Equal(t, int64(1), evicted.Value())
+}
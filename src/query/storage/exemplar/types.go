@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package exemplar provides a bounded in-memory store of Prometheus
+// exemplars, samples that link a series to a trace.
+package exemplar
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+// Exemplar is a sample linked to a trace, typically via a trace ID label.
+type Exemplar struct {
+	Labels    []models.Tag
+	Value     float64
+	Timestamp time.Time
+}
+
+// SeriesExemplars is the exemplars of a single series.
+type SeriesExemplars struct {
+	Tags      models.Tags
+	Exemplars []Exemplar
+}
+
+// Store is a bounded store of exemplars, retaining the most recent
+// exemplars of each series in a fixed size ring.
+type Store interface {
+	// Add adds exemplars to the series with the given tags.
+	Add(tags models.Tags, exemplars []Exemplar)
+
+	// Query returns the exemplars within the given time range of the
+	// series matching all matchers, in ascending time order.
+	Query(matchers models.Matchers, start, end time.Time) ([]SeriesExemplars, error)
+}
@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/m3db/m3/src/query/block"
@@ -148,11 +149,86 @@ func (s *fanoutStorage) FetchProm(
 	return storage.PromResult{
 		Metadata: resultMeta,
 		PromResult: &prompb.QueryResult{
-			Timeseries: series,
+			Timeseries: dedupePromSeries(series),
 		},
 	}, nil
 }
 
+// dedupePromSeries merges series returned by multiple stores that share
+// an identical label set, combining their samples in timestamp order and
+// keeping the first sample seen for any duplicated timestamp.
+func dedupePromSeries(series []*prompb.TimeSeries) []*prompb.TimeSeries {
+	if len(series) < 2 {
+		return series
+	}
+
+	var (
+		buf     bytes.Buffer
+		indices = make(map[string]int, len(series))
+		deduped = make([]*prompb.TimeSeries, 0, len(series))
+		merged  = make(map[int]struct{})
+	)
+
+	for _, s := range series {
+		labels := make([]prompb.Label, len(s.GetLabels()))
+		copy(labels, s.GetLabels())
+		sort.Slice(labels, func(i, j int) bool {
+			return bytes.Compare(labels[i].Name, labels[j].Name) < 0
+		})
+
+		buf.Reset()
+		for _, l := range labels {
+			buf.Write(l.Name)
+			buf.WriteByte(0)
+			buf.Write(l.Value)
+			buf.WriteByte(0)
+		}
+
+		key := buf.String()
+		idx, found := indices[key]
+		if !found {
+			indices[key] = len(deduped)
+			deduped = append(deduped, s)
+			continue
+		}
+
+		existing := deduped[idx]
+		if _, copied := merged[idx]; !copied {
+			// NB: copy the series before mutating so that the result from the
+			// underlying store is left untouched.
+			existing = &prompb.TimeSeries{
+				Labels:  existing.Labels,
+				Samples: append([]prompb.Sample(nil), existing.Samples...),
+				Type:    existing.Type,
+				Source:  existing.Source,
+			}
+			deduped[idx] = existing
+			merged[idx] = struct{}{}
+		}
+
+		existing.Samples = append(existing.Samples, s.GetSamples()...)
+	}
+
+	for idx := range merged {
+		samples := deduped[idx].Samples
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].Timestamp < samples[j].Timestamp
+		})
+
+		filtered := samples[:0]
+		for _, sample := range samples {
+			n := len(filtered)
+			if n > 0 && sample.Timestamp == filtered[n-1].Timestamp {
+				continue
+			}
+			filtered = append(filtered, sample)
+		}
+		deduped[idx].Samples = filtered
+	}
+
+	return deduped
+}
+
 func (s *fanoutStorage) FetchBlocks(
 	ctx context.Context,
 	query *storage.FetchQuery,
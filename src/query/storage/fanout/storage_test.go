@@ -476,3 +476,68 @@ func TestFanoutFetchErrorContinues(t *testing.T) {
 	require.Equal(t, 1, len(labels))
 	assert.Equal(t, "ok", string(labels[0].GetName()))
 }
+
+func TestFanoutFetchPromDedupesSeries(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	filter := func(_ storage.Query, _ storage.Storage) bool {
+		return true
+	}
+
+	tFilter := func(_ storage.CompleteTagsQuery, _ storage.Storage) bool {
+		return true
+	}
+
+	newStore := func(series ...*prompb.TimeSeries) storage.Storage {
+		store := storage.NewMockStorage(ctrl)
+		store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(storage.PromResult{
+				PromResult: &prompb.QueryResult{Timeseries: series},
+			}, nil)
+		return store
+	}
+
+	label := func(name, value string) prompb.Label {
+		return prompb.Label{Name: []byte(name), Value: []byte(value)}
+	}
+
+	stores := []storage.Storage{
+		newStore(
+			&prompb.TimeSeries{
+				Labels:  []prompb.Label{label("a", "1"), label("b", "2")},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1}, {Value: 3, Timestamp: 3}},
+			},
+			&prompb.TimeSeries{
+				Labels:  []prompb.Label{label("a", "2")},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1}},
+			},
+		),
+		newStore(
+			&prompb.TimeSeries{
+				Labels:  []prompb.Label{label("b", "2"), label("a", "1")},
+				Samples: []prompb.Sample{{Value: 2, Timestamp: 2}, {Value: 30, Timestamp: 3}},
+			},
+		),
+	}
+
+	store := NewStorage(stores, filter, filter, tFilter,
+		models.NewTagOptions(), instrument.NewOptions())
+	result, err := store.FetchProm(context.TODO(), &storage.FetchQuery{},
+		storage.NewFetchOptions())
+	require.NoError(t, err)
+
+	series := result.PromResult.GetTimeseries()
+	require.Equal(t, 2, len(series))
+	for _, s := range series {
+		if len(s.Labels) == 1 {
+			assert.Equal(t, 1, len(s.Samples))
+			continue
+		}
+
+		require.Equal(t, 3, len(s.Samples))
+		for i, ts := range []int64{1, 2, 3} {
+			assert.Equal(t, ts, s.Samples[i].Timestamp)
+		}
+	}
+}
@@ -40,6 +40,7 @@ func NewFetchOptions() *FetchOptions {
 			FanoutUnaggregated:        FanoutDefault,
 			FanoutAggregated:          FanoutDefault,
 			FanoutAggregatedOptimized: FanoutDefault,
+			Stitch:                    FanoutDefault,
 		},
 		Enforcer: cost.NoopChainedEnforcer(),
 		Scope:    tally.NoopScope,
@@ -360,3 +360,71 @@ func newCoversRangeFilter(opts coversRangeFilterOptions) func(namespace ClusterN
 		return !clusterStart.After(opts.queryStart)
 	}
 }
+
+// clusterNamespaceRange is a cluster namespace to read a part of the query
+// range from.
+type clusterNamespaceRange struct {
+	namespace ClusterNamespace
+	start     time.Time
+	end       time.Time
+}
+
+// resolveStitchedClusterNamespacesForQuery returns the namespaces to read
+// each part of the query range from when stitching together results read
+// from namespaces with different storage policies. Each part of the range is
+// read from the most granular namespace with a complete set of metrics that
+// retains it. Returns false if there is nothing to stitch because a single
+// namespace would be read.
+func resolveStitchedClusterNamespacesForQuery(
+	now, start, end time.Time,
+	clusters Clusters,
+	opts *storage.FanoutOptions,
+) ([]clusterNamespaceRange, bool) {
+	all := clusters.ClusterNamespaces()
+	candidates := make([]ClusterNamespace, 0, len(all))
+	if opts.FanoutUnaggregated != storage.FanoutForceDisable {
+		candidates = append(candidates, clusters.UnaggregatedClusterNamespace())
+	}
+
+	if opts.FanoutAggregated != storage.FanoutForceDisable {
+		var r reusedAggregatedNamespaceSlices
+		r = aggregatedNamespaces(all, r, nil, opts)
+		// NB: only namespaces with a complete set of metrics can be stitched,
+		// since any part of the range read from a partially aggregated
+		// namespace may be missing metrics.
+		candidates = append(candidates, r.completeAggregated...)
+	}
+
+	// NB: the unaggregated namespace has no resolution so sorts first.
+	sort.Stable(ClusterNamespacesByResolutionAsc(candidates))
+
+	var (
+		result []clusterNamespaceRange
+		cursor = end
+	)
+	for _, namespace := range candidates {
+		if !cursor.After(start) {
+			break
+		}
+
+		retention := namespace.Options().Attributes().Retention
+		namespaceStart := now.Add(-1 * retention)
+		if !namespaceStart.Before(cursor) {
+			// Does not retain any of the remaining range.
+			continue
+		}
+
+		if namespaceStart.Before(start) {
+			namespaceStart = start
+		}
+
+		result = append(result, clusterNamespaceRange{
+			namespace: namespace,
+			start:     namespaceStart,
+			end:       cursor,
+		})
+		cursor = namespaceStart
+	}
+
+	return result, len(result) > 1
+}
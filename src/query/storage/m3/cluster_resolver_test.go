@@ -529,3 +529,127 @@ func TestExampleCase(t *testing.T) {
 		assert.Equal(t, consolidators.NamespaceCoversPartialQueryRange, fanoutType)
 	}
 }
+
+func TestResolveStitchedClusterNamespacesForQuery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	session := client.NewMockSession(ctrl)
+	day := 24 * time.Hour
+
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("UNAGG"),
+		Retention:   2 * day,
+		Session:     session,
+	}, AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("AGG_10M_COMPLETE"),
+		Retention:   365 * day,
+		Resolution:  10 * time.Minute,
+		Downsample:  &ClusterNamespaceDownsampleOptions{All: true},
+		Session:     session,
+	}, AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("AGG_5M_PARTIAL"),
+		Retention:   90 * day,
+		Resolution:  5 * time.Minute,
+		Downsample:  &ClusterNamespaceDownsampleOptions{All: false},
+		Session:     session,
+	}, AggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("AGG_1M_COMPLETE"),
+		Retention:   30 * day,
+		Resolution:  time.Minute,
+		Downsample:  &ClusterNamespaceDownsampleOptions{All: true},
+		Session:     session,
+	})
+	require.NoError(t, err)
+
+	type expectedRange struct {
+		namespace  string
+		start, end time.Time
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		opts     *storage.FanoutOptions
+		expected []expectedRange
+		ok       bool
+	}{
+		{
+			name:  "stitches across all retentions",
+			start: now.Add(-60 * day),
+			end:   now,
+			opts:  &storage.FanoutOptions{},
+			expected: []expectedRange{
+				{"UNAGG", now.Add(-2 * day), now},
+				{"AGG_1M_COMPLETE", now.Add(-30 * day), now.Add(-2 * day)},
+				{"AGG_10M_COMPLETE", now.Add(-60 * day), now.Add(-30 * day)},
+			},
+			ok: true,
+		},
+		{
+			name:  "skips namespaces not retaining the range",
+			start: now.Add(-60 * day),
+			end:   now.Add(-10 * day),
+			opts:  &storage.FanoutOptions{},
+			expected: []expectedRange{
+				{"AGG_1M_COMPLETE", now.Add(-30 * day), now.Add(-10 * day)},
+				{"AGG_10M_COMPLETE", now.Add(-60 * day), now.Add(-30 * day)},
+			},
+			ok: true,
+		},
+		{
+			name:  "unaggregated disabled",
+			start: now.Add(-10 * day),
+			end:   now,
+			opts: &storage.FanoutOptions{
+				FanoutUnaggregated: storage.FanoutForceDisable,
+			},
+			expected: []expectedRange{
+				{"AGG_1M_COMPLETE", now.Add(-10 * day), now},
+			},
+			ok: false,
+		},
+		{
+			name:  "single namespace covers range",
+			start: now.Add(-time.Hour),
+			end:   now,
+			opts:  &storage.FanoutOptions{},
+			expected: []expectedRange{
+				{"UNAGG", now.Add(-time.Hour), now},
+			},
+			ok: false,
+		},
+		{
+			name:  "aggregated optimization disabled",
+			start: now.Add(-60 * day),
+			end:   now,
+			opts: &storage.FanoutOptions{
+				FanoutAggregatedOptimized: storage.FanoutForceDisable,
+			},
+			expected: []expectedRange{
+				{"UNAGG", now.Add(-2 * day), now},
+			},
+			ok: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges, ok := resolveStitchedClusterNamespacesForQuery(now,
+				tt.start, tt.end, clusters, tt.opts)
+			assert.Equal(t, tt.ok, ok)
+
+			actual := make([]expectedRange, 0, len(ranges))
+			for _, r := range ranges {
+				actual = append(actual, expectedRange{
+					namespace: r.namespace.NamespaceID().String(),
+					start:     r.start,
+					end:       r.end,
+				})
+			}
+
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
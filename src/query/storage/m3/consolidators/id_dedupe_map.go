@@ -75,6 +75,20 @@ func (m *idDedupeMap) add(
 		return nil
 	}
 
+	if m.fanout == NamespacesStitchQueryRange {
+		stitched, err := existing.stitch(multiResultSeries{
+			attrs: attrs,
+			iter:  iter,
+			tags:  tags,
+		})
+		if err != nil {
+			return err
+		}
+
+		m.series[id] = stitched
+		return nil
+	}
+
 	var existsBetter bool
 	switch m.fanout {
 	case NamespaceCoversAllQueryRange:
@@ -74,6 +74,34 @@ type multiResultSeries struct {
 	tags  models.Tags
 }
 
+// stitch accumulates the iterator of a series read from a namespace covering
+// another part of the query range into the existing series, keeping the
+// attributes of the coarsest resolution read.
+func (s multiResultSeries) stitch(
+	other multiResultSeries,
+) (multiResultSeries, error) {
+	acc, ok := s.iter.(encoding.SeriesIteratorAccumulator)
+	if !ok {
+		var err error
+		acc, err = encoding.NewSeriesIteratorAccumulator(s.iter,
+			encoding.SeriesAccumulatorOptions{AllowMultipleNamespaces: true})
+		if err != nil {
+			return s, err
+		}
+	}
+
+	if err := acc.Add(other.iter); err != nil {
+		return s, err
+	}
+
+	s.iter = acc
+	if other.attrs.Resolution > s.attrs.Resolution {
+		s.attrs = other.attrs
+	}
+
+	return s, nil
+}
+
 func (r *multiResult) Close() error {
 	r.Lock()
 	defer r.Unlock()
@@ -26,14 +26,17 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/query/block"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage/m3/storagemetadata"
 	"github.com/m3db/m3/src/x/ident"
 	xtest "github.com/m3db/m3/src/x/test"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var defaultTestOpts = MatchOptions{
@@ -160,6 +163,88 @@ func testMultiResult(t *testing.T, fanoutType QueryFanoutType, expected string)
 	assert.NoError(t, r.Close())
 }
 
+func TestMultiResultStitch(t *testing.T) {
+	for _, matchType := range []MatchType{MatchIDs, MatchTags} {
+		t.Run(fmt.Sprint(matchType), func(t *testing.T) {
+			testMultiResultStitch(t, matchType)
+		})
+	}
+}
+
+func testMultiResultStitch(t *testing.T, matchType MatchType) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now().Truncate(time.Hour)
+	generateStitchIterators := func(
+		ns string,
+		at time.Time,
+		v float64,
+	) encoding.SeriesIterators {
+		iter := encoding.NewMockSeriesIterator(ctrl)
+		iter.EXPECT().ID().Return(ident.StringID(common)).AnyTimes()
+		iter.EXPECT().Namespace().Return(ident.StringID(ns)).AnyTimes()
+		iter.EXPECT().Tags().Return(ident.EmptyTagIterator).AnyTimes()
+		iter.EXPECT().Next().Return(true)
+		dp := ts.Datapoint{TimestampNanos: xtime.ToUnixNano(at), Value: v}
+		iter.EXPECT().Current().Return(dp, xtime.Second, nil).AnyTimes()
+		iter.EXPECT().Next().Return(false).AnyTimes()
+		iter.EXPECT().Start().Return(at).AnyTimes()
+		iter.EXPECT().End().Return(at.Add(time.Minute)).AnyTimes()
+		iter.EXPECT().Err().Return(nil).AnyTimes()
+		iter.EXPECT().Close().AnyTimes()
+
+		iters := encoding.NewMockSeriesIterators(ctrl)
+		iters.EXPECT().Close().Return().Times(1)
+		iters.EXPECT().Len().Return(1).AnyTimes()
+		iters.EXPECT().Iters().Return([]encoding.SeriesIterator{iter})
+		return iters
+	}
+
+	coarseAttrs := storagemetadata.Attributes{
+		MetricsType: storagemetadata.AggregatedMetricsType,
+		Retention:   720 * time.Hour,
+		Resolution:  time.Minute,
+	}
+	fineAttrs := storagemetadata.Attributes{
+		MetricsType: storagemetadata.AggregatedMetricsType,
+		Retention:   48 * time.Hour,
+		Resolution:  10 * time.Second,
+	}
+
+	pools := generateIteratorPools(ctrl)
+	r := NewMultiFetchResult(NamespacesStitchQueryRange, pools,
+		MatchOptions{MatchType: matchType}, models.NewTagOptions())
+
+	meta := block.NewResultMetadata()
+	r.Add(generateStitchIterators("fine", start.Add(time.Hour), 2),
+		meta, fineAttrs, nil)
+	r.Add(generateStitchIterators("coarse", start, 1),
+		meta, coarseAttrs, nil)
+
+	result, attrs, err := r.FinalResultWithAttrs()
+	require.NoError(t, err)
+	require.Equal(t, []storagemetadata.Attributes{coarseAttrs}, attrs)
+
+	iters := result.seriesData.seriesIterators
+	require.Equal(t, 1, iters.Len())
+
+	iter := iters.Iters()[0]
+	assert.Equal(t, common, iter.ID().String())
+	assert.Equal(t, start, iter.Start())
+	assert.Equal(t, start.Add(time.Hour+time.Minute), iter.End())
+
+	var values []float64
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		values = append(values, dp.Value)
+	}
+
+	require.NoError(t, iter.Err())
+	assert.Equal(t, []float64{1, 2}, values)
+	assert.NoError(t, r.Close())
+}
+
 var exhaustTests = []struct {
 	name        string
 	exhaustives []bool
@@ -78,6 +78,16 @@ func (m *tagDedupeMap) add(
 		return nil
 	}
 
+	if m.fanout == NamespacesStitchQueryRange {
+		stitched, err := existing.stitch(series)
+		if err != nil {
+			return err
+		}
+
+		m.mapWrapper.set(tags, stitched)
+		return nil
+	}
+
 	var existsBetter bool
 	var existsEqual bool
 	switch m.fanout {
@@ -57,6 +57,10 @@ const (
 	// NamespaceCoversPartialQueryRange indicates the given namespace covers
 	// a partial query range.
 	NamespaceCoversPartialQueryRange
+	// NamespacesStitchQueryRange indicates each given namespace covers a
+	// separate part of the query range, so results for the same series are
+	// stitched together.
+	NamespacesStitchQueryRange
 )
 
 func (t QueryFanoutType) String() string {
@@ -65,6 +69,8 @@ func (t QueryFanoutType) String() string {
 		return "coversAllQueryRange"
 	case NamespaceCoversPartialQueryRange:
 		return "coversPartialQueryRange"
+	case NamespacesStitchQueryRange:
+		return "stitchQueryRange"
 	default:
 		return "unknown"
 	}
@@ -209,6 +209,67 @@ func (s *m3storage) FetchCompressed(
 	return result, accumulator.Close, nil
 }
 
+// resolveClusterNamespaceRangesForQuery returns the namespaces to read and
+// the part of the query range to read from each.
+func (s *m3storage) resolveClusterNamespaceRangesForQuery(
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+) ([]clusterNamespaceRange, consolidators.QueryFanoutType, error) {
+	now := s.nowFn()
+	if s.stitch(options) {
+		ranges, ok := resolveStitchedClusterNamespacesForQuery(now,
+			query.Start, query.End, s.clusters, options.FanoutOptions)
+		if ok {
+			return ranges, consolidators.NamespacesStitchQueryRange, nil
+		}
+	}
+
+	// NB(r): Since we don't use a single index we fan out to each
+	// cluster that can completely fulfill this range and then prefer the
+	// highest resolution (most fine grained) results.
+	// This needs to be optimized, however this is a start.
+	fanout, namespaces, err := resolveClusterNamespacesForQuery(
+		now,
+		query.Start,
+		query.End,
+		s.clusters,
+		options.FanoutOptions,
+		options.RestrictQueryOptions,
+	)
+	if err != nil {
+		return nil, fanout, err
+	}
+
+	ranges := make([]clusterNamespaceRange, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		ranges = append(ranges, clusterNamespaceRange{
+			namespace: namespace,
+			start:     query.Start,
+			end:       query.End,
+		})
+	}
+
+	return ranges, fanout, nil
+}
+
+// stitch returns whether results read from namespaces with different
+// storage policies should be stitched together for a fetch.
+func (s *m3storage) stitch(options *storage.FetchOptions) bool {
+	if options.RestrictQueryOptions.GetRestrictByType() != nil {
+		// Restricted to a single namespace.
+		return false
+	}
+
+	switch options.FanoutOptions.Stitch {
+	case storage.FanoutForceEnable:
+		return true
+	case storage.FanoutForceDisable:
+		return false
+	default:
+		return s.opts.StitchStoragePolicies()
+	}
+}
+
 // fetches compressed series, returning a MultiFetchResult accumulator
 func (s *m3storage) fetchCompressed(
 	ctx context.Context,
@@ -233,24 +294,14 @@ func (s *m3storage) fetchCompressed(
 		return nil, err
 	}
 
-	// NB(r): Since we don't use a single index we fan out to each
-	// cluster that can completely fulfill this range and then prefer the
-	// highest resolution (most fine grained) results.
-	// This needs to be optimized, however this is a start.
-	fanout, namespaces, err := resolveClusterNamespacesForQuery(
-		s.nowFn(),
-		query.Start,
-		query.End,
-		s.clusters,
-		options.FanoutOptions,
-		options.RestrictQueryOptions,
-	)
+	ranges, fanout, err := s.resolveClusterNamespaceRangesForQuery(query, options)
 	if err != nil {
 		return nil, err
 	}
 
 	if s.logger.Core().Enabled(zapcore.DebugLevel) {
-		for _, n := range namespaces {
+		for _, r := range ranges {
+			n := r.namespace
 			// NB(r): Need to perform log on inner loop, cannot reuse a
 			// checked entry returned from logger.Check(...).
 			// Will see: "Unsafe CheckedEntry re-use near Entry ..." otherwise.
@@ -262,8 +313,8 @@ func (s *m3storage) fetchCompressed(
 
 			debugLog.Write(zap.String("query", query.Raw),
 				zap.String("m3query", m3query.String()),
-				zap.Time("start", query.Start),
-				zap.Time("end", query.End),
+				zap.Time("start", r.start),
+				zap.Time("end", r.end),
 				zap.String("fanoutType", fanout.String()),
 				zap.String("namespace", n.NamespaceID().String()),
 				zap.String("type", n.Options().Attributes().MetricsType.String()),
@@ -273,24 +324,23 @@ func (s *m3storage) fetchCompressed(
 		}
 	}
 
-	var (
-		opts = storage.FetchOptionsToM3Options(options, query)
-		wg   sync.WaitGroup
-	)
-	if len(namespaces) == 0 {
+	if len(ranges) == 0 {
 		return nil, errNoNamespacesConfigured
 	}
 
-	pools, err := namespaces[0].Session().IteratorPools()
+	pools, err := ranges[0].namespace.Session().IteratorPools()
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve iterator pools: %v", err)
 	}
 
-	matchOpts := s.opts.SeriesConsolidationMatchOptions()
-	tagOpts := s.opts.TagOptions()
-	result := consolidators.NewMultiFetchResult(fanout, pools, matchOpts, tagOpts)
-	for _, namespace := range namespaces {
-		namespace := namespace // Capture var
+	var (
+		matchOpts = s.opts.SeriesConsolidationMatchOptions()
+		tagOpts   = s.opts.TagOptions()
+		result    = consolidators.NewMultiFetchResult(fanout, pools, matchOpts, tagOpts)
+		wg        sync.WaitGroup
+	)
+	for _, r := range ranges {
+		r := r // Capture var
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -298,8 +348,12 @@ func (s *m3storage) fetchCompressed(
 				tracepoint.FetchCompressedFetchTagged)
 			defer span.Finish()
 
-			session := namespace.Session()
-			namespaceID := namespace.NamespaceID()
+			rangeQuery := *query
+			rangeQuery.Start, rangeQuery.End = r.start, r.end
+			opts := storage.FetchOptionsToM3Options(options, &rangeQuery)
+
+			session := r.namespace.Session()
+			namespaceID := r.namespace.NamespaceID()
 			iters, metadata, err := session.FetchTagged(namespaceID, m3query, opts)
 			if err == nil && sampled {
 				span.LogFields(
@@ -315,7 +369,7 @@ func (s *m3storage) fetchCompressed(
 			blockMeta.Exhaustive = metadata.Exhaustive
 			// Ignore error from getting iterator pools, since operation
 			// will not be dramatically impacted if pools is nil
-			result.Add(iters, blockMeta, namespace.Options().Attributes(), err)
+			result.Add(iters, blockMeta, r.namespace.Options().Attributes(), err)
 		}()
 	}
 
@@ -797,3 +797,31 @@ func TestInvalidBlockTypes(t *testing.T) {
 	_, err = s.FetchBlocks(context.TODO(), nil, fetchOpts)
 	assert.Error(t, err)
 }
+
+func TestStitchStoragePolicies(t *testing.T) {
+	for _, stitchDefault := range []bool{false, true} {
+		opts := m3db.NewOptions().SetStitchStoragePolicies(stitchDefault)
+		s, err := NewStorage(nil, opts, instrument.NewOptions())
+		require.NoError(t, err)
+		store, ok := s.(*m3storage)
+		require.True(t, ok)
+
+		fetchOpts := storage.NewFetchOptions()
+		assert.Equal(t, stitchDefault, store.stitch(fetchOpts))
+
+		fetchOpts.FanoutOptions.Stitch = storage.FanoutForceEnable
+		assert.True(t, store.stitch(fetchOpts))
+
+		fetchOpts.FanoutOptions.Stitch = storage.FanoutForceDisable
+		assert.False(t, store.stitch(fetchOpts))
+
+		// NB: restricting to a single namespace disables stitching.
+		fetchOpts.FanoutOptions.Stitch = storage.FanoutForceEnable
+		fetchOpts.RestrictQueryOptions = &storage.RestrictQueryOptions{
+			RestrictByType: &storage.RestrictByType{
+				MetricsType: storagemetadata.UnaggregatedMetricsType,
+			},
+		}
+		assert.False(t, store.stitch(fetchOpts))
+	}
+}
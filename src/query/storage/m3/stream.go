@@ -0,0 +1,199 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package m3
+
+import (
+	"context"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/query/block"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// FetchStreamHandler handles the series of a streamed fetch.
+type FetchStreamHandler interface {
+	// OnSeries is called with the ID and tags of each series matching the
+	// query before the values of any series are fetched. The ID and tags are
+	// only valid for the duration of the call.
+	OnSeries(id ident.ID, tags ident.TagIterator) error
+
+	// OnValues is called with each batch of series iterators as they are
+	// fetched. The iterators are only valid for the duration of the call.
+	OnValues(iters []encoding.SeriesIterator) error
+}
+
+// StreamingQuerier is a Querier that can stream the series of a fetch as
+// they are fetched rather than buffering the entire result.
+type StreamingQuerier interface {
+	Querier
+
+	// FetchCompressedStream fetches timeseries data based on a query,
+	// streaming the series to the handler in batches of up to batchSize.
+	FetchCompressedStream(
+		ctx context.Context,
+		query *storage.FetchQuery,
+		options *storage.FetchOptions,
+		batchSize int,
+		handler FetchStreamHandler,
+	) (block.ResultMetadata, error)
+}
+
+var _ StreamingQuerier = (*m3storage)(nil)
+
+// FetchCompressedStream fetches the IDs and tags of the series matching the
+// query and then their values in batches, so only a batch of values is held
+// in memory at a time. Queries resolving to more than one namespace, which
+// need the results of every namespace to be consolidated, fall back to
+// streaming the consolidated result.
+func (s *m3storage) FetchCompressedStream(
+	ctx context.Context,
+	query *storage.FetchQuery,
+	options *storage.FetchOptions,
+	batchSize int,
+	handler FetchStreamHandler,
+) (block.ResultMetadata, error) {
+	meta := block.NewResultMetadata()
+	if err := options.BlockType.Validate(); err != nil {
+		// This is an invariant error; should not be able to get to here.
+		return meta, instrument.InvariantErrorf("invalid block type on "+
+			"fetch, got: %v with error %v", options.BlockType, err)
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	ranges, _, err := s.resolveClusterNamespaceRangesForQuery(query, options)
+	if err != nil {
+		return meta, err
+	}
+	if len(ranges) != 1 {
+		result, cleanup, err := s.FetchCompressed(ctx, query, options)
+		defer cleanup()
+		if err != nil {
+			return result.Metadata, err
+		}
+		return result.Metadata, StreamSeriesIterators(ctx,
+			result.SeriesIterators(), batchSize, handler)
+	}
+
+	m3query, err := storage.FetchQueryToM3Query(query, options)
+	if err != nil {
+		return meta, err
+	}
+
+	var (
+		r           = ranges[0]
+		session     = r.namespace.Session()
+		namespaceID = r.namespace.NamespaceID()
+		rangeQuery  = *query
+	)
+	rangeQuery.Start, rangeQuery.End = r.start, r.end
+	opts := storage.FetchOptionsToM3Options(options, &rangeQuery)
+	idsIter, fetchMeta, err := session.FetchTaggedIDs(namespaceID, m3query, opts)
+	if err != nil {
+		return meta, err
+	}
+	defer idsIter.Finalize()
+
+	meta.Exhaustive = fetchMeta.Exhaustive
+	if options.IncludeResolution {
+		meta.Resolutions = []int64{int64(r.namespace.Options().Attributes().Resolution)}
+	}
+
+	ids := make([]ident.ID, 0, idsIter.Remaining())
+	for idsIter.Next() {
+		_, id, tags := idsIter.Current()
+		if err := handler.OnSeries(id, tags); err != nil {
+			return meta, err
+		}
+		// NB: the ID is only valid until the next series.
+		ids = append(ids, ident.BytesID(append([]byte(nil), id.Bytes()...)))
+	}
+	if err := idsIter.Err(); err != nil {
+		return meta, err
+	}
+
+	for start := 0; start < len(ids); start += batchSize {
+		select {
+		case <-ctx.Done():
+			return meta, ctx.Err()
+		default:
+		}
+
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		iters, err := session.FetchIDs(namespaceID,
+			ident.NewIDSliceIterator(ids[start:end]), r.start, r.end)
+		if err != nil {
+			return meta, err
+		}
+		err = handler.OnValues(iters.Iters())
+		iters.Close()
+		if err != nil {
+			return meta, err
+		}
+	}
+
+	return meta, nil
+}
+
+// StreamSeriesIterators streams already fetched series iterators to the
+// handler, first the ID and tags of every series and then their values in
+// batches of up to batchSize. The iterators are not closed.
+func StreamSeriesIterators(
+	ctx context.Context,
+	iters []encoding.SeriesIterator,
+	batchSize int,
+	handler FetchStreamHandler,
+) error {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for _, iter := range iters {
+		tags := iter.Tags().Duplicate()
+		err := handler.OnSeries(iter.ID(), tags)
+		tags.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	for start := 0; start < len(iters); start += batchSize {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		end := start + batchSize
+		if end > len(iters) {
+			end = len(iters)
+		}
+		if err := handler.OnValues(iters[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
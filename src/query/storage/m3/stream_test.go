@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package m3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/x/ident"
+	xtest "github.com/m3db/m3/src/x/test"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStreamHandler struct {
+	series  []string
+	batches [][]string
+}
+
+func (h *testStreamHandler) OnSeries(id ident.ID, tags ident.TagIterator) error {
+	h.series = append(h.series, id.String())
+	return nil
+}
+
+func (h *testStreamHandler) OnValues(iters []encoding.SeriesIterator) error {
+	batch := make([]string, 0, len(iters))
+	for _, iter := range iters {
+		batch = append(batch, iter.ID().String())
+	}
+	h.batches = append(h.batches, batch)
+	return nil
+}
+
+func newTestStreamSeriesIterators(
+	ctrl *gomock.Controller,
+	ids ...string,
+) encoding.SeriesIterators {
+	iters := make([]encoding.SeriesIterator, 0, len(ids))
+	for _, id := range ids {
+		iter := encoding.NewMockSeriesIterator(ctrl)
+		iter.EXPECT().ID().Return(ident.StringID(id)).AnyTimes()
+		iters = append(iters, iter)
+	}
+	result := encoding.NewMockSeriesIterators(ctrl)
+	result.EXPECT().Iters().Return(iters)
+	result.EXPECT().Close()
+	return result
+}
+
+func TestFetchCompressedStreamFetchesValuesInBatches(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	store, sessions := setup(t, ctrl)
+	querier, ok := store.(StreamingQuerier)
+	require.True(t, ok)
+
+	ids := []string{"foo", "bar", "baz"}
+	idsIter := client.NewMockTaggedIDsIterator(ctrl)
+	idsIter.EXPECT().Remaining().Return(len(ids))
+	calls := make([]*gomock.Call, 0, 2*len(ids)+3)
+	for _, id := range ids {
+		calls = append(calls,
+			idsIter.EXPECT().Next().Return(true),
+			idsIter.EXPECT().Current().Return(ident.StringID("metrics_unaggregated"),
+				ident.StringID(id), ident.EmptyTagIterator))
+	}
+	calls = append(calls,
+		idsIter.EXPECT().Next().Return(false),
+		idsIter.EXPECT().Err().Return(nil),
+		idsIter.EXPECT().Finalize())
+	gomock.InOrder(calls...)
+
+	session := sessions.unaggregated1MonthRetention
+	session.EXPECT().FetchTaggedIDs(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(idsIter, testFetchResponseMetadata, nil)
+	gomock.InOrder(
+		session.EXPECT().FetchIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(newTestStreamSeriesIterators(ctrl, "foo", "bar"), nil),
+		session.EXPECT().FetchIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(newTestStreamSeriesIterators(ctrl, "baz"), nil),
+	)
+
+	handler := &testStreamHandler{}
+	meta, err := querier.FetchCompressedStream(context.TODO(), newFetchReq(),
+		buildFetchOpts(), 2, handler)
+	require.NoError(t, err)
+	assert.True(t, meta.Exhaustive)
+	assert.Equal(t, ids, handler.series)
+	assert.Equal(t, [][]string{{"foo", "bar"}, {"baz"}}, handler.batches)
+}
+
+func TestStreamSeriesIteratorsSendsTagsBeforeValues(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+
+	var iters []encoding.SeriesIterator
+	for _, id := range []string{"foo", "bar", "baz"} {
+		iter := encoding.NewMockSeriesIterator(ctrl)
+		iter.EXPECT().ID().Return(ident.StringID(id)).AnyTimes()
+		iter.EXPECT().Tags().Return(ident.EmptyTagIterator)
+		iters = append(iters, iter)
+	}
+
+	handler := &testStreamHandler{}
+	require.NoError(t, StreamSeriesIterators(context.TODO(), iters, 2, handler))
+	assert.Equal(t, []string{"foo", "bar", "baz"}, handler.series)
+	assert.Equal(t, [][]string{{"foo", "bar"}, {"baz"}}, handler.batches)
+}
@@ -43,11 +43,16 @@ func iteratorToPromResult(
 ) (*prompb.TimeSeries, error) {
 	samples := make([]prompb.Sample, 0, initRawFetchAllocSize)
 	for iter.Next() {
-		dp, _, _ := iter.Current()
-		samples = append(samples, prompb.Sample{
+		dp, _, annotation := iter.Current()
+		sample := prompb.Sample{
 			Timestamp: TimeToPromTimestamp(dp.Timestamp),
 			Value:     dp.Value,
-		})
+		}
+		if len(annotation) > 0 {
+			// NB: the annotation is only valid until the next call to Next.
+			sample.Annotation = append([]byte(nil), annotation...)
+		}
+		samples = append(samples, sample)
 	}
 
 	if err := iter.Err(); err != nil {
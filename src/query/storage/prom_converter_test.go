@@ -242,7 +242,7 @@ func TestDecodeIteratorsWithEmptySeries(t *testing.T) {
 		if hasVal {
 			iter.EXPECT().Next().Return(true)
 			dp := dts.Datapoint{Timestamp: now, Value: 1}
-			iter.EXPECT().Current().Return(dp, xtime.Second, nil)
+			iter.EXPECT().Current().Return(dp, xtime.Second, dts.Annotation(val))
 		}
 
 		iter.EXPECT().Err().Return(nil)
@@ -287,6 +287,7 @@ func TestDecodeIteratorsWithEmptySeries(t *testing.T) {
 			s := samples[0]
 			assert.Equal(t, float64(1), s.GetValue())
 			assert.Equal(t, now.UnixNano()/int64(time.Millisecond), s.GetTimestamp())
+			assert.Equal(t, exSeriesTags[i], string(s.GetAnnotation()))
 		}
 	}
 
@@ -154,6 +154,11 @@ type FanoutOptions struct {
 	// FanoutAggregatedOptimized describes the fanout options for the
 	// aggregated namespace optimization.
 	FanoutAggregatedOptimized FanoutOption
+	// Stitch describes the fanout options for stitching together results
+	// read from namespaces with different storage policies, reading recent
+	// parts of the query range from the most granular namespace and older
+	// parts from namespaces with longer retention.
+	Stitch FanoutOption
 }
 
 // FanoutOption describes the fanout option.
@@ -61,6 +61,7 @@ type encodedBlockOptions struct {
 	readWorkerPools               xsync.PooledWorkerPool
 	writeWorkerPools              xsync.PooledWorkerPool
 	queryConsolidatorMatchOptions queryconsolidator.MatchOptions
+	stitchStoragePolicies         bool
 	batchingFn                    IteratorBatchingFn
 	adminOptions                  []client.CustomAdminOption
 	instrumented                  bool
@@ -207,6 +208,16 @@ func (o *encodedBlockOptions) SeriesConsolidationMatchOptions() queryconsolidato
 	return o.queryConsolidatorMatchOptions
 }
 
+func (o *encodedBlockOptions) SetStitchStoragePolicies(value bool) Options {
+	opts := *o
+	opts.stitchStoragePolicies = value
+	return &opts
+}
+
+func (o *encodedBlockOptions) StitchStoragePolicies() bool {
+	return o.stitchStoragePolicies
+}
+
 func (o *encodedBlockOptions) SetIteratorBatchingFn(fn IteratorBatchingFn) Options {
 	opts := *o
 	opts.batchingFn = fn
@@ -80,6 +80,12 @@ type Options interface {
 	SetSeriesConsolidationMatchOptions(value queryconsolidator.MatchOptions) Options
 	// SetSeriesConsolidationMatchOptions sets series consolidation options.
 	SeriesConsolidationMatchOptions() queryconsolidator.MatchOptions
+	// SetStitchStoragePolicies sets whether results read from namespaces with
+	// different storage policies are stitched together by default.
+	SetStitchStoragePolicies(value bool) Options
+	// StitchStoragePolicies returns whether results read from namespaces with
+	// different storage policies are stitched together by default.
+	StitchStoragePolicies() bool
 	// SetIteratorBatchingFn sets the batching function for the converter.
 	SetIteratorBatchingFn(IteratorBatchingFn) Options
 	// IteratorBatchingFn returns the batching function for the converter.
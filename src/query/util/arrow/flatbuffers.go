@@ -0,0 +1,231 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package arrow
+
+import (
+	"encoding/binary"
+)
+
+// builder builds flatbuffers back to front, as the reference flatbuffers
+// implementation does, with just enough support to encode Arrow IPC
+// metadata.
+type builder struct {
+	bytes    []byte
+	head     int
+	minAlign int
+	vtable   []int
+	objEnd   int
+}
+
+func newBuilder(size int) *builder {
+	return &builder{
+		bytes:    make([]byte, size),
+		head:     size,
+		minAlign: 1,
+	}
+}
+
+// offset returns the offset of the head from the end of the buffer, which
+// is how objects already written are referred to.
+func (b *builder) offset() int {
+	return len(b.bytes) - b.head
+}
+
+func (b *builder) grow() {
+	size := len(b.bytes)
+	bytes := make([]byte, 2*size)
+	copy(bytes[size:], b.bytes)
+	b.bytes = bytes
+	b.head += size
+}
+
+// prep aligns the head so that size bytes can be written aligned after
+// additional bytes are written.
+func (b *builder) prep(size, additional int) {
+	if size > b.minAlign {
+		b.minAlign = size
+	}
+
+	pad := (^(b.offset() + additional) + 1) & (size - 1)
+	for b.head < pad+size+additional {
+		b.grow()
+	}
+
+	for i := 0; i < pad; i++ {
+		b.head--
+		b.bytes[b.head] = 0
+	}
+}
+
+func (b *builder) placeUint8(v uint8) {
+	b.head--
+	b.bytes[b.head] = v
+}
+
+func (b *builder) placeUint16(v uint16) {
+	b.head -= 2
+	binary.LittleEndian.PutUint16(b.bytes[b.head:], v)
+}
+
+func (b *builder) placeUint32(v uint32) {
+	b.head -= 4
+	binary.LittleEndian.PutUint32(b.bytes[b.head:], v)
+}
+
+func (b *builder) placeUint64(v uint64) {
+	b.head -= 8
+	binary.LittleEndian.PutUint64(b.bytes[b.head:], v)
+}
+
+func (b *builder) prependUint8(v uint8) {
+	b.prep(1, 0)
+	b.placeUint8(v)
+}
+
+func (b *builder) prependInt16(v int16) {
+	b.prep(2, 0)
+	b.placeUint16(uint16(v))
+}
+
+func (b *builder) prependInt64(v int64) {
+	b.prep(8, 0)
+	b.placeUint64(uint64(v))
+}
+
+// prependOffset prepends a reference to an object already written.
+func (b *builder) prependOffset(off int) {
+	b.prep(4, 0)
+	b.placeUint32(uint32(b.offset() - off + 4))
+}
+
+func (b *builder) createString(s string) int {
+	b.prep(4, len(s)+1)
+	b.placeUint8(0)
+	b.head -= len(s)
+	copy(b.bytes[b.head:], s)
+	b.placeUint32(uint32(len(s)))
+	return b.offset()
+}
+
+func (b *builder) createOffsetVector(offsets []int) int {
+	b.prep(4, 4*len(offsets))
+	for i := len(offsets) - 1; i >= 0; i-- {
+		b.prependOffset(offsets[i])
+	}
+
+	b.placeUint32(uint32(len(offsets)))
+	return b.offset()
+}
+
+// createStructVector creates a vector of structs of two int64 fields, such
+// as the field nodes and buffers of a record batch.
+func (b *builder) createStructVector(structs [][2]int64) int {
+	b.prep(4, 16*len(structs))
+	b.prep(8, 16*len(structs))
+	for i := len(structs) - 1; i >= 0; i-- {
+		b.prep(8, 16)
+		b.placeUint64(uint64(structs[i][1]))
+		b.placeUint64(uint64(structs[i][0]))
+	}
+
+	b.placeUint32(uint32(len(structs)))
+	return b.offset()
+}
+
+func (b *builder) startTable(numFields int) {
+	b.vtable = make([]int, numFields)
+	b.objEnd = b.offset()
+}
+
+func (b *builder) slot(field int) {
+	b.vtable[field] = b.offset()
+}
+
+func (b *builder) addBool(field int, v bool) {
+	var u uint8
+	if v {
+		u = 1
+	}
+
+	b.prependUint8(u)
+	b.slot(field)
+}
+
+func (b *builder) addUint8(field int, v uint8) {
+	b.prependUint8(v)
+	b.slot(field)
+}
+
+func (b *builder) addInt16(field int, v int16) {
+	b.prependInt16(v)
+	b.slot(field)
+}
+
+func (b *builder) addInt64(field int, v int64) {
+	b.prependInt64(v)
+	b.slot(field)
+}
+
+func (b *builder) addOffset(field int, off int) {
+	b.prependOffset(off)
+	b.slot(field)
+}
+
+func (b *builder) endTable() int {
+	// NB: placeholder for the offset to the vtable, written once the
+	// vtable is.
+	b.prep(4, 0)
+	b.placeUint32(0)
+	objOffset := b.offset()
+
+	numFields := len(b.vtable)
+	for numFields > 0 && b.vtable[numFields-1] == 0 {
+		numFields--
+	}
+
+	for i := numFields - 1; i >= 0; i-- {
+		var off uint16
+		if b.vtable[i] != 0 {
+			off = uint16(objOffset - b.vtable[i])
+		}
+
+		b.prep(2, 0)
+		b.placeUint16(off)
+	}
+
+	b.prep(2, 0)
+	b.placeUint16(uint16(objOffset - b.objEnd))
+	b.prep(2, 0)
+	b.placeUint16(uint16((numFields + 2) * 2))
+
+	vtableOffset := b.offset()
+	binary.LittleEndian.PutUint32(b.bytes[len(b.bytes)-objOffset:],
+		uint32(int32(vtableOffset-objOffset)))
+	b.vtable = nil
+	return objOffset
+}
+
+// finish writes the reference to the root table and returns the buffer.
+func (b *builder) finish(root int) []byte {
+	b.prep(b.minAlign, 4)
+	b.prependOffset(root)
+	return b.bytes[b.head:]
+}
@@ -0,0 +1,381 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package arrow writes tabular results in the Apache Arrow IPC streaming
+// format, supporting the few column types needed to export time series.
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	continuationMarker = 0xFFFFFFFF
+	alignment          = 8
+
+	metadataVersionV5 = 4
+
+	messageHeaderSchema      = 1
+	messageHeaderRecordBatch = 3
+
+	typeFloatingPoint = 3
+	typeUtf8          = 5
+	typeTimestamp     = 10
+
+	precisionDouble     = 2
+	timeUnitMillisecond = 1
+	timezoneUTC         = "UTC"
+)
+
+var errWriterClosed = errors.New("arrow stream writer closed")
+
+// Type is the type of the values of a field.
+type Type int
+
+const (
+	// TimestampType is a timestamp with millisecond precision in UTC.
+	TimestampType Type = iota
+	// Float64Type is a double precision floating point number.
+	Float64Type
+	// StringType is a UTF-8 string.
+	StringType
+)
+
+// Field is a named column of a schema.
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool
+}
+
+type column struct {
+	typ       Type
+	length    int
+	nullCount int
+	validity  []byte
+	values    []byte
+	offsets   []byte
+}
+
+func (c *column) reset() {
+	c.length = 0
+	c.nullCount = 0
+	c.validity = c.validity[:0]
+	c.values = c.values[:0]
+	c.offsets = c.offsets[:0]
+}
+
+func (c *column) appendValid(valid bool) {
+	if c.length%8 == 0 {
+		c.validity = append(c.validity, 0)
+	}
+
+	if valid {
+		c.validity[c.length/8] |= 1 << uint(c.length%8)
+	} else {
+		c.nullCount++
+	}
+
+	c.length++
+}
+
+func (c *column) appendUint64(v uint64) {
+	c.values = append(c.values, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint64(c.values[len(c.values)-8:], v)
+}
+
+func (c *column) appendOffset() {
+	if len(c.offsets) == 0 {
+		c.offsets = append(c.offsets, 0, 0, 0, 0)
+	}
+
+	c.offsets = append(c.offsets, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(c.offsets[len(c.offsets)-4:],
+		uint32(len(c.values)))
+}
+
+// buffers returns the buffers of the column in the order of the Arrow
+// columnar format.
+func (c *column) buffers() [][]byte {
+	validity := c.validity
+	if c.nullCount == 0 {
+		// NB: the validity buffer may be omitted if there are no nulls.
+		validity = nil
+	}
+
+	if c.typ != StringType {
+		return [][]byte{validity, c.values}
+	}
+
+	offsets := c.offsets
+	if len(offsets) == 0 {
+		offsets = make([]byte, 4)
+	}
+
+	return [][]byte{validity, offsets, c.values}
+}
+
+// RecordBatch is a batch of rows of a schema, built by appending a value to
+// each column of every row.
+type RecordBatch struct {
+	columns []*column
+}
+
+// NewRecordBatch returns a new record batch for a schema.
+func NewRecordBatch(fields []Field) *RecordBatch {
+	columns := make([]*column, 0, len(fields))
+	for _, f := range fields {
+		columns = append(columns, &column{typ: f.Type})
+	}
+
+	return &RecordBatch{columns: columns}
+}
+
+// AppendTimestamp appends a timestamp to a timestamp column.
+func (r *RecordBatch) AppendTimestamp(col int, t time.Time) {
+	c := r.columns[col]
+	c.appendValid(true)
+	c.appendUint64(uint64(t.UnixNano() / int64(time.Millisecond)))
+}
+
+// AppendFloat64 appends a value to a float64 column.
+func (r *RecordBatch) AppendFloat64(col int, v float64) {
+	c := r.columns[col]
+	c.appendValid(true)
+	c.appendUint64(math.Float64bits(v))
+}
+
+// AppendString appends a value to a string column.
+func (r *RecordBatch) AppendString(col int, v string) {
+	c := r.columns[col]
+	c.appendValid(true)
+	c.values = append(c.values, v...)
+	c.appendOffset()
+}
+
+// AppendNull appends a null to a column.
+func (r *RecordBatch) AppendNull(col int) {
+	c := r.columns[col]
+	c.appendValid(false)
+	if c.typ == StringType {
+		c.appendOffset()
+	} else {
+		c.appendUint64(0)
+	}
+}
+
+// Len returns the number of rows in the batch.
+func (r *RecordBatch) Len() int {
+	if len(r.columns) == 0 {
+		return 0
+	}
+
+	return r.columns[0].length
+}
+
+// Reset removes all rows from the batch so it can be reused.
+func (r *RecordBatch) Reset() {
+	for _, c := range r.columns {
+		c.reset()
+	}
+}
+
+// StreamWriter writes record batches in the Arrow IPC streaming format.
+type StreamWriter struct {
+	w           io.Writer
+	fields      []Field
+	wroteSchema bool
+	closed      bool
+}
+
+// NewStreamWriter returns a new stream writer for a schema, the schema is
+// written before the first record batch.
+func NewStreamWriter(w io.Writer, fields []Field) *StreamWriter {
+	return &StreamWriter{
+		w:      w,
+		fields: fields,
+	}
+}
+
+// Write writes a record batch.
+func (w *StreamWriter) Write(batch *RecordBatch) error {
+	if err := w.writeSchema(); err != nil {
+		return err
+	}
+
+	var (
+		length  = int64(batch.Len())
+		nodes   = make([][2]int64, 0, len(batch.columns))
+		buffers = make([][2]int64, 0, 3*len(batch.columns))
+		body    [][]byte
+		offset  int64
+	)
+	for _, c := range batch.columns {
+		nodes = append(nodes, [2]int64{int64(c.length), int64(c.nullCount)})
+		for _, buf := range c.buffers() {
+			size := int64(len(buf))
+			buffers = append(buffers, [2]int64{offset, size})
+			body = append(body, buf)
+			offset += padded(size)
+		}
+	}
+
+	metadata := encodeMessage(messageHeaderRecordBatch, offset,
+		func(b *builder) int {
+			buffersVector := b.createStructVector(buffers)
+			nodesVector := b.createStructVector(nodes)
+			b.startTable(4)
+			b.addInt64(0, length)
+			b.addOffset(2, buffersVector)
+			b.addOffset(1, nodesVector)
+			return b.endTable()
+		})
+
+	return w.writeMessage(metadata, body)
+}
+
+// Close writes the end of the stream, writing the schema first if no
+// record batches were written.
+func (w *StreamWriter) Close() error {
+	if err := w.writeSchema(); err != nil {
+		return err
+	}
+
+	w.closed = true
+	var eos [8]byte
+	binary.LittleEndian.PutUint32(eos[:], continuationMarker)
+	_, err := w.w.Write(eos[:])
+	return err
+}
+
+func (w *StreamWriter) writeSchema() error {
+	if w.closed {
+		return errWriterClosed
+	}
+
+	if w.wroteSchema {
+		return nil
+	}
+
+	w.wroteSchema = true
+	metadata := encodeMessage(messageHeaderSchema, 0, w.encodeSchema)
+	return w.writeMessage(metadata, nil)
+}
+
+func (w *StreamWriter) encodeSchema(b *builder) int {
+	fields := make([]int, 0, len(w.fields))
+	for _, f := range w.fields {
+		name := b.createString(f.Name)
+
+		var (
+			typeType uint8
+			typ      int
+		)
+		switch f.Type {
+		case TimestampType:
+			timezone := b.createString(timezoneUTC)
+			b.startTable(2)
+			b.addOffset(1, timezone)
+			b.addInt16(0, timeUnitMillisecond)
+			typeType, typ = typeTimestamp, b.endTable()
+		case Float64Type:
+			b.startTable(1)
+			b.addInt16(0, precisionDouble)
+			typeType, typ = typeFloatingPoint, b.endTable()
+		default:
+			b.startTable(0)
+			typeType, typ = typeUtf8, b.endTable()
+		}
+
+		// NB: readers expect the children of a field to be set, even for
+		// types without children.
+		children := b.createOffsetVector(nil)
+		b.startTable(7)
+		b.addOffset(5, children)
+		b.addOffset(3, typ)
+		b.addOffset(0, name)
+		b.addUint8(2, typeType)
+		b.addBool(1, f.Nullable)
+		fields = append(fields, b.endTable())
+	}
+
+	fieldsVector := b.createOffsetVector(fields)
+	b.startTable(4)
+	b.addOffset(1, fieldsVector)
+	// NB: little endian.
+	b.addInt16(0, 0)
+	return b.endTable()
+}
+
+func (w *StreamWriter) writeMessage(metadata []byte, body [][]byte) error {
+	var prefix [8]byte
+	size := padded(int64(len(metadata)))
+	binary.LittleEndian.PutUint32(prefix[:4], continuationMarker)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(size))
+	if _, err := w.w.Write(prefix[:]); err != nil {
+		return err
+	}
+
+	if err := w.writePadded(metadata); err != nil {
+		return err
+	}
+
+	for _, buf := range body {
+		if err := w.writePadded(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *StreamWriter) writePadded(buf []byte) error {
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+
+	var padding [alignment]byte
+	pad := padded(int64(len(buf))) - int64(len(buf))
+	_, err := w.w.Write(padding[:pad])
+	return err
+}
+
+func encodeMessage(
+	headerType uint8,
+	bodyLength int64,
+	header func(b *builder) int,
+) []byte {
+	b := newBuilder(1024)
+	h := header(b)
+	b.startTable(5)
+	b.addInt64(3, bodyLength)
+	b.addOffset(2, h)
+	b.addUint8(1, headerType)
+	b.addInt16(0, metadataVersionV5)
+	return b.finish(b.endTable())
+}
+
+func padded(size int64) int64 {
+	return (size + alignment - 1) / alignment * alignment
+}
@@ -0,0 +1,263 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// table reads a flatbuffer table for verifying written messages.
+type table struct {
+	buf []byte
+	pos int
+}
+
+func rootTable(buf []byte) table {
+	return table{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+}
+
+func (t table) offset(slot int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	size := int(binary.LittleEndian.Uint16(t.buf[vtable:]))
+	if at := 4 + 2*slot; at < size {
+		return int(binary.LittleEndian.Uint16(t.buf[vtable+at:]))
+	}
+
+	return 0
+}
+
+func (t table) has(slot int) bool {
+	return t.offset(slot) != 0
+}
+
+func (t table) uint8(slot int) uint8 {
+	if o := t.offset(slot); o != 0 {
+		return t.buf[t.pos+o]
+	}
+
+	return 0
+}
+
+func (t table) int16(slot int) int16 {
+	if o := t.offset(slot); o != 0 {
+		return int16(binary.LittleEndian.Uint16(t.buf[t.pos+o:]))
+	}
+
+	return 0
+}
+
+func (t table) int64(slot int) int64 {
+	if o := t.offset(slot); o != 0 {
+		return int64(binary.LittleEndian.Uint64(t.buf[t.pos+o:]))
+	}
+
+	return 0
+}
+
+func (t table) indirect(slot int) int {
+	at := t.pos + t.offset(slot)
+	return at + int(binary.LittleEndian.Uint32(t.buf[at:]))
+}
+
+func (t table) table(slot int) table {
+	return table{buf: t.buf, pos: t.indirect(slot)}
+}
+
+func (t table) string(slot int) string {
+	at := t.indirect(slot)
+	n := int(binary.LittleEndian.Uint32(t.buf[at:]))
+	return string(t.buf[at+4 : at+4+n])
+}
+
+func (t table) vector(slot int) (int, int) {
+	at := t.indirect(slot)
+	return at + 4, int(binary.LittleEndian.Uint32(t.buf[at:]))
+}
+
+func (t table) tables(slot int) []table {
+	start, n := t.vector(slot)
+	tables := make([]table, 0, n)
+	for i := 0; i < n; i++ {
+		at := start + 4*i
+		tables = append(tables, table{
+			buf: t.buf,
+			pos: at + int(binary.LittleEndian.Uint32(t.buf[at:])),
+		})
+	}
+
+	return tables
+}
+
+func (t table) structs(slot int) [][2]int64 {
+	start, n := t.vector(slot)
+	structs := make([][2]int64, 0, n)
+	for i := 0; i < n; i++ {
+		at := start + 16*i
+		structs = append(structs, [2]int64{
+			int64(binary.LittleEndian.Uint64(t.buf[at:])),
+			int64(binary.LittleEndian.Uint64(t.buf[at+8:])),
+		})
+	}
+
+	return structs
+}
+
+func readMessage(t *testing.T, r *bytes.Reader) (table, []byte, bool) {
+	var prefix [8]byte
+	_, err := r.Read(prefix[:])
+	require.NoError(t, err)
+	require.Equal(t, uint32(continuationMarker),
+		binary.LittleEndian.Uint32(prefix[:4]))
+
+	size := int(binary.LittleEndian.Uint32(prefix[4:]))
+	if size == 0 {
+		return table{}, nil, false
+	}
+
+	require.Equal(t, 0, (8+size)%alignment)
+	metadata := make([]byte, size)
+	_, err = r.Read(metadata)
+	require.NoError(t, err)
+
+	message := rootTable(metadata)
+	assert.Equal(t, int16(metadataVersionV5), message.int16(0))
+
+	body := make([]byte, message.int64(3))
+	if len(body) > 0 {
+		_, err = r.Read(body)
+		require.NoError(t, err)
+	}
+
+	return message, body, true
+}
+
+func TestStreamWriter(t *testing.T) {
+	fields := []Field{
+		{Name: "timestamp", Type: TimestampType},
+		{Name: "value", Type: Float64Type},
+		{Name: "name", Type: StringType, Nullable: true},
+	}
+
+	now := time.Unix(1600000000, 0)
+	batch := NewRecordBatch(fields)
+	for i, name := range []string{"foo", "", "quail"} {
+		batch.AppendTimestamp(0, now.Add(time.Duration(i)*time.Second))
+		batch.AppendFloat64(1, float64(i)+0.5)
+		if name == "" {
+			batch.AppendNull(2)
+		} else {
+			batch.AppendString(2, name)
+		}
+	}
+
+	require.Equal(t, 3, batch.Len())
+
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf, fields)
+	require.NoError(t, w.Write(batch))
+	require.NoError(t, w.Close())
+	require.Error(t, w.Write(batch))
+
+	r := bytes.NewReader(buf.Bytes())
+	message, body, ok := readMessage(t, r)
+	require.True(t, ok)
+	require.Equal(t, uint8(messageHeaderSchema), message.uint8(1))
+	assert.Len(t, body, 0)
+
+	schema := message.table(2)
+	schemaFields := schema.tables(1)
+	require.Len(t, schemaFields, 3)
+
+	expectedTypes := []uint8{typeTimestamp, typeFloatingPoint, typeUtf8}
+	for i, f := range schemaFields {
+		assert.Equal(t, fields[i].Name, f.string(0))
+		assert.Equal(t, fields[i].Nullable, f.uint8(1) == 1)
+		assert.Equal(t, expectedTypes[i], f.uint8(2))
+		assert.True(t, f.has(5))
+	}
+
+	timestampType := schemaFields[0].table(3)
+	assert.Equal(t, int16(timeUnitMillisecond), timestampType.int16(0))
+	assert.Equal(t, timezoneUTC, timestampType.string(1))
+	assert.Equal(t, int16(precisionDouble), schemaFields[1].table(3).int16(0))
+
+	message, body, ok = readMessage(t, r)
+	require.True(t, ok)
+	require.Equal(t, uint8(messageHeaderRecordBatch), message.uint8(1))
+
+	recordBatch := message.table(2)
+	assert.Equal(t, int64(3), recordBatch.int64(0))
+	assert.Equal(t, [][2]int64{{3, 0}, {3, 0}, {3, 1}}, recordBatch.structs(1))
+
+	buffers := recordBatch.structs(2)
+	require.Len(t, buffers, 7)
+	slice := func(i int) []byte {
+		assert.Equal(t, int64(0), buffers[i][0]%alignment)
+		return body[buffers[i][0] : buffers[i][0]+buffers[i][1]]
+	}
+
+	// NB: no validity buffers for columns without nulls.
+	assert.Len(t, slice(0), 0)
+	assert.Len(t, slice(2), 0)
+	for i := 0; i < 3; i++ {
+		ts := int64(binary.LittleEndian.Uint64(slice(1)[8*i:]))
+		assert.Equal(t, now.Unix()*1000+int64(i)*1000, ts)
+		v := math.Float64frombits(binary.LittleEndian.Uint64(slice(3)[8*i:]))
+		assert.Equal(t, float64(i)+0.5, v)
+	}
+
+	assert.Equal(t, []byte{0x5}, slice(4))
+	offsets := slice(5)
+	require.Len(t, offsets, 16)
+	expectedOffsets := []uint32{0, 3, 3, 8}
+	for i, expected := range expectedOffsets {
+		assert.Equal(t, expected, binary.LittleEndian.Uint32(offsets[4*i:]))
+	}
+	assert.Equal(t, "fooquail", string(slice(6)))
+
+	_, _, ok = readMessage(t, r)
+	require.False(t, ok)
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestStreamWriterNoBatches(t *testing.T) {
+	fields := []Field{{Name: "value", Type: Float64Type}}
+
+	var buf bytes.Buffer
+	w := NewStreamWriter(&buf, fields)
+	require.NoError(t, w.Close())
+
+	r := bytes.NewReader(buf.Bytes())
+	message, _, ok := readMessage(t, r)
+	require.True(t, ok)
+	require.Equal(t, uint8(messageHeaderSchema), message.uint8(1))
+	require.Len(t, message.table(2).tables(1), 1)
+
+	_, _, ok = readMessage(t, r)
+	require.False(t, ok)
+}
@@ -41,6 +41,7 @@ type loggerKeyType int
 const (
 	loggerKey loggerKeyType = iota
 	rqIDKey
+	requestStatsKey
 
 	undefinedID = "undefined"
 )
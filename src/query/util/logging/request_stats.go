@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// RequestStats is the number of series and datapoints returned or written
+// by a request, reported in its access log.
+type RequestStats struct {
+	series     int64
+	datapoints int64
+}
+
+// NewContextWithRequestStats returns a context that tracks the stats of the
+// request it belongs to.
+func NewContextWithRequestStats(ctx context.Context) (context.Context, *RequestStats) {
+	stats := &RequestStats{}
+	return context.WithValue(ctx, requestStatsKey, stats), stats
+}
+
+// RecordRequestStats adds to the series and datapoints of the request the
+// context belongs to, if its stats are tracked.
+func RecordRequestStats(ctx context.Context, series, datapoints int) {
+	stats, ok := ctx.Value(requestStatsKey).(*RequestStats)
+	if !ok {
+		return
+	}
+	atomic.AddInt64(&stats.series, int64(series))
+	atomic.AddInt64(&stats.datapoints, int64(datapoints))
+}
+
+// Series returns the number of series returned or written by the request.
+func (s *RequestStats) Series() int64 {
+	return atomic.LoadInt64(&s.series)
+}
+
+// Datapoints returns the number of datapoints returned or written by the
+// request.
+func (s *RequestStats) Datapoints() int64 {
+	return atomic.LoadInt64(&s.datapoints)
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fault
+
+import (
+	"time"
+)
+
+// Configuration configures an injector. Fault injection must only be
+// configured in test and non-production deployments.
+type Configuration struct {
+	// FailWrites fails connection writes from startup.
+	FailWrites bool `yaml:"failWrites"`
+
+	// DropWrites silently discards connection writes from startup.
+	DropWrites bool `yaml:"dropWrites"`
+
+	// CorruptWrites corrupts connection writes from startup.
+	CorruptWrites bool `yaml:"corruptWrites"`
+
+	// WriteDelay delays each connection write from startup.
+	WriteDelay time.Duration `yaml:"writeDelay"`
+
+	// ReadDelay delays each connection read from startup.
+	ReadDelay time.Duration `yaml:"readDelay"`
+
+	// FailSyncs fails file syncs from startup.
+	FailSyncs bool `yaml:"failSyncs"`
+}
+
+// NewInjector returns a new injector with the configured faults applied,
+// the faults can be changed later with the debug handler.
+func (c Configuration) NewInjector() *Injector {
+	injector := NewInjector()
+	injector.SetFaults(Faults{
+		FailWrites:    c.FailWrites,
+		DropWrites:    c.DropWrites,
+		CorruptWrites: c.CorruptWrites,
+		WriteDelay:    c.WriteDelay,
+		ReadDelay:     c.ReadDelay,
+		FailSyncs:     c.FailSyncs,
+	})
+	return injector
+}
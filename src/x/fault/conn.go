@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"net"
+)
+
+type conn struct {
+	net.Conn
+
+	injector *Injector
+}
+
+// NewConn wraps the given connection so that reads and writes are subject
+// to the faults of the injector.
+func NewConn(c net.Conn, injector *Injector) net.Conn {
+	return &conn{Conn: c, injector: injector}
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	c.injector.read()
+	return c.Conn.Read(b)
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	data, skip, err := c.injector.write(b)
+	if err != nil {
+		return 0, err
+	}
+	if skip {
+		return len(b), nil
+	}
+	return c.Conn.Write(data)
+}
+
+type listener struct {
+	net.Listener
+
+	injector *Injector
+}
+
+// NewListener wraps the given listener so that accepted connections are
+// subject to the faults of the injector.
+func NewListener(l net.Listener, injector *Injector) net.Listener {
+	return &listener{Listener: l, injector: injector}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return NewConn(c, l.injector), nil
+}
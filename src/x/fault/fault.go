@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package fault provides injectable network and disk faults so that
+// resilience features such as write retries and repairs can be exercised
+// deterministically in tests.
+package fault
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInjectedWrite is returned by writes failed by an injector.
+	ErrInjectedWrite = errors.New("injected write failure")
+
+	// ErrInjectedSync is returned by syncs failed by an injector.
+	ErrInjectedSync = errors.New("injected sync failure")
+)
+
+// Faults describes the faults an injector applies.
+type Faults struct {
+	// FailWrites fails writes with ErrInjectedWrite.
+	FailWrites bool
+	// DropWrites silently discards written bytes while reporting success.
+	DropWrites bool
+	// CorruptWrites flips the bits of the first byte of each write.
+	CorruptWrites bool
+	// WriteDelay delays each write by the given duration.
+	WriteDelay time.Duration
+	// ReadDelay delays each read by the given duration.
+	ReadDelay time.Duration
+	// FailSyncs fails syncs with ErrInjectedSync.
+	FailSyncs bool
+}
+
+// Syncer is a file or other resource that can be synced to disk.
+type Syncer interface {
+	Sync() error
+}
+
+// Injector injects faults into the resources it wraps. The faults can be
+// changed at any time and apply to all resources wrapped by the injector.
+type Injector struct {
+	sync.RWMutex
+
+	faults  Faults
+	sleepFn func(time.Duration)
+}
+
+// NewInjector returns a new injector with no faults set.
+func NewInjector() *Injector {
+	return &Injector{sleepFn: time.Sleep}
+}
+
+// Faults returns the faults currently applied.
+func (i *Injector) Faults() Faults {
+	i.RLock()
+	faults := i.faults
+	i.RUnlock()
+	return faults
+}
+
+// SetFaults sets the faults to apply.
+func (i *Injector) SetFaults(faults Faults) {
+	i.Lock()
+	i.faults = faults
+	i.Unlock()
+}
+
+// Reset clears all faults.
+func (i *Injector) Reset() {
+	i.SetFaults(Faults{})
+}
+
+// Sync syncs the given resource unless sync failures are being injected.
+func (i *Injector) Sync(s Syncer) error {
+	if i.Faults().FailSyncs {
+		return ErrInjectedSync
+	}
+	return s.Sync()
+}
+
+// write applies write faults to the given bytes, returning the bytes to
+// write and whether the write should be skipped.
+func (i *Injector) write(b []byte) ([]byte, bool, error) {
+	faults := i.Faults()
+	if faults.WriteDelay > 0 {
+		i.sleepFn(faults.WriteDelay)
+	}
+	if faults.FailWrites {
+		return nil, false, ErrInjectedWrite
+	}
+	if faults.DropWrites {
+		return nil, true, nil
+	}
+	if faults.CorruptWrites && len(b) > 0 {
+		// NB: copy so the caller's buffer is left intact.
+		corrupted := append([]byte(nil), b...)
+		corrupted[0] = ^corrupted[0]
+		return corrupted, false, nil
+	}
+	return b, false, nil
+}
+
+func (i *Injector) read() {
+	if delay := i.Faults().ReadDelay; delay > 0 {
+		i.sleepFn(delay)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type syncFn func() error
+
+func (fn syncFn) Sync() error { return fn() }
+
+func newTestConn(injector *Injector) (net.Conn, net.Conn) {
+	client, server := net.Pipe()
+	return NewConn(client, injector), server
+}
+
+func readAsync(server net.Conn, n int) chan []byte {
+	ch := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, n)
+		read, _ := server.Read(buf)
+		ch <- buf[:read]
+	}()
+	return ch
+}
+
+func TestConnWriteFaults(t *testing.T) {
+	injector := NewInjector()
+	var slept []time.Duration
+	injector.sleepFn = func(d time.Duration) { slept = append(slept, d) }
+
+	client, server := newTestConn(injector)
+	defer client.Close()
+	defer server.Close()
+
+	// No faults.
+	ch := readAsync(server, 3)
+	n, err := client.Write([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, []byte("foo"), <-ch)
+
+	// Corrupted writes leave the caller's buffer intact.
+	injector.SetFaults(Faults{CorruptWrites: true, WriteDelay: time.Second})
+	data := []byte("foo")
+	ch = readAsync(server, 3)
+	_, err = client.Write(data)
+	require.NoError(t, err)
+	require.Equal(t, []byte{^byte('f'), 'o', 'o'}, <-ch)
+	require.Equal(t, []byte("foo"), data)
+	require.Equal(t, []time.Duration{time.Second}, slept)
+
+	// Dropped writes report success without writing.
+	injector.SetFaults(Faults{DropWrites: true})
+	n, err = client.Write([]byte("foo"))
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+
+	injector.SetFaults(Faults{FailWrites: true})
+	_, err = client.Write([]byte("foo"))
+	require.Equal(t, ErrInjectedWrite, err)
+
+	injector.Reset()
+	ch = readAsync(server, 3)
+	_, err = client.Write([]byte("bar"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), <-ch)
+}
+
+func TestInjectorSync(t *testing.T) {
+	injector := NewInjector()
+	synced := 0
+	syncer := syncFn(func() error {
+		synced++
+		return nil
+	})
+
+	require.NoError(t, injector.Sync(syncer))
+	injector.SetFaults(Faults{FailSyncs: true})
+	require.Equal(t, ErrInjectedSync, injector.Sync(syncer))
+	require.Equal(t, 1, synced)
+}
+
+func TestHandler(t *testing.T) {
+	injector := NewInjector()
+	handler := NewHandler(injector, zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodPost, HandlerURL,
+		strings.NewReader(`{"dropWrites":true,"writeDelay":"10ms"}`))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, Faults{DropWrites: true, WriteDelay: 10 * time.Millisecond},
+		injector.Faults())
+
+	req = httptest.NewRequest(http.MethodPost, HandlerURL,
+		strings.NewReader(`{"readDelay":"foo"}`))
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, HandlerURL, nil)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, Faults{}, injector.Faults())
+
+	body, err := ioutil.ReadAll(recorder.Body)
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(body, []byte(`"dropWrites":false`)))
+}
+
+func TestConfigurationNewInjector(t *testing.T) {
+	cfg := Configuration{FailSyncs: true, ReadDelay: time.Second}
+	injector := cfg.NewInjector()
+	require.Equal(t, Faults{FailSyncs: true, ReadDelay: time.Second}, injector.Faults())
+
+	err := injector.Sync(syncFn(func() error { return nil }))
+	require.Equal(t, ErrInjectedSync, err)
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+// HandlerURL is the default url for the fault injection debug handler.
+const HandlerURL = "/debug/faults"
+
+type faultsJSON struct {
+	FailWrites    bool   `json:"failWrites"`
+	DropWrites    bool   `json:"dropWrites"`
+	CorruptWrites bool   `json:"corruptWrites"`
+	WriteDelay    string `json:"writeDelay"`
+	ReadDelay     string `json:"readDelay"`
+	FailSyncs     bool   `json:"failSyncs"`
+}
+
+type handler struct {
+	injector *Injector
+	logger   *zap.Logger
+}
+
+// NewHandler returns a debug handler that returns the faults of the
+// injector on GET, replaces them on POST and clears them on DELETE. It
+// must only be registered in test and non-production builds.
+func NewHandler(injector *Injector, logger *zap.Logger) http.Handler {
+	return &handler{injector: injector, logger: logger}
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		faults, err := parseFaults(r)
+		if err != nil {
+			xhttp.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		h.injector.SetFaults(faults)
+	case http.MethodDelete:
+		h.injector.Reset()
+	default:
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+		return
+	}
+
+	faults := h.injector.Faults()
+	xhttp.WriteJSONResponse(w, faultsJSON{
+		FailWrites:    faults.FailWrites,
+		DropWrites:    faults.DropWrites,
+		CorruptWrites: faults.CorruptWrites,
+		WriteDelay:    faults.WriteDelay.String(),
+		ReadDelay:     faults.ReadDelay.String(),
+		FailSyncs:     faults.FailSyncs,
+	}, h.logger)
+}
+
+func parseFaults(r *http.Request) (Faults, error) {
+	if r.Body == nil {
+		return Faults{}, fmt.Errorf("empty request body")
+	}
+
+	defer r.Body.Close()
+
+	var req faultsJSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return Faults{}, err
+	}
+
+	faults := Faults{
+		FailWrites:    req.FailWrites,
+		DropWrites:    req.DropWrites,
+		CorruptWrites: req.CorruptWrites,
+		FailSyncs:     req.FailSyncs,
+	}
+
+	var err error
+	if req.WriteDelay != "" {
+		if faults.WriteDelay, err = time.ParseDuration(req.WriteDelay); err != nil {
+			return Faults{}, fmt.Errorf("invalid write delay: %v", err)
+		}
+	}
+	if req.ReadDelay != "" {
+		if faults.ReadDelay, err = time.ParseDuration(req.ReadDelay); err != nil {
+			return Faults{}, fmt.Errorf("invalid read delay: %v", err)
+		}
+	}
+
+	return faults, nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// LivenessService is the gRPC health service name that reports liveness.
+	LivenessService = "liveness"
+
+	// ReadinessService is the gRPC health service name that reports
+	// readiness, which is also reported for the empty service name.
+	ReadinessService = "readiness"
+)
+
+type grpcServer struct {
+	registry      Registry
+	watchInterval time.Duration
+}
+
+// NewGRPCServer returns a gRPC health service backed by the registry, watches
+// re-run the checks at the given interval and send the status on change.
+func NewGRPCServer(
+	registry Registry,
+	watchInterval time.Duration,
+) healthpb.HealthServer {
+	return &grpcServer{
+		registry:      registry,
+		watchInterval: watchInterval,
+	}
+}
+
+func (s *grpcServer) Check(
+	ctx context.Context,
+	req *healthpb.HealthCheckRequest,
+) (*healthpb.HealthCheckResponse, error) {
+	kind, err := serviceKind(req.Service)
+	if err != nil {
+		return nil, err
+	}
+	return &healthpb.HealthCheckResponse{Status: s.status(ctx, kind)}, nil
+}
+
+func (s *grpcServer) Watch(
+	req *healthpb.HealthCheckRequest,
+	stream healthpb.Health_WatchServer,
+) error {
+	kind, err := serviceKind(req.Service)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		curr := s.status(stream.Context(), kind)
+		if curr != last {
+			resp := &healthpb.HealthCheckResponse{Status: curr}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			last = curr
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+func (s *grpcServer) status(
+	ctx context.Context,
+	kind Kind,
+) healthpb.HealthCheckResponse_ServingStatus {
+	if s.registry.Check(ctx, kind).Healthy {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+func serviceKind(service string) (Kind, error) {
+	switch service {
+	case LivenessService:
+		return Liveness, nil
+	case "", ReadinessService:
+		return Readiness, nil
+	default:
+		return 0, status.Errorf(codes.NotFound, "unknown service: %s", service)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package health
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultCheckTimeout = 5 * time.Second
+)
+
+// Options is a set of health registry options.
+type Options interface {
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetCheckTimeout sets the time a check may take before it is considered
+	// to have failed.
+	SetCheckTimeout(value time.Duration) Options
+
+	// CheckTimeout returns the time a check may take before it is considered
+	// to have failed.
+	CheckTimeout() time.Duration
+}
+
+type options struct {
+	instrumentOpts instrument.Options
+	checkTimeout   time.Duration
+}
+
+// NewOptions creates a new set of health registry options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts: instrument.NewOptions(),
+		checkTimeout:   defaultCheckTimeout,
+	}
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetCheckTimeout(value time.Duration) Options {
+	opts := *o
+	opts.checkTimeout = value
+	return &opts
+}
+
+func (o *options) CheckTimeout() time.Duration {
+	return o.checkTimeout
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package health provides a registry of health checks that subsystems
+// register with, reported over HTTP and gRPC so that orchestrators can
+// restart unhealthy processes and gate traffic to processes not yet ready.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+var errCheckTimeout = errors.New("check timed out")
+
+// Kind is the kind of a health check.
+type Kind int
+
+const (
+	// Liveness checks fail when the process can no longer make progress and
+	// should be restarted.
+	Liveness Kind = iota
+	// Readiness checks fail when the process should not be sent traffic, for
+	// example while it is bootstrapping. A process is only ready if it is
+	// also live.
+	Readiness
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Liveness:
+		return "liveness"
+	case Readiness:
+		return "readiness"
+	default:
+		return "unknown"
+	}
+}
+
+// CheckFn checks the health of a subsystem, returning an error if it is
+// unhealthy.
+type CheckFn func(ctx context.Context) error
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Healthy  bool   `json:"healthy"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the result of running the checks of a kind.
+type Report struct {
+	Healthy bool          `json:"healthy"`
+	Checks  []CheckResult `json:"checks"`
+}
+
+// Registry is a registry of health checks.
+type Registry interface {
+	// Register registers a check with the given name, names must be unique.
+	Register(name string, kind Kind, fn CheckFn) error
+
+	// Check runs the checks of the given kind concurrently and reports their
+	// results, readiness checks include the liveness checks.
+	Check(ctx context.Context, kind Kind) Report
+}
+
+type check struct {
+	name string
+	kind Kind
+	fn   CheckFn
+}
+
+type registry struct {
+	sync.RWMutex
+
+	checks       map[string]check
+	checkTimeout time.Duration
+	scope        tally.Scope
+	logger       *zap.Logger
+}
+
+// NewRegistry returns a new health check registry.
+func NewRegistry(opts Options) Registry {
+	iOpts := opts.InstrumentOptions()
+	return &registry{
+		checks:       make(map[string]check),
+		checkTimeout: opts.CheckTimeout(),
+		scope:        iOpts.MetricsScope().SubScope("health"),
+		logger:       iOpts.Logger(),
+	}
+}
+
+func (r *registry) Register(name string, kind Kind, fn CheckFn) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if _, ok := r.checks[name]; ok {
+		return fmt.Errorf("health check %s already registered", name)
+	}
+	r.checks[name] = check{name: name, kind: kind, fn: fn}
+	return nil
+}
+
+func (r *registry) Check(ctx context.Context, kind Kind) Report {
+	r.RLock()
+	checks := make([]check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.kind <= kind {
+			checks = append(checks, c)
+		}
+	}
+	r.RUnlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].name < checks[j].name
+	})
+
+	var (
+		wg     sync.WaitGroup
+		report = Report{
+			Healthy: true,
+			Checks:  make([]CheckResult, len(checks)),
+		}
+	)
+	for i, c := range checks {
+		i, c := i, c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if !result.Healthy {
+			report.Healthy = false
+		}
+	}
+	return report
+}
+
+func (r *registry) run(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.checkTimeout)
+	defer cancel()
+
+	var (
+		start = time.Now()
+		errCh = make(chan error, 1)
+	)
+	// NB: run the check in its own goroutine so that checks which do not
+	// respect the context still time out.
+	go func() {
+		errCh <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errCheckTimeout
+	}
+
+	result := CheckResult{
+		Name:     c.name,
+		Kind:     c.kind.String(),
+		Healthy:  err == nil,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		r.scope.Tagged(map[string]string{
+			"check": c.name,
+			"kind":  c.kind.String(),
+		}).Counter("check-failures").Inc(1)
+		r.logger.Warn("health check failed",
+			zap.String("check", c.name),
+			zap.Stringer("kind", c.kind),
+			zap.Error(err))
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestRegistry(t *testing.T, scope tally.Scope) Registry {
+	opts := NewOptions().
+		SetCheckTimeout(50 * time.Millisecond).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	r := NewRegistry(opts)
+
+	healthy := func(context.Context) error { return nil }
+	require.NoError(t, r.Register("live", Liveness, healthy))
+	require.NoError(t, r.Register("ready", Readiness, healthy))
+	return r
+}
+
+func TestRegistryRegisterDuplicate(t *testing.T) {
+	r := newTestRegistry(t, tally.NoopScope)
+	require.Error(t, r.Register("live", Readiness,
+		func(context.Context) error { return nil }))
+}
+
+func TestRegistryCheck(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	r := newTestRegistry(t, scope)
+
+	report := r.Check(context.Background(), Liveness)
+	require.True(t, report.Healthy)
+	require.Equal(t, 1, len(report.Checks))
+	assert.Equal(t, "live", report.Checks[0].Name)
+
+	// Readiness includes the liveness checks.
+	report = r.Check(context.Background(), Readiness)
+	require.True(t, report.Healthy)
+	require.Equal(t, 2, len(report.Checks))
+	assert.Equal(t, "live", report.Checks[0].Name)
+	assert.Equal(t, "ready", report.Checks[1].Name)
+
+	require.NoError(t, r.Register("bootstrap", Readiness,
+		func(context.Context) error { return errors.New("bootstrapping") }))
+
+	// A failed readiness check does not affect liveness.
+	require.True(t, r.Check(context.Background(), Liveness).Healthy)
+
+	report = r.Check(context.Background(), Readiness)
+	require.False(t, report.Healthy)
+	require.Equal(t, 3, len(report.Checks))
+	assert.Equal(t, "bootstrap", report.Checks[0].Name)
+	assert.False(t, report.Checks[0].Healthy)
+	assert.Equal(t, "bootstrapping", report.Checks[0].Error)
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1),
+		counters["health.check-failures+check=bootstrap,kind=readiness"].Value())
+}
+
+func TestRegistryCheckTimeout(t *testing.T) {
+	r := newTestRegistry(t, tally.NoopScope)
+
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, r.Register("stuck", Liveness, func(context.Context) error {
+		<-block
+		return nil
+	}))
+
+	report := r.Check(context.Background(), Liveness)
+	require.False(t, report.Healthy)
+	assert.Equal(t, errCheckTimeout.Error(), report.Checks[1].Error)
+}
+
+func TestHandler(t *testing.T) {
+	r := newTestRegistry(t, tally.NoopScope)
+
+	serve := func(kind Kind) (int, Report) {
+		w := httptest.NewRecorder()
+		NewHandler(r, kind).ServeHTTP(w,
+			httptest.NewRequest(http.MethodGet, ReadinessURL, nil))
+
+		var report Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	code, report := serve(Readiness)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, report.Healthy)
+
+	require.NoError(t, r.Register("bootstrap", Readiness,
+		func(context.Context) error { return errors.New("bootstrapping") }))
+
+	code, report = serve(Readiness)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.False(t, report.Healthy)
+
+	code, _ = serve(Liveness)
+	require.Equal(t, http.StatusOK, code)
+}
+
+func TestGRPCServerCheck(t *testing.T) {
+	r := newTestRegistry(t, tally.NoopScope)
+	require.NoError(t, r.Register("bootstrap", Readiness,
+		func(context.Context) error { return errors.New("bootstrapping") }))
+
+	s := NewGRPCServer(r, time.Second)
+	for _, test := range []struct {
+		service  string
+		expected healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{service: "", expected: healthpb.HealthCheckResponse_NOT_SERVING},
+		{service: ReadinessService, expected: healthpb.HealthCheckResponse_NOT_SERVING},
+		{service: LivenessService, expected: healthpb.HealthCheckResponse_SERVING},
+	} {
+		resp, err := s.Check(context.Background(),
+			&healthpb.HealthCheckRequest{Service: test.service})
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, resp.Status, test.service)
+	}
+
+	_, err := s.Check(context.Background(),
+		&healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hll provides a HyperLogLog sketch estimating the number of distinct
+// values added to it in a fixed amount of memory.
+package hll
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// MinPrecision is the minimum precision of a sketch.
+	MinPrecision = 4
+	// MaxPrecision is the maximum precision of a sketch.
+	MaxPrecision = 18
+)
+
+var errPrecisionMismatch = errors.New("cannot merge sketches of different precision")
+
+// Sketch is a HyperLogLog sketch with 2^precision registers.
+// NB: Sketch is not safe for concurrent use.
+type Sketch struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewSketch returns a new sketch with the given precision, the relative
+// standard error of its estimates is 1.04/sqrt(2^precision).
+func NewSketch(precision int) (*Sketch, error) {
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, fmt.Errorf("precision %d must be between %d and %d",
+			precision, MinPrecision, MaxPrecision)
+	}
+	return &Sketch{
+		precision: uint8(precision),
+		registers: make([]uint8, 1<<uint(precision)),
+	}, nil
+}
+
+// Add adds a value to the sketch.
+func (s *Sketch) Add(value []byte) {
+	s.AddHash(xxhash.Sum64(value))
+}
+
+// AddHash adds an already hashed value to the sketch.
+func (s *Sketch) AddHash(hash uint64) {
+	idx := hash >> (64 - s.precision)
+	// NB: set the lowest bit so the rank is bounded when the remaining bits
+	// are all zero.
+	rank := uint8(bits.LeadingZeros64(hash<<s.precision|1<<(s.precision-1))) + 1
+	if rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// Merge merges another sketch of the same precision into the sketch.
+func (s *Sketch) Merge(other *Sketch) error {
+	if s.precision != other.precision {
+		return errPrecisionMismatch
+	}
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate returns the estimated number of distinct values added.
+func (s *Sketch) Estimate() uint64 {
+	var (
+		m     = float64(len(s.registers))
+		sum   float64
+		zeros int
+	)
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha(m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities where the raw estimate
+		// is heavily biased.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+// RelativeError returns the relative standard error of the estimates.
+func (s *Sketch) RelativeError() float64 {
+	return 1.04 / math.Sqrt(float64(len(s.registers)))
+}
+
+// Precision returns the precision of the sketch.
+func (s *Sketch) Precision() int {
+	return int(s.precision)
+}
+
+// Reset resets the sketch to empty.
+func (s *Sketch) Reset() {
+	for i := range s.registers {
+		s.registers[i] = 0
+	}
+}
+
+func alpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
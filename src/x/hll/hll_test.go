@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hll
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSketchInvalidPrecision(t *testing.T) {
+	_, err := NewSketch(MinPrecision - 1)
+	require.Error(t, err)
+	_, err = NewSketch(MaxPrecision + 1)
+	require.Error(t, err)
+}
+
+func TestSketchEstimate(t *testing.T) {
+	for _, n := range []int{0, 10, 1000, 100000} {
+		s, err := NewSketch(14)
+		require.NoError(t, err)
+		for i := 0; i < n; i++ {
+			value := []byte(fmt.Sprintf("series%d", i))
+			// Duplicates do not change the estimate.
+			s.Add(value)
+			s.Add(value)
+		}
+		bound := 3 * s.RelativeError() * float64(n)
+		require.InDelta(t, float64(n), float64(s.Estimate()), math.Max(bound, 1),
+			"n=%d", n)
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	s1, err := NewSketch(12)
+	require.NoError(t, err)
+	s2, err := NewSketch(12)
+	require.NoError(t, err)
+	for i := 0; i < 5000; i++ {
+		s1.Add([]byte(fmt.Sprintf("series%d", i)))
+		s2.Add([]byte(fmt.Sprintf("series%d", i+2500)))
+	}
+	require.NoError(t, s1.Merge(s2))
+	require.InDelta(t, 7500, float64(s1.Estimate()), 3*s1.RelativeError()*7500)
+
+	s3, err := NewSketch(10)
+	require.NoError(t, err)
+	require.Equal(t, errPrecisionMismatch, s1.Merge(s3))
+
+	s1.Reset()
+	require.Equal(t, uint64(0), s1.Estimate())
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package intern provides a bounded cache interning frequently repeated byte
+// slices, such as tag names and values shared across many series, so that
+// long-lived copies of equal slices share the same underlying storage.
+package intern
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/m3db/m3/src/x/instrument"
+	xunsafe "github.com/m3db/m3/src/x/unsafe"
+
+	"github.com/uber-go/tally"
+)
+
+const (
+	defaultMaxLength = 256
+	numShards        = 32
+)
+
+var errInvalidCapacity = errors.New("intern cache capacity must be positive")
+
+// Configuration configures an intern cache.
+type Configuration struct {
+	// Capacity is the maximum number of interned slices retained.
+	Capacity int `yaml:"capacity" validate:"min=1"`
+
+	// MaxLength is the maximum length of slices interned, longer slices
+	// are unlikely to be repeated and are copied instead.
+	MaxLength int `yaml:"maxLength"`
+}
+
+// NewCache creates a new intern cache.
+func (c Configuration) NewCache(instrumentOpts instrument.Options) (*Cache, error) {
+	maxLength := c.MaxLength
+	if maxLength == 0 {
+		maxLength = defaultMaxLength
+	}
+	return NewCache(c.Capacity, maxLength,
+		instrumentOpts.MetricsScope().SubScope("intern-cache"))
+}
+
+type cacheMetrics struct {
+	hits      tally.Counter
+	misses    tally.Counter
+	evictions tally.Counter
+	skipped   tally.Counter
+}
+
+func newCacheMetrics(scope tally.Scope) cacheMetrics {
+	return cacheMetrics{
+		hits:      scope.Counter("hits"),
+		misses:    scope.Counter("misses"),
+		evictions: scope.Counter("evictions"),
+		skipped:   scope.Counter("skipped"),
+	}
+}
+
+type cacheShard struct {
+	sync.Mutex
+
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+// Cache interns byte slices, evicting the least recently interned slices
+// once full. Evicting a slice only drops the reference held by the cache,
+// so slices interned before remain valid and are never mutated.
+type Cache struct {
+	maxLength int
+	shards    []cacheShard
+	metrics   cacheMetrics
+}
+
+// NewCache creates a new intern cache retaining up to capacity slices no
+// longer than maxLength.
+func NewCache(capacity, maxLength int, scope tally.Scope) (*Cache, error) {
+	if capacity <= 0 {
+		return nil, errInvalidCapacity
+	}
+	shardCapacity := (capacity + numShards - 1) / numShards
+	shards := make([]cacheShard, numShards)
+	for i := range shards {
+		shards[i].capacity = shardCapacity
+		shards[i].entries = make(map[string]*list.Element)
+		shards[i].lru = list.New()
+	}
+	return &Cache{
+		maxLength: maxLength,
+		shards:    shards,
+		metrics:   newCacheMetrics(scope),
+	}, nil
+}
+
+// Intern returns an immutable slice equal to b, shared with previous callers
+// that interned an equal slice. A nil cache, and slices longer than the max
+// length, return a copy of b so callers need not check whether interning is
+// enabled. The returned slice must never be mutated.
+func (c *Cache) Intern(b []byte) []byte {
+	if c == nil {
+		return append([]byte(nil), b...)
+	}
+	if len(b) > c.maxLength {
+		c.metrics.skipped.Inc(1)
+		return append([]byte(nil), b...)
+	}
+
+	shard := &c.shards[shardIndex(b)]
+	shard.Lock()
+	if elem, exists := shard.entries[string(b)]; exists {
+		shard.lru.MoveToFront(elem)
+		interned := elem.Value.([]byte)
+		shard.Unlock()
+		c.metrics.hits.Inc(1)
+		return interned
+	}
+
+	interned := append([]byte(nil), b...)
+	// NB: the key references the interned slice rather than a copy of it
+	// since the interned slice is never mutated.
+	shard.entries[xunsafe.String(interned)] = shard.lru.PushFront(interned)
+	var evicted bool
+	if shard.lru.Len() > shard.capacity {
+		oldest := shard.lru.Back()
+		shard.lru.Remove(oldest)
+		delete(shard.entries, xunsafe.String(oldest.Value.([]byte)))
+		evicted = true
+	}
+	shard.Unlock()
+
+	c.metrics.misses.Inc(1)
+	if evicted {
+		c.metrics.evictions.Inc(1)
+	}
+	return interned
+}
+
+// Len returns the number of interned slices retained.
+func (c *Cache) Len() int {
+	var n int
+	for i := range c.shards {
+		c.shards[i].Lock()
+		n += c.shards[i].lru.Len()
+		c.shards[i].Unlock()
+	}
+	return n
+}
+
+// shardIndex returns the shard of the slice using the FNV-1a hash of the
+// slice computed inline to avoid allocating a hasher per call.
+func shardIndex(b []byte) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for _, c := range b {
+		h ^= uint32(c)
+		h *= prime32
+	}
+	return int(h % numShards)
+}
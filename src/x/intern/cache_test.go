@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package intern
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestNewCacheInvalid(t *testing.T) {
+	_, err := NewCache(0, 10, tally.NoopScope)
+	require.Equal(t, errInvalidCapacity, err)
+}
+
+func TestCacheIntern(t *testing.T) {
+	c, err := NewCache(64, 10, tally.NoopScope)
+	require.NoError(t, err)
+
+	b := []byte("service")
+	interned := c.Intern(b)
+	require.Equal(t, b, interned)
+
+	// The interned slice does not alias the input.
+	b[0] = 'S'
+	require.Equal(t, []byte("service"), interned)
+
+	// Equal slices share the interned storage.
+	again := c.Intern([]byte("service"))
+	require.Equal(t, &interned[0], &again[0])
+	require.Equal(t, 1, c.Len())
+}
+
+func TestCacheInternTooLong(t *testing.T) {
+	c, err := NewCache(64, 4, tally.NoopScope)
+	require.NoError(t, err)
+
+	first := c.Intern([]byte("service"))
+	second := c.Intern([]byte("service"))
+	require.Equal(t, first, second)
+	require.NotEqual(t, &first[0], &second[0])
+	require.Equal(t, 0, c.Len())
+}
+
+func TestCacheInternEvicts(t *testing.T) {
+	c, err := NewCache(numShards, 16, tally.NoopScope)
+	require.NoError(t, err)
+
+	for i := 0; i < 10*numShards; i++ {
+		c.Intern([]byte(fmt.Sprintf("value%d", i)))
+	}
+	require.True(t, c.Len() <= numShards)
+}
+
+func TestCacheInternNil(t *testing.T) {
+	var c *Cache
+	b := []byte("service")
+	interned := c.Intern(b)
+	require.Equal(t, b, interned)
+	require.NotEqual(t, &b[0], &interned[0])
+}
+
+func TestCacheInternConcurrent(t *testing.T) {
+	c, err := NewCache(1024, 16, tally.NoopScope)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				value := fmt.Sprintf("value%d", j%100)
+				require.Equal(t, value, string(c.Intern([]byte(value))))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 100, c.Len())
+}
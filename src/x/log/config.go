@@ -35,6 +35,13 @@ type Configuration struct {
 
 // BuildLogger builds a new Logger based on the configuration.
 func (cfg Configuration) BuildLogger() (*zap.Logger, error) {
+	logger, _, err := cfg.BuildLoggerWithLevel()
+	return logger, err
+}
+
+// BuildLoggerWithLevel builds a new Logger based on the configuration and
+// returns the level it logs at, which can be changed at runtime.
+func (cfg Configuration) BuildLoggerWithLevel() (*zap.Logger, zap.AtomicLevel, error) {
 	zc := zap.Config{
 		Level:             zap.NewAtomicLevelAt(zap.InfoLevel),
 		Development:       false,
@@ -59,10 +66,14 @@ func (cfg Configuration) BuildLogger() (*zap.Logger, error) {
 	if len(cfg.Level) != 0 {
 		var parsedLevel zap.AtomicLevel
 		if err := parsedLevel.UnmarshalText([]byte(cfg.Level)); err != nil {
-			return nil, fmt.Errorf("unable to parse log level %s: %v", cfg.Level, err)
+			return nil, zap.AtomicLevel{}, fmt.Errorf("unable to parse log level %s: %v", cfg.Level, err)
 		}
 		zc.Level = parsedLevel
 	}
 
-	return zc.Build()
+	logger, err := zc.Build()
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+	return logger, zc.Level, nil
 }
@@ -60,3 +60,30 @@ func TestLoggingConfiguration(t *testing.T) {
 	require.True(t, strings.Contains(data, `"my-field":"my-val"`))
 	require.True(t, strings.Contains(data, `"level":"error"`))
 }
+
+func TestLoggingConfigurationWithLevel(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "logtest")
+	require.NoError(t, err)
+
+	defer tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	cfg := Configuration{
+		Level: "error",
+		File:  tmpfile.Name(),
+	}
+
+	log, level, err := cfg.BuildLoggerWithLevel()
+	require.NoError(t, err)
+
+	log.Info("should not appear")
+	require.NoError(t, level.UnmarshalText([]byte("info")))
+	log.Info("this should appear")
+
+	b, err := ioutil.ReadAll(tmpfile)
+	require.NoError(t, err)
+
+	data := string(b)
+	require.Equal(t, 1, strings.Count(data, "\n"), data)
+	require.True(t, strings.Contains(data, `"msg":"this should appear"`))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Configuration is the configuration for a memory monitor.
+type Configuration struct {
+	// Limit is the heap usage limit in bytes.
+	Limit uint64 `yaml:"limit" validate:"nonzero"`
+
+	// Watermarks are the fractions of the limit at which each stage is
+	// entered, defaults are used if not set.
+	Watermarks *Watermarks `yaml:"watermarks"`
+
+	// Hysteresis is the fraction of the limit heap usage must fall below a
+	// stage's watermark to leave that stage.
+	Hysteresis *float64 `yaml:"hysteresis"`
+
+	// CheckInterval is the interval heap usage is checked at.
+	CheckInterval time.Duration `yaml:"checkInterval"`
+}
+
+// NewMonitor returns a new memory monitor for the configuration.
+func (c Configuration) NewMonitor(iOpts instrument.Options) (Monitor, error) {
+	opts := NewOptions().
+		SetInstrumentOptions(iOpts).
+		SetLimit(c.Limit)
+	if c.Watermarks != nil {
+		opts = opts.SetWatermarks(*c.Watermarks)
+	}
+	if c.Hysteresis != nil {
+		opts = opts.SetHysteresis(*c.Hysteresis)
+	}
+	if c.CheckInterval > 0 {
+		opts = opts.SetCheckInterval(c.CheckInterval)
+	}
+	return NewMonitor(opts)
+}
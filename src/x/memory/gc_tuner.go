@@ -0,0 +1,332 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSoftLimitFraction     = 0.7
+	defaultMinGCPercent          = 25
+	defaultMaxGCPercent          = 400
+	defaultBootstrapMaxGCPercent = 1000
+	defaultTuneInterval          = time.Second
+)
+
+var (
+	errGCTunerAlreadyStarted = errors.New("gc tuner already started")
+	errGCTunerNotStarted     = errors.New("gc tuner not started")
+	errNoSoftLimit           = errors.New("gc tuner soft limit not set and no container limit")
+)
+
+// Phase is a workload phase, the GC is tuned more aggressively for
+// throughput during bulk phases.
+type Phase int
+
+const (
+	// PhaseSteadyState is the phase when serving regular traffic.
+	PhaseSteadyState Phase = iota
+	// PhaseBootstrap is the phase when bulk loading data.
+	PhaseBootstrap
+)
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseSteadyState:
+		return "steady-state"
+	case PhaseBootstrap:
+		return "bootstrap"
+	default:
+		return "unknown"
+	}
+}
+
+// GCTunerConfiguration is the configuration for a GC tuner.
+type GCTunerConfiguration struct {
+	// SoftLimit is the heap size in bytes the GC percent is tuned to keep
+	// the heap under, if not set it is derived from the container limit.
+	SoftLimit uint64 `yaml:"softLimit"`
+
+	// SoftLimitFraction is the fraction of the container limit used as the
+	// soft limit when SoftLimit is not set.
+	SoftLimitFraction float64 `yaml:"softLimitFraction" validate:"min=0,max=1"`
+
+	// MinGCPercent is the lowest GC percent set as the heap nears the soft
+	// limit.
+	MinGCPercent int `yaml:"minGCPercent" validate:"min=0"`
+
+	// MaxGCPercent is the highest GC percent set in steady state.
+	MaxGCPercent int `yaml:"maxGCPercent" validate:"min=0"`
+
+	// BootstrapMaxGCPercent is the highest GC percent set while
+	// bootstrapping, trading memory for less GC CPU during bulk loads.
+	BootstrapMaxGCPercent int `yaml:"bootstrapMaxGCPercent" validate:"min=0"`
+
+	// BallastBytes is the size of a heap ballast allocated on start, which
+	// raises the heap size the first GCs run at.
+	BallastBytes uint64 `yaml:"ballastBytes"`
+
+	// Interval is the interval the GC percent is tuned at.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// NewGCTuner returns a new GC tuner for the configuration.
+func (c GCTunerConfiguration) NewGCTuner(iOpts instrument.Options) (GCTuner, error) {
+	softLimit := c.SoftLimit
+	if softLimit == 0 {
+		limit, ok, err := ContainerLimit()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read container memory limit: %v", err)
+		}
+		if !ok {
+			return nil, errNoSoftLimit
+		}
+		fraction := c.SoftLimitFraction
+		if fraction == 0 {
+			fraction = defaultSoftLimitFraction
+		}
+		softLimit = uint64(float64(limit) * fraction)
+	}
+
+	var (
+		minGCPercent          = defaultMinGCPercent
+		maxGCPercent          = defaultMaxGCPercent
+		bootstrapMaxGCPercent = defaultBootstrapMaxGCPercent
+		interval              = defaultTuneInterval
+	)
+	if c.MinGCPercent > 0 {
+		minGCPercent = c.MinGCPercent
+	}
+	if c.MaxGCPercent > 0 {
+		maxGCPercent = c.MaxGCPercent
+	}
+	if c.BootstrapMaxGCPercent > 0 {
+		bootstrapMaxGCPercent = c.BootstrapMaxGCPercent
+	}
+	if c.Interval > 0 {
+		interval = c.Interval
+	}
+	if minGCPercent > maxGCPercent || maxGCPercent > bootstrapMaxGCPercent {
+		return nil, fmt.Errorf(
+			"gc percents must be increasing: min=%d, max=%d, bootstrapMax=%d",
+			minGCPercent, maxGCPercent, bootstrapMaxGCPercent)
+	}
+
+	return &gcTuner{
+		softLimit:    softLimit,
+		minGCPercent: minGCPercent,
+		maxGCPercent: map[Phase]int{
+			PhaseSteadyState: maxGCPercent,
+			PhaseBootstrap:   bootstrapMaxGCPercent,
+		},
+		ballastBytes: c.BallastBytes,
+		interval:     interval,
+		readStatsFn:  runtime.ReadMemStats,
+		setGCPercent: debug.SetGCPercent,
+		gcPercent:    currentGCPercent(),
+		logger:       iOpts.Logger(),
+		metrics:      newGCTunerMetrics(iOpts.MetricsScope().SubScope("gc-tuner")),
+	}, nil
+}
+
+// GCTuner tunes the GC percent so that the heap grows freely while it is
+// well under a soft limit and is collected more often as it nears the soft
+// limit, reducing GC CPU without running out of memory.
+type GCTuner interface {
+	// Start allocates the ballast, if any, and starts tuning periodically.
+	Start() error
+
+	// Stop stops tuning and restores the GC percent set before starting.
+	Stop() error
+
+	// SetPhase sets the workload phase.
+	SetPhase(phase Phase)
+}
+
+type gcTunerMetrics struct {
+	gcPercent tally.Gauge
+	softLimit tally.Gauge
+	liveHeap  tally.Gauge
+}
+
+func newGCTunerMetrics(scope tally.Scope) gcTunerMetrics {
+	return gcTunerMetrics{
+		gcPercent: scope.Gauge("gc-percent"),
+		softLimit: scope.Gauge("soft-limit-bytes"),
+		liveHeap:  scope.Gauge("live-heap-bytes"),
+	}
+}
+
+type gcTuner struct {
+	sync.Mutex
+
+	softLimit    uint64
+	minGCPercent int
+	maxGCPercent map[Phase]int
+	ballastBytes uint64
+	interval     time.Duration
+	readStatsFn  func(*runtime.MemStats)
+	setGCPercent func(int) int
+	logger       *zap.Logger
+	metrics      gcTunerMetrics
+
+	phase     Phase
+	gcPercent int
+	prevPct   int
+	ballast   []byte
+	started   bool
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+}
+
+func (t *gcTuner) Start() error {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.started {
+		return errGCTunerAlreadyStarted
+	}
+	t.started = true
+	t.prevPct = t.gcPercent
+	if t.ballastBytes > 0 {
+		t.ballast = make([]byte, t.ballastBytes)
+	}
+	t.closeCh = make(chan struct{})
+	t.doneCh = make(chan struct{})
+	t.tuneWithLock()
+
+	go t.tuneLoop(t.closeCh, t.doneCh)
+	return nil
+}
+
+func (t *gcTuner) Stop() error {
+	t.Lock()
+	if !t.started {
+		t.Unlock()
+		return errGCTunerNotStarted
+	}
+	t.started = false
+	close(t.closeCh)
+	doneCh := t.doneCh
+	t.Unlock()
+
+	<-doneCh
+
+	t.Lock()
+	t.ballast = nil
+	t.gcPercent = t.prevPct
+	t.setGCPercent(t.prevPct)
+	t.Unlock()
+	return nil
+}
+
+func (t *gcTuner) SetPhase(phase Phase) {
+	t.Lock()
+	defer t.Unlock()
+
+	if phase == t.phase {
+		return
+	}
+	t.logger.Info("gc tuner phase changed",
+		zap.Stringer("from", t.phase),
+		zap.Stringer("to", phase))
+	t.phase = phase
+	if t.started {
+		t.tuneWithLock()
+	}
+}
+
+func (t *gcTuner) tuneLoop(closeCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Lock()
+			t.tuneWithLock()
+			t.Unlock()
+		case <-closeCh:
+			return
+		}
+	}
+}
+
+func (t *gcTuner) tuneWithLock() {
+	var stats runtime.MemStats
+	t.readStatsFn(&stats)
+
+	// NB: the next GC target is the live heap after the last GC grown by the
+	// GC percent, so the live heap can be recovered from it.
+	live := stats.NextGC
+	if t.gcPercent > 0 {
+		live = stats.NextGC * 100 / uint64(100+t.gcPercent)
+	}
+
+	pct := targetGCPercent(live, t.softLimit, t.minGCPercent, t.maxGCPercent[t.phase])
+	if pct != t.gcPercent {
+		t.setGCPercent(pct)
+		t.gcPercent = pct
+	}
+
+	t.metrics.gcPercent.Update(float64(pct))
+	t.metrics.softLimit.Update(float64(t.softLimit))
+	t.metrics.liveHeap.Update(float64(live))
+}
+
+// targetGCPercent returns the GC percent that makes the next GC run when
+// the heap reaches the soft limit, clamped to the given bounds.
+func targetGCPercent(live, softLimit uint64, minPct, maxPct int) int {
+	if live == 0 {
+		return maxPct
+	}
+	if live >= softLimit {
+		return minPct
+	}
+	pct := int((softLimit - live) * 100 / live)
+	if pct < minPct {
+		return minPct
+	}
+	if pct > maxPct {
+		return maxPct
+	}
+	return pct
+}
+
+func currentGCPercent() int {
+	// NB: there is no getter for the GC percent, so set it and put it back.
+	pct := debug.SetGCPercent(100)
+	debug.SetGCPercent(pct)
+	return pct
+}
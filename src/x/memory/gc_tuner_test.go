@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestTargetGCPercent(t *testing.T) {
+	for _, test := range []struct {
+		live, softLimit uint64
+		expected        int
+	}{
+		{live: 0, softLimit: 1000, expected: 400},
+		{live: 100, softLimit: 1000, expected: 400},
+		{live: 500, softLimit: 1000, expected: 100},
+		{live: 900, softLimit: 1000, expected: 25},
+		{live: 2000, softLimit: 1000, expected: 25},
+	} {
+		require.Equal(t, test.expected,
+			targetGCPercent(test.live, test.softLimit, 25, 400))
+	}
+}
+
+func TestGCTunerPhases(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	tuner, err := GCTunerConfiguration{
+		SoftLimit: 1000,
+		Interval:  time.Hour,
+	}.NewGCTuner(instrument.NewOptions().SetMetricsScope(scope))
+	require.NoError(t, err)
+
+	var (
+		gt      = tuner.(*gcTuner)
+		percent = gt.gcPercent
+		nextGC  = uint64(100)
+	)
+	gt.readStatsFn = func(stats *runtime.MemStats) {
+		stats.NextGC = nextGC * uint64(100+percent) / 100
+	}
+	gt.setGCPercent = func(pct int) int {
+		prev := percent
+		percent = pct
+		return prev
+	}
+	prevPercent := percent
+
+	require.NoError(t, tuner.Start())
+	require.Error(t, tuner.Start())
+	require.Equal(t, defaultMaxGCPercent, percent)
+
+	tuner.SetPhase(PhaseBootstrap)
+	require.Equal(t, 900, percent)
+
+	nextGC = 800
+	gt.Lock()
+	gt.tuneWithLock()
+	gt.Unlock()
+	require.Equal(t, defaultMinGCPercent, percent)
+	require.Equal(t, float64(defaultMinGCPercent),
+		scope.Snapshot().Gauges()["gc-tuner.gc-percent+"].Value())
+
+	tuner.SetPhase(PhaseSteadyState)
+	require.Equal(t, defaultMinGCPercent, percent)
+
+	require.NoError(t, tuner.Stop())
+	require.Error(t, tuner.Stop())
+	require.Equal(t, prevPercent, percent)
+}
+
+func TestGCTunerConfigurationInvalidPercents(t *testing.T) {
+	_, err := GCTunerConfiguration{
+		SoftLimit:    1000,
+		MinGCPercent: 500,
+	}.NewGCTuner(instrument.NewOptions())
+	require.Error(t, err)
+}
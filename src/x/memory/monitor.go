@@ -0,0 +1,269 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package memory provides a monitor that tracks heap usage against a limit
+// and moves through staged load shedding responses as usage grows, so that
+// processes shed load instead of being killed when they run out of memory.
+package memory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+var (
+	errMonitorAlreadyStarted = errors.New("memory monitor already started")
+	errMonitorNotStarted     = errors.New("memory monitor not started")
+)
+
+// Stage is a load shedding stage, each stage includes the responses of the
+// stages before it.
+type Stage int32
+
+const (
+	// StageNone is the stage when heap usage is below all watermarks.
+	StageNone Stage = iota
+	// StageRejectNewSeries is the stage in which writes creating new series
+	// are rejected.
+	StageRejectNewSeries
+	// StageFreeMemory is the stage in which memory freed by the garbage
+	// collector is returned to the OS on entering it.
+	StageFreeMemory
+	// StageShedQueries is the stage in which the lowest priority queries
+	// are rejected.
+	StageShedQueries
+)
+
+var stages = []Stage{
+	StageRejectNewSeries,
+	StageFreeMemory,
+	StageShedQueries,
+}
+
+func (s Stage) String() string {
+	switch s {
+	case StageNone:
+		return "none"
+	case StageRejectNewSeries:
+		return "reject-new-series"
+	case StageFreeMemory:
+		return "free-memory"
+	case StageShedQueries:
+		return "shed-queries"
+	default:
+		return "unknown"
+	}
+}
+
+// StageListenerFn is called with the previous and current stage whenever
+// the stage changes.
+type StageListenerFn func(prev, curr Stage)
+
+// Monitor tracks heap usage against a limit.
+type Monitor interface {
+	// Start starts checking heap usage periodically.
+	Start() error
+
+	// Stop stops checking heap usage.
+	Stop() error
+
+	// Stage returns the current stage.
+	Stage() Stage
+
+	// RegisterListener registers a function called whenever the stage
+	// changes.
+	RegisterListener(fn StageListenerFn)
+}
+
+type monitorMetrics struct {
+	heapBytes    tally.Gauge
+	usage        tally.Gauge
+	stage        tally.Gauge
+	stageEntered map[Stage]tally.Counter
+}
+
+func newMonitorMetrics(scope tally.Scope) monitorMetrics {
+	m := monitorMetrics{
+		heapBytes:    scope.Gauge("heap-bytes"),
+		usage:        scope.Gauge("usage"),
+		stage:        scope.Gauge("stage"),
+		stageEntered: make(map[Stage]tally.Counter, len(stages)),
+	}
+	for _, stage := range stages {
+		m.stageEntered[stage] = scope.Tagged(map[string]string{
+			"stage": stage.String(),
+		}).Counter("stage-entered")
+	}
+	return m
+}
+
+type monitor struct {
+	sync.Mutex
+
+	limit        float64
+	watermarks   Watermarks
+	hysteresis   float64
+	interval     time.Duration
+	heapUsageFn  HeapUsageFn
+	freeMemoryFn func()
+	logger       *zap.Logger
+	metrics      monitorMetrics
+
+	stage     atomic.Int32
+	listeners []StageListenerFn
+	started   bool
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+}
+
+// NewMonitor returns a new memory monitor.
+func NewMonitor(opts Options) (Monitor, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	iOpts := opts.InstrumentOptions()
+	return &monitor{
+		limit:        float64(opts.Limit()),
+		watermarks:   opts.Watermarks(),
+		hysteresis:   opts.Hysteresis(),
+		interval:     opts.CheckInterval(),
+		heapUsageFn:  opts.HeapUsageFn(),
+		freeMemoryFn: opts.FreeMemoryFn(),
+		logger:       iOpts.Logger(),
+		metrics:      newMonitorMetrics(iOpts.MetricsScope().SubScope("memory-monitor")),
+	}, nil
+}
+
+func (m *monitor) Start() error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.started {
+		return errMonitorAlreadyStarted
+	}
+	m.started = true
+	m.closeCh = make(chan struct{})
+	m.doneCh = make(chan struct{})
+
+	go m.checkLoop(m.closeCh, m.doneCh)
+	return nil
+}
+
+func (m *monitor) Stop() error {
+	m.Lock()
+	if !m.started {
+		m.Unlock()
+		return errMonitorNotStarted
+	}
+	m.started = false
+	close(m.closeCh)
+	doneCh := m.doneCh
+	m.Unlock()
+
+	<-doneCh
+	return nil
+}
+
+func (m *monitor) Stage() Stage {
+	return Stage(m.stage.Load())
+}
+
+func (m *monitor) RegisterListener(fn StageListenerFn) {
+	m.Lock()
+	m.listeners = append(m.listeners, fn)
+	m.Unlock()
+}
+
+func (m *monitor) checkLoop(closeCh, doneCh chan struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check()
+		select {
+		case <-ticker.C:
+		case <-closeCh:
+			return
+		}
+	}
+}
+
+func (m *monitor) check() {
+	var (
+		heap  = m.heapUsageFn()
+		usage = float64(heap) / m.limit
+		prev  = m.Stage()
+		curr  = m.nextStage(prev, usage)
+	)
+	m.metrics.heapBytes.Update(float64(heap))
+	m.metrics.usage.Update(usage)
+	m.metrics.stage.Update(float64(curr))
+	if curr == prev {
+		return
+	}
+
+	m.stage.Store(int32(curr))
+	for _, stage := range stages {
+		if stage > prev && stage <= curr {
+			m.metrics.stageEntered[stage].Inc(1)
+		}
+	}
+	m.logger.Warn("memory stage changed",
+		zap.Stringer("from", prev),
+		zap.Stringer("to", curr),
+		zap.Uint64("heapBytes", heap),
+		zap.Float64("usage", usage))
+
+	if prev < StageFreeMemory && curr >= StageFreeMemory && m.freeMemoryFn != nil {
+		m.freeMemoryFn()
+	}
+
+	m.Lock()
+	listeners := m.listeners
+	m.Unlock()
+	for _, fn := range listeners {
+		fn(prev, curr)
+	}
+}
+
+// nextStage returns the stage for the given usage, stages are entered as
+// soon as usage reaches their watermark but only left once usage falls
+// below their watermark by the hysteresis.
+func (m *monitor) nextStage(prev Stage, usage float64) Stage {
+	next := StageNone
+	for _, stage := range stages {
+		watermark := m.watermarks.forStage(stage)
+		if stage <= prev {
+			watermark -= m.hysteresis
+		}
+		if usage >= watermark {
+			next = stage
+		}
+	}
+	return next
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/atomic"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	require.Equal(t, errNoLimit, NewOptions().Validate())
+	require.NoError(t, NewOptions().SetLimit(100).Validate())
+
+	for _, w := range []Watermarks{
+		{RejectNewSeries: 0.9, FreeMemory: 0.8, ShedQueries: 0.95},
+		{RejectNewSeries: 0.8, FreeMemory: 0.9, ShedQueries: 1.1},
+		{RejectNewSeries: 0, FreeMemory: 0.9, ShedQueries: 0.95},
+	} {
+		require.Error(t, NewOptions().SetLimit(100).SetWatermarks(w).Validate())
+	}
+	require.Error(t, NewOptions().SetLimit(100).SetHysteresis(0.8).Validate())
+}
+
+func TestMonitorStages(t *testing.T) {
+	var (
+		scope           = tally.NewTestScope("", nil)
+		heap            = atomic.NewUint64(0)
+		freeMemoryCalls = atomic.NewInt32(0)
+		changes         [][2]Stage
+	)
+	opts := NewOptions().
+		SetLimit(100).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetHeapUsageFn(func() uint64 { return heap.Load() }).
+		SetFreeMemoryFn(func() { freeMemoryCalls.Inc() })
+	m, err := NewMonitor(opts)
+	require.NoError(t, err)
+	m.RegisterListener(func(prev, curr Stage) {
+		changes = append(changes, [2]Stage{prev, curr})
+	})
+	check := m.(*monitor).check
+
+	for _, test := range []struct {
+		heap     uint64
+		expected Stage
+	}{
+		{heap: 50, expected: StageNone},
+		{heap: 80, expected: StageRejectNewSeries},
+		// Stages are only left once usage falls below the hysteresis.
+		{heap: 78, expected: StageRejectNewSeries},
+		{heap: 74, expected: StageNone},
+		// Stages in between are entered when jumping straight to a stage.
+		{heap: 96, expected: StageShedQueries},
+		{heap: 91, expected: StageShedQueries},
+		{heap: 89, expected: StageFreeMemory},
+		{heap: 10, expected: StageNone},
+	} {
+		heap.Store(test.heap)
+		check()
+		assert.Equal(t, test.expected, m.Stage(), "heap %d", test.heap)
+	}
+
+	assert.Equal(t, [][2]Stage{
+		{StageNone, StageRejectNewSeries},
+		{StageRejectNewSeries, StageNone},
+		{StageNone, StageShedQueries},
+		{StageShedQueries, StageFreeMemory},
+		{StageFreeMemory, StageNone},
+	}, changes)
+	assert.Equal(t, int32(1), freeMemoryCalls.Load())
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2),
+		counters["memory-monitor.stage-entered+stage=reject-new-series"].Value())
+	assert.Equal(t, int64(1),
+		counters["memory-monitor.stage-entered+stage=free-memory"].Value())
+	assert.Equal(t, int64(1),
+		counters["memory-monitor.stage-entered+stage=shed-queries"].Value())
+}
+
+func TestMonitorStartStop(t *testing.T) {
+	m, err := NewMonitor(NewOptions().SetLimit(100).
+		SetHeapUsageFn(func() uint64 { return 100 }).
+		SetFreeMemoryFn(nil))
+	require.NoError(t, err)
+
+	require.Equal(t, errMonitorNotStarted, m.Stop())
+	require.NoError(t, m.Start())
+	require.Equal(t, errMonitorAlreadyStarted, m.Start())
+	require.NoError(t, m.Stop())
+
+	// The first check runs as soon as the monitor starts.
+	require.Equal(t, StageShedQueries, m.Stage())
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultCheckInterval = time.Second
+	defaultHysteresis    = 0.05
+)
+
+var (
+	errNoLimit = errors.New("memory limit not set")
+
+	defaultWatermarks = Watermarks{
+		RejectNewSeries: 0.8,
+		FreeMemory:      0.9,
+		ShedQueries:     0.95,
+	}
+)
+
+// HeapUsageFn returns the number of bytes of heap in use.
+type HeapUsageFn func() uint64
+
+// Watermarks are the fractions of the memory limit at which each stage is
+// entered.
+type Watermarks struct {
+	RejectNewSeries float64 `yaml:"rejectNewSeries"`
+	FreeMemory      float64 `yaml:"freeMemory"`
+	ShedQueries     float64 `yaml:"shedQueries"`
+}
+
+// Validate validates the watermarks are increasing fractions of the limit.
+func (w Watermarks) Validate() error {
+	prev := 0.0
+	for _, curr := range []float64{w.RejectNewSeries, w.FreeMemory, w.ShedQueries} {
+		if curr <= prev || curr > 1 {
+			return fmt.Errorf("watermarks must be increasing and within (0, 1]: %+v", w)
+		}
+		prev = curr
+	}
+	return nil
+}
+
+func (w Watermarks) forStage(stage Stage) float64 {
+	switch stage {
+	case StageRejectNewSeries:
+		return w.RejectNewSeries
+	case StageFreeMemory:
+		return w.FreeMemory
+	case StageShedQueries:
+		return w.ShedQueries
+	default:
+		return 0
+	}
+}
+
+// Options is a set of memory monitor options.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetLimit sets the heap usage limit in bytes.
+	SetLimit(value uint64) Options
+
+	// Limit returns the heap usage limit in bytes.
+	Limit() uint64
+
+	// SetWatermarks sets the watermarks at which each stage is entered.
+	SetWatermarks(value Watermarks) Options
+
+	// Watermarks returns the watermarks at which each stage is entered.
+	Watermarks() Watermarks
+
+	// SetHysteresis sets the fraction of the limit heap usage must fall
+	// below a stage's watermark to leave that stage, which stops the stage
+	// flapping while usage hovers around the watermark.
+	SetHysteresis(value float64) Options
+
+	// Hysteresis returns the fraction of the limit heap usage must fall
+	// below a stage's watermark to leave that stage.
+	Hysteresis() float64
+
+	// SetCheckInterval sets the interval heap usage is checked at.
+	SetCheckInterval(value time.Duration) Options
+
+	// CheckInterval returns the interval heap usage is checked at.
+	CheckInterval() time.Duration
+
+	// SetHeapUsageFn sets the function that returns the heap usage.
+	SetHeapUsageFn(value HeapUsageFn) Options
+
+	// HeapUsageFn returns the function that returns the heap usage.
+	HeapUsageFn() HeapUsageFn
+
+	// SetFreeMemoryFn sets the function called on entering the free memory
+	// stage, which by default returns freed memory to the OS.
+	SetFreeMemoryFn(value func()) Options
+
+	// FreeMemoryFn returns the function called on entering the free memory
+	// stage.
+	FreeMemoryFn() func()
+}
+
+type options struct {
+	instrumentOpts instrument.Options
+	limit          uint64
+	watermarks     Watermarks
+	hysteresis     float64
+	checkInterval  time.Duration
+	heapUsageFn    HeapUsageFn
+	freeMemoryFn   func()
+}
+
+// NewOptions creates a new set of memory monitor options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts: instrument.NewOptions(),
+		watermarks:     defaultWatermarks,
+		hysteresis:     defaultHysteresis,
+		checkInterval:  defaultCheckInterval,
+		heapUsageFn:    heapUsage,
+		freeMemoryFn:   debug.FreeOSMemory,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.limit == 0 {
+		return errNoLimit
+	}
+	if o.hysteresis < 0 || o.hysteresis >= o.watermarks.RejectNewSeries {
+		return fmt.Errorf("hysteresis must be within [0, %v): %v",
+			o.watermarks.RejectNewSeries, o.hysteresis)
+	}
+	return o.watermarks.Validate()
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetLimit(value uint64) Options {
+	opts := *o
+	opts.limit = value
+	return &opts
+}
+
+func (o *options) Limit() uint64 {
+	return o.limit
+}
+
+func (o *options) SetWatermarks(value Watermarks) Options {
+	opts := *o
+	opts.watermarks = value
+	return &opts
+}
+
+func (o *options) Watermarks() Watermarks {
+	return o.watermarks
+}
+
+func (o *options) SetHysteresis(value float64) Options {
+	opts := *o
+	opts.hysteresis = value
+	return &opts
+}
+
+func (o *options) Hysteresis() float64 {
+	return o.hysteresis
+}
+
+func (o *options) SetCheckInterval(value time.Duration) Options {
+	opts := *o
+	opts.checkInterval = value
+	return &opts
+}
+
+func (o *options) CheckInterval() time.Duration {
+	return o.checkInterval
+}
+
+func (o *options) SetHeapUsageFn(value HeapUsageFn) Options {
+	opts := *o
+	opts.heapUsageFn = value
+	return &opts
+}
+
+func (o *options) HeapUsageFn() HeapUsageFn {
+	return o.heapUsageFn
+}
+
+func (o *options) SetFreeMemoryFn(value func()) Options {
+	opts := *o
+	opts.freeMemoryFn = value
+	return &opts
+}
+
+func (o *options) FreeMemoryFn() func() {
+	return o.freeMemoryFn
+}
+
+func heapUsage() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
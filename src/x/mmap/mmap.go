@@ -52,6 +52,8 @@ type Options struct {
 	Write bool
 	// hugeTLB is the mmap huge TLB options
 	HugeTLB HugeTLBOptions
+	// Advice is the expected access pattern of the mmap'd bytes
+	Advice Advice
 	// ReporterOptions is the reporter options
 	ReporterOptions ReporterOptions
 }
@@ -63,6 +65,21 @@ type Descriptor struct {
 	ReporterOptions ReporterOptions
 }
 
+// Advice is a hint of the expected access pattern of mmap'd bytes, used
+// on platforms that support it to tune read ahead and page reclaim.
+type Advice uint
+
+const (
+	// AdviceNormal gives no advice, which is the default.
+	AdviceNormal Advice = iota
+	// AdviceSequential expects the bytes to be read sequentially, so pages
+	// can be read ahead aggressively and reclaimed soon after being read.
+	AdviceSequential
+	// AdviceRandom expects the bytes to be read in random order, so pages
+	// are not read ahead.
+	AdviceRandom
+)
+
 // HugeTLBOptions contains all options related to huge TLB
 type HugeTLBOptions struct {
 	// enabled determines if using the huge TLB flag is enabled for platforms
@@ -94,6 +94,11 @@ func mmap(fd, offset, length int64, flags int, opts Options) (Descriptor, error)
 		return Descriptor{}, fmt.Errorf("mmap error: %v", err)
 	}
 
+	if err := madvise(b, opts.Advice); err != nil && warning == nil {
+		// Advice is only a hint so don't fail the mmap if it is rejected.
+		warning = fmt.Errorf("error while trying to madvise: %s", err.Error())
+	}
+
 	if reporter := opts.ReporterOptions.Reporter; reporter != nil {
 		opts.ReporterOptions.Context.Size = length
 		if err := reporter.ReportMap(opts.ReporterOptions.Context); err != nil {
@@ -133,6 +138,16 @@ func Munmap(desc Descriptor) error {
 	return nil
 }
 
+func madvise(b []byte, advice Advice) error {
+	switch advice {
+	case AdviceSequential:
+		return syscall.Madvise(b, syscall.MADV_SEQUENTIAL)
+	case AdviceRandom:
+		return syscall.Madvise(b, syscall.MADV_RANDOM)
+	}
+	return nil
+}
+
 // MadviseDontNeed frees mmapped memory.
 // `MADV_DONTNEED` informs the kernel to free the mmapped pages right away instead of waiting for memory pressure.
 // NB(bodu): DO NOT FREE anonymously mapped memory or else it will null all of the underlying bytes as the
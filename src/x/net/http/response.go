@@ -34,6 +34,9 @@ const (
 	// HeaderContentType is the HTTP Content Type header.
 	HeaderContentType = "Content-Type"
 
+	// HeaderRetryAfter is the HTTP Retry After header.
+	HeaderRetryAfter = "Retry-After"
+
 	// ContentTypeJSON is the Content-Type value for a JSON response.
 	ContentTypeJSON = "application/json"
 
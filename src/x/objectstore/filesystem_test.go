@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package objectstore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystemStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objectstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFilesystemStore(dir)
+	require.NoError(t, err)
+
+	_, err = store.Get("a/b")
+	require.Equal(t, ErrNotFound, err)
+	_, err = store.Stat("a/b")
+	require.Equal(t, ErrNotFound, err)
+
+	require.NoError(t, store.Put("a/b", bytes.NewReader([]byte("foo"))))
+	require.NoError(t, store.Put("a/c/d", bytes.NewReader([]byte("quux"))))
+	require.NoError(t, store.Put("b", bytes.NewReader([]byte("bar"))))
+
+	r, err := store.Get("a/b")
+	require.NoError(t, err)
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "foo", string(data))
+
+	info, err := store.Stat("a/c/d")
+	require.NoError(t, err)
+	assert.Equal(t, ObjectInfo{Key: "a/c/d", Size: 4}, info)
+
+	objects, err := store.List("a/")
+	require.NoError(t, err)
+	assert.Equal(t, []ObjectInfo{
+		{Key: "a/b", Size: 3},
+		{Key: "a/c/d", Size: 4},
+	}, objects)
+
+	require.NoError(t, store.Delete("a/b"))
+	require.NoError(t, store.Delete("a/b"))
+	objects, err = store.List("")
+	require.NoError(t, err)
+	assert.Len(t, objects, 2)
+
+	require.Error(t, store.Put("", bytes.NewReader(nil)))
+	require.Error(t, store.Put("a/", bytes.NewReader(nil)))
+}
+
+func TestFilesystemStoreKeysStayBelowRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "objectstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := NewFilesystemStore(dir + "/root")
+	require.NoError(t, err)
+	require.NoError(t, store.Put("../escaped", bytes.NewReader([]byte("foo"))))
+
+	_, err = os.Stat(dir + "/escaped")
+	require.True(t, os.IsNotExist(err))
+	_, err = store.Stat("escaped")
+	require.NoError(t, err)
+}
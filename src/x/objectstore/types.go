@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package objectstore provides an interface to blob object storage, such as
+// S3 or GCS buckets, along with a filesystem implementation.
+package objectstore
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	// Key is the key of the object.
+	Key string
+	// Size is the size of the object in bytes.
+	Size int64
+}
+
+// Store is an object store, keys are slash separated paths.
+type Store interface {
+	// Put writes an object, replacing any existing object with the same key.
+	// An object is only visible once it has been completely written.
+	Put(key string, r io.Reader) error
+
+	// Get returns a reader for an object, ErrNotFound is returned if the
+	// object does not exist. The caller must close the reader.
+	Get(key string) (io.ReadCloser, error)
+
+	// Stat returns the info of an object, ErrNotFound is returned if the
+	// object does not exist.
+	Stat(key string) (ObjectInfo, error)
+
+	// List returns the info of all objects with keys starting with a prefix,
+	// sorted by key.
+	List(prefix string) ([]ObjectInfo, error)
+
+	// Delete deletes an object, deleting an object that does not exist is
+	// not an error.
+	Delete(key string) error
+}
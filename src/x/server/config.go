@@ -25,6 +25,7 @@ import (
 
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/retry"
+	xtls "github.com/m3db/m3/src/x/tls"
 )
 
 // Configuration configs a server.
@@ -40,10 +41,13 @@ type Configuration struct {
 
 	// KeepAlive period.
 	KeepAlivePeriod *time.Duration `yaml:"keepAlivePeriod"`
+
+	// TLS configuration.
+	TLS *xtls.Configuration `yaml:"tls"`
 }
 
 // NewOptions creates server options.
-func (c Configuration) NewOptions(iOpts instrument.Options) Options {
+func (c Configuration) NewOptions(iOpts instrument.Options) (Options, error) {
 	opts := NewOptions().
 		SetRetryOptions(c.Retry.NewOptions(iOpts.MetricsScope())).
 		SetInstrumentOptions(iOpts)
@@ -53,10 +57,18 @@ func (c Configuration) NewOptions(iOpts instrument.Options) Options {
 	if c.KeepAlivePeriod != nil {
 		opts = opts.SetTCPConnectionKeepAlivePeriod(*c.KeepAlivePeriod)
 	}
-	return opts
+	tlsConfig, err := c.TLS.ServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	return opts.SetTLSConfig(tlsConfig), nil
 }
 
 // NewServer creates a new server.
-func (c Configuration) NewServer(handler Handler, iOpts instrument.Options) Server {
-	return NewServer(c.ListenAddress, handler, c.NewOptions(iOpts))
+func (c Configuration) NewServer(handler Handler, iOpts instrument.Options) (Server, error) {
+	opts, err := c.NewOptions(iOpts)
+	if err != nil {
+		return nil, err
+	}
+	return NewServer(c.ListenAddress, handler, opts), nil
 }
@@ -43,9 +43,13 @@ keepAlivePeriod: 5s
 	require.True(t, *cfg.KeepAliveEnabled)
 	require.Equal(t, 5*time.Second, *cfg.KeepAlivePeriod)
 
-	opts := cfg.NewOptions(instrument.NewOptions())
+	opts, err := cfg.NewOptions(instrument.NewOptions())
+	require.NoError(t, err)
 	require.Equal(t, 5*time.Second, opts.TCPConnectionKeepAlivePeriod())
 	require.True(t, opts.TCPConnectionKeepAlive())
+	require.Nil(t, opts.TLSConfig())
 
-	require.NotNil(t, cfg.NewServer(nil, instrument.NewOptions()))
+	server, err := cfg.NewServer(nil, instrument.NewOptions())
+	require.NoError(t, err)
+	require.NotNil(t, server)
 }
@@ -22,6 +22,7 @@
 package server
 
 import (
+	"crypto/tls"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -82,6 +83,7 @@ type server struct {
 	reportInterval               time.Duration
 	tcpConnectionKeepAlive       bool
 	tcpConnectionKeepAlivePeriod time.Duration
+	tlsConfig                    *tls.Config
 
 	closed       bool
 	closedChan   chan struct{}
@@ -112,6 +114,7 @@ func NewServer(address string, handler Handler, opts Options) Server {
 		metrics:                      newServerMetrics(scope),
 		handler:                      handler,
 		listenerOpts:                 opts.ListenerOptions(),
+		tlsConfig:                    opts.TLSConfig(),
 	}
 
 	// Set up the connection functions.
@@ -150,6 +153,11 @@ func (s *server) serve() {
 				tcpConn.SetKeepAlivePeriod(s.tcpConnectionKeepAlivePeriod)
 			}
 		}
+		if s.tlsConfig != nil {
+			// NB: the handshake is performed lazily on the first read or
+			// write so that a slow client does not block the accept loop.
+			conn = tls.Server(conn, s.tlsConfig)
+		}
 		if !s.addConnectionFn(conn) {
 			conn.Close()
 		} else {
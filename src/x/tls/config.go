@@ -0,0 +1,217 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tls provides a shared TLS configuration for servers and clients
+// that reloads certificates when they change on disk.
+package tls
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+var (
+	errCertFileNotSet       = errors.New("certFile must be set with keyFile")
+	errKeyFileNotSet        = errors.New("keyFile must be set with certFile")
+	errServerCertNotSet     = errors.New("certFile and keyFile must be set for servers")
+	errClientAuthRequiresCA = errors.New("caFile must be set to verify client certificates")
+	errNoCACerts            = errors.New("no certificates found in caFile")
+)
+
+var versions = map[string]uint16{
+	"1.0": gotls.VersionTLS10,
+	"1.1": gotls.VersionTLS11,
+	"1.2": gotls.VersionTLS12,
+	"1.3": gotls.VersionTLS13,
+}
+
+const defaultMinVersion = gotls.VersionTLS12
+
+// Configuration configures TLS for a server or a client.
+type Configuration struct {
+	// Enabled enables TLS.
+	Enabled bool `yaml:"enabled"`
+
+	// CertFile is the path to the PEM encoded certificate.
+	CertFile string `yaml:"certFile"`
+
+	// KeyFile is the path to the PEM encoded private key.
+	KeyFile string `yaml:"keyFile"`
+
+	// CAFile is the path to the PEM encoded CA bundle used to verify peers.
+	// When not set the system roots are used by clients.
+	CAFile string `yaml:"caFile"`
+
+	// ClientAuth requires and verifies client certificates against the CA
+	// bundle, only used by servers.
+	ClientAuth bool `yaml:"clientAuth"`
+
+	// MinVersion is the minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3,
+	// defaults to 1.2.
+	MinVersion string `yaml:"minVersion"`
+
+	// CipherSuites restricts the cipher suites used for TLS 1.2 and below,
+	// for example TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	CipherSuites []string `yaml:"cipherSuites"`
+
+	// ServerName overrides the server name verified by clients.
+	ServerName string `yaml:"serverName"`
+
+	// InsecureSkipVerify disables server certificate verification by clients.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+}
+
+// ServerConfig returns the TLS config for a server, or nil if TLS is
+// not enabled. The certificate is loaded eagerly and reloaded whenever the
+// certificate or key file changes.
+func (c *Configuration) ServerConfig() (*gotls.Config, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	if c.CertFile == "" && c.KeyFile == "" {
+		return nil, errServerCertNotSet
+	}
+	cfg, err := c.baseConfig()
+	if err != nil {
+		return nil, err
+	}
+	loader, err := c.newCertLoader()
+	if err != nil {
+		return nil, err
+	}
+	cfg.GetCertificate = func(*gotls.ClientHelloInfo) (*gotls.Certificate, error) {
+		return loader.Certificate()
+	}
+	if c.ClientAuth {
+		if c.CAFile == "" {
+			return nil, errClientAuthRequiresCA
+		}
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = gotls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ClientConfig returns the TLS config for a client, or nil if TLS is
+// not enabled. The client certificate is optional and, when set, is
+// reloaded whenever the certificate or key file changes.
+func (c *Configuration) ClientConfig() (*gotls.Config, error) {
+	if c == nil || !c.Enabled {
+		return nil, nil
+	}
+	cfg, err := c.baseConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.ServerName = c.ServerName
+	cfg.InsecureSkipVerify = c.InsecureSkipVerify
+	if c.CAFile != "" {
+		pool, err := loadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		loader, err := c.newCertLoader()
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = func(*gotls.CertificateRequestInfo) (*gotls.Certificate, error) {
+			return loader.Certificate()
+		}
+	}
+	return cfg, nil
+}
+
+func (c *Configuration) baseConfig() (*gotls.Config, error) {
+	minVersion := uint16(defaultMinVersion)
+	if c.MinVersion != "" {
+		v, ok := versions[c.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS min version: %s", c.MinVersion)
+		}
+		minVersion = v
+	}
+
+	cipherSuites, err := parseCipherSuites(c.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gotls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
+	}, nil
+}
+
+func (c *Configuration) newCertLoader() (*certLoader, error) {
+	if c.CertFile == "" {
+		return nil, errCertFileNotSet
+	}
+	if c.KeyFile == "" {
+		return nil, errKeyFileNotSet
+	}
+	loader := newCertLoader(c.CertFile, c.KeyFile)
+	// NB: load eagerly so that misconfigured certificates fail at startup
+	// rather than on the first handshake.
+	if _, err := loader.Certificate(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	byName := make(map[string]uint16)
+	for _, suite := range gotls.CipherSuites() {
+		byName[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid or insecure TLS cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errNoCACerts
+	}
+	return pool, nil
+}
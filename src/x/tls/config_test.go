@@ -0,0 +1,252 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCert(t *testing.T, name string, serial int64, parent *testCert) testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth,
+			x509.ExtKeyUsageClientAuth,
+		},
+	}
+
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert,
+		&key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return testCert{cert: cert, key: key, der: der}
+}
+
+func (c testCert) write(t *testing.T, dir, name string) (string, string) {
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+	require.NoError(t, ioutil.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "tls-test")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestConfigurationDisabled(t *testing.T) {
+	var cfg *Configuration
+	serverCfg, err := cfg.ServerConfig()
+	require.NoError(t, err)
+	require.Nil(t, serverCfg)
+
+	clientCfg, err := (&Configuration{}).ClientConfig()
+	require.NoError(t, err)
+	require.Nil(t, clientCfg)
+}
+
+func TestConfigurationErrors(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := newTestCert(t, "server", 2, &ca).write(t, dir, "server")
+
+	_, err := (&Configuration{Enabled: true}).ServerConfig()
+	require.Equal(t, errServerCertNotSet, err)
+
+	_, err = (&Configuration{Enabled: true, CertFile: certFile}).ServerConfig()
+	require.Equal(t, errKeyFileNotSet, err)
+
+	_, err = (&Configuration{
+		Enabled:    true,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		ClientAuth: true,
+	}).ServerConfig()
+	require.Equal(t, errClientAuthRequiresCA, err)
+
+	_, err = (&Configuration{
+		Enabled:    true,
+		CertFile:   certFile,
+		KeyFile:    keyFile,
+		MinVersion: "0.9",
+	}).ServerConfig()
+	require.Error(t, err)
+
+	_, err = (&Configuration{
+		Enabled:      true,
+		CAFile:       caFile,
+		CipherSuites: []string{"TLS_FOO"},
+	}).ClientConfig()
+	require.Error(t, err)
+
+	_, err = (&Configuration{Enabled: true, CAFile: keyFile}).ClientConfig()
+	require.Equal(t, errNoCACerts, err)
+}
+
+func TestConfigurationMutualTLS(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCertFile, serverKeyFile := newTestCert(t, "server", 2, &ca).write(t, dir, "server")
+	clientCertFile, clientKeyFile := newTestCert(t, "client", 3, &ca).write(t, dir, "client")
+
+	serverCfg, err := (&Configuration{
+		Enabled:    true,
+		CertFile:   serverCertFile,
+		KeyFile:    serverKeyFile,
+		CAFile:     caFile,
+		ClientAuth: true,
+	}).ServerConfig()
+	require.NoError(t, err)
+	require.Equal(t, uint16(gotls.VersionTLS12), serverCfg.MinVersion)
+	require.Equal(t, gotls.RequireAndVerifyClientCert, serverCfg.ClientAuth)
+
+	handshake := func(clientCfg *gotls.Config) (error, error) {
+		clientConn, serverConn := net.Pipe()
+		defer clientConn.Close()
+		defer serverConn.Close()
+
+		serverErrCh := make(chan error, 1)
+		go func() {
+			conn := gotls.Server(serverConn, serverCfg)
+			err := conn.Handshake()
+			// NB: close so a client still waiting on the handshake is unblocked.
+			conn.Close()
+			serverErrCh <- err
+		}()
+		clientErr := gotls.Client(clientConn, clientCfg).Handshake()
+		clientConn.Close()
+		return clientErr, <-serverErrCh
+	}
+
+	clientCfg, err := (&Configuration{
+		Enabled:    true,
+		CertFile:   clientCertFile,
+		KeyFile:    clientKeyFile,
+		CAFile:     caFile,
+		ServerName: "server",
+	}).ClientConfig()
+	require.NoError(t, err)
+	clientErr, serverErr := handshake(clientCfg)
+	require.NoError(t, clientErr)
+	require.NoError(t, serverErr)
+
+	// Clients without a certificate are rejected.
+	noCertCfg, err := (&Configuration{
+		Enabled:    true,
+		CAFile:     caFile,
+		ServerName: "server",
+	}).ClientConfig()
+	require.NoError(t, err)
+	_, serverErr = handshake(noCertCfg)
+	require.Error(t, serverErr)
+}
+
+func TestCertLoaderReloadsOnChange(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCert(t, "ca", 1, nil)
+	certFile, keyFile := newTestCert(t, "server", 2, &ca).write(t, dir, "server")
+
+	loader := newCertLoader(certFile, keyFile)
+	cert, err := loader.Certificate()
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(2), leaf.SerialNumber.Int64())
+
+	// Unchanged files return the cached certificate.
+	cached, err := loader.Certificate()
+	require.NoError(t, err)
+	require.True(t, cert == cached)
+
+	// Rotate the certificate and bump the modification times.
+	newTestCert(t, "server", 3, &ca).write(t, dir, "server")
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+
+	cert, err = loader.Certificate()
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(3), leaf.SerialNumber.Int64())
+
+	// A broken rotation keeps serving the last good certificate.
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("garbage"), 0600))
+	modTime = modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	broken, err := loader.Certificate()
+	require.NoError(t, err)
+	require.True(t, cert == broken)
+}
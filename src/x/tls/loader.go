@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tls
+
+import (
+	gotls "crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// certLoader loads a key pair, reloading it when the modification time of
+// either file changes so certificates can be rotated without a restart.
+type certLoader struct {
+	sync.Mutex
+
+	certFile string
+	keyFile  string
+
+	cert        *gotls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertLoader(certFile, keyFile string) *certLoader {
+	return &certLoader{certFile: certFile, keyFile: keyFile}
+}
+
+// Certificate returns the current certificate, reloading it if either file
+// has changed since it was last loaded. If a reload fails the previously
+// loaded certificate is returned so that a partially written rotation does
+// not break new connections.
+func (l *certLoader) Certificate() (*gotls.Certificate, error) {
+	certInfo, certErr := os.Stat(l.certFile)
+	keyInfo, keyErr := os.Stat(l.keyFile)
+
+	l.Lock()
+	defer l.Unlock()
+
+	if certErr != nil || keyErr != nil {
+		if l.cert != nil {
+			return l.cert, nil
+		}
+		if certErr != nil {
+			return nil, certErr
+		}
+		return nil, keyErr
+	}
+
+	if l.cert != nil &&
+		certInfo.ModTime().Equal(l.certModTime) &&
+		keyInfo.ModTime().Equal(l.keyModTime) {
+		return l.cert, nil
+	}
+
+	cert, err := gotls.LoadX509KeyPair(l.certFile, l.keyFile)
+	if err != nil {
+		if l.cert != nil {
+			return l.cert, nil
+		}
+		return nil, err
+	}
+
+	l.cert = &cert
+	l.certModTime = certInfo.ModTime()
+	l.keyModTime = keyInfo.ModTime()
+	return l.cert, nil
+}